@@ -0,0 +1,145 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// publicAnnouncement is the announcement shown to users, including users who are not logged in.
+type publicAnnouncement struct {
+	ID       int                     `json:"id"`
+	Title    string                  `json:"title"`
+	Message  string                  `json:"message"`
+	Severity db.AnnouncementSeverity `json:"severity"`
+	Start    *time.Time              `json:"start"`
+	End      *time.Time              `json:"end"`
+}
+
+// getActiveAnnouncements returns announcements which are shown now. It does not require authentication,
+// so maintenance notices are visible on the login page.
+func getActiveAnnouncements(w http.ResponseWriter, r *http.Request) {
+	announcements, err := helpers.Store(r).GetAnnouncements()
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	res := make([]publicAnnouncement, 0)
+	for _, a := range db.GetActiveAnnouncements(announcements, time.Now()) {
+		res = append(res, publicAnnouncement{
+			ID:       a.ID,
+			Title:    a.Title,
+			Message:  a.Message,
+			Severity: a.Severity,
+			Start:    a.Start,
+			End:      a.End,
+		})
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, res)
+}
+
+func announcementMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		announcementID, err := helpers.GetIntParam("announcement_id", w, r)
+		if err != nil {
+			return
+		}
+
+		announcement, err := helpers.Store(r).GetAnnouncement(announcementID)
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		context.Set(r, "announcement", announcement)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func getAnnouncements(w http.ResponseWriter, r *http.Request) {
+	announcements, err := helpers.Store(r).GetAnnouncements()
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, announcements)
+}
+
+func addAnnouncement(w http.ResponseWriter, r *http.Request) {
+	user := helpers.UserFromContext(r)
+
+	var announcement db.Announcement
+	if !helpers.Bind(w, r, &announcement) {
+		return
+	}
+
+	announcement.UserID = &user.ID
+	announcement.Created = time.Now().UTC()
+
+	newAnnouncement, err := helpers.Store(r).CreateAnnouncement(announcement)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogCreate, helpers.EventLogItem{
+		UserID:      user.ID,
+		ObjectType:  db.EventAnnouncement,
+		ObjectID:    newAnnouncement.ID,
+		Description: fmt.Sprintf("Announcement %s created", newAnnouncement.Title),
+	})
+
+	helpers.WriteJSON(w, http.StatusCreated, newAnnouncement)
+}
+
+func updateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	oldAnnouncement := context.Get(r, "announcement").(db.Announcement)
+
+	var announcement db.Announcement
+	if !helpers.Bind(w, r, &announcement) {
+		return
+	}
+
+	announcement.ID = oldAnnouncement.ID
+	announcement.UserID = oldAnnouncement.UserID
+	announcement.Created = oldAnnouncement.Created
+
+	if err := helpers.Store(r).UpdateAnnouncement(announcement); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogUpdate, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ObjectType:  db.EventAnnouncement,
+		ObjectID:    announcement.ID,
+		Description: fmt.Sprintf("Announcement %s updated", announcement.Title),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func deleteAnnouncement(w http.ResponseWriter, r *http.Request) {
+	announcement := context.Get(r, "announcement").(db.Announcement)
+
+	if err := helpers.Store(r).DeleteAnnouncement(announcement.ID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogDelete, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ObjectType:  db.EventAnnouncement,
+		ObjectID:    announcement.ID,
+		Description: fmt.Sprintf("Announcement %s deleted", announcement.Title),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -3,6 +3,7 @@ package api
 import (
 	//_ "github.com/snikch/goodman/hooks"
 	//_ "github.com/snikch/goodman/transaction"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -20,3 +21,33 @@ func TestApiPing(t *testing.T) {
 		t.Errorf("Response code should be 200 %d", rr.Code)
 	}
 }
+
+func TestApiOpenAPI(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/api/openapi.json", nil)
+	rr := httptest.NewRecorder()
+
+	r := Route()
+
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != 200 {
+		t.Fatalf("Response code should be 200 %d", rr.Code)
+	}
+
+	var spec struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+
+	if err := json.Unmarshal(rr.Body.Bytes(), &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	if spec.OpenAPI != "3.1.0" {
+		t.Errorf("Unexpected OpenAPI version %s", spec.OpenAPI)
+	}
+
+	if _, ok := spec.Paths["/project/{project_id}/templates/{template_id}"]["put"]; !ok {
+		t.Error("Template update operation is missing")
+	}
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"fmt"
+	"github.com/semaphoreui/semaphore/api/auth"
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/util"
@@ -11,8 +13,13 @@ import (
 	"time"
 )
 
-func authenticationHandler(w http.ResponseWriter, r *http.Request) bool {
+// authenticationHandler authenticates the user of the request. API tokens with limited role
+// are accepted only if the role is tokenRole, e.g. dashboard tokens for dashboard endpoints.
+func authenticationHandler(w http.ResponseWriter, r *http.Request, tokenRole db.APITokenRole) bool {
 	var userID int
+	// passwordSession is true if the user logged in with the password,
+	// such users must change the expired password before using the API.
+	var passwordSession bool
 
 	authHeader := strings.ToLower(r.Header.Get("authorization"))
 
@@ -28,7 +35,25 @@ func authenticationHandler(w http.ResponseWriter, r *http.Request) bool {
 			return false
 		}
 
+		if token.Role != db.APITokenRoleUser && token.Role != tokenRole {
+			w.WriteHeader(http.StatusForbidden)
+			return false
+		}
+
 		userID = token.UserID
+	} else if _, err := r.Cookie("semaphore"); err != nil {
+		// no session, the request can be authenticated by the auth provider
+		user, err := authenticateByProviders(helpers.Store(r), r)
+		if err != nil {
+			log.Warn(err.Error())
+		}
+
+		if user == nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return false
+		}
+
+		userID = user.ID
 	} else {
 		// fetch session from cookie
 		cookie, err := r.Cookie("semaphore")
@@ -61,6 +86,11 @@ func authenticationHandler(w http.ResponseWriter, r *http.Request) bool {
 			return false
 		}
 
+		if !checkImpersonationSession(w, r, value, userID, sessionID) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return false
+		}
+
 		if time.Since(session.LastActive).Hours() > 7*24 {
 			// more than week old unused session
 			// destroy.
@@ -78,6 +108,8 @@ func authenticationHandler(w http.ResponseWriter, r *http.Request) bool {
 			w.WriteHeader(http.StatusUnauthorized)
 			return false
 		}
+
+		passwordSession = getImpersonator(r) == nil
 	}
 
 	user, err := helpers.Store(r).GetUser(userID)
@@ -90,14 +122,48 @@ func authenticationHandler(w http.ResponseWriter, r *http.Request) bool {
 		return false
 	}
 
+	if passwordSession && db.IsPasswordExpired(user, time.Now()) && !isPasswordChangeRequest(r, user) {
+		helpers.WriteError(w, db.ErrPasswordExpired)
+		return false
+	}
+
 	context.Set(r, "user", &user)
 	return true
 }
 
+// isPasswordChangeRequest returns true for requests which are allowed for the user with
+// the expired password: getting the current user and changing the password.
+func isPasswordChangeRequest(r *http.Request, user db.User) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		return strings.HasSuffix(r.URL.Path, "/api/user")
+	case http.MethodPost:
+		return strings.HasSuffix(r.URL.Path, fmt.Sprintf("/api/users/%d/password", user.ID))
+	}
+	return false
+}
+
+// authenticateByProviders returns the user authenticated by the first request provider
+// which found credentials in the request, or nil if there are no credentials.
+func authenticateByProviders(store db.Store, r *http.Request) (*db.User, error) {
+	for _, p := range auth.RequestProviders() {
+		user, err := p.Authenticate(store, r)
+		if err != nil {
+			return nil, fmt.Errorf("auth provider %s: %w", p.ID(), err)
+		}
+
+		if user != nil {
+			return user, nil
+		}
+	}
+
+	return nil, nil
+}
+
 // nolint: gocyclo
 func authentication(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ok := authenticationHandler(w, r)
+		ok := authenticationHandler(w, r, db.APITokenRoleUser)
 		if ok {
 			next.ServeHTTP(w, r)
 		}
@@ -112,7 +178,7 @@ func authenticationWithStore(next http.Handler) http.Handler {
 		var ok bool
 
 		db.StoreSession(store, r.URL.String(), func() {
-			ok = authenticationHandler(w, r)
+			ok = authenticationHandler(w, r, db.APITokenRoleUser)
 		})
 
 		if ok {
@@ -121,6 +187,17 @@ func authenticationWithStore(next http.Handler) http.Handler {
 	})
 }
 
+// dashboardAuthentication accepts dashboard API tokens in addition to all credentials
+// accepted by authentication.
+func dashboardAuthentication(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok := authenticationHandler(w, r, db.APITokenRoleDashboard)
+		if ok {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
 func adminMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		user := context.Get(r, "user").(*db.User)
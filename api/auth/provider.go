@@ -0,0 +1,138 @@
+// Package auth contains the registry of authentication providers. Built-in
+// providers (local users, LDAP and OIDC) are registered by the api package.
+// Custom providers, for example a corporate SSO gateway, are compiled into
+// the binary and registered in the init function of their package:
+//
+//	func init() {
+//		auth.Register(&gatewayProvider{})
+//	}
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// Provider authenticates users. Every provider implements at least one of
+// PasswordProvider, RequestProvider and RedirectProvider.
+type Provider interface {
+	// ID is the unique name of the provider, for example "ldap".
+	ID() string
+	// Enabled returns false if the provider is not configured.
+	Enabled() bool
+}
+
+// PasswordProvider authenticates users by the login and the password
+// entered on the login page.
+type PasswordProvider interface {
+	Provider
+	// Login returns nil user without error if the login is unknown
+	// to the provider, so the next provider is tried.
+	Login(store db.Store, login string, password string) (*db.User, error)
+	// VerifyPassword checks the password of the authenticated user
+	// who confirms the destructive action.
+	VerifyPassword(user *db.User, password string) bool
+}
+
+// RequestProvider authenticates API requests which have no session cookie
+// and API token, for example by the header set by the SSO gateway.
+type RequestProvider interface {
+	Provider
+	// Authenticate returns nil user without error if the request
+	// has no credentials of the provider.
+	Authenticate(store db.Store, r *http.Request) (*db.User, error)
+}
+
+// LoginOption is the button on the login page which opens
+// the external login page, for example of the OIDC provider.
+type LoginOption struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+	Icon  string `json:"icon"`
+	Order int    `json:"-"`
+}
+
+// RedirectProvider authenticates users on the external login page which
+// redirects back to /api/auth/{provider}/{option}/redirect.
+type RedirectProvider interface {
+	Provider
+	LoginOptions() []LoginOption
+	// StartLogin redirects the user to the external login page of the option.
+	// redirectPath is the page of Semaphore opened after login.
+	StartLogin(w http.ResponseWriter, r *http.Request, optionID string, redirectPath string) error
+	// CompleteLogin returns the user authenticated by the external login page.
+	CompleteLogin(store db.Store, r *http.Request, optionID string) (*db.User, error)
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   []Provider
+)
+
+// Register adds the provider. Providers are tried in the order of registration.
+// It panics if the provider with the same ID is already registered.
+func Register(p Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	for _, existing := range providers {
+		if existing.ID() == p.ID() {
+			panic(fmt.Sprintf("auth provider %s is already registered", p.ID()))
+		}
+	}
+
+	providers = append(providers, p)
+}
+
+// Get returns the enabled provider by ID.
+func Get(id string) (Provider, bool) {
+	for _, p := range Providers() {
+		if p.ID() == id {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Providers returns the enabled providers.
+func Providers() []Provider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	res := make([]Provider, 0, len(providers))
+	for _, p := range providers {
+		if p.Enabled() {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// PasswordProviders returns the enabled providers which check passwords.
+func PasswordProviders() []PasswordProvider {
+	return providersOf[PasswordProvider]()
+}
+
+// RequestProviders returns the enabled providers which authenticate requests.
+func RequestProviders() []RequestProvider {
+	return providersOf[RequestProvider]()
+}
+
+// RedirectProviders returns the enabled providers with external login pages.
+func RedirectProviders() []RedirectProvider {
+	return providersOf[RedirectProvider]()
+}
+
+func providersOf[T Provider]() []T {
+	var res []T
+	for _, p := range Providers() {
+		if t, ok := p.(T); ok {
+			res = append(res, t)
+		}
+	}
+	return res
+}
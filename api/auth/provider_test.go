@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+type testProvider struct {
+	id      string
+	enabled bool
+}
+
+func (p testProvider) ID() string {
+	return p.id
+}
+
+func (p testProvider) Enabled() bool {
+	return p.enabled
+}
+
+func (p testProvider) Authenticate(store db.Store, r *http.Request) (*db.User, error) {
+	return nil, nil
+}
+
+func TestRegister(t *testing.T) {
+	Register(testProvider{id: "test_enabled", enabled: true})
+	Register(testProvider{id: "test_disabled"})
+
+	if _, ok := Get("test_enabled"); !ok {
+		t.Fatal("enabled provider must be found")
+	}
+
+	if _, ok := Get("test_disabled"); ok {
+		t.Fatal("disabled provider must not be found")
+	}
+
+	found := false
+	for _, p := range RequestProviders() {
+		if p.ID() == "test_disabled" {
+			t.Fatal("disabled provider must be skipped")
+		}
+		found = found || p.ID() == "test_enabled"
+	}
+
+	if !found {
+		t.Fatal("request provider expected")
+	}
+
+	if len(PasswordProviders()) != 0 {
+		t.Fatal("test providers don't check passwords")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("duplicate provider must panic")
+		}
+	}()
+
+	Register(testProvider{id: "test_enabled"})
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+)
+
+func TestAuthenticationHandlerTokenRole(t *testing.T) {
+	store := bolt.CreateTestStore()
+
+	user, err := store.CreateUserWithoutPassword(db.User{Username: "wallboard", Name: "Wallboard", Email: "wallboard@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := store.CreateAPIToken(db.APIToken{ID: "dashboardtoken", UserID: user.ID, Role: db.APITokenRoleDashboard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticate := func(tokenRole db.APITokenRole) (bool, int) {
+		r := httptest.NewRequest("GET", "/api/project/1/dashboard/templates", nil)
+		r.Header.Set("Authorization", "Bearer "+token.ID)
+		context.Set(r, "store", store)
+		defer context.Clear(r)
+
+		w := httptest.NewRecorder()
+		ok := authenticationHandler(w, r, tokenRole)
+		return ok, w.Code
+	}
+
+	if ok, code := authenticate(db.APITokenRoleUser); ok || code != http.StatusForbidden {
+		t.Fatal("dashboard token must be rejected by regular endpoints", code)
+	}
+
+	if ok, _ := authenticate(db.APITokenRoleDashboard); !ok {
+		t.Fatal("dashboard token must be accepted by dashboard endpoints")
+	}
+}
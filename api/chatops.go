@@ -0,0 +1,235 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/chatops"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// chatOpsMaxBody limits the size of the slash command form.
+const chatOpsMaxBody = 64 * 1024
+
+// ReceiveChatOpsCommand handles slash commands of Slack and Mattermost, e.g.
+// "/semaphore run deploy-web env=prod". The project is selected by the URL of the command.
+// Errors of the command are returned to the user who sent it only.
+func ReceiveChatOpsCommand(w http.ResponseWriter, r *http.Request) {
+	config := util.Config.ChatOps
+	if config == nil || !config.Enabled {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	platformName, err := helpers.GetStrParam("platform", w, r)
+	if err != nil {
+		return
+	}
+
+	projectID, err := helpers.GetIntParam("project_id", w, r)
+	if err != nil {
+		return
+	}
+
+	platform := chatops.Platform(platformName)
+	if platform != chatops.PlatformSlack && platform != chatops.PlatformMattermost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, chatOpsMaxBody))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req, err := chatops.ParseRequest(platform, body)
+	if err != nil {
+		helpers.WriteErrorStatus(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch platform {
+	case chatops.PlatformSlack:
+		err = chatops.VerifySlackSignature(
+			config.SlackSigningSecret,
+			r.Header.Get("X-Slack-Request-Timestamp"),
+			body,
+			r.Header.Get("X-Slack-Signature"),
+			time.Now())
+	case chatops.PlatformMattermost:
+		err = chatops.VerifyMattermostToken(config.MattermostToken, req.Token)
+	}
+
+	if err != nil {
+		log.WithFields(log.Fields{
+			"platform": platform,
+			"remote":   r.RemoteAddr,
+		}).Warn(err.Error())
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, runChatOpsCommand(helpers.Store(r), helpers.TaskPool(r), config, projectID, req))
+}
+
+func runChatOpsCommand(store db.Store, pool chatOpsTaskPool, config *util.ChatOpsConfig, projectID int, req chatops.Request) chatops.Response {
+	cmd, err := chatops.ParseCommand(req.Text)
+	if err != nil {
+		return chatops.Ephemeral("%s\n\n%s", err.Error(), chatops.HelpText)
+	}
+
+	if cmd.Name == chatops.CommandHelp {
+		return chatops.Ephemeral("%s", chatops.HelpText)
+	}
+
+	project, err := store.GetProject(projectID)
+	if err != nil {
+		return chatops.Ephemeral("Project not found")
+	}
+
+	if project.Archived {
+		return chatops.Ephemeral("Project %s is archived", project.Name)
+	}
+
+	user, err := getChatOpsUser(store, config, req, project.ID)
+	if err != nil {
+		return chatops.Ephemeral("%s", err.Error())
+	}
+
+	now := time.Now()
+
+	var tpl db.Template
+	var vars map[string]string
+
+	switch cmd.Name {
+	case chatops.CommandRun:
+		tpl, err = findChatOpsTemplate(store, project.ID, cmd.Template)
+		if err != nil {
+			return chatops.Ephemeral("%s", err.Error())
+		}
+
+		vars = cmd.Vars
+
+		if chatops.NeedsConfirmation(config, tpl.Tags) {
+			var code string
+			code, err = chatops.AddPendingRun(chatops.PendingRun{
+				Platform:   req.Platform,
+				ChatUserID: req.UserID,
+				ProjectID:  project.ID,
+				TemplateID: tpl.ID,
+				Vars:       vars,
+			}, now)
+			if err != nil {
+				util.LogError(err)
+				return chatops.Ephemeral("Can not start the task")
+			}
+			return chatops.Ephemeral("Template '%s' requires confirmation. Send `confirm %s` within 5 minutes to run it.", tpl.Name, code)
+		}
+	case chatops.CommandConfirm:
+		run, ok := chatops.TakePendingRun(cmd.Code, req.Platform, req.UserID, project.ID, now)
+		if !ok {
+			return chatops.Ephemeral("Confirmation code is invalid or expired")
+		}
+
+		tpl, err = store.GetTemplate(project.ID, run.TemplateID)
+		if err != nil {
+			return chatops.Ephemeral("Template not found")
+		}
+
+		vars = run.Vars
+	}
+
+	environment, err := json.Marshal(vars)
+	if err != nil {
+		return chatops.Ephemeral("Invalid variables")
+	}
+
+	task, err := pool.AddTask(db.Task{
+		TemplateID:  tpl.ID,
+		ProjectID:   project.ID,
+		Environment: string(environment),
+	}, &user.ID, project.ID)
+
+	var validationErr *db.ValidationError
+	var quotaErr *db.QuotaExceededError
+
+	if errors.As(err, &validationErr) || errors.As(err, &quotaErr) {
+		return chatops.Ephemeral("%s", err.Error())
+	} else if err != nil {
+		util.LogError(err)
+		return chatops.Ephemeral("Can not start the task")
+	}
+
+	go chatops.WatchTask(store, task, tpl, req.ResponseURL)
+
+	mention := "<@" + req.UserID + ">"
+	if req.Platform == chatops.PlatformMattermost {
+		mention = "@" + req.UserName
+	}
+
+	text := fmt.Sprintf("%s started task #%d of '%s'", mention, task.ID, tpl.Name)
+	if u := task.GetUrl(); u != nil {
+		text += "\n" + *u
+	}
+
+	return chatops.InChannel("%s", text)
+}
+
+// chatOpsTaskPool is the part of the task pool used by chat commands.
+type chatOpsTaskPool interface {
+	AddTask(taskObj db.Task, userID *int, projectID int) (db.Task, error)
+}
+
+// getChatOpsUser returns the Semaphore user of the chat user if the user can run tasks of the project.
+func getChatOpsUser(store db.Store, config *util.ChatOpsConfig, req chatops.Request, projectID int) (user db.User, err error) {
+	username := chatops.GetUsername(config, req)
+	if username == "" {
+		err = errors.New("your chat account is not mapped to a Semaphore user")
+		return
+	}
+
+	user, err = store.GetUserByLoginOrEmail(username, "")
+	if err != nil || user.Username != username {
+		err = errors.New("your chat account is not mapped to a Semaphore user")
+		return
+	}
+
+	if user.Admin {
+		return
+	}
+
+	projectUser, err := store.GetProjectUser(projectID, user.ID)
+	if err != nil || projectUser.IsExpired(time.Now()) || !projectUser.Role.Can(db.CanRunProjectTasks) {
+		err = errors.New("you are not allowed to run tasks of the project")
+		return
+	}
+
+	return
+}
+
+// findChatOpsTemplate finds the template of the project by the name, case-insensitive.
+func findChatOpsTemplate(store db.Store, projectID int, name string) (tpl db.Template, err error) {
+	templates, err := store.GetTemplates(projectID, db.TemplateFilter{}, db.RetrieveQueryParams{})
+	if err != nil {
+		return
+	}
+
+	for _, t := range templates {
+		if strings.EqualFold(t.Name, name) {
+			tpl = t
+			return
+		}
+	}
+
+	err = errors.New("template '" + name + "' not found")
+	return
+}
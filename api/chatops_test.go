@@ -0,0 +1,79 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/services/chatops"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+type testChatOpsTaskPool struct {
+	tasks []db.Task
+}
+
+func (p *testChatOpsTaskPool) AddTask(task db.Task, userID *int, projectID int) (db.Task, error) {
+	task.ID = len(p.tasks) + 1
+	task.UserID = userID
+	p.tasks = append(p.tasks, task)
+	return task, nil
+}
+
+func TestRunChatOpsCommand(t *testing.T) {
+	store := bolt.CreateTestStore()
+
+	project, err := store.CreateProject(db.Project{Name: "Test", Created: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := store.CreateUser(db.UserWithPwd{Pwd: "password", User: db.User{
+		Username: "alice",
+		Name:     "Alice",
+		Email:    "alice@example.com",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = store.CreateProjectUser(db.ProjectUser{ProjectID: project.ID, UserID: user.ID, Role: db.ProjectTaskRunner}); err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, err := store.CreateTemplate(db.Template{ProjectID: project.ID, Name: "deploy-web", Playbook: "deploy.yml", Tags: []string{"prod"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &util.ChatOpsConfig{Enabled: true, Users: map[string]string{"slack:U1": "alice"}}
+	pool := &testChatOpsTaskPool{}
+
+	res := runChatOpsCommand(store, pool, config, project.ID, chatops.Request{Platform: chatops.PlatformSlack, UserID: "U2", Text: "run deploy-web"})
+	if len(pool.tasks) != 0 || !strings.Contains(res.Text, "not mapped") {
+		t.Fatalf("unmapped user must not run tasks: %+v", res)
+	}
+
+	req := chatops.Request{Platform: chatops.PlatformSlack, UserID: "U1", Text: "run Deploy-Web env=prod"}
+
+	res = runChatOpsCommand(store, pool, config, project.ID, req)
+	if len(pool.tasks) != 0 || res.ResponseType != chatops.ResponseEphemeral || !strings.Contains(res.Text, "confirm ") {
+		t.Fatalf("prod template must require confirmation: %+v", res)
+	}
+
+	code := strings.Fields(res.Text[strings.Index(res.Text, "`confirm ")+1:])[1]
+	code = strings.TrimSuffix(code, "`")
+
+	req.Text = "confirm " + code
+	res = runChatOpsCommand(store, pool, config, project.ID, req)
+	if len(pool.tasks) != 1 || res.ResponseType != chatops.ResponseInChannel {
+		t.Fatalf("confirmed run must start the task: %+v", res)
+	}
+
+	task := pool.tasks[0]
+	if task.TemplateID != tpl.ID || task.UserID == nil || *task.UserID != user.ID || task.Environment != `{"env":"prod"}` {
+		t.Fatalf("unexpected task %+v", task)
+	}
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// compressionMinSize is the size of the response body below which the response is not compressed.
+const compressionMinSize = 1024
+
+const (
+	encodingZstd = "zstd"
+	encodingGzip = "gzip"
+)
+
+var gzipWriterPool = sync.Pool{
+	New: func() any {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	},
+}
+
+var zstdEncoderPool = sync.Pool{
+	New: func() any {
+		w, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+		return w
+	},
+}
+
+// negotiateEncoding returns the encoding accepted by the client, zstd is preferred over gzip.
+func negotiateEncoding(acceptEncoding string) string {
+	accepted := make(map[string]bool)
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if value, err := strconv.ParseFloat(q, 64); err == nil && value == 0 {
+				continue
+			}
+		}
+
+		accepted[name] = true
+	}
+
+	for _, encoding := range []string{encodingZstd, encodingGzip} {
+		if accepted[encoding] {
+			return encoding
+		}
+	}
+
+	return ""
+}
+
+func isCompressibleContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == "application/json" ||
+		mediaType == "application/yaml" ||
+		mediaType == "application/x-ndjson" ||
+		mediaType == "image/svg+xml"
+}
+
+// compressedResponseWriter compresses the body of the response. The first compressionMinSize
+// bytes are buffered, so small responses are sent as is.
+type compressedResponseWriter struct {
+	http.ResponseWriter
+	encoding string
+
+	code     int
+	compress bool
+	buf      []byte
+	encoder  io.WriteCloser
+}
+
+func (cw *compressedResponseWriter) WriteHeader(code int) {
+	if cw.code != 0 {
+		return
+	}
+
+	cw.code = code
+
+	h := cw.Header()
+	cw.compress = code >= http.StatusOK &&
+		code != http.StatusNoContent &&
+		code != http.StatusNotModified &&
+		h.Get("content-encoding") == "" &&
+		isCompressibleContentType(h.Get("content-type"))
+
+	if !cw.compress {
+		cw.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (cw *compressedResponseWriter) Write(b []byte) (int, error) {
+	if cw.code == 0 {
+		if cw.Header().Get("content-type") == "" {
+			cw.Header().Set("content-type", http.DetectContentType(b))
+		}
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if !cw.compress {
+		return cw.ResponseWriter.Write(b)
+	}
+
+	if cw.encoder != nil {
+		return cw.encoder.Write(b)
+	}
+
+	cw.buf = append(cw.buf, b...)
+
+	if len(cw.buf) >= compressionMinSize {
+		if err := cw.startEncoding(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(b), nil
+}
+
+func (cw *compressedResponseWriter) startEncoding() error {
+	h := cw.Header()
+	h.Del("content-length")
+	h.Set("content-encoding", cw.encoding)
+
+	cw.ResponseWriter.WriteHeader(cw.code)
+
+	switch cw.encoding {
+	case encodingZstd:
+		encoder := zstdEncoderPool.Get().(*zstd.Encoder)
+		encoder.Reset(cw.ResponseWriter)
+		cw.encoder = encoder
+	default:
+		encoder := gzipWriterPool.Get().(*gzip.Writer)
+		encoder.Reset(cw.ResponseWriter)
+		cw.encoder = encoder
+	}
+
+	buf := cw.buf
+	cw.buf = nil
+
+	_, err := cw.encoder.Write(buf)
+	return err
+}
+
+func (cw *compressedResponseWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Flush sends the buffered data to the client, e.g. for chunked downloads.
+func (cw *compressedResponseWriter) Flush() {
+	if cw.code == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if cw.compress && cw.encoder == nil {
+		if err := cw.startEncoding(); err != nil {
+			return
+		}
+	}
+
+	if flusher, ok := cw.encoder.(interface{ Flush() error }); ok {
+		if err := flusher.Flush(); err != nil {
+			return
+		}
+	}
+
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// close completes the response, the encoder is returned to the pool.
+func (cw *compressedResponseWriter) close() {
+	if cw.encoder == nil {
+		if cw.compress {
+			cw.ResponseWriter.WriteHeader(cw.code)
+			_, _ = cw.ResponseWriter.Write(cw.buf)
+		}
+		return
+	}
+
+	_ = cw.encoder.Close()
+
+	switch encoder := cw.encoder.(type) {
+	case *zstd.Encoder:
+		encoder.Reset(io.Discard)
+		zstdEncoderPool.Put(encoder)
+	case *gzip.Writer:
+		encoder.Reset(io.Discard)
+		gzipWriterPool.Put(encoder)
+	}
+}
+
+// compressionMiddleware compresses JSON and text responses by zstd or gzip accepted by the client.
+// It must not be used for websockets and event streams.
+func compressionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if (util.Config != nil && util.Config.DisableCompression) || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(r.Header.Get("accept-encoding"))
+		if encoding == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressedResponseWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.close()
+
+		next.ServeHTTP(cw, r)
+	})
+}
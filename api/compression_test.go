@@ -0,0 +1,96 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	cases := map[string]string{
+		"":                        "",
+		"gzip, deflate, br":       encodingGzip,
+		"gzip, zstd":              encodingZstd,
+		"zstd;q=0, gzip;q=0.5":    encodingGzip,
+		"identity":                "",
+		"GZIP":                    encodingGzip,
+		"br, zstd;q=1.0, *;q=0.1": encodingZstd,
+	}
+
+	for accept, expected := range cases {
+		if res := negotiateEncoding(accept); res != expected {
+			t.Errorf("expected %q for %q, got %q", expected, accept, res)
+		}
+	}
+}
+
+func serveCompressed(t *testing.T, acceptEncoding string, contentType string, body string) *http.Response {
+	handler := compressionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", contentType)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(body))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/project/1/tasks", nil)
+	req.Header.Set("accept-encoding", acceptEncoding)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	return rec.Result()
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	body := "[" + strings.Repeat(`{"id":1,"status":"success"},`, 100) + `{"id":2}]`
+
+	res := serveCompressed(t, "gzip", "application/json", body)
+	if res.Header.Get("content-encoding") != encodingGzip {
+		t.Fatal("large JSON must be compressed")
+	}
+
+	reader, err := gzip.NewReader(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil || string(data) != body {
+		t.Fatal("invalid gzip body", err)
+	}
+
+	res = serveCompressed(t, "zstd, gzip", "application/json", body)
+	if res.Header.Get("content-encoding") != encodingZstd {
+		t.Fatal("zstd must be preferred")
+	}
+
+	decoder, err := zstd.NewReader(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer decoder.Close()
+
+	data, err = io.ReadAll(decoder)
+	if err != nil || string(data) != body {
+		t.Fatal("invalid zstd body", err)
+	}
+
+	res = serveCompressed(t, "gzip", "application/json", `{"id":1}`)
+	data, _ = io.ReadAll(res.Body)
+	if res.Header.Get("content-encoding") != "" || string(data) != `{"id":1}` {
+		t.Fatal("small responses must not be compressed")
+	}
+
+	res = serveCompressed(t, "gzip", "application/octet-stream", body)
+	if res.Header.Get("content-encoding") != "" {
+		t.Fatal("binary responses must not be compressed")
+	}
+
+	if res.Header.Get("vary") != "Accept-Encoding" {
+		t.Fatal("responses must vary by accept-encoding")
+	}
+}
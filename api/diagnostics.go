@@ -0,0 +1,134 @@
+package api
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	runtimePprof "runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// diagnosticsMiddleware hides diagnostics endpoints if they are not enabled in the config.
+func diagnosticsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !util.Config.EnableDiagnostics {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func getPprofProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Del("content-type")
+
+	switch name := mux.Vars(r)["profile"]; name {
+	case "":
+		pprof.Index(w, r)
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		pprof.Handler(name).ServeHTTP(w, r)
+	}
+}
+
+type runtimeInfo struct {
+	Version      string    `json:"version"`
+	GoVersion    string    `json:"go_version"`
+	OS           string    `json:"os"`
+	Arch         string    `json:"arch"`
+	NumCPU       int       `json:"num_cpu"`
+	NumGoroutine int       `json:"num_goroutine"`
+	HeapAlloc    uint64    `json:"heap_alloc"`
+	HeapSys      uint64    `json:"heap_sys"`
+	NumGC        uint32    `json:"num_gc"`
+	Time         time.Time `json:"time"`
+}
+
+func getRuntimeInfo() runtimeInfo {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return runtimeInfo{
+		Version:      util.Version(),
+		GoVersion:    runtime.Version(),
+		OS:           runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		NumCPU:       runtime.NumCPU(),
+		NumGoroutine: runtime.NumGoroutine(),
+		HeapAlloc:    mem.HeapAlloc,
+		HeapSys:      mem.HeapSys,
+		NumGC:        mem.NumGC,
+		Time:         time.Now(),
+	}
+}
+
+// getDiagnosticsBundle returns ZIP archive with goroutines, heap profile,
+// runtime info, config with hidden secrets and recent logs.
+func getDiagnosticsBundle(w http.ResponseWriter, r *http.Request) {
+	config, err := util.Config.ToRedactedJSON()
+	if err != nil {
+		log.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	info, err := json.MarshalIndent(getRuntimeInfo(), "", "\t")
+	if err != nil {
+		log.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/zip")
+	w.Header().Set("content-disposition", "attachment; filename=\"semaphore-diagnostics-"+time.Now().Format("20060102-150405")+".zip\"")
+
+	archive := zip.NewWriter(w)
+
+	writeBytes := func(data []byte) func(f io.Writer) error {
+		return func(f io.Writer) error {
+			_, e := f.Write(data)
+			return e
+		}
+	}
+
+	files := []struct {
+		name  string
+		write func(f io.Writer) error
+	}{
+		{"runtime.json", writeBytes(info)},
+		{"config.json", writeBytes(config)},
+		{"goroutines.txt", func(f io.Writer) error { return runtimePprof.Lookup("goroutine").WriteTo(f, 2) }},
+		{"heap.pprof", func(f io.Writer) error { return runtimePprof.Lookup("heap").WriteTo(f, 0) }},
+		{"logs.txt", writeBytes([]byte(strings.Join(util.RecentLogs.Lines(), "")))},
+	}
+
+	for _, file := range files {
+		f, err := archive.Create(file.name)
+		if err == nil {
+			err = file.write(f)
+		}
+		if err != nil {
+			log.Error(err)
+			break
+		}
+	}
+
+	if err = archive.Close(); err != nil {
+		log.Error(err)
+	}
+}
@@ -0,0 +1,19 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/semaphoreui/semaphore/api/helpers"
+)
+
+// getDiskUsage returns the disk consumed by the tmp directories of the server and the runners
+// by project and kind. The usage is measured by the disk_usage housekeeping job and by runners.
+func getDiskUsage(w http.ResponseWriter, r *http.Request) {
+	usage, err := helpers.Store(r).GetDiskUsage(nil)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, usage)
+}
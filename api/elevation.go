@@ -0,0 +1,115 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/auth"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	elevationTokenName   = "semaphore_elevation"
+	elevationTokenHeader = "X-Semaphore-Elevation"
+	elevationTokenTTL    = 5 * time.Minute
+)
+
+type elevationToken struct {
+	Token   string    `json:"token"`
+	Expires time.Time `json:"expires"`
+}
+
+func makeElevationToken(userID int, now time.Time) (res elevationToken, err error) {
+	res.Expires = now.Add(elevationTokenTTL)
+	res.Token, err = util.Cookie.Encode(elevationTokenName, map[string]interface{}{
+		"user":    userID,
+		"expires": res.Expires.Unix(),
+	})
+	return
+}
+
+// checkElevationToken returns true if the token was issued for the user and is not expired.
+func checkElevationToken(token string, userID int, now time.Time) bool {
+	value := make(map[string]interface{})
+	if err := util.Cookie.Decode(elevationTokenName, token, &value); err != nil {
+		return false
+	}
+
+	user, ok := value["user"].(int)
+	if !ok || user != userID {
+		return false
+	}
+
+	expires, ok := value["expires"].(int64)
+	if !ok {
+		return false
+	}
+
+	return now.Before(time.Unix(expires, 0))
+}
+
+// verifyUserPassword checks the password of the current user by the password providers.
+// Users authenticated via OIDC have no password and can't be verified.
+func verifyUserPassword(user *db.User, password string) bool {
+	for _, p := range auth.PasswordProviders() {
+		if p.VerifyPassword(user, password) {
+			return true
+		}
+	}
+	return false
+}
+
+// elevate issues short-lived token which confirms that the user re-entered the password.
+// The token must be passed in X-Semaphore-Elevation header of destructive requests.
+func elevate(w http.ResponseWriter, r *http.Request) {
+	user := context.Get(r, "user").(*db.User)
+
+	var body struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	if !verifyUserPassword(user, body.Password) {
+		log.Warn("User " + user.Username + " failed to confirm the password")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	token, err := makeElevationToken(user.ID, time.Now())
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusCreated, token)
+}
+
+// elevationMiddleware rejects the request without valid elevation token
+// if re-authentication is required for destructive actions.
+func elevationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !util.Config.DestructiveActionReauth {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user := context.Get(r, "user").(*db.User)
+
+		if !checkElevationToken(r.Header.Get(elevationTokenHeader), user.ID, time.Now()) {
+			helpers.WriteErrorStatus(w, "Re-authentication required", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func elevated(handler http.HandlerFunc) http.Handler {
+	return elevationMiddleware(handler)
+}
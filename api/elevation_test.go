@@ -0,0 +1,36 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestElevationToken(t *testing.T) {
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+
+	now := time.Now()
+
+	token, err := makeElevationToken(1, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !checkElevationToken(token.Token, 1, now.Add(time.Minute)) {
+		t.Fatal("token must be valid")
+	}
+
+	if checkElevationToken(token.Token, 2, now) {
+		t.Fatal("token must not be valid for another user")
+	}
+
+	if checkElevationToken(token.Token, 1, now.Add(elevationTokenTTL+time.Second)) {
+		t.Fatal("token must expire")
+	}
+
+	if checkElevationToken("invalid", 1, now) {
+		t.Fatal("invalid token must be rejected")
+	}
+}
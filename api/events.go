@@ -4,6 +4,9 @@ import (
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/context"
 )
@@ -38,7 +41,14 @@ func getEvents(w http.ResponseWriter, r *http.Request, limit int) {
 		return
 	}
 
-	helpers.WriteJSON(w, http.StatusOK, events)
+	var lastModified time.Time
+	for _, event := range events {
+		if event.Created.After(lastModified) {
+			lastModified = event.Created
+		}
+	}
+
+	helpers.WriteConditionalJSON(w, r, lastModified, events)
 }
 
 func getLastEvents(w http.ResponseWriter, r *http.Request) {
@@ -48,3 +58,69 @@ func getLastEvents(w http.ResponseWriter, r *http.Request) {
 func getAllEvents(w http.ResponseWriter, r *http.Request) {
 	getEvents(w, r, 0)
 }
+
+const (
+	defaultActivityPageSize = 50
+	maxActivityPageSize     = 200
+)
+
+// getActivity returns a page of the project activity feed.
+// Query params: before - cursor returned as next_cursor by the previous page,
+// limit - page size, object_type - comma separated list of event object types,
+// object_id - ID of the object, action - comma separated list of event actions.
+func getActivity(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	params := db.ActivityQueryParams{
+		Count: defaultActivityPageSize,
+	}
+
+	query := r.URL.Query()
+
+	if before := query.Get("before"); before != "" {
+		var err error
+		params.Before, err = strconv.Atoi(before)
+		if err != nil || params.Before <= 0 {
+			helpers.WriteErrorStatus(w, "Invalid before cursor", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		var err error
+		params.Count, err = strconv.Atoi(limit)
+		if err != nil || params.Count <= 0 || params.Count > maxActivityPageSize {
+			helpers.WriteErrorStatus(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if objectTypes := query.Get("object_type"); objectTypes != "" {
+		for _, objType := range strings.Split(objectTypes, ",") {
+			params.ObjectTypes = append(params.ObjectTypes, db.EventObjectType(strings.TrimSpace(objType)))
+		}
+	}
+
+	if objectID := query.Get("object_id"); objectID != "" {
+		id, err := strconv.Atoi(objectID)
+		if err != nil {
+			helpers.WriteErrorStatus(w, "Invalid object_id", http.StatusBadRequest)
+			return
+		}
+		params.ObjectID = &id
+	}
+
+	if actions := query.Get("action"); actions != "" {
+		for _, action := range strings.Split(actions, ",") {
+			params.Actions = append(params.Actions, strings.TrimSpace(action))
+		}
+	}
+
+	events, err := helpers.Store(r).GetActivity(project.ID, params)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, db.NewActivityFeed(events, params.Count))
+}
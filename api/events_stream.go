@@ -0,0 +1,75 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/api/sse"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// eventStreamKeepAlivePeriod is the interval of comments sent to the client
+// to keep connection open through proxies.
+const eventStreamKeepAlivePeriod = 30 * time.Second
+
+// getEventStream streams project events (task status changes, new tasks,
+// schedule fires) to the client using Server-Sent Events.
+func getEventStream(w http.ResponseWriter, r *http.Request) {
+	user := context.Get(r, "user").(*db.User)
+
+	projectID, err := helpers.GetIntParam("project_id", w, r)
+	if err != nil {
+		return
+	}
+
+	if !user.Admin {
+		store := helpers.Store(r)
+
+		db.StoreSession(store, r.URL.String(), func() {
+			_, err = store.GetProjectUser(projectID, user.ID)
+		})
+
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		helpers.WriteErrorStatus(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	s := sse.Subscribe(projectID)
+	defer sse.Unsubscribe(s)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(eventStreamKeepAlivePeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			_, err = fmt.Fprint(w, ": ping\n\n")
+		case msg := <-s.Events():
+			_, err = fmt.Fprint(w, msg)
+		}
+
+		if err != nil {
+			return
+		}
+
+		flusher.Flush()
+	}
+}
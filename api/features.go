@@ -0,0 +1,92 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/context"
+	"github.com/gorilla/mux"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+type featureFlagValue struct {
+	// Enabled is nil to reset the flag to the instance value or the default.
+	Enabled *bool `json:"enabled"`
+}
+
+func getFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	flags, err := db.GetFeatureFlags(helpers.Store(r))
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, flags)
+}
+
+// setFeatureFlag sets the flag for the instance or for the project if project_id is in the path.
+func setFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	flag := db.FeatureFlag(mux.Vars(r)["flag"])
+
+	var projectID *int
+	if _, ok := mux.Vars(r)["project_id"]; ok {
+		id, err := helpers.GetIntParam("project_id", w, r)
+		if err != nil {
+			return
+		}
+
+		if _, err = helpers.Store(r).GetProject(id); err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		projectID = &id
+	}
+
+	var body featureFlagValue
+	if !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	if err := db.SetFeatureFlag(helpers.Store(r), flag, projectID, body.Enabled); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	// changes of the project flags are shown in the activity of the project
+	if projectID != nil {
+		desc := fmt.Sprintf("Feature %s is reset", flag)
+		if body.Enabled != nil {
+			desc = fmt.Sprintf("Feature %s is set to %t", flag, *body.Enabled)
+		}
+
+		helpers.EventLog(r, helpers.EventLogUpdate, helpers.EventLogItem{
+			UserID:      helpers.UserFromContext(r).ID,
+			ProjectID:   *projectID,
+			ObjectType:  db.EventProject,
+			ObjectID:    *projectID,
+			Description: desc,
+		})
+	}
+
+	getFeatureFlags(w, r)
+}
+
+// getProjectFeatureFlags returns the values of all flags for the project.
+func getProjectFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	flags, err := db.GetFeatureFlags(helpers.Store(r))
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	res := make(map[db.FeatureFlag]bool)
+	for _, flag := range flags {
+		res[flag.Name] = flag.IsEnabledFor(&project.ID)
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, res)
+}
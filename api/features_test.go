@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+)
+
+func TestFeatureFlags(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	project, err := store.CreateProject(db.Project{Name: "Experimental"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	disabled := false
+	if err = db.SetFeatureFlag(store, db.FeatureTaskOutputStorage, nil, &disabled); err != nil {
+		t.Fatal(err)
+	}
+
+	enabled := true
+	if err = db.SetFeatureFlag(store, db.FeatureTaskOutputStorage, &project.ID, &enabled); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = db.SetFeatureFlag(store, "unknown", nil, &enabled); err == nil {
+		t.Fatal("unknown flag must be rejected")
+	}
+
+	handler := helpers.FeatureMiddleware(db.FeatureTaskOutputStorage)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func(project *db.Project) int {
+		r := httptest.NewRequest("GET", "/api/test", nil)
+		context.Set(r, "store", store)
+		if project != nil {
+			context.Set(r, "project", *project)
+		}
+		defer context.Clear(r)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := serve(nil); code != http.StatusNotFound {
+		t.Fatalf("feature must be disabled for the instance, got %d", code)
+	}
+
+	if code := serve(&project); code != http.StatusNoContent {
+		t.Fatalf("feature must be enabled for the project, got %d", code)
+	}
+
+	// the project value is reset to the instance value
+	if err = db.SetFeatureFlag(store, db.FeatureTaskOutputStorage, &project.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := serve(&project); code != http.StatusNotFound {
+		t.Fatalf("feature must be disabled after reset, got %d", code)
+	}
+
+	flags, err := db.GetFeatureFlags(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(flags) != 1 || flags[0].Enabled == nil || *flags[0].Enabled || len(flags[0].Projects) != 0 {
+		t.Fatalf("unexpected flags %+v", flags)
+	}
+}
@@ -0,0 +1,383 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+type graphQLContextKey struct{}
+
+// graphQLContext holds request scoped values available to resolvers.
+type graphQLContext struct {
+	store db.Store
+	user  *db.User
+}
+
+func graphQLContextFrom(p graphql.ResolveParams) graphQLContext {
+	return p.Context.Value(graphQLContextKey{}).(graphQLContext)
+}
+
+var graphQLTaskType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Task",
+	Fields: graphql.Fields{
+		"id":             &graphql.Field{Type: graphql.Int},
+		"template_id":    &graphql.Field{Type: graphql.Int},
+		"project_id":     &graphql.Field{Type: graphql.Int},
+		"status":         &graphql.Field{Type: graphql.String},
+		"playbook":       &graphql.Field{Type: graphql.String},
+		"limit":          &graphql.Field{Type: graphql.String},
+		"git_branch":     &graphql.Field{Type: graphql.String},
+		"user_id":        &graphql.Field{Type: graphql.Int},
+		"integration_id": &graphql.Field{Type: graphql.Int},
+		"schedule_id":    &graphql.Field{Type: graphql.Int},
+		"created":        &graphql.Field{Type: graphql.DateTime},
+		"start":          &graphql.Field{Type: graphql.DateTime},
+		"end":            &graphql.Field{Type: graphql.DateTime},
+		"message":        &graphql.Field{Type: graphql.String},
+		"commit_hash":    &graphql.Field{Type: graphql.String},
+		"commit_message": &graphql.Field{Type: graphql.String},
+		"build_task_id":  &graphql.Field{Type: graphql.Int},
+		"version":        &graphql.Field{Type: graphql.String},
+		"inventory_id":   &graphql.Field{Type: graphql.Int},
+	},
+})
+
+var graphQLLimitArgs = graphql.FieldConfigArgument{
+	"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 20},
+}
+
+func tasksWithoutTpl(tasks []db.TaskWithTpl) []db.Task {
+	res := make([]db.Task, 0, len(tasks))
+	for _, task := range tasks {
+		res = append(res, task.Task)
+	}
+	return res
+}
+
+var graphQLTemplateType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Template",
+	Fields: graphql.Fields{
+		"id":                &graphql.Field{Type: graphql.Int},
+		"project_id":        &graphql.Field{Type: graphql.Int},
+		"inventory_id":      &graphql.Field{Type: graphql.Int},
+		"repository_id":     &graphql.Field{Type: graphql.Int},
+		"environment_id":    &graphql.Field{Type: graphql.Int},
+		"view_id":           &graphql.Field{Type: graphql.Int},
+		"build_template_id": &graphql.Field{Type: graphql.Int},
+		"name":              &graphql.Field{Type: graphql.String},
+		"playbook":          &graphql.Field{Type: graphql.String},
+		"arguments":         &graphql.Field{Type: graphql.String},
+		"description":       &graphql.Field{Type: graphql.String},
+		"type":              &graphql.Field{Type: graphql.String},
+		"app":               &graphql.Field{Type: graphql.String},
+		"git_branch":        &graphql.Field{Type: graphql.String},
+		"autorun":           &graphql.Field{Type: graphql.Boolean},
+		"last_task": &graphql.Field{
+			Type: graphQLTaskType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				tpl := p.Source.(db.Template)
+				if tpl.LastTask == nil {
+					return nil, nil
+				}
+				return tpl.LastTask.Task, nil
+			},
+		},
+		"tasks": &graphql.Field{
+			Type: graphql.NewList(graphQLTaskType),
+			Args: graphQLLimitArgs,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				tpl := p.Source.(db.Template)
+				tasks, err := graphQLContextFrom(p).store.GetTemplateTasks(tpl.ProjectID, tpl.ID, db.RetrieveQueryParams{
+					Count: p.Args["limit"].(int),
+				})
+				return tasksWithoutTpl(tasks), err
+			},
+		},
+	},
+})
+
+var graphQLRepositoryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Repository",
+	Fields: graphql.Fields{
+		"id":         &graphql.Field{Type: graphql.Int},
+		"project_id": &graphql.Field{Type: graphql.Int},
+		"name":       &graphql.Field{Type: graphql.String},
+		"git_url":    &graphql.Field{Type: graphql.String},
+		"git_branch": &graphql.Field{Type: graphql.String},
+		"ssh_key_id": &graphql.Field{Type: graphql.Int},
+	},
+})
+
+var graphQLInventoryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Inventory",
+	Fields: graphql.Fields{
+		"id":            &graphql.Field{Type: graphql.Int},
+		"project_id":    &graphql.Field{Type: graphql.Int},
+		"name":          &graphql.Field{Type: graphql.String},
+		"type":          &graphql.Field{Type: graphql.String},
+		"inventory":     &graphql.Field{Type: graphql.String},
+		"ssh_key_id":    &graphql.Field{Type: graphql.Int},
+		"become_key_id": &graphql.Field{Type: graphql.Int},
+		"repository_id": &graphql.Field{Type: graphql.Int},
+	},
+})
+
+var graphQLEnvironmentType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Environment",
+	Fields: graphql.Fields{
+		"id":         &graphql.Field{Type: graphql.Int},
+		"project_id": &graphql.Field{Type: graphql.Int},
+		"name":       &graphql.Field{Type: graphql.String},
+		"json":       &graphql.Field{Type: graphql.String},
+		"env":        &graphql.Field{Type: graphql.String},
+	},
+})
+
+var graphQLViewType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "View",
+	Fields: graphql.Fields{
+		"id":         &graphql.Field{Type: graphql.Int},
+		"project_id": &graphql.Field{Type: graphql.Int},
+		"title":      &graphql.Field{Type: graphql.String},
+		"position":   &graphql.Field{Type: graphql.Int},
+	},
+})
+
+var graphQLScheduleType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Schedule",
+	Fields: graphql.Fields{
+		"id":               &graphql.Field{Type: graphql.Int},
+		"project_id":       &graphql.Field{Type: graphql.Int},
+		"template_id":      &graphql.Field{Type: graphql.Int},
+		"type":             &graphql.Field{Type: graphql.String},
+		"cron_format":      &graphql.Field{Type: graphql.String},
+		"interval_minutes": &graphql.Field{Type: graphql.Int},
+		"name":             &graphql.Field{Type: graphql.String},
+		"active":           &graphql.Field{Type: graphql.Boolean},
+		"repository_id":    &graphql.Field{Type: graphql.Int},
+	},
+})
+
+var graphQLProjectType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Project",
+	Fields: graphql.Fields{
+		"id":                 &graphql.Field{Type: graphql.Int},
+		"name":               &graphql.Field{Type: graphql.String},
+		"created":            &graphql.Field{Type: graphql.DateTime},
+		"alert":              &graphql.Field{Type: graphql.Boolean},
+		"max_parallel_tasks": &graphql.Field{Type: graphql.Int},
+		"type":               &graphql.Field{Type: graphql.String},
+		"templates": &graphql.Field{
+			Type: graphql.NewList(graphQLTemplateType),
+			Args: graphql.FieldConfigArgument{
+				"view_id": &graphql.ArgumentConfig{Type: graphql.Int},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				project := p.Source.(db.Project)
+				filter := db.TemplateFilter{}
+				if viewID, ok := p.Args["view_id"].(int); ok {
+					filter.ViewID = &viewID
+				}
+				return graphQLContextFrom(p).store.GetTemplates(project.ID, filter, db.RetrieveQueryParams{})
+			},
+		},
+		"template": &graphql.Field{
+			Type: graphQLTemplateType,
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				project := p.Source.(db.Project)
+				return graphQLContextFrom(p).store.GetTemplate(project.ID, p.Args["id"].(int))
+			},
+		},
+		"repositories": &graphql.Field{
+			Type: graphql.NewList(graphQLRepositoryType),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				project := p.Source.(db.Project)
+				return graphQLContextFrom(p).store.GetRepositories(project.ID, db.RetrieveQueryParams{})
+			},
+		},
+		"inventories": &graphql.Field{
+			Type: graphql.NewList(graphQLInventoryType),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				project := p.Source.(db.Project)
+				return graphQLContextFrom(p).store.GetInventories(project.ID, db.RetrieveQueryParams{})
+			},
+		},
+		"environments": &graphql.Field{
+			Type: graphql.NewList(graphQLEnvironmentType),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				project := p.Source.(db.Project)
+				return graphQLContextFrom(p).store.GetEnvironments(project.ID, db.RetrieveQueryParams{})
+			},
+		},
+		"views": &graphql.Field{
+			Type: graphql.NewList(graphQLViewType),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				project := p.Source.(db.Project)
+				return graphQLContextFrom(p).store.GetViews(project.ID)
+			},
+		},
+		"schedules": &graphql.Field{
+			Type: graphql.NewList(graphQLScheduleType),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				project := p.Source.(db.Project)
+				schedules, err := graphQLContextFrom(p).store.GetProjectSchedules(project.ID)
+				if err != nil {
+					return nil, err
+				}
+				res := make([]db.Schedule, 0, len(schedules))
+				for _, s := range schedules {
+					res = append(res, s.Schedule)
+				}
+				return res, nil
+			},
+		},
+		"tasks": &graphql.Field{
+			Type: graphql.NewList(graphQLTaskType),
+			Args: graphQLLimitArgs,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				project := p.Source.(db.Project)
+				tasks, err := graphQLContextFrom(p).store.GetProjectTasks(project.ID, db.RetrieveQueryParams{
+					Count: p.Args["limit"].(int),
+				})
+				return tasksWithoutTpl(tasks), err
+			},
+		},
+		"task": &graphql.Field{
+			Type: graphQLTaskType,
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				project := p.Source.(db.Project)
+				return graphQLContextFrom(p).store.GetTask(project.ID, p.Args["id"].(int))
+			},
+		},
+	},
+})
+
+var graphQLUserType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "User",
+	Fields: graphql.Fields{
+		"id":       &graphql.Field{Type: graphql.Int},
+		"username": &graphql.Field{Type: graphql.String},
+		"name":     &graphql.Field{Type: graphql.String},
+		"email":    &graphql.Field{Type: graphql.String},
+		"admin":    &graphql.Field{Type: graphql.Boolean},
+	},
+})
+
+var graphQLQueryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Query",
+	Fields: graphql.Fields{
+		"me": &graphql.Field{
+			Type: graphQLUserType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return *graphQLContextFrom(p).user, nil
+			},
+		},
+		"projects": &graphql.Field{
+			Type: graphql.NewList(graphQLProjectType),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				ctx := graphQLContextFrom(p)
+				if ctx.user.Admin {
+					return ctx.store.GetAllProjects()
+				}
+				return ctx.store.GetProjects(ctx.user.ID)
+			},
+		},
+		"project": &graphql.Field{
+			Type: graphQLProjectType,
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				ctx := graphQLContextFrom(p)
+				projectID := p.Args["id"].(int)
+
+				// check if user in project's team
+				if !ctx.user.Admin {
+					if _, err := ctx.store.GetProjectUser(projectID, ctx.user.ID); err != nil {
+						return nil, err
+					}
+				}
+
+				return ctx.store.GetProject(projectID)
+			},
+		},
+	},
+})
+
+var graphQLSchema struct {
+	once   sync.Once
+	schema graphql.Schema
+	err    error
+}
+
+func getGraphQLSchema() (graphql.Schema, error) {
+	graphQLSchema.once.Do(func() {
+		graphQLSchema.schema, graphQLSchema.err = graphql.NewSchema(graphql.SchemaConfig{
+			Query: graphQLQueryType,
+		})
+	})
+	return graphQLSchema.schema, graphQLSchema.err
+}
+
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// graphQLHandler executes read-only GraphQL queries on behalf of the current user.
+func graphQLHandler(w http.ResponseWriter, r *http.Request) {
+	if !util.Config.GraphQLEnabled {
+		helpers.WriteErrorStatus(w, "GraphQL API is disabled", http.StatusNotFound)
+		return
+	}
+
+	var req graphQLRequest
+
+	if r.Method == "GET" {
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if vars := r.URL.Query().Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				helpers.WriteErrorStatus(w, "Invalid variables", http.StatusBadRequest)
+				return
+			}
+		}
+	} else if !helpers.Bind(w, r, &req) {
+		return
+	}
+
+	schema, err := getGraphQLSchema()
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), graphQLContextKey{}, graphQLContext{
+		store: helpers.Store(r),
+		user:  helpers.UserFromContext(r),
+	})
+
+	res := graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  req.Query,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+		Context:        ctx,
+	})
+
+	helpers.WriteJSON(w, http.StatusOK, res)
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+func TestGraphQLProjectTemplates(t *testing.T) {
+	store := bolt.CreateTestStore()
+
+	user, err := store.CreateUserWithoutPassword(db.User{Username: "test", Name: "Test", Email: "test@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := store.CreateProject(db.Project{Name: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.CreateProjectUser(db.ProjectUser{ProjectID: project.ID, UserID: user.ID, Role: db.ProjectOwner})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, err := store.CreateTemplate(db.Template{ProjectID: project.ID, Name: "Deploy", Playbook: "deploy.yml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.CreateTask(db.Task{ProjectID: project.ID, TemplateID: tpl.ID, Status: task_logger.TaskSuccessStatus}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := getGraphQLSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  "query ($id: Int!) { project(id: $id) { name templates { name last_task { status } } } }",
+		VariableValues: map[string]any{"id": project.ID},
+		Context:        context.WithValue(context.Background(), graphQLContextKey{}, graphQLContext{store: store, user: &user}),
+	})
+
+	if res.HasErrors() {
+		t.Fatal(res.Errors)
+	}
+
+	data, _ := json.Marshal(res.Data)
+
+	if string(data) != `{"project":{"name":"Test","templates":[{"last_task":{"status":"success"},"name":"Deploy"}]}}` {
+		t.Fatal("unexpected result", string(data))
+	}
+
+	other, err := store.CreateUserWithoutPassword(db.User{Username: "other", Name: "Other", Email: "other@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res = graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  "query ($id: Int!) { project(id: $id) { name } }",
+		VariableValues: map[string]any{"id": project.ID},
+		Context:        context.WithValue(context.Background(), graphQLContextKey{}, graphQLContext{store: store, user: &other}),
+	})
+
+	if !res.HasErrors() {
+		t.Fatal("project must not be accessible for non-member")
+	}
+}
@@ -0,0 +1,78 @@
+package helpers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// etagMatches checks if the ETag is in the list of If-None-Match header.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified checks conditional headers of the request. If-None-Match takes
+// precedence over If-Modified-Since as described in RFC 9110.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag)
+	}
+
+	if lastModified.IsZero() {
+		return false
+	}
+
+	ifModifiedSince, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.Truncate(time.Second).After(ifModifiedSince)
+}
+
+// WriteConditionalJSON writes out as JSON with ETag and Last-Modified headers.
+// It responds with 304 Not Modified if the client already has the same content.
+// lastModified can be zero if it is unknown.
+func WriteConditionalJSON(w http.ResponseWriter, r *http.Request, lastModified time.Time, out interface{}) {
+	body, err := json.Marshal(out)
+	if err != nil {
+		log.Error(err)
+		WriteErrorStatus(w, "Can not encode response", http.StatusInternalServerError)
+		return
+	}
+
+	body = append(body, '\n')
+
+	hash := sha256.Sum256(body)
+	etag := "\"" + hex.EncodeToString(hash[:16]) + "\""
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err = w.Write(body); err != nil {
+		log.Error(err)
+	}
+}
@@ -29,6 +29,7 @@ func EventLog(r *http.Request, action EventLogType, event EventLogItem) {
 	record := db.Event{
 		ObjectType:  &event.ObjectType,
 		ObjectID:    &event.ObjectID,
+		Action:      string(action),
 		Description: &event.Description,
 	}
 
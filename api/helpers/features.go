@@ -0,0 +1,35 @@
+package helpers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/context"
+	"github.com/gorilla/mux"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// FeatureMiddleware responds with 404 if the feature is disabled. The flag is checked
+// for the project from the context if the route belongs to the project.
+func FeatureMiddleware(flag db.FeatureFlag) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var projectID *int
+			if project, ok := context.Get(r, "project").(db.Project); ok {
+				projectID = &project.ID
+			}
+
+			enabled, err := db.IsFeatureEnabled(Store(r), flag, projectID)
+			if err != nil {
+				WriteError(w, err)
+				return
+			}
+
+			if !enabled {
+				WriteErrorStatus(w, "Feature "+string(flag)+" is disabled", http.StatusNotFound)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
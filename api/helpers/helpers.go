@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"runtime/debug"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -15,6 +16,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/i18n"
 
 	"github.com/gorilla/mux"
 )
@@ -75,7 +77,7 @@ type H map[string]interface{}
 func Bind(w http.ResponseWriter, r *http.Request, out interface{}) bool {
 	err := json.NewDecoder(r.Body).Decode(out)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		WriteErrorStatus(w, "Invalid request body", http.StatusBadRequest)
 	}
 
 	return err == nil
@@ -92,41 +94,165 @@ func WriteJSON(w http.ResponseWriter, code int, out interface{}) {
 	}
 }
 
+// ErrorResponse is the body of the error response. Error is the localized message
+// and Code is the machine-readable code of the error.
+type ErrorResponse struct {
+	Error string       `json:"error"`
+	Code  db.ErrorCode `json:"code"`
+	Field string       `json:"field,omitempty"`
+	Hint  string       `json:"hint,omitempty"`
+	// Fields contains messages of the invalid fields by field names.
+	Fields map[string]string `json:"fields,omitempty"`
+	// Errors contains errors of the invalid fields with their codes.
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is the error of the invalid field.
+type FieldError struct {
+	Field   string       `json:"field"`
+	Code    db.ErrorCode `json:"code"`
+	Message string       `json:"message"`
+}
+
+// WriteErrorStatus writes the error with the code of the HTTP status.
 func WriteErrorStatus(w http.ResponseWriter, err string, code int) {
-	WriteJSON(w, code, map[string]string{
-		"error": err,
+	WriteJSON(w, code, ErrorResponse{
+		Error: i18n.T(Locale(w), err),
+		Code:  db.GetErrorCodeOfStatus(code),
+	})
+}
+
+// WriteErrorCode writes the error with the specific code.
+func WriteErrorCode(w http.ResponseWriter, err string, code db.ErrorCode) {
+	WriteJSON(w, code.HTTPStatus(), ErrorResponse{
+		Error: i18n.T(Locale(w), err),
+		Code:  code,
+	})
+}
+
+// WriteCodedError writes the error with its code, field and hint.
+func WriteCodedError(w http.ResponseWriter, err *db.CodedError) {
+	res := ErrorResponse{
+		Error: i18n.T(Locale(w), err.Error()),
+		Code:  err.Code,
+		Field: err.Field,
+	}
+
+	if err.Hint != "" {
+		res.Hint = i18n.T(Locale(w), err.Hint)
+	}
+
+	WriteJSON(w, err.Code.HTTPStatus(), res)
+}
+
+// WriteFieldErrors writes errors of the invalid fields. The error contains all of them,
+// so it can be shown by clients which do not support errors of the fields.
+func WriteFieldErrors(w http.ResponseWriter, err *db.FieldValidationError) {
+	translated := &db.FieldValidationError{}
+	for field, msg := range err.Fields {
+		translated.Add(field, i18n.T(Locale(w), msg))
+	}
+
+	res := ErrorResponse{
+		Error:  translated.Error(),
+		Code:   db.ErrorCodeValidationFailed,
+		Fields: translated.Fields,
+	}
+
+	fields := make([]string, 0, len(translated.Fields))
+	for field := range translated.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		res.Errors = append(res.Errors, FieldError{
+			Field:   field,
+			Code:    err.GetCode(field),
+			Message: translated.Fields[field],
+		})
+	}
+
+	if len(fields) == 1 {
+		res.Field = fields[0]
+	}
+
+	WriteJSON(w, http.StatusBadRequest, res)
+}
+
+// WriteObjectInUse writes the error of deletion of the object which is used by other objects.
+// The response contains the referring objects.
+func WriteObjectInUse(w http.ResponseWriter, err string, refs db.ObjectReferrers) {
+	WriteJSON(w, http.StatusBadRequest, map[string]interface{}{
+		"error": i18n.T(Locale(w), err),
+		"code":  db.ErrorCodeObjectInUse,
+		"inUse": true,
+		"refs":  refs,
 	})
 }
 
 func WriteError(w http.ResponseWriter, err error) {
 	if errors.Is(err, tasks.ErrInvalidSubscription) {
-		WriteErrorStatus(w, "You have no subscription.", http.StatusForbidden)
+		WriteErrorCode(w, "You have no subscription.", db.ErrorCodeSubscriptionRequired)
 		return
 	}
 
 	if errors.Is(err, db.ErrNotFound) {
-		w.WriteHeader(http.StatusNotFound)
+		WriteErrorCode(w, "Not found", db.ErrorCodeNotFound)
+		return
+	}
+
+	if errors.Is(err, db.ErrProjectArchived) {
+		WriteErrorCode(w, err.Error(), db.ErrorCodeProjectArchived)
 		return
 	}
 
 	if errors.Is(err, db.ErrInvalidOperation) {
-		w.WriteHeader(http.StatusConflict)
+		WriteErrorCode(w, "Invalid operation", db.ErrorCodeConflict)
+		return
+	}
+
+	var quotaErr *db.QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		WriteErrorCode(w, quotaErr.Error(), db.ErrorCodeQuotaExceeded)
+		return
+	}
+
+	var codedErr *db.CodedError
+	if errors.As(err, &codedErr) {
+		if codedErr.Code.HTTPStatus() >= http.StatusInternalServerError {
+			log.Error(err)
+		}
+		WriteCodedError(w, codedErr)
 		return
 	}
 
 	switch e := err.(type) {
 	case *db.ValidationError:
 		WriteErrorStatus(w, e.Error(), http.StatusBadRequest)
+	case *db.FieldValidationError:
+		WriteFieldErrors(w, e)
 	default:
 		log.Error(err)
 		debug.PrintStack()
-		w.WriteHeader(http.StatusBadRequest)
+		WriteErrorCode(w, "Bad request", db.ErrorCodeBadRequest)
 	}
 }
 
 func QueryParams(url *url.URL) db.RetrieveQueryParams {
-	return db.RetrieveQueryParams{
+	params := db.RetrieveQueryParams{
 		SortBy:       url.Query().Get("sort"),
 		SortInverted: url.Query().Get("order") == "desc",
 	}
+
+	// offset can not be used without count
+	if count, err := strconv.Atoi(url.Query().Get("count")); err == nil && count > 0 {
+		params.Count = count
+
+		if offset, err := strconv.Atoi(url.Query().Get("offset")); err == nil && offset > 0 {
+			params.Offset = offset
+		}
+	}
+
+	return params
 }
@@ -1,11 +1,17 @@
 package helpers
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
 )
 
 func TestGetIntParam(t *testing.T) {
@@ -29,3 +35,97 @@ func mockParam(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(200)
 }
+
+func TestWriteConditionalJSON(t *testing.T) {
+	lastModified := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	out := []string{"a", "b"}
+
+	req, _ := http.NewRequest("GET", "/tasks", nil)
+	rr := httptest.NewRecorder()
+	WriteConditionalJSON(rr, req, lastModified, out)
+
+	etag := rr.Header().Get("ETag")
+	if rr.Code != http.StatusOK || etag == "" || rr.Header().Get("Last-Modified") != "Wed, 01 May 2024 10:00:00 GMT" {
+		t.Fatal("full response with validators expected", rr.Code)
+	}
+
+	req.Header.Set("If-None-Match", "W/"+etag)
+	rr = httptest.NewRecorder()
+	WriteConditionalJSON(rr, req, lastModified, out)
+
+	if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Fatal("not modified expected for matching ETag", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	WriteConditionalJSON(rr, req, lastModified, []string{"a"})
+
+	if rr.Code != http.StatusOK {
+		t.Fatal("changed content must be returned", rr.Code)
+	}
+
+	req.Header.Del("If-None-Match")
+	req.Header.Set("If-Modified-Since", "Wed, 01 May 2024 10:00:00 GMT")
+	rr = httptest.NewRecorder()
+	WriteConditionalJSON(rr, req, lastModified, out)
+
+	if rr.Code != http.StatusNotModified {
+		t.Fatal("not modified expected for If-Modified-Since", rr.Code)
+	}
+}
+
+func TestWriteErrorStatusTranslated(t *testing.T) {
+	rr := httptest.NewRecorder()
+	WriteErrorStatus(WithLocale(rr, "de"), "Name cannot be empty", http.StatusBadRequest)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d", rr.Code)
+	}
+
+	if body := rr.Body.String(); !strings.Contains(body, "Name darf nicht leer sein") {
+		t.Fatalf("error is not translated: %s", body)
+	}
+}
+
+func TestWriteErrorCodes(t *testing.T) {
+	util.Config = &util.ConfigType{}
+
+	fieldErr := &db.FieldValidationError{}
+	fieldErr.AddWithCode("inventory_id", db.ErrorCodeTemplateReferenceMissing, "inventory not found in the project")
+
+	for _, c := range []struct {
+		err    error
+		status int
+		code   db.ErrorCode
+		field  string
+	}{
+		{err: db.ErrNotFound, status: http.StatusNotFound, code: db.ErrorCodeNotFound},
+		{err: &db.ValidationError{Message: "name can not be empty"}, status: http.StatusBadRequest, code: db.ErrorCodeBadRequest},
+		{err: fmt.Errorf("can not run the task: %w", db.ErrKeyDecryptFailed), status: http.StatusInternalServerError, code: db.ErrorCodeKeyDecryptFailed},
+		{err: fieldErr, status: http.StatusBadRequest, code: db.ErrorCodeValidationFailed, field: "inventory_id"},
+	} {
+		rr := httptest.NewRecorder()
+		WriteError(rr, c.err)
+
+		var res ErrorResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+
+		if rr.Code != c.status || res.Code != c.code || res.Field != c.field || res.Error == "" {
+			t.Fatalf("unexpected response %d %+v for %v", rr.Code, res, c.err)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	WriteError(rr, fieldErr)
+
+	var res ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Errors) != 1 || res.Errors[0].Code != db.ErrorCodeTemplateReferenceMissing {
+		t.Fatalf("expected the code of the field error, got %+v", res.Errors)
+	}
+}
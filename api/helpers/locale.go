@@ -0,0 +1,54 @@
+package helpers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/i18n"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// localeResponseWriter keeps the locale of the response,
+// error messages written by WriteErrorStatus are translated to it.
+type localeResponseWriter struct {
+	http.ResponseWriter
+	locale string
+}
+
+func (w *localeResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WithLocale returns the writer which translates error messages to the locale.
+func WithLocale(w http.ResponseWriter, locale string) http.ResponseWriter {
+	return &localeResponseWriter{ResponseWriter: w, locale: locale}
+}
+
+// Locale returns the locale of the response written by w.
+func Locale(w http.ResponseWriter) string {
+	for {
+		switch v := w.(type) {
+		case *localeResponseWriter:
+			return v.locale
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = v.Unwrap()
+		default:
+			return util.Config.GetLocale()
+		}
+	}
+}
+
+// RequestLocale returns the locale of the authenticated user,
+// the locale of the browser or the server locale.
+func RequestLocale(r *http.Request) string {
+	if user, ok := context.Get(r, "user").(*db.User); ok && user.Locale != "" {
+		return user.Locale
+	}
+
+	if locale := i18n.FromAcceptLanguage(r.Header.Get("Accept-Language")); locale != "" {
+		return locale
+	}
+
+	return util.Config.GetLocale()
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/context"
+	"github.com/gorilla/mux"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/services/housekeeping"
+)
+
+func getHousekeepingJobs(w http.ResponseWriter, r *http.Request) {
+	housekeeper := context.Get(r, "housekeeper").(*housekeeping.Housekeeper)
+	helpers.WriteJSON(w, http.StatusOK, housekeeper.GetStatuses())
+}
+
+func runHousekeepingJob(w http.ResponseWriter, r *http.Request) {
+	housekeeper := context.Get(r, "housekeeper").(*housekeeping.Housekeeper)
+
+	if err := housekeeper.Trigger(mux.Vars(r)["job"]); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
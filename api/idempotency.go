@@ -0,0 +1,175 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotency-Replayed"
+	idempotencyKeyMaxLength   = 255
+)
+
+// idempotencyResponseWriter keeps the response, so it can be returned
+// to the requests repeated with the same key.
+type idempotencyResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *idempotencyResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *idempotencyResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *idempotencyResponseWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.body.Len() <= db.IdempotencyKeyMaxResponse {
+		w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func hashIdempotencyValues(values ...[]byte) string {
+	h := sha256.New()
+	for _, v := range values {
+		h.Write([]byte(strconv.Itoa(len(v))))
+		h.Write([]byte{':'})
+		h.Write(v)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// getIdempotencyScope returns the client which sent the request. Keys of
+// anonymous requests, e.g. integration webhooks, are scoped by the path.
+func getIdempotencyScope(r *http.Request) string {
+	if user, ok := context.Get(r, "user").(*db.User); ok && user != nil {
+		return "user:" + strconv.Itoa(user.ID)
+	}
+	if account, ok := context.Get(r, "serviceAccount").(db.ServiceAccount); ok {
+		return "service_account:" + strconv.Itoa(account.ID)
+	}
+	return "path:" + r.URL.Path
+}
+
+// replayIdempotencyKey writes the stored response of the key.
+func replayIdempotencyKey(w http.ResponseWriter, key db.IdempotencyKey) {
+	w.Header().Set(idempotencyReplayedHeader, "true")
+	w.WriteHeader(key.Status)
+	_, _ = w.Write([]byte(key.Response))
+}
+
+// idempotencyMiddleware processes POST requests with the Idempotency-Key header once.
+// Repeated requests with the same key get the stored response of the first request
+// until the key expires. Keys of failed requests (5xx) are released, so the request can be retried.
+func idempotencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keyValue := r.Header.Get(idempotencyKeyHeader)
+
+		if r.Method != http.MethodPost || keyValue == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(keyValue) > idempotencyKeyMaxLength {
+			helpers.WriteErrorStatus(w, "Idempotency-Key must not be longer than 255 characters", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			helpers.WriteErrorStatus(w, "Can not read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		store := helpers.Store(r)
+		now := time.Now()
+
+		key := db.IdempotencyKey{
+			ID:          hashIdempotencyValues([]byte(getIdempotencyScope(r)), []byte(keyValue)),
+			RequestHash: hashIdempotencyValues([]byte(r.Method), []byte(r.URL.Path), body),
+			Created:     now,
+		}
+
+		err = store.CreateIdempotencyKey(key)
+
+		if errors.Is(err, db.ErrInvalidOperation) {
+			var existing db.IdempotencyKey
+			existing, err = store.GetIdempotencyKey(key.ID)
+
+			if err == nil && existing.IsExpired(now) {
+				// the expired key is not removed by housekeeping yet
+				if err = store.DeleteIdempotencyKey(key.ID); err == nil {
+					err = store.CreateIdempotencyKey(key)
+				}
+			} else if err == nil {
+				switch {
+				case existing.RequestHash != key.RequestHash:
+					helpers.WriteErrorStatus(w, "Idempotency-Key is already used by another request", http.StatusUnprocessableEntity)
+				case existing.Status == 0:
+					helpers.WriteErrorStatus(w, "Request with the same Idempotency-Key is being processed", http.StatusConflict)
+				default:
+					replayIdempotencyKey(w, existing)
+				}
+				return
+			}
+		}
+
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		rw := &idempotencyResponseWriter{ResponseWriter: w}
+		stored := false
+
+		// the key is released if the response is not stored, even if the handler panics
+		defer func() {
+			if stored {
+				return
+			}
+			if deleteErr := store.DeleteIdempotencyKey(key.ID); deleteErr != nil {
+				util.LogError(deleteErr)
+			}
+		}()
+
+		next.ServeHTTP(rw, r)
+
+		if rw.status >= http.StatusInternalServerError || rw.body.Len() > db.IdempotencyKeyMaxResponse {
+			return
+		}
+
+		if rw.status == 0 {
+			rw.status = http.StatusOK
+		}
+
+		if err = store.SetIdempotencyKeyResponse(key.ID, rw.status, rw.body.String()); err != nil {
+			util.LogError(err)
+			return
+		}
+
+		stored = true
+	})
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	store := bolt.CreateTestStore()
+	user := &db.User{ID: 1}
+
+	calls := 0
+	handler := idempotencyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+
+	request := func(key string, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/api/project/1/tasks", strings.NewReader(body))
+		r.Header.Set("Idempotency-Key", key)
+		context.Set(r, "store", store)
+		context.Set(r, "user", user)
+		defer context.Clear(r)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := request("key1", `{"template_id":1}`); w.Code != http.StatusCreated {
+		t.Fatal("first request must be processed", w.Code)
+	}
+
+	w := request("key1", `{"template_id":1}`)
+	if w.Code != http.StatusCreated || w.Body.String() != `{"id":1}` || w.Header().Get("Idempotency-Replayed") != "true" {
+		t.Fatal("repeated request must get the stored response", w.Code, w.Body.String())
+	}
+
+	if calls != 1 {
+		t.Fatal("repeated request must not be processed")
+	}
+
+	if w = request("key1", `{"template_id":2}`); w.Code != http.StatusUnprocessableEntity {
+		t.Fatal("key must not be reused for another request", w.Code)
+	}
+
+	if w = request("key2", `{"template_id":1}`); w.Code != http.StatusCreated || calls != 2 {
+		t.Fatal("request with another key must be processed", w.Code)
+	}
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	impersonationTTL = 30 * time.Minute
+	// impersonatorCookieName keeps the admin session while the admin impersonates the user.
+	impersonatorCookieName = "semaphore_impersonator"
+	impersonatorHeader     = "X-Semaphore-Impersonator"
+)
+
+type impersonation struct {
+	UserID  int       `json:"user_id"`
+	Expires time.Time `json:"expires"`
+}
+
+// checkImpersonationSession validates impersonation data of the session cookie.
+// It returns false if impersonation is expired.
+func checkImpersonationSession(w http.ResponseWriter, r *http.Request, value map[string]interface{}, userID int, sessionID int) bool {
+	impersonatorID, ok := value["impersonator"].(int)
+	if !ok {
+		return true
+	}
+
+	expires, ok := value["expires"].(int64)
+	if !ok || time.Now().After(time.Unix(expires, 0)) {
+		if err := helpers.Store(r).ExpireSession(userID, sessionID); err != nil {
+			log.Error(err)
+		}
+		return false
+	}
+
+	impersonator, err := helpers.Store(r).GetUser(impersonatorID)
+	if err != nil || !impersonator.Admin {
+		return false
+	}
+
+	context.Set(r, "impersonator", &impersonator)
+	w.Header().Set(impersonatorHeader, impersonator.Username)
+
+	return true
+}
+
+func getImpersonator(r *http.Request) *db.User {
+	impersonator, ok := context.GetOk(r, "impersonator")
+	if !ok {
+		return nil
+	}
+	return impersonator.(*db.User)
+}
+
+// impersonateUser replaces the admin session with the session of the user for a limited time.
+// The admin session is restored by stopImpersonation.
+func impersonateUser(w http.ResponseWriter, r *http.Request) {
+	targetUser := context.Get(r, "_user").(db.User)
+	editor := context.Get(r, "user").(*db.User)
+
+	if !editor.Admin || getImpersonator(r) != nil {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if targetUser.ID == editor.ID || targetUser.Admin {
+		helpers.WriteErrorStatus(w, "Only non-admin users can be impersonated", http.StatusBadRequest)
+		return
+	}
+
+	adminCookie, err := r.Cookie("semaphore")
+	if err != nil {
+		helpers.WriteErrorStatus(w, "Impersonation requires session authentication", http.StatusBadRequest)
+		return
+	}
+
+	session, err := helpers.Store(r).CreateSession(db.Session{
+		UserID:     targetUser.ID,
+		Created:    time.Now(),
+		LastActive: time.Now(),
+		IP:         r.Header.Get("X-Real-IP"),
+		UserAgent:  r.Header.Get("user-agent"),
+	})
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	res := impersonation{
+		UserID:  targetUser.ID,
+		Expires: time.Now().Add(impersonationTTL),
+	}
+
+	encoded, err := util.Cookie.Encode("semaphore", map[string]interface{}{
+		"user":         targetUser.ID,
+		"session":      session.ID,
+		"impersonator": editor.ID,
+		"expires":      res.Expires.Unix(),
+	})
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:  impersonatorCookieName,
+		Value: adminCookie.Value,
+		Path:  "/",
+	})
+
+	http.SetCookie(w, &http.Cookie{
+		Name:  "semaphore",
+		Value: encoded,
+		Path:  "/",
+	})
+
+	log.Warn("User " + editor.Username + " started impersonation of user " + targetUser.Username)
+
+	helpers.EventLog(r, helpers.EventLogCreate, helpers.EventLogItem{
+		UserID:      editor.ID,
+		ObjectType:  db.EventUser,
+		ObjectID:    targetUser.ID,
+		Description: fmt.Sprintf("User %s impersonated by %s until %s", targetUser.Username, editor.Username, res.Expires.Format(time.RFC3339)),
+	})
+
+	helpers.WriteJSON(w, http.StatusCreated, res)
+}
+
+// stopImpersonation expires the impersonation session and restores the admin session.
+func stopImpersonation(w http.ResponseWriter, r *http.Request) {
+	user := context.Get(r, "user").(*db.User)
+	impersonator := getImpersonator(r)
+
+	if impersonator == nil {
+		helpers.WriteErrorStatus(w, "Impersonation is not active", http.StatusBadRequest)
+		return
+	}
+
+	if cookie, err := r.Cookie("semaphore"); err == nil {
+		value := make(map[string]interface{})
+		if util.Cookie.Decode("semaphore", cookie.Value, &value) == nil {
+			if sessionID, ok := value["session"].(int); ok {
+				if err = helpers.Store(r).ExpireSession(user.ID, sessionID); err != nil {
+					log.Error(err)
+				}
+			}
+		}
+	}
+
+	// admin session is restored if it is available, otherwise the user is logged out
+	restored := http.Cookie{
+		Name:    "semaphore",
+		Value:   "",
+		Expires: time.Now().Add(24 * 7 * time.Hour * -1),
+		Path:    "/",
+	}
+
+	if adminCookie, err := r.Cookie(impersonatorCookieName); err == nil {
+		restored.Value = adminCookie.Value
+		restored.Expires = time.Time{}
+	}
+
+	http.SetCookie(w, &restored)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:    impersonatorCookieName,
+		Value:   "",
+		Expires: time.Now().Add(24 * 7 * time.Hour * -1),
+		Path:    "/",
+	})
+
+	log.Warn("User " + impersonator.Username + " stopped impersonation of user " + user.Username)
+
+	helpers.EventLog(r, helpers.EventLogDelete, helpers.EventLogItem{
+		UserID:      impersonator.ID,
+		ObjectType:  db.EventUser,
+		ObjectID:    user.ID,
+		Description: fmt.Sprintf("Impersonation of user %s by %s stopped", user.Username, impersonator.Username),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
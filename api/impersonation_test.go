@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+)
+
+func TestCheckImpersonationSession(t *testing.T) {
+	store := bolt.CreateTestStore()
+
+	admin, err := store.CreateUserWithoutPassword(db.User{Username: "admin", Name: "Admin", Email: "admin@example.com", Admin: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest("GET", "/api/user", nil)
+	context.Set(r, "store", store)
+	defer context.Clear(r)
+
+	w := httptest.NewRecorder()
+
+	if !checkImpersonationSession(w, r, map[string]interface{}{}, 2, 1) {
+		t.Fatal("regular session must be valid")
+	}
+
+	if getImpersonator(r) != nil {
+		t.Fatal("regular session must not have impersonator")
+	}
+
+	value := map[string]interface{}{
+		"impersonator": admin.ID,
+		"expires":      time.Now().Add(time.Minute).Unix(),
+	}
+
+	if !checkImpersonationSession(w, r, value, 2, 1) {
+		t.Fatal("impersonation session must be valid")
+	}
+
+	if getImpersonator(r) == nil || w.Header().Get(impersonatorHeader) != "admin" {
+		t.Fatal("impersonation must be reported")
+	}
+
+	value["expires"] = time.Now().Add(-time.Minute).Unix()
+
+	if checkImpersonationSession(httptest.NewRecorder(), r, value, 2, 1) {
+		t.Fatal("expired impersonation session must be rejected")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
@@ -71,13 +72,20 @@ func ReceiveIntegration(w http.ResponseWriter, r *http.Request) {
 
 	log.Info(fmt.Sprintf("%d integrations found for alias %s", len(integrations), integrationAlias))
 
+	payload, err := io.ReadAll(r.Body)
+
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
 	projects := make(map[int]db.Project)
 
 	for _, integration := range integrations {
 
 		project, ok := projects[integration.ProjectID]
 		if !ok {
-			project, err = helpers.Store(r).GetProject(integrations[0].ProjectID)
+			project, err = helpers.Store(r).GetProject(integration.ProjectID)
 			if err != nil {
 				log.Error(err)
 				return
@@ -85,8 +93,9 @@ func ReceiveIntegration(w http.ResponseWriter, r *http.Request) {
 			projects[integration.ProjectID] = project
 		}
 
-		if integration.ProjectID != project.ID {
-			panic("")
+		if project.Archived {
+			log.Info(fmt.Sprintf("Integration %d is skipped, project %d is archived", integration.ID, project.ID))
+			continue
 		}
 
 		err = db.FillIntegration(helpers.Store(r), &integration)
@@ -95,96 +104,177 @@ func ReceiveIntegration(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		var payload []byte
-
-		payload, err = io.ReadAll(r.Body)
-
+		err = authorizeIntegration(integration, r.Header, payload)
 		if err != nil {
 			log.Error(err)
+			recordIntegrationDelivery(r, integration, r.Header, payload, db.IntegrationDeliveryUnauthorized, err.Error(), nil)
 			continue
 		}
 
-		switch integration.AuthMethod {
-		case db.IntegrationAuthGitHub:
-			ok := isValidHmacPayload(
-				integration.AuthSecret.LoginPassword.Password,
-				r.Header.Get("X-Hub-Signature-256"),
-				payload,
-				"sha256=")
-
-			if !ok {
-				log.Error("Invalid HMAC signature")
-				continue
-			}
-		case db.IntegrationAuthHmac:
-			ok := isValidHmacPayload(
-				integration.AuthSecret.LoginPassword.Password,
-				r.Header.Get(integration.AuthHeader),
-				payload,
-				"")
-
-			if !ok {
-				log.Error("Invalid HMAC signature")
-				continue
-			}
-		case db.IntegrationAuthToken:
-			if integration.AuthSecret.LoginPassword.Password != r.Header.Get(integration.AuthHeader) {
-				log.Error("Invalid verification token")
-				continue
-			}
-		case db.IntegrationAuthNone:
-			// Do nothing
-		default:
-			log.Error("Unknown verification method: " + integration.AuthMethod)
-			continue
+		deliver(r, integration, project, r.Header, payload)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorizeIntegration checks the request signature or token required by the integration.
+// The secret replaced by the rotation is accepted until its grace period ends.
+func authorizeIntegration(integration db.Integration, header http.Header, payload []byte) error {
+	err := checkIntegrationSecret(integration, integration.AuthSecret.LoginPassword.Password, header, payload)
+
+	if err != nil && integration.PreviousAuthSecret != nil {
+		if checkIntegrationSecret(integration, integration.PreviousAuthSecret.LoginPassword.Password, header, payload) == nil {
+			log.Warn(fmt.Sprintf("Integration %d is authorized by the previous secret which expires at %s",
+				integration.ID, integration.PreviousAuthSecretExpires.Format(time.RFC3339)))
+			return nil
 		}
+	}
 
-		var matchers []db.IntegrationMatcher
-		matchers, err = helpers.Store(r).GetIntegrationMatchers(integration.ProjectID, db.RetrieveQueryParams{}, integration.ID)
-		if err != nil {
-			log.Error(err)
+	return err
+}
+
+func checkIntegrationSecret(integration db.Integration, secret string, header http.Header, payload []byte) error {
+	switch integration.AuthMethod {
+	case db.IntegrationAuthGitHub:
+		ok := isValidHmacPayload(
+			secret,
+			header.Get("X-Hub-Signature-256"),
+			payload,
+			"sha256=")
+
+		if !ok {
+			return fmt.Errorf("invalid HMAC signature")
+		}
+	case db.IntegrationAuthHmac:
+		ok := isValidHmacPayload(
+			secret,
+			header.Get(integration.AuthHeader),
+			payload,
+			"")
+
+		if !ok {
+			return fmt.Errorf("invalid HMAC signature")
+		}
+	case db.IntegrationAuthToken:
+		if secret != header.Get(integration.AuthHeader) {
+			return fmt.Errorf("invalid verification token")
 		}
+	case db.IntegrationAuthNone:
+		// Do nothing
+	default:
+		return fmt.Errorf("unknown verification method: %s", integration.AuthMethod)
+	}
 
-		var matched = false
+	return nil
+}
 
-		for _, matcher := range matchers {
-			if Match(matcher, r.Header, payload) {
-				matched = true
-				continue
-			} else {
-				matched = false
-				break
-			}
+// deliver runs the integration if the payload matches all its matchers
+// and stores the outcome as a delivery.
+func deliver(r *http.Request, integration db.Integration, project db.Project, header http.Header, payload []byte) (delivery db.IntegrationDelivery, results []IntegrationMatcherResult) {
+	matchers, err := helpers.Store(r).GetIntegrationMatchers(integration.ProjectID, db.RetrieveQueryParams{}, integration.ID)
+	if err != nil {
+		log.Error(err)
+		return recordIntegrationDelivery(r, integration, header, payload, db.IntegrationDeliveryFailed, err.Error(), nil), nil
+	}
+
+	results = EvaluateMatchers(matchers, header, payload)
+
+	message := notMatchedMessage(results)
+	if message != "" {
+		return recordIntegrationDelivery(r, integration, header, payload, db.IntegrationDeliveryNotMatched, message, nil), results
+	}
+
+	task, err := RunIntegration(integration, project, r, header, payload)
+	if err != nil {
+		return recordIntegrationDelivery(r, integration, header, payload, db.IntegrationDeliveryFailed, err.Error(), nil), results
+	}
+
+	return recordIntegrationDelivery(r, integration, header, payload, db.IntegrationDeliveryTriggered, "", &task.ID), results
+}
+
+// IntegrationMatcherResult describes how the matcher was evaluated against the payload.
+type IntegrationMatcherResult struct {
+	Matcher db.IntegrationMatcher `json:"matcher"`
+	Value   string                `json:"value"`
+	Matched bool                  `json:"matched"`
+}
+
+func EvaluateMatchers(matchers []db.IntegrationMatcher, header http.Header, bodyBytes []byte) []IntegrationMatcherResult {
+	results := make([]IntegrationMatcherResult, 0, len(matchers))
+
+	for _, matcher := range matchers {
+		value, ok := matcherValue(matcher, header, bodyBytes)
+
+		result := IntegrationMatcherResult{
+			Matcher: matcher,
+			Matched: ok && MatchCompare(value, matcher.Method, matcher.Value),
 		}
 
-		if !matched {
-			continue
+		if ok && value != nil {
+			result.Value = formatMatcherValue(value)
 		}
 
-		RunIntegration(integration, project, r, payload)
+		results = append(results, result)
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	return results
+}
+
+// notMatchedMessage returns the reason why the payload was not matched
+// or empty string if all matchers are matched.
+func notMatchedMessage(results []IntegrationMatcherResult) string {
+	if len(results) == 0 {
+		return "integration has no matchers"
+	}
+
+	for _, res := range results {
+		if !res.Matched {
+			return fmt.Sprintf("matcher %s: %s %s %s %s, actual value is \"%s\"",
+				res.Matcher.Name,
+				res.Matcher.MatchType,
+				res.Matcher.Key,
+				res.Matcher.Method,
+				res.Matcher.Value,
+				res.Value)
+		}
+	}
+
+	return ""
 }
 
 func Match(matcher db.IntegrationMatcher, header http.Header, bodyBytes []byte) (matched bool) {
+	value, ok := matcherValue(matcher, header, bodyBytes)
+	if !ok {
+		return false
+	}
+
+	return MatchCompare(value, matcher.Method, matcher.Value)
+}
+
+func matcherValue(matcher db.IntegrationMatcher, header http.Header, bodyBytes []byte) (interface{}, bool) {
 
 	switch matcher.MatchType {
 	case db.IntegrationMatchHeader:
-		return MatchCompare(header.Get(matcher.Key), matcher.Method, matcher.Value)
+		return header.Get(matcher.Key), true
 	case db.IntegrationMatchBody:
 		var body = string(bodyBytes)
 		switch matcher.BodyDataType {
 		case db.IntegrationBodyDataJSON:
-			value := gojsonq.New().JSONString(body).Find(matcher.Key)
-
-			return MatchCompare(value, matcher.Method, matcher.Value)
+			return gojsonq.New().JSONString(body).Find(matcher.Key), true
 		case db.IntegrationBodyDataString:
-			return MatchCompare(body, matcher.Method, matcher.Value)
+			return body, true
 		}
 	}
 
-	return false
+	return nil, false
+}
+
+func formatMatcherValue(value interface{}) string {
+	if intValue, ok := convertFloatToIntIfPossible(value); ok {
+		value = intValue
+	}
+
+	return fmt.Sprintf("%v", value)
 }
 
 func convertFloatToIntIfPossible(v interface{}) (int64, bool) {
@@ -227,7 +317,7 @@ func MatchCompare(value interface{}, method db.IntegrationMatchMethodType, expec
 	}
 }
 
-func RunIntegration(integration db.Integration, project db.Project, r *http.Request, payload []byte) {
+func RunIntegration(integration db.Integration, project db.Project, r *http.Request, header http.Header, payload []byte) (task db.Task, err error) {
 
 	log.Info(fmt.Sprintf("Running integration %d", integration.ID))
 
@@ -241,7 +331,7 @@ func RunIntegration(integration db.Integration, project db.Project, r *http.Requ
 
 	extractValues = append(extractValues, extractValuesForExtractor...)
 
-	var extractedResults = Extract(extractValues, r, payload)
+	var extractedResults = Extract(extractValues, header, payload)
 
 	environmentJSONBytes, err := json.Marshal(extractedResults)
 	if err != nil {
@@ -257,20 +347,22 @@ func RunIntegration(integration db.Integration, project db.Project, r *http.Requ
 		IntegrationID: &integration.ID,
 	}
 
-	_, err = helpers.TaskPool(r).AddTask(taskDefinition, nil, integration.ProjectID)
+	task, err = helpers.TaskPool(r).AddTask(taskDefinition, nil, integration.ProjectID)
 	if err != nil {
 		log.Error(err)
 		return
 	}
+
+	return
 }
 
-func Extract(extractValues []db.IntegrationExtractValue, r *http.Request, payload []byte) (result map[string]string) {
+func Extract(extractValues []db.IntegrationExtractValue, header http.Header, payload []byte) (result map[string]string) {
 	result = make(map[string]string)
 
 	for _, extractValue := range extractValues {
 		switch extractValue.ValueSource {
 		case db.IntegrationExtractHeaderValue:
-			result[extractValue.Variable] = r.Header.Get(extractValue.Key)
+			result[extractValue.Variable] = header.Get(extractValue.Key)
 		case db.IntegrationExtractBodyValue:
 			switch extractValue.BodyDataType {
 			case db.IntegrationBodyDataJSON:
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	log "github.com/sirupsen/logrus"
+)
+
+const redactedValue = "********"
+
+// sensitiveHeaders are never stored in integration deliveries.
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"X-Hub-Signature",
+	"X-Hub-Signature-256",
+	"X-Gitlab-Token",
+}
+
+// sensitivePayloadKeys are substrings of JSON keys which values are redacted.
+var sensitivePayloadKeys = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"private_key",
+	"api_key",
+	"apikey",
+}
+
+func redactHeaders(integration db.Integration, header http.Header) http.Header {
+	res := header.Clone()
+
+	for _, name := range sensitiveHeaders {
+		if res.Get(name) != "" {
+			res.Set(name, redactedValue)
+		}
+	}
+
+	if integration.AuthHeader != "" && res.Get(integration.AuthHeader) != "" {
+		res.Set(integration.AuthHeader, redactedValue)
+	}
+
+	return res
+}
+
+func isSensitivePayloadKey(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, k := range sensitivePayloadKeys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func redactPayloadValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if isSensitivePayloadKey(key) {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactPayloadValue(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactPayloadValue(item)
+		}
+	}
+
+	return value
+}
+
+// redactPayload masks values of sensitive keys if the payload is JSON and
+// truncates the payload to db.IntegrationDeliveryMaxPayload bytes.
+func redactPayload(payload []byte) string {
+	var value interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&value); err == nil {
+		if redacted, err := json.Marshal(redactPayloadValue(value)); err == nil {
+			payload = redacted
+		}
+	}
+
+	if len(payload) > db.IntegrationDeliveryMaxPayload {
+		payload = payload[:db.IntegrationDeliveryMaxPayload]
+	}
+
+	return string(payload)
+}
+
+func recordIntegrationDelivery(
+	r *http.Request,
+	integration db.Integration,
+	header http.Header,
+	payload []byte,
+	status db.IntegrationDeliveryStatus,
+	message string,
+	taskID *int,
+) db.IntegrationDelivery {
+	headers, err := json.Marshal(redactHeaders(integration, header))
+	if err != nil {
+		log.Error(err)
+	}
+
+	delivery, err := helpers.Store(r).CreateIntegrationDelivery(db.IntegrationDelivery{
+		ProjectID:     integration.ProjectID,
+		IntegrationID: integration.ID,
+		Created:       time.Now().UTC(),
+		Headers:       string(headers),
+		Payload:       redactPayload(payload),
+		Status:        status,
+		Message:       message,
+		TaskID:        taskID,
+	}, db.IntegrationDeliveryMaxCount)
+
+	if err != nil {
+		log.Error(err)
+	}
+
+	return delivery
+}
+
+type integrationDeliveryDetails struct {
+	db.IntegrationDelivery
+	Matchers []IntegrationMatcherResult `json:"matchers"`
+}
+
+func deliveryHeader(delivery db.IntegrationDelivery) http.Header {
+	header := make(http.Header)
+
+	if delivery.Headers != "" {
+		if err := json.Unmarshal([]byte(delivery.Headers), &header); err != nil {
+			log.Error(err)
+		}
+	}
+
+	return header
+}
+
+func GetIntegrationDeliveries(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	integration := context.Get(r, "integration").(db.Integration)
+
+	deliveries, err := helpers.Store(r).GetIntegrationDeliveries(project.ID, integration.ID, helpers.QueryParams(r.URL))
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, deliveries)
+}
+
+// GetIntegrationDelivery returns the delivery with the result of evaluation of
+// every current matcher of the integration against the stored payload.
+func GetIntegrationDelivery(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	integration := context.Get(r, "integration").(db.Integration)
+
+	deliveryID, err := helpers.GetIntParam("delivery_id", w, r)
+	if err != nil {
+		return
+	}
+
+	delivery, err := helpers.Store(r).GetIntegrationDelivery(project.ID, integration.ID, deliveryID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	matchers, err := helpers.Store(r).GetIntegrationMatchers(project.ID, db.RetrieveQueryParams{}, integration.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, integrationDeliveryDetails{
+		IntegrationDelivery: delivery,
+		Matchers:            EvaluateMatchers(matchers, deliveryHeader(delivery), []byte(delivery.Payload)),
+	})
+}
+
+// ReplayIntegrationDelivery delivers the stored payload to the integration again.
+// Authentication is skipped because secrets are redacted from stored deliveries.
+func ReplayIntegrationDelivery(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	integration := context.Get(r, "integration").(db.Integration)
+
+	deliveryID, err := helpers.GetIntParam("delivery_id", w, r)
+	if err != nil {
+		return
+	}
+
+	delivery, err := helpers.Store(r).GetIntegrationDelivery(project.ID, integration.ID, deliveryID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	newDelivery, results := deliver(r, integration, project, deliveryHeader(delivery), []byte(delivery.Payload))
+
+	helpers.EventLog(r, helpers.EventLogCreate, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   project.ID,
+		ObjectType:  db.EventIntegration,
+		ObjectID:    integration.ID,
+		Description: fmt.Sprintf("Integration %s delivery %d replayed", integration.Name, delivery.ID),
+	})
+
+	helpers.WriteJSON(w, http.StatusOK, integrationDeliveryDetails{
+		IntegrationDelivery: newDelivery,
+		Matchers:            results,
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/semaphoreui/semaphore/db"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestIntegrationMatch(t *testing.T) {
@@ -24,3 +25,90 @@ func TestIntegrationMatch(t *testing.T) {
 		t.Fatal()
 	}
 }
+
+func TestEvaluateMatchers(t *testing.T) {
+	body := []byte("{\"action\": \"push\", \"ref\": \"refs/heads/dev\"}")
+
+	results := EvaluateMatchers([]db.IntegrationMatcher{
+		{
+			Name:         "Action",
+			MatchType:    db.IntegrationMatchBody,
+			Method:       db.IntegrationMatchMethodEquals,
+			BodyDataType: db.IntegrationBodyDataJSON,
+			Key:          "action",
+			Value:        "push",
+		},
+		{
+			Name:         "Branch",
+			MatchType:    db.IntegrationMatchBody,
+			Method:       db.IntegrationMatchMethodEquals,
+			BodyDataType: db.IntegrationBodyDataJSON,
+			Key:          "ref",
+			Value:        "refs/heads/main",
+		},
+	}, make(http.Header), body)
+
+	if !results[0].Matched || results[1].Matched {
+		t.Fatal("unexpected match results")
+	}
+
+	if results[1].Value != "refs/heads/dev" {
+		t.Fatal("actual value expected, got", results[1].Value)
+	}
+
+	if notMatchedMessage(results) == "" {
+		t.Fatal("message expected for not matched payload")
+	}
+}
+
+func TestRedactIntegrationDelivery(t *testing.T) {
+	header := make(http.Header)
+	header.Set("Authorization", "Bearer secret")
+	header.Set("X-Token", "secret")
+	header.Set("X-Event", "push")
+
+	redacted := redactHeaders(db.Integration{AuthHeader: "X-Token"}, header)
+
+	if redacted.Get("Authorization") != redactedValue || redacted.Get("X-Token") != redactedValue {
+		t.Fatal("sensitive headers must be redacted")
+	}
+
+	if redacted.Get("X-Event") != "push" || header.Get("X-Token") != "secret" {
+		t.Fatal("other headers and original header must be preserved")
+	}
+
+	payload := redactPayload([]byte("{\"id\": 4856239453, \"user\": {\"api_token\": \"abc\"}}"))
+
+	if payload != "{\"id\":4856239453,\"user\":{\"api_token\":\"********\"}}" {
+		t.Fatal("unexpected payload", payload)
+	}
+}
+
+func TestAuthorizeIntegrationPreviousSecret(t *testing.T) {
+	expires := time.Now().Add(time.Hour)
+	previousID := 1
+
+	integration := db.Integration{
+		AuthMethod:                db.IntegrationAuthToken,
+		AuthHeader:                "X-Token",
+		AuthSecret:                db.AccessKey{LoginPassword: db.LoginPassword{Password: "new"}},
+		PreviousAuthSecretID:      &previousID,
+		PreviousAuthSecretExpires: &expires,
+		PreviousAuthSecret:        &db.AccessKey{LoginPassword: db.LoginPassword{Password: "old"}},
+	}
+
+	header := make(http.Header)
+
+	for token, valid := range map[string]bool{"new": true, "old": true, "other": false} {
+		header.Set("X-Token", token)
+		if err := authorizeIntegration(integration, header, nil); (err == nil) != valid {
+			t.Errorf("unexpected authorization result for token %s: %v", token, err)
+		}
+	}
+
+	integration.PreviousAuthSecret = nil
+	header.Set("X-Token", "old")
+	if authorizeIntegration(integration, header, nil) == nil {
+		t.Error("previous secret must be rejected after the grace period")
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"errors"
 	"context"
 	"crypto/tls"
 	"encoding/base64"
@@ -16,6 +17,7 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/semaphoreui/semaphore/api/auth"
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/pkg/random"
@@ -214,44 +216,70 @@ func loginByLDAP(store db.Store, ldapUser db.User) (user db.User, err error) {
 	return
 }
 
-type loginMetadataOidcProvider struct {
-	ID    string `json:"id"`
-	Name  string `json:"name"`
-	Color string `json:"color"`
-	Icon  string `json:"icon"`
+type loginMetadataOption struct {
+	auth.LoginOption
+	Provider string `json:"provider"`
 }
 
 type loginMetadata struct {
-	OidcProviders     []loginMetadataOidcProvider `json:"oidc_providers"`
-	LoginWithPassword bool                        `json:"login_with_password"`
+	// OidcProviders are login options of the OIDC provider, kept for compatibility.
+	OidcProviders     []auth.LoginOption    `json:"oidc_providers"`
+	LoginOptions      []loginMetadataOption `json:"login_options"`
+	LoginWithPassword bool                  `json:"login_with_password"`
 }
 
-// nolint: gocyclo
-func login(w http.ResponseWriter, r *http.Request) {
-	if r.Method == "GET" {
-		config := &loginMetadata{
-			OidcProviders:     make([]loginMetadataOidcProvider, len(util.Config.OidcProviders)),
-			LoginWithPassword: !util.Config.PasswordLoginDisable,
+func getLoginMetadata() loginMetadata {
+	config := loginMetadata{
+		OidcProviders:     make([]auth.LoginOption, 0),
+		LoginOptions:      make([]loginMetadataOption, 0),
+		LoginWithPassword: !util.Config.PasswordLoginDisable,
+	}
+
+	for _, p := range auth.RedirectProviders() {
+		options := p.LoginOptions()
+
+		sort.SliceStable(options, func(i, j int) bool {
+			return options[i].Order < options[j].Order
+		})
+
+		if p.ID() == oidcProviderID {
+			config.OidcProviders = options
 		}
-		i := 0
-
-		for k, v := range util.Config.OidcProviders {
-			config.OidcProviders[i] = loginMetadataOidcProvider{
-				ID:    k,
-				Name:  v.DisplayName,
-				Color: v.Color,
-				Icon:  v.Icon,
-			}
-			i++
+
+		for _, option := range options {
+			config.LoginOptions = append(config.LoginOptions, loginMetadataOption{
+				LoginOption: option,
+				Provider:    p.ID(),
+			})
 		}
+	}
 
-		sort.Slice(config.OidcProviders, func(i, j int) bool {
-			a := util.Config.OidcProviders[config.OidcProviders[i].ID]
-			b := util.Config.OidcProviders[config.OidcProviders[j].ID]
-			return a.Order < b.Order
-		})
+	return config
+}
+
+// loginByProviders tries the password providers in the order of registration.
+// It returns db.ErrNotFound if no provider knows the login and the password.
+func loginByProviders(store db.Store, login string, password string) (user db.User, err error) {
+	for _, p := range auth.PasswordProviders() {
+		var found *db.User
+		found, err = p.Login(store, login, password)
+		if err != nil {
+			return
+		}
+
+		if found != nil {
+			user = *found
+			return
+		}
+	}
 
-		helpers.WriteJSON(w, http.StatusOK, config)
+	err = db.ErrNotFound
+	return
+}
+
+func login(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" {
+		helpers.WriteJSON(w, http.StatusOK, getLoginMetadata())
 		return
 	}
 
@@ -263,51 +291,19 @@ func login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	/*
-		logic:
-		- fetch user from ldap if enabled
-		- fetch user from database by username/email
-		- create user in database if doesn't exist & ldap record found
-		- check password if non-ldap user
-		- create session & send cookie
-	*/
-
 	login.Auth = strings.ToLower(login.Auth)
 
-	var err error
-
-	var ldapUser *db.User
-
-	if util.Config.LdapEnable {
-		ldapUser, err = tryFindLDAPUser(login.Auth, login.Password)
-		if err != nil {
-			log.Warn(err.Error())
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-	}
-
-	var user db.User
-
-	if ldapUser == nil {
-		user, err = loginByPassword(helpers.Store(r), login.Auth, login.Password)
-	} else {
-		user, err = loginByLDAP(helpers.Store(r), *ldapUser)
-	}
+	user, err := loginByProviders(helpers.Store(r), login.Auth, login.Password)
 
 	if err != nil {
-		if err == db.ErrNotFound {
+		if errors.Is(err, db.ErrNotFound) {
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
 
-		switch err.(type) {
-		case *db.ValidationError:
-			// TODO: Return more informative error code.
-		}
-
 		log.Error(err.Error())
 		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 
 	createSession(w, r, user)
@@ -409,27 +405,71 @@ func getOidcProvider(id string, ctx context.Context, redirectPath string) (*oidc
 	return oidcProvider, &oauthConfig, nil
 }
 
-func oidcLogin(w http.ResponseWriter, r *http.Request) {
-	pid := mux.Vars(r)["provider"]
-	ctx := context.Background()
+// getRedirectProvider returns the enabled provider with external login pages by the route variable.
+func getRedirectProvider(r *http.Request) (auth.RedirectProvider, error) {
+	id := mux.Vars(r)["provider"]
+
+	p, ok := auth.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("no such auth provider: %s", id)
+	}
+
+	redirectProvider, ok := p.(auth.RedirectProvider)
+	if !ok {
+		return nil, fmt.Errorf("auth provider %s has no login page", id)
+	}
 
-	redirectPath := ""
+	return redirectProvider, nil
+}
 
-	if r.URL.Query()["redirect"] != nil {
+// externalLogin redirects to the login page of the auth provider.
+func externalLogin(w http.ResponseWriter, r *http.Request) {
+	loginURL, _ := url.JoinPath(util.Config.WebHost, "auth/login")
+
+	p, err := getRedirectProvider(r)
+	if err == nil {
 		// TODO: validate path
-		redirectPath = r.URL.Query()["redirect"][0]
+		err = p.StartLogin(w, r, mux.Vars(r)["option"], r.URL.Query().Get("redirect"))
 	}
 
-	_, oauth, err := getOidcProvider(pid, ctx, redirectPath)
 	if err != nil {
 		log.Error(err.Error())
-		loginURL, _ := url.JoinPath(util.Config.WebHost, "auth/login")
+		http.Redirect(w, r, loginURL, http.StatusTemporaryRedirect)
+	}
+}
+
+// externalRedirect completes the login on the page of the auth provider.
+func externalRedirect(w http.ResponseWriter, r *http.Request) {
+	loginURL, _ := url.JoinPath(util.Config.WebHost, "auth/login")
+
+	p, err := getRedirectProvider(r)
+	if err != nil {
+		log.Error(err.Error())
 		http.Redirect(w, r, loginURL, http.StatusTemporaryRedirect)
 		return
 	}
-	state := generateStateOauthCookie(w)
-	u := oauth.AuthCodeURL(state)
-	http.Redirect(w, r, u, http.StatusTemporaryRedirect)
+
+	user, err := p.CompleteLogin(helpers.Store(r), r, mux.Vars(r)["option"])
+	if err == nil && user == nil {
+		err = fmt.Errorf("auth provider %s returned no user", p.ID())
+	}
+
+	if err != nil {
+		log.Error(err.Error())
+		http.Redirect(w, r, loginURL, http.StatusTemporaryRedirect)
+		return
+	}
+
+	createSession(w, r, *user)
+
+	redirectPath, err := url.JoinPath(util.Config.WebHost, mux.Vars(r)["redirect_path"])
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, loginURL, http.StatusTemporaryRedirect)
+		return
+	}
+
+	http.Redirect(w, r, redirectPath, http.StatusTemporaryRedirect)
 }
 
 func generateStateOauthCookie(w http.ResponseWriter) string {
@@ -567,121 +607,3 @@ func getSecretFromFile(source string) (string, error) {
 
 	return string(content), nil
 }
-
-func oidcRedirect(w http.ResponseWriter, r *http.Request) {
-	pid := mux.Vars(r)["provider"]
-	oauthState, err := r.Cookie("oauthstate")
-	loginURL, _ := url.JoinPath(util.Config.WebHost, "auth/login")
-
-	if err != nil {
-		log.Error(err.Error())
-		http.Redirect(w, r, loginURL, http.StatusTemporaryRedirect)
-		return
-	}
-
-	if r.FormValue("state") != oauthState.Value {
-		http.Redirect(w, r, loginURL, http.StatusTemporaryRedirect)
-		return
-	}
-
-	ctx := context.Background()
-
-	_oidc, oauth, err := getOidcProvider(pid, ctx, r.URL.Path)
-	if err != nil {
-		log.Error(err.Error())
-		http.Redirect(w, r, loginURL, http.StatusTemporaryRedirect)
-		return
-	}
-
-	provider, ok := util.Config.OidcProviders[pid]
-	if !ok {
-		log.Error(fmt.Errorf("no such provider: %s", pid))
-		http.Redirect(w, r, loginURL, http.StatusTemporaryRedirect)
-		return
-	}
-
-	verifier := _oidc.Verifier(&oidc.Config{ClientID: oauth.ClientID})
-
-	code := r.URL.Query().Get("code")
-
-	oauth2Token, err := oauth.Exchange(ctx, code)
-	if err != nil {
-		log.Error(err.Error())
-		http.Redirect(w, r, loginURL, http.StatusTemporaryRedirect)
-		return
-	}
-
-	var claims claimResult
-
-	// Extract the ID Token from OAuth2 token.
-	rawIDToken, ok := oauth2Token.Extra("id_token").(string)
-
-	if ok && rawIDToken != "" {
-		var idToken *oidc.IDToken
-		// Parse and verify ID Token payload.
-		idToken, err = verifier.Verify(ctx, rawIDToken)
-
-		if err == nil {
-			claims, err = claimOidcToken(idToken, provider)
-		}
-	} else {
-		var userInfo *oidc.UserInfo
-		userInfo, err = _oidc.UserInfo(ctx, oauth2.StaticTokenSource(oauth2Token))
-
-		if err == nil {
-
-			if userInfo.Email == "" {
-				claims, err = claimOidcUserInfo(userInfo, provider)
-			} else {
-				claims.email = userInfo.Email
-				claims.name = userInfo.Profile
-			}
-		}
-
-		claims.username = getRandomUsername()
-		if userInfo.Profile == "" {
-			claims.name = getRandomProfileName()
-		}
-	}
-
-	if err != nil {
-		log.Error(err.Error())
-		http.Redirect(w, r, loginURL, http.StatusTemporaryRedirect)
-		return
-	}
-
-	user, err := helpers.Store(r).GetUserByLoginOrEmail("", claims.email) // ignore username because it creates a lot of problems
-	if err != nil {
-		user = db.User{
-			Username: claims.username,
-			Name:     claims.name,
-			Email:    claims.email,
-			External: true,
-		}
-		user, err = helpers.Store(r).CreateUserWithoutPassword(user)
-		if err != nil {
-			log.Error(err.Error())
-			http.Redirect(w, r, loginURL, http.StatusTemporaryRedirect)
-			return
-		}
-	}
-
-	if !user.External {
-		log.Error(fmt.Errorf("OIDC user '%s' conflicts with local user", user.Username))
-		http.Redirect(w, r, loginURL, http.StatusTemporaryRedirect)
-		return
-	}
-
-	createSession(w, r, user)
-
-	redirectPath := mux.Vars(r)["redirect_path"]
-
-	redirectPath, err = url.JoinPath(util.Config.WebHost, redirectPath)
-	if err != nil {
-		log.Error(err)
-		http.Redirect(w, r, loginURL, http.StatusTemporaryRedirect)
-		return
-	}
-
-	http.Redirect(w, r, redirectPath, http.StatusTemporaryRedirect)
-}
@@ -0,0 +1,262 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/semaphoreui/semaphore/api/auth"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/mtls"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/oauth2"
+)
+
+const oidcProviderID = "oidc"
+
+func init() {
+	// LDAP is tried before local users, local users have no LDAP entries
+	auth.Register(ldapAuthProvider{})
+	auth.Register(localAuthProvider{})
+	auth.Register(oidcAuthProvider{})
+	auth.Register(certAuthProvider{})
+}
+
+// localAuthProvider authenticates users stored in the database with a password.
+type localAuthProvider struct{}
+
+func (localAuthProvider) ID() string {
+	return "local"
+}
+
+func (localAuthProvider) Enabled() bool {
+	return true
+}
+
+func (localAuthProvider) Login(store db.Store, login string, password string) (*db.User, error) {
+	user, err := loginByPassword(store, login, password)
+
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (localAuthProvider) VerifyPassword(user *db.User, password string) bool {
+	return !user.External && bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) == nil
+}
+
+// ldapAuthProvider authenticates users by the LDAP server,
+// users are created on the first login.
+type ldapAuthProvider struct{}
+
+func (ldapAuthProvider) ID() string {
+	return "ldap"
+}
+
+func (ldapAuthProvider) Enabled() bool {
+	return util.Config.LdapEnable
+}
+
+func (ldapAuthProvider) Login(store db.Store, login string, password string) (*db.User, error) {
+	ldapUser, err := tryFindLDAPUser(login, password)
+	if err != nil {
+		log.Warn(err.Error())
+		return nil, err
+	}
+
+	if ldapUser == nil {
+		return nil, nil
+	}
+
+	user, err := loginByLDAP(store, *ldapUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (ldapAuthProvider) VerifyPassword(user *db.User, password string) bool {
+	if !user.External {
+		return false
+	}
+
+	ldapUser, err := tryFindLDAPUser(user.Username, password)
+	if err != nil {
+		log.Warn(err.Error())
+		return false
+	}
+
+	return ldapUser != nil
+}
+
+// oidcAuthProvider authenticates users by OIDC providers from the config,
+// every OIDC provider is the login option.
+type oidcAuthProvider struct{}
+
+func (oidcAuthProvider) ID() string {
+	return oidcProviderID
+}
+
+func (oidcAuthProvider) Enabled() bool {
+	return len(util.Config.OidcProviders) > 0
+}
+
+func (oidcAuthProvider) LoginOptions() []auth.LoginOption {
+	options := make([]auth.LoginOption, 0, len(util.Config.OidcProviders))
+
+	for k, v := range util.Config.OidcProviders {
+		options = append(options, auth.LoginOption{
+			ID:    k,
+			Name:  v.DisplayName,
+			Color: v.Color,
+			Icon:  v.Icon,
+			Order: v.Order,
+		})
+	}
+
+	return options
+}
+
+func (oidcAuthProvider) StartLogin(w http.ResponseWriter, r *http.Request, optionID string, redirectPath string) error {
+	_, oauth, err := getOidcProvider(optionID, context.Background(), redirectPath)
+	if err != nil {
+		return err
+	}
+
+	state := generateStateOauthCookie(w)
+	u := oauth.AuthCodeURL(state)
+	http.Redirect(w, r, u, http.StatusTemporaryRedirect)
+	return nil
+}
+
+// nolint: gocyclo
+func (oidcAuthProvider) CompleteLogin(store db.Store, r *http.Request, optionID string) (*db.User, error) {
+	oauthState, err := r.Cookie("oauthstate")
+	if err != nil {
+		return nil, err
+	}
+
+	if r.FormValue("state") != oauthState.Value {
+		return nil, fmt.Errorf("invalid oauth state")
+	}
+
+	ctx := context.Background()
+
+	_oidc, oauth, err := getOidcProvider(optionID, ctx, r.URL.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, ok := util.Config.OidcProviders[optionID]
+	if !ok {
+		return nil, fmt.Errorf("no such provider: %s", optionID)
+	}
+
+	verifier := _oidc.Verifier(&oidc.Config{ClientID: oauth.ClientID})
+
+	code := r.URL.Query().Get("code")
+
+	oauth2Token, err := oauth.Exchange(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims claimResult
+
+	// Extract the ID Token from OAuth2 token.
+	rawIDToken, ok := oauth2Token.Extra("id_token").(string)
+
+	if ok && rawIDToken != "" {
+		var idToken *oidc.IDToken
+		// Parse and verify ID Token payload.
+		idToken, err = verifier.Verify(ctx, rawIDToken)
+
+		if err == nil {
+			claims, err = claimOidcToken(idToken, provider)
+		}
+	} else {
+		var userInfo *oidc.UserInfo
+		userInfo, err = _oidc.UserInfo(ctx, oauth2.StaticTokenSource(oauth2Token))
+
+		if err == nil {
+
+			if userInfo.Email == "" {
+				claims, err = claimOidcUserInfo(userInfo, provider)
+			} else {
+				claims.email = userInfo.Email
+				claims.name = userInfo.Profile
+			}
+		}
+
+		claims.username = getRandomUsername()
+		if userInfo.Profile == "" {
+			claims.name = getRandomProfileName()
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := store.GetUserByLoginOrEmail("", claims.email) // ignore username because it creates a lot of problems
+	if err != nil {
+		user = db.User{
+			Username: claims.username,
+			Name:     claims.name,
+			Email:    claims.email,
+			External: true,
+		}
+		user, err = store.CreateUserWithoutPassword(user)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !user.External {
+		return nil, fmt.Errorf("OIDC user '%s' conflicts with local user", user.Username)
+	}
+
+	return &user, nil
+}
+
+// certAuthProvider authenticates API requests by client certificates (mTLS).
+// The certificate is verified and checked for revocation during the TLS handshake.
+type certAuthProvider struct{}
+
+func (certAuthProvider) ID() string {
+	return "mtls"
+}
+
+func (certAuthProvider) Enabled() bool {
+	return util.Config.TLS.ClientCertAuthEnabled()
+}
+
+func (certAuthProvider) Authenticate(store db.Store, r *http.Request) (*db.User, error) {
+	cert := mtls.ClientCertificate(r)
+	if cert == nil {
+		return nil, nil
+	}
+
+	identity, err := mtls.Identity(cert, mtls.IdentityField(util.Config.TLS.ClientCertUserField))
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := store.GetUserByLoginOrEmail(identity, identity)
+	if err != nil {
+		return nil, fmt.Errorf("no user for certificate %s: %w", identity, err)
+	}
+
+	return &user, nil
+}
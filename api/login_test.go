@@ -1,7 +1,11 @@
 package api
 
 import (
+	"errors"
 	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
 )
 
 func TestParseClaim(t *testing.T) {
@@ -83,3 +87,28 @@ func TestParseClaim5(t *testing.T) {
 		t.Fatalf("Expected: %v, Got: %v", "123456757343", res)
 	}
 }
+
+func TestLoginByProviders(t *testing.T) {
+	store := bolt.CreateTestStore()
+
+	_, err := store.CreateUser(db.UserWithPwd{
+		Pwd:  "secret",
+		User: db.User{Username: "local", Name: "Local", Email: "local@example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := loginByProviders(store, "local", "secret")
+	if err != nil || user.Username != "local" {
+		t.Fatal("local user must be authenticated", err)
+	}
+
+	if !verifyUserPassword(&user, "secret") || verifyUserPassword(&user, "wrong") {
+		t.Fatal("password of local user must be verified")
+	}
+
+	if _, err = loginByProviders(store, "local", "wrong"); !errors.Is(err, db.ErrNotFound) {
+		t.Fatal("invalid password must be rejected", err)
+	}
+}
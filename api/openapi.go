@@ -0,0 +1,120 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/openapi"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// openAPIModels maps resource segments of API paths to their models.
+var openAPIModels = map[string]any{
+	"projects":        db.Project{},
+	"project":         db.Project{},
+	"templates":       db.Template{},
+	"repositories":    db.Repository{},
+	"inventory":       db.Inventory{},
+	"environment":     db.Environment{},
+	"variable_groups": db.Environment{},
+	"keys":            db.AccessKey{},
+	"views":           db.View{},
+	"schedules":       db.Schedule{},
+	"tasks":           db.Task{},
+	"users":           db.User{},
+	"user":            db.User{},
+	"tokens":          db.APIToken{},
+	"integrations":    db.Integration{},
+	"matchers":        db.IntegrationMatcher{},
+	"values":          db.IntegrationExtractValue{},
+	"aliases":         db.IntegrationAlias{},
+	"deliveries":      db.IntegrationDelivery{},
+	"runners":         db.Runner{},
+	"events":          db.Event{},
+}
+
+// openAPIOperationSpec guesses request and response bodies by REST conventions:
+// collection paths end with a resource name, item paths end with its ID.
+func openAPIOperationSpec(path string, method string) (spec openapi.OperationSpec) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	resource := ""
+	isItem := false
+	for i := len(segments) - 1; i >= 0; i-- {
+		if strings.HasPrefix(segments[i], "{") {
+			if i == len(segments)-1 {
+				isItem = true
+			}
+			continue
+		}
+		resource = segments[i]
+		break
+	}
+
+	model, ok := openAPIModels[resource]
+	if !ok {
+		return
+	}
+
+	switch method {
+	case "GET":
+		spec.Response = model
+		spec.ResponseList = !isItem && resource != "project" && resource != "user"
+	case "POST":
+		spec.Request = model
+		if !isItem {
+			spec.Response = model
+			spec.Status = http.StatusCreated
+		} else {
+			spec.Status = http.StatusNoContent
+		}
+	case "PUT":
+		spec.Request = model
+		spec.Status = http.StatusNoContent
+	case "DELETE":
+		spec.Status = http.StatusNoContent
+	}
+
+	return
+}
+
+// GenerateOpenAPI builds the specification from routes of the router.
+func GenerateOpenAPI(router *mux.Router, basePath string) ([]byte, error) {
+	g := openapi.NewGenerator("Semaphore API", util.Version(), basePath)
+
+	g.Document().Components.SecuritySchemes = map[string]*openapi.SecurityScheme{
+		"bearer": {Type: "http", Scheme: "bearer"},
+		"cookie": {Type: "apiKey", In: "cookie", Name: "semaphore"},
+	}
+
+	if err := g.AddRouter(router, basePath, openAPIOperationSpec); err != nil {
+		return nil, err
+	}
+
+	return g.JSON()
+}
+
+func openAPIHandler(router *mux.Router, basePath string) http.HandlerFunc {
+	var once sync.Once
+	var spec []byte
+	var err error
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// routes are complete only after Route returns, so generate on first request
+		once.Do(func() {
+			spec, err = GenerateOpenAPI(router, basePath)
+		})
+
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		w.Header().Set("content-type", "application/json")
+		_, _ = w.Write(spec)
+	}
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/housekeeping"
+	log "github.com/sirupsen/logrus"
+)
+
+// getOrphanedReferences returns rows which refer to deleted objects.
+func getOrphanedReferences(w http.ResponseWriter, r *http.Request) {
+	refs, err := helpers.Store(r).GetOrphanedReferences()
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, refs)
+}
+
+// fixOrphanedReferences repairs or purges orphaned references. Confirm must be the number
+// of the references in the report seen by the user, so changes are not applied to rows
+// which appeared after the report.
+func fixOrphanedReferences(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Fixes   []db.OrphanFix `json:"fixes"`
+		Confirm *int           `json:"confirm"`
+	}
+
+	if !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	for _, fix := range body.Fixes {
+		if fix != db.OrphanFixUnset && fix != db.OrphanFixPurge {
+			helpers.WriteErrorStatus(w, "invalid fix "+string(fix), http.StatusBadRequest)
+			return
+		}
+	}
+
+	store := helpers.Store(r)
+
+	refs, err := store.GetOrphanedReferences()
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if body.Confirm == nil || *body.Confirm != len(refs) {
+		helpers.WriteErrorStatus(w, fmt.Sprintf("confirm must be the number of orphaned references (%d)", len(refs)), http.StatusConflict)
+		return
+	}
+
+	fixed, err := housekeeping.FixOrphanedReferences(store, refs, body.Fixes...)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	log.WithField("user", helpers.UserFromContext(r).Username).Infof("%d orphaned references fixed", fixed)
+
+	helpers.WriteJSON(w, http.StatusOK, map[string]int{"fixed": fixed})
+}
@@ -0,0 +1,113 @@
+package projects
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// AdhocCommandMiddleware gets the ad-hoc command by id and sets the context to it
+func AdhocCommandMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		project := context.Get(r, "project").(db.Project)
+		commandID, err := helpers.GetIntParam("command_id", w, r)
+		if err != nil {
+			return
+		}
+
+		command, err := helpers.Store(r).GetAdhocCommand(project.ID, commandID)
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		context.Set(r, "adhocCommand", command)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetAdhocCommands returns ad-hoc commands of the project, the newest first
+func GetAdhocCommands(w http.ResponseWriter, r *http.Request) {
+	if command := context.Get(r, "adhocCommand"); command != nil {
+		helpers.WriteJSON(w, http.StatusOK, command.(db.AdhocCommand))
+		return
+	}
+
+	project := context.Get(r, "project").(db.Project)
+
+	commands, err := helpers.Store(r).GetAdhocCommands(project.ID, helpers.QueryParams(r.URL))
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, commands)
+}
+
+// RunAdhocCommand starts the Ansible module on the hosts of the inventory
+func RunAdhocCommand(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	user := context.Get(r, "user").(*db.User)
+
+	var command db.AdhocCommand
+	if !helpers.Bind(w, r, &command) {
+		return
+	}
+
+	if command.KeyID != nil && !userCan(r, db.CanUseProjectKeys) {
+		helpers.WriteErrorStatus(w, "You can not use access keys of the project", http.StatusForbidden)
+		return
+	}
+
+	newCommand, err := helpers.TaskPool(r).RunAdhocCommand(command, &user.ID, project.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogCreate, helpers.EventLogItem{
+		UserID:      user.ID,
+		ProjectID:   project.ID,
+		ObjectType:  db.EventAdhocCommand,
+		ObjectID:    newCommand.ID,
+		Description: "Ad-hoc command " + strconv.Itoa(newCommand.ID) + " (" + newCommand.Module + ") started",
+	})
+
+	helpers.WriteJSON(w, http.StatusCreated, newCommand)
+}
+
+// GetAdhocCommandOutput returns the output of the ad-hoc command
+func GetAdhocCommandOutput(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	command := context.Get(r, "adhocCommand").(db.AdhocCommand)
+
+	output, err := helpers.Store(r).GetAdhocCommandOutputs(project.ID, command.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if r.URL.Query().Get("strip_ansi") == "1" {
+		for i := range output {
+			output[i].Output = util.StripANSI(output[i].Output)
+		}
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, output)
+}
+
+// StopAdhocCommand kills the running ad-hoc command
+func StopAdhocCommand(w http.ResponseWriter, r *http.Request) {
+	command := context.Get(r, "adhocCommand").(db.AdhocCommand)
+
+	if err := helpers.TaskPool(r).StopAdhocCommand(command.ID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
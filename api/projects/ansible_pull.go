@@ -0,0 +1,230 @@
+package projects
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/context"
+	"github.com/gorilla/mux"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/services/tasks"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxPullReportOutput limits the output of the ansible-pull run stored in the task.
+const maxPullReportOutput = 1024 * 1024
+
+// GetPullHosts returns the hosts enrolled to the ansible-pull mode of the template.
+func GetPullHosts(w http.ResponseWriter, r *http.Request) {
+	tpl := context.Get(r, "template").(db.Template)
+
+	hosts, err := helpers.Store(r).GetPullHosts(tpl.ProjectID, tpl.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, hosts)
+}
+
+// AddPullHost enrolls the host to the ansible-pull mode of the template. The token of the host
+// is returned once with the crontab line which should be installed on the host.
+func AddPullHost(w http.ResponseWriter, r *http.Request) {
+	tpl := context.Get(r, "template").(db.Template)
+
+	var body struct {
+		Name string `json:"name"`
+	}
+
+	if !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	var params db.AnsibleTemplateParams
+	if err := tpl.GetParams(&params); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if !tpl.App.IsAnsible() || !params.Pull.Enabled {
+		helpers.WriteError(w, tasks.ErrAnsiblePullDisabled)
+		return
+	}
+
+	host, token, err := tasks.CreatePullHost(helpers.Store(r), tpl, strings.TrimSpace(body.Name))
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusCreated, map[string]any{
+		"host":  host,
+		"token": token,
+		"cron":  tasks.GetPullHostCron(params.Pull.GetSchedule(), token),
+	})
+}
+
+// RemovePullHost revokes the token of the pull host.
+func RemovePullHost(w http.ResponseWriter, r *http.Request) {
+	tpl := context.Get(r, "template").(db.Template)
+
+	hostID, err := helpers.GetIntParam("host_id", w, r)
+	if err != nil {
+		return
+	}
+
+	store := helpers.Store(r)
+
+	host, err := store.GetPullHost(tpl.ProjectID, hostID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if host.TemplateID != tpl.ID {
+		helpers.WriteError(w, db.ErrNotFound)
+		return
+	}
+
+	if err = store.DeletePullHost(tpl.ProjectID, hostID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getPullHost authenticates the pull host by the token from the Authorization header.
+func getPullHost(w http.ResponseWriter, r *http.Request) (host db.PullHost, ok bool) {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || token == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	host, err := helpers.Store(r).GetPullHostByToken(db.HashPullToken(token))
+	if errors.Is(err, db.ErrNotFound) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	ok = true
+	return
+}
+
+// GetPullConfig returns the ansible-pull configuration of the host with a new short-lived repository URL.
+func GetPullConfig(w http.ResponseWriter, r *http.Request) {
+	host, ok := getPullHost(w, r)
+	if !ok {
+		return
+	}
+
+	cfg, err := tasks.GetPullConfig(helpers.Store(r), host, time.Now())
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, cfg)
+}
+
+// GetPullScript returns the shell script which runs ansible-pull on the host and reports the result.
+func GetPullScript(w http.ResponseWriter, r *http.Request) {
+	host, ok := getPullHost(w, r)
+	if !ok {
+		return
+	}
+
+	cfg, err := tasks.GetPullConfig(helpers.Store(r), host, time.Now())
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("content-type", "text/x-shellscript; charset=utf-8")
+	w.Header().Set("cache-control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err = w.Write([]byte(tasks.RenderPullScript(cfg))); err != nil {
+		log.WithError(err).Error("Can not write the ansible-pull script")
+	}
+}
+
+func parsePullReportTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// ReportPullRun stores the result of the ansible-pull run reported by the host as a task of the template.
+func ReportPullRun(w http.ResponseWriter, r *http.Request) {
+	host, ok := getPullHost(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 2*maxPullReportOutput)
+
+	if err := r.ParseMultipartForm(maxPullReportOutput); err != nil {
+		helpers.WriteErrorStatus(w, "invalid report", http.StatusBadRequest)
+		return
+	}
+
+	output := r.FormValue("output")
+	if len(output) > maxPullReportOutput {
+		output = output[len(output)-maxPullReportOutput:]
+	}
+
+	task, err := tasks.ReportPullRun(helpers.Store(r), host, tasks.PullReport{
+		Status: task_logger.TaskStatus(r.FormValue("status")),
+		Start:  parsePullReportTime(r.FormValue("start")),
+		End:    parsePullReportTime(r.FormValue("end")),
+		Commit: r.FormValue("commit"),
+		Output: output,
+	})
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusCreated, map[string]int{
+		"task_id": task.ID,
+	})
+}
+
+// GetPullRepositoryFile serves the repository of the template to ansible-pull by the dumb HTTP protocol
+// of git. Only files needed to clone the repository are served.
+func GetPullRepositoryFile(w http.ResponseWriter, r *http.Request) {
+	_, tpl, err := tasks.GetPullHostByRepositoryToken(helpers.Store(r), mux.Vars(r)["token"], time.Now())
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	filePath, err := tasks.GetPullRepositoryFile(tpl, mux.Vars(r)["path"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if _, err = os.Stat(filePath); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("content-type", "application/octet-stream")
+	w.Header().Set("cache-control", "no-store")
+	http.ServeFile(w, r, filePath)
+}
@@ -1,9 +1,8 @@
 package projects
 
 import (
-	"io"
+	"bufio"
 	"net/http"
-	"strings"
 
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
@@ -24,15 +23,24 @@ func GetBackup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	str, err := backup.Marshal()
-	if err != nil {
-		helpers.WriteError(w, err)
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if r.Method == http.MethodHead {
 		return
 	}
 
-	w.Header().Set("content-type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write([]byte(str))
+	// The response is streamed, the status can not be changed on error.
+	bw := bufio.NewWriter(w)
+	if err = backup.MarshalTo(bw); err == nil {
+		err = bw.Flush()
+	}
+
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"project_id": project.ID,
+		}).Error("Failed to write project backup")
+	}
 }
 
 func Restore(w http.ResponseWriter, r *http.Request) {
@@ -40,16 +48,7 @@ func Restore(w http.ResponseWriter, r *http.Request) {
 
 	var backup projectService.BackupFormat
 
-	buf := new(strings.Builder)
-	if _, err := io.Copy(buf, r.Body); err != nil {
-		log.Error(err)
-		helpers.WriteError(w, err)
-		return
-	}
-
-	str := buf.String()
-
-	if err := backup.Unmarshal(str); err != nil {
+	if err := backup.UnmarshalFrom(r.Body); err != nil {
 		log.Error(err)
 		helpers.WriteError(w, err)
 		return
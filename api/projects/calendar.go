@@ -0,0 +1,114 @@
+package projects
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/schedules"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultCalendarDays = 30
+	maxCalendarDays     = 90
+)
+
+// GetCalendarToken returns the token of the project calendar feed or null if the feed is disabled.
+func GetCalendarToken(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	helpers.WriteJSON(w, http.StatusOK, map[string]*string{
+		"token": project.CalendarToken,
+	})
+}
+
+// RotateCalendarToken enables the calendar feed of the project or replaces its token,
+// so the previously shared feed URL stops working.
+func RotateCalendarToken(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	if err := helpers.Store(r).SetProjectCalendarToken(project.ID, &token); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, map[string]string{
+		"token": token,
+	})
+}
+
+// DeleteCalendarToken disables the calendar feed of the project.
+func DeleteCalendarToken(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	if err := helpers.Store(r).SetProjectCalendarToken(project.ID, nil); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetCalendar returns upcoming scheduled runs of the project in iCalendar format.
+// The request is not authenticated, the project calendar token must be passed
+// in the token query parameter.
+func GetCalendar(w http.ResponseWriter, r *http.Request) {
+	projectID, err := helpers.GetIntParam("project_id", w, r)
+	if err != nil {
+		return
+	}
+
+	store := helpers.Store(r)
+
+	project, err := store.GetProject(projectID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	if project.CalendarToken == nil || token == "" ||
+		subtle.ConstantTimeCompare([]byte(*project.CalendarToken), []byte(token)) != 1 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	days := defaultCalendarDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		days, err = strconv.Atoi(value)
+		if err != nil || days <= 0 || days > maxCalendarDays {
+			helpers.WriteErrorStatus(w, "days must be between 1 and "+strconv.Itoa(maxCalendarDays), http.StatusBadRequest)
+			return
+		}
+	}
+
+	now := time.Now()
+
+	events, err := schedules.GetProjectCalendarEvents(store, project.ID, now, now.AddDate(0, 0, days))
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("content-type", "text/calendar; charset=utf-8")
+	w.Header().Set("content-disposition", "inline; filename=\"semaphore-project-"+strconv.Itoa(project.ID)+".ics\"")
+	w.WriteHeader(http.StatusOK)
+
+	if err = schedules.WriteCalendar(w, project.Name, events, now); err != nil {
+		log.Error(err)
+	}
+}
@@ -0,0 +1,73 @@
+package projects
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+// dashboardTask is the last task of the template shown on dashboards.
+type dashboardTask struct {
+	ID      int                    `json:"id"`
+	Status  task_logger.TaskStatus `json:"status"`
+	Created time.Time              `json:"created"`
+	Start   *time.Time             `json:"start"`
+	End     *time.Time             `json:"end"`
+}
+
+// dashboardTemplate is the template metadata available for dashboard tokens.
+// It must not contain anything which reveals inventories, secrets or task output.
+type dashboardTemplate struct {
+	ID          int             `json:"id"`
+	Name        string          `json:"name"`
+	Description *string         `json:"description"`
+	App         db.TemplateApp  `json:"app"`
+	Type        db.TemplateType `json:"type"`
+	ViewID      *int            `json:"view_id"`
+	Tags        []string        `json:"tags"`
+	LastTask    *dashboardTask  `json:"last_task"`
+}
+
+// GetDashboardTemplates returns templates of the project with statuses of their last tasks.
+// It is the only project endpoint available for dashboard API tokens.
+func GetDashboardTemplates(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	templates, err := helpers.Store(r).GetTemplates(project.ID, db.TemplateFilter{}, db.RetrieveQueryParams{})
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	res := make([]dashboardTemplate, 0, len(templates))
+
+	for _, tpl := range templates {
+		item := dashboardTemplate{
+			ID:          tpl.ID,
+			Name:        tpl.Name,
+			Description: tpl.Description,
+			App:         tpl.App,
+			Type:        tpl.Type,
+			ViewID:      tpl.ViewID,
+			Tags:        tpl.Tags,
+		}
+
+		if tpl.LastTask != nil {
+			item.LastTask = &dashboardTask{
+				ID:      tpl.LastTask.ID,
+				Status:  tpl.LastTask.Status,
+				Created: tpl.LastTask.Created,
+				Start:   tpl.LastTask.Start,
+				End:     tpl.LastTask.End,
+			}
+		}
+
+		res = append(res, item)
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, res)
+}
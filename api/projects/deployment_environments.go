@@ -0,0 +1,360 @@
+package projects
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/services/tasks"
+
+	"github.com/gorilla/context"
+)
+
+// DeploymentEnvironmentMiddleware ensures a deployment environment exists and loads it to the context
+func DeploymentEnvironmentMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		project := context.Get(r, "project").(db.Project)
+		envID, err := helpers.GetIntParam("deployment_environment_id", w, r)
+		if err != nil {
+			return
+		}
+
+		env, err := helpers.Store(r).GetDeploymentEnvironment(project.ID, envID)
+
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		context.Set(r, "deploymentEnvironment", env)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetDeploymentEnvironments retrieves deployment environments in order of promotion
+func GetDeploymentEnvironments(w http.ResponseWriter, r *http.Request) {
+	if env := context.Get(r, "deploymentEnvironment"); env != nil {
+		helpers.WriteJSON(w, http.StatusOK, env.(db.DeploymentEnvironment))
+		return
+	}
+
+	project := context.Get(r, "project").(db.Project)
+
+	envs, err := helpers.Store(r).GetDeploymentEnvironments(project.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, envs)
+}
+
+// AddDeploymentEnvironment adds a new deployment environment to the database
+func AddDeploymentEnvironment(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	var env db.DeploymentEnvironment
+
+	if !helpers.Bind(w, r, &env) {
+		return
+	}
+
+	if env.ProjectID != project.ID {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Project ID in body and URL must be the same",
+		})
+		return
+	}
+
+	if err := env.Validate(); err != nil {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	newEnv, err := helpers.Store(r).CreateDeploymentEnvironment(env)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogCreate, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   newEnv.ProjectID,
+		ObjectType:  db.EventDeploymentEnvironment,
+		ObjectID:    newEnv.ID,
+		Description: fmt.Sprintf("Deployment environment %s created", env.Name),
+	})
+
+	helpers.WriteJSON(w, http.StatusCreated, newEnv)
+}
+
+func SetDeploymentEnvironmentPositions(w http.ResponseWriter, r *http.Request) {
+	var positions map[int]int
+
+	project := context.Get(r, "project").(db.Project)
+
+	if !helpers.Bind(w, r, &positions) {
+		return
+	}
+
+	err := helpers.Store(r).SetDeploymentEnvironmentPositions(project.ID, positions)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateDeploymentEnvironment updates deployment environment in database
+func UpdateDeploymentEnvironment(w http.ResponseWriter, r *http.Request) {
+	var env db.DeploymentEnvironment
+	oldEnv := context.Get(r, "deploymentEnvironment").(db.DeploymentEnvironment)
+
+	if !helpers.Bind(w, r, &env) {
+		return
+	}
+
+	if env.ID != oldEnv.ID {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Deployment environment ID in URL and in body must be the same",
+		})
+		return
+	}
+
+	if err := env.Validate(); err != nil {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	env.ProjectID = oldEnv.ProjectID
+
+	if err := helpers.Store(r).UpdateDeploymentEnvironment(env); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogUpdate, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   oldEnv.ProjectID,
+		ObjectType:  db.EventDeploymentEnvironment,
+		ObjectID:    oldEnv.ID,
+		Description: fmt.Sprintf("Deployment environment %s updated", env.Name),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveDeploymentEnvironment deletes a deployment environment from the database
+func RemoveDeploymentEnvironment(w http.ResponseWriter, r *http.Request) {
+	env := context.Get(r, "deploymentEnvironment").(db.DeploymentEnvironment)
+
+	err := helpers.Store(r).DeleteDeploymentEnvironment(env.ProjectID, env.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogDelete, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   env.ProjectID,
+		ObjectType:  db.EventDeploymentEnvironment,
+		ObjectID:    env.ID,
+		Description: fmt.Sprintf("Deployment environment %s deleted", env.Name),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTemplateDeployments returns the last successful task of the template
+// for each deployment environment
+func GetTemplateDeployments(w http.ResponseWriter, r *http.Request) {
+	tpl := context.Get(r, "template").(db.Template)
+	store := helpers.Store(r)
+
+	envs, err := store.GetDeploymentEnvironments(tpl.ProjectID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	db.SortDeploymentEnvironments(envs)
+
+	deployments := make([]db.Deployment, 0, len(envs))
+
+	for _, env := range envs {
+		deployment := db.Deployment{Environment: env}
+
+		task, err := store.GetLastDeploymentTask(tpl.ProjectID, tpl.ID, env.ID)
+
+		if err == nil {
+			deployment.Task = &task
+		} else if !errors.Is(err, db.ErrNotFound) {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		deployments = append(deployments, deployment)
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, deployments)
+}
+
+// PromoteTask runs the commit of the successful task in the next deployment environment
+// or in the environment specified in the request body
+func PromoteTask(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	task := context.Get(r, "task").(db.Task)
+
+	var body struct {
+		DeploymentEnvironmentID *int `json:"deployment_environment_id"`
+	}
+
+	if r.ContentLength > 0 && !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	if task.Status != task_logger.TaskSuccessStatus {
+		helpers.WriteErrorStatus(w, "Only successful tasks can be promoted", http.StatusBadRequest)
+		return
+	}
+
+	if task.CommitHash == nil {
+		helpers.WriteErrorStatus(w, "Task has no commit to promote", http.StatusBadRequest)
+		return
+	}
+
+	store := helpers.Store(r)
+
+	var target db.DeploymentEnvironment
+	var err error
+
+	switch {
+	case body.DeploymentEnvironmentID != nil:
+		target, err = store.GetDeploymentEnvironment(project.ID, *body.DeploymentEnvironmentID)
+	case task.DeploymentEnvironmentID != nil:
+		var envs []db.DeploymentEnvironment
+		envs, err = store.GetDeploymentEnvironments(project.ID)
+		if err == nil {
+			target, err = db.GetNextDeploymentEnvironment(envs, *task.DeploymentEnvironmentID)
+		}
+		if errors.Is(err, db.ErrNotFound) {
+			helpers.WriteErrorStatus(w, "There is no environment to promote the task to", http.StatusBadRequest)
+			return
+		}
+	default:
+		helpers.WriteErrorStatus(w, "Deployment environment required", http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	newTask := rerunTask(task)
+	newTask.Message = fmt.Sprintf("Promoted from task #%d", task.ID)
+	newTask.DeploymentEnvironmentID = &target.ID
+
+	addRerunTask(w, r, newTask)
+}
+
+// rerunTask makes the new task which runs the commit of the task with the same variables.
+func rerunTask(task db.Task) db.Task {
+	return db.Task{
+		TemplateID:  task.TemplateID,
+		Debug:       task.Debug,
+		DryRun:      task.DryRun,
+		Diff:        task.Diff,
+		Playbook:    task.Playbook,
+		Environment: task.Environment,
+		Limit:       task.Limit,
+		Arguments:   task.Arguments,
+		GitBranch:   task.GitBranch,
+		CommitHash:  task.CommitHash,
+		BuildTaskID: task.BuildTaskID,
+		InventoryID: task.InventoryID,
+		Params:      task.Params,
+	}
+}
+
+func addRerunTask(w http.ResponseWriter, r *http.Request, task db.Task) {
+	project := context.Get(r, "project").(db.Project)
+	user := context.Get(r, "user").(*db.User)
+
+	newTask, err := helpers.TaskPool(r).AddTask(task, &user.ID, project.ID)
+
+	if errors.Is(err, tasks.ErrInvalidSubscription) {
+		helpers.WriteErrorStatus(w, "No active subscription available.", http.StatusForbidden)
+		return
+	} else if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusCreated, newTask)
+}
+
+// RollbackTask re-runs the template with the commit and variables of the previous
+// successful task in the deployment environment
+func RollbackTask(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	var body struct {
+		TemplateID              int `json:"template_id"`
+		DeploymentEnvironmentID int `json:"deployment_environment_id"`
+	}
+
+	if !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	store := helpers.Store(r)
+
+	if _, err := store.GetTemplate(project.ID, body.TemplateID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if _, err := store.GetDeploymentEnvironment(project.ID, body.DeploymentEnvironmentID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	deployments, err := store.GetDeploymentTasks(project.ID, body.TemplateID, body.DeploymentEnvironmentID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	task, err := db.GetRollbackTask(deployments)
+
+	if errors.Is(err, db.ErrNotFound) {
+		helpers.WriteErrorStatus(w, "There is no previous successful task to roll back to", http.StatusBadRequest)
+		return
+	}
+
+	if task.CommitHash == nil {
+		helpers.WriteErrorStatus(w, "Previous successful task has no commit to roll back to", http.StatusBadRequest)
+		return
+	}
+
+	newTask := rerunTask(task)
+	newTask.Message = fmt.Sprintf("Rollback to task #%d", task.ID)
+	newTask.DeploymentEnvironmentID = &body.DeploymentEnvironmentID
+	newTask.RollbackTaskID = &task.ID
+
+	addRerunTask(w, r, newTask)
+}
@@ -0,0 +1,23 @@
+package projects
+
+import (
+	"net/http"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// GetDiskUsage returns the disk consumed by the caches and the temporary workspaces
+// of the project on the server and the runners.
+func GetDiskUsage(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	usage, err := helpers.Store(r).GetDiskUsage(&project.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, usage)
+}
@@ -1,6 +1,7 @@
 package projects
 
 import (
+	"errors"
 	"fmt"
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
@@ -85,6 +86,13 @@ func EnvironmentMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// project defaults are managed by GetProjectDefaultEnvironment and UpdateProjectDefaultEnvironment,
+		// variable groups are managed by VariableGroupMiddleware
+		if env.ProjectDefault || env.VariableGroup {
+			helpers.WriteError(w, db.ErrNotFound)
+			return
+		}
+
 		if err = db.FillEnvironmentSecrets(helpers.Store(r), &env, false); err != nil {
 			helpers.WriteError(w, err)
 			return
@@ -149,6 +157,10 @@ func UpdateEnvironment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !mustNotBeUsedByRunningTasks(w, r, oldEnv.ProjectID, db.TaskObjectRef{ObjectType: db.EventEnvironment, ObjectID: oldEnv.ID}) {
+		return
+	}
+
 	if err := helpers.Store(r).UpdateEnvironment(env); err != nil {
 		helpers.WriteError(w, err)
 		return
@@ -220,12 +232,20 @@ func AddEnvironment(w http.ResponseWriter, r *http.Request) {
 func RemoveEnvironment(w http.ResponseWriter, r *http.Request) {
 	env := context.Get(r, "environment").(db.Environment)
 
-	err := helpers.Store(r).DeleteEnvironment(env.ProjectID, env.ID)
-	if err == db.ErrInvalidOperation {
-		helpers.WriteJSON(w, http.StatusBadRequest, map[string]interface{}{
-			"error": "Environment is in use by one or more templates",
-			"inUse": true,
-		})
+	refs, err := helpers.Store(r).GetEnvironmentRefs(env.ProjectID, env.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if !refs.IsEmpty() {
+		helpers.WriteObjectInUse(w, "Environment is in use by one or more templates", refs)
+		return
+	}
+
+	err = helpers.Store(r).DeleteEnvironment(env.ProjectID, env.ID)
+	if errors.Is(err, db.ErrInvalidOperation) {
+		helpers.WriteObjectInUse(w, "Environment is in use by one or more templates", refs)
 		return
 	}
 
@@ -244,3 +264,85 @@ func RemoveEnvironment(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// GetProjectDefaultEnvironment returns project-wide variables and secrets which are
+// merged into the environment of every task. Empty defaults are returned if they are not set.
+func GetProjectDefaultEnvironment(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	env, err := helpers.Store(r).GetProjectDefaultEnvironment(project.ID)
+
+	if errors.Is(err, db.ErrNotFound) {
+		emptyENV := "{}"
+		helpers.WriteJSON(w, http.StatusOK, db.Environment{
+			ProjectID: project.ID,
+			Name:      db.ProjectDefaultEnvironmentName,
+			JSON:      "{}",
+			ENV:       &emptyENV,
+			Secrets:   []db.EnvironmentSecret{},
+		})
+		return
+	}
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	env.Secrets = []db.EnvironmentSecret{}
+	if err = db.FillEnvironmentSecrets(helpers.Store(r), &env, false); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, env)
+}
+
+// UpdateProjectDefaultEnvironment creates or updates project-wide variables and secrets.
+func UpdateProjectDefaultEnvironment(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	store := helpers.Store(r)
+
+	var env db.Environment
+	if !helpers.Bind(w, r, &env) {
+		return
+	}
+
+	env.ProjectID = project.ID
+	env.Name = db.ProjectDefaultEnvironmentName
+	env.ProjectDefault = true
+
+	if env.JSON == "" {
+		env.JSON = "{}"
+	}
+
+	oldEnv, err := store.GetProjectDefaultEnvironment(project.ID)
+
+	switch {
+	case err == nil:
+		env.ID = oldEnv.ID
+		err = store.UpdateEnvironment(env)
+	case errors.Is(err, db.ErrNotFound):
+		env, err = store.CreateEnvironment(env)
+	}
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogUpdate, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   project.ID,
+		ObjectType:  db.EventEnvironment,
+		ObjectID:    env.ID,
+		Description: "Project default environment updated",
+	})
+
+	if err = updateEnvironmentSecrets(store, env); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,84 @@
+package projects
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetEnvironmentFile exports the environment as the multi-document YAML file
+// with separate vars, env and secrets sections. Values of secrets are not exported.
+func GetEnvironmentFile(w http.ResponseWriter, r *http.Request) {
+	env := context.Get(r, "environment").(db.Environment)
+
+	file, err := db.NewEnvironmentFile(env)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	data, err := file.Marshal()
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/yaml")
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(data); err != nil {
+		log.WithError(err).Error("Failed to write environment file")
+	}
+}
+
+// UpdateEnvironmentFile replaces variables and secrets of the environment with the sections
+// of the multi-document YAML file. Secrets which are not in the file are deleted.
+func UpdateEnvironmentFile(w http.ResponseWriter, r *http.Request) {
+	env := context.Get(r, "environment").(db.Environment)
+	store := helpers.Store(r)
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	file, err := db.ParseEnvironmentFile(data)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if err = file.ApplyTo(&env); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if !mustNotBeUsedByRunningTasks(w, r, env.ProjectID, db.TaskObjectRef{ObjectType: db.EventEnvironment, ObjectID: env.ID}) {
+		return
+	}
+
+	if err = store.UpdateEnvironment(env); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogUpdate, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   env.ProjectID,
+		ObjectType:  db.EventEnvironment,
+		ObjectID:    env.ID,
+		Description: fmt.Sprintf("Environment %s updated from file", env.Name),
+	})
+
+	if err = updateEnvironmentSecrets(store, env); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
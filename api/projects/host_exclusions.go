@@ -0,0 +1,165 @@
+package projects
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+
+	"github.com/gorilla/context"
+)
+
+// HostExclusionMiddleware ensures a host exclusion exists and loads it to the context
+func HostExclusionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		project := context.Get(r, "project").(db.Project)
+		exclusionID, err := helpers.GetIntParam("exclusion_id", w, r)
+		if err != nil {
+			return
+		}
+
+		exclusion, err := helpers.Store(r).GetHostExclusion(project.ID, exclusionID)
+
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		context.Set(r, "hostExclusion", exclusion)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetHostExclusions retrieves hosts excluded from the runs of the project
+func GetHostExclusions(w http.ResponseWriter, r *http.Request) {
+	if exclusion := context.Get(r, "hostExclusion"); exclusion != nil {
+		helpers.WriteJSON(w, http.StatusOK, exclusion.(db.HostExclusion))
+		return
+	}
+
+	project := context.Get(r, "project").(db.Project)
+
+	exclusions, err := helpers.Store(r).GetHostExclusions(project.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, exclusions)
+}
+
+// AddHostExclusion excludes the host from the runs of the project
+func AddHostExclusion(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	var exclusion db.HostExclusion
+
+	if !helpers.Bind(w, r, &exclusion) {
+		return
+	}
+
+	if exclusion.ProjectID != project.ID {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Project ID in body and URL must be the same",
+		})
+		return
+	}
+
+	if err := exclusion.Validate(); err != nil {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if !validateExpiresAt(exclusion.ExpiresAt, w) {
+		return
+	}
+
+	exclusion.UserID = &helpers.UserFromContext(r).ID
+
+	newExclusion, err := helpers.Store(r).CreateHostExclusion(exclusion)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogCreate, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   newExclusion.ProjectID,
+		ObjectType:  db.EventHostExclusion,
+		ObjectID:    newExclusion.ID,
+		Description: fmt.Sprintf("Host %s excluded from runs", exclusion.Host),
+	})
+
+	helpers.WriteJSON(w, http.StatusCreated, newExclusion)
+}
+
+// UpdateHostExclusion updates host exclusion in database
+func UpdateHostExclusion(w http.ResponseWriter, r *http.Request) {
+	var exclusion db.HostExclusion
+	oldExclusion := context.Get(r, "hostExclusion").(db.HostExclusion)
+
+	if !helpers.Bind(w, r, &exclusion) {
+		return
+	}
+
+	if exclusion.ID != oldExclusion.ID {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Host exclusion ID in URL and in body must be the same",
+		})
+		return
+	}
+
+	if err := exclusion.Validate(); err != nil {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if !validateExpiresAt(exclusion.ExpiresAt, w) {
+		return
+	}
+
+	exclusion.ProjectID = oldExclusion.ProjectID
+
+	if err := helpers.Store(r).UpdateHostExclusion(exclusion); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogUpdate, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   oldExclusion.ProjectID,
+		ObjectType:  db.EventHostExclusion,
+		ObjectID:    oldExclusion.ID,
+		Description: fmt.Sprintf("Exclusion of host %s updated", exclusion.Host),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveHostExclusion returns the host to the runs of the project
+func RemoveHostExclusion(w http.ResponseWriter, r *http.Request) {
+	exclusion := context.Get(r, "hostExclusion").(db.HostExclusion)
+
+	err := helpers.Store(r).DeleteHostExclusion(exclusion.ProjectID, exclusion.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogDelete, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   exclusion.ProjectID,
+		ObjectType:  db.EventHostExclusion,
+		ObjectID:    exclusion.ID,
+		Description: fmt.Sprintf("Host %s returned to runs", exclusion.Host),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
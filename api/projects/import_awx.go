@@ -0,0 +1,52 @@
+package projects
+
+import (
+	"net/http"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	projectService "github.com/semaphoreui/semaphore/services/project"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// ImportAWX creates a project from the output of `awx export`. The name of the project
+// and the imported organization are passed in the query parameters name and organization.
+func ImportAWX(w http.ResponseWriter, r *http.Request) {
+	user := context.Get(r, "user").(*db.User)
+
+	if !user.Admin && !util.Config.NonAdminCanCreateProject {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var export projectService.AWXExport
+	if !helpers.Bind(w, r, &export) {
+		return
+	}
+
+	report, err := export.Import(projectService.AWXImportOptions{
+		ProjectName:  r.URL.Query().Get("name"),
+		Organization: r.URL.Query().Get("organization"),
+		Owner:        user,
+	}, helpers.Store(r))
+
+	if err != nil {
+		log.WithError(err).Error("Failed to import AWX export")
+		helpers.WriteError(w, err)
+		return
+	}
+
+	refreshSchedulePool(r)
+
+	helpers.EventLog(r, helpers.EventLogCreate, helpers.EventLogItem{
+		UserID:      user.ID,
+		ProjectID:   report.Project.ID,
+		ObjectType:  db.EventProject,
+		ObjectID:    report.Project.ID,
+		Description: "Project imported from AWX",
+	})
+
+	helpers.WriteJSON(w, http.StatusCreated, report)
+}
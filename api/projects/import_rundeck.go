@@ -0,0 +1,62 @@
+package projects
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	projectService "github.com/semaphoreui/semaphore/services/project"
+	log "github.com/sirupsen/logrus"
+)
+
+// ImportRundeckJobs creates templates from job definitions exported from Rundeck.
+// The body is the XML, YAML or JSON export, the query parameters are the repository_id
+// of the repository where the generated scripts must be committed and the optional scripts_dir.
+func ImportRundeckJobs(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	repositoryID, err := strconv.Atoi(r.URL.Query().Get("repository_id"))
+	if err != nil {
+		helpers.WriteErrorStatus(w, "repository_id required", http.StatusBadRequest)
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	jobs, err := projectService.ParseRundeckJobs(data)
+	if err != nil {
+		helpers.WriteErrorStatus(w, "Invalid job definitions: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := projectService.ImportRundeckJobs(jobs, projectService.RundeckImportOptions{
+		ProjectID:    project.ID,
+		RepositoryID: repositoryID,
+		ScriptsDir:   r.URL.Query().Get("scripts_dir"),
+	}, helpers.Store(r))
+
+	if err != nil {
+		log.WithError(err).Error("Failed to import Rundeck jobs")
+		helpers.WriteError(w, err)
+		return
+	}
+
+	refreshSchedulePool(r)
+
+	helpers.EventLog(r, helpers.EventLogCreate, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   project.ID,
+		ObjectType:  db.EventProject,
+		ObjectID:    project.ID,
+		Description: "Templates imported from Rundeck",
+	})
+
+	helpers.WriteJSON(w, http.StatusCreated, report)
+}
@@ -1,8 +1,11 @@
 package projects
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"net/http"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -11,6 +14,12 @@ import (
 	"github.com/gorilla/context"
 )
 
+const (
+	// defaultSecretGracePeriod is the number of hours during which the rotated secret is accepted.
+	defaultSecretGracePeriod = 24
+	maxSecretGracePeriod     = 24 * 30
+)
+
 func IntegrationMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		integrationId, err := helpers.GetIntParam("integration_id", w, r)
@@ -142,6 +151,10 @@ func UpdateIntegration(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the previous secret is changed only by the rotation
+	integration.PreviousAuthSecretID = oldIntegration.PreviousAuthSecretID
+	integration.PreviousAuthSecretExpires = oldIntegration.PreviousAuthSecretExpires
+
 	err := helpers.Store(r).UpdateIntegration(integration)
 
 	if err != nil {
@@ -171,3 +184,85 @@ func DeleteIntegration(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// RotateIntegrationSecret replaces the secret of the integration. The previous secret
+// is accepted during the grace period, so the webhook sender can be updated without losing deliveries.
+func RotateIntegrationSecret(w http.ResponseWriter, r *http.Request) {
+	integration := context.Get(r, "integration").(db.Integration)
+
+	var params struct {
+		// Secret is the new secret, it is generated if empty.
+		Secret string `json:"secret"`
+		// GracePeriod is the number of hours during which the previous secret is accepted.
+		GracePeriod *int `json:"grace_period"`
+	}
+
+	if r.ContentLength > 0 && !helpers.Bind(w, r, &params) {
+		return
+	}
+
+	gracePeriod := defaultSecretGracePeriod
+	if params.GracePeriod != nil {
+		gracePeriod = *params.GracePeriod
+	}
+
+	if gracePeriod < 0 || gracePeriod > maxSecretGracePeriod {
+		helpers.WriteErrorStatus(w, fmt.Sprintf("Grace period must be from 0 to %d hours", maxSecretGracePeriod), http.StatusBadRequest)
+		return
+	}
+
+	if integration.AuthMethod == db.IntegrationAuthNone {
+		helpers.WriteErrorStatus(w, "Integration has no secret", http.StatusBadRequest)
+		return
+	}
+
+	secret := params.Secret
+	if secret == "" {
+		secretBytes := make([]byte, 32)
+		if _, err := rand.Read(secretBytes); err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+		secret = hex.EncodeToString(secretBytes)
+	}
+
+	if err := db.CheckAccessKeyQuota(helpers.Store(r), integration.ProjectID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	key, err := helpers.Store(r).CreateAccessKey(db.AccessKey{
+		Name:      fmt.Sprintf("%s secret (rotated %s)", integration.Name, time.Now().UTC().Format("2006-01-02 15:04")),
+		Type:      db.AccessKeyLoginPassword,
+		ProjectID: &integration.ProjectID,
+		LoginPassword: db.LoginPassword{
+			Password: secret,
+		},
+	})
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	integration.RotateSecret(key.ID, time.Duration(gracePeriod)*time.Hour)
+
+	if err = helpers.Store(r).UpdateIntegration(integration); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogUpdate, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   integration.ProjectID,
+		ObjectType:  db.EventIntegration,
+		ObjectID:    integration.ID,
+		Description: fmt.Sprintf("Secret of integration %s rotated", integration.Name),
+	})
+
+	helpers.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"secret":                       secret,
+		"auth_secret_id":               integration.AuthSecretID,
+		"previous_auth_secret_id":      integration.PreviousAuthSecretID,
+		"previous_auth_secret_expires": integration.PreviousAuthSecretExpires,
+	})
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/tasks"
 
 	"os"
 	"path/filepath"
@@ -47,10 +48,84 @@ func GetInventoryRefs(w http.ResponseWriter, r *http.Request) {
 	helpers.WriteJSON(w, http.StatusOK, refs)
 }
 
+// updateInventorySecrets creates, updates and deletes secrets of the inventory
+// according to operations of the secrets.
+func updateInventorySecrets(store db.Store, inventory db.Inventory) error {
+	for _, secret := range inventory.Secrets {
+		switch secret.Operation {
+		case db.EnvironmentSecretCreate:
+			_, err := store.CreateAccessKey(db.AccessKey{
+				Name:        secret.Name,
+				String:      secret.Secret,
+				InventoryID: &inventory.ID,
+				ProjectID:   &inventory.ProjectID,
+				Type:        db.AccessKeyString,
+			})
+			if err != nil {
+				return err
+			}
+		case db.EnvironmentSecretDelete, db.EnvironmentSecretUpdate:
+			key, err := store.GetAccessKey(inventory.ProjectID, secret.ID)
+			if err != nil {
+				return err
+			}
+
+			if key.InventoryID == nil || *key.InventoryID != inventory.ID {
+				return db.ErrNotFound
+			}
+
+			if secret.Operation == db.EnvironmentSecretDelete {
+				err = store.DeleteAccessKey(inventory.ProjectID, secret.ID)
+			} else {
+				updateKey := db.AccessKey{
+					ID:        key.ID,
+					ProjectID: key.ProjectID,
+					Name:      secret.Name,
+					Type:      db.AccessKeyString,
+				}
+				if secret.Secret != "" {
+					updateKey.String = secret.Secret
+					updateKey.OverrideSecret = true
+				}
+				err = store.UpdateAccessKey(updateKey)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateInventorySecrets checks secrets of the inventory before the inventory is saved.
+func validateInventorySecrets(inventory db.Inventory) error {
+	for _, secret := range inventory.Secrets {
+		if secret.Operation == db.EnvironmentSecretDelete {
+			continue
+		}
+
+		if err := secret.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // GetInventory returns an inventory from the database
 func GetInventory(w http.ResponseWriter, r *http.Request) {
 	if inventory := context.Get(r, "inventory"); inventory != nil {
-		helpers.WriteJSON(w, http.StatusOK, inventory.(db.Inventory))
+		inv := inventory.(db.Inventory)
+
+		// values of the secrets are not returned
+		if err := db.FillInventorySecrets(helpers.Store(r), &inv, false); err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		helpers.WriteJSON(w, http.StatusOK, inv)
 		return
 	}
 
@@ -93,12 +168,21 @@ func AddInventory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !mustCanUseKeys(w, r, nil, []*int{inventory.SSHKeyID, inventory.BecomeKeyID}) {
+		return
+	}
+
 	err := db.ValidateInventory(helpers.Store(r), &inventory)
 	if err != nil {
 		helpers.WriteError(w, err)
 		return
 	}
 
+	if err = validateInventorySecrets(inventory); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
 	newInventory, err := helpers.Store(r).CreateInventory(inventory)
 
 	if err != nil {
@@ -106,6 +190,17 @@ func AddInventory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	newInventory.Secrets = inventory.Secrets
+	if err = updateInventorySecrets(helpers.Store(r), newInventory); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if err = db.FillInventorySecrets(helpers.Store(r), &newInventory, false); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
 	helpers.EventLog(r, helpers.EventLogCreate, helpers.EventLogItem{
 		UserID:      helpers.UserFromContext(r).ID,
 		ProjectID:   project.ID,
@@ -179,16 +274,36 @@ func UpdateInventory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !mustCanUseKeys(w, r,
+		[]*int{oldInventory.SSHKeyID, oldInventory.BecomeKeyID},
+		[]*int{inventory.SSHKeyID, inventory.BecomeKeyID}) {
+		return
+	}
+
 	if err := db.ValidateInventory(helpers.Store(r), &inventory); err != nil {
 		helpers.WriteError(w, err)
 		return
 	}
 
+	if err := validateInventorySecrets(inventory); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if !mustNotBeUsedByRunningTasks(w, r, oldInventory.ProjectID, db.TaskObjectRef{ObjectType: db.EventInventory, ObjectID: oldInventory.ID}) {
+		return
+	}
+
 	if err := helpers.Store(r).UpdateInventory(inventory); err != nil {
 		helpers.WriteError(w, err)
 		return
 	}
 
+	if err := updateInventorySecrets(helpers.Store(r), inventory); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
 	helpers.EventLog(r, helpers.EventLogUpdate, helpers.EventLogItem{
 		UserID:      helpers.UserFromContext(r).ID,
 		ProjectID:   oldInventory.ProjectID,
@@ -203,14 +318,21 @@ func UpdateInventory(w http.ResponseWriter, r *http.Request) {
 // RemoveInventory deletes an inventory from the database
 func RemoveInventory(w http.ResponseWriter, r *http.Request) {
 	inventory := context.Get(r, "inventory").(db.Inventory)
-	var err error
+
+	refs, err := helpers.Store(r).GetInventoryRefs(inventory.ProjectID, inventory.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if !refs.IsEmpty() {
+		helpers.WriteObjectInUse(w, "Inventory is in use by one or more templates", refs)
+		return
+	}
 
 	err = helpers.Store(r).DeleteInventory(inventory.ProjectID, inventory.ID)
 	if errors.Is(err, db.ErrInvalidOperation) {
-		helpers.WriteJSON(w, http.StatusBadRequest, map[string]interface{}{
-			"error": "Inventory is in use by one or more templates",
-			"inUse": true,
-		})
+		helpers.WriteObjectInUse(w, "Inventory is in use by one or more templates", refs)
 		return
 	}
 
@@ -229,3 +351,30 @@ func RemoveInventory(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// PingInventory checks reachability of the inventory hosts with the Ansible ping module
+func PingInventory(w http.ResponseWriter, r *http.Request) {
+	inventory := context.Get(r, "inventory").(db.Inventory)
+
+	var params struct {
+		KeyID *int   `json:"key_id"`
+		Limit string `json:"limit"`
+	}
+
+	if r.ContentLength > 0 && !helpers.Bind(w, r, &params) {
+		return
+	}
+
+	if params.KeyID != nil && !userCan(r, db.CanUseProjectKeys) {
+		helpers.WriteErrorStatus(w, "You can not use access keys of the project", http.StatusForbidden)
+		return
+	}
+
+	res, err := tasks.PingInventory(helpers.Store(r), inventory.ProjectID, inventory.ID, params.KeyID, params.Limit)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, res)
+}
@@ -1,11 +1,14 @@
 package projects
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/ssh"
 
 	"github.com/gorilla/context"
 )
@@ -31,6 +34,37 @@ func KeyMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// mustCanUseKeys checks that the user can attach the keys which were not
+// attached before. It writes 403 and returns false otherwise.
+func mustCanUseKeys(w http.ResponseWriter, r *http.Request, oldKeyIDs []*int, newKeyIDs []*int) bool {
+	if userCan(r, db.CanUseProjectKeys) {
+		return true
+	}
+
+	attached := make(map[int]bool)
+	for _, id := range oldKeyIDs {
+		if id != nil {
+			attached[*id] = true
+		}
+	}
+
+	for _, id := range newKeyIDs {
+		if id != nil && !attached[*id] {
+			helpers.WriteErrorStatus(w, "You have no permission to use access keys", http.StatusForbidden)
+			return false
+		}
+	}
+
+	return true
+}
+
+func getTemplateKeyIDs(template db.Template) (res []*int) {
+	for _, vault := range template.Vaults {
+		res = append(res, vault.VaultKeyID)
+	}
+	return
+}
+
 func GetKeyRefs(w http.ResponseWriter, r *http.Request) {
 	key := context.Get(r, "accessKey").(db.AccessKey)
 	refs, err := helpers.Store(r).GetAccessKeyRefs(*key.ProjectID, key.ID)
@@ -44,13 +78,16 @@ func GetKeyRefs(w http.ResponseWriter, r *http.Request) {
 
 // GetKeys retrieves sorted keys from the database
 func GetKeys(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	now := time.Now()
+
 	if key := context.Get(r, "accessKey"); key != nil {
 		k := key.(db.AccessKey)
+		k.RotationOverdue = k.IsRotationOverdue(project.KeyRotationDays, now)
 		helpers.WriteJSON(w, http.StatusOK, k)
 		return
 	}
 
-	project := context.Get(r, "project").(db.Project)
 	var keys []db.AccessKey
 
 	keys, err := helpers.Store(r).GetAccessKeys(project.ID, helpers.QueryParams(r.URL))
@@ -60,6 +97,10 @@ func GetKeys(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for i := range keys {
+		keys[i].RotationOverdue = keys[i].IsRotationOverdue(project.KeyRotationDays, now)
+	}
+
 	helpers.WriteJSON(w, http.StatusOK, keys)
 }
 
@@ -86,6 +127,11 @@ func AddKey(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := db.CheckAccessKeyQuota(helpers.Store(r), project.ID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
 	newKey, err := helpers.Store(r).CreateAccessKey(key)
 
 	if err != nil {
@@ -121,6 +167,10 @@ func UpdateKey(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !mustNotBeUsedByRunningTasks(w, r, *oldKey.ProjectID, db.TaskObjectRef{ObjectType: db.EventKey, ObjectID: oldKey.ID}) {
+		return
+	}
+
 	repos, err := helpers.Store(r).GetRepositories(*key.ProjectID, db.RetrieveQueryParams{})
 	if err != nil {
 		helpers.WriteError(w, err)
@@ -159,12 +209,20 @@ func UpdateKey(w http.ResponseWriter, r *http.Request) {
 func RemoveKey(w http.ResponseWriter, r *http.Request) {
 	key := context.Get(r, "accessKey").(db.AccessKey)
 
-	err := helpers.Store(r).DeleteAccessKey(*key.ProjectID, key.ID)
-	if err == db.ErrInvalidOperation {
-		helpers.WriteJSON(w, http.StatusBadRequest, map[string]interface{}{
-			"error": "Access Key is in use by one or more templates",
-			"inUse": true,
-		})
+	refs, err := helpers.Store(r).GetAccessKeyRefs(*key.ProjectID, key.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if !refs.IsEmpty() {
+		helpers.WriteObjectInUse(w, "Access Key is in use by one or more templates", refs)
+		return
+	}
+
+	err = helpers.Store(r).DeleteAccessKey(*key.ProjectID, key.ID)
+	if errors.Is(err, db.ErrInvalidOperation) {
+		helpers.WriteObjectInUse(w, "Access Key is in use by one or more templates", refs)
 		return
 	}
 
@@ -183,3 +241,48 @@ func RemoveKey(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// TestKey checks that the SSH key can be parsed and decrypted and optionally
+// connects to the given host using the key.
+func TestKey(w http.ResponseWriter, r *http.Request) {
+	key := context.Get(r, "accessKey").(db.AccessKey)
+
+	var params struct {
+		Host  string `json:"host"`
+		Login string `json:"login"`
+	}
+
+	if r.ContentLength != 0 && !helpers.Bind(w, r, &params) {
+		return
+	}
+
+	if key.Type != db.AccessKeySSH {
+		helpers.WriteErrorStatus(w, "Only SSH keys can be tested", http.StatusBadRequest)
+		return
+	}
+
+	if err := key.DeserializeSecret(); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if params.Login == "" {
+		params.Login = key.SshKey.Login
+	}
+
+	if params.Host != "" && params.Login == "" {
+		helpers.WriteErrorStatus(w, "Login is required to connect to the host", http.StatusBadRequest)
+		return
+	}
+
+	res := ssh.CheckKey(ssh.AgentKey{
+		Key:         []byte(key.SshKey.PrivateKey),
+		Passphrase:  []byte(key.SshKey.Passphrase),
+		Certificate: []byte(key.SshKey.Certificate),
+	}, ssh.KeyCheckOptions{
+		Host:  params.Host,
+		Login: params.Login,
+	})
+
+	helpers.WriteJSON(w, http.StatusOK, res)
+}
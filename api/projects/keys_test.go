@@ -0,0 +1,40 @@
+package projects
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func TestMustCanUseKeys(t *testing.T) {
+	keyID := 1
+	otherKeyID := 2
+
+	request := func(role db.ProjectUserRole, oldKeyIDs []*int, newKeyIDs []*int) (bool, int) {
+		r := httptest.NewRequest("PUT", "/", nil)
+		context.Set(r, "user", &db.User{ID: 1})
+		context.Set(r, "projectUserRole", role)
+		w := httptest.NewRecorder()
+		ok := mustCanUseKeys(w, r, oldKeyIDs, newKeyIDs)
+		return ok, w.Code
+	}
+
+	if ok, _ := request(db.ProjectManager, nil, []*int{&keyID}); !ok {
+		t.Fatal("manager must be able to attach keys")
+	}
+
+	if ok, code := request(db.ProjectGuest, nil, []*int{&keyID}); ok || code != http.StatusForbidden {
+		t.Fatal("guest must not be able to attach keys")
+	}
+
+	if ok, _ := request(db.ProjectGuest, []*int{&keyID}, []*int{&keyID, nil}); !ok {
+		t.Fatal("keeping attached keys must be allowed")
+	}
+
+	if ok, _ := request(db.ProjectGuest, []*int{&keyID}, []*int{&otherKeyID}); ok {
+		t.Fatal("replacing keys must not be allowed")
+	}
+}
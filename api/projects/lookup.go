@@ -0,0 +1,301 @@
+package projects
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/context"
+	"github.com/gorilla/mux"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// namedObjectKind describes project resource which can be resolved by name.
+// Upsert delegates to the regular Add/Update handlers, so validation
+// and event logging are the same as for requests by ID.
+type namedObjectKind struct {
+	contextKey string
+	nameField  string
+	list       func(store db.Store, projectID int) ([]db.BackupEntity, error)
+	get        func(store db.Store, projectID int, objectID int) (any, error)
+	add        http.HandlerFunc
+	update     http.HandlerFunc
+	// permissions are required in addition to the permissions of the regular handlers.
+	permissions db.ProjectUserPermission
+}
+
+func toEntities[T db.BackupEntity](items []T, err error) ([]db.BackupEntity, error) {
+	if err != nil {
+		return nil, err
+	}
+	res := make([]db.BackupEntity, len(items))
+	for i := range items {
+		res[i] = items[i]
+	}
+	return res, nil
+}
+
+var namedObjectKinds = map[string]namedObjectKind{
+	"templates": {
+		contextKey: "template",
+		nameField:  "name",
+		list: func(store db.Store, projectID int) ([]db.BackupEntity, error) {
+			return toEntities(store.GetTemplates(projectID, db.TemplateFilter{}, db.RetrieveQueryParams{}))
+		},
+		get: func(store db.Store, projectID int, objectID int) (any, error) {
+			return store.GetTemplate(projectID, objectID)
+		},
+		add:    AddTemplate,
+		update: UpdateTemplate,
+	},
+	"repositories": {
+		contextKey: "repository",
+		nameField:  "name",
+		list: func(store db.Store, projectID int) ([]db.BackupEntity, error) {
+			return toEntities(store.GetRepositories(projectID, db.RetrieveQueryParams{}))
+		},
+		get: func(store db.Store, projectID int, objectID int) (any, error) {
+			return store.GetRepository(projectID, objectID)
+		},
+		add:    AddRepository,
+		update: UpdateRepository,
+	},
+	"inventory": {
+		contextKey: "inventory",
+		nameField:  "name",
+		list: func(store db.Store, projectID int) ([]db.BackupEntity, error) {
+			return toEntities(store.GetInventories(projectID, db.RetrieveQueryParams{}))
+		},
+		get: func(store db.Store, projectID int, objectID int) (any, error) {
+			return store.GetInventory(projectID, objectID)
+		},
+		add:    AddInventory,
+		update: UpdateInventory,
+	},
+	"environment": {
+		contextKey: "environment",
+		nameField:  "name",
+		list: func(store db.Store, projectID int) ([]db.BackupEntity, error) {
+			return toEntities(store.GetEnvironments(projectID, db.RetrieveQueryParams{}))
+		},
+		get: func(store db.Store, projectID int, objectID int) (any, error) {
+			return store.GetEnvironment(projectID, objectID)
+		},
+		add:    AddEnvironment,
+		update: UpdateEnvironment,
+	},
+	"keys": {
+		contextKey: "accessKey",
+		nameField:  "name",
+		list: func(store db.Store, projectID int) ([]db.BackupEntity, error) {
+			return toEntities(store.GetAccessKeys(projectID, db.RetrieveQueryParams{}))
+		},
+		get: func(store db.Store, projectID int, objectID int) (any, error) {
+			return store.GetAccessKey(projectID, objectID)
+		},
+		add:         AddKey,
+		update:      UpdateKey,
+		permissions: db.CanViewProjectKeys,
+	},
+	"views": {
+		contextKey: "view",
+		nameField:  "title",
+		list: func(store db.Store, projectID int) ([]db.BackupEntity, error) {
+			return toEntities(store.GetViews(projectID))
+		},
+		get: func(store db.Store, projectID int, objectID int) (any, error) {
+			return store.GetView(projectID, objectID)
+		},
+		add:    AddView,
+		update: UpdateView,
+	},
+}
+
+func getNamedObjectKind(w http.ResponseWriter, r *http.Request) (kind namedObjectKind, ok bool) {
+	kind, ok = namedObjectKinds[mux.Vars(r)["kind"]]
+	if !ok {
+		helpers.WriteErrorStatus(w, "Unknown object kind", http.StatusNotFound)
+		return
+	}
+
+	if !userCan(r, kind.permissions) {
+		w.WriteHeader(http.StatusForbidden)
+		ok = false
+	}
+	return
+}
+
+func findObjectIDByName(store db.Store, kind namedObjectKind, projectID int, name string) (int, error) {
+	items, err := kind.list(store, projectID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, item := range items {
+		if item.GetName() == name {
+			return item.GetID(), nil
+		}
+	}
+
+	return 0, db.ErrNotFound
+}
+
+// GetProjectByName returns the project of the current user with the given name
+func GetProjectByName(w http.ResponseWriter, r *http.Request) {
+	user := context.Get(r, "user").(*db.User)
+	name := r.URL.Query().Get("name")
+
+	var err error
+	var projects []db.Project
+	if user.Admin {
+		projects, err = helpers.Store(r).GetAllProjects()
+	} else {
+		projects, err = helpers.Store(r).GetProjects(user.ID)
+	}
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	for _, project := range projects {
+		if project.Name == name {
+			helpers.WriteJSON(w, http.StatusOK, project)
+			return
+		}
+	}
+
+	helpers.WriteError(w, db.ErrNotFound)
+}
+
+// GetObjectByName returns project resource by its name,
+// for example GET /project/1/lookup/templates?name=Deploy
+func GetObjectByName(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	store := helpers.Store(r)
+
+	kind, ok := getNamedObjectKind(w, r)
+	if !ok {
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		helpers.WriteErrorStatus(w, "Query parameter name required", http.StatusBadRequest)
+		return
+	}
+
+	objectID, err := findObjectIDByName(store, kind, project.ID, name)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	object, err := kind.get(store, project.ID, objectID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, object)
+}
+
+// upsertResponseWriter replaces empty 204 response of update handlers
+// with the updated object.
+type upsertResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *upsertResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *upsertResponseWriter) WriteHeader(status int) {
+	w.status = status
+	if status == http.StatusNoContent {
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *upsertResponseWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// UpsertObjectByName creates project resource or updates the existing
+// resource with the same name. Responds 201 for created and 200 for updated object.
+func UpsertObjectByName(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	store := helpers.Store(r)
+
+	kind, ok := getNamedObjectKind(w, r)
+	if !ok {
+		return
+	}
+
+	var body map[string]any
+	if !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	name, _ := body[kind.nameField].(string)
+	if name == "" {
+		helpers.WriteErrorStatus(w, "Field "+kind.nameField+" required", http.StatusBadRequest)
+		return
+	}
+
+	objectID, err := findObjectIDByName(store, kind, project.ID, name)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	body["project_id"] = project.ID
+	isNew := errors.Is(err, db.ErrNotFound)
+	if isNew {
+		delete(body, "id")
+	} else {
+		body["id"] = objectID
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+
+	if isNew {
+		kind.add(w, r)
+		return
+	}
+
+	oldObject, err := kind.get(store, project.ID, objectID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+	context.Set(r, kind.contextKey, oldObject)
+
+	uw := &upsertResponseWriter{ResponseWriter: w}
+	kind.update(uw, r)
+
+	if uw.status != http.StatusNoContent {
+		return
+	}
+
+	object, err := kind.get(store, project.ID, objectID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, object)
+}
@@ -0,0 +1,62 @@
+package projects
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/context"
+	"github.com/gorilla/mux"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestUpsertObjectByName(t *testing.T) {
+	util.Config = &util.ConfigType{TmpPath: "/tmp"}
+
+	store := bolt.CreateTestStore()
+	project, _ := store.CreateProject(db.Project{Name: "Test"})
+	key, _ := store.CreateAccessKey(db.AccessKey{ProjectID: &project.ID, Name: "None", Type: db.AccessKeyNone})
+
+	request := func(method string, body string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/lookup/repositories?name=Repo", strings.NewReader(body))
+		r = mux.SetURLVars(r, map[string]string{"kind": "repositories"})
+		context.Set(r, "store", store)
+		context.Set(r, "project", project)
+		context.Set(r, "user", &db.User{ID: 1})
+		context.Set(r, "projectUserRole", db.ProjectManager)
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w
+	}
+
+	body := fmt.Sprintf(`{"name": "Repo", "git_url": "git@example.com:test", "git_branch": "%s", "ssh_key_id": %d}`, "%s", key.ID)
+
+	if w := request("GET", "", GetObjectByName); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+
+	if w := request("PUT", fmt.Sprintf(body, "main"), UpsertObjectByName); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+
+	if w := request("PUT", fmt.Sprintf(body, "dev"), UpsertObjectByName); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	repos, err := store.GetRepositories(project.ID, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(repos) != 1 || repos[0].GitBranch != "dev" {
+		t.Fatal("repository must be updated instead of created twice")
+	}
+
+	if w := request("GET", "", GetObjectByName); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
@@ -0,0 +1,155 @@
+package projects
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+
+	"github.com/gorilla/context"
+)
+
+// MaskingRuleMiddleware ensures a masking rule exists and loads it to the context
+func MaskingRuleMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		project := context.Get(r, "project").(db.Project)
+		ruleID, err := helpers.GetIntParam("rule_id", w, r)
+		if err != nil {
+			return
+		}
+
+		rule, err := helpers.Store(r).GetMaskingRule(project.ID, ruleID)
+
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		context.Set(r, "maskingRule", rule)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetMaskingRules retrieves masking rules of the project
+func GetMaskingRules(w http.ResponseWriter, r *http.Request) {
+	if rule := context.Get(r, "maskingRule"); rule != nil {
+		helpers.WriteJSON(w, http.StatusOK, rule.(db.MaskingRule))
+		return
+	}
+
+	project := context.Get(r, "project").(db.Project)
+
+	rules, err := helpers.Store(r).GetMaskingRules(project.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, rules)
+}
+
+// AddMaskingRule adds a new masking rule to the database
+func AddMaskingRule(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	var rule db.MaskingRule
+
+	if !helpers.Bind(w, r, &rule) {
+		return
+	}
+
+	if rule.ProjectID != project.ID {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Project ID in body and URL must be the same",
+		})
+		return
+	}
+
+	if err := rule.Validate(); err != nil {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	newRule, err := helpers.Store(r).CreateMaskingRule(rule)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogCreate, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   newRule.ProjectID,
+		ObjectType:  db.EventMaskingRule,
+		ObjectID:    newRule.ID,
+		Description: fmt.Sprintf("Masking rule %s created", rule.Name),
+	})
+
+	helpers.WriteJSON(w, http.StatusCreated, newRule)
+}
+
+// UpdateMaskingRule updates masking rule in database
+func UpdateMaskingRule(w http.ResponseWriter, r *http.Request) {
+	var rule db.MaskingRule
+	oldRule := context.Get(r, "maskingRule").(db.MaskingRule)
+
+	if !helpers.Bind(w, r, &rule) {
+		return
+	}
+
+	if rule.ID != oldRule.ID {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Masking rule ID in URL and in body must be the same",
+		})
+		return
+	}
+
+	if err := rule.Validate(); err != nil {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	rule.ProjectID = oldRule.ProjectID
+
+	if err := helpers.Store(r).UpdateMaskingRule(rule); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogUpdate, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   oldRule.ProjectID,
+		ObjectType:  db.EventMaskingRule,
+		ObjectID:    oldRule.ID,
+		Description: fmt.Sprintf("Masking rule %s updated", rule.Name),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveMaskingRule deletes a masking rule from the database
+func RemoveMaskingRule(w http.ResponseWriter, r *http.Request) {
+	rule := context.Get(r, "maskingRule").(db.MaskingRule)
+
+	err := helpers.Store(r).DeleteMaskingRule(rule.ProjectID, rule.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogDelete, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   rule.ProjectID,
+		ObjectType:  db.EventMaskingRule,
+		ObjectID:    rule.ID,
+		Description: fmt.Sprintf("Masking rule %s deleted", rule.Name),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
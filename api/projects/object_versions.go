@@ -0,0 +1,65 @@
+package projects
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// mustNotBeUsedByRunningTasks checks if the object is used by running tasks before it is saved.
+// The changes are rejected if the instance blocks such changes, otherwise the response gets
+// the Warning header, because the changes affect only next tasks.
+func mustNotBeUsedByRunningTasks(w http.ResponseWriter, r *http.Request, projectID int, ref db.TaskObjectRef) bool {
+	runningTasks, err := db.GetRunningTasksUsingObject(helpers.Store(r), projectID, ref)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return false
+	}
+
+	if len(runningTasks) == 0 {
+		return true
+	}
+
+	ids := make([]string, 0, len(runningTasks))
+	for _, task := range runningTasks {
+		ids = append(ids, strconv.Itoa(task.ID))
+	}
+
+	msg := fmt.Sprintf("The %s is used by running tasks %s", ref.ObjectType, strings.Join(ids, ", "))
+
+	if db.GetInUseObjectEditMode() == db.InUseObjectEditBlock {
+		helpers.WriteCodedError(w, &db.CodedError{
+			Code:    db.ErrorCodeObjectUsedByRunningTask,
+			Message: msg,
+			Hint:    "Save the changes when the tasks are finished",
+		})
+		return false
+	}
+
+	w.Header().Add("Warning", fmt.Sprintf("299 - %q", msg+", the changes affect only next tasks"))
+	return true
+}
+
+// GetTaskObjectVersions returns versions of the inventory, environments and keys used by the task
+// and whether they were changed after the task started.
+func GetTaskObjectVersions(w http.ResponseWriter, r *http.Request) {
+	task := context.Get(r, "task").(db.Task)
+
+	versions, err := helpers.Store(r).GetTaskObjectVersions(task.ProjectID, task.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if err = db.FillTaskObjectVersionsChanged(helpers.Store(r), versions); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, versions)
+}
@@ -1,12 +1,18 @@
 package projects
 
 import (
+	"fmt"
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
+	projectService "github.com/semaphoreui/semaphore/services/project"
+	"github.com/semaphoreui/semaphore/util"
 	"github.com/gorilla/mux"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gorilla/context"
+	log "github.com/sirupsen/logrus"
 )
 
 // ProjectMiddleware ensures a project exists and loads it to the context
@@ -26,6 +32,12 @@ func ProjectMiddleware(next http.Handler) http.Handler {
 		// check if user in project's team
 		projectUser, err := helpers.Store(r).GetProjectUser(projectID, user.ID)
 
+		if err == nil && projectUser.IsExpired(time.Now()) {
+			// expired membership is removed by the housekeeping job later
+			projectUser = db.ProjectUser{}
+			err = db.ErrNotFound
+		}
+
 		if !user.Admin && err != nil {
 			helpers.WriteError(w, err)
 			return
@@ -38,12 +50,46 @@ func ProjectMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		if project.Archived && !canModifyArchivedProject(r) {
+			helpers.WriteError(w, db.ErrProjectArchived)
+			return
+		}
+
 		context.Set(r, "projectUserRole", projectUser.Role)
 		context.Set(r, "project", project)
 		next.ServeHTTP(w, r)
 	})
 }
 
+// canModifyArchivedProject returns true if the request is allowed for the archived project.
+// Archived project is read-only: it can only be unarchived, cloned, left or deleted.
+func canModifyArchivedProject(r *http.Request) bool {
+	if r.Method == "GET" || r.Method == "HEAD" {
+		return true
+	}
+
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return false
+	}
+
+	tpl, err := route.GetPathTemplate()
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case strings.HasSuffix(tpl, "/project/{project_id}/archive"),
+		strings.HasSuffix(tpl, "/project/{project_id}/clone"),
+		strings.HasSuffix(tpl, "/project/{project_id}/me"):
+		return true
+	case strings.HasSuffix(tpl, "/project/{project_id}"):
+		return r.Method == "DELETE"
+	default:
+		return false
+	}
+}
+
 // GetMustCanMiddleware ensures that the user has administrator rights
 func GetMustCanMiddleware(permissions db.ProjectUserPermission) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
@@ -61,6 +107,29 @@ func GetMustCanMiddleware(permissions db.ProjectUserPermission) mux.MiddlewareFu
 	}
 }
 
+// GetMustCanAlwaysMiddleware checks the permissions for all requests
+// including GET and HEAD ones.
+func GetMustCanAlwaysMiddleware(permissions db.ProjectUserPermission) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !userCan(r, permissions) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// userCan returns true if the current user has the permissions in the project.
+// Admins have all permissions.
+func userCan(r *http.Request, permissions db.ProjectUserPermission) bool {
+	me := context.Get(r, "user").(*db.User)
+	myRole, _ := context.Get(r, "projectUserRole").(db.ProjectUserRole)
+	return me.Admin || myRole.Can(permissions)
+}
+
 // GetProject returns a project details
 func GetProject(w http.ResponseWriter, r *http.Request) {
 	helpers.WriteJSON(w, http.StatusOK, context.Get(r, "project"))
@@ -92,6 +161,33 @@ func UpdateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !mustCanUseKeys(w, r, project.GetDefaultKeyIDs(), body.GetDefaultKeyIDs()) {
+		return
+	}
+
+	if err := db.ValidateProjectDefaultKeys(helpers.Store(r), &body); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if body.AlertDigest != "" && !validateCronFormat(body.AlertDigest, w) {
+		return
+	}
+
+	if body.AccessReview != "" && !validateCronFormat(body.AccessReview, w) {
+		return
+	}
+
+	if body.KeyRotationDays < 0 {
+		helpers.WriteErrorStatus(w, "Key rotation period must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	if err := body.ValidateProtectedBranches(); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
 	err := helpers.Store(r).UpdateProject(body)
 
 	if err != nil {
@@ -99,6 +195,10 @@ func UpdateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if body.AlertDigest != project.AlertDigest || body.AccessReview != project.AccessReview {
+		refreshSchedulePool(r)
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -115,3 +215,96 @@ func DeleteProject(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// ArchiveProject makes the project read-only and stops its active tasks
+func ArchiveProject(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	user := context.Get(r, "user").(*db.User)
+
+	if project.Archived {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	err := helpers.Store(r).SetProjectArchived(project.ID, true)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	err = helpers.TaskPool(r).StopProjectTasks(project.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogUpdate, helpers.EventLogItem{
+		UserID:      user.ID,
+		ProjectID:   project.ID,
+		ObjectType:  db.EventProject,
+		ObjectID:    project.ID,
+		Description: fmt.Sprintf("Project %s archived", project.Name),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnarchiveProject restores the archived project
+func UnarchiveProject(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	user := context.Get(r, "user").(*db.User)
+
+	if !project.Archived {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	err := helpers.Store(r).SetProjectArchived(project.ID, false)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogUpdate, helpers.EventLogItem{
+		UserID:      user.ID,
+		ProjectID:   project.ID,
+		ObjectType:  db.EventProject,
+		ObjectID:    project.ID,
+		Description: fmt.Sprintf("Project %s unarchived", project.Name),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CloneProject copies the project resources into a new project
+func CloneProject(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	user := context.Get(r, "user").(*db.User)
+
+	if !user.Admin && !util.Config.NonAdminCanCreateProject {
+		log.Warn(user.Username + " is not permitted to create projects")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var opts projectService.CloneOptions
+	if !helpers.Bind(w, r, &opts) {
+		return
+	}
+
+	newProject, err := projectService.CloneProject(project.ID, opts, *user, helpers.Store(r))
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogCreate, helpers.EventLogItem{
+		UserID:      user.ID,
+		ProjectID:   newProject.ID,
+		ObjectType:  db.EventProject,
+		ObjectID:    newProject.ID,
+		Description: fmt.Sprintf("Project cloned from project ID %d", project.ID),
+	})
+
+	helpers.WriteJSON(w, http.StatusCreated, newProject)
+}
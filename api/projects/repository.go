@@ -78,6 +78,10 @@ func AddRepository(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
+	if !mustCanUseKeys(w, r, nil, []*int{&repository.SSHKeyID}) {
+		return
+	}
+
 	if err := db.ValidateRepository(helpers.Store(r), &repository); err != nil {
 		helpers.WriteError(w, err)
 		return
@@ -124,6 +128,10 @@ func UpdateRepository(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !mustCanUseKeys(w, r, []*int{&oldRepo.SSHKeyID}, []*int{&repository.SSHKeyID}) {
+		return
+	}
+
 	if err := db.ValidateRepository(helpers.Store(r), &repository); err != nil {
 		helpers.WriteError(w, err)
 		return
@@ -153,14 +161,20 @@ func UpdateRepository(w http.ResponseWriter, r *http.Request) {
 func RemoveRepository(w http.ResponseWriter, r *http.Request) {
 	repository := context.Get(r, "repository").(db.Repository)
 
-	var err error
+	refs, err := helpers.Store(r).GetRepositoryRefs(repository.ProjectID, repository.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if !refs.IsEmpty() {
+		helpers.WriteObjectInUse(w, "Repository is in use by one or more templates", refs)
+		return
+	}
 
 	err = helpers.Store(r).DeleteRepository(repository.ProjectID, repository.ID)
 	if errors.Is(err, db.ErrInvalidOperation) {
-		helpers.WriteJSON(w, http.StatusBadRequest, map[string]interface{}{
-			"error": "Repository is in use by one or more templates",
-			"inUse": true,
-		})
+		helpers.WriteObjectInUse(w, "Repository is in use by one or more templates", refs)
 		return
 	}
 
@@ -0,0 +1,72 @@
+package projects
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// GetRunRecords returns the run records of the project in the order of the chain.
+func GetRunRecords(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	records, err := helpers.Store(r).GetRunRecords(project.ID, helpers.QueryParams(r.URL))
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, records)
+}
+
+// VerifyRunRecords checks the hash chain of the project run records.
+func VerifyRunRecords(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	records, err := helpers.Store(r).GetRunRecords(project.ID, db.RetrieveQueryParams{})
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, db.VerifyRunRecordChain(records))
+}
+
+// GetRunRecordAttestation returns the signed attestation of the run record.
+func GetRunRecordAttestation(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	recordID, err := helpers.GetIntParam("record_id", w, r)
+	if err != nil {
+		return
+	}
+
+	record, err := helpers.Store(r).GetRunRecord(project.ID, recordID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if record.Hash != record.ComputeHash() {
+		helpers.WriteErrorStatus(w, "Run record was modified", http.StatusConflict)
+		return
+	}
+
+	key, err := util.Config.GetRunAttestationKey()
+	if err != nil {
+		helpers.WriteErrorStatus(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	attestation, err := db.NewRunAttestation(record, key, time.Now())
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, attestation)
+}
@@ -3,6 +3,7 @@ package projects
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
@@ -53,6 +54,20 @@ func GetProjectSchedules(w http.ResponseWriter, r *http.Request) {
 
 	helpers.WriteJSON(w, http.StatusOK, tplSchedules)
 }
+// GetProjectScheduleStatuses reports the last and the expected fire time of the project schedules
+func GetProjectScheduleStatuses(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	pool := context.Get(r, "schedule_pool").(schedules.SchedulePool)
+
+	statuses, err := pool.GetProjectScheduleStatuses(project.ID, time.Now())
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, statuses)
+}
+
 func GetTemplateSchedules(w http.ResponseWriter, r *http.Request) {
 	project := context.Get(r, "project").(db.Project)
 	templateID, err := helpers.GetIntParam("template_id", w, r)
@@ -83,6 +98,31 @@ func validateCronFormat(cronFormat string, w http.ResponseWriter) bool {
 	return false
 }
 
+// validateSchedule checks the timing of the schedule. Interval schedules have no cron format.
+func validateSchedule(schedule *db.Schedule, w http.ResponseWriter) bool {
+	if err := schedule.ValidateFailurePolicy(); err != nil {
+		helpers.WriteError(w, err)
+		return false
+	}
+
+	switch schedule.Type {
+	case "", db.ScheduleTypeCron:
+		return validateCronFormat(schedule.CronFormat, w)
+	case db.ScheduleTypeInterval:
+		if err := schedule.ValidateInterval(); err != nil {
+			helpers.WriteError(w, err)
+			return false
+		}
+		schedule.CronFormat = ""
+		return true
+	default:
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Unknown schedule type " + string(schedule.Type),
+		})
+		return false
+	}
+}
+
 func ValidateScheduleCronFormat(w http.ResponseWriter, r *http.Request) {
 	var schedule db.Schedule
 	if !helpers.Bind(w, r, &schedule) {
@@ -92,6 +132,25 @@ func ValidateScheduleCronFormat(w http.ResponseWriter, r *http.Request) {
 	_ = validateCronFormat(schedule.CronFormat, w)
 }
 
+// PreviewScheduleCronFormat validates the cron format with the time zone
+// and returns the next fire times, so clients can check the schedule before saving.
+func PreviewScheduleCronFormat(w http.ResponseWriter, r *http.Request) {
+	var req schedules.CronPreviewRequest
+	if !helpers.Bind(w, r, &req) {
+		return
+	}
+
+	preview, err := schedules.PreviewCronFormat(req, time.Now())
+	if err != nil {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Cron: " + err.Error(),
+		})
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, preview)
+}
+
 // AddSchedule adds a template to the database
 func AddSchedule(w http.ResponseWriter, r *http.Request) {
 	project := context.Get(r, "project").(db.Project)
@@ -101,7 +160,7 @@ func AddSchedule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !validateCronFormat(schedule.CronFormat, w) {
+	if !validateSchedule(&schedule, w) {
 		return
 	}
 
@@ -150,7 +209,7 @@ func UpdateSchedule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !validateCronFormat(schedule.CronFormat, w) {
+	if !validateSchedule(&schedule, w) {
 		return
 	}
 
@@ -160,6 +219,15 @@ func UpdateSchedule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// enabling of the schedule disabled by failures starts counting failures again
+	if schedule.Active && oldSchedule.AutoDisabled {
+		err = helpers.Store(r).SetScheduleActive(schedule.ProjectID, schedule.ID, true)
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+	}
+
 	helpers.EventLog(r, helpers.EventLogUpdate, helpers.EventLogItem{
 		UserID:      helpers.UserFromContext(r).ID,
 		ProjectID:   oldSchedule.ProjectID,
@@ -173,6 +241,8 @@ func UpdateSchedule(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// SetScheduleActive enables or disables the schedule. Enabling of the schedule
+// which was disabled by consecutive failures resets the counter of failures.
 func SetScheduleActive(w http.ResponseWriter, r *http.Request) {
 	oldSchedule := context.Get(r, "schedule").(db.Schedule)
 
@@ -0,0 +1,317 @@
+package projects
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// ServiceAccountMiddleware ensures a service account exists and loads it to the context
+func ServiceAccountMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		project := context.Get(r, "project").(db.Project)
+		accountID, err := helpers.GetIntParam("account_id", w, r)
+		if err != nil {
+			return
+		}
+
+		account, err := helpers.Store(r).GetServiceAccount(project.ID, accountID)
+
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		context.Set(r, "serviceAccount", account)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validateServiceAccountTemplates checks that the templates of the account exist in the project.
+func validateServiceAccountTemplates(w http.ResponseWriter, r *http.Request, account db.ServiceAccount) bool {
+	for _, id := range account.TemplateIDs {
+		_, err := helpers.Store(r).GetTemplate(account.ProjectID, id)
+
+		if err == db.ErrNotFound {
+			helpers.WriteErrorStatus(w, fmt.Sprintf("Template %d not found", id), http.StatusBadRequest)
+			return false
+		}
+
+		if err != nil {
+			helpers.WriteError(w, err)
+			return false
+		}
+	}
+
+	return true
+}
+
+// issueServiceAccountToken replaces the token of the account. The old token stops working.
+func issueServiceAccountToken(r *http.Request, account *db.ServiceAccount) error {
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return err
+	}
+	secret := base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	if err := helpers.Store(r).SetServiceAccountToken(account.ProjectID, account.ID, db.HashTaskShareToken(secret)); err != nil {
+		return err
+	}
+
+	account.Token = db.MakeServiceAccountToken(account.ID, secret)
+	return nil
+}
+
+// GetServiceAccounts returns service accounts of the project
+func GetServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	if account := context.Get(r, "serviceAccount"); account != nil {
+		helpers.WriteJSON(w, http.StatusOK, account.(db.ServiceAccount))
+		return
+	}
+
+	project := context.Get(r, "project").(db.Project)
+
+	accounts, err := helpers.Store(r).GetServiceAccounts(project.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, accounts)
+}
+
+// AddServiceAccount creates the service account with the token.
+// The token is returned only once.
+func AddServiceAccount(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	var account db.ServiceAccount
+
+	if !helpers.Bind(w, r, &account) {
+		return
+	}
+
+	if account.ProjectID != project.ID {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Project ID in body and URL must be the same",
+		})
+		return
+	}
+
+	if err := account.Validate(); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if !validateServiceAccountTemplates(w, r, account) {
+		return
+	}
+
+	account.UserID = &helpers.UserFromContext(r).ID
+
+	newAccount, err := helpers.Store(r).CreateServiceAccount(account)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if err = issueServiceAccountToken(r, &newAccount); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogCreate, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   newAccount.ProjectID,
+		ObjectType:  db.EventServiceAccount,
+		ObjectID:    newAccount.ID,
+		Description: fmt.Sprintf("Service account %s created", newAccount.Name),
+	})
+
+	helpers.WriteJSON(w, http.StatusCreated, newAccount)
+}
+
+// UpdateServiceAccount updates the name, the templates and the state of the service account
+func UpdateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	var account db.ServiceAccount
+	oldAccount := context.Get(r, "serviceAccount").(db.ServiceAccount)
+
+	if !helpers.Bind(w, r, &account) {
+		return
+	}
+
+	if account.ID != oldAccount.ID {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Service account ID in URL and in body must be the same",
+		})
+		return
+	}
+
+	account.ProjectID = oldAccount.ProjectID
+
+	if err := account.Validate(); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if !validateServiceAccountTemplates(w, r, account) {
+		return
+	}
+
+	if err := helpers.Store(r).UpdateServiceAccount(account); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogUpdate, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   oldAccount.ProjectID,
+		ObjectType:  db.EventServiceAccount,
+		ObjectID:    oldAccount.ID,
+		Description: fmt.Sprintf("Service account %s updated", account.Name),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RotateServiceAccountToken issues the new token of the service account and revokes the old one.
+func RotateServiceAccountToken(w http.ResponseWriter, r *http.Request) {
+	account := context.Get(r, "serviceAccount").(db.ServiceAccount)
+
+	if err := issueServiceAccountToken(r, &account); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogUpdate, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   account.ProjectID,
+		ObjectType:  db.EventServiceAccount,
+		ObjectID:    account.ID,
+		Description: fmt.Sprintf("Token of service account %s rotated", account.Name),
+	})
+
+	helpers.WriteJSON(w, http.StatusOK, account)
+}
+
+// RemoveServiceAccount deletes the service account. Its tasks are kept.
+func RemoveServiceAccount(w http.ResponseWriter, r *http.Request) {
+	account := context.Get(r, "serviceAccount").(db.ServiceAccount)
+
+	if err := helpers.Store(r).DeleteServiceAccount(account.ProjectID, account.ID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogDelete, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   account.ProjectID,
+		ObjectType:  db.EventServiceAccount,
+		ObjectID:    account.ID,
+		Description: fmt.Sprintf("Service account %s deleted", account.Name),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getServiceAccountToken returns the token passed in the authorization header.
+func getServiceAccountToken(r *http.Request) string {
+	fields := strings.Fields(r.Header.Get("authorization"))
+	if len(fields) != 2 || !strings.EqualFold(fields[0], "bearer") {
+		return ""
+	}
+	return fields[1]
+}
+
+// ServiceAccountAuthentication authenticates requests of the service account by its token
+// and loads the account and its project to the context. The request is not tied to a user.
+func ServiceAccountAuthentication(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		projectID, err := helpers.GetIntParam("project_id", w, r)
+		if err != nil {
+			return
+		}
+
+		accountID, secret, ok := db.ParseServiceAccountToken(getServiceAccountToken(r))
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		// the same response for unknown, disabled and foreign accounts
+		account, err := helpers.Store(r).GetServiceAccount(projectID, accountID)
+		if err != nil || !account.CheckToken(secret) || account.Disabled {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		project, err := helpers.Store(r).GetProject(projectID)
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		if project.Archived {
+			helpers.WriteError(w, db.ErrProjectArchived)
+			return
+		}
+
+		context.Set(r, "serviceAccount", account)
+		context.Set(r, "project", project)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AddServiceAccountTask launches the task of the template allowed for the service account.
+func AddServiceAccountTask(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	account := context.Get(r, "serviceAccount").(db.ServiceAccount)
+
+	var taskObj db.Task
+
+	if !helpers.Bind(w, r, &taskObj) {
+		return
+	}
+
+	if !account.CanLaunch(taskObj.TemplateID) {
+		helpers.WriteErrorCode(w, "Service account can not launch the template", db.ErrorCodeForbidden)
+		return
+	}
+
+	taskObj.IntegrationID = nil
+	taskObj.ScheduleID = nil
+	taskObj.ServiceAccountID = &account.ID
+
+	launchTask(w, r, project, taskObj, nil)
+}
+
+// GetServiceAccountTask returns the task launched by the service account, e.g. to wait for its result.
+func GetServiceAccountTask(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	account := context.Get(r, "serviceAccount").(db.ServiceAccount)
+
+	taskID, err := helpers.GetIntParam("task_id", w, r)
+	if err != nil {
+		return
+	}
+
+	task, err := helpers.Store(r).GetTask(project.ID, taskID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if task.ServiceAccountID == nil || *task.ServiceAccountID != account.ID {
+		helpers.WriteError(w, db.ErrNotFound)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, task)
+}
@@ -0,0 +1,140 @@
+package projects
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/tasks"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// taskApprovalPage is shown by the link of the approval email. The decision is applied
+// by the form submission, so email scanners which open links do not approve tasks.
+var taskApprovalPage = template.Must(template.New("approval").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Product}}</title>
+<style>
+body { font-family: sans-serif; max-width: 480px; margin: 40px auto; padding: 0 16px; }
+button { font-size: 1.1em; padding: 12px 24px; width: 100%; }
+</style>
+</head>
+<body>
+<h2>{{.Product}}</h2>
+<p>{{.Message}}</p>
+{{if .Decision}}
+<form method="post">
+<input type="hidden" name="token" value="{{.Token}}">
+<input type="hidden" name="decision" value="{{.Decision}}">
+<button type="submit">{{if eq .Decision "approve"}}Approve{{else}}Reject{{end}} task #{{.TaskID}}</button>
+</form>
+{{end}}
+</body>
+</html>
+`))
+
+type taskApprovalPageData struct {
+	Product  string
+	Message  string
+	TaskID   int
+	Token    string
+	Decision db.TaskApprovalDecision
+}
+
+func writeTaskApprovalPage(w http.ResponseWriter, status int, data taskApprovalPageData) {
+	data.Product = util.Config.GetBranding().ProductName
+	if data.Product == "" {
+		data.Product = "Semaphore"
+	}
+
+	w.Header().Set("content-type", "text/html; charset=utf-8")
+	w.Header().Set("cache-control", "no-store")
+	w.Header().Set("referrer-policy", "no-referrer")
+	w.WriteHeader(status)
+
+	_ = taskApprovalPage.Execute(w, data)
+}
+
+// GetTaskApprovalPage shows the page which asks the approver to confirm the decision
+// of the approval email link. Unknown, used and expired links get the same response.
+func GetTaskApprovalPage(w http.ResponseWriter, r *http.Request) {
+	projectID, err := helpers.GetIntParam("project_id", w, r)
+	if err != nil {
+		return
+	}
+
+	approvalID, err := helpers.GetIntParam("approval_id", w, r)
+	if err != nil {
+		return
+	}
+
+	query := r.URL.Query()
+	token := query.Get("token")
+	decision := db.TaskApprovalDecision(query.Get("decision"))
+
+	approval, err := helpers.Store(r).GetTaskApproval(projectID, approvalID)
+	if err != nil || !decision.IsValid() || !approval.CheckToken(token) || !approval.IsUsable(time.Now()) {
+		writeTaskApprovalPage(w, http.StatusNotFound, taskApprovalPageData{
+			Message: "The link is invalid, expired or already used.",
+		})
+		return
+	}
+
+	message := "Do you approve the task?"
+	if decision == db.TaskApprovalReject {
+		message = "Do you reject the task?"
+	}
+
+	writeTaskApprovalPage(w, http.StatusOK, taskApprovalPageData{
+		Message:  message,
+		TaskID:   approval.TaskID,
+		Token:    token,
+		Decision: decision,
+	})
+}
+
+// DecideTaskApproval confirms or stops the task by the form of the approval page.
+func DecideTaskApproval(w http.ResponseWriter, r *http.Request) {
+	projectID, err := helpers.GetIntParam("project_id", w, r)
+	if err != nil {
+		return
+	}
+
+	approvalID, err := helpers.GetIntParam("approval_id", w, r)
+	if err != nil {
+		return
+	}
+
+	decision := db.TaskApprovalDecision(r.PostFormValue("decision"))
+	if !decision.IsValid() {
+		writeTaskApprovalPage(w, http.StatusBadRequest, taskApprovalPageData{
+			Message: "The decision is invalid.",
+		})
+		return
+	}
+
+	task, err := helpers.TaskPool(r).DecideTaskApproval(projectID, approvalID, r.PostFormValue("token"), decision)
+
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		writeTaskApprovalPage(w, http.StatusNotFound, taskApprovalPageData{
+			Message: "The link is invalid, expired or already used.",
+		})
+	case errors.Is(err, tasks.ErrTaskNotWaitingApproval):
+		writeTaskApprovalPage(w, http.StatusConflict, taskApprovalPageData{
+			Message: "The task does not wait for approval anymore.",
+		})
+	case err != nil:
+		helpers.WriteError(w, err)
+	case decision == db.TaskApprovalApprove:
+		writeTaskApprovalPage(w, http.StatusOK, taskApprovalPageData{Message: "The task is approved.", TaskID: task.ID})
+	default:
+		writeTaskApprovalPage(w, http.StatusOK, taskApprovalPageData{Message: "The task is rejected.", TaskID: task.ID})
+	}
+}
@@ -0,0 +1,155 @@
+package projects
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// GetTaskComments returns comments of the task
+func GetTaskComments(w http.ResponseWriter, r *http.Request) {
+	task := context.Get(r, "task").(db.Task)
+	project := context.Get(r, "project").(db.Project)
+
+	comments, err := helpers.Store(r).GetTaskComments(project.ID, db.TaskCommentFilter{
+		TaskIDs: []int{task.ID},
+	})
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, comments)
+}
+
+// SearchTaskComments returns comments of the project tasks filtered by label and text.
+func SearchTaskComments(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	comments, err := helpers.Store(r).GetTaskComments(project.ID, db.TaskCommentFilter{
+		Label: r.URL.Query().Get("label"),
+		Query: r.URL.Query().Get("q"),
+	})
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, comments)
+}
+
+// AddTaskComment attaches a comment to the task
+func AddTaskComment(w http.ResponseWriter, r *http.Request) {
+	task := context.Get(r, "task").(db.Task)
+	project := context.Get(r, "project").(db.Project)
+	user := context.Get(r, "user").(*db.User)
+
+	var comment db.TaskComment
+
+	if !helpers.Bind(w, r, &comment) {
+		return
+	}
+
+	comment.ProjectID = project.ID
+	comment.TaskID = task.ID
+	comment.UserID = &user.ID
+	comment.Created = time.Now().UTC()
+
+	if err := comment.Validate(); err != nil {
+		helpers.WriteErrorStatus(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	newComment, err := helpers.Store(r).CreateTaskComment(comment)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogCreate, helpers.EventLogItem{
+		UserID:      user.ID,
+		ProjectID:   project.ID,
+		ObjectType:  db.EventTask,
+		ObjectID:    task.ID,
+		Description: fmt.Sprintf("Comment added to task ID %d", task.ID),
+	})
+
+	helpers.WriteJSON(w, http.StatusCreated, newComment)
+}
+
+// RemoveTaskComment deletes the task comment
+func RemoveTaskComment(w http.ResponseWriter, r *http.Request) {
+	task := context.Get(r, "task").(db.Task)
+	project := context.Get(r, "project").(db.Project)
+	user := context.Get(r, "user").(*db.User)
+
+	commentID, err := helpers.GetIntParam("comment_id", w, r)
+	if err != nil {
+		return
+	}
+
+	comment, err := helpers.Store(r).GetTaskComment(project.ID, commentID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if comment.TaskID != task.ID {
+		helpers.WriteErrorStatus(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+
+	err = helpers.Store(r).DeleteTaskComment(project.ID, comment.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogDelete, helpers.EventLogItem{
+		UserID:      user.ID,
+		ProjectID:   project.ID,
+		ObjectType:  db.EventTask,
+		ObjectID:    task.ID,
+		Description: "Comment " + strconv.Itoa(comment.ID) + " removed from task ID " + strconv.Itoa(task.ID),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// fillTaskLabels sets labels of the task comments to the tasks.
+func fillTaskLabels(store db.Store, projectID int, tasks []db.TaskWithTpl) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	taskIDs := make([]int, 0, len(tasks))
+	for _, task := range tasks {
+		taskIDs = append(taskIDs, task.ID)
+	}
+
+	comments, err := store.GetTaskComments(projectID, db.TaskCommentFilter{TaskIDs: taskIDs})
+	if err != nil {
+		return err
+	}
+
+	labels := make(map[int][]string)
+	for _, comment := range comments {
+		if comment.Label != "" {
+			labels[comment.TaskID] = append(labels[comment.TaskID], comment.Label)
+		}
+	}
+
+	for i := range tasks {
+		tasks[i].Labels = labels[tasks[i].ID]
+	}
+
+	return nil
+}
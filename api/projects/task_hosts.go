@@ -0,0 +1,54 @@
+package projects
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// GetHostTimeline returns the chronological list of the tasks which ran against the host
+// or the group of hosts with their results. Only ansible tasks which reached the PLAY RECAP
+// are listed, groups are known only for static inventories.
+// Query params: host or group, from and to - dates or RFC 3339 times.
+func GetHostTimeline(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	query := r.URL.Query()
+
+	filter := db.TaskHostFilter{
+		Host:  query.Get("host"),
+		Group: query.Get("group"),
+	}
+
+	if (filter.Host == "") == (filter.Group == "") {
+		helpers.WriteErrorStatus(w, "Either host or group required", http.StatusBadRequest)
+		return
+	}
+
+	for _, bound := range []struct {
+		name  string
+		value **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		value := query.Get(bound.name)
+		if value == "" {
+			continue
+		}
+
+		t, err := parseTaskExportTime(value)
+		if err != nil {
+			helpers.WriteErrorStatus(w, "Invalid "+bound.name, http.StatusBadRequest)
+			return
+		}
+		*bound.value = &t
+	}
+
+	hosts, err := helpers.Store(r).GetTaskHosts(project.ID, filter)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, hosts)
+}
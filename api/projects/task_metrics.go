@@ -0,0 +1,76 @@
+package projects
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// GetTaskMetrics returns the statistics of the project tasks aggregated by the period
+// for year-over-year reports. Statistics are kept after the tasks are pruned.
+// Query params: period - day, month (default) or year, from and to - dates or RFC 3339 times,
+// template_id - statistics of the template, by_template - group rows by templates.
+func GetTaskMetrics(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	query := r.URL.Query()
+
+	period := db.TaskMetricsPeriod(query.Get("period"))
+	if period == "" {
+		period = db.TaskMetricsPeriodMonth
+	}
+
+	if !period.IsValid() {
+		helpers.WriteErrorStatus(w, "period must be day, month or year", http.StatusBadRequest)
+		return
+	}
+
+	var filter db.TaskMetricFilter
+
+	if value := query.Get("template_id"); value != "" {
+		templateID, err := strconv.Atoi(value)
+		if err != nil {
+			helpers.WriteErrorStatus(w, "Invalid template_id", http.StatusBadRequest)
+			return
+		}
+		filter.TemplateID = &templateID
+	}
+
+	for _, bound := range []struct {
+		name  string
+		value **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		value := query.Get(bound.name)
+		if value == "" {
+			continue
+		}
+
+		t, err := parseTaskExportTime(value)
+		if err != nil {
+			helpers.WriteErrorStatus(w, "Invalid "+bound.name, http.StatusBadRequest)
+			return
+		}
+		*bound.value = &t
+	}
+
+	store := helpers.Store(r)
+
+	rollups, err := store.GetTaskMetricsDaily(project.ID, filter)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	metrics, err := store.GetTaskMetrics(project.ID, filter)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	byTemplate := query.Get("by_template") == "true"
+
+	helpers.WriteJSON(w, http.StatusOK, db.GetTaskMetricsReport(rollups, metrics, period, byTemplate))
+}
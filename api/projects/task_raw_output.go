@@ -0,0 +1,72 @@
+package projects
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/tasks"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// rawOutputPageSize is the number of output records which are read and sent at once.
+const rawOutputPageSize = 1000
+
+// GetTaskRawOutput downloads the output of the task as plain text. The output is read
+// and sent by pages, so long outputs are not kept in memory.
+// Query params: strip_ansi=1 removes ANSI sequences.
+func GetTaskRawOutput(w http.ResponseWriter, r *http.Request) {
+	task := context.Get(r, "task").(db.Task)
+	store := helpers.Store(r)
+	stripANSI := r.URL.Query().Get("strip_ansi") == "1"
+
+	output, err := tasks.GetTaskOutputsFrom(store, task, 0, rawOutputPageSize)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("content-type", "text/plain; charset=utf-8")
+	w.Header().Set("content-disposition", fmt.Sprintf("attachment; filename=\"task-%d.log\"", task.ID))
+	w.WriteHeader(http.StatusOK)
+
+	controller := http.NewResponseController(w)
+	buf := bufio.NewWriter(w)
+
+	for offset := 0; len(output) > 0; {
+		for _, o := range output {
+			line := o.Output
+			if stripANSI {
+				line = util.StripANSI(line)
+			}
+
+			if _, err = buf.WriteString(line + "\n"); err != nil {
+				return
+			}
+		}
+
+		if err = buf.Flush(); err != nil {
+			return
+		}
+
+		// the page is sent as a chunk of the response
+		_ = controller.Flush()
+
+		if len(output) < rawOutputPageSize {
+			break
+		}
+
+		offset += len(output)
+
+		output, err = tasks.GetTaskOutputsFrom(store, task, offset, rawOutputPageSize)
+		if err != nil {
+			// the status is already sent, the download is truncated
+			log.WithError(err).Error("Failed to read output of task " + fmt.Sprint(task.ID))
+			return
+		}
+	}
+}
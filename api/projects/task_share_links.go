@@ -0,0 +1,208 @@
+package projects
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/services/tasks"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+const defaultTaskShareLinkHours = 24
+
+// sharedTaskOutput is the task output returned by the share link.
+type sharedTaskOutput struct {
+	TaskID       int                    `json:"task_id"`
+	TemplateName string                 `json:"template_name"`
+	Status       task_logger.TaskStatus `json:"status"`
+	Start        *time.Time             `json:"start"`
+	End          *time.Time             `json:"end"`
+	Expires      time.Time              `json:"expires"`
+	Output       []db.TaskOutput        `json:"output"`
+}
+
+// GetTaskShareLinks returns active share links of the task
+func GetTaskShareLinks(w http.ResponseWriter, r *http.Request) {
+	task := context.Get(r, "task").(db.Task)
+
+	links, err := helpers.Store(r).GetTaskShareLinks(task.ProjectID, task.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	now := time.Now()
+	active := make([]db.TaskShareLink, 0, len(links))
+	for _, link := range links {
+		if !link.IsExpired(now) {
+			active = append(active, link)
+		}
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, active)
+}
+
+// AddTaskShareLink creates the link which gives read-only access to the task output
+// without authentication. The token of the link is returned only once.
+func AddTaskShareLink(w http.ResponseWriter, r *http.Request) {
+	task := context.Get(r, "task").(db.Task)
+	user := context.Get(r, "user").(*db.User)
+
+	var params struct {
+		// ExpiresIn is the lifetime of the link in hours.
+		ExpiresIn int `json:"expires_in"`
+	}
+
+	if r.ContentLength > 0 && !helpers.Bind(w, r, &params) {
+		return
+	}
+
+	if params.ExpiresIn == 0 {
+		params.ExpiresIn = defaultTaskShareLinkHours
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	now := time.Now().UTC()
+
+	link, err := helpers.Store(r).CreateTaskShareLink(db.TaskShareLink{
+		ProjectID: task.ProjectID,
+		TaskID:    task.ID,
+		UserID:    &user.ID,
+		TokenHash: db.HashTaskShareToken(token),
+		Created:   now,
+		Expires:   now.Add(time.Duration(params.ExpiresIn) * time.Hour),
+	})
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	link.Token = token
+
+	helpers.EventLog(r, helpers.EventLogCreate, helpers.EventLogItem{
+		UserID:      user.ID,
+		ProjectID:   task.ProjectID,
+		ObjectType:  db.EventTask,
+		ObjectID:    task.ID,
+		Description: fmt.Sprintf("Share link of task ID %d created, expires %s", task.ID, link.Expires.Format(time.RFC3339)),
+	})
+
+	helpers.WriteJSON(w, http.StatusCreated, link)
+}
+
+// RemoveTaskShareLink revokes the share link of the task
+func RemoveTaskShareLink(w http.ResponseWriter, r *http.Request) {
+	task := context.Get(r, "task").(db.Task)
+	user := context.Get(r, "user").(*db.User)
+
+	linkID, err := helpers.GetIntParam("link_id", w, r)
+	if err != nil {
+		return
+	}
+
+	link, err := helpers.Store(r).GetTaskShareLink(task.ProjectID, linkID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if link.TaskID != task.ID {
+		helpers.WriteError(w, db.ErrNotFound)
+		return
+	}
+
+	if err = helpers.Store(r).DeleteTaskShareLink(task.ProjectID, link.ID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogDelete, helpers.EventLogItem{
+		UserID:      user.ID,
+		ProjectID:   task.ProjectID,
+		ObjectType:  db.EventTask,
+		ObjectID:    task.ID,
+		Description: fmt.Sprintf("Share link of task ID %d revoked", task.ID),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSharedTaskOutput returns the output of the task shared by the link.
+// The request is not authenticated, the token of the link must be passed
+// in the token query parameter. The output is returned as plain text if format=text.
+func GetSharedTaskOutput(w http.ResponseWriter, r *http.Request) {
+	projectID, err := helpers.GetIntParam("project_id", w, r)
+	if err != nil {
+		return
+	}
+
+	linkID, err := helpers.GetIntParam("link_id", w, r)
+	if err != nil {
+		return
+	}
+
+	store := helpers.Store(r)
+
+	// the same response for unknown, expired and foreign links
+	link, err := store.GetTaskShareLink(projectID, linkID)
+	if err != nil || !link.CheckToken(r.URL.Query().Get("token")) || link.IsExpired(time.Now()) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	task, err := store.GetTask(projectID, link.TaskID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	tpl, err := store.GetTemplate(projectID, task.TemplateID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	output, err := tasks.GetTaskOutputs(store, task)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("cache-control", "no-store")
+
+	if r.URL.Query().Get("format") == "text" {
+		lines := make([]string, 0, len(output))
+		for _, o := range output {
+			lines = append(lines, util.StripANSI(o.Output))
+		}
+
+		w.Header().Set("content-type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(strings.Join(lines, "\n") + "\n"))
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, sharedTaskOutput{
+		TaskID:       task.ID,
+		TemplateName: tpl.Name,
+		Status:       task.Status,
+		Start:        task.Start,
+		End:          task.End,
+		Expires:      link.Expires,
+		Output:       output,
+	})
+}
@@ -10,6 +10,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 // AddTask inserts a task into the database and returns a header or returns error
@@ -23,11 +24,39 @@ func AddTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newTask, err := helpers.TaskPool(r).AddTask(taskObj, &user.ID, project.ID)
+	// only tasks launched by the token of the service account are attributed to it
+	taskObj.ServiceAccountID = nil
+
+	launchTask(w, r, project, taskObj, &user.ID)
+}
+
+// launchTask adds the task to the pool and writes the created task.
+func launchTask(w http.ResponseWriter, r *http.Request, project db.Project, taskObj db.Task, userID *int) {
+	if taskObj.DeploymentEnvironmentID != nil {
+		_, err := helpers.Store(r).GetDeploymentEnvironment(project.ID, *taskObj.DeploymentEnvironmentID)
+		if errors.Is(err, db.ErrNotFound) {
+			helpers.WriteErrorStatus(w, "Deployment environment not found", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+	}
+
+	newTask, err := helpers.TaskPool(r).AddTask(taskObj, userID, project.ID)
+
+	var quotaErr *db.QuotaExceededError
+	var validationErr *db.ValidationError
 
 	if errors.Is(err, tasks.ErrInvalidSubscription) {
 		helpers.WriteErrorStatus(w, "No active subscription available.", http.StatusForbidden)
 		return
+	} else if errors.As(err, &quotaErr) {
+		helpers.WriteErrorStatus(w, quotaErr.Error(), http.StatusTooManyRequests)
+		return
+	} else if errors.As(err, &validationErr) {
+		helpers.WriteError(w, err)
+		return
 	} else if err != nil {
 
 		util.LogErrorWithFields(err, log.Fields{"error": "Cannot write new event to database"})
@@ -46,14 +75,16 @@ func GetTasksList(w http.ResponseWriter, r *http.Request, limit int) {
 	var err error
 	var tasks []db.TaskWithTpl
 
+	// tasks can be filtered by status, e.g. ?status=partial
+	params := db.RetrieveQueryParams{
+		Count:  limit,
+		Filter: r.URL.Query().Get("status"),
+	}
+
 	if tpl != nil {
-		tasks, err = helpers.Store(r).GetTemplateTasks(tpl.(db.Template).ProjectID, tpl.(db.Template).ID, db.RetrieveQueryParams{
-			Count: limit,
-		})
+		tasks, err = helpers.Store(r).GetTemplateTasks(tpl.(db.Template).ProjectID, tpl.(db.Template).ID, params)
 	} else {
-		tasks, err = helpers.Store(r).GetProjectTasks(project.ID, db.RetrieveQueryParams{
-			Count: limit,
-		})
+		tasks, err = helpers.Store(r).GetProjectTasks(project.ID, params)
 	}
 
 	if err != nil {
@@ -62,7 +93,21 @@ func GetTasksList(w http.ResponseWriter, r *http.Request, limit int) {
 		return
 	}
 
-	helpers.WriteJSON(w, http.StatusOK, tasks)
+	if err = fillTaskLabels(helpers.Store(r), project.ID, tasks); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	var lastModified time.Time
+	for _, task := range tasks {
+		for _, t := range []*time.Time{&task.Created, task.Start, task.End} {
+			if t != nil && t.After(lastModified) {
+				lastModified = *t
+			}
+		}
+	}
+
+	helpers.WriteConditionalJSON(w, r, lastModified, tasks)
 }
 
 // GetAllTasks returns all tasks for the current project
@@ -110,13 +155,93 @@ func GetTaskMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// GetTaskRunOutputs returns outputs registered by the task
+func GetTaskRunOutputs(w http.ResponseWriter, r *http.Request) {
+	task := context.Get(r, "task").(db.Task)
+	project := context.Get(r, "project").(db.Project)
+
+	outputs, err := helpers.Store(r).GetRunOutputs(project.ID, task.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, outputs)
+}
+
+// GetTaskArtifacts returns artifacts published by the task with their checksums
+func GetTaskArtifacts(w http.ResponseWriter, r *http.Request) {
+	task := context.Get(r, "task").(db.Task)
+	project := context.Get(r, "project").(db.Project)
+
+	artifacts, err := helpers.Store(r).GetTaskArtifacts(project.ID, task.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, artifacts)
+}
+
+// GetTaskTerraformPlan returns resource changes planned by the terraform task
+func GetTaskTerraformPlan(w http.ResponseWriter, r *http.Request) {
+	task := context.Get(r, "task").(db.Task)
+	project := context.Get(r, "project").(db.Project)
+
+	changes, err := helpers.Store(r).GetTerraformPlanChanges(project.ID, task.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, changes)
+}
+
+// GetTaskTerraformPlanDiff compares the plan of the task with the plan of the base task
+// passed by the base query parameter, e.g. the task which was approved before.
+func GetTaskTerraformPlanDiff(w http.ResponseWriter, r *http.Request) {
+	task := context.Get(r, "task").(db.Task)
+	project := context.Get(r, "project").(db.Project)
+
+	baseTaskID, err := strconv.Atoi(r.URL.Query().Get("base"))
+	if err != nil || baseTaskID <= 0 {
+		helpers.WriteErrorStatus(w, "Invalid base task", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := tasks.GetTerraformPlanDiff(helpers.Store(r), project.ID, baseTaskID, task.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, diff)
+}
+
+// GetTaskLifecycle returns events of the task from queueing to finishing
+// with the time passed between them.
+func GetTaskLifecycle(w http.ResponseWriter, r *http.Request) {
+	task := context.Get(r, "task").(db.Task)
+
+	events, err := helpers.Store(r).GetActivity(task.ProjectID, db.GetTaskLifecycleQueryParams(task.ID))
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, db.NewTaskLifecycle(events))
+}
+
 // GetTaskOutput returns the logged task output by id and writes it as json or returns error
 func GetTaskStages(w http.ResponseWriter, r *http.Request) {
 	task := context.Get(r, "task").(db.Task)
-	project := context.Get(r, "project").(db.Project)
 
 	var output []db.TaskOutput
-	output, err := helpers.Store(r).GetTaskOutputs(project.ID, task.ID)
+	output, err := tasks.GetTaskOutputs(helpers.Store(r), task)
 
 	if err != nil {
 		util.LogErrorWithFields(err, log.Fields{"error": "Bad request. Cannot get task output from database"})
@@ -127,13 +252,27 @@ func GetTaskStages(w http.ResponseWriter, r *http.Request) {
 	helpers.WriteJSON(w, http.StatusOK, output)
 }
 
+// GetTaskFindings returns problems found by the pre-run checks of the task
+func GetTaskFindings(w http.ResponseWriter, r *http.Request) {
+	task := context.Get(r, "task").(db.Task)
+	project := context.Get(r, "project").(db.Project)
+
+	findings, err := helpers.Store(r).GetTaskFindings(project.ID, task.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, findings)
+}
+
 // GetTaskOutput returns the logged task output by id and writes it as json or returns error
 func GetTaskOutput(w http.ResponseWriter, r *http.Request) {
 	task := context.Get(r, "task").(db.Task)
-	project := context.Get(r, "project").(db.Project)
 
 	var output []db.TaskOutput
-	output, err := helpers.Store(r).GetTaskOutputs(project.ID, task.ID)
+	output, err := tasks.GetTaskOutputs(helpers.Store(r), task)
 
 	if err != nil {
 		util.LogErrorWithFields(err, log.Fields{"error": "Bad request. Cannot get task output from database"})
@@ -141,7 +280,32 @@ func GetTaskOutput(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	helpers.WriteJSON(w, http.StatusOK, output)
+	// clients which already have the output up to the offset, e.g. up to the checkpoint, ask for the rest
+	if value := r.URL.Query().Get("offset"); value != "" {
+		offset, convErr := strconv.Atoi(value)
+		if convErr != nil || offset < 0 {
+			helpers.WriteErrorStatus(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+
+		output = output[min(offset, len(output)):]
+	}
+
+	// the output may be stored with ANSI sequences, clients which can't render them may ask to strip them
+	if r.URL.Query().Get("strip_ansi") == "1" {
+		for i := range output {
+			output[i].Output = util.StripANSI(output[i].Output)
+		}
+	}
+
+	var lastModified time.Time
+	for _, o := range output {
+		if o.Time.After(lastModified) {
+			lastModified = o.Time
+		}
+	}
+
+	helpers.WriteConditionalJSON(w, r, lastModified, output)
 }
 
 func ConfirmTask(w http.ResponseWriter, r *http.Request) {
@@ -188,11 +352,42 @@ func StopTask(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// GetDelayedTasks returns the tasks of the project which wait for their start time
+func GetDelayedTasks(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	delayed, err := helpers.TaskPool(r).GetDelayedTasks(project.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, delayed)
+}
+
+// CancelTask cancels the delayed task before it starts
+func CancelTask(w http.ResponseWriter, r *http.Request) {
+	targetTask := context.Get(r, "task").(db.Task)
+	project := context.Get(r, "project").(db.Project)
+
+	if targetTask.ProjectID != project.ID {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err := helpers.TaskPool(r).CancelDelayedTask(targetTask)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // RemoveTask removes a task from the database
 func RemoveTask(w http.ResponseWriter, r *http.Request) {
 	targetTask := context.Get(r, "task").(db.Task)
 	editor := context.Get(r, "user").(*db.User)
-	project := context.Get(r, "project").(db.Project)
 
 	activeTask := helpers.TaskPool(r).GetTask(targetTask.ID)
 
@@ -209,7 +404,7 @@ func RemoveTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := helpers.Store(r).DeleteTaskWithOutputs(project.ID, targetTask.ID)
+	err := tasks.DeleteTaskWithOutputs(helpers.Store(r), targetTask)
 	if err != nil {
 		util.LogErrorWithFields(err, log.Fields{"error": "Bad request. Cannot delete task from database"})
 		w.WriteHeader(http.StatusBadRequest)
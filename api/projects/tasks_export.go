@@ -0,0 +1,93 @@
+package projects
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/tasks"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultTaskExportDays = 30
+	maxTaskExportDays     = 366
+)
+
+// parseTaskExportTime parses the bound of the exported period, either a date or RFC 3339 time.
+func parseTaskExportTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// ExportTasks writes the history of the project tasks created in the period [from, to) as CSV or Parquet.
+// Query params: format - csv (default) or parquet, from and to - dates or RFC 3339 times,
+// by default the last 30 days are exported.
+func ExportTasks(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+
+	if format != "csv" && format != "parquet" {
+		helpers.WriteErrorStatus(w, "format must be csv or parquet", http.StatusBadRequest)
+		return
+	}
+
+	to := time.Now().UTC()
+	if value := query.Get("to"); value != "" {
+		var err error
+		if to, err = parseTaskExportTime(value); err != nil {
+			helpers.WriteErrorStatus(w, "Invalid to", http.StatusBadRequest)
+			return
+		}
+	}
+
+	from := to.AddDate(0, 0, -defaultTaskExportDays)
+	if value := query.Get("from"); value != "" {
+		var err error
+		if from, err = parseTaskExportTime(value); err != nil {
+			helpers.WriteErrorStatus(w, "Invalid from", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if !from.Before(to) || to.Sub(from) > maxTaskExportDays*24*time.Hour {
+		helpers.WriteErrorStatus(w, "Period must be from 1 second to "+strconv.Itoa(maxTaskExportDays)+" days", http.StatusBadRequest)
+		return
+	}
+
+	records, err := tasks.GetTaskHistory(helpers.Store(r), project.ID, from, to)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	filename := "semaphore-project-" + strconv.Itoa(project.ID) + "-tasks." + format
+
+	if format == "parquet" {
+		w.Header().Set("content-type", "application/vnd.apache.parquet")
+	} else {
+		w.Header().Set("content-type", "text/csv; charset=utf-8")
+	}
+	w.Header().Set("content-disposition", "attachment; filename=\""+filename+"\"")
+	w.WriteHeader(http.StatusOK)
+
+	if format == "parquet" {
+		err = tasks.WriteTaskHistoryParquet(w, records)
+	} else {
+		err = tasks.WriteTaskHistoryCSV(w, records)
+	}
+
+	if err != nil {
+		log.Error(err)
+	}
+}
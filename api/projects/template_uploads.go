@@ -0,0 +1,255 @@
+package projects
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetTemplateUploads returns files uploaded to the template without their content.
+func GetTemplateUploads(w http.ResponseWriter, r *http.Request) {
+	tpl := context.Get(r, "template").(db.Template)
+
+	uploads, err := helpers.Store(r).GetTemplateUploads(tpl.ProjectID, tpl.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, uploads)
+}
+
+// readUploadContent reads the content of the uploaded file from the request body
+// and stops reading when the file is larger than allowed.
+func readUploadContent(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	maxSize := db.GetUploadMaxSize()
+
+	content, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSize))
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		helpers.WriteErrorStatus(w, fmt.Sprintf("uploaded file must not be larger than %d bytes", maxSize), http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return nil, false
+	}
+
+	return content, true
+}
+
+// isUploadPathUsed returns true if another upload of the template has the same path.
+func isUploadPathUsed(store db.Store, upload db.TemplateUpload) (bool, error) {
+	uploads, err := store.GetTemplateUploads(upload.ProjectID, upload.TemplateID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, u := range uploads {
+		if u.ID != upload.ID && filepath.Clean(u.Path) == filepath.Clean(upload.Path) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// AddTemplateUpload uploads the file to the template. The content is the request body,
+// the path and the mode of the file are query parameters.
+func AddTemplateUpload(w http.ResponseWriter, r *http.Request) {
+	tpl := context.Get(r, "template").(db.Template)
+	user := helpers.UserFromContext(r)
+	store := helpers.Store(r)
+
+	now := time.Now().UTC()
+
+	upload := db.TemplateUpload{
+		ProjectID:  tpl.ProjectID,
+		TemplateID: tpl.ID,
+		Path:       r.URL.Query().Get("path"),
+		Mode:       r.URL.Query().Get("mode"),
+		UserID:     &user.ID,
+		Created:    now,
+		Updated:    now,
+	}
+
+	if err := upload.Validate(); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	used, err := isUploadPathUsed(store, upload)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if used {
+		helpers.WriteErrorStatus(w, "file "+upload.Path+" is already uploaded to the template", http.StatusConflict)
+		return
+	}
+
+	content, ok := readUploadContent(w, r)
+	if !ok {
+		return
+	}
+
+	if err = upload.SetContent(content); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	newUpload, err := store.CreateTemplateUpload(upload)
+	if err != nil {
+		if delErr := upload.DeleteContent(); delErr != nil {
+			log.WithError(delErr).Error("can not remove content of the upload")
+		}
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogCreate, helpers.EventLogItem{
+		UserID:      user.ID,
+		ProjectID:   tpl.ProjectID,
+		ObjectType:  db.EventTemplateUpload,
+		ObjectID:    newUpload.ID,
+		Description: fmt.Sprintf("File %s (%d bytes, sha256 %s) uploaded to template %s", newUpload.Path, newUpload.Size, newUpload.SHA256, tpl.Name),
+	})
+
+	helpers.WriteJSON(w, http.StatusCreated, newUpload)
+}
+
+// UpdateTemplateUpload replaces the content of the uploaded file. The path and the mode
+// of the file are changed if they are passed in query parameters.
+func UpdateTemplateUpload(w http.ResponseWriter, r *http.Request) {
+	tpl := context.Get(r, "template").(db.Template)
+	user := helpers.UserFromContext(r)
+	store := helpers.Store(r)
+
+	uploadID, err := helpers.GetIntParam("upload_id", w, r)
+	if err != nil {
+		return
+	}
+
+	oldUpload, err := store.GetTemplateUpload(tpl.ProjectID, tpl.ID, uploadID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	upload := oldUpload
+
+	if r.URL.Query().Has("path") {
+		upload.Path = r.URL.Query().Get("path")
+	}
+
+	if r.URL.Query().Has("mode") {
+		upload.Mode = r.URL.Query().Get("mode")
+	}
+
+	if err = upload.Validate(); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	used, err := isUploadPathUsed(store, upload)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if used {
+		helpers.WriteErrorStatus(w, "file "+upload.Path+" is already uploaded to the template", http.StatusConflict)
+		return
+	}
+
+	content, ok := readUploadContent(w, r)
+	if !ok {
+		return
+	}
+
+	if err = upload.SetContent(content); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	upload.UserID = &user.ID
+	upload.Updated = time.Now().UTC()
+
+	if err = store.UpdateTemplateUpload(upload); err != nil {
+		if delErr := upload.DeleteContent(); delErr != nil {
+			log.WithError(delErr).Error("can not remove content of the upload")
+		}
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if err = oldUpload.DeleteContent(); err != nil {
+		log.WithError(err).Error("can not remove previous content of the upload")
+	}
+
+	desc := fmt.Sprintf("File %s of template %s replaced", upload.Path, tpl.Name)
+	if upload.Path != oldUpload.Path {
+		desc = fmt.Sprintf("File %s of template %s replaced by %s", oldUpload.Path, tpl.Name, upload.Path)
+	}
+
+	if upload.SHA256 != oldUpload.SHA256 {
+		desc += fmt.Sprintf(", sha256 %s changed to %s", oldUpload.SHA256, upload.SHA256)
+	}
+
+	helpers.EventLog(r, helpers.EventLogUpdate, helpers.EventLogItem{
+		UserID:      user.ID,
+		ProjectID:   tpl.ProjectID,
+		ObjectType:  db.EventTemplateUpload,
+		ObjectID:    upload.ID,
+		Description: desc,
+	})
+
+	helpers.WriteJSON(w, http.StatusOK, upload)
+}
+
+// RemoveTemplateUpload removes the uploaded file from the template.
+func RemoveTemplateUpload(w http.ResponseWriter, r *http.Request) {
+	tpl := context.Get(r, "template").(db.Template)
+	store := helpers.Store(r)
+
+	uploadID, err := helpers.GetIntParam("upload_id", w, r)
+	if err != nil {
+		return
+	}
+
+	upload, err := store.GetTemplateUpload(tpl.ProjectID, tpl.ID, uploadID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if err = store.DeleteTemplateUpload(tpl.ProjectID, tpl.ID, uploadID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if err = upload.DeleteContent(); err != nil {
+		log.WithError(err).Error("can not remove content of the upload")
+	}
+
+	helpers.EventLog(r, helpers.EventLogDelete, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   tpl.ProjectID,
+		ObjectType:  db.EventTemplateUpload,
+		ObjectID:    upload.ID,
+		Description: fmt.Sprintf("File %s removed from template %s", upload.Path, tpl.Name),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
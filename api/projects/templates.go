@@ -4,9 +4,18 @@ import (
 	"fmt"
 	"github.com/semaphoreui/semaphore/util"
 	"net/http"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db_lib"
+	"github.com/semaphoreui/semaphore/services/schedules"
 	"github.com/gorilla/context"
 	log "github.com/sirupsen/logrus"
 )
@@ -53,16 +62,58 @@ func GetTemplateRefs(w http.ResponseWriter, r *http.Request) {
 func GetTemplates(w http.ResponseWriter, r *http.Request) {
 	project := context.Get(r, "project").(db.Project)
 
-	templates, err := helpers.Store(r).GetTemplates(project.ID, db.TemplateFilter{}, helpers.QueryParams(r.URL))
+	filter := db.TemplateFilter{
+		Tags:   r.URL.Query()["tag"],
+		Search: r.URL.Query().Get("search"),
+	}
+
+	if viewID, err := strconv.Atoi(r.URL.Query().Get("view_id")); err == nil {
+		filter.ViewID = &viewID
+	}
+
+	templates, err := helpers.Store(r).GetTemplates(project.ID, filter, helpers.QueryParams(r.URL))
 
 	if err != nil {
 		helpers.WriteError(w, err)
 		return
 	}
 
+	if err = fillTemplatesStats(helpers.Store(r), project.ID, templates); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
 	helpers.WriteJSON(w, http.StatusOK, templates)
 }
 
+// fillTemplatesStats sets Stats of the templates using aggregated queries
+// instead of querying tasks and schedules of each template.
+func fillTemplatesStats(store db.Store, projectID int, templates []db.Template) error {
+	stats, err := store.GetTemplatesStats(projectID)
+	if err != nil {
+		return err
+	}
+
+	if err = schedules.FillTemplatesNextRun(store, projectID, stats, time.Now()); err != nil {
+		return err
+	}
+
+	statsMap := make(map[int]*db.TemplateStats)
+	for i := range stats {
+		statsMap[stats[i].TemplateID] = &stats[i]
+	}
+
+	for i := range templates {
+		if s, ok := statsMap[templates[i].ID]; ok {
+			templates[i].Stats = s
+		} else {
+			templates[i].Stats = &db.TemplateStats{TemplateID: templates[i].ID}
+		}
+	}
+
+	return nil
+}
+
 // AddTemplate adds a template to the database
 func AddTemplate(w http.ResponseWriter, r *http.Request) {
 	project := context.Get(r, "project").(db.Project)
@@ -75,6 +126,26 @@ func AddTemplate(w http.ResponseWriter, r *http.Request) {
 	var err error
 
 	template.ProjectID = project.ID
+
+	if !mustCanUseKeys(w, r, nil, getTemplateKeyIDs(template)) {
+		return
+	}
+
+	if err = db.ValidateTemplate(helpers.Store(r), &template); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if err = checkTemplatePlaybook(helpers.Store(r), template); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if err = db.CheckTemplateQuota(helpers.Store(r), project.ID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
 	newTemplate, err := helpers.Store(r).CreateTemplate(template)
 
 	if err != nil {
@@ -136,6 +207,71 @@ func AddTemplate(w http.ResponseWriter, r *http.Request) {
 	helpers.WriteJSON(w, http.StatusCreated, newTemplate)
 }
 
+func isTemplatePlaybookChanged(oldTemplate db.Template, template db.Template) bool {
+	return oldTemplate.Playbook != template.Playbook ||
+		oldTemplate.RepositoryID != template.RepositoryID ||
+		oldTemplate.App != template.App ||
+		!reflect.DeepEqual(oldTemplate.GitBranch, template.GitBranch)
+}
+
+// checkTemplatePlaybook verifies that the playbook of the ansible template exists
+// in the branch of the repository, so the wrong path is reported when the template
+// is saved instead of when it runs. The template is saved if the repository can not be reached.
+func checkTemplatePlaybook(store db.Store, template db.Template) error {
+	if !template.App.IsAnsible() || template.Playbook == "" || strings.Contains(template.Playbook, "{{") {
+		return nil
+	}
+
+	repo, err := store.GetRepository(template.ProjectID, template.RepositoryID)
+	if err != nil {
+		return err
+	}
+
+	if template.GitBranch != nil && *template.GitBranch != "" {
+		repo.GitBranch = *template.GitBranch
+	}
+
+	var exists bool
+
+	if repo.GetType() == db.RepositoryLocal {
+		_, err = os.Stat(path.Join(repo.GitURL, template.Playbook))
+		exists = err == nil
+		if os.IsNotExist(err) {
+			err = nil
+		}
+	} else {
+		if err = repo.SSHKey.DeserializeSecret(); err != nil {
+			return err
+		}
+
+		exists, err = db_lib.GitRepository{
+			TemplateID: template.ID,
+			Repository: repo,
+			Client:     db_lib.CreateDefaultGitClient(),
+		}.RemoteFileExists(template.Playbook)
+	}
+
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"project_id":    template.ProjectID,
+			"repository_id": repo.ID,
+		}).Warn("Can not check the playbook of the template")
+		return nil
+	}
+
+	if !exists {
+		fieldErr := &db.FieldValidationError{}
+		if repo.GetType() == db.RepositoryLocal {
+			fieldErr.Add("playbook", fmt.Sprintf("playbook '%s' not found in the repository '%s'", template.Playbook, repo.Name))
+		} else {
+			fieldErr.Add("playbook", fmt.Sprintf("playbook '%s' not found in the branch '%s' of the repository '%s'", template.Playbook, repo.GitBranch, repo.Name))
+		}
+		return fieldErr
+	}
+
+	return nil
+}
+
 // UpdateTemplate writes a template to an existing key in the database
 func UpdateTemplate(w http.ResponseWriter, r *http.Request) {
 	oldTemplate := context.Get(r, "template").(db.Template)
@@ -178,6 +314,22 @@ func UpdateTemplate(w http.ResponseWriter, r *http.Request) {
 		template.StartVersion = nil
 	}
 
+	if !mustCanUseKeys(w, r, getTemplateKeyIDs(oldTemplate), getTemplateKeyIDs(template)) {
+		return
+	}
+
+	if err := db.ValidateTemplate(helpers.Store(r), &template); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if isTemplatePlaybookChanged(oldTemplate, template) {
+		if err := checkTemplatePlaybook(helpers.Store(r), template); err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+	}
+
 	err := helpers.Store(r).UpdateTemplate(template)
 	if err != nil {
 		helpers.WriteError(w, err)
@@ -202,13 +354,27 @@ func UpdateTemplate(w http.ResponseWriter, r *http.Request) {
 // RemoveTemplate deletes a template from the database
 func RemoveTemplate(w http.ResponseWriter, r *http.Request) {
 	tpl := context.Get(r, "template").(db.Template)
+	store := helpers.Store(r)
+
+	uploads, err := store.GetTemplateUploads(tpl.ProjectID, tpl.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
 
-	err := helpers.Store(r).DeleteTemplate(tpl.ProjectID, tpl.ID)
+	err = store.DeleteTemplate(tpl.ProjectID, tpl.ID)
 	if err != nil {
 		helpers.WriteError(w, err)
 		return
 	}
 
+	// uploads are removed from the database with the template, but not from the upload storage
+	for _, upload := range uploads {
+		if err = upload.DeleteContent(); err != nil {
+			log.WithError(err).Error("can not remove content of the upload")
+		}
+	}
+
 	helpers.EventLog(r, helpers.EventLogDelete, helpers.EventLogItem{
 		UserID:      helpers.UserFromContext(r).ID,
 		ProjectID:   tpl.ProjectID,
@@ -219,3 +385,150 @@ func RemoveTemplate(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// maxParamsStatusTasks limits the number of recent tasks grouped by GetTemplateParamsStatuses.
+const maxParamsStatusTasks = 1000
+
+// GetTemplateParamsStatuses returns the last status of the template for each distinct
+// set of survey values the template was run with.
+func GetTemplateParamsStatuses(w http.ResponseWriter, r *http.Request) {
+	tpl := context.Get(r, "template").(db.Template)
+
+	tasks, err := helpers.Store(r).GetTemplateTasks(tpl.ProjectID, tpl.ID, db.RetrieveQueryParams{
+		Count: maxParamsStatusTasks,
+	})
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, db.GetTemplateParamsStatuses(tpl, tasks))
+}
+
+// getTemplateHealthWindow returns the number of the last tasks used to compute the health.
+func getTemplateHealthWindow(r *http.Request) int {
+	window, err := strconv.Atoi(r.URL.Query().Get("window"))
+	if err != nil || window <= 0 {
+		return db.TemplateHealthWindow
+	}
+	return min(window, maxParamsStatusTasks)
+}
+
+// GetTemplateHealth returns the health score, flakiness and duration variance
+// of the template over the last tasks.
+func GetTemplateHealth(w http.ResponseWriter, r *http.Request) {
+	tpl := context.Get(r, "template").(db.Template)
+	window := getTemplateHealthWindow(r)
+
+	tasks, err := helpers.Store(r).GetTemplateTasks(tpl.ProjectID, tpl.ID, db.RetrieveQueryParams{
+		Count: window,
+	})
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, db.GetTemplateHealth(tpl.ID, tasks, window))
+}
+
+// GetTemplateDoc returns plays, variables and tags discovered in the playbook at the last
+// checkout of the repository and the survey variables of the launch form generated from them.
+// It responds with 404 if the playbook was not parsed yet.
+func GetTemplateDoc(w http.ResponseWriter, r *http.Request) {
+	tpl := context.Get(r, "template").(db.Template)
+
+	doc, err := helpers.Store(r).GetTemplateDoc(tpl.ProjectID, tpl.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, struct {
+		db.TemplateDoc
+		SurveyVars []db.SurveyVar `json:"survey_vars"`
+	}{doc, doc.Content.GetSurveyVars()})
+}
+
+// GetTemplatesHealth returns the health of the project templates, the least healthy
+// first. If the flaky query parameter is set, only flaky templates are returned.
+func GetTemplatesHealth(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	store := helpers.Store(r)
+	window := getTemplateHealthWindow(r)
+	onlyFlaky := r.URL.Query().Get("flaky") == "true"
+
+	templates, err := store.GetTemplates(project.ID, db.TemplateFilter{}, db.RetrieveQueryParams{})
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	res := make([]db.TemplateHealth, 0)
+
+	for _, tpl := range templates {
+		tasks, err := store.GetTemplateTasks(project.ID, tpl.ID, db.RetrieveQueryParams{
+			Count: window,
+		})
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		health := db.GetTemplateHealth(tpl.ID, tasks, window)
+		if onlyFlaky && !health.Flaky {
+			continue
+		}
+
+		res = append(res, health)
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Score < res[j].Score
+	})
+
+	helpers.WriteJSON(w, http.StatusOK, res)
+}
+
+// GetTemplateWorkspaces returns the last task and the last apply
+// of each workspace managed by the terraform template.
+func GetTemplateWorkspaces(w http.ResponseWriter, r *http.Request) {
+	tpl := context.Get(r, "template").(db.Template)
+
+	if !tpl.App.IsTerraform() {
+		helpers.WriteErrorStatus(w, "template does not manage terraform workspaces", http.StatusBadRequest)
+		return
+	}
+
+	tasks, err := helpers.Store(r).GetTemplateTasks(tpl.ProjectID, tpl.ID, db.RetrieveQueryParams{
+		Count: maxParamsStatusTasks,
+	})
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, db.GetTerraformWorkspaceStatuses(tasks))
+}
+
+// SimulateTemplateTask returns the command line, the environment and the inventory which the task
+// of the template would use with the survey values and the inventory of the task in the body.
+// Nothing is run, secrets are masked.
+func SimulateTemplateTask(w http.ResponseWriter, r *http.Request) {
+	tpl := context.Get(r, "template").(db.Template)
+	user := helpers.UserFromContext(r)
+
+	var taskObj db.Task
+	if !helpers.Bind(w, r, &taskObj) {
+		return
+	}
+
+	taskObj.TemplateID = tpl.ID
+
+	sim, err := helpers.TaskPool(r).SimulateTask(taskObj, tpl.ProjectID, user.Username)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, sim)
+}
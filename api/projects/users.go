@@ -3,9 +3,12 @@ package projects
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/tasks"
+	"github.com/semaphoreui/semaphore/util"
 	"github.com/gorilla/context"
 )
 
@@ -38,10 +41,20 @@ func UserMiddleware(next http.Handler) http.Handler {
 }
 
 type projUser struct {
-	ID       int                `json:"id"`
-	Username string             `json:"username"`
-	Name     string             `json:"name"`
-	Role     db.ProjectUserRole `json:"role"`
+	ID        int                `json:"id"`
+	Username  string             `json:"username"`
+	Name      string             `json:"name"`
+	Role      db.ProjectUserRole `json:"role"`
+	ExpiresAt *time.Time         `json:"expires_at"`
+}
+
+// validateExpiresAt writes the error and returns false if the expiry date of the membership is in the past.
+func validateExpiresAt(expiresAt *time.Time, w http.ResponseWriter) bool {
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		helpers.WriteErrorStatus(w, "Expiry date must be in the future", http.StatusBadRequest)
+		return false
+	}
+	return true
 }
 
 // GetUsers returns all users in a project
@@ -65,10 +78,11 @@ func GetUsers(w http.ResponseWriter, r *http.Request) {
 
 	for _, user := range users {
 		result = append(result, projUser{
-			ID:       user.ID,
-			Name:     user.Name,
-			Username: user.Username,
-			Role:     user.Role,
+			ID:        user.ID,
+			Name:      user.Name,
+			Username:  user.Username,
+			Role:      user.Role,
+			ExpiresAt: user.ExpiresAt,
 		})
 	}
 
@@ -79,8 +93,9 @@ func GetUsers(w http.ResponseWriter, r *http.Request) {
 func AddUser(w http.ResponseWriter, r *http.Request) {
 	project := context.Get(r, "project").(db.Project)
 	var projectUser struct {
-		UserID int                `json:"user_id" binding:"required"`
-		Role   db.ProjectUserRole `json:"role"`
+		UserID    int                `json:"user_id" binding:"required"`
+		Role      db.ProjectUserRole `json:"role"`
+		ExpiresAt *time.Time         `json:"expires_at"`
 	}
 
 	if !helpers.Bind(w, r, &projectUser) {
@@ -92,10 +107,15 @@ func AddUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !validateExpiresAt(projectUser.ExpiresAt, w) {
+		return
+	}
+
 	_, err := helpers.Store(r).CreateProjectUser(db.ProjectUser{
 		ProjectID: project.ID,
 		UserID:    projectUser.UserID,
 		Role:      projectUser.Role,
+		ExpiresAt: projectUser.ExpiresAt,
 	})
 
 	if err != nil {
@@ -103,6 +123,13 @@ func AddUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	sendAccessChangeAlert(r, tasks.AccessChange{
+		Type:      tasks.AccessUserAdded,
+		ProjectID: project.ID,
+		UserID:    projectUser.UserID,
+		Role:      projectUser.Role,
+	})
+
 	helpers.EventLog(r, helpers.EventLogCreate, helpers.EventLogItem{
 		UserID:      helpers.UserFromContext(r).ID,
 		ProjectID:   project.ID,
@@ -114,6 +141,27 @@ func AddUser(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// sendAccessChangeAlert fills the user who changed the access and the username
+// of the target user if it is not set, and sends the access alert.
+func sendAccessChangeAlert(r *http.Request, change tasks.AccessChange) {
+	store := helpers.Store(r)
+	actor := helpers.UserFromContext(r)
+
+	change.ActorID = actor.ID
+	change.ActorUsername = actor.Username
+
+	if change.Username == "" {
+		user, err := store.GetUser(change.UserID)
+		if err != nil {
+			util.LogError(err)
+			return
+		}
+		change.Username = user.Username
+	}
+
+	tasks.SendAccessChangeAlert(store, change)
+}
+
 // removeUser removes a user from a project team
 func removeUser(targetUser db.User, w http.ResponseWriter, r *http.Request) {
 	project := context.Get(r, "project").(db.Project)
@@ -125,13 +173,27 @@ func removeUser(targetUser db.User, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := helpers.Store(r).DeleteProjectUser(project.ID, targetUser.ID)
+	targetProjectUser, err := helpers.Store(r).GetProjectUser(project.ID, targetUser.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	err = helpers.Store(r).DeleteProjectUser(project.ID, targetUser.ID)
 
 	if err != nil {
 		helpers.WriteError(w, err)
 		return
 	}
 
+	sendAccessChangeAlert(r, tasks.AccessChange{
+		Type:         tasks.AccessUserRemoved,
+		ProjectID:    project.ID,
+		UserID:       targetUser.ID,
+		Username:     targetUser.Username,
+		PreviousRole: targetProjectUser.Role,
+	})
+
 	helpers.EventLog(r, helpers.EventLogDelete, helpers.EventLogItem{
 		UserID:      helpers.UserFromContext(r).ID,
 		ProjectID:   project.ID,
@@ -167,7 +229,8 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var projectUser struct {
-		Role db.ProjectUserRole `json:"role"`
+		Role      db.ProjectUserRole `json:"role"`
+		ExpiresAt *time.Time         `json:"expires_at"`
 	}
 
 	if !helpers.Bind(w, r, &projectUser) {
@@ -179,10 +242,21 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := helpers.Store(r).UpdateProjectUser(db.ProjectUser{
+	if !validateExpiresAt(projectUser.ExpiresAt, w) {
+		return
+	}
+
+	targetProjectUser, err := helpers.Store(r).GetProjectUser(project.ID, targetUser.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	err = helpers.Store(r).UpdateProjectUser(db.ProjectUser{
 		UserID:    targetUser.ID,
 		ProjectID: project.ID,
 		Role:      projectUser.Role,
+		ExpiresAt: projectUser.ExpiresAt,
 	})
 
 	if err != nil {
@@ -190,6 +264,17 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if targetProjectUser.Role != projectUser.Role {
+		sendAccessChangeAlert(r, tasks.AccessChange{
+			Type:         tasks.AccessRoleChanged,
+			ProjectID:    project.ID,
+			UserID:       targetUser.ID,
+			Username:     targetUser.Username,
+			Role:         projectUser.Role,
+			PreviousRole: targetProjectUser.Role,
+		})
+	}
+
 	helpers.EventLog(r, helpers.EventLogUpdate, helpers.EventLogItem{
 		UserID:      helpers.UserFromContext(r).ID,
 		ProjectID:   project.ID,
@@ -200,3 +285,33 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// GetAccessReview returns users who have access to the project with their last activity in the project.
+func GetAccessReview(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	review, err := tasks.GetProjectAccessReview(helpers.Store(r), project, time.Now())
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, review)
+}
+
+// SendAccessReview emails the access review to the owners of the project.
+func SendAccessReview(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	if !util.Config.EmailAlert {
+		helpers.WriteErrorStatus(w, "Email alerts are disabled", http.StatusBadRequest)
+		return
+	}
+
+	if err := tasks.SendProjectAccessReview(helpers.Store(r), project, time.Now()); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
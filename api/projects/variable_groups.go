@@ -0,0 +1,190 @@
+package projects
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// VariableGroupMiddleware ensures a variable group exists and loads it to the context
+func VariableGroupMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		project := context.Get(r, "project").(db.Project)
+		groupID, err := helpers.GetIntParam("variable_group_id", w, r)
+		if err != nil {
+			return
+		}
+
+		group, err := db.GetVariableGroup(helpers.Store(r), project.ID, groupID)
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		group.Secrets = []db.EnvironmentSecret{}
+		if err = db.FillEnvironmentSecrets(helpers.Store(r), &group, false); err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		context.Set(r, "variable_group", group)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetVariableGroups returns variable groups of the project
+func GetVariableGroups(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	groups, err := helpers.Store(r).GetVariableGroups(project.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if groups == nil {
+		groups = []db.Environment{}
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, groups)
+}
+
+// GetVariableGroup returns the variable group with names of its secrets
+func GetVariableGroup(w http.ResponseWriter, r *http.Request) {
+	helpers.WriteJSON(w, http.StatusOK, context.Get(r, "variable_group").(db.Environment))
+}
+
+// GetVariableGroupRefs returns templates which use the variable group
+func GetVariableGroupRefs(w http.ResponseWriter, r *http.Request) {
+	group := context.Get(r, "variable_group").(db.Environment)
+
+	refs, err := db.GetVariableGroupRefs(helpers.Store(r), group.ProjectID, group.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, refs)
+}
+
+// AddVariableGroup creates a variable group. Secrets of the group are encrypted
+// like secrets of environments.
+func AddVariableGroup(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	store := helpers.Store(r)
+
+	var group db.Environment
+	if !helpers.Bind(w, r, &group) {
+		return
+	}
+
+	group.ID = 0
+	group.ProjectID = project.ID
+	group.ProjectDefault = false
+	group.VariableGroup = true
+
+	if group.JSON == "" {
+		group.JSON = "{}"
+	}
+
+	newGroup, err := store.CreateEnvironment(group)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	newGroup.Secrets = group.Secrets
+	if err = updateEnvironmentSecrets(store, newGroup); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogCreate, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   project.ID,
+		ObjectType:  db.EventEnvironment,
+		ObjectID:    newGroup.ID,
+		Description: fmt.Sprintf("Variable group %s created", newGroup.Name),
+	})
+
+	newGroup.Secrets = []db.EnvironmentSecret{}
+	helpers.WriteJSON(w, http.StatusCreated, newGroup)
+}
+
+// UpdateVariableGroup updates variables and secrets of the variable group
+func UpdateVariableGroup(w http.ResponseWriter, r *http.Request) {
+	oldGroup := context.Get(r, "variable_group").(db.Environment)
+	store := helpers.Store(r)
+
+	var group db.Environment
+	if !helpers.Bind(w, r, &group) {
+		return
+	}
+
+	if group.ID != oldGroup.ID {
+		helpers.WriteErrorStatus(w, "Variable group ID in body and URL must be the same", http.StatusBadRequest)
+		return
+	}
+
+	group.ProjectID = oldGroup.ProjectID
+	group.VariableGroup = true
+
+	if group.JSON == "" {
+		group.JSON = "{}"
+	}
+
+	if err := store.UpdateEnvironment(group); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if err := updateEnvironmentSecrets(store, group); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogUpdate, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   oldGroup.ProjectID,
+		ObjectType:  db.EventEnvironment,
+		ObjectID:    oldGroup.ID,
+		Description: fmt.Sprintf("Variable group %s updated", group.Name),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveVariableGroup deletes the variable group if it is not attached to templates
+func RemoveVariableGroup(w http.ResponseWriter, r *http.Request) {
+	group := context.Get(r, "variable_group").(db.Environment)
+	store := helpers.Store(r)
+
+	refs, err := db.GetVariableGroupRefs(store, group.ProjectID, group.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if !refs.IsEmpty() {
+		helpers.WriteObjectInUse(w, "Variable group is in use by one or more templates", refs)
+		return
+	}
+
+	if err = store.DeleteEnvironment(group.ProjectID, group.ID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.EventLog(r, helpers.EventLogDelete, helpers.EventLogItem{
+		UserID:      helpers.UserFromContext(r).ID,
+		ProjectID:   group.ProjectID,
+		ObjectType:  db.EventEnvironment,
+		ObjectID:    group.ID,
+		Description: fmt.Sprintf("Variable group %s deleted", group.Name),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
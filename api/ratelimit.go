@@ -0,0 +1,141 @@
+package api
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/pkg/ratelimit"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+const rateLimitWindow = time.Minute
+
+type rateLimiters struct {
+	global   *ratelimit.Limiter
+	perToken *ratelimit.Limiter
+	perIP    *ratelimit.Limiter
+}
+
+func newRateLimiters(conf *util.RateLimitConfig) (res rateLimiters) {
+	if conf == nil {
+		return
+	}
+
+	if conf.Global > 0 {
+		res.global = ratelimit.New(conf.Global, rateLimitWindow)
+	}
+
+	if conf.PerToken > 0 {
+		res.perToken = ratelimit.New(conf.PerToken, rateLimitWindow)
+	}
+
+	if conf.PerIP > 0 {
+		res.perIP = ratelimit.New(conf.PerIP, rateLimitWindow)
+	}
+
+	return
+}
+
+func (l rateLimiters) enabled() bool {
+	return l.global != nil || l.perToken != nil || l.perIP != nil
+}
+
+// requestToken returns the hash of API token of the request or empty string
+// if the request is not authorized by token.
+func requestToken(r *http.Request) string {
+	authHeader := strings.ToLower(r.Header.Get("authorization"))
+
+	if !strings.HasPrefix(authHeader, "bearer ") {
+		return ""
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.TrimPrefix(authHeader, "bearer "))))
+}
+
+func requestIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// allow checks the request against all configured limits and returns
+// the most restrictive result.
+func (l rateLimiters) allow(r *http.Request) (res ratelimit.Result) {
+	res.Allowed = true
+	res.Remaining = -1
+
+	check := func(limiter *ratelimit.Limiter, key string) {
+		if limiter == nil || !res.Allowed {
+			return
+		}
+
+		current := limiter.Allow(key)
+
+		if !current.Allowed || res.Remaining < 0 || current.Remaining < res.Remaining {
+			res = current
+		}
+	}
+
+	if token := requestToken(r); token != "" {
+		check(l.perToken, token)
+	} else {
+		check(l.perIP, requestIP(r))
+	}
+
+	check(l.global, "")
+
+	return
+}
+
+// rateLimitMiddleware limits the number of API requests according to the
+// rate_limit config section. Requests authorized by API token are limited
+// per token, other requests are limited per client IP.
+func rateLimitMiddleware(apiPath string) func(http.Handler) http.Handler {
+	var limiters rateLimiters
+
+	if util.Config != nil {
+		limiters = newRateLimiters(util.Config.RateLimit)
+	}
+
+	return func(next http.Handler) http.Handler {
+		if !limiters.enabled() {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.URL.Path, apiPath) || r.URL.Path == apiPath+"ping" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res := limiters.allow(r)
+
+			if res.Remaining >= 0 {
+				reset := strconv.Itoa(int(res.Reset.Round(time.Second).Seconds()))
+
+				w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+				w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+				w.Header().Set("RateLimit-Reset", reset)
+
+				if !res.Allowed {
+					w.Header().Set("Retry-After", reset)
+				}
+			}
+
+			if !res.Allowed {
+				helpers.WriteErrorStatus(w, "Too many requests", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
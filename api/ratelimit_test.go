@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	oldConfig := util.Config
+	defer func() { util.Config = oldConfig }()
+
+	util.Config = &util.ConfigType{
+		RateLimit: &util.RateLimitConfig{PerIP: 2, PerToken: 3},
+	}
+
+	handler := rateLimitMiddleware("/api/")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(path string, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 2; i++ {
+		if rr := request("/api/projects", ""); rr.Code != http.StatusOK {
+			t.Fatal("request must be allowed", rr.Code)
+		}
+	}
+
+	rr := request("/api/projects", "")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatal("request must be rejected", rr.Code)
+	}
+
+	if rr.Header().Get("RateLimit-Remaining") != "0" || rr.Header().Get("Retry-After") == "" {
+		t.Fatal("rate limit headers expected")
+	}
+
+	if rr = request("/api/projects", "abc"); rr.Code != http.StatusOK || rr.Header().Get("RateLimit-Limit") != "3" {
+		t.Fatal("token requests must be limited separately")
+	}
+
+	if rr = request("/api/ping", ""); rr.Code != http.StatusOK {
+		t.Fatal("ping must not be limited")
+	}
+}
@@ -46,6 +46,13 @@ func JSONMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// localeMiddleware translates error messages of the response to the locale of the user
+func localeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(helpers.WithLocale(w, helpers.RequestLocale(r)), r)
+	})
+}
+
 // plainTextMiddleware resets headers to Plain Text if needed
 func plainTextMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -73,19 +80,32 @@ func Route() *mux.Router {
 	}
 
 	r.Use(mux.CORSMethodMiddleware(r))
+	r.Use(rateLimitMiddleware(webPath + "api/"))
 
 	pingRouter := r.Path(webPath + "api/ping").Subrouter()
 	pingRouter.Use(plainTextMiddleware)
 	pingRouter.Methods("GET", "HEAD").HandlerFunc(pongHandler)
 
+	r.Path(webPath + "api/openapi.json").HandlerFunc(openAPIHandler(r, webPath+"api")).Methods("GET", "HEAD")
+
 	publicAPIRouter := r.PathPrefix(webPath + "api").Subrouter()
-	publicAPIRouter.Use(StoreMiddleware, JSONMiddleware)
+	publicAPIRouter.Use(compressionMiddleware, StoreMiddleware, JSONMiddleware, localeMiddleware)
 
+	publicAPIRouter.HandleFunc("/bootstrap", getBootstrap).Methods("GET", "HEAD")
+	publicAPIRouter.HandleFunc("/announcements/active", getActiveAnnouncements).Methods("GET", "HEAD")
 	publicAPIRouter.HandleFunc("/auth/login", login).Methods("GET", "POST")
 	publicAPIRouter.HandleFunc("/auth/logout", logout).Methods("POST")
-	publicAPIRouter.HandleFunc("/auth/oidc/{provider}/login", oidcLogin).Methods("GET")
-	publicAPIRouter.HandleFunc("/auth/oidc/{provider}/redirect", oidcRedirect).Methods("GET")
-	publicAPIRouter.HandleFunc("/auth/oidc/{provider}/redirect/{redirect_path:.*}", oidcRedirect).Methods("GET")
+	publicAPIRouter.HandleFunc("/auth/{provider}/{option}/login", externalLogin).Methods("GET")
+	publicAPIRouter.HandleFunc("/auth/{provider}/{option}/redirect", externalRedirect).Methods("GET")
+	publicAPIRouter.HandleFunc("/auth/{provider}/{option}/redirect/{redirect_path:.*}", externalRedirect).Methods("GET")
+	publicAPIRouter.HandleFunc("/project/{project_id}/calendar.ics", projects.GetCalendar).Methods("GET", "HEAD")
+	publicAPIRouter.HandleFunc("/project/{project_id}/shared/{link_id}/output", projects.GetSharedTaskOutput).Methods("GET", "HEAD")
+	publicAPIRouter.HandleFunc("/project/{project_id}/approvals/{approval_id}", projects.GetTaskApprovalPage).Methods("GET", "HEAD")
+	publicAPIRouter.HandleFunc("/project/{project_id}/approvals/{approval_id}", projects.DecideTaskApproval).Methods("POST")
+	publicAPIRouter.HandleFunc("/pull/config", projects.GetPullConfig).Methods("GET")
+	publicAPIRouter.HandleFunc("/pull/run.sh", projects.GetPullScript).Methods("GET")
+	publicAPIRouter.HandleFunc("/pull/report", projects.ReportPullRun).Methods("POST")
+	publicAPIRouter.HandleFunc("/pull/repository/{token}/{path:.*}", projects.GetPullRepositoryFile).Methods("GET", "HEAD")
 
 	internalAPI := publicAPIRouter.PathPrefix("/internal").Subrouter()
 	internalAPI.HandleFunc("/runners", runners.RegisterRunner).Methods("POST")
@@ -96,22 +116,45 @@ func Route() *mux.Router {
 	runnersAPI.Path("").HandlerFunc(runners.UpdateRunner).Methods("PUT")
 	runnersAPI.Path("").HandlerFunc(runners.UnregisterRunner).Methods("DELETE")
 
+	// the stream is not served by runnersAPI which keeps the store session for the whole request
+	runnersStreamAPI := r.PathPrefix(webPath + "api/internal/runners/stream/").Subrouter()
+	runnersStreamAPI.Use(runners.StreamMiddleware)
+	runnersStreamAPI.PathPrefix("/").Handler(runners.Stream(webPath + "api/internal/runners/stream")).Methods("POST")
+
 	publicWebHookRouter := r.PathPrefix(webPath + "api").Subrouter()
-	publicWebHookRouter.Use(StoreMiddleware, JSONMiddleware)
+	publicWebHookRouter.Use(StoreMiddleware, JSONMiddleware, idempotencyMiddleware)
 	publicWebHookRouter.Path("/integrations/{integration_alias}").HandlerFunc(ReceiveIntegration).Methods("POST", "GET", "OPTIONS")
+	publicWebHookRouter.Path("/chatops/{platform}/{project_id}").HandlerFunc(ReceiveChatOpsCommand).Methods("POST")
 
 	authenticatedWS := r.PathPrefix(webPath + "api").Subrouter()
 	authenticatedWS.Use(JSONMiddleware, authenticationWithStore)
 	authenticatedWS.Path("/ws").HandlerFunc(sockets.Handler).Methods("GET", "HEAD")
+	authenticatedWS.Path("/project/{project_id}/events/stream").HandlerFunc(getEventStream).Methods("GET")
+	authenticatedWS.Path("/project/{project_id}/tasks/{task_id}/output/stream").HandlerFunc(getTaskOutputStream).Methods("GET")
+
+	// the only endpoints available for dashboard API tokens
+	dashboardAPI := r.PathPrefix(webPath + "api/project/{project_id}/dashboard").Subrouter()
+	dashboardAPI.Use(StoreMiddleware, JSONMiddleware, dashboardAuthentication, projects.ProjectMiddleware)
+	dashboardAPI.Path("/templates").HandlerFunc(projects.GetDashboardTemplates).Methods("GET", "HEAD")
+
+	// endpoints of service accounts authenticated by their tokens instead of users
+	serviceAccountAPI := r.PathPrefix(webPath + "api/project/{project_id}/service").Subrouter()
+	serviceAccountAPI.Use(StoreMiddleware, JSONMiddleware, projects.ServiceAccountAuthentication, idempotencyMiddleware)
+	serviceAccountAPI.Path("/tasks").HandlerFunc(projects.AddServiceAccountTask).Methods("POST")
+	serviceAccountAPI.Path("/tasks/{task_id}").HandlerFunc(projects.GetServiceAccountTask).Methods("GET", "HEAD")
 
 	authenticatedAPI := r.PathPrefix(webPath + "api").Subrouter()
-	authenticatedAPI.Use(StoreMiddleware, JSONMiddleware, authentication)
+	authenticatedAPI.Use(compressionMiddleware, StoreMiddleware, JSONMiddleware, authentication, localeMiddleware, idempotencyMiddleware)
 
 	authenticatedAPI.Path("/info").HandlerFunc(getSystemInfo).Methods("GET", "HEAD")
+	authenticatedAPI.Path("/auth/elevate").HandlerFunc(elevate).Methods("POST")
+	authenticatedAPI.Path("/auth/impersonation/stop").HandlerFunc(stopImpersonation).Methods("POST")
 
 	authenticatedAPI.Path("/projects").HandlerFunc(projects.GetProjects).Methods("GET", "HEAD")
 	authenticatedAPI.Path("/projects").HandlerFunc(projects.AddProject).Methods("POST")
-	authenticatedAPI.Path("/projects/restore").HandlerFunc(projects.Restore).Methods("POST")
+	authenticatedAPI.Path("/projects/restore").Handler(elevated(projects.Restore)).Methods("POST")
+	authenticatedAPI.Path("/projects/import/awx").Handler(elevated(projects.ImportAWX)).Methods("POST")
+	authenticatedAPI.Path("/projects/lookup").HandlerFunc(projects.GetProjectByName).Methods("GET", "HEAD")
 	authenticatedAPI.Path("/events").HandlerFunc(getAllEvents).Methods("GET", "HEAD")
 	authenticatedAPI.HandleFunc("/events/last", getLastEvents).Methods("GET", "HEAD")
 
@@ -121,6 +164,8 @@ func Route() *mux.Router {
 
 	authenticatedAPI.Path("/apps").HandlerFunc(getApps).Methods("GET", "HEAD")
 
+	authenticatedAPI.Path("/graphql").HandlerFunc(graphQLHandler).Methods("GET", "POST")
+
 	tokenAPI := authenticatedAPI.PathPrefix("/user").Subrouter()
 	tokenAPI.Path("/tokens").HandlerFunc(getAPITokens).Methods("GET", "HEAD")
 	tokenAPI.Path("/tokens").HandlerFunc(createAPIToken).Methods("POST")
@@ -131,6 +176,34 @@ func Route() *mux.Router {
 	adminAPI.Path("/options").HandlerFunc(getOptions).Methods("GET", "HEAD")
 	adminAPI.Path("/options").HandlerFunc(setOption).Methods("POST")
 
+	adminAPI.Path("/settings").HandlerFunc(getSettings).Methods("GET", "HEAD")
+	adminAPI.Path("/settings").HandlerFunc(updateSettings).Methods("PUT")
+	adminAPI.Path("/settings/{key}").HandlerFunc(resetSetting).Methods("DELETE")
+
+	adminAPI.Path("/features").HandlerFunc(getFeatureFlags).Methods("GET", "HEAD")
+	adminAPI.Path("/features/{flag}").HandlerFunc(setFeatureFlag).Methods("PUT")
+	adminAPI.Path("/features/{flag}/projects/{project_id}").HandlerFunc(setFeatureFlag).Methods("PUT")
+
+	adminAPI.Path("/announcements").HandlerFunc(getAnnouncements).Methods("GET", "HEAD")
+	adminAPI.Path("/announcements").HandlerFunc(addAnnouncement).Methods("POST")
+
+	announcementsAPI := adminAPI.PathPrefix("/announcements").Subrouter()
+	announcementsAPI.Use(announcementMiddleware)
+	announcementsAPI.Path("/{announcement_id}").HandlerFunc(updateAnnouncement).Methods("PUT")
+	announcementsAPI.Path("/{announcement_id}").HandlerFunc(deleteAnnouncement).Methods("DELETE")
+
+	adminAPI.Path("/housekeeping/jobs").HandlerFunc(getHousekeepingJobs).Methods("GET", "HEAD")
+	adminAPI.Path("/housekeeping/jobs/{job}/run").HandlerFunc(runHousekeepingJob).Methods("POST")
+	adminAPI.Path("/disk_usage").HandlerFunc(getDiskUsage).Methods("GET", "HEAD")
+	adminAPI.Path("/orphaned_references").HandlerFunc(getOrphanedReferences).Methods("GET", "HEAD")
+	adminAPI.Path("/orphaned_references/fix").HandlerFunc(fixOrphanedReferences).Methods("POST")
+
+	diagnosticsAPI := adminAPI.PathPrefix("/debug").Subrouter()
+	diagnosticsAPI.Use(diagnosticsMiddleware)
+	diagnosticsAPI.Path("/pprof/").HandlerFunc(getPprofProfile).Methods("GET", "HEAD")
+	diagnosticsAPI.Path("/pprof/{profile}").HandlerFunc(getPprofProfile).Methods("GET", "HEAD", "POST")
+	diagnosticsAPI.Path("/bundle").HandlerFunc(getDiagnosticsBundle).Methods("GET", "HEAD")
+
 	adminAPI.Path("/runners").HandlerFunc(getGlobalRunners).Methods("GET", "HEAD")
 	adminAPI.Path("/runners").HandlerFunc(addGlobalRunner).Methods("POST", "HEAD")
 
@@ -159,11 +232,12 @@ func Route() *mux.Router {
 
 	userAPI.Methods("GET", "HEAD").HandlerFunc(getUser)
 	userAPI.Methods("PUT").HandlerFunc(updateUser)
-	userAPI.Methods("DELETE").HandlerFunc(deleteUser)
+	userAPI.Methods("DELETE").Handler(elevated(deleteUser))
 
 	userPasswordAPI := authenticatedAPI.PathPrefix("/users/{user_id}").Subrouter()
 	userPasswordAPI.Use(getUserMiddleware)
 	userPasswordAPI.Path("/password").HandlerFunc(updateUserPassword).Methods("POST")
+	userPasswordAPI.Path("/impersonate").HandlerFunc(impersonateUser).Methods("POST")
 
 	projectGet := authenticatedAPI.Path("/project/{project_id}").Subrouter()
 	projectGet.Use(projects.ProjectMiddleware)
@@ -174,11 +248,22 @@ func Route() *mux.Router {
 	projectTaskStart := authenticatedAPI.PathPrefix("/project/{project_id}").Subrouter()
 	projectTaskStart.Use(projects.ProjectMiddleware, projects.GetMustCanMiddleware(db.CanRunProjectTasks))
 	projectTaskStart.Path("/tasks").HandlerFunc(projects.AddTask).Methods("POST")
+	projectTaskStart.Path("/tasks/rollback").HandlerFunc(projects.RollbackTask).Methods("POST")
+	projectTaskStart.Path("/adhoc").HandlerFunc(projects.RunAdhocCommand).Methods("POST")
 
 	projectTaskStop := authenticatedAPI.PathPrefix("/project/{project_id}").Subrouter()
 	projectTaskStop.Use(projects.ProjectMiddleware, projects.GetTaskMiddleware, projects.GetMustCanMiddleware(db.CanRunProjectTasks))
 	projectTaskStop.HandleFunc("/tasks/{task_id}/stop", projects.StopTask).Methods("POST")
 	projectTaskStop.HandleFunc("/tasks/{task_id}/confirm", projects.ConfirmTask).Methods("POST")
+	projectTaskStop.HandleFunc("/tasks/{task_id}/promote", projects.PromoteTask).Methods("POST")
+	projectTaskStop.HandleFunc("/tasks/{task_id}/cancel", projects.CancelTask).Methods("POST")
+	projectTaskStop.HandleFunc("/tasks/{task_id}/share_links", projects.GetTaskShareLinks).Methods("GET", "HEAD")
+	projectTaskStop.HandleFunc("/tasks/{task_id}/share_links", projects.AddTaskShareLink).Methods("POST")
+	projectTaskStop.HandleFunc("/tasks/{task_id}/share_links/{link_id}", projects.RemoveTaskShareLink).Methods("DELETE")
+
+	projectAdhocStop := authenticatedAPI.PathPrefix("/project/{project_id}/adhoc").Subrouter()
+	projectAdhocStop.Use(projects.ProjectMiddleware, projects.AdhocCommandMiddleware, projects.GetMustCanMiddleware(db.CanRunProjectTasks))
+	projectAdhocStop.HandleFunc("/{command_id}/stop", projects.StopAdhocCommand).Methods("POST")
 
 	//
 	// Project resources CRUD
@@ -189,11 +274,17 @@ func Route() *mux.Router {
 
 	projectUserAPI.Path("/events").HandlerFunc(getAllEvents).Methods("GET", "HEAD")
 	projectUserAPI.HandleFunc("/events/last", getLastEvents).Methods("GET", "HEAD")
+	projectUserAPI.Path("/activity").HandlerFunc(getActivity).Methods("GET", "HEAD")
+
+	projectUserAPI.Path("/run_records").HandlerFunc(projects.GetRunRecords).Methods("GET", "HEAD")
+	projectUserAPI.Path("/run_records/verify").HandlerFunc(projects.VerifyRunRecords).Methods("GET", "HEAD")
+	projectUserAPI.Path("/run_records/{record_id}/attestation").HandlerFunc(projects.GetRunRecordAttestation).Methods("GET", "HEAD")
 
 	projectUserAPI.Path("/users").HandlerFunc(projects.GetUsers).Methods("GET", "HEAD")
 
-	projectUserAPI.Path("/keys").HandlerFunc(projects.GetKeys).Methods("GET", "HEAD")
-	projectUserAPI.Path("/keys").HandlerFunc(projects.AddKey).Methods("POST")
+	canViewKeys := projects.GetMustCanAlwaysMiddleware(db.CanViewProjectKeys)
+	projectUserAPI.Path("/keys").Handler(canViewKeys(http.HandlerFunc(projects.GetKeys))).Methods("GET", "HEAD")
+	projectUserAPI.Path("/keys").Handler(canViewKeys(http.HandlerFunc(projects.AddKey))).Methods("POST")
 
 	projectUserAPI.Path("/repositories").HandlerFunc(projects.GetRepositories).Methods("GET", "HEAD")
 	projectUserAPI.Path("/repositories").HandlerFunc(projects.AddRepository).Methods("POST")
@@ -203,24 +294,55 @@ func Route() *mux.Router {
 
 	projectUserAPI.Path("/environment").HandlerFunc(projects.GetEnvironment).Methods("GET", "HEAD")
 	projectUserAPI.Path("/environment").HandlerFunc(projects.AddEnvironment).Methods("POST")
+	projectUserAPI.Path("/environment/defaults").HandlerFunc(projects.GetProjectDefaultEnvironment).Methods("GET", "HEAD")
+	projectUserAPI.Path("/environment/defaults").HandlerFunc(projects.UpdateProjectDefaultEnvironment).Methods("PUT")
+
+	projectUserAPI.Path("/variable_groups").HandlerFunc(projects.GetVariableGroups).Methods("GET", "HEAD")
+	projectUserAPI.Path("/variable_groups").HandlerFunc(projects.AddVariableGroup).Methods("POST")
 
 	projectUserAPI.Path("/tasks").HandlerFunc(projects.GetAllTasks).Methods("GET", "HEAD")
 	projectUserAPI.HandleFunc("/tasks/last", projects.GetLastTasks).Methods("GET", "HEAD")
+	projectUserAPI.HandleFunc("/tasks/delayed", projects.GetDelayedTasks).Methods("GET", "HEAD")
+	projectUserAPI.HandleFunc("/tasks/comments", projects.SearchTaskComments).Methods("GET", "HEAD")
+	projectUserAPI.HandleFunc("/tasks/export", projects.ExportTasks).Methods("GET", "HEAD")
+	projectUserAPI.HandleFunc("/tasks/metrics", projects.GetTaskMetrics).Methods("GET", "HEAD")
+	projectUserAPI.HandleFunc("/hosts/timeline", projects.GetHostTimeline).Methods("GET", "HEAD")
+	projectUserAPI.HandleFunc("/disk_usage", projects.GetDiskUsage).Methods("GET", "HEAD")
+
+	projectUserAPI.Path("/adhoc").HandlerFunc(projects.GetAdhocCommands).Methods("GET", "HEAD")
 
 	projectUserAPI.Path("/templates").HandlerFunc(projects.GetTemplates).Methods("GET", "HEAD")
 	projectUserAPI.Path("/templates").HandlerFunc(projects.AddTemplate).Methods("POST")
+	projectUserAPI.Path("/templates/health").HandlerFunc(projects.GetTemplatesHealth).Methods("GET", "HEAD")
+	projectUserAPI.Path("/import/rundeck").HandlerFunc(projects.ImportRundeckJobs).Methods("POST")
 
 	projectUserAPI.Path("/schedules").HandlerFunc(projects.GetProjectSchedules).Methods("GET", "HEAD")
 	projectUserAPI.Path("/schedules").HandlerFunc(projects.AddSchedule).Methods("POST")
 	projectUserAPI.Path("/schedules/validate").HandlerFunc(projects.ValidateScheduleCronFormat).Methods("POST")
+	projectUserAPI.Path("/schedules/preview").HandlerFunc(projects.PreviewScheduleCronFormat).Methods("POST")
+	projectUserAPI.Path("/schedules/status").HandlerFunc(projects.GetProjectScheduleStatuses).Methods("GET", "HEAD")
 
 	projectUserAPI.Path("/views").HandlerFunc(projects.GetViews).Methods("GET", "HEAD")
 	projectUserAPI.Path("/views").HandlerFunc(projects.AddView).Methods("POST")
 	projectUserAPI.Path("/views/positions").HandlerFunc(projects.SetViewPositions).Methods("POST")
 
+	projectUserAPI.Path("/masking_rules").HandlerFunc(projects.GetMaskingRules).Methods("GET", "HEAD")
+	projectUserAPI.Path("/masking_rules").HandlerFunc(projects.AddMaskingRule).Methods("POST")
+
+	projectUserAPI.Path("/host_exclusions").HandlerFunc(projects.GetHostExclusions).Methods("GET", "HEAD")
+	projectUserAPI.Path("/host_exclusions").HandlerFunc(projects.AddHostExclusion).Methods("POST")
+
+	projectUserAPI.Path("/deployment_environments").HandlerFunc(projects.GetDeploymentEnvironments).Methods("GET", "HEAD")
+	projectUserAPI.Path("/deployment_environments").HandlerFunc(projects.AddDeploymentEnvironment).Methods("POST")
+	projectUserAPI.Path("/deployment_environments/positions").HandlerFunc(projects.SetDeploymentEnvironmentPositions).Methods("POST")
+
 	projectUserAPI.Path("/integrations").HandlerFunc(projects.GetIntegrations).Methods("GET", "HEAD")
 	projectUserAPI.Path("/integrations").HandlerFunc(projects.AddIntegration).Methods("POST")
 	projectUserAPI.Path("/backup").HandlerFunc(projects.GetBackup).Methods("GET", "HEAD")
+	projectUserAPI.Path("/clone").HandlerFunc(projects.CloneProject).Methods("POST")
+
+	projectUserAPI.Path("/lookup/{kind}").HandlerFunc(projects.GetObjectByName).Methods("GET", "HEAD")
+	projectUserAPI.Path("/lookup/{kind}").HandlerFunc(projects.UpsertObjectByName).Methods("PUT")
 
 	projectUserAPI.Path("/runners").HandlerFunc(projects.GetRunners).Methods("GET", "HEAD")
 	projectUserAPI.Path("/runners").HandlerFunc(projects.AddRunner).Methods("POST")
@@ -237,7 +359,22 @@ func Route() *mux.Router {
 	projectAdminAPI := authenticatedAPI.Path("/project/{project_id}").Subrouter()
 	projectAdminAPI.Use(projects.ProjectMiddleware, projects.GetMustCanMiddleware(db.CanUpdateProject))
 	projectAdminAPI.Methods("PUT").HandlerFunc(projects.UpdateProject)
-	projectAdminAPI.Methods("DELETE").HandlerFunc(projects.DeleteProject)
+	projectAdminAPI.Methods("DELETE").Handler(elevated(projects.DeleteProject))
+
+	projectFeaturesAPI := authenticatedAPI.Path("/project/{project_id}/features").Subrouter()
+	projectFeaturesAPI.Use(projects.ProjectMiddleware)
+	projectFeaturesAPI.Methods("GET", "HEAD").HandlerFunc(getProjectFeatureFlags)
+
+	projectArchiveAPI := authenticatedAPI.Path("/project/{project_id}/archive").Subrouter()
+	projectArchiveAPI.Use(projects.ProjectMiddleware, projects.GetMustCanMiddleware(db.CanUpdateProject))
+	projectArchiveAPI.Methods("POST").HandlerFunc(projects.ArchiveProject)
+	projectArchiveAPI.Methods("DELETE").HandlerFunc(projects.UnarchiveProject)
+
+	projectCalendarAPI := authenticatedAPI.Path("/project/{project_id}/calendar/token").Subrouter()
+	projectCalendarAPI.Use(projects.ProjectMiddleware, projects.GetMustCanMiddleware(db.CanUpdateProject))
+	projectCalendarAPI.Methods("GET", "HEAD").HandlerFunc(projects.GetCalendarToken)
+	projectCalendarAPI.Methods("POST").HandlerFunc(projects.RotateCalendarToken)
+	projectCalendarAPI.Methods("DELETE").HandlerFunc(projects.DeleteCalendarToken)
 
 	meAPI := authenticatedAPI.Path("/project/{project_id}/me").Subrouter()
 	meAPI.Use(projects.ProjectMiddleware)
@@ -249,6 +386,18 @@ func Route() *mux.Router {
 
 	projectAdminUsersAPI.Use(projects.ProjectMiddleware, projects.GetMustCanMiddleware(db.CanManageProjectUsers))
 	projectAdminUsersAPI.Path("/users").HandlerFunc(projects.AddUser).Methods("POST")
+	projectAdminUsersAPI.Path("/access_review").HandlerFunc(projects.GetAccessReview).Methods("GET", "HEAD")
+	projectAdminUsersAPI.Path("/access_review").HandlerFunc(projects.SendAccessReview).Methods("POST")
+
+	projectAdminUsersAPI.Path("/service_accounts").HandlerFunc(projects.GetServiceAccounts).Methods("GET", "HEAD")
+	projectAdminUsersAPI.Path("/service_accounts").HandlerFunc(projects.AddServiceAccount).Methods("POST")
+
+	projectServiceAccountManagement := projectAdminUsersAPI.PathPrefix("/service_accounts").Subrouter()
+	projectServiceAccountManagement.Use(projects.ServiceAccountMiddleware)
+	projectServiceAccountManagement.HandleFunc("/{account_id}", projects.GetServiceAccounts).Methods("GET", "HEAD")
+	projectServiceAccountManagement.HandleFunc("/{account_id}", projects.UpdateServiceAccount).Methods("PUT")
+	projectServiceAccountManagement.HandleFunc("/{account_id}", projects.RemoveServiceAccount).Methods("DELETE")
+	projectServiceAccountManagement.HandleFunc("/{account_id}/token", projects.RotateServiceAccountToken).Methods("POST")
 
 	projectUserManagement := projectAdminUsersAPI.PathPrefix("/users").Subrouter()
 	projectUserManagement.Use(projects.UserMiddleware)
@@ -260,12 +409,13 @@ func Route() *mux.Router {
 	//
 	// Project resources CRUD (continue)
 	projectKeyManagement := projectUserAPI.PathPrefix("/keys").Subrouter()
-	projectKeyManagement.Use(projects.KeyMiddleware)
+	projectKeyManagement.Use(canViewKeys, projects.KeyMiddleware)
 
 	projectKeyManagement.HandleFunc("/{key_id}", projects.GetKeys).Methods("GET", "HEAD")
 	projectKeyManagement.HandleFunc("/{key_id}/refs", projects.GetKeyRefs).Methods("GET", "HEAD")
+	projectKeyManagement.HandleFunc("/{key_id}/test", projects.TestKey).Methods("POST")
 	projectKeyManagement.HandleFunc("/{key_id}", projects.UpdateKey).Methods("PUT")
-	projectKeyManagement.HandleFunc("/{key_id}", projects.RemoveKey).Methods("DELETE")
+	projectKeyManagement.Handle("/{key_id}", elevated(projects.RemoveKey)).Methods("DELETE")
 
 	projectRepoManagement := projectUserAPI.PathPrefix("/repositories").Subrouter()
 	projectRepoManagement.Use(projects.RepositoryMiddleware)
@@ -282,6 +432,7 @@ func Route() *mux.Router {
 	projectInventoryManagement.HandleFunc("/{inventory_id}/refs", projects.GetInventoryRefs).Methods("GET", "HEAD")
 	projectInventoryManagement.HandleFunc("/{inventory_id}", projects.UpdateInventory).Methods("PUT")
 	projectInventoryManagement.HandleFunc("/{inventory_id}", projects.RemoveInventory).Methods("DELETE")
+	projectInventoryManagement.HandleFunc("/{inventory_id}/ping", projects.PingInventory).Methods("POST")
 
 	projectInventoryManagement.HandleFunc("/{inventory_id}/terraform/aliases", projects.GetTerraformInventoryAliases).Methods("GET", "HEAD")
 	projectInventoryManagement.HandleFunc("/{inventory_id}/terraform/aliases", projects.AddTerraformInventoryAlias).Methods("POST")
@@ -299,9 +450,19 @@ func Route() *mux.Router {
 
 	projectEnvManagement.HandleFunc("/{environment_id}", projects.GetEnvironment).Methods("GET", "HEAD")
 	projectEnvManagement.HandleFunc("/{environment_id}/refs", projects.GetEnvironmentRefs).Methods("GET", "HEAD")
+	projectEnvManagement.HandleFunc("/{environment_id}/file", projects.GetEnvironmentFile).Methods("GET", "HEAD")
+	projectEnvManagement.HandleFunc("/{environment_id}/file", projects.UpdateEnvironmentFile).Methods("PUT")
 	projectEnvManagement.HandleFunc("/{environment_id}", projects.UpdateEnvironment).Methods("PUT")
 	projectEnvManagement.HandleFunc("/{environment_id}", projects.RemoveEnvironment).Methods("DELETE")
 
+	projectVariableGroupManagement := projectUserAPI.PathPrefix("/variable_groups").Subrouter()
+	projectVariableGroupManagement.Use(projects.VariableGroupMiddleware)
+
+	projectVariableGroupManagement.HandleFunc("/{variable_group_id}", projects.GetVariableGroup).Methods("GET", "HEAD")
+	projectVariableGroupManagement.HandleFunc("/{variable_group_id}/refs", projects.GetVariableGroupRefs).Methods("GET", "HEAD")
+	projectVariableGroupManagement.HandleFunc("/{variable_group_id}", projects.UpdateVariableGroup).Methods("PUT")
+	projectVariableGroupManagement.HandleFunc("/{variable_group_id}", projects.RemoveVariableGroup).Methods("DELETE")
+
 	projectTmplManagement := projectUserAPI.PathPrefix("/templates").Subrouter()
 	projectTmplManagement.Use(projects.TemplatesMiddleware)
 
@@ -312,14 +473,43 @@ func Route() *mux.Router {
 	projectTmplManagement.HandleFunc("/{template_id}/tasks", projects.GetAllTasks).Methods("GET")
 	projectTmplManagement.HandleFunc("/{template_id}/tasks/last", projects.GetLastTasks).Methods("GET")
 	projectTmplManagement.HandleFunc("/{template_id}/schedules", projects.GetTemplateSchedules).Methods("GET")
+	projectTmplManagement.HandleFunc("/{template_id}/deployments", projects.GetTemplateDeployments).Methods("GET", "HEAD")
+	projectTmplManagement.HandleFunc("/{template_id}/params_status", projects.GetTemplateParamsStatuses).Methods("GET", "HEAD")
+	projectTmplManagement.HandleFunc("/{template_id}/health", projects.GetTemplateHealth).Methods("GET", "HEAD")
+	projectTmplManagement.HandleFunc("/{template_id}/doc", projects.GetTemplateDoc).Methods("GET", "HEAD")
+	projectTmplManagement.HandleFunc("/{template_id}/workspaces", projects.GetTemplateWorkspaces).Methods("GET", "HEAD")
+	projectTmplManagement.HandleFunc("/{template_id}/what_if", projects.SimulateTemplateTask).Methods("POST")
+	projectTmplManagement.HandleFunc("/{template_id}/pull/hosts", projects.GetPullHosts).Methods("GET", "HEAD")
+	projectTmplManagement.HandleFunc("/{template_id}/pull/hosts", projects.AddPullHost).Methods("POST")
+	projectTmplManagement.HandleFunc("/{template_id}/pull/hosts/{host_id}", projects.RemovePullHost).Methods("DELETE")
+	projectTmplManagement.HandleFunc("/{template_id}/uploads", projects.GetTemplateUploads).Methods("GET", "HEAD")
+	projectTmplManagement.HandleFunc("/{template_id}/uploads", projects.AddTemplateUpload).Methods("POST")
+	projectTmplManagement.HandleFunc("/{template_id}/uploads/{upload_id}", projects.UpdateTemplateUpload).Methods("PUT")
+	projectTmplManagement.HandleFunc("/{template_id}/uploads/{upload_id}", projects.RemoveTemplateUpload).Methods("DELETE")
 
 	projectTaskManagement := projectUserAPI.PathPrefix("/tasks").Subrouter()
 	projectTaskManagement.Use(projects.GetTaskMiddleware)
 
 	projectTaskManagement.HandleFunc("/{task_id}/output", projects.GetTaskOutput).Methods("GET", "HEAD")
+	projectTaskManagement.HandleFunc("/{task_id}/raw_output", projects.GetTaskRawOutput).Methods("GET", "HEAD")
+	projectTaskManagement.HandleFunc("/{task_id}/findings", projects.GetTaskFindings).Methods("GET", "HEAD")
+	projectTaskManagement.HandleFunc("/{task_id}/lifecycle", projects.GetTaskLifecycle).Methods("GET", "HEAD")
+	projectTaskManagement.HandleFunc("/{task_id}/object_versions", projects.GetTaskObjectVersions).Methods("GET", "HEAD")
+	projectTaskManagement.HandleFunc("/{task_id}/outputs", projects.GetTaskRunOutputs).Methods("GET", "HEAD")
+	projectTaskManagement.HandleFunc("/{task_id}/artifacts", projects.GetTaskArtifacts).Methods("GET", "HEAD")
+	projectTaskManagement.HandleFunc("/{task_id}/terraform/plan", projects.GetTaskTerraformPlan).Methods("GET", "HEAD")
+	projectTaskManagement.HandleFunc("/{task_id}/terraform/plan/diff", projects.GetTaskTerraformPlanDiff).Methods("GET", "HEAD")
+	projectTaskManagement.HandleFunc("/{task_id}/comments", projects.GetTaskComments).Methods("GET", "HEAD")
+	projectTaskManagement.HandleFunc("/{task_id}/comments", projects.AddTaskComment).Methods("POST")
+	projectTaskManagement.HandleFunc("/{task_id}/comments/{comment_id}", projects.RemoveTaskComment).Methods("DELETE")
 	projectTaskManagement.HandleFunc("/{task_id}", projects.GetTask).Methods("GET", "HEAD")
 	projectTaskManagement.HandleFunc("/{task_id}", projects.RemoveTask).Methods("DELETE")
 
+	projectAdhocManagement := projectUserAPI.PathPrefix("/adhoc").Subrouter()
+	projectAdhocManagement.Use(projects.AdhocCommandMiddleware)
+	projectAdhocManagement.HandleFunc("/{command_id}", projects.GetAdhocCommands).Methods("GET", "HEAD")
+	projectAdhocManagement.HandleFunc("/{command_id}/output", projects.GetAdhocCommandOutput).Methods("GET", "HEAD")
+
 	projectScheduleManagement := projectUserAPI.PathPrefix("/schedules").Subrouter()
 	projectScheduleManagement.Use(projects.SchedulesMiddleware)
 	projectScheduleManagement.HandleFunc("/{schedule_id}", projects.GetSchedule).Methods("GET", "HEAD")
@@ -334,6 +524,24 @@ func Route() *mux.Router {
 	projectViewManagement.HandleFunc("/{view_id}", projects.RemoveView).Methods("DELETE")
 	projectViewManagement.HandleFunc("/{view_id}/templates", projects.GetViewTemplates).Methods("GET", "HEAD")
 
+	projectMaskingRuleManagement := projectUserAPI.PathPrefix("/masking_rules").Subrouter()
+	projectMaskingRuleManagement.Use(projects.MaskingRuleMiddleware)
+	projectMaskingRuleManagement.HandleFunc("/{rule_id}", projects.GetMaskingRules).Methods("GET", "HEAD")
+	projectMaskingRuleManagement.HandleFunc("/{rule_id}", projects.UpdateMaskingRule).Methods("PUT")
+	projectMaskingRuleManagement.HandleFunc("/{rule_id}", projects.RemoveMaskingRule).Methods("DELETE")
+
+	projectHostExclusionManagement := projectUserAPI.PathPrefix("/host_exclusions").Subrouter()
+	projectHostExclusionManagement.Use(projects.HostExclusionMiddleware)
+	projectHostExclusionManagement.HandleFunc("/{exclusion_id}", projects.GetHostExclusions).Methods("GET", "HEAD")
+	projectHostExclusionManagement.HandleFunc("/{exclusion_id}", projects.UpdateHostExclusion).Methods("PUT")
+	projectHostExclusionManagement.HandleFunc("/{exclusion_id}", projects.RemoveHostExclusion).Methods("DELETE")
+
+	projectDeploymentEnvironmentManagement := projectUserAPI.PathPrefix("/deployment_environments").Subrouter()
+	projectDeploymentEnvironmentManagement.Use(projects.DeploymentEnvironmentMiddleware)
+	projectDeploymentEnvironmentManagement.HandleFunc("/{deployment_environment_id}", projects.GetDeploymentEnvironments).Methods("GET", "HEAD")
+	projectDeploymentEnvironmentManagement.HandleFunc("/{deployment_environment_id}", projects.UpdateDeploymentEnvironment).Methods("PUT")
+	projectDeploymentEnvironmentManagement.HandleFunc("/{deployment_environment_id}", projects.RemoveDeploymentEnvironment).Methods("DELETE")
+
 	projectIntegrationsAliasAPI := projectUserAPI.PathPrefix("/integrations").Subrouter()
 	projectIntegrationsAliasAPI.Use(projects.ProjectMiddleware)
 	projectIntegrationsAliasAPI.HandleFunc("/aliases", projects.GetIntegrationAlias).Methods("GET", "HEAD")
@@ -346,6 +554,7 @@ func Route() *mux.Router {
 	projectIntegrationsAPI.HandleFunc("/{integration_id}", projects.DeleteIntegration).Methods("DELETE")
 	projectIntegrationsAPI.HandleFunc("/{integration_id}", projects.GetIntegration).Methods("GET")
 	projectIntegrationsAPI.HandleFunc("/{integration_id}/refs", projects.GetIntegrationRefs).Methods("GET", "HEAD")
+	projectIntegrationsAPI.Handle("/{integration_id}/rotate_secret", canViewKeys(http.HandlerFunc(projects.RotateIntegrationSecret))).Methods("POST")
 	projectIntegrationsAPI.HandleFunc("/{integration_id}/matchers", projects.GetIntegrationMatchers).Methods("GET", "HEAD")
 	projectIntegrationsAPI.HandleFunc("/{integration_id}/matchers", projects.AddIntegrationMatcher).Methods("POST")
 	projectIntegrationsAPI.HandleFunc("/{integration_id}/values", projects.GetIntegrationExtractValues).Methods("GET", "HEAD")
@@ -364,6 +573,10 @@ func Route() *mux.Router {
 	projectIntegrationsAPI.HandleFunc("/{integration_id}/values/{value_id}", projects.DeleteIntegrationExtractValue).Methods("DELETE")
 	projectIntegrationsAPI.HandleFunc("/{integration_id}/values/{value_id}/refs", projects.GetIntegrationExtractValueRefs).Methods("GET")
 
+	projectIntegrationsAPI.HandleFunc("/{integration_id}/deliveries", GetIntegrationDeliveries).Methods("GET", "HEAD")
+	projectIntegrationsAPI.HandleFunc("/{integration_id}/deliveries/{delivery_id}", GetIntegrationDelivery).Methods("GET", "HEAD")
+	projectIntegrationsAPI.HandleFunc("/{integration_id}/deliveries/{delivery_id}/replay", ReplayIntegrationDelivery).Methods("POST")
+
 	if os.Getenv("DEBUG") == "1" {
 		defer debugPrintRoutes(r)
 	}
@@ -1,14 +1,19 @@
 package runners
 
 import (
+	"crypto/x509"
+	"fmt"
 	"net/http"
 
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/mtls"
 	"github.com/semaphoreui/semaphore/pkg/task_logger"
 	"github.com/semaphoreui/semaphore/services/runners"
+	"github.com/semaphoreui/semaphore/services/tasks"
 	"github.com/semaphoreui/semaphore/util"
 	"github.com/gorilla/context"
+	log "github.com/sirupsen/logrus"
 )
 
 func RunnerMiddleware(next http.Handler) http.Handler {
@@ -16,6 +21,23 @@ func RunnerMiddleware(next http.Handler) http.Handler {
 
 		token := r.Header.Get("X-Runner-Token")
 
+		store := helpers.Store(r)
+
+		if token == "" && mtls.ClientCertificate(r) != nil && util.Config.TLS.ClientCertAuthEnabled() {
+			runner, err := findRunnerByCertificate(store, mtls.ClientCertificate(r))
+			if err != nil {
+				log.Warn(err.Error())
+				helpers.WriteJSON(w, http.StatusUnauthorized, map[string]string{
+					"error": "Invalid certificate",
+				})
+				return
+			}
+
+			context.Set(r, "runner", runner)
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		if token == "" {
 			helpers.WriteJSON(w, http.StatusUnauthorized, map[string]string{
 				"error": "Invalid token",
@@ -23,8 +45,6 @@ func RunnerMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		store := helpers.Store(r)
-
 		runner, err := store.GetGlobalRunnerByToken(token)
 
 		if err != nil {
@@ -46,14 +66,57 @@ func RunnerMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// requestRunner returns the runner authenticated by RunnerMiddleware.
+func requestRunner(r *http.Request) db.Runner {
+	return context.Get(r, "runner").(db.Runner)
+}
+
+// findRunnerByCertificate returns the active global runner whose name is
+// the common name of the client certificate.
+func findRunnerByCertificate(store db.Store, cert *x509.Certificate) (runner db.Runner, err error) {
+	name, err := mtls.Identity(cert, mtls.IdentityCommonName)
+	if err != nil {
+		return
+	}
+
+	runners, err := store.GetGlobalRunners(true)
+	if err != nil {
+		return
+	}
+
+	found := 0
+	for _, r := range runners {
+		if r.Name == name {
+			runner = r
+			found++
+		}
+	}
+
+	switch found {
+	case 0:
+		err = fmt.Errorf("no runner for certificate %s", name)
+	case 1:
+	default:
+		err = fmt.Errorf("several runners have name %s", name)
+	}
+
+	return
+}
+
 func GetRunner(w http.ResponseWriter, r *http.Request) {
 	runner := context.Get(r, "runner").(db.Runner)
 
+	helpers.WriteJSON(w, http.StatusOK, getRunnerState(helpers.TaskPool(r), runner))
+}
+
+// getRunnerState returns new jobs assigned to the runner with their access keys
+// and statuses of the jobs which are run by the runner.
+func getRunnerState(taskPool *tasks.TaskPool, runner db.Runner) runners.RunnerState {
 	data := runners.RunnerState{
 		AccessKeys: make(map[int]db.AccessKey),
 	}
 
-	tasks := helpers.TaskPool(r).GetRunningTasks()
+	tasks := taskPool.GetRunningTasks()
 
 	for _, tsk := range tasks {
 		if tsk.RunnerID != runner.ID {
@@ -65,7 +128,7 @@ func GetRunner(w http.ResponseWriter, r *http.Request) {
 			data.NewJobs = append(data.NewJobs, runners.JobData{
 				Username:            tsk.Username,
 				IncomingVersion:     tsk.IncomingVersion,
-				Task:                tsk.Task,
+				Task:                tsk.GetJobTask(),
 				Template:            tsk.Template,
 				Inventory:           tsk.Inventory,
 				InventoryRepository: tsk.Inventory.Repository,
@@ -101,6 +164,26 @@ func GetRunner(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 
+			for _, file := range tsk.Template.SecretFiles {
+				if file.Key != nil {
+					err := file.Key.DeserializeSecret()
+					if err != nil {
+						// TODO: return error
+					}
+					data.AccessKeys[file.KeyID] = *file.Key
+				}
+			}
+
+			for _, artifact := range tsk.Template.Artifacts {
+				if artifact.Key != nil {
+					err := artifact.Key.DeserializeSecret()
+					if err != nil {
+						// TODO: return error
+					}
+					data.AccessKeys[*artifact.KeyID] = *artifact.Key
+				}
+			}
+
 			if tsk.Inventory.RepositoryID != nil {
 				err := tsk.Inventory.Repository.SSHKey.DeserializeSecret()
 				if err != nil {
@@ -119,7 +202,7 @@ func GetRunner(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	helpers.WriteJSON(w, http.StatusOK, data)
+	return data
 }
 
 func UpdateRunner(w http.ResponseWriter, r *http.Request) {
@@ -135,13 +218,22 @@ func UpdateRunner(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	taskPool := helpers.TaskPool(r)
+	if body.DiskUsage != nil {
+		applyDiskUsage(helpers.Store(r), runner, *body.DiskUsage)
+	}
 
 	if body.Jobs == nil {
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
+	applyProgress(helpers.TaskPool(r), runner, body)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applyProgress writes logs and statuses of the jobs sent by the runner to their tasks.
+func applyProgress(taskPool *tasks.TaskPool, runner db.Runner, body runners.RunnerProgress) {
 	for _, job := range body.Jobs {
 		tsk := taskPool.GetTask(job.ID)
 
@@ -159,10 +251,35 @@ func UpdateRunner(w http.ResponseWriter, r *http.Request) {
 			tsk.LogWithTime(logRecord.Time, logRecord.Message)
 		}
 
+		if len(job.Findings) > 0 {
+			tsk.AddFindings(job.Findings)
+		}
+
+		if len(job.RunOutputs) > 0 {
+			tsk.SetRunOutputs(job.RunOutputs)
+		}
+
+		if len(job.Artifacts) > 0 {
+			tsk.AddArtifacts(job.Artifacts)
+		}
+
+		if job.Commit != nil {
+			tsk.SetCommit(job.Commit.Hash, job.Commit.Message)
+		}
+
 		tsk.SetStatus(job.Status)
 	}
+}
 
-	w.WriteHeader(http.StatusNoContent)
+// applyDiskUsage stores the disk usage of the tmp directory reported by the runner.
+func applyDiskUsage(store db.Store, runner db.Runner, usage runners.RunnerDiskUsage) {
+	err := store.SetDiskUsage(&runner.ID, usage.Usage)
+	if err != nil {
+		util.LogErrorWithFields(err, log.Fields{
+			"runner_id": runner.ID,
+			"error":     "Can not store disk usage of the runner",
+		})
+	}
 }
 
 func RegisterRunner(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,174 @@
+package runners
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/services/runners"
+	"github.com/semaphoreui/semaphore/services/tasks"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// streamResyncInterval is the period of sending the state of the runner even if it is not changed,
+// so the runner receives tasks assigned by other nodes of the cluster.
+const streamResyncInterval = 5 * time.Second
+
+type streamContextKey struct{}
+
+// streamContext is passed to the handler of the stream by the context of the request.
+type streamContext struct {
+	runner   db.Runner
+	store    db.Store
+	taskPool *tasks.TaskPool
+}
+
+var streamServer = runners.NewStreamServer(serveStream)
+
+// StreamMiddleware authenticates the runner of the stream. Unlike RunnerMiddleware,
+// the store session is closed before the stream starts, so the long-living stream
+// does not hold it.
+func StreamMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authenticated := false
+
+		db.StoreSession(helpers.Store(r), r.URL.String(), func() {
+			RunnerMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				authenticated = true
+			})).ServeHTTP(w, r)
+		})
+
+		if authenticated {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// Stream serves the gRPC stream of the runner, see runners.StreamPath.
+// The request must be sent by HTTP/2.
+func Stream(prefix string) http.Handler {
+	handler := http.StripPrefix(prefix, streamServer)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), streamContextKey{}, &streamContext{
+			runner:   requestRunner(r),
+			store:    helpers.Store(r),
+			taskPool: helpers.TaskPool(r),
+		})
+
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// getStreamMessage returns the message with the state of the runner.
+// Jobs of the runner in stopping status are cancelled.
+func getStreamMessage(sc *streamContext) runners.ServerMessage {
+	state := getRunnerState(sc.taskPool, sc.runner)
+
+	msg := runners.ServerMessage{State: &state}
+
+	for _, job := range state.CurrentJobs {
+		if job.Status == task_logger.TaskStoppingStatus {
+			msg.Cancel = append(msg.Cancel, job.ID)
+		}
+	}
+
+	return msg
+}
+
+// serveStream pushes the state of the runner when its tasks change and applies
+// the progress sent by the runner.
+func serveStream(_ any, stream grpc.ServerStream) error {
+	sc := stream.Context().Value(streamContextKey{}).(*streamContext)
+
+	changes, unsubscribe := sc.taskPool.SubscribeRunner(sc.runner.ID)
+	defer unsubscribe()
+
+	received := make(chan *runners.RunnerMessage)
+	receiveErr := make(chan error, 1)
+
+	go func() {
+		for {
+			msg := &runners.RunnerMessage{}
+			if err := stream.RecvMsg(msg); err != nil {
+				receiveErr <- err
+				return
+			}
+
+			select {
+			case received <- msg:
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+
+	resyncTicker := time.NewTicker(streamResyncInterval)
+	defer resyncTicker.Stop()
+
+	var lastSent []byte
+
+	// send writes the state of the runner if it changed since the previous message
+	// or if force is true.
+	send := func(force bool) error {
+		msg := getStreamMessage(sc)
+
+		encoded, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+
+		if !force && bytes.Equal(encoded, lastSent) {
+			return nil
+		}
+
+		lastSent = encoded
+		return stream.SendMsg(&msg)
+	}
+
+	for {
+		var err error
+
+		select {
+		case msg := <-received:
+			if msg.Progress != nil && len(msg.Progress.Jobs) > 0 {
+				db.StoreSession(sc.store, "runner stream", func() {
+					applyProgress(sc.taskPool, sc.runner, *msg.Progress)
+				})
+			}
+
+			if msg.Progress != nil && msg.Progress.DiskUsage != nil {
+				db.StoreSession(sc.store, "runner stream", func() {
+					applyDiskUsage(sc.store, sc.runner, *msg.Progress.DiskUsage)
+				})
+			}
+
+			if msg.Heartbeat {
+				err = send(true)
+			}
+
+		case <-changes:
+			err = send(false)
+
+		case <-resyncTicker.C:
+			err = send(false)
+
+		case err = <-receiveErr:
+			log.WithField("runner_id", sc.runner.ID).Debug("Stream of the runner closed: " + err.Error())
+			return nil
+
+		case <-stream.Context().Done():
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func writeSettings(w http.ResponseWriter, r *http.Request) {
+	options, err := helpers.Store(r).GetOptions(db.RetrieveQueryParams{})
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, util.GetSettings(options))
+}
+
+// getBootstrap returns the public settings required by the UI before login.
+func getBootstrap(w http.ResponseWriter, r *http.Request) {
+	branding := util.Config.GetBranding()
+
+	helpers.WriteJSON(w, http.StatusOK, map[string]string{
+		"product_name":  util.Config.GetProductName(),
+		"logo_url":      branding.LogoURL,
+		"login_message": branding.LoginMessage,
+	})
+}
+
+func getSettings(w http.ResponseWriter, r *http.Request) {
+	writeSettings(w, r)
+}
+
+// updateSettings stores the settings in the database and applies them
+// without restart. Null value resets the setting to the value from the config file.
+func updateSettings(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	if !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	values := make(map[string]*string)
+
+	for key, value := range body {
+		if value == nil {
+			if !util.IsEditableSetting(key) {
+				helpers.WriteErrorStatus(w, fmt.Sprintf("setting %s can not be changed", key), http.StatusBadRequest)
+				return
+			}
+			values[key] = nil
+			continue
+		}
+
+		str := fmt.Sprintf("%v", value)
+		if _, err := util.ParseSettingValue(key, str); err != nil {
+			helpers.WriteErrorStatus(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		values[key] = &str
+	}
+
+	store := helpers.Store(r)
+
+	for key, value := range values {
+		var err error
+
+		if value == nil {
+			err = store.DeleteOption(key)
+			if err == nil || errors.Is(err, db.ErrNotFound) {
+				err = util.ResetSetting(key)
+			}
+		} else {
+			err = store.SetOption(key, *value)
+			if err == nil {
+				err = util.ApplySetting(key, *value)
+			}
+		}
+
+		if err != nil {
+			helpers.WriteErrorStatus(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeSettings(w, r)
+}
+
+func resetSetting(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	if !util.IsEditableSetting(key) {
+		helpers.WriteErrorStatus(w, fmt.Sprintf("setting %s can not be changed", key), http.StatusBadRequest)
+		return
+	}
+
+	if err := helpers.Store(r).DeleteOption(key); err != nil && !errors.Is(err, db.ErrNotFound) {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if err := util.ResetSetting(key); err != nil {
+		helpers.WriteErrorStatus(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,96 @@
+package sse
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	EventTaskCreated   = "task_created"
+	EventTaskStatus    = "task_status"
+	EventTaskProgress  = "task_progress"
+	EventScheduleFired = "schedule_fired"
+)
+
+// subscriberBufferSize is the number of events which can be queued for a slow
+// client. Events are dropped for the client when the buffer is full.
+const subscriberBufferSize = 64
+
+// Subscriber receives events of the project.
+type Subscriber struct {
+	projectID int
+	send      chan string
+}
+
+// Events returns the channel of events formatted for text/event-stream.
+func (s *Subscriber) Events() <-chan string {
+	return s.send
+}
+
+// hub keeps subscribers grouped by projects.
+type hub struct {
+	mu          sync.RWMutex
+	subscribers map[int]map[*Subscriber]bool
+	lastID      atomic.Uint64
+}
+
+var h = hub{
+	subscribers: make(map[int]map[*Subscriber]bool),
+}
+
+// Subscribe registers a new subscriber for events of the project.
+// Unsubscribe must be called when the subscriber is not needed anymore.
+func Subscribe(projectID int) *Subscriber {
+	s := &Subscriber{
+		projectID: projectID,
+		send:      make(chan string, subscriberBufferSize),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers[projectID] == nil {
+		h.subscribers[projectID] = make(map[*Subscriber]bool)
+	}
+	h.subscribers[projectID][s] = true
+
+	return s
+}
+
+// Unsubscribe removes the subscriber from the hub.
+func Unsubscribe(s *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.subscribers[s.projectID], s)
+	if len(h.subscribers[s.projectID]) == 0 {
+		delete(h.subscribers, s.projectID)
+	}
+}
+
+// Publish sends the event to all clients subscribed to the project.
+// data is encoded to JSON.
+func Publish(projectID int, eventType string, data any) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	msg := fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", h.lastID.Add(1), eventType, b)
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for s := range h.subscribers[projectID] {
+		select {
+		case s.send <- msg:
+		default:
+			log.Warn("Dropping event " + eventType + " for slow SSE client")
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package sse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPublish(t *testing.T) {
+	s := Subscribe(1)
+	defer Unsubscribe(s)
+
+	other := Subscribe(2)
+	defer Unsubscribe(other)
+
+	Publish(1, EventTaskStatus, map[string]any{"task_id": 5, "status": "running"})
+
+	select {
+	case msg := <-s.Events():
+		if !strings.Contains(msg, "event: task_status\ndata: {\"status\":\"running\",\"task_id\":5}\n\n") {
+			t.Fatal("unexpected message", msg)
+		}
+	default:
+		t.Fatal("event expected")
+	}
+
+	select {
+	case msg := <-other.Events():
+		t.Fatal("event of other project received", msg)
+	default:
+	}
+}
+
+func TestPublishToSlowSubscriber(t *testing.T) {
+	s := Subscribe(3)
+	defer Unsubscribe(s)
+
+	for i := 0; i < subscriberBufferSize+10; i++ {
+		Publish(3, EventTaskCreated, i)
+	}
+
+	if len(s.Events()) != subscriberBufferSize {
+		t.Fatal("events must be dropped when buffer is full")
+	}
+}
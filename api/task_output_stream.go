@@ -0,0 +1,194 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/tasks"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+const (
+	// taskOutputPollInterval is the period of reading new output of the followed task.
+	// The output is stored by batches once a second, so it is not read more often.
+	taskOutputPollInterval = time.Second
+
+	// taskOutputPageSize is the maximum number of output records read at once.
+	taskOutputPageSize = 500
+
+	// taskOutputEndDelay is the time after the end of the task during which its last
+	// output can still be buffered, the stream does not end before it.
+	taskOutputEndDelay = 3 * time.Second
+)
+
+// getOutputStreamOffset returns the number of output records the client already received.
+// Browsers send it in Last-Event-ID when they reconnect, other clients pass the offset
+// of the checkpoint or of the last received record in the query.
+func getOutputStreamOffset(r *http.Request) (int, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("offset")
+	}
+
+	if value == "" {
+		return 0, nil
+	}
+
+	offset, err := strconv.Atoi(value)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid offset %q", value)
+	}
+
+	return offset, nil
+}
+
+// getTaskOutputStream streams the output of the task using Server-Sent Events.
+// The ID of every event is the offset of the next record, so the client can resume
+// the stream after the reconnect or the restart of the server. The stream ends
+// with the "end" event when the task is finished and all its output is sent.
+func getTaskOutputStream(w http.ResponseWriter, r *http.Request) {
+	user := context.Get(r, "user").(*db.User)
+	store := helpers.Store(r)
+
+	projectID, err := helpers.GetIntParam("project_id", w, r)
+	if err != nil {
+		return
+	}
+
+	taskID, err := helpers.GetIntParam("task_id", w, r)
+	if err != nil {
+		return
+	}
+
+	offset, err := getOutputStreamOffset(r)
+	if err != nil {
+		helpers.WriteErrorStatus(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var task db.Task
+
+	db.StoreSession(store, r.URL.String(), func() {
+		if !user.Admin {
+			_, err = store.GetProjectUser(projectID, user.ID)
+			if err != nil {
+				return
+			}
+		}
+
+		task, err = store.GetTask(projectID, taskID)
+	})
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		helpers.WriteErrorStatus(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	stripANSI := r.URL.Query().Get("strip_ansi") == "1"
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	pollTicker := time.NewTicker(taskOutputPollInterval)
+	defer pollTicker.Stop()
+
+	keepAliveTicker := time.NewTicker(eventStreamKeepAlivePeriod)
+	defer keepAliveTicker.Stop()
+
+	for {
+		var output []db.TaskOutput
+		finished := task.Status.IsFinished() && (task.End == nil || time.Since(*task.End) > taskOutputEndDelay)
+
+		db.StoreSession(store, r.URL.String(), func() {
+			output, err = tasks.GetTaskOutputsFrom(store, task, offset, taskOutputPageSize)
+			if err != nil || len(output) > 0 || !finished {
+				return
+			}
+
+			// the output of the finished task could be moved to the object storage
+			// after the task was read, the task is read again before the end
+			var current db.Task
+			current, err = store.GetTask(projectID, taskID)
+			if err == nil && current.OutputObject != nil && task.OutputObject == nil {
+				task = current
+				finished = false
+			}
+		})
+
+		if err != nil {
+			_, _ = fmt.Fprintf(w, "event: error\ndata: %q\n\n", err.Error())
+			flusher.Flush()
+			return
+		}
+
+		for _, record := range output {
+			offset++
+
+			if stripANSI {
+				record.Output = util.StripANSI(record.Output)
+			}
+
+			data, _ := json.Marshal(record)
+			if _, err = fmt.Fprintf(w, "id: %d\nevent: output\ndata: %s\n\n", offset, data); err != nil {
+				return
+			}
+		}
+
+		if len(output) == 0 && finished {
+			data, _ := json.Marshal(map[string]any{
+				"status": task.Status,
+				"offset": offset,
+			})
+			_, _ = fmt.Fprintf(w, "event: end\ndata: %s\n\n", data)
+			flusher.Flush()
+			return
+		}
+
+		flusher.Flush()
+
+		if len(output) == taskOutputPageSize {
+			// the next page is read without waiting
+			continue
+		}
+
+		if !finished {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-keepAliveTicker.C:
+				if _, err = fmt.Fprint(w, ": ping\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-pollTicker.C:
+			}
+
+			db.StoreSession(store, r.URL.String(), func() {
+				var current db.Task
+				current, err = store.GetTask(projectID, taskID)
+				if err == nil {
+					task = current
+				}
+			})
+
+			if err != nil {
+				return
+			}
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/tasks"
 	"github.com/semaphoreui/semaphore/util"
 	"github.com/gorilla/context"
 	"github.com/gorilla/mux"
@@ -22,11 +23,17 @@ func getUser(w http.ResponseWriter, r *http.Request) {
 	var user struct {
 		db.User
 		CanCreateProject bool `json:"can_create_project"`
+		// ImpersonatedBy is the username of the admin who impersonates the user.
+		ImpersonatedBy *string `json:"impersonated_by,omitempty"`
 	}
 
 	user.User = *context.Get(r, "user").(*db.User)
 	user.CanCreateProject = user.Admin || util.Config.NonAdminCanCreateProject
 
+	if impersonator := getImpersonator(r); impersonator != nil {
+		user.ImpersonatedBy = &impersonator.Username
+	}
+
 	helpers.WriteJSON(w, http.StatusOK, user)
 }
 
@@ -44,6 +51,21 @@ func getAPITokens(w http.ResponseWriter, r *http.Request) {
 
 func createAPIToken(w http.ResponseWriter, r *http.Request) {
 	user := context.Get(r, "user").(*db.User)
+
+	var params struct {
+		Role db.APITokenRole `json:"role"`
+	}
+
+	// the body is optional, tokens are created with all permissions of the user by default
+	if r.ContentLength > 0 && !helpers.Bind(w, r, &params) {
+		return
+	}
+
+	if !params.Role.IsValid() {
+		helpers.WriteErrorStatus(w, "Invalid token role", http.StatusBadRequest)
+		return
+	}
+
 	tokenID := make([]byte, 32)
 	if _, err := io.ReadFull(rand.Reader, tokenID); err != nil {
 		panic(err)
@@ -53,11 +75,20 @@ func createAPIToken(w http.ResponseWriter, r *http.Request) {
 		ID:      strings.ToLower(base64.URLEncoding.EncodeToString(tokenID)),
 		UserID:  user.ID,
 		Expired: false,
+		Role:    params.Role,
 	})
 	if err != nil {
 		panic(err)
 	}
 
+	tasks.SendAccessChangeAlert(helpers.Store(r), tasks.AccessChange{
+		Type:          tasks.AccessAPITokenCreated,
+		UserID:        user.ID,
+		Username:      user.Username,
+		ActorID:       user.ID,
+		ActorUsername: user.Username,
+	})
+
 	helpers.WriteJSON(w, http.StatusCreated, token)
 }
 
@@ -54,11 +54,18 @@ func addUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !user.External {
+		if err := db.ValidatePassword(util.Config.GetPasswordPolicy(), user.Pwd); err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+	}
+
 	newUser, err := helpers.Store(r).CreateUser(user)
 
 	if err != nil {
 		log.Warn(editor.Username + " is not created: " + err.Error())
-		w.WriteHeader(http.StatusBadRequest)
+		helpers.WriteError(w, err)
 		return
 	}
 
@@ -120,6 +127,18 @@ func updateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := db.ValidateUser(user.User); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if user.Pwd != "" && !targetUser.External {
+		if err := db.ValidatePasswordChange(helpers.Store(r), targetUser, user.Pwd); err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+	}
+
 	user.ID = targetUser.ID
 	if err := helpers.Store(r).UpdateUser(user); err != nil {
 		log.Error(err.Error())
@@ -154,6 +173,11 @@ func updateUserPassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := db.ValidatePasswordChange(helpers.Store(r), user, pwd.Pwd); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
 	if err := helpers.Store(r).SetUserPassword(user.ID, pwd.Pwd); err != nil {
 		util.LogWarning(err)
 		w.WriteHeader(http.StatusInternalServerError)
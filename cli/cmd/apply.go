@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	projectService "github.com/semaphoreui/semaphore/services/project"
+	"github.com/spf13/cobra"
+)
+
+var applyArgs struct {
+	file   string
+	dryRun bool
+}
+
+func init() {
+	applyCmd.PersistentFlags().StringVarP(&applyArgs.file, "file", "f", "", "Path to YAML file with projects configuration")
+	applyCmd.PersistentFlags().BoolVar(&applyArgs.dryRun, "dry-run", false, "Print changes without applying them")
+	rootCmd.AddCommand(applyCmd)
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Reconcile projects with declarative configuration",
+	Long: "Creates and updates projects, keys, repositories, inventories, environments and templates " +
+		"described in the YAML file and prints the difference. Secret values can be read from " +
+		"environment variables ({env: NAME}) or HashiCorp Vault ({vault: path#field}).",
+	Run: func(cmd *cobra.Command, args []string) {
+		if applyArgs.file == "" {
+			fmt.Println("Argument --file required")
+			fmt.Println("Use command `semaphore apply --help` for details.")
+			os.Exit(1)
+		}
+
+		data, err := os.ReadFile(applyArgs.file)
+		if err != nil {
+			panic(err)
+		}
+
+		config, err := projectService.ParseApplyConfig(data)
+		if err != nil {
+			panic(err)
+		}
+
+		store := createStore("")
+		defer store.Close("")
+
+		changes, err := projectService.Apply(config, store, applyArgs.dryRun)
+
+		for _, change := range changes {
+			fmt.Println(change.String())
+		}
+
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		if applyArgs.dryRun {
+			fmt.Println("Dry run, no changes applied.")
+		}
+	},
+}
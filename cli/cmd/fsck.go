@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/housekeeping"
+	"github.com/spf13/cobra"
+)
+
+var fsckArgs struct {
+	repair bool
+	purge  bool
+	yes    bool
+}
+
+func init() {
+	fsckCmd.PersistentFlags().BoolVar(&fsckArgs.repair, "repair", false, "Unset references to deleted objects, the rows are kept")
+	fsckCmd.PersistentFlags().BoolVar(&fsckArgs.purge, "purge", false, "Remove rows which can not be used without deleted objects")
+	fsckCmd.PersistentFlags().BoolVar(&fsckArgs.yes, "yes", false, "Do not ask for the confirmation")
+	rootCmd.AddCommand(fsckCmd)
+}
+
+var fsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "Check the database for orphaned references",
+	Long: "Finds rows which refer to deleted objects, e.g. tasks of deleted templates and secrets " +
+		"of deleted environments. Without flags the command prints them and exits with code 1 " +
+		"if there are any. Use --repair and --purge to fix them.",
+	Run: func(cmd *cobra.Command, args []string) {
+		store := createStore("fsck")
+		defer store.Close("fsck")
+
+		refs, err := store.GetOrphanedReferences()
+		if err != nil {
+			panic(err)
+		}
+
+		if len(refs) == 0 {
+			fmt.Println("No orphaned references found")
+			return
+		}
+
+		fmt.Printf("Orphaned references: %d\n\n", len(refs))
+
+		for _, ref := range refs {
+			fmt.Printf("  %s %d of project %d: %s %d does not exist (%s)\n",
+				ref.ObjectType, ref.ObjectID, ref.ProjectID, ref.Field, ref.RefID, ref.Fix)
+		}
+
+		var fixes []db.OrphanFix
+		if fsckArgs.repair {
+			fixes = append(fixes, db.OrphanFixUnset)
+		}
+		if fsckArgs.purge {
+			fixes = append(fixes, db.OrphanFixPurge)
+		}
+
+		if len(fixes) == 0 {
+			fmt.Println("\nRun `semaphore fsck --repair --purge` to fix them.")
+			os.Exit(1)
+		}
+
+		if !fsckArgs.yes {
+			answer := readNewline("\nApply fixes to the database? [y/N]: ", bufio.NewReader(os.Stdin))
+			if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+				fmt.Println("Nothing changed")
+				os.Exit(1)
+			}
+		}
+
+		fixed, err := housekeeping.FixOrphanedReferences(store, refs, fixes...)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("%d orphaned references fixed\n", fixed)
+	},
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/semaphoreui/semaphore/pkg/gitcredential"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(gitCredentialCmd)
+}
+
+// gitCredentialCmd is the credential helper of git commands run by the tasks.
+// It is not intended to be run by users.
+var gitCredentialCmd = &cobra.Command{
+	Use:    gitcredential.Command + " <action>",
+	Short:  "Provide credentials of the repository to git",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// credentials are only served, git can not store or erase them
+		if args[0] != "get" {
+			return
+		}
+
+		socketFile := os.Getenv(gitcredential.SocketEnv)
+		if socketFile == "" {
+			return
+		}
+
+		if err := gitcredential.Get(socketFile, os.Stdin, os.Stdout); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
@@ -1,20 +1,107 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/util"
 	"github.com/spf13/cobra"
 )
 
+// largeTableRows is the number of rows starting from which altering of the table
+// can take long time and lock the table.
+const largeTableRows = 1000000
+
+var migrateArgs struct {
+	plan           bool
+	check          bool
+	compatibleOnly bool
+}
+
 func init() {
+	migrateCmd.PersistentFlags().BoolVar(&migrateArgs.plan, "plan", false, "Print pending migrations without applying them")
+	migrateCmd.PersistentFlags().BoolVar(&migrateArgs.check, "check", false, "Print pending migrations and exit with code 1 if there are any")
+	migrateCmd.PersistentFlags().BoolVar(&migrateArgs.compatibleOnly, "compatible-only", false, "Apply only migrations which do not break nodes of the previous version")
 	rootCmd.AddCommand(migrateCmd)
 }
 
 var migrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Execute migrations",
+	Long: "Applies pending database migrations. For the rolling upgrade of several nodes " +
+		"run `semaphore migrate --compatible-only` before upgrading the nodes, the remaining " +
+		"migrations are applied after all nodes are upgraded.",
 	Run: func(cmd *cobra.Command, args []string) {
-		store := createStore("migrate")
+		if !migrateArgs.plan && !migrateArgs.check && !migrateArgs.compatibleOnly {
+			store := createStore("migrate")
+			defer store.Close("migrate")
+			util.Config.PrintDbInfo()
+			return
+		}
+
+		store := connectStore("migrate")
 		defer store.Close("migrate")
-		util.Config.PrintDbInfo()
+
+		if migrateArgs.compatibleOnly {
+			applied, err := db.MigrateCompatible(store)
+			if err != nil {
+				panic(err)
+			}
+			fmt.Printf("%d backward compatible migrations applied\n", applied)
+		}
+
+		pending, err := db.GetPendingMigrations(store)
+		if err != nil {
+			panic(err)
+		}
+
+		if len(pending) == 0 {
+			fmt.Println("No pending migrations")
+			return
+		}
+
+		fmt.Printf("Pending migrations: %d\n", len(pending))
+
+		for _, version := range pending {
+			plan, err := store.GetMigrationPlan(version)
+			if err != nil {
+				panic(err)
+			}
+			printMigrationPlan(plan)
+		}
+
+		if migrateArgs.check {
+			os.Exit(1)
+		}
 	},
 }
+
+func printMigrationPlan(plan db.MigrationPlan) {
+	compatibility := "breaks nodes of the previous version"
+	if plan.BackwardCompatible {
+		compatibility = "backward compatible"
+	}
+
+	fmt.Printf("\n%s (%s)\n", plan.Migration.HumanoidVersion(), compatibility)
+
+	for _, query := range plan.Queries {
+		fmt.Printf("  %s\n", query)
+	}
+
+	tables := make([]string, 0, len(plan.TableRows))
+	for table := range plan.TableRows {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	for _, table := range tables {
+		rows := plan.TableRows[table]
+		fmt.Printf("  table %s: ~%d rows", table, rows)
+		if rows >= largeTableRows {
+			fmt.Print(" (large table, the migration can take long time)")
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/semaphoreui/semaphore/api"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(openAPICmd)
+}
+
+var openAPICmd = &cobra.Command{
+	Use:   "openapi",
+	Short: "Print OpenAPI specification generated from the API routes",
+	Run: func(cmd *cobra.Command, args []string) {
+		spec, err := api.GenerateOpenAPI(api.Route(), "/api")
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Println(string(spec))
+	},
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/spf13/cobra"
+)
+
+type projectArgs struct {
+	name  string
+	owner string
+	stdin bool
+}
+
+var targetProjectArgs projectArgs
+
+func init() {
+	rootCmd.AddCommand(projectCmd)
+}
+
+var projectCmd = &cobra.Command{
+	Use:     "projects",
+	Aliases: []string{"project"},
+	Short:   "Manage projects",
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+		os.Exit(0)
+	},
+}
+
+// findProject returns the project with the given name or nil if it does not exist.
+func findProject(store db.Store, name string) (*db.Project, error) {
+	projects, err := store.GetAllProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range projects {
+		if p.Name == name {
+			return &p, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func mustFindProject(store db.Store, name string) db.Project {
+	project, err := findProject(store, name)
+	if err != nil {
+		panic(err)
+	}
+
+	if project == nil {
+		fmt.Printf("Project %s not found\n", name)
+		os.Exit(1)
+	}
+
+	return *project
+}
+
+// readStdinJSON fills args from JSON on stdin if --stdin flag is set.
+func readStdinJSON(args any) {
+	if !targetProjectArgs.stdin {
+		return
+	}
+
+	if err := json.NewDecoder(os.Stdin).Decode(args); err != nil {
+		fmt.Printf("Invalid JSON on stdin: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	projectAddCmd.PersistentFlags().StringVar(&targetProjectArgs.name, "name", "", "Project name")
+	projectAddCmd.PersistentFlags().StringVar(&targetProjectArgs.owner, "owner", "", "Login of the project owner")
+	projectCmd.AddCommand(projectAddCmd)
+}
+
+var projectAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add new project, does nothing if project with the same name exists",
+	Run: func(cmd *cobra.Command, args []string) {
+		if targetProjectArgs.name == "" {
+			fmt.Println("Argument --name required")
+			fmt.Println("Use command `semaphore project add --help` for details.")
+			os.Exit(1)
+		}
+
+		store := createStore("")
+		defer store.Close("")
+
+		existing, err := findProject(store, targetProjectArgs.name)
+		if err != nil {
+			panic(err)
+		}
+
+		if existing != nil {
+			fmt.Printf("Project %s already exists (ID %d)\n", existing.Name, existing.ID)
+			return
+		}
+
+		project, _, _ := createProject(store, targetProjectArgs.name, targetProjectArgs.owner)
+
+		fmt.Printf("Project %s added (ID %d)\n", project.Name, project.ID)
+	},
+}
+
+// createProject creates the project with the None key and the Empty environment
+// which are required by other resources, and adds the owner if it is not empty.
+func createProject(store db.Store, name string, owner string) (project db.Project, noneKey db.AccessKey, emptyEnv db.Environment) {
+	project, err := store.CreateProject(db.Project{Name: name})
+	if err != nil {
+		panic(err)
+	}
+
+	if owner != "" {
+		var user db.User
+		user, err = store.GetUserByLoginOrEmail(owner, owner)
+		if err != nil {
+			panic(err)
+		}
+
+		_, err = store.CreateProjectUser(db.ProjectUser{ProjectID: project.ID, UserID: user.ID, Role: db.ProjectOwner})
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	noneKey, err = store.CreateAccessKey(db.AccessKey{
+		Name:      "None",
+		Type:      db.AccessKeyNone,
+		ProjectID: &project.ID,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	emptyEnv, err = store.CreateEnvironment(db.Environment{
+		Name:      "Empty",
+		ProjectID: project.ID,
+		JSON:      "{}",
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/semaphoreui/semaphore/db"
+	projectService "github.com/semaphoreui/semaphore/services/project"
+	"github.com/spf13/cobra"
+)
+
+var projectImportAWXArgs struct {
+	file         string
+	url          string
+	token        string
+	organization string
+}
+
+func init() {
+	projectImportAWXCmd.PersistentFlags().StringVar(&projectImportAWXArgs.file, "file", "", "Path to JSON file created by `awx export`")
+	projectImportAWXCmd.PersistentFlags().StringVar(&projectImportAWXArgs.url, "url", "", "URL of AWX to read objects from the API instead of the file")
+	projectImportAWXCmd.PersistentFlags().StringVar(&projectImportAWXArgs.token, "token", "", "OAuth2 token of AWX user")
+	projectImportAWXCmd.PersistentFlags().StringVar(&projectImportAWXArgs.organization, "organization", "", "Import only objects of the organization")
+	projectImportAWXCmd.PersistentFlags().StringVar(&targetProjectArgs.name, "name", "", "Project name, the organization name by default")
+	projectImportAWXCmd.PersistentFlags().StringVar(&targetProjectArgs.owner, "owner", "", "Login of the project owner")
+	projectCmd.AddCommand(projectImportAWXCmd)
+}
+
+var projectImportAWXCmd = &cobra.Command{
+	Use:   "import-awx",
+	Short: "Create project from AWX or Ansible Tower",
+	Long: "Creates a project with keys, repositories, inventories, templates and schedules " +
+		"equivalent to credentials, projects, inventories, job templates and schedules of AWX, " +
+		"and prints objects which can not be imported. Secrets are not exported by AWX, " +
+		"imported keys must be filled manually.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if (projectImportAWXArgs.file == "") == (projectImportAWXArgs.url == "") {
+			fmt.Println("One of arguments --file or --url required")
+			fmt.Println("Use command `semaphore project import-awx --help` for details.")
+			os.Exit(1)
+		}
+
+		var export *projectService.AWXExport
+		var err error
+
+		if projectImportAWXArgs.file != "" {
+			var data []byte
+			if data, err = os.ReadFile(projectImportAWXArgs.file); err != nil {
+				panic(err)
+			}
+			export, err = projectService.ParseAWXExport(data)
+		} else {
+			export, err = projectService.FetchAWXExport(projectImportAWXArgs.url, projectImportAWXArgs.token)
+		}
+
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		store := createStore("")
+		defer store.Close("")
+
+		options := projectService.AWXImportOptions{
+			ProjectName:  targetProjectArgs.name,
+			Organization: projectImportAWXArgs.organization,
+		}
+
+		if targetProjectArgs.owner != "" {
+			var owner db.User
+			owner, err = store.GetUserByLoginOrEmail(targetProjectArgs.owner, targetProjectArgs.owner)
+			if err != nil {
+				panic(err)
+			}
+			options.Owner = &owner
+		}
+
+		report, err := export.Import(options, store)
+
+		if report != nil {
+			for _, item := range report.Imported {
+				fmt.Printf("+ %s %q\n", item.Kind, item.Name)
+			}
+			for _, item := range report.Warnings {
+				fmt.Printf("! %s %q: %s\n", item.Kind, item.Name, item.Reason)
+			}
+			for _, item := range report.Unmapped {
+				fmt.Printf("- %s %q: %s\n", item.Kind, item.Name, item.Reason)
+			}
+		}
+
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Printf("Project %s imported (ID %d)\n", report.Project.Name, report.Project.ID)
+	},
+}
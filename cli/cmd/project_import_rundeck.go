@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/semaphoreui/semaphore/db"
+	projectService "github.com/semaphoreui/semaphore/services/project"
+	"github.com/spf13/cobra"
+)
+
+var projectImportRundeckArgs struct {
+	file       string
+	repository string
+	scriptsDir string
+	output     string
+}
+
+func init() {
+	projectImportRundeckCmd.PersistentFlags().StringVar(&targetProjectArgs.name, "project", "", "Project name")
+	projectImportRundeckCmd.PersistentFlags().StringVar(&projectImportRundeckArgs.file, "file", "", "Path to XML or YAML file with job definitions exported from Rundeck")
+	projectImportRundeckCmd.PersistentFlags().StringVar(&projectImportRundeckArgs.repository, "repository", "", "Name of repository to which the scripts of jobs will be committed")
+	projectImportRundeckCmd.PersistentFlags().StringVar(&projectImportRundeckArgs.scriptsDir, "scripts-dir", "rundeck", "Directory of the scripts in the repository")
+	projectImportRundeckCmd.PersistentFlags().StringVar(&projectImportRundeckArgs.output, "output", ".", "Path to working copy of the repository where the scripts are written")
+	projectCmd.AddCommand(projectImportRundeckCmd)
+}
+
+var projectImportRundeckCmd = &cobra.Command{
+	Use:   "import-rundeck",
+	Short: "Create templates from Rundeck jobs",
+	Long: "Creates bash templates with survey variables and schedules for command and script jobs " +
+		"of Rundeck and prints objects which can not be imported. Steps of each job are converted " +
+		"to the script which is written to the working copy of the repository and must be committed.",
+	Run: func(cmd *cobra.Command, args []string) {
+		ok := true
+		if targetProjectArgs.name == "" {
+			fmt.Println("Argument --project required")
+			ok = false
+		}
+		if projectImportRundeckArgs.file == "" {
+			fmt.Println("Argument --file required")
+			ok = false
+		}
+		if projectImportRundeckArgs.repository == "" {
+			fmt.Println("Argument --repository required")
+			ok = false
+		}
+
+		if !ok {
+			fmt.Println("Use command `semaphore project import-rundeck --help` for details.")
+			os.Exit(1)
+		}
+
+		data, err := os.ReadFile(projectImportRundeckArgs.file)
+		if err != nil {
+			panic(err)
+		}
+
+		jobs, err := projectService.ParseRundeckJobs(data)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		store := createStore("")
+		defer store.Close("")
+
+		project := mustFindProject(store, targetProjectArgs.name)
+
+		repos, err := store.GetRepositories(project.ID, db.RetrieveQueryParams{})
+		if err != nil {
+			panic(err)
+		}
+
+		var repositoryID int
+		for _, repo := range repos {
+			if repo.Name == projectImportRundeckArgs.repository {
+				repositoryID = repo.ID
+			}
+		}
+
+		if repositoryID == 0 {
+			fmt.Printf("Repository %s not found in project %s\n", projectImportRundeckArgs.repository, project.Name)
+			os.Exit(1)
+		}
+
+		report, err := projectService.ImportRundeckJobs(jobs, projectService.RundeckImportOptions{
+			ProjectID:    project.ID,
+			RepositoryID: repositoryID,
+			ScriptsDir:   projectImportRundeckArgs.scriptsDir,
+		}, store)
+
+		if report != nil {
+			for _, script := range report.Scripts {
+				fullPath := filepath.Join(projectImportRundeckArgs.output, filepath.FromSlash(script.Path))
+				if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+					panic(err)
+				}
+				if err := os.WriteFile(fullPath, []byte(script.Content), 0755); err != nil {
+					panic(err)
+				}
+				fmt.Printf("> %s\n", fullPath)
+			}
+			for _, item := range report.Imported {
+				fmt.Printf("+ %s %q\n", item.Kind, item.Name)
+			}
+			for _, item := range report.Warnings {
+				fmt.Printf("! %s %q: %s\n", item.Kind, item.Name, item.Reason)
+			}
+			for _, item := range report.Unmapped {
+				fmt.Printf("- %s %q: %s\n", item.Kind, item.Name, item.Reason)
+			}
+		}
+
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Printf("Jobs imported to project %s, commit the scripts to repository %s\n", project.Name, projectImportRundeckArgs.repository)
+	},
+}
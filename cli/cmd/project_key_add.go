@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/spf13/cobra"
+)
+
+var projectKeyAddArgs struct {
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	Login          string `json:"login"`
+	Password       string `json:"password"`
+	PrivateKey     string `json:"private_key"`
+	PrivateKeyFile string `json:"private_key_file"`
+	Passphrase     string `json:"passphrase"`
+	String         string `json:"string"`
+}
+
+func init() {
+	projectKeyAddCmd.PersistentFlags().StringVar(&targetProjectArgs.name, "project", "", "Project name")
+	projectKeyAddCmd.PersistentFlags().BoolVar(&targetProjectArgs.stdin, "stdin", false, "Read key as JSON object from stdin")
+	projectKeyAddCmd.PersistentFlags().StringVar(&projectKeyAddArgs.Name, "name", "", "Key name")
+	projectKeyAddCmd.PersistentFlags().StringVar(&projectKeyAddArgs.Type, "type", string(db.AccessKeyNone), "Key type: none, ssh, login_password or string")
+	projectKeyAddCmd.PersistentFlags().StringVar(&projectKeyAddArgs.Login, "login", "", "Login for ssh and login_password keys")
+	projectKeyAddCmd.PersistentFlags().StringVar(&projectKeyAddArgs.Password, "password", "", "Password for login_password key")
+	projectKeyAddCmd.PersistentFlags().StringVar(&projectKeyAddArgs.PrivateKeyFile, "private-key-file", "", "Path to private key for ssh key")
+	projectKeyAddCmd.PersistentFlags().StringVar(&projectKeyAddArgs.Passphrase, "passphrase", "", "Passphrase of private key")
+	projectKeyAddCmd.PersistentFlags().StringVar(&projectKeyAddArgs.String, "string", "", "Value of string key")
+	projectCmd.AddCommand(projectKeyAddCmd)
+}
+
+var projectKeyAddCmd = &cobra.Command{
+	Use:   "key-add",
+	Short: "Add access key to project or update secret of the existing key",
+	Run: func(cmd *cobra.Command, args []string) {
+		readStdinJSON(&projectKeyAddArgs)
+
+		ok := true
+		if targetProjectArgs.name == "" {
+			fmt.Println("Argument --project required")
+			ok = false
+		}
+		if projectKeyAddArgs.Name == "" {
+			fmt.Println("Argument --name required")
+			ok = false
+		}
+
+		if !ok {
+			fmt.Println("Use command `semaphore project key-add --help` for details.")
+			os.Exit(1)
+		}
+
+		if projectKeyAddArgs.PrivateKeyFile != "" {
+			content, err := os.ReadFile(projectKeyAddArgs.PrivateKeyFile)
+			if err != nil {
+				panic(err)
+			}
+			projectKeyAddArgs.PrivateKey = string(content)
+		}
+
+		store := createStore("")
+		defer store.Close("")
+
+		project := mustFindProject(store, targetProjectArgs.name)
+
+		key := db.AccessKey{
+			Name:      projectKeyAddArgs.Name,
+			Type:      db.AccessKeyType(projectKeyAddArgs.Type),
+			ProjectID: &project.ID,
+			String:    projectKeyAddArgs.String,
+			LoginPassword: db.LoginPassword{
+				Login:    projectKeyAddArgs.Login,
+				Password: projectKeyAddArgs.Password,
+			},
+			SshKey: db.SshKey{
+				Login:      projectKeyAddArgs.Login,
+				PrivateKey: projectKeyAddArgs.PrivateKey,
+				Passphrase: projectKeyAddArgs.Passphrase,
+			},
+		}
+
+		if err := key.Validate(true); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		keys, err := store.GetAccessKeys(project.ID, db.RetrieveQueryParams{})
+		if err != nil {
+			panic(err)
+		}
+
+		for _, existing := range keys {
+			if existing.Name != key.Name {
+				continue
+			}
+
+			key.ID = existing.ID
+			key.OverrideSecret = true
+			if err = store.UpdateAccessKey(key); err != nil {
+				panic(err)
+			}
+
+			fmt.Printf("Key %s updated (ID %d)\n", key.Name, key.ID)
+			return
+		}
+
+		key, err = store.CreateAccessKey(key)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Key %s added (ID %d)\n", key.Name, key.ID)
+	},
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/spf13/cobra"
+)
+
+var projectRepoAddArgs struct {
+	Name      string `json:"name"`
+	GitURL    string `json:"git_url"`
+	GitBranch string `json:"git_branch"`
+	Key       string `json:"key"`
+}
+
+func init() {
+	projectRepoAddCmd.PersistentFlags().StringVar(&targetProjectArgs.name, "project", "", "Project name")
+	projectRepoAddCmd.PersistentFlags().BoolVar(&targetProjectArgs.stdin, "stdin", false, "Read repository as JSON object from stdin")
+	projectRepoAddCmd.PersistentFlags().StringVar(&projectRepoAddArgs.Name, "name", "", "Repository name")
+	projectRepoAddCmd.PersistentFlags().StringVar(&projectRepoAddArgs.GitURL, "git-url", "", "Repository URL")
+	projectRepoAddCmd.PersistentFlags().StringVar(&projectRepoAddArgs.GitBranch, "git-branch", "", "Repository branch")
+	projectRepoAddCmd.PersistentFlags().StringVar(&projectRepoAddArgs.Key, "key", "None", "Name of access key")
+	projectCmd.AddCommand(projectRepoAddCmd)
+}
+
+var projectRepoAddCmd = &cobra.Command{
+	Use:   "repo-add",
+	Short: "Add repository to project or update the existing repository",
+	Run: func(cmd *cobra.Command, args []string) {
+		readStdinJSON(&projectRepoAddArgs)
+
+		ok := true
+		if targetProjectArgs.name == "" {
+			fmt.Println("Argument --project required")
+			ok = false
+		}
+		if projectRepoAddArgs.Name == "" {
+			fmt.Println("Argument --name required")
+			ok = false
+		}
+
+		if !ok {
+			fmt.Println("Use command `semaphore project repo-add --help` for details.")
+			os.Exit(1)
+		}
+
+		store := createStore("")
+		defer store.Close("")
+
+		project := mustFindProject(store, targetProjectArgs.name)
+
+		keys, err := store.GetAccessKeys(project.ID, db.RetrieveQueryParams{})
+		if err != nil {
+			panic(err)
+		}
+
+		repo := db.Repository{
+			Name:      projectRepoAddArgs.Name,
+			ProjectID: project.ID,
+			GitURL:    projectRepoAddArgs.GitURL,
+			GitBranch: projectRepoAddArgs.GitBranch,
+		}
+
+		for _, key := range keys {
+			if key.Name == projectRepoAddArgs.Key {
+				repo.SSHKeyID = key.ID
+			}
+		}
+
+		if repo.SSHKeyID == 0 {
+			fmt.Printf("Key %s not found in project %s\n", projectRepoAddArgs.Key, project.Name)
+			os.Exit(1)
+		}
+
+		if err = repo.Validate(); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		repos, err := store.GetRepositories(project.ID, db.RetrieveQueryParams{})
+		if err != nil {
+			panic(err)
+		}
+
+		for _, existing := range repos {
+			if existing.Name != repo.Name {
+				continue
+			}
+
+			repo.ID = existing.ID
+			if err = store.UpdateRepository(repo); err != nil {
+				panic(err)
+			}
+
+			fmt.Printf("Repository %s updated (ID %d)\n", repo.Name, repo.ID)
+			return
+		}
+
+		repo, err = store.CreateRepository(repo)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Repository %s added (ID %d)\n", repo.Name, repo.ID)
+	},
+}
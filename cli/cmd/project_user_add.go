@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/spf13/cobra"
+)
+
+var projectUserAddArgs struct {
+	login   string
+	role    string
+	expires string
+}
+
+func init() {
+	projectUserAddCmd.PersistentFlags().StringVar(&targetProjectArgs.name, "project", "", "Project name")
+	projectUserAddCmd.PersistentFlags().StringVar(&projectUserAddArgs.login, "login", "", "User login or email")
+	projectUserAddCmd.PersistentFlags().StringVar(&projectUserAddArgs.role, "role", string(db.ProjectTaskRunner), "Role: owner, manager, task_runner or guest")
+	projectUserAddCmd.PersistentFlags().StringVar(&projectUserAddArgs.expires, "expires", "", "Date when the user loses access to the project, e.g. 2025-12-31")
+	projectCmd.AddCommand(projectUserAddCmd)
+}
+
+var projectUserAddCmd = &cobra.Command{
+	Use:   "user-add",
+	Short: "Add user to project or update the user's role and expiry date",
+	Run: func(cmd *cobra.Command, args []string) {
+		ok := true
+		if targetProjectArgs.name == "" {
+			fmt.Println("Argument --project required")
+			ok = false
+		}
+		if projectUserAddArgs.login == "" {
+			fmt.Println("Argument --login required")
+			ok = false
+		}
+
+		role := db.ProjectUserRole(projectUserAddArgs.role)
+		if !role.IsValid() {
+			fmt.Printf("Invalid role %s\n", role)
+			ok = false
+		}
+
+		var expiresAt *time.Time
+		if projectUserAddArgs.expires != "" {
+			t, err := time.Parse(time.DateOnly, projectUserAddArgs.expires)
+			if err != nil {
+				fmt.Printf("Invalid expiry date %s\n", projectUserAddArgs.expires)
+				ok = false
+			}
+			expiresAt = &t
+		}
+
+		if !ok {
+			fmt.Println("Use command `semaphore project user-add --help` for details.")
+			os.Exit(1)
+		}
+
+		store := createStore("")
+		defer store.Close("")
+
+		project := mustFindProject(store, targetProjectArgs.name)
+
+		user, err := store.GetUserByLoginOrEmail(projectUserAddArgs.login, projectUserAddArgs.login)
+		if err != nil {
+			panic(err)
+		}
+
+		projectUser, err := store.GetProjectUser(project.ID, user.ID)
+
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			_, err = store.CreateProjectUser(db.ProjectUser{ProjectID: project.ID, UserID: user.ID, Role: role, ExpiresAt: expiresAt})
+		case err != nil:
+		default:
+			projectUser.Role = role
+			projectUser.ExpiresAt = expiresAt
+			err = store.UpdateProjectUser(projectUser)
+		}
+
+		if err != nil {
+			panic(err)
+		}
+
+		if expiresAt != nil {
+			fmt.Printf("User %s is %s of project %s until %s\n", user.Username, role, project.Name, projectUserAddArgs.expires)
+		} else {
+			fmt.Printf("User %s is %s of project %s\n", user.Username, role, project.Name)
+		}
+	},
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/semaphoreui/semaphore/api/sockets"
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/db/factory"
+	"github.com/semaphoreui/semaphore/services/ha"
+	"github.com/semaphoreui/semaphore/services/housekeeping"
 	"github.com/semaphoreui/semaphore/services/schedules"
 	"github.com/semaphoreui/semaphore/services/tasks"
 	"github.com/semaphoreui/semaphore/util"
@@ -17,6 +19,8 @@ import (
 	"github.com/gorilla/handlers"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 var persistentFlags struct {
@@ -60,11 +64,18 @@ func Execute() {
 }
 
 func runService() {
+	// recent logs are included into the diagnostics bundle
+	log.AddHook(util.RecentLogs)
+
 	store := createStore("root")
+	elector := ha.CreateElector(store)
 	taskPool := tasks.CreateTaskPool(store)
-	schedulePool := schedules.CreateSchedulePool(store, &taskPool)
+	schedulePool := schedules.CreateSchedulePool(store, &taskPool, elector)
+	housekeeper := housekeeping.CreateHousekeeper(store, housekeeping.DefaultJobs(&taskPool))
 
+	defer elector.Destroy()
 	defer schedulePool.Destroy()
+	defer housekeeper.Destroy()
 
 	util.Config.PrintDbInfo()
 
@@ -79,9 +90,18 @@ func runService() {
 	fmt.Printf("Interface %v\n", util.Config.Interface)
 	fmt.Printf("Port %v\n", util.Config.Port)
 
+	elector.Run()
+	elector.AddListener(func(leader bool) {
+		if leader {
+			// schedules could be changed while the node was not the leader
+			schedulePool.Refresh()
+		}
+	})
+
 	go sockets.StartWS()
 	go schedulePool.Run()
 	go taskPool.Run()
+	housekeeper.Run()
 
 	route := api.Route()
 
@@ -90,6 +110,7 @@ func runService() {
 			context.Set(r, "store", store)
 			context.Set(r, "schedule_pool", schedulePool)
 			context.Set(r, "task_pool", &taskPool)
+			context.Set(r, "housekeeper", housekeeper)
 			next.ServeHTTP(w, r)
 		})
 	})
@@ -107,20 +128,67 @@ func runService() {
 		store.Close("root")
 	}
 
-	err := http.ListenAndServe(util.Config.Interface+port, cropTrailingSlashMiddleware(router))
+	var err error
+
+	if util.Config.TLS != nil && util.Config.TLS.Enabled {
+		server := &http.Server{
+			Addr:    util.Config.Interface + port,
+			Handler: cropTrailingSlashMiddleware(router),
+		}
+
+		var challengeHandler http.Handler
+		server.TLSConfig, challengeHandler, err = util.Config.TLS.ServerConfig()
+		if err != nil {
+			log.Panic(err)
+		}
+
+		if challengeHandler != nil && util.Config.TLS.ACME.HTTPAddr != "" {
+			go func() {
+				if err := http.ListenAndServe(util.Config.TLS.ACME.HTTPAddr, challengeHandler); err != nil {
+					log.WithError(err).Error("ACME HTTP challenge server stopped")
+				}
+			}()
+		}
+
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		// streams of runners require HTTP/2, it is served without TLS as h2c
+		err = http.ListenAndServe(util.Config.Interface+port, h2c.NewHandler(cropTrailingSlashMiddleware(router), &http2.Server{}))
+	}
 
 	if err != nil {
 		log.Panic(err)
 	}
 }
 
-func createStore(token string) db.Store {
+// connectStore connects to the database without applying migrations.
+func connectStore(token string) db.Store {
 	util.ConfigInit(persistentFlags.configPath, persistentFlags.noConfig)
 
 	store := factory.CreateStore()
 
 	store.Connect(token)
 
+	if db.GetSecretStorage() == nil {
+		secretStorage, err := factory.CreateSecretStorage()
+		if err != nil {
+			panic(err)
+		}
+		if secretStorage != nil {
+			db.SetSecretStorage(secretStorage)
+		}
+	}
+
+	if uploadStorage := factory.CreateUploadStorage(); uploadStorage != nil {
+		db.SetUploadStorage(uploadStorage)
+	}
+
+	return store
+}
+
+func createStore(token string) db.Store {
+	store := connectStore(token)
+
 	err := db.Migrate(store)
 
 	if err != nil {
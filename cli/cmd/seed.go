@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	projectService "github.com/semaphoreui/semaphore/services/project"
+	"github.com/spf13/cobra"
+)
+
+type seedArgs struct {
+	demo  bool
+	name  string
+	owner string
+}
+
+var targetSeedArgs seedArgs
+
+func init() {
+	seedCmd.PersistentFlags().BoolVar(&targetSeedArgs.demo, "demo", false, "Create the demo project")
+	seedCmd.PersistentFlags().StringVar(&targetSeedArgs.name, "name", "Demo", "Name of the demo project")
+	seedCmd.PersistentFlags().StringVar(&targetSeedArgs.owner, "owner", "", "Login of the project owner")
+	rootCmd.AddCommand(seedCmd)
+}
+
+var seedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Fill the database with sample data, does nothing if the project with the same name exists",
+	Run: func(cmd *cobra.Command, args []string) {
+		if !targetSeedArgs.demo {
+			fmt.Println("Argument --demo required")
+			fmt.Println("Use command `semaphore seed --help` for details.")
+			os.Exit(1)
+		}
+
+		store := createStore("")
+		defer store.Close("")
+
+		existing, err := findProject(store, targetSeedArgs.name)
+		if err != nil {
+			panic(err)
+		}
+
+		if existing != nil {
+			fmt.Printf("Project %s already exists (ID %d)\n", existing.Name, existing.ID)
+			return
+		}
+
+		project, noneKey, emptyEnv := createProject(store, targetSeedArgs.name, targetSeedArgs.owner)
+
+		err = projectService.CreateDemoProject(project.ID, noneKey.ID, emptyEnv.ID, store)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Demo project %s added (ID %d)\n", project.Name, project.ID)
+	},
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+type remoteArgs struct {
+	server string
+	token  string
+}
+
+var targetRemoteArgs remoteArgs
+
+func init() {
+	taskCmd.PersistentFlags().StringVar(&targetRemoteArgs.server, "server", "", "Semaphore server URL, default is $SEMAPHORE_URL or http://localhost:3000")
+	taskCmd.PersistentFlags().StringVar(&targetRemoteArgs.token, "token", "", "API token, default is $SEMAPHORE_TOKEN")
+	rootCmd.AddCommand(taskCmd)
+}
+
+var taskCmd = &cobra.Command{
+	Use:     "tasks",
+	Aliases: []string{"task"},
+	Short:   "Run tasks on Semaphore server",
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+		os.Exit(0)
+	},
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+var taskRunArgs struct {
+	project   string
+	template  string
+	extraVars []string
+	limit     string
+	branch    string
+	message   string
+	follow    bool
+}
+
+func init() {
+	taskRunCmd.PersistentFlags().StringVar(&taskRunArgs.project, "project", "", "Project name or ID")
+	taskRunCmd.PersistentFlags().StringVar(&taskRunArgs.template, "template", "", "Template name or ID")
+	taskRunCmd.PersistentFlags().StringArrayVar(&taskRunArgs.extraVars, "extra-vars", nil, "Extra variable as key=value or JSON object, can be repeated")
+	taskRunCmd.PersistentFlags().StringVar(&taskRunArgs.limit, "limit", "", "Limit hosts")
+	taskRunCmd.PersistentFlags().StringVar(&taskRunArgs.branch, "branch", "", "Override git branch")
+	taskRunCmd.PersistentFlags().StringVar(&taskRunArgs.message, "message", "", "Task message")
+	taskRunCmd.PersistentFlags().BoolVar(&taskRunArgs.follow, "follow", false, "Stream task output and exit with the task status")
+	taskCmd.AddCommand(taskRunCmd)
+}
+
+func newRemoteClient() *client.Client {
+	server := targetRemoteArgs.server
+	if server == "" {
+		server = os.Getenv("SEMAPHORE_URL")
+	}
+	if server == "" {
+		server = "http://localhost:3000"
+	}
+
+	token := targetRemoteArgs.token
+	if token == "" {
+		token = os.Getenv("SEMAPHORE_TOKEN")
+	}
+
+	return client.New(server, token)
+}
+
+func parseExtraVars(values []string) (string, error) {
+	vars := make(map[string]any)
+
+	for _, v := range values {
+		if strings.HasPrefix(strings.TrimSpace(v), "{") {
+			if err := json.Unmarshal([]byte(v), &vars); err != nil {
+				return "", fmt.Errorf("invalid extra vars %s: %s", v, err.Error())
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" {
+			return "", fmt.Errorf("invalid extra var %s, expected key=value", v)
+		}
+		vars[key] = value
+	}
+
+	if len(vars) == 0 {
+		return "", nil
+	}
+
+	res, err := json.Marshal(vars)
+	return string(res), err
+}
+
+func resolveProjectID(ctx context.Context, c *client.Client, project string) (int, error) {
+	if id, err := strconv.Atoi(project); err == nil {
+		return id, nil
+	}
+
+	p, err := c.LookupProject(ctx, project)
+	if err != nil {
+		return 0, fmt.Errorf("project %s: %s", project, err.Error())
+	}
+
+	return p.ID, nil
+}
+
+func resolveTemplateID(ctx context.Context, p *client.ProjectClient, template string) (int, error) {
+	if id, err := strconv.Atoi(template); err == nil {
+		return id, nil
+	}
+
+	tpl, err := p.Templates().Lookup(ctx, template)
+	if err != nil {
+		return 0, fmt.Errorf("template %s: %s", template, err.Error())
+	}
+
+	return tpl.ID, nil
+}
+
+// followTask prints task output until the task is finished.
+func followTask(ctx context.Context, tasks *client.TaskClient, taskID int) (db.Task, error) {
+	printed := 0
+
+	for {
+		task, err := tasks.Get(ctx, taskID)
+		if err != nil {
+			return task, err
+		}
+
+		output, err := tasks.Output(ctx, taskID)
+		if err != nil {
+			return task, err
+		}
+
+		for ; printed < len(output); printed++ {
+			fmt.Println(output[printed].Output)
+		}
+
+		if task.Status.IsFinished() {
+			return task, nil
+		}
+
+		time.Sleep(time.Second)
+	}
+}
+
+var taskRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run task from template",
+	Long: "Starts a new task on local or remote Semaphore server. With --follow streams " +
+		"the task output and exits with non-zero code if the task was not successful.",
+	Run: func(cmd *cobra.Command, args []string) {
+		ok := true
+		if taskRunArgs.project == "" {
+			fmt.Println("Argument --project required")
+			ok = false
+		}
+		if taskRunArgs.template == "" {
+			fmt.Println("Argument --template required")
+			ok = false
+		}
+
+		if !ok {
+			fmt.Println("Use command `semaphore task run --help` for details.")
+			os.Exit(1)
+		}
+
+		environment, err := parseExtraVars(taskRunArgs.extraVars)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		ctx := context.Background()
+		c := newRemoteClient()
+
+		projectID, err := resolveProjectID(ctx, c, taskRunArgs.project)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		project := c.Project(projectID)
+
+		templateID, err := resolveTemplateID(ctx, project, taskRunArgs.template)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		task := db.Task{
+			TemplateID:  templateID,
+			Environment: environment,
+			Limit:       taskRunArgs.limit,
+			Message:     taskRunArgs.message,
+		}
+
+		if taskRunArgs.branch != "" {
+			task.GitBranch = &taskRunArgs.branch
+		}
+
+		task, err = project.Tasks().Run(ctx, task)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Printf("Task %d started\n", task.ID)
+
+		if !taskRunArgs.follow {
+			return
+		}
+
+		task, err = followTask(ctx, project.Tasks(), task.ID)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Printf("Task %d finished with status %s\n", task.ID, task.Status)
+
+		if !client.IsSuccess(task) {
+			os.Exit(2)
+		}
+	},
+}
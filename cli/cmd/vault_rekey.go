@@ -15,7 +15,8 @@ var vaultRekeyCmd = &cobra.Command{
 	Short: "Re-encrypt Key Store in database with using current encryption key",
 	Long: "To update the encryption key, modify it within the configuration file and " +
 		"then employ the 'vault rekey --old-key <old-key>' command to ensure the re-encryption of the " +
-		"pre-existing keys stored in the database.",
+		"pre-existing keys stored in the database. If the secrets database is configured, " +
+		"the re-encrypted keys are moved to it.",
 	Run: func(cmd *cobra.Command, args []string) {
 		store := createStore("")
 		defer store.Close("")
@@ -2,10 +2,27 @@ package db
 
 import "time"
 
+// APITokenRole limits the access given by the API token.
+type APITokenRole string
+
+const (
+	// APITokenRoleUser gives the token all permissions of the user.
+	APITokenRoleUser APITokenRole = ""
+	// APITokenRoleDashboard allows to read only metadata of templates and statuses
+	// of their last tasks, e.g. for wallboards in shared spaces. Task output, inventories
+	// and secrets are not available for such tokens.
+	APITokenRoleDashboard APITokenRole = "dashboard"
+)
+
+func (r APITokenRole) IsValid() bool {
+	return r == APITokenRoleUser || r == APITokenRoleDashboard
+}
+
 // APIToken is given to a user to allow API access
 type APIToken struct {
-	ID      string    `db:"id" json:"id"`
-	Created time.Time `db:"created" json:"created"`
-	Expired bool      `db:"expired" json:"expired"`
-	UserID  int       `db:"user_id" json:"user_id"`
+	ID      string       `db:"id" json:"id"`
+	Created time.Time    `db:"created" json:"created"`
+	Expired bool         `db:"expired" json:"expired"`
+	UserID  int          `db:"user_id" json:"user_id"`
+	Role    APITokenRole `db:"role" json:"role"`
 }
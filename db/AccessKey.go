@@ -6,13 +6,16 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/semaphoreui/semaphore/pkg/random"
 	"github.com/semaphoreui/semaphore/pkg/ssh"
 	"github.com/semaphoreui/semaphore/pkg/task_logger"
 	"github.com/semaphoreui/semaphore/util"
 	"io"
+	"os"
 	"path"
+	"time"
 )
 
 type AccessKeyType string
@@ -22,6 +25,8 @@ const (
 	AccessKeyNone          AccessKeyType = "none"
 	AccessKeyLoginPassword AccessKeyType = "login_password"
 	AccessKeyString        AccessKeyType = "string"
+	// AccessKeyVault is the key which requests dynamic credentials from HashiCorp Vault.
+	AccessKeyVault AccessKeyType = "vault"
 )
 
 // AccessKey represents a key used to access a machine with ansible from semaphore
@@ -37,20 +42,39 @@ type AccessKey struct {
 	// You should use methods SerializeSecret to fill this field.
 	Secret *string `db:"secret" json:"-" backup:"-"`
 
-	String         string        `db:"-" json:"string"`
-	LoginPassword  LoginPassword `db:"-" json:"login_password"`
-	SshKey         SshKey        `db:"-" json:"ssh"`
-	OverrideSecret bool          `db:"-" json:"override_secret"`
+	String         string           `db:"-" json:"string"`
+	LoginPassword  LoginPassword    `db:"-" json:"login_password"`
+	SshKey         SshKey           `db:"-" json:"ssh"`
+	Vault          VaultCredentials `db:"-" json:"vault"`
+	OverrideSecret bool             `db:"-" json:"override_secret"`
 
 	// EnvironmentID is an ID of environment which owns the access key.
 	EnvironmentID *int `db:"environment_id" json:"-" backup:"-"`
 
+	// InventoryID is an ID of inventory which owns the access key.
+	InventoryID *int `db:"inventory_id" json:"-" backup:"-"`
+
 	// UserID is an ID of user which owns the access key.
 	UserID *int `db:"user_id" json:"-" backup:"-"`
 
+	// SecretChanged is the time when the secret of the key was set last time.
+	// It is nil for keys created before the time was tracked.
+	SecretChanged *time.Time `db:"secret_changed" json:"secret_changed" backup:"-"`
+	// RotationOverdue is true if the secret is older than the rotation policy of the project.
+	RotationOverdue bool `db:"-" json:"rotation_overdue"`
+
 	Empty bool `db:"-" json:"empty,omitempty"`
 }
 
+// IsRotationOverdue returns true if the secret of the key was not changed for more than the number of days.
+// Keys without a secret are never overdue, keys with unknown time of the last change are always overdue.
+func (key *AccessKey) IsRotationOverdue(days int, now time.Time) bool {
+	if days <= 0 || key.Type == AccessKeyNone {
+		return false
+	}
+	return key.SecretChanged == nil || now.Sub(*key.SecretChanged) > time.Duration(days)*24*time.Hour
+}
+
 type LoginPassword struct {
 	Login    string `json:"login"`
 	Password string `json:"password"`
@@ -60,6 +84,8 @@ type SshKey struct {
 	Login      string `json:"login"`
 	Passphrase string `json:"passphrase"`
 	PrivateKey string `json:"private_key"`
+	// Certificate is an optional OpenSSH certificate of the key signed by CA.
+	Certificate string `json:"certificate,omitempty"`
 }
 
 type AccessKeyRole int
@@ -71,18 +97,42 @@ const (
 	AccessKeyRoleGit
 )
 
+// IsUsableAs returns true if the key of its type can be installed in the role.
+// Credentials of vault keys are issued for the role when the key is installed.
+func (key *AccessKey) IsUsableAs(role AccessKeyRole) bool {
+	switch key.Type {
+	case AccessKeyVault:
+		return true
+	case AccessKeyNone:
+		return role != AccessKeyRoleAnsiblePasswordVault
+	}
+
+	switch role {
+	case AccessKeyRoleAnsibleUser, AccessKeyRoleGit:
+		return key.Type == AccessKeySSH || key.Type == AccessKeyLoginPassword
+	case AccessKeyRoleAnsibleBecomeUser, AccessKeyRoleAnsiblePasswordVault:
+		return key.Type == AccessKeyLoginPassword
+	default:
+		return false
+	}
+}
+
 type AccessKeyInstallation struct {
 	SSHAgent *ssh.Agent
 	Login    string
 	Password string
 	Script   string
+
+	// vaultLease is the lease of the dynamic credentials, it is revoked by Destroy.
+	vaultLease *vaultLease
 }
 
 func (key AccessKeyInstallation) Destroy() error {
+	var err error
 	if key.SSHAgent != nil {
-		return key.SSHAgent.Close()
+		err = key.SSHAgent.Close()
 	}
-	return nil
+	return errors.Join(err, key.vaultLease.revoke())
 }
 
 func (key *AccessKey) startSSHAgent(logger task_logger.Logger) (ssh.Agent, error) {
@@ -90,13 +140,18 @@ func (key *AccessKey) startSSHAgent(logger task_logger.Logger) (ssh.Agent, error
 		Logger: logger,
 		Keys: []ssh.AgentKey{
 			{
-				Key:        []byte(key.SshKey.PrivateKey),
-				Passphrase: []byte(key.SshKey.Passphrase),
+				Key:         []byte(key.SshKey.PrivateKey),
+				Passphrase:  []byte(key.SshKey.Passphrase),
+				Certificate: []byte(key.SshKey.Certificate),
 			},
 		},
 		SocketFile: path.Join(util.Config.TmpPath, fmt.Sprintf("ssh-agent-%d-%s.sock", key.ID, random.String(10))),
 	}
 
+	if util.Config.SSHAgentForwarding {
+		sshAgent.ForwardSocket = os.Getenv("SSH_AUTH_SOCK")
+	}
+
 	return sshAgent, sshAgent.Listen()
 }
 
@@ -112,6 +167,10 @@ func (key *AccessKey) Install(usage AccessKeyRole, logger task_logger.Logger) (i
 		return
 	}
 
+	if key.Type == AccessKeyVault {
+		return key.installVaultCredentials(usage, logger)
+	}
+
 	switch usage {
 	case AccessKeyRoleGit:
 		switch key.Type {
@@ -166,10 +225,17 @@ func (key *AccessKey) Validate(validateSecretFields bool) error {
 		if key.SshKey.PrivateKey == "" {
 			return fmt.Errorf("private key can not be empty")
 		}
+		if key.SshKey.Certificate != "" {
+			if _, err := ssh.ParseCertificate([]byte(key.SshKey.Certificate)); err != nil {
+				return err
+			}
+		}
 	case AccessKeyLoginPassword:
 		if key.LoginPassword.Password == "" {
 			return fmt.Errorf("password can not be empty")
 		}
+	case AccessKeyVault:
+		return key.Vault.Validate()
 	}
 
 	return nil
@@ -212,6 +278,11 @@ func (key *AccessKey) SerializeSecret() error {
 		if err != nil {
 			return err
 		}
+	case AccessKeyVault:
+		plaintext, err = json.Marshal(key.Vault)
+		if err != nil {
+			return err
+		}
 	case AccessKeyNone:
 		key.Secret = nil
 		return nil
@@ -222,9 +293,7 @@ func (key *AccessKey) SerializeSecret() error {
 	encryptionString := util.Config.AccessKeyEncryption
 
 	if encryptionString == "" {
-		secret := base64.StdEncoding.EncodeToString(plaintext)
-		key.Secret = &secret
-		return nil
+		return key.setSecret(base64.StdEncoding.EncodeToString(plaintext))
 	}
 
 	encryption, err := base64.StdEncoding.DecodeString(encryptionString)
@@ -248,9 +317,16 @@ func (key *AccessKey) SerializeSecret() error {
 		return err
 	}
 
-	secret := base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plaintext, nil))
-	key.Secret = &secret
+	return key.setSecret(base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plaintext, nil)))
+}
 
+// setSecret sets the serialized secret, or the reference to it if the secret storage is used.
+func (key *AccessKey) setSecret(secret string) error {
+	secret, err := storeSecret(secret)
+	if err != nil {
+		return err
+	}
+	key.Secret = &secret
 	return nil
 }
 
@@ -270,6 +346,12 @@ func (key *AccessKey) unmarshalAppropriateField(secret []byte) (err error) {
 		if err == nil {
 			key.LoginPassword = loginPass
 		}
+	case AccessKeyVault:
+		vault := VaultCredentials{}
+		err = json.Unmarshal(secret, &vault)
+		if err == nil {
+			key.Vault = vault
+		}
 	}
 	return
 }
@@ -283,19 +365,26 @@ func (key *AccessKey) DeserializeSecret2(encryptionString string) error {
 		return nil
 	}
 
-	ciphertext := []byte(*key.Secret)
+	secret, err := loadSecret(*key.Secret)
+	if err != nil {
+		return err
+	}
 
-	if ciphertext[len(*key.Secret)-1] == '\n' { // not encrypted private key, used for back compatibility
+	if secret == "" {
+		return nil
+	}
+
+	if secret[len(secret)-1] == '\n' { // not encrypted private key, used for back compatibility
 		if key.Type != AccessKeySSH {
 			return fmt.Errorf("invalid access key type")
 		}
 		key.SshKey = SshKey{
-			PrivateKey: *key.Secret,
+			PrivateKey: secret,
 		}
 		return nil
 	}
 
-	ciphertext, err := base64.StdEncoding.DecodeString(*key.Secret)
+	ciphertext, err := base64.StdEncoding.DecodeString(secret)
 	if err != nil {
 		return err
 	}
@@ -334,10 +423,66 @@ func (key *AccessKey) DeserializeSecret2(encryptionString string) error {
 
 	if err != nil {
 		if err.Error() == "cipher: message authentication failed" {
-			err = fmt.Errorf("cannot decrypt access key, perhaps encryption key was changed")
+			err = ErrKeyDecryptFailed
 		}
 		return err
 	}
 
 	return key.unmarshalAppropriateField(ciphertext)
 }
+
+// FillAccessKeyTemplateRefs adds the templates which use the key as vault password,
+// secret file or artifact registry key to the referrers of the key. These keys are not columns of the template,
+// so they are not found by the referring fields.
+func FillAccessKeyTemplateRefs(store Store, projectID int, keyID int, refs *ObjectReferrers) error {
+	templates, err := store.GetTemplates(projectID, TemplateFilter{}, RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[int]bool)
+	for _, ref := range refs.Templates {
+		seen[ref.ID] = true
+	}
+
+	for _, tpl := range templates {
+		if seen[tpl.ID] {
+			continue
+		}
+
+		used := false
+
+		for _, file := range tpl.SecretFiles {
+			if file.KeyID == keyID {
+				used = true
+			}
+		}
+
+		for _, artifact := range tpl.Artifacts {
+			if artifact.KeyID != nil && *artifact.KeyID == keyID {
+				used = true
+			}
+		}
+
+		if !used {
+			var vaults []TemplateVault
+			vaults, err = store.GetTemplateVaults(projectID, tpl.ID)
+			if err != nil {
+				return err
+			}
+
+			for _, vault := range vaults {
+				if vault.VaultKeyID != nil && *vault.VaultKeyID == keyID {
+					used = true
+				}
+			}
+		}
+
+		if used {
+			seen[tpl.ID] = true
+			refs.Templates = append(refs.Templates, ObjectReferrer{ID: tpl.ID, Name: tpl.Name})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,291 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/semaphoreui/semaphore/pkg/ssh"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+type VaultEngine string
+
+const (
+	// VaultEngineSSHSign signs the ephemeral SSH key by the SSH secrets engine.
+	VaultEngineSSHSign VaultEngine = "ssh_sign"
+	// VaultEngineSSHOTP requests the one-time SSH password by the SSH secrets engine.
+	VaultEngineSSHOTP VaultEngine = "ssh_otp"
+	// VaultEngineDatabase requests the login and password by the database secrets engine.
+	VaultEngineDatabase VaultEngine = "database"
+)
+
+const vaultRequestTimeout = 30 * time.Second
+
+// VaultCredentials describes dynamic credentials issued by HashiCorp Vault.
+// The credentials are requested every time the key is installed for a task
+// and their lease is revoked when the installation is destroyed.
+type VaultCredentials struct {
+	// Address of the Vault server. VAULT_ADDR of the server environment is used if it is empty.
+	Address string `json:"address"`
+	// Token to authenticate in Vault. VAULT_TOKEN of the server environment is used if it is empty.
+	Token     string `json:"token"`
+	Namespace string `json:"namespace"`
+
+	Engine VaultEngine `json:"engine"`
+	// Mount is the path the secrets engine is mounted at, e.g. ssh or database.
+	Mount string `json:"mount"`
+	Role  string `json:"role"`
+
+	// Login is the SSH user. It is the principal of the signed certificate
+	// and the user of the one-time password.
+	Login string `json:"login"`
+	// IP is the address of the host the one-time password is requested for.
+	IP string `json:"ip"`
+}
+
+func (v *VaultCredentials) Validate() error {
+	switch v.Engine {
+	case VaultEngineSSHSign, VaultEngineDatabase:
+	case VaultEngineSSHOTP:
+		if net.ParseIP(v.IP) == nil {
+			return fmt.Errorf("host IP is required for the one-time SSH password")
+		}
+	default:
+		return fmt.Errorf("unknown vault secrets engine")
+	}
+
+	if v.Mount == "" || v.Role == "" {
+		return fmt.Errorf("vault mount and role can not be empty")
+	}
+
+	if strings.Contains(v.Mount, "..") || strings.Contains(v.Role, "/") {
+		return fmt.Errorf("invalid vault mount or role")
+	}
+
+	return nil
+}
+
+// supports returns true if the credentials of the engine can be used for the usage.
+func (v *VaultCredentials) supports(usage AccessKeyRole) bool {
+	switch v.Engine {
+	case VaultEngineSSHSign:
+		return usage == AccessKeyRoleAnsibleUser || usage == AccessKeyRoleGit
+	case VaultEngineSSHOTP:
+		return usage == AccessKeyRoleAnsibleUser
+	case VaultEngineDatabase:
+		return usage == AccessKeyRoleAnsibleUser || usage == AccessKeyRoleAnsibleBecomeUser
+	}
+	return false
+}
+
+type vaultClient struct {
+	address   string
+	token     string
+	namespace string
+}
+
+type vaultResponse struct {
+	LeaseID string         `json:"lease_id"`
+	Data    map[string]any `json:"data"`
+	Errors  []string       `json:"errors"`
+}
+
+func (v *VaultCredentials) client() (vaultClient, error) {
+	c := vaultClient{
+		address:   v.Address,
+		token:     v.Token,
+		namespace: v.Namespace,
+	}
+
+	if c.address == "" {
+		c.address = os.Getenv("VAULT_ADDR")
+	}
+
+	if c.token == "" {
+		c.token = os.Getenv("VAULT_TOKEN")
+	}
+
+	if c.address == "" {
+		return c, fmt.Errorf("vault address is not set")
+	}
+
+	return c, nil
+}
+
+func (c vaultClient) request(method string, apiPath string, body any) (res vaultResponse, err error) {
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err = json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return
+		}
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.address, "/")+"/v1/"+apiPath, &reqBody)
+	if err != nil {
+		return
+	}
+
+	req.Header.Set("X-Vault-Token", c.token)
+	req.Header.Set("Content-Type", "application/json")
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+
+	client := &http.Client{Timeout: vaultRequestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close() //nolint: errcheck
+
+	if resp.StatusCode == http.StatusNoContent {
+		return
+	}
+
+	// errors are returned in the body, so it is decoded before the status is checked
+	decodeErr := json.NewDecoder(resp.Body).Decode(&res)
+
+	if resp.StatusCode >= 300 {
+		if len(res.Errors) > 0 {
+			err = fmt.Errorf("vault returned status %d: %s", resp.StatusCode, strings.Join(res.Errors, "; "))
+		} else {
+			err = fmt.Errorf("vault returned status %d", resp.StatusCode)
+		}
+		return
+	}
+
+	err = decodeErr
+	return
+}
+
+// revokeLease revokes the lease of the dynamic credentials, so they can not be used anymore.
+func (c vaultClient) revokeLease(leaseID string) error {
+	_, err := c.request("PUT", "sys/leases/revoke", map[string]string{"lease_id": leaseID})
+	return err
+}
+
+// vaultLease is the lease of the credentials issued for the task.
+type vaultLease struct {
+	client vaultClient
+	id     string
+}
+
+func (l *vaultLease) revoke() error {
+	if l == nil || l.id == "" {
+		return nil
+	}
+	return l.client.revokeLease(l.id)
+}
+
+func getVaultString(data map[string]any, key string) (string, error) {
+	value, ok := data[key].(string)
+	if !ok || value == "" {
+		return "", fmt.Errorf("vault response does not contain %s", key)
+	}
+	return value, nil
+}
+
+// installVaultCredentials requests the credentials of the key from Vault.
+func (key *AccessKey) installVaultCredentials(usage AccessKeyRole, logger task_logger.Logger) (installation AccessKeyInstallation, err error) {
+	creds := key.Vault
+
+	if !creds.supports(usage) {
+		err = fmt.Errorf("vault %s credentials can not be used for this purpose", creds.Engine)
+		return
+	}
+
+	client, err := creds.client()
+	if err != nil {
+		return
+	}
+
+	mount := strings.Trim(creds.Mount, "/")
+
+	switch creds.Engine {
+	case VaultEngineSSHSign:
+		var privateKey []byte
+		var publicKey string
+		privateKey, publicKey, err = ssh.GenerateKey()
+		if err != nil {
+			return
+		}
+
+		var res vaultResponse
+		res, err = client.request("POST", mount+"/sign/"+creds.Role, map[string]string{
+			"public_key":       publicKey,
+			"valid_principals": creds.Login,
+			"cert_type":        "user",
+		})
+		if err != nil {
+			return
+		}
+
+		var certificate string
+		certificate, err = getVaultString(res.Data, "signed_key")
+		if err != nil {
+			return
+		}
+
+		// the key is never stored, it lives in the agent of the task only
+		key.SshKey = SshKey{
+			Login:       creds.Login,
+			PrivateKey:  string(privateKey),
+			Certificate: certificate,
+		}
+
+		var agent ssh.Agent
+		agent, err = key.startSSHAgent(logger)
+		installation.SSHAgent = &agent
+		installation.Login = creds.Login
+
+		logger.Log("SSH certificate signed by Vault role " + creds.Role)
+
+	case VaultEngineSSHOTP:
+		var res vaultResponse
+		res, err = client.request("POST", mount+"/creds/"+creds.Role, map[string]string{
+			"ip":       creds.IP,
+			"username": creds.Login,
+		})
+		if err != nil {
+			return
+		}
+
+		installation.vaultLease = &vaultLease{client: client, id: res.LeaseID}
+		installation.Login = creds.Login
+		installation.Password, err = getVaultString(res.Data, "key")
+
+		logger.Log("One-time SSH password issued by Vault role " + creds.Role)
+
+	case VaultEngineDatabase:
+		var res vaultResponse
+		res, err = client.request("GET", mount+"/creds/"+creds.Role, nil)
+		if err != nil {
+			return
+		}
+
+		installation.vaultLease = &vaultLease{client: client, id: res.LeaseID}
+
+		installation.Login, err = getVaultString(res.Data, "username")
+		if err == nil {
+			installation.Password, err = getVaultString(res.Data, "password")
+		}
+
+		logger.Log("Database credentials issued by Vault role " + creds.Role)
+	}
+
+	if err != nil && installation.vaultLease != nil {
+		// do not leave the credentials valid if they can not be used
+		if revokeErr := installation.vaultLease.revoke(); revokeErr != nil {
+			logger.Log("Can't revoke Vault lease: " + revokeErr.Error())
+		}
+		installation.vaultLease = nil
+	}
+
+	return
+}
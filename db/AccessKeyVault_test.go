@@ -0,0 +1,115 @@
+package db
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+type vaultTestLogger struct {
+	task_logger.Logger
+}
+
+func (l vaultTestLogger) Log(msg string) {}
+
+func TestInstallVaultDatabaseCredentials(t *testing.T) {
+	var revoked string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors": ["permission denied"]}`))
+			return
+		}
+
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v1/database/creds/app":
+			_, _ = w.Write([]byte(`{"lease_id": "database/creds/app/l1", "data": {"username": "v-app-1", "password": "p1"}}`))
+		case r.Method == "PUT" && r.URL.Path == "/v1/sys/leases/revoke":
+			var body struct {
+				LeaseID string `json:"lease_id"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			revoked = body.LeaseID
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	util.Config = &util.ConfigType{}
+
+	key := AccessKey{
+		Name: "db",
+		Type: AccessKeyVault,
+		Vault: VaultCredentials{
+			Address: server.URL,
+			Token:   "test-token",
+			Engine:  VaultEngineDatabase,
+			Mount:   "database",
+			Role:    "app",
+		},
+	}
+
+	if err := key.Validate(true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := key.SerializeSecret(); err != nil {
+		t.Fatal(err)
+	}
+	key.Vault = VaultCredentials{}
+
+	if _, err := key.Install(AccessKeyRoleGit, vaultTestLogger{}); err == nil {
+		t.Fatal("database credentials must not be used for git")
+	}
+
+	installation, err := key.Install(AccessKeyRoleAnsibleBecomeUser, vaultTestLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if installation.Login != "v-app-1" || installation.Password != "p1" {
+		t.Fatalf("unexpected credentials %s:%s", installation.Login, installation.Password)
+	}
+
+	if revoked != "" {
+		t.Fatal("lease must not be revoked before the installation is destroyed")
+	}
+
+	if err = installation.Destroy(); err != nil {
+		t.Fatal(err)
+	}
+
+	if revoked != "database/creds/app/l1" {
+		t.Fatalf("lease is not revoked, got %q", revoked)
+	}
+
+	key.Vault.Token = "wrong"
+	if err = key.SerializeSecret(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = key.Install(AccessKeyRoleAnsibleUser, vaultTestLogger{})
+	if err == nil || err.Error() != "vault returned status 403: permission denied" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestValidateVaultCredentials(t *testing.T) {
+	for _, creds := range []VaultCredentials{
+		{Engine: "kv", Mount: "secret", Role: "app"},
+		{Engine: VaultEngineSSHSign, Mount: "ssh"},
+		{Engine: VaultEngineSSHOTP, Mount: "ssh", Role: "otp"},
+		{Engine: VaultEngineDatabase, Mount: "../sys", Role: "app"},
+	} {
+		if err := creds.Validate(); err == nil {
+			t.Errorf("credentials %v must be rejected", creds)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"github.com/semaphoreui/semaphore/util"
 	"testing"
+	"time"
 )
 
 func TestSetSecret(t *testing.T) {
@@ -89,3 +90,50 @@ func TestSetGetSecretWithEncryption(t *testing.T) {
 		t.Error("invalid secret")
 	}
 }
+
+func TestIsRotationOverdue(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	changed := now.AddDate(0, 0, -30)
+
+	cases := []struct {
+		key  AccessKey
+		days int
+		want bool
+	}{
+		{AccessKey{Type: AccessKeyString, SecretChanged: &changed}, 0, false},
+		{AccessKey{Type: AccessKeyString, SecretChanged: &changed}, 90, false},
+		{AccessKey{Type: AccessKeyString, SecretChanged: &changed}, 7, true},
+		{AccessKey{Type: AccessKeyString}, 90, true},
+		{AccessKey{Type: AccessKeyNone}, 7, false},
+	}
+
+	for i, c := range cases {
+		if got := c.key.IsRotationOverdue(c.days, now); got != c.want {
+			t.Errorf("case %d: expected %v, got %v", i, c.want, got)
+		}
+	}
+}
+
+func TestIsUsableAs(t *testing.T) {
+	cases := []struct {
+		keyType AccessKeyType
+		role    AccessKeyRole
+		want    bool
+	}{
+		{AccessKeySSH, AccessKeyRoleAnsibleUser, true},
+		{AccessKeySSH, AccessKeyRoleAnsibleBecomeUser, false},
+		{AccessKeySSH, AccessKeyRoleAnsiblePasswordVault, false},
+		{AccessKeyLoginPassword, AccessKeyRoleAnsiblePasswordVault, true},
+		{AccessKeyString, AccessKeyRoleAnsibleUser, false},
+		{AccessKeyNone, AccessKeyRoleAnsibleBecomeUser, true},
+		{AccessKeyNone, AccessKeyRoleAnsiblePasswordVault, false},
+		{AccessKeyVault, AccessKeyRoleAnsiblePasswordVault, true},
+	}
+
+	for _, c := range cases {
+		key := AccessKey{Type: c.keyType}
+		if got := key.IsUsableAs(c.role); got != c.want {
+			t.Errorf("key %s, role %d: expected %v, got %v", c.keyType, c.role, c.want, got)
+		}
+	}
+}
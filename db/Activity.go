@@ -0,0 +1,135 @@
+package db
+
+import (
+	"slices"
+	"time"
+)
+
+// ActivityCategory groups project events in the activity feed.
+type ActivityCategory string
+
+const (
+	ActivityConfig      ActivityCategory = "config"
+	ActivityRun         ActivityCategory = "run"
+	ActivityMembership  ActivityCategory = "membership"
+	ActivityIntegration ActivityCategory = "integration"
+)
+
+// ActivityQueryParams selects a page of the activity feed.
+type ActivityQueryParams struct {
+	// Before is the cursor, only events with smaller ID are returned.
+	Before      int
+	Count       int
+	ObjectTypes []EventObjectType
+	// ObjectID selects events of one object, it is used with ObjectTypes.
+	ObjectID *int
+	Actions  []string
+}
+
+// Match returns true if the event matches the object and action filters of the params.
+func (p ActivityQueryParams) Match(evt Event) bool {
+	if len(p.ObjectTypes) > 0 && (evt.ObjectType == nil || !slices.Contains(p.ObjectTypes, *evt.ObjectType)) {
+		return false
+	}
+
+	if p.ObjectID != nil && (evt.ObjectID == nil || *evt.ObjectID != *p.ObjectID) {
+		return false
+	}
+
+	return len(p.Actions) == 0 || slices.Contains(p.Actions, evt.Action)
+}
+
+// ActivityItem is the event of the project activity feed.
+type ActivityItem struct {
+	ID            int              `json:"id"`
+	Type          string           `json:"type"`
+	Category      ActivityCategory `json:"category"`
+	Action        string           `json:"action,omitempty"`
+	ObjectType    EventObjectType  `json:"object_type"`
+	ObjectID      *int             `json:"object_id"`
+	ObjectName    string           `json:"object_name"`
+	Description   string           `json:"description"`
+	UserID        *int             `json:"user_id"`
+	Username      *string          `json:"username"`
+	IntegrationID *int             `json:"integration_id"`
+	Created       time.Time        `json:"created"`
+}
+
+// ActivityFeed is the page of the activity feed. NextCursor is nil on the last page.
+type ActivityFeed struct {
+	Items      []ActivityItem `json:"items"`
+	NextCursor *int           `json:"next_cursor"`
+}
+
+func GetActivityCategory(evt Event) ActivityCategory {
+	if evt.IntegrationID != nil {
+		return ActivityIntegration
+	}
+
+	if evt.ObjectType == nil {
+		return ActivityConfig
+	}
+
+	switch *evt.ObjectType {
+	case EventTask:
+		return ActivityRun
+	case EventUser:
+		return ActivityMembership
+	default:
+		return ActivityConfig
+	}
+}
+
+// NewActivityItem converts the event to the activity item with type
+// in form <category>.<object type>[.<action>], e.g. config.template.update.
+func NewActivityItem(evt Event) ActivityItem {
+	item := ActivityItem{
+		ID:            evt.ID,
+		Category:      GetActivityCategory(evt),
+		Action:        evt.Action,
+		ObjectID:      evt.ObjectID,
+		ObjectName:    evt.ObjectName,
+		UserID:        evt.UserID,
+		Username:      evt.Username,
+		IntegrationID: evt.IntegrationID,
+		Created:       evt.Created,
+	}
+
+	if evt.ObjectType != nil {
+		item.ObjectType = *evt.ObjectType
+	}
+
+	if evt.Description != nil {
+		item.Description = *evt.Description
+	}
+
+	item.Type = string(item.Category)
+
+	if item.ObjectType != "" {
+		item.Type += "." + string(item.ObjectType)
+	}
+
+	if item.Action != "" {
+		item.Type += "." + item.Action
+	}
+
+	return item
+}
+
+// NewActivityFeed makes the feed page from events sorted by ID in descending order.
+func NewActivityFeed(events []Event, count int) ActivityFeed {
+	feed := ActivityFeed{
+		Items: make([]ActivityItem, 0, len(events)),
+	}
+
+	for _, evt := range events {
+		feed.Items = append(feed.Items, NewActivityItem(evt))
+	}
+
+	if count > 0 && len(events) == count {
+		next := events[len(events)-1].ID
+		feed.NextCursor = &next
+	}
+
+	return feed
+}
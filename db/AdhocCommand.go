@@ -0,0 +1,67 @@
+package db
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+var adhocModuleRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+(\.[A-Za-z0-9_]+)*$`)
+
+// AdhocCommand is a run of a single Ansible module against the inventory
+// without a template, e.g. ansible all -i inventory -m ping.
+type AdhocCommand struct {
+	ID          int  `db:"id" json:"id"`
+	ProjectID   int  `db:"project_id" json:"project_id"`
+	InventoryID int  `db:"inventory_id" json:"inventory_id" binding:"required"`
+	UserID      *int `db:"user_id" json:"user_id"`
+
+	// KeyID is the access key used to connect to the hosts instead of the key of the inventory.
+	KeyID *int `db:"key_id" json:"key_id"`
+
+	// Module is the Ansible module, e.g. ping or ansible.builtin.shell.
+	Module string `db:"module" json:"module"`
+	// Args are module arguments passed with -a.
+	Args string `db:"args" json:"args"`
+	// Limit is the host pattern, all hosts of the inventory are used if it is empty.
+	Limit  string `db:"hosts_limit" json:"limit"`
+	Become bool   `db:"become" json:"become"`
+
+	Status task_logger.TaskStatus `db:"status" json:"status"`
+
+	Created time.Time  `db:"created" json:"created"`
+	Start   *time.Time `db:"start" json:"start"`
+	End     *time.Time `db:"end" json:"end"`
+}
+
+// AdhocCommandOutput is a line of the output of the ad-hoc command.
+type AdhocCommandOutput struct {
+	CommandID int       `db:"command_id" json:"command_id"`
+	Time      time.Time `db:"time" json:"time"`
+	Output    string    `db:"output" json:"output"`
+}
+
+func (c *AdhocCommand) Validate() error {
+	if c.Module == "" {
+		c.Module = "command"
+	}
+
+	if !adhocModuleRegexp.MatchString(c.Module) {
+		return &ValidationError{"invalid module name"}
+	}
+
+	if c.InventoryID == 0 {
+		return &ValidationError{"inventory is required"}
+	}
+
+	return nil
+}
+
+// GetPattern returns the host pattern of the command.
+func (c *AdhocCommand) GetPattern() string {
+	if c.Limit == "" {
+		return "all"
+	}
+	return c.Limit
+}
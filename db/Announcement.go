@@ -0,0 +1,101 @@
+package db
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+type AnnouncementSeverity string
+
+const (
+	AnnouncementInfo     AnnouncementSeverity = "info"
+	AnnouncementWarning  AnnouncementSeverity = "warning"
+	AnnouncementCritical AnnouncementSeverity = "critical"
+)
+
+// announcementSeverityOrder is used to show more severe announcements first.
+var announcementSeverityOrder = map[AnnouncementSeverity]int{
+	AnnouncementCritical: 0,
+	AnnouncementWarning:  1,
+	AnnouncementInfo:     2,
+}
+
+// Announcement is the instance-wide message shown to all users, e.g. a notice of maintenance
+// or deprecation. It is managed by admins.
+type Announcement struct {
+	ID       int                  `db:"id" json:"id"`
+	Title    string               `db:"title" json:"title"`
+	Message  string               `db:"message" json:"message"`
+	Severity AnnouncementSeverity `db:"severity" json:"severity"`
+	// Start and End limit the time when the announcement is shown. Nil means no limit.
+	Start   *time.Time `db:"starts" json:"start"`
+	End     *time.Time `db:"ends" json:"end"`
+	UserID  *int       `db:"user_id" json:"user_id"`
+	Created time.Time  `db:"created" json:"created"`
+}
+
+func (a *Announcement) Validate() error {
+	a.Title = strings.TrimSpace(a.Title)
+
+	if a.Title == "" {
+		return &ValidationError{"announcement title can not be empty"}
+	}
+
+	if len(a.Title) > 255 {
+		return &ValidationError{"announcement title is too long"}
+	}
+
+	if a.Severity == "" {
+		a.Severity = AnnouncementInfo
+	}
+
+	if _, ok := announcementSeverityOrder[a.Severity]; !ok {
+		return &ValidationError{"invalid announcement severity " + string(a.Severity)}
+	}
+
+	if a.Start != nil && a.End != nil && !a.End.After(*a.Start) {
+		return &ValidationError{"announcement end must be after its start"}
+	}
+
+	return nil
+}
+
+// IsActive returns true if the announcement is shown at the time.
+func (a *Announcement) IsActive(now time.Time) bool {
+	if a.Start != nil && now.Before(*a.Start) {
+		return false
+	}
+
+	return a.End == nil || now.Before(*a.End)
+}
+
+// GetActiveAnnouncements returns announcements shown at the time,
+// the most severe and then the most recently started first.
+func GetActiveAnnouncements(announcements []Announcement, now time.Time) []Announcement {
+	res := make([]Announcement, 0)
+
+	for _, a := range announcements {
+		if a.IsActive(now) {
+			res = append(res, a)
+		}
+	}
+
+	slices.SortStableFunc(res, func(a, b Announcement) int {
+		if d := announcementSeverityOrder[a.Severity] - announcementSeverityOrder[b.Severity]; d != 0 {
+			return d
+		}
+
+		aStart, bStart := a.Created, b.Created
+		if a.Start != nil {
+			aStart = *a.Start
+		}
+		if b.Start != nil {
+			bStart = *b.Start
+		}
+
+		return bStart.Compare(aStart)
+	})
+
+	return res
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetActiveAnnouncements(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(hours int) *time.Time {
+		t := now.Add(time.Duration(hours) * time.Hour)
+		return &t
+	}
+
+	announcements := []Announcement{
+		{ID: 1, Severity: AnnouncementInfo, Start: at(-5)},
+		{ID: 2, Severity: AnnouncementCritical, Start: at(-1), End: at(1)},
+		{ID: 3, Severity: AnnouncementWarning, Start: at(1)},
+		{ID: 4, Severity: AnnouncementWarning, End: at(-1)},
+		{ID: 5, Severity: AnnouncementInfo, Start: at(-2)},
+	}
+
+	active := GetActiveAnnouncements(announcements, now)
+
+	var ids []int
+	for _, a := range active {
+		ids = append(ids, a.ID)
+	}
+
+	if len(ids) != 3 || ids[0] != 2 || ids[1] != 5 || ids[2] != 1 {
+		t.Fatalf("expected announcements 2, 5, 1, got %v", ids)
+	}
+}
+
+func TestAnnouncementValidate(t *testing.T) {
+	a := Announcement{Title: " Maintenance "}
+	if err := a.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Title != "Maintenance" || a.Severity != AnnouncementInfo {
+		t.Fatalf("unexpected announcement %+v", a)
+	}
+
+	start := time.Now()
+	for _, a := range []Announcement{
+		{Title: ""},
+		{Title: "Upgrade", Severity: "urgent"},
+		{Title: "Upgrade", Start: &start, End: &start},
+	} {
+		if err := a.Validate(); err == nil {
+			t.Errorf("announcement %+v must be invalid", a)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MaxAnsibleForks limits the number of parallel processes of ansible-playbook.
+const MaxAnsibleForks = 500
+
+var ansibleSerialRegexp = regexp.MustCompile(`^[1-9][0-9]*%?$`)
+
+type AnsibleStrategy string
+
+const (
+	// AnsibleStrategyDefault keeps the strategy of plays and ansible.cfg.
+	AnsibleStrategyDefault AnsibleStrategy = ""
+	AnsibleStrategyLinear  AnsibleStrategy = "linear"
+	AnsibleStrategyFree    AnsibleStrategy = "free"
+	// AnsibleStrategyMitogenLinear and AnsibleStrategyMitogenFree require
+	// Mitogen for Ansible installed for the Python of Ansible.
+	AnsibleStrategyMitogenLinear AnsibleStrategy = "mitogen_linear"
+	AnsibleStrategyMitogenFree   AnsibleStrategy = "mitogen_free"
+)
+
+func (s AnsibleStrategy) IsMitogen() bool {
+	return strings.HasPrefix(string(s), "mitogen_")
+}
+
+// AnsibleExecution controls the parallelism of the run of the playbook.
+// Zero values keep settings of the playbook and ansible.cfg.
+type AnsibleExecution struct {
+	// Forks is the number of hosts managed in parallel, ansible-playbook --forks.
+	Forks int `json:"forks,omitempty"`
+	// Serial is the size of batches of hosts, the number or the percentage, e.g. "2" or "30%".
+	// Ansible has no option to override serial of plays, so it is passed as semaphore_serial
+	// variable which plays use as serial: "{{ semaphore_serial | default(0) }}".
+	Serial   string          `json:"serial,omitempty"`
+	Strategy AnsibleStrategy `json:"strategy,omitempty"`
+}
+
+func (e AnsibleExecution) Validate() error {
+	if e.Forks < 0 || e.Forks > MaxAnsibleForks {
+		return &ValidationError{"forks must be between 1 and " + strconv.Itoa(MaxAnsibleForks)}
+	}
+
+	if e.Serial != "" {
+		if !ansibleSerialRegexp.MatchString(e.Serial) {
+			return &ValidationError{"serial must be a number of hosts or a percentage, e.g. 2 or 30%"}
+		}
+
+		if percent, ok := strings.CutSuffix(e.Serial, "%"); ok {
+			if n, _ := strconv.Atoi(percent); n > 100 {
+				return &ValidationError{"serial percentage can not be greater than 100%"}
+			}
+		}
+	}
+
+	switch e.Strategy {
+	case AnsibleStrategyDefault, AnsibleStrategyLinear, AnsibleStrategyFree,
+		AnsibleStrategyMitogenLinear, AnsibleStrategyMitogenFree:
+	default:
+		return &ValidationError{"strategy must be linear, free, mitogen_linear or mitogen_free"}
+	}
+
+	return nil
+}
+
+// GetAnsibleExecution returns execution settings of the template overridden
+// by the settings of the task which are set.
+func GetAnsibleExecution(tplParams AnsibleTemplateParams, taskParams AnsibleTaskParams) AnsibleExecution {
+	res := tplParams.Execution
+
+	if taskParams.Execution == nil || !tplParams.AllowOverrideExecution {
+		return res
+	}
+
+	if taskParams.Execution.Forks != 0 {
+		res.Forks = taskParams.Execution.Forks
+	}
+
+	if taskParams.Execution.Serial != "" {
+		res.Serial = taskParams.Execution.Serial
+	}
+
+	if taskParams.Execution.Strategy != AnsibleStrategyDefault {
+		res.Strategy = taskParams.Execution.Strategy
+	}
+
+	return res
+}
@@ -0,0 +1,53 @@
+package db
+
+import "testing"
+
+func TestAnsibleExecutionValidate(t *testing.T) {
+	valid := []AnsibleExecution{
+		{},
+		{Forks: 20, Serial: "5", Strategy: AnsibleStrategyFree},
+		{Serial: "30%", Strategy: AnsibleStrategyMitogenLinear},
+	}
+
+	for _, e := range valid {
+		if err := e.Validate(); err != nil {
+			t.Fatalf("%+v must be valid: %s", e, err.Error())
+		}
+	}
+
+	invalid := []AnsibleExecution{
+		{Forks: -1},
+		{Forks: MaxAnsibleForks + 1},
+		{Serial: "0"},
+		{Serial: "150%"},
+		{Serial: "all"},
+		{Strategy: "host_pinned"},
+	}
+
+	for _, e := range invalid {
+		if err := e.Validate(); err == nil {
+			t.Fatalf("%+v must be invalid", e)
+		}
+	}
+}
+
+func TestGetAnsibleExecution(t *testing.T) {
+	tplParams := AnsibleTemplateParams{
+		Execution: AnsibleExecution{Forks: 10, Strategy: AnsibleStrategyLinear},
+	}
+
+	taskParams := AnsibleTaskParams{
+		Execution: &AnsibleExecution{Serial: "2", Strategy: AnsibleStrategyFree},
+	}
+
+	if res := GetAnsibleExecution(tplParams, taskParams); res != tplParams.Execution {
+		t.Fatalf("task must not override execution of the template, got %+v", res)
+	}
+
+	tplParams.AllowOverrideExecution = true
+
+	res := GetAnsibleExecution(tplParams, taskParams)
+	if res.Forks != 10 || res.Serial != "2" || res.Strategy != AnsibleStrategyFree {
+		t.Fatalf("unexpected execution %+v", res)
+	}
+}
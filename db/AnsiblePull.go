@@ -0,0 +1,91 @@
+package db
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+// PullRepositoryTokenLifetime is the lifetime of the repository URL given to the pull host.
+// The host receives a new URL every time it requests the configuration.
+const PullRepositoryTokenLifetime = time.Hour
+
+// DefaultAnsiblePullSchedule runs ansible-pull every 30 minutes.
+const DefaultAnsiblePullSchedule = "*/30 * * * *"
+
+var pullHostNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// AnsiblePull enables the ansible-pull mode of the template. Hosts which can not accept
+// inbound SSH run the playbook by themselves with ansible-pull and report results to Semaphore.
+type AnsiblePull struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Schedule is the cron schedule of ansible-pull on the hosts, DefaultAnsiblePullSchedule if empty.
+	Schedule string `json:"schedule,omitempty"`
+	// OnlyIfChanged runs the playbook only if the repository changed, ansible-pull --only-if-changed.
+	OnlyIfChanged bool `json:"only_if_changed,omitempty"`
+}
+
+func (p AnsiblePull) GetSchedule() string {
+	if p.Schedule == "" {
+		return DefaultAnsiblePullSchedule
+	}
+	return p.Schedule
+}
+
+func (p AnsiblePull) Validate() error {
+	if p.Schedule == "" {
+		return nil
+	}
+
+	if _, err := cron.ParseStandard(p.Schedule); err != nil || strings.HasPrefix(p.Schedule, "@") {
+		return &ValidationError{"ansible-pull schedule must be a cron expression of five fields"}
+	}
+
+	return nil
+}
+
+// PullHost is the host enrolled to run the playbook of the template with ansible-pull.
+// Only hashes of the host token and of the current repository token are stored.
+type PullHost struct {
+	ID         int       `db:"id" json:"id"`
+	ProjectID  int       `db:"project_id" json:"project_id"`
+	TemplateID int       `db:"template_id" json:"template_id"`
+	Name       string    `db:"name" json:"name"`
+	TokenHash  string    `db:"token_hash" json:"-"`
+	Created    time.Time `db:"created" json:"created"`
+
+	RepositoryTokenHash    string     `db:"repository_token_hash" json:"-"`
+	RepositoryTokenExpires *time.Time `db:"repository_token_expires" json:"-"`
+
+	// LastReport, LastStatus and LastTaskID describe the last run reported by the host.
+	LastReport *time.Time             `db:"last_report" json:"last_report"`
+	LastStatus task_logger.TaskStatus `db:"last_status" json:"last_status"`
+	LastTaskID *int                   `db:"last_task_id" json:"last_task_id"`
+}
+
+func (h *PullHost) Validate() error {
+	if !pullHostNameRegexp.MatchString(h.Name) || len(h.Name) > 255 {
+		return &ValidationError{"pull host name must be a host name"}
+	}
+	return nil
+}
+
+// HashPullToken returns the hash of the host or repository token stored in the database.
+func HashPullToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckRepositoryToken returns true if the repository token matches the host and is not expired.
+func (h *PullHost) CheckRepositoryToken(token string, now time.Time) bool {
+	if token == "" || h.RepositoryTokenHash == "" || h.RepositoryTokenExpires == nil || !now.Before(*h.RepositoryTokenExpires) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(h.RepositoryTokenHash), []byte(HashPullToken(token))) == 1
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CanaryBatch is the batch of hosts of the canary deployment.
+type CanaryBatch string
+
+const (
+	CanaryBatchNone      CanaryBatch = ""
+	CanaryBatchCanary    CanaryBatch = "canary"
+	CanaryBatchRemainder CanaryBatch = "remainder"
+)
+
+// CanaryRemainderParam is the task parameter of the canary deployment
+// which contains the limit of the remainder batch.
+const CanaryRemainderParam = "canary_remainder"
+
+// IsCanaryDeployment returns true if the task coordinates the batches of the canary launch.
+// Such task does not run the playbook itself, its batches are run by the child tasks.
+func (task *Task) IsCanaryDeployment() bool {
+	if task.ParentTaskID != nil || task.Params == nil {
+		return false
+	}
+	canary, ok := task.Params["canary"].(string)
+	return ok && canary != ""
+}
+
+// parseCanarySize returns the number of hosts of the canary batch. The size is
+// the number of hosts or the percentage of all hosts rounded up, e.g. "2" or "10%".
+func parseCanarySize(size string, total int) (int, error) {
+	size = strings.TrimSpace(size)
+
+	if percent, ok := strings.CutSuffix(size, "%"); ok {
+		n, err := strconv.ParseFloat(strings.TrimSpace(percent), 64)
+		if err != nil || n <= 0 || n >= 100 {
+			return 0, &ValidationError{"canary percentage must be greater than 0 and less than 100"}
+		}
+		return int(math.Ceil(float64(total) * n / 100)), nil
+	}
+
+	n, err := strconv.Atoi(size)
+	if err != nil || n <= 0 {
+		return 0, &ValidationError{"canary size must be a positive number of hosts or a percentage"}
+	}
+
+	return n, nil
+}
+
+// SplitCanaryHosts splits the hosts into the canary batch and the remainder batch.
+// Both batches must contain at least one host.
+func SplitCanaryHosts(hosts []string, size string) (canary []string, remainder []string, err error) {
+	n, err := parseCanarySize(size, len(hosts))
+	if err != nil {
+		return
+	}
+
+	if len(hosts) < 2 {
+		err = &ValidationError{"canary launch requires at least two hosts"}
+		return
+	}
+
+	if n >= len(hosts) {
+		err = &ValidationError{"canary batch must not include all " + strconv.Itoa(len(hosts)) + " hosts"}
+		return
+	}
+
+	canary = hosts[:n]
+	remainder = hosts[n:]
+	return
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitCanaryHosts(t *testing.T) {
+	hosts := []string{"web1", "web2", "web3", "web4", "web5"}
+
+	cases := []struct {
+		size   string
+		canary []string
+		valid  bool
+	}{
+		{"1", []string{"web1"}, true},
+		{"2", []string{"web1", "web2"}, true},
+		{"10%", []string{"web1"}, true},
+		{"50%", []string{"web1", "web2", "web3"}, true},
+		{"5", nil, false},
+		{"0", nil, false},
+		{"100%", nil, false},
+		{"abc", nil, false},
+	}
+
+	for _, c := range cases {
+		canary, remainder, err := SplitCanaryHosts(hosts, c.size)
+
+		if !c.valid {
+			if err == nil {
+				t.Errorf("size %q: expected error", c.size)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("size %q: unexpected error %v", c.size, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(canary, c.canary) {
+			t.Errorf("size %q: expected canary %v, got %v", c.size, c.canary, canary)
+		}
+
+		if len(canary)+len(remainder) != len(hosts) {
+			t.Errorf("size %q: batches must include all hosts", c.size)
+		}
+	}
+
+	if _, _, err := SplitCanaryHosts([]string{"web1"}, "1"); err == nil {
+		t.Error("expected error for single host")
+	}
+}
+
+func TestIsCanaryDeployment(t *testing.T) {
+	parentID := 1
+
+	if !(&Task{Params: MapStringAnyField{"canary": "1"}}).IsCanaryDeployment() {
+		t.Error("task with canary size must be canary deployment")
+	}
+
+	if (&Task{Params: MapStringAnyField{"canary": ""}}).IsCanaryDeployment() {
+		t.Error("task with empty canary size must not be canary deployment")
+	}
+
+	if (&Task{ParentTaskID: &parentID, Params: MapStringAnyField{"canary": "1"}}).IsCanaryDeployment() {
+		t.Error("batch of canary deployment must not be canary deployment")
+	}
+}
@@ -0,0 +1,93 @@
+package db
+
+import "sort"
+
+// DeploymentEnvironment is a stage to which templates are deployed, e.g. dev, stage or prod.
+// Environments are ordered by Position, tasks are promoted to the next environment.
+type DeploymentEnvironment struct {
+	ID        int    `db:"id" json:"id" backup:"-"`
+	ProjectID int    `db:"project_id" json:"project_id" backup:"-"`
+	Name      string `db:"name" json:"name"`
+	Position  int    `db:"position" json:"position"`
+}
+
+// Deployment is the last successful task of the template in the deployment environment.
+type Deployment struct {
+	Environment DeploymentEnvironment `json:"environment"`
+	Task        *Task                 `json:"task"`
+}
+
+func (env *DeploymentEnvironment) Validate() error {
+	if env.Name == "" {
+		return &ValidationError{"name can not be empty"}
+	}
+	return nil
+}
+
+// SortDeploymentEnvironments sorts environments in order of promotion.
+func SortDeploymentEnvironments(envs []DeploymentEnvironment) {
+	sort.SliceStable(envs, func(i, j int) bool {
+		if envs[i].Position == envs[j].Position {
+			return envs[i].ID < envs[j].ID
+		}
+		return envs[i].Position < envs[j].Position
+	})
+}
+
+// GetNextDeploymentEnvironment returns the environment which follows the environment
+// with the given ID in order of promotion.
+func GetNextDeploymentEnvironment(envs []DeploymentEnvironment, envID int) (next DeploymentEnvironment, err error) {
+	envs = append([]DeploymentEnvironment{}, envs...)
+	SortDeploymentEnvironments(envs)
+
+	for i, env := range envs {
+		if env.ID != envID {
+			continue
+		}
+
+		if i+1 == len(envs) {
+			break
+		}
+
+		next = envs[i+1]
+		return
+	}
+
+	err = ErrNotFound
+	return
+}
+
+// GetRollbackTask returns the task to which the deployment environment must be rolled back.
+// Deployments are successful tasks of the template in the environment sorted from the newest.
+// Rollbacks are never selected, the rollback of the rollback returns the task preceding
+// the task to which the environment was rolled back.
+func GetRollbackTask(deployments []Task) (task Task, err error) {
+	if len(deployments) == 0 {
+		err = ErrNotFound
+		return
+	}
+
+	current := deployments[0]
+
+	for current.RollbackTaskID != nil {
+		rollbackTaskID := *current.RollbackTaskID
+		current = Task{ID: rollbackTaskID}
+
+		for _, d := range deployments {
+			if d.ID == rollbackTaskID {
+				current = d
+				break
+			}
+		}
+	}
+
+	for _, d := range deployments {
+		if d.ID < current.ID && d.RollbackTaskID == nil {
+			task = d
+			return
+		}
+	}
+
+	err = ErrNotFound
+	return
+}
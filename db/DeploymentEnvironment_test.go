@@ -0,0 +1,36 @@
+package db
+
+import "testing"
+
+func TestGetRollbackTask(t *testing.T) {
+	_, err := GetRollbackTask(nil)
+	if err != ErrNotFound {
+		t.Fatal("expected ErrNotFound without deployments")
+	}
+
+	deployments := []Task{{ID: 3}, {ID: 2}, {ID: 1}}
+
+	task, err := GetRollbackTask(deployments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.ID != 2 {
+		t.Fatal("rollback must return previous deployment")
+	}
+
+	rollbackTaskID := 2
+	deployments = append([]Task{{ID: 4, RollbackTaskID: &rollbackTaskID}}, deployments...)
+
+	task, err = GetRollbackTask(deployments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.ID != 1 {
+		t.Fatal("rollback of rollback must return deployment preceding rolled back one")
+	}
+
+	_, err = GetRollbackTask([]Task{{ID: 1}})
+	if err != ErrNotFound {
+		t.Fatal("expected ErrNotFound for the only deployment")
+	}
+}
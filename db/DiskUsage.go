@@ -0,0 +1,179 @@
+package db
+
+import (
+	"sort"
+	"time"
+)
+
+// DiskUsageKind is the kind of data which Semaphore stores in the tmp directory.
+type DiskUsageKind string
+
+const (
+	// DiskUsageRepository is the cache of the repository cloned for the template.
+	DiskUsageRepository DiskUsageKind = "repository"
+	// DiskUsageTemplate contains roles, collections and ansible.cfg of the template.
+	DiskUsageTemplate DiskUsageKind = "template"
+	// DiskUsageShared is the cache shared by all projects, e.g. galaxy cache and python venvs.
+	DiskUsageShared DiskUsageKind = "shared"
+	// DiskUsageTask is the temporary workspace of the task: inventories, outputs and keys.
+	DiskUsageTask DiskUsageKind = "task"
+	// DiskUsageOther is the data which does not belong to any known kind.
+	DiskUsageOther DiskUsageKind = "other"
+)
+
+// IsCache returns true if the data of the kind can be removed and restored by the next task.
+func (k DiskUsageKind) IsCache() bool {
+	return k == DiskUsageRepository || k == DiskUsageTemplate || k == DiskUsageShared
+}
+
+// DiskUsageEntry is the file or directory of the tmp directory.
+type DiskUsageEntry struct {
+	Name string        `json:"name"`
+	Kind DiskUsageKind `json:"kind"`
+
+	// RepositoryID, TemplateID and TaskID are parsed from the name of the entry.
+	RepositoryID int `json:"repository_id,omitempty"`
+	TemplateID   int `json:"template_id,omitempty"`
+	TaskID       int `json:"task_id,omitempty"`
+
+	Size int64 `json:"size"`
+	// LastUsed is the latest modification time of the files of the entry.
+	LastUsed time.Time `json:"last_used"`
+}
+
+// DiskUsage is the disk consumed by the data of the kind of the project on the server or the runner.
+type DiskUsage struct {
+	ID int `db:"id" json:"id"`
+	// RunnerID is empty for the data of the server.
+	RunnerID *int `db:"runner_id" json:"runner_id"`
+	// ProjectID is empty for the shared data and for the data which can not be attributed to a project.
+	ProjectID *int          `db:"project_id" json:"project_id"`
+	Kind      DiskUsageKind `db:"kind" json:"kind"`
+	Size      int64         `db:"size" json:"size"`
+	Entries   int           `db:"entries" json:"entries"`
+	Updated   time.Time     `db:"updated" json:"updated"`
+}
+
+type diskUsageKey struct {
+	projectID int
+	kind      DiskUsageKind
+}
+
+// SumDiskUsage groups the entries by project and kind. projectOf returns
+// the project of the entry or nil if the entry does not belong to a project.
+func SumDiskUsage(entries []DiskUsageEntry, projectOf func(DiskUsageEntry) *int, updated time.Time) []DiskUsage {
+	sums := make(map[diskUsageKey]*DiskUsage)
+
+	for _, entry := range entries {
+		projectID := projectOf(entry)
+
+		key := diskUsageKey{kind: entry.Kind}
+		if projectID != nil {
+			key.projectID = *projectID
+		}
+
+		usage, ok := sums[key]
+		if !ok {
+			usage = &DiskUsage{
+				ProjectID: projectID,
+				Kind:      entry.Kind,
+				Updated:   updated.UTC(),
+			}
+			sums[key] = usage
+		}
+
+		usage.Size += entry.Size
+		usage.Entries++
+	}
+
+	res := make([]DiskUsage, 0, len(sums))
+	for _, usage := range sums {
+		res = append(res, *usage)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		pi, pj := 0, 0
+		if res[i].ProjectID != nil {
+			pi = *res[i].ProjectID
+		}
+		if res[j].ProjectID != nil {
+			pj = *res[j].ProjectID
+		}
+		if pi != pj {
+			return pi < pj
+		}
+		return res[i].Kind < res[j].Kind
+	})
+
+	return res
+}
+
+// SelectDiskUsageEvictions returns the cache entries which must be removed to fit the caps.
+// Entries used least recently are removed first, entries for which inUse returns true are kept.
+// The project cap is applied to the caches of each project, the total cap to all caches.
+// Zero caps are not applied.
+func SelectDiskUsageEvictions(
+	entries []DiskUsageEntry,
+	projectOf func(DiskUsageEntry) *int,
+	inUse func(DiskUsageEntry) bool,
+	projectCap int64,
+	totalCap int64,
+) (evicted []DiskUsageEntry) {
+	var caches []DiskUsageEntry
+	for _, entry := range entries {
+		if entry.Kind.IsCache() {
+			caches = append(caches, entry)
+		}
+	}
+
+	sort.SliceStable(caches, func(i, j int) bool {
+		return caches[i].LastUsed.Before(caches[j].LastUsed)
+	})
+
+	removed := make(map[string]bool)
+
+	if projectCap > 0 {
+		projectSizes := make(map[int]int64)
+		for _, entry := range caches {
+			if projectID := projectOf(entry); projectID != nil {
+				projectSizes[*projectID] += entry.Size
+			}
+		}
+
+		for _, entry := range caches {
+			projectID := projectOf(entry)
+			if projectID == nil || projectSizes[*projectID] <= projectCap || inUse(entry) {
+				continue
+			}
+
+			projectSizes[*projectID] -= entry.Size
+			removed[entry.Name] = true
+			evicted = append(evicted, entry)
+		}
+	}
+
+	if totalCap > 0 {
+		var total int64
+		for _, entry := range caches {
+			if !removed[entry.Name] {
+				total += entry.Size
+			}
+		}
+
+		for _, entry := range caches {
+			if total <= totalCap {
+				break
+			}
+
+			if removed[entry.Name] || inUse(entry) {
+				continue
+			}
+
+			total -= entry.Size
+			removed[entry.Name] = true
+			evicted = append(evicted, entry)
+		}
+	}
+
+	return
+}
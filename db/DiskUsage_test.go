@@ -0,0 +1,51 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelectDiskUsageEvictions(t *testing.T) {
+	now := time.Now()
+	project1 := 1
+	project2 := 2
+
+	entries := []DiskUsageEntry{
+		{Name: "repository_1_1", Kind: DiskUsageRepository, RepositoryID: 1, TemplateID: 1, Size: 300, LastUsed: now.Add(-3 * time.Hour)},
+		{Name: "repository_1_2", Kind: DiskUsageRepository, RepositoryID: 1, TemplateID: 2, Size: 300, LastUsed: now.Add(-2 * time.Hour)},
+		{Name: "template_2", Kind: DiskUsageTemplate, TemplateID: 2, Size: 100, LastUsed: now.Add(-time.Hour)},
+		{Name: "repository_2_3", Kind: DiskUsageRepository, RepositoryID: 2, TemplateID: 3, Size: 500, LastUsed: now.Add(-4 * time.Hour)},
+		{Name: "inventory_5", Kind: DiskUsageTask, TaskID: 5, Size: 1000, LastUsed: now.Add(-5 * time.Hour)},
+	}
+
+	projectOf := func(entry DiskUsageEntry) *int {
+		switch entry.Name {
+		case "repository_2_3":
+			return &project2
+		case "inventory_5":
+			return nil
+		default:
+			return &project1
+		}
+	}
+
+	inUse := func(entry DiskUsageEntry) bool {
+		return entry.Name == "repository_2_3"
+	}
+
+	evicted := SelectDiskUsageEvictions(entries, projectOf, inUse, 500, 0)
+	if len(evicted) != 1 || evicted[0].Name != "repository_1_1" {
+		t.Fatalf("expected the least recently used cache of the project to be evicted, got %v", evicted)
+	}
+
+	evicted = SelectDiskUsageEvictions(entries, projectOf, inUse, 0, 600)
+	if len(evicted) != 2 || evicted[0].Name != "repository_1_1" || evicted[1].Name != "repository_1_2" {
+		t.Fatalf("expected caches which are not in use to be evicted, got %v", evicted)
+	}
+
+	usage := SumDiskUsage(entries, projectOf, now)
+	if len(usage) != 4 || usage[0].ProjectID != nil || usage[0].Size != 1000 ||
+		usage[1].Kind != DiskUsageRepository || usage[1].Size != 600 || usage[1].Entries != 2 {
+		t.Fatalf("unexpected disk usage %v", usage)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"strings"
+
+	"github.com/semaphoreui/semaphore/pkg/jsonschema"
 )
 
 type EnvironmentSecretOperation string
@@ -38,10 +40,26 @@ type Environment struct {
 	JSON      string  `db:"json" json:"json" binding:"required"`
 	ENV       *string `db:"env" json:"env" binding:"required"`
 
+	// JSONSchema is JSON Schema of extra variables. Extra variables of the environment
+	// are validated against it on save and extra variables of the task are validated on launch.
+	JSONSchema *string `db:"json_schema" json:"json_schema"`
+
+	// ProjectDefault marks the environment which holds project-wide variables and secrets.
+	// It is not listed with other environments and is merged into the environment of every task.
+	ProjectDefault bool `db:"project_default" json:"-" backup:"-"`
+
+	// VariableGroup marks the environment which is attached to templates as a reusable set
+	// of variables and secrets. It is not listed with other environments.
+	VariableGroup bool `db:"variable_group" json:"-" backup:"-"`
+
 	// Secrets is a field which used to update secrets associated with the environment.
 	Secrets []EnvironmentSecret `db:"-" json:"secrets" backup:"-"`
 }
 
+// ProjectDefaultEnvironmentName is the name of the environment which holds
+// project-wide variables and secrets.
+const ProjectDefaultEnvironmentName = "Project defaults"
+
 func (s *EnvironmentSecret) Validate() error {
 
 	if s.Type == EnvironmentSecretVar || s.Type == EnvironmentSecretEnv {
@@ -68,6 +86,110 @@ func (env *Environment) Validate() error {
 		return &ValidationError{"Environment variables must be valid JSON"}
 	}
 
+	return env.validateSchema()
+}
+
+// HasSchema returns true if extra variables of the environment are described by JSON Schema.
+func (env *Environment) HasSchema() bool {
+	return env.JSONSchema != nil && strings.TrimSpace(*env.JSONSchema) != ""
+}
+
+func (env *Environment) parseSchema() (*jsonschema.Schema, error) {
+	schema, err := jsonschema.Parse([]byte(*env.JSONSchema))
+	if err != nil {
+		return nil, &ValidationError{"Invalid JSON schema: " + err.Error()}
+	}
+	return schema, nil
+}
+
+// validateSchema checks that the schema is valid and extra variables of the environment match it.
+// Required variables are not checked because they can be passed by secrets,
+// project defaults or the task.
+func (env *Environment) validateSchema() error {
+	if !env.HasSchema() {
+		return nil
+	}
+
+	schema, err := env.parseSchema()
+	if err != nil {
+		return err
+	}
+
+	schema.Required = nil
+
+	if err = schema.ValidateJSON([]byte(env.JSON)); err != nil {
+		return &ValidationError{"Extra variables do not match the schema: " + err.Error()}
+	}
+
+	return nil
+}
+
+// ValidateTaskEnvironment validates extra variables which the task of the template
+// will receive against the schema of the template environment. taskEnvironment is
+// the extra variables passed to the task.
+func ValidateTaskEnvironment(store Store, tpl Template, taskEnvironment string) error {
+	if tpl.EnvironmentID == nil {
+		return nil
+	}
+
+	env, err := store.GetEnvironment(tpl.ProjectID, *tpl.EnvironmentID)
+	if err != nil {
+		return err
+	}
+
+	if !env.HasSchema() {
+		return nil
+	}
+
+	schema, err := env.parseSchema()
+	if err != nil {
+		return err
+	}
+
+	if err = FillEnvironmentSecrets(store, &env, true); err != nil {
+		return err
+	}
+
+	if err = MergeTemplateVariableGroups(store, tpl, &env, true); err != nil {
+		return err
+	}
+
+	defaults, err := store.GetProjectDefaultEnvironment(tpl.ProjectID)
+	if err == nil {
+		if err = FillEnvironmentSecrets(store, &defaults, true); err != nil {
+			return err
+		}
+
+		if err = env.MergeProjectDefaults(defaults); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	vars := make(map[string]any)
+
+	if taskEnvironment != "" {
+		if err = json.Unmarshal([]byte(taskEnvironment), &vars); err != nil {
+			return &ValidationError{"Task extra variables must be valid JSON"}
+		}
+	}
+
+	// variables of the environment override variables of the task when the task is run
+	if err = unmarshalEnvironmentVars(env.JSON, vars); err != nil {
+		return err
+	}
+
+	for _, s := range env.Secrets {
+		if s.Type == EnvironmentSecretVar {
+			vars[s.Name] = s.Secret
+		}
+	}
+
+	if err = schema.Validate(vars); err != nil {
+		return &ValidationError{"Extra variables do not match the schema of environment " + env.Name + ": " + err.Error()}
+	}
+
 	return nil
 }
 
@@ -110,3 +232,97 @@ func FillEnvironmentSecrets(store Store, env *Environment, deserializeSecret boo
 
 	return nil
 }
+
+func unmarshalEnvironmentVars[T any](str string, vars map[string]T) error {
+	if str == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(str), &vars)
+}
+
+func (env *Environment) hasSecret(secretType EnvironmentSecretType, name string) bool {
+	for _, s := range env.Secrets {
+		if s.Type == secretType && s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// MergeProjectDefaults adds extra variables, environment variables and secrets
+// of the project default environment or the variable group which are not defined in env.
+// Values of env always win over the defaults.
+func (env *Environment) MergeProjectDefaults(defaults Environment) error {
+	extraVars := make(map[string]any)
+	defaultExtraVars := make(map[string]any)
+	envVars := make(map[string]string)
+	defaultEnvVars := make(map[string]string)
+
+	if err := unmarshalEnvironmentVars(env.JSON, extraVars); err != nil {
+		return err
+	}
+
+	if err := unmarshalEnvironmentVars(defaults.JSON, defaultExtraVars); err != nil {
+		return err
+	}
+
+	if env.ENV != nil {
+		if err := unmarshalEnvironmentVars(*env.ENV, envVars); err != nil {
+			return err
+		}
+	}
+
+	if defaults.ENV != nil {
+		if err := unmarshalEnvironmentVars(*defaults.ENV, defaultEnvVars); err != nil {
+			return err
+		}
+	}
+
+	var secrets []EnvironmentSecret
+	for _, s := range defaults.Secrets {
+		if env.hasSecret(s.Type, s.Name) {
+			continue
+		}
+
+		if _, ok := extraVars[s.Name]; ok && s.Type == EnvironmentSecretVar {
+			continue
+		}
+
+		if _, ok := envVars[s.Name]; ok && s.Type == EnvironmentSecretEnv {
+			continue
+		}
+
+		secrets = append(secrets, s)
+	}
+
+	for k, v := range defaultExtraVars {
+		if _, ok := extraVars[k]; ok || env.hasSecret(EnvironmentSecretVar, k) {
+			continue
+		}
+		extraVars[k] = v
+	}
+
+	for k, v := range defaultEnvVars {
+		if _, ok := envVars[k]; ok || env.hasSecret(EnvironmentSecretEnv, k) {
+			continue
+		}
+		envVars[k] = v
+	}
+
+	extraVarsJSON, err := json.Marshal(extraVars)
+	if err != nil {
+		return err
+	}
+
+	envVarsJSON, err := json.Marshal(envVars)
+	if err != nil {
+		return err
+	}
+
+	env.JSON = string(extraVarsJSON)
+	envVarsStr := string(envVarsJSON)
+	env.ENV = &envVarsStr
+	env.Secrets = append(env.Secrets, secrets...)
+
+	return nil
+}
@@ -0,0 +1,291 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvironmentDocumentKind is the section of the environment described by one document
+// of the environment file.
+type EnvironmentDocumentKind string
+
+const (
+	// EnvironmentDocumentVars holds extra variables passed to Ansible, Terraform or the script.
+	EnvironmentDocumentVars EnvironmentDocumentKind = "vars"
+	// EnvironmentDocumentEnv holds environment variables of the process.
+	EnvironmentDocumentEnv EnvironmentDocumentKind = "env"
+	// EnvironmentDocumentSecrets holds secret extra variables and secret environment variables.
+	EnvironmentDocumentSecrets EnvironmentDocumentKind = "secrets"
+)
+
+type environmentDocument struct {
+	Kind EnvironmentDocumentKind `yaml:"kind"`
+	Data yaml.Node               `yaml:"data"`
+}
+
+// EnvironmentFileSecrets are secrets of the environment file by the way they are injected.
+// A nil value keeps the stored value of the existing secret.
+type EnvironmentFileSecrets struct {
+	Vars map[string]*string `yaml:"vars"`
+	Env  map[string]*string `yaml:"env"`
+}
+
+// EnvironmentFile is the environment split into separate sections: extra variables,
+// environment variables and secrets. Each section is a YAML document of the file,
+// so a value can not be defined in one section and silently injected by another.
+//
+//	kind: vars
+//	data:
+//	  region: us-east-1
+//	---
+//	kind: env
+//	data:
+//	  AWS_REGION: us-east-1
+//	---
+//	kind: secrets
+//	data:
+//	  vars:
+//	    db_password: ~
+//	  env:
+//	    AWS_SECRET_ACCESS_KEY: "..."
+type EnvironmentFile struct {
+	Vars    map[string]any
+	Env     map[string]string
+	Secrets EnvironmentFileSecrets
+}
+
+// ParseEnvironmentFile parses and validates the multi-document environment file.
+// Missing documents are treated as empty sections.
+func ParseEnvironmentFile(data []byte) (*EnvironmentFile, error) {
+	file := &EnvironmentFile{
+		Vars: make(map[string]any),
+		Env:  make(map[string]string),
+	}
+
+	seen := make(map[EnvironmentDocumentKind]bool)
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		var doc environmentDocument
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, &ValidationError{"Invalid environment file: " + err.Error()}
+		}
+
+		switch doc.Kind {
+		case EnvironmentDocumentVars, EnvironmentDocumentEnv, EnvironmentDocumentSecrets:
+		default:
+			return nil, &ValidationError{fmt.Sprintf("Unknown kind of environment document: %q", doc.Kind)}
+		}
+
+		if seen[doc.Kind] {
+			return nil, &ValidationError{fmt.Sprintf("Environment file contains more than one %s document", doc.Kind)}
+		}
+		seen[doc.Kind] = true
+
+		if doc.Data.IsZero() {
+			continue
+		}
+
+		switch doc.Kind {
+		case EnvironmentDocumentVars:
+			err = doc.Data.Decode(&file.Vars)
+		case EnvironmentDocumentEnv:
+			err = doc.Data.Decode(&file.Env)
+		case EnvironmentDocumentSecrets:
+			err = doc.Data.Decode(&file.Secrets)
+		}
+
+		if err != nil {
+			return nil, &ValidationError{fmt.Sprintf("Invalid %s document: %s", doc.Kind, err.Error())}
+		}
+	}
+
+	if err := file.validate(); err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func (f *EnvironmentFile) validate() error {
+	for name := range f.Secrets.Vars {
+		if _, ok := f.Vars[name]; ok {
+			return &ValidationError{fmt.Sprintf("Variable %s is defined in both vars and secrets", name)}
+		}
+	}
+
+	for name := range f.Env {
+		if !IsValidEnvVarName(name) {
+			return &ValidationError{fmt.Sprintf("Invalid name of environment variable: %s", name)}
+		}
+	}
+
+	for name := range f.Secrets.Env {
+		if !IsValidEnvVarName(name) {
+			return &ValidationError{fmt.Sprintf("Invalid name of environment variable: %s", name)}
+		}
+
+		if _, ok := f.Env[name]; ok {
+			return &ValidationError{fmt.Sprintf("Environment variable %s is defined in both env and secrets", name)}
+		}
+	}
+
+	return nil
+}
+
+// NewEnvironmentFile returns the file of the environment. Values of secrets are never exported.
+func NewEnvironmentFile(env Environment) (*EnvironmentFile, error) {
+	file := &EnvironmentFile{
+		Vars: make(map[string]any),
+		Env:  make(map[string]string),
+		Secrets: EnvironmentFileSecrets{
+			Vars: make(map[string]*string),
+			Env:  make(map[string]*string),
+		},
+	}
+
+	if err := unmarshalEnvironmentVars(env.JSON, file.Vars); err != nil {
+		return nil, err
+	}
+
+	if env.ENV != nil {
+		if err := unmarshalEnvironmentVars(*env.ENV, file.Env); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range env.Secrets {
+		switch s.Type {
+		case EnvironmentSecretVar:
+			file.Secrets.Vars[s.Name] = nil
+		case EnvironmentSecretEnv:
+			file.Secrets.Env[s.Name] = nil
+		}
+	}
+
+	return file, nil
+}
+
+// Marshal returns the file as YAML documents in the order vars, env, secrets.
+func (f *EnvironmentFile) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	docs := []struct {
+		Kind EnvironmentDocumentKind `yaml:"kind"`
+		Data any                     `yaml:"data"`
+	}{
+		{EnvironmentDocumentVars, f.Vars},
+		{EnvironmentDocumentEnv, f.Env},
+		{EnvironmentDocumentSecrets, f.Secrets},
+	}
+
+	for _, doc := range docs {
+		if err := encoder.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ApplyTo replaces variables of env with the sections of the file.
+// env.Secrets must contain the existing secrets of the environment. They are replaced
+// with create, update and delete operations. Secrets which are not in the file are deleted.
+func (f *EnvironmentFile) ApplyTo(env *Environment) error {
+	varsJSON, err := json.Marshal(f.Vars)
+	if err != nil {
+		return err
+	}
+
+	envJSON, err := json.Marshal(f.Env)
+	if err != nil {
+		return err
+	}
+
+	env.JSON = string(varsJSON)
+	envStr := string(envJSON)
+	env.ENV = &envStr
+
+	existing := make(map[EnvironmentSecretType]map[string]EnvironmentSecret)
+	for _, s := range env.Secrets {
+		if existing[s.Type] == nil {
+			existing[s.Type] = make(map[string]EnvironmentSecret)
+		}
+		existing[s.Type][s.Name] = s
+	}
+
+	var secrets []EnvironmentSecret
+
+	sections := []struct {
+		Type    EnvironmentSecretType
+		Secrets map[string]*string
+	}{
+		{EnvironmentSecretVar, f.Secrets.Vars},
+		{EnvironmentSecretEnv, f.Secrets.Env},
+	}
+
+	for _, section := range sections {
+		names := make([]string, 0, len(section.Secrets))
+		for name := range section.Secrets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			value := section.Secrets[name]
+			old, ok := existing[section.Type][name]
+			delete(existing[section.Type], name)
+
+			switch {
+			case ok && value != nil:
+				secrets = append(secrets, EnvironmentSecret{
+					ID:        old.ID,
+					Type:      section.Type,
+					Name:      name,
+					Secret:    *value,
+					Operation: EnvironmentSecretUpdate,
+				})
+			case !ok && value == nil:
+				return &ValidationError{fmt.Sprintf("Secret %s is new and must have a value", name)}
+			case !ok:
+				secrets = append(secrets, EnvironmentSecret{
+					Type:      section.Type,
+					Name:      name,
+					Secret:    *value,
+					Operation: EnvironmentSecretCreate,
+				})
+			}
+		}
+	}
+
+	for _, secretType := range []EnvironmentSecretType{EnvironmentSecretVar, EnvironmentSecretEnv} {
+		for _, old := range existing[secretType] {
+			secrets = append(secrets, EnvironmentSecret{
+				ID:        old.ID,
+				Type:      old.Type,
+				Name:      old.Name,
+				Operation: EnvironmentSecretDelete,
+			})
+		}
+	}
+
+	env.Secrets = secrets
+
+	return nil
+}
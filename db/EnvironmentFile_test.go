@@ -0,0 +1,108 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnvironmentFile(t *testing.T) {
+	file, err := ParseEnvironmentFile([]byte(`
+kind: vars
+data:
+  region: us-east-1
+  replicas: 3
+  tags:
+    team: ops
+---
+kind: env
+data:
+  AWS_REGION: us-east-1
+  PORT: 8080
+---
+kind: secrets
+data:
+  vars:
+    db_password: ~
+  env:
+    AWS_SECRET_ACCESS_KEY: new
+`))
+	require.NoError(t, err)
+
+	oldEnv := `{"OLD": "1"}`
+	env := Environment{
+		JSON: `{"old": 1}`,
+		ENV:  &oldEnv,
+		Secrets: []EnvironmentSecret{
+			{ID: 1, Type: EnvironmentSecretVar, Name: "db_password"},
+			{ID: 2, Type: EnvironmentSecretEnv, Name: "AWS_SECRET_ACCESS_KEY"},
+			{ID: 3, Type: EnvironmentSecretEnv, Name: "GITHUB_TOKEN"},
+		},
+	}
+
+	require.NoError(t, file.ApplyTo(&env))
+
+	assert.JSONEq(t, `{"region": "us-east-1", "replicas": 3, "tags": {"team": "ops"}}`, env.JSON)
+	assert.JSONEq(t, `{"AWS_REGION": "us-east-1", "PORT": "8080"}`, *env.ENV)
+	assert.Equal(t, []EnvironmentSecret{
+		{ID: 2, Type: EnvironmentSecretEnv, Name: "AWS_SECRET_ACCESS_KEY", Secret: "new", Operation: EnvironmentSecretUpdate},
+		{ID: 3, Type: EnvironmentSecretEnv, Name: "GITHUB_TOKEN", Operation: EnvironmentSecretDelete},
+	}, env.Secrets)
+}
+
+func TestParseEnvironmentFile_Conflicts(t *testing.T) {
+	_, err := ParseEnvironmentFile([]byte(`
+kind: vars
+data:
+  db_password: plain
+---
+kind: secrets
+data:
+  vars:
+    db_password: secret
+`))
+	assert.Error(t, err)
+
+	_, err = ParseEnvironmentFile([]byte(`
+kind: env
+data:
+  A: 1
+---
+kind: env
+data:
+  B: 2
+`))
+	assert.Error(t, err)
+
+	_, err = ParseEnvironmentFile([]byte(`
+kind: extra_vars
+data:
+  a: 1
+`))
+	assert.Error(t, err)
+}
+
+func TestEnvironmentFile_Marshal(t *testing.T) {
+	envVars := `{"AWS_REGION": "us-east-1"}`
+	file, err := NewEnvironmentFile(Environment{
+		JSON: `{"region": "us-east-1"}`,
+		ENV:  &envVars,
+		Secrets: []EnvironmentSecret{
+			{ID: 1, Type: EnvironmentSecretVar, Name: "db_password", Secret: "p4ssw0rd"},
+		},
+	})
+	require.NoError(t, err)
+
+	data, err := file.Marshal()
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(data), "p4ssw0rd")
+
+	parsed, err := ParseEnvironmentFile(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]any{"region": "us-east-1"}, parsed.Vars)
+	assert.Equal(t, map[string]string{"AWS_REGION": "us-east-1"}, parsed.Env)
+	assert.Equal(t, map[string]*string{"db_password": nil}, parsed.Secrets.Vars)
+}
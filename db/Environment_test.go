@@ -0,0 +1,72 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvironment_MergeProjectDefaults(t *testing.T) {
+	envVars := `{"REGION": "eu-west-1"}`
+	env := Environment{
+		JSON: `{"app_version": "2.0"}`,
+		ENV:  &envVars,
+		Secrets: []EnvironmentSecret{
+			{Type: EnvironmentSecretEnv, Name: "TOKEN", Secret: "template"},
+		},
+	}
+
+	defaultEnvVars := `{"REGION": "us-east-1", "LOG_LEVEL": "info", "TOKEN": "plain"}`
+	defaults := Environment{
+		JSON: `{"app_version": "1.0", "company": "acme"}`,
+		ENV:  &defaultEnvVars,
+		Secrets: []EnvironmentSecret{
+			{Type: EnvironmentSecretEnv, Name: "TOKEN", Secret: "default"},
+			{Type: EnvironmentSecretVar, Name: "db_password", Secret: "secret"},
+		},
+	}
+
+	err := env.MergeProjectDefaults(defaults)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{"app_version": "2.0", "company": "acme"}`, env.JSON)
+	assert.JSONEq(t, `{"REGION": "eu-west-1", "LOG_LEVEL": "info"}`, *env.ENV)
+	assert.Equal(t, []EnvironmentSecret{
+		{Type: EnvironmentSecretEnv, Name: "TOKEN", Secret: "template"},
+		{Type: EnvironmentSecretVar, Name: "db_password", Secret: "secret"},
+	}, env.Secrets)
+}
+
+func TestEnvironment_MergeProjectDefaultsToEmpty(t *testing.T) {
+	env := Environment{}
+
+	err := env.MergeProjectDefaults(Environment{JSON: `{"company": "acme"}`})
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{"company": "acme"}`, env.JSON)
+	assert.JSONEq(t, `{}`, *env.ENV)
+}
+
+func TestEnvironment_ValidateSchema(t *testing.T) {
+	schema := `{"type": "object", "required": ["region"], "properties": {"region": {"type": "string"}, "replicas": {"type": "integer"}}}`
+
+	env := Environment{
+		Name:       "prod",
+		JSON:       `{"replicas": 3}`,
+		JSONSchema: &schema,
+	}
+
+	// required variables can be passed by the task
+	require.NoError(t, env.Validate())
+
+	env.JSON = `{"replicas": "three"}`
+	err := env.Validate()
+	require.Error(t, err)
+	assert.Equal(t, "Extra variables do not match the schema: /replicas: expected integer, got string", err.Error())
+
+	invalid := `{"type": "text"}`
+	env.JSON = `{}`
+	env.JSONSchema = &invalid
+	assert.Error(t, env.Validate())
+}
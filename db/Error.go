@@ -0,0 +1,113 @@
+package db
+
+import "net/http"
+
+// ErrorCode is the machine-readable code of the error returned by the API.
+// Clients use it to react to the error and to localize the message.
+type ErrorCode string
+
+const (
+	ErrorCodeBadRequest           ErrorCode = "bad_request"
+	ErrorCodeUnauthorized         ErrorCode = "unauthorized"
+	ErrorCodeForbidden            ErrorCode = "forbidden"
+	ErrorCodeNotFound             ErrorCode = "not_found"
+	ErrorCodeConflict             ErrorCode = "conflict"
+	ErrorCodeTooManyRequests      ErrorCode = "too_many_requests"
+	ErrorCodeInternal             ErrorCode = "internal_error"
+	ErrorCodeValidationFailed     ErrorCode = "validation_failed"
+	ErrorCodeFieldInvalid         ErrorCode = "field_invalid"
+	ErrorCodeObjectInUse          ErrorCode = "object_in_use"
+	ErrorCodeProjectArchived      ErrorCode = "project_archived"
+	ErrorCodeQuotaExceeded        ErrorCode = "quota_exceeded"
+	ErrorCodeSubscriptionRequired ErrorCode = "subscription_required"
+	// ErrorCodeTemplateReferenceMissing is returned if the object referred by the template
+	// does not exist in the project, e.g. the inventory or the repository.
+	ErrorCodeTemplateReferenceMissing ErrorCode = "template_reference_missing"
+	// ErrorCodeKeyDecryptFailed is returned if the secret of the access key can not be decrypted.
+	ErrorCodeKeyDecryptFailed ErrorCode = "key_decrypt_failed"
+	// ErrorCodePasswordPolicy is returned if the new password does not match the password policy.
+	ErrorCodePasswordPolicy ErrorCode = "password_policy_violation"
+	// ErrorCodePasswordExpired is returned if the password of the user must be changed
+	// before using the API.
+	ErrorCodePasswordExpired ErrorCode = "password_expired"
+	// ErrorCodeObjectUsedByRunningTask is returned if the object can not be changed
+	// while running tasks use it.
+	ErrorCodeObjectUsedByRunningTask ErrorCode = "object_used_by_running_task"
+)
+
+// HTTPStatus returns the HTTP status of the response with the error of the code.
+func (c ErrorCode) HTTPStatus() int {
+	switch c {
+	case ErrorCodeUnauthorized:
+		return http.StatusUnauthorized
+	case ErrorCodeForbidden, ErrorCodeQuotaExceeded, ErrorCodeSubscriptionRequired, ErrorCodePasswordExpired:
+		return http.StatusForbidden
+	case ErrorCodeNotFound:
+		return http.StatusNotFound
+	case ErrorCodeConflict, ErrorCodeProjectArchived, ErrorCodeObjectUsedByRunningTask:
+		return http.StatusConflict
+	case ErrorCodeTooManyRequests:
+		return http.StatusTooManyRequests
+	case ErrorCodeInternal, ErrorCodeKeyDecryptFailed:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// GetErrorCodeOfStatus returns the code of the error written with the HTTP status only.
+func GetErrorCodeOfStatus(status int) ErrorCode {
+	switch status {
+	case http.StatusUnauthorized:
+		return ErrorCodeUnauthorized
+	case http.StatusForbidden:
+		return ErrorCodeForbidden
+	case http.StatusNotFound:
+		return ErrorCodeNotFound
+	case http.StatusConflict:
+		return ErrorCodeConflict
+	case http.StatusTooManyRequests:
+		return ErrorCodeTooManyRequests
+	}
+
+	if status >= http.StatusInternalServerError {
+		return ErrorCodeInternal
+	}
+
+	return ErrorCodeBadRequest
+}
+
+// CodedError is the error with the code, the invalid field and the hint how to fix it,
+// which are returned by the API with the message.
+type CodedError struct {
+	Code ErrorCode
+	// Field is the JSON name of the field which caused the error, if any.
+	Field string
+	// Hint describes how to fix the error.
+	Hint    string
+	Message string
+	Err     error
+}
+
+func (e *CodedError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+
+	return string(e.Code)
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+// ErrKeyDecryptFailed is returned if the access key was encrypted with other encryption key.
+var ErrKeyDecryptFailed = &CodedError{
+	Code:    ErrorCodeKeyDecryptFailed,
+	Message: "cannot decrypt access key, perhaps encryption key was changed",
+	Hint:    "Check that access_key_encryption of the config is the key the access keys were encrypted with",
+}
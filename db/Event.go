@@ -13,6 +13,7 @@ type Event struct {
 
 	ObjectID    *int             `db:"object_id" json:"object_id"`
 	ObjectType  *EventObjectType `db:"object_type" json:"object_type"`
+	Action      string           `db:"action" json:"action,omitempty"`
 	Description *string          `db:"description" json:"description"`
 	Created     time.Time        `db:"created" json:"created"`
 
@@ -34,9 +35,36 @@ const (
 	EventTemplate                EventObjectType = "template"
 	EventUser                    EventObjectType = "user"
 	EventView                    EventObjectType = "view"
+	EventDeploymentEnvironment   EventObjectType = "deployment_environment"
+	EventMaskingRule             EventObjectType = "masking_rule"
+	EventHostExclusion           EventObjectType = "host_exclusion"
 	EventIntegration             EventObjectType = "integration"
 	EventIntegrationExtractValue EventObjectType = "integrationextractvalue"
 	EventIntegrationMatcher      EventObjectType = "integrationmatcher"
+	EventAdhocCommand            EventObjectType = "adhoc_command"
+	EventServiceAccount          EventObjectType = "service_account"
+	EventTemplateUpload          EventObjectType = "template_upload"
+	EventAnnouncement            EventObjectType = "announcement"
+)
+
+// Actions of task events. Actions of other events are defined by helpers.EventLogType.
+const (
+	EventActionTaskQueue  = "queue"
+	EventActionTaskStart  = "start"
+	EventActionTaskFinish = "finish"
+	// EventActionTaskInteractiveBecome records that the task is launched
+	// with the become password entered at the launch.
+	EventActionTaskInteractiveBecome = "interactive_become"
+	// EventActionTaskDequeue records that the task left the queue to be started.
+	EventActionTaskDequeue = "dequeue"
+	// EventActionTaskAssign records that the task is assigned to the remote runner.
+	EventActionTaskAssign = "assign"
+)
+
+// Actions of schedule events recorded by the scheduler.
+const (
+	EventActionScheduleFire = "fire"
+	EventActionScheduleSkip = "skip"
 )
 
 func FillEvents(d Store, events []Event) (err error) {
@@ -0,0 +1,177 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FeatureFlag enables or disables the feature per instance or per project
+// without rebuilding. Values of the flags are stored in options:
+// features.<name>.instance and features.<name>.projects.<project_id>.
+type FeatureFlag string
+
+const (
+	// FeatureTaskOutputStorage moves the output of finished tasks to the object storage
+	// if the storage is configured.
+	FeatureTaskOutputStorage FeatureFlag = "task_output_storage"
+)
+
+const featureOptionPrefix = "features"
+
+type featureFlagDefinition struct {
+	name        FeatureFlag
+	description string
+	// enabled is the value of the flag if it is not set for the instance and the project.
+	enabled bool
+}
+
+var featureFlags = []featureFlagDefinition{
+	{
+		name:        FeatureTaskOutputStorage,
+		description: "Move output of finished tasks to the object storage",
+		enabled:     true,
+	},
+}
+
+func getFeatureFlagDefinition(flag FeatureFlag) (featureFlagDefinition, bool) {
+	for _, def := range featureFlags {
+		if def.name == flag {
+			return def, true
+		}
+	}
+	return featureFlagDefinition{}, false
+}
+
+// FeatureFlagState describes the flag and its values for the admin API.
+type FeatureFlagState struct {
+	Name        FeatureFlag `json:"name"`
+	Description string      `json:"description"`
+	// Default is the value of the flag if it is not set for the instance.
+	Default bool `json:"default"`
+	// Enabled is the value of the flag for the instance, nil if it is not set.
+	Enabled *bool `json:"enabled"`
+	// Projects contains values of the flag overridden for the projects.
+	Projects map[int]bool `json:"projects"`
+}
+
+// IsEnabledFor returns the value of the flag for the project, the project value
+// overrides the instance value. projectID is nil for features of the instance.
+func (s *FeatureFlagState) IsEnabledFor(projectID *int) bool {
+	if projectID != nil {
+		if enabled, ok := s.Projects[*projectID]; ok {
+			return enabled
+		}
+	}
+
+	if s.Enabled != nil {
+		return *s.Enabled
+	}
+
+	return s.Default
+}
+
+func getFeatureOptionKey(flag FeatureFlag, projectID *int) string {
+	if projectID == nil {
+		return fmt.Sprintf("%s.%s.instance", featureOptionPrefix, flag)
+	}
+	return fmt.Sprintf("%s.%s.projects.%d", featureOptionPrefix, flag, *projectID)
+}
+
+// ValidateFeatureFlag returns ValidationError if the flag is unknown.
+func ValidateFeatureFlag(flag FeatureFlag) error {
+	if _, ok := getFeatureFlagDefinition(flag); !ok {
+		return &ValidationError{"unknown feature flag " + string(flag)}
+	}
+	return nil
+}
+
+// GetFeatureFlags returns all known flags with their values.
+func GetFeatureFlags(store Store) ([]FeatureFlagState, error) {
+	options, err := store.GetOptions(RetrieveQueryParams{Filter: featureOptionPrefix})
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]FeatureFlagState, 0, len(featureFlags))
+
+	for _, def := range featureFlags {
+		state := FeatureFlagState{
+			Name:        def.name,
+			Description: def.description,
+			Default:     def.enabled,
+			Projects:    make(map[int]bool),
+		}
+
+		instanceKey := getFeatureOptionKey(def.name, nil)
+		projectPrefix := fmt.Sprintf("%s.%s.projects.", featureOptionPrefix, def.name)
+
+		for key, value := range options {
+			enabled := value == "true"
+
+			switch {
+			case key == instanceKey:
+				state.Enabled = &enabled
+			case strings.HasPrefix(key, projectPrefix):
+				projectID, convErr := strconv.Atoi(strings.TrimPrefix(key, projectPrefix))
+				if convErr == nil {
+					state.Projects[projectID] = enabled
+				}
+			}
+		}
+
+		states = append(states, state)
+	}
+
+	return states, nil
+}
+
+// IsFeatureEnabled returns true if the feature is enabled for the project.
+// projectID is nil for features of the instance.
+func IsFeatureEnabled(store Store, flag FeatureFlag, projectID *int) (bool, error) {
+	def, ok := getFeatureFlagDefinition(flag)
+	if !ok {
+		return false, ValidateFeatureFlag(flag)
+	}
+
+	if projectID != nil {
+		value, err := store.GetOption(getFeatureOptionKey(flag, projectID))
+		if err != nil {
+			return false, err
+		}
+		if value != "" {
+			return value == "true", nil
+		}
+	}
+
+	value, err := store.GetOption(getFeatureOptionKey(flag, nil))
+	if err != nil {
+		return false, err
+	}
+	if value != "" {
+		return value == "true", nil
+	}
+
+	return def.enabled, nil
+}
+
+// SetFeatureFlag sets the value of the flag for the instance or the project.
+// Nil value removes the flag, so the instance value or the default is used.
+func SetFeatureFlag(store Store, flag FeatureFlag, projectID *int, enabled *bool) error {
+	if err := ValidateFeatureFlag(flag); err != nil {
+		return err
+	}
+
+	key := getFeatureOptionKey(flag, projectID)
+
+	if enabled == nil {
+		err := store.DeleteOption(key)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return store.SetOption(key, strconv.FormatBool(*enabled))
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"strings"
+	"time"
+)
+
+// HostExclusion is the host temporarily excluded from the runs of the project,
+// e.g. the host under maintenance. The host is excluded by the limit of ansible-playbook.
+type HostExclusion struct {
+	ID        int    `db:"id" json:"id" backup:"-"`
+	ProjectID int    `db:"project_id" json:"project_id" backup:"-"`
+	Host      string `db:"host" json:"host"`
+	Reason    string `db:"reason" json:"reason"`
+	// ExpiresAt is the time when the host returns to the runs. Nil means
+	// the host is excluded until the exclusion is removed.
+	ExpiresAt *time.Time `db:"expires_at" json:"expires_at"`
+	Created   time.Time  `db:"created" json:"created"`
+	UserID    *int       `db:"user_id" json:"user_id"`
+}
+
+func (e *HostExclusion) IsExpired(now time.Time) bool {
+	return e.ExpiresAt != nil && !e.ExpiresAt.After(now)
+}
+
+func (e *HostExclusion) Validate() error {
+	e.Host = strings.TrimSpace(e.Host)
+
+	if e.Host == "" {
+		return &ValidationError{"host can not be empty"}
+	}
+
+	if strings.ContainsAny(e.Host, ",: \t!&") {
+		return &ValidationError{"host must be the name or the pattern of a single host"}
+	}
+
+	if len(e.Reason) > 1000 {
+		return &ValidationError{"reason is too long"}
+	}
+
+	return nil
+}
+
+// ExcludeHosts adds the hosts to the limit of ansible-playbook as exclusion patterns,
+// e.g. "web,!web3". Empty limit means all hosts.
+func ExcludeHosts(limit string, hosts []string) string {
+	if len(hosts) == 0 {
+		return limit
+	}
+
+	patterns := []string{limit}
+	if limit == "" {
+		patterns[0] = "all"
+	}
+
+	for _, host := range hosts {
+		patterns = append(patterns, "!"+host)
+	}
+
+	return strings.Join(patterns, ",")
+}
+
+// GetActiveHostExclusions returns the exclusions of the project which are not expired.
+func GetActiveHostExclusions(store Store, projectID int, now time.Time) ([]HostExclusion, error) {
+	exclusions, err := store.GetHostExclusions(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make([]HostExclusion, 0, len(exclusions))
+
+	for _, e := range exclusions {
+		if !e.IsExpired(now) {
+			active = append(active, e)
+		}
+	}
+
+	return active, nil
+}
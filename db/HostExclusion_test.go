@@ -0,0 +1,40 @@
+package db
+
+import (
+	"testing"
+)
+
+func TestExcludeHosts(t *testing.T) {
+	cases := []struct {
+		limit string
+		hosts []string
+		want  string
+	}{
+		{"", nil, ""},
+		{"web", nil, "web"},
+		{"", []string{"web3"}, "all,!web3"},
+		{"web", []string{"web3", "db*"}, "web,!web3,!db*"},
+	}
+
+	for _, c := range cases {
+		if got := ExcludeHosts(c.limit, c.hosts); got != c.want {
+			t.Errorf("limit %q, hosts %v: expected %q, got %q", c.limit, c.hosts, c.want, got)
+		}
+	}
+}
+
+func TestHostExclusionValidate(t *testing.T) {
+	for host, valid := range map[string]bool{
+		"web1.example.com": true,
+		" db* ":            true,
+		"":                 false,
+		"web1,web2":        false,
+		"!web1":            false,
+		"web:db":           false,
+	} {
+		exclusion := HostExclusion{Host: host}
+		if err := exclusion.Validate(); (err == nil) != valid {
+			t.Errorf("host %q: expected valid=%v, got %v", host, valid, err)
+		}
+	}
+}
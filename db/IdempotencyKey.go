@@ -0,0 +1,30 @@
+package db
+
+import "time"
+
+// IdempotencyKeyTTL is the time during which the request repeated with the same
+// Idempotency-Key header gets the stored response instead of being processed again.
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotencyKeyMaxResponse is the maximum size of the stored response in bytes.
+// Larger responses are not stored and their requests can be repeated.
+const IdempotencyKeyMaxResponse = 65535
+
+// IdempotencyKey is the Idempotency-Key of the request and the response to the request.
+type IdempotencyKey struct {
+	// ID is the hash of the key and the client which sent the request,
+	// so different clients can use the same keys.
+	ID string `db:"id" json:"id"`
+	// RequestHash is the hash of the method, the path and the body of the request.
+	// The key can not be reused for another request.
+	RequestHash string `db:"request_hash" json:"request_hash"`
+	// Status is the status code of the response, it is zero while the request is processed.
+	Status   int       `db:"status" json:"status"`
+	Response string    `db:"response" json:"response"`
+	Created  time.Time `db:"created" json:"created"`
+}
+
+// IsExpired returns true if the key can be used for a new request.
+func (k *IdempotencyKey) IsExpired(now time.Time) bool {
+	return k.Created.Add(IdempotencyKeyTTL).Before(now)
+}
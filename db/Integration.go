@@ -3,6 +3,7 @@ package db
 import (
 	"strconv"
 	"strings"
+	"time"
 )
 
 type IntegrationAuthMethod string
@@ -14,6 +15,15 @@ const (
 	IntegrationAuthHmac   = "hmac"
 )
 
+type IntegrationCommitStatus string
+
+const (
+	IntegrationCommitStatusNone   IntegrationCommitStatus = ""
+	IntegrationCommitStatusGitHub IntegrationCommitStatus = "github"
+	IntegrationCommitStatusGitLab IntegrationCommitStatus = "gitlab"
+	IntegrationCommitStatusGitea  IntegrationCommitStatus = "gitea"
+)
+
 type IntegrationMatchType string
 
 const (
@@ -82,12 +92,49 @@ type Integration struct {
 	AuthSecret   AccessKey             `db:"-" json:"-" backup:"-"`
 	Searchable   bool                  `db:"searchable" json:"searchable"`
 	TaskParams   MapStringAnyField     `db:"task_params" json:"task_params"`
+	// CommitStatus is the forge to which statuses of the commit are reported.
+	// Reporting is disabled if it is empty.
+	CommitStatus IntegrationCommitStatus `db:"commit_status" json:"commit_status"`
+
+	// PreviousAuthSecretID is the secret replaced by the rotation. It is accepted
+	// along with the current secret until PreviousAuthSecretExpires.
+	PreviousAuthSecretID      *int       `db:"previous_auth_secret_id" json:"previous_auth_secret_id" backup:"-"`
+	PreviousAuthSecretExpires *time.Time `db:"previous_auth_secret_expires" json:"previous_auth_secret_expires" backup:"-"`
+	PreviousAuthSecret        *AccessKey `db:"-" json:"-" backup:"-"`
+}
+
+// HasPreviousSecret returns true if the secret replaced by the rotation is still accepted.
+func (env *Integration) HasPreviousSecret() bool {
+	return env.PreviousAuthSecretID != nil &&
+		env.PreviousAuthSecretExpires != nil &&
+		time.Now().Before(*env.PreviousAuthSecretExpires)
+}
+
+// RotateSecret makes the new secret current and keeps the current one valid during the grace period.
+func (env *Integration) RotateSecret(newSecretID int, gracePeriod time.Duration) {
+	env.PreviousAuthSecretID = nil
+	env.PreviousAuthSecretExpires = nil
+
+	if env.AuthSecretID != nil && gracePeriod > 0 {
+		expires := time.Now().Add(gracePeriod).UTC()
+		env.PreviousAuthSecretID = env.AuthSecretID
+		env.PreviousAuthSecretExpires = &expires
+	}
+
+	env.AuthSecretID = &newSecretID
 }
 
 func (env *Integration) Validate() error {
 	if env.Name == "" {
 		return &ValidationError{"No Name set for integration"}
 	}
+
+	switch env.CommitStatus {
+	case IntegrationCommitStatusNone, IntegrationCommitStatusGitHub, IntegrationCommitStatusGitLab, IntegrationCommitStatusGitea:
+	default:
+		return &ValidationError{"Invalid commit status provider"}
+	}
+
 	return nil
 }
 
@@ -193,6 +240,24 @@ func FillIntegration(d Store, inventory *Integration) (err error) {
 	}
 
 	err = inventory.AuthSecret.DeserializeSecret()
+	if err != nil {
+		return
+	}
+
+	if inventory.HasPreviousSecret() {
+		var previous AccessKey
+		previous, err = d.GetAccessKey(inventory.ProjectID, *inventory.PreviousAuthSecretID)
+		if err != nil {
+			return
+		}
+
+		err = previous.DeserializeSecret()
+		if err != nil {
+			return
+		}
+
+		inventory.PreviousAuthSecret = &previous
+	}
 
 	return
 }
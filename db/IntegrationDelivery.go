@@ -0,0 +1,32 @@
+package db
+
+import "time"
+
+type IntegrationDeliveryStatus string
+
+const (
+	IntegrationDeliveryUnauthorized IntegrationDeliveryStatus = "unauthorized"
+	IntegrationDeliveryNotMatched   IntegrationDeliveryStatus = "not_matched"
+	IntegrationDeliveryTriggered    IntegrationDeliveryStatus = "triggered"
+	IntegrationDeliveryFailed       IntegrationDeliveryStatus = "failed"
+)
+
+// IntegrationDeliveryMaxPayload is the maximum size of the stored payload in bytes.
+const IntegrationDeliveryMaxPayload = 65535
+
+// IntegrationDeliveryMaxCount is the number of deliveries kept for every integration.
+const IntegrationDeliveryMaxCount = 100
+
+// IntegrationDelivery is an inbound request received by the integration.
+// Secrets are redacted from Headers and Payload before the delivery is stored.
+type IntegrationDelivery struct {
+	ID            int                       `db:"id" json:"id"`
+	ProjectID     int                       `db:"project_id" json:"project_id"`
+	IntegrationID int                       `db:"integration_id" json:"integration_id"`
+	Created       time.Time                 `db:"created" json:"created"`
+	Headers       string                    `db:"headers" json:"headers"`
+	Payload       string                    `db:"payload" json:"payload"`
+	Status        IntegrationDeliveryStatus `db:"status" json:"status"`
+	Message       string                    `db:"message" json:"message"`
+	TaskID        *int                      `db:"task_id" json:"task_id"`
+}
@@ -1,5 +1,14 @@
 package db
 
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
 type InventoryType string
 
 const (
@@ -26,6 +35,9 @@ type Inventory struct {
 	BecomeKeyID *int      `db:"become_key_id" json:"become_key_id" backup:"-"`
 	BecomeKey   AccessKey `db:"-" json:"-" backup:"-"`
 
+	// SSHOptions are SSH connection options of the hosts used by Ansible.
+	SSHOptions *InventorySSHOptions `db:"ssh_options" json:"ssh_options"`
+
 	// static/file
 	Type InventoryType `db:"type" json:"type"`
 
@@ -39,6 +51,176 @@ type Inventory struct {
 	// If null than inventory will be got from template repository.
 	RepositoryID *int        `db:"repository_id" json:"repository_id" backup:"-"`
 	Repository   *Repository `db:"-" json:"-" backup:"-"`
+
+	// Secrets is a field which used to update secret variables of the inventory.
+	// Values of the secrets are returned only to the task which uses the inventory.
+	Secrets []InventorySecret `db:"-" json:"secrets" backup:"-"`
+}
+
+// InventorySecret is the secret variable of the static inventory. It is stored encrypted as the
+// access key owned by the inventory and is referenced in the inventory as ${secrets.name}.
+// References are replaced by values only in the inventory file rendered for the task.
+type InventorySecret struct {
+	ID        int                        `json:"id"`
+	Name      string                     `json:"name"`
+	Secret    string                     `json:"secret"`
+	Operation EnvironmentSecretOperation `json:"operation"`
+}
+
+var inventorySecretNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// inventorySecretRefRegexp matches references to secrets in the inventory, e.g. "${secrets.db_password}".
+var inventorySecretRefRegexp = regexp.MustCompile(`\$\{secrets\.([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+func (s *InventorySecret) Validate() error {
+	if !inventorySecretNameRegexp.MatchString(s.Name) {
+		return &ValidationError{"name of the inventory secret must be a valid variable name"}
+	}
+
+	if s.Operation == EnvironmentSecretCreate && s.Secret == "" {
+		return &ValidationError{"missing secret"}
+	}
+
+	return nil
+}
+
+type StrictHostKeyChecking string
+
+const (
+	StrictHostKeyCheckingDefault   StrictHostKeyChecking = ""
+	StrictHostKeyCheckingYes       StrictHostKeyChecking = "yes"
+	StrictHostKeyCheckingNo        StrictHostKeyChecking = "no"
+	StrictHostKeyCheckingAcceptNew StrictHostKeyChecking = "accept-new"
+)
+
+var controlPersistRegexp = regexp.MustCompile(`^(yes|no|[0-9]+[smhdw]?)$`)
+
+// InventorySSHOptions are rendered into the ssh_config file used by Ansible
+// to connect to the hosts of the inventory. Empty fields keep defaults of Ansible.
+// Host variables of the inventory, e.g. ansible_port or ansible_user, take precedence.
+type InventorySSHOptions struct {
+	Port int `json:"port,omitempty"`
+	// User is the remote user if neither the inventory key nor the host defines it.
+	User string `json:"user,omitempty"`
+	// ControlPersist is how long the master connection stays open, e.g. 60s or no.
+	ControlPersist        string                `json:"control_persist,omitempty"`
+	StrictHostKeyChecking StrictHostKeyChecking `json:"strict_host_key_checking,omitempty"`
+	ProxyCommand          string                `json:"proxy_command,omitempty"`
+}
+
+// IsEmpty returns true if no option is set.
+func (o *InventorySSHOptions) IsEmpty() bool {
+	return o == nil || *o == InventorySSHOptions{}
+}
+
+func (o *InventorySSHOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+
+	o.User = strings.TrimSpace(o.User)
+	o.ControlPersist = strings.TrimSpace(o.ControlPersist)
+	o.ProxyCommand = strings.TrimSpace(o.ProxyCommand)
+
+	if o.Port < 0 || o.Port > 65535 {
+		return &ValidationError{"SSH port must be between 1 and 65535"}
+	}
+
+	if strings.ContainsAny(o.User, " \t\r\n") {
+		return &ValidationError{"SSH user can not contain spaces"}
+	}
+
+	if o.ControlPersist != "" && !controlPersistRegexp.MatchString(o.ControlPersist) {
+		return &ValidationError{"ControlPersist must be yes, no or time, e.g. 60s"}
+	}
+
+	switch o.StrictHostKeyChecking {
+	case StrictHostKeyCheckingDefault, StrictHostKeyCheckingYes, StrictHostKeyCheckingNo, StrictHostKeyCheckingAcceptNew:
+	default:
+		return &ValidationError{"StrictHostKeyChecking must be yes, no or accept-new"}
+	}
+
+	if strings.ContainsAny(o.ProxyCommand, "\r\n") {
+		return &ValidationError{"SSH proxy command must be a single line"}
+	}
+
+	return nil
+}
+
+func (o *InventorySSHOptions) Scan(value interface{}) error {
+	if value == nil {
+		*o = InventorySSHOptions{}
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, o)
+	case string:
+		return json.Unmarshal([]byte(v), o)
+	default:
+		return errors.New("unsupported type for InventorySSHOptions")
+	}
+}
+
+func (o *InventorySSHOptions) Value() (driver.Value, error) {
+	if o.IsEmpty() {
+		return nil, nil
+	}
+	return json.Marshal(o)
+}
+
+// FillInventorySecrets loads secrets of the inventory. Values of the secrets are decrypted
+// only if deserializeSecret is true, otherwise only names are filled.
+func FillInventorySecrets(store Store, inventory *Inventory, deserializeSecret bool) error {
+	keys, err := store.GetInventorySecrets(inventory.ProjectID, inventory.ID)
+	if err != nil {
+		return err
+	}
+
+	inventory.Secrets = make([]InventorySecret, 0, len(keys))
+
+	for _, k := range keys {
+		if deserializeSecret {
+			if err = k.DeserializeSecret(); err != nil {
+				return err
+			}
+		}
+
+		inventory.Secrets = append(inventory.Secrets, InventorySecret{
+			ID:     k.ID,
+			Name:   k.Name,
+			Secret: k.String,
+		})
+	}
+
+	return nil
+}
+
+// RenderSecrets returns the inventory in which references to secrets are replaced by
+// values of the secrets. Secrets must be filled with decrypted values.
+func (e Inventory) RenderSecrets() (string, error) {
+	values := make(map[string]string)
+	for _, s := range e.Secrets {
+		values[s.Name] = s.Secret
+	}
+
+	var missing []string
+
+	res := inventorySecretRefRegexp.ReplaceAllStringFunc(e.Inventory, func(ref string) string {
+		name := inventorySecretRefRegexp.FindStringSubmatch(ref)[1]
+		value, ok := values[name]
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("inventory secret %s not found", strings.Join(missing, ", "))
+	}
+
+	return res, nil
 }
 
 func (e Inventory) GetFilename() string {
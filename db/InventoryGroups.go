@@ -0,0 +1,147 @@
+package db
+
+import (
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// inventoryGroups is the structure of the static inventory: hosts and child groups of the groups.
+type inventoryGroups struct {
+	hosts    map[string][]string
+	children map[string][]string
+}
+
+func newInventoryGroups() *inventoryGroups {
+	return &inventoryGroups{
+		hosts:    make(map[string][]string),
+		children: make(map[string][]string),
+	}
+}
+
+// parseIniInventory reads groups of the INI inventory. Host patterns, e.g. web[01:03],
+// are not expanded.
+func parseIniInventory(content string) *inventoryGroups {
+	groups := newInventoryGroups()
+	group, section := "ungrouped", ""
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			group, section, _ = strings.Cut(strings.TrimSpace(line[1:len(line)-1]), ":")
+			continue
+		}
+
+		name := strings.Fields(line)[0]
+
+		switch section {
+		case "":
+			groups.hosts[group] = append(groups.hosts[group], name)
+		case "children":
+			groups.children[group] = append(groups.children[group], name)
+		}
+	}
+
+	return groups
+}
+
+type yamlInventoryGroup struct {
+	Hosts    map[string]any                `yaml:"hosts"`
+	Children map[string]yamlInventoryGroup `yaml:"children"`
+}
+
+func (groups *inventoryGroups) addYamlGroup(name string, group yamlInventoryGroup) {
+	for host := range group.Hosts {
+		groups.hosts[name] = append(groups.hosts[name], host)
+	}
+
+	for child, childGroup := range group.Children {
+		groups.children[name] = append(groups.children[name], child)
+		groups.addYamlGroup(child, childGroup)
+	}
+}
+
+// parseYamlInventory reads groups of the YAML inventory.
+func parseYamlInventory(content string) (*inventoryGroups, error) {
+	var root map[string]yamlInventoryGroup
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil {
+		return nil, err
+	}
+
+	groups := newInventoryGroups()
+	for name, group := range root {
+		groups.addYamlGroup(name, group)
+	}
+
+	return groups, nil
+}
+
+// collectHosts adds the group to the groups of its hosts and of the hosts of its child groups.
+func (groups *inventoryGroups) collectHosts(group string, name string, visited map[string]bool, res map[string]map[string]bool) {
+	if visited[name] {
+		return
+	}
+	visited[name] = true
+
+	for _, host := range groups.hosts[name] {
+		if res[host] == nil {
+			res[host] = make(map[string]bool)
+		}
+		res[host][group] = true
+	}
+
+	for _, child := range groups.children[name] {
+		groups.collectHosts(group, child, visited, res)
+	}
+}
+
+// GetInventoryHostGroups returns the groups of each host of the static inventory, sorted by name.
+// The implicit groups all and ungrouped are not returned. Groups of other types of inventories
+// are not known before ansible runs, so nil is returned for them.
+func GetInventoryHostGroups(inventory Inventory) (map[string][]string, error) {
+	var groups *inventoryGroups
+
+	switch inventory.Type {
+	case InventoryStatic:
+		groups = parseIniInventory(inventory.Inventory)
+	case InventoryStaticYaml:
+		var err error
+		if groups, err = parseYamlInventory(inventory.Inventory); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, nil
+	}
+
+	names := make(map[string]bool)
+	for name := range groups.hosts {
+		names[name] = true
+	}
+	for name := range groups.children {
+		names[name] = true
+	}
+
+	hostGroups := make(map[string]map[string]bool)
+	for name := range names {
+		if name == "all" || name == "ungrouped" {
+			continue
+		}
+		groups.collectHosts(name, name, make(map[string]bool), hostGroups)
+	}
+
+	res := make(map[string][]string)
+	for host, set := range hostGroups {
+		for name := range set {
+			res[host] = append(res[host], name)
+		}
+		sort.Strings(res[host])
+	}
+
+	return res, nil
+}
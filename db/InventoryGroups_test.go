@@ -0,0 +1,69 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetInventoryHostGroups_Ini(t *testing.T) {
+	groups, err := GetInventoryHostGroups(Inventory{
+		Type: InventoryStatic,
+		Inventory: `
+bastion ansible_host=10.0.0.1
+
+[db]
+db-01
+db-03 ansible_port=2222
+
+[web]
+web-01
+
+[prod:children]
+db
+web
+
+[prod:vars]
+env=prod
+`,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{
+		"db-01":  {"db", "prod"},
+		"db-03":  {"db", "prod"},
+		"web-01": {"prod", "web"},
+	}, groups)
+}
+
+func TestGetInventoryHostGroups_Yaml(t *testing.T) {
+	groups, err := GetInventoryHostGroups(Inventory{
+		Type: InventoryStaticYaml,
+		Inventory: `
+all:
+  hosts:
+    bastion:
+  children:
+    prod:
+      children:
+        db:
+          hosts:
+            db-03:
+              ansible_port: 2222
+        web:
+          hosts:
+            web-01:
+`,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{
+		"db-03":  {"db", "prod"},
+		"web-01": {"prod", "web"},
+	}, groups)
+
+	groups, err = GetInventoryHostGroups(Inventory{Type: InventoryFile, Inventory: "hosts.yml"})
+	require.NoError(t, err)
+	assert.Nil(t, groups)
+}
@@ -0,0 +1,44 @@
+package db
+
+import "testing"
+
+func TestInventory_RenderSecrets(t *testing.T) {
+	inv := Inventory{
+		Inventory: "[db]\ndb1 ansible_password=${secrets.db_password} token=${secrets.token}\n",
+		Secrets: []InventorySecret{
+			{Name: "db_password", Secret: "qwerty"},
+			{Name: "token", Secret: "$1"},
+		},
+	}
+
+	res, err := inv.RenderSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res != "[db]\ndb1 ansible_password=qwerty token=$1\n" {
+		t.Fatal("invalid rendered inventory: " + res)
+	}
+
+	inv.Inventory = "db1 ansible_password=${secrets.unknown}"
+	if _, err = inv.RenderSecrets(); err == nil {
+		t.Fatal("reference to unknown secret must fail")
+	}
+}
+
+func TestInventorySecret_Validate(t *testing.T) {
+	for _, secret := range []InventorySecret{
+		{Name: "db-password", Secret: "x"},
+		{Name: "", Secret: "x"},
+		{Name: "token", Operation: EnvironmentSecretCreate},
+	} {
+		if secret.Validate() == nil {
+			t.Fatal("secret must be invalid", secret.Name)
+		}
+	}
+
+	secret := InventorySecret{Name: "db_password", Operation: EnvironmentSecretUpdate}
+	if err := secret.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
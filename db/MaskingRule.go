@@ -0,0 +1,136 @@
+package db
+
+import (
+	"regexp"
+	"strings"
+)
+
+type MaskingRuleType string
+
+const (
+	// MaskingRuleKeyword masks all occurrences of the pattern.
+	MaskingRuleKeyword MaskingRuleType = "keyword"
+	// MaskingRuleRegex masks matches of the regular expression. If the expression
+	// contains groups only the groups are masked, e.g. `password=(\S+)`.
+	MaskingRuleRegex MaskingRuleType = "regex"
+)
+
+// MaskedValue replaces masked parts of the task output.
+const MaskedValue = "********"
+
+// MaskingRule describes values which are hidden in task output of the project.
+type MaskingRule struct {
+	ID        int             `db:"id" json:"id" backup:"-"`
+	ProjectID int             `db:"project_id" json:"project_id" backup:"-"`
+	Name      string          `db:"name" json:"name"`
+	Type      MaskingRuleType `db:"type" json:"type"`
+	Pattern   string          `db:"pattern" json:"pattern"`
+}
+
+func (rule *MaskingRule) compile() (*regexp.Regexp, error) {
+	switch rule.Type {
+	case MaskingRuleKeyword:
+		return regexp.Compile(regexp.QuoteMeta(rule.Pattern))
+	case MaskingRuleRegex:
+		return regexp.Compile(rule.Pattern)
+	default:
+		return nil, &ValidationError{"invalid masking rule type"}
+	}
+}
+
+func (rule *MaskingRule) Validate() error {
+	if rule.Name == "" {
+		return &ValidationError{"name can not be empty"}
+	}
+
+	if rule.Pattern == "" {
+		return &ValidationError{"pattern can not be empty"}
+	}
+
+	re, err := rule.compile()
+	if err != nil {
+		if _, ok := err.(*ValidationError); ok {
+			return err
+		}
+		return &ValidationError{"invalid regular expression: " + err.Error()}
+	}
+
+	if re.MatchString("") {
+		return &ValidationError{"pattern can not match empty string"}
+	}
+
+	return nil
+}
+
+// OutputMasker hides values matched by masking rules in task output.
+type OutputMasker struct {
+	expressions []*regexp.Regexp
+}
+
+// NewOutputMasker compiles the rules. Invalid rules are skipped.
+func NewOutputMasker(rules []MaskingRule) *OutputMasker {
+	masker := &OutputMasker{}
+
+	for _, rule := range rules {
+		if rule.Validate() != nil {
+			continue
+		}
+
+		re, _ := rule.compile()
+		masker.expressions = append(masker.expressions, re)
+	}
+
+	return masker
+}
+
+// AddValues masks all occurrences of the values, e.g. secrets used by the task.
+// Empty values are skipped.
+func (m *OutputMasker) AddValues(values ...string) {
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		m.expressions = append(m.expressions, regexp.MustCompile(regexp.QuoteMeta(value)))
+	}
+}
+
+// Mask replaces matched values in the output by MaskedValue.
+func (m *OutputMasker) Mask(output string) string {
+	if m == nil {
+		return output
+	}
+
+	for _, re := range m.expressions {
+		if re.NumSubexp() == 0 {
+			output = re.ReplaceAllLiteralString(output, MaskedValue)
+			continue
+		}
+
+		output = maskSubmatches(re, output)
+	}
+
+	return output
+}
+
+// maskSubmatches replaces matched groups of the expression by MaskedValue.
+func maskSubmatches(re *regexp.Regexp, output string) string {
+	var res strings.Builder
+	last := 0
+
+	for _, match := range re.FindAllStringSubmatchIndex(output, -1) {
+		for i := 2; i < len(match); i += 2 {
+			start, end := match[i], match[i+1]
+			// skip not matched and nested groups
+			if start < last || start == end {
+				continue
+			}
+
+			res.WriteString(output[last:start])
+			res.WriteString(MaskedValue)
+			last = end
+		}
+	}
+
+	res.WriteString(output[last:])
+	return res.String()
+}
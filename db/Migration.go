@@ -12,6 +12,19 @@ type Migration struct {
 	Notes        *string    `db:"notes" json:"notes"`
 }
 
+// MigrationPlan describes changes made by the migration. It is used to check
+// pending migrations before applying them.
+type MigrationPlan struct {
+	Migration Migration
+	Queries   []string
+	// TableRows contains estimated numbers of rows of the tables changed by the migration.
+	TableRows map[string]int64
+	// BackwardCompatible is true if Semaphore of the previous version can work
+	// with the database after the migration. Such migrations can be applied
+	// before the rolling upgrade of the nodes.
+	BackwardCompatible bool
+}
+
 // HumanoidVersion adds a v to the VersionString
 func (m Migration) HumanoidVersion() string {
 	return "v" + m.Version
@@ -76,9 +89,96 @@ func GetMigrations() []Migration {
 		{Version: "2.10.28"},
 		{Version: "2.10.33"},
 		{Version: "2.10.46"},
+		{Version: "2.10.47"},
+		{Version: "2.10.48"},
+		{Version: "2.10.49"},
+		{Version: "2.10.50"},
+		{Version: "2.10.51"},
+		{Version: "2.10.52"},
+		{Version: "2.10.53"},
+		{Version: "2.10.54"},
+		{Version: "2.10.55"},
+		{Version: "2.10.56"},
+		{Version: "2.10.57"},
+		{Version: "2.10.58"},
+		{Version: "2.10.59"},
+		{Version: "2.10.60"},
+		{Version: "2.10.61"},
+		{Version: "2.10.62"},
+		{Version: "2.10.63"},
+		{Version: "2.10.64"},
+		{Version: "2.10.65"},
+		{Version: "2.10.66"},
+		{Version: "2.10.67"},
+		{Version: "2.10.68"},
+		{Version: "2.10.69"},
+		{Version: "2.10.70"},
+		{Version: "2.10.71"},
+		{Version: "2.10.72"},
+		{Version: "2.10.73"},
+		{Version: "2.10.74"},
+		{Version: "2.10.75"},
+		{Version: "2.10.76"},
+		{Version: "2.10.77"},
+		{Version: "2.10.78"},
+		{Version: "2.10.79"},
+		{Version: "2.10.80"},
+		{Version: "2.10.81"},
+		{Version: "2.10.82"},
+		{Version: "2.10.83"},
+		{Version: "2.10.84"},
+		{Version: "2.10.85"},
+		{Version: "2.10.86"},
+		{Version: "2.10.87"},
+		{Version: "2.10.88"},
+		{Version: "2.10.89"},
+		{Version: "2.10.90"},
+		{Version: "2.10.91"},
+		{Version: "2.10.92"},
+		{Version: "2.10.93"},
+		{Version: "2.10.94"},
+		{Version: "2.10.95"},
+		{Version: "2.10.96"},
+		{Version: "2.10.97"},
+		{Version: "2.10.98"},
+		{Version: "2.10.99"},
+		{Version: "2.10.100"},
+		{Version: "2.10.101"},
+		{Version: "2.10.102"},
+		{Version: "2.10.103"},
+		{Version: "2.10.104"},
+		{Version: "2.10.105"},
+		{Version: "2.10.106"},
+		{Version: "2.10.107"},
+		{Version: "2.10.108"},
 	}
 }
 
+// GetPendingMigrations returns migrations which are not applied yet.
+func GetPendingMigrations(d Store) (pending []Migration, err error) {
+	for _, version := range GetMigrations() {
+		var exists bool
+		exists, err = d.IsMigrationApplied(version)
+		if err != nil {
+			return
+		}
+		if !exists {
+			pending = append(pending, version)
+		}
+	}
+	return
+}
+
+func applyMigration(d Store, version Migration) error {
+	fmt.Printf("Executing migration %s (at %v)...\n", version.HumanoidVersion(), time.Now())
+	if err := d.ApplyMigration(version); err != nil {
+		fmt.Printf("Rolling back %s (time: %v)...\n", version.HumanoidVersion(), time.Now())
+		d.TryRollbackMigration(version)
+		return err
+	}
+	return nil
+}
+
 func Migrate(d Store) error {
 	didRun := false
 
@@ -92,10 +192,7 @@ func Migrate(d Store) error {
 		}
 
 		didRun = true
-		fmt.Printf("Executing migration %s (at %v)...\n", version.HumanoidVersion(), time.Now())
-		if err := d.ApplyMigration(version); err != nil {
-			fmt.Printf("Rolling back %s (time: %v)...\n", version.HumanoidVersion(), time.Now())
-			d.TryRollbackMigration(version)
+		if err := applyMigration(d, version); err != nil {
 			return err
 		}
 	}
@@ -106,3 +203,28 @@ func Migrate(d Store) error {
 
 	return nil
 }
+
+// MigrateCompatible applies pending migrations until the first backward incompatible one.
+// It is used before the rolling upgrade: nodes of the previous version keep working
+// and the remaining migrations are applied by the upgraded nodes.
+func MigrateCompatible(d Store) (applied int, err error) {
+	pending, err := GetPendingMigrations(d)
+	if err != nil {
+		return
+	}
+
+	for _, version := range pending {
+		var plan MigrationPlan
+		plan, err = d.GetMigrationPlan(version)
+		if err != nil || !plan.BackwardCompatible {
+			return
+		}
+
+		if err = applyMigration(d, version); err != nil {
+			return
+		}
+		applied++
+	}
+
+	return
+}
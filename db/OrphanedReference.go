@@ -0,0 +1,62 @@
+package db
+
+type OrphanKind string
+
+const (
+	// OrphanTaskTemplate is the task of the deleted template.
+	OrphanTaskTemplate OrphanKind = "task_template"
+	// OrphanKeyEnvironment is the secret of the deleted environment.
+	OrphanKeyEnvironment OrphanKind = "key_environment"
+	// OrphanKeyUser is the access key owned by the deleted user.
+	OrphanKeyUser OrphanKind = "key_user"
+)
+
+type OrphanFix string
+
+const (
+	// OrphanFixUnset removes the dangling reference, the row is kept.
+	OrphanFixUnset OrphanFix = "unset"
+	// OrphanFixPurge removes the row which can not be used without the deleted object.
+	OrphanFixPurge OrphanFix = "purge"
+)
+
+// OrphanedReference is the row which refers to the deleted object. Such rows are left
+// by databases without enforced foreign keys and by interrupted deletions.
+type OrphanedReference struct {
+	Kind OrphanKind `json:"kind"`
+	// ProjectID is 0 if the row does not belong to a project.
+	ProjectID  int             `json:"project_id"`
+	ObjectType EventObjectType `json:"object_type"`
+	ObjectID   int             `json:"object_id"`
+	// Field is the column which refers to the deleted object and RefID is its value.
+	Field string    `json:"field"`
+	RefID int       `json:"ref_id"`
+	Fix   OrphanFix `json:"fix"`
+}
+
+// NewOrphanedReference returns the orphaned reference of the kind with its object type and fix.
+func NewOrphanedReference(kind OrphanKind, projectID int, objectID int, refID int) OrphanedReference {
+	ref := OrphanedReference{
+		Kind:      kind,
+		ProjectID: projectID,
+		ObjectID:  objectID,
+		RefID:     refID,
+	}
+
+	switch kind {
+	case OrphanTaskTemplate:
+		ref.ObjectType = EventTask
+		ref.Field = "template_id"
+		ref.Fix = OrphanFixPurge
+	case OrphanKeyEnvironment:
+		ref.ObjectType = EventKey
+		ref.Field = "environment_id"
+		ref.Fix = OrphanFixPurge
+	case OrphanKeyUser:
+		ref.ObjectType = EventKey
+		ref.Field = "user_id"
+		ref.Fix = OrphanFixUnset
+	}
+
+	return ref
+}
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/semaphoreui/semaphore/util"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrPasswordExpired is returned for requests of the user who must change the expired password.
+var ErrPasswordExpired = &CodedError{
+	Code:    ErrorCodePasswordExpired,
+	Message: "Password expired",
+	Hint:    "Change the password to continue",
+}
+
+func newPasswordPolicyError(message string, hint string) error {
+	return &CodedError{
+		Code:    ErrorCodePasswordPolicy,
+		Field:   "password",
+		Message: message,
+		Hint:    hint,
+	}
+}
+
+// ValidatePassword checks that the password matches the complexity requirements of the policy.
+func ValidatePassword(policy util.PasswordPolicyConfig, password string) error {
+	if len([]rune(password)) < policy.MinLength {
+		return newPasswordPolicyError(
+			"Password is too short",
+			fmt.Sprintf("The password must contain at least %d characters", policy.MinLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	var missing []string
+
+	if policy.RequireUppercase && !hasUpper {
+		missing = append(missing, "an uppercase letter")
+	}
+	if policy.RequireLowercase && !hasLower {
+		missing = append(missing, "a lowercase letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		missing = append(missing, "a digit")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		missing = append(missing, "a symbol")
+	}
+
+	if len(missing) > 0 {
+		return newPasswordPolicyError(
+			"Password is too simple",
+			"The password must contain "+strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// ValidatePasswordChange checks the new password of the existing user against
+// the policy of the instance, including the history of the passwords of the user.
+func ValidatePasswordChange(store Store, user User, password string) error {
+	policy := util.Config.GetPasswordPolicy()
+
+	if err := ValidatePassword(policy, password); err != nil {
+		return err
+	}
+
+	if policy.HistorySize <= 0 {
+		return nil
+	}
+
+	hashes := []string{user.Password}
+
+	if policy.HistorySize > 1 {
+		history, err := store.GetUserPasswordHistory(user.ID, policy.HistorySize-1)
+		if err != nil {
+			return err
+		}
+		for _, h := range history {
+			hashes = append(hashes, h.Password)
+		}
+	}
+
+	for _, hash := range hashes {
+		if hash != "" && bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			return newPasswordPolicyError(
+				"Password was used recently",
+				fmt.Sprintf("The password must differ from the last %d passwords", policy.HistorySize))
+		}
+	}
+
+	return nil
+}
+
+// IsPasswordExpired returns true if the local user must change the password at the time.
+// Passwords of LDAP and OIDC users are not managed by Semaphore and never expire.
+func IsPasswordExpired(user User, now time.Time) bool {
+	maxAge := util.Config.GetPasswordPolicy().MaxAgeDays
+
+	if maxAge <= 0 || user.External || user.Password == "" {
+		return false
+	}
+
+	changed := user.Created
+	if user.PasswordChanged != nil {
+		changed = *user.PasswordChanged
+	}
+
+	return !now.Before(changed.AddDate(0, 0, maxAge))
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestValidatePassword(t *testing.T) {
+	policy := util.PasswordPolicyConfig{
+		MinLength:        8,
+		RequireUppercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+	}
+
+	for pwd, valid := range map[string]bool{
+		"Ab1!":      false,
+		"abcdefg1!": false,
+		"Abcdefgh!": false,
+		"Abcdefg1":  false,
+		"Abcdefg1!": true,
+	} {
+		err := ValidatePassword(policy, pwd)
+
+		var codedErr *CodedError
+		if valid && err != nil {
+			t.Fatalf("expected password %s to be valid, got %v", pwd, err)
+		}
+		if !valid && (!errors.As(err, &codedErr) || codedErr.Code != ErrorCodePasswordPolicy || codedErr.Field != "password") {
+			t.Fatalf("expected password %s to violate the policy, got %v", pwd, err)
+		}
+	}
+}
+
+func TestIsPasswordExpired(t *testing.T) {
+	util.Config = &util.ConfigType{
+		PasswordPolicy: &util.PasswordPolicyConfig{MaxAgeDays: 30},
+	}
+
+	now := time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC)
+	changed := now.AddDate(0, 0, -10)
+
+	user := User{Password: "hash", Created: now.AddDate(0, 0, -40)}
+	if !IsPasswordExpired(user, now) {
+		t.Fatal("expected the password of the old user to be expired")
+	}
+
+	user.PasswordChanged = &changed
+	if IsPasswordExpired(user, now) {
+		t.Fatal("expected the changed password not to be expired")
+	}
+
+	user.PasswordChanged = nil
+	user.External = true
+	if IsPasswordExpired(user, now) {
+		t.Fatal("expected passwords of external users not to expire")
+	}
+}
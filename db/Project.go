@@ -1,9 +1,16 @@
 package db
 
 import (
+	"errors"
+	"path"
+	"slices"
+	"strings"
 	"time"
 )
 
+// ErrProjectArchived is returned if the archived project is changed or its tasks are run.
+var ErrProjectArchived = errors.New("project is archived")
+
 // Project is the top level structure in Semaphore
 type Project struct {
 	ID               int       `db:"id" json:"id" backup:"-"`
@@ -13,4 +20,171 @@ type Project struct {
 	AlertChat        *string   `db:"alert_chat" json:"alert_chat"`
 	MaxParallelTasks int       `db:"max_parallel_tasks" json:"max_parallel_tasks"`
 	Type             string    `db:"type" json:"type"`
+	// CalendarToken protects the public calendar feed of the project.
+	CalendarToken *string `db:"calendar_token" json:"-" backup:"-"`
+
+	// DefaultSSHKeyID is used by Ansible templates which inventory has no SSH key.
+	DefaultSSHKeyID *int `db:"default_ssh_key_id" json:"default_ssh_key_id" backup:"-"`
+	// DefaultBecomeKeyID is used by Ansible templates which inventory has no become key.
+	DefaultBecomeKeyID *int `db:"default_become_key_id" json:"default_become_key_id" backup:"-"`
+	// DefaultVaultKeyID is used as the password of the default vault
+	// by Ansible templates which have no vaults.
+	DefaultVaultKeyID *int `db:"default_vault_key_id" json:"default_vault_key_id" backup:"-"`
+
+	// Archived projects are read-only: tasks are not run, schedules and integrations are skipped.
+	Archived bool `db:"archived" json:"archived" backup:"-"`
+
+	// AlertDigest is the cron format of sending the digest of scheduled runs.
+	// If it is set, alerts are not sent for every scheduled run.
+	AlertDigest string `db:"alert_digest" json:"alert_digest" backup:"-"`
+	// AlertDigestSent is the end of the period covered by the last sent digest.
+	AlertDigestSent *time.Time `db:"alert_digest_sent" json:"-" backup:"-"`
+
+	// AccessReview is the cron format of sending the access review report to the project owners.
+	AccessReview string `db:"access_review" json:"access_review" backup:"-"`
+
+	// KeyRotationDays is the maximum age of secrets of the access keys, 0 disables rotation reminders.
+	KeyRotationDays int `db:"key_rotation_days" json:"key_rotation_days" backup:"-"`
+
+	// ProtectedBranches is the comma separated list of branches, e.g. "main, release/*",
+	// which are the only branches the templates tagged with ProductionTag can run for.
+	// Empty value disables the protection.
+	ProtectedBranches string `db:"protected_branches" json:"protected_branches" backup:"-"`
+}
+
+// ProductionTag marks templates which run only for the protected branches of the project.
+const ProductionTag = "production"
+
+// GetProtectedBranches returns the patterns of the protected branches of the project.
+func (project *Project) GetProtectedBranches() (patterns []string) {
+	for _, pattern := range strings.Split(project.ProtectedBranches, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return
+}
+
+// ValidateProtectedBranches checks that the patterns of the protected branches are valid.
+func (project *Project) ValidateProtectedBranches() error {
+	for _, pattern := range project.GetProtectedBranches() {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return &ValidationError{"invalid protected branch " + pattern}
+		}
+	}
+	return nil
+}
+
+// CheckBranchProtection returns the error if the template is tagged with ProductionTag
+// and the branch is not one of the protected branches of the project.
+func (project *Project) CheckBranchProtection(template Template, branch string) error {
+	patterns := project.GetProtectedBranches()
+
+	if len(patterns) == 0 || !slices.Contains(template.Tags, ProductionTag) {
+		return nil
+	}
+
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, branch); matched {
+			return nil
+		}
+	}
+
+	return &ValidationError{"production template can not run for branch " + branch + ", the protected branches are " +
+		strings.Join(patterns, ", ")}
+}
+
+// GetDefaultKeyIDs returns IDs of the default keys of the project.
+func (project *Project) GetDefaultKeyIDs() []*int {
+	return []*int{project.DefaultSSHKeyID, project.DefaultBecomeKeyID, project.DefaultVaultKeyID}
+}
+
+// ClearDefaultKey removes the key from the default keys of the project.
+// It returns true if the project was changed.
+func (project *Project) ClearDefaultKey(keyID int) (changed bool) {
+	for _, id := range []**int{&project.DefaultSSHKeyID, &project.DefaultBecomeKeyID, &project.DefaultVaultKeyID} {
+		if *id != nil && **id == keyID {
+			*id = nil
+			changed = true
+		}
+	}
+	return
+}
+
+// ValidateProjectDefaultKeys checks that the default keys belong to the project
+// and have the types suitable for their usage.
+func ValidateProjectDefaultKeys(store Store, project *Project) error {
+	check := func(keyID *int, name string, types ...AccessKeyType) error {
+		if keyID == nil {
+			return nil
+		}
+
+		key, err := store.GetAccessKey(project.ID, *keyID)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range types {
+			if key.Type == t {
+				return nil
+			}
+		}
+
+		return &ValidationError{"invalid type of the default " + name + " key"}
+	}
+
+	if err := check(project.DefaultSSHKeyID, "SSH", AccessKeySSH, AccessKeyLoginPassword, AccessKeyVault); err != nil {
+		return err
+	}
+
+	if err := check(project.DefaultBecomeKeyID, "become", AccessKeyLoginPassword, AccessKeyVault); err != nil {
+		return err
+	}
+
+	return check(project.DefaultVaultKeyID, "vault", AccessKeyLoginPassword)
+}
+
+// ApplyProjectDefaultKeys sets the default keys of the project to the inventory
+// and the template of the Ansible task if they do not define own keys.
+func ApplyProjectDefaultKeys(store Store, project Project, inventory *Inventory, template *Template) (err error) {
+	if template.App != AppAnsible {
+		return
+	}
+
+	if inventory.ID != 0 && inventory.Type != InventoryTerraformWorkspace {
+		if inventory.SSHKeyID == nil && project.DefaultSSHKeyID != nil {
+			inventory.SSHKey, err = store.GetAccessKey(project.ID, *project.DefaultSSHKeyID)
+			if err != nil {
+				return
+			}
+			inventory.SSHKeyID = project.DefaultSSHKeyID
+		}
+
+		if inventory.BecomeKeyID == nil && project.DefaultBecomeKeyID != nil {
+			inventory.BecomeKey, err = store.GetAccessKey(project.ID, *project.DefaultBecomeKeyID)
+			if err != nil {
+				return
+			}
+			inventory.BecomeKeyID = project.DefaultBecomeKeyID
+		}
+	}
+
+	if len(template.Vaults) == 0 && project.DefaultVaultKeyID != nil {
+		var key AccessKey
+		key, err = store.GetAccessKey(project.ID, *project.DefaultVaultKeyID)
+		if err != nil {
+			return
+		}
+
+		template.Vaults = []TemplateVault{{
+			ProjectID:  project.ID,
+			TemplateID: template.ID,
+			VaultKeyID: project.DefaultVaultKeyID,
+			Type:       TemplateVaultPassword,
+			Vault:      &key,
+		}}
+	}
+
+	return
 }
@@ -1,5 +1,7 @@
 package db
 
+import "time"
+
 type ProjectUserRole string
 
 const (
@@ -17,12 +19,18 @@ const (
 	CanUpdateProject
 	CanManageProjectResources
 	CanManageProjectUsers
+	// CanViewProjectKeys allows to list access keys and see their metadata.
+	// Creating and editing keys also requires CanManageProjectResources.
+	CanViewProjectKeys
+	// CanUseProjectKeys allows to attach access keys to templates, inventories
+	// and repositories and to run tasks which use them.
+	CanUseProjectKeys
 )
 
 var rolePermissions = map[ProjectUserRole]ProjectUserPermission{
-	ProjectOwner:      CanRunProjectTasks | CanManageProjectResources | CanUpdateProject | CanManageProjectUsers,
-	ProjectManager:    CanRunProjectTasks | CanManageProjectResources,
-	ProjectTaskRunner: CanRunProjectTasks,
+	ProjectOwner:      CanRunProjectTasks | CanManageProjectResources | CanUpdateProject | CanManageProjectUsers | CanViewProjectKeys | CanUseProjectKeys,
+	ProjectManager:    CanRunProjectTasks | CanManageProjectResources | CanViewProjectKeys | CanUseProjectKeys,
+	ProjectTaskRunner: CanRunProjectTasks | CanUseProjectKeys,
 	ProjectGuest:      0,
 }
 
@@ -36,6 +44,13 @@ type ProjectUser struct {
 	ProjectID int             `db:"project_id" json:"project_id"`
 	UserID    int             `db:"user_id" json:"user_id"`
 	Role      ProjectUserRole `db:"role" json:"role"`
+	// ExpiresAt is the time after which the user loses access to the project.
+	ExpiresAt *time.Time `db:"expires_at" json:"expires_at"`
+}
+
+// IsExpired returns true if the membership expired at the time.
+func (u *ProjectUser) IsExpired(now time.Time) bool {
+	return u.ExpiresAt != nil && !now.Before(*u.ExpiresAt)
 }
 
 func (r ProjectUserRole) Can(permissions ProjectUserPermission) bool {
@@ -0,0 +1,39 @@
+package db
+
+import "testing"
+
+func TestProjectCheckBranchProtection(t *testing.T) {
+	project := Project{ProtectedBranches: " main, release/* ,"}
+	production := Template{Tags: []string{"deploy", ProductionTag}}
+
+	for _, branch := range []string{"main", "release/1.0"} {
+		if err := project.CheckBranchProtection(production, branch); err != nil {
+			t.Fatalf("branch %s must be allowed: %v", branch, err)
+		}
+	}
+
+	for _, branch := range []string{"feature/login", "release/1.0/fix", "", "mainline"} {
+		err := project.CheckBranchProtection(production, branch)
+		if _, ok := err.(*ValidationError); !ok {
+			t.Fatalf("branch %q must be rejected, got %v", branch, err)
+		}
+	}
+
+	if err := project.CheckBranchProtection(Template{Tags: []string{"deploy"}}, "feature/login"); err != nil {
+		t.Fatal("template without production tag must not be restricted", err)
+	}
+
+	if err := (&Project{}).CheckBranchProtection(production, "feature/login"); err != nil {
+		t.Fatal("project without protected branches must not restrict templates", err)
+	}
+}
+
+func TestProjectValidateProtectedBranches(t *testing.T) {
+	if err := (&Project{ProtectedBranches: "main, release/*"}).ValidateProtectedBranches(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (&Project{ProtectedBranches: "main, release/["}).ValidateProtectedBranches(); err == nil {
+		t.Fatal("invalid pattern must be rejected")
+	}
+}
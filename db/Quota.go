@@ -0,0 +1,73 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// QuotaExceededError is returned when the project reaches the limit
+// of the instance quotas (see util.QuotaConfig).
+type QuotaExceededError struct {
+	Resource string
+	Limit    int
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("project quota exceeded: at most %d %s allowed", e.Limit, e.Resource)
+}
+
+func getQuotas() util.QuotaConfig {
+	if util.Config == nil || util.Config.Quotas == nil {
+		return util.QuotaConfig{}
+	}
+	return *util.Config.Quotas
+}
+
+// CheckTemplateQuota returns QuotaExceededError if new template can not be added to the project.
+func CheckTemplateQuota(store Store, projectID int) error {
+	limit := getQuotas().MaxTemplates
+	if limit <= 0 {
+		return nil
+	}
+
+	templates, err := store.GetTemplates(projectID, TemplateFilter{}, RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+
+	if len(templates) >= limit {
+		return &QuotaExceededError{Resource: "templates", Limit: limit}
+	}
+
+	return nil
+}
+
+// CheckAccessKeyQuota returns QuotaExceededError if new access key can not be added to the project.
+func CheckAccessKeyQuota(store Store, projectID int) error {
+	limit := getQuotas().MaxKeys
+	if limit <= 0 {
+		return nil
+	}
+
+	keys, err := store.GetAccessKeys(projectID, RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+
+	if len(keys) >= limit {
+		return &QuotaExceededError{Resource: "access keys", Limit: limit}
+	}
+
+	return nil
+}
+
+// CheckConcurrentTasksQuota returns QuotaExceededError if the project
+// already has maximum number of waiting and running tasks.
+func CheckConcurrentTasksQuota(activeTasks int) error {
+	limit := getQuotas().MaxConcurrentTasks
+	if limit > 0 && activeTasks >= limit {
+		return &QuotaExceededError{Resource: "concurrent tasks", Limit: limit}
+	}
+	return nil
+}
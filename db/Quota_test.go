@@ -0,0 +1,27 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestCheckConcurrentTasksQuota(t *testing.T) {
+	util.Config = &util.ConfigType{}
+
+	if err := CheckConcurrentTasksQuota(100); err != nil {
+		t.Fatal("tasks must not be limited without quota")
+	}
+
+	util.Config.Quotas = &util.QuotaConfig{MaxConcurrentTasks: 2}
+
+	if err := CheckConcurrentTasksQuota(1); err != nil {
+		t.Fatal(err)
+	}
+
+	var quotaErr *QuotaExceededError
+	if err := CheckConcurrentTasksQuota(2); !errors.As(err, &quotaErr) || quotaErr.Limit != 2 {
+		t.Fatal("quota must be exceeded")
+	}
+}
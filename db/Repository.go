@@ -1,7 +1,6 @@
 package db
 
 import (
-	"fmt"
 	"os"
 	"path"
 	"regexp"
@@ -75,37 +74,12 @@ func (r Repository) GetFullPath(templateID int) string {
 	return path.Join(util.Config.TmpPath, r.GetDirName(templateID))
 }
 
+// GetGitURL returns the URL of the repository which is passed to git.
+// Login and password of HTTP repositories are not embedded into the URL,
+// git gets them from the credential helper of the task, so they are never
+// written to the config of the cloned repository.
 func (r Repository) GetGitURL() string {
-	url := r.GitURL
-
-	if r.GetType() == RepositoryHTTP {
-		auth := ""
-		switch r.SSHKey.Type {
-		case AccessKeyLoginPassword:
-			if r.SSHKey.LoginPassword.Login == "" {
-				auth = r.SSHKey.LoginPassword.Password
-			} else {
-				auth = r.SSHKey.LoginPassword.Login + ":" + r.SSHKey.LoginPassword.Password
-			}
-		}
-		if auth != "" {
-			auth += "@"
-		}
-
-		re := regexp.MustCompile(`^(https?)://`)
-		m := re.FindStringSubmatch(url)
-		var protocol string
-
-		if m == nil {
-			panic(fmt.Errorf("invalid git url: %s", url))
-		}
-
-		protocol = m[1]
-
-		url = protocol + "://" + auth + r.GitURL[len(protocol)+3:]
-	}
-
-	return url
+	return r.GitURL
 }
 
 func (r Repository) GetType() RepositoryType {
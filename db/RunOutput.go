@@ -0,0 +1,259 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type RunOutputType string
+
+const (
+	RunOutputString  RunOutputType = "string"
+	RunOutputNumber  RunOutputType = "number"
+	RunOutputBoolean RunOutputType = "boolean"
+	RunOutputJSON    RunOutputType = "json"
+)
+
+// RunOutput is the value registered by the task, for example the ID of the image built by Packer.
+// The task writes outputs to the file defined by SEMAPHORE_OUTPUT_FILE environment variable.
+// Value is stored as JSON text of the value.
+type RunOutput struct {
+	ID     int           `db:"id" json:"id"`
+	TaskID int           `db:"task_id" json:"task_id"`
+	Name   string        `db:"name" json:"name"`
+	Type   RunOutputType `db:"type" json:"type"`
+	Value  string        `db:"value" json:"value"`
+}
+
+type runOutputJSON RunOutput
+
+// MarshalJSON writes the value as JSON value of its type instead of JSON text.
+func (o RunOutput) MarshalJSON() ([]byte, error) {
+	value := json.RawMessage(o.Value)
+	if len(value) == 0 {
+		value = json.RawMessage("null")
+	}
+
+	return json.Marshal(struct {
+		runOutputJSON
+		Value json.RawMessage `json:"value"`
+	}{runOutputJSON(o), value})
+}
+
+func (o *RunOutput) UnmarshalJSON(data []byte) error {
+	var res struct {
+		runOutputJSON
+		Value json.RawMessage `json:"value"`
+	}
+
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+
+	*o = RunOutput(res.runOutputJSON)
+	o.Value = string(res.Value)
+
+	return nil
+}
+
+// GetValue returns the decoded value of the output.
+func (o *RunOutput) GetValue() (value interface{}, err error) {
+	err = json.Unmarshal([]byte(o.Value), &value)
+	return
+}
+
+// String returns the value of the string output or JSON text of the value of other types.
+func (o *RunOutput) String() string {
+	if o.Type == RunOutputString {
+		var s string
+		if json.Unmarshal([]byte(o.Value), &s) == nil {
+			return s
+		}
+	}
+	return o.Value
+}
+
+var runOutputNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func getRunOutputType(value json.RawMessage) RunOutputType {
+	switch value[0] {
+	case '"':
+		return RunOutputString
+	case 't', 'f':
+		return RunOutputBoolean
+	case '{', '[', 'n':
+		return RunOutputJSON
+	default:
+		return RunOutputNumber
+	}
+}
+
+// ParseRunOutputs parses the content of the output file of the task. The content is
+// the JSON object or lines of "name=value" pairs. Values of the pairs are strings,
+// values of the object keep their JSON types.
+func ParseRunOutputs(content []byte) (outputs []RunOutput, err error) {
+	content = bytes.TrimSpace(content)
+
+	if len(content) == 0 {
+		return
+	}
+
+	if content[0] == '{' {
+		values := make(map[string]json.RawMessage)
+		if err = json.Unmarshal(content, &values); err != nil {
+			return nil, &ValidationError{"invalid JSON of outputs: " + err.Error()}
+		}
+
+		for name, value := range values {
+			outputs = append(outputs, RunOutput{
+				Name:  name,
+				Type:  getRunOutputType(value),
+				Value: string(value),
+			})
+		}
+
+		sort.Slice(outputs, func(i, j int) bool {
+			return outputs[i].Name < outputs[j].Name
+		})
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		seen := make(map[string]int)
+
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			name, value, ok := strings.Cut(line, "=")
+			if !ok {
+				return nil, &ValidationError{fmt.Sprintf("invalid output line %q, must be name=value", line)}
+			}
+
+			encoded, _ := json.Marshal(value)
+			output := RunOutput{Name: strings.TrimSpace(name), Type: RunOutputString, Value: string(encoded)}
+
+			// the last value of the output wins
+			if i, ok := seen[output.Name]; ok {
+				outputs[i] = output
+				continue
+			}
+
+			seen[output.Name] = len(outputs)
+			outputs = append(outputs, output)
+		}
+
+		if err = scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, output := range outputs {
+		if !runOutputNameRegexp.MatchString(output.Name) {
+			return nil, &ValidationError{fmt.Sprintf("invalid output name %q", output.Name)}
+		}
+	}
+
+	return
+}
+
+// runOutputRefRegexp matches references to outputs of the build task, e.g. "${outputs.ami_id}".
+var runOutputRefRegexp = regexp.MustCompile(`\$\{outputs\.([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// HasRunOutputRefs returns true if the text contains references to outputs.
+func HasRunOutputRefs(text string) bool {
+	return runOutputRefRegexp.MatchString(text)
+}
+
+// resolveRunOutputRefs replaces references in string values of the decoded JSON.
+// If typed is true, the string which consists of the single reference is replaced by the typed value.
+func resolveRunOutputRefs(value interface{}, outputs map[string]RunOutput, typed bool) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if m := runOutputRefRegexp.FindStringSubmatch(v); typed && m != nil && m[0] == v {
+			output, ok := outputs[m[1]]
+			if !ok {
+				return nil, fmt.Errorf("output %s not found", m[1])
+			}
+			return output.GetValue()
+		}
+
+		var err error
+		res := runOutputRefRegexp.ReplaceAllStringFunc(v, func(ref string) string {
+			name := runOutputRefRegexp.FindStringSubmatch(ref)[1]
+			output, ok := outputs[name]
+			if !ok {
+				err = fmt.Errorf("output %s not found", name)
+				return ref
+			}
+			return output.String()
+		})
+		return res, err
+	case map[string]interface{}:
+		for k, item := range v {
+			resolved, err := resolveRunOutputRefs(item, outputs, typed)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = resolved
+		}
+		return v, nil
+	case []interface{}:
+		for i, item := range v {
+			resolved, err := resolveRunOutputRefs(item, outputs, typed)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = resolved
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+// ResolveRunOutputRefs replaces references to outputs in string values of the JSON object,
+// e.g. {"ami": "${outputs.ami_id}"}. The value which consists of the single reference
+// gets the type of the output, otherwise the reference is replaced by the text of the value.
+func ResolveRunOutputRefs(jsonText string, outputs []RunOutput) (string, error) {
+	return resolveRunOutputRefsJSON(jsonText, outputs, true)
+}
+
+// ResolveRunOutputEnvRefs replaces references to outputs in the JSON object of environment
+// variables. Values of the variables remain strings.
+func ResolveRunOutputEnvRefs(jsonText string, outputs []RunOutput) (string, error) {
+	return resolveRunOutputRefsJSON(jsonText, outputs, false)
+}
+
+func resolveRunOutputRefsJSON(jsonText string, outputs []RunOutput, typed bool) (string, error) {
+	if !HasRunOutputRefs(jsonText) {
+		return jsonText, nil
+	}
+
+	byName := make(map[string]RunOutput)
+	for _, output := range outputs {
+		byName[output.Name] = output
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(jsonText), &value); err != nil {
+		return "", err
+	}
+
+	value, err := resolveRunOutputRefs(value, byName, typed)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(res), nil
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseRunOutputsJSON(t *testing.T) {
+	outputs, err := ParseRunOutputs([]byte(`{"ami_id": "ami-123", "count": 3, "ok": true, "tags": {"env": "prod"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []RunOutput{
+		{Name: "ami_id", Type: RunOutputString, Value: `"ami-123"`},
+		{Name: "count", Type: RunOutputNumber, Value: `3`},
+		{Name: "ok", Type: RunOutputBoolean, Value: `true`},
+		{Name: "tags", Type: RunOutputJSON, Value: `{"env": "prod"}`},
+	}
+
+	if len(outputs) != len(want) {
+		t.Fatalf("expected %d outputs, got %d", len(want), len(outputs))
+	}
+
+	for i, output := range outputs {
+		if output != want[i] {
+			t.Errorf("expected %v, got %v", want[i], output)
+		}
+	}
+}
+
+func TestParseRunOutputsLines(t *testing.T) {
+	outputs, err := ParseRunOutputs([]byte("# packer\nami_id=ami-1\n\nregion = us-east-1\nami_id=ami-2=x\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(outputs) != 2 {
+		t.Fatalf("expected 2 outputs, got %d", len(outputs))
+	}
+
+	if outputs[0].Name != "ami_id" || outputs[0].String() != "ami-2=x" {
+		t.Errorf("unexpected output %v", outputs[0])
+	}
+
+	if outputs[1].Name != "region" || outputs[1].String() != " us-east-1" || outputs[1].Type != RunOutputString {
+		t.Errorf("unexpected output %v", outputs[1])
+	}
+
+	for _, content := range []string{"ami_id", "1ami=x", `{"bad-name": 1}`, `{"a": }`} {
+		if _, err = ParseRunOutputs([]byte(content)); err == nil {
+			t.Errorf("content %q must be invalid", content)
+		}
+	}
+}
+
+func TestRunOutputJSON(t *testing.T) {
+	output := RunOutput{ID: 1, TaskID: 2, Name: "count", Type: RunOutputNumber, Value: "3"}
+
+	data, err := json.Marshal(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `{"id":1,"task_id":2,"name":"count","type":"number","value":3}` {
+		t.Errorf("unexpected JSON %s", data)
+	}
+
+	var res RunOutput
+	if err = json.Unmarshal(data, &res); err != nil {
+		t.Fatal(err)
+	}
+
+	if res != output {
+		t.Errorf("expected %v, got %v", output, res)
+	}
+}
+
+func TestResolveRunOutputRefs(t *testing.T) {
+	outputs := []RunOutput{
+		{Name: "ami_id", Type: RunOutputString, Value: `"ami-123"`},
+		{Name: "count", Type: RunOutputNumber, Value: `3`},
+	}
+
+	res, err := ResolveRunOutputRefs(
+		`{"ami": "${outputs.ami_id}", "size": "${outputs.count}", "name": "web-${outputs.count}", "list": ["${outputs.ami_id}"]}`,
+		outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res != `{"ami":"ami-123","list":["ami-123"],"name":"web-3","size":3}` {
+		t.Errorf("unexpected result %s", res)
+	}
+
+	res, err = ResolveRunOutputEnvRefs(`{"SIZE": "${outputs.count}"}`, outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res != `{"SIZE":"3"}` {
+		t.Errorf("unexpected result %s", res)
+	}
+
+	if _, err = ResolveRunOutputRefs(`{"ami": "${outputs.missing}"}`, outputs); err == nil {
+		t.Error("missing output must fail")
+	}
+
+	if res, _ = ResolveRunOutputRefs(`{"a": 1}`, nil); res != `{"a": 1}` {
+		t.Errorf("JSON without references must not change, got %s", res)
+	}
+}
@@ -0,0 +1,280 @@
+package db
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+// RunRecordAttestationType is the type of the statement signed by RunAttestation.
+const RunRecordAttestationType = "https://semaphoreui.com/attestation/run/v1"
+
+// RunRecord is an append-only record of the finished task and its inputs.
+// Records of the project form a hash chain: every record contains the hash
+// of the previous record, so modification or removal of any record breaks the chain.
+// Records are not removed together with tasks, templates and users.
+type RunRecord struct {
+	ID        int `db:"id" json:"id"`
+	ProjectID int `db:"project_id" json:"project_id"`
+	// Seq is the position of the record in the chain of the project, starting from 1.
+	Seq int `db:"seq" json:"seq"`
+
+	TaskID        int     `db:"task_id" json:"task_id"`
+	TemplateID    int     `db:"template_id" json:"template_id"`
+	TemplateName  string  `db:"template_name" json:"template_name"`
+	UserID        *int    `db:"user_id" json:"user_id"`
+	Username      *string `db:"username" json:"username"`
+	IntegrationID *int    `db:"integration_id" json:"integration_id"`
+	ScheduleID    *int    `db:"schedule_id" json:"schedule_id"`
+
+	CommitHash *string `db:"commit_hash" json:"commit_hash"`
+	// Inputs contains JSON of RunRecordInputs.
+	Inputs string                 `db:"inputs" json:"inputs"`
+	Status task_logger.TaskStatus `db:"status" json:"status"`
+
+	Start   *time.Time `db:"start" json:"start"`
+	End     *time.Time `db:"end" json:"end"`
+	Created time.Time  `db:"created" json:"created"`
+
+	PrevHash string `db:"prev_hash" json:"prev_hash"`
+	Hash     string `db:"hash" json:"hash"`
+}
+
+// RunRecordInputs are the parameters of the task which affect the run.
+type RunRecordInputs struct {
+	Playbook    string            `json:"playbook"`
+	Arguments   *string           `json:"arguments"`
+	Environment string            `json:"environment"`
+	Limit       string            `json:"limit"`
+	GitBranch   *string           `json:"git_branch"`
+	InventoryID *int              `json:"inventory_id"`
+	Params      MapStringAnyField `json:"params"`
+}
+
+// runRecordHashData is the content of the record covered by the hash.
+// Times are formatted with second precision because some databases
+// do not store fractional seconds.
+type runRecordHashData struct {
+	ProjectID     int                    `json:"project_id"`
+	Seq           int                    `json:"seq"`
+	TaskID        int                    `json:"task_id"`
+	TemplateID    int                    `json:"template_id"`
+	TemplateName  string                 `json:"template_name"`
+	UserID        *int                   `json:"user_id"`
+	Username      *string                `json:"username"`
+	IntegrationID *int                   `json:"integration_id"`
+	ScheduleID    *int                   `json:"schedule_id"`
+	CommitHash    *string                `json:"commit_hash"`
+	Inputs        string                 `json:"inputs"`
+	Status        task_logger.TaskStatus `json:"status"`
+	Start         string                 `json:"start"`
+	End           string                 `json:"end"`
+	Created       string                 `json:"created"`
+	PrevHash      string                 `json:"prev_hash"`
+}
+
+func formatRunRecordTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func truncateRunRecordTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	res := t.UTC().Truncate(time.Second)
+	return &res
+}
+
+// NewRunRecord creates the record of the finished task.
+// The record must be added to the chain by Store.AppendRunRecord.
+func NewRunRecord(task Task, template Template, username *string) (record RunRecord, err error) {
+	now := time.Now()
+
+	inputs, err := json.Marshal(RunRecordInputs{
+		Playbook:    task.Playbook,
+		Arguments:   task.Arguments,
+		Environment: task.Environment,
+		Limit:       task.Limit,
+		GitBranch:   task.GitBranch,
+		InventoryID: task.InventoryID,
+		Params:      task.Params,
+	})
+	if err != nil {
+		return
+	}
+
+	record = RunRecord{
+		ProjectID:     task.ProjectID,
+		TaskID:        task.ID,
+		TemplateID:    task.TemplateID,
+		TemplateName:  template.Name,
+		UserID:        task.UserID,
+		Username:      username,
+		IntegrationID: task.IntegrationID,
+		ScheduleID:    task.ScheduleID,
+		CommitHash:    task.CommitHash,
+		Inputs:        string(inputs),
+		Status:        task.Status,
+		Start:         truncateRunRecordTime(task.Start),
+		End:           truncateRunRecordTime(task.End),
+		Created:       *truncateRunRecordTime(&now),
+	}
+
+	return
+}
+
+// ComputeHash returns hex encoded SHA-256 hash of the record content
+// and the hash of the previous record.
+func (r *RunRecord) ComputeHash() string {
+	data, _ := json.Marshal(runRecordHashData{
+		ProjectID:     r.ProjectID,
+		Seq:           r.Seq,
+		TaskID:        r.TaskID,
+		TemplateID:    r.TemplateID,
+		TemplateName:  r.TemplateName,
+		UserID:        r.UserID,
+		Username:      r.Username,
+		IntegrationID: r.IntegrationID,
+		ScheduleID:    r.ScheduleID,
+		CommitHash:    r.CommitHash,
+		Inputs:        r.Inputs,
+		Status:        r.Status,
+		Start:         formatRunRecordTime(r.Start),
+		End:           formatRunRecordTime(r.End),
+		Created:       formatRunRecordTime(&r.Created),
+		PrevHash:      r.PrevHash,
+	})
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ChainTo links the record to the previous record of the project.
+// prev is nil for the first record of the project.
+func (r *RunRecord) ChainTo(prev *RunRecord) {
+	r.Seq = 1
+	r.PrevHash = ""
+
+	if prev != nil {
+		r.Seq = prev.Seq + 1
+		r.PrevHash = prev.Hash
+	}
+
+	r.Hash = r.ComputeHash()
+}
+
+// RunRecordChainStatus is the result of the verification of the project chain.
+type RunRecordChainStatus struct {
+	Valid bool `json:"valid"`
+	Count int  `json:"count"`
+	// LastHash is the hash of the last record, it can be saved outside
+	// of Semaphore to detect removal of the last records.
+	LastHash string `json:"last_hash,omitempty"`
+	// BrokenRecordID is the ID of the first record which does not match the chain.
+	BrokenRecordID *int   `json:"broken_record_id,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// VerifyRunRecordChain checks hashes and links of the records.
+// The records must be ordered by Seq.
+func VerifyRunRecordChain(records []RunRecord) (res RunRecordChainStatus) {
+	res.Count = len(records)
+
+	var prev *RunRecord
+
+	for i := range records {
+		record := &records[i]
+
+		var err error
+
+		switch {
+		case prev == nil && (record.Seq != 1 || record.PrevHash != ""):
+			err = fmt.Errorf("the chain does not start from the first record")
+		case prev != nil && record.Seq != prev.Seq+1:
+			err = fmt.Errorf("record %d is missing", prev.Seq+1)
+		case prev != nil && record.PrevHash != prev.Hash:
+			err = fmt.Errorf("record %d is not linked to the previous record", record.Seq)
+		case record.Hash != record.ComputeHash():
+			err = fmt.Errorf("record %d was modified", record.Seq)
+		}
+
+		if err != nil {
+			res.BrokenRecordID = &record.ID
+			res.Error = err.Error()
+			return
+		}
+
+		prev = record
+	}
+
+	if prev != nil {
+		res.LastHash = prev.Hash
+	}
+
+	res.Valid = true
+	return
+}
+
+// RunAttestation is the signed statement about the task run:
+// who ran what, when and against which commit.
+type RunAttestation struct {
+	// Payload is BASE64 encoded JSON of the RunAttestationStatement.
+	Payload string `json:"payload"`
+	// Signature is BASE64 encoded Ed25519 signature of the decoded payload.
+	Signature string `json:"signature"`
+	// PublicKey is BASE64 encoded Ed25519 public key which verifies the signature.
+	PublicKey string `json:"public_key"`
+}
+
+type RunAttestationStatement struct {
+	Type     string    `json:"type"`
+	Record   RunRecord `json:"record"`
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+// NewRunAttestation signs the statement about the record with the private key.
+func NewRunAttestation(record RunRecord, key ed25519.PrivateKey, issuedAt time.Time) (att RunAttestation, err error) {
+	payload, err := json.Marshal(RunAttestationStatement{
+		Type:     RunRecordAttestationType,
+		Record:   record,
+		IssuedAt: issuedAt.UTC(),
+	})
+	if err != nil {
+		return
+	}
+
+	att.Payload = base64.StdEncoding.EncodeToString(payload)
+	att.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload))
+	att.PublicKey = base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+	return
+}
+
+// Verify checks the signature of the attestation and returns the signed statement.
+func (att *RunAttestation) Verify(pub ed25519.PublicKey) (statement RunAttestationStatement, err error) {
+	payload, err := base64.StdEncoding.DecodeString(att.Payload)
+	if err != nil {
+		return
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(att.Signature)
+	if err != nil {
+		return
+	}
+
+	if !ed25519.Verify(pub, payload, sig) {
+		err = fmt.Errorf("invalid signature")
+		return
+	}
+
+	err = json.Unmarshal(payload, &statement)
+	return
+}
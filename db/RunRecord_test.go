@@ -0,0 +1,94 @@
+package db
+
+import (
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+func createTestRunRecords(t *testing.T, n int) []RunRecord {
+	records := make([]RunRecord, 0, n)
+
+	for i := 0; i < n; i++ {
+		start := time.Now()
+		record, err := NewRunRecord(Task{
+			ID:         i + 1,
+			ProjectID:  1,
+			TemplateID: 1,
+			Status:     task_logger.TaskSuccessStatus,
+			Playbook:   "deploy.yml",
+			Start:      &start,
+		}, Template{Name: "Deploy"}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		record.ID = i + 1
+
+		if i == 0 {
+			record.ChainTo(nil)
+		} else {
+			record.ChainTo(&records[i-1])
+		}
+
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func TestVerifyRunRecordChain(t *testing.T) {
+	records := createTestRunRecords(t, 3)
+
+	res := VerifyRunRecordChain(records)
+	if !res.Valid || res.Count != 3 || res.LastHash != records[2].Hash {
+		t.Fatal("chain must be valid", res.Error)
+	}
+
+	records[1].Inputs = "{}"
+	res = VerifyRunRecordChain(records)
+	if res.Valid || res.BrokenRecordID == nil || *res.BrokenRecordID != 2 {
+		t.Fatal("modified record must break the chain")
+	}
+
+	records = createTestRunRecords(t, 3)
+	res = VerifyRunRecordChain([]RunRecord{records[0], records[2]})
+	if res.Valid || *res.BrokenRecordID != 3 {
+		t.Fatal("removed record must break the chain")
+	}
+
+	res = VerifyRunRecordChain(records[1:])
+	if res.Valid || *res.BrokenRecordID != 2 {
+		t.Fatal("removed first record must break the chain")
+	}
+}
+
+func TestRunAttestation(t *testing.T) {
+	record := createTestRunRecords(t, 1)[0]
+
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	att, err := NewRunAttestation(record, key, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statement, err := att.Verify(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if statement.Type != RunRecordAttestationType || statement.Record.Hash != record.Hash {
+		t.Fatal("statement must contain the record")
+	}
+
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	if _, err = att.Verify(otherPub); err == nil {
+		t.Fatal("signature must not be verified by other key")
+	}
+}
@@ -1,18 +1,148 @@
 package db
 
+import (
+	"fmt"
+	"time"
+)
+
+type ScheduleType string
+
+const (
+	ScheduleTypeCron ScheduleType = "cron"
+	// ScheduleTypeInterval fires the schedule when the interval is passed
+	// after the previous run of the schedule finished.
+	ScheduleTypeInterval ScheduleType = "interval"
+)
+
 type Schedule struct {
-	ID         int    `db:"id" json:"id" backup:"-"`
-	ProjectID  int    `db:"project_id" json:"project_id" backup:"-"`
-	TemplateID int    `db:"template_id" json:"template_id" backup:"-"`
-	CronFormat string `db:"cron_format" json:"cron_format"`
-	Name       string `db:"name" json:"name"`
-	Active     bool   `db:"active" json:"active"`
+	ID         int          `db:"id" json:"id" backup:"-"`
+	ProjectID  int          `db:"project_id" json:"project_id" backup:"-"`
+	TemplateID int          `db:"template_id" json:"template_id" backup:"-"`
+	Type       ScheduleType `db:"type" json:"type"`
+	CronFormat string       `db:"cron_format" json:"cron_format"`
+	Name       string       `db:"name" json:"name"`
+	Active     bool         `db:"active" json:"active"`
+
+	// IntervalMinutes is the delay between the end of the previous run and the next run
+	// of the interval schedule.
+	IntervalMinutes int `db:"interval_minutes" json:"interval_minutes"`
 
 	LastCommitHash *string `db:"last_commit_hash" json:"-" backup:"-"`
 	RepositoryID   *int    `db:"repository_id" json:"repository_id" backup:"-"`
+
+	// LastFired is the time when the schedule was fired last time.
+	LastFired *time.Time `db:"last_fired" json:"last_fired" backup:"-"`
+
+	// DisableAfterFailures is the number of consecutive failed runs after which
+	// the schedule is disabled. Zero means the schedule is never disabled.
+	DisableAfterFailures int `db:"disable_after_failures" json:"disable_after_failures"`
+	// ConsecutiveFailures is the number of the last runs of the schedule which failed.
+	ConsecutiveFailures int `db:"consecutive_failures" json:"consecutive_failures" backup:"-"`
+	// AutoDisabled is set if the schedule was disabled because of consecutive failures.
+	// It is reset when the schedule is enabled again.
+	AutoDisabled bool `db:"auto_disabled" json:"auto_disabled" backup:"-"`
+}
+
+func (s *Schedule) IsInterval() bool {
+	return s.Type == ScheduleTypeInterval
+}
+
+func (s *Schedule) GetInterval() time.Duration {
+	return time.Duration(s.IntervalMinutes) * time.Minute
+}
+
+// ValidateFailurePolicy checks the number of failures after which the schedule is disabled.
+func (s *Schedule) ValidateFailurePolicy() error {
+	if s.DisableAfterFailures < 0 {
+		return &ValidationError{"number of failures can not be negative"}
+	}
+	return nil
+}
+
+// RecordRun counts the consecutive failures of the schedule after its run finished
+// and disables the schedule if the number of failures reached the limit.
+// It returns true if the schedule was disabled by this run.
+func (s *Schedule) RecordRun(failed bool) bool {
+	if !failed {
+		s.ConsecutiveFailures = 0
+		return false
+	}
+
+	s.ConsecutiveFailures++
+
+	if s.DisableAfterFailures <= 0 || s.AutoDisabled || s.ConsecutiveFailures < s.DisableAfterFailures {
+		return false
+	}
+
+	s.Active = false
+	s.AutoDisabled = true
+	return true
+}
+
+// GetFormat returns the human-readable timing of the schedule.
+func (s *Schedule) GetFormat() string {
+	if s.IsInterval() {
+		return fmt.Sprintf("every %d min after the previous run", s.IntervalMinutes)
+	}
+	return s.CronFormat
+}
+
+// ValidateInterval checks the interval of the interval schedule.
+func (s *Schedule) ValidateInterval() error {
+	if s.IntervalMinutes < 1 {
+		return &ValidationError{"interval must be at least 1 minute"}
+	}
+	return nil
+}
+
+// GetIntervalNextFire returns the time when the interval schedule should fire next.
+// lastTask is the last task started by the schedule, since is the time from which
+// the schedule is expected to fire. It returns nil if the last task is not finished yet.
+func (s *Schedule) GetIntervalNextFire(lastTask *Task, since time.Time) *time.Time {
+	ref := since
+
+	if s.LastFired != nil && s.LastFired.After(ref) {
+		ref = *s.LastFired
+	}
+
+	if lastTask != nil {
+		if !lastTask.Status.IsFinished() {
+			return nil
+		}
+
+		if lastTask.End != nil && lastTask.End.After(ref) {
+			ref = *lastTask.End
+		}
+	}
+
+	next := ref.Add(s.GetInterval())
+	return &next
 }
 
 type ScheduleWithTpl struct {
 	Schedule
 	TemplateName string `db:"tpl_name" json:"tpl_name"`
 }
+
+type ScheduleState string
+
+const (
+	ScheduleStateOK       ScheduleState = "ok"
+	ScheduleStateMissed   ScheduleState = "missed"
+	ScheduleStateDisabled ScheduleState = "disabled"
+	ScheduleStateInvalid  ScheduleState = "invalid"
+)
+
+// ScheduleStatus compares the last fire time of the schedule with the time
+// when the schedule was expected to fire.
+type ScheduleStatus struct {
+	ScheduleID int           `json:"schedule_id"`
+	TemplateID int           `json:"template_id"`
+	Name       string        `json:"name"`
+	CronFormat string        `json:"cron_format"`
+	State      ScheduleState `json:"state"`
+	LastFired  *time.Time    `json:"last_fired"`
+	// ExpectedFired is the last time when the schedule was expected to fire but did not.
+	ExpectedFired *time.Time `json:"expected_fired"`
+	NextFire      *time.Time `json:"next_fire"`
+}
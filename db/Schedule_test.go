@@ -0,0 +1,43 @@
+package db
+
+import "testing"
+
+func TestScheduleRecordRun(t *testing.T) {
+	schedule := Schedule{Active: true, DisableAfterFailures: 2}
+
+	if schedule.RecordRun(true) {
+		t.Fatal("schedule must not be disabled after the first failure")
+	}
+
+	if schedule.RecordRun(false) || schedule.ConsecutiveFailures != 0 {
+		t.Fatal("successful run must reset failures")
+	}
+
+	schedule.RecordRun(true)
+
+	if !schedule.RecordRun(true) {
+		t.Fatal("schedule must be disabled after two failures")
+	}
+
+	if schedule.Active || !schedule.AutoDisabled || schedule.ConsecutiveFailures != 2 {
+		t.Fatal("schedule must be inactive")
+	}
+
+	if schedule.RecordRun(true) {
+		t.Fatal("schedule must be disabled only once")
+	}
+}
+
+func TestScheduleRecordRunWithoutPolicy(t *testing.T) {
+	schedule := Schedule{Active: true}
+
+	for i := 0; i < 10; i++ {
+		if schedule.RecordRun(true) {
+			t.Fatal("schedule without policy must not be disabled")
+		}
+	}
+
+	if !schedule.Active || schedule.ConsecutiveFailures != 10 {
+		t.Fatal("failures must be counted")
+	}
+}
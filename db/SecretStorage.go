@@ -0,0 +1,81 @@
+package db
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// SecretStorage keeps the encrypted secrets of access keys in a separate database,
+// so stricter encryption, backup and access policies can be applied to it.
+// The access_key table keeps references to the secrets only.
+type SecretStorage interface {
+	// GetSecret returns ErrNotFound if the secret does not exist.
+	GetSecret(id string) (string, error)
+	SetSecret(id string, secret string) error
+	DeleteSecret(id string) error
+	Close() error
+}
+
+const secretRefPrefix = "secret-ref:"
+
+var secretStorage SecretStorage
+
+// SetSecretStorage sets the storage of new secrets. Secrets are kept in the
+// access_key table if the storage is nil.
+func SetSecretStorage(storage SecretStorage) {
+	secretStorage = storage
+}
+
+func GetSecretStorage() SecretStorage {
+	return secretStorage
+}
+
+// IsSecretRef returns true if the secret is kept in the secret storage.
+func IsSecretRef(secret *string) bool {
+	return secret != nil && strings.HasPrefix(*secret, secretRefPrefix)
+}
+
+// storeSecret moves the serialized secret to the secret storage and returns the reference to it.
+func storeSecret(secret string) (string, error) {
+	if secretStorage == nil {
+		return secret, nil
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	id := hex.EncodeToString(b)
+
+	if err := secretStorage.SetSecret(id, secret); err != nil {
+		return "", err
+	}
+
+	return secretRefPrefix + id, nil
+}
+
+// loadSecret returns the serialized secret by the reference.
+func loadSecret(secret string) (string, error) {
+	if !IsSecretRef(&secret) {
+		return secret, nil
+	}
+
+	if secretStorage == nil {
+		return "", fmt.Errorf("secret storage is not configured")
+	}
+
+	return secretStorage.GetSecret(strings.TrimPrefix(secret, secretRefPrefix))
+}
+
+// ReleaseSecret removes the secret from the secret storage if the access key
+// refers to it. It is called when the secret of the key is replaced or the key is deleted.
+func ReleaseSecret(secret *string) error {
+	if !IsSecretRef(secret) || secretStorage == nil {
+		return nil
+	}
+
+	return secretStorage.DeleteSecret(strings.TrimPrefix(*secret, secretRefPrefix))
+}
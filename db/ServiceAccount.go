@@ -0,0 +1,131 @@
+package db
+
+import (
+	"crypto/subtle"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServiceAccount is the identity of a machine integration in the project, e.g. a CI system.
+// Tasks launched with its token are not tied to a user, so they keep working when people
+// leave the project. The account can launch only the listed templates.
+// Only the hash of the token is stored.
+type ServiceAccount struct {
+	ID        int    `db:"id" json:"id"`
+	ProjectID int    `db:"project_id" json:"project_id"`
+	Name      string `db:"name" json:"name"`
+	// TemplateIDs are the templates which can be launched by the account.
+	TemplateIDs ServiceAccountTemplates `db:"template_ids" json:"template_ids"`
+	TokenHash   string                  `db:"token_hash" json:"-"`
+	Disabled    bool                    `db:"disabled" json:"disabled"`
+	Created     time.Time               `db:"created" json:"created"`
+	// UserID is the user who created the account.
+	UserID *int `db:"user_id" json:"user_id"`
+
+	// Token is returned only when the token is issued.
+	Token string `db:"-" json:"token,omitempty"`
+}
+
+// ServiceAccountTemplates are IDs of the templates which can be launched by the service account.
+type ServiceAccountTemplates []int
+
+func (ids *ServiceAccountTemplates) Scan(value interface{}) error {
+	if value == nil {
+		*ids = nil
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, ids)
+	case string:
+		return json.Unmarshal([]byte(v), ids)
+	default:
+		return errors.New("unsupported type for ServiceAccountTemplates")
+	}
+}
+
+func (ids ServiceAccountTemplates) Value() (driver.Value, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	return json.Marshal(ids)
+}
+
+func (a *ServiceAccount) Validate() error {
+	a.Name = strings.TrimSpace(a.Name)
+
+	if a.Name == "" {
+		return &ValidationError{"name can not be empty"}
+	}
+
+	if len(a.Name) > 100 {
+		return &ValidationError{"name is too long"}
+	}
+
+	ids := make(map[int]bool)
+
+	for _, id := range a.TemplateIDs {
+		if id <= 0 {
+			return &ValidationError{"invalid template " + strconv.Itoa(id)}
+		}
+
+		if ids[id] {
+			return &ValidationError{"template " + strconv.Itoa(id) + " is listed more than once"}
+		}
+		ids[id] = true
+	}
+
+	return nil
+}
+
+// CanLaunch returns true if the account can launch tasks of the template.
+func (a *ServiceAccount) CanLaunch(templateID int) bool {
+	if a.Disabled {
+		return false
+	}
+
+	for _, id := range a.TemplateIDs {
+		if id == templateID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MakeServiceAccountToken returns the token of the account passed by clients.
+// The token starts with the ID of the account, so the account is found without
+// checking hashes of all accounts of the project.
+func MakeServiceAccountToken(accountID int, secret string) string {
+	return "sa" + strconv.Itoa(accountID) + "_" + secret
+}
+
+// ParseServiceAccountToken returns the ID of the account and the secret of the token.
+func ParseServiceAccountToken(token string) (accountID int, secret string, ok bool) {
+	id, secret, found := strings.Cut(strings.TrimPrefix(token, "sa"), "_")
+	if !found || !strings.HasPrefix(token, "sa") || secret == "" {
+		return
+	}
+
+	accountID, err := strconv.Atoi(id)
+	if err != nil || accountID <= 0 {
+		return 0, "", false
+	}
+
+	ok = true
+	return
+}
+
+// CheckToken returns true if the secret of the token matches the account.
+func (a *ServiceAccount) CheckToken(secret string) bool {
+	if secret == "" || a.TokenHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(a.TokenHash), []byte(HashTaskShareToken(secret))) == 1
+}
@@ -0,0 +1,45 @@
+package db
+
+import "testing"
+
+func TestServiceAccountToken(t *testing.T) {
+	token := MakeServiceAccountToken(12, "c2VjcmV0_x")
+
+	id, secret, ok := ParseServiceAccountToken(token)
+	if !ok || id != 12 || secret != "c2VjcmV0_x" {
+		t.Fatalf("unexpected parsed token %d %s %v", id, secret, ok)
+	}
+
+	for _, invalid := range []string{"", "12_secret", "sa_secret", "sa0_secret", "saX_secret", "sa12_"} {
+		if _, _, ok = ParseServiceAccountToken(invalid); ok {
+			t.Fatalf("expected token %q to be invalid", invalid)
+		}
+	}
+
+	account := ServiceAccount{ID: 12, TemplateIDs: ServiceAccountTemplates{3}, TokenHash: HashTaskShareToken(secret)}
+
+	if !account.CheckToken(secret) || account.CheckToken("other") {
+		t.Fatal("unexpected result of token check")
+	}
+
+	if !account.CanLaunch(3) || account.CanLaunch(4) {
+		t.Fatal("expected the account to launch only the listed templates")
+	}
+
+	account.Disabled = true
+	if account.CanLaunch(3) {
+		t.Fatal("expected the disabled account not to launch templates")
+	}
+}
+
+func TestServiceAccountValidate(t *testing.T) {
+	account := ServiceAccount{Name: "  CI  ", TemplateIDs: ServiceAccountTemplates{1, 2}}
+	if err := account.Validate(); err != nil || account.Name != "CI" {
+		t.Fatalf("expected the account to be valid, got %v", err)
+	}
+
+	account.TemplateIDs = ServiceAccountTemplates{1, 1}
+	if err := account.Validate(); err == nil {
+		t.Fatal("expected the duplicated template to be rejected")
+	}
+}
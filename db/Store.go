@@ -4,7 +4,10 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -58,6 +61,15 @@ type ObjectReferrers struct {
 	Schedules    []ObjectReferrer `json:"schedules"`
 }
 
+// IsEmpty returns true if the object is not used by other objects.
+func (r ObjectReferrers) IsEmpty() bool {
+	return len(r.Templates) == 0 &&
+		len(r.Inventories) == 0 &&
+		len(r.Repositories) == 0 &&
+		len(r.Integrations) == 0 &&
+		len(r.Schedules) == 0
+}
+
 type IntegrationReferrers struct {
 	IntegrationMatchers      []ObjectReferrer `json:"matchers"`
 	IntegrationExtractValues []ObjectReferrer `json:"values"`
@@ -92,6 +104,54 @@ func (e *ValidationError) Error() string {
 	return e.Message
 }
 
+// FieldValidationError contains validation errors of the object by JSON names
+// of the invalid fields, e.g. "inventory_id" or "vaults[0].vault_key_id".
+type FieldValidationError struct {
+	Fields map[string]string
+	// Codes contains codes of the field errors which are more specific than ErrorCodeFieldInvalid.
+	Codes map[string]ErrorCode
+}
+
+func (e *FieldValidationError) Add(field string, message string) {
+	if e.Fields == nil {
+		e.Fields = make(map[string]string)
+	}
+	e.Fields[field] = message
+}
+
+// AddWithCode adds the error of the field with the specific code.
+func (e *FieldValidationError) AddWithCode(field string, code ErrorCode, message string) {
+	e.Add(field, message)
+
+	if e.Codes == nil {
+		e.Codes = make(map[string]ErrorCode)
+	}
+	e.Codes[field] = code
+}
+
+// GetCode returns the code of the error of the field.
+func (e *FieldValidationError) GetCode(field string) ErrorCode {
+	if code, ok := e.Codes[field]; ok {
+		return code
+	}
+	return ErrorCodeFieldInvalid
+}
+
+func (e *FieldValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	messages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		messages = append(messages, field+": "+e.Fields[field])
+	}
+
+	return strings.Join(messages, "; ")
+}
+
 type Store interface {
 	// Connect connects to the database.
 	// Token parameter used if PermanentConnection returns false.
@@ -115,6 +175,14 @@ type Store interface {
 	// TryRollbackMigration attempts to roll back the database to an earlier version
 	// if a rollback exists
 	TryRollbackMigration(version Migration)
+	// GetMigrationPlan describes the migration without applying it.
+	GetMigrationPlan(version Migration) (MigrationPlan, error)
+
+	// TryLock tries to acquire the lock shared by all Semaphore nodes connected to the database.
+	// The lock is held until Unlock is called or the connection to the database is lost.
+	// Calling TryLock for the held lock checks that the lock is still held.
+	TryLock(name string) (bool, error)
+	Unlock(name string) error
 
 	GetOptions(params RetrieveQueryParams) (map[string]string, error)
 	GetOption(key string) (string, error)
@@ -129,6 +197,11 @@ type Store interface {
 	CreateEnvironment(env Environment) (Environment, error)
 	DeleteEnvironment(projectID int, templateID int) error
 	GetEnvironmentSecrets(projectID int, environmentID int) ([]AccessKey, error)
+	// GetProjectDefaultEnvironment returns the environment with project-wide variables and secrets.
+	// It returns ErrNotFound if the project has no defaults.
+	GetProjectDefaultEnvironment(projectID int) (Environment, error)
+	// GetVariableGroups returns environments which are attached to templates as variable groups.
+	GetVariableGroups(projectID int) ([]Environment, error)
 
 	GetInventory(projectID int, inventoryID int) (Inventory, error)
 	GetInventoryRefs(projectID int, inventoryID int) (ObjectReferrers, error)
@@ -136,6 +209,8 @@ type Store interface {
 	UpdateInventory(inventory Inventory) error
 	CreateInventory(inventory Inventory) (Inventory, error)
 	DeleteInventory(projectID int, inventoryID int) error
+	// GetInventorySecrets returns access keys which hold secret variables of the inventory.
+	GetInventorySecrets(projectID int, inventoryID int) ([]AccessKey, error)
 
 	GetRepository(projectID int, repositoryID int) (Repository, error)
 	GetRepositoryRefs(projectID int, repositoryID int) (ObjectReferrers, error)
@@ -176,6 +251,17 @@ type Store interface {
 	DeleteIntegrationAlias(projectID int, aliasID int) error
 	GetAllSearchableIntegrations() ([]Integration, error)
 
+	CreateIntegrationDelivery(delivery IntegrationDelivery, maxDeliveries int) (IntegrationDelivery, error)
+	GetIntegrationDeliveries(projectID int, integrationID int, params RetrieveQueryParams) ([]IntegrationDelivery, error)
+	GetIntegrationDelivery(projectID int, integrationID int, deliveryID int) (IntegrationDelivery, error)
+
+	// AppendRunRecord links the record to the last record of the project and stores it.
+	// Run records can not be updated or deleted.
+	AppendRunRecord(record RunRecord) (RunRecord, error)
+	// GetRunRecords returns records of the project ordered by Seq.
+	GetRunRecords(projectID int, params RetrieveQueryParams) ([]RunRecord, error)
+	GetRunRecord(projectID int, recordID int) (RunRecord, error)
+
 	UpdateAccessKey(accessKey AccessKey) error
 	CreateAccessKey(accessKey AccessKey) (AccessKey, error)
 	DeleteAccessKey(projectID int, accessKeyID int) error
@@ -190,6 +276,10 @@ type Store interface {
 	// Pwd should be present of you want update user password. Empty Pwd ignored.
 	UpdateUser(user UserWithPwd) error
 	SetUserPassword(userID int, password string) error
+	// GetUserPasswordHistory returns the previous password hashes of the user, newest first.
+	// The previous hash is added to the history by UpdateUser and SetUserPassword
+	// when the password is changed.
+	GetUserPasswordHistory(userID int, limit int) ([]UserPassword, error)
 	GetUser(userID int) (User, error)
 	GetUserByLoginOrEmail(login string, email string) (User, error)
 
@@ -199,8 +289,15 @@ type Store interface {
 	CreateProject(project Project) (Project, error)
 	DeleteProject(projectID int) error
 	UpdateProject(project Project) error
+	SetProjectCalendarToken(projectID int, token *string) error
+	SetProjectArchived(projectID int, archived bool) error
+	SetProjectAlertDigestSent(projectID int, sent time.Time) error
 
 	GetTemplates(projectID int, filter TemplateFilter, params RetrieveQueryParams) ([]Template, error)
+	// GetTemplatesStats returns task and schedule counters of all templates of the project
+	// which have tasks or schedules. Task counters are taken from the summaries of the templates.
+	// NextRun is not filled.
+	GetTemplatesStats(projectID int) ([]TemplateStats, error)
 	GetTemplateRefs(projectID int, templateID int) (ObjectReferrers, error)
 	CreateTemplate(template Template) (Template, error)
 	UpdateTemplate(template Template) error
@@ -213,8 +310,16 @@ type Store interface {
 	CreateSchedule(schedule Schedule) (Schedule, error)
 	UpdateSchedule(schedule Schedule) error
 	SetScheduleCommitHash(projectID int, scheduleID int, hash string) error
+	// SetScheduleActive enables or disables the schedule. Enabling the schedule
+	// resets the counter of consecutive failures.
 	SetScheduleActive(projectID int, scheduleID int, active bool) error
+	// SetScheduleFailures stores the counter of consecutive failures of the schedule,
+	// the schedule is disabled if autoDisabled is true.
+	SetScheduleFailures(projectID int, scheduleID int, failures int, autoDisabled bool) error
+	SetScheduleLastFired(projectID int, scheduleID int, lastFired time.Time) error
 	GetSchedule(projectID int, scheduleID int) (Schedule, error)
+	// GetScheduleLastTask returns the last task started by the schedule or ErrNotFound.
+	GetScheduleLastTask(projectID int, scheduleID int) (Task, error)
 	DeleteSchedule(projectID int, scheduleID int) error
 
 	GetAllAdmins() ([]User, error)
@@ -223,10 +328,17 @@ type Store interface {
 	DeleteProjectUser(projectID int, userID int) error
 	GetProjectUser(projectID int, userID int) (ProjectUser, error)
 	UpdateProjectUser(projectUser ProjectUser) error
+	// DeleteExpiredProjectUsers removes memberships of all projects which expired at the time
+	// and returns the removed memberships.
+	DeleteExpiredProjectUsers(now time.Time) ([]ProjectUser, error)
+	// GetProjectUsersLastActivity returns the time of the last event of every user in the project.
+	GetProjectUsersLastActivity(projectID int) (map[int]time.Time, error)
 
 	CreateEvent(event Event) (Event, error)
 	GetUserEvents(userID int, params RetrieveQueryParams) ([]Event, error)
 	GetEvents(projectID int, params RetrieveQueryParams) ([]Event, error)
+	// GetActivity returns events of the project sorted by ID in descending order.
+	GetActivity(projectID int, params ActivityQueryParams) ([]Event, error)
 
 	GetAPITokens(userID int) ([]APIToken, error)
 	CreateAPIToken(token APIToken) (APIToken, error)
@@ -238,6 +350,24 @@ type Store interface {
 	CreateSession(session Session) (Session, error)
 	ExpireSession(userID int, sessionID int) error
 	TouchSession(userID int, sessionID int) error
+	// DeleteInactiveSessions removes expired sessions and sessions which were not active since lastActiveBefore.
+	DeleteInactiveSessions(lastActiveBefore time.Time) (int, error)
+
+	GetIdempotencyKey(keyID string) (IdempotencyKey, error)
+	// CreateIdempotencyKey reserves the key for the request being processed.
+	// It returns ErrInvalidOperation if the key is already reserved.
+	CreateIdempotencyKey(key IdempotencyKey) error
+	SetIdempotencyKeyResponse(keyID string, status int, response string) error
+	DeleteIdempotencyKey(keyID string) error
+	// DeleteIdempotencyKeysBefore removes keys created before the time and returns their number.
+	DeleteIdempotencyKeysBefore(created time.Time) (int, error)
+
+	// GetAnnouncements returns all announcements, the newest first.
+	GetAnnouncements() ([]Announcement, error)
+	GetAnnouncement(announcementID int) (Announcement, error)
+	CreateAnnouncement(announcement Announcement) (Announcement, error)
+	UpdateAnnouncement(announcement Announcement) error
+	DeleteAnnouncement(announcementID int) error
 
 	CreateTask(task Task, maxTasks int) (Task, error)
 	UpdateTask(task Task) error
@@ -246,11 +376,51 @@ type Store interface {
 	GetProjectTasks(projectID int, params RetrieveQueryParams) ([]TaskWithTpl, error)
 	GetTask(projectID int, taskID int) (Task, error)
 	DeleteTaskWithOutputs(projectID int, taskID int) error
+	// GetProjectScheduledTasks returns tasks started by schedules of the project which finished in the period [from, to).
+	GetProjectScheduledTasks(projectID int, from time.Time, to time.Time) ([]Task, error)
+	// GetProjectTaskHistory returns tasks of the project created in the period [from, to) in the order of creation.
+	GetProjectTaskHistory(projectID int, from time.Time, to time.Time) ([]TaskWithTpl, error)
+	// GetFinishedTasksCreatedBefore returns finished tasks of all projects created before the time.
+	GetFinishedTasksCreatedBefore(before time.Time, limit int) ([]Task, error)
+	// GetUnfinishedTasks returns waiting and running tasks of all projects.
+	GetUnfinishedTasks() ([]Task, error)
+	// ClaimTask sets the node which runs the task. It returns false
+	// if the task is already claimed by another node.
+	ClaimTask(taskID int, nodeID string) (bool, error)
 	GetTaskOutputs(projectID int, taskID int) ([]TaskOutput, error)
+	// GetTaskOutputsPage returns params.Count output records of the task
+	// after params.Offset records in the order of GetTaskOutputs.
+	GetTaskOutputsPage(projectID int, taskID int, params RetrieveQueryParams) ([]TaskOutput, error)
 	CreateTaskOutput(output TaskOutput) (TaskOutput, error)
+	// CreateTaskOutputs stores several output records by one query.
+	CreateTaskOutputs(outputs []TaskOutput) error
+	// SetTaskOutputObject marks the output of the task as moved to the object storage
+	// and removes the output from the database.
+	SetTaskOutputObject(projectID int, taskID int, objectKey string) error
 	GetTaskStages(projectID int, taskID int) ([]TaskStage, error)
+	CreateTaskFinding(finding TaskFinding) (TaskFinding, error)
+	GetTaskFindings(projectID int, taskID int) ([]TaskFinding, error)
+	CreateRunOutput(output RunOutput) (RunOutput, error)
+	GetRunOutputs(projectID int, taskID int) ([]RunOutput, error)
+	CreateTaskArtifact(artifact TaskArtifact) (TaskArtifact, error)
+	GetTaskArtifacts(projectID int, taskID int) ([]TaskArtifact, error)
+	CreateTerraformPlanChange(change TerraformPlanChange) (TerraformPlanChange, error)
+	GetTerraformPlanChanges(projectID int, taskID int) ([]TerraformPlanChange, error)
+
+	GetTaskComment(projectID int, commentID int) (TaskComment, error)
+	GetTaskComments(projectID int, filter TaskCommentFilter) ([]TaskComment, error)
+	CreateTaskComment(comment TaskComment) (TaskComment, error)
+	DeleteTaskComment(projectID int, commentID int) error
 	CreateTaskStage(stage TaskStage) (TaskStage, error)
 
+	// CreateTaskObjectVersions stores versions of the objects used by the task.
+	CreateTaskObjectVersions(versions []TaskObjectVersion) error
+	GetTaskObjectVersions(projectID int, taskID int) ([]TaskObjectVersion, error)
+	GetTaskShareLink(projectID int, linkID int) (TaskShareLink, error)
+	GetTaskShareLinks(projectID int, taskID int) ([]TaskShareLink, error)
+	CreateTaskShareLink(link TaskShareLink) (TaskShareLink, error)
+	DeleteTaskShareLink(projectID int, linkID int) error
+
 	GetView(projectID int, viewID int) (View, error)
 	GetViews(projectID int) ([]View, error)
 	UpdateView(view View) error
@@ -258,6 +428,48 @@ type Store interface {
 	DeleteView(projectID int, viewID int) error
 	SetViewPositions(projectID int, viewPositions map[int]int) error
 
+	GetAdhocCommand(projectID int, commandID int) (AdhocCommand, error)
+	GetAdhocCommands(projectID int, params RetrieveQueryParams) ([]AdhocCommand, error)
+	CreateAdhocCommand(command AdhocCommand) (AdhocCommand, error)
+	// UpdateAdhocCommand updates status and run time of the command.
+	UpdateAdhocCommand(command AdhocCommand) error
+	GetAdhocCommandOutputs(projectID int, commandID int) ([]AdhocCommandOutput, error)
+	CreateAdhocCommandOutput(output AdhocCommandOutput) error
+
+	GetMaskingRule(projectID int, ruleID int) (MaskingRule, error)
+	GetMaskingRules(projectID int) ([]MaskingRule, error)
+	UpdateMaskingRule(rule MaskingRule) error
+	CreateMaskingRule(rule MaskingRule) (MaskingRule, error)
+	DeleteMaskingRule(projectID int, ruleID int) error
+
+	GetHostExclusion(projectID int, exclusionID int) (HostExclusion, error)
+	GetHostExclusions(projectID int) ([]HostExclusion, error)
+	UpdateHostExclusion(exclusion HostExclusion) error
+	CreateHostExclusion(exclusion HostExclusion) (HostExclusion, error)
+	DeleteHostExclusion(projectID int, exclusionID int) error
+	// DeleteExpiredHostExclusions removes exclusions of all projects which expired at the time
+	// and returns the removed exclusions.
+	DeleteExpiredHostExclusions(now time.Time) ([]HostExclusion, error)
+
+	GetServiceAccount(projectID int, accountID int) (ServiceAccount, error)
+	GetServiceAccounts(projectID int) ([]ServiceAccount, error)
+	CreateServiceAccount(account ServiceAccount) (ServiceAccount, error)
+	// UpdateServiceAccount updates the name, the templates and the state of the account.
+	UpdateServiceAccount(account ServiceAccount) error
+	SetServiceAccountToken(projectID int, accountID int, tokenHash string) error
+	DeleteServiceAccount(projectID int, accountID int) error
+
+	GetDeploymentEnvironment(projectID int, envID int) (DeploymentEnvironment, error)
+	GetDeploymentEnvironments(projectID int) ([]DeploymentEnvironment, error)
+	UpdateDeploymentEnvironment(env DeploymentEnvironment) error
+	CreateDeploymentEnvironment(env DeploymentEnvironment) (DeploymentEnvironment, error)
+	DeleteDeploymentEnvironment(projectID int, envID int) error
+	SetDeploymentEnvironmentPositions(projectID int, positions map[int]int) error
+	// GetLastDeploymentTask returns the last successful task of the template in the deployment environment.
+	GetLastDeploymentTask(projectID int, templateID int, envID int) (Task, error)
+	// GetDeploymentTasks returns successful tasks of the template in the deployment environment, newest first.
+	GetDeploymentTasks(projectID int, templateID int, envID int) ([]Task, error)
+
 	GetRunner(projectID int, runnerID int) (Runner, error)
 	GetRunners(projectID int, activeOnly bool) ([]Runner, error)
 	DeleteRunner(projectID int, runnerID int) error
@@ -271,6 +483,66 @@ type Store interface {
 	GetTemplateVaults(projectID int, templateID int) ([]TemplateVault, error)
 	CreateTemplateVault(vault TemplateVault) (TemplateVault, error)
 	UpdateTemplateVaults(projectID int, templateID int, vaults []TemplateVault) error
+
+	GetTemplateUploads(projectID int, templateID int) ([]TemplateUpload, error)
+	GetTemplateUpload(projectID int, templateID int, uploadID int) (TemplateUpload, error)
+	CreateTemplateUpload(upload TemplateUpload) (TemplateUpload, error)
+	UpdateTemplateUpload(upload TemplateUpload) error
+	DeleteTemplateUpload(projectID int, templateID int, uploadID int) error
+
+	// GetTemplateDoc returns ErrNotFound if the playbook of the template was not parsed yet.
+	GetTemplateDoc(projectID int, templateID int) (TemplateDoc, error)
+	// SetTemplateDoc replaces the documentation of the template.
+	SetTemplateDoc(doc TemplateDoc) error
+
+	// GetTemplateSummary returns ErrNotFound if the template has no summary yet.
+	GetTemplateSummary(projectID int, templateID int) (TemplateSummary, error)
+	GetTemplateSummaries(projectID int) ([]TemplateSummary, error)
+	// SetTemplateSummary replaces the summary of the task history of the template.
+	SetTemplateSummary(summary TemplateSummary) error
+
+	CreateTaskMetric(metric TaskMetric) (TaskMetric, error)
+	// GetTaskMetrics returns metrics of the project which are not rolled up yet, oldest first.
+	GetTaskMetrics(projectID int, filter TaskMetricFilter) ([]TaskMetric, error)
+	// GetTaskMetricsBefore returns metrics of all projects of the tasks finished before the time, oldest first.
+	GetTaskMetricsBefore(before time.Time, limit int) ([]TaskMetric, error)
+	// RollupTaskMetrics adds the metrics to the daily rollups and removes the metrics.
+	RollupTaskMetrics(metrics []TaskMetric) error
+	// GetTaskMetricsDaily returns daily rollups of the project ordered by day.
+	GetTaskMetricsDaily(projectID int, filter TaskMetricFilter) ([]TaskMetricDaily, error)
+	// DeleteTaskMetricsDailyBefore removes rollups of all projects of the days before the time
+	// and returns the number of removed rollups.
+	DeleteTaskMetricsDailyBefore(before time.Time) (int, error)
+
+	CreateTaskHosts(hosts []TaskHost) error
+	// GetTaskHosts returns results of the tasks of the project on the hosts, oldest first.
+	GetTaskHosts(projectID int, filter TaskHostFilter) ([]TaskHost, error)
+
+	CreateTaskApproval(approval TaskApproval) (TaskApproval, error)
+	GetTaskApproval(projectID int, approvalID int) (TaskApproval, error)
+	// UseTaskApproval stores the decision of the approval. It returns ErrNotFound
+	// if the approval does not exist or is already used, so the link can be used once.
+	UseTaskApproval(projectID int, approvalID int, decision TaskApprovalDecision, used time.Time) error
+
+	// SetDiskUsage replaces the disk usage of the runner or of the server if runnerID is nil.
+	SetDiskUsage(runnerID *int, usage []DiskUsage) error
+	// GetDiskUsage returns the disk usage of the project or of all projects and shared data if projectID is nil.
+	GetDiskUsage(projectID *int) ([]DiskUsage, error)
+
+	// GetOrphanedReferences returns rows which refer to deleted objects.
+	GetOrphanedReferences() ([]OrphanedReference, error)
+	// FixOrphanedReference unsets the dangling reference or purges the row according to the fix of the reference.
+	FixOrphanedReference(ref OrphanedReference) error
+
+	CreatePullHost(host PullHost) (PullHost, error)
+	GetPullHost(projectID int, hostID int) (PullHost, error)
+	GetPullHosts(projectID int, templateID int) ([]PullHost, error)
+	// GetPullHostByToken returns the host of any project by the hash of its token.
+	GetPullHostByToken(tokenHash string) (PullHost, error)
+	// GetPullHostByRepositoryToken returns the host of any project by the hash of its repository token.
+	GetPullHostByRepositoryToken(tokenHash string) (PullHost, error)
+	UpdatePullHost(host PullHost) error
+	DeletePullHost(projectID int, hostID int) error
 }
 
 var AccessKeyProps = ObjectProps{
@@ -313,6 +585,19 @@ var IntegrationAliasProps = ObjectProps{
 	PrimaryColumnName: "id",
 }
 
+var IntegrationDeliveryProps = ObjectProps{
+	TableName:         "project__integration_delivery",
+	Type:              reflect.TypeOf(IntegrationDelivery{}),
+	PrimaryColumnName: "id",
+	SortInverted:      true,
+}
+
+var RunRecordProps = ObjectProps{
+	TableName:         "project__run_record",
+	Type:              reflect.TypeOf(RunRecord{}),
+	PrimaryColumnName: "id",
+}
+
 var EnvironmentProps = ObjectProps{
 	TableName:             "project__environment",
 	Type:                  reflect.TypeOf(Environment{}),
@@ -376,6 +661,21 @@ var UserProps = ObjectProps{
 	IsGlobal:          true,
 }
 
+var AnnouncementProps = ObjectProps{
+	TableName:         "announcement",
+	Type:              reflect.TypeOf(Announcement{}),
+	PrimaryColumnName: "id",
+	IsGlobal:          true,
+	SortInverted:      true,
+}
+
+var IdempotencyKeyProps = ObjectProps{
+	TableName:         "idempotency_key",
+	Type:              reflect.TypeOf(IdempotencyKey{}),
+	PrimaryColumnName: "id",
+	IsGlobal:          true,
+}
+
 var SessionProps = ObjectProps{
 	TableName:         "session",
 	Type:              reflect.TypeOf(Session{}),
@@ -388,6 +688,12 @@ var TokenProps = ObjectProps{
 	PrimaryColumnName: "id",
 }
 
+var UserPasswordProps = ObjectProps{
+	TableName:         "user__password_history",
+	Type:              reflect.TypeOf(UserPassword{}),
+	PrimaryColumnName: "id",
+}
+
 var TaskProps = ObjectProps{
 	TableName:         "task",
 	Type:              reflect.TypeOf(Task{}),
@@ -401,6 +707,50 @@ var TaskOutputProps = ObjectProps{
 	Type:      reflect.TypeOf(TaskOutput{}),
 }
 
+var TaskObjectVersionProps = ObjectProps{
+	TableName:         "task__object_version",
+	Type:              reflect.TypeOf(TaskObjectVersion{}),
+	PrimaryColumnName: "id",
+}
+
+var TaskFindingProps = ObjectProps{
+	TableName:         "task__finding",
+	Type:              reflect.TypeOf(TaskFinding{}),
+	PrimaryColumnName: "id",
+}
+
+var RunOutputProps = ObjectProps{
+	TableName:         "task__run_output",
+	Type:              reflect.TypeOf(RunOutput{}),
+	PrimaryColumnName: "id",
+}
+
+var TaskArtifactProps = ObjectProps{
+	TableName:         "task__artifact",
+	Type:              reflect.TypeOf(TaskArtifact{}),
+	PrimaryColumnName: "id",
+}
+
+var TerraformPlanChangeProps = ObjectProps{
+	TableName:         "task__terraform_plan_change",
+	Type:              reflect.TypeOf(TerraformPlanChange{}),
+	PrimaryColumnName: "id",
+}
+
+var TaskCommentProps = ObjectProps{
+	TableName:            "task__comment",
+	Type:                 reflect.TypeOf(TaskComment{}),
+	PrimaryColumnName:    "id",
+	DefaultSortingColumn: "id",
+}
+
+var TaskShareLinkProps = ObjectProps{
+	TableName:            "task__share_link",
+	Type:                 reflect.TypeOf(TaskShareLink{}),
+	PrimaryColumnName:    "id",
+	DefaultSortingColumn: "id",
+}
+
 var TaskStageProps = ObjectProps{
 	TableName: "task__stage",
 	Type:      reflect.TypeOf(TaskStage{}),
@@ -413,6 +763,44 @@ var ViewProps = ObjectProps{
 	DefaultSortingColumn: "position",
 }
 
+var AdhocCommandProps = ObjectProps{
+	TableName:            "project__adhoc_command",
+	Type:                 reflect.TypeOf(AdhocCommand{}),
+	PrimaryColumnName:    "id",
+	DefaultSortingColumn: "id",
+	SortInverted:         true,
+}
+
+var AdhocCommandOutputProps = ObjectProps{
+	TableName: "project__adhoc_command__output",
+	Type:      reflect.TypeOf(AdhocCommandOutput{}),
+}
+
+var MaskingRuleProps = ObjectProps{
+	TableName:         "project__masking_rule",
+	Type:              reflect.TypeOf(MaskingRule{}),
+	PrimaryColumnName: "id",
+}
+
+var ServiceAccountProps = ObjectProps{
+	TableName:         "project__service_account",
+	Type:              reflect.TypeOf(ServiceAccount{}),
+	PrimaryColumnName: "id",
+}
+
+var HostExclusionProps = ObjectProps{
+	TableName:         "project__host_exclusion",
+	Type:              reflect.TypeOf(HostExclusion{}),
+	PrimaryColumnName: "id",
+}
+
+var DeploymentEnvironmentProps = ObjectProps{
+	TableName:            "project__deployment_environment",
+	Type:                 reflect.TypeOf(DeploymentEnvironment{}),
+	PrimaryColumnName:    "id",
+	DefaultSortingColumn: "position",
+}
+
 var RunnerProps = ObjectProps{
 	TableName:         "runner",
 	Type:              reflect.TypeOf(Runner{}),
@@ -440,6 +828,62 @@ var TemplateVaultProps = ObjectProps{
 	ReferringColumnSuffix: "template_id",
 }
 
+var TemplateDocProps = ObjectProps{
+	TableName:         "project__template_doc",
+	Type:              reflect.TypeOf(TemplateDoc{}),
+	PrimaryColumnName: "template_id",
+}
+
+var TemplateSummaryProps = ObjectProps{
+	TableName:         "project__template_summary",
+	Type:              reflect.TypeOf(TemplateSummary{}),
+	PrimaryColumnName: "template_id",
+}
+
+var TaskMetricProps = ObjectProps{
+	TableName:         "project__task_metric",
+	Type:              reflect.TypeOf(TaskMetric{}),
+	PrimaryColumnName: "id",
+}
+
+var TaskMetricDailyProps = ObjectProps{
+	TableName:         "project__task_metric_daily",
+	Type:              reflect.TypeOf(TaskMetricDaily{}),
+	PrimaryColumnName: "id",
+}
+
+var TemplateUploadProps = ObjectProps{
+	TableName:            "project__template_upload",
+	Type:                 reflect.TypeOf(TemplateUpload{}),
+	PrimaryColumnName:    "id",
+	DefaultSortingColumn: "path",
+}
+
+var TaskHostProps = ObjectProps{
+	TableName:         "project__task_host",
+	Type:              reflect.TypeOf(TaskHost{}),
+	PrimaryColumnName: "id",
+}
+
+var TaskApprovalProps = ObjectProps{
+	TableName:         "task__approval",
+	Type:              reflect.TypeOf(TaskApproval{}),
+	PrimaryColumnName: "id",
+}
+
+var PullHostProps = ObjectProps{
+	TableName:         "project__pull_host",
+	Type:              reflect.TypeOf(PullHost{}),
+	PrimaryColumnName: "id",
+}
+
+var DiskUsageProps = ObjectProps{
+	TableName:         "disk_usage",
+	Type:              reflect.TypeOf(DiskUsage{}),
+	PrimaryColumnName: "id",
+	IsGlobal:          true,
+}
+
 func (p ObjectProps) GetReferringFieldsFrom(t reflect.Type) (fields []string, err error) {
 	n := t.NumField()
 	for i := 0; i < n; i++ {
@@ -483,6 +927,10 @@ func ValidateRepository(store Store, repo *Repository) (err error) {
 }
 
 func ValidateInventory(store Store, inventory *Inventory) (err error) {
+	if err = inventory.SSHOptions.Validate(); err != nil {
+		return
+	}
+
 	if inventory.SSHKeyID != nil {
 		_, err = store.GetAccessKey(inventory.ProjectID, *inventory.SSHKeyID)
 	}
@@ -506,6 +954,189 @@ func ValidateInventory(store Store, inventory *Inventory) (err error) {
 	return
 }
 
+// ValidateTemplate checks that the objects referenced by the template exist in the project
+// of the template and the keys of the template can be used in their roles.
+// Problems are returned as FieldValidationError.
+func ValidateTemplate(store Store, template *Template) error {
+	fieldErr := &FieldValidationError{}
+
+	// notFound adds the field error if the object does not exist and returns other errors
+	notFound := func(err error, field string, message string) error {
+		if errors.Is(err, ErrNotFound) {
+			fieldErr.AddWithCode(field, ErrorCodeTemplateReferenceMissing, message)
+			return nil
+		}
+		return err
+	}
+
+	if template.RepositoryID == 0 {
+		fieldErr.Add("repository_id", "template repository can not be empty")
+	} else if _, err := store.GetRepository(template.ProjectID, template.RepositoryID); err != nil {
+		if err = notFound(err, "repository_id", "repository not found in the project"); err != nil {
+			return err
+		}
+	}
+
+	if template.InventoryID != nil {
+		inventory, err := store.GetInventory(template.ProjectID, *template.InventoryID)
+		if err != nil {
+			if err = notFound(err, "inventory_id", "inventory not found in the project"); err != nil {
+				return err
+			}
+		} else if template.App.IsAnsible() {
+			switch {
+			case inventory.Type == InventoryTerraformWorkspace || inventory.Type == InventoryTofuWorkspace:
+				fieldErr.Add("inventory_id", "workspace inventory can not be used by ansible template")
+			case inventory.SSHKeyID != nil && !inventory.SSHKey.IsUsableAs(AccessKeyRoleAnsibleUser):
+				fieldErr.Add("inventory_id", "inventory user key type can not be used to access hosts")
+			case inventory.BecomeKeyID != nil && !inventory.BecomeKey.IsUsableAs(AccessKeyRoleAnsibleBecomeUser):
+				fieldErr.Add("inventory_id", "inventory become key type can not be used to become user")
+			default:
+				if err = validateRunAsUsers(template, inventory, fieldErr); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if template.EnvironmentID != nil {
+		if env, err := store.GetEnvironment(template.ProjectID, *template.EnvironmentID); err != nil {
+			if err = notFound(err, "environment_id", "environment not found in the project"); err != nil {
+				return err
+			}
+		} else if env.VariableGroup {
+			fieldErr.Add("environment_id", "variable group can not be used as environment")
+		}
+	}
+
+	for _, groupID := range template.VariableGroupIDs {
+		if _, err := GetVariableGroup(store, template.ProjectID, groupID); err != nil {
+			if err = notFound(err, "variable_group_ids", "variable group not found in the project"); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	if template.BuildTemplateID != nil {
+		if _, err := store.GetTemplate(template.ProjectID, *template.BuildTemplateID); err != nil {
+			if err = notFound(err, "build_template_id", "build template not found in the project"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if template.AbortTemplateID != nil {
+		abortTemplate, err := store.GetTemplate(template.ProjectID, *template.AbortTemplateID)
+		if err != nil {
+			if err = notFound(err, "abort_template_id", "abort template not found"); err != nil {
+				return err
+			}
+		} else if abortTemplate.HasChangeWindow() {
+			// the abort template runs after the window is closed
+			fieldErr.Add("abort_template_id", "abort template can not have change window")
+		}
+	}
+
+	for i, vault := range template.Vaults {
+		if vault.VaultKeyID == nil {
+			continue
+		}
+
+		field := "vaults[" + strconv.Itoa(i) + "].vault_key_id"
+
+		key, err := store.GetAccessKey(template.ProjectID, *vault.VaultKeyID)
+		if err != nil {
+			if err = notFound(err, field, "key not found in the project"); err != nil {
+				return err
+			}
+		} else if vault.Type == TemplateVaultPassword && !key.IsUsableAs(AccessKeyRoleAnsiblePasswordVault) {
+			fieldErr.Add(field, "key type can not be used as vault password")
+		}
+	}
+
+	for i, file := range template.SecretFiles {
+		if file.KeyID == 0 {
+			continue
+		}
+
+		field := "secret_files[" + strconv.Itoa(i) + "].key_id"
+
+		key, err := store.GetAccessKey(template.ProjectID, file.KeyID)
+		if err != nil {
+			if err = notFound(err, field, "key not found in the project"); err != nil {
+				return err
+			}
+		} else if key.Type != AccessKeyString && key.Type != AccessKeySSH {
+			fieldErr.Add(field, "only string and SSH keys can be installed as secret files")
+		}
+	}
+
+	for i, artifact := range template.Artifacts {
+		if artifact.KeyID == nil {
+			continue
+		}
+
+		field := "artifacts[" + strconv.Itoa(i) + "].key_id"
+
+		key, err := store.GetAccessKey(template.ProjectID, *artifact.KeyID)
+		if err != nil {
+			if err = notFound(err, field, "key not found in the project"); err != nil {
+				return err
+			}
+		} else if key.Type != AccessKeyLoginPassword && (artifact.Registry != ArtifactRegistryGitHubRelease || key.Type != AccessKeyString) {
+			fieldErr.Add(field, "key type can not be used by the registry")
+		}
+	}
+
+	if len(fieldErr.Fields) > 0 {
+		return fieldErr
+	}
+
+	return nil
+}
+
+// validateRunAsUsers checks the remote and become users of the ansible template against
+// the keys of the inventory. The password of the login/password key belongs to its login,
+// so the key can not be used by another user.
+func validateRunAsUsers(template *Template, inventory Inventory, fieldErr *FieldValidationError) error {
+	var params AnsibleTemplateParams
+	if err := template.GetParams(&params); err != nil {
+		// invalid params are reported by Template.Validate
+		return nil
+	}
+
+	checks := []struct {
+		user  string
+		keyID *int
+		key   AccessKey
+		field string
+		name  string
+	}{
+		{params.RemoteUser, inventory.SSHKeyID, inventory.SSHKey, "task_params.remote_user", "remote user"},
+		{params.BecomeUser, inventory.BecomeKeyID, inventory.BecomeKey, "task_params.become_user", "become user"},
+	}
+
+	for _, check := range checks {
+		if check.user == "" || check.keyID == nil || check.key.Type != AccessKeyLoginPassword {
+			continue
+		}
+
+		key := check.key
+		if err := key.DeserializeSecret(); err != nil {
+			return err
+		}
+
+		if key.LoginPassword.Login != "" && key.LoginPassword.Login != check.user {
+			fieldErr.Add(check.field, fmt.Sprintf(
+				"%s %s does not match login %s of the inventory login/password key",
+				check.name, check.user, key.LoginPassword.Login))
+		}
+	}
+
+	return nil
+}
+
 type MapStringAnyField map[string]interface{}
 
 func (m *MapStringAnyField) Scan(value interface{}) error {
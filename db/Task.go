@@ -17,12 +17,24 @@ type TerraformTaskParams struct {
 	Plan        bool `json:"plan"`
 	Destroy     bool `json:"destroy"`
 	AutoApprove bool `json:"auto_approve"`
+	// Workspace is the workspace the task runs in. If it is empty, the workspace
+	// of the inventory is used. The workspace is created if it does not exist.
+	Workspace string `json:"workspace"`
 }
 
 type AnsibleTaskParams struct {
 	Debug  bool `json:"debug"`
 	DryRun bool `json:"dry_run"`
 	Diff   bool `json:"diff"`
+	// GalaxyForceRefresh reinstalls galaxy requirements even if they are cached.
+	GalaxyForceRefresh bool `json:"galaxy_force_refresh"`
+	// Canary is the size of the canary batch, the number of hosts or the percentage
+	// of hosts, e.g. "2" or "10%". If it is set, the playbook runs against the canary
+	// batch first and against the remaining hosts after the confirmation.
+	Canary string `json:"canary,omitempty"`
+	// Execution overrides forks, serial and strategy of the template
+	// if the template allows it.
+	Execution *AnsibleExecution `json:"execution,omitempty"`
 }
 
 // Task is a model of a task which will be executed by the runner
@@ -45,14 +57,24 @@ type Task struct {
 	Arguments   *string `db:"arguments" json:"arguments"`
 	GitBranch   *string `db:"git_branch" json:"git_branch"`
 
+	// BecomePassword is the become password entered at the launch of the task.
+	// It is kept encrypted in memory for the run only and is never stored.
+	BecomePassword string `db:"-" json:"become_password,omitempty" backup:"-"`
+
 	UserID        *int `db:"user_id" json:"user_id"`
 	IntegrationID *int `db:"integration_id" json:"integration_id"`
 	ScheduleID    *int `db:"schedule_id" json:"schedule_id"`
+	// ServiceAccountID is the service account of the project which launched the task.
+	ServiceAccountID *int `db:"service_account_id" json:"service_account_id"`
 
 	Created time.Time  `db:"created" json:"created"`
 	Start   *time.Time `db:"start" json:"start"`
 	End     *time.Time `db:"end" json:"end"`
 
+	// RunAt delays the start of the task. The task waits in the queue
+	// until this time and can be cancelled before it starts.
+	RunAt *time.Time `db:"run_at" json:"run_at"`
+
 	Message string `db:"message" json:"message"`
 
 	// CommitMessage is a git commit hash of playbook repository which
@@ -68,7 +90,67 @@ type Task struct {
 
 	InventoryID *int `db:"inventory_id" json:"inventory_id"`
 
+	// DeploymentEnvironmentID is the environment to which the task deploys.
+	DeploymentEnvironmentID *int `db:"deployment_environment_id" json:"deployment_environment_id"`
+	// RollbackTaskID is the task whose commit and variables were re-run by the rollback.
+	RollbackTaskID *int `db:"rollback_task_id" json:"rollback_task_id"`
+
+	// BudgetExceeded is set if the task runs longer than the runtime budget of the template.
+	BudgetExceeded bool `db:"budget_exceeded" json:"budget_exceeded"`
+
+	// ChangeWindowClosed is set if the change window of the template closed before
+	// the task completed and the task was aborted.
+	ChangeWindowClosed bool `db:"change_window_closed" json:"change_window_closed"`
+
+	// HostsTotal and HostsUnreachable are counted by the PLAY RECAP of ansible-playbook.
+	HostsTotal       int `db:"hosts_total" json:"hosts_total"`
+	HostsUnreachable int `db:"hosts_unreachable" json:"hosts_unreachable"`
+
+	// TasksTotal is the number of playbook tasks listed before the run,
+	// TasksDone is the number of these tasks completed by the run.
+	// Both are zero if the progress is not tracked.
+	TasksTotal int `db:"tasks_total" json:"tasks_total"`
+	TasksDone  int `db:"tasks_done" json:"tasks_done"`
+
+	// InteractiveBecome is set if the task is launched with the become password
+	// entered at the launch instead of the become key of the inventory.
+	InteractiveBecome bool `db:"interactive_become" json:"interactive_become"`
+
+	// ClaimedBy is the ID of the node which runs the task in HA mode.
+	ClaimedBy *string `db:"claimed_by" json:"claimed_by"`
+
+	// OutputOffset is the number of output records of the running task stored
+	// by the last checkpoint, CheckpointAt is the time of this checkpoint.
+	// The output before the offset survives the restart of the server.
+	OutputOffset int        `db:"output_offset" json:"output_offset"`
+	CheckpointAt *time.Time `db:"checkpoint_at" json:"checkpoint_at"`
+
+	// OutputObject is the key of the object which contains the task output
+	// if the output is moved to the object storage.
+	OutputObject *string `db:"output_object" json:"-"`
+
+	// ParentTaskID is the canary deployment task which started this task as one of its batches.
+	ParentTaskID *int `db:"parent_task_id" json:"parent_task_id"`
+	// CanaryBatch is the batch of the canary deployment run by the task.
+	CanaryBatch CanaryBatch `db:"canary_batch" json:"canary_batch"`
+
 	Params MapStringAnyField `db:"params" json:"params"`
+
+	// PreparationSteps are durations of the steps which prepared the task to run,
+	// e.g. the update of the repository or the installation of the inventory.
+	PreparationSteps TaskPreparationSteps `db:"preparation_steps" json:"preparation_steps"`
+}
+
+// GetProgress returns the percentage of completed playbook tasks
+// or nil if the progress is not tracked.
+func (task *Task) GetProgress() *int {
+	if task.TasksTotal <= 0 {
+		return nil
+	}
+
+	done := min(task.TasksDone, task.TasksTotal)
+	progress := done * 100 / task.TasksTotal
+	return &progress
 }
 
 func (task *Task) GetParams(target interface{}) (err error) {
@@ -83,6 +165,11 @@ func (task *Task) GetParams(target interface{}) (err error) {
 func (task *Task) PreInsert(gorp.SqlExecutor) error {
 	task.Created = task.Created.UTC()
 
+	if task.RunAt != nil {
+		runAt := task.RunAt.UTC()
+		task.RunAt = &runAt
+	}
+
 	// Init params from old fields for backward compatibility
 
 	if task.Debug {
@@ -145,7 +232,15 @@ func (task *Task) GetUrl() *string {
 	return nil
 }
 
+// IsDelayed returns true if the task waits for its start time.
+func (task *Task) IsDelayed() bool {
+	return task.Status == task_logger.TaskWaitingStatus && task.RunAt != nil && task.RunAt.After(time.Now())
+}
+
 func (task *Task) ValidateNewTask(template Template) error {
+	if task.RunAt != nil && !task.RunAt.After(time.Now()) {
+		return &ValidationError{"task start time must be in the future"}
+	}
 
 	var params interface{}
 	switch template.App {
@@ -157,7 +252,30 @@ func (task *Task) ValidateNewTask(template Template) error {
 		params = &DefaultTaskParams{}
 	}
 
-	return task.GetParams(params)
+	if err := task.GetParams(params); err != nil {
+		return err
+	}
+
+	if p, ok := params.(*TerraformTaskParams); ok && p.Workspace != "" && !IsValidTerraformWorkspace(p.Workspace) {
+		return &ValidationError{"invalid terraform workspace name"}
+	}
+
+	if p, ok := params.(*AnsibleTaskParams); ok && p.Execution != nil {
+		var tplParams AnsibleTemplateParams
+		if err := template.GetParams(&tplParams); err != nil {
+			return err
+		}
+
+		if !tplParams.AllowOverrideExecution {
+			return &ValidationError{"template does not allow to override forks, serial and strategy"}
+		}
+
+		if err := p.Execution.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (task *TaskWithTpl) Fill(d Store) error {
@@ -183,6 +301,8 @@ type TaskWithTpl struct {
 	TemplateApp      TemplateApp  `db:"tpl_app" json:"tpl_app"`
 	UserName         *string      `db:"user_name" json:"user_name"`
 	BuildTask        *Task        `db:"-" json:"build_task"`
+	// Labels are labels of the task comments.
+	Labels []string `db:"-" json:"labels,omitempty"`
 }
 
 // TaskOutput is the ansible log output from the task
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"time"
+)
+
+// TaskApprovalLinkLifetime is the lifetime of the links of the approval email.
+const TaskApprovalLinkLifetime = 24 * time.Hour
+
+type TaskApprovalDecision string
+
+const (
+	TaskApprovalApprove TaskApprovalDecision = "approve"
+	TaskApprovalReject  TaskApprovalDecision = "reject"
+)
+
+func (d TaskApprovalDecision) IsValid() bool {
+	return d == TaskApprovalApprove || d == TaskApprovalReject
+}
+
+// TaskApproval allows the approver to confirm or reject the task waiting for confirmation
+// by the link from the email without logging in. Every approver receives own token
+// which can be used once until it expires. Only the hash of the token is stored.
+type TaskApproval struct {
+	ID        int       `db:"id" json:"id"`
+	ProjectID int       `db:"project_id" json:"project_id"`
+	TaskID    int       `db:"task_id" json:"task_id"`
+	UserID    int       `db:"user_id" json:"user_id"`
+	TokenHash string    `db:"token_hash" json:"-"`
+	Created   time.Time `db:"created" json:"created"`
+	Expires   time.Time `db:"expires" json:"expires"`
+
+	// Used and Decision are set when the link is used.
+	Used     *time.Time           `db:"used" json:"used"`
+	Decision TaskApprovalDecision `db:"decision" json:"decision"`
+}
+
+// HashTaskApprovalToken returns the hash of the token stored in the database.
+func HashTaskApprovalToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckToken returns true if the token matches the approval.
+func (a *TaskApproval) CheckToken(token string) bool {
+	if token == "" || a.TokenHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(a.TokenHash), []byte(HashTaskApprovalToken(token))) == 1
+}
+
+// IsUsable returns true if the link is not used and not expired.
+func (a *TaskApproval) IsUsable(now time.Time) bool {
+	return a.Used == nil && now.Before(a.Expires)
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"strings"
+	"time"
+)
+
+const maxTaskCommentLabelLength = 50
+
+// TaskComment is the operational note attached to the task run,
+// e.g. label "reverted" with comment "caused incident #42".
+type TaskComment struct {
+	ID        int       `db:"id" json:"id"`
+	ProjectID int       `db:"project_id" json:"project_id"`
+	TaskID    int       `db:"task_id" json:"task_id"`
+	UserID    *int      `db:"user_id" json:"user_id"`
+	Label     string    `db:"label" json:"label"`
+	Comment   string    `db:"comment" json:"comment"`
+	Created   time.Time `db:"created" json:"created"`
+}
+
+// TaskCommentFilter selects comments of the project. Empty fields are ignored.
+type TaskCommentFilter struct {
+	TaskIDs []int
+	Label   string
+	// Query is the case-insensitive substring of the comment or the label.
+	Query string
+}
+
+func (c *TaskComment) Validate() error {
+	c.Label = strings.TrimSpace(c.Label)
+	c.Comment = strings.TrimSpace(c.Comment)
+
+	if c.Label == "" && c.Comment == "" {
+		return &ValidationError{"label or comment is required"}
+	}
+
+	if len(c.Label) > maxTaskCommentLabelLength {
+		return &ValidationError{"label is too long"}
+	}
+
+	return nil
+}
+
+// Match checks if the comment satisfies the filter.
+func (f TaskCommentFilter) Match(c TaskComment) bool {
+	if len(f.TaskIDs) > 0 {
+		found := false
+		for _, id := range f.TaskIDs {
+			if id == c.TaskID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if f.Label != "" && !strings.EqualFold(f.Label, c.Label) {
+		return false
+	}
+
+	if f.Query != "" {
+		q := strings.ToLower(f.Query)
+		if !strings.Contains(strings.ToLower(c.Comment), q) && !strings.Contains(strings.ToLower(c.Label), q) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,30 @@
+package db
+
+type TaskFindingSeverity string
+
+const (
+	TaskFindingError   TaskFindingSeverity = "error"
+	TaskFindingWarning TaskFindingSeverity = "warning"
+)
+
+type TaskFindingTool string
+
+const (
+	TaskFindingSyntaxCheck       TaskFindingTool = "syntax_check"
+	TaskFindingAnsibleLint       TaskFindingTool = "ansible_lint"
+	TaskFindingTerraformValidate TaskFindingTool = "terraform_validate"
+	TaskFindingSecretsScan       TaskFindingTool = "secrets_scan"
+)
+
+// TaskFinding is a problem found by the pre-run check of the task,
+// for example ansible-playbook --syntax-check, terraform validate or the secrets scanner.
+type TaskFinding struct {
+	ID       int                 `db:"id" json:"id"`
+	TaskID   int                 `db:"task_id" json:"task_id"`
+	Tool     TaskFindingTool     `db:"tool" json:"tool"`
+	Severity TaskFindingSeverity `db:"severity" json:"severity"`
+	Rule     string              `db:"rule" json:"rule"`
+	File     string              `db:"file" json:"file"`
+	Line     int                 `db:"line" json:"line"`
+	Message  string              `db:"message" json:"message"`
+}
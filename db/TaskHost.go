@@ -0,0 +1,120 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+// TaskHostStatus is the result of the task on the host taken from the PLAY RECAP.
+type TaskHostStatus string
+
+const (
+	TaskHostOK          TaskHostStatus = "ok"
+	TaskHostChanged     TaskHostStatus = "changed"
+	TaskHostFailed      TaskHostStatus = "failed"
+	TaskHostUnreachable TaskHostStatus = "unreachable"
+)
+
+// TaskHost is the result of the ansible task on one host. Results are kept after
+// the task is removed, so the history of the host can be looked up without task logs.
+type TaskHost struct {
+	ID         int                    `db:"id" json:"id"`
+	ProjectID  int                    `db:"project_id" json:"project_id"`
+	TemplateID int                    `db:"template_id" json:"template_id"`
+	TaskID     int                    `db:"task_id" json:"task_id"`
+	Host       string                 `db:"host" json:"host"`
+	Groups     TaskHostGroups         `db:"host_groups" json:"groups"`
+	Status     TaskHostStatus         `db:"status" json:"status"`
+	TaskStatus task_logger.TaskStatus `db:"task_status" json:"task_status"`
+	Start      *time.Time             `db:"start" json:"start"`
+	Finished   time.Time              `db:"finished" json:"finished"`
+}
+
+// TaskHostGroups are groups of the static inventory which contained the host when the task ran.
+type TaskHostGroups []string
+
+func (g *TaskHostGroups) Scan(value interface{}) error {
+	if value == nil {
+		*g = nil
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, g)
+	case string:
+		return json.Unmarshal([]byte(v), g)
+	default:
+		return errors.New("unsupported type for TaskHostGroups")
+	}
+}
+
+func (g TaskHostGroups) Value() (driver.Value, error) {
+	if len(g) == 0 {
+		return nil, nil
+	}
+
+	return json.Marshal(g)
+}
+
+// Contains returns true if the host was in the group.
+func (g TaskHostGroups) Contains(group string) bool {
+	for _, name := range g {
+		if name == group {
+			return true
+		}
+	}
+	return false
+}
+
+// TaskHostFilter selects results of the host or the group of hosts. Zero values do not filter.
+type TaskHostFilter struct {
+	Host  string
+	Group string
+	From  *time.Time
+	To    *time.Time
+}
+
+// Match returns true if the result matches the filter, To is exclusive.
+func (f TaskHostFilter) Match(h TaskHost) bool {
+	return (f.Host == "" || f.Host == h.Host) &&
+		(f.Group == "" || h.Groups.Contains(f.Group)) &&
+		(f.From == nil || !h.Finished.Before(*f.From)) &&
+		(f.To == nil || h.Finished.Before(*f.To))
+}
+
+// NewTaskHosts returns the results of the finished task on the hosts.
+// hostGroups are groups of the hosts, see GetInventoryHostGroups.
+func NewTaskHosts(task Task, results map[string]TaskHostStatus, hostGroups map[string][]string) []TaskHost {
+	finished := time.Now().UTC()
+	if task.End != nil {
+		finished = task.End.UTC()
+	}
+
+	hosts := make([]TaskHost, 0, len(results))
+
+	for host, status := range results {
+		hosts = append(hosts, TaskHost{
+			ProjectID:  task.ProjectID,
+			TemplateID: task.TemplateID,
+			TaskID:     task.ID,
+			Host:       host,
+			Groups:     hostGroups[host],
+			Status:     status,
+			TaskStatus: task.Status,
+			Start:      task.Start,
+			Finished:   finished,
+		})
+	}
+
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].Host < hosts[j].Host
+	})
+
+	return hosts
+}
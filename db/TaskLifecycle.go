@@ -0,0 +1,84 @@
+package db
+
+import (
+	"slices"
+	"time"
+)
+
+// taskLifecycleActions are actions of task events which make the lifecycle of the task.
+var taskLifecycleActions = []string{
+	EventActionTaskQueue,
+	EventActionTaskDequeue,
+	EventActionTaskStart,
+	EventActionTaskAssign,
+	EventActionTaskFinish,
+}
+
+// TaskLifecycleStage is the event of the task lifecycle.
+type TaskLifecycleStage struct {
+	Action      string    `json:"action"`
+	Description string    `json:"description"`
+	Time        time.Time `json:"time"`
+	// Seconds is the time passed since the previous stage.
+	Seconds float64 `json:"seconds"`
+}
+
+// TaskLifecycle breaks down the time the task spent in the queue, waiting for the runner and running.
+type TaskLifecycle struct {
+	Stages []TaskLifecycleStage `json:"stages"`
+	// WaitSeconds is the time between queueing and the start of the task.
+	// It is nil until the task is started.
+	WaitSeconds *float64 `json:"wait_seconds"`
+}
+
+// GetTaskLifecycleQueryParams returns params which select lifecycle events of the task.
+func GetTaskLifecycleQueryParams(taskID int) ActivityQueryParams {
+	return ActivityQueryParams{
+		ObjectTypes: []EventObjectType{EventTask},
+		ObjectID:    &taskID,
+		Actions:     taskLifecycleActions,
+	}
+}
+
+// NewTaskLifecycle makes the lifecycle from events of the task in any order.
+func NewTaskLifecycle(events []Event) TaskLifecycle {
+	events = slices.Clone(events)
+	slices.SortStableFunc(events, func(a, b Event) int {
+		return a.ID - b.ID
+	})
+
+	res := TaskLifecycle{
+		Stages: make([]TaskLifecycleStage, 0, len(events)),
+	}
+
+	var queued *time.Time
+
+	for i, evt := range events {
+		stage := TaskLifecycleStage{
+			Action: evt.Action,
+			Time:   evt.Created,
+		}
+
+		if evt.Description != nil {
+			stage.Description = *evt.Description
+		}
+
+		if i > 0 {
+			stage.Seconds = evt.Created.Sub(events[i-1].Created).Seconds()
+		}
+
+		switch evt.Action {
+		case EventActionTaskQueue:
+			queued = &events[i].Created
+		case EventActionTaskStart:
+			if queued != nil && res.WaitSeconds == nil {
+				wait := evt.Created.Sub(*queued).Seconds()
+				res.WaitSeconds = &wait
+			}
+		}
+
+		res.Stages = append(res.Stages, stage)
+	}
+
+	return res
+}
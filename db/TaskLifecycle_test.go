@@ -0,0 +1,50 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewTaskLifecycle(t *testing.T) {
+	queued := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	// events are returned by the store in descending order
+	events := []Event{
+		{ID: 4, Action: EventActionTaskAssign, Created: queued.Add(50 * time.Second)},
+		{ID: 3, Action: EventActionTaskStart, Created: queued.Add(45 * time.Second)},
+		{ID: 2, Action: EventActionTaskDequeue, Created: queued.Add(40 * time.Second)},
+		{ID: 1, Action: EventActionTaskQueue, Created: queued},
+	}
+
+	lifecycle := NewTaskLifecycle(events)
+
+	if len(lifecycle.Stages) != 4 || lifecycle.Stages[0].Action != EventActionTaskQueue {
+		t.Fatalf("stages must be sorted from the oldest, got %+v", lifecycle.Stages)
+	}
+
+	for i, seconds := range []float64{0, 40, 5, 5} {
+		if lifecycle.Stages[i].Seconds != seconds {
+			t.Errorf("stage %s: expected %v seconds, got %v", lifecycle.Stages[i].Action, seconds, lifecycle.Stages[i].Seconds)
+		}
+	}
+
+	if lifecycle.WaitSeconds == nil || *lifecycle.WaitSeconds != 45 {
+		t.Fatalf("expected wait of 45 seconds, got %v", lifecycle.WaitSeconds)
+	}
+
+	if NewTaskLifecycle(events[2:]).WaitSeconds != nil {
+		t.Fatal("wait time must not be set before the task is started")
+	}
+
+	taskID := 7
+	params := GetTaskLifecycleQueryParams(taskID)
+	objType := EventTask
+
+	if !params.Match(Event{ObjectType: &objType, ObjectID: &taskID, Action: EventActionTaskDequeue}) {
+		t.Error("dequeue event of the task must match")
+	}
+
+	if params.Match(Event{ObjectType: &objType, ObjectID: &taskID, Action: EventActionTaskInteractiveBecome}) {
+		t.Error("events which are not lifecycle stages must not match")
+	}
+}
@@ -0,0 +1,289 @@
+package db
+
+import (
+	"sort"
+	"time"
+
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+// TaskMetric is the compact summary of the finished task. Metrics are kept after
+// the task is removed and are rolled up into TaskMetricDaily when they become old.
+type TaskMetric struct {
+	ID         int                    `db:"id" json:"id"`
+	ProjectID  int                    `db:"project_id" json:"project_id"`
+	TemplateID int                    `db:"template_id" json:"template_id"`
+	TaskID     int                    `db:"task_id" json:"task_id"`
+	Status     task_logger.TaskStatus `db:"status" json:"status"`
+	Finished   time.Time              `db:"finished" json:"finished"`
+
+	// Duration is in seconds.
+	Duration     int `db:"duration" json:"duration"`
+	HostsTotal   int `db:"hosts_total" json:"hosts_total"`
+	HostsChanged int `db:"hosts_changed" json:"hosts_changed"`
+	HostsFailed  int `db:"hosts_failed" json:"hosts_failed"`
+	// OutputSize is the size of the task output in bytes.
+	OutputSize int64 `db:"output_size" json:"output_size"`
+}
+
+// TaskMetricDaily is the rollup of the metrics of the template for one day (UTC).
+type TaskMetricDaily struct {
+	ID         int       `db:"id" json:"id"`
+	ProjectID  int       `db:"project_id" json:"project_id"`
+	TemplateID int       `db:"template_id" json:"template_id"`
+	Day        time.Time `db:"day" json:"day"`
+
+	TaskCount    int `db:"task_count" json:"task_count"`
+	SuccessCount int `db:"success_count" json:"success_count"`
+	FailCount    int `db:"fail_count" json:"fail_count"`
+
+	// DurationSum and DurationMax are in seconds.
+	DurationSum  int64 `db:"duration_sum" json:"duration_sum"`
+	DurationMax  int   `db:"duration_max" json:"duration_max"`
+	HostsChanged int   `db:"hosts_changed" json:"hosts_changed"`
+	HostsFailed  int   `db:"hosts_failed" json:"hosts_failed"`
+	OutputSize   int64 `db:"output_size" json:"output_size"`
+}
+
+// TaskMetricFilter selects metrics of the project. Zero values do not filter.
+type TaskMetricFilter struct {
+	TemplateID *int
+	From       *time.Time
+	To         *time.Time
+}
+
+// Match returns true if the time is in the range of the filter, To is exclusive.
+func (f TaskMetricFilter) Match(templateID int, t time.Time) bool {
+	return (f.TemplateID == nil || *f.TemplateID == templateID) &&
+		(f.From == nil || !t.Before(*f.From)) &&
+		(f.To == nil || t.Before(*f.To))
+}
+
+// NewTaskMetric returns the metrics of the finished task.
+func NewTaskMetric(task Task, hostsChanged int, hostsFailed int, outputSize int64) TaskMetric {
+	metric := TaskMetric{
+		ProjectID:    task.ProjectID,
+		TemplateID:   task.TemplateID,
+		TaskID:       task.ID,
+		Status:       task.Status,
+		Finished:     time.Now().UTC(),
+		HostsTotal:   task.HostsTotal,
+		HostsChanged: hostsChanged,
+		HostsFailed:  hostsFailed,
+		OutputSize:   outputSize,
+	}
+
+	if task.End != nil {
+		metric.Finished = task.End.UTC()
+	}
+
+	if task.Start != nil && task.End != nil {
+		metric.Duration = int(task.End.Sub(*task.Start).Seconds())
+	}
+
+	return metric
+}
+
+// GetTaskMetricDay returns the start of the UTC day of the time.
+func GetTaskMetricDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// Add includes the metrics of the task into the rollup.
+func (d *TaskMetricDaily) Add(metric TaskMetric) {
+	d.TaskCount++
+
+	switch metric.Status {
+	case task_logger.TaskSuccessStatus:
+		d.SuccessCount++
+	case task_logger.TaskFailStatus:
+		d.FailCount++
+	}
+
+	d.DurationSum += int64(metric.Duration)
+	if metric.Duration > d.DurationMax {
+		d.DurationMax = metric.Duration
+	}
+
+	d.HostsChanged += metric.HostsChanged
+	d.HostsFailed += metric.HostsFailed
+	d.OutputSize += metric.OutputSize
+}
+
+// Merge includes the other rollup into the rollup.
+func (d *TaskMetricDaily) Merge(other TaskMetricDaily) {
+	d.TaskCount += other.TaskCount
+	d.SuccessCount += other.SuccessCount
+	d.FailCount += other.FailCount
+	d.DurationSum += other.DurationSum
+	if other.DurationMax > d.DurationMax {
+		d.DurationMax = other.DurationMax
+	}
+	d.HostsChanged += other.HostsChanged
+	d.HostsFailed += other.HostsFailed
+	d.OutputSize += other.OutputSize
+}
+
+type taskMetricDailyKey struct {
+	projectID  int
+	templateID int
+	day        time.Time
+}
+
+// RollupTaskMetrics groups the metrics by project, template and day.
+// Rollups are ordered by day, project and template.
+func RollupTaskMetrics(metrics []TaskMetric) []TaskMetricDaily {
+	rollups := make(map[taskMetricDailyKey]*TaskMetricDaily)
+
+	for _, metric := range metrics {
+		key := taskMetricDailyKey{
+			projectID:  metric.ProjectID,
+			templateID: metric.TemplateID,
+			day:        GetTaskMetricDay(metric.Finished),
+		}
+
+		rollup, ok := rollups[key]
+		if !ok {
+			rollup = &TaskMetricDaily{
+				ProjectID:  key.projectID,
+				TemplateID: key.templateID,
+				Day:        key.day,
+			}
+			rollups[key] = rollup
+		}
+
+		rollup.Add(metric)
+	}
+
+	res := make([]TaskMetricDaily, 0, len(rollups))
+	for _, rollup := range rollups {
+		res = append(res, *rollup)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].Day.Equal(res[j].Day) {
+			return res[i].Day.Before(res[j].Day)
+		}
+		if res[i].ProjectID != res[j].ProjectID {
+			return res[i].ProjectID < res[j].ProjectID
+		}
+		return res[i].TemplateID < res[j].TemplateID
+	})
+
+	return res
+}
+
+type TaskMetricsPeriod string
+
+const (
+	TaskMetricsPeriodDay   TaskMetricsPeriod = "day"
+	TaskMetricsPeriodMonth TaskMetricsPeriod = "month"
+	TaskMetricsPeriodYear  TaskMetricsPeriod = "year"
+)
+
+func (p TaskMetricsPeriod) format(day time.Time) string {
+	switch p {
+	case TaskMetricsPeriodYear:
+		return day.Format("2006")
+	case TaskMetricsPeriodMonth:
+		return day.Format("2006-01")
+	default:
+		return day.Format("2006-01-02")
+	}
+}
+
+func (p TaskMetricsPeriod) IsValid() bool {
+	switch p {
+	case TaskMetricsPeriodDay, TaskMetricsPeriodMonth, TaskMetricsPeriodYear:
+		return true
+	default:
+		return false
+	}
+}
+
+// TaskMetricsReportRow contains the metrics of the period, e.g. "2024", "2024-03" or "2024-03-15".
+type TaskMetricsReportRow struct {
+	Period string `json:"period"`
+	// TemplateID is set if the report is grouped by templates.
+	TemplateID *int `json:"template_id,omitempty"`
+
+	TaskCount    int `json:"task_count"`
+	SuccessCount int `json:"success_count"`
+	FailCount    int `json:"fail_count"`
+
+	// DurationMean and DurationMax are in seconds.
+	DurationMean float64 `json:"duration_mean"`
+	DurationMax  int     `json:"duration_max"`
+	HostsChanged int     `json:"hosts_changed"`
+	HostsFailed  int     `json:"hosts_failed"`
+	OutputSize   int64   `json:"output_size"`
+}
+
+// GetTaskMetricsReport aggregates the daily rollups and the metrics which are not rolled up yet
+// by the period. Rows are ordered by period and template.
+func GetTaskMetricsReport(
+	rollups []TaskMetricDaily,
+	metrics []TaskMetric,
+	period TaskMetricsPeriod,
+	byTemplate bool,
+) []TaskMetricsReportRow {
+	type reportKey struct {
+		period     string
+		templateID int
+	}
+
+	totals := make(map[reportKey]*TaskMetricDaily)
+
+	for _, rollup := range append(rollups, RollupTaskMetrics(metrics)...) {
+		key := reportKey{period: period.format(rollup.Day)}
+		if byTemplate {
+			key.templateID = rollup.TemplateID
+		}
+
+		total, ok := totals[key]
+		if !ok {
+			total = &TaskMetricDaily{}
+			totals[key] = total
+		}
+
+		total.Merge(rollup)
+	}
+
+	res := make([]TaskMetricsReportRow, 0, len(totals))
+
+	for key, total := range totals {
+		row := TaskMetricsReportRow{
+			Period:       key.period,
+			TaskCount:    total.TaskCount,
+			SuccessCount: total.SuccessCount,
+			FailCount:    total.FailCount,
+			DurationMax:  total.DurationMax,
+			HostsChanged: total.HostsChanged,
+			HostsFailed:  total.HostsFailed,
+			OutputSize:   total.OutputSize,
+		}
+
+		if byTemplate {
+			templateID := key.templateID
+			row.TemplateID = &templateID
+		}
+
+		if total.TaskCount > 0 {
+			row.DurationMean = float64(total.DurationSum) / float64(total.TaskCount)
+		}
+
+		res = append(res, row)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Period != res[j].Period {
+			return res[i].Period < res[j].Period
+		}
+		if res[i].TemplateID == nil || res[j].TemplateID == nil {
+			return false
+		}
+		return *res[i].TemplateID < *res[j].TemplateID
+	})
+
+	return res
+}
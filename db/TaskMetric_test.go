@@ -0,0 +1,52 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+func TestGetTaskMetricsReport(t *testing.T) {
+	day := func(s string) time.Time {
+		res, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Add(10 * time.Hour)
+	}
+
+	metrics := []TaskMetric{
+		{ProjectID: 1, TemplateID: 1, Status: task_logger.TaskSuccessStatus, Finished: day("2023-03-01"), Duration: 10, HostsChanged: 2},
+		{ProjectID: 1, TemplateID: 1, Status: task_logger.TaskFailStatus, Finished: day("2023-03-01"), Duration: 30, HostsFailed: 1},
+		{ProjectID: 1, TemplateID: 2, Status: task_logger.TaskSuccessStatus, Finished: day("2024-03-02"), Duration: 20, OutputSize: 100},
+	}
+
+	rollups := RollupTaskMetrics(metrics)
+	if len(rollups) != 2 || rollups[0].TaskCount != 2 || rollups[0].DurationMax != 30 || !rollups[0].Day.Equal(day("2023-03-01").Add(-10*time.Hour)) {
+		t.Fatalf("unexpected rollups %+v", rollups)
+	}
+
+	// metrics which are not rolled up yet are added to the rollups
+	report := GetTaskMetricsReport(rollups[:1], []TaskMetric{
+		metrics[2],
+		{ProjectID: 1, TemplateID: 2, Status: task_logger.TaskSuccessStatus, Finished: day("2024-12-31"), Duration: 40},
+	}, TaskMetricsPeriodYear, false)
+
+	if len(report) != 2 || report[0].Period != "2023" || report[1].Period != "2024" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	if report[0].SuccessCount != 1 || report[0].FailCount != 1 || report[0].DurationMean != 20 || report[0].HostsChanged != 2 || report[0].HostsFailed != 1 {
+		t.Fatalf("unexpected 2023 %+v", report[0])
+	}
+
+	if report[1].TaskCount != 2 || report[1].DurationMean != 30 || report[1].OutputSize != 100 {
+		t.Fatalf("unexpected 2024 %+v", report[1])
+	}
+
+	report = GetTaskMetricsReport(rollups, nil, TaskMetricsPeriodMonth, true)
+	if len(report) != 2 || report[0].Period != "2023-03" || *report[0].TemplateID != 1 || *report[1].TemplateID != 2 {
+		t.Fatalf("unexpected report by templates %+v", report)
+	}
+}
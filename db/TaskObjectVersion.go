@@ -0,0 +1,204 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// TaskObjectVersion is the version of the inventory, the environment or the access key
+// used by the task. The version is the hash of the stored content of the object,
+// including encrypted secrets, so changes made after the task started are detected.
+type TaskObjectVersion struct {
+	ID         int             `db:"id" json:"-"`
+	TaskID     int             `db:"task_id" json:"task_id"`
+	ProjectID  int             `db:"project_id" json:"project_id"`
+	ObjectType EventObjectType `db:"object_type" json:"object_type"`
+	ObjectID   int             `db:"object_id" json:"object_id"`
+	Version    string          `db:"version" json:"version"`
+	// Changed is true if the object was changed or removed after the task started.
+	Changed bool `db:"-" json:"changed"`
+}
+
+// TaskObjectRef is the object used by the task.
+type TaskObjectRef struct {
+	ObjectType EventObjectType
+	ObjectID   int
+}
+
+// InUseObjectEditMode is what happens when the object used by a running task is changed.
+type InUseObjectEditMode string
+
+const (
+	// InUseObjectEditWarn saves the changes and warns that they affect only next tasks.
+	InUseObjectEditWarn InUseObjectEditMode = "warn"
+	// InUseObjectEditBlock rejects the changes until the tasks are finished.
+	InUseObjectEditBlock InUseObjectEditMode = "block"
+)
+
+// GetInUseObjectEditMode returns the mode configured for the instance.
+func GetInUseObjectEditMode() InUseObjectEditMode {
+	if util.Config != nil && InUseObjectEditMode(util.Config.InUseObjectEdit) == InUseObjectEditBlock {
+		return InUseObjectEditBlock
+	}
+	return InUseObjectEditWarn
+}
+
+// secretVersion is the part of the object version of the secret. Only the encrypted value is used.
+type secretVersion struct {
+	Name   string  `json:"name"`
+	Secret *string `json:"secret"`
+}
+
+func hashObjectVersion(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+func getSecretVersions(keys []AccessKey) []secretVersion {
+	secrets := make([]secretVersion, 0, len(keys))
+	for _, k := range keys {
+		secrets = append(secrets, secretVersion{Name: k.Name, Secret: k.Secret})
+	}
+
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].Name < secrets[j].Name
+	})
+
+	return secrets
+}
+
+// GetObjectVersion returns the current version of the inventory, the environment or the access key.
+func GetObjectVersion(store Store, projectID int, ref TaskObjectRef) (string, error) {
+	switch ref.ObjectType {
+	case EventInventory:
+		inventory, err := store.GetInventory(projectID, ref.ObjectID)
+		if err != nil {
+			return "", err
+		}
+
+		keys, err := store.GetInventorySecrets(projectID, ref.ObjectID)
+		if err != nil {
+			return "", err
+		}
+
+		inventory.Secrets = nil
+
+		return hashObjectVersion([]any{inventory, getSecretVersions(keys)})
+	case EventEnvironment:
+		env, err := store.GetEnvironment(projectID, ref.ObjectID)
+		if err != nil {
+			return "", err
+		}
+
+		keys, err := store.GetEnvironmentSecrets(projectID, ref.ObjectID)
+		if err != nil {
+			return "", err
+		}
+
+		env.Secrets = nil
+
+		return hashObjectVersion([]any{env, getSecretVersions(keys)})
+	case EventKey:
+		key, err := store.GetAccessKey(projectID, ref.ObjectID)
+		if err != nil {
+			return "", err
+		}
+
+		return hashObjectVersion(struct {
+			Name   string        `json:"name"`
+			Type   AccessKeyType `json:"type"`
+			Secret *string       `json:"secret"`
+		}{key.Name, key.Type, key.Secret})
+	default:
+		return "", fmt.Errorf("versions of %s are not tracked", ref.ObjectType)
+	}
+}
+
+// MakeTaskObjectVersions returns the current versions of the objects used by the task.
+// Objects which are used more than once are returned once.
+func MakeTaskObjectVersions(store Store, task Task, refs []TaskObjectRef) ([]TaskObjectVersion, error) {
+	versions := make([]TaskObjectVersion, 0, len(refs))
+	added := make(map[TaskObjectRef]bool)
+
+	for _, ref := range refs {
+		if ref.ObjectID == 0 || added[ref] {
+			continue
+		}
+		added[ref] = true
+
+		version, err := GetObjectVersion(store, task.ProjectID, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, TaskObjectVersion{
+			TaskID:     task.ID,
+			ProjectID:  task.ProjectID,
+			ObjectType: ref.ObjectType,
+			ObjectID:   ref.ObjectID,
+			Version:    version,
+		})
+	}
+
+	return versions, nil
+}
+
+// FillTaskObjectVersionsChanged marks the versions of the objects which were changed or removed.
+func FillTaskObjectVersionsChanged(store Store, versions []TaskObjectVersion) error {
+	for i, v := range versions {
+		current, err := GetObjectVersion(store, v.ProjectID, TaskObjectRef{ObjectType: v.ObjectType, ObjectID: v.ObjectID})
+		if err == ErrNotFound {
+			versions[i].Changed = true
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		versions[i].Changed = current != v.Version
+	}
+
+	return nil
+}
+
+// GetRunningTasksUsingObject returns the started and unfinished tasks of the project which use the object.
+func GetRunningTasksUsingObject(store Store, projectID int, ref TaskObjectRef) ([]Task, error) {
+	unfinished, err := store.GetUnfinishedTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []Task
+
+	for _, task := range unfinished {
+		if task.ProjectID != projectID {
+			continue
+		}
+
+		// versions are recorded when the task starts
+		versions, err := store.GetTaskObjectVersions(projectID, task.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range versions {
+			if v.ObjectType == ref.ObjectType && v.ObjectID == ref.ObjectID {
+				tasks = append(tasks, task)
+				break
+			}
+		}
+	}
+
+	return tasks, nil
+}
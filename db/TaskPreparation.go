@@ -0,0 +1,42 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// TaskPreparationStep is the step of the preparation of the task. Independent steps
+// are run in parallel, so steps can overlap in time.
+type TaskPreparationStep struct {
+	Name       string    `json:"name"`
+	Start      time.Time `json:"start"`
+	DurationMs int64     `json:"duration_ms"`
+	Failed     bool      `json:"failed,omitempty"`
+}
+
+type TaskPreparationSteps []TaskPreparationStep
+
+func (s *TaskPreparationSteps) Scan(value interface{}) error {
+	if value == nil {
+		*s = nil
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return errors.New("unsupported type for TaskPreparationSteps")
+	}
+}
+
+func (s TaskPreparationSteps) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(s)
+}
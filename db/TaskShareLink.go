@@ -0,0 +1,65 @@
+package db
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"time"
+)
+
+// MaxTaskShareLinkLifetime limits the lifetime of the task share link.
+const MaxTaskShareLinkLifetime = 30 * 24 * time.Hour
+
+// TaskShareLink gives read-only access to the output of the task without authentication
+// until it expires. Only the hash of the token is stored.
+type TaskShareLink struct {
+	ID        int       `db:"id" json:"id"`
+	ProjectID int       `db:"project_id" json:"project_id"`
+	TaskID    int       `db:"task_id" json:"task_id"`
+	UserID    *int      `db:"user_id" json:"user_id"`
+	TokenHash string    `db:"token_hash" json:"-"`
+	Created   time.Time `db:"created" json:"created"`
+	Expires   time.Time `db:"expires" json:"expires"`
+
+	// Token is returned only when the link is created.
+	Token string `db:"-" json:"token,omitempty"`
+}
+
+// HashTaskShareToken returns the hash of the token stored in the database.
+func HashTaskShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsExpired returns true if the link can not be used anymore.
+func (l *TaskShareLink) IsExpired(now time.Time) bool {
+	return !now.Before(l.Expires)
+}
+
+// CheckToken returns true if the token matches the link.
+func (l *TaskShareLink) CheckToken(token string) bool {
+	if token == "" || l.TokenHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(l.TokenHash), []byte(HashTaskShareToken(token))) == 1
+}
+
+func (l *TaskShareLink) Validate() error {
+	if l.TaskID == 0 {
+		return &ValidationError{"task is required"}
+	}
+
+	if l.TokenHash == "" {
+		return &ValidationError{"token is required"}
+	}
+
+	if !l.Expires.After(l.Created) {
+		return &ValidationError{"expiration time must be in the future"}
+	}
+
+	if l.Expires.Sub(l.Created) > MaxTaskShareLinkLifetime {
+		return &ValidationError{"share link can not live longer than 30 days"}
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTaskShareLink(t *testing.T) {
+	now := time.Now()
+
+	link := TaskShareLink{
+		TaskID:    1,
+		TokenHash: HashTaskShareToken("secret"),
+		Created:   now,
+		Expires:   now.Add(time.Hour),
+	}
+
+	if err := link.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !link.CheckToken("secret") {
+		t.Fatal("token of the link must be accepted")
+	}
+
+	if link.CheckToken("other") || link.CheckToken("") {
+		t.Fatal("wrong token must be rejected")
+	}
+
+	if link.IsExpired(now) || !link.IsExpired(now.Add(time.Hour)) {
+		t.Fatal("link must expire at the expiration time")
+	}
+
+	link.Expires = now.Add(MaxTaskShareLinkLifetime + time.Hour)
+	if link.Validate() == nil {
+		t.Fatal("lifetime of the link must be limited")
+	}
+}
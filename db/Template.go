@@ -2,6 +2,13 @@ package db
 
 import (
 	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
 )
 
 type TemplateType string
@@ -28,19 +35,142 @@ func (t TemplateApp) IsTerraform() bool {
 	return t == AppTerraform || t == AppTofu
 }
 
+func (t TemplateApp) IsAnsible() bool {
+	return t == AppAnsible
+}
+
+// IsShell returns true if the app runs shell scripts, so ShellTemplateParams are applied.
+func (t TemplateApp) IsShell() bool {
+	return t == AppBash || t == AppPowerShell
+}
+
+// RunConflictPolicy defines what happens when a task of the template is started by the schedule
+// while a manual run of the template is active, or vice versa.
+type RunConflictPolicy string
+
+const (
+	// RunConflictQueue runs the new task after the active task finishes.
+	RunConflictQueue RunConflictPolicy = ""
+	// RunConflictSkip stops the new task without running it.
+	RunConflictSkip RunConflictPolicy = "skip"
+	// RunConflictCancelOlder stops the active task and runs the new task.
+	RunConflictCancelOlder RunConflictPolicy = "cancel_older"
+)
+
+// SecretsScanMode defines what happens when the secrets scanner finds raw credentials
+// in the commits checked out by the task.
+type SecretsScanMode string
+
+const (
+	SecretsScanDisabled SecretsScanMode = ""
+	SecretsScanWarn     SecretsScanMode = "warn"
+	SecretsScanFail     SecretsScanMode = "fail"
+)
+
 type SurveyVarType string
 
 const (
 	SurveyVarStr  TemplateType = ""
 	SurveyVarInt  TemplateType = "int"
 	SurveyVarEnum TemplateType = "enum"
+	// SurveyVarSecret is the string which is hidden in the task parameters.
+	SurveyVarSecret TemplateType = "secret"
 )
 
 type TerraformTemplateParams struct {
 	AllowDestroy     bool `json:"allow_destroy"`
 	AllowAutoApprove bool `json:"allow_auto_approve"`
+	// Validate runs terraform validate before the plan.
+	Validate bool `json:"validate"`
+	// LockTimeout is the duration, e.g. 5m, to retry acquiring the state lock
+	// of the workspace if it is held by another operation.
+	LockTimeout string `json:"lock_timeout"`
+}
+
+type AnsibleTemplateParams struct {
+	// AnsibleConfig is content of ansible.cfg which is used instead of ansible.cfg from the repository.
+	AnsibleConfig string `json:"ansible_config"`
+	// IsolateGalaxyPaths makes Semaphore install roles and collections of the template
+	// to a separate directory, so templates requiring different versions do not conflict.
+	// Galaxy cache entries are always separated, so it has no effect if the cache is enabled.
+	IsolateGalaxyPaths bool `json:"isolate_galaxy_paths"`
+	// CollectionsPaths and RolesPaths are additional directories, relative to the repository root,
+	// where Ansible looks for collections and roles.
+	CollectionsPaths []string `json:"collections_paths"`
+	RolesPaths       []string `json:"roles_paths"`
+
+	// PythonInterpreter is a path to the Python interpreter with installed Ansible.
+	// If AnsibleCoreVersion is set, it is used to create the virtualenv instead.
+	PythonInterpreter string `json:"python_interpreter"`
+	// AnsibleCoreVersion makes Semaphore create the virtualenv with the pinned ansible-core version.
+	AnsibleCoreVersion string `json:"ansible_core_version"`
+
+	// SyntaxCheck and Lint enable pre-run checks with ansible-playbook --syntax-check and ansible-lint.
+	// The task fails without running the playbook if they find errors.
+	SyntaxCheck bool `json:"syntax_check"`
+	Lint        bool `json:"lint"`
+
+	// TrackProgress makes Semaphore list tasks of the playbook with ansible-playbook --list-tasks
+	// before the run, so the progress of the run can be computed.
+	TrackProgress bool `json:"track_progress"`
+
+	// Execution controls forks, serial and strategy of all runs of the template.
+	Execution AnsibleExecution `json:"execution"`
+	// AllowOverrideExecution allows tasks to override Execution at the launch.
+	AllowOverrideExecution bool `json:"allow_override_execution"`
+
+	// Pull enables the ansible-pull mode of the template.
+	Pull AnsiblePull `json:"pull"`
+
+	// RemoteUser overrides the login of the inventory user key, so the playbook connects
+	// to the hosts as the delegated user.
+	RemoteUser string `json:"remote_user"`
+	// BecomeUser overrides the login of the inventory become key.
+	BecomeUser string `json:"become_user"`
 }
 
+// ShellInterpreter is the shell which runs the script of the shell template.
+type ShellInterpreter string
+
+const (
+	// ShellDefault runs the script by the interpreter of the app, bash or powershell.
+	ShellDefault ShellInterpreter = ""
+	ShellBash    ShellInterpreter = "bash"
+	ShellSh      ShellInterpreter = "sh"
+	ShellZsh     ShellInterpreter = "zsh"
+	ShellPwsh    ShellInterpreter = "pwsh"
+)
+
+// ShellVarsMode defines how survey and extra variables are passed to the script.
+type ShellVarsMode string
+
+const (
+	// ShellVarsArgs passes variables as name=value arguments after the template arguments.
+	ShellVarsArgs ShellVarsMode = ""
+	// ShellVarsEnv passes variables as environment variables.
+	ShellVarsEnv ShellVarsMode = "env"
+)
+
+type ShellTemplateParams struct {
+	Shell ShellInterpreter `json:"shell"`
+	// WorkingDirectory is the directory, relative to the repository, in which the script runs.
+	// The path of the script is still relative to the repository.
+	WorkingDirectory string        `json:"working_directory"`
+	VarsMode         ShellVarsMode `json:"vars_mode"`
+}
+
+var envVarNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// IsValidEnvVarName returns true if the name can be used as the name of the environment variable.
+func IsValidEnvVarName(name string) bool {
+	return envVarNameRegexp.MatchString(name)
+}
+
+var ansibleCoreVersionRegexp = regexp.MustCompile(`^[0-9]+(\.[0-9A-Za-z*]+)*$`)
+
+// runAsUserRegexp matches POSIX user names and Windows names like user@domain or DOMAIN\user.
+var runAsUserRegexp = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.@\\-]{0,63}\$?$`)
+
 type SurveyVarEnumValue struct {
 	Name  string `json:"name" backup:"name"`
 	Value string `json:"value" backup:"value"`
@@ -55,10 +185,63 @@ type SurveyVar struct {
 	Values      []SurveyVarEnumValue `json:"values" backup:"values"`
 }
 
+// TemplateStats is the aggregated information about tasks and schedules
+// of the template shown on project dashboards.
+type TemplateStats struct {
+	TemplateID     int                     `db:"template_id" json:"-"`
+	TaskCount      int                     `db:"task_count" json:"task_count"`
+	LastTaskID     *int                    `db:"last_task_id" json:"last_task_id"`
+	LastTaskStatus *task_logger.TaskStatus `db:"last_task_status" json:"last_task_status"`
+	ScheduleCount  int                     `db:"schedule_count" json:"schedule_count"`
+	// LastSuccess is the end time of the last successful task.
+	LastSuccess *time.Time `db:"-" json:"last_success"`
+	// SuccessRate is the share of successful tasks finished in the last 30 days, nil if there are none.
+	SuccessRate *float64 `db:"-" json:"success_rate_30d"`
+	// AverageDuration is the average duration in seconds of the tasks finished in the last 30 days.
+	AverageDuration int `db:"-" json:"average_duration_30d"`
+	// NextRun is the nearest fire time of the active schedules of the template.
+	NextRun *time.Time `db:"-" json:"next_run"`
+}
+
 type TemplateFilter struct {
 	ViewID          *int
 	BuildTemplateID *int
 	AutorunOnly     bool
+	// Tags filters templates which have all the tags.
+	Tags []string
+	// Search filters templates which name, description or one of tags contains the string.
+	Search string
+}
+
+// Match checks Tags and Search conditions of the filter.
+func (f TemplateFilter) Match(tpl Template) bool {
+	for _, tag := range f.Tags {
+		if !slices.Contains(tpl.Tags, tag) {
+			return false
+		}
+	}
+
+	if f.Search == "" {
+		return true
+	}
+
+	search := strings.ToLower(f.Search)
+
+	if strings.Contains(strings.ToLower(tpl.Name), search) {
+		return true
+	}
+
+	if tpl.Description != nil && strings.Contains(strings.ToLower(*tpl.Description), search) {
+		return true
+	}
+
+	for _, tag := range tpl.Tags {
+		if strings.Contains(strings.ToLower(tag), search) {
+			return true
+		}
+	}
+
+	return false
 }
 
 // Template is a user defined model that is used to run a task
@@ -83,14 +266,37 @@ type Template struct {
 
 	Vaults []TemplateVault `db:"-" json:"vaults" backup:"-"`
 
+	// SecretFiles are access keys written to files in the repository for the time of the task.
+	SecretFiles TemplateSecretFiles `db:"secret_files" json:"secret_files" backup:"-"`
+
+	// Uploads are uploaded files written to the repository for the time of the task.
+	// They are loaded with the content for the task only.
+	Uploads []TemplateUpload `db:"-" json:"uploads,omitempty" backup:"-"`
+
+	// Artifacts are files built by the task which are published to registries after the successful run.
+	Artifacts TemplateArtifacts `db:"artifacts" json:"artifacts" backup:"-"`
+
+	// VariableGroupIDs are variable groups whose variables and secrets are passed to the task.
+	VariableGroupIDs TemplateVariableGroups `db:"variable_group_ids" json:"variable_group_ids" backup:"-"`
+
 	Type            TemplateType `db:"type" json:"type"`
 	StartVersion    *string      `db:"start_version" json:"start_version"`
 	BuildTemplateID *int         `db:"build_template_id" json:"build_template_id" backup:"-"`
 
+	// ViewID is the first of ViewIDs. It is left for compatibility with
+	// clients which support only one view per template.
 	ViewID *int `db:"view_id" json:"view_id" backup:"-"`
+	// ViewIDs contains all views in which the template is shown.
+	ViewIDs []int `db:"view_ids" json:"view_ids" backup:"-"`
+
+	// Tags are free-form labels used for filtering and search.
+	Tags []string `db:"tags" json:"tags"`
 
 	LastTask *TaskWithTpl `db:"-" json:"last_task" backup:"-"`
 
+	// Stats is filled only by the template list endpoint.
+	Stats *TemplateStats `db:"-" json:"stats,omitempty" backup:"-"`
+
 	Autorun bool `db:"autorun" json:"autorun"`
 
 	// override variables
@@ -104,6 +310,37 @@ type Template struct {
 
 	SuppressSuccessAlerts bool `db:"suppress_success_alerts" json:"suppress_success_alerts"`
 
+	// MaxDuration is the runtime budget of the task in minutes. 0 means no budget.
+	MaxDuration int `db:"max_duration" json:"max_duration"`
+	// DurationFactor is the number of average runtimes of the recent successful tasks
+	// after which the task exceeds the budget. 0 disables the check.
+	DurationFactor int `db:"duration_factor" json:"duration_factor"`
+
+	// SecretsScan enables scanning of the commits added to the repository since the last run
+	// for raw credentials before the task starts.
+	SecretsScan SecretsScanMode `db:"secrets_scan" json:"secrets_scan"`
+
+	// ConcurrencyGroup is the name of the group of templates whose tasks never run simultaneously,
+	// even if the templates belong to different projects. Empty string means no group.
+	ConcurrencyGroup string `db:"concurrency_group" json:"concurrency_group"`
+
+	// RunConflictPolicy is applied when the schedule starts a task of the template while
+	// a manual run is active or a task is started manually while a scheduled run is active.
+	RunConflictPolicy RunConflictPolicy `db:"run_conflict_policy" json:"run_conflict_policy"`
+
+	// ApprovalEmails sends members of the project who can run tasks emails with links
+	// to approve or reject the task when it waits for confirmation.
+	ApprovalEmails bool `db:"approval_emails" json:"approval_emails"`
+
+	// ChangeWindowStart and ChangeWindowEnd are the daily time window (HH:MM, server time zone)
+	// in which the task must start and complete. The window can cross midnight, e.g. 22:00-02:00.
+	// Empty strings mean no window.
+	ChangeWindowStart string `db:"change_window_start" json:"change_window_start"`
+	ChangeWindowEnd   string `db:"change_window_end" json:"change_window_end"`
+	// AbortTemplateID is the template which is run automatically if the change window
+	// closes before the task completes, e.g. the rollback of the change.
+	AbortTemplateID *int `db:"abort_template_id" json:"abort_template_id" backup:"-"`
+
 	App TemplateApp `db:"app" json:"app"`
 
 	Tasks int `db:"tasks" json:"tasks" backup:"-"`
@@ -111,12 +348,180 @@ type Template struct {
 	TaskParams MapStringAnyField `db:"task_params" json:"task_params"`
 }
 
+// FillDefaults initializes Tags and ViewIDs of templates stored before
+// they were introduced.
+func (tpl *Template) FillDefaults() {
+	if tpl.Tags == nil {
+		tpl.Tags = []string{}
+	}
+
+	if tpl.ViewIDs == nil {
+		tpl.ViewIDs = []int{}
+		if tpl.ViewID != nil {
+			tpl.ViewIDs = append(tpl.ViewIDs, *tpl.ViewID)
+		}
+	}
+}
+
+// normalizeViewsAndTags sorts tags, removes duplicated and empty ones and keeps ViewID
+// in sync with ViewIDs. If ViewIDs is not provided it is made from ViewID.
+func (tpl *Template) normalizeViewsAndTags() {
+	tags := make([]string, 0, len(tpl.Tags))
+	for _, tag := range tpl.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+	tpl.Tags = tags
+
+	if tpl.ViewIDs == nil {
+		tpl.FillDefaults()
+		return
+	}
+
+	viewIDs := make([]int, 0, len(tpl.ViewIDs))
+	for _, id := range tpl.ViewIDs {
+		if !slices.Contains(viewIDs, id) {
+			viewIDs = append(viewIDs, id)
+		}
+	}
+	tpl.ViewIDs = viewIDs
+
+	tpl.ViewID = nil
+	if len(viewIDs) > 0 {
+		tpl.ViewID = &viewIDs[0]
+	}
+}
+
+// GetParams reads app specific template params, for example AnsibleTemplateParams.
+func (tpl *Template) GetParams(target interface{}) (err error) {
+	content, err := json.Marshal(tpl.TaskParams)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(content, target)
+	return
+}
+
+func (tpl *Template) validateAnsibleParams() error {
+	var params AnsibleTemplateParams
+	if err := tpl.GetParams(&params); err != nil {
+		return &ValidationError{"invalid ansible template params"}
+	}
+
+	for _, p := range append(params.CollectionsPaths, params.RolesPaths...) {
+		if !filepath.IsLocal(p) {
+			return &ValidationError{"collections and roles paths must be relative to the repository"}
+		}
+	}
+
+	if err := params.Execution.Validate(); err != nil {
+		return err
+	}
+
+	if err := params.Pull.Validate(); err != nil {
+		return err
+	}
+
+	if params.RemoteUser != "" && !runAsUserRegexp.MatchString(params.RemoteUser) {
+		return &ValidationError{"invalid remote user " + params.RemoteUser}
+	}
+
+	if params.BecomeUser != "" && !runAsUserRegexp.MatchString(params.BecomeUser) {
+		return &ValidationError{"invalid become user " + params.BecomeUser}
+	}
+
+	if params.AnsibleCoreVersion != "" && !ansibleCoreVersionRegexp.MatchString(params.AnsibleCoreVersion) {
+		return &ValidationError{"invalid ansible-core version"}
+	}
+
+	if params.PythonInterpreter != "" {
+		if strings.HasPrefix(params.PythonInterpreter, "-") {
+			return &ValidationError{"invalid Python interpreter"}
+		}
+
+		if params.AnsibleCoreVersion == "" && !filepath.IsAbs(params.PythonInterpreter) {
+			return &ValidationError{"Python interpreter path must be absolute"}
+		}
+	}
+
+	return nil
+}
+
+func (tpl *Template) validateTerraformParams() error {
+	var params TerraformTemplateParams
+	if err := tpl.GetParams(&params); err != nil {
+		return &ValidationError{"invalid terraform template params"}
+	}
+
+	if params.LockTimeout != "" {
+		if d, err := time.ParseDuration(params.LockTimeout); err != nil || d < 0 {
+			return &ValidationError{"invalid terraform state lock timeout"}
+		}
+	}
+
+	return nil
+}
+
+func (tpl *Template) validateShellParams() error {
+	var params ShellTemplateParams
+	if err := tpl.GetParams(&params); err != nil {
+		return &ValidationError{"invalid shell template params"}
+	}
+
+	switch params.Shell {
+	case ShellDefault, ShellBash, ShellSh, ShellZsh, ShellPwsh:
+	default:
+		return &ValidationError{"template shell must be bash, sh, zsh or pwsh"}
+	}
+
+	if params.WorkingDirectory != "" && !filepath.IsLocal(params.WorkingDirectory) {
+		return &ValidationError{"working directory must be relative to the repository"}
+	}
+
+	switch params.VarsMode {
+	case ShellVarsArgs:
+	case ShellVarsEnv:
+		for _, v := range tpl.SurveyVars {
+			if !IsValidEnvVarName(v.Name) {
+				return &ValidationError{"survey variable " + v.Name + " can not be passed as environment variable"}
+			}
+		}
+	default:
+		return &ValidationError{"template variables mode must be empty or env"}
+	}
+
+	return nil
+}
+
 func (tpl *Template) Validate() error {
+	tpl.normalizeViewsAndTags()
+
+	for _, tag := range tpl.Tags {
+		if len(tag) > 255 {
+			return &ValidationError{"template tag can not be longer than 255 characters"}
+		}
+	}
+
 	switch tpl.App {
 	case AppAnsible:
 		if tpl.InventoryID == nil {
 			return &ValidationError{"template inventory can not be empty"}
 		}
+
+		if err := tpl.validateAnsibleParams(); err != nil {
+			return err
+		}
+	case AppTerraform, AppTofu:
+		if err := tpl.validateTerraformParams(); err != nil {
+			return err
+		}
+	case AppBash, AppPowerShell:
+		if err := tpl.validateShellParams(); err != nil {
+			return err
+		}
 	}
 
 	if tpl.Name == "" {
@@ -133,9 +538,123 @@ func (tpl *Template) Validate() error {
 		}
 	}
 
+	if tpl.MaxDuration < 0 || tpl.DurationFactor < 0 {
+		return &ValidationError{"template runtime budget can not be negative"}
+	}
+
+	switch tpl.SecretsScan {
+	case SecretsScanDisabled, SecretsScanWarn, SecretsScanFail:
+	default:
+		return &ValidationError{"template secrets scan mode must be empty, warn or fail"}
+	}
+
+	switch tpl.RunConflictPolicy {
+	case RunConflictQueue, RunConflictSkip, RunConflictCancelOlder:
+	default:
+		return &ValidationError{"template run conflict policy must be empty, skip or cancel_older"}
+	}
+
+	tpl.ConcurrencyGroup = strings.TrimSpace(tpl.ConcurrencyGroup)
+	if len(tpl.ConcurrencyGroup) > 100 {
+		return &ValidationError{"template concurrency group can not be longer than 100 characters"}
+	}
+
+	if err := tpl.validateChangeWindow(); err != nil {
+		return err
+	}
+
+	if err := tpl.VariableGroupIDs.Validate(); err != nil {
+		return err
+	}
+
+	if err := tpl.SecretFiles.Validate(); err != nil {
+		return err
+	}
+
+	if err := tpl.Artifacts.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// HasChangeWindow returns true if tasks of the template must run inside the change window.
+func (tpl *Template) HasChangeWindow() bool {
+	return tpl.ChangeWindowStart != "" && tpl.ChangeWindowEnd != ""
+}
+
+// GetChangeWindowEnd returns the end of the change window which contains the moment
+// or false if the moment is outside the change window.
+func (tpl *Template) GetChangeWindowEnd(moment time.Time) (end time.Time, ok bool) {
+	start, err := parseClock(tpl.ChangeWindowStart)
+	if err != nil {
+		return
+	}
+
+	finish, err := parseClock(tpl.ChangeWindowEnd)
+	if err != nil {
+		return
+	}
+
+	// the window containing the moment starts today or yesterday if it crosses midnight
+	for _, day := range []int{0, -1} {
+		windowStart := time.Date(moment.Year(), moment.Month(), moment.Day()+day, start.hour, start.minute, 0, 0, moment.Location())
+		windowEnd := time.Date(moment.Year(), moment.Month(), moment.Day()+day, finish.hour, finish.minute, 0, 0, moment.Location())
+
+		if !windowEnd.After(windowStart) {
+			windowEnd = windowEnd.AddDate(0, 0, 1)
+		}
+
+		if !moment.Before(windowStart) && moment.Before(windowEnd) {
+			return windowEnd, true
+		}
+	}
+
+	return
+}
+
+func (tpl *Template) validateChangeWindow() error {
+	if tpl.ChangeWindowStart == "" && tpl.ChangeWindowEnd == "" {
+		if tpl.AbortTemplateID != nil {
+			return &ValidationError{"template abort template requires change window"}
+		}
+		return nil
+	}
+
+	start, err := parseClock(tpl.ChangeWindowStart)
+	if err != nil {
+		return &ValidationError{"template change window start must be in HH:MM format"}
+	}
+
+	end, err := parseClock(tpl.ChangeWindowEnd)
+	if err != nil {
+		return &ValidationError{"template change window end must be in HH:MM format"}
+	}
+
+	if start == end {
+		return &ValidationError{"template change window start and end can not be equal"}
+	}
+
+	if tpl.AbortTemplateID != nil && tpl.ID != 0 && *tpl.AbortTemplateID == tpl.ID {
+		return &ValidationError{"template can not be its own abort template"}
+	}
+
 	return nil
 }
 
+type clock struct {
+	hour   int
+	minute int
+}
+
+func parseClock(s string) (c clock, err error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return
+	}
+	return clock{hour: t.Hour(), minute: t.Minute()}, nil
+}
+
 func FillTemplate(d Store, template *Template) (err error) {
 	var vaults []TemplateVault
 	vaults, err = d.GetTemplateVaults(template.ProjectID, template.ID)
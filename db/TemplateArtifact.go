@@ -0,0 +1,227 @@
+package db
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type ArtifactRegistry string
+
+const (
+	// ArtifactRegistryS3 uploads the artifact to the S3 compatible bucket.
+	ArtifactRegistryS3 ArtifactRegistry = "s3"
+	// ArtifactRegistryNexusRaw uploads the artifact to the raw repository of Nexus.
+	ArtifactRegistryNexusRaw ArtifactRegistry = "nexus_raw"
+	// ArtifactRegistryGitHubRelease attaches the artifact to the release of the GitHub repository.
+	ArtifactRegistryGitHubRelease ArtifactRegistry = "github_release"
+)
+
+// DefaultArtifactVersion is the version template of the artifact if the template does not define it.
+const DefaultArtifactVersion = "{{ .Version }}"
+
+// TemplateArtifact is the file built by the task which is published to the registry
+// after the successful run, e.g. the installable bundle.
+type TemplateArtifact struct {
+	// Path is the path of the file relative to the repository root.
+	Path     string           `json:"path"`
+	Registry ArtifactRegistry `json:"registry"`
+	// URL is the endpoint of the S3 storage, the URL of the Nexus raw repository
+	// or the GitHub repository in owner/name form.
+	URL string `json:"url"`
+	// Bucket, Region and PathStyle are used by the S3 registry only.
+	Bucket    string `json:"bucket,omitempty"`
+	Region    string `json:"region,omitempty"`
+	PathStyle bool   `json:"path_style,omitempty"`
+	// Version is the template of the published version, e.g. "v{{ .Version }}".
+	// It is the directory of the artifact in S3 and Nexus and the release tag in GitHub.
+	Version string `json:"version,omitempty"`
+	// Name is the template of the published file name, e.g. "app-{{ .Version }}.tar.gz".
+	// The base name of the file is used if it is empty.
+	Name string `json:"name,omitempty"`
+	// KeyID is the key of the registry. Login/password keys are used by S3 and Nexus,
+	// string or login/password keys with the token as the password are used by GitHub.
+	KeyID *int `json:"key_id"`
+
+	Key *AccessKey `json:"-"`
+}
+
+// ArtifactVars are the values available in the version and name templates of the artifact.
+type ArtifactVars struct {
+	TaskID     int
+	TemplateID int
+	// Version is the version of the task, the version of its build task or the task ID.
+	Version    string
+	CommitHash string
+	// File is the base name of the file.
+	File string
+}
+
+func parseArtifactTemplate(text string) (*template.Template, error) {
+	return template.New("artifact").Option("missingkey=error").Parse(text)
+}
+
+func renderArtifactTemplate(text string, vars ArtifactVars) (string, error) {
+	tpl, err := parseArtifactTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err = tpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// GetVersion returns the published version of the artifact.
+func (a *TemplateArtifact) GetVersion(vars ArtifactVars) (string, error) {
+	text := a.Version
+	if text == "" {
+		text = DefaultArtifactVersion
+	}
+
+	version, err := renderArtifactTemplate(text, vars)
+	if err != nil {
+		return "", err
+	}
+
+	if version == "" || strings.ContainsAny(version, "/\\") || version == "." || version == ".." {
+		return "", errors.New("invalid version " + strconv.Quote(version) + " of artifact " + a.Path)
+	}
+
+	return version, nil
+}
+
+// GetName returns the published file name of the artifact.
+func (a *TemplateArtifact) GetName(vars ArtifactVars) (string, error) {
+	vars.File = filepath.Base(a.Path)
+
+	if a.Name == "" {
+		return vars.File, nil
+	}
+
+	name, err := renderArtifactTemplate(a.Name, vars)
+	if err != nil {
+		return "", err
+	}
+
+	if name == "" || strings.ContainsAny(name, "/\\") || name == "." || name == ".." {
+		return "", errors.New("invalid name " + strconv.Quote(name) + " of artifact " + a.Path)
+	}
+
+	return name, nil
+}
+
+func (a *TemplateArtifact) Validate() error {
+	if a.Path == "" || !filepath.IsLocal(a.Path) {
+		return &ValidationError{"artifact path must be relative to the repository"}
+	}
+
+	switch a.Registry {
+	case ArtifactRegistryS3:
+		if !strings.HasPrefix(a.URL, "http://") && !strings.HasPrefix(a.URL, "https://") {
+			return &ValidationError{"URL of artifact " + a.Path + " must be the endpoint of the S3 storage"}
+		}
+		if a.Bucket == "" {
+			return &ValidationError{"bucket of artifact " + a.Path + " can not be empty"}
+		}
+		if a.KeyID == nil {
+			return &ValidationError{"key of artifact " + a.Path + " can not be empty"}
+		}
+	case ArtifactRegistryNexusRaw:
+		if !strings.HasPrefix(a.URL, "http://") && !strings.HasPrefix(a.URL, "https://") {
+			return &ValidationError{"URL of artifact " + a.Path + " must be the URL of the Nexus raw repository"}
+		}
+	case ArtifactRegistryGitHubRelease:
+		if parts := strings.Split(a.URL, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return &ValidationError{"URL of artifact " + a.Path + " must be the GitHub repository in owner/name form"}
+		}
+		if a.KeyID == nil {
+			return &ValidationError{"key of artifact " + a.Path + " can not be empty"}
+		}
+	default:
+		return &ValidationError{"invalid registry of artifact " + a.Path}
+	}
+
+	for _, text := range []string{a.Version, a.Name} {
+		if _, err := parseArtifactTemplate(text); err != nil {
+			return &ValidationError{"invalid template of artifact " + a.Path + ": " + err.Error()}
+		}
+	}
+
+	return nil
+}
+
+type TemplateArtifacts []TemplateArtifact
+
+func (artifacts TemplateArtifacts) Validate() error {
+	for i := range artifacts {
+		if err := artifacts[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (artifacts *TemplateArtifacts) Scan(value interface{}) error {
+	if value == nil {
+		*artifacts = nil
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, artifacts)
+	case string:
+		return json.Unmarshal([]byte(v), artifacts)
+	default:
+		return errors.New("unsupported type for TemplateArtifacts")
+	}
+}
+
+func (artifacts TemplateArtifacts) Value() (driver.Value, error) {
+	if len(artifacts) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(artifacts)
+}
+
+// FillTemplateArtifacts loads keys of the artifacts of the template.
+func FillTemplateArtifacts(d Store, tpl *Template) error {
+	for i := range tpl.Artifacts {
+		if tpl.Artifacts[i].KeyID == nil {
+			continue
+		}
+
+		key, err := d.GetAccessKey(tpl.ProjectID, *tpl.Artifacts[i].KeyID)
+		if err != nil {
+			return err
+		}
+		tpl.Artifacts[i].Key = &key
+	}
+	return nil
+}
+
+// TaskArtifact is the artifact published by the task.
+type TaskArtifact struct {
+	ID       int              `db:"id" json:"id"`
+	TaskID   int              `db:"task_id" json:"task_id"`
+	Path     string           `db:"path" json:"path"`
+	Registry ArtifactRegistry `db:"registry" json:"registry"`
+	Version  string           `db:"version" json:"version"`
+	Name     string           `db:"name" json:"name"`
+	// URL is the location of the published artifact.
+	URL       string    `db:"url" json:"url"`
+	Size      int64     `db:"size" json:"size"`
+	SHA256    string    `db:"sha256" json:"sha256"`
+	Published time.Time `db:"published" json:"published"`
+}
@@ -0,0 +1,73 @@
+package db
+
+import "testing"
+
+func TestTemplateArtifactName(t *testing.T) {
+	artifact := TemplateArtifact{
+		Path:    "dist/app.tar.gz",
+		Version: "v{{ .Version }}",
+		Name:    "app-{{ .Version }}-{{ .TaskID }}.tar.gz",
+	}
+
+	vars := ArtifactVars{TaskID: 12, Version: "1.0.3"}
+
+	version, err := artifact.GetVersion(vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version != "v1.0.3" {
+		t.Fatalf("unexpected version %s", version)
+	}
+
+	name, err := artifact.GetName(vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name != "app-1.0.3-12.tar.gz" {
+		t.Fatalf("unexpected name %s", name)
+	}
+
+	artifact.Name = ""
+	if name, _ = artifact.GetName(vars); name != "app.tar.gz" {
+		t.Fatalf("base name of the file must be used by default, got %s", name)
+	}
+
+	artifact.Version = "{{ .Version }}/.."
+	if _, err = artifact.GetVersion(vars); err == nil {
+		t.Fatal("version with slash must be rejected")
+	}
+}
+
+func TestTemplateArtifactValidate(t *testing.T) {
+	keyID := 1
+
+	valid := []TemplateArtifact{
+		{Path: "app.zip", Registry: ArtifactRegistryS3, URL: "https://s3.amazonaws.com", Bucket: "bundles", KeyID: &keyID},
+		{Path: "app.zip", Registry: ArtifactRegistryNexusRaw, URL: "https://nexus.example.com/repository/raw"},
+		{Path: "app.zip", Registry: ArtifactRegistryGitHubRelease, URL: "acme/app", KeyID: &keyID},
+	}
+
+	for _, artifact := range valid {
+		if err := artifact.Validate(); err != nil {
+			t.Fatalf("artifact %s must be valid: %s", artifact.Registry, err.Error())
+		}
+	}
+
+	invalid := []TemplateArtifact{
+		{Path: "../app.zip", Registry: ArtifactRegistryNexusRaw, URL: "https://nexus.example.com/repository/raw"},
+		{Path: "app.zip", Registry: ArtifactRegistryS3, URL: "https://s3.amazonaws.com", KeyID: &keyID},
+		{Path: "app.zip", Registry: ArtifactRegistryNexusRaw, URL: "nexus.example.com"},
+		{Path: "app.zip", Registry: ArtifactRegistryGitHubRelease, URL: "acme/app"},
+		{Path: "app.zip", Registry: ArtifactRegistryGitHubRelease, URL: "acme", KeyID: &keyID},
+		{Path: "app.zip", Registry: ArtifactRegistryNexusRaw, URL: "https://nexus.example.com", Name: "{{ .Version"},
+		{Path: "app.zip", Registry: "ftp"},
+	}
+
+	for i, artifact := range invalid {
+		if err := artifact.Validate(); err == nil {
+			t.Fatalf("artifact %d must be invalid", i)
+		}
+	}
+}
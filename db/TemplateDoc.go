@@ -0,0 +1,129 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type TemplateDocVarSource string
+
+const (
+	TemplateDocVarPlay         TemplateDocVarSource = "vars"
+	TemplateDocVarFile         TemplateDocVarSource = "vars_files"
+	TemplateDocVarPrompt       TemplateDocVarSource = "vars_prompt"
+	TemplateDocVarRoleDefaults TemplateDocVarSource = "role_defaults"
+)
+
+// TemplateDocVar is the variable declared by the playbook.
+type TemplateDocVar struct {
+	Name   string               `json:"name"`
+	Source TemplateDocVarSource `json:"source"`
+	// File is the path of the file declaring the variable, relative to the repository root.
+	File string `json:"file"`
+	// Default is the value of the variable in the playbook. It is not set for
+	// private prompts and values encrypted by Ansible Vault.
+	Default any    `json:"default,omitempty"`
+	Prompt  string `json:"prompt,omitempty"`
+	// Secret is set for private prompts and values encrypted by Ansible Vault.
+	Secret bool `json:"secret,omitempty"`
+}
+
+// TemplateDocPlay is the play of the playbook.
+type TemplateDocPlay struct {
+	Name  string   `json:"name"`
+	Hosts []string `json:"hosts"`
+	Tags  []string `json:"tags"`
+	Roles []string `json:"roles"`
+	// File is the path of the playbook file containing the play, relative to the repository root.
+	File string `json:"file"`
+}
+
+// TemplateDocContent is discovered by parsing the playbook of the template.
+type TemplateDocContent struct {
+	// Description is the comment at the beginning of the playbook.
+	Description string            `json:"description"`
+	Plays       []TemplateDocPlay `json:"plays"`
+	Vars        []TemplateDocVar  `json:"vars"`
+	// Tags are tags of plays, roles and tasks of the playbook.
+	Tags []string `json:"tags"`
+	// Warnings are problems found while parsing, e.g. files which can not be read.
+	Warnings []string `json:"warnings"`
+}
+
+func (c *TemplateDocContent) Scan(value interface{}) error {
+	if value == nil {
+		*c = TemplateDocContent{}
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return errors.New("unsupported type for TemplateDocContent")
+	}
+}
+
+func (c TemplateDocContent) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// TemplateDoc is the documentation of the template generated from its playbook
+// at the checkout of the repository. Only the last version is kept.
+type TemplateDoc struct {
+	TemplateID int       `db:"template_id" json:"template_id"`
+	ProjectID  int       `db:"project_id" json:"project_id"`
+	Playbook   string    `db:"playbook" json:"playbook"`
+	CommitHash *string   `db:"commit_hash" json:"commit_hash"`
+	Updated    time.Time `db:"updated" json:"updated"`
+
+	Content TemplateDocContent `db:"content" json:"content"`
+}
+
+// GetSurveyVars returns survey variables of the launch form generated
+// from prompts and variables of the playbook.
+func (c TemplateDocContent) GetSurveyVars() []SurveyVar {
+	res := make([]SurveyVar, 0)
+	added := make(map[string]bool)
+
+	for _, v := range c.Vars {
+		if added[v.Name] || v.Source == TemplateDocVarRoleDefaults {
+			continue
+		}
+		added[v.Name] = true
+
+		surveyVar := SurveyVar{
+			Name:  v.Name,
+			Title: v.Name,
+			// variables without default values must be entered at the launch
+			Required: v.Default == nil && !v.Secret,
+			Type:     SurveyVarType(SurveyVarStr),
+		}
+
+		if v.Prompt != "" {
+			surveyVar.Title = v.Prompt
+		}
+
+		if _, ok := v.Default.(int); ok {
+			surveyVar.Type = SurveyVarType(SurveyVarInt)
+		}
+
+		if v.Default != nil {
+			if b, err := json.Marshal(v.Default); err == nil {
+				surveyVar.Description = "Default: " + string(b)
+			}
+		}
+
+		if v.Secret {
+			surveyVar.Type = SurveyVarType(SurveyVarSecret)
+		}
+
+		res = append(res, surveyVar)
+	}
+
+	return res
+}
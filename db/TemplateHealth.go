@@ -0,0 +1,158 @@
+package db
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+const (
+	// TemplateHealthWindow is the default number of the last tasks used to compute the health.
+	TemplateHealthWindow = 30
+
+	// The template is flaky if its runs with the same configuration alternate
+	// between success and failure at least TemplateFlakyMinFlips times and
+	// in at least TemplateFlakyMinRate of the comparable runs.
+	TemplateFlakyMinFlips = 2
+	TemplateFlakyMinRate  = 0.3
+)
+
+// TemplateHealth describes the stability of the template over the last finished tasks.
+type TemplateHealth struct {
+	TemplateID int `json:"template_id"`
+
+	// TaskCount is the number of successful and failed tasks in the window.
+	TaskCount    int `json:"task_count"`
+	SuccessCount int `json:"success_count"`
+	FailCount    int `json:"fail_count"`
+
+	// Flips is the number of runs whose result differs from the previous run
+	// with the same configuration: commit, inventory, variables and arguments.
+	// FlakinessRate is the ratio of flips to the runs which have such a previous run.
+	Flips         int     `json:"flips"`
+	FlakinessRate float64 `json:"flakiness_rate"`
+	Flaky         bool    `json:"flaky"`
+
+	// DurationMean and DurationStdDev are in seconds. DurationVariation is
+	// the coefficient of variation, the standard deviation divided by the mean.
+	DurationMean      float64 `json:"duration_mean"`
+	DurationStdDev    float64 `json:"duration_std_dev"`
+	DurationVariation float64 `json:"duration_variation"`
+
+	// Score is from 0 to 100, it is reduced by failures, flakiness and unstable duration.
+	Score int `json:"score"`
+}
+
+// getTaskConfigKey returns the key of the settings which affect the result of the task.
+// Runs with the same key are expected to give the same result.
+func getTaskConfigKey(task Task) string {
+	b, _ := json.Marshal(struct {
+		CommitHash  *string           `json:"commit_hash"`
+		Version     *string           `json:"version"`
+		GitBranch   *string           `json:"git_branch"`
+		InventoryID *int              `json:"inventory_id"`
+		Playbook    string            `json:"playbook"`
+		Environment string            `json:"environment"`
+		Limit       string            `json:"limit"`
+		Arguments   *string           `json:"arguments"`
+		Params      MapStringAnyField `json:"params"`
+		DryRun      bool              `json:"dry_run"`
+	}{
+		CommitHash:  task.CommitHash,
+		Version:     task.Version,
+		GitBranch:   task.GitBranch,
+		InventoryID: task.InventoryID,
+		Playbook:    task.Playbook,
+		Environment: task.Environment,
+		Limit:       task.Limit,
+		Arguments:   task.Arguments,
+		Params:      task.Params,
+		DryRun:      task.DryRun,
+	})
+	return string(b)
+}
+
+// GetTemplateHealth computes the health of the template by the last window tasks.
+// Only successful and failed tasks are taken into account.
+func GetTemplateHealth(templateID int, tasks []TaskWithTpl, window int) TemplateHealth {
+	health := TemplateHealth{
+		TemplateID: templateID,
+		Score:      100,
+	}
+
+	var finished []Task
+	for _, task := range tasks {
+		if task.Status == task_logger.TaskSuccessStatus || task.Status == task_logger.TaskFailStatus {
+			finished = append(finished, task.Task)
+		}
+	}
+
+	// the last tasks of the window in the order they were run
+	sort.SliceStable(finished, func(i, j int) bool {
+		return finished[i].ID < finished[j].ID
+	})
+	if window > 0 && len(finished) > window {
+		finished = finished[len(finished)-window:]
+	}
+
+	lastResults := make(map[string]task_logger.TaskStatus)
+	comparable := 0
+	var durations []float64
+
+	for _, task := range finished {
+		health.TaskCount++
+		if task.Status == task_logger.TaskSuccessStatus {
+			health.SuccessCount++
+		} else {
+			health.FailCount++
+		}
+
+		key := getTaskConfigKey(task)
+		if last, ok := lastResults[key]; ok {
+			comparable++
+			if last != task.Status {
+				health.Flips++
+			}
+		}
+		lastResults[key] = task.Status
+
+		if task.Start != nil && task.End != nil {
+			durations = append(durations, task.End.Sub(*task.Start).Seconds())
+		}
+	}
+
+	if health.TaskCount == 0 {
+		return health
+	}
+
+	if comparable > 0 {
+		health.FlakinessRate = float64(health.Flips) / float64(comparable)
+	}
+	health.Flaky = health.Flips >= TemplateFlakyMinFlips && health.FlakinessRate >= TemplateFlakyMinRate
+
+	if len(durations) > 0 {
+		sum := 0.0
+		for _, d := range durations {
+			sum += d
+		}
+		health.DurationMean = sum / float64(len(durations))
+
+		variance := 0.0
+		for _, d := range durations {
+			variance += (d - health.DurationMean) * (d - health.DurationMean)
+		}
+		health.DurationStdDev = math.Sqrt(variance / float64(len(durations)))
+
+		if health.DurationMean > 0 {
+			health.DurationVariation = health.DurationStdDev / health.DurationMean
+		}
+	}
+
+	failRate := float64(health.FailCount) / float64(health.TaskCount)
+	penalty := 50*failRate + 30*health.FlakinessRate + 20*math.Min(health.DurationVariation, 1)
+	health.Score = int(math.Round(math.Max(0, 100-penalty)))
+
+	return health
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+func TestGetTemplateHealth(t *testing.T) {
+	start := time.Now()
+	commit := "abc"
+
+	task := func(id int, status task_logger.TaskStatus, seconds int) TaskWithTpl {
+		end := start.Add(time.Duration(seconds) * time.Second)
+		return TaskWithTpl{Task: Task{ID: id, Status: status, CommitHash: &commit, Start: &start, End: &end}}
+	}
+
+	health := GetTemplateHealth(1, []TaskWithTpl{
+		task(6, task_logger.TaskRunningStatus, 0),
+		task(5, task_logger.TaskFailStatus, 10),
+		task(4, task_logger.TaskSuccessStatus, 10),
+		task(3, task_logger.TaskFailStatus, 10),
+		task(2, task_logger.TaskSuccessStatus, 10),
+		task(1, task_logger.TaskStoppedStatus, 1),
+	}, TemplateHealthWindow)
+
+	if health.TaskCount != 4 || health.Flips != 3 || !health.Flaky {
+		t.Fatalf("expected flaky template, got %+v", health)
+	}
+
+	if health.DurationMean != 10 || health.DurationVariation != 0 {
+		t.Fatalf("unexpected duration %+v", health)
+	}
+
+	if health.Score != 45 {
+		t.Fatalf("expected score 45, got %d", health.Score)
+	}
+}
+
+func TestGetTemplateHealthConfigChanges(t *testing.T) {
+	task := func(id int, status task_logger.TaskStatus, commit string) TaskWithTpl {
+		return TaskWithTpl{Task: Task{ID: id, Status: status, CommitHash: &commit}}
+	}
+
+	// every failure is fixed by the new commit, the template is not flaky
+	health := GetTemplateHealth(1, []TaskWithTpl{
+		task(1, task_logger.TaskFailStatus, "a"),
+		task(2, task_logger.TaskSuccessStatus, "b"),
+		task(3, task_logger.TaskFailStatus, "c"),
+		task(4, task_logger.TaskSuccessStatus, "d"),
+	}, TemplateHealthWindow)
+
+	if health.Flips != 0 || health.Flaky {
+		t.Fatalf("expected stable template, got %+v", health)
+	}
+
+	health = GetTemplateHealth(1, []TaskWithTpl{
+		task(1, task_logger.TaskFailStatus, "a"),
+		task(2, task_logger.TaskSuccessStatus, "a"),
+		task(3, task_logger.TaskFailStatus, "a"),
+	}, 2)
+
+	if health.TaskCount != 2 || health.Flips != 1 {
+		t.Fatalf("expected the window of 2 tasks, got %+v", health)
+	}
+}
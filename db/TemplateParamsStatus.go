@@ -0,0 +1,112 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+// TemplateParamsStatus is the status of the last run of the template
+// with the distinct set of survey values, e.g. site=a and site=b.
+type TemplateParamsStatus struct {
+	// Fingerprint identifies the set of values.
+	Fingerprint string         `json:"fingerprint"`
+	Params      map[string]any `json:"params"`
+	TaskCount   int            `json:"task_count"`
+
+	LastTaskID     int                    `json:"last_task_id"`
+	LastTaskStatus task_logger.TaskStatus `json:"last_task_status"`
+	LastTaskEnd    *time.Time             `json:"last_task_end"`
+
+	LastSuccessTaskID *int       `json:"last_success_task_id"`
+	LastSuccessEnd    *time.Time `json:"last_success_end"`
+}
+
+// GetTaskParams returns the values of survey variables the task was run with.
+// All extra variables of the task are returned if the template has no survey variables.
+func (tpl *Template) GetTaskParams(task Task) map[string]any {
+	params := make(map[string]any)
+
+	if task.Environment == "" {
+		return params
+	}
+
+	vars := make(map[string]any)
+	if err := json.Unmarshal([]byte(task.Environment), &vars); err != nil {
+		return params
+	}
+
+	if len(tpl.SurveyVars) == 0 {
+		return vars
+	}
+
+	for _, v := range tpl.SurveyVars {
+		if value, ok := vars[v.Name]; ok {
+			params[v.Name] = value
+		}
+	}
+
+	return params
+}
+
+// GetParamsFingerprint returns the hash identifying the set of values.
+func GetParamsFingerprint(params map[string]any) string {
+	// keys of maps are sorted by encoding/json, so the same values give the same hash
+	b, _ := json.Marshal(params)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+// GetTemplateParamsStatuses groups finished tasks of the template by the values of survey variables
+// and returns the last status of each group. The most recently run groups are returned first.
+func GetTemplateParamsStatuses(tpl Template, tasks []TaskWithTpl) []TemplateParamsStatus {
+	sorted := make([]TaskWithTpl, len(tasks))
+	copy(sorted, tasks)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ID > sorted[j].ID
+	})
+
+	groups := make(map[string]*TemplateParamsStatus)
+	var order []string
+
+	for _, task := range sorted {
+		if !task.Status.IsFinished() {
+			continue
+		}
+
+		params := tpl.GetTaskParams(task.Task)
+		fingerprint := GetParamsFingerprint(params)
+
+		group, ok := groups[fingerprint]
+		if !ok {
+			group = &TemplateParamsStatus{
+				Fingerprint:    fingerprint,
+				Params:         params,
+				LastTaskID:     task.ID,
+				LastTaskStatus: task.Status,
+				LastTaskEnd:    task.End,
+			}
+			groups[fingerprint] = group
+			order = append(order, fingerprint)
+		}
+
+		group.TaskCount++
+
+		if group.LastSuccessTaskID == nil && task.Status == task_logger.TaskSuccessStatus {
+			taskID := task.ID
+			group.LastSuccessTaskID = &taskID
+			group.LastSuccessEnd = task.End
+		}
+	}
+
+	res := make([]TemplateParamsStatus, 0, len(order))
+	for _, fingerprint := range order {
+		res = append(res, *groups[fingerprint])
+	}
+
+	return res
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+func TestGetTemplateParamsStatuses(t *testing.T) {
+	tpl := Template{
+		SurveyVars: []SurveyVar{{Name: "site"}},
+	}
+
+	task := func(id int, status task_logger.TaskStatus, env string) TaskWithTpl {
+		return TaskWithTpl{Task: Task{ID: id, Status: status, Environment: env}}
+	}
+
+	statuses := GetTemplateParamsStatuses(tpl, []TaskWithTpl{
+		task(5, task_logger.TaskRunningStatus, `{"site": "a"}`),
+		task(4, task_logger.TaskFailStatus, `{"site": "a", "debug": true}`),
+		task(3, task_logger.TaskSuccessStatus, `{"site": "b"}`),
+		task(2, task_logger.TaskSuccessStatus, `{"site": "a"}`),
+		task(1, task_logger.TaskFailStatus, `{}`),
+	})
+
+	if len(statuses) != 3 {
+		t.Fatalf("expected 3 groups, got %v", statuses)
+	}
+
+	a := statuses[0]
+	if a.Params["site"] != "a" || a.TaskCount != 2 || a.LastTaskID != 4 || a.LastTaskStatus != task_logger.TaskFailStatus {
+		t.Fatalf("unexpected status of site a %v", a)
+	}
+
+	if a.LastSuccessTaskID == nil || *a.LastSuccessTaskID != 2 {
+		t.Fatal("last successful task of site a must be 2")
+	}
+
+	b := statuses[1]
+	if b.Params["site"] != "b" || b.LastTaskStatus != task_logger.TaskSuccessStatus {
+		t.Fatalf("unexpected status of site b %v", b)
+	}
+
+	if len(statuses[2].Params) != 0 || statuses[2].LastSuccessTaskID != nil {
+		t.Fatalf("unexpected status of the task without values %v", statuses[2])
+	}
+}
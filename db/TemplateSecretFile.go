@@ -0,0 +1,134 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+)
+
+// DefaultSecretFileMode is the permission mode of the secret file if the template does not define it.
+const DefaultSecretFileMode = 0600
+
+// TemplateSecretFile is the access key which is written to the file in the repository
+// of the task before the run, e.g. kubeconfig or .npmrc. The file is removed after the run.
+type TemplateSecretFile struct {
+	KeyID int `json:"key_id"`
+	// Path is the path of the file relative to the repository root.
+	Path string `json:"path"`
+	// Mode is the octal permission mode of the file, e.g. 0600.
+	Mode string `json:"mode,omitempty"`
+
+	Key *AccessKey `json:"-"`
+}
+
+// GetMode returns the permission mode of the file.
+func (f *TemplateSecretFile) GetMode() uint32 {
+	if f.Mode == "" {
+		return DefaultSecretFileMode
+	}
+
+	mode, err := strconv.ParseUint(f.Mode, 8, 32)
+	if err != nil {
+		return DefaultSecretFileMode
+	}
+
+	return uint32(mode)
+}
+
+func (f *TemplateSecretFile) Validate() error {
+	if f.KeyID == 0 {
+		return &ValidationError{"secret file key can not be empty"}
+	}
+
+	if f.Path == "" || !filepath.IsLocal(f.Path) {
+		return &ValidationError{"secret file path must be relative to the repository"}
+	}
+
+	if f.Mode != "" {
+		mode, err := strconv.ParseUint(f.Mode, 8, 32)
+		if err != nil || mode > 0777 {
+			return &ValidationError{"invalid secret file mode " + f.Mode}
+		}
+
+		if mode&0400 == 0 || mode&0022 != 0 {
+			return &ValidationError{"secret file must be readable by the owner and not writable by others"}
+		}
+	}
+
+	return nil
+}
+
+// GetContent returns the content of the file. Only string and SSH keys can be installed as files.
+// The key must be deserialized.
+func (f *TemplateSecretFile) GetContent() ([]byte, error) {
+	if f.Key == nil {
+		return nil, fmt.Errorf("key of secret file %s is not loaded", f.Path)
+	}
+
+	switch f.Key.Type {
+	case AccessKeyString:
+		return []byte(f.Key.String), nil
+	case AccessKeySSH:
+		return []byte(f.Key.SshKey.PrivateKey), nil
+	default:
+		return nil, fmt.Errorf("key of type %s can not be installed as file", f.Key.Type)
+	}
+}
+
+type TemplateSecretFiles []TemplateSecretFile
+
+func (files TemplateSecretFiles) Validate() error {
+	paths := make(map[string]bool)
+
+	for i := range files {
+		if err := files[i].Validate(); err != nil {
+			return err
+		}
+
+		p := filepath.Clean(files[i].Path)
+		if paths[p] {
+			return &ValidationError{"secret file path " + p + " is used more than once"}
+		}
+		paths[p] = true
+	}
+
+	return nil
+}
+
+func (files *TemplateSecretFiles) Scan(value interface{}) error {
+	if value == nil {
+		*files = nil
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, files)
+	case string:
+		return json.Unmarshal([]byte(v), files)
+	default:
+		return errors.New("unsupported type for TemplateSecretFiles")
+	}
+}
+
+func (files TemplateSecretFiles) Value() (driver.Value, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(files)
+}
+
+// FillTemplateSecretFiles loads keys of the secret files of the template.
+func FillTemplateSecretFiles(d Store, tpl *Template) error {
+	for i := range tpl.SecretFiles {
+		key, err := d.GetAccessKey(tpl.ProjectID, tpl.SecretFiles[i].KeyID)
+		if err != nil {
+			return err
+		}
+		tpl.SecretFiles[i].Key = &key
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+// TemplateSummaryDays is the number of days of the success rate and the average duration of the summary.
+const TemplateSummaryDays = 30
+
+const templateSummaryDayFormat = "2006-01-02"
+
+// TemplateSummaryDay contains counters of the tasks of the template finished in one day (UTC).
+type TemplateSummaryDay struct {
+	Day          string `json:"day"`
+	TaskCount    int    `json:"task_count"`
+	SuccessCount int    `json:"success_count"`
+	// DurationSum is in seconds.
+	DurationSum int64 `json:"duration_sum"`
+}
+
+type TemplateSummaryDayList []TemplateSummaryDay
+
+func (days *TemplateSummaryDayList) Scan(value interface{}) error {
+	if value == nil {
+		*days = nil
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, days)
+	case string:
+		return json.Unmarshal([]byte(v), days)
+	default:
+		return errors.New("unsupported type for TemplateSummaryDayList")
+	}
+}
+
+func (days TemplateSummaryDayList) Value() (driver.Value, error) {
+	if len(days) == 0 {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(days)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// TemplateSummary is the summary of the task history of the template which is updated
+// when tasks are created and finished, so template lists do not aggregate the tasks table.
+type TemplateSummary struct {
+	TemplateID int `db:"template_id" json:"template_id"`
+	ProjectID  int `db:"project_id" json:"project_id"`
+
+	TaskCount      int                     `db:"task_count" json:"task_count"`
+	LastTaskID     *int                    `db:"last_task_id" json:"last_task_id"`
+	LastTaskStatus *task_logger.TaskStatus `db:"last_task_status" json:"last_task_status"`
+	LastSuccess    *time.Time              `db:"last_success" json:"last_success"`
+
+	// Days contains counters of the last TemplateSummaryDays days.
+	Days TemplateSummaryDayList `db:"days" json:"days"`
+
+	Updated time.Time `db:"updated" json:"updated"`
+}
+
+// ApplyTask updates the last task of the summary. created is true for the new task,
+// which becomes the last task. Task IDs are not ordered in all stores, so other tasks
+// only update the status if they are the last task.
+func (s *TemplateSummary) ApplyTask(task Task, created bool) {
+	if created {
+		s.TaskCount++
+	}
+
+	if created || s.LastTaskID == nil || *s.LastTaskID == task.ID {
+		id := task.ID
+		status := task.Status
+		s.LastTaskID = &id
+		s.LastTaskStatus = &status
+	}
+}
+
+// ApplyFinishedTask adds the finished task to the counters of the day when it ended.
+// It must be called once per task.
+func (s *TemplateSummary) ApplyFinishedTask(task Task, now time.Time) {
+	s.ApplyTask(task, false)
+
+	end := now
+	if task.End != nil {
+		end = *task.End
+	}
+	end = end.UTC()
+
+	if task.Status == task_logger.TaskSuccessStatus && (s.LastSuccess == nil || s.LastSuccess.Before(end)) {
+		s.LastSuccess = &end
+	}
+
+	day := end.Format(templateSummaryDayFormat)
+
+	var counters *TemplateSummaryDay
+	for i := range s.Days {
+		if s.Days[i].Day == day {
+			counters = &s.Days[i]
+			break
+		}
+	}
+
+	if counters == nil {
+		s.Days = append(s.Days, TemplateSummaryDay{Day: day})
+		counters = &s.Days[len(s.Days)-1]
+	}
+
+	counters.TaskCount++
+	if task.Status == task_logger.TaskSuccessStatus {
+		counters.SuccessCount++
+	}
+	if task.Start != nil && task.End != nil && task.End.After(*task.Start) {
+		counters.DurationSum += int64(task.End.Sub(*task.Start).Seconds())
+	}
+
+	s.pruneDays(now)
+}
+
+func (s *TemplateSummary) pruneDays(now time.Time) {
+	first := now.UTC().AddDate(0, 0, -TemplateSummaryDays+1).Format(templateSummaryDayFormat)
+
+	days := make(TemplateSummaryDayList, 0, len(s.Days))
+	for _, d := range s.Days {
+		if d.Day >= first {
+			days = append(days, d)
+		}
+	}
+	s.Days = days
+}
+
+// GetRecentCounters returns the number of tasks, successful tasks and the sum of durations
+// of the tasks finished in the last TemplateSummaryDays days.
+func (s *TemplateSummary) GetRecentCounters(now time.Time) (tasks int, successes int, durationSum int64) {
+	first := now.UTC().AddDate(0, 0, -TemplateSummaryDays+1).Format(templateSummaryDayFormat)
+
+	for _, d := range s.Days {
+		if d.Day < first {
+			continue
+		}
+		tasks += d.TaskCount
+		successes += d.SuccessCount
+		durationSum += d.DurationSum
+	}
+
+	return
+}
+
+// FillStats sets the counters of the summary to the stats of the template.
+func (s *TemplateSummary) FillStats(stats *TemplateStats, now time.Time) {
+	stats.TaskCount = s.TaskCount
+	stats.LastTaskID = s.LastTaskID
+	stats.LastTaskStatus = s.LastTaskStatus
+	stats.LastSuccess = s.LastSuccess
+
+	tasks, successes, durationSum := s.GetRecentCounters(now)
+	if tasks > 0 {
+		rate := float64(successes) / float64(tasks)
+		stats.SuccessRate = &rate
+		stats.AverageDuration = int(durationSum / int64(tasks))
+	}
+}
+
+// MakeTemplatesStats combines the summaries and the numbers of active schedules of the templates.
+// Templates without tasks and schedules are skipped.
+func MakeTemplatesStats(summaries []TemplateSummary, scheduleCounts map[int]int, now time.Time) []TemplateStats {
+	statsMap := make(map[int]*TemplateStats)
+
+	getStats := func(templateID int) *TemplateStats {
+		s, ok := statsMap[templateID]
+		if !ok {
+			s = &TemplateStats{TemplateID: templateID}
+			statsMap[templateID] = s
+		}
+		return s
+	}
+
+	for i := range summaries {
+		if summaries[i].TaskCount == 0 && summaries[i].LastTaskID == nil {
+			continue
+		}
+		summaries[i].FillStats(getStats(summaries[i].TemplateID), now)
+	}
+
+	for templateID, count := range scheduleCounts {
+		if count > 0 {
+			getStats(templateID).ScheduleCount = count
+		}
+	}
+
+	stats := make([]TemplateStats, 0, len(statsMap))
+	for _, s := range statsMap {
+		stats = append(stats, *s)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].TemplateID < stats[j].TemplateID
+	})
+
+	return stats
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+func TestTemplateSummaryApplyFinishedTask(t *testing.T) {
+	now := time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC)
+
+	summary := TemplateSummary{ProjectID: 1, TemplateID: 1}
+
+	old := now.AddDate(0, 0, -TemplateSummaryDays)
+	oldStart := old.Add(-time.Hour)
+	summary.ApplyTask(Task{ID: 1, Status: task_logger.TaskWaitingStatus}, true)
+	summary.ApplyFinishedTask(Task{ID: 1, Status: task_logger.TaskFailStatus, Start: &oldStart, End: &old}, old)
+
+	start := now.Add(-2 * time.Minute)
+	for i, status := range []task_logger.TaskStatus{task_logger.TaskSuccessStatus, task_logger.TaskFailStatus} {
+		task := Task{ID: i + 2, Status: status, Start: &start, End: &now}
+		summary.ApplyTask(task, true)
+		summary.ApplyFinishedTask(task, now)
+	}
+
+	if summary.TaskCount != 3 || *summary.LastTaskID != 3 || *summary.LastTaskStatus != task_logger.TaskFailStatus {
+		t.Fatalf("unexpected last task of the summary %v", summary)
+	}
+
+	if summary.LastSuccess == nil || !summary.LastSuccess.Equal(now) {
+		t.Fatalf("unexpected last success %v", summary.LastSuccess)
+	}
+
+	if len(summary.Days) != 1 {
+		t.Fatalf("expected days older than %d days to be pruned, got %v", TemplateSummaryDays, summary.Days)
+	}
+
+	stats := MakeTemplatesStats([]TemplateSummary{summary, {ProjectID: 1, TemplateID: 2}}, map[int]int{3: 1}, now)
+	if len(stats) != 2 || stats[0].TemplateID != 1 || stats[1].TemplateID != 3 || stats[1].ScheduleCount != 1 {
+		t.Fatalf("unexpected stats %v", stats)
+	}
+
+	if stats[0].SuccessRate == nil || *stats[0].SuccessRate != 0.5 || stats[0].AverageDuration != 120 {
+		t.Fatalf("unexpected 30 days stats %v", stats[0])
+	}
+}
@@ -0,0 +1,245 @@
+package db
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// DefaultUploadMaxSize is the maximum size of the uploaded file if it is not configured.
+const DefaultUploadMaxSize = 1 << 20
+
+// TemplateUpload is the small file, e.g. a certificate or a config snippet, uploaded to the template.
+// The file is written to the repository of the task before the run and removed after the run.
+type TemplateUpload struct {
+	ID         int `db:"id" json:"id"`
+	ProjectID  int `db:"project_id" json:"project_id"`
+	TemplateID int `db:"template_id" json:"template_id"`
+	// Path is the path of the file relative to the repository root.
+	Path string `db:"path" json:"path"`
+	// Mode is the octal permission mode of the file, e.g. 0600.
+	Mode   string `db:"mode" json:"mode,omitempty"`
+	Size   int64  `db:"size" json:"size"`
+	SHA256 string `db:"sha256" json:"sha256"`
+	// Data is the encrypted content of the file or the reference to it in the upload storage.
+	Data    string    `db:"data" json:"-"`
+	UserID  *int      `db:"user_id" json:"user_id"`
+	Created time.Time `db:"created" json:"created"`
+	Updated time.Time `db:"updated" json:"updated"`
+
+	// Content is the decrypted content of the file. It is loaded for the task only.
+	Content []byte `db:"-" json:"content,omitempty"`
+}
+
+// GetMode returns the permission mode of the file.
+func (u *TemplateUpload) GetMode() uint32 {
+	file := TemplateSecretFile{Mode: u.Mode}
+	return file.GetMode()
+}
+
+func (u *TemplateUpload) Validate() error {
+	if u.Path == "" || !filepath.IsLocal(u.Path) {
+		return &ValidationError{"upload path must be relative to the repository"}
+	}
+
+	if u.Mode != "" {
+		mode, err := strconv.ParseUint(u.Mode, 8, 32)
+		if err != nil || mode > 0777 {
+			return &ValidationError{"invalid upload mode " + u.Mode}
+		}
+
+		if mode&0400 == 0 || mode&0022 != 0 {
+			return &ValidationError{"uploaded file must be readable by the owner and not writable by others"}
+		}
+	}
+
+	return nil
+}
+
+// UploadStorage keeps the encrypted content of uploaded files outside of the database,
+// e.g. in the object storage. The database keeps references to the content only.
+type UploadStorage interface {
+	// GetUpload returns ErrNotFound if the content does not exist.
+	GetUpload(id string) ([]byte, error)
+	SetUpload(id string, data []byte) error
+	DeleteUpload(id string) error
+}
+
+const uploadRefPrefix = "upload-ref:"
+
+var uploadStorage UploadStorage
+
+// SetUploadStorage sets the storage of the content of new uploads.
+// The content is kept in the database if the storage is nil.
+func SetUploadStorage(storage UploadStorage) {
+	uploadStorage = storage
+}
+
+// GetUploadMaxSize returns the maximum size of the uploaded file in bytes.
+func GetUploadMaxSize() int64 {
+	if util.Config != nil && util.Config.Uploads != nil && util.Config.Uploads.MaxSize > 0 {
+		return util.Config.Uploads.MaxSize
+	}
+	return DefaultUploadMaxSize
+}
+
+// SetContent encrypts the content of the file and stores it to the upload storage if it is configured.
+// The previous content must be removed by DeleteContent after the upload is saved.
+func (u *TemplateUpload) SetContent(content []byte) error {
+	if int64(len(content)) > GetUploadMaxSize() {
+		return &ValidationError{fmt.Sprintf("uploaded file must not be larger than %d bytes", GetUploadMaxSize())}
+	}
+
+	data, err := encryptUpload(content)
+	if err != nil {
+		return err
+	}
+
+	if uploadStorage != nil {
+		b := make([]byte, 16)
+		if _, err = rand.Read(b); err != nil {
+			return err
+		}
+
+		id := hex.EncodeToString(b)
+
+		if err = uploadStorage.SetUpload(id, []byte(data)); err != nil {
+			return err
+		}
+
+		data = uploadRefPrefix + id
+	}
+
+	hash := sha256.Sum256(content)
+
+	u.Data = data
+	u.Size = int64(len(content))
+	u.SHA256 = hex.EncodeToString(hash[:])
+	return nil
+}
+
+// LoadContent decrypts the content of the file into Content.
+func (u *TemplateUpload) LoadContent() error {
+	data := u.Data
+
+	if strings.HasPrefix(data, uploadRefPrefix) {
+		if uploadStorage == nil {
+			return fmt.Errorf("upload storage is not configured")
+		}
+
+		b, err := uploadStorage.GetUpload(strings.TrimPrefix(data, uploadRefPrefix))
+		if err != nil {
+			return err
+		}
+		data = string(b)
+	}
+
+	content, err := decryptUpload(data)
+	if err != nil {
+		return err
+	}
+
+	u.Content = content
+	return nil
+}
+
+// DeleteContent removes the content of the file from the upload storage.
+func (u *TemplateUpload) DeleteContent() error {
+	if !strings.HasPrefix(u.Data, uploadRefPrefix) || uploadStorage == nil {
+		return nil
+	}
+	return uploadStorage.DeleteUpload(strings.TrimPrefix(u.Data, uploadRefPrefix))
+}
+
+// FillTemplateUploads loads uploaded files of the template with their content.
+func FillTemplateUploads(d Store, tpl *Template) (err error) {
+	tpl.Uploads, err = d.GetTemplateUploads(tpl.ProjectID, tpl.ID)
+	if err != nil {
+		return
+	}
+
+	for i := range tpl.Uploads {
+		if err = tpl.Uploads[i].LoadContent(); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func getUploadCipher() (cipher.AEAD, error) {
+	if util.Config == nil || util.Config.AccessKeyEncryption == "" {
+		return nil, nil
+	}
+
+	encryption, err := base64.StdEncoding.DecodeString(util.Config.AccessKeyEncryption)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := aes.NewCipher(encryption)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(c)
+}
+
+// encryptUpload encrypts the content by the access key encryption key like secrets of access keys.
+func encryptUpload(content []byte) (string, error) {
+	gcm, err := getUploadCipher()
+	if err != nil {
+		return "", err
+	}
+
+	if gcm == nil {
+		return base64.StdEncoding.EncodeToString(content), nil
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, content, nil)), nil
+}
+
+func decryptUpload(data string) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := getUploadCipher()
+	if err != nil {
+		return nil, err
+	}
+
+	if gcm == nil {
+		return ciphertext, nil
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+
+	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
+
+	content, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrKeyDecryptFailed
+	}
+
+	return content, nil
+}
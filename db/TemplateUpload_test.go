@@ -0,0 +1,119 @@
+package db
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+type memoryUploadStorage map[string][]byte
+
+func (s memoryUploadStorage) GetUpload(id string) ([]byte, error) {
+	data, ok := s[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+func (s memoryUploadStorage) SetUpload(id string, data []byte) error {
+	s[id] = data
+	return nil
+}
+
+func (s memoryUploadStorage) DeleteUpload(id string) error {
+	delete(s, id)
+	return nil
+}
+
+func TestTemplateUploadContent(t *testing.T) {
+	prevConfig := util.Config
+	defer func() { util.Config = prevConfig }()
+
+	util.Config = &util.ConfigType{
+		AccessKeyEncryption: "hHYgPrhQTZYm7UFTvcdNfKJMB3wtAXtJENUButH+DmM=",
+	}
+
+	content := []byte("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n")
+
+	upload := TemplateUpload{Path: "certs/ca.pem"}
+	if err := upload.SetContent(content); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(upload.Data, "CERTIFICATE") || upload.Size != int64(len(content)) || len(upload.SHA256) != 64 {
+		t.Fatalf("unexpected upload %+v", upload)
+	}
+
+	if err := upload.LoadContent(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(upload.Content, content) {
+		t.Fatalf("unexpected content %q", upload.Content)
+	}
+
+	util.Config.Uploads = &util.UploadsConfig{MaxSize: 10}
+
+	err := upload.SetContent(content)
+	if _, ok := err.(*ValidationError); !ok {
+		t.Fatalf("file larger than the limit must be rejected, got %v", err)
+	}
+}
+
+func TestTemplateUploadStorage(t *testing.T) {
+	prevConfig := util.Config
+	defer func() { util.Config = prevConfig }()
+
+	util.Config = &util.ConfigType{}
+
+	storage := memoryUploadStorage{}
+	SetUploadStorage(storage)
+	defer SetUploadStorage(nil)
+
+	upload := TemplateUpload{Path: "app.conf"}
+	if err := upload.SetContent([]byte("listen 80;")); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(upload.Data, uploadRefPrefix) || len(storage) != 1 {
+		t.Fatalf("content must be kept in the upload storage, data %s", upload.Data)
+	}
+
+	if err := upload.LoadContent(); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(upload.Content) != "listen 80;" {
+		t.Fatalf("unexpected content %q", upload.Content)
+	}
+
+	if err := upload.DeleteContent(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(storage) != 0 {
+		t.Fatal("content must be removed from the upload storage")
+	}
+}
+
+func TestTemplateUploadValidate(t *testing.T) {
+	for _, upload := range []TemplateUpload{
+		{Path: ""},
+		{Path: "../ca.pem"},
+		{Path: "/etc/ca.pem"},
+		{Path: "ca.pem", Mode: "0666"},
+		{Path: "ca.pem", Mode: "abc"},
+	} {
+		if err := upload.Validate(); err == nil {
+			t.Errorf("upload %+v must be invalid", upload)
+		}
+	}
+
+	upload := TemplateUpload{Path: "certs/ca.pem", Mode: "0644"}
+	if err := upload.Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestTemplateGetChangeWindowEnd(t *testing.T) {
+	tpl := Template{ChangeWindowStart: "22:00", ChangeWindowEnd: "02:00"}
+
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2024, 3, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	for _, c := range []struct {
+		moment time.Time
+		end    time.Time
+		ok     bool
+	}{
+		{moment: at(10, 23, 0), end: at(11, 2, 0), ok: true},
+		{moment: at(11, 1, 59), end: at(11, 2, 0), ok: true},
+		{moment: at(11, 22, 0), end: at(12, 2, 0), ok: true},
+		{moment: at(11, 2, 0)},
+		{moment: at(11, 12, 0)},
+	} {
+		end, ok := tpl.GetChangeWindowEnd(c.moment)
+		if ok != c.ok || !end.Equal(c.end) {
+			t.Errorf("window end for %s must be %s (%v), got %s (%v)", c.moment, c.end, c.ok, end, ok)
+		}
+	}
+
+	tpl = Template{ChangeWindowStart: "09:00", ChangeWindowEnd: "17:30"}
+
+	end, ok := tpl.GetChangeWindowEnd(at(11, 17, 0))
+	if !ok || !end.Equal(at(11, 17, 30)) {
+		t.Errorf("window end must be %s, got %s", at(11, 17, 30), end)
+	}
+}
+
+func TestTemplateValidateChangeWindow(t *testing.T) {
+	abortTemplateID := 2
+
+	for _, c := range []struct {
+		tpl   Template
+		valid bool
+	}{
+		{tpl: Template{}, valid: true},
+		{tpl: Template{ChangeWindowStart: "22:00", ChangeWindowEnd: "02:00", AbortTemplateID: &abortTemplateID}, valid: true},
+		{tpl: Template{ChangeWindowStart: "22:00"}},
+		{tpl: Template{ChangeWindowStart: "25:00", ChangeWindowEnd: "02:00"}},
+		{tpl: Template{ChangeWindowStart: "02:00", ChangeWindowEnd: "02:00"}},
+		{tpl: Template{AbortTemplateID: &abortTemplateID}},
+		{tpl: Template{ID: 2, ChangeWindowStart: "22:00", ChangeWindowEnd: "02:00", AbortTemplateID: &abortTemplateID}},
+	} {
+		err := c.tpl.validateChangeWindow()
+		if (err == nil) != c.valid {
+			t.Errorf("unexpected validation result %v for %+v", err, c.tpl)
+		}
+	}
+}
+
+func TestTemplateValidateShellParams(t *testing.T) {
+	for _, c := range []struct {
+		params     MapStringAnyField
+		surveyVars []SurveyVar
+		valid      bool
+	}{
+		{params: nil, valid: true},
+		{params: MapStringAnyField{"shell": "zsh", "working_directory": "scripts/deploy", "vars_mode": "env"}, valid: true},
+		{params: MapStringAnyField{"shell": "fish"}},
+		{params: MapStringAnyField{"working_directory": "../other"}},
+		{params: MapStringAnyField{"working_directory": "/etc"}},
+		{params: MapStringAnyField{"vars_mode": "stdin"}},
+		{params: MapStringAnyField{"vars_mode": "env"}, surveyVars: []SurveyVar{{Name: "RELEASE_TAG"}}, valid: true},
+		{params: MapStringAnyField{"vars_mode": "env"}, surveyVars: []SurveyVar{{Name: "release-tag"}}},
+	} {
+		tpl := Template{App: AppBash, TaskParams: c.params, SurveyVars: c.surveyVars}
+
+		err := tpl.validateShellParams()
+		if (err == nil) != c.valid {
+			t.Errorf("unexpected validation result %v for %+v", err, c.params)
+		}
+	}
+}
+
+func TestValidateRunAsUsers(t *testing.T) {
+	util.Config = &util.ConfigType{}
+
+	key := AccessKey{
+		Type:          AccessKeyLoginPassword,
+		LoginPassword: LoginPassword{Login: "root", Password: "secret"},
+	}
+	if err := key.SerializeSecret(); err != nil {
+		t.Fatal(err)
+	}
+
+	keyID := 1
+	inventory := Inventory{BecomeKeyID: &keyID, BecomeKey: AccessKey{Type: AccessKeyLoginPassword, Secret: key.Secret}}
+
+	for _, c := range []struct {
+		becomeUser string
+		valid      bool
+	}{
+		{"", true},
+		{"root", true},
+		{"postgres", false},
+	} {
+		tpl := Template{App: AppAnsible, TaskParams: MapStringAnyField{"become_user": c.becomeUser}}
+		fieldErr := &FieldValidationError{}
+
+		if err := validateRunAsUsers(&tpl, inventory, fieldErr); err != nil {
+			t.Fatal(err)
+		}
+
+		if (len(fieldErr.Fields) == 0) != c.valid {
+			t.Errorf("become user %q: expected valid=%v, got %v", c.becomeUser, c.valid, fieldErr.Fields)
+		}
+	}
+
+	tpl := Template{App: AppAnsible, TaskParams: MapStringAnyField{"remote_user": "-oProxyCommand=x"}}
+	if err := tpl.validateAnsibleParams(); err == nil {
+		t.Error("remote user starting with dash must be invalid")
+	}
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"regexp"
+	"strings"
+)
+
+type TerraformPlanAction string
+
+const (
+	TerraformPlanCreate  TerraformPlanAction = "create"
+	TerraformPlanUpdate  TerraformPlanAction = "update"
+	TerraformPlanReplace TerraformPlanAction = "replace"
+	TerraformPlanDelete  TerraformPlanAction = "delete"
+	TerraformPlanRead    TerraformPlanAction = "read"
+)
+
+// TerraformPlanChange is the change of the resource planned by the terraform task.
+type TerraformPlanChange struct {
+	ID      int                 `db:"id" json:"id"`
+	TaskID  int                 `db:"task_id" json:"task_id"`
+	Address string              `db:"address" json:"address"`
+	Action  TerraformPlanAction `db:"action" json:"action"`
+}
+
+// terraformPlanLineRegexp matches headers of the resource changes of terraform plan output, e.g.
+// "# module.app.aws_instance.web["a"] will be updated in-place".
+var terraformPlanLineRegexp = regexp.MustCompile(
+	`^#\s+(.+?)\s+(will be created|will be updated in-place|must be replaced|is tainted, so must be replaced|will be destroyed|will be read during apply)$`)
+
+var terraformPlanActions = map[string]TerraformPlanAction{
+	"will be created":                 TerraformPlanCreate,
+	"will be updated in-place":        TerraformPlanUpdate,
+	"must be replaced":                TerraformPlanReplace,
+	"is tainted, so must be replaced": TerraformPlanReplace,
+	"will be destroyed":               TerraformPlanDelete,
+	"will be read during apply":       TerraformPlanRead,
+}
+
+// ParseTerraformPlanLine returns the change of the resource if the line of terraform
+// plan output is the header of the change. The line must not contain ANSI sequences.
+func ParseTerraformPlanLine(line string) (change TerraformPlanChange, ok bool) {
+	m := terraformPlanLineRegexp.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return
+	}
+
+	return TerraformPlanChange{Address: m[1], Action: terraformPlanActions[m[2]]}, true
+}
+
+// TerraformPlanActionChange is the resource which is changed by both plans with different actions.
+type TerraformPlanActionChange struct {
+	Address    string              `json:"address"`
+	BaseAction TerraformPlanAction `json:"base_action"`
+	Action     TerraformPlanAction `json:"action"`
+}
+
+// TerraformPlanDiff compares the plan of the task with the plan of the base task.
+type TerraformPlanDiff struct {
+	BaseTaskID int `json:"base_task_id"`
+	TaskID     int `json:"task_id"`
+	// Added are changes which are planned by the task but not by the base task.
+	Added []TerraformPlanChange `json:"added"`
+	// Removed are changes which are planned by the base task but not by the task.
+	Removed []TerraformPlanChange       `json:"removed"`
+	Changed []TerraformPlanActionChange `json:"changed"`
+}
+
+// DiffTerraformPlans compares the changes of the plan with the changes of the base plan
+// by the addresses of the resources. Results keep the order of the plans.
+func DiffTerraformPlans(base []TerraformPlanChange, plan []TerraformPlanChange) (diff TerraformPlanDiff) {
+	diff.Added = make([]TerraformPlanChange, 0)
+	diff.Removed = make([]TerraformPlanChange, 0)
+	diff.Changed = make([]TerraformPlanActionChange, 0)
+
+	baseActions := make(map[string]TerraformPlanAction)
+	for _, change := range base {
+		baseActions[change.Address] = change.Action
+	}
+
+	planActions := make(map[string]TerraformPlanAction)
+	for _, change := range plan {
+		planActions[change.Address] = change.Action
+	}
+
+	for _, change := range plan {
+		baseAction, ok := baseActions[change.Address]
+
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, change)
+		case baseAction != change.Action:
+			diff.Changed = append(diff.Changed, TerraformPlanActionChange{
+				Address:    change.Address,
+				BaseAction: baseAction,
+				Action:     change.Action,
+			})
+		}
+	}
+
+	for _, change := range base {
+		if _, ok := planActions[change.Address]; !ok {
+			diff.Removed = append(diff.Removed, change)
+		}
+	}
+
+	return
+}
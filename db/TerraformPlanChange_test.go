@@ -0,0 +1,61 @@
+package db
+
+import "testing"
+
+func TestParseTerraformPlanLine(t *testing.T) {
+	for line, expected := range map[string]TerraformPlanChange{
+		"  # aws_instance.web will be created":                            {Address: "aws_instance.web", Action: TerraformPlanCreate},
+		`  # module.app.aws_instance.web["a b"] will be updated in-place`: {Address: `module.app.aws_instance.web["a b"]`, Action: TerraformPlanUpdate},
+		"# aws_instance.db must be replaced":                              {Address: "aws_instance.db", Action: TerraformPlanReplace},
+		"# aws_instance.db is tainted, so must be replaced":               {Address: "aws_instance.db", Action: TerraformPlanReplace},
+		"# aws_s3_bucket.logs will be destroyed":                          {Address: "aws_s3_bucket.logs", Action: TerraformPlanDelete},
+		"# data.aws_ami.ubuntu will be read during apply":                 {Address: "data.aws_ami.ubuntu", Action: TerraformPlanRead},
+	} {
+		change, ok := ParseTerraformPlanLine(line)
+		if !ok || change != expected {
+			t.Fatalf("line %q: expected %+v, got %+v", line, expected, change)
+		}
+	}
+
+	for _, line := range []string{
+		"  # (because aws_s3_bucket.logs is not in configuration)",
+		"Plan: 1 to add, 1 to change, 1 to destroy.",
+		"aws_instance.web: Creating...",
+	} {
+		if _, ok := ParseTerraformPlanLine(line); ok {
+			t.Fatalf("line %q must not be parsed", line)
+		}
+	}
+}
+
+func TestDiffTerraformPlans(t *testing.T) {
+	base := []TerraformPlanChange{
+		{Address: "aws_instance.web", Action: TerraformPlanUpdate},
+		{Address: "aws_instance.db", Action: TerraformPlanUpdate},
+		{Address: "aws_s3_bucket.old", Action: TerraformPlanCreate},
+	}
+
+	plan := []TerraformPlanChange{
+		{Address: "aws_instance.web", Action: TerraformPlanUpdate},
+		{Address: "aws_instance.db", Action: TerraformPlanDelete},
+		{Address: "aws_s3_bucket.logs", Action: TerraformPlanCreate},
+	}
+
+	diff := DiffTerraformPlans(base, plan)
+
+	if len(diff.Added) != 1 || diff.Added[0].Address != "aws_s3_bucket.logs" {
+		t.Fatal("invalid added changes", diff.Added)
+	}
+
+	if len(diff.Removed) != 1 || diff.Removed[0].Address != "aws_s3_bucket.old" {
+		t.Fatal("invalid removed changes", diff.Removed)
+	}
+
+	if len(diff.Changed) != 1 || diff.Changed[0] != (TerraformPlanActionChange{
+		Address:    "aws_instance.db",
+		BaseAction: TerraformPlanUpdate,
+		Action:     TerraformPlanDelete,
+	}) {
+		t.Fatal("invalid changed actions", diff.Changed)
+	}
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+// DefaultTerraformWorkspace is the workspace which always exists and is used
+// if neither the task nor the inventory defines the workspace.
+const DefaultTerraformWorkspace = "default"
+
+var terraformWorkspaceRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,89}$`)
+
+// IsValidTerraformWorkspace checks the name of the workspace.
+func IsValidTerraformWorkspace(name string) bool {
+	return terraformWorkspaceRegexp.MatchString(name)
+}
+
+// GetTerraformWorkspace returns the workspace the task runs in: the workspace
+// of the task if it is set, otherwise the workspace of the inventory.
+func GetTerraformWorkspace(params TerraformTaskParams, inventory *Inventory) string {
+	if params.Workspace != "" {
+		return params.Workspace
+	}
+
+	if inventory != nil && inventory.Inventory != "" {
+		return inventory.Inventory
+	}
+
+	return DefaultTerraformWorkspace
+}
+
+// TerraformWorkspaceStatus is the state of the workspace managed by the template.
+type TerraformWorkspaceStatus struct {
+	Workspace string `json:"workspace"`
+	TaskCount int    `json:"task_count"`
+
+	// ActiveTaskID is the task which is running in the workspace now
+	// and can hold the state lock.
+	ActiveTaskID *int `json:"active_task_id"`
+
+	LastTaskID     int                    `json:"last_task_id"`
+	LastTaskStatus task_logger.TaskStatus `json:"last_task_status"`
+	LastTaskEnd    *time.Time             `json:"last_task_end"`
+
+	// LastApplyTaskID is the last task which successfully applied changes to the workspace.
+	LastApplyTaskID    *int       `json:"last_apply_task_id"`
+	LastApplyEnd       *time.Time `json:"last_apply_end"`
+	LastApplyUser      *string    `json:"last_apply_user"`
+	LastApplyCommit    *string    `json:"last_apply_commit"`
+	LastApplyIsDestroy bool       `json:"last_apply_is_destroy"`
+}
+
+// GetTerraformWorkspaceStatuses groups tasks of the template by the workspace
+// and returns the last task and the last apply of each workspace.
+// Tasks created before the workspace was recorded in the task params are skipped.
+// The most recently used workspaces are returned first.
+func GetTerraformWorkspaceStatuses(tasks []TaskWithTpl) []TerraformWorkspaceStatus {
+	sorted := make([]TaskWithTpl, len(tasks))
+	copy(sorted, tasks)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ID > sorted[j].ID
+	})
+
+	groups := make(map[string]*TerraformWorkspaceStatus)
+	var order []string
+
+	for _, task := range sorted {
+		var params TerraformTaskParams
+		if err := task.GetParams(&params); err != nil || params.Workspace == "" {
+			continue
+		}
+
+		group, ok := groups[params.Workspace]
+		if !ok {
+			group = &TerraformWorkspaceStatus{
+				Workspace: params.Workspace,
+			}
+			groups[params.Workspace] = group
+			order = append(order, params.Workspace)
+		}
+
+		group.TaskCount++
+
+		if !task.Status.IsFinished() {
+			if group.ActiveTaskID == nil {
+				taskID := task.ID
+				group.ActiveTaskID = &taskID
+			}
+			continue
+		}
+
+		if group.LastTaskID == 0 {
+			group.LastTaskID = task.ID
+			group.LastTaskStatus = task.Status
+			group.LastTaskEnd = task.End
+		}
+
+		if group.LastApplyTaskID == nil &&
+			task.Status == task_logger.TaskSuccessStatus &&
+			!params.Plan && !task.DryRun {
+			taskID := task.ID
+			group.LastApplyTaskID = &taskID
+			group.LastApplyEnd = task.End
+			group.LastApplyUser = task.UserName
+			group.LastApplyCommit = task.CommitHash
+			group.LastApplyIsDestroy = params.Destroy
+		}
+	}
+
+	res := make([]TerraformWorkspaceStatus, 0, len(order))
+	for _, workspace := range order {
+		res = append(res, *groups[workspace])
+	}
+
+	return res
+}
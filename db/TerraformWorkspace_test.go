@@ -0,0 +1,62 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+func TestGetTerraformWorkspaceStatuses(t *testing.T) {
+	task := func(id int, status task_logger.TaskStatus, params MapStringAnyField) TaskWithTpl {
+		return TaskWithTpl{Task: Task{ID: id, Status: status, Params: params}}
+	}
+
+	statuses := GetTerraformWorkspaceStatuses([]TaskWithTpl{
+		task(6, task_logger.TaskRunningStatus, MapStringAnyField{"workspace": "prod"}),
+		task(5, task_logger.TaskSuccessStatus, MapStringAnyField{"workspace": "prod", "plan": true}),
+		task(4, task_logger.TaskFailStatus, MapStringAnyField{"workspace": "stage"}),
+		task(3, task_logger.TaskSuccessStatus, MapStringAnyField{"workspace": "prod"}),
+		task(2, task_logger.TaskSuccessStatus, MapStringAnyField{"workspace": "stage", "destroy": true}),
+		task(1, task_logger.TaskSuccessStatus, MapStringAnyField{}),
+	})
+
+	if len(statuses) != 2 {
+		t.Fatalf("expected 2 workspaces, got %v", statuses)
+	}
+
+	prod := statuses[0]
+	if prod.Workspace != "prod" || prod.TaskCount != 3 || prod.LastTaskID != 5 {
+		t.Fatalf("unexpected status of prod %v", prod)
+	}
+
+	if prod.ActiveTaskID == nil || *prod.ActiveTaskID != 6 {
+		t.Fatal("task 6 must be active in prod")
+	}
+
+	if prod.LastApplyTaskID == nil || *prod.LastApplyTaskID != 3 {
+		t.Fatal("plan must not be counted as the last apply of prod")
+	}
+
+	stage := statuses[1]
+	if stage.LastTaskStatus != task_logger.TaskFailStatus || stage.LastApplyTaskID == nil ||
+		*stage.LastApplyTaskID != 2 || !stage.LastApplyIsDestroy {
+		t.Fatalf("unexpected status of stage %v", stage)
+	}
+}
+
+func TestValidateNewTaskWorkspace(t *testing.T) {
+	tpl := Template{App: AppTofu}
+
+	for name, valid := range map[string]bool{
+		"prod":        true,
+		"eu-west_1.a": true,
+		"../prod":     false,
+		"-prod":       false,
+		"prod stage":  false,
+	} {
+		task := Task{Params: MapStringAnyField{"workspace": name}}
+		if err := task.ValidateNewTask(tpl); (err == nil) != valid {
+			t.Errorf("unexpected result of validation of workspace %q: %v", name, err)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package db
 
 import (
 	"time"
+
+	"github.com/semaphoreui/semaphore/pkg/i18n"
 )
 
 // User is the model for an entity which has access to the API
@@ -15,13 +17,33 @@ type User struct {
 	Admin    bool      `db:"admin" json:"admin"`
 	External bool      `db:"external" json:"external"`
 	Alert    bool      `db:"alert" json:"alert"`
+	// Locale is the language of messages sent to the user by the server.
+	// Empty locale means the language of the browser or the server.
+	Locale string `db:"locale" json:"locale"`
+	// PasswordChanged is the time of the last change of the password.
+	// Nil means the password was not changed since the user was created.
+	PasswordChanged *time.Time `db:"password_changed" json:"password_changed,omitempty"`
+}
+
+// UserPassword is the previous password hash of the user, it is kept to prevent reusing of passwords.
+type UserPassword struct {
+	ID       int       `db:"id" json:"-"`
+	UserID   int       `db:"user_id" json:"-"`
+	Password string    `db:"password" json:"-"`
+	Created  time.Time `db:"created" json:"-"`
 }
 
 type UserWithProjectRole struct {
-	Role ProjectUserRole `db:"role" json:"role"`
+	Role      ProjectUserRole `db:"role" json:"role"`
+	ExpiresAt *time.Time      `db:"expires_at" json:"expires_at"`
 	User
 }
 
+// IsExpired returns true if the membership of the user expired at the time.
+func (u *UserWithProjectRole) IsExpired(now time.Time) bool {
+	return u.ExpiresAt != nil && !now.Before(*u.ExpiresAt)
+}
+
 // UserWithPwd extends User structure with field for unhashed password received from JSON.
 type UserWithPwd struct {
 	Pwd string `db:"-" json:"password"` // unhashed password from JSON
@@ -38,5 +60,8 @@ func ValidateUser(user User) error {
 	if user.Name == "" {
 		return &ValidationError{Message: "Name cannot be empty"}
 	}
+	if !i18n.IsSupported(user.Locale) {
+		return &ValidationError{Message: "Unsupported locale"}
+	}
 	return nil
 }
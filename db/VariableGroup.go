@@ -0,0 +1,110 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// TemplateVariableGroups are IDs of the variable groups attached to the template.
+// Variable groups are environments with extra variables, environment variables
+// and secrets which are shared by templates instead of being copied to each environment.
+//
+// Variables are resolved in the following order, later sources win:
+// project defaults, variable groups in the order of attachment, the template environment
+// and the extra variables of the task.
+type TemplateVariableGroups []int
+
+func (groups TemplateVariableGroups) Validate() error {
+	ids := make(map[int]bool)
+
+	for _, id := range groups {
+		if id <= 0 {
+			return &ValidationError{"invalid variable group " + strconv.Itoa(id)}
+		}
+
+		if ids[id] {
+			return &ValidationError{"variable group " + strconv.Itoa(id) + " is attached more than once"}
+		}
+		ids[id] = true
+	}
+
+	return nil
+}
+
+func (groups *TemplateVariableGroups) Scan(value interface{}) error {
+	if value == nil {
+		*groups = nil
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, groups)
+	case string:
+		return json.Unmarshal([]byte(v), groups)
+	default:
+		return errors.New("unsupported type for TemplateVariableGroups")
+	}
+}
+
+func (groups TemplateVariableGroups) Value() (driver.Value, error) {
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	return json.Marshal(groups)
+}
+
+// GetVariableGroup returns the variable group of the project.
+// ErrNotFound is returned if the environment is not a variable group.
+func GetVariableGroup(store Store, projectID int, groupID int) (group Environment, err error) {
+	group, err = store.GetEnvironment(projectID, groupID)
+	if err == nil && !group.VariableGroup {
+		err = ErrNotFound
+	}
+	return
+}
+
+// MergeTemplateVariableGroups adds variables and secrets of the variable groups of the template
+// which are not defined in env. Groups attached later win over groups attached earlier.
+func MergeTemplateVariableGroups(store Store, tpl Template, env *Environment, deserializeSecret bool) error {
+	for i := len(tpl.VariableGroupIDs) - 1; i >= 0; i-- {
+		group, err := GetVariableGroup(store, tpl.ProjectID, tpl.VariableGroupIDs[i])
+		if err != nil {
+			return err
+		}
+
+		if err = FillEnvironmentSecrets(store, &group, deserializeSecret); err != nil {
+			return err
+		}
+
+		if err = env.MergeProjectDefaults(group); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetVariableGroupRefs returns templates which use the variable group.
+func GetVariableGroupRefs(store Store, projectID int, groupID int) (refs ObjectReferrers, err error) {
+	templates, err := store.GetTemplates(projectID, TemplateFilter{}, RetrieveQueryParams{})
+	if err != nil {
+		return
+	}
+
+	refs.Templates = []ObjectReferrer{}
+
+	for _, tpl := range templates {
+		for _, id := range tpl.VariableGroupIDs {
+			if id == groupID {
+				refs.Templates = append(refs.Templates, ObjectReferrer{ID: tpl.ID, Name: tpl.Name})
+				break
+			}
+		}
+	}
+
+	return
+}
@@ -8,11 +8,9 @@ import (
 	"sort"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/util"
-	"go.etcd.io/bbolt"
 )
 
 const MaxID = 2147483647
@@ -33,8 +31,11 @@ func (d emptyEnumerable) Next() (key []byte, value []byte) {
 }
 
 type BoltDb struct {
-	Filename    string
-	db          *bbolt.DB
+	Filename string
+	// Engine is EngineBolt or EngineBadger, the engine option of the config is used if it is empty.
+	Engine string
+
+	db          kvDB
 	connections map[string]bool
 	mu          sync.Mutex
 }
@@ -85,13 +86,25 @@ func (d *BoltDb) openDbFile() {
 	}
 
 	var err error
-	d.db, err = bbolt.Open(filename, 0666, &bbolt.Options{
-		Timeout: 5 * time.Second,
-	})
+	d.db, err = openKV(d.getEngine(), filename)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// getEngine returns the storage engine of the database, see the engine option of the config.
+func (d *BoltDb) getEngine() string {
+	if d.Engine != "" {
+		return d.Engine
+	}
 
+	config, err := util.Config.GetDBConfig()
 	if err != nil {
 		panic(err)
 	}
+
+	return config.Options["engine"]
 }
 
 func (d *BoltDb) openSession(token string) {
@@ -175,8 +188,18 @@ func (d *BoltDb) PermanentConnection() bool {
 	return true
 }
 
+// TryLock always acquires the lock because BoltDB file can be opened
+// by a single Semaphore node only.
+func (d *BoltDb) TryLock(name string) (bool, error) {
+	return true, nil
+}
+
+func (d *BoltDb) Unlock(name string) error {
+	return nil
+}
+
 func (d *BoltDb) IsInitialized() (initialized bool, err error) {
-	err = d.db.View(func(tx *bbolt.Tx) error {
+	err = d.db.View(func(tx kvTx) error {
 		k, _ := tx.Cursor().First()
 		initialized = k != nil
 		return nil
@@ -185,7 +208,7 @@ func (d *BoltDb) IsInitialized() (initialized bool, err error) {
 }
 
 func (d *BoltDb) getObject(bucketID int, props db.ObjectProps, objectID objectID, object interface{}) (err error) {
-	err = d.db.View(func(tx *bbolt.Tx) error {
+	err = d.db.View(func(tx kvTx) error {
 		b := tx.Bucket(makeBucketId(props, bucketID))
 		if b == nil {
 			return db.ErrNotFound
@@ -385,7 +408,7 @@ func apply(
 func (d *BoltDb) count(bucketID int, props db.ObjectProps, params db.RetrieveQueryParams, filter func(interface{}) bool) (n int, err error) {
 	n = 0
 
-	err = d.db.View(func(tx *bbolt.Tx) error {
+	err = d.db.View(func(tx kvTx) error {
 		b := tx.Bucket(makeBucketId(props, bucketID))
 		if b == nil {
 			return db.ErrNotFound
@@ -431,7 +454,7 @@ func unmarshalObjects(rawData enumerable, props db.ObjectProps, params db.Retrie
 	return
 }
 
-func (d *BoltDb) getObjectsTx(tx *bbolt.Tx, bucketID int, props db.ObjectProps, params db.RetrieveQueryParams, filter func(interface{}) bool, objects interface{}) error {
+func (d *BoltDb) getObjectsTx(tx kvTx, bucketID int, props db.ObjectProps, params db.RetrieveQueryParams, filter func(interface{}) bool, objects interface{}) error {
 	b := tx.Bucket(makeBucketId(props, bucketID))
 	var c enumerable
 	if b == nil {
@@ -443,13 +466,13 @@ func (d *BoltDb) getObjectsTx(tx *bbolt.Tx, bucketID int, props db.ObjectProps,
 }
 
 func (d *BoltDb) getObjects(bucketID int, props db.ObjectProps, params db.RetrieveQueryParams, filter func(interface{}) bool, objects interface{}) error {
-	return d.db.View(func(tx *bbolt.Tx) error {
+	return d.db.View(func(tx kvTx) error {
 		return d.getObjectsTx(tx, bucketID, props, params, filter, objects)
 	})
 }
 
 func (d *BoltDb) apply(bucketID int, props db.ObjectProps, params db.RetrieveQueryParams, applier func(interface{}) error) error {
-	return d.db.View(func(tx *bbolt.Tx) error {
+	return d.db.View(func(tx kvTx) error {
 		b := tx.Bucket(makeBucketId(props, bucketID))
 		var c enumerable
 		if b == nil {
@@ -462,7 +485,7 @@ func (d *BoltDb) apply(bucketID int, props db.ObjectProps, params db.RetrieveQue
 	})
 }
 
-func (d *BoltDb) deleteObject(bucketID int, props db.ObjectProps, objectID objectID, tx *bbolt.Tx) error {
+func (d *BoltDb) deleteObject(bucketID int, props db.ObjectProps, objectID objectID, tx kvTx) error {
 	for _, u := range []db.ObjectProps{db.TemplateProps, db.EnvironmentProps, db.InventoryProps, db.RepositoryProps} {
 		inUse, err := d.isObjectInUse(bucketID, props, objectID, u)
 		if err != nil {
@@ -473,7 +496,7 @@ func (d *BoltDb) deleteObject(bucketID int, props db.ObjectProps, objectID objec
 		}
 	}
 
-	fn := func(tx *bbolt.Tx) error {
+	fn := func(tx kvTx) error {
 		b := tx.Bucket(makeBucketId(props, bucketID))
 		if b == nil {
 			return db.ErrNotFound
@@ -488,7 +511,7 @@ func (d *BoltDb) deleteObject(bucketID int, props db.ObjectProps, objectID objec
 	return d.db.Update(fn)
 }
 
-func (d *BoltDb) updateObjectTx(tx *bbolt.Tx, bucketID int, props db.ObjectProps, object interface{}) error {
+func (d *BoltDb) updateObjectTx(tx kvTx, bucketID int, props db.ObjectProps, object interface{}) error {
 	b := tx.Bucket(makeBucketId(props, bucketID))
 	if b == nil {
 		return db.ErrNotFound
@@ -538,12 +561,12 @@ func (d *BoltDb) updateObjectTx(tx *bbolt.Tx, bucketID int, props db.ObjectProps
 
 // updateObject updates data for object in database.
 func (d *BoltDb) updateObject(bucketID int, props db.ObjectProps, object interface{}) error {
-	return d.db.Update(func(tx *bbolt.Tx) error {
+	return d.db.Update(func(tx kvTx) error {
 		return d.updateObjectTx(tx, bucketID, props, object)
 	})
 }
 
-func (d *BoltDb) createObjectTx(tx *bbolt.Tx, bucketID int, props db.ObjectProps, object interface{}) (interface{}, error) {
+func (d *BoltDb) createObjectTx(tx kvTx, bucketID int, props db.ObjectProps, object interface{}) (interface{}, error) {
 	b, err := tx.CreateBucketIfNotExists(makeBucketId(props, bucketID))
 
 	if err != nil {
@@ -629,7 +652,7 @@ func (d *BoltDb) createObjectTx(tx *bbolt.Tx, bucketID int, props db.ObjectProps
 
 func (d *BoltDb) createObject(bucketID int, props db.ObjectProps, object interface{}) (res interface{}, err error) {
 
-	_ = d.db.Update(func(tx *bbolt.Tx) error {
+	_ = d.db.Update(func(tx kvTx) error {
 		res, err = d.createObjectTx(tx, bucketID, props, object)
 		return err
 	})
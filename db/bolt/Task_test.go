@@ -2,7 +2,9 @@ package bolt
 
 import (
 	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
 	"testing"
+	"time"
 )
 
 func TestTask_GetVersion(t *testing.T) {
@@ -94,3 +96,96 @@ func TestTask_GetVersion(t *testing.T) {
 		return
 	}
 }
+
+func TestGetFinishedTasksCreatedBefore(t *testing.T) {
+	store := CreateTestStore()
+
+	for _, status := range []task_logger.TaskStatus{
+		task_logger.TaskSuccessStatus,
+		task_logger.TaskRunningStatus,
+		task_logger.TaskFailStatus,
+	} {
+		if _, err := store.CreateTask(db.Task{ProjectID: 1, TemplateID: 1, Status: status}, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tasks, err := store.GetFinishedTasksCreatedBefore(time.Now().Add(time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tasks) != 2 {
+		t.Fatal("only finished tasks must be returned")
+	}
+
+	tasks, err = store.GetFinishedTasksCreatedBefore(time.Now().Add(-time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tasks) != 0 {
+		t.Fatal("new tasks must not be returned")
+	}
+}
+
+func TestClaimTask(t *testing.T) {
+	store := CreateTestStore()
+
+	task, err := store.CreateTask(db.Task{ProjectID: 1, TemplateID: 1, Status: task_logger.TaskWaitingStatus}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := store.GetUnfinishedTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tasks) != 1 || tasks[0].ID != task.ID {
+		t.Fatal("waiting task must be returned")
+	}
+
+	claimed, err := store.ClaimTask(task.ID, "node1")
+	if err != nil || !claimed {
+		t.Fatal("unclaimed task must be claimed", err)
+	}
+
+	claimed, err = store.ClaimTask(task.ID, "node1")
+	if err != nil || !claimed {
+		t.Fatal("task claimed by the node must be claimed again", err)
+	}
+
+	claimed, err = store.ClaimTask(task.ID, "node2")
+	if err != nil || claimed {
+		t.Fatal("task claimed by another node must not be claimed", err)
+	}
+}
+
+func TestCreateTaskOutputs(t *testing.T) {
+	store := CreateTestStore()
+
+	task, err := store.CreateTask(db.Task{ProjectID: 1, TemplateID: 1}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+
+	err = store.CreateTaskOutputs([]db.TaskOutput{
+		{TaskID: task.ID, Output: "first", Time: now},
+		{TaskID: task.ID, Output: "second", Time: now.Add(time.Millisecond)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := store.GetTaskOutputs(1, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(output) != 2 || output[0].Output != "first" || output[1].Output != "second" {
+		t.Fatal("all records must be stored in order", output)
+	}
+}
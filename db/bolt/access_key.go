@@ -1,8 +1,10 @@
 package bolt
 
 import (
+	"errors"
+	"time"
+
 	"github.com/semaphoreui/semaphore/db"
-	"go.etcd.io/bbolt"
 )
 
 func (d *BoltDb) GetAccessKey(projectID int, accessKeyID int) (key db.AccessKey, err error) {
@@ -14,15 +16,21 @@ func (d *BoltDb) GetAccessKey(projectID int, accessKeyID int) (key db.AccessKey,
 	return
 }
 
-func (d *BoltDb) GetAccessKeyRefs(projectID int, accessKeyID int) (db.ObjectReferrers, error) {
-	return d.getObjectRefs(projectID, db.AccessKeyProps, accessKeyID)
+func (d *BoltDb) GetAccessKeyRefs(projectID int, accessKeyID int) (refs db.ObjectReferrers, err error) {
+	refs, err = d.getObjectRefs(projectID, db.AccessKeyProps, accessKeyID)
+	if err != nil {
+		return
+	}
+
+	err = db.FillAccessKeyTemplateRefs(d, projectID, accessKeyID, &refs)
+	return
 }
 
 func (d *BoltDb) GetAccessKeys(projectID int, params db.RetrieveQueryParams) ([]db.AccessKey, error) {
 	var keys []db.AccessKey
 	err := d.getObjects(projectID, db.AccessKeyProps, params, func(i interface{}) bool {
 		k := i.(db.AccessKey)
-		return k.EnvironmentID == nil
+		return k.EnvironmentID == nil && k.InventoryID == nil
 	}, &keys)
 	return keys, err
 }
@@ -34,21 +42,30 @@ func (d *BoltDb) UpdateAccessKey(key db.AccessKey) error {
 		return err
 	}
 
-	if key.OverrideSecret {
-		err = key.SerializeSecret()
-		if err != nil {
-			return err
-		}
-	} else { // accept only new name, ignore other changes
-		oldKey, err2 := d.GetAccessKey(*key.ProjectID, key.ID)
-		if err2 != nil {
-			return err2
-		}
+	oldKey, err := d.GetAccessKey(*key.ProjectID, key.ID)
+	if err != nil {
+		return err
+	}
+
+	if !key.OverrideSecret { // accept only new name, ignore other changes
 		oldKey.Name = key.Name
-		key = oldKey
+		return d.updateObject(*key.ProjectID, db.AccessKeyProps, oldKey)
 	}
 
-	return d.updateObject(*key.ProjectID, db.AccessKeyProps, key)
+	err = key.SerializeSecret()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	key.SecretChanged = &now
+
+	err = d.updateObject(*key.ProjectID, db.AccessKeyProps, key)
+	if err != nil {
+		_ = db.ReleaseSecret(key.Secret)
+		return err
+	}
+
+	return db.ReleaseSecret(oldKey.Secret)
 }
 
 func (d *BoltDb) CreateAccessKey(key db.AccessKey) (db.AccessKey, error) {
@@ -56,16 +73,52 @@ func (d *BoltDb) CreateAccessKey(key db.AccessKey) (db.AccessKey, error) {
 	if err != nil {
 		return db.AccessKey{}, err
 	}
+	now := time.Now().UTC()
+	key.SecretChanged = &now
 	newKey, err := d.createObject(*key.ProjectID, db.AccessKeyProps, key)
-	return newKey.(db.AccessKey), err
+	if err != nil {
+		_ = db.ReleaseSecret(key.Secret)
+		return db.AccessKey{}, err
+	}
+	return newKey.(db.AccessKey), nil
 }
 
 func (d *BoltDb) DeleteAccessKey(projectID int, accessKeyID int) error {
-	return d.deleteObject(projectID, db.AccessKeyProps, intObjectID(accessKeyID), nil)
+	key, err := d.GetAccessKey(projectID, accessKeyID)
+	if err != nil {
+		return err
+	}
+
+	err = d.deleteObject(projectID, db.AccessKeyProps, intObjectID(accessKeyID), nil)
+	if err != nil {
+		return err
+	}
+
+	if err = db.ReleaseSecret(key.Secret); err != nil {
+		return err
+	}
+
+	// the key is no longer used by default
+	project, err := d.GetProject(projectID)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if project.ClearDefaultKey(accessKeyID) {
+		err = d.updateObject(0, db.ProjectProps, project)
+	}
+
+	return err
 }
 
 func (d *BoltDb) RekeyAccessKeys(oldKey string) error {
-	return d.db.Update(func(tx *bbolt.Tx) error {
+	// the replaced secrets are removed from the secret storage after the commit
+	var oldSecrets []*string
+
+	err := d.db.Update(func(tx kvTx) error {
 		var allProjects []db.Project
 
 		err := d.getObjectsTx(tx, 0, db.ProjectProps, db.RetrieveQueryParams{}, nil, &allProjects)
@@ -82,6 +135,8 @@ func (d *BoltDb) RekeyAccessKeys(oldKey string) error {
 			}
 
 			for _, key := range keys {
+				oldSecrets = append(oldSecrets, key.Secret)
+
 				err = key.DeserializeSecret2(oldKey)
 
 				if err != nil {
@@ -102,4 +157,16 @@ func (d *BoltDb) RekeyAccessKeys(oldKey string) error {
 
 		return nil
 	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, secret := range oldSecrets {
+		if err = db.ReleaseSecret(secret); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
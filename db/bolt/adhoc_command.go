@@ -0,0 +1,43 @@
+package bolt
+
+import "github.com/semaphoreui/semaphore/db"
+
+func (d *BoltDb) GetAdhocCommand(projectID int, commandID int) (command db.AdhocCommand, err error) {
+	err = d.getObject(projectID, db.AdhocCommandProps, intObjectID(commandID), &command)
+	return
+}
+
+func (d *BoltDb) GetAdhocCommands(projectID int, params db.RetrieveQueryParams) (commands []db.AdhocCommand, err error) {
+	commands = make([]db.AdhocCommand, 0)
+	err = d.getObjects(projectID, db.AdhocCommandProps, params, nil, &commands)
+	return
+}
+
+func (d *BoltDb) CreateAdhocCommand(command db.AdhocCommand) (db.AdhocCommand, error) {
+	newCommand, err := d.createObject(command.ProjectID, db.AdhocCommandProps, command)
+	if err != nil {
+		return db.AdhocCommand{}, err
+	}
+	return newCommand.(db.AdhocCommand), nil
+}
+
+func (d *BoltDb) UpdateAdhocCommand(command db.AdhocCommand) error {
+	return d.updateObject(command.ProjectID, db.AdhocCommandProps, command)
+}
+
+func (d *BoltDb) GetAdhocCommandOutputs(projectID int, commandID int) (outputs []db.AdhocCommandOutput, err error) {
+	// check if command exists in the project
+	_, err = d.GetAdhocCommand(projectID, commandID)
+	if err != nil {
+		return
+	}
+
+	outputs = make([]db.AdhocCommandOutput, 0)
+	err = d.getObjects(commandID, db.AdhocCommandOutputProps, db.RetrieveQueryParams{}, nil, &outputs)
+	return
+}
+
+func (d *BoltDb) CreateAdhocCommandOutput(output db.AdhocCommandOutput) error {
+	_, err := d.createObject(output.CommandID, db.AdhocCommandOutputProps, output)
+	return err
+}
@@ -0,0 +1,40 @@
+package bolt
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *BoltDb) GetAnnouncements() (announcements []db.Announcement, err error) {
+	announcements = make([]db.Announcement, 0)
+	err = d.getObjects(0, db.AnnouncementProps, db.RetrieveQueryParams{}, nil, &announcements)
+	return
+}
+
+func (d *BoltDb) GetAnnouncement(announcementID int) (announcement db.Announcement, err error) {
+	err = d.getObject(0, db.AnnouncementProps, intObjectID(announcementID), &announcement)
+	return
+}
+
+func (d *BoltDb) CreateAnnouncement(announcement db.Announcement) (db.Announcement, error) {
+	if err := announcement.Validate(); err != nil {
+		return db.Announcement{}, err
+	}
+
+	newAnnouncement, err := d.createObject(0, db.AnnouncementProps, announcement)
+	if err != nil {
+		return db.Announcement{}, err
+	}
+	return newAnnouncement.(db.Announcement), nil
+}
+
+func (d *BoltDb) UpdateAnnouncement(announcement db.Announcement) error {
+	if err := announcement.Validate(); err != nil {
+		return err
+	}
+
+	return d.updateObject(0, db.AnnouncementProps, announcement)
+}
+
+func (d *BoltDb) DeleteAnnouncement(announcementID int) error {
+	return d.deleteObject(0, db.AnnouncementProps, intObjectID(announcementID), nil)
+}
@@ -0,0 +1,45 @@
+package bolt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func TestAnnouncements(t *testing.T) {
+	store := CreateTestStore()
+
+	for _, title := range []string{"Maintenance", "Deprecation"} {
+		if _, err := store.CreateAnnouncement(db.Announcement{Title: title, Created: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	announcements, err := store.GetAnnouncements()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(announcements) != 2 || announcements[0].Title != "Deprecation" || announcements[0].Severity != db.AnnouncementInfo {
+		t.Fatalf("expected the newest announcement first, got %+v", announcements)
+	}
+
+	announcement := announcements[1]
+	announcement.Severity = db.AnnouncementCritical
+	if err = store.UpdateAnnouncement(announcement); err != nil {
+		t.Fatal(err)
+	}
+
+	if announcement, err = store.GetAnnouncement(announcement.ID); err != nil || announcement.Severity != db.AnnouncementCritical {
+		t.Fatalf("announcement must be updated, got %+v, %v", announcement, err)
+	}
+
+	if err = store.DeleteAnnouncement(announcement.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = store.GetAnnouncement(announcement.ID); err != db.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
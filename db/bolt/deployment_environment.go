@@ -0,0 +1,80 @@
+package bolt
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+func (d *BoltDb) GetDeploymentEnvironment(projectID int, envID int) (env db.DeploymentEnvironment, err error) {
+	err = d.getObject(projectID, db.DeploymentEnvironmentProps, intObjectID(envID), &env)
+	return
+}
+
+func (d *BoltDb) GetDeploymentEnvironments(projectID int) (envs []db.DeploymentEnvironment, err error) {
+	err = d.getObjects(projectID, db.DeploymentEnvironmentProps, db.RetrieveQueryParams{}, nil, &envs)
+	db.SortDeploymentEnvironments(envs)
+	return
+}
+
+func (d *BoltDb) UpdateDeploymentEnvironment(env db.DeploymentEnvironment) error {
+	return d.updateObject(env.ProjectID, db.DeploymentEnvironmentProps, env)
+}
+
+func (d *BoltDb) CreateDeploymentEnvironment(env db.DeploymentEnvironment) (db.DeploymentEnvironment, error) {
+	newEnv, err := d.createObject(env.ProjectID, db.DeploymentEnvironmentProps, env)
+	if err != nil {
+		return db.DeploymentEnvironment{}, err
+	}
+	return newEnv.(db.DeploymentEnvironment), nil
+}
+
+func (d *BoltDb) DeleteDeploymentEnvironment(projectID int, envID int) error {
+	return d.deleteObject(projectID, db.DeploymentEnvironmentProps, intObjectID(envID), nil)
+}
+
+func (d *BoltDb) SetDeploymentEnvironmentPositions(projectID int, positions map[int]int) error {
+	for id, position := range positions {
+		env, err := d.GetDeploymentEnvironment(projectID, id)
+		if err != nil {
+			return err
+		}
+		env.Position = position
+		err = d.UpdateDeploymentEnvironment(env)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *BoltDb) getDeploymentTasks(projectID int, templateID int, envID int, params db.RetrieveQueryParams) (tasks []db.Task, err error) {
+	err = d.getObjects(0, db.TaskProps, params, func(tsk interface{}) bool {
+		t := tsk.(db.Task)
+		return t.ProjectID == projectID &&
+			t.TemplateID == templateID &&
+			t.DeploymentEnvironmentID != nil &&
+			*t.DeploymentEnvironmentID == envID &&
+			t.Status == task_logger.TaskSuccessStatus
+	}, &tasks)
+	return
+}
+
+func (d *BoltDb) GetLastDeploymentTask(projectID int, templateID int, envID int) (task db.Task, err error) {
+	tasks, err := d.getDeploymentTasks(projectID, templateID, envID, db.RetrieveQueryParams{Count: 1})
+
+	if err != nil {
+		return
+	}
+
+	if len(tasks) == 0 {
+		err = db.ErrNotFound
+		return
+	}
+
+	task = tasks[0]
+	return
+}
+
+func (d *BoltDb) GetDeploymentTasks(projectID int, templateID int, envID int) ([]db.Task, error) {
+	return d.getDeploymentTasks(projectID, templateID, envID, db.RetrieveQueryParams{})
+}
@@ -0,0 +1,92 @@
+package bolt
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+func TestGetLastDeploymentTask(t *testing.T) {
+	store := CreateTestStore()
+
+	tpl, err := store.CreateTemplate(db.Template{
+		ProjectID: 1,
+		Name:      "Deploy",
+		Playbook:  "deploy.yml",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dev, err := store.CreateDeploymentEnvironment(db.DeploymentEnvironment{ProjectID: 1, Name: "dev", Position: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prod, err := store.CreateDeploymentEnvironment(db.DeploymentEnvironment{ProjectID: 1, Name: "prod", Position: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.GetLastDeploymentTask(1, tpl.ID, dev.ID)
+	if err != db.ErrNotFound {
+		t.Fatal("expected ErrNotFound for environment without tasks")
+	}
+
+	commit := "abc123"
+
+	deployed, err := store.CreateTask(db.Task{
+		ProjectID:               1,
+		TemplateID:              tpl.ID,
+		Status:                  task_logger.TaskSuccessStatus,
+		CommitHash:              &commit,
+		DeploymentEnvironmentID: &dev.ID,
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.CreateTask(db.Task{
+		ProjectID:               1,
+		TemplateID:              tpl.ID,
+		Status:                  task_logger.TaskFailStatus,
+		DeploymentEnvironmentID: &dev.ID,
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := store.GetLastDeploymentTask(1, tpl.ID, dev.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if task.ID != deployed.ID {
+		t.Fatal("failed task must not be returned as deployment")
+	}
+
+	_, err = store.GetLastDeploymentTask(1, tpl.ID, prod.ID)
+	if err != db.ErrNotFound {
+		t.Fatal("expected ErrNotFound for environment without tasks")
+	}
+
+	envs, err := store.GetDeploymentEnvironments(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := db.GetNextDeploymentEnvironment(envs, dev.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next.ID != prod.ID {
+		t.Fatal("prod must follow dev")
+	}
+
+	_, err = db.GetNextDeploymentEnvironment(envs, prod.ID)
+	if err != db.ErrNotFound {
+		t.Fatal("prod must be the last environment")
+	}
+}
@@ -0,0 +1,60 @@
+package bolt
+
+import (
+	"sort"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func sameRunner(a *int, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func (d *BoltDb) SetDiskUsage(runnerID *int, usage []db.DiskUsage) error {
+	return d.db.Update(func(tx kvTx) error {
+		var existing []db.DiskUsage
+		err := d.getObjectsTx(tx, 0, db.DiskUsageProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+			return sameRunner(i.(db.DiskUsage).RunnerID, runnerID)
+		}, &existing)
+		if err != nil {
+			return err
+		}
+
+		if b := tx.Bucket(makeBucketId(db.DiskUsageProps, 0)); b != nil {
+			for _, u := range existing {
+				if err = b.Delete(intObjectID(u.ID).ToBytes()); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, u := range usage {
+			u.ID = 0
+			u.RunnerID = runnerID
+			u.Updated = u.Updated.UTC()
+
+			if _, err = d.createObjectTx(tx, 0, db.DiskUsageProps, u); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (d *BoltDb) GetDiskUsage(projectID *int) (usage []db.DiskUsage, err error) {
+	usage = make([]db.DiskUsage, 0)
+	err = d.getObjects(0, db.DiskUsageProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		u := i.(db.DiskUsage)
+		return projectID == nil || (u.ProjectID != nil && *u.ProjectID == *projectID)
+	}, &usage)
+
+	sort.SliceStable(usage, func(i, j int) bool {
+		return usage[i].ID < usage[j].ID
+	})
+
+	return
+}
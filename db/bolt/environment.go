@@ -12,7 +12,36 @@ func (d *BoltDb) GetEnvironmentRefs(projectID int, environmentID int) (db.Object
 }
 
 func (d *BoltDb) GetEnvironments(projectID int, params db.RetrieveQueryParams) (environment []db.Environment, err error) {
-	err = d.getObjects(projectID, db.EnvironmentProps, params, nil, &environment)
+	err = d.getObjects(projectID, db.EnvironmentProps, params, func(i interface{}) bool {
+		env := i.(db.Environment)
+		return !env.ProjectDefault && !env.VariableGroup
+	}, &environment)
+	return
+}
+
+func (d *BoltDb) GetVariableGroups(projectID int) (groups []db.Environment, err error) {
+	err = d.getObjects(projectID, db.EnvironmentProps, db.RetrieveQueryParams{SortBy: "name"}, func(i interface{}) bool {
+		return i.(db.Environment).VariableGroup
+	}, &groups)
+	return
+}
+
+func (d *BoltDb) GetProjectDefaultEnvironment(projectID int) (environment db.Environment, err error) {
+	var environments []db.Environment
+	err = d.getObjects(projectID, db.EnvironmentProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		return i.(db.Environment).ProjectDefault
+	}, &environments)
+
+	if err != nil {
+		return
+	}
+
+	if len(environments) == 0 {
+		err = db.ErrNotFound
+		return
+	}
+
+	environment = environments[0]
 	return
 }
 
@@ -0,0 +1,128 @@
+package bolt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func TestGetProjectDefaultEnvironment(t *testing.T) {
+	store := CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{
+		Created: time.Now(),
+		Name:    "TestProject",
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, err = store.GetProjectDefaultEnvironment(proj.ID)
+	if err != db.ErrNotFound {
+		t.Fatal("expected ErrNotFound for project without defaults")
+	}
+
+	_, err = store.CreateEnvironment(db.Environment{
+		ProjectID: proj.ID,
+		Name:      "Production",
+		JSON:      "{}",
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	defaults, err := store.CreateEnvironment(db.Environment{
+		ProjectID:      proj.ID,
+		Name:           db.ProjectDefaultEnvironmentName,
+		JSON:           `{"company": "acme"}`,
+		ProjectDefault: true,
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	envs, err := store.GetEnvironments(proj.ID, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(envs) != 1 || envs[0].Name != "Production" {
+		t.Fatalf("expected only regular environments, got %d", len(envs))
+	}
+
+	found, err := store.GetProjectDefaultEnvironment(proj.ID)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if found.ID != defaults.ID || !found.ProjectDefault {
+		t.Fatal("unexpected default environment")
+	}
+}
+
+func TestMergeTemplateVariableGroups(t *testing.T) {
+	store := CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{
+		Created: time.Now(),
+		Name:    "TestProject",
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	common, err := store.CreateEnvironment(db.Environment{
+		ProjectID:     proj.ID,
+		Name:          "Common",
+		JSON:          `{"company": "acme", "region": "us-east-1"}`,
+		VariableGroup: true,
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	europe, err := store.CreateEnvironment(db.Environment{
+		ProjectID:     proj.ID,
+		Name:          "Europe",
+		JSON:          `{"region": "eu-west-1", "app_version": "1.0"}`,
+		VariableGroup: true,
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	envs, err := store.GetEnvironments(proj.ID, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(envs) != 0 {
+		t.Fatalf("expected variable groups not to be listed as environments, got %d", len(envs))
+	}
+
+	groups, err := store.GetVariableGroups(proj.ID)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(groups) != 2 || groups[0].Name != "Common" {
+		t.Fatalf("expected 2 variable groups, got %d", len(groups))
+	}
+
+	env := db.Environment{JSON: `{"app_version": "2.0"}`}
+
+	err = db.MergeTemplateVariableGroups(store, db.Template{
+		ProjectID:        proj.ID,
+		VariableGroupIDs: db.TemplateVariableGroups{common.ID, europe.ID},
+	}, &env, false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var vars map[string]string
+	if err = json.Unmarshal([]byte(env.JSON), &vars); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if vars["company"] != "acme" || vars["region"] != "eu-west-1" || vars["app_version"] != "2.0" {
+		t.Fatalf("unexpected variables %v", vars)
+	}
+}
@@ -3,7 +3,7 @@ package bolt
 import (
 	"encoding/json"
 	"github.com/semaphoreui/semaphore/db"
-	"go.etcd.io/bbolt"
+	"strconv"
 	"time"
 )
 
@@ -75,7 +75,7 @@ func (d *BoltDb) CreateEvent(evt db.Event) (newEvent db.Event, err error) {
 	newEvent = evt
 	newEvent.Created = time.Now()
 
-	err = d.db.Update(func(tx *bbolt.Tx) error {
+	err = d.db.Update(func(tx kvTx) error {
 		b, err2 := tx.CreateBucketIfNotExists([]byte("events"))
 		if err2 != nil {
 			return err2
@@ -100,7 +100,7 @@ func (d *BoltDb) CreateEvent(evt db.Event) (newEvent db.Event, err error) {
 }
 
 func (d *BoltDb) GetUserEvents(userID int, params db.RetrieveQueryParams) (events []db.Event, err error) {
-	err = d.db.View(func(tx *bbolt.Tx) error {
+	err = d.db.View(func(tx kvTx) error {
 		b := tx.Bucket([]byte("events"))
 		if b == nil {
 			return nil
@@ -122,7 +122,7 @@ func (d *BoltDb) GetUserEvents(userID int, params db.RetrieveQueryParams) (event
 }
 
 func (d *BoltDb) GetEvents(projectID int, params db.RetrieveQueryParams) (events []db.Event, err error) {
-	err = d.db.View(func(tx *bbolt.Tx) error {
+	err = d.db.View(func(tx kvTx) error {
 		b := tx.Bucket([]byte("events"))
 		if b == nil {
 			return nil
@@ -141,3 +141,88 @@ func (d *BoltDb) GetEvents(projectID int, params db.RetrieveQueryParams) (events
 
 	return
 }
+
+func (d *BoltDb) GetActivity(projectID int, params db.ActivityQueryParams) (events []db.Event, err error) {
+	events = []db.Event{}
+
+	err = d.db.View(func(tx kvTx) error {
+		b := tx.Bucket([]byte("events"))
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+
+		// events are stored in reverse order, so newer events have smaller keys
+		k, v := c.First()
+		if params.Before > 0 {
+			k, v = c.Seek(intObjectID(MaxID - params.Before + 1).ToBytes())
+		}
+
+		for ; k != nil; k, v = c.Next() {
+			var evt db.Event
+			if err2 := json.Unmarshal(v, &evt); err2 != nil {
+				return err2
+			}
+
+			if evt.ProjectID == nil || *evt.ProjectID != projectID {
+				continue
+			}
+
+			if !params.Match(evt) {
+				continue
+			}
+
+			key, err2 := strconv.Atoi(string(k))
+			if err2 != nil {
+				return err2
+			}
+			evt.ID = MaxID - key
+
+			events = append(events, evt)
+
+			if params.Count > 0 && len(events) >= params.Count {
+				break
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return
+	}
+
+	err = db.FillEvents(d, events)
+	return
+}
+
+func (d *BoltDb) GetProjectUsersLastActivity(projectID int) (res map[int]time.Time, err error) {
+	res = make(map[int]time.Time)
+
+	err = d.db.View(func(tx kvTx) error {
+		b := tx.Bucket([]byte("events"))
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(_, v []byte) error {
+			var evt db.Event
+			if err2 := json.Unmarshal(v, &evt); err2 != nil {
+				return err2
+			}
+
+			if evt.ProjectID == nil || *evt.ProjectID != projectID || evt.UserID == nil {
+				return nil
+			}
+
+			if last, ok := res[*evt.UserID]; !ok || evt.Created.After(last) {
+				res[*evt.UserID] = evt.Created
+			}
+
+			return nil
+		})
+	})
+
+	return
+}
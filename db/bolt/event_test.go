@@ -0,0 +1,70 @@
+package bolt
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func TestGetActivity(t *testing.T) {
+	store := CreateTestStore()
+
+	projectID := 1
+	otherProjectID := 2
+	templateType := db.EventTemplate
+	keyType := db.EventKey
+
+	for i := 1; i <= 5; i++ {
+		objectID := i
+		evt := db.Event{
+			ProjectID:  &projectID,
+			ObjectType: &templateType,
+			ObjectID:   &objectID,
+			Action:     "update",
+		}
+
+		if i == 3 {
+			evt.ObjectType = &keyType
+		}
+
+		if _, err := store.CreateEvent(evt); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.CreateEvent(db.Event{ProjectID: &otherProjectID, ObjectType: &templateType}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, err := store.GetActivity(projectID, db.ActivityQueryParams{Count: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 || *events[0].ObjectID != 5 || *events[1].ObjectID != 4 {
+		t.Fatal("invalid first page")
+	}
+
+	feed := db.NewActivityFeed(events, 2)
+	if feed.NextCursor == nil || feed.Items[0].Type != "config.template.update" {
+		t.Fatal("invalid feed")
+	}
+
+	events, err = store.GetActivity(projectID, db.ActivityQueryParams{Count: 2, Before: *feed.NextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 || *events[0].ObjectID != 3 || *events[1].ObjectID != 2 {
+		t.Fatal("invalid second page")
+	}
+
+	events, err = store.GetActivity(projectID, db.ActivityQueryParams{ObjectTypes: []db.EventObjectType{db.EventKey}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 || *events[0].ObjectID != 3 {
+		t.Fatal("invalid filtered events")
+	}
+}
@@ -5,7 +5,6 @@ import (
 
 	"github.com/gorilla/securecookie"
 	"github.com/semaphoreui/semaphore/db"
-	"go.etcd.io/bbolt"
 )
 
 func (d *BoltDb) GetGlobalRunnerByToken(token string) (runner db.Runner, err error) {
@@ -48,7 +47,7 @@ func (d *BoltDb) GetGlobalRunners(activeOnly bool) (runners []db.Runner, err err
 }
 
 func (d *BoltDb) DeleteGlobalRunner(runnerID int) (err error) {
-	return d.db.Update(func(tx *bbolt.Tx) error {
+	return d.db.Update(func(tx kvTx) error {
 		return d.deleteObject(0, db.GlobalRunnerProps, intObjectID(runnerID), tx)
 	})
 }
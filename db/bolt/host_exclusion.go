@@ -0,0 +1,70 @@
+package bolt
+
+import (
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *BoltDb) GetHostExclusion(projectID int, exclusionID int) (exclusion db.HostExclusion, err error) {
+	err = d.getObject(projectID, db.HostExclusionProps, intObjectID(exclusionID), &exclusion)
+	return
+}
+
+func (d *BoltDb) GetHostExclusions(projectID int) (exclusions []db.HostExclusion, err error) {
+	err = d.getObjects(projectID, db.HostExclusionProps, db.RetrieveQueryParams{}, nil, &exclusions)
+	return
+}
+
+func (d *BoltDb) UpdateHostExclusion(exclusion db.HostExclusion) error {
+	old, err := d.GetHostExclusion(exclusion.ProjectID, exclusion.ID)
+	if err != nil {
+		return err
+	}
+
+	exclusion.Created = old.Created
+	exclusion.UserID = old.UserID
+
+	return d.updateObject(exclusion.ProjectID, db.HostExclusionProps, exclusion)
+}
+
+func (d *BoltDb) CreateHostExclusion(exclusion db.HostExclusion) (db.HostExclusion, error) {
+	exclusion.Created = time.Now().UTC()
+
+	newExclusion, err := d.createObject(exclusion.ProjectID, db.HostExclusionProps, exclusion)
+	if err != nil {
+		return db.HostExclusion{}, err
+	}
+	return newExclusion.(db.HostExclusion), nil
+}
+
+func (d *BoltDb) DeleteHostExclusion(projectID int, exclusionID int) error {
+	return d.deleteObject(projectID, db.HostExclusionProps, intObjectID(exclusionID), nil)
+}
+
+func (d *BoltDb) DeleteExpiredHostExclusions(now time.Time) (expired []db.HostExclusion, err error) {
+	projects, err := d.GetAllProjects()
+	if err != nil {
+		return
+	}
+
+	for _, project := range projects {
+		var exclusions []db.HostExclusion
+		err = d.getObjects(project.ID, db.HostExclusionProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+			exclusion := i.(db.HostExclusion)
+			return exclusion.IsExpired(now)
+		}, &exclusions)
+		if err != nil {
+			return
+		}
+
+		for _, exclusion := range exclusions {
+			if err = d.DeleteHostExclusion(project.ID, exclusion.ID); err != nil {
+				return
+			}
+			expired = append(expired, exclusion)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,58 @@
+package bolt
+
+import (
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *BoltDb) GetIdempotencyKey(keyID string) (key db.IdempotencyKey, err error) {
+	err = d.getObject(0, db.IdempotencyKeyProps, strObjectID(keyID), &key)
+	return
+}
+
+func (d *BoltDb) CreateIdempotencyKey(key db.IdempotencyKey) error {
+	return d.db.Update(func(tx kvTx) error {
+		b := tx.Bucket(makeBucketId(db.IdempotencyKeyProps, 0))
+		if b != nil && b.Get(strObjectID(key.ID).ToBytes()) != nil {
+			return db.ErrInvalidOperation
+		}
+
+		_, err := d.createObjectTx(tx, 0, db.IdempotencyKeyProps, key)
+		return err
+	})
+}
+
+func (d *BoltDb) SetIdempotencyKeyResponse(keyID string, status int, response string) error {
+	key, err := d.GetIdempotencyKey(keyID)
+	if err != nil {
+		return err
+	}
+	key.Status = status
+	key.Response = response
+	return d.updateObject(0, db.IdempotencyKeyProps, key)
+}
+
+func (d *BoltDb) DeleteIdempotencyKey(keyID string) error {
+	return d.deleteObject(0, db.IdempotencyKeyProps, strObjectID(keyID), nil)
+}
+
+func (d *BoltDb) DeleteIdempotencyKeysBefore(created time.Time) (count int, err error) {
+	var keys []db.IdempotencyKey
+
+	err = d.getObjects(0, db.IdempotencyKeyProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		return i.(db.IdempotencyKey).Created.Before(created)
+	}, &keys)
+	if err != nil {
+		return
+	}
+
+	for _, key := range keys {
+		if err = d.DeleteIdempotencyKey(key.ID); err != nil {
+			return
+		}
+		count++
+	}
+
+	return
+}
@@ -0,0 +1,68 @@
+package bolt
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *BoltDb) clearIntegrationDeliveries(projectID int, integrationID int, maxDeliveries int, tx kvTx) error {
+	var deliveries []db.IntegrationDelivery
+	filter := func(i interface{}) bool {
+		return i.(db.IntegrationDelivery).IntegrationID == integrationID
+	}
+
+	var err error
+	if tx == nil {
+		err = d.getObjects(projectID, db.IntegrationDeliveryProps, db.RetrieveQueryParams{}, filter, &deliveries)
+	} else {
+		err = d.getObjectsTx(tx, projectID, db.IntegrationDeliveryProps, db.RetrieveQueryParams{}, filter, &deliveries)
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := maxDeliveries; i < len(deliveries); i++ {
+		err = d.deleteObject(projectID, db.IntegrationDeliveryProps, intObjectID(deliveries[i].ID), tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *BoltDb) CreateIntegrationDelivery(delivery db.IntegrationDelivery, maxDeliveries int) (db.IntegrationDelivery, error) {
+	newDelivery, err := d.createObject(delivery.ProjectID, db.IntegrationDeliveryProps, delivery)
+	if err != nil {
+		return db.IntegrationDelivery{}, err
+	}
+
+	if maxDeliveries > 0 {
+		_ = d.clearIntegrationDeliveries(delivery.ProjectID, delivery.IntegrationID, maxDeliveries, nil)
+	}
+
+	return newDelivery.(db.IntegrationDelivery), nil
+}
+
+func (d *BoltDb) GetIntegrationDeliveries(projectID int, integrationID int, params db.RetrieveQueryParams) (deliveries []db.IntegrationDelivery, err error) {
+	deliveries = make([]db.IntegrationDelivery, 0)
+
+	err = d.getObjects(projectID, db.IntegrationDeliveryProps, params, func(i interface{}) bool {
+		delivery := i.(db.IntegrationDelivery)
+		return delivery.IntegrationID == integrationID
+	}, &deliveries)
+
+	return
+}
+
+func (d *BoltDb) GetIntegrationDelivery(projectID int, integrationID int, deliveryID int) (delivery db.IntegrationDelivery, err error) {
+	err = d.getObject(projectID, db.IntegrationDeliveryProps, intObjectID(deliveryID), &delivery)
+	if err != nil {
+		return
+	}
+
+	if delivery.IntegrationID != integrationID {
+		err = db.ErrNotFound
+	}
+
+	return
+}
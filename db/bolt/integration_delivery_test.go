@@ -0,0 +1,54 @@
+package bolt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func TestCreateIntegrationDelivery_KeepsLastDeliveries(t *testing.T) {
+	store := CreateTestStore()
+
+	for i := 0; i < 5; i++ {
+		for _, integrationID := range []int{1, 2} {
+			_, err := store.CreateIntegrationDelivery(db.IntegrationDelivery{
+				ProjectID:     1,
+				IntegrationID: integrationID,
+				Created:       time.Unix(int64(i), 0),
+				Payload:       "{}",
+				Status:        db.IntegrationDeliveryNotMatched,
+			}, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	deliveries, err := store.GetIntegrationDeliveries(1, 1, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deliveries) != 3 {
+		t.Fatal("expected 3 deliveries, got", len(deliveries))
+	}
+
+	if !deliveries[0].Created.After(deliveries[1].Created) {
+		t.Fatal("deliveries must be sorted from newest to oldest")
+	}
+
+	delivery, err := store.GetIntegrationDelivery(1, 1, deliveries[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if delivery.IntegrationID != 1 {
+		t.Fatal("wrong integration")
+	}
+
+	_, err = store.GetIntegrationDelivery(1, 2, deliveries[0].ID)
+	if err != db.ErrNotFound {
+		t.Fatal("expected not found error for other integration")
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"reflect"
 
 	"github.com/semaphoreui/semaphore/db"
-	"go.etcd.io/bbolt"
 )
 
 /*
@@ -146,7 +145,7 @@ func (d *BoltDb) UpdateIntegrationMatcher(projectID int, integrationMatcher db.I
 	return d.updateObject(projectID, db.IntegrationMatcherProps, integrationMatcher)
 }
 
-func (d *BoltDb) deleteIntegrationMatcher(projectID int, matcherID int, integrationID int, tx *bbolt.Tx) error {
+func (d *BoltDb) deleteIntegrationMatcher(projectID int, matcherID int, integrationID int, tx kvTx) error {
 	return d.deleteObject(projectID, db.IntegrationMatcherProps, intObjectID(matcherID), tx)
 }
 
@@ -158,7 +157,7 @@ func (d *BoltDb) DeleteIntegration(projectID int, integrationID int) error {
 	return d.deleteIntegration(projectID, integrationID, nil)
 }
 
-func (d *BoltDb) deleteIntegration(projectID int, integrationID int, tx *bbolt.Tx) error {
+func (d *BoltDb) deleteIntegration(projectID int, integrationID int, tx kvTx) error {
 	matchers, err := d.GetIntegrationMatchers(projectID, db.RetrieveQueryParams{}, integrationID)
 
 	if err != nil {
@@ -169,6 +168,11 @@ func (d *BoltDb) deleteIntegration(projectID int, integrationID int, tx *bbolt.T
 		d.deleteIntegrationMatcher(projectID, matchers[m].ID, integrationID, tx)
 	}
 
+	err = d.clearIntegrationDeliveries(projectID, integrationID, 0, tx)
+	if err != nil {
+		return err
+	}
+
 	return d.deleteObject(projectID, db.IntegrationProps, intObjectID(integrationID), tx)
 }
 
@@ -25,7 +25,31 @@ func (d *BoltDb) GetInventoryRefs(projectID int, inventoryID int) (db.ObjectRefe
 }
 
 func (d *BoltDb) DeleteInventory(projectID int, inventoryID int) error {
-	return d.deleteObject(projectID, db.InventoryProps, intObjectID(inventoryID), nil)
+	secrets, err := d.GetInventorySecrets(projectID, inventoryID)
+	if err != nil {
+		return err
+	}
+
+	if err = d.deleteObject(projectID, db.InventoryProps, intObjectID(inventoryID), nil); err != nil {
+		return err
+	}
+
+	for _, key := range secrets {
+		if err = d.deleteObject(projectID, db.AccessKeyProps, intObjectID(key.ID), nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *BoltDb) GetInventorySecrets(projectID int, inventoryID int) ([]db.AccessKey, error) {
+	var keys []db.AccessKey
+	err := d.getObjects(projectID, db.AccessKeyProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		k := i.(db.AccessKey)
+		return k.InventoryID != nil && *k.InventoryID == inventoryID
+	}, &keys)
+	return keys, err
 }
 
 func (d *BoltDb) UpdateInventory(inventory db.Inventory) error {
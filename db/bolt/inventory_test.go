@@ -0,0 +1,75 @@
+package bolt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func TestInventorySecrets(t *testing.T) {
+	store := CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{
+		Created: time.Now(),
+		Name:    "TestProject",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inv, err := store.CreateInventory(db.Inventory{
+		ProjectID: proj.ID,
+		Name:      "Production",
+		Type:      db.InventoryStatic,
+		Inventory: "db1 ansible_password=${secrets.db_password}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.CreateAccessKey(db.AccessKey{
+		Name:        "db_password",
+		Type:        db.AccessKeyString,
+		String:      "qwerty",
+		ProjectID:   &proj.ID,
+		InventoryID: &inv.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := store.GetAccessKeys(proj.ID, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatal("secrets of the inventory must not be listed as keys of the project")
+	}
+
+	if err = db.FillInventorySecrets(store, &inv, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(inv.Secrets) != 1 || inv.Secrets[0].Secret != "" {
+		t.Fatal("secret must be filled without the value")
+	}
+
+	if err = db.FillInventorySecrets(store, &inv, true); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := inv.RenderSecrets(); err != nil || res != "db1 ansible_password=qwerty" {
+		t.Fatal("invalid rendered inventory", res, err)
+	}
+
+	if err = store.DeleteInventory(proj.ID, inv.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err = store.GetInventorySecrets(proj.ID, inv.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatal("secrets must be deleted with the inventory")
+	}
+}
@@ -0,0 +1,67 @@
+package bolt
+
+import (
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// EngineBolt stores the database in the single BoltDB file. It is used by default.
+	EngineBolt = "bolt"
+	// EngineBadger stores the database in the BadgerDB directory. Unlike BoltDB files,
+	// it is compacted online and has better write throughput.
+	EngineBadger = "badger"
+)
+
+// errBucketNotFound is returned by kvTx.DeleteBucket if the bucket does not exist.
+var errBucketNotFound = bbolt.ErrBucketNotFound
+
+// kvDB is the key-value storage of BoltDb. Keys of the storage are grouped in buckets.
+// Writable transactions are executed one by one, like in BoltDB.
+type kvDB interface {
+	View(fn func(tx kvTx) error) error
+	Update(fn func(tx kvTx) error) error
+	Close() error
+}
+
+type kvTx interface {
+	// Bucket returns nil if the bucket does not exist.
+	Bucket(name []byte) kvBucket
+	CreateBucketIfNotExists(name []byte) (kvBucket, error)
+	DeleteBucket(name []byte) error
+	// Cursor iterates over names of the buckets, values are nil.
+	Cursor() kvCursor
+}
+
+type kvBucket interface {
+	// Get returns nil if the key does not exist. The value is valid in the transaction only.
+	Get(key []byte) []byte
+	Put(key []byte, value []byte) error
+	Delete(key []byte) error
+	// NextSequence returns the next integer of the bucket starting from 1.
+	NextSequence() (uint64, error)
+	ForEach(fn func(key []byte, value []byte) error) error
+	Cursor() kvCursor
+}
+
+// kvCursor iterates over keys in the byte order. Methods return nil key after the last item.
+type kvCursor interface {
+	First() (key []byte, value []byte)
+	Last() (key []byte, value []byte)
+	Next() (key []byte, value []byte)
+	Seek(seek []byte) (key []byte, value []byte)
+}
+
+// openKV opens the storage of the engine at the path, the file of BoltDB
+// or the directory of BadgerDB.
+func openKV(engine string, path string) (kvDB, error) {
+	switch engine {
+	case "", EngineBolt:
+		return openBoltKV(path)
+	case EngineBadger:
+		return openBadgerKV(path)
+	default:
+		return nil, fmt.Errorf("unsupported database engine: %s", engine)
+	}
+}
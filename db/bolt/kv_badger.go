@@ -0,0 +1,499 @@
+package bolt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+// Keys of the BadgerDB storage:
+//
+//	"b" + bucket name         -> generation of the bucket (8 bytes) + last sequence of the bucket (8 bytes)
+//	"d" + generation (8 bytes) + key -> value
+//	"g"                       -> last generation
+//
+// The bucket is deleted by deleting its marker, so big buckets like outputs of tasks
+// are deleted at once. Keys of deleted generations are removed by the online compaction.
+const (
+	badgerBucketPrefix = 'b'
+	badgerDataPrefix   = 'd'
+
+	badgerCompactionInterval = 10 * time.Minute
+	badgerValueLogGCRatio    = 0.5
+)
+
+var badgerGenerationKey = []byte("g")
+
+// badgerKV is the storage in the BadgerDB directory.
+type badgerKV struct {
+	db *badger.DB
+	// writer serializes writable transactions, so they never conflict.
+	writer sync.Mutex
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+type badgerTx struct {
+	txn      *badger.Txn
+	writable bool
+	// iterators are closed at the end of the transaction.
+	iterators []*badger.Iterator
+}
+
+type badgerBucket struct {
+	tx     *badgerTx
+	marker []byte
+	prefix []byte
+}
+
+type badgerItem struct {
+	key   []byte
+	value []byte
+}
+
+// badgerCursor iterates over the keys with the prefix. Read-only transactions iterate
+// the storage directly. Writable transactions of BadgerDB can have one open iterator
+// only, so the cursor of the writable transaction reads all keys of the bucket at once.
+type badgerCursor struct {
+	tx     *badgerTx
+	prefix []byte
+	// keysOnly is true for the cursor over names of the buckets.
+	keysOnly bool
+
+	it *badger.Iterator
+
+	items  []badgerItem
+	loaded bool
+	pos    int
+}
+
+func openBadgerKV(dir string) (kvDB, error) {
+	opts := badger.DefaultOptions(dir).
+		WithLogger(log.StandardLogger()).
+		WithLoggingLevel(badger.WARNING)
+
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &badgerKV{db: db, done: make(chan struct{})}
+
+	s.wg.Add(1)
+	go s.runCompaction()
+
+	return s, nil
+}
+
+func (s *badgerKV) View(fn func(tx kvTx) error) error {
+	return s.db.View(func(txn *badger.Txn) error {
+		tx := &badgerTx{txn: txn}
+		defer tx.close()
+		return fn(tx)
+	})
+}
+
+func (s *badgerKV) Update(fn func(tx kvTx) error) error {
+	s.writer.Lock()
+	defer s.writer.Unlock()
+
+	return s.db.Update(func(txn *badger.Txn) error {
+		tx := &badgerTx{txn: txn, writable: true}
+		defer tx.close()
+		return fn(tx)
+	})
+}
+
+func (s *badgerKV) Close() error {
+	close(s.done)
+	s.wg.Wait()
+	return s.db.Close()
+}
+
+func (s *badgerKV) runCompaction() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(badgerCompactionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			if err := s.compact(); err != nil {
+				log.WithError(err).Error("Failed to compact the database")
+			}
+		}
+	}
+}
+
+// compact removes keys of deleted buckets and rewrites value log files
+// which contain mostly deleted values.
+func (s *badgerKV) compact() error {
+	var deleted [][]byte
+
+	err := s.db.View(func(txn *badger.Txn) error {
+		lastGeneration, err := badgerGet(txn, badgerGenerationKey)
+		if err != nil || lastGeneration == nil {
+			return err
+		}
+
+		live := make(map[uint64]bool)
+
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte{badgerBucketPrefix}
+		it := txn.NewIterator(opts)
+		for it.Rewind(); it.Valid(); it.Next() {
+			marker, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				it.Close()
+				return err
+			}
+			live[binary.BigEndian.Uint64(marker)] = true
+		}
+		it.Close()
+
+		// buckets created after the start of the compaction have greater generations
+		last := binary.BigEndian.Uint64(lastGeneration)
+
+		opts = badger.DefaultIteratorOptions
+		opts.Prefix = []byte{badgerDataPrefix}
+		opts.PrefetchValues = false
+		it = txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); {
+			key := it.Item().Key()
+			generation := binary.BigEndian.Uint64(key[1:9])
+
+			if generation > last || live[generation] {
+				// skip keys of the bucket
+				it.Seek(badgerDataKey(generation+1, nil))
+				continue
+			}
+
+			deleted = append(deleted, it.Item().KeyCopy(nil))
+			it.Next()
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if len(deleted) > 0 {
+		batch := s.db.NewWriteBatch()
+		defer batch.Cancel()
+
+		for _, key := range deleted {
+			if err = batch.Delete(key); err != nil {
+				return err
+			}
+		}
+
+		if err = batch.Flush(); err != nil {
+			return err
+		}
+	}
+
+	for s.db.RunValueLogGC(badgerValueLogGCRatio) == nil {
+		// the value log is rewritten until no file can be rewritten
+	}
+
+	return nil
+}
+
+func badgerBucketKey(name []byte) []byte {
+	return append([]byte{badgerBucketPrefix}, name...)
+}
+
+func badgerDataKey(generation uint64, key []byte) []byte {
+	res := make([]byte, 9, 9+len(key))
+	res[0] = badgerDataPrefix
+	binary.BigEndian.PutUint64(res[1:], generation)
+	return append(res, key...)
+}
+
+// badgerGet returns nil if the key does not exist.
+func badgerGet(txn *badger.Txn, key []byte) ([]byte, error) {
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := item.ValueCopy(nil)
+	if value == nil {
+		value = []byte{}
+	}
+	return value, err
+}
+
+func (t *badgerTx) close() {
+	for _, it := range t.iterators {
+		it.Close()
+	}
+	t.iterators = nil
+}
+
+func (t *badgerTx) newIterator(prefix []byte, keysOnly bool, reverse bool) *badger.Iterator {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+	opts.PrefetchValues = !keysOnly
+	opts.Reverse = reverse
+	return t.txn.NewIterator(opts)
+}
+
+func (t *badgerTx) bucket(name []byte) (kvBucket, error) {
+	key := badgerBucketKey(name)
+
+	marker, err := badgerGet(t.txn, key)
+	if err != nil || marker == nil {
+		return nil, err
+	}
+
+	return &badgerBucket{
+		tx:     t,
+		marker: key,
+		prefix: badgerDataKey(binary.BigEndian.Uint64(marker), nil),
+	}, nil
+}
+
+func (t *badgerTx) Bucket(name []byte) kvBucket {
+	b, err := t.bucket(name)
+	if err != nil {
+		log.WithError(err).Error("Failed to read the bucket " + string(name))
+		return nil
+	}
+	return b
+}
+
+func (t *badgerTx) CreateBucketIfNotExists(name []byte) (kvBucket, error) {
+	b, err := t.bucket(name)
+	if err != nil || b != nil {
+		return b, err
+	}
+
+	lastGeneration, err := badgerGet(t.txn, badgerGenerationKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var generation uint64 = 1
+	if lastGeneration != nil {
+		generation = binary.BigEndian.Uint64(lastGeneration) + 1
+	}
+
+	if err = t.txn.Set(badgerGenerationKey, binary.BigEndian.AppendUint64(nil, generation)); err != nil {
+		return nil, err
+	}
+
+	marker := make([]byte, 16)
+	binary.BigEndian.PutUint64(marker, generation)
+
+	if err = t.txn.Set(badgerBucketKey(name), marker); err != nil {
+		return nil, err
+	}
+
+	return t.bucket(name)
+}
+
+func (t *badgerTx) DeleteBucket(name []byte) error {
+	b, err := t.bucket(name)
+	if err != nil {
+		return err
+	}
+
+	if b == nil {
+		return errBucketNotFound
+	}
+
+	return t.txn.Delete(b.(*badgerBucket).marker)
+}
+
+func (t *badgerTx) Cursor() kvCursor {
+	return &badgerCursor{tx: t, prefix: []byte{badgerBucketPrefix}, keysOnly: true}
+}
+
+func (b *badgerBucket) Get(key []byte) []byte {
+	value, err := badgerGet(b.tx.txn, append(bytes.Clone(b.prefix), key...))
+	if err != nil {
+		log.WithError(err).Error("Failed to read the key " + string(key))
+		return nil
+	}
+	return value
+}
+
+func (b *badgerBucket) Put(key []byte, value []byte) error {
+	return b.tx.txn.Set(append(bytes.Clone(b.prefix), key...), bytes.Clone(value))
+}
+
+func (b *badgerBucket) Delete(key []byte) error {
+	return b.tx.txn.Delete(append(bytes.Clone(b.prefix), key...))
+}
+
+func (b *badgerBucket) NextSequence() (uint64, error) {
+	marker, err := badgerGet(b.tx.txn, b.marker)
+	if err != nil {
+		return 0, err
+	}
+
+	sequence := binary.BigEndian.Uint64(marker[8:]) + 1
+	binary.BigEndian.PutUint64(marker[8:], sequence)
+
+	return sequence, b.tx.txn.Set(b.marker, marker)
+}
+
+func (b *badgerBucket) ForEach(fn func(key []byte, value []byte) error) error {
+	c := b.Cursor()
+
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *badgerBucket) Cursor() kvCursor {
+	return &badgerCursor{tx: b.tx, prefix: b.prefix}
+}
+
+func (c *badgerCursor) item(item *badger.Item) (badgerItem, error) {
+	res := badgerItem{key: item.KeyCopy(nil)[len(c.prefix):]}
+
+	if c.keysOnly {
+		return res, nil
+	}
+
+	value, err := item.ValueCopy(nil)
+	if value == nil {
+		value = []byte{}
+	}
+	res.value = value
+
+	return res, err
+}
+
+// current returns the item of the live iterator.
+func (c *badgerCursor) current() ([]byte, []byte) {
+	if c.it == nil || !c.it.Valid() {
+		return nil, nil
+	}
+
+	item, err := c.item(c.it.Item())
+	if err != nil {
+		log.WithError(err).Error("Failed to read the value")
+		return nil, nil
+	}
+
+	return item.key, item.value
+}
+
+// load reads all items of the writable transaction.
+func (c *badgerCursor) load() {
+	if c.loaded {
+		return
+	}
+
+	c.loaded = true
+
+	it := c.tx.newIterator(c.prefix, c.keysOnly, false)
+	defer it.Close()
+
+	for it.Rewind(); it.Valid(); it.Next() {
+		item, err := c.item(it.Item())
+		if err != nil {
+			log.WithError(err).Error("Failed to read the value")
+			return
+		}
+		c.items = append(c.items, item)
+	}
+}
+
+// loadedItem returns the item at the position of the cursor of the writable transaction.
+func (c *badgerCursor) loadedItem() ([]byte, []byte) {
+	if c.pos < 0 || c.pos >= len(c.items) {
+		return nil, nil
+	}
+	return c.items[c.pos].key, c.items[c.pos].value
+}
+
+func (c *badgerCursor) First() ([]byte, []byte) {
+	return c.Seek(nil)
+}
+
+func (c *badgerCursor) Last() ([]byte, []byte) {
+	if c.tx.writable {
+		c.load()
+		c.pos = len(c.items) - 1
+		return c.loadedItem()
+	}
+
+	it := c.tx.newIterator(c.prefix, c.keysOnly, true)
+	defer it.Close()
+
+	// the reverse iterator seeks the greatest key which is less or equal to the key
+	it.Seek(append(bytes.Clone(c.prefix), 0xFF))
+
+	// the next key after the last one does not exist
+	c.it = nil
+
+	if !it.Valid() {
+		return nil, nil
+	}
+
+	item, err := c.item(it.Item())
+	if err != nil {
+		log.WithError(err).Error("Failed to read the value")
+		return nil, nil
+	}
+
+	return item.key, item.value
+}
+
+func (c *badgerCursor) Next() ([]byte, []byte) {
+	if c.tx.writable {
+		if !c.loaded {
+			return nil, nil
+		}
+		c.pos++
+		return c.loadedItem()
+	}
+
+	if c.it == nil || !c.it.Valid() {
+		return nil, nil
+	}
+
+	c.it.Next()
+	return c.current()
+}
+
+func (c *badgerCursor) Seek(seek []byte) ([]byte, []byte) {
+	if c.tx.writable {
+		c.load()
+		c.pos = sort.Search(len(c.items), func(i int) bool {
+			return bytes.Compare(c.items[i].key, seek) >= 0
+		})
+		return c.loadedItem()
+	}
+
+	if c.it == nil {
+		c.it = c.tx.newIterator(c.prefix, c.keysOnly, false)
+		c.tx.iterators = append(c.tx.iterators, c.it)
+	}
+
+	c.it.Seek(append(bytes.Clone(c.prefix), seek...))
+	return c.current()
+}
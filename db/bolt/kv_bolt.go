@@ -0,0 +1,76 @@
+package bolt
+
+import (
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// boltKV is the storage in the BoltDB file.
+type boltKV struct {
+	db *bbolt.DB
+}
+
+type boltTx struct {
+	tx *bbolt.Tx
+}
+
+type boltBucket struct {
+	*bbolt.Bucket
+}
+
+func openBoltKV(filename string) (kvDB, error) {
+	db, err := bbolt.Open(filename, 0666, &bbolt.Options{
+		Timeout: 5 * time.Second,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &boltKV{db: db}, nil
+}
+
+func (s *boltKV) View(fn func(tx kvTx) error) error {
+	return s.db.View(func(tx *bbolt.Tx) error {
+		return fn(boltTx{tx: tx})
+	})
+}
+
+func (s *boltKV) Update(fn func(tx kvTx) error) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return fn(boltTx{tx: tx})
+	})
+}
+
+func (s *boltKV) Close() error {
+	return s.db.Close()
+}
+
+func (t boltTx) Bucket(name []byte) kvBucket {
+	b := t.tx.Bucket(name)
+	if b == nil {
+		return nil
+	}
+	return boltBucket{b}
+}
+
+func (t boltTx) CreateBucketIfNotExists(name []byte) (kvBucket, error) {
+	b, err := t.tx.CreateBucketIfNotExists(name)
+	if err != nil {
+		return nil, err
+	}
+	return boltBucket{b}, nil
+}
+
+func (t boltTx) DeleteBucket(name []byte) error {
+	return t.tx.DeleteBucket(name)
+}
+
+func (t boltTx) Cursor() kvCursor {
+	return t.tx.Cursor()
+}
+
+func (b boltBucket) Cursor() kvCursor {
+	return b.Bucket.Cursor()
+}
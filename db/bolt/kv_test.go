@@ -0,0 +1,200 @@
+package bolt
+
+import (
+	"os"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func openTestKV(t *testing.T, engine string) kvDB {
+	path := "/tmp/test_semaphore_kv_" + util.RandString(5)
+
+	kv, err := openKV(engine, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = kv.Close()
+		_ = os.RemoveAll(path)
+	})
+
+	return kv
+}
+
+func TestKV(t *testing.T) {
+	for _, engine := range []string{EngineBolt, EngineBadger} {
+		t.Run(engine, func(t *testing.T) {
+			kv := openTestKV(t, engine)
+
+			err := kv.Update(func(tx kvTx) error {
+				b, err := tx.CreateBucketIfNotExists([]byte("project"))
+				if err != nil {
+					return err
+				}
+
+				for _, key := range []string{"3", "1", "2"} {
+					seq, err := b.NextSequence()
+					if err != nil {
+						return err
+					}
+					if err = b.Put([]byte(key), []byte{byte('0' + seq)}); err != nil {
+						return err
+					}
+				}
+
+				_, err = tx.CreateBucketIfNotExists([]byte("project__task_1"))
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = kv.View(func(tx kvTx) error {
+				if tx.Bucket([]byte("user")) != nil {
+					t.Fatal("bucket must not exist")
+				}
+
+				var names []string
+				c := tx.Cursor()
+				for k, _ := c.First(); k != nil; k, _ = c.Next() {
+					names = append(names, string(k))
+				}
+				if len(names) != 2 || names[0] != "project" || names[1] != "project__task_1" {
+					t.Fatal("invalid buckets", names)
+				}
+
+				b := tx.Bucket([]byte("project"))
+
+				if string(b.Get([]byte("1"))) != "2" || b.Get([]byte("4")) != nil {
+					t.Fatal("invalid values")
+				}
+
+				var keys string
+				c = b.Cursor()
+				for k, _ := c.First(); k != nil; k, _ = c.Next() {
+					keys += string(k)
+				}
+				if keys != "123" {
+					t.Fatal("keys must be sorted", keys)
+				}
+
+				if k, v := c.Last(); string(k) != "3" || string(v) != "1" {
+					t.Fatal("invalid last item", string(k))
+				}
+
+				if k, _ := c.Seek([]byte("2")); string(k) != "2" {
+					t.Fatal("invalid item after seek", string(k))
+				}
+
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = kv.Update(func(tx kvTx) error {
+				b := tx.Bucket([]byte("project"))
+
+				// items are deleted while the bucket is iterated
+				err := b.ForEach(func(k, _ []byte) error {
+					return b.Delete(k)
+				})
+				if err != nil {
+					return err
+				}
+
+				if err = tx.DeleteBucket([]byte("project__task_1")); err != nil {
+					return err
+				}
+
+				if err = tx.DeleteBucket([]byte("project__task_2")); err != errBucketNotFound {
+					t.Fatal("expected bucket not found error", err)
+				}
+
+				seq, err := b.NextSequence()
+				if seq != 4 {
+					t.Fatal("sequence must not be reset by deletion of items", seq)
+				}
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = kv.View(func(tx kvTx) error {
+				if k, _ := tx.Bucket([]byte("project")).Cursor().First(); k != nil {
+					t.Fatal("items must be deleted")
+				}
+				if tx.Bucket([]byte("project__task_1")) != nil {
+					t.Fatal("bucket must be deleted")
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestBadgerKVCompactsDeletedBuckets(t *testing.T) {
+	kv := openTestKV(t, EngineBadger).(*badgerKV)
+
+	for i := 0; i < 2; i++ {
+		err := kv.Update(func(tx kvTx) error {
+			if i > 0 {
+				if err := tx.DeleteBucket([]byte("project__task_output_1")); err != nil {
+					return err
+				}
+			}
+
+			b, err := tx.CreateBucketIfNotExists([]byte("project__task_output_1"))
+			if err != nil {
+				return err
+			}
+
+			return b.Put([]byte("1"), []byte("line"))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	countKeys := func() (n int) {
+		err := kv.View(func(tx kvTx) error {
+			c := &badgerCursor{tx: tx.(*badgerTx), prefix: []byte{badgerDataPrefix}, keysOnly: true}
+			for k, _ := c.First(); k != nil; k, _ = c.Next() {
+				n++
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	if n := countKeys(); n != 2 {
+		t.Fatal("keys of the deleted bucket must be kept until the compaction", n)
+	}
+
+	if err := kv.compact(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := countKeys(); n != 1 {
+		t.Fatal("keys of the deleted bucket must be removed by the compaction", n)
+	}
+
+	err := kv.View(func(tx kvTx) error {
+		if v := tx.Bucket([]byte("project__task_output_1")).Get([]byte("1")); string(v) != "line" {
+			t.Fatal("keys of the bucket must be kept", string(v))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
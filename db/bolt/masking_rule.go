@@ -0,0 +1,29 @@
+package bolt
+
+import "github.com/semaphoreui/semaphore/db"
+
+func (d *BoltDb) GetMaskingRule(projectID int, ruleID int) (rule db.MaskingRule, err error) {
+	err = d.getObject(projectID, db.MaskingRuleProps, intObjectID(ruleID), &rule)
+	return
+}
+
+func (d *BoltDb) GetMaskingRules(projectID int) (rules []db.MaskingRule, err error) {
+	err = d.getObjects(projectID, db.MaskingRuleProps, db.RetrieveQueryParams{}, nil, &rules)
+	return
+}
+
+func (d *BoltDb) UpdateMaskingRule(rule db.MaskingRule) error {
+	return d.updateObject(rule.ProjectID, db.MaskingRuleProps, rule)
+}
+
+func (d *BoltDb) CreateMaskingRule(rule db.MaskingRule) (db.MaskingRule, error) {
+	newRule, err := d.createObject(rule.ProjectID, db.MaskingRuleProps, rule)
+	if err != nil {
+		return db.MaskingRule{}, err
+	}
+	return newRule.(db.MaskingRule), nil
+}
+
+func (d *BoltDb) DeleteMaskingRule(projectID int, ruleID int) error {
+	return d.deleteObject(projectID, db.MaskingRuleProps, intObjectID(ruleID), nil)
+}
@@ -3,11 +3,10 @@ package bolt
 import (
 	"encoding/json"
 	"github.com/semaphoreui/semaphore/db"
-	"go.etcd.io/bbolt"
 )
 
 func (d *BoltDb) IsMigrationApplied(migration db.Migration) (bool, error) {
-	err := d.db.View(func(tx *bbolt.Tx) error {
+	err := d.db.View(func(tx kvTx) error {
 		b := tx.Bucket([]byte("migrations"))
 		if b == nil {
 			return db.ErrNotFound
@@ -55,7 +54,7 @@ func (d *BoltDb) ApplyMigration(m db.Migration) (err error) {
 		return
 	}
 
-	return d.db.Update(func(tx *bbolt.Tx) error {
+	return d.db.Update(func(tx kvTx) error {
 		b, err := tx.CreateBucketIfNotExists([]byte("migrations"))
 
 		if err != nil {
@@ -78,12 +77,18 @@ func (d *BoltDb) TryRollbackMigration(m db.Migration) {
 	}
 }
 
+// GetMigrationPlan returns the plan without queries because BoltDB migrations
+// are done by code. BoltDB is used by a single node, so rolling upgrades are not possible.
+func (d *BoltDb) GetMigrationPlan(m db.Migration) (db.MigrationPlan, error) {
+	return db.MigrationPlan{Migration: m}, nil
+}
+
 type migration struct {
-	db *bbolt.DB
+	db kvDB
 }
 
 func (d migration) getProjectIDs() (projectIDs []string, err error) {
-	err = d.db.View(func(tx *bbolt.Tx) error {
+	err = d.db.View(func(tx kvTx) error {
 		b := tx.Bucket([]byte("project"))
 		if b == nil {
 			return nil
@@ -100,7 +105,7 @@ func (d migration) getProjectIDs() (projectIDs []string, err error) {
 func (d migration) getObjects(projectID string, objectPrefix string) (map[string]map[string]interface{}, error) {
 	repos := make(map[string]map[string]interface{}) // ???
 
-	err := d.db.View(func(tx *bbolt.Tx) error {
+	err := d.db.View(func(tx kvTx) error {
 		b := tx.Bucket([]byte("project__" + objectPrefix + "_" + projectID))
 		if b == nil {
 			return nil
@@ -116,7 +121,7 @@ func (d migration) getObjects(projectID string, objectPrefix string) (map[string
 }
 
 func (d migration) setObject(projectID string, objectPrefix string, objectID string, object map[string]interface{}) error {
-	return d.db.Update(func(tx *bbolt.Tx) error {
+	return d.db.Update(func(tx kvTx) error {
 		b, err := tx.CreateBucketIfNotExists([]byte("project__" + objectPrefix + "_" + projectID))
 		if err != nil {
 			return err
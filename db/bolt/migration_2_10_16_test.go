@@ -2,14 +2,13 @@ package bolt
 
 import (
 	"encoding/json"
-	"go.etcd.io/bbolt"
 	"testing"
 )
 
 func TestMigration_2_10_16_Apply(t *testing.T) {
 	store := CreateTestStore()
 
-	err := store.db.Update(func(tx *bbolt.Tx) error {
+	err := store.db.Update(func(tx kvTx) error {
 		b, err := tx.CreateBucketIfNotExists([]byte("project"))
 		if err != nil {
 			return err
@@ -41,7 +40,7 @@ func TestMigration_2_10_16_Apply(t *testing.T) {
 	}
 
 	var repo map[string]interface{}
-	err = store.db.View(func(tx *bbolt.Tx) error {
+	err = store.db.View(func(tx kvTx) error {
 		b := tx.Bucket([]byte("project__template_0000000001"))
 		str := string(b.Get([]byte("0000000001")))
 		return json.Unmarshal([]byte(str), &repo)
@@ -66,7 +65,7 @@ func TestMigration_2_10_16_Apply(t *testing.T) {
 func TestMigration_2_10_16_Apply2(t *testing.T) {
 	store := CreateTestStore()
 
-	err := store.db.Update(func(tx *bbolt.Tx) error {
+	err := store.db.Update(func(tx kvTx) error {
 		b, err := tx.CreateBucketIfNotExists([]byte("project"))
 		if err != nil {
 			return err
@@ -5,13 +5,12 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"go.etcd.io/bbolt"
 )
 
 func TestMigration_2_8_28_Apply(t *testing.T) {
 	store := CreateTestStore()
 
-	err := store.db.Update(func(tx *bbolt.Tx) error {
+	err := store.db.Update(func(tx kvTx) error {
 		b, err := tx.CreateBucketIfNotExists([]byte("project"))
 		if err != nil {
 			return err
@@ -39,7 +38,7 @@ func TestMigration_2_8_28_Apply(t *testing.T) {
 	assert.NoError(t, err)
 
 	var repo map[string]interface{}
-	err = store.db.View(func(tx *bbolt.Tx) error {
+	err = store.db.View(func(tx kvTx) error {
 		b := tx.Bucket([]byte("project__repository_0000000001"))
 		str := string(b.Get([]byte("0000000001")))
 		return json.Unmarshal([]byte(str), &repo)
@@ -53,7 +52,7 @@ func TestMigration_2_8_28_Apply(t *testing.T) {
 func TestMigration_2_8_28_Apply2(t *testing.T) {
 	store := CreateTestStore()
 
-	err := store.db.Update(func(tx *bbolt.Tx) error {
+	err := store.db.Update(func(tx kvTx) error {
 		b, err := tx.CreateBucketIfNotExists([]byte("project"))
 		if err != nil {
 			return err
@@ -3,7 +3,6 @@ package bolt
 import (
 	"errors"
 	"github.com/semaphoreui/semaphore/db"
-	"go.etcd.io/bbolt"
 	"strings"
 )
 
@@ -70,7 +69,7 @@ func (d *BoltDb) DeleteOption(key string) (err error) {
 		return
 	}
 
-	return d.db.Update(func(tx *bbolt.Tx) error {
+	return d.db.Update(func(tx kvTx) error {
 		return d.deleteObject(-1, db.OptionProps, strObjectID(key), tx)
 	})
 }
@@ -0,0 +1,104 @@
+package bolt
+
+import (
+	"fmt"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *BoltDb) GetOrphanedReferences() (refs []db.OrphanedReference, err error) {
+	refs = make([]db.OrphanedReference, 0)
+
+	projects, err := d.GetAllProjects()
+	if err != nil {
+		return
+	}
+
+	var users []db.User
+	if err = d.getObjects(0, db.UserProps, db.RetrieveQueryParams{}, nil, &users); err != nil {
+		return
+	}
+
+	userIDs := make(map[int]bool)
+	for _, user := range users {
+		userIDs[user.ID] = true
+	}
+
+	// IDs of templates are unique only in the project
+	templateIDs := make(map[int]map[int]bool)
+
+	for _, project := range projects {
+		var templates []db.Template
+		if err = d.getObjects(project.ID, db.TemplateProps, db.RetrieveQueryParams{}, nil, &templates); err != nil {
+			return
+		}
+
+		templateIDs[project.ID] = make(map[int]bool)
+		for _, tpl := range templates {
+			templateIDs[project.ID][tpl.ID] = true
+		}
+
+		var envs []db.Environment
+		if err = d.getObjects(project.ID, db.EnvironmentProps, db.RetrieveQueryParams{}, nil, &envs); err != nil {
+			return
+		}
+
+		envIDs := make(map[int]bool)
+		for _, env := range envs {
+			envIDs[env.ID] = true
+		}
+
+		var keys []db.AccessKey
+		if err = d.getObjects(project.ID, db.AccessKeyProps, db.RetrieveQueryParams{}, nil, &keys); err != nil {
+			return
+		}
+
+		for _, key := range keys {
+			if key.EnvironmentID != nil && !envIDs[*key.EnvironmentID] {
+				refs = append(refs, db.NewOrphanedReference(db.OrphanKeyEnvironment, project.ID, key.ID, *key.EnvironmentID))
+			}
+
+			if key.UserID != nil && !userIDs[*key.UserID] {
+				refs = append(refs, db.NewOrphanedReference(db.OrphanKeyUser, project.ID, key.ID, *key.UserID))
+			}
+		}
+	}
+
+	var tasks []db.Task
+	err = d.getObjects(0, db.TaskProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		task := i.(db.Task)
+		return !templateIDs[task.ProjectID][task.TemplateID]
+	}, &tasks)
+	if err != nil {
+		return
+	}
+
+	for _, task := range tasks {
+		refs = append(refs, db.NewOrphanedReference(db.OrphanTaskTemplate, task.ProjectID, task.ID, task.TemplateID))
+	}
+
+	return
+}
+
+func (d *BoltDb) FixOrphanedReference(ref db.OrphanedReference) error {
+	switch ref.Kind {
+	case db.OrphanTaskTemplate:
+		return d.DeleteTaskWithOutputs(ref.ProjectID, ref.ObjectID)
+	case db.OrphanKeyEnvironment:
+		return d.DeleteAccessKey(ref.ProjectID, ref.ObjectID)
+	case db.OrphanKeyUser:
+		var key db.AccessKey
+		if err := d.getObject(ref.ProjectID, db.AccessKeyProps, intObjectID(ref.ObjectID), &key); err != nil {
+			return err
+		}
+
+		if key.UserID == nil || *key.UserID != ref.RefID {
+			return nil
+		}
+
+		key.UserID = nil
+		return d.updateObject(ref.ProjectID, db.AccessKeyProps, key)
+	default:
+		return fmt.Errorf("unknown kind of orphaned reference %s", ref.Kind)
+	}
+}
@@ -57,5 +57,39 @@ func (d *BoltDb) DeleteProject(projectID int) error {
 }
 
 func (d *BoltDb) UpdateProject(project db.Project) error {
+	existing, err := d.GetProject(project.ID)
+	if err != nil {
+		return err
+	}
+	project.CalendarToken = existing.CalendarToken
+	project.Archived = existing.Archived
+	project.AlertDigestSent = existing.AlertDigestSent
+	return d.updateObject(0, db.ProjectProps, project)
+}
+
+func (d *BoltDb) SetProjectAlertDigestSent(projectID int, sent time.Time) error {
+	project, err := d.GetProject(projectID)
+	if err != nil {
+		return err
+	}
+	project.AlertDigestSent = &sent
+	return d.updateObject(0, db.ProjectProps, project)
+}
+
+func (d *BoltDb) SetProjectArchived(projectID int, archived bool) error {
+	project, err := d.GetProject(projectID)
+	if err != nil {
+		return err
+	}
+	project.Archived = archived
+	return d.updateObject(0, db.ProjectProps, project)
+}
+
+func (d *BoltDb) SetProjectCalendarToken(projectID int, token *string) error {
+	project, err := d.GetProject(projectID)
+	if err != nil {
+		return err
+	}
+	project.CalendarToken = token
 	return d.updateObject(0, db.ProjectProps, project)
 }
@@ -81,3 +81,85 @@ func TestGetProject(t *testing.T) {
 		t.Fatal(err.Error())
 	}
 }
+
+func TestProjectDefaultKeys(t *testing.T) {
+	store := CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{
+		Created: time.Now(),
+		Name:    "Test1",
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	sshKey, err := store.CreateAccessKey(db.AccessKey{
+		Name:      "SSH",
+		Type:      db.AccessKeySSH,
+		ProjectID: &proj.ID,
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	vaultKey, err := store.CreateAccessKey(db.AccessKey{
+		Name:      "Vault",
+		Type:      db.AccessKeyLoginPassword,
+		ProjectID: &proj.ID,
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	proj.DefaultSSHKeyID = &sshKey.ID
+	proj.DefaultVaultKeyID = &vaultKey.ID
+
+	if err = db.ValidateProjectDefaultKeys(store, &proj); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err = store.UpdateProject(proj); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	proj, err = store.GetProject(proj.ID)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	inventory := db.Inventory{ID: 1, ProjectID: proj.ID, Type: db.InventoryStatic}
+	template := db.Template{ID: 1, ProjectID: proj.ID, App: db.AppAnsible}
+
+	if err = db.ApplyProjectDefaultKeys(store, proj, &inventory, &template); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if inventory.SSHKeyID == nil || *inventory.SSHKeyID != sshKey.ID || inventory.SSHKey.ID != sshKey.ID {
+		t.Fatal("default SSH key must be applied to the inventory")
+	}
+
+	if inventory.BecomeKeyID != nil {
+		t.Fatal("become key must not be set")
+	}
+
+	if len(template.Vaults) != 1 || *template.Vaults[0].VaultKeyID != vaultKey.ID {
+		t.Fatal("default vault must be applied to the template")
+	}
+
+	if err = store.DeleteAccessKey(proj.ID, vaultKey.ID); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	proj, err = store.GetProject(proj.ID)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if proj.DefaultVaultKeyID != nil {
+		t.Fatal("deleted key must be removed from the project defaults")
+	}
+
+	if proj.DefaultSSHKeyID == nil || *proj.DefaultSSHKeyID != sshKey.ID {
+		t.Fatal("default SSH key must be kept")
+	}
+}
@@ -0,0 +1,116 @@
+package bolt
+
+import (
+	"sort"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// Pull hosts of all projects are stored in the same bucket, so hosts are found by their tokens.
+
+func (d *BoltDb) CreatePullHost(host db.PullHost) (db.PullHost, error) {
+	if err := host.Validate(); err != nil {
+		return db.PullHost{}, err
+	}
+
+	var newHost db.PullHost
+
+	err := d.db.Update(func(tx kvTx) error {
+		var existing []db.PullHost
+		err := d.getObjectsTx(tx, 0, db.PullHostProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+			h := i.(db.PullHost)
+			return h.ProjectID == host.ProjectID && h.TemplateID == host.TemplateID && h.Name == host.Name
+		}, &existing)
+		if err != nil {
+			return err
+		}
+
+		if len(existing) > 0 {
+			return &db.ValidationError{Message: "pull host with this name already exists"}
+		}
+
+		res, err := d.createObjectTx(tx, 0, db.PullHostProps, host)
+		if err != nil {
+			return err
+		}
+
+		newHost = res.(db.PullHost)
+		return nil
+	})
+
+	return newHost, err
+}
+
+func (d *BoltDb) GetPullHost(projectID int, hostID int) (host db.PullHost, err error) {
+	err = d.getObject(0, db.PullHostProps, intObjectID(hostID), &host)
+	if err == nil && host.ProjectID != projectID {
+		err = db.ErrNotFound
+	}
+	return
+}
+
+func (d *BoltDb) GetPullHosts(projectID int, templateID int) (hosts []db.PullHost, err error) {
+	hosts = make([]db.PullHost, 0)
+	err = d.getObjects(0, db.PullHostProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		h := i.(db.PullHost)
+		return h.ProjectID == projectID && h.TemplateID == templateID
+	}, &hosts)
+
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].Name < hosts[j].Name
+	})
+
+	return
+}
+
+func (d *BoltDb) getPullHostBy(match func(db.PullHost) bool) (host db.PullHost, err error) {
+	var hosts []db.PullHost
+	err = d.getObjects(0, db.PullHostProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		return match(i.(db.PullHost))
+	}, &hosts)
+	if err != nil {
+		return
+	}
+
+	if len(hosts) == 0 {
+		err = db.ErrNotFound
+		return
+	}
+
+	host = hosts[0]
+	return
+}
+
+func (d *BoltDb) GetPullHostByToken(tokenHash string) (db.PullHost, error) {
+	return d.getPullHostBy(func(h db.PullHost) bool {
+		return tokenHash != "" && h.TokenHash == tokenHash
+	})
+}
+
+func (d *BoltDb) GetPullHostByRepositoryToken(tokenHash string) (db.PullHost, error) {
+	return d.getPullHostBy(func(h db.PullHost) bool {
+		return tokenHash != "" && h.RepositoryTokenHash == tokenHash
+	})
+}
+
+func (d *BoltDb) UpdatePullHost(host db.PullHost) error {
+	existing, err := d.GetPullHost(host.ProjectID, host.ID)
+	if err != nil {
+		return err
+	}
+
+	existing.RepositoryTokenHash = host.RepositoryTokenHash
+	existing.RepositoryTokenExpires = host.RepositoryTokenExpires
+	existing.LastReport = host.LastReport
+	existing.LastStatus = host.LastStatus
+	existing.LastTaskID = host.LastTaskID
+
+	return d.updateObject(0, db.PullHostProps, existing)
+}
+
+func (d *BoltDb) DeletePullHost(projectID int, hostID int) error {
+	if _, err := d.GetPullHost(projectID, hostID); err != nil {
+		return err
+	}
+	return d.deleteObject(0, db.PullHostProps, intObjectID(hostID), nil)
+}
@@ -0,0 +1,27 @@
+package bolt
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *BoltDb) CreateRunOutput(output db.RunOutput) (db.RunOutput, error) {
+	newOutput, err := d.createObject(output.TaskID, db.RunOutputProps, output)
+	if err != nil {
+		return db.RunOutput{}, err
+	}
+	return newOutput.(db.RunOutput), nil
+}
+
+func (d *BoltDb) GetRunOutputs(projectID int, taskID int) (outputs []db.RunOutput, err error) {
+	// check if task exists in the project
+	_, err = d.GetTask(projectID, taskID)
+
+	if err != nil {
+		return
+	}
+
+	outputs = make([]db.RunOutput, 0)
+	err = d.getObjects(taskID, db.RunOutputProps, db.RetrieveQueryParams{}, nil, &outputs)
+
+	return
+}
@@ -0,0 +1,46 @@
+package bolt
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *BoltDb) AppendRunRecord(record db.RunRecord) (newRecord db.RunRecord, err error) {
+	err = d.db.Update(func(tx kvTx) error {
+		var prev *db.RunRecord
+
+		// records are stored in the order of the chain
+		if b := tx.Bucket(makeBucketId(db.RunRecordProps, record.ProjectID)); b != nil {
+			if _, data := b.Cursor().Last(); data != nil {
+				var last db.RunRecord
+				if err2 := unmarshalObject(data, &last); err2 != nil {
+					return err2
+				}
+				prev = &last
+			}
+		}
+
+		newRecord = record
+		newRecord.ChainTo(prev)
+
+		res, err2 := d.createObjectTx(tx, record.ProjectID, db.RunRecordProps, newRecord)
+		if err2 != nil {
+			return err2
+		}
+
+		newRecord = res.(db.RunRecord)
+		return nil
+	})
+
+	return
+}
+
+func (d *BoltDb) GetRunRecords(projectID int, params db.RetrieveQueryParams) (records []db.RunRecord, err error) {
+	records = make([]db.RunRecord, 0)
+	err = d.getObjects(projectID, db.RunRecordProps, params, nil, &records)
+	return
+}
+
+func (d *BoltDb) GetRunRecord(projectID int, recordID int) (record db.RunRecord, err error) {
+	err = d.getObject(projectID, db.RunRecordProps, intObjectID(recordID), &record)
+	return
+}
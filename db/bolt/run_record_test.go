@@ -0,0 +1,46 @@
+package bolt
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func TestAppendRunRecord(t *testing.T) {
+	store := CreateTestStore()
+
+	for i := 1; i <= 3; i++ {
+		record, err := db.NewRunRecord(db.Task{ID: i, ProjectID: 1, TemplateID: 1}, db.Template{Name: "Test"}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		record, err = store.AppendRunRecord(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if record.Seq != i {
+			t.Fatal("invalid position of the record", record.Seq)
+		}
+	}
+
+	records, err := store.GetRunRecords(1, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := db.VerifyRunRecordChain(records)
+	if !res.Valid || res.Count != 3 {
+		t.Fatal("chain must be valid", res.Error)
+	}
+
+	record, err := store.GetRunRecord(1, records[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if record.Hash != records[1].Hash || record.PrevHash != records[0].Hash {
+		t.Fatal("invalid record")
+	}
+}
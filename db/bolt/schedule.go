@@ -2,7 +2,7 @@ package bolt
 
 import (
 	"github.com/semaphoreui/semaphore/db"
-	"go.etcd.io/bbolt"
+	"time"
 )
 
 func (d *BoltDb) GetSchedules() (schedules []db.Schedule, err error) {
@@ -78,6 +78,13 @@ func (d *BoltDb) CreateSchedule(schedule db.Schedule) (newSchedule db.Schedule,
 }
 
 func (d *BoltDb) UpdateSchedule(schedule db.Schedule) error {
+	existing, err := d.GetSchedule(schedule.ProjectID, schedule.ID)
+	if err != nil {
+		return err
+	}
+	schedule.LastFired = existing.LastFired
+	schedule.ConsecutiveFailures = existing.ConsecutiveFailures
+	schedule.AutoDisabled = existing.AutoDisabled
 	return d.updateObject(schedule.ProjectID, db.ScheduleProps, schedule)
 }
 
@@ -86,12 +93,33 @@ func (d *BoltDb) GetSchedule(projectID int, scheduleID int) (schedule db.Schedul
 	return
 }
 
-func (d *BoltDb) deleteSchedule(projectID int, scheduleID int, tx *bbolt.Tx) error {
+func (d *BoltDb) GetScheduleLastTask(projectID int, scheduleID int) (task db.Task, err error) {
+	var tasks []db.Task
+
+	err = d.getObjects(0, db.TaskProps, db.RetrieveQueryParams{Count: 1}, func(i interface{}) bool {
+		tsk := i.(db.Task)
+		return tsk.ProjectID == projectID && tsk.ScheduleID != nil && *tsk.ScheduleID == scheduleID
+	}, &tasks)
+
+	if err != nil {
+		return
+	}
+
+	if len(tasks) == 0 {
+		err = db.ErrNotFound
+		return
+	}
+
+	task = tasks[0]
+	return
+}
+
+func (d *BoltDb) deleteSchedule(projectID int, scheduleID int, tx kvTx) error {
 	return d.deleteObject(projectID, db.ScheduleProps, intObjectID(scheduleID), tx)
 }
 
 func (d *BoltDb) DeleteSchedule(projectID int, scheduleID int) error {
-	return d.db.Update(func(tx *bbolt.Tx) error {
+	return d.db.Update(func(tx kvTx) error {
 		return d.deleteSchedule(projectID, scheduleID, tx)
 	})
 }
@@ -102,6 +130,23 @@ func (d *BoltDb) SetScheduleActive(projectID int, scheduleID int, active bool) e
 		return err
 	}
 	schedule.Active = active
+	if active {
+		schedule.ConsecutiveFailures = 0
+		schedule.AutoDisabled = false
+	}
+	return d.updateObject(projectID, db.ScheduleProps, schedule)
+}
+
+func (d *BoltDb) SetScheduleFailures(projectID int, scheduleID int, failures int, autoDisabled bool) error {
+	schedule, err := d.GetSchedule(projectID, scheduleID)
+	if err != nil {
+		return err
+	}
+	schedule.ConsecutiveFailures = failures
+	if autoDisabled {
+		schedule.Active = false
+		schedule.AutoDisabled = true
+	}
 	return d.updateObject(projectID, db.ScheduleProps, schedule)
 }
 
@@ -113,3 +158,12 @@ func (d *BoltDb) SetScheduleCommitHash(projectID int, scheduleID int, hash strin
 	schedule.LastCommitHash = &hash
 	return d.updateObject(projectID, db.ScheduleProps, schedule)
 }
+
+func (d *BoltDb) SetScheduleLastFired(projectID int, scheduleID int, lastFired time.Time) error {
+	schedule, err := d.GetSchedule(projectID, scheduleID)
+	if err != nil {
+		return err
+	}
+	schedule.LastFired = &lastFired
+	return d.updateObject(projectID, db.ScheduleProps, schedule)
+}
@@ -0,0 +1,60 @@
+package bolt
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+var secretBucket = []byte("secret")
+
+// SecretStorage keeps the secrets of access keys in the separate BoltDB file
+// or BadgerDB directory.
+type SecretStorage struct {
+	db kvDB
+}
+
+func OpenSecretStorage(engine string, path string) (*SecretStorage, error) {
+	kv, err := openKV(engine, path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = kv.Update(func(tx kvTx) error {
+		_, err2 := tx.CreateBucketIfNotExists(secretBucket)
+		return err2
+	})
+
+	if err != nil {
+		_ = kv.Close()
+		return nil, err
+	}
+
+	return &SecretStorage{db: kv}, nil
+}
+
+func (s *SecretStorage) GetSecret(id string) (secret string, err error) {
+	err = s.db.View(func(tx kvTx) error {
+		value := tx.Bucket(secretBucket).Get([]byte(id))
+		if value == nil {
+			return db.ErrNotFound
+		}
+		secret = string(value)
+		return nil
+	})
+	return
+}
+
+func (s *SecretStorage) SetSecret(id string, secret string) error {
+	return s.db.Update(func(tx kvTx) error {
+		return tx.Bucket(secretBucket).Put([]byte(id), []byte(secret))
+	})
+}
+
+func (s *SecretStorage) DeleteSecret(id string) error {
+	return s.db.Update(func(tx kvTx) error {
+		return tx.Bucket(secretBucket).Delete([]byte(id))
+	})
+}
+
+func (s *SecretStorage) Close() error {
+	return s.db.Close()
+}
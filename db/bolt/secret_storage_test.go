@@ -0,0 +1,96 @@
+package bolt
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestAccessKeySecretStorage(t *testing.T) {
+	store := CreateTestStore()
+
+	fn := "/tmp/test_semaphore_secrets_" + util.RandString(5)
+	defer os.Remove(fn)
+
+	secrets, err := OpenSecretStorage(EngineBolt, fn)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer secrets.Close()
+
+	db.SetSecretStorage(secrets)
+	defer db.SetSecretStorage(nil)
+
+	proj, err := store.CreateProject(db.Project{
+		Created: time.Now(),
+		Name:    "TestProject",
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	key, err := store.CreateAccessKey(db.AccessKey{
+		Name:      "TestKey",
+		Type:      db.AccessKeyString,
+		String:    "s3cr3t",
+		ProjectID: &proj.ID,
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	key, err = store.GetAccessKey(proj.ID, key.ID)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if !db.IsSecretRef(key.Secret) {
+		t.Fatal("secret must be kept in the secret storage")
+	}
+
+	oldSecret := key.Secret
+
+	if err = key.DeserializeSecret(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if key.String != "s3cr3t" {
+		t.Fatalf("unexpected secret %q", key.String)
+	}
+
+	key.String = "n3w"
+	key.OverrideSecret = true
+
+	if err = store.UpdateAccessKey(key); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err = secrets.GetSecret((*oldSecret)[len("secret-ref:"):]); !errors.Is(err, db.ErrNotFound) {
+		t.Fatal("replaced secret must be removed from the secret storage")
+	}
+
+	key, err = store.GetAccessKey(proj.ID, key.ID)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err = key.DeserializeSecret(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if key.String != "n3w" {
+		t.Fatalf("unexpected secret %q", key.String)
+	}
+
+	if err = store.DeleteAccessKey(proj.ID, key.ID); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err = secrets.GetSecret((*key.Secret)[len("secret-ref:"):]); !errors.Is(err, db.ErrNotFound) {
+		t.Fatal("secret of deleted key must be removed from the secret storage")
+	}
+}
@@ -0,0 +1,55 @@
+package bolt
+
+import (
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *BoltDb) GetServiceAccount(projectID int, accountID int) (account db.ServiceAccount, err error) {
+	err = d.getObject(projectID, db.ServiceAccountProps, intObjectID(accountID), &account)
+	return
+}
+
+func (d *BoltDb) GetServiceAccounts(projectID int) (accounts []db.ServiceAccount, err error) {
+	err = d.getObjects(projectID, db.ServiceAccountProps, db.RetrieveQueryParams{}, nil, &accounts)
+	return
+}
+
+func (d *BoltDb) CreateServiceAccount(account db.ServiceAccount) (db.ServiceAccount, error) {
+	account.Created = time.Now().UTC()
+
+	newAccount, err := d.createObject(account.ProjectID, db.ServiceAccountProps, account)
+	if err != nil {
+		return db.ServiceAccount{}, err
+	}
+	return newAccount.(db.ServiceAccount), nil
+}
+
+func (d *BoltDb) UpdateServiceAccount(account db.ServiceAccount) error {
+	old, err := d.GetServiceAccount(account.ProjectID, account.ID)
+	if err != nil {
+		return err
+	}
+
+	account.TokenHash = old.TokenHash
+	account.Created = old.Created
+	account.UserID = old.UserID
+
+	return d.updateObject(account.ProjectID, db.ServiceAccountProps, account)
+}
+
+func (d *BoltDb) SetServiceAccountToken(projectID int, accountID int, tokenHash string) error {
+	account, err := d.GetServiceAccount(projectID, accountID)
+	if err != nil {
+		return err
+	}
+
+	account.TokenHash = tokenHash
+
+	return d.updateObject(projectID, db.ServiceAccountProps, account)
+}
+
+func (d *BoltDb) DeleteServiceAccount(projectID int, accountID int) error {
+	return d.deleteObject(projectID, db.ServiceAccountProps, intObjectID(accountID), nil)
+}
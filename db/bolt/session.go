@@ -104,3 +104,31 @@ func (d *BoltDb) GetAPITokens(userID int) (tokens []db.APIToken, err error) {
 	err = d.getObjects(userID, db.TokenProps, db.RetrieveQueryParams{}, nil, &tokens)
 	return
 }
+
+func (d *BoltDb) DeleteInactiveSessions(lastActiveBefore time.Time) (count int, err error) {
+	users, err := d.GetUsers(db.RetrieveQueryParams{})
+	if err != nil {
+		return
+	}
+
+	for _, user := range users {
+		var sessions []db.Session
+		err = d.getObjects(user.ID, db.SessionProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+			session := i.(db.Session)
+			return session.Expired || session.LastActive.Before(lastActiveBefore)
+		}, &sessions)
+		if err != nil {
+			return
+		}
+
+		for _, session := range sessions {
+			err = d.deleteObject(user.ID, db.SessionProps, intObjectID(session.ID), nil)
+			if err != nil {
+				return
+			}
+			count++
+		}
+	}
+
+	return
+}
@@ -2,7 +2,7 @@ package bolt
 
 import (
 	"github.com/semaphoreui/semaphore/db"
-	"go.etcd.io/bbolt"
+	"slices"
 	"time"
 )
 
@@ -65,7 +65,7 @@ func (d *BoltDb) clearTasks(projectID int, templateID int, maxTasks int) {
 
 	i := 0
 
-	_ = d.db.Update(func(tx *bbolt.Tx) error {
+	_ = d.db.Update(func(tx kvTx) error {
 		b := tx.Bucket(makeBucketId(db.TaskProps, projectID))
 		if b == nil {
 			return db.ErrNotFound
@@ -116,6 +116,17 @@ func (d *BoltDb) CreateTaskOutput(output db.TaskOutput) (db.TaskOutput, error) {
 	return newOutput.(db.TaskOutput), nil
 }
 
+func (d *BoltDb) CreateTaskOutputs(outputs []db.TaskOutput) error {
+	return d.db.Update(func(tx kvTx) error {
+		for _, output := range outputs {
+			if _, err := d.createObjectTx(tx, output.TaskID, db.TaskOutputProps, output); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (d *BoltDb) getTasks(projectID int, templateID *int, params db.RetrieveQueryParams) (tasksWithTpl []db.TaskWithTpl, err error) {
 	var tasks []db.Task
 
@@ -130,6 +141,10 @@ func (d *BoltDb) getTasks(projectID int, templateID *int, params db.RetrieveQuer
 			return false
 		}
 
+		if params.Filter != "" && string(task.Status) != params.Filter {
+			return false
+		}
+
 		return true
 	}, &tasks)
 
@@ -137,6 +152,11 @@ func (d *BoltDb) getTasks(projectID int, templateID *int, params db.RetrieveQuer
 		return
 	}
 
+	return d.getTasksWithTpl(tasks)
+}
+
+// getTasksWithTpl adds fields of templates and users to the tasks.
+func (d *BoltDb) getTasksWithTpl(tasks []db.Task) (tasksWithTpl []db.TaskWithTpl, err error) {
 	var templates = make(map[int]db.Template)
 	var users = make(map[int]db.User)
 
@@ -144,11 +164,7 @@ func (d *BoltDb) getTasks(projectID int, templateID *int, params db.RetrieveQuer
 	for i, task := range tasks {
 		tpl, ok := templates[task.TemplateID]
 		if !ok {
-			if templateID == nil {
-				tpl, _ = d.getRawTemplate(task.ProjectID, task.TemplateID)
-			} else {
-				tpl, _ = d.getRawTemplate(task.ProjectID, *templateID)
-			}
+			tpl, _ = d.getRawTemplate(task.ProjectID, task.TemplateID)
 			templates[task.TemplateID] = tpl
 		}
 		tasksWithTpl[i] = db.TaskWithTpl{Task: task}
@@ -195,11 +211,97 @@ func (d *BoltDb) GetTemplateTasks(projectID int, templateID int, params db.Retri
 	return d.getTasks(projectID, &templateID, params)
 }
 
+func (d *BoltDb) GetFinishedTasksCreatedBefore(before time.Time, limit int) (tasks []db.Task, err error) {
+	err = d.getObjects(0, db.TaskProps, db.RetrieveQueryParams{Count: limit}, func(i interface{}) bool {
+		task := i.(db.Task)
+		return task.Status.IsFinished() && task.Created.Before(before)
+	}, &tasks)
+	return
+}
+
+func (d *BoltDb) GetUnfinishedTasks() (tasks []db.Task, err error) {
+	err = d.getObjects(0, db.TaskProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		return !i.(db.Task).Status.IsFinished()
+	}, &tasks)
+	return
+}
+
+func (d *BoltDb) ClaimTask(taskID int, nodeID string) (bool, error) {
+	var task db.Task
+	err := d.getObject(0, db.TaskProps, intObjectID(taskID), &task)
+	if err != nil {
+		return false, err
+	}
+
+	if task.ClaimedBy != nil {
+		return *task.ClaimedBy == nodeID, nil
+	}
+
+	task.ClaimedBy = &nodeID
+	return true, d.updateObject(0, db.TaskProps, task)
+}
+
+func (d *BoltDb) SetTaskOutputObject(projectID int, taskID int, objectKey string) error {
+	task, err := d.GetTask(projectID, taskID)
+	if err != nil {
+		return err
+	}
+
+	task.OutputObject = &objectKey
+
+	return d.db.Update(func(tx kvTx) error {
+		err := d.updateObjectTx(tx, 0, db.TaskProps, task)
+		if err != nil {
+			return err
+		}
+
+		err = tx.DeleteBucket(makeBucketId(db.TaskOutputProps, taskID))
+		if err == errBucketNotFound {
+			err = nil
+		}
+		return err
+	})
+}
+
+func (d *BoltDb) GetProjectScheduledTasks(projectID int, from time.Time, to time.Time) (tasks []db.Task, err error) {
+	err = d.getObjects(0, db.TaskProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		task := i.(db.Task)
+		return task.ProjectID == projectID &&
+			task.ScheduleID != nil &&
+			task.End != nil &&
+			!task.End.Before(from) &&
+			task.End.Before(to)
+	}, &tasks)
+
+	// tasks are stored in the reverse order
+	slices.Reverse(tasks)
+	return
+}
+
+func (d *BoltDb) GetProjectTaskHistory(projectID int, from time.Time, to time.Time) ([]db.TaskWithTpl, error) {
+	var tasks []db.Task
+
+	err := d.getObjects(0, db.TaskProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		task := i.(db.Task)
+		return task.ProjectID == projectID &&
+			!task.Created.Before(from) &&
+			task.Created.Before(to)
+	}, &tasks)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// tasks are stored in the reverse order
+	slices.Reverse(tasks)
+	return d.getTasksWithTpl(tasks)
+}
+
 func (d *BoltDb) GetProjectTasks(projectID int, params db.RetrieveQueryParams) ([]db.TaskWithTpl, error) {
 	return d.getTasks(projectID, nil, params)
 }
 
-func (d *BoltDb) deleteTaskWithOutputs(projectID int, taskID int, checkTaskExisting bool, tx *bbolt.Tx) (err error) {
+func (d *BoltDb) deleteTaskWithOutputs(projectID int, taskID int, checkTaskExisting bool, tx kvTx) (err error) {
 
 	if checkTaskExisting {
 		_, err = d.GetTask(projectID, taskID)
@@ -214,7 +316,52 @@ func (d *BoltDb) deleteTaskWithOutputs(projectID int, taskID int, checkTaskExist
 	}
 
 	err = tx.DeleteBucket(makeBucketId(db.TaskOutputProps, taskID))
-	if err == bbolt.ErrBucketNotFound {
+	if err == errBucketNotFound {
+		err = nil
+	}
+
+	if err != nil {
+		return
+	}
+
+	err = tx.DeleteBucket(makeBucketId(db.TaskFindingProps, taskID))
+	if err == errBucketNotFound {
+		err = nil
+	}
+
+	if err != nil {
+		return
+	}
+
+	err = tx.DeleteBucket(makeBucketId(db.RunOutputProps, taskID))
+	if err == errBucketNotFound {
+		err = nil
+	}
+
+	if err != nil {
+		return
+	}
+
+	err = tx.DeleteBucket(makeBucketId(db.TaskArtifactProps, taskID))
+	if err == errBucketNotFound {
+		err = nil
+	}
+
+	if err != nil {
+		return
+	}
+
+	err = tx.DeleteBucket(makeBucketId(db.TaskObjectVersionProps, taskID))
+	if err == errBucketNotFound {
+		err = nil
+	}
+
+	if err != nil {
+		return
+	}
+
+	err = tx.DeleteBucket(makeBucketId(db.TerraformPlanChangeProps, taskID))
+	if err == errBucketNotFound {
 		err = nil
 	}
 
@@ -222,7 +369,7 @@ func (d *BoltDb) deleteTaskWithOutputs(projectID int, taskID int, checkTaskExist
 }
 
 func (d *BoltDb) DeleteTaskWithOutputs(projectID int, taskID int) error {
-	return d.db.Update(func(tx *bbolt.Tx) error {
+	return d.db.Update(func(tx kvTx) error {
 		return d.deleteTaskWithOutputs(projectID, taskID, true, tx)
 	})
 }
@@ -239,3 +386,18 @@ func (d *BoltDb) GetTaskOutputs(projectID int, taskID int) (outputs []db.TaskOut
 
 	return
 }
+
+func (d *BoltDb) GetTaskOutputsPage(projectID int, taskID int, params db.RetrieveQueryParams) (outputs []db.TaskOutput, err error) {
+	_, err = d.GetTask(projectID, taskID)
+
+	if err != nil {
+		return
+	}
+
+	err = d.getObjects(taskID, db.TaskOutputProps, db.RetrieveQueryParams{
+		Offset: params.Offset,
+		Count:  params.Count,
+	}, nil, &outputs)
+
+	return
+}
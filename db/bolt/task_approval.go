@@ -0,0 +1,50 @@
+package bolt
+
+import (
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *BoltDb) CreateTaskApproval(approval db.TaskApproval) (db.TaskApproval, error) {
+	newApproval, err := d.createObject(approval.ProjectID, db.TaskApprovalProps, approval)
+	if err != nil {
+		return db.TaskApproval{}, err
+	}
+	return newApproval.(db.TaskApproval), nil
+}
+
+func (d *BoltDb) GetTaskApproval(projectID int, approvalID int) (approval db.TaskApproval, err error) {
+	err = d.getObject(projectID, db.TaskApprovalProps, intObjectID(approvalID), &approval)
+	return
+}
+
+func (d *BoltDb) UseTaskApproval(projectID int, approvalID int, decision db.TaskApprovalDecision, used time.Time) error {
+	// the approval is read and updated in the same transaction, so the link can be used once
+	return d.db.Update(func(tx kvTx) error {
+		b := tx.Bucket(makeBucketId(db.TaskApprovalProps, projectID))
+		if b == nil {
+			return db.ErrNotFound
+		}
+
+		data := b.Get(intObjectID(approvalID).ToBytes())
+		if data == nil {
+			return db.ErrNotFound
+		}
+
+		var approval db.TaskApproval
+		if err := unmarshalObject(data, &approval); err != nil {
+			return err
+		}
+
+		if approval.Used != nil {
+			return db.ErrNotFound
+		}
+
+		used = used.UTC()
+		approval.Used = &used
+		approval.Decision = decision
+
+		return d.updateObjectTx(tx, projectID, db.TaskApprovalProps, approval)
+	})
+}
@@ -0,0 +1,27 @@
+package bolt
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *BoltDb) CreateTaskArtifact(artifact db.TaskArtifact) (db.TaskArtifact, error) {
+	newArtifact, err := d.createObject(artifact.TaskID, db.TaskArtifactProps, artifact)
+	if err != nil {
+		return db.TaskArtifact{}, err
+	}
+	return newArtifact.(db.TaskArtifact), nil
+}
+
+func (d *BoltDb) GetTaskArtifacts(projectID int, taskID int) (artifacts []db.TaskArtifact, err error) {
+	// check if task exists in the project
+	_, err = d.GetTask(projectID, taskID)
+
+	if err != nil {
+		return
+	}
+
+	artifacts = make([]db.TaskArtifact, 0)
+	err = d.getObjects(taskID, db.TaskArtifactProps, db.RetrieveQueryParams{}, nil, &artifacts)
+
+	return
+}
@@ -0,0 +1,28 @@
+package bolt
+
+import "github.com/semaphoreui/semaphore/db"
+
+func (d *BoltDb) GetTaskComment(projectID int, commentID int) (comment db.TaskComment, err error) {
+	err = d.getObject(projectID, db.TaskCommentProps, intObjectID(commentID), &comment)
+	return
+}
+
+func (d *BoltDb) GetTaskComments(projectID int, filter db.TaskCommentFilter) (comments []db.TaskComment, err error) {
+	comments = make([]db.TaskComment, 0)
+	err = d.getObjects(projectID, db.TaskCommentProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		return filter.Match(i.(db.TaskComment))
+	}, &comments)
+	return
+}
+
+func (d *BoltDb) CreateTaskComment(comment db.TaskComment) (db.TaskComment, error) {
+	newComment, err := d.createObject(comment.ProjectID, db.TaskCommentProps, comment)
+	if err != nil {
+		return db.TaskComment{}, err
+	}
+	return newComment.(db.TaskComment), nil
+}
+
+func (d *BoltDb) DeleteTaskComment(projectID int, commentID int) error {
+	return d.deleteObject(projectID, db.TaskCommentProps, intObjectID(commentID), nil)
+}
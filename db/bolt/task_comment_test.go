@@ -0,0 +1,48 @@
+package bolt
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func TestGetTaskComments(t *testing.T) {
+	store := CreateTestStore()
+
+	for _, c := range []db.TaskComment{
+		{ProjectID: 1, TaskID: 1, Label: "reverted", Comment: "Caused incident #42"},
+		{ProjectID: 1, TaskID: 2, Comment: "Slow run"},
+		{ProjectID: 2, TaskID: 3, Label: "reverted"},
+	} {
+		if _, err := store.CreateTaskComment(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	comments, err := store.GetTaskComments(1, db.TaskCommentFilter{Label: "Reverted"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(comments) != 1 || comments[0].TaskID != 1 {
+		t.Fatal("invalid comments filtered by label")
+	}
+
+	comments, err = store.GetTaskComments(1, db.TaskCommentFilter{Query: "incident"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(comments) != 1 || comments[0].TaskID != 1 {
+		t.Fatal("invalid comments filtered by query")
+	}
+
+	comments, err = store.GetTaskComments(1, db.TaskCommentFilter{TaskIDs: []int{2, 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(comments) != 1 || comments[0].TaskID != 2 {
+		t.Fatal("invalid comments filtered by tasks")
+	}
+}
@@ -0,0 +1,27 @@
+package bolt
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *BoltDb) CreateTaskFinding(finding db.TaskFinding) (db.TaskFinding, error) {
+	newFinding, err := d.createObject(finding.TaskID, db.TaskFindingProps, finding)
+	if err != nil {
+		return db.TaskFinding{}, err
+	}
+	return newFinding.(db.TaskFinding), nil
+}
+
+func (d *BoltDb) GetTaskFindings(projectID int, taskID int) (findings []db.TaskFinding, err error) {
+	// check if task exists in the project
+	_, err = d.GetTask(projectID, taskID)
+
+	if err != nil {
+		return
+	}
+
+	findings = make([]db.TaskFinding, 0)
+	err = d.getObjects(taskID, db.TaskFindingProps, db.RetrieveQueryParams{}, nil, &findings)
+
+	return
+}
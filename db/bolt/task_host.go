@@ -0,0 +1,38 @@
+package bolt
+
+import (
+	"sort"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *BoltDb) CreateTaskHosts(hosts []db.TaskHost) error {
+	for _, h := range hosts {
+		h.Finished = h.Finished.UTC()
+
+		if _, err := d.createObject(h.ProjectID, db.TaskHostProps, h); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *BoltDb) GetTaskHosts(projectID int, filter db.TaskHostFilter) (hosts []db.TaskHost, err error) {
+	hosts = make([]db.TaskHost, 0)
+	err = d.getObjects(projectID, db.TaskHostProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		return filter.Match(i.(db.TaskHost))
+	}, &hosts)
+	if err != nil {
+		return
+	}
+
+	sort.SliceStable(hosts, func(i, j int) bool {
+		if hosts[i].Finished.Equal(hosts[j].Finished) {
+			return hosts[i].ID < hosts[j].ID
+		}
+		return hosts[i].Finished.Before(hosts[j].Finished)
+	})
+
+	return
+}
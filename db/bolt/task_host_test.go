@@ -0,0 +1,56 @@
+package bolt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+func TestGetTaskHosts(t *testing.T) {
+	store := CreateTestStore()
+
+	monday := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	tuesday := monday.AddDate(0, 0, 1)
+
+	groups := map[string][]string{"db-03": {"db", "prod"}, "web-01": {"prod", "web"}}
+
+	for i, end := range []time.Time{tuesday, monday} {
+		task := db.Task{ID: i + 1, ProjectID: 1, TemplateID: 1, Status: task_logger.TaskSuccessStatus, End: &end}
+
+		hosts := db.NewTaskHosts(task, map[string]db.TaskHostStatus{
+			"db-03":  db.TaskHostChanged,
+			"web-01": db.TaskHostOK,
+		}, groups)
+
+		if err := store.CreateTaskHosts(hosts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hosts, err := store.GetTaskHosts(1, db.TaskHostFilter{Host: "db-03"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hosts) != 2 || hosts[0].TaskID != 2 || hosts[1].TaskID != 1 {
+		t.Fatal("results of the host must be ordered by time", hosts)
+	}
+
+	if hosts[0].Status != db.TaskHostChanged || !hosts[0].Groups.Contains("prod") {
+		t.Fatal("invalid result of the host", hosts[0])
+	}
+
+	from := tuesday.Truncate(24 * time.Hour)
+	to := from.AddDate(0, 0, 1)
+
+	hosts, err = store.GetTaskHosts(1, db.TaskHostFilter{Group: "web", From: &from, To: &to})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hosts) != 1 || hosts[0].Host != "web-01" || hosts[0].TaskID != 1 {
+		t.Fatal("only the result of the group on tuesday must be returned", hosts)
+	}
+}
@@ -0,0 +1,140 @@
+package bolt
+
+import (
+	"sort"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *BoltDb) CreateTaskMetric(metric db.TaskMetric) (db.TaskMetric, error) {
+	metric.Finished = metric.Finished.UTC()
+
+	newMetric, err := d.createObject(metric.ProjectID, db.TaskMetricProps, metric)
+	if err != nil {
+		return db.TaskMetric{}, err
+	}
+	return newMetric.(db.TaskMetric), nil
+}
+
+func (d *BoltDb) GetTaskMetrics(projectID int, filter db.TaskMetricFilter) (metrics []db.TaskMetric, err error) {
+	metrics = make([]db.TaskMetric, 0)
+	err = d.getObjects(projectID, db.TaskMetricProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		metric := i.(db.TaskMetric)
+		return filter.Match(metric.TemplateID, metric.Finished)
+	}, &metrics)
+	return
+}
+
+func (d *BoltDb) GetTaskMetricsBefore(before time.Time, limit int) (metrics []db.TaskMetric, err error) {
+	projects, err := d.GetAllProjects()
+	if err != nil {
+		return
+	}
+
+	metrics = make([]db.TaskMetric, 0)
+
+	for _, project := range projects {
+		var projectMetrics []db.TaskMetric
+		err = d.getObjects(project.ID, db.TaskMetricProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+			return i.(db.TaskMetric).Finished.Before(before)
+		}, &projectMetrics)
+		if err != nil {
+			return
+		}
+
+		metrics = append(metrics, projectMetrics...)
+	}
+
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].Finished.Before(metrics[j].Finished)
+	})
+
+	if limit > 0 && len(metrics) > limit {
+		metrics = metrics[:limit]
+	}
+
+	return
+}
+
+func (d *BoltDb) RollupTaskMetrics(metrics []db.TaskMetric) error {
+	return d.db.Update(func(tx kvTx) error {
+		for _, rollup := range db.RollupTaskMetrics(metrics) {
+			var existing []db.TaskMetricDaily
+			err := d.getObjectsTx(tx, rollup.ProjectID, db.TaskMetricDailyProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+				r := i.(db.TaskMetricDaily)
+				return r.TemplateID == rollup.TemplateID && r.Day.Equal(rollup.Day)
+			}, &existing)
+			if err != nil {
+				return err
+			}
+
+			if len(existing) > 0 {
+				existing[0].Merge(rollup)
+				err = d.updateObjectTx(tx, rollup.ProjectID, db.TaskMetricDailyProps, existing[0])
+			} else {
+				_, err = d.createObjectTx(tx, rollup.ProjectID, db.TaskMetricDailyProps, rollup)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, metric := range metrics {
+			b := tx.Bucket(makeBucketId(db.TaskMetricProps, metric.ProjectID))
+			if b == nil {
+				continue
+			}
+
+			if err := b.Delete(intObjectID(metric.ID).ToBytes()); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (d *BoltDb) GetTaskMetricsDaily(projectID int, filter db.TaskMetricFilter) (rollups []db.TaskMetricDaily, err error) {
+	rollups = make([]db.TaskMetricDaily, 0)
+	err = d.getObjects(projectID, db.TaskMetricDailyProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		rollup := i.(db.TaskMetricDaily)
+		return filter.Match(rollup.TemplateID, rollup.Day)
+	}, &rollups)
+
+	sort.SliceStable(rollups, func(i, j int) bool {
+		if !rollups[i].Day.Equal(rollups[j].Day) {
+			return rollups[i].Day.Before(rollups[j].Day)
+		}
+		return rollups[i].TemplateID < rollups[j].TemplateID
+	})
+
+	return
+}
+
+func (d *BoltDb) DeleteTaskMetricsDailyBefore(before time.Time) (removed int, err error) {
+	projects, err := d.GetAllProjects()
+	if err != nil {
+		return
+	}
+
+	for _, project := range projects {
+		var rollups []db.TaskMetricDaily
+		err = d.getObjects(project.ID, db.TaskMetricDailyProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+			return i.(db.TaskMetricDaily).Day.Before(before)
+		}, &rollups)
+		if err != nil {
+			return
+		}
+
+		for _, rollup := range rollups {
+			if err = d.deleteObject(project.ID, db.TaskMetricDailyProps, intObjectID(rollup.ID), nil); err != nil {
+				return
+			}
+			removed++
+		}
+	}
+
+	return
+}
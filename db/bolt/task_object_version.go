@@ -0,0 +1,23 @@
+package bolt
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *BoltDb) CreateTaskObjectVersions(versions []db.TaskObjectVersion) error {
+	for _, v := range versions {
+		if _, err := d.createObject(v.TaskID, db.TaskObjectVersionProps, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *BoltDb) GetTaskObjectVersions(projectID int, taskID int) (versions []db.TaskObjectVersion, err error) {
+	versions = make([]db.TaskObjectVersion, 0)
+	err = d.getObjects(taskID, db.TaskObjectVersionProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		return i.(db.TaskObjectVersion).ProjectID == projectID
+	}, &versions)
+	return
+}
@@ -0,0 +1,32 @@
+package bolt
+
+import "github.com/semaphoreui/semaphore/db"
+
+func (d *BoltDb) GetTaskShareLink(projectID int, linkID int) (link db.TaskShareLink, err error) {
+	err = d.getObject(projectID, db.TaskShareLinkProps, intObjectID(linkID), &link)
+	return
+}
+
+func (d *BoltDb) GetTaskShareLinks(projectID int, taskID int) (links []db.TaskShareLink, err error) {
+	links = make([]db.TaskShareLink, 0)
+	err = d.getObjects(projectID, db.TaskShareLinkProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		return i.(db.TaskShareLink).TaskID == taskID
+	}, &links)
+	return
+}
+
+func (d *BoltDb) CreateTaskShareLink(link db.TaskShareLink) (db.TaskShareLink, error) {
+	if err := link.Validate(); err != nil {
+		return db.TaskShareLink{}, err
+	}
+
+	newLink, err := d.createObject(link.ProjectID, db.TaskShareLinkProps, link)
+	if err != nil {
+		return db.TaskShareLink{}, err
+	}
+	return newLink.(db.TaskShareLink), nil
+}
+
+func (d *BoltDb) DeleteTaskShareLink(projectID int, linkID int) error {
+	return d.deleteObject(projectID, db.TaskShareLinkProps, intObjectID(linkID), nil)
+}
@@ -3,9 +3,10 @@ package bolt
 import (
 	"encoding/json"
 	"errors"
+	"slices"
+	"time"
 
 	"github.com/semaphoreui/semaphore/db"
-	"go.etcd.io/bbolt"
 )
 
 func (d *BoltDb) CreateTemplate(template db.Template) (newTemplate db.Template, err error) {
@@ -48,9 +49,11 @@ func (d *BoltDb) GetTemplates(projectID int, filter db.TemplateFilter, params db
 	var ftr = func(tpl interface{}) bool {
 		template := tpl.(db.Template)
 		var res = true
+		template.FillDefaults()
 		if filter.ViewID != nil {
-			res = res && template.ViewID != nil && *template.ViewID == *filter.ViewID
+			res = res && slices.Contains(template.ViewIDs, *filter.ViewID)
 		}
+		res = res && filter.Match(template)
 		if filter.BuildTemplateID != nil {
 			res = res && template.BuildTemplateID != nil && *template.BuildTemplateID == *filter.BuildTemplateID
 			if filter.AutorunOnly {
@@ -69,6 +72,7 @@ func (d *BoltDb) GetTemplates(projectID int, filter db.TemplateFilter, params db
 	templatesMap := make(map[int]*db.Template)
 
 	for i := 0; i < len(templates); i++ {
+		templates[i].FillDefaults()
 
 		if templates[i].SurveyVarsJSON != nil {
 			err = json.Unmarshal([]byte(*templates[i].SurveyVarsJSON), &templates[i].SurveyVars)
@@ -125,8 +129,31 @@ func (d *BoltDb) GetTemplates(projectID int, filter db.TemplateFilter, params db
 	return
 }
 
+func (d *BoltDb) GetTemplatesStats(projectID int) (stats []db.TemplateStats, err error) {
+	summaries, err := d.GetTemplateSummaries(projectID)
+	if err != nil {
+		return
+	}
+
+	schedules, err := d.getProjectSchedules(projectID, func(s db.Schedule) bool {
+		return s.Active
+	})
+	if err != nil {
+		return
+	}
+
+	scheduleCounts := make(map[int]int)
+	for _, schedule := range schedules {
+		scheduleCounts[schedule.TemplateID]++
+	}
+
+	stats = db.MakeTemplatesStats(summaries, scheduleCounts, time.Now())
+	return
+}
+
 func (d *BoltDb) getRawTemplate(projectID int, templateID int) (template db.Template, err error) {
 	err = d.getObject(projectID, db.TemplateProps, intObjectID(templateID), &template)
+	template.FillDefaults()
 	return
 }
 
@@ -139,7 +166,7 @@ func (d *BoltDb) GetTemplate(projectID int, templateID int) (template db.Templat
 	return
 }
 
-func (d *BoltDb) deleteTemplate(projectID int, templateID int, tx *bbolt.Tx) (err error) {
+func (d *BoltDb) deleteTemplate(projectID int, templateID int, tx kvTx) (err error) {
 	inUse, err := d.isObjectInUse(projectID, db.TemplateProps, intObjectID(templateID), db.TemplateProps)
 
 	if err != nil {
@@ -184,6 +211,21 @@ func (d *BoltDb) deleteTemplate(projectID int, templateID int, tx *bbolt.Tx) (er
 		}
 	}
 
+	err = d.deleteTemplateUploads(projectID, templateID, tx)
+	if err != nil {
+		return
+	}
+
+	err = d.deleteTemplateDoc(projectID, templateID, tx)
+	if err != nil {
+		return
+	}
+
+	err = d.deleteTemplateSummary(projectID, templateID, tx)
+	if err != nil {
+		return
+	}
+
 	integrations, err := d.GetIntegrations(projectID, db.RetrieveQueryParams{})
 	if err != nil {
 		return
@@ -200,7 +242,7 @@ func (d *BoltDb) deleteTemplate(projectID int, templateID int, tx *bbolt.Tx) (er
 }
 
 func (d *BoltDb) DeleteTemplate(projectID int, templateID int) error {
-	return d.db.Update(func(tx *bbolt.Tx) error {
+	return d.db.Update(func(tx kvTx) error {
 		return d.deleteTemplate(projectID, templateID, tx)
 	})
 }
@@ -0,0 +1,37 @@
+package bolt
+
+import (
+	"errors"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *BoltDb) GetTemplateDoc(projectID int, templateID int) (doc db.TemplateDoc, err error) {
+	err = d.getObject(projectID, db.TemplateDocProps, intObjectID(templateID), &doc)
+	return
+}
+
+func (d *BoltDb) SetTemplateDoc(doc db.TemplateDoc) error {
+	// check if template exists in the project
+	if _, err := d.GetTemplate(doc.ProjectID, doc.TemplateID); err != nil {
+		return err
+	}
+
+	_, err := d.GetTemplateDoc(doc.ProjectID, doc.TemplateID)
+
+	if errors.Is(err, db.ErrNotFound) {
+		_, err = d.createObject(doc.ProjectID, db.TemplateDocProps, doc)
+	} else if err == nil {
+		err = d.updateObject(doc.ProjectID, db.TemplateDocProps, doc)
+	}
+
+	return err
+}
+
+func (d *BoltDb) deleteTemplateDoc(projectID int, templateID int, tx kvTx) error {
+	err := d.deleteObject(projectID, db.TemplateDocProps, intObjectID(templateID), tx)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,40 @@
+package bolt
+
+import (
+	"errors"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *BoltDb) GetTemplateSummary(projectID int, templateID int) (summary db.TemplateSummary, err error) {
+	err = d.getObject(projectID, db.TemplateSummaryProps, intObjectID(templateID), &summary)
+	return
+}
+
+func (d *BoltDb) GetTemplateSummaries(projectID int) (summaries []db.TemplateSummary, err error) {
+	summaries = []db.TemplateSummary{}
+	err = d.getObjects(projectID, db.TemplateSummaryProps, db.RetrieveQueryParams{}, nil, &summaries)
+	return
+}
+
+func (d *BoltDb) SetTemplateSummary(summary db.TemplateSummary) error {
+	summary.Updated = summary.Updated.UTC()
+
+	_, err := d.GetTemplateSummary(summary.ProjectID, summary.TemplateID)
+
+	if errors.Is(err, db.ErrNotFound) {
+		_, err = d.createObject(summary.ProjectID, db.TemplateSummaryProps, summary)
+	} else if err == nil {
+		err = d.updateObject(summary.ProjectID, db.TemplateSummaryProps, summary)
+	}
+
+	return err
+}
+
+func (d *BoltDb) deleteTemplateSummary(projectID int, templateID int, tx kvTx) error {
+	err := d.deleteObject(projectID, db.TemplateSummaryProps, intObjectID(templateID), tx)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil
+	}
+	return err
+}
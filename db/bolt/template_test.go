@@ -0,0 +1,224 @@
+package bolt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+func TestGetTemplatesByTagsAndViews(t *testing.T) {
+	store := CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{
+		Created: time.Now(),
+		Name:    "TestProject",
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	view1, err := store.CreateView(db.View{ProjectID: proj.ID, Title: "View1"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	view2, err := store.CreateView(db.View{ProjectID: proj.ID, Title: "View2"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tpl1, err := store.CreateTemplate(db.Template{
+		ProjectID: proj.ID,
+		Name:      "Deploy web",
+		Playbook:  "web.yml",
+		Tags:      []string{"prod", " web ", "prod"},
+		ViewIDs:   []int{view2.ID, view1.ID},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, err = store.CreateTemplate(db.Template{
+		ProjectID: proj.ID,
+		Name:      "Deploy db",
+		Playbook:  "db.yml",
+		Tags:      []string{"prod"},
+		ViewID:    &view1.ID,
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tpl, err := store.GetTemplate(proj.ID, tpl1.ID)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(tpl.Tags) != 2 || tpl.Tags[0] != "prod" || tpl.Tags[1] != "web" {
+		t.Fatalf("unexpected tags %v", tpl.Tags)
+	}
+
+	if tpl.ViewID == nil || *tpl.ViewID != view2.ID || len(tpl.ViewIDs) != 2 {
+		t.Fatalf("unexpected views %v", tpl.ViewIDs)
+	}
+
+	found, err := store.GetTemplates(proj.ID, db.TemplateFilter{ViewID: &view1.ID}, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(found) != 2 {
+		t.Fatalf("expected 2 templates in view, got %d", len(found))
+	}
+
+	found, err = store.GetTemplates(proj.ID, db.TemplateFilter{ViewID: &view2.ID}, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(found) != 1 || found[0].ID != tpl1.ID {
+		t.Fatalf("expected 1 template in view, got %d", len(found))
+	}
+
+	found, err = store.GetTemplates(proj.ID, db.TemplateFilter{Tags: []string{"prod", "web"}}, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(found) != 1 || found[0].ID != tpl1.ID {
+		t.Fatalf("expected 1 template with tags, got %d", len(found))
+	}
+
+	found, err = store.GetTemplates(proj.ID, db.TemplateFilter{Search: "DB"}, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(found) != 1 || found[0].Name != "Deploy db" {
+		t.Fatalf("expected 1 template by search, got %d", len(found))
+	}
+}
+
+func TestGetTemplatesStats(t *testing.T) {
+	store := CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{
+		Created: time.Now(),
+		Name:    "TestProject",
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tpl1, err := store.CreateTemplate(db.Template{ProjectID: proj.ID, Name: "Deploy", Playbook: "deploy.yml"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tpl2, err := store.CreateTemplate(db.Template{ProjectID: proj.ID, Name: "Backup", Playbook: "backup.yml"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, err = store.CreateTemplate(db.Template{ProjectID: proj.ID, Name: "Unused", Playbook: "unused.yml"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	now := time.Now()
+	summary := db.TemplateSummary{ProjectID: proj.ID, TemplateID: tpl1.ID}
+
+	for _, status := range []task_logger.TaskStatus{task_logger.TaskSuccessStatus, task_logger.TaskFailStatus} {
+		start := now.Add(-time.Minute)
+		task, err := store.CreateTask(db.Task{ProjectID: proj.ID, TemplateID: tpl1.ID, Status: status, Start: &start, End: &now}, 0)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		summary.ApplyTask(task, true)
+		summary.ApplyFinishedTask(task, now)
+	}
+
+	if err = store.SetTemplateSummary(summary); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, err = store.CreateSchedule(db.Schedule{ProjectID: proj.ID, TemplateID: tpl2.ID, CronFormat: "* * * * *", Active: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	stats, err := store.GetTemplatesStats(proj.ID)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(stats) != 2 {
+		t.Fatalf("unexpected stats %v", stats)
+	}
+
+	if stats[0].TemplateID != tpl1.ID || stats[0].TaskCount != 2 || stats[0].ScheduleCount != 0 ||
+		stats[0].LastTaskStatus == nil || *stats[0].LastTaskStatus != task_logger.TaskFailStatus {
+		t.Fatalf("unexpected stats of the first template %v", stats[0])
+	}
+
+	if stats[0].SuccessRate == nil || *stats[0].SuccessRate != 0.5 || stats[0].AverageDuration != 60 || stats[0].LastSuccess == nil {
+		t.Fatalf("unexpected 30 days stats of the first template %v", stats[0])
+	}
+
+	if stats[1].TemplateID != tpl2.ID || stats[1].TaskCount != 0 || stats[1].ScheduleCount != 1 || stats[1].LastTaskID != nil {
+		t.Fatalf("unexpected stats of the second template %v", stats[1])
+	}
+}
+
+func TestSetTemplateDoc(t *testing.T) {
+	store := CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{
+		Created: time.Now(),
+		Name:    "TestProject",
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tpl, err := store.CreateTemplate(db.Template{
+		ProjectID: proj.ID,
+		Name:      "Deploy web",
+		Playbook:  "web.yml",
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err = store.GetTemplateDoc(proj.ID, tpl.ID); err != db.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	for _, tag := range []string{"deploy", "restart"} {
+		err = store.SetTemplateDoc(db.TemplateDoc{
+			TemplateID: tpl.ID,
+			ProjectID:  proj.ID,
+			Playbook:   "web.yml",
+			Updated:    time.Now(),
+			Content:    db.TemplateDocContent{Tags: []string{tag}},
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	doc, err := store.GetTemplateDoc(proj.ID, tpl.ID)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(doc.Content.Tags) != 1 || doc.Content.Tags[0] != "restart" {
+		t.Fatalf("documentation must be replaced, got %+v", doc.Content)
+	}
+
+	if err = store.DeleteTemplate(proj.ID, tpl.ID); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err = store.GetTemplateDoc(proj.ID, tpl.ID); err != db.ErrNotFound {
+		t.Fatalf("documentation must be deleted with the template, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package bolt
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *BoltDb) GetTemplateUploads(projectID int, templateID int) (uploads []db.TemplateUpload, err error) {
+	uploads = make([]db.TemplateUpload, 0)
+	err = d.getObjects(projectID, db.TemplateUploadProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		return i.(db.TemplateUpload).TemplateID == templateID
+	}, &uploads)
+	return
+}
+
+func (d *BoltDb) GetTemplateUpload(projectID int, templateID int, uploadID int) (upload db.TemplateUpload, err error) {
+	err = d.getObject(projectID, db.TemplateUploadProps, intObjectID(uploadID), &upload)
+	if err == nil && upload.TemplateID != templateID {
+		err = db.ErrNotFound
+	}
+	return
+}
+
+func (d *BoltDb) CreateTemplateUpload(upload db.TemplateUpload) (db.TemplateUpload, error) {
+	if err := upload.Validate(); err != nil {
+		return db.TemplateUpload{}, err
+	}
+
+	newUpload, err := d.createObject(upload.ProjectID, db.TemplateUploadProps, upload)
+	if err != nil {
+		return db.TemplateUpload{}, err
+	}
+	return newUpload.(db.TemplateUpload), nil
+}
+
+func (d *BoltDb) UpdateTemplateUpload(upload db.TemplateUpload) error {
+	if err := upload.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := d.GetTemplateUpload(upload.ProjectID, upload.TemplateID, upload.ID); err != nil {
+		return err
+	}
+
+	return d.updateObject(upload.ProjectID, db.TemplateUploadProps, upload)
+}
+
+func (d *BoltDb) DeleteTemplateUpload(projectID int, templateID int, uploadID int) error {
+	if _, err := d.GetTemplateUpload(projectID, templateID, uploadID); err != nil {
+		return err
+	}
+
+	return d.deleteObject(projectID, db.TemplateUploadProps, intObjectID(uploadID), nil)
+}
+
+func (d *BoltDb) deleteTemplateUploads(projectID int, templateID int, tx kvTx) error {
+	uploads, err := d.GetTemplateUploads(projectID, templateID)
+	if err != nil {
+		return err
+	}
+
+	for _, upload := range uploads {
+		if err = d.deleteObject(projectID, db.TemplateUploadProps, intObjectID(upload.ID), tx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
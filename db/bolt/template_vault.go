@@ -2,7 +2,6 @@ package bolt
 
 import (
 	"github.com/semaphoreui/semaphore/db"
-	"go.etcd.io/bbolt"
 )
 
 func (d *BoltDb) GetTemplateVaults(projectID int, templateID int) (vaults []db.TemplateVault, err error) {
@@ -39,7 +38,7 @@ func (d *BoltDb) UpdateTemplateVaults(projectID int, templateID int, vaults []db
 	var oldVaults []db.TemplateVault
 	oldVaults, err = d.GetTemplateVaults(projectID, templateID)
 
-	err = d.db.Update(func(tx *bbolt.Tx) error {
+	err = d.db.Update(func(tx kvTx) error {
 		for _, vault := range oldVaults {
 			err = d.deleteObject(projectID, db.TemplateVaultProps, intObjectID(vault.ID), tx)
 			if err != nil {
@@ -70,6 +69,6 @@ func (d *BoltDb) UpdateTemplateVaults(projectID int, templateID int, vaults []db
 	return
 }
 
-func (d *BoltDb) deleteTemplateVault(projectID int, vaultID int, tx *bbolt.Tx) error {
+func (d *BoltDb) deleteTemplateVault(projectID int, vaultID int, tx kvTx) error {
 	return d.deleteObject(projectID, db.TemplateVaultProps, intObjectID(vaultID), tx)
 }
@@ -0,0 +1,27 @@
+package bolt
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *BoltDb) CreateTerraformPlanChange(change db.TerraformPlanChange) (db.TerraformPlanChange, error) {
+	newChange, err := d.createObject(change.TaskID, db.TerraformPlanChangeProps, change)
+	if err != nil {
+		return db.TerraformPlanChange{}, err
+	}
+	return newChange.(db.TerraformPlanChange), nil
+}
+
+func (d *BoltDb) GetTerraformPlanChanges(projectID int, taskID int) (changes []db.TerraformPlanChange, err error) {
+	// check if task exists in the project
+	_, err = d.GetTask(projectID, taskID)
+
+	if err != nil {
+		return
+	}
+
+	changes = make([]db.TerraformPlanChange, 0)
+	err = d.getObjects(taskID, db.TerraformPlanChangeProps, db.RetrieveQueryParams{}, nil, &changes)
+
+	return
+}
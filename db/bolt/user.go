@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/semaphoreui/semaphore/db"
 	"golang.org/x/crypto/bcrypt"
+	"sort"
 	"time"
 )
 
@@ -91,28 +92,27 @@ func (d *BoltDb) DeleteUser(userID int) error {
 }
 
 func (d *BoltDb) UpdateUser(user db.UserWithPwd) error {
-	var password string
+	oldUser, err := d.GetUser(user.ID)
+	if err != nil {
+		return err
+	}
 
-	if user.Pwd != "" {
-		var pwdHash []byte
-		pwdHash, err := bcrypt.GenerateFromPassword([]byte(user.Pwd), 11)
-		if err != nil {
-			return err
-		}
-		password = string(pwdHash)
-	} else {
-		oldUser, err := d.GetUser(user.ID)
-		if err != nil {
-			return err
-		}
-		password = oldUser.Password
+	user.Password = oldUser.Password
+	user.PasswordChanged = oldUser.PasswordChanged
+
+	err = d.updateObject(0, db.UserProps, user.User)
+	if err != nil {
+		return err
 	}
 
-	user.Password = password
+	if user.Pwd != "" {
+		err = d.SetUserPassword(user.ID, user.Pwd)
+	}
 
-	return d.updateObject(0, db.UserProps, user)
+	return err
 }
 
+// SetUserPassword changes the password of the user and adds the previous password to the history.
 func (d *BoltDb) SetUserPassword(userID int, password string) error {
 	pwdHash, err := bcrypt.GenerateFromPassword([]byte(password), 11)
 	if err != nil {
@@ -122,10 +122,43 @@ func (d *BoltDb) SetUserPassword(userID int, password string) error {
 	if err != nil {
 		return err
 	}
+
+	now := db.GetParsedTime(time.Now())
+
+	if user.Password != "" {
+		_, err = d.createObject(userID, db.UserPasswordProps, db.UserPassword{
+			UserID:   userID,
+			Password: user.Password,
+			Created:  now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	user.Password = string(pwdHash)
+	user.PasswordChanged = &now
 	return d.updateObject(0, db.UserProps, user)
 }
 
+func (d *BoltDb) GetUserPasswordHistory(userID int, limit int) (history []db.UserPassword, err error) {
+	err = d.getObjects(userID, db.UserPasswordProps, db.RetrieveQueryParams{}, nil, &history)
+	if err != nil {
+		return
+	}
+
+	// times of passwords changed within a second are equal, IDs grow
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].ID > history[j].ID
+	})
+
+	if len(history) > limit {
+		history = history[:limit]
+	}
+
+	return
+}
+
 func (d *BoltDb) CreateProjectUser(projectUser db.ProjectUser) (db.ProjectUser, error) {
 	newProjectUser, err := d.createObject(projectUser.ProjectID, db.ProjectUserProps, projectUser)
 
@@ -154,8 +187,9 @@ func (d *BoltDb) GetProjectUsers(projectID int, params db.RetrieveQueryParams) (
 			return
 		}
 		var usrWithRole = db.UserWithProjectRole{
-			User: usr,
-			Role: projUser.Role,
+			User:      usr,
+			Role:      projUser.Role,
+			ExpiresAt: projUser.ExpiresAt,
 		}
 		users = append(users, usrWithRole)
 	}
@@ -170,6 +204,33 @@ func (d *BoltDb) DeleteProjectUser(projectID, userID int) error {
 	return d.deleteObject(projectID, db.ProjectUserProps, intObjectID(userID), nil)
 }
 
+func (d *BoltDb) DeleteExpiredProjectUsers(now time.Time) (expired []db.ProjectUser, err error) {
+	projects, err := d.GetAllProjects()
+	if err != nil {
+		return
+	}
+
+	for _, project := range projects {
+		var projectUsers []db.ProjectUser
+		err = d.getObjects(project.ID, db.ProjectUserProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+			projectUser := i.(db.ProjectUser)
+			return projectUser.IsExpired(now)
+		}, &projectUsers)
+		if err != nil {
+			return
+		}
+
+		for _, projectUser := range projectUsers {
+			if err = d.DeleteProjectUser(project.ID, projectUser.UserID); err != nil {
+				return
+			}
+			expired = append(expired, projectUser)
+		}
+	}
+
+	return
+}
+
 // GetUser retrieves a user from the database by ID
 func (d *BoltDb) GetUser(userID int) (user db.User, err error) {
 	err = d.getObject(0, db.UserProps, intObjectID(userID), &user)
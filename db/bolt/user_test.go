@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
 	"github.com/stretchr/testify/require"
 )
 
@@ -154,3 +155,43 @@ func TestBoltDb_DeleteUser(t *testing.T) {
 	_, err = store.GetProjectUser(proj.ID, usr.ID)
 	require.Error(t, err)
 }
+
+func TestValidatePasswordChange(t *testing.T) {
+	store := CreateTestStore()
+
+	util.Config = &util.ConfigType{
+		PasswordPolicy: &util.PasswordPolicyConfig{HistorySize: 2},
+	}
+
+	usr, err := store.CreateUser(db.UserWithPwd{
+		Pwd: "first",
+		User: db.User{
+			Email:    "denguk@example.com",
+			Name:     "Denis Gukov",
+			Username: "fiftin",
+		},
+	})
+	require.NoError(t, err)
+	require.Nil(t, usr.PasswordChanged)
+
+	require.NoError(t, store.SetUserPassword(usr.ID, "second"))
+
+	usr, err = store.GetUser(usr.ID)
+	require.NoError(t, err)
+	require.NotNil(t, usr.PasswordChanged)
+
+	for _, pwd := range []string{"first", "second"} {
+		var codedErr *db.CodedError
+		err = db.ValidatePasswordChange(store, usr, pwd)
+		require.ErrorAs(t, err, &codedErr)
+		require.Equal(t, db.ErrorCodePasswordPolicy, codedErr.Code)
+	}
+
+	require.NoError(t, store.UpdateUser(db.UserWithPwd{Pwd: "third", User: usr}))
+
+	usr, err = store.GetUser(usr.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, db.ValidatePasswordChange(store, usr, "first"))
+	require.Error(t, db.ValidatePasswordChange(store, usr, "second"))
+}
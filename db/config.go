@@ -83,6 +83,20 @@ func assignMapToStructRecursive(m map[string]interface{}, structValue reflect.Va
 					if err != nil {
 						return err
 					}
+				case reflect.Ptr:
+					mapValue, ok := value.(map[string]interface{})
+					if !ok || fieldValue.Type().Elem().Kind() != reflect.Struct {
+						return fmt.Errorf("cannot assign value of type %T to field %s of type %s", value, field.Name, field.Type)
+					}
+
+					if fieldValue.IsNil() {
+						fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+					}
+
+					err := assignMapToStructRecursive(mapValue, fieldValue.Elem())
+					if err != nil {
+						return err
+					}
 				case reflect.Map:
 					if fieldValue.IsNil() {
 						mapValue := reflect.MakeMap(fieldValue.Type())
@@ -73,3 +73,28 @@ func TestConfig_assignMapToStruct(t *testing.T) {
 	//	t.Errorf("Expected occupation to be engineer but got %s", john.Details["occupation"].Value)
 	//}
 }
+
+func TestConfig_assignMapToStructPointer(t *testing.T) {
+	type Mappings struct {
+		UID string `json:"uid"`
+		CN  string `json:"cn"`
+	}
+
+	type Config struct {
+		Mappings *Mappings `json:"mappings,omitempty"`
+	}
+
+	var config Config
+
+	err := AssignMapToStruct(ConvertFlatToNested(map[string]string{
+		"mappings.uid": "sAMAccountName",
+	}), &config)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Mappings == nil || config.Mappings.UID != "sAMAccountName" {
+		t.Errorf("Expected uid mapping to be assigned")
+	}
+}
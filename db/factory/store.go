@@ -1,6 +1,8 @@
 package factory
 
 import (
+	"fmt"
+
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/db/bolt"
 	"github.com/semaphoreui/semaphore/db/sql"
@@ -23,3 +25,20 @@ func CreateStore() db.Store {
 		panic("Unsupported database dialect: " + config.Dialect)
 	}
 }
+
+// CreateSecretStorage opens the database of secrets of access keys.
+// It returns nil if the secrets are kept in the main database.
+func CreateSecretStorage() (db.SecretStorage, error) {
+	config := util.Config.SecretsDb
+	if !config.IsEnabled() {
+		return nil, nil
+	}
+	switch config.Dialect {
+	case util.DbDriverBolt:
+		return bolt.OpenSecretStorage(config.Options["engine"], config.Hostname)
+	case util.DbDriverMySQL, util.DbDriverPostgres:
+		return sql.OpenSecretStorage(config)
+	default:
+		return nil, fmt.Errorf("unsupported secrets database dialect: %s", config.Dialect)
+	}
+}
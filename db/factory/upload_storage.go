@@ -0,0 +1,59 @@
+package factory
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/objectstorage"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// objectUploadStorage keeps the content of uploaded files in the object storage.
+type objectUploadStorage struct {
+	client *objectstorage.Client
+	prefix string
+}
+
+func (s *objectUploadStorage) GetUpload(id string) ([]byte, error) {
+	body, err := s.client.GetObject(s.prefix + id)
+	if errors.Is(err, objectstorage.ErrNotFound) {
+		return nil, db.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+func (s *objectUploadStorage) SetUpload(id string, data []byte) error {
+	return s.client.PutObject(s.prefix+id, bytes.NewReader(data), int64(len(data)))
+}
+
+func (s *objectUploadStorage) DeleteUpload(id string) error {
+	return s.client.DeleteObject(s.prefix + id)
+}
+
+// CreateUploadStorage returns the storage of uploaded files.
+// It returns nil if the files are kept in the main database.
+func CreateUploadStorage() db.UploadStorage {
+	if util.Config.Uploads == nil || util.Config.Uploads.Storage == nil || util.Config.Uploads.Storage.Bucket == "" {
+		return nil
+	}
+
+	cfg := util.Config.Uploads.Storage
+
+	return &objectUploadStorage{
+		client: &objectstorage.Client{
+			Endpoint:        cfg.Endpoint,
+			Region:          cfg.Region,
+			Bucket:          cfg.Bucket,
+			AccessKeyID:     cfg.AccessKeyID,
+			SecretAccessKey: cfg.SecretAccessKey,
+			PathStyle:       cfg.PathStyle,
+		},
+		prefix: cfg.Prefix,
+	}
+}
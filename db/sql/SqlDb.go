@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/go-gorp/gorp/v3"
@@ -20,6 +21,10 @@ import (
 
 type SqlDb struct {
 	sql *gorp.DbMap
+
+	locksMu sync.Mutex
+	// locks contains connections which hold advisory locks, see TryLock.
+	locks map[string]*sql.Conn
 }
 
 var initialSQL = `
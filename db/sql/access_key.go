@@ -2,8 +2,8 @@ package sql
 
 import (
 	"database/sql"
-	"errors"
 	"github.com/semaphoreui/semaphore/db"
+	"time"
 )
 
 func (d *SqlDb) GetAccessKey(projectID int, accessKeyID int) (key db.AccessKey, err error) {
@@ -11,14 +11,20 @@ func (d *SqlDb) GetAccessKey(projectID int, accessKeyID int) (key db.AccessKey,
 	return
 }
 
-func (d *SqlDb) GetAccessKeyRefs(projectID int, keyID int) (db.ObjectReferrers, error) {
-	return d.getObjectRefs(projectID, db.AccessKeyProps, keyID)
+func (d *SqlDb) GetAccessKeyRefs(projectID int, keyID int) (refs db.ObjectReferrers, err error) {
+	refs, err = d.getObjectRefs(projectID, db.AccessKeyProps, keyID)
+	if err != nil {
+		return
+	}
+
+	err = db.FillAccessKeyTemplateRefs(d, projectID, keyID, &refs)
+	return
 }
 
 func (d *SqlDb) GetAccessKeys(projectID int, params db.RetrieveQueryParams) (keys []db.AccessKey, err error) {
 	keys = make([]db.AccessKey, 0)
 
-	q := d.makeObjectsQuery(projectID, db.AccessKeyProps, params).Where("pe.environment_id IS NULL")
+	q := d.makeObjectsQuery(projectID, db.AccessKeyProps, params).Where("pe.environment_id IS NULL AND pe.inventory_id IS NULL")
 
 	query, args, err := q.ToSql()
 
@@ -44,22 +50,30 @@ func (d *SqlDb) UpdateAccessKey(key db.AccessKey) error {
 		return err
 	}
 
-	err = key.SerializeSecret()
-
-	if err != nil {
-		return err
-	}
-
 	var res sql.Result
 
 	var args []interface{}
 	query := "update access_key set name=?"
 	args = append(args, key.Name)
 
+	var oldKey db.AccessKey
+
 	if key.OverrideSecret {
-		query += ", type=?, secret=?"
+		oldKey, err = d.GetAccessKey(*key.ProjectID, key.ID)
+		if err != nil {
+			return err
+		}
+
+		err = key.SerializeSecret()
+
+		if err != nil {
+			return err
+		}
+
+		query += ", type=?, secret=?, secret_changed=?"
 		args = append(args, key.Type)
 		args = append(args, key.Secret)
+		args = append(args, time.Now().UTC())
 	}
 
 	query += " where id=?"
@@ -70,7 +84,18 @@ func (d *SqlDb) UpdateAccessKey(key db.AccessKey) error {
 
 	res, err = d.exec(query, args...)
 
-	return validateMutationResult(res, err)
+	err = validateMutationResult(res, err)
+
+	if !key.OverrideSecret {
+		return err
+	}
+
+	if err != nil {
+		_ = db.ReleaseSecret(key.Secret)
+		return err
+	}
+
+	return db.ReleaseSecret(oldKey.Secret)
 }
 
 func (d *SqlDb) CreateAccessKey(key db.AccessKey) (newKey db.AccessKey, err error) {
@@ -79,26 +104,54 @@ func (d *SqlDb) CreateAccessKey(key db.AccessKey) (newKey db.AccessKey, err erro
 		return
 	}
 
+	now := time.Now().UTC()
+
 	insertID, err := d.insert(
 		"id",
-		"insert into access_key (name, type, project_id, secret, environment_id) values (?, ?, ?, ?, ?)",
+		"insert into access_key (name, type, project_id, secret, environment_id, inventory_id, secret_changed) values (?, ?, ?, ?, ?, ?, ?)",
 		key.Name,
 		key.Type,
 		key.ProjectID,
 		key.Secret,
-		key.EnvironmentID)
+		key.EnvironmentID,
+		key.InventoryID,
+		now)
 
 	if err != nil {
+		_ = db.ReleaseSecret(key.Secret)
 		return
 	}
 
 	newKey = key
 	newKey.ID = insertID
+	newKey.SecretChanged = &now
 	return
 }
 
 func (d *SqlDb) DeleteAccessKey(projectID int, accessKeyID int) error {
-	return d.deleteObject(projectID, db.AccessKeyProps, accessKeyID)
+	key, err := d.GetAccessKey(projectID, accessKeyID)
+	if err != nil {
+		return err
+	}
+
+	err = d.deleteObject(projectID, db.AccessKeyProps, accessKeyID)
+	if err != nil {
+		return err
+	}
+
+	if err = db.ReleaseSecret(key.Secret); err != nil {
+		return err
+	}
+
+	// the key is no longer used by default
+	for _, column := range []string{"default_ssh_key_id", "default_become_key_id", "default_vault_key_id"} {
+		_, err = d.exec("update project set "+column+"=null where id=? and "+column+"=?", projectID, accessKeyID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 const RekeyBatchSize = 100
@@ -122,6 +175,7 @@ func (d *SqlDb) RekeyAccessKeys(oldKey string) (err error) {
 		}
 
 		for _, key := range keys {
+			oldSecret := key.Secret
 
 			err = key.DeserializeSecret2(oldKey)
 
@@ -129,10 +183,22 @@ func (d *SqlDb) RekeyAccessKeys(oldKey string) (err error) {
 				return err
 			}
 
-			key.OverrideSecret = true
-			err = d.UpdateAccessKey(key)
+			err = key.SerializeSecret()
+
+			if err != nil {
+				return err
+			}
+
+			// re-encryption does not change the secret, so the time of the last change is kept
+			_, err = d.exec("update access_key set secret=? where id=?", key.Secret, key.ID)
 
-			if err != nil && !errors.Is(err, db.ErrNotFound) {
+			if err != nil {
+				return err
+			}
+
+			err = db.ReleaseSecret(oldSecret)
+
+			if err != nil {
 				return err
 			}
 		}
@@ -0,0 +1,75 @@
+package sql
+
+import "github.com/semaphoreui/semaphore/db"
+
+func (d *SqlDb) GetAdhocCommand(projectID int, commandID int) (command db.AdhocCommand, err error) {
+	err = d.getObject(projectID, db.AdhocCommandProps, commandID, &command)
+	return
+}
+
+func (d *SqlDb) GetAdhocCommands(projectID int, params db.RetrieveQueryParams) (commands []db.AdhocCommand, err error) {
+	commands = make([]db.AdhocCommand, 0)
+	params.SortInverted = true
+	err = d.getObjects(projectID, db.AdhocCommandProps, params, nil, &commands)
+	return
+}
+
+func (d *SqlDb) CreateAdhocCommand(command db.AdhocCommand) (newCommand db.AdhocCommand, err error) {
+	insertID, err := d.insert(
+		"id",
+		"insert into project__adhoc_command (project_id, inventory_id, user_id, key_id, module, args, hosts_limit, become, status, created) "+
+			"values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		command.ProjectID,
+		command.InventoryID,
+		command.UserID,
+		command.KeyID,
+		command.Module,
+		command.Args,
+		command.Limit,
+		command.Become,
+		command.Status,
+		command.Created.UTC())
+
+	if err != nil {
+		return
+	}
+
+	newCommand = command
+	newCommand.ID = insertID
+	return
+}
+
+func (d *SqlDb) UpdateAdhocCommand(command db.AdhocCommand) error {
+	_, err := d.exec(
+		"update project__adhoc_command set status=?, start=?, `end`=? where project_id=? and id=?",
+		command.Status,
+		command.Start,
+		command.End,
+		command.ProjectID,
+		command.ID)
+
+	return err
+}
+
+func (d *SqlDb) GetAdhocCommandOutputs(projectID int, commandID int) (outputs []db.AdhocCommandOutput, err error) {
+	// check if command exists in the project
+	_, err = d.GetAdhocCommand(projectID, commandID)
+	if err != nil {
+		return
+	}
+
+	outputs = make([]db.AdhocCommandOutput, 0)
+	_, err = d.selectAll(&outputs,
+		"select command_id, time, output from project__adhoc_command__output where command_id=? order by time asc",
+		commandID)
+	return
+}
+
+func (d *SqlDb) CreateAdhocCommandOutput(output db.AdhocCommandOutput) error {
+	_, err := d.exec(
+		"insert into project__adhoc_command__output (command_id, time, output) values (?, ?, ?)",
+		output.CommandID,
+		output.Time.UTC(),
+		output.Output)
+	return err
+}
@@ -0,0 +1,71 @@
+package sql
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) GetAnnouncements() (announcements []db.Announcement, err error) {
+	announcements = make([]db.Announcement, 0)
+	_, err = d.selectAll(&announcements, "select * from announcement order by id desc")
+	return
+}
+
+func (d *SqlDb) GetAnnouncement(announcementID int) (announcement db.Announcement, err error) {
+	err = d.selectOne(&announcement, "select * from announcement where id=?", announcementID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
+func (d *SqlDb) CreateAnnouncement(announcement db.Announcement) (newAnnouncement db.Announcement, err error) {
+	if err = announcement.Validate(); err != nil {
+		return
+	}
+
+	insertID, err := d.insert(
+		"id",
+		"insert into announcement (title, message, severity, starts, ends, user_id, created) values (?, ?, ?, ?, ?, ?, ?)",
+		announcement.Title,
+		announcement.Message,
+		announcement.Severity,
+		announcement.Start,
+		announcement.End,
+		announcement.UserID,
+		announcement.Created)
+
+	if err != nil {
+		return
+	}
+
+	newAnnouncement = announcement
+	newAnnouncement.ID = insertID
+	return
+}
+
+func (d *SqlDb) UpdateAnnouncement(announcement db.Announcement) error {
+	if err := announcement.Validate(); err != nil {
+		return err
+	}
+
+	_, err := d.exec(
+		"update announcement set title=?, message=?, severity=?, starts=?, ends=? where id=?",
+		announcement.Title,
+		announcement.Message,
+		announcement.Severity,
+		announcement.Start,
+		announcement.End,
+		announcement.ID)
+
+	return err
+}
+
+func (d *SqlDb) DeleteAnnouncement(announcementID int) error {
+	_, err := d.exec("delete from announcement where id=?", announcementID)
+	return err
+}
@@ -0,0 +1,67 @@
+package sql
+
+import "github.com/semaphoreui/semaphore/db"
+
+func (d *SqlDb) GetDeploymentEnvironment(projectID int, envID int) (env db.DeploymentEnvironment, err error) {
+	err = d.getObject(projectID, db.DeploymentEnvironmentProps, envID, &env)
+	return
+}
+
+func (d *SqlDb) GetDeploymentEnvironments(projectID int) (envs []db.DeploymentEnvironment, err error) {
+	err = d.getObjects(projectID, db.DeploymentEnvironmentProps, db.RetrieveQueryParams{}, nil, &envs)
+	return
+}
+
+func (d *SqlDb) UpdateDeploymentEnvironment(env db.DeploymentEnvironment) error {
+	_, err := d.exec(
+		"update project__deployment_environment set name=?, position=? where project_id=? and id=?",
+		env.Name,
+		env.Position,
+		env.ProjectID,
+		env.ID)
+
+	return err
+}
+
+func (d *SqlDb) CreateDeploymentEnvironment(env db.DeploymentEnvironment) (newEnv db.DeploymentEnvironment, err error) {
+	insertID, err := d.insert(
+		"id",
+		"insert into project__deployment_environment (project_id, name, position) values (?, ?, ?)",
+		env.ProjectID,
+		env.Name,
+		env.Position)
+
+	if err != nil {
+		return
+	}
+
+	newEnv = env
+	newEnv.ID = insertID
+	return
+}
+
+func (d *SqlDb) DeleteDeploymentEnvironment(projectID int, envID int) error {
+	_, err := d.exec(
+		"update task set deployment_environment_id=null where project_id=? and deployment_environment_id=?",
+		projectID,
+		envID)
+
+	if err != nil {
+		return err
+	}
+
+	return d.deleteObject(projectID, db.DeploymentEnvironmentProps, envID)
+}
+
+func (d *SqlDb) SetDeploymentEnvironmentPositions(projectID int, positions map[int]int) error {
+	for id, position := range positions {
+		_, err := d.exec("update project__deployment_environment set position=? where project_id=? and id=?",
+			position,
+			projectID,
+			id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
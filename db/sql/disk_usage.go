@@ -0,0 +1,62 @@
+package sql
+
+import (
+	"github.com/Masterminds/squirrel"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) SetDiskUsage(runnerID *int, usage []db.DiskUsage) error {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+
+	if runnerID == nil {
+		_, err = tx.Exec(d.PrepareQuery("delete from disk_usage where runner_id is null"))
+	} else {
+		_, err = tx.Exec(d.PrepareQuery("delete from disk_usage where runner_id=?"), *runnerID)
+	}
+
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	for _, u := range usage {
+		_, err = tx.Exec(d.PrepareQuery("insert into disk_usage "+
+			"(runner_id, project_id, kind, size, entries, updated) "+
+			"values (?, ?, ?, ?, ?, ?)"),
+			runnerID,
+			u.ProjectID,
+			u.Kind,
+			u.Size,
+			u.Entries,
+			u.Updated.UTC())
+
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (d *SqlDb) GetDiskUsage(projectID *int) (usage []db.DiskUsage, err error) {
+	q := squirrel.Select("*").
+		From("disk_usage").
+		OrderBy("runner_id", "project_id", "kind")
+
+	if projectID != nil {
+		q = q.Where(squirrel.Eq{"project_id": *projectID})
+	}
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return
+	}
+
+	usage = make([]db.DiskUsage, 0)
+	_, err = d.selectAll(&usage, query, args...)
+	return
+}
@@ -1,6 +1,9 @@
 package sql
 
 import (
+	"database/sql"
+
+	"github.com/Masterminds/squirrel"
 	"github.com/semaphoreui/semaphore/db"
 )
 
@@ -15,10 +18,35 @@ func (d *SqlDb) GetEnvironmentRefs(projectID int, environmentID int) (db.ObjectR
 
 func (d *SqlDb) GetEnvironments(projectID int, params db.RetrieveQueryParams) ([]db.Environment, error) {
 	var environment []db.Environment
-	err := d.getObjects(projectID, db.EnvironmentProps, params, nil, &environment)
+	err := d.getObjects(projectID, db.EnvironmentProps, params, func(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return q.Where("pe.project_default = ? and pe.variable_group = ?", false, false)
+	}, &environment)
 	return environment, err
 }
 
+func (d *SqlDb) GetVariableGroups(projectID int) (groups []db.Environment, err error) {
+	_, err = d.selectAll(
+		&groups,
+		"select * from project__environment where project_id=? and variable_group=? order by name",
+		projectID,
+		true)
+	return
+}
+
+func (d *SqlDb) GetProjectDefaultEnvironment(projectID int) (environment db.Environment, err error) {
+	err = d.selectOne(
+		&environment,
+		"select * from project__environment where project_id=? and project_default=? limit 1",
+		projectID,
+		true)
+
+	if err == sql.ErrNoRows {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
 func (d *SqlDb) UpdateEnvironment(env db.Environment) error {
 	err := env.Validate()
 
@@ -27,10 +55,11 @@ func (d *SqlDb) UpdateEnvironment(env db.Environment) error {
 	}
 
 	_, err = d.exec(
-		"update project__environment set name=?, json=?, env=?, password=? where id=?",
+		"update project__environment set name=?, json=?, env=?, json_schema=?, password=? where id=?",
 		env.Name,
 		env.JSON,
 		env.ENV,
+		env.JSONSchema,
 		env.Password,
 		env.ID)
 	return err
@@ -45,12 +74,15 @@ func (d *SqlDb) CreateEnvironment(env db.Environment) (newEnv db.Environment, er
 
 	insertID, err := d.insert(
 		"id",
-		"insert into project__environment (project_id, name, json, env, password) values (?, ?, ?, ?, ?)",
+		"insert into project__environment (project_id, name, json, env, json_schema, password, project_default, variable_group) values (?, ?, ?, ?, ?, ?, ?, ?)",
 		env.ProjectID,
 		env.Name,
 		env.JSON,
 		env.ENV,
-		env.Password)
+		env.JSONSchema,
+		env.Password,
+		env.ProjectDefault,
+		env.VariableGroup)
 
 	if err != nil {
 		return
@@ -33,11 +33,13 @@ func (d *SqlDb) CreateEvent(evt db.Event) (newEvent db.Event, err error) {
 	var created = time.Now().UTC()
 
 	_, err = d.exec(
-		"insert into event(user_id, project_id, object_id, object_type, description, created) values (?, ?, ?, ?, ?, ?)",
+		"insert into event(user_id, project_id, integration_id, object_id, object_type, action, description, created) values (?, ?, ?, ?, ?, ?, ?, ?)",
 		evt.UserID,
 		evt.ProjectID,
+		evt.IntegrationID,
 		evt.ObjectID,
 		evt.ObjectType,
+		evt.Action,
 		evt.Description,
 		created)
 
@@ -70,3 +72,51 @@ func (d *SqlDb) GetEvents(projectID int, params db.RetrieveQueryParams) ([]db.Ev
 
 	return d.getEvents(q, params)
 }
+
+func (d *SqlDb) GetActivity(projectID int, params db.ActivityQueryParams) ([]db.Event, error) {
+	q := squirrel.Select("event.*, p.name as project_name").
+		From("event").
+		LeftJoin("project as p on event.project_id=p.id").
+		OrderBy("event.id desc").
+		Where("event.project_id=?", projectID)
+
+	if params.Before > 0 {
+		q = q.Where("event.id<?", params.Before)
+	}
+
+	if len(params.ObjectTypes) > 0 {
+		q = q.Where(squirrel.Eq{"event.object_type": params.ObjectTypes})
+	}
+
+	if params.ObjectID != nil {
+		q = q.Where("event.object_id=?", *params.ObjectID)
+	}
+
+	if len(params.Actions) > 0 {
+		q = q.Where(squirrel.Eq{"event.action": params.Actions})
+	}
+
+	return d.getEvents(q, db.RetrieveQueryParams{Count: params.Count})
+}
+
+func (d *SqlDb) GetProjectUsersLastActivity(projectID int) (map[int]time.Time, error) {
+	var rows []struct {
+		UserID  int       `db:"user_id"`
+		Created time.Time `db:"created"`
+	}
+
+	_, err := d.selectAll(&rows,
+		"select user_id, max(created) as created from event where project_id=? and user_id is not null group by user_id",
+		projectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[int]time.Time)
+	for _, row := range rows {
+		res[row.UserID] = row.Created
+	}
+
+	return res, nil
+}
@@ -0,0 +1,65 @@
+package sql
+
+import (
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) GetHostExclusion(projectID int, exclusionID int) (exclusion db.HostExclusion, err error) {
+	err = d.getObject(projectID, db.HostExclusionProps, exclusionID, &exclusion)
+	return
+}
+
+func (d *SqlDb) GetHostExclusions(projectID int) (exclusions []db.HostExclusion, err error) {
+	err = d.getObjects(projectID, db.HostExclusionProps, db.RetrieveQueryParams{}, nil, &exclusions)
+	return
+}
+
+func (d *SqlDb) UpdateHostExclusion(exclusion db.HostExclusion) error {
+	_, err := d.exec(
+		"update project__host_exclusion set host=?, reason=?, expires_at=? where project_id=? and id=?",
+		exclusion.Host,
+		exclusion.Reason,
+		exclusion.ExpiresAt,
+		exclusion.ProjectID,
+		exclusion.ID)
+
+	return err
+}
+
+func (d *SqlDb) CreateHostExclusion(exclusion db.HostExclusion) (newExclusion db.HostExclusion, err error) {
+	exclusion.Created = time.Now().UTC()
+
+	insertID, err := d.insert(
+		"id",
+		"insert into project__host_exclusion (project_id, host, reason, expires_at, created, user_id) values (?, ?, ?, ?, ?, ?)",
+		exclusion.ProjectID,
+		exclusion.Host,
+		exclusion.Reason,
+		exclusion.ExpiresAt,
+		exclusion.Created,
+		exclusion.UserID)
+
+	if err != nil {
+		return
+	}
+
+	newExclusion = exclusion
+	newExclusion.ID = insertID
+	return
+}
+
+func (d *SqlDb) DeleteHostExclusion(projectID int, exclusionID int) error {
+	return d.deleteObject(projectID, db.HostExclusionProps, exclusionID)
+}
+
+func (d *SqlDb) DeleteExpiredHostExclusions(now time.Time) (expired []db.HostExclusion, err error) {
+	_, err = d.selectAll(&expired, "select * from project__host_exclusion where expires_at<=?", now.UTC())
+	if err != nil || len(expired) == 0 {
+		return
+	}
+
+	_, err = d.exec("delete from project__host_exclusion where expires_at<=?", now.UTC())
+	return
+}
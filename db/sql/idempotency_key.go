@@ -0,0 +1,60 @@
+package sql
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) GetIdempotencyKey(keyID string) (key db.IdempotencyKey, err error) {
+	err = d.selectOne(&key, "select * from idempotency_key where id=?", keyID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
+func (d *SqlDb) CreateIdempotencyKey(key db.IdempotencyKey) error {
+	_, err := d.exec(
+		"insert into idempotency_key (id, request_hash, status, response, created) values (?, ?, ?, ?, ?)",
+		key.ID,
+		key.RequestHash,
+		key.Status,
+		key.Response,
+		key.Created.UTC())
+
+	if err == nil {
+		return nil
+	}
+
+	// the insert fails by the primary key if the key is reserved by the concurrent request
+	if _, getErr := d.GetIdempotencyKey(key.ID); getErr == nil {
+		return db.ErrInvalidOperation
+	}
+
+	return err
+}
+
+func (d *SqlDb) SetIdempotencyKeyResponse(keyID string, status int, response string) error {
+	_, err := d.exec("update idempotency_key set status=?, response=? where id=?", status, response, keyID)
+	return err
+}
+
+func (d *SqlDb) DeleteIdempotencyKey(keyID string) error {
+	_, err := d.exec("delete from idempotency_key where id=?", keyID)
+	return err
+}
+
+func (d *SqlDb) DeleteIdempotencyKeysBefore(created time.Time) (int, error) {
+	res, err := d.exec("delete from idempotency_key where created<?", created.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
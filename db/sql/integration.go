@@ -16,15 +16,19 @@ func (d *SqlDb) CreateIntegration(integration db.Integration) (newIntegration db
 	insertID, err := d.insert(
 		"id",
 		"insert into project__integration "+
-			"(project_id, name, template_id, auth_method, auth_secret_id, auth_header, searchable) values "+
-			"(?, ?, ?, ?, ?, ?, ?)",
+			"(project_id, name, template_id, auth_method, auth_secret_id, auth_header, searchable, commit_status, "+
+			"previous_auth_secret_id, previous_auth_secret_expires) values "+
+			"(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		integration.ProjectID,
 		integration.Name,
 		integration.TemplateID,
 		integration.AuthMethod,
 		integration.AuthSecretID,
 		integration.AuthHeader,
-		integration.Searchable)
+		integration.Searchable,
+		integration.CommitStatus,
+		integration.PreviousAuthSecretID,
+		integration.PreviousAuthSecretExpires)
 
 	if err != nil {
 		return
@@ -67,13 +71,17 @@ func (d *SqlDb) UpdateIntegration(integration db.Integration) error {
 	}
 
 	_, err = d.exec(
-		"update project__integration set `name`=?, template_id=?, auth_method=?, auth_secret_id=?, auth_header=?, searchable=? where `id`=?",
+		"update project__integration set `name`=?, template_id=?, auth_method=?, auth_secret_id=?, auth_header=?, searchable=?, commit_status=?, "+
+			"previous_auth_secret_id=?, previous_auth_secret_expires=? where `id`=?",
 		integration.Name,
 		integration.TemplateID,
 		integration.AuthMethod,
 		integration.AuthSecretID,
 		integration.AuthHeader,
 		integration.Searchable,
+		integration.CommitStatus,
+		integration.PreviousAuthSecretID,
+		integration.PreviousAuthSecretExpires,
 		integration.ID)
 
 	return err
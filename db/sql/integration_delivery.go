@@ -0,0 +1,91 @@
+package sql
+
+import (
+	"database/sql"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) clearIntegrationDeliveries(integrationID int, maxDeliveries int) {
+	var oldest db.IntegrationDelivery
+	err := d.selectOne(&oldest,
+		"select id from project__integration_delivery where integration_id=? order by id desc limit 1 offset ?",
+		integrationID, maxDeliveries-1)
+
+	if err != nil {
+		return
+	}
+
+	_, _ = d.exec("delete from project__integration_delivery where integration_id=? and id<?", integrationID, oldest.ID)
+}
+
+func (d *SqlDb) CreateIntegrationDelivery(delivery db.IntegrationDelivery, maxDeliveries int) (newDelivery db.IntegrationDelivery, err error) {
+	insertID, err := d.insert(
+		"id",
+		"insert into project__integration_delivery "+
+			"(project_id, integration_id, created, headers, payload, status, message, task_id) values "+
+			"(?, ?, ?, ?, ?, ?, ?, ?)",
+		delivery.ProjectID,
+		delivery.IntegrationID,
+		delivery.Created,
+		delivery.Headers,
+		delivery.Payload,
+		delivery.Status,
+		delivery.Message,
+		delivery.TaskID)
+
+	if err != nil {
+		return
+	}
+
+	newDelivery = delivery
+	newDelivery.ID = insertID
+
+	if maxDeliveries > 0 {
+		d.clearIntegrationDeliveries(delivery.IntegrationID, maxDeliveries)
+	}
+
+	return
+}
+
+func (d *SqlDb) GetIntegrationDeliveries(projectID int, integrationID int, params db.RetrieveQueryParams) (deliveries []db.IntegrationDelivery, err error) {
+	q := squirrel.Select("d.*").
+		From("project__integration_delivery as d").
+		Where(squirrel.Eq{"d.project_id": projectID, "d.integration_id": integrationID}).
+		OrderBy("d.id desc")
+
+	if params.Count > 0 {
+		q = q.Limit(uint64(params.Count)).Offset(uint64(params.Offset))
+	}
+
+	query, args, err := q.ToSql()
+
+	if err != nil {
+		return
+	}
+
+	deliveries = make([]db.IntegrationDelivery, 0)
+	_, err = d.selectAll(&deliveries, query, args...)
+
+	return
+}
+
+func (d *SqlDb) GetIntegrationDelivery(projectID int, integrationID int, deliveryID int) (delivery db.IntegrationDelivery, err error) {
+	query, args, err := squirrel.Select("d.*").
+		From("project__integration_delivery as d").
+		Where(squirrel.Eq{"d.id": deliveryID, "d.project_id": projectID, "d.integration_id": integrationID}).
+		ToSql()
+
+	if err != nil {
+		return
+	}
+
+	err = d.selectOne(&delivery, query, args...)
+
+	if err == sql.ErrNoRows {
+		err = db.ErrNotFound
+	}
+
+	return
+}
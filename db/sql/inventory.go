@@ -29,7 +29,7 @@ func (d *SqlDb) DeleteInventory(projectID int, inventoryID int) error {
 func (d *SqlDb) UpdateInventory(inventory db.Inventory) error {
 
 	_, err := d.exec(
-		"update project__inventory set name=?, type=?, ssh_key_id=?, inventory=?, become_key_id=?, holder_id=?, repository_id=? where id=?",
+		"update project__inventory set name=?, type=?, ssh_key_id=?, inventory=?, become_key_id=?, holder_id=?, repository_id=?, ssh_options=? where id=?",
 		inventory.Name,
 		inventory.Type,
 		inventory.SSHKeyID,
@@ -37,6 +37,7 @@ func (d *SqlDb) UpdateInventory(inventory db.Inventory) error {
 		inventory.BecomeKeyID,
 		inventory.HolderID,
 		inventory.RepositoryID,
+		inventory.SSHOptions,
 		inventory.ID)
 
 	return err
@@ -45,8 +46,8 @@ func (d *SqlDb) UpdateInventory(inventory db.Inventory) error {
 func (d *SqlDb) CreateInventory(inventory db.Inventory) (newInventory db.Inventory, err error) {
 	insertID, err := d.insert(
 		"id",
-		"insert into project__inventory (project_id, name, type, ssh_key_id, inventory, become_key_id, holder_id, repository_id) values "+
-			"(?, ?, ?, ?, ?, ?, ?, ?)",
+		"insert into project__inventory (project_id, name, type, ssh_key_id, inventory, become_key_id, holder_id, repository_id, ssh_options) values "+
+			"(?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		inventory.ProjectID,
 		inventory.Name,
 		inventory.Type,
@@ -54,7 +55,8 @@ func (d *SqlDb) CreateInventory(inventory db.Inventory) (newInventory db.Invento
 		inventory.Inventory,
 		inventory.BecomeKeyID,
 		inventory.HolderID,
-		inventory.RepositoryID)
+		inventory.RepositoryID,
+		inventory.SSHOptions)
 
 	if err != nil {
 		return
@@ -64,3 +66,19 @@ func (d *SqlDb) CreateInventory(inventory db.Inventory) (newInventory db.Invento
 	newInventory.ID = insertID
 	return
 }
+
+func (d *SqlDb) GetInventorySecrets(projectID int, inventoryID int) (keys []db.AccessKey, err error) {
+	keys = make([]db.AccessKey, 0)
+
+	q := d.makeObjectsQuery(projectID, db.AccessKeyProps, db.RetrieveQueryParams{}).Where("pe.inventory_id = ?", inventoryID)
+
+	query, args, err := q.ToSql()
+
+	if err != nil {
+		return
+	}
+
+	_, err = d.selectAll(&keys, query, args...)
+
+	return
+}
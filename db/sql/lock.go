@@ -0,0 +1,93 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+
+	"github.com/go-gorp/gorp/v3"
+)
+
+// lockNamePrefix separates Semaphore locks from locks of other applications
+// which use the same database server.
+const lockNamePrefix = "semaphore_"
+
+// getAdvisoryLockKey converts the lock name to the key of PostgreSQL advisory lock.
+func getAdvisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(lockNamePrefix + name))
+	return int64(h.Sum64())
+}
+
+// TryLock acquires the advisory lock (pg_try_advisory_lock in PostgreSQL and
+// GET_LOCK in MySQL). The locks are bound to the database session, so every held lock
+// keeps its own connection out of the pool.
+func (d *SqlDb) TryLock(name string) (bool, error) {
+	d.locksMu.Lock()
+	defer d.locksMu.Unlock()
+
+	ctx := context.Background()
+
+	if conn, ok := d.locks[name]; ok {
+		if err := conn.PingContext(ctx); err == nil {
+			return true, nil
+		}
+		// the session is lost together with the lock
+		_ = conn.Close()
+		delete(d.locks, name)
+	}
+
+	conn, err := d.sql.Db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var acquired bool
+
+	switch d.sql.Dialect.(type) {
+	case gorp.PostgresDialect:
+		err = conn.QueryRowContext(ctx, "select pg_try_advisory_lock($1)", getAdvisoryLockKey(name)).Scan(&acquired)
+	default:
+		var res sql.NullInt64
+		err = conn.QueryRowContext(ctx, "select get_lock(?, 0)", lockNamePrefix+name).Scan(&res)
+		acquired = res.Valid && res.Int64 == 1
+	}
+
+	if err != nil || !acquired {
+		_ = conn.Close()
+		return false, err
+	}
+
+	if d.locks == nil {
+		d.locks = make(map[string]*sql.Conn)
+	}
+	d.locks[name] = conn
+
+	return true, nil
+}
+
+func (d *SqlDb) Unlock(name string) (err error) {
+	d.locksMu.Lock()
+	defer d.locksMu.Unlock()
+
+	conn, ok := d.locks[name]
+	if !ok {
+		return
+	}
+
+	delete(d.locks, name)
+
+	switch d.sql.Dialect.(type) {
+	case gorp.PostgresDialect:
+		_, err = conn.ExecContext(context.Background(), "select pg_advisory_unlock($1)", getAdvisoryLockKey(name))
+	default:
+		_, err = conn.ExecContext(context.Background(), "select release_lock(?)", lockNamePrefix+name)
+	}
+
+	closeErr := conn.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	return
+}
@@ -0,0 +1,47 @@
+package sql
+
+import "github.com/semaphoreui/semaphore/db"
+
+func (d *SqlDb) GetMaskingRule(projectID int, ruleID int) (rule db.MaskingRule, err error) {
+	err = d.getObject(projectID, db.MaskingRuleProps, ruleID, &rule)
+	return
+}
+
+func (d *SqlDb) GetMaskingRules(projectID int) (rules []db.MaskingRule, err error) {
+	err = d.getObjects(projectID, db.MaskingRuleProps, db.RetrieveQueryParams{}, nil, &rules)
+	return
+}
+
+func (d *SqlDb) UpdateMaskingRule(rule db.MaskingRule) error {
+	_, err := d.exec(
+		"update project__masking_rule set name=?, type=?, pattern=? where project_id=? and id=?",
+		rule.Name,
+		rule.Type,
+		rule.Pattern,
+		rule.ProjectID,
+		rule.ID)
+
+	return err
+}
+
+func (d *SqlDb) CreateMaskingRule(rule db.MaskingRule) (newRule db.MaskingRule, err error) {
+	insertID, err := d.insert(
+		"id",
+		"insert into project__masking_rule (project_id, name, type, pattern) values (?, ?, ?, ?)",
+		rule.ProjectID,
+		rule.Name,
+		rule.Type,
+		rule.Pattern)
+
+	if err != nil {
+		return
+	}
+
+	newRule = rule
+	newRule.ID = insertID
+	return
+}
+
+func (d *SqlDb) DeleteMaskingRule(projectID int, ruleID int) error {
+	return d.deleteObject(projectID, db.MaskingRuleProps, ruleID)
+}
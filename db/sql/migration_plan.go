@@ -0,0 +1,92 @@
+package sql
+
+import (
+	"regexp"
+
+	"github.com/go-gorp/gorp/v3"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+var (
+	changedTableRE = regexp.MustCompile("(?i)^(?:alter table|update|delete from|insert into|create (?:unique )?index [`\"]?\\w+[`\"]? on)\\s+[`\"]?(\\w+)")
+	// incompatibleQueryRE matches queries which remove or rename columns and tables
+	// used by the previous version of Semaphore.
+	incompatibleQueryRE = regexp.MustCompile(`(?i)\bdrop\s+(table|column)\b|\brename\b|\bchange\b|\bmodify\b`)
+	alterTableRE        = regexp.MustCompile(`(?i)^alter table\b`)
+	notNullRE           = regexp.MustCompile(`(?i)\bnot null\b`)
+	defaultRE           = regexp.MustCompile(`(?i)\bdefault\b`)
+)
+
+// migrationsWithCode contains migrations which convert data by Go code,
+// see ApplyMigration.
+var migrationsWithCode = map[string]bool{
+	"2.8.26":  true,
+	"2.8.42":  true,
+	"2.10.24": true,
+}
+
+func isBackwardCompatibleQuery(query string) bool {
+	if incompatibleQueryRE.MatchString(query) {
+		return false
+	}
+
+	// previous version does not fill new required columns
+	if alterTableRE.MatchString(query) && notNullRE.MatchString(query) && !defaultRE.MatchString(query) {
+		return false
+	}
+
+	return true
+}
+
+// estimateTableRows returns the number of rows from the table statistics,
+// because counting rows of large tables is slow.
+func (d *SqlDb) estimateTableRows(table string) (rows int64, ok bool) {
+	var query string
+
+	switch d.sql.Dialect.(type) {
+	case gorp.PostgresDialect:
+		query = "select reltuples::bigint from pg_class where relname = $1"
+	default:
+		query = "select table_rows from information_schema.tables where table_schema = database() and table_name = ?"
+	}
+
+	res, err := d.sql.SelectNullInt(query, table)
+	if err != nil || !res.Valid || res.Int64 < 0 {
+		return
+	}
+
+	return res.Int64, true
+}
+
+func (d *SqlDb) GetMigrationPlan(version db.Migration) (plan db.MigrationPlan, err error) {
+	plan.Migration = version
+	plan.TableRows = make(map[string]int64)
+	plan.BackwardCompatible = !migrationsWithCode[version.Version]
+
+	for _, query := range getVersionSQL(getVersionPath(version)) {
+		if query == "" {
+			continue
+		}
+
+		plan.Queries = append(plan.Queries, query)
+
+		if !isBackwardCompatibleQuery(query) {
+			plan.BackwardCompatible = false
+		}
+
+		m := changedTableRE.FindStringSubmatch(query)
+		if m == nil {
+			continue
+		}
+
+		if _, ok := plan.TableRows[m[1]]; ok {
+			continue
+		}
+
+		if rows, ok := d.estimateTableRows(m[1]); ok {
+			plan.TableRows[m[1]] = rows
+		}
+	}
+
+	return
+}
@@ -0,0 +1,19 @@
+package sql
+
+import "testing"
+
+func TestIsBackwardCompatibleQuery(t *testing.T) {
+	for query, compatible := range map[string]bool{
+		"alter table `task` add `claimed_by` varchar(100) null":                       true,
+		"alter table `task` add `budget_exceeded` boolean not null default false":     true,
+		"create index `task_status` on `task` (`status`)":                             true,
+		"alter table `task` add `node` varchar(100) not null":                         false,
+		"alter table `task` drop column `node`":                                       false,
+		"alter table `project__template` change `alias` `name` varchar(100) not null": false,
+		"drop table `project__template_schedule`":                                     false,
+	} {
+		if isBackwardCompatibleQuery(query) != compatible {
+			t.Error("invalid compatibility of query", query)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+alter table `project__environment` add `variable_group` boolean not null default false;
+
+alter table `project__template` add `variable_group_ids` text;
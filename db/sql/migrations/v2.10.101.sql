@@ -0,0 +1,13 @@
+create table `project__template_summary` (
+  `template_id` int primary key,
+  `project_id` int not null,
+  `task_count` int not null default 0,
+  `last_task_id` int null,
+  `last_task_status` varchar(255) null,
+  `last_success` datetime null,
+  `days` text null,
+  `updated` datetime not null,
+
+  foreign key (`template_id`) references project__template(`id`) on delete cascade,
+  foreign key (`project_id`) references project(`id`) on delete cascade
+);
@@ -0,0 +1,10 @@
+alter table `user` add `password_changed` datetime null;
+
+create table `user__password_history` (
+  `id` integer primary key autoincrement,
+  `user_id` int not null,
+  `password` varchar(255) not null,
+  `created` datetime not null,
+
+  foreign key (`user_id`) references `user`(`id`) on delete cascade
+);
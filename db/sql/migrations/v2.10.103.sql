@@ -0,0 +1,15 @@
+create table `project__service_account` (
+  `id` integer primary key autoincrement,
+  `project_id` int not null,
+  `name` varchar(100) not null,
+  `template_ids` text null,
+  `token_hash` varchar(64) not null default '',
+  `disabled` boolean not null default false,
+  `created` datetime not null,
+  `user_id` int null,
+
+  foreign key (`project_id`) references project(`id`) on delete cascade,
+  foreign key (`user_id`) references `user`(`id`) on delete set null
+);
+
+alter table `task` add `service_account_id` int null references project__service_account(`id`) on delete set null;
@@ -0,0 +1,13 @@
+create table `task__object_version` (
+  `id` integer primary key autoincrement,
+  `task_id` int not null,
+  `project_id` int not null,
+  `object_type` varchar(50) not null,
+  `object_id` int not null,
+  `version` varchar(64) not null,
+
+  foreign key (`task_id`) references task(`id`) on delete cascade,
+  foreign key (`project_id`) references project(`id`) on delete cascade
+);
+
+create index `task__object_version_task_id` on `task__object_version` (`task_id`);
@@ -0,0 +1,16 @@
+create table `project__task_host` (
+  `id` integer primary key autoincrement,
+  `project_id` int not null,
+  `template_id` int not null,
+  `task_id` int not null,
+  `host` varchar(255) not null,
+  `host_groups` text,
+  `status` varchar(50) not null,
+  `task_status` varchar(255) not null,
+  `start` datetime,
+  `finished` datetime not null,
+
+  foreign key (`project_id`) references project(`id`) on delete cascade
+);
+
+create index `project__task_host_host` on `project__task_host` (`project_id`, `host`);
@@ -0,0 +1,18 @@
+create table `project__template_upload` (
+  `id` integer primary key autoincrement,
+  `project_id` int not null,
+  `template_id` int not null,
+  `path` varchar(255) not null,
+  `mode` varchar(10) not null default '',
+  `size` bigint not null,
+  `sha256` varchar(64) not null,
+  `data` longtext not null,
+  `user_id` int null,
+  `created` datetime not null,
+  `updated` datetime not null,
+
+  unique (`template_id`, `path`),
+  foreign key (`project_id`) references project(`id`) on delete cascade,
+  foreign key (`template_id`) references project__template(`id`) on delete cascade,
+  foreign key (`user_id`) references `user`(`id`) on delete set null
+);
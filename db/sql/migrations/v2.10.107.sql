@@ -0,0 +1,12 @@
+create table `announcement` (
+  `id` integer primary key autoincrement,
+  `title` varchar(255) not null,
+  `message` text,
+  `severity` varchar(20) not null,
+  `starts` datetime null,
+  `ends` datetime null,
+  `user_id` int null,
+  `created` datetime not null,
+
+  foreign key (`user_id`) references `user`(`id`) on delete set null
+);
@@ -0,0 +1,16 @@
+alter table `project__template` add `artifacts` text;
+
+create table `task__artifact` (
+  `id` integer primary key autoincrement,
+  `task_id` int not null,
+  `path` varchar(1000) not null,
+  `registry` varchar(20) not null,
+  `version` varchar(255) not null,
+  `name` varchar(255) not null,
+  `url` text not null,
+  `size` bigint not null,
+  `sha256` varchar(64) not null,
+  `published` datetime not null,
+
+  foreign key (`task_id`) references task(`id`) on delete cascade
+);
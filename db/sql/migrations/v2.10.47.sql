@@ -0,0 +1,15 @@
+create table project__integration_delivery (
+  `id` integer primary key autoincrement,
+  `project_id` int not null,
+  `integration_id` int not null,
+  `created` datetime not null,
+  `headers` text,
+  `payload` longtext,
+  `status` varchar(20) not null,
+  `message` text,
+  `task_id` int,
+
+  foreign key (`project_id`) references project(`id`) on delete cascade,
+  foreign key (`integration_id`) references project__integration(`id`) on delete cascade,
+  foreign key (`task_id`) references task(`id`) on delete set null
+);
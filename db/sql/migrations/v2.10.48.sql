@@ -0,0 +1,19 @@
+create table project__template_tag (
+  `template_id` int not null,
+  `tag` varchar(255) not null,
+
+  primary key (`template_id`, `tag`),
+  foreign key (`template_id`) references project__template(`id`) on delete cascade
+);
+
+create table project__template_view (
+  `template_id` int not null,
+  `view_id` int not null,
+  `position` int not null default 0,
+
+  primary key (`template_id`, `view_id`),
+  foreign key (`template_id`) references project__template(`id`) on delete cascade,
+  foreign key (`view_id`) references project__view(`id`) on delete cascade
+);
+
+insert into project__template_view (template_id, view_id) select id, view_id from project__template where view_id is not null;
@@ -0,0 +1 @@
+alter table `project__environment` add `project_default` boolean not null default false;
@@ -0,0 +1,12 @@
+create table task__finding (
+  `id` integer primary key autoincrement,
+  `task_id` int not null,
+  `tool` varchar(50) not null,
+  `severity` varchar(20) not null,
+  `rule` varchar(255) not null default '',
+  `file` varchar(1000) not null default '',
+  `line` int not null default 0,
+  `message` text,
+
+  foreign key (`task_id`) references task(`id`) on delete cascade
+);
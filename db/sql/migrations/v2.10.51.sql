@@ -0,0 +1 @@
+alter table `project__integration` add `commit_status` varchar(20) not null default '';
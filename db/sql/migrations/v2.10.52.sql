@@ -0,0 +1,10 @@
+create table `project__deployment_environment` (
+  `id` integer primary key autoincrement,
+  `project_id` int not null,
+  `name` varchar(100) not null,
+  `position` int not null default 0,
+
+  foreign key (`project_id`) references project(`id`) on delete cascade
+);
+
+alter table `task` add `deployment_environment_id` int null references project__deployment_environment(`id`) on delete set null;
@@ -0,0 +1 @@
+alter table `task` add `rollback_task_id` int null references task(`id`) on delete set null;
@@ -0,0 +1,3 @@
+alter table `project__template` add `max_duration` int not null default 0;
+alter table `project__template` add `duration_factor` int not null default 0;
+alter table `task` add `budget_exceeded` boolean not null default false;
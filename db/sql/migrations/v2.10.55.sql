@@ -0,0 +1,9 @@
+create table `project__masking_rule` (
+  `id` integer primary key autoincrement,
+  `project_id` int not null,
+  `name` varchar(100) not null,
+  `type` varchar(20) not null,
+  `pattern` varchar(1000) not null,
+
+  foreign key (`project_id`) references project(`id`) on delete cascade
+);
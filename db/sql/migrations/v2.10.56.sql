@@ -0,0 +1,2 @@
+alter table `event` add `integration_id` int null references project__integration(`id`) on delete set null;
+alter table `event` add `action` varchar(20) not null default '';
@@ -0,0 +1 @@
+alter table `project__schedule` add `last_fired` datetime null;
@@ -0,0 +1 @@
+alter table `project` add `calendar_token` varchar(44) null;
@@ -0,0 +1 @@
+alter table `task` add `claimed_by` varchar(100) null;
@@ -0,0 +1 @@
+alter table `task` add `output_object` varchar(255) null;
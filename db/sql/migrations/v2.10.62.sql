@@ -0,0 +1,3 @@
+alter table `project` add `default_ssh_key_id` int null;
+alter table `project` add `default_become_key_id` int null;
+alter table `project` add `default_vault_key_id` int null;
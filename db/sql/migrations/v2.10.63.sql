@@ -0,0 +1,22 @@
+create table project__run_record (
+  `id` integer primary key autoincrement,
+  `project_id` int not null,
+  `seq` int not null,
+  `task_id` int not null,
+  `template_id` int not null,
+  `template_name` varchar(100) not null,
+  `user_id` int,
+  `username` varchar(255),
+  `integration_id` int,
+  `schedule_id` int,
+  `commit_hash` varchar(64),
+  `inputs` longtext not null,
+  `status` varchar(255) not null,
+  `start` datetime,
+  `end` datetime,
+  `created` datetime not null,
+  `prev_hash` varchar(64) not null,
+  `hash` varchar(64) not null,
+
+  unique (`project_id`, `seq`)
+);
@@ -0,0 +1 @@
+alter table `user` add `locale` varchar(10) not null default '';
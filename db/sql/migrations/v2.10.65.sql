@@ -0,0 +1 @@
+alter table `project__template` add `secrets_scan` varchar(10) not null default '';
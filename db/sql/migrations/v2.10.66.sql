@@ -0,0 +1,28 @@
+create table project__adhoc_command (
+  `id` integer primary key autoincrement,
+  `project_id` int not null,
+  `inventory_id` int not null,
+  `user_id` int null,
+  `key_id` int null,
+  `module` varchar(255) not null,
+  `args` text,
+  `hosts_limit` varchar(255) not null default '',
+  `become` boolean not null default false,
+  `status` varchar(255) not null,
+  `created` datetime not null,
+  `start` datetime null,
+  `end` datetime null,
+
+  foreign key (`project_id`) references project(`id`) on delete cascade,
+  foreign key (`inventory_id`) references project__inventory(`id`) on delete cascade,
+  foreign key (`user_id`) references `user`(`id`) on delete set null,
+  foreign key (`key_id`) references access_key(`id`) on delete set null
+);
+
+create table project__adhoc_command__output (
+  `command_id` int not null,
+  `time` datetime not null,
+  `output` longtext not null,
+
+  foreign key (`command_id`) references project__adhoc_command(`id`) on delete cascade
+);
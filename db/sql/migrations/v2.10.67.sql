@@ -0,0 +1 @@
+alter table `project__template` add `concurrency_group` varchar(100) not null default '';
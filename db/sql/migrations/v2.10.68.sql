@@ -0,0 +1 @@
+alter table `task` add `run_at` datetime null;
@@ -0,0 +1,4 @@
+alter table `project__template` add `change_window_start` varchar(5) not null default '';
+alter table `project__template` add `change_window_end` varchar(5) not null default '';
+alter table `project__template` add `abort_template_id` int null references `project__template`(`id`) on delete set null;
+alter table `task` add `change_window_closed` boolean not null default false;
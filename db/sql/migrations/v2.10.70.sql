@@ -0,0 +1,2 @@
+alter table `project__integration` add `previous_auth_secret_id` int null references access_key(`id`) on delete set null;
+alter table `project__integration` add `previous_auth_secret_expires` datetime null;
@@ -0,0 +1,2 @@
+alter table `task` add `hosts_total` int not null default 0;
+alter table `task` add `hosts_unreachable` int not null default 0;
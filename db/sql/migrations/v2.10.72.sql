@@ -0,0 +1 @@
+alter table `project__environment` add `json_schema` text;
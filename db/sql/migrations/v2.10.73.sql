@@ -0,0 +1,13 @@
+create table task__share_link (
+  `id` integer primary key autoincrement,
+  `project_id` int not null,
+  `task_id` int not null,
+  `user_id` int,
+  `token_hash` varchar(64) not null,
+  `created` datetime not null,
+  `expires` datetime not null,
+
+  foreign key (`project_id`) references project(`id`) on delete cascade,
+  foreign key (`task_id`) references task(`id`) on delete cascade,
+  foreign key (`user_id`) references `user`(`id`) on delete set null
+);
@@ -0,0 +1 @@
+alter table `project__inventory` add `ssh_options` text;
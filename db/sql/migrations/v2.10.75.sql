@@ -0,0 +1 @@
+alter table `project__template` add `secret_files` text;
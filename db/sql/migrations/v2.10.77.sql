@@ -0,0 +1,2 @@
+alter table `project__schedule` add `type` varchar(20) not null default 'cron';
+alter table `project__schedule` add `interval_minutes` int not null default 0;
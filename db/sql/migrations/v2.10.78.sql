@@ -0,0 +1,2 @@
+alter table `project` add `alert_digest` varchar(100) not null default '';
+alter table `project` add `alert_digest_sent` datetime null;
@@ -0,0 +1,2 @@
+alter table `project__user` add `expires_at` datetime null;
+alter table `project` add `access_review` varchar(100) not null default '';
@@ -0,0 +1,2 @@
+alter table `access_key` add `secret_changed` datetime null;
+alter table `project` add `key_rotation_days` int not null default 0;
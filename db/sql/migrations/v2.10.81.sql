@@ -0,0 +1,2 @@
+alter table `task` add `parent_task_id` int null references task(`id`) on delete cascade;
+alter table `task` add `canary_batch` varchar(20) not null default '';
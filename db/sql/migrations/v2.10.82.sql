@@ -0,0 +1,12 @@
+create table `project__host_exclusion` (
+  `id` integer primary key autoincrement,
+  `project_id` int not null,
+  `host` varchar(255) not null,
+  `reason` varchar(1000) not null default '',
+  `expires_at` datetime null,
+  `created` datetime not null,
+  `user_id` int null,
+
+  foreign key (`project_id`) references project(`id`) on delete cascade,
+  foreign key (`user_id`) references `user`(`id`) on delete set null
+);
@@ -0,0 +1,9 @@
+create table `task__run_output` (
+  `id` integer primary key autoincrement,
+  `task_id` int not null,
+  `name` varchar(255) not null,
+  `type` varchar(20) not null,
+  `value` text not null,
+
+  foreign key (`task_id`) references task(`id`) on delete cascade
+);
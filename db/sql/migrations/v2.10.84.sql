@@ -0,0 +1,2 @@
+alter table `task` add `output_offset` int not null default 0;
+alter table `task` add `checkpoint_at` datetime null;
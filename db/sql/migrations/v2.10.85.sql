@@ -0,0 +1 @@
+alter table `project` add `protected_branches` varchar(1000) not null default '';
@@ -0,0 +1,8 @@
+create table `task__terraform_plan_change` (
+  `id` integer primary key autoincrement,
+  `task_id` int not null,
+  `address` varchar(1000) not null,
+  `action` varchar(20) not null,
+
+  foreign key (`task_id`) references task(`id`) on delete cascade
+);
@@ -0,0 +1 @@
+alter table `user__token` add `role` varchar(20) not null default '';
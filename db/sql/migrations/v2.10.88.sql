@@ -0,0 +1 @@
+alter table `access_key` add `inventory_id` int null references project__inventory(`id`) on delete cascade;
@@ -0,0 +1 @@
+alter table `task` add `preparation_steps` text null;
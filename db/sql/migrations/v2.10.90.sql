@@ -0,0 +1,3 @@
+alter table `project__schedule` add `disable_after_failures` int not null default 0;
+alter table `project__schedule` add `consecutive_failures` int not null default 0;
+alter table `project__schedule` add `auto_disabled` boolean not null default false;
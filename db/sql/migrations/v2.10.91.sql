@@ -0,0 +1,2 @@
+alter table `task` add `tasks_total` int not null default 0;
+alter table `task` add `tasks_done` int not null default 0;
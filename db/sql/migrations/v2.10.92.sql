@@ -0,0 +1,9 @@
+create table `idempotency_key` (
+  `id` varchar(64) primary key,
+  `request_hash` varchar(64) not null,
+  `status` int not null default 0,
+  `response` text not null,
+  `created` datetime not null
+);
+
+create index `idempotency_key_created_idx` on `idempotency_key` (`created`);
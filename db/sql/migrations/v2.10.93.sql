@@ -0,0 +1 @@
+alter table `task` add `interactive_become` boolean not null default false;
@@ -0,0 +1,11 @@
+create table `project__template_doc` (
+  `template_id` int primary key,
+  `project_id` int not null,
+  `playbook` varchar(255) not null,
+  `commit_hash` varchar(64),
+  `updated` datetime not null,
+  `content` text not null,
+
+  foreign key (`template_id`) references project__template(`id`) on delete cascade,
+  foreign key (`project_id`) references project(`id`) on delete cascade
+);
@@ -0,0 +1,35 @@
+create table `project__task_metric` (
+  `id` integer primary key autoincrement,
+  `project_id` int not null,
+  `template_id` int not null,
+  `task_id` int not null,
+  `status` varchar(255) not null,
+  `finished` datetime not null,
+  `duration` int not null default 0,
+  `hosts_total` int not null default 0,
+  `hosts_changed` int not null default 0,
+  `hosts_failed` int not null default 0,
+  `output_size` bigint not null default 0,
+
+  foreign key (`project_id`) references project(`id`) on delete cascade
+);
+
+create index `task_metric_finished_idx` on `project__task_metric` (`finished`);
+
+create table `project__task_metric_daily` (
+  `id` integer primary key autoincrement,
+  `project_id` int not null,
+  `template_id` int not null,
+  `day` datetime not null,
+  `task_count` int not null default 0,
+  `success_count` int not null default 0,
+  `fail_count` int not null default 0,
+  `duration_sum` bigint not null default 0,
+  `duration_max` int not null default 0,
+  `hosts_changed` int not null default 0,
+  `hosts_failed` int not null default 0,
+  `output_size` bigint not null default 0,
+
+  unique (`project_id`, `template_id`, `day`),
+  foreign key (`project_id`) references project(`id`) on delete cascade
+);
@@ -0,0 +1,17 @@
+alter table `project__template` add `approval_emails` boolean not null default false;
+
+create table `task__approval` (
+  `id` integer primary key autoincrement,
+  `project_id` int not null,
+  `task_id` int not null,
+  `user_id` int not null,
+  `token_hash` varchar(64) not null,
+  `created` datetime not null,
+  `expires` datetime not null,
+  `used` datetime null,
+  `decision` varchar(20) not null default '',
+
+  foreign key (`project_id`) references project(`id`) on delete cascade,
+  foreign key (`task_id`) references task(`id`) on delete cascade,
+  foreign key (`user_id`) references `user`(`id`) on delete cascade
+);
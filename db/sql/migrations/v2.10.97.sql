@@ -0,0 +1 @@
+alter table `project__template` add `run_conflict_policy` varchar(20) not null default '';
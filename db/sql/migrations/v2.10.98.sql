@@ -0,0 +1,12 @@
+create table `disk_usage` (
+  `id` integer primary key autoincrement,
+  `runner_id` int null,
+  `project_id` int null,
+  `kind` varchar(50) not null,
+  `size` bigint not null default 0,
+  `entries` int not null default 0,
+  `updated` datetime not null,
+
+  foreign key (`runner_id`) references runner(`id`) on delete cascade,
+  foreign key (`project_id`) references project(`id`) on delete cascade
+);
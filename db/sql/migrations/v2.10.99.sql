@@ -0,0 +1,20 @@
+create table `project__pull_host` (
+  `id` integer primary key autoincrement,
+  `project_id` int not null,
+  `template_id` int not null,
+  `name` varchar(255) not null,
+  `token_hash` varchar(64) not null,
+  `created` datetime not null,
+  `repository_token_hash` varchar(64) not null default '',
+  `repository_token_expires` datetime null,
+  `last_report` datetime null,
+  `last_status` varchar(255) not null default '',
+  `last_task_id` int null,
+
+  unique (`template_id`, `name`),
+  foreign key (`project_id`) references project(`id`) on delete cascade,
+  foreign key (`template_id`) references project__template(`id`) on delete cascade
+);
+
+create index `pull_host_token_hash_idx` on `project__pull_host` (`token_hash`);
+create index `pull_host_repository_token_hash_idx` on `project__pull_host` (`repository_token_hash`);
@@ -0,0 +1,84 @@
+package sql
+
+import (
+	"fmt"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+type orphanedReferenceRow struct {
+	ID        int  `db:"id"`
+	ProjectID *int `db:"project_id"`
+	RefID     int  `db:"ref_id"`
+}
+
+// orphanedReferenceQueries select rows of each kind which refer to deleted objects.
+var orphanedReferenceQueries = []struct {
+	kind  db.OrphanKind
+	query string
+}{
+	{
+		kind: db.OrphanTaskTemplate,
+		query: "select t.id, t.project_id, t.template_id as ref_id from task t " +
+			"left join project__template tpl on tpl.id = t.template_id " +
+			"where tpl.id is null order by t.id",
+	},
+	{
+		kind: db.OrphanKeyEnvironment,
+		query: "select k.id, k.project_id, k.environment_id as ref_id from access_key k " +
+			"left join project__environment e on e.id = k.environment_id " +
+			"where k.environment_id is not null and e.id is null order by k.id",
+	},
+	{
+		kind: db.OrphanKeyUser,
+		query: "select k.id, k.project_id, k.user_id as ref_id from access_key k " +
+			"left join `user` u on u.id = k.user_id " +
+			"where k.user_id is not null and u.id is null order by k.id",
+	},
+}
+
+func (d *SqlDb) GetOrphanedReferences() (refs []db.OrphanedReference, err error) {
+	refs = make([]db.OrphanedReference, 0)
+
+	for _, q := range orphanedReferenceQueries {
+		var rows []orphanedReferenceRow
+		if _, err = d.selectAll(&rows, q.query); err != nil {
+			return
+		}
+
+		for _, row := range rows {
+			projectID := 0
+			if row.ProjectID != nil {
+				projectID = *row.ProjectID
+			}
+			refs = append(refs, db.NewOrphanedReference(q.kind, projectID, row.ID, row.RefID))
+		}
+	}
+
+	return
+}
+
+func (d *SqlDb) FixOrphanedReference(ref db.OrphanedReference) (err error) {
+	switch ref.Kind {
+	case db.OrphanTaskTemplate:
+		// the task is not found by GetTask without its template
+		return d.deleteTaskWithOutputs(ref.ObjectID)
+	case db.OrphanKeyEnvironment:
+		var key db.AccessKey
+		err = d.selectOne(&key, "select * from access_key where id=?", ref.ObjectID)
+		if err != nil {
+			return
+		}
+
+		if _, err = d.exec("delete from access_key where id=?", ref.ObjectID); err != nil {
+			return
+		}
+
+		return db.ReleaseSecret(key.Secret)
+	case db.OrphanKeyUser:
+		_, err = d.exec("update access_key set user_id=null where id=? and user_id=?", ref.ObjectID, ref.RefID)
+		return
+	default:
+		return fmt.Errorf("unknown kind of orphaned reference %s", ref.Kind)
+	}
+}
@@ -110,11 +110,34 @@ func (d *SqlDb) DeleteProject(projectID int) error {
 
 func (d *SqlDb) UpdateProject(project db.Project) error {
 	_, err := d.exec(
-		"update project set name=?, alert=?, alert_chat=?, max_parallel_tasks=? where id=?",
+		"update project set name=?, alert=?, alert_chat=?, alert_digest=?, access_review=?, key_rotation_days=?, max_parallel_tasks=?, "+
+			"default_ssh_key_id=?, default_become_key_id=?, default_vault_key_id=?, protected_branches=? where id=?",
 		project.Name,
 		project.Alert,
 		project.AlertChat,
+		project.AlertDigest,
+		project.AccessReview,
+		project.KeyRotationDays,
 		project.MaxParallelTasks,
+		project.DefaultSSHKeyID,
+		project.DefaultBecomeKeyID,
+		project.DefaultVaultKeyID,
+		project.ProtectedBranches,
 		project.ID)
 	return err
 }
+
+func (d *SqlDb) SetProjectArchived(projectID int, archived bool) error {
+	_, err := d.exec("update project set archived=? where id=?", archived, projectID)
+	return err
+}
+
+func (d *SqlDb) SetProjectAlertDigestSent(projectID int, sent time.Time) error {
+	_, err := d.exec("update project set alert_digest_sent=? where id=?", sent, projectID)
+	return err
+}
+
+func (d *SqlDb) SetProjectCalendarToken(projectID int, token *string) error {
+	_, err := d.exec("update project set calendar_token=? where id=?", token, projectID)
+	return err
+}
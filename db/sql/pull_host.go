@@ -0,0 +1,86 @@
+package sql
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) CreatePullHost(host db.PullHost) (newHost db.PullHost, err error) {
+	if err = host.Validate(); err != nil {
+		return
+	}
+
+	insertID, err := d.insert(
+		"id",
+		"insert into project__pull_host (project_id, template_id, name, token_hash, created) values (?, ?, ?, ?, ?)",
+		host.ProjectID,
+		host.TemplateID,
+		host.Name,
+		host.TokenHash,
+		host.Created.UTC())
+
+	if err != nil {
+		return
+	}
+
+	newHost = host
+	newHost.ID = insertID
+	return
+}
+
+func (d *SqlDb) GetPullHost(projectID int, hostID int) (host db.PullHost, err error) {
+	err = d.getObject(projectID, db.PullHostProps, hostID, &host)
+	return
+}
+
+func (d *SqlDb) GetPullHosts(projectID int, templateID int) (hosts []db.PullHost, err error) {
+	hosts = make([]db.PullHost, 0)
+	_, err = d.selectAll(&hosts,
+		"select * from project__pull_host where project_id=? and template_id=? order by name",
+		projectID,
+		templateID)
+	return
+}
+
+func (d *SqlDb) getPullHostBy(column string, tokenHash string) (host db.PullHost, err error) {
+	if tokenHash == "" {
+		err = db.ErrNotFound
+		return
+	}
+
+	err = d.selectOne(&host, "select * from project__pull_host where "+column+"=?", tokenHash)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
+func (d *SqlDb) GetPullHostByToken(tokenHash string) (db.PullHost, error) {
+	return d.getPullHostBy("token_hash", tokenHash)
+}
+
+func (d *SqlDb) GetPullHostByRepositoryToken(tokenHash string) (db.PullHost, error) {
+	return d.getPullHostBy("repository_token_hash", tokenHash)
+}
+
+func (d *SqlDb) UpdatePullHost(host db.PullHost) error {
+	_, err := d.exec("update project__pull_host set "+
+		"repository_token_hash=?, repository_token_expires=?, last_report=?, last_status=?, last_task_id=? "+
+		"where project_id=? and id=?",
+		host.RepositoryTokenHash,
+		host.RepositoryTokenExpires,
+		host.LastReport,
+		host.LastStatus,
+		host.LastTaskID,
+		host.ProjectID,
+		host.ID)
+	return err
+}
+
+func (d *SqlDb) DeletePullHost(projectID int, hostID int) error {
+	return d.deleteObject(projectID, db.PullHostProps, hostID)
+}
@@ -0,0 +1,39 @@
+package sql
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) CreateRunOutput(output db.RunOutput) (newOutput db.RunOutput, err error) {
+	insertID, err := d.insert(
+		"id",
+		"insert into task__run_output (task_id, name, type, value) values (?, ?, ?, ?)",
+		output.TaskID,
+		output.Name,
+		output.Type,
+		output.Value)
+
+	if err != nil {
+		return
+	}
+
+	newOutput = output
+	newOutput.ID = insertID
+	return
+}
+
+func (d *SqlDb) GetRunOutputs(projectID int, taskID int) (outputs []db.RunOutput, err error) {
+	// check if task exists in the project
+	_, err = d.GetTask(projectID, taskID)
+
+	if err != nil {
+		return
+	}
+
+	outputs = make([]db.RunOutput, 0)
+
+	_, err = d.selectAll(&outputs,
+		"select * from task__run_output where task_id=? order by id asc",
+		taskID)
+	return
+}
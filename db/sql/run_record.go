@@ -0,0 +1,116 @@
+package sql
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// runRecordAppendAttempts is the number of attempts to append the record
+// when the other node appends the record to the same chain concurrently.
+const runRecordAppendAttempts = 5
+
+func (d *SqlDb) getLastRunRecord(projectID int) (*db.RunRecord, error) {
+	var record db.RunRecord
+	err := d.selectOne(&record,
+		"select * from project__run_record where project_id=? order by seq desc limit 1",
+		projectID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (d *SqlDb) AppendRunRecord(record db.RunRecord) (newRecord db.RunRecord, err error) {
+	for i := 0; i < runRecordAppendAttempts; i++ {
+		var prev *db.RunRecord
+		prev, err = d.getLastRunRecord(record.ProjectID)
+		if err != nil {
+			return
+		}
+
+		newRecord = record
+		newRecord.ChainTo(prev)
+
+		// unique index on project_id and seq rejects the record
+		// if the chain was extended after getLastRunRecord
+		newRecord.ID, err = d.insert(
+			"id",
+			"insert into project__run_record "+
+				"(project_id, seq, task_id, template_id, template_name, user_id, username, integration_id, schedule_id, "+
+				"commit_hash, inputs, status, start, `end`, created, prev_hash, hash) values "+
+				"(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			newRecord.ProjectID,
+			newRecord.Seq,
+			newRecord.TaskID,
+			newRecord.TemplateID,
+			newRecord.TemplateName,
+			newRecord.UserID,
+			newRecord.Username,
+			newRecord.IntegrationID,
+			newRecord.ScheduleID,
+			newRecord.CommitHash,
+			newRecord.Inputs,
+			newRecord.Status,
+			newRecord.Start,
+			newRecord.End,
+			newRecord.Created,
+			newRecord.PrevHash,
+			newRecord.Hash)
+
+		if err == nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (d *SqlDb) GetRunRecords(projectID int, params db.RetrieveQueryParams) (records []db.RunRecord, err error) {
+	q := squirrel.Select("r.*").
+		From("project__run_record as r").
+		Where(squirrel.Eq{"r.project_id": projectID}).
+		OrderBy("r.seq")
+
+	if params.Count > 0 {
+		q = q.Limit(uint64(params.Count)).Offset(uint64(params.Offset))
+	}
+
+	query, args, err := q.ToSql()
+
+	if err != nil {
+		return
+	}
+
+	records = make([]db.RunRecord, 0)
+	_, err = d.selectAll(&records, query, args...)
+
+	return
+}
+
+func (d *SqlDb) GetRunRecord(projectID int, recordID int) (record db.RunRecord, err error) {
+	query, args, err := squirrel.Select("r.*").
+		From("project__run_record as r").
+		Where(squirrel.Eq{"r.id": recordID, "r.project_id": projectID}).
+		ToSql()
+
+	if err != nil {
+		return
+	}
+
+	err = d.selectOne(&record, query, args...)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		err = db.ErrNotFound
+	}
+
+	return
+}
@@ -2,20 +2,32 @@ package sql
 
 import (
 	"database/sql"
+	"errors"
 	"github.com/semaphoreui/semaphore/db"
+	"time"
 )
 
+func getScheduleType(schedule db.Schedule) db.ScheduleType {
+	if schedule.Type == "" {
+		return db.ScheduleTypeCron
+	}
+	return schedule.Type
+}
+
 func (d *SqlDb) CreateSchedule(schedule db.Schedule) (newSchedule db.Schedule, err error) {
 	insertID, err := d.insert(
 		"id",
-		"insert into project__schedule (project_id, template_id, cron_format, repository_id, `name`, `active`)"+
-			"values (?, ?, ?, ?, ?, ?)",
+		"insert into project__schedule (project_id, template_id, `type`, cron_format, interval_minutes, repository_id, `name`, `active`, disable_after_failures)"+
+			"values (?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		schedule.ProjectID,
 		schedule.TemplateID,
+		getScheduleType(schedule),
 		schedule.CronFormat,
+		schedule.IntervalMinutes,
 		schedule.RepositoryID,
 		schedule.Name,
-		schedule.Active)
+		schedule.Active,
+		schedule.DisableAfterFailures)
 
 	if err != nil {
 		return
@@ -39,18 +51,24 @@ func (d *SqlDb) SetScheduleLastCommitHash(projectID int, scheduleID int, lastCom
 
 func (d *SqlDb) UpdateSchedule(schedule db.Schedule) error {
 	_, err := d.exec("update project__schedule set "+
+		"`type`=?, "+
 		"cron_format=?, "+
+		"interval_minutes=?, "+
 		"repository_id=?, "+
 		"template_id=?, "+
 		"`name`=?, "+
 		"`active`=?, "+
+		"disable_after_failures=?, "+
 		"last_commit_hash = NULL "+
 		"where project_id=? and id=?",
+		getScheduleType(schedule),
 		schedule.CronFormat,
+		schedule.IntervalMinutes,
 		schedule.RepositoryID,
 		schedule.TemplateID,
 		schedule.Name,
 		schedule.Active,
+		schedule.DisableAfterFailures,
 		schedule.ProjectID,
 		schedule.ID)
 	return err
@@ -70,13 +88,27 @@ func (d *SqlDb) GetSchedule(projectID int, scheduleID int) (template db.Schedule
 	return
 }
 
+func (d *SqlDb) GetScheduleLastTask(projectID int, scheduleID int) (task db.Task, err error) {
+	err = d.selectOne(
+		&task,
+		"select * from task where project_id=? and schedule_id=? order by id desc limit 1",
+		projectID,
+		scheduleID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
 func (d *SqlDb) DeleteSchedule(projectID int, scheduleID int) error {
 	_, err := d.exec("delete from project__schedule where project_id=? and id=?", projectID, scheduleID)
 	return err
 }
 
 func (d *SqlDb) GetSchedules() (schedules []db.Schedule, err error) {
-	_, err = d.selectAll(&schedules, "select * from project__schedule where cron_format != ''")
+	_, err = d.selectAll(&schedules, "select * from project__schedule where cron_format != '' or `type` = ?", db.ScheduleTypeInterval)
 	return
 }
 
@@ -98,6 +130,15 @@ func (d *SqlDb) GetTemplateSchedules(projectID int, templateID int) (schedules [
 }
 
 func (d *SqlDb) SetScheduleActive(projectID int, scheduleID int, active bool) error {
+	if active {
+		_, err := d.exec("update project__schedule set `active`=?, consecutive_failures=0, auto_disabled=? where project_id=? and id=?",
+			true,
+			false,
+			projectID,
+			scheduleID)
+		return err
+	}
+
 	_, err := d.exec("update project__schedule set `active`=? where project_id=? and id=?",
 		active,
 		projectID,
@@ -105,6 +146,24 @@ func (d *SqlDb) SetScheduleActive(projectID int, scheduleID int, active bool) er
 	return err
 }
 
+func (d *SqlDb) SetScheduleFailures(projectID int, scheduleID int, failures int, autoDisabled bool) error {
+	if autoDisabled {
+		_, err := d.exec("update project__schedule set consecutive_failures=?, `active`=?, auto_disabled=? where project_id=? and id=?",
+			failures,
+			false,
+			true,
+			projectID,
+			scheduleID)
+		return err
+	}
+
+	_, err := d.exec("update project__schedule set consecutive_failures=? where project_id=? and id=?",
+		failures,
+		projectID,
+		scheduleID)
+	return err
+}
+
 func (d *SqlDb) SetScheduleCommitHash(projectID int, scheduleID int, hash string) error {
 	_, err := d.exec("update project__schedule set last_commit_hash=? where project_id=? and id=?",
 		hash,
@@ -112,3 +171,11 @@ func (d *SqlDb) SetScheduleCommitHash(projectID int, scheduleID int, hash string
 		scheduleID)
 	return err
 }
+
+func (d *SqlDb) SetScheduleLastFired(projectID int, scheduleID int, lastFired time.Time) error {
+	_, err := d.exec("update project__schedule set last_fired=? where project_id=? and id=?",
+		lastFired,
+		projectID,
+		scheduleID)
+	return err
+}
@@ -0,0 +1,85 @@
+package sql
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// SecretStorage keeps the secrets of access keys in the separate MySQL or PostgreSQL database.
+type SecretStorage struct {
+	sql         *sql.DB
+	placeholder squirrel.PlaceholderFormat
+}
+
+func OpenSecretStorage(cfg *util.SecretsDbConfig) (*SecretStorage, error) {
+	connectionString, err := cfg.GetConnectionString()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := sql.Open(cfg.Dialect, connectionString)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = conn.Ping(); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	_, err = conn.Exec("create table if not exists secret (id varchar(64) primary key, secret text not null)")
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	s := &SecretStorage{sql: conn, placeholder: squirrel.Question}
+	if cfg.Dialect == util.DbDriverPostgres {
+		s.placeholder = squirrel.Dollar
+	}
+
+	return s, nil
+}
+
+func (s *SecretStorage) GetSecret(id string) (secret string, err error) {
+	err = squirrel.Select("secret").
+		From("secret").
+		Where("id=?", id).
+		PlaceholderFormat(s.placeholder).
+		RunWith(s.sql).
+		QueryRow().
+		Scan(&secret)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
+func (s *SecretStorage) SetSecret(id string, secret string) error {
+	_, err := squirrel.Insert("secret").
+		Columns("id", "secret").
+		Values(id, secret).
+		PlaceholderFormat(s.placeholder).
+		RunWith(s.sql).
+		Exec()
+	return err
+}
+
+func (s *SecretStorage) DeleteSecret(id string) error {
+	_, err := squirrel.Delete("secret").
+		Where("id=?", id).
+		PlaceholderFormat(s.placeholder).
+		RunWith(s.sql).
+		Exec()
+	return err
+}
+
+func (s *SecretStorage) Close() error {
+	return s.sql.Close()
+}
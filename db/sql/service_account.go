@@ -0,0 +1,66 @@
+package sql
+
+import (
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) GetServiceAccount(projectID int, accountID int) (account db.ServiceAccount, err error) {
+	err = d.getObject(projectID, db.ServiceAccountProps, accountID, &account)
+	return
+}
+
+func (d *SqlDb) GetServiceAccounts(projectID int) (accounts []db.ServiceAccount, err error) {
+	err = d.getObjects(projectID, db.ServiceAccountProps, db.RetrieveQueryParams{}, nil, &accounts)
+	return
+}
+
+func (d *SqlDb) CreateServiceAccount(account db.ServiceAccount) (newAccount db.ServiceAccount, err error) {
+	account.Created = time.Now().UTC()
+
+	insertID, err := d.insert(
+		"id",
+		"insert into project__service_account (project_id, name, template_ids, token_hash, disabled, created, user_id) values (?, ?, ?, ?, ?, ?, ?)",
+		account.ProjectID,
+		account.Name,
+		account.TemplateIDs,
+		account.TokenHash,
+		account.Disabled,
+		account.Created,
+		account.UserID)
+
+	if err != nil {
+		return
+	}
+
+	newAccount = account
+	newAccount.ID = insertID
+	return
+}
+
+func (d *SqlDb) UpdateServiceAccount(account db.ServiceAccount) error {
+	_, err := d.exec(
+		"update project__service_account set name=?, template_ids=?, disabled=? where project_id=? and id=?",
+		account.Name,
+		account.TemplateIDs,
+		account.Disabled,
+		account.ProjectID,
+		account.ID)
+
+	return err
+}
+
+func (d *SqlDb) SetServiceAccountToken(projectID int, accountID int, tokenHash string) error {
+	_, err := d.exec(
+		"update project__service_account set token_hash=? where project_id=? and id=?",
+		tokenHash,
+		projectID,
+		accountID)
+
+	return err
+}
+
+func (d *SqlDb) DeleteServiceAccount(projectID int, accountID int) error {
+	return d.deleteObject(projectID, db.ServiceAccountProps, accountID)
+}
@@ -70,3 +70,13 @@ func (d *SqlDb) GetAPITokens(userID int) (tokens []db.APIToken, err error) {
 
 	return
 }
+
+func (d *SqlDb) DeleteInactiveSessions(lastActiveBefore time.Time) (int, error) {
+	res, err := d.exec("delete from session where expired=? or last_active<?", true, lastActiveBefore.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
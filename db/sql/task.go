@@ -2,9 +2,12 @@ package sql
 
 import (
 	"database/sql"
+	"fmt"
 	"github.com/Masterminds/squirrel"
 	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
 	"math/rand"
+	"time"
 )
 
 func (d *SqlDb) CreateTaskStage(stage db.TaskStage) (db.TaskStage, error) {
@@ -98,10 +101,22 @@ func (d *SqlDb) UpdateTask(task db.Task) error {
 	}
 
 	_, err = d.exec(
-		"update task set status=?, start=?, `end`=? where id=?",
+		"update task set status=?, start=?, `end`=?, commit_hash=?, commit_message=?, budget_exceeded=?, change_window_closed=?, "+
+			"hosts_total=?, hosts_unreachable=?, tasks_total=?, tasks_done=?, output_offset=?, checkpoint_at=?, preparation_steps=? where id=?",
 		task.Status,
 		task.Start,
 		task.End,
+		task.CommitHash,
+		task.CommitMessage,
+		task.BudgetExceeded,
+		task.ChangeWindowClosed,
+		task.HostsTotal,
+		task.HostsUnreachable,
+		task.TasksTotal,
+		task.TasksDone,
+		task.OutputOffset,
+		task.CheckpointAt,
+		task.PreparationSteps,
 		task.ID)
 
 	return err
@@ -116,7 +131,8 @@ func (d *SqlDb) CreateTaskOutput(output db.TaskOutput) (db.TaskOutput, error) {
 	return output, err
 }
 
-func (d *SqlDb) getTasks(projectID int, templateID *int, taskIDs []int, params db.RetrieveQueryParams, tasks *[]db.TaskWithTpl) (err error) {
+// getTasksWithTplQuery returns the query which selects tasks with the fields of their templates and users.
+func (d *SqlDb) getTasksWithTplQuery() squirrel.SelectBuilder {
 	fields := "task.*"
 	fields += ", tpl.playbook as tpl_playbook" +
 		", `user`.name as user_name" +
@@ -124,11 +140,14 @@ func (d *SqlDb) getTasks(projectID int, templateID *int, taskIDs []int, params d
 		", tpl.type as tpl_type" +
 		", tpl.app as tpl_app"
 
-	q := squirrel.Select(fields).
+	return squirrel.Select(fields).
 		From("task").
 		Join("project__template as tpl on task.template_id=tpl.id").
-		LeftJoin("`user` on task.user_id=`user`.id").
-		OrderBy("id desc")
+		LeftJoin("`user` on task.user_id=`user`.id")
+}
+
+func (d *SqlDb) getTasks(projectID int, templateID *int, taskIDs []int, params db.RetrieveQueryParams, tasks *[]db.TaskWithTpl) (err error) {
+	q := d.getTasksWithTplQuery().OrderBy("id desc")
 
 	if templateID == nil {
 		q = q.Where("tpl.project_id=?", projectID)
@@ -140,6 +159,10 @@ func (d *SqlDb) getTasks(projectID int, templateID *int, taskIDs []int, params d
 		q = q.Where(squirrel.Eq{"task.id": taskIDs})
 	}
 
+	if params.Filter != "" {
+		q = q.Where("task.status=?", params.Filter)
+	}
+
 	if params.Count > 0 {
 		q = q.Limit(uint64(params.Count))
 	}
@@ -147,17 +170,95 @@ func (d *SqlDb) getTasks(projectID int, templateID *int, taskIDs []int, params d
 	query, args, _ := q.ToSql()
 
 	_, err = d.selectAll(tasks, query, args...)
+	if err != nil {
+		return
+	}
 
-	for i := range *tasks {
-		err = (*tasks)[i].Fill(d)
-		if err != nil {
-			return
+	err = d.fillBuildTasks(projectID, *tasks)
+
+	return
+}
+
+// fillBuildTasks loads build tasks of the tasks by one query.
+func (d *SqlDb) fillBuildTasks(projectID int, tasks []db.TaskWithTpl) (err error) {
+	var buildTaskIDs []int
+	for _, task := range tasks {
+		if task.BuildTaskID != nil {
+			buildTaskIDs = append(buildTaskIDs, *task.BuildTaskID)
+		}
+	}
+
+	if len(buildTaskIDs) == 0 {
+		return
+	}
+
+	query, args, err := squirrel.Select("*").
+		From("task").
+		Where(squirrel.Eq{"project_id": projectID, "id": buildTaskIDs}).
+		ToSql()
+	if err != nil {
+		return
+	}
+
+	var buildTasks []db.Task
+	_, err = d.selectAll(&buildTasks, query, args...)
+	if err != nil {
+		return
+	}
+
+	for i := range tasks {
+		if tasks[i].BuildTaskID == nil {
+			continue
+		}
+		for j := range buildTasks {
+			if buildTasks[j].ID == *tasks[i].BuildTaskID {
+				tasks[i].BuildTask = &buildTasks[j]
+				break
+			}
 		}
 	}
 
 	return
 }
 
+func (d *SqlDb) getDeploymentTasksQuery(projectID int, templateID int, envID int) squirrel.SelectBuilder {
+	return squirrel.Select("task.*").
+		From("task").
+		Where("task.project_id=? AND task.template_id=? AND task.deployment_environment_id=? AND task.status=?",
+			projectID,
+			templateID,
+			envID,
+			task_logger.TaskSuccessStatus).
+		OrderBy("task.id DESC")
+}
+
+func (d *SqlDb) GetLastDeploymentTask(projectID int, templateID int, envID int) (task db.Task, err error) {
+	query, args, err := d.getDeploymentTasksQuery(projectID, templateID, envID).Limit(1).ToSql()
+
+	if err != nil {
+		return
+	}
+
+	err = d.selectOne(&task, query, args...)
+
+	if err == sql.ErrNoRows {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
+func (d *SqlDb) GetDeploymentTasks(projectID int, templateID int, envID int) (tasks []db.Task, err error) {
+	query, args, err := d.getDeploymentTasksQuery(projectID, templateID, envID).ToSql()
+
+	if err != nil {
+		return
+	}
+
+	_, err = d.selectAll(&tasks, query, args...)
+	return
+}
+
 func (d *SqlDb) GetTask(projectID int, taskID int) (task db.Task, err error) {
 	q := squirrel.Select("task.*").
 		From("task").
@@ -189,12 +290,36 @@ func (d *SqlDb) GetTemplateTasks(projectID int, templateID int, params db.Retrie
 	return
 }
 
+func (d *SqlDb) GetProjectScheduledTasks(projectID int, from time.Time, to time.Time) (tasks []db.Task, err error) {
+	_, err = d.selectAll(&tasks,
+		"select * from task where project_id=? and schedule_id is not null and `end`>=? and `end`<? order by id",
+		projectID,
+		from.UTC(),
+		to.UTC())
+	return
+}
+
 func (d *SqlDb) GetProjectTasks(projectID int, params db.RetrieveQueryParams) (tasks []db.TaskWithTpl, err error) {
 	tasks = make([]db.TaskWithTpl, 0)
 	err = d.getTasks(projectID, nil, nil, params, &tasks)
 	return
 }
 
+func (d *SqlDb) GetProjectTaskHistory(projectID int, from time.Time, to time.Time) (tasks []db.TaskWithTpl, err error) {
+	query, args, err := d.getTasksWithTplQuery().
+		Where("tpl.project_id=? and task.created>=? and task.created<?", projectID, from.UTC(), to.UTC()).
+		OrderBy("task.id").
+		ToSql()
+
+	if err != nil {
+		return
+	}
+
+	tasks = make([]db.TaskWithTpl, 0)
+	_, err = d.selectAll(&tasks, query, args...)
+	return
+}
+
 func (d *SqlDb) DeleteTaskWithOutputs(projectID int, taskID int) (err error) {
 	// check if task exists in the project
 	_, err = d.GetTask(projectID, taskID)
@@ -203,16 +328,152 @@ func (d *SqlDb) DeleteTaskWithOutputs(projectID int, taskID int) (err error) {
 		return
 	}
 
+	return d.deleteTaskWithOutputs(taskID)
+}
+
+// deleteTaskWithOutputs removes the task and its records without checking the project of the task.
+func (d *SqlDb) deleteTaskWithOutputs(taskID int) (err error) {
 	_, err = d.exec("delete from task__output where task_id=?", taskID)
 
 	if err != nil {
 		return
 	}
 
+	_, err = d.exec("delete from task__finding where task_id=?", taskID)
+
+	if err != nil {
+		return
+	}
+
+	_, err = d.exec("delete from task__run_output where task_id=?", taskID)
+
+	if err != nil {
+		return
+	}
+
+	_, err = d.exec("delete from task__artifact where task_id=?", taskID)
+
+	if err != nil {
+		return
+	}
+
+	_, err = d.exec("delete from task__terraform_plan_change where task_id=?", taskID)
+
+	if err != nil {
+		return
+	}
+
+	_, err = d.exec("delete from task__object_version where task_id=?", taskID)
+
+	if err != nil {
+		return
+	}
+
 	_, err = d.exec("delete from task where id=?", taskID)
 	return
 }
 
+func (d *SqlDb) GetFinishedTasksCreatedBefore(before time.Time, limit int) (tasks []db.Task, err error) {
+	query, args, err := squirrel.Select("*").
+		From("task").
+		Where("created<?", before.UTC()).
+		Where(squirrel.Eq{"status": []task_logger.TaskStatus{
+			task_logger.TaskSuccessStatus,
+			task_logger.TaskPartialStatus,
+			task_logger.TaskFailStatus,
+			task_logger.TaskStoppedStatus,
+		}}).
+		OrderBy("id").
+		Limit(uint64(limit)).
+		ToSql()
+
+	if err != nil {
+		return
+	}
+
+	_, err = d.selectAll(&tasks, query, args...)
+	return
+}
+
+func (d *SqlDb) GetUnfinishedTasks() (tasks []db.Task, err error) {
+	query, args, err := squirrel.Select("*").
+		From("task").
+		Where(squirrel.NotEq{"status": []task_logger.TaskStatus{
+			task_logger.TaskSuccessStatus,
+			task_logger.TaskPartialStatus,
+			task_logger.TaskFailStatus,
+			task_logger.TaskStoppedStatus,
+		}}).
+		OrderBy("id").
+		ToSql()
+
+	if err != nil {
+		return
+	}
+
+	_, err = d.selectAll(&tasks, query, args...)
+	return
+}
+
+func (d *SqlDb) ClaimTask(taskID int, nodeID string) (bool, error) {
+	res, err := d.exec("update task set claimed_by=? where id=? and claimed_by is null", nodeID, taskID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if affected > 0 {
+		return true, nil
+	}
+
+	// the task can be already claimed by this node
+	claimedBy, err := d.sql.SelectNullStr(d.PrepareQuery("select claimed_by from task where id=?"), taskID)
+	if err != nil {
+		return false, err
+	}
+
+	return claimedBy.Valid && claimedBy.String == nodeID, nil
+}
+
+func (d *SqlDb) CreateTaskOutputs(outputs []db.TaskOutput) error {
+	if len(outputs) == 0 {
+		return nil
+	}
+
+	q := squirrel.Insert("task__output").Columns("task_id", "task", "output", "time")
+
+	for _, output := range outputs {
+		q = q.Values(output.TaskID, "", output.Output, output.Time.UTC())
+	}
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = d.exec(query, args...)
+	return err
+}
+
+func (d *SqlDb) SetTaskOutputObject(projectID int, taskID int, objectKey string) error {
+	_, err := d.GetTask(projectID, taskID)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.exec("update task set output_object=? where id=?", objectKey, taskID)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.exec("delete from task__output where task_id=?", taskID)
+	return err
+}
+
 func (d *SqlDb) GetTaskOutputs(projectID int, taskID int) (output []db.TaskOutput, err error) {
 	// check if task exists in the project
 	_, err = d.GetTask(projectID, taskID)
@@ -222,7 +483,27 @@ func (d *SqlDb) GetTaskOutputs(projectID int, taskID int) (output []db.TaskOutpu
 	}
 
 	_, err = d.selectAll(&output,
-		"select task_id, task, time, output from task__output where task_id=? order by time asc",
+		"select task_id, task, time, output from task__output where task_id=? order by time asc, id asc",
 		taskID)
 	return
 }
+
+func (d *SqlDb) GetTaskOutputsPage(projectID int, taskID int, params db.RetrieveQueryParams) (output []db.TaskOutput, err error) {
+	_, err = d.GetTask(projectID, taskID)
+
+	if err != nil {
+		return
+	}
+
+	if params.Count <= 0 {
+		err = fmt.Errorf("offset cannot be without limit")
+		return
+	}
+
+	_, err = d.selectAll(&output,
+		"select task_id, task, time, output from task__output where task_id=? order by time asc, id asc limit ? offset ?",
+		taskID,
+		params.Count,
+		params.Offset)
+	return
+}
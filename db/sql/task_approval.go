@@ -0,0 +1,57 @@
+package sql
+
+import (
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) CreateTaskApproval(approval db.TaskApproval) (newApproval db.TaskApproval, err error) {
+	insertID, err := d.insert(
+		"id",
+		"insert into task__approval (project_id, task_id, user_id, token_hash, created, expires) values (?, ?, ?, ?, ?, ?)",
+		approval.ProjectID,
+		approval.TaskID,
+		approval.UserID,
+		approval.TokenHash,
+		approval.Created,
+		approval.Expires)
+
+	if err != nil {
+		return
+	}
+
+	newApproval = approval
+	newApproval.ID = insertID
+	return
+}
+
+func (d *SqlDb) GetTaskApproval(projectID int, approvalID int) (approval db.TaskApproval, err error) {
+	err = d.getObject(projectID, db.TaskApprovalProps, approvalID, &approval)
+	return
+}
+
+func (d *SqlDb) UseTaskApproval(projectID int, approvalID int, decision db.TaskApprovalDecision, used time.Time) error {
+	// the condition on used rejects the second use of the link by concurrent requests
+	res, err := d.exec(
+		"update task__approval set used=?, decision=? where project_id=? and id=? and used is null",
+		used.UTC(),
+		decision,
+		projectID,
+		approvalID)
+
+	if err != nil {
+		return err
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if updated == 0 {
+		return db.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package sql
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) CreateTaskArtifact(artifact db.TaskArtifact) (newArtifact db.TaskArtifact, err error) {
+	insertID, err := d.insert(
+		"id",
+		"insert into task__artifact (task_id, path, registry, version, name, url, size, sha256, published) values (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		artifact.TaskID,
+		artifact.Path,
+		artifact.Registry,
+		artifact.Version,
+		artifact.Name,
+		artifact.URL,
+		artifact.Size,
+		artifact.SHA256,
+		artifact.Published)
+
+	if err != nil {
+		return
+	}
+
+	newArtifact = artifact
+	newArtifact.ID = insertID
+	return
+}
+
+func (d *SqlDb) GetTaskArtifacts(projectID int, taskID int) (artifacts []db.TaskArtifact, err error) {
+	// check if task exists in the project
+	_, err = d.GetTask(projectID, taskID)
+
+	if err != nil {
+		return
+	}
+
+	artifacts = make([]db.TaskArtifact, 0)
+
+	_, err = d.selectAll(&artifacts,
+		"select * from task__artifact where task_id=? order by id asc",
+		taskID)
+	return
+}
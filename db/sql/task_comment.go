@@ -0,0 +1,58 @@
+package sql
+
+import (
+	"github.com/Masterminds/squirrel"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) GetTaskComment(projectID int, commentID int) (comment db.TaskComment, err error) {
+	err = d.getObject(projectID, db.TaskCommentProps, commentID, &comment)
+	return
+}
+
+func (d *SqlDb) GetTaskComments(projectID int, filter db.TaskCommentFilter) (comments []db.TaskComment, err error) {
+	comments = make([]db.TaskComment, 0)
+
+	err = d.getObjects(projectID, db.TaskCommentProps, db.RetrieveQueryParams{}, func(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+		if len(filter.TaskIDs) > 0 {
+			q = q.Where(squirrel.Eq{"pe.task_id": filter.TaskIDs})
+		}
+
+		if filter.Label != "" {
+			q = q.Where("lower(pe.label)=lower(?)", filter.Label)
+		}
+
+		if filter.Query != "" {
+			pattern := "%" + filter.Query + "%"
+			q = q.Where("(lower(pe.comment) like lower(?) or lower(pe.label) like lower(?))", pattern, pattern)
+		}
+
+		return q
+	}, &comments)
+
+	return
+}
+
+func (d *SqlDb) CreateTaskComment(comment db.TaskComment) (newComment db.TaskComment, err error) {
+	insertID, err := d.insert(
+		"id",
+		"insert into task__comment (project_id, task_id, user_id, label, comment, created) values (?, ?, ?, ?, ?, ?)",
+		comment.ProjectID,
+		comment.TaskID,
+		comment.UserID,
+		comment.Label,
+		comment.Comment,
+		comment.Created)
+
+	if err != nil {
+		return
+	}
+
+	newComment = comment
+	newComment.ID = insertID
+	return
+}
+
+func (d *SqlDb) DeleteTaskComment(projectID int, commentID int) error {
+	return d.deleteObject(projectID, db.TaskCommentProps, commentID)
+}
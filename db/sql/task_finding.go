@@ -0,0 +1,42 @@
+package sql
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) CreateTaskFinding(finding db.TaskFinding) (newFinding db.TaskFinding, err error) {
+	insertID, err := d.insert(
+		"id",
+		"insert into task__finding (task_id, tool, severity, rule, file, line, message) values (?, ?, ?, ?, ?, ?, ?)",
+		finding.TaskID,
+		finding.Tool,
+		finding.Severity,
+		finding.Rule,
+		finding.File,
+		finding.Line,
+		finding.Message)
+
+	if err != nil {
+		return
+	}
+
+	newFinding = finding
+	newFinding.ID = insertID
+	return
+}
+
+func (d *SqlDb) GetTaskFindings(projectID int, taskID int) (findings []db.TaskFinding, err error) {
+	// check if task exists in the project
+	_, err = d.GetTask(projectID, taskID)
+
+	if err != nil {
+		return
+	}
+
+	findings = make([]db.TaskFinding, 0)
+
+	_, err = d.selectAll(&findings,
+		"select * from task__finding where task_id=? order by id asc",
+		taskID)
+	return
+}
@@ -0,0 +1,82 @@
+package sql
+
+import (
+	"encoding/json"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) CreateTaskHosts(hosts []db.TaskHost) error {
+	for _, h := range hosts {
+		_, err := d.insert(
+			"id",
+			"insert into project__task_host "+
+				"(project_id, template_id, task_id, host, host_groups, status, task_status, start, finished) "+
+				"values (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			h.ProjectID,
+			h.TemplateID,
+			h.TaskID,
+			h.Host,
+			h.Groups,
+			h.Status,
+			h.TaskStatus,
+			h.Start,
+			h.Finished.UTC())
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *SqlDb) GetTaskHosts(projectID int, filter db.TaskHostFilter) (hosts []db.TaskHost, err error) {
+	q := squirrel.Select("*").
+		From("project__task_host").
+		Where(squirrel.Eq{"project_id": projectID}).
+		OrderBy("finished", "id")
+
+	if filter.Host != "" {
+		q = q.Where(squirrel.Eq{"host": filter.Host})
+	}
+
+	if filter.Group != "" {
+		// groups are stored as JSON array, the exact match is checked below
+		var group []byte
+		group, err = json.Marshal(filter.Group)
+		if err != nil {
+			return
+		}
+		q = q.Where("host_groups like ?", "%"+string(group)+"%")
+	}
+
+	if filter.From != nil {
+		q = q.Where(squirrel.GtOrEq{"finished": filter.From.UTC()})
+	}
+
+	if filter.To != nil {
+		q = q.Where(squirrel.Lt{"finished": filter.To.UTC()})
+	}
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return
+	}
+
+	var all []db.TaskHost
+	_, err = d.selectAll(&all, query, args...)
+	if err != nil {
+		return
+	}
+
+	hosts = make([]db.TaskHost, 0, len(all))
+	for _, h := range all {
+		if filter.Match(h) {
+			hosts = append(hosts, h)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,165 @@
+package sql
+
+import (
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) CreateTaskMetric(metric db.TaskMetric) (newMetric db.TaskMetric, err error) {
+	insertID, err := d.insert(
+		"id",
+		"insert into project__task_metric "+
+			"(project_id, template_id, task_id, status, finished, duration, hosts_total, hosts_changed, hosts_failed, output_size) "+
+			"values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		metric.ProjectID,
+		metric.TemplateID,
+		metric.TaskID,
+		metric.Status,
+		metric.Finished.UTC(),
+		metric.Duration,
+		metric.HostsTotal,
+		metric.HostsChanged,
+		metric.HostsFailed,
+		metric.OutputSize)
+
+	if err != nil {
+		return
+	}
+
+	newMetric = metric
+	newMetric.ID = insertID
+	return
+}
+
+func applyTaskMetricFilter(q squirrel.SelectBuilder, column string, filter db.TaskMetricFilter) squirrel.SelectBuilder {
+	if filter.TemplateID != nil {
+		q = q.Where(squirrel.Eq{"template_id": *filter.TemplateID})
+	}
+
+	if filter.From != nil {
+		q = q.Where(squirrel.GtOrEq{column: filter.From.UTC()})
+	}
+
+	if filter.To != nil {
+		q = q.Where(squirrel.Lt{column: filter.To.UTC()})
+	}
+
+	return q
+}
+
+func (d *SqlDb) GetTaskMetrics(projectID int, filter db.TaskMetricFilter) (metrics []db.TaskMetric, err error) {
+	q := squirrel.Select("*").
+		From("project__task_metric").
+		Where(squirrel.Eq{"project_id": projectID}).
+		OrderBy("id")
+
+	query, args, err := applyTaskMetricFilter(q, "finished", filter).ToSql()
+	if err != nil {
+		return
+	}
+
+	metrics = make([]db.TaskMetric, 0)
+	_, err = d.selectAll(&metrics, query, args...)
+	return
+}
+
+func (d *SqlDb) GetTaskMetricsBefore(before time.Time, limit int) (metrics []db.TaskMetric, err error) {
+	metrics = make([]db.TaskMetric, 0)
+	_, err = d.selectAll(&metrics,
+		"select * from project__task_metric where finished<? order by id limit ?",
+		before.UTC(),
+		limit)
+	return
+}
+
+func (d *SqlDb) RollupTaskMetrics(metrics []db.TaskMetric) error {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, rollup := range db.RollupTaskMetrics(metrics) {
+		res, err2 := tx.Exec(d.PrepareQuery("update project__task_metric_daily set "+
+			"task_count=task_count+?, success_count=success_count+?, fail_count=fail_count+?, "+
+			"duration_sum=duration_sum+?, duration_max=case when duration_max<? then ? else duration_max end, "+
+			"hosts_changed=hosts_changed+?, hosts_failed=hosts_failed+?, output_size=output_size+? "+
+			"where project_id=? and template_id=? and day=?"),
+			rollup.TaskCount,
+			rollup.SuccessCount,
+			rollup.FailCount,
+			rollup.DurationSum,
+			rollup.DurationMax,
+			rollup.DurationMax,
+			rollup.HostsChanged,
+			rollup.HostsFailed,
+			rollup.OutputSize,
+			rollup.ProjectID,
+			rollup.TemplateID,
+			rollup.Day)
+
+		var updated int64
+		if err2 == nil {
+			updated, err2 = res.RowsAffected()
+		}
+
+		if err2 == nil && updated == 0 {
+			_, err2 = tx.Exec(d.PrepareQuery("insert into project__task_metric_daily "+
+				"(project_id, template_id, day, task_count, success_count, fail_count, "+
+				"duration_sum, duration_max, hosts_changed, hosts_failed, output_size) "+
+				"values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+				rollup.ProjectID,
+				rollup.TemplateID,
+				rollup.Day,
+				rollup.TaskCount,
+				rollup.SuccessCount,
+				rollup.FailCount,
+				rollup.DurationSum,
+				rollup.DurationMax,
+				rollup.HostsChanged,
+				rollup.HostsFailed,
+				rollup.OutputSize)
+		}
+
+		if err2 != nil {
+			_ = tx.Rollback()
+			return err2
+		}
+	}
+
+	for _, metric := range metrics {
+		if _, err = tx.Exec(d.PrepareQuery("delete from project__task_metric where id=?"), metric.ID); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (d *SqlDb) GetTaskMetricsDaily(projectID int, filter db.TaskMetricFilter) (rollups []db.TaskMetricDaily, err error) {
+	q := squirrel.Select("*").
+		From("project__task_metric_daily").
+		Where(squirrel.Eq{"project_id": projectID}).
+		OrderBy("day", "template_id")
+
+	query, args, err := applyTaskMetricFilter(q, "day", filter).ToSql()
+	if err != nil {
+		return
+	}
+
+	rollups = make([]db.TaskMetricDaily, 0)
+	_, err = d.selectAll(&rollups, query, args...)
+	return
+}
+
+func (d *SqlDb) DeleteTaskMetricsDailyBefore(before time.Time) (int, error) {
+	res, err := d.exec("delete from project__task_metric_daily where day<?", before.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	removed, err := res.RowsAffected()
+	return int(removed), err
+}
@@ -0,0 +1,32 @@
+package sql
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) CreateTaskObjectVersions(versions []db.TaskObjectVersion) error {
+	for _, v := range versions {
+		_, err := d.exec(
+			"insert into task__object_version (task_id, project_id, object_type, object_id, version) values (?, ?, ?, ?, ?)",
+			v.TaskID,
+			v.ProjectID,
+			v.ObjectType,
+			v.ObjectID,
+			v.Version)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *SqlDb) GetTaskObjectVersions(projectID int, taskID int) (versions []db.TaskObjectVersion, err error) {
+	versions = make([]db.TaskObjectVersion, 0)
+	_, err = d.selectAll(&versions,
+		"select * from task__object_version where project_id=? and task_id=? order by id",
+		projectID,
+		taskID)
+	return
+}
@@ -0,0 +1,47 @@
+package sql
+
+import (
+	"github.com/Masterminds/squirrel"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) GetTaskShareLink(projectID int, linkID int) (link db.TaskShareLink, err error) {
+	err = d.getObject(projectID, db.TaskShareLinkProps, linkID, &link)
+	return
+}
+
+func (d *SqlDb) GetTaskShareLinks(projectID int, taskID int) (links []db.TaskShareLink, err error) {
+	links = make([]db.TaskShareLink, 0)
+	err = d.getObjects(projectID, db.TaskShareLinkProps, db.RetrieveQueryParams{}, func(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return q.Where("pe.task_id=?", taskID)
+	}, &links)
+	return
+}
+
+func (d *SqlDb) CreateTaskShareLink(link db.TaskShareLink) (newLink db.TaskShareLink, err error) {
+	if err = link.Validate(); err != nil {
+		return
+	}
+
+	insertID, err := d.insert(
+		"id",
+		"insert into task__share_link (project_id, task_id, user_id, token_hash, created, expires) values (?, ?, ?, ?, ?, ?)",
+		link.ProjectID,
+		link.TaskID,
+		link.UserID,
+		link.TokenHash,
+		link.Created,
+		link.Expires)
+
+	if err != nil {
+		return
+	}
+
+	newLink = link
+	newLink.ID = insertID
+	return
+}
+
+func (d *SqlDb) DeleteTaskShareLink(projectID int, linkID int) error {
+	return d.deleteObject(projectID, db.TaskShareLinkProps, linkID)
+}
@@ -3,6 +3,8 @@ package sql
 import (
 	"database/sql"
 	"encoding/json"
+	"strings"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/semaphoreui/semaphore/db"
@@ -19,8 +21,9 @@ func (d *SqlDb) CreateTemplate(template db.Template) (newTemplate db.Template, e
 		"id",
 		"insert into project__template (project_id, inventory_id, repository_id, environment_id, "+
 			"name, playbook, arguments, allow_override_args_in_task, description, `type`, start_version,"+
-			"build_template_id, view_id, autorun, survey_vars, suppress_success_alerts, app, git_branch)"+
-			"values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			"build_template_id, view_id, autorun, survey_vars, suppress_success_alerts, app, git_branch, task_params, max_duration, duration_factor, secrets_scan, concurrency_group, "+
+			"change_window_start, change_window_end, abort_template_id, secret_files, approval_emails, run_conflict_policy, variable_group_ids, artifacts)"+
+			"values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		template.ProjectID,
 		template.InventoryID,
 		template.RepositoryID,
@@ -38,7 +41,20 @@ func (d *SqlDb) CreateTemplate(template db.Template) (newTemplate db.Template, e
 		db.ObjectToJSON(template.SurveyVars),
 		template.SuppressSuccessAlerts,
 		template.App,
-		template.GitBranch)
+		template.GitBranch,
+		template.TaskParams,
+		template.MaxDuration,
+		template.DurationFactor,
+		template.SecretsScan,
+		template.ConcurrencyGroup,
+		template.ChangeWindowStart,
+		template.ChangeWindowEnd,
+		template.AbortTemplateID,
+		template.SecretFiles,
+		template.ApprovalEmails,
+		template.RunConflictPolicy,
+		template.VariableGroupIDs,
+		template.Artifacts)
 
 	if err != nil {
 		return
@@ -49,6 +65,11 @@ func (d *SqlDb) CreateTemplate(template db.Template) (newTemplate db.Template, e
 		return
 	}
 
+	err = d.updateTemplateTagsAndViews(insertID, template.Tags, template.ViewIDs)
+	if err != nil {
+		return
+	}
+
 	err = db.FillTemplate(d, &newTemplate)
 
 	if err != nil {
@@ -85,7 +106,20 @@ func (d *SqlDb) UpdateTemplate(template db.Template) error {
 		"survey_vars=?, "+
 		"suppress_success_alerts=?, "+
 		"app=?, "+
-		"`git_branch`=? "+
+		"`git_branch`=?, "+
+		"task_params=?, "+
+		"max_duration=?, "+
+		"duration_factor=?, "+
+		"secrets_scan=?, "+
+		"concurrency_group=?, "+
+		"change_window_start=?, "+
+		"change_window_end=?, "+
+		"abort_template_id=?, "+
+		"secret_files=?, "+
+		"approval_emails=?, "+
+		"run_conflict_policy=?, "+
+		"variable_group_ids=?, "+
+		"artifacts=? "+
 		"where id=? and project_id=?",
 		template.InventoryID,
 		template.RepositoryID,
@@ -104,6 +138,19 @@ func (d *SqlDb) UpdateTemplate(template db.Template) error {
 		template.SuppressSuccessAlerts,
 		template.App,
 		template.GitBranch,
+		template.TaskParams,
+		template.MaxDuration,
+		template.DurationFactor,
+		template.SecretsScan,
+		template.ConcurrencyGroup,
+		template.ChangeWindowStart,
+		template.ChangeWindowEnd,
+		template.AbortTemplateID,
+		template.SecretFiles,
+		template.ApprovalEmails,
+		template.RunConflictPolicy,
+		template.VariableGroupIDs,
+		template.Artifacts,
 		template.ID,
 		template.ProjectID,
 	)
@@ -112,8 +159,11 @@ func (d *SqlDb) UpdateTemplate(template db.Template) error {
 	}
 
 	err = d.UpdateTemplateVaults(template.ProjectID, template.ID, template.Vaults)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return d.updateTemplateTagsAndViews(template.ID, template.Tags, template.ViewIDs)
 }
 
 func (d *SqlDb) GetTemplates(projectID int, filter db.TemplateFilter, params db.RetrieveQueryParams) (templates []db.Template, err error) {
@@ -148,7 +198,18 @@ func (d *SqlDb) GetTemplates(projectID int, filter db.TemplateFilter, params db.
 		From("project__template pt")
 
 	if filter.ViewID != nil {
-		q = q.Where("pt.view_id=?", *filter.ViewID)
+		q = q.Where("pt.id in (select template_id from project__template_view where view_id=?)", *filter.ViewID)
+	}
+
+	for _, tag := range filter.Tags {
+		q = q.Where("pt.id in (select template_id from project__template_tag where tag=?)", tag)
+	}
+
+	if filter.Search != "" {
+		search := "%" + strings.ToLower(filter.Search) + "%"
+		q = q.Where("(lower(pt.name) like ? or lower(pt.description) like ? or "+
+			"pt.id in (select template_id from project__template_tag where lower(tag) like ?))",
+			search, search, search)
 	}
 
 	if filter.BuildTemplateID != nil {
@@ -206,9 +267,20 @@ func (d *SqlDb) GetTemplates(projectID int, filter db.TemplateFilter, params db.
 		}
 	}
 
-	var tasks []db.TaskWithTpl
-	err = d.getTasks(projectID, nil, taskIDs, db.RetrieveQueryParams{}, &tasks)
+	tasks := make(map[int]db.TaskWithTpl)
 
+	if len(taskIDs) > 0 {
+		var taskList []db.TaskWithTpl
+		err = d.getTasks(projectID, nil, taskIDs, db.RetrieveQueryParams{}, &taskList)
+		if err != nil {
+			return
+		}
+		for _, tsk := range taskList {
+			tasks[tsk.ID] = tsk
+		}
+	}
+
+	vaults, err := d.getProjectTemplateVaults(projectID)
 	if err != nil {
 		return
 	}
@@ -217,34 +289,55 @@ func (d *SqlDb) GetTemplates(projectID int, filter db.TemplateFilter, params db.
 		template := tpl.Template
 
 		if tpl.LastTaskID != nil {
-			for _, tsk := range tasks {
-				if tsk.ID == *tpl.LastTaskID {
-					err = tsk.Fill(d)
-					if err != nil {
-						return
-					}
-					template.LastTask = &tsk
-					break
-				}
+			if tsk, ok := tasks[*tpl.LastTaskID]; ok {
+				template.LastTask = &tsk
 			}
 		}
 
-		if tpl.SurveyVarsJSON != nil {
-			err = json.Unmarshal([]byte(*tpl.SurveyVarsJSON), &tpl.SurveyVars)
-		}
-
-		if err != nil {
-			return
+		if template.SurveyVarsJSON != nil {
+			err = json.Unmarshal([]byte(*template.SurveyVarsJSON), &template.SurveyVars)
+			if err != nil {
+				return
+			}
 		}
 
-		template.Vaults, err = d.GetTemplateVaults(projectID, template.ID)
-		if err != nil {
-			return
+		template.Vaults = vaults[template.ID]
+		if template.Vaults == nil {
+			template.Vaults = []db.TemplateVault{}
 		}
 
 		templates = append(templates, template)
 	}
 
+	err = d.fillTemplatesTagsAndViews(projectID, templates)
+
+	return
+}
+
+func (d *SqlDb) GetTemplatesStats(projectID int) (stats []db.TemplateStats, err error) {
+	summaries, err := d.GetTemplateSummaries(projectID)
+	if err != nil {
+		return
+	}
+
+	var scheduleCounts []struct {
+		TemplateID    int `db:"template_id"`
+		ScheduleCount int `db:"schedule_count"`
+	}
+
+	_, err = d.selectAll(&scheduleCounts, "select template_id, count(*) schedule_count "+
+		"from project__schedule where project_id=? and active=? group by template_id",
+		projectID, true)
+	if err != nil {
+		return
+	}
+
+	counts := make(map[int]int)
+	for _, c := range scheduleCounts {
+		counts[c.TemplateID] = c.ScheduleCount
+	}
+
+	stats = db.MakeTemplatesStats(summaries, counts, time.Now())
 	return
 }
 
@@ -264,6 +357,11 @@ func (d *SqlDb) GetTemplate(projectID int, templateID int) (template db.Template
 	}
 
 	err = db.FillTemplate(d, &template)
+	if err != nil {
+		return
+	}
+
+	err = d.fillTemplateTagsAndViews(&template)
 	return
 }
 
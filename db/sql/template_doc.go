@@ -0,0 +1,63 @@
+package sql
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) GetTemplateDoc(projectID int, templateID int) (doc db.TemplateDoc, err error) {
+	query, args, err := squirrel.Select("*").
+		From("project__template_doc").
+		Where(squirrel.Eq{"template_id": templateID, "project_id": projectID}).
+		ToSql()
+
+	if err != nil {
+		return
+	}
+
+	err = d.selectOne(&doc, query, args...)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
+func (d *SqlDb) SetTemplateDoc(doc db.TemplateDoc) error {
+	// check if template exists in the project
+	if _, err := d.GetTemplate(doc.ProjectID, doc.TemplateID); err != nil {
+		return err
+	}
+
+	content, err := doc.Content.Value()
+	if err != nil {
+		return err
+	}
+
+	_, err = d.GetTemplateDoc(doc.ProjectID, doc.TemplateID)
+
+	if errors.Is(err, db.ErrNotFound) {
+		_, err = d.exec(
+			"insert into project__template_doc (template_id, project_id, playbook, commit_hash, updated, content) values (?, ?, ?, ?, ?, ?)",
+			doc.TemplateID,
+			doc.ProjectID,
+			doc.Playbook,
+			doc.CommitHash,
+			doc.Updated,
+			content)
+	} else if err == nil {
+		_, err = d.exec(
+			"update project__template_doc set playbook=?, commit_hash=?, updated=?, content=? where template_id=?",
+			doc.Playbook,
+			doc.CommitHash,
+			doc.Updated,
+			content,
+			doc.TemplateID)
+	}
+
+	return err
+}
@@ -0,0 +1,63 @@
+package sql
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) GetTemplateSummary(projectID int, templateID int) (summary db.TemplateSummary, err error) {
+	err = d.selectOne(
+		&summary,
+		"select * from project__template_summary where project_id=? and template_id=?",
+		projectID,
+		templateID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
+func (d *SqlDb) GetTemplateSummaries(projectID int) (summaries []db.TemplateSummary, err error) {
+	summaries = []db.TemplateSummary{}
+	_, err = d.selectAll(
+		&summaries,
+		"select * from project__template_summary where project_id=? order by template_id",
+		projectID)
+	return
+}
+
+func (d *SqlDb) SetTemplateSummary(summary db.TemplateSummary) error {
+	_, err := d.GetTemplateSummary(summary.ProjectID, summary.TemplateID)
+
+	if errors.Is(err, db.ErrNotFound) {
+		_, err = d.exec(
+			"insert into project__template_summary "+
+				"(template_id, project_id, task_count, last_task_id, last_task_status, last_success, days, updated) "+
+				"values (?, ?, ?, ?, ?, ?, ?, ?)",
+			summary.TemplateID,
+			summary.ProjectID,
+			summary.TaskCount,
+			summary.LastTaskID,
+			summary.LastTaskStatus,
+			summary.LastSuccess,
+			summary.Days,
+			summary.Updated.UTC())
+	} else if err == nil {
+		_, err = d.exec(
+			"update project__template_summary set task_count=?, last_task_id=?, last_task_status=?, "+
+				"last_success=?, days=?, updated=? where template_id=?",
+			summary.TaskCount,
+			summary.LastTaskID,
+			summary.LastTaskStatus,
+			summary.LastSuccess,
+			summary.Days,
+			summary.Updated.UTC(),
+			summary.TemplateID)
+	}
+
+	return err
+}
@@ -0,0 +1,97 @@
+package sql
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// updateTemplateTagsAndViews replaces tags and views of the template.
+func (d *SqlDb) updateTemplateTagsAndViews(templateID int, tags []string, viewIDs []int) (err error) {
+	_, err = d.exec("delete from project__template_tag where template_id=?", templateID)
+	if err != nil {
+		return
+	}
+
+	for _, tag := range tags {
+		_, err = d.exec("insert into project__template_tag (template_id, tag) values (?, ?)", templateID, tag)
+		if err != nil {
+			return
+		}
+	}
+
+	_, err = d.exec("delete from project__template_view where template_id=?", templateID)
+	if err != nil {
+		return
+	}
+
+	for i, viewID := range viewIDs {
+		_, err = d.exec("insert into project__template_view (template_id, view_id, position) values (?, ?, ?)",
+			templateID, viewID, i)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// fillTemplatesTagsAndViews loads tags and views of the project templates.
+func (d *SqlDb) fillTemplatesTagsAndViews(projectID int, templates []db.Template) (err error) {
+	var tags []struct {
+		TemplateID int    `db:"template_id"`
+		Tag        string `db:"tag"`
+	}
+
+	_, err = d.selectAll(&tags, "select t.template_id, t.tag from project__template_tag t "+
+		"join project__template pt on pt.id = t.template_id "+
+		"where pt.project_id=? order by t.tag", projectID)
+	if err != nil {
+		return
+	}
+
+	var views []struct {
+		TemplateID int `db:"template_id"`
+		ViewID     int `db:"view_id"`
+	}
+
+	_, err = d.selectAll(&views, "select v.template_id, v.view_id from project__template_view v "+
+		"join project__template pt on pt.id = v.template_id "+
+		"where pt.project_id=? order by v.position", projectID)
+	if err != nil {
+		return
+	}
+
+	for i := range templates {
+		templates[i].Tags = []string{}
+		templates[i].ViewIDs = []int{}
+
+		for _, t := range tags {
+			if t.TemplateID == templates[i].ID {
+				templates[i].Tags = append(templates[i].Tags, t.Tag)
+			}
+		}
+
+		for _, v := range views {
+			if v.TemplateID == templates[i].ID {
+				templates[i].ViewIDs = append(templates[i].ViewIDs, v.ViewID)
+			}
+		}
+	}
+
+	return
+}
+
+func (d *SqlDb) fillTemplateTagsAndViews(template *db.Template) (err error) {
+	template.Tags = []string{}
+	template.ViewIDs = []int{}
+
+	_, err = d.selectAll(&template.Tags,
+		"select tag from project__template_tag where template_id=? order by tag", template.ID)
+	if err != nil {
+		return
+	}
+
+	_, err = d.selectAll(&template.ViewIDs,
+		"select view_id from project__template_view where template_id=? order by position", template.ID)
+
+	return
+}
@@ -0,0 +1,81 @@
+package sql
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) GetTemplateUploads(projectID int, templateID int) (uploads []db.TemplateUpload, err error) {
+	uploads = make([]db.TemplateUpload, 0)
+	_, err = d.selectAll(
+		&uploads,
+		"select * from project__template_upload where project_id=? and template_id=? order by path",
+		projectID,
+		templateID)
+	return
+}
+
+func (d *SqlDb) GetTemplateUpload(projectID int, templateID int, uploadID int) (upload db.TemplateUpload, err error) {
+	err = d.getObject(projectID, db.TemplateUploadProps, uploadID, &upload)
+	if err == nil && upload.TemplateID != templateID {
+		err = db.ErrNotFound
+	}
+	return
+}
+
+func (d *SqlDb) CreateTemplateUpload(upload db.TemplateUpload) (newUpload db.TemplateUpload, err error) {
+	if err = upload.Validate(); err != nil {
+		return
+	}
+
+	insertID, err := d.insert(
+		"id",
+		"insert into project__template_upload (project_id, template_id, path, mode, size, sha256, data, user_id, created, updated) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		upload.ProjectID,
+		upload.TemplateID,
+		upload.Path,
+		upload.Mode,
+		upload.Size,
+		upload.SHA256,
+		upload.Data,
+		upload.UserID,
+		upload.Created,
+		upload.Updated)
+
+	if err != nil {
+		return
+	}
+
+	newUpload = upload
+	newUpload.ID = insertID
+	return
+}
+
+func (d *SqlDb) UpdateTemplateUpload(upload db.TemplateUpload) error {
+	if err := upload.Validate(); err != nil {
+		return err
+	}
+
+	_, err := d.exec(
+		"update project__template_upload set path=?, mode=?, size=?, sha256=?, data=?, user_id=?, updated=? where project_id=? and template_id=? and id=?",
+		upload.Path,
+		upload.Mode,
+		upload.Size,
+		upload.SHA256,
+		upload.Data,
+		upload.UserID,
+		upload.Updated,
+		upload.ProjectID,
+		upload.TemplateID,
+		upload.ID)
+
+	return err
+}
+
+func (d *SqlDb) DeleteTemplateUpload(projectID int, templateID int, uploadID int) error {
+	_, err := d.exec(
+		"delete from project__template_upload where project_id=? and template_id=? and id=?",
+		projectID,
+		templateID,
+		uploadID)
+	return err
+}
@@ -1,6 +1,7 @@
 package sql
 
 import (
+	"github.com/Masterminds/squirrel"
 	"github.com/semaphoreui/semaphore/db"
 	"strconv"
 	"strings"
@@ -22,6 +23,64 @@ func (d *SqlDb) GetTemplateVaults(projectID int, templateID int) (vaults []db.Te
 	return
 }
 
+// getProjectTemplateVaults returns vaults of all templates of the project grouped by template ID.
+func (d *SqlDb) getProjectTemplateVaults(projectID int) (res map[int][]db.TemplateVault, err error) {
+	var vaults []db.TemplateVault
+
+	_, err = d.selectAll(&vaults, "select * from project__template_vault where project_id=?", projectID)
+	if err != nil {
+		return
+	}
+
+	var keyIDs []int
+	for _, vault := range vaults {
+		if vault.Type == db.TemplateVaultPassword && vault.VaultKeyID != nil {
+			keyIDs = append(keyIDs, *vault.VaultKeyID)
+		}
+	}
+
+	keys := make(map[int]db.AccessKey)
+
+	if len(keyIDs) > 0 {
+		var keyList []db.AccessKey
+		var query string
+		var args []interface{}
+
+		query, args, err = squirrel.Select("*").
+			From("access_key").
+			Where(squirrel.Eq{"project_id": projectID, "id": keyIDs}).
+			ToSql()
+		if err != nil {
+			return
+		}
+
+		_, err = d.selectAll(&keyList, query, args...)
+		if err != nil {
+			return
+		}
+
+		for _, key := range keyList {
+			keys[key.ID] = key
+		}
+	}
+
+	res = make(map[int][]db.TemplateVault)
+
+	for _, vault := range vaults {
+		if vault.Type == db.TemplateVaultPassword && vault.VaultKeyID != nil {
+			key, ok := keys[*vault.VaultKeyID]
+			if !ok {
+				err = db.ErrNotFound
+				return
+			}
+			vault.Vault = &key
+		}
+		res[vault.TemplateID] = append(res[vault.TemplateID], vault)
+	}
+
+	return
+}
+
 func (d *SqlDb) CreateTemplateVault(vault db.TemplateVault) (newVault db.TemplateVault, err error) {
 	insertID, err := d.insert(
 		"id",
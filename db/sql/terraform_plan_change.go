@@ -0,0 +1,38 @@
+package sql
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) CreateTerraformPlanChange(change db.TerraformPlanChange) (newChange db.TerraformPlanChange, err error) {
+	insertID, err := d.insert(
+		"id",
+		"insert into task__terraform_plan_change (task_id, address, action) values (?, ?, ?)",
+		change.TaskID,
+		change.Address,
+		change.Action)
+
+	if err != nil {
+		return
+	}
+
+	newChange = change
+	newChange.ID = insertID
+	return
+}
+
+func (d *SqlDb) GetTerraformPlanChanges(projectID int, taskID int) (changes []db.TerraformPlanChange, err error) {
+	// check if task exists in the project
+	_, err = d.GetTask(projectID, taskID)
+
+	if err != nil {
+		return
+	}
+
+	changes = make([]db.TerraformPlanChange, 0)
+
+	_, err = d.selectAll(&changes,
+		"select * from task__terraform_plan_change where task_id=? order by id asc",
+		taskID)
+	return
+}
@@ -60,54 +60,70 @@ func (d *SqlDb) DeleteUser(userID int) error {
 }
 
 func (d *SqlDb) UpdateUser(user db.UserWithPwd) error {
-	var err error
+	_, err := d.exec(
+		"update `user` set name=?, username=?, email=?, alert=?, admin=?, locale=? where id=?",
+		user.Name,
+		user.Username,
+		user.Email,
+		user.Alert,
+		user.Admin,
+		user.Locale,
+		user.ID)
+
+	if err != nil {
+		return err
+	}
 
 	if user.Pwd != "" {
-		var pwdHash []byte
-		pwdHash, err = bcrypt.GenerateFromPassword([]byte(user.Pwd), 11)
-		if err != nil {
-			return err
-		}
-		_, err = d.exec(
-			"update `user` set name=?, username=?, email=?, alert=?, admin=?, password=? where id=?",
-			user.Name,
-			user.Username,
-			user.Email,
-			user.Alert,
-			user.Admin,
-			string(pwdHash),
-			user.ID)
-	} else {
-		_, err = d.exec(
-			"update `user` set name=?, username=?, email=?, alert=?, admin=? where id=?",
-			user.Name,
-			user.Username,
-			user.Email,
-			user.Alert,
-			user.Admin,
-			user.ID)
+		err = d.SetUserPassword(user.ID, user.Pwd)
 	}
 
 	return err
 }
 
+// SetUserPassword changes the password of the user and adds the previous password to the history.
 func (d *SqlDb) SetUserPassword(userID int, password string) error {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), 11)
 	if err != nil {
 		return err
 	}
+
+	user, err := d.GetUser(userID)
+	if err != nil {
+		return err
+	}
+
+	now := db.GetParsedTime(time.Now().UTC())
+
+	if user.Password != "" {
+		_, err = d.exec(
+			"insert into user__password_history (user_id, password, created) values (?, ?, ?)",
+			userID, user.Password, now)
+		if err != nil {
+			return err
+		}
+	}
+
 	_, err = d.exec(
-		"update `user` set password=? where id=?",
-		string(hash), userID)
+		"update `user` set password=?, password_changed=? where id=?",
+		string(hash), now, userID)
 	return err
 }
 
+func (d *SqlDb) GetUserPasswordHistory(userID int, limit int) (history []db.UserPassword, err error) {
+	_, err = d.selectAll(&history,
+		"select * from user__password_history where user_id=? order by created desc, id desc limit ?",
+		userID, limit)
+	return
+}
+
 func (d *SqlDb) CreateProjectUser(projectUser db.ProjectUser) (newProjectUser db.ProjectUser, err error) {
 	_, err = d.exec(
-		"insert into project__user (project_id, user_id, `role`) values (?, ?, ?)",
+		"insert into project__user (project_id, user_id, `role`, expires_at) values (?, ?, ?, ?)",
 		projectUser.ProjectID,
 		projectUser.UserID,
-		projectUser.Role)
+		projectUser.Role,
+		projectUser.ExpiresAt)
 
 	if err != nil {
 		return
@@ -135,6 +151,7 @@ func (d *SqlDb) GetProjectUser(projectID, userID int) (db.ProjectUser, error) {
 func (d *SqlDb) GetProjectUsers(projectID int, params db.RetrieveQueryParams) (users []db.UserWithProjectRole, err error) {
 	q := squirrel.Select("u.*").
 		Column("pu.role").
+		Column("pu.expires_at").
 		From("project__user as pu").
 		LeftJoin("`user` as u on pu.user_id=u.id").
 		Where("pu.project_id=?", projectID)
@@ -166,8 +183,9 @@ func (d *SqlDb) GetProjectUsers(projectID int, params db.RetrieveQueryParams) (u
 
 func (d *SqlDb) UpdateProjectUser(projectUser db.ProjectUser) error {
 	_, err := d.exec(
-		"update `project__user` set role=? where user_id=? and project_id = ?",
+		"update `project__user` set role=?, expires_at=? where user_id=? and project_id = ?",
 		projectUser.Role,
+		projectUser.ExpiresAt,
 		projectUser.UserID,
 		projectUser.ProjectID)
 
@@ -179,6 +197,16 @@ func (d *SqlDb) DeleteProjectUser(projectID, userID int) error {
 	return err
 }
 
+func (d *SqlDb) DeleteExpiredProjectUsers(now time.Time) (expired []db.ProjectUser, err error) {
+	_, err = d.selectAll(&expired, "select * from project__user where expires_at<=?", now.UTC())
+	if err != nil || len(expired) == 0 {
+		return
+	}
+
+	_, err = d.exec("delete from project__user where expires_at<=?", now.UTC())
+	return
+}
+
 // GetUser retrieves a user from the database by ID
 func (d *SqlDb) GetUser(userID int) (db.User, error) {
 	var user db.User
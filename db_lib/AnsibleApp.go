@@ -5,10 +5,14 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path"
+	"slices"
+	"strings"
 
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
 )
 
 func getMD5Hash(filepath string) (string, error) {
@@ -53,6 +57,10 @@ type AnsibleApp struct {
 	Playbook   *AnsiblePlaybook
 	Template   db.Template
 	Repository db.Repository
+
+	// galaxyPaths contains galaxy cache entries and isolated template
+	// directories used by the task.
+	galaxyPaths map[GalaxyRequirementsType][]string
 }
 
 func (t *AnsibleApp) SetLogger(logger task_logger.Logger) task_logger.Logger {
@@ -61,19 +69,179 @@ func (t *AnsibleApp) SetLogger(logger task_logger.Logger) task_logger.Logger {
 	return logger
 }
 
+func (t *AnsibleApp) SetCmdWrapper(wrapper CmdWrapper) {
+	t.Playbook.cmdWrapper = wrapper
+}
+
+// getRunArgs returns arguments and environment variables of ansible-playbook.
+func (t *AnsibleApp) getRunArgs(args LocalAppRunningArgs) (cliArgs []string, environmentVars []string, err error) {
+	environmentVars, err = t.getAnsibleEnvironmentVars()
+	if err != nil {
+		return
+	}
+	if args.EnvironmentVars != nil {
+		environmentVars = append(environmentVars, *args.EnvironmentVars...)
+	}
+
+	executionArgs, executionEnv, err := t.getExecutionArgs(args.TaskParams)
+	if err != nil {
+		return
+	}
+	// structured settings override variables of the environment
+	environmentVars = append(environmentVars, executionEnv...)
+
+	cliArgs = append(executionArgs, args.CliArgs...)
+	return
+}
+
 func (t *AnsibleApp) Run(args LocalAppRunningArgs) error {
-	return t.Playbook.RunPlaybook(args.CliArgs, args.EnvironmentVars, args.Inputs, args.Callback)
+	cliArgs, environmentVars, err := t.getRunArgs(args)
+	if err != nil {
+		return err
+	}
+
+	return t.Playbook.RunPlaybook(cliArgs, &environmentVars, args.Inputs, args.Callback)
+}
+
+func (t *AnsibleApp) PreviewCmd(args LocalAppRunningArgs) (*exec.Cmd, error) {
+	cliArgs, environmentVars, err := t.getRunArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.Playbook.makeCmd("ansible-playbook", cliArgs, &environmentVars), nil
+}
+
+func (t *AnsibleApp) getTemplateParams() (params db.AnsibleTemplateParams, err error) {
+	err = t.Template.GetParams(&params)
+	return
+}
+
+// getTemplateDataPath returns directory managed by Semaphore which contains
+// ansible.cfg override and isolated roles and collections of the template.
+func (t *AnsibleApp) getTemplateDataPath() string {
+	return path.Join(util.Config.TmpPath, fmt.Sprintf("template_%d", t.Template.ID))
+}
+
+// getAnsibleEnvironmentVars returns ANSIBLE_CONFIG, ANSIBLE_ROLES_PATH and ANSIBLE_COLLECTIONS_PATH
+// according to the template params. They are used by both ansible-galaxy and ansible-playbook.
+func (t *AnsibleApp) getAnsibleEnvironmentVars() (res []string, err error) {
+	params, err := t.getTemplateParams()
+	if err != nil {
+		return
+	}
+
+	if params.AnsibleConfig != "" {
+		res = append(res, "ANSIBLE_CONFIG="+path.Join(t.getTemplateDataPath(), "ansible.cfg"))
+	}
+
+	res = append(res, t.getGalaxyEnvironmentVars(params)...)
+	return
+}
+
+// installAnsibleConfig writes ansible.cfg override of the template.
+func (t *AnsibleApp) installAnsibleConfig() error {
+	params, err := t.getTemplateParams()
+	if err != nil {
+		return err
+	}
+
+	if params.AnsibleConfig == "" {
+		return nil
+	}
+
+	if err = os.MkdirAll(t.getTemplateDataPath(), 0755); err != nil {
+		return err
+	}
+
+	// write to temporary file first to not break running tasks of the template
+	tmpFile, err := os.CreateTemp(t.getTemplateDataPath(), ".ansible.cfg_")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmpFile.WriteString(params.AnsibleConfig)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), path.Join(t.getTemplateDataPath(), "ansible.cfg"))
+	}
+
+	if err != nil {
+		_ = os.Remove(tmpFile.Name())
+	}
+
+	return err
+}
+
+// getGalaxyEnvironmentVars returns ANSIBLE_ROLES_PATH and ANSIBLE_COLLECTIONS_PATH
+// which include galaxy cache entries, isolated template directories and paths
+// from the template params. Ansible default paths are kept.
+func (t *AnsibleApp) getGalaxyEnvironmentVars(params db.AnsibleTemplateParams) (res []string) {
+	defaultPaths := map[GalaxyRequirementsType][]string{
+		GalaxyRole: {
+			path.Join(util.Config.TmpPath, ".ansible", "roles"),
+			"/usr/share/ansible/roles",
+			"/etc/ansible/roles",
+		},
+		GalaxyCollection: {
+			path.Join(util.Config.TmpPath, ".ansible", "collections"),
+			"/usr/share/ansible/collections",
+		},
+	}
+
+	envNames := map[GalaxyRequirementsType]string{
+		GalaxyRole:       "ANSIBLE_ROLES_PATH",
+		GalaxyCollection: "ANSIBLE_COLLECTIONS_PATH",
+	}
+
+	repoPaths := map[GalaxyRequirementsType][]string{
+		GalaxyRole:       params.RolesPaths,
+		GalaxyCollection: params.CollectionsPaths,
+	}
+
+	for _, requirementsType := range []GalaxyRequirementsType{GalaxyRole, GalaxyCollection} {
+		paths := append([]string{}, t.galaxyPaths[requirementsType]...)
+
+		for _, p := range repoPaths[requirementsType] {
+			paths = append(paths, path.Join(t.getRepoPath(), p))
+		}
+
+		if len(paths) == 0 {
+			continue
+		}
+
+		paths = append(paths, defaultPaths[requirementsType]...)
+		res = append(res, envNames[requirementsType]+"="+strings.Join(paths, ":"))
+	}
+
+	return
 }
 
 func (t *AnsibleApp) Log(msg string) {
 	t.Logger.Log(msg)
 }
 
-func (t *AnsibleApp) InstallRequirements(environmentVars *[]string) error {
-	if err := t.installCollectionsRequirements(); err != nil {
+func (t *AnsibleApp) InstallRequirements(args LocalAppInstallingArgs) error {
+	forceRefresh := false
+	if params, ok := args.TaskParams.(*db.AnsibleTaskParams); ok {
+		forceRefresh = params.GalaxyForceRefresh
+	}
+
+	if err := t.installAnsibleConfig(); err != nil {
 		return err
 	}
-	if err := t.installRolesRequirements(); err != nil {
+
+	if err := t.installPython(); err != nil {
+		return err
+	}
+
+	if err := t.installCollectionsRequirements(forceRefresh); err != nil {
+		return err
+	}
+	if err := t.installRolesRequirements(forceRefresh); err != nil {
 		return err
 	}
 	return nil
@@ -90,7 +258,7 @@ func (t *AnsibleApp) getRepoPath() string {
 	return repo.GetFullPath()
 }
 
-func (t *AnsibleApp) installGalaxyRequirementsFile(requirementsType GalaxyRequirementsType, requirementsFilePath string) error {
+func (t *AnsibleApp) installGalaxyRequirementsFile(requirementsType GalaxyRequirementsType, requirementsFilePath string, forceRefresh bool) error {
 
 	requirementsHashFilePath := fmt.Sprintf("%s.md5", requirementsFilePath)
 
@@ -99,14 +267,37 @@ func (t *AnsibleApp) installGalaxyRequirementsFile(requirementsType GalaxyRequir
 		return nil
 	}
 
-	if hasRequirementsChanges(requirementsFilePath, requirementsHashFilePath) {
-		if err := t.runGalaxy([]string{
-			string(requirementsType),
-			"install",
-			"-r",
-			requirementsFilePath,
-			"--force",
-		}); err != nil {
+	if isGalaxyCacheEnabled() {
+		return t.installCachedGalaxyRequirementsFile(requirementsType, requirementsFilePath, forceRefresh)
+	}
+
+	params, err := t.getTemplateParams()
+	if err != nil {
+		return err
+	}
+
+	args := []string{
+		string(requirementsType),
+		"install",
+		"-r",
+		requirementsFilePath,
+		"--force",
+	}
+
+	if params.IsolateGalaxyPaths {
+		isolatedPath := path.Join(t.getTemplateDataPath(), string(requirementsType))
+		args = append(args, "-p", isolatedPath)
+		t.addGalaxyPath(requirementsType, isolatedPath)
+
+		// keep hash with installed requirements to reinstall them if the directory is removed
+		requirementsHashFilePath = path.Join(isolatedPath, fmt.Sprintf("%x.md5", md5.Sum([]byte(requirementsFilePath))))
+	}
+
+	if forceRefresh || hasRequirementsChanges(requirementsFilePath, requirementsHashFilePath) {
+		if err := t.runGalaxy(args); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(path.Dir(requirementsHashFilePath), 0755); err != nil {
 			return err
 		}
 		if err := writeMD5Hash(requirementsFilePath, requirementsHashFilePath); err != nil {
@@ -119,6 +310,76 @@ func (t *AnsibleApp) installGalaxyRequirementsFile(requirementsType GalaxyRequir
 	return nil
 }
 
+// installCachedGalaxyRequirementsFile installs requirements to the shared galaxy cache
+// or reuses the cache entry installed by previous tasks.
+func (t *AnsibleApp) installCachedGalaxyRequirementsFile(requirementsType GalaxyRequirementsType, requirementsFilePath string, forceRefresh bool) error {
+	entryPath, err := getGalaxyCacheEntryPath(requirementsType, requirementsFilePath)
+	if err != nil {
+		return err
+	}
+
+	if !forceRefresh && isGalaxyCacheEntryValid(entryPath, getGalaxyCacheTTL()) {
+		t.Log(requirementsFilePath + " found in galaxy cache. Skip galaxy install process.\n")
+		touchGalaxyCacheEntry(entryPath)
+		t.addGalaxyPath(requirementsType, entryPath)
+		return nil
+	}
+
+	if err = os.MkdirAll(getGalaxyCachePath(), 0755); err != nil {
+		return err
+	}
+
+	// install to temporary directory to not break tasks which use the current entry
+	tmpPath, err := os.MkdirTemp(getGalaxyCachePath(), ".install_")
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(tmpPath) //nolint:errcheck
+
+	if err = t.runGalaxy([]string{
+		string(requirementsType),
+		"install",
+		"-r",
+		requirementsFilePath,
+		"-p",
+		tmpPath,
+		"--force",
+	}); err != nil {
+		return err
+	}
+
+	if err = os.WriteFile(path.Join(tmpPath, galaxyCacheMarker), []byte(requirementsFilePath), 0644); err != nil {
+		return err
+	}
+
+	if err = os.RemoveAll(entryPath); err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmpPath, entryPath); err != nil {
+		return err
+	}
+
+	t.addGalaxyPath(requirementsType, entryPath)
+
+	maxSize := int64(util.Config.GalaxyCache.MaxSizeMB) * 1024 * 1024
+	if err = shrinkGalaxyCache(maxSize, entryPath); err != nil {
+		t.Log("Failed to shrink galaxy cache: " + err.Error() + "\n")
+	}
+
+	return nil
+}
+
+func (t *AnsibleApp) addGalaxyPath(requirementsType GalaxyRequirementsType, entryPath string) {
+	if t.galaxyPaths == nil {
+		t.galaxyPaths = make(map[GalaxyRequirementsType][]string)
+	}
+	if !slices.Contains(t.galaxyPaths[requirementsType], entryPath) {
+		t.galaxyPaths[requirementsType] = append(t.galaxyPaths[requirementsType], entryPath)
+	}
+}
+
 func (t *AnsibleApp) GetPlaybookDir() string {
 	playbookPath := path.Join(t.getRepoPath(), t.Template.Playbook)
 
@@ -132,24 +393,28 @@ const (
 	GalaxyCollection GalaxyRequirementsType = "collection"
 )
 
-func (t *AnsibleApp) installRolesRequirements() (err error) {
-	err = t.installGalaxyRequirementsFile(GalaxyRole, path.Join(t.GetPlaybookDir(), "roles", "requirements.yml"))
+func (t *AnsibleApp) installRolesRequirements(forceRefresh bool) (err error) {
+	err = t.installGalaxyRequirementsFile(GalaxyRole, path.Join(t.GetPlaybookDir(), "roles", "requirements.yml"), forceRefresh)
 	if err != nil {
 		return
 	}
-	err = t.installGalaxyRequirementsFile(GalaxyRole, path.Join(t.GetPlaybookDir(), "requirements.yml"))
+	err = t.installGalaxyRequirementsFile(GalaxyRole, path.Join(t.GetPlaybookDir(), "requirements.yml"), forceRefresh)
 	return
 }
 
-func (t *AnsibleApp) installCollectionsRequirements() (err error) {
-	err = t.installGalaxyRequirementsFile(GalaxyCollection, path.Join(t.GetPlaybookDir(), "collections", "requirements.yml"))
+func (t *AnsibleApp) installCollectionsRequirements(forceRefresh bool) (err error) {
+	err = t.installGalaxyRequirementsFile(GalaxyCollection, path.Join(t.GetPlaybookDir(), "collections", "requirements.yml"), forceRefresh)
 	if err != nil {
 		return
 	}
-	err = t.installGalaxyRequirementsFile(GalaxyCollection, path.Join(t.GetPlaybookDir(), "requirements.yml"))
+	err = t.installGalaxyRequirementsFile(GalaxyCollection, path.Join(t.GetPlaybookDir(), "requirements.yml"), forceRefresh)
 	return
 }
 
 func (t *AnsibleApp) runGalaxy(args []string) error {
-	return t.Playbook.RunGalaxy(args)
+	environmentVars, err := t.getAnsibleEnvironmentVars()
+	if err != nil {
+		return err
+	}
+	return t.Playbook.RunGalaxy(args, &environmentVars)
 }
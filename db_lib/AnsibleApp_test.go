@@ -0,0 +1,113 @@
+package db_lib
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestAnsibleAppTemplateParams(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: t.TempDir(),
+	}
+
+	repoPath := t.TempDir()
+
+	app := AnsibleApp{
+		Template: db.Template{
+			ID:  3,
+			App: db.AppAnsible,
+			TaskParams: db.MapStringAnyField{
+				"ansible_config":    "[defaults]\nforks = 50\n",
+				"collections_paths": []string{"vendor/collections"},
+			},
+		},
+		Repository: db.Repository{GitURL: repoPath},
+	}
+
+	if err := app.installAnsibleConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfgPath := path.Join(util.Config.TmpPath, "template_3", "ansible.cfg")
+
+	content, err := os.ReadFile(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "[defaults]\nforks = 50\n" {
+		t.Fatal("invalid ansible.cfg content")
+	}
+
+	res, err := app.getAnsibleEnvironmentVars()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res) != 2 {
+		t.Fatalf("expected 2 environment variables, got %v", res)
+	}
+
+	if res[0] != "ANSIBLE_CONFIG="+cfgPath {
+		t.Fatal("invalid ANSIBLE_CONFIG: " + res[0])
+	}
+
+	if !contains(res, "ANSIBLE_COLLECTIONS_PATH="+path.Join(repoPath, "vendor/collections")+":") {
+		t.Fatal("invalid ANSIBLE_COLLECTIONS_PATH: " + res[1])
+	}
+}
+
+func TestAnsibleAppPythonInterpreter(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: t.TempDir(),
+	}
+
+	app := AnsibleApp{
+		Template: db.Template{
+			App: db.AppAnsible,
+			TaskParams: db.MapStringAnyField{
+				"python_interpreter": "/opt/ansible-2.9/bin/python",
+			},
+		},
+		Playbook: &AnsiblePlaybook{},
+	}
+
+	if err := app.installPython(); err != nil {
+		t.Fatal(err)
+	}
+
+	if app.Playbook.BinPath != "/opt/ansible-2.9/bin" {
+		t.Fatal("invalid bin path: " + app.Playbook.BinPath)
+	}
+
+	cmd := app.Playbook.makeCmd("ansible-playbook", nil, nil)
+
+	if cmd.Path != "/opt/ansible-2.9/bin/ansible-playbook" {
+		t.Fatal("invalid command path: " + cmd.Path)
+	}
+
+	if !contains(cmd.Env, "PATH=/opt/ansible-2.9/bin:") {
+		t.Fatal("PATH must start with the bin path")
+	}
+}
+
+func TestGetPythonVenvPath(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: "/tmp/semaphore",
+	}
+
+	p1 := getPythonVenvPath("python3", "2.15.0")
+	p2 := getPythonVenvPath("python3.11", "2.15.0")
+
+	if p1 == p2 {
+		t.Fatal("virtualenvs of different interpreters must be separated")
+	}
+
+	if p1 != getPythonVenvPath("python3", "2.15.0") {
+		t.Fatal("virtualenv path must be stable")
+	}
+}
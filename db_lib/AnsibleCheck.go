@@ -0,0 +1,191 @@
+package db_lib
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+var (
+	ansibleErrorMessageRegexp  = regexp.MustCompile(`(?m)^ERROR! (.+)$`)
+	ansibleErrorLocationRegexp = regexp.MustCompile(`The error appears to be in '([^']+)': line (\d+)`)
+)
+
+// ansibleLintIssue is an issue in ansible-lint codeclimate output format.
+type ansibleLintIssue struct {
+	CheckName   string `json:"check_name"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+	Location    struct {
+		Path  string `json:"path"`
+		Lines struct {
+			Begin int `json:"begin"`
+		} `json:"lines"`
+		Positions struct {
+			Begin struct {
+				Line int `json:"line"`
+			} `json:"begin"`
+		} `json:"positions"`
+	} `json:"location"`
+}
+
+func (issue ansibleLintIssue) toFinding() db.TaskFinding {
+	severity := db.TaskFindingWarning
+	switch issue.Severity {
+	case "blocker", "critical", "major":
+		severity = db.TaskFindingError
+	}
+
+	line := issue.Location.Lines.Begin
+	if line == 0 {
+		line = issue.Location.Positions.Begin.Line
+	}
+
+	return db.TaskFinding{
+		Tool:     db.TaskFindingAnsibleLint,
+		Severity: severity,
+		Rule:     issue.CheckName,
+		File:     issue.Location.Path,
+		Line:     line,
+		Message:  issue.Description,
+	}
+}
+
+// parseAnsibleSyntaxError makes the finding from ansible-playbook error output.
+func (t *AnsibleApp) parseAnsibleSyntaxError(output string) db.TaskFinding {
+	finding := db.TaskFinding{
+		Tool:     db.TaskFindingSyntaxCheck,
+		Severity: db.TaskFindingError,
+		Message:  strings.TrimSpace(output),
+	}
+
+	if m := ansibleErrorMessageRegexp.FindStringSubmatch(output); m != nil {
+		finding.Message = strings.TrimSpace(m[1])
+	}
+
+	if m := ansibleErrorLocationRegexp.FindStringSubmatch(output); m != nil {
+		finding.File = strings.TrimPrefix(strings.TrimPrefix(m[1], t.getRepoPath()), "/")
+		finding.Line, _ = strconv.Atoi(m[2])
+	}
+
+	return finding
+}
+
+func (t *AnsibleApp) logCheckOutput(output string) {
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if line != "" {
+			t.Log(line)
+		}
+	}
+}
+
+func (t *AnsibleApp) getCheckEnvironmentVars(args LocalAppRunningArgs) (res []string, err error) {
+	res, err = t.getAnsibleEnvironmentVars()
+	if err != nil {
+		return
+	}
+
+	if args.EnvironmentVars != nil {
+		res = append(res, *args.EnvironmentVars...)
+	}
+
+	// findings are parsed from the output, so it must not contain color codes
+	res = append(res, "ANSIBLE_FORCE_COLOR=False", "ANSIBLE_NOCOLOR=True")
+	return
+}
+
+func (t *AnsibleApp) syntaxCheck(args LocalAppRunningArgs) ([]db.TaskFinding, error) {
+	t.Log("Running syntax check")
+
+	environmentVars, err := t.getCheckEnvironmentVars(args)
+	if err != nil {
+		return nil, err
+	}
+
+	cliArgs := append(append([]string{}, args.CliArgs...), "--syntax-check")
+
+	stdout, stderr, err := t.Playbook.runCheckCmd("ansible-playbook", cliArgs, &environmentVars, args.Inputs)
+	t.logCheckOutput(stdout)
+	t.logCheckOutput(stderr)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return []db.TaskFinding{t.parseAnsibleSyntaxError(stderr + stdout)}, ErrCheckFailed
+	}
+
+	return nil, err
+}
+
+func (t *AnsibleApp) lint(args LocalAppRunningArgs) (findings []db.TaskFinding, err error) {
+	t.Log("Running ansible-lint")
+
+	environmentVars, err := t.getCheckEnvironmentVars(args)
+	if err != nil {
+		return
+	}
+
+	// playbook is always the last argument of ansible-playbook
+	playbook := t.Template.Playbook
+	if len(args.CliArgs) > 0 {
+		playbook = args.CliArgs[len(args.CliArgs)-1]
+	}
+
+	stdout, stderr, err := t.Playbook.runCheckCmd("ansible-lint", []string{
+		"--nocolor",
+		"-f",
+		"codeclimate",
+		playbook,
+	}, &environmentVars, nil)
+
+	var execErr *exec.Error
+	if errors.As(err, &execErr) || errors.Is(err, fs.ErrNotExist) {
+		t.Log("ansible-lint is not installed. Skip lint.")
+		return nil, nil
+	}
+
+	t.logCheckOutput(stderr)
+
+	var issues []ansibleLintIssue
+	if jsonErr := json.Unmarshal([]byte(stdout), &issues); jsonErr != nil {
+		t.logCheckOutput(stdout)
+	}
+
+	for _, issue := range issues {
+		findings = append(findings, issue.toFinding())
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		err = ErrCheckFailed
+	}
+
+	return
+}
+
+func (t *AnsibleApp) Check(args LocalAppRunningArgs) (findings []db.TaskFinding, err error) {
+	params, err := t.getTemplateParams()
+	if err != nil {
+		return
+	}
+
+	if params.SyntaxCheck {
+		findings, err = t.syntaxCheck(args)
+		if err != nil {
+			return
+		}
+	}
+
+	if params.Lint {
+		var lintFindings []db.TaskFinding
+		lintFindings, err = t.lint(args)
+		findings = append(findings, lintFindings...)
+	}
+
+	return
+}
@@ -0,0 +1,78 @@
+package db_lib
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAnsibleSyntaxError(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: "/tmp/semaphore",
+	}
+
+	app := AnsibleApp{
+		Repository: db.Repository{GitURL: "/srv/playbooks"},
+	}
+
+	output := "ERROR! conflicting action statements: debug, msg\n\n" +
+		"The error appears to be in '/srv/playbooks/site.yml': line 5, column 7, but may\n" +
+		"be elsewhere in the file depending on the exact syntax problem.\n"
+
+	finding := app.parseAnsibleSyntaxError(output)
+
+	assert.Equal(t, db.TaskFinding{
+		Tool:     db.TaskFindingSyntaxCheck,
+		Severity: db.TaskFindingError,
+		File:     "site.yml",
+		Line:     5,
+		Message:  "conflicting action statements: debug, msg",
+	}, finding)
+}
+
+func TestAnsibleLintIssueToFinding(t *testing.T) {
+	var issues []ansibleLintIssue
+	err := json.Unmarshal([]byte(`[
+		{"check_name": "yaml[truthy]", "severity": "minor", "description": "Truthy value should be one of [false, true]",
+		 "location": {"path": "site.yml", "lines": {"begin": 3}}},
+		{"check_name": "syntax-check[specific]", "severity": "blocker", "description": "Invalid options",
+		 "location": {"path": "roles/web/tasks/main.yml", "positions": {"begin": {"line": 7, "column": 3}}}}
+	]`), &issues)
+	require.NoError(t, err)
+
+	f1 := issues[0].toFinding()
+	assert.Equal(t, db.TaskFindingWarning, f1.Severity)
+	assert.Equal(t, "yaml[truthy]", f1.Rule)
+	assert.Equal(t, 3, f1.Line)
+
+	f2 := issues[1].toFinding()
+	assert.Equal(t, db.TaskFindingError, f2.Severity)
+	assert.Equal(t, "roles/web/tasks/main.yml", f2.File)
+	assert.Equal(t, 7, f2.Line)
+}
+
+func TestTerraformValidateFindings(t *testing.T) {
+	var res terraformValidateResult
+	err := json.Unmarshal([]byte(`{
+		"valid": false,
+		"diagnostics": [{
+			"severity": "error",
+			"summary": "Unsupported argument",
+			"detail": "An argument named \"foo\" is not expected here.",
+			"range": {"filename": "main.tf", "start": {"line": 12}}
+		}]
+	}`), &res)
+	require.NoError(t, err)
+
+	assert.Equal(t, []db.TaskFinding{{
+		Tool:     db.TaskFindingTerraformValidate,
+		Severity: db.TaskFindingError,
+		File:     "main.tf",
+		Line:     12,
+		Message:  "Unsupported argument: An argument named \"foo\" is not expected here.",
+	}}, res.findings())
+}
@@ -0,0 +1,411 @@
+package db_lib
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/semaphoreui/semaphore/db"
+	"gopkg.in/yaml.v3"
+)
+
+// maxPlaybookDocDepth limits the nesting of playbooks imported by import_playbook.
+const maxPlaybookDocDepth = 5
+
+// playbookTaskListKeys are keys of plays and blocks which contain tasks.
+var playbookTaskListKeys = []string{"pre_tasks", "tasks", "post_tasks", "handlers", "block", "rescue", "always"}
+
+type playbookDocParser struct {
+	root    string
+	doc     db.TemplateDocContent
+	tags    map[string]bool
+	visited map[string]bool
+}
+
+// ParsePlaybookDoc discovers plays, variables and tags of the playbook. The playbook path is
+// relative to the repository root. Plays of imported playbooks, variables of vars_files and
+// defaults of roles are included. Only the playbook itself must be readable, problems with
+// other files are returned as warnings.
+func ParsePlaybookDoc(repoPath string, playbook string) (doc db.TemplateDocContent, err error) {
+	p := playbookDocParser{
+		root:    repoPath,
+		tags:    make(map[string]bool),
+		visited: make(map[string]bool),
+	}
+
+	p.doc.Plays = make([]db.TemplateDocPlay, 0)
+	p.doc.Vars = make([]db.TemplateDocVar, 0)
+	p.doc.Warnings = make([]string, 0)
+
+	data, err := p.readFile(filepath.Clean(playbook))
+	if err != nil {
+		return
+	}
+
+	p.doc.Description = getPlaybookDescription(data)
+
+	err = p.parsePlaybook(filepath.Clean(playbook), data, 0)
+	if err != nil {
+		return
+	}
+
+	p.doc.Tags = make([]string, 0, len(p.tags))
+	for tag := range p.tags {
+		p.doc.Tags = append(p.doc.Tags, tag)
+	}
+	sort.Strings(p.doc.Tags)
+
+	doc = p.doc
+	return
+}
+
+// readFile reads the file of the repository. Files outside the repository are not read.
+func (p *playbookDocParser) readFile(rel string) ([]byte, error) {
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("file %s is outside the repository", rel)
+	}
+
+	return os.ReadFile(filepath.Join(p.root, rel))
+}
+
+func (p *playbookDocParser) warn(format string, args ...any) {
+	p.doc.Warnings = append(p.doc.Warnings, fmt.Sprintf(format, args...))
+}
+
+func (p *playbookDocParser) parsePlaybook(rel string, data []byte, depth int) error {
+	p.visited[rel] = true
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("invalid playbook %s: %s", rel, err.Error())
+	}
+
+	if len(root.Content) == 0 {
+		return nil
+	}
+
+	plays := root.Content[0]
+	if plays.Kind != yaml.SequenceNode {
+		return fmt.Errorf("invalid playbook %s: list of plays expected", rel)
+	}
+
+	for _, play := range plays.Content {
+		if play.Kind != yaml.MappingNode {
+			continue
+		}
+
+		if imported := getYamlValue(play, "import_playbook", "ansible.builtin.import_playbook"); imported != nil {
+			p.importPlaybook(rel, imported.Value, depth)
+			continue
+		}
+
+		p.parsePlay(rel, play)
+	}
+
+	return nil
+}
+
+func (p *playbookDocParser) importPlaybook(parent string, imported string, depth int) {
+	if strings.Contains(imported, "{{") {
+		p.warn("%s: templated import_playbook %s is skipped", parent, imported)
+		return
+	}
+
+	rel := filepath.Join(filepath.Dir(parent), imported)
+	if p.visited[rel] {
+		return
+	}
+
+	if depth+1 > maxPlaybookDocDepth {
+		p.warn("%s: import_playbook %s is nested too deep", parent, imported)
+		return
+	}
+
+	data, err := p.readFile(rel)
+	if err != nil {
+		p.warn("%s: can't read imported playbook %s", parent, imported)
+		return
+	}
+
+	if err = p.parsePlaybook(rel, data, depth+1); err != nil {
+		p.warn("%s", err.Error())
+	}
+}
+
+func (p *playbookDocParser) parsePlay(rel string, node *yaml.Node) {
+	play := db.TemplateDocPlay{
+		File:  rel,
+		Hosts: getYamlStrings(getYamlValue(node, "hosts")),
+		Tags:  getYamlStrings(getYamlValue(node, "tags")),
+		Roles: make([]string, 0),
+	}
+
+	if name := getYamlValue(node, "name"); name != nil {
+		play.Name = name.Value
+	}
+
+	for _, tag := range play.Tags {
+		p.tags[tag] = true
+	}
+
+	if vars := getYamlValue(node, "vars"); vars != nil && vars.Kind == yaml.MappingNode {
+		p.addVars(vars, db.TemplateDocVarPlay, rel)
+	}
+
+	if varsFiles := getYamlValue(node, "vars_files"); varsFiles != nil {
+		p.parseVarsFiles(rel, varsFiles)
+	}
+
+	if prompts := getYamlValue(node, "vars_prompt"); prompts != nil {
+		p.parseVarsPrompt(rel, prompts)
+	}
+
+	if roles := getYamlValue(node, "roles"); roles != nil && roles.Kind == yaml.SequenceNode {
+		for _, role := range roles.Content {
+			name := p.parseRole(rel, role)
+			if name != "" {
+				play.Roles = append(play.Roles, name)
+			}
+		}
+	}
+
+	p.collectTaskTags(node)
+
+	p.doc.Plays = append(p.doc.Plays, play)
+}
+
+// addVars adds variables of the mapping in the order of declaration.
+func (p *playbookDocParser) addVars(vars *yaml.Node, source db.TemplateDocVarSource, file string) {
+	for i := 0; i+1 < len(vars.Content); i += 2 {
+		v := db.TemplateDocVar{
+			Name:   vars.Content[i].Value,
+			Source: source,
+			File:   file,
+		}
+		v.Default, v.Secret = getYamlVarValue(vars.Content[i+1])
+		p.doc.Vars = append(p.doc.Vars, v)
+	}
+}
+
+func (p *playbookDocParser) parseVarsFiles(rel string, varsFiles *yaml.Node) {
+	for _, item := range varsFiles.Content {
+		// the list of files means the first found file is used
+		candidates := getYamlStrings(item)
+
+		found := false
+		for _, file := range candidates {
+			if strings.Contains(file, "{{") {
+				p.warn("%s: templated vars file %s is skipped", rel, file)
+				found = true
+				break
+			}
+
+			fileRel := filepath.Join(filepath.Dir(rel), file)
+			data, err := p.readFile(fileRel)
+			if err != nil {
+				continue
+			}
+
+			found = true
+			p.parseVarsFile(fileRel, data, db.TemplateDocVarFile)
+			break
+		}
+
+		if !found && len(candidates) > 0 {
+			p.warn("%s: can't read vars file %s", rel, strings.Join(candidates, ", "))
+		}
+	}
+}
+
+func (p *playbookDocParser) parseVarsFile(rel string, data []byte, source db.TemplateDocVarSource) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		p.warn("invalid vars file %s: %s", rel, err.Error())
+		return
+	}
+
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return
+	}
+
+	p.addVars(root.Content[0], source, rel)
+}
+
+func (p *playbookDocParser) parseVarsPrompt(rel string, prompts *yaml.Node) {
+	for _, prompt := range prompts.Content {
+		if prompt.Kind != yaml.MappingNode {
+			continue
+		}
+
+		name := getYamlValue(prompt, "name")
+		if name == nil {
+			continue
+		}
+
+		v := db.TemplateDocVar{
+			Name:   name.Value,
+			Source: db.TemplateDocVarPrompt,
+			File:   rel,
+			// prompts are private by default
+			Secret: true,
+		}
+
+		if text := getYamlValue(prompt, "prompt"); text != nil {
+			v.Prompt = text.Value
+		}
+
+		if private := getYamlValue(prompt, "private"); private != nil {
+			var b bool
+			if private.Decode(&b) == nil {
+				v.Secret = b
+			}
+		}
+
+		if def := getYamlValue(prompt, "default"); def != nil && !v.Secret {
+			v.Default, v.Secret = getYamlVarValue(def)
+		}
+
+		p.doc.Vars = append(p.doc.Vars, v)
+	}
+}
+
+// parseRole adds tags and defaults of the role of the play and returns the name of the role.
+func (p *playbookDocParser) parseRole(rel string, role *yaml.Node) string {
+	var name string
+
+	switch role.Kind {
+	case yaml.ScalarNode:
+		name = role.Value
+	case yaml.MappingNode:
+		if n := getYamlValue(role, "role", "name"); n != nil {
+			name = n.Value
+		}
+		for _, tag := range getYamlStrings(getYamlValue(role, "tags")) {
+			p.tags[tag] = true
+		}
+	}
+
+	if name == "" || strings.Contains(name, "{{") {
+		return name
+	}
+
+	for _, file := range []string{"main.yml", "main.yaml"} {
+		defaultsRel := filepath.Join(filepath.Dir(rel), "roles", name, "defaults", file)
+		data, err := p.readFile(defaultsRel)
+		if err != nil {
+			continue
+		}
+		p.parseVarsFile(defaultsRel, data, db.TemplateDocVarRoleDefaults)
+		break
+	}
+
+	return name
+}
+
+// collectTaskTags adds tags of tasks and blocks of the play.
+func (p *playbookDocParser) collectTaskTags(node *yaml.Node) {
+	for _, key := range playbookTaskListKeys {
+		tasks := getYamlValue(node, key)
+		if tasks == nil || tasks.Kind != yaml.SequenceNode {
+			continue
+		}
+
+		for _, task := range tasks.Content {
+			if task.Kind != yaml.MappingNode {
+				continue
+			}
+
+			for _, tag := range getYamlStrings(getYamlValue(task, "tags")) {
+				p.tags[tag] = true
+			}
+
+			p.collectTaskTags(task)
+		}
+	}
+}
+
+// getYamlValue returns the value of the first found key of the mapping.
+func getYamlValue(mapping *yaml.Node, keys ...string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for _, key := range keys {
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if mapping.Content[i].Value == key {
+				return mapping.Content[i+1]
+			}
+		}
+	}
+
+	return nil
+}
+
+// getYamlStrings returns the list of strings of the value which can be either
+// a list or a comma separated string, e.g. tags: [web, db] or tags: web,db.
+func getYamlStrings(node *yaml.Node) []string {
+	res := make([]string, 0)
+
+	if node == nil {
+		return res
+	}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if strings.Contains(node.Value, "{{") {
+			return append(res, node.Value)
+		}
+		for _, s := range strings.Split(node.Value, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				res = append(res, s)
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if item.Kind == yaml.ScalarNode && item.Value != "" {
+				res = append(res, item.Value)
+			}
+		}
+	}
+
+	return res
+}
+
+// getYamlVarValue returns the value of the variable. Values encrypted by Ansible Vault are not returned.
+func getYamlVarValue(node *yaml.Node) (value any, secret bool) {
+	if node.Tag == "!vault" {
+		return nil, true
+	}
+
+	if node.Decode(&value) != nil {
+		return nil, false
+	}
+
+	return value, false
+}
+
+// getPlaybookDescription returns the comment at the beginning of the playbook.
+func getPlaybookDescription(data []byte) string {
+	var lines []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "---" || strings.HasPrefix(line, "#!") || line == "" && len(lines) == 0 {
+			continue
+		}
+
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+
+		lines = append(lines, strings.TrimSpace(strings.TrimPrefix(line, "#")))
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,150 @@
+package db_lib
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func writeDocFile(t *testing.T, dir string, name string, content string) {
+	if err := os.MkdirAll(path.Dir(path.Join(dir, name)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParsePlaybookDoc(t *testing.T) {
+	dir := t.TempDir()
+
+	writeDocFile(t, dir, "site.yml", `---
+# Deploys the web application.
+# Run it after the database migration.
+
+- import_playbook: db.yml
+
+- name: Deploy web
+  hosts: web,lb
+  tags: [deploy]
+  vars:
+    app_port: 8080
+    api_token: !vault |
+      $ANSIBLE_VAULT;1.1;AES256
+      6162
+  vars_files:
+    - vars/common.yml
+    - [vars/missing.yml, vars/fallback.yml]
+  vars_prompt:
+    - name: release
+      prompt: Release to deploy
+      private: false
+      default: latest
+    - name: admin_password
+      prompt: Admin password
+  roles:
+    - nginx
+    - role: app
+      tags: app
+  tasks:
+    - name: Restart
+      service: name=app state=restarted
+      tags: [restart]
+    - block:
+        - debug: msg=ok
+          tags: debug
+`)
+	writeDocFile(t, dir, "db.yml", `
+- name: Migrate database
+  hosts: db
+  tasks: []
+`)
+	writeDocFile(t, dir, "vars/common.yml", "app_user: deploy\n")
+	writeDocFile(t, dir, "vars/fallback.yml", "app_env: prod\n")
+	writeDocFile(t, dir, "roles/nginx/defaults/main.yml", "nginx_workers: 4\n")
+
+	doc, err := ParsePlaybookDoc(dir, "site.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Description != "Deploys the web application.\nRun it after the database migration." {
+		t.Fatalf("unexpected description %q", doc.Description)
+	}
+
+	if len(doc.Plays) != 2 || doc.Plays[0].Name != "Migrate database" || doc.Plays[1].Name != "Deploy web" {
+		t.Fatalf("unexpected plays %+v", doc.Plays)
+	}
+
+	if strings.Join(doc.Plays[1].Hosts, ",") != "web,lb" || strings.Join(doc.Plays[1].Roles, ",") != "nginx,app" {
+		t.Fatalf("unexpected play %+v", doc.Plays[1])
+	}
+
+	if strings.Join(doc.Tags, ",") != "app,debug,deploy,restart" {
+		t.Fatalf("unexpected tags %v", doc.Tags)
+	}
+
+	vars := make(map[string]db.TemplateDocVar)
+	for _, v := range doc.Vars {
+		vars[v.Name] = v
+	}
+
+	if vars["app_port"].Default != 8080 || vars["app_port"].Source != db.TemplateDocVarPlay {
+		t.Fatalf("unexpected app_port %+v", vars["app_port"])
+	}
+
+	if !vars["api_token"].Secret || vars["api_token"].Default != nil {
+		t.Fatalf("vault value must be hidden, got %+v", vars["api_token"])
+	}
+
+	if vars["app_user"].File != "vars/common.yml" || vars["app_env"].File != "vars/fallback.yml" {
+		t.Fatalf("unexpected vars files %+v %+v", vars["app_user"], vars["app_env"])
+	}
+
+	if vars["release"].Default != "latest" || vars["release"].Secret {
+		t.Fatalf("unexpected release %+v", vars["release"])
+	}
+
+	if !vars["admin_password"].Secret {
+		t.Fatalf("prompts must be private by default, got %+v", vars["admin_password"])
+	}
+
+	if vars["nginx_workers"].Source != db.TemplateDocVarRoleDefaults {
+		t.Fatalf("unexpected nginx_workers %+v", vars["nginx_workers"])
+	}
+
+	surveyVars := doc.GetSurveyVars()
+	for _, v := range surveyVars {
+		if v.Name == "nginx_workers" {
+			t.Fatal("role defaults must not be added to the launch form")
+		}
+	}
+}
+
+func TestParsePlaybookDocOutsideRepository(t *testing.T) {
+	dir := t.TempDir()
+
+	writeDocFile(t, dir, "site.yml", `
+- import_playbook: ../../etc/playbook.yml
+- name: Test
+  hosts: all
+  vars_files:
+    - ../secrets.yml
+`)
+
+	doc, err := ParsePlaybookDoc(dir, "site.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(doc.Warnings) != 2 || len(doc.Plays) != 1 {
+		t.Fatalf("files outside the repository must be skipped, got %+v", doc)
+	}
+
+	if _, err = ParsePlaybookDoc(dir, "../site.yml"); err == nil {
+		t.Fatal("playbook outside the repository must not be parsed")
+	}
+}
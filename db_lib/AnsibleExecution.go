@@ -0,0 +1,90 @@
+package db_lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// getExecutionArgs returns options and environment variables of ansible-playbook
+// which apply forks, serial and strategy of the template overridden by the task.
+func (t *AnsibleApp) getExecutionArgs(taskParams interface{}) (args []string, env []string, err error) {
+	tplParams, err := t.getTemplateParams()
+	if err != nil {
+		return
+	}
+
+	var params db.AnsibleTaskParams
+	if p, ok := taskParams.(*db.AnsibleTaskParams); ok && p != nil {
+		params = *p
+	}
+
+	execution := db.GetAnsibleExecution(tplParams, params)
+	if err = execution.Validate(); err != nil {
+		return
+	}
+
+	if execution == (db.AnsibleExecution{}) {
+		return
+	}
+
+	var applied []string
+
+	if execution.Forks > 0 {
+		args = append(args, "--forks", strconv.Itoa(execution.Forks))
+		applied = append(applied, "forks="+strconv.Itoa(execution.Forks))
+	}
+
+	if execution.Serial != "" {
+		var serial []byte
+		serial, err = json.Marshal(map[string]string{"semaphore_serial": execution.Serial})
+		if err != nil {
+			return
+		}
+		args = append(args, "--extra-vars", string(serial))
+		applied = append(applied, "serial="+execution.Serial)
+	}
+
+	if execution.Strategy != db.AnsibleStrategyDefault {
+		env = append(env, "ANSIBLE_STRATEGY="+string(execution.Strategy))
+		applied = append(applied, "strategy="+string(execution.Strategy))
+
+		if execution.Strategy.IsMitogen() {
+			var plugins string
+			plugins, err = t.getMitogenStrategyPlugins(tplParams)
+			if err != nil {
+				return
+			}
+			env = append(env, "ANSIBLE_STRATEGY_PLUGINS="+plugins)
+		}
+	}
+
+	t.Log("Execution: " + strings.Join(applied, ", ") + "\n")
+
+	return
+}
+
+// getMitogenStrategyPlugins returns the directory of strategy plugins of Mitogen
+// installed for the Python which runs Ansible.
+func (t *AnsibleApp) getMitogenStrategyPlugins(params db.AnsibleTemplateParams) (string, error) {
+	interpreter := defaultPythonInterpreter
+	if params.PythonInterpreter != "" && params.AnsibleCoreVersion == "" {
+		interpreter = params.PythonInterpreter
+	}
+
+	cmd := t.Playbook.makeCmd(interpreter, []string{
+		"-c",
+		"import os, ansible_mitogen; print(os.path.dirname(ansible_mitogen.__file__))",
+	}, nil)
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("mitogen strategy requires Mitogen for Ansible installed for %s", interpreter)
+	}
+
+	return path.Join(strings.TrimSpace(string(out)), "plugins", "strategy"), nil
+}
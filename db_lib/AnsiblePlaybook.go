@@ -1,9 +1,11 @@
 package db_lib
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
 	"strings"
 
 	"github.com/creack/pty"
@@ -16,9 +18,19 @@ type AnsiblePlaybook struct {
 	TemplateID int
 	Repository db.Repository
 	Logger     task_logger.Logger
+
+	// BinPath is a directory with Ansible executables, e.g. bin directory of the virtualenv.
+	// Executables are searched in PATH if it is empty.
+	BinPath string
+
+	cmdWrapper CmdWrapper
 }
 
 func (p AnsiblePlaybook) makeCmd(command string, args []string, environmentVars *[]string) *exec.Cmd {
+	if p.BinPath != "" && !path.IsAbs(command) {
+		command = path.Join(p.BinPath, command)
+	}
+
 	cmd := exec.Command(command, args...) //nolint: gas
 	cmd.Dir = p.GetFullPath()
 
@@ -29,21 +41,36 @@ func (p AnsiblePlaybook) makeCmd(command string, args []string, environmentVars
 	cmd.Env = append(cmd.Env, fmt.Sprintf("HOME=%s", util.Config.TmpPath))
 	cmd.Env = append(cmd.Env, fmt.Sprintf("PWD=%s", cmd.Dir))
 
+	if p.BinPath != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("PATH=%s:%s", p.BinPath, os.Getenv("PATH")))
+		cmd.Env = append(cmd.Env, fmt.Sprintf("VIRTUAL_ENV=%s", path.Dir(p.BinPath)))
+	}
+
 	if environmentVars != nil {
 		cmd.Env = append(cmd.Env, *environmentVars...)
 	}
 
-	return cmd
+	return wrapCmd(p.cmdWrapper, cmd)
 }
 
-func (p AnsiblePlaybook) runCmd(command string, args []string) error {
-	cmd := p.makeCmd(command, args, nil)
+func (p AnsiblePlaybook) runCmd(command string, args []string, environmentVars *[]string) error {
+	cmd := p.makeCmd(command, args, environmentVars)
 	p.Logger.LogCmd(cmd)
 	return cmd.Run()
 }
 
 func (p AnsiblePlaybook) RunPlaybook(args []string, environmentVars *[]string, inputs map[string]string, cb func(*os.Process)) error {
-	cmd := p.makeCmd("ansible-playbook", args, environmentVars)
+	return p.runInteractiveCmd("ansible-playbook", args, environmentVars, inputs, cb)
+}
+
+// RunAdhoc runs the ansible command which executes a single module on the hosts.
+func (p AnsiblePlaybook) RunAdhoc(args []string, environmentVars *[]string, inputs map[string]string, cb func(*os.Process)) error {
+	return p.runInteractiveCmd("ansible", args, environmentVars, inputs, cb)
+}
+
+// runInteractiveCmd runs the command in the terminal answering prompts of inputs.
+func (p AnsiblePlaybook) runInteractiveCmd(command string, args []string, environmentVars *[]string, inputs map[string]string, cb func(*os.Process)) error {
+	cmd := p.makeCmd(command, args, environmentVars)
 	p.Logger.LogCmd(cmd)
 
 	ptmx, err := pty.Start(cmd)
@@ -52,37 +79,59 @@ func (p AnsiblePlaybook) RunPlaybook(args []string, environmentVars *[]string, i
 		panic(err)
 	}
 
-	go func() {
+	go answerInputs(ptmx, inputs)
 
-		b := make([]byte, 100)
+	defer func() { _ = ptmx.Close() }()
+	cb(cmd.Process)
+	return cmd.Wait()
+}
 
-		var e error
+// answerInputs writes values of inputs to the terminal when the command prompts their keys.
+func answerInputs(ptmx *os.File, inputs map[string]string) {
+	b := make([]byte, 100)
 
-		for {
-			var n int
-			n, e = ptmx.Read(b)
-			if e != nil {
-				break
-			}
+	for {
+		n, err := ptmx.Read(b)
+		if err != nil {
+			break
+		}
 
-			s := strings.TrimSpace(string(b[0:n]))
+		s := strings.TrimSpace(string(b[0:n]))
 
-			for k, v := range inputs {
-				if strings.HasPrefix(s, k) {
-					_, _ = ptmx.WriteString(v + "\n")
-				}
+		for k, v := range inputs {
+			if strings.HasPrefix(s, k) {
+				_, _ = ptmx.WriteString(v + "\n")
 			}
 		}
+	}
+}
 
-	}()
+// runCheckCmd runs the command answering prompts of inputs and returns its stdout and stderr.
+func (p AnsiblePlaybook) runCheckCmd(command string, args []string, environmentVars *[]string, inputs map[string]string) (stdout string, stderr string, err error) {
+	cmd := p.makeCmd(command, args, environmentVars)
 
-	defer func() { _ = ptmx.Close() }()
-	cb(cmd.Process)
-	return cmd.Wait()
+	var stdoutBuf, stderrBuf bytes.Buffer
+	cmd.Stdout = &stdoutBuf
+	cmd.Stderr = &stderrBuf
+
+	// only stdin and controlling terminal are attached to pty, prompts are written to the terminal
+	ptmx, err := pty.Start(cmd)
+	if err != nil {
+		return
+	}
+
+	go answerInputs(ptmx, inputs)
+
+	err = cmd.Wait()
+	_ = ptmx.Close()
+
+	stdout = stdoutBuf.String()
+	stderr = stderrBuf.String()
+	return
 }
 
-func (p AnsiblePlaybook) RunGalaxy(args []string) error {
-	return p.runCmd("ansible-galaxy", args)
+func (p AnsiblePlaybook) RunGalaxy(args []string, environmentVars *[]string) error {
+	return p.runCmd("ansible-galaxy", args, environmentVars)
 }
 
 func (p AnsiblePlaybook) GetFullPath() (path string) {
@@ -0,0 +1,46 @@
+package db_lib
+
+import (
+	"strings"
+)
+
+// countListedTasks counts tasks in the output of ansible-playbook --list-tasks, e.g.
+//
+//	play #1 (web): Deploy	TAGS: []
+//	  tasks:
+//	    Install nginx	TAGS: []
+//	    nginx : Start service	TAGS: [service]
+func countListedTasks(output string) (count int) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.Contains(line, "TAGS: [") || strings.HasPrefix(line, "play #") {
+			continue
+		}
+		count++
+	}
+	return
+}
+
+// CountTasks lists tasks of the playbook if the progress tracking is enabled for the template.
+// Tasks of dynamic includes are not listed, so the count is approximate.
+func (t *AnsibleApp) CountTasks(args LocalAppRunningArgs) (int, error) {
+	params, err := t.getTemplateParams()
+	if err != nil || !params.TrackProgress {
+		return 0, err
+	}
+
+	environmentVars, err := t.getCheckEnvironmentVars(args)
+	if err != nil {
+		return 0, err
+	}
+
+	cliArgs := append(append([]string{}, args.CliArgs...), "--list-tasks")
+
+	stdout, stderr, err := t.Playbook.runCheckCmd("ansible-playbook", cliArgs, &environmentVars, args.Inputs)
+	if err != nil {
+		t.logCheckOutput(stderr)
+		return 0, err
+	}
+
+	return countListedTasks(stdout), nil
+}
@@ -0,0 +1,22 @@
+package db_lib
+
+import "testing"
+
+func TestCountListedTasks(t *testing.T) {
+	output := `
+playbook: site.yml
+
+  play #1 (web): Deploy web	TAGS: []
+    tasks:
+      Install nginx	TAGS: []
+      nginx : Start service	TAGS: [service]
+
+  play #2 (db): Deploy db	TAGS: [db]
+    tasks:
+      Install postgres	TAGS: [db]
+`
+
+	if count := countListedTasks(output); count != 3 {
+		t.Fatalf("expected 3 tasks, got %d", count)
+	}
+}
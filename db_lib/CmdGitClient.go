@@ -2,24 +2,90 @@ package db_lib
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"os/exec"
+	"path"
 	"strings"
 
 	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/gitcredential"
+	"github.com/semaphoreui/semaphore/pkg/random"
 	"github.com/semaphoreui/semaphore/util"
 )
 
 type CmdGitClient struct {
-	keyInstallation db.AccessKeyInstallation
+	keyInstallation  db.AccessKeyInstallation
+	credentialHelper *gitcredential.Helper
+	credentialArgs   []string
+}
+
+// installKey installs the key of the repository for the git command. Login and password
+// of HTTP repositories are served by the credential helper which lives until destroyKey.
+func (c *CmdGitClient) installKey(r GitRepository) (err error) {
+	c.keyInstallation, err = r.Repository.SSHKey.Install(db.AccessKeyRoleGit, r.Logger)
+	if err != nil {
+		return
+	}
+
+	if r.Repository.GetType() != db.RepositoryHTTP || r.Repository.SSHKey.Type != db.AccessKeyLoginPassword {
+		return
+	}
+
+	defer func() {
+		if err != nil {
+			c.keyInstallation.Destroy() //nolint: errcheck
+		}
+	}()
+
+	gitURL, err := url.Parse(r.Repository.GitURL)
+	if err != nil {
+		return
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return
+	}
+
+	helper := &gitcredential.Helper{
+		Host:       gitURL.Host,
+		Username:   r.Repository.SSHKey.LoginPassword.Login,
+		Password:   r.Repository.SSHKey.LoginPassword.Password,
+		SocketFile: path.Join(util.Config.TmpPath, fmt.Sprintf("git-credential-%s.sock", random.String(10))),
+	}
+
+	// the token without login is passed as the username
+	if helper.Username == "" {
+		helper.Username = helper.Password
+		helper.Password = ""
+	}
+
+	if err = helper.Listen(); err != nil {
+		return
+	}
+
+	c.credentialHelper = helper
+	c.credentialArgs = gitcredential.GitArgs(executable)
+
+	return
+}
+
+func (c *CmdGitClient) destroyKey() {
+	if c.credentialHelper != nil {
+		c.credentialHelper.Close() //nolint: errcheck
+		c.credentialHelper = nil
+	}
+
+	c.keyInstallation.Destroy() //nolint: errcheck
 }
 
 func (c CmdGitClient) makeCmd(r GitRepository, targetDir GitRepositoryDirType, args ...string) *exec.Cmd {
 	cmd := exec.Command("git") //nolint: gas
 
-	cmd.Env = os.Environ()
-	cmd.Env = append(cmd.Env, fmt.Sprintln("GIT_TERMINAL_PROMPT=0"))
-	if r.Repository.SSHKey.Type == db.AccessKeySSH {
+	cmd.Env = getEnvironmentVars()
+	cmd.Env = append(cmd.Env, "GIT_TERMINAL_PROMPT=0")
+	if c.keyInstallation.SSHAgent != nil {
 		cmd.Env = append(cmd.Env, fmt.Sprintf("SSH_AUTH_SOCK=%s", c.keyInstallation.SSHAgent.SocketFile))
 		sshCmd := "ssh -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null"
 		if util.Config.SshConfigPath != "" {
@@ -27,6 +93,10 @@ func (c CmdGitClient) makeCmd(r GitRepository, targetDir GitRepositoryDirType, a
 		}
 		cmd.Env = append(cmd.Env, fmt.Sprintf("GIT_SSH_COMMAND=%s", sshCmd))
 	}
+	if c.credentialHelper != nil {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", gitcredential.SocketEnv, c.credentialHelper.SocketFile))
+		cmd.Args = append(cmd.Args, c.credentialArgs...)
+	}
 
 	switch targetDir {
 	case GitRepositoryTmpPath:
@@ -43,14 +113,12 @@ func (c CmdGitClient) makeCmd(r GitRepository, targetDir GitRepositoryDirType, a
 }
 
 func (c CmdGitClient) run(r GitRepository, targetDir GitRepositoryDirType, args ...string) error {
-	var err error
-	c.keyInstallation, err = r.Repository.SSHKey.Install(db.AccessKeyRoleGit, r.Logger)
-
+	err := c.installKey(r)
 	if err != nil {
 		return err
 	}
 
-	defer c.keyInstallation.Destroy() //nolint: errcheck
+	defer c.destroyKey()
 
 	cmd := c.makeCmd(r, targetDir, args...)
 
@@ -60,12 +128,12 @@ func (c CmdGitClient) run(r GitRepository, targetDir GitRepositoryDirType, args
 }
 
 func (c CmdGitClient) output(r GitRepository, targetDir GitRepositoryDirType, args ...string) (out string, err error) {
-	c.keyInstallation, err = r.Repository.SSHKey.Install(db.AccessKeyRoleGit, r.Logger)
+	err = c.installKey(r)
 	if err != nil {
 		return
 	}
 
-	defer c.keyInstallation.Destroy() //nolint: errcheck
+	defer c.destroyKey()
 
 	bytes, err := c.makeCmd(r, targetDir, args...).Output()
 	if err != nil {
@@ -97,6 +165,11 @@ func (c CmdGitClient) Clone(r GitRepository) error {
 func (c CmdGitClient) Pull(r GitRepository) error {
 	r.Logger.Log("Updating Repository " + r.Repository.GitURL)
 
+	err := c.resetRemoteURL(r)
+	if err != nil {
+		return err
+	}
+
 	return c.run(r, GitRepositoryFullPath, "pull", "--recurse-submodules", "origin", r.Repository.GitBranch)
 }
 
@@ -106,7 +179,21 @@ func (c CmdGitClient) Checkout(r GitRepository, target string) error {
 	return c.run(r, GitRepositoryFullPath, "checkout", target)
 }
 
+// resetRemoteURL replaces the URL of the origin of the cloned repository,
+// so the credentials embedded into the URL by older versions do not remain in its config.
+func (c CmdGitClient) resetRemoteURL(r GitRepository) error {
+	if r.Repository.GetType() != db.RepositoryHTTP {
+		return nil
+	}
+
+	return c.makeCmd(r, GitRepositoryFullPath, "remote", "set-url", "origin", r.Repository.GetGitURL()).Run()
+}
+
 func (c CmdGitClient) CanBePulled(r GitRepository) bool {
+	if c.resetRemoteURL(r) != nil {
+		return false
+	}
+
 	err := c.run(r, GitRepositoryFullPath, "fetch")
 	if err != nil {
 		return false
@@ -156,3 +243,34 @@ func (c CmdGitClient) GetLastRemoteCommitHash(r GitRepository) (hash string, err
 	hash = out[0:firstSpaceIndex]
 	return
 }
+
+// RemoteFileExists fetches the last commit of the branch without file contents
+// to the temporary repository and looks for the file in its tree.
+func (c CmdGitClient) RemoteFileExists(r GitRepository, filePath string) (exists bool, err error) {
+	r.TmpDirName = "ls-tree-" + random.String(10)
+
+	defer os.RemoveAll(r.GetFullPath()) //nolint: errcheck
+
+	if _, err = c.output(r, GitRepositoryTmpPath, "init", "--quiet", r.TmpDirName); err != nil {
+		return
+	}
+
+	_, err = c.output(r, GitRepositoryFullPath,
+		"fetch",
+		"--quiet",
+		"--depth=1",
+		"--filter=blob:none",
+		r.Repository.GetGitURL(),
+		r.Repository.GitBranch)
+	if err != nil {
+		return
+	}
+
+	out, err := c.output(r, GitRepositoryFullPath, "ls-tree", "--name-only", "FETCH_HEAD", "--", filePath)
+	if err != nil {
+		return
+	}
+
+	exists = out != ""
+	return
+}
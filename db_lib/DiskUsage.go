@@ -0,0 +1,149 @@
+package db_lib
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	repositoryDirRegexp = regexp.MustCompile(`^repository_(\d+)_(\d+)$`)
+	templateDirRegexp   = regexp.MustCompile(`^template_(\d+)$`)
+	taskTmpEntryRegexp  = regexp.MustCompile(`^(?:inventory|ssh_config|ssh_control|outputs)_(\d+)`)
+	taskOutputRegexp    = regexp.MustCompile(`^task_output_.*\.jsonl$`)
+)
+
+// sharedCacheDirs are the caches of the tmp directory which are used by all projects.
+var sharedCacheDirs = map[string]bool{
+	"galaxy_cache": true,
+	"python_venvs": true,
+	".ansible":     true,
+}
+
+// getDiskUsageEntry classifies the entry of the tmp directory by its name.
+func getDiskUsageEntry(name string) db.DiskUsageEntry {
+	entry := db.DiskUsageEntry{Name: name, Kind: db.DiskUsageOther}
+
+	if m := repositoryDirRegexp.FindStringSubmatch(name); m != nil {
+		entry.Kind = db.DiskUsageRepository
+		entry.RepositoryID, _ = strconv.Atoi(m[1])
+		entry.TemplateID, _ = strconv.Atoi(m[2])
+	} else if m = templateDirRegexp.FindStringSubmatch(name); m != nil {
+		entry.Kind = db.DiskUsageTemplate
+		entry.TemplateID, _ = strconv.Atoi(m[1])
+	} else if m = taskTmpEntryRegexp.FindStringSubmatch(name); m != nil {
+		entry.Kind = db.DiskUsageTask
+		entry.TaskID, _ = strconv.Atoi(m[1])
+	} else if taskOutputRegexp.MatchString(name) {
+		entry.Kind = db.DiskUsageTask
+	} else if sharedCacheDirs[name] {
+		entry.Kind = db.DiskUsageShared
+	}
+
+	return entry
+}
+
+// ScanDiskUsage returns the size and the last use of each entry of the directory.
+// Files which disappear during the scan are skipped.
+func ScanDiskUsage(dir string) (entries []db.DiskUsageEntry, err error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+
+	for _, dirEntry := range dirEntries {
+		entry := getDiskUsageEntry(dirEntry.Name())
+
+		walkErr := filepath.WalkDir(path.Join(dir, dirEntry.Name()), func(_ string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+
+			if !d.IsDir() {
+				entry.Size += info.Size()
+			}
+
+			if info.ModTime().After(entry.LastUsed) {
+				entry.LastUsed = info.ModTime()
+			}
+
+			return nil
+		})
+
+		if walkErr != nil {
+			log.WithError(walkErr).WithField("entry", entry.Name).Warn("Can not measure disk usage")
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return
+}
+
+// CollectDiskUsage measures the tmp directory, removes the caches which exceed the limits
+// of the disk usage config and returns the usage of the remaining data by project and kind.
+func CollectDiskUsage(
+	projectOf func(db.DiskUsageEntry) *int,
+	inUse func(db.DiskUsageEntry) bool,
+	now time.Time,
+) (usage []db.DiskUsage, evicted []db.DiskUsageEntry, err error) {
+	entries, err := ScanDiskUsage(util.Config.TmpPath)
+	if err != nil {
+		return
+	}
+
+	if cfg := util.Config.DiskUsage; cfg != nil {
+		candidates := db.SelectDiskUsageEvictions(
+			entries,
+			projectOf,
+			inUse,
+			int64(cfg.ProjectCacheLimitMB)*1024*1024,
+			int64(cfg.CacheLimitMB)*1024*1024,
+		)
+
+		removed := make(map[string]bool)
+
+		for _, entry := range candidates {
+			if err = os.RemoveAll(path.Join(util.Config.TmpPath, entry.Name)); err != nil {
+				return
+			}
+
+			removed[entry.Name] = true
+			evicted = append(evicted, entry)
+		}
+
+		remaining := make([]db.DiskUsageEntry, 0, len(entries))
+		for _, entry := range entries {
+			if !removed[entry.Name] {
+				remaining = append(remaining, entry)
+			}
+		}
+		entries = remaining
+	}
+
+	usage = db.SumDiskUsage(entries, projectOf, now)
+	return
+}
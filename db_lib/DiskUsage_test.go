@@ -0,0 +1,68 @@
+package db_lib
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func createDiskUsageEntry(t *testing.T, name string, size int, lastUsed time.Time) {
+	entryPath := path.Join(util.Config.TmpPath, name)
+
+	if err := os.MkdirAll(entryPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	filePath := path.Join(entryPath, "data")
+	if err := os.WriteFile(filePath, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{filePath, entryPath} {
+		if err := os.Chtimes(p, lastUsed, lastUsed); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCollectDiskUsage(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath:   t.TempDir(),
+		DiskUsage: &util.DiskUsageConfig{CacheLimitMB: 1},
+	}
+
+	now := time.Now()
+	createDiskUsageEntry(t, "repository_1_1", 700*1024, now.Add(-2*time.Hour))
+	createDiskUsageEntry(t, "repository_1_2", 700*1024, now.Add(-time.Hour))
+	createDiskUsageEntry(t, "inventory_5", 100, now)
+
+	projectID := 1
+	projectOf := func(entry db.DiskUsageEntry) *int {
+		if entry.Kind == db.DiskUsageRepository {
+			return &projectID
+		}
+		return nil
+	}
+
+	usage, evicted, err := CollectDiskUsage(projectOf, func(db.DiskUsageEntry) bool { return false }, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(evicted) != 1 || evicted[0].Name != "repository_1_1" {
+		t.Fatalf("expected the least recently used repository to be evicted, got %v", evicted)
+	}
+
+	if _, err = os.Stat(path.Join(util.Config.TmpPath, "repository_1_1")); !os.IsNotExist(err) {
+		t.Fatal("evicted repository must be removed")
+	}
+
+	if len(usage) != 2 || usage[0].Kind != db.DiskUsageTask || usage[0].Size != 100 ||
+		usage[1].Kind != db.DiskUsageRepository || usage[1].Size != 700*1024 {
+		t.Fatalf("unexpected disk usage %v", usage)
+	}
+}
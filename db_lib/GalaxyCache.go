@@ -0,0 +1,211 @@
+package db_lib
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// galaxyCacheMarker is created in the cache entry after successful installation.
+// Its modification time is the installation time.
+const galaxyCacheMarker = ".semaphore_installed"
+
+type galaxyCacheEntry struct {
+	path     string
+	lastUsed time.Time
+	size     int64
+}
+
+func getGalaxyCachePath() string {
+	return path.Join(util.Config.TmpPath, "galaxy_cache")
+}
+
+func getGalaxyCacheTTL() time.Duration {
+	return time.Duration(util.Config.GalaxyCache.TTLHours) * time.Hour
+}
+
+func isGalaxyCacheEnabled() bool {
+	return util.Config.GalaxyCache != nil && util.Config.GalaxyCache.Enabled
+}
+
+func getSHA256Hash(filepath string) (string, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// getGalaxyCacheEntryPath returns directory of the cache entry for the requirements file.
+func getGalaxyCacheEntryPath(requirementsType GalaxyRequirementsType, requirementsFilePath string) (string, error) {
+	hash, err := getSHA256Hash(requirementsFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(getGalaxyCachePath(), string(requirementsType)+"_"+hash), nil
+}
+
+func isGalaxyCacheEntryValid(entryPath string, ttl time.Duration) bool {
+	info, err := os.Stat(path.Join(entryPath, galaxyCacheMarker))
+	if err != nil {
+		return false
+	}
+
+	return ttl <= 0 || time.Since(info.ModTime()) < ttl
+}
+
+// touchGalaxyCacheEntry updates last usage time of the entry which is used to
+// find least recently used entries.
+func touchGalaxyCacheEntry(entryPath string) {
+	now := time.Now()
+	_ = os.Chtimes(entryPath, now, now)
+}
+
+func getDirSize(dir string) (size int64, err error) {
+	err = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		size += info.Size()
+		return nil
+	})
+	return
+}
+
+func getGalaxyCacheEntries() (entries []galaxyCacheEntry, err error) {
+	dirs, err := os.ReadDir(getGalaxyCachePath())
+	if err != nil {
+		return
+	}
+
+	for _, d := range dirs {
+		// skip files and installations in progress
+		if !d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			continue
+		}
+
+		entry := galaxyCacheEntry{
+			path: path.Join(getGalaxyCachePath(), d.Name()),
+		}
+
+		info, err2 := d.Info()
+		if err2 != nil {
+			continue
+		}
+		entry.lastUsed = info.ModTime()
+
+		entry.size, err = getDirSize(entry.path)
+		if err != nil {
+			return
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return
+}
+
+// shrinkGalaxyCache removes least recently used entries until the cache size
+// is less than maxSize. The entry with path keepPath is never removed.
+func shrinkGalaxyCache(maxSize int64, keepPath string) error {
+	if maxSize <= 0 {
+		return nil
+	}
+
+	entries, err := getGalaxyCacheEntries()
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].lastUsed.Before(entries[j].lastUsed)
+	})
+
+	for _, e := range entries {
+		if total <= maxSize {
+			break
+		}
+
+		if e.path == keepPath {
+			continue
+		}
+
+		if err = os.RemoveAll(e.path); err != nil {
+			return err
+		}
+
+		total -= e.size
+	}
+
+	return nil
+}
+
+// EvictGalaxyCache removes expired entries and shrinks the cache to the maximum size.
+// It returns the number of removed entries.
+func EvictGalaxyCache() (removed int, err error) {
+	if !isGalaxyCacheEnabled() {
+		return
+	}
+
+	entries, err := getGalaxyCacheEntries()
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return
+	}
+
+	ttl := getGalaxyCacheTTL()
+	for _, e := range entries {
+		if ttl > 0 && !isGalaxyCacheEntryValid(e.path, ttl) {
+			if err = os.RemoveAll(e.path); err != nil {
+				return
+			}
+			removed++
+		}
+	}
+
+	maxSize := int64(util.Config.GalaxyCache.MaxSizeMB) * 1024 * 1024
+	if err = shrinkGalaxyCache(maxSize, ""); err != nil {
+		return
+	}
+
+	remaining, err := getGalaxyCacheEntries()
+	if err != nil {
+		return
+	}
+
+	removed += len(entries) - removed - len(remaining)
+	return
+}
@@ -0,0 +1,78 @@
+package db_lib
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func createGalaxyCacheEntry(t *testing.T, name string, size int, lastUsed time.Time) string {
+	entryPath := path.Join(getGalaxyCachePath(), name)
+
+	if err := os.MkdirAll(entryPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path.Join(entryPath, galaxyCacheMarker), make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(entryPath, lastUsed, lastUsed); err != nil {
+		t.Fatal(err)
+	}
+
+	return entryPath
+}
+
+func TestShrinkGalaxyCache(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: t.TempDir(),
+	}
+
+	now := time.Now()
+	oldest := createGalaxyCacheEntry(t, "role_1", 100, now.Add(-3*time.Hour))
+	middle := createGalaxyCacheEntry(t, "role_2", 100, now.Add(-2*time.Hour))
+	newest := createGalaxyCacheEntry(t, "role_3", 100, now.Add(-1*time.Hour))
+
+	if err := shrinkGalaxyCache(250, middle); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(oldest); !os.IsNotExist(err) {
+		t.Fatal("least recently used entry must be removed")
+	}
+
+	for _, p := range []string{middle, newest} {
+		if _, err := os.Stat(p); err != nil {
+			t.Fatal("entry must be kept: " + p)
+		}
+	}
+}
+
+func TestIsGalaxyCacheEntryValid(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: t.TempDir(),
+	}
+
+	entryPath := createGalaxyCacheEntry(t, "collection_1", 1, time.Now())
+
+	if !isGalaxyCacheEntryValid(entryPath, 0) {
+		t.Fatal("entry must be valid without TTL")
+	}
+
+	installed := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(path.Join(entryPath, galaxyCacheMarker), installed, installed); err != nil {
+		t.Fatal(err)
+	}
+
+	if isGalaxyCacheEntryValid(entryPath, time.Hour) {
+		t.Fatal("expired entry must be invalid")
+	}
+
+	if isGalaxyCacheEntryValid(path.Join(getGalaxyCachePath(), "missing"), 0) {
+		t.Fatal("missing entry must be invalid")
+	}
+}
@@ -24,6 +24,9 @@ type GitClient interface {
 	GetLastCommitMessage(r GitRepository) (msg string, err error)
 	GetLastCommitHash(r GitRepository) (hash string, err error)
 	GetLastRemoteCommitHash(r GitRepository) (hash string, err error)
+	// RemoteFileExists checks that the file exists in the branch of the remote repository
+	// without cloning it. The path of the file is relative to the repository root.
+	RemoteFileExists(r GitRepository, filePath string) (bool, error)
 }
 
 type GitRepository struct {
@@ -73,3 +76,7 @@ func (r GitRepository) GetLastCommitHash() (hash string, err error) {
 func (r GitRepository) GetLastRemoteCommitHash() (hash string, err error) {
 	return r.Client.GetLastRemoteCommitHash(r)
 }
+
+func (r GitRepository) RemoteFileExists(filePath string) (bool, error) {
+	return r.Client.RemoteFileExists(r, filePath)
+}
@@ -0,0 +1,82 @@
+package db_lib
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func createTestGitRepository(t *testing.T) string {
+	dir := t.TempDir()
+
+	if err := os.MkdirAll(path.Join(dir, "playbooks"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path.Join(dir, "playbooks", "site.yml"), []byte("- hosts: all\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"init", "--quiet", "--initial-branch=main"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Skipf("git is not available: %s", out)
+		}
+	}
+
+	return dir
+}
+
+func TestRemoteFileExists(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: t.TempDir(),
+	}
+
+	repo := GitRepository{
+		Repository: db.Repository{
+			GitURL:    "file://" + createTestGitRepository(t),
+			GitBranch: "main",
+			SSHKey:    db.AccessKey{Type: db.AccessKeyNone},
+		},
+	}
+
+	for name, client := range map[string]GitClient{
+		"cmd_git": CreateCmdGitClient(),
+		"go_git":  CreateGoGitClient(),
+	} {
+		repo.Client = client
+
+		exists, err := repo.RemoteFileExists("playbooks/site.yml")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !exists {
+			t.Fatalf("%s: expected the playbook to exist", name)
+		}
+
+		exists, err = repo.RemoteFileExists("playbooks/missing.yml")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if exists {
+			t.Fatalf("%s: expected the playbook to be missing", name)
+		}
+	}
+
+	entries, err := os.ReadDir(util.Config.TmpPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected temporary repositories to be removed, got %d entries", len(entries))
+	}
+}
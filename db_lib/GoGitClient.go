@@ -2,6 +2,7 @@ package db_lib
 
 import (
 	"errors"
+	"path"
 
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/pkg/task_logger"
@@ -9,6 +10,7 @@ import (
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
@@ -272,3 +274,48 @@ func (c GoGitClient) GetLastRemoteCommitHash(r GitRepository) (hash string, err
 
 	return
 }
+
+// RemoteFileExists clones the last commit of the branch to the memory
+// and looks for the file in its tree.
+func (c GoGitClient) RemoteFileExists(r GitRepository, filePath string) (exists bool, err error) {
+	auth, err := getAuthMethod(r)
+	if err != nil {
+		return
+	}
+
+	rep, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
+		URL:           r.Repository.GetGitURL(),
+		ReferenceName: plumbing.NewBranchReferenceName(r.Repository.GitBranch),
+		SingleBranch:  true,
+		Depth:         1,
+		NoCheckout:    true,
+		Auth:          auth,
+	})
+	if err != nil {
+		return
+	}
+
+	headRef, err := rep.Head()
+	if err != nil {
+		return
+	}
+
+	commit, err := rep.CommitObject(headRef.Hash())
+	if err != nil {
+		return
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return
+	}
+
+	_, err = tree.FindEntry(path.Clean(filePath))
+	if errors.Is(err, object.ErrEntryNotFound) || errors.Is(err, object.ErrDirectoryNotFound) {
+		err = nil
+		return
+	}
+
+	exists = err == nil
+	return
+}
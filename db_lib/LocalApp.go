@@ -1,24 +1,20 @@
 package db_lib
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 
+	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/pkg/task_logger"
 	"github.com/semaphoreui/semaphore/util"
 )
 
+// getEnvironmentVars returns the environment of child processes: allowlisted variables
+// of the server environment and variables defined in the config.
 func getEnvironmentVars() []string {
-	res := []string{
-		fmt.Sprintf("PATH=%s", os.Getenv("PATH")),
-	}
-
-	for _, e := range util.Config.ForwardedEnvVars {
-		v := os.Getenv(e)
-		if v != "" {
-			res = append(res, fmt.Sprintf("%s=%s", e, v))
-		}
-	}
+	res := util.AllowedEnvironmentVars()
 
 	for k, v := range util.Config.EnvVars {
 		res = append(res, fmt.Sprintf("%s=%s", k, v))
@@ -35,8 +31,51 @@ type LocalAppRunningArgs struct {
 	Callback        func(*os.Process)
 }
 
+type LocalAppInstallingArgs struct {
+	EnvironmentVars *[]string
+	TaskParams      interface{}
+}
+
+// ErrCheckFailed is returned by LocalAppChecker if the task must not be started.
+var ErrCheckFailed = errors.New("pre-run check failed")
+
+// LocalAppChecker is implemented by apps which can check the code before the run,
+// for example validate syntax or run linter.
+type LocalAppChecker interface {
+	Check(args LocalAppRunningArgs) ([]db.TaskFinding, error)
+}
+
+// LocalAppTaskCounter is implemented by apps which can count tasks of the code before the run,
+// so the progress of the run can be computed. Zero means the progress is not tracked.
+type LocalAppTaskCounter interface {
+	CountTasks(args LocalAppRunningArgs) (int, error)
+}
+
 type LocalApp interface {
 	SetLogger(logger task_logger.Logger) task_logger.Logger
-	InstallRequirements(environmentVars *[]string) error
+	InstallRequirements(args LocalAppInstallingArgs) error
 	Run(args LocalAppRunningArgs) error
 }
+
+// LocalAppCmdPreviewer is implemented by apps which can make the command of the run
+// without running it, e.g. to show how variables of the task are resolved.
+type LocalAppCmdPreviewer interface {
+	PreviewCmd(args LocalAppRunningArgs) (*exec.Cmd, error)
+}
+
+// CmdWrapper replaces the command of the app before it is started,
+// e.g. with the command which runs it on the other host.
+type CmdWrapper func(cmd *exec.Cmd) *exec.Cmd
+
+// LocalAppCmdWrapper is implemented by apps whose commands can be run by the wrapper.
+// Commands made after SetCmdWrapper are passed to the wrapper.
+type LocalAppCmdWrapper interface {
+	SetCmdWrapper(wrapper CmdWrapper)
+}
+
+func wrapCmd(wrapper CmdWrapper, cmd *exec.Cmd) *exec.Cmd {
+	if wrapper == nil {
+		return cmd
+	}
+	return wrapper(cmd)
+}
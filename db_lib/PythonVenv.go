@@ -0,0 +1,108 @@
+package db_lib
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// pythonVenvMarker is created in the virtualenv after ansible-core is installed.
+const pythonVenvMarker = ".semaphore_installed"
+
+const defaultPythonInterpreter = "python3"
+
+// pythonVenvLocks prevents concurrent creation of the same virtualenv by
+// tasks running in parallel.
+var pythonVenvLocks sync.Map
+
+func getPythonVenvsPath() string {
+	return path.Join(util.Config.TmpPath, "python_venvs")
+}
+
+// getPythonVenvPath returns directory of the virtualenv created by the interpreter
+// with the pinned ansible-core version.
+func getPythonVenvPath(interpreter string, ansibleCoreVersion string) string {
+	hash := sha256.Sum256([]byte(interpreter + "\n" + ansibleCoreVersion))
+	return path.Join(getPythonVenvsPath(), fmt.Sprintf("ansible-core-%s_%x", ansibleCoreVersion, hash[:8]))
+}
+
+func isPythonVenvReady(venvPath string) bool {
+	_, err := os.Stat(path.Join(venvPath, pythonVenvMarker))
+	return err == nil
+}
+
+// installPythonVenv creates the virtualenv with the pinned ansible-core version
+// if it does not exist yet and returns its bin directory.
+func (t *AnsibleApp) installPythonVenv(interpreter string, ansibleCoreVersion string) (string, error) {
+	venvPath := getPythonVenvPath(interpreter, ansibleCoreVersion)
+	binPath := path.Join(venvPath, "bin")
+
+	lock, _ := pythonVenvLocks.LoadOrStore(venvPath, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	if isPythonVenvReady(venvPath) {
+		t.Log("Using Python virtualenv " + venvPath + "\n")
+		return binPath, nil
+	}
+
+	// virtualenv is not relocatable, so it is created in place.
+	// Remove leftovers of the failed installation.
+	if err := os.RemoveAll(venvPath); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(getPythonVenvsPath(), 0755); err != nil {
+		return "", err
+	}
+
+	t.Log("Creating Python virtualenv " + venvPath + "\n")
+
+	if err := t.Playbook.runCmd(interpreter, []string{"-m", "venv", venvPath}, nil); err != nil {
+		return "", err
+	}
+
+	if err := t.Playbook.runCmd(path.Join(binPath, "pip"), []string{
+		"install",
+		"--disable-pip-version-check",
+		"ansible-core==" + ansibleCoreVersion,
+	}, nil); err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(path.Join(venvPath, pythonVenvMarker), []byte(ansibleCoreVersion), 0644); err != nil {
+		return "", err
+	}
+
+	return binPath, nil
+}
+
+// installPython selects directory with Ansible executables according to the template params.
+// Host Ansible is used if neither interpreter nor ansible-core version is specified.
+func (t *AnsibleApp) installPython() error {
+	params, err := t.getTemplateParams()
+	if err != nil {
+		return err
+	}
+
+	if params.AnsibleCoreVersion != "" {
+		interpreter := params.PythonInterpreter
+		if interpreter == "" {
+			interpreter = defaultPythonInterpreter
+		}
+
+		t.Playbook.BinPath, err = t.installPythonVenv(interpreter, params.AnsibleCoreVersion)
+		return err
+	}
+
+	if params.PythonInterpreter != "" {
+		// Ansible must be installed for the interpreter, e.g. in the same virtualenv
+		t.Playbook.BinPath = path.Dir(params.PythonInterpreter)
+	}
+
+	return nil
+}
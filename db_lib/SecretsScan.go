@@ -0,0 +1,178 @@
+package db_lib
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// ErrSecretsFound is returned if the secrets scanner found raw credentials
+// and the template requires to fail the task.
+var ErrSecretsFound = errors.New("raw credentials found in the repository")
+
+// secretsScanMaxFileSize is the size of the biggest file scanned when the whole tree is scanned.
+const secretsScanMaxFileSize = 1 << 20
+
+// secretsScanMaxFindings limits the number of reported findings.
+const secretsScanMaxFindings = 100
+
+// secretRule is a pattern of raw credentials searched by the secrets scanner.
+type secretRule struct {
+	ID          string
+	Description string
+	Pattern     *regexp.Regexp
+}
+
+var secretRules = []secretRule{
+	{"aws_access_key", "AWS access key ID", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"private_key", "private key", regexp.MustCompile(`-----BEGIN ((RSA|DSA|EC|OPENSSH|PGP|ENCRYPTED) )?PRIVATE KEY( BLOCK)?-----`)},
+	{"github_token", "GitHub token", regexp.MustCompile(`\b(gh[pousr]_[0-9A-Za-z]{36}|github_pat_[0-9A-Za-z_]{82})\b`)},
+	{"gitlab_token", "GitLab personal access token", regexp.MustCompile(`\bglpat-[0-9A-Za-z_\-]{20}\b`)},
+	{"slack_token", "Slack token", regexp.MustCompile(`\bxox[abprs]-[0-9A-Za-z\-]{10,}`)},
+	{"slack_webhook", "Slack webhook URL", regexp.MustCompile(`https://hooks\.slack\.com/services/T[0-9A-Z]+/B[0-9A-Z]+/[0-9A-Za-z]+`)},
+	{"google_api_key", "Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{"stripe_key", "Stripe live key", regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{24,}\b`)},
+	{"password_in_url", "password in URL", regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.\-]*://[^/\s:@'"]+:[^/\s:@'"${}]+@`)},
+}
+
+// scanLine returns findings of all rules matching the line.
+// The message never contains the matched value to keep it out of the task record.
+func scanLine(file string, line int, text string, severity db.TaskFindingSeverity) (findings []db.TaskFinding) {
+	for _, rule := range secretRules {
+		if !rule.Pattern.MatchString(text) {
+			continue
+		}
+
+		findings = append(findings, db.TaskFinding{
+			Tool:     db.TaskFindingSecretsScan,
+			Severity: severity,
+			Rule:     rule.ID,
+			File:     file,
+			Line:     line,
+			Message:  "possible " + rule.Description,
+		})
+	}
+	return
+}
+
+// secretsScanner collects findings until the limit is reached.
+type secretsScanner struct {
+	severity db.TaskFindingSeverity
+	findings []db.TaskFinding
+}
+
+func (s *secretsScanner) full() bool {
+	return len(s.findings) >= secretsScanMaxFindings
+}
+
+func (s *secretsScanner) scanLines(file string, firstLine int, lines []string) {
+	for i, text := range lines {
+		if s.full() {
+			return
+		}
+		s.findings = append(s.findings, scanLine(file, firstLine+i, text, s.severity)...)
+	}
+}
+
+// scanPatch scans lines added by the patch.
+func (s *secretsScanner) scanPatch(patch *object.Patch) {
+	for _, filePatch := range patch.FilePatches() {
+		_, to := filePatch.Files()
+		if to == nil || filePatch.IsBinary() {
+			continue
+		}
+
+		line := 1
+		for _, chunk := range filePatch.Chunks() {
+			content := strings.TrimSuffix(chunk.Content(), "\n")
+			if content == "" {
+				continue
+			}
+			lines := strings.Split(content, "\n")
+
+			switch chunk.Type() {
+			case diff.Equal:
+				line += len(lines)
+			case diff.Add:
+				s.scanLines(to.Path(), line, lines)
+				line += len(lines)
+			}
+		}
+	}
+}
+
+// scanTree scans all text files of the tree.
+func (s *secretsScanner) scanTree(tree *object.Tree) error {
+	return tree.Files().ForEach(func(f *object.File) error {
+		if s.full() || f.Size > secretsScanMaxFileSize {
+			return nil
+		}
+
+		if binary, err := f.IsBinary(); err != nil || binary {
+			return err
+		}
+
+		lines, err := f.Lines()
+		if err != nil {
+			return err
+		}
+
+		s.scanLines(f.Name, 1, lines)
+		return nil
+	})
+}
+
+// ScanSecrets searches raw credentials in the lines added to the repository checked out at repoPath
+// since the commit baseHash. The whole tree of HEAD is scanned if baseHash is empty
+// or the commit is not found in the repository.
+func ScanSecrets(repoPath string, baseHash string, severity db.TaskFindingSeverity) ([]db.TaskFinding, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := secretsScanner{severity: severity}
+
+	var baseCommit *object.Commit
+	if baseHash != "" {
+		baseCommit, _ = repo.CommitObject(plumbing.NewHash(baseHash))
+	}
+
+	if baseCommit == nil {
+		tree, err := headCommit.Tree()
+		if err != nil {
+			return nil, err
+		}
+
+		err = scanner.scanTree(tree)
+		return scanner.findings, err
+	}
+
+	if baseCommit.Hash == headCommit.Hash {
+		return nil, nil
+	}
+
+	patch, err := baseCommit.Patch(headCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner.scanPatch(patch)
+	return scanner.findings, nil
+}
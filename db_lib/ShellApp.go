@@ -3,6 +3,7 @@ package db_lib
 import (
 	"fmt"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -17,6 +18,7 @@ type ShellApp struct {
 	Repository db.Repository
 	App        db.TemplateApp
 	reader     bashReader
+	cmdWrapper CmdWrapper
 }
 
 type bashReader struct {
@@ -42,7 +44,7 @@ func (r *bashReader) Read(p []byte) (n int, err error) {
 
 func (t *ShellApp) makeCmd(command string, args []string, environmentVars *[]string) *exec.Cmd {
 	cmd := exec.Command(command, args...) //nolint: gas
-	cmd.Dir = t.GetFullPath()
+	cmd.Dir = t.GetWorkingDirectory()
 
 	cmd.Env = getEnvironmentVars()
 	cmd.Env = append(cmd.Env, fmt.Sprintf("HOME=%s", util.Config.TmpPath))
@@ -52,7 +54,7 @@ func (t *ShellApp) makeCmd(command string, args []string, environmentVars *[]str
 		cmd.Env = append(cmd.Env, *environmentVars...)
 	}
 
-	return cmd
+	return wrapCmd(t.cmdWrapper, cmd)
 }
 
 func (t *ShellApp) runCmd(command string, args []string) error {
@@ -66,6 +68,20 @@ func (t *ShellApp) GetFullPath() (path string) {
 	return
 }
 
+// getParams returns the shell params of the template, other apps have no shell params.
+func (t *ShellApp) getParams() (params db.ShellTemplateParams) {
+	if t.App.IsShell() {
+		_ = t.Template.GetParams(&params)
+	}
+	return
+}
+
+// GetWorkingDirectory returns the directory in which the script runs,
+// the repository or its subdirectory set by the template.
+func (t *ShellApp) GetWorkingDirectory() string {
+	return filepath.Join(t.GetFullPath(), t.getParams().WorkingDirectory)
+}
+
 func (t *ShellApp) SetLogger(logger task_logger.Logger) task_logger.Logger {
 	t.Logger = logger
 	t.Logger.AddStatusListener(func(status task_logger.TaskStatus) {
@@ -75,11 +91,24 @@ func (t *ShellApp) SetLogger(logger task_logger.Logger) task_logger.Logger {
 	return logger
 }
 
-func (t *ShellApp) InstallRequirements(environmentVars *[]string) error {
+func (t *ShellApp) SetCmdWrapper(wrapper CmdWrapper) {
+	t.cmdWrapper = wrapper
+}
+
+func (t *ShellApp) InstallRequirements(args LocalAppInstallingArgs) error {
 	return nil
 }
 
 func (t *ShellApp) makeShellCmd(args []string, environmentVars *[]string) *exec.Cmd {
+	if shell := t.getParams().Shell; shell != db.ShellDefault {
+		var appArgs []string
+		if shell == db.ShellPwsh {
+			appArgs = []string{"-File"}
+		}
+
+		return t.makeCmd(string(shell), append(appArgs, args...), environmentVars)
+	}
+
 	var command string
 	var appArgs []string
 	switch t.App {
@@ -106,6 +135,10 @@ func (t *ShellApp) makeShellCmd(args []string, environmentVars *[]string) *exec.
 	return t.makeCmd(command, append(appArgs, args...), environmentVars)
 }
 
+func (t *ShellApp) PreviewCmd(args LocalAppRunningArgs) (*exec.Cmd, error) {
+	return t.makeShellCmd(args.CliArgs, args.EnvironmentVars), nil
+}
+
 func (t *ShellApp) Run(args LocalAppRunningArgs) error {
 	cmd := t.makeShellCmd(args.CliArgs, args.EnvironmentVars)
 	t.Logger.LogCmd(cmd)
@@ -0,0 +1,38 @@
+package db_lib
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestShellAppMakeShellCmd(t *testing.T) {
+	util.Config = &util.ConfigType{TmpPath: t.TempDir()}
+
+	app := ShellApp{
+		App:        db.AppBash,
+		Repository: db.Repository{ID: 1, GitURL: "https://example.com/repo.git"},
+		Template:   db.Template{ID: 2},
+	}
+
+	cmd := app.makeShellCmd([]string{"deploy.sh"}, nil)
+
+	if filepath.Base(cmd.Path) != "bash" || cmd.Dir != app.GetFullPath() {
+		t.Fatal("script must be run by bash in the repository", cmd.Path, cmd.Dir)
+	}
+
+	app.Template.TaskParams = db.MapStringAnyField{"shell": "pwsh", "working_directory": "scripts"}
+
+	cmd = app.makeShellCmd([]string{"deploy.ps1"}, nil)
+
+	if !slices.Equal(cmd.Args, []string{"pwsh", "-File", "deploy.ps1"}) {
+		t.Fatal("script must be run by the shell of the template", cmd.Args)
+	}
+
+	if cmd.Dir != filepath.Join(app.GetFullPath(), "scripts") {
+		t.Fatal("script must be run in the working directory", cmd.Dir)
+	}
+}
@@ -21,6 +21,11 @@ type TerraformApp struct {
 	reader     terraformReader
 	Name       string
 	noChanges  bool
+	// stateLocked is set if the command failed because the state
+	// of the workspace is locked by another operation.
+	stateLocked bool
+	workspace   string
+	cmdWrapper  CmdWrapper
 }
 
 type terraformReaderResult int
@@ -46,7 +51,7 @@ func (t *TerraformApp) makeCmd(command string, args []string, environmentVars *[
 		cmd.Env = append(cmd.Env, *environmentVars...)
 	}
 
-	return cmd
+	return wrapCmd(t.cmdWrapper, cmd)
 }
 
 func (t *TerraformApp) runCmd(command string, args []string) error {
@@ -66,6 +71,9 @@ func (t *TerraformApp) SetLogger(logger task_logger.Logger) task_logger.Logger {
 		if strings.Contains(msg, "No changes.") {
 			t.noChanges = true
 		}
+		if strings.Contains(msg, "Error acquiring the state lock") {
+			t.stateLocked = true
+		}
 	})
 
 	t.Logger.AddStatusListener(func(status task_logger.TaskStatus) {
@@ -116,29 +124,70 @@ func (t *TerraformApp) selectWorkspace(workspace string, environmentVars *[]stri
 	return cmd.Wait()
 }
 
-func (t *TerraformApp) InstallRequirements(environmentVars *[]string) (err error) {
+func (t *TerraformApp) InstallRequirements(args LocalAppInstallingArgs) (err error) {
+	environmentVars := args.EnvironmentVars
+
 	err = t.init(environmentVars)
 	if err != nil {
 		return
 	}
 
-	workspace := "default"
-
-	if t.Inventory.Inventory != "" {
-		workspace = t.Inventory.Inventory
+	var params db.TerraformTaskParams
+	if p, ok := args.TaskParams.(*db.TerraformTaskParams); ok {
+		params = *p
 	}
 
-	if workspace == "default" && !t.isWorkspacesSupported(environmentVars) {
+	workspace := db.GetTerraformWorkspace(params, &t.Inventory)
+	t.workspace = workspace
+
+	if workspace == db.DefaultTerraformWorkspace && !t.isWorkspacesSupported(environmentVars) {
 		return
 	}
 
+	t.Logger.Log("Selecting workspace " + workspace)
+
 	err = t.selectWorkspace(workspace, environmentVars)
 	return
 }
 
+// getLockArgs returns arguments which make plan and apply wait for the state lock.
+func (t *TerraformApp) getLockArgs() []string {
+	var params db.TerraformTemplateParams
+	if err := t.Template.GetParams(&params); err != nil || params.LockTimeout == "" {
+		return nil
+	}
+
+	return []string{"-lock-timeout=" + params.LockTimeout}
+}
+
+// checkStateLock explains the error of the command if the state was locked.
+func (t *TerraformApp) checkStateLock(err error) error {
+	if err == nil || !t.stateLocked {
+		return err
+	}
+
+	workspace := t.workspace
+	if workspace == "" {
+		workspace = db.DefaultTerraformWorkspace
+	}
+
+	t.Logger.Log("The state of workspace " + workspace + " is locked by another operation. " +
+		"Wait for it to finish, or set the lock timeout of the template to wait for the lock.")
+
+	return fmt.Errorf("state of workspace %s is locked: %w", workspace, err)
+}
+
+func (t *TerraformApp) makePlanCmd(args []string, environmentVars *[]string) *exec.Cmd {
+	return t.makeCmd(t.Name, append(append([]string{"plan"}, t.getLockArgs()...), args...), environmentVars)
+}
+
+// PreviewCmd returns the plan command, apply is run with the same arguments.
+func (t *TerraformApp) PreviewCmd(args LocalAppRunningArgs) (*exec.Cmd, error) {
+	return t.makePlanCmd(args.CliArgs, args.EnvironmentVars), nil
+}
+
 func (t *TerraformApp) Plan(args []string, environmentVars *[]string, inputs map[string]string, cb func(*os.Process)) error {
-	args = append([]string{"plan"}, args...)
-	cmd := t.makeCmd(t.Name, args, environmentVars)
+	cmd := t.makePlanCmd(args, environmentVars)
 	t.Logger.LogCmd(cmd)
 	cmd.Stdin = strings.NewReader("")
 	err := cmd.Start()
@@ -146,11 +195,11 @@ func (t *TerraformApp) Plan(args []string, environmentVars *[]string, inputs map
 		return err
 	}
 	cb(cmd.Process)
-	return cmd.Wait()
+	return t.checkStateLock(cmd.Wait())
 }
 
 func (t *TerraformApp) Apply(args []string, environmentVars *[]string, inputs map[string]string, cb func(*os.Process)) error {
-	args = append([]string{"apply", "-auto-approve"}, args...)
+	args = append(append([]string{"apply", "-auto-approve"}, t.getLockArgs()...), args...)
 	cmd := t.makeCmd(t.Name, args, environmentVars)
 	t.Logger.LogCmd(cmd)
 	cmd.Stdin = strings.NewReader("")
@@ -159,7 +208,7 @@ func (t *TerraformApp) Apply(args []string, environmentVars *[]string, inputs ma
 		return err
 	}
 	cb(cmd.Process)
-	return cmd.Wait()
+	return t.checkStateLock(cmd.Wait())
 }
 
 func (t *TerraformApp) Run(args LocalAppRunningArgs) error {
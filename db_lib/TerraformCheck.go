@@ -0,0 +1,102 @@
+package db_lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"strings"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// terraformValidateResult is output of terraform validate -json.
+type terraformValidateResult struct {
+	Valid       bool `json:"valid"`
+	Diagnostics []struct {
+		Severity string `json:"severity"`
+		Summary  string `json:"summary"`
+		Detail   string `json:"detail"`
+		Range    *struct {
+			Filename string `json:"filename"`
+			Start    struct {
+				Line int `json:"line"`
+			} `json:"start"`
+		} `json:"range"`
+	} `json:"diagnostics"`
+}
+
+func (res terraformValidateResult) findings() (findings []db.TaskFinding) {
+	for _, d := range res.Diagnostics {
+		finding := db.TaskFinding{
+			Tool:     db.TaskFindingTerraformValidate,
+			Severity: db.TaskFindingWarning,
+			Message:  d.Summary,
+		}
+
+		if d.Severity == "error" {
+			finding.Severity = db.TaskFindingError
+		}
+
+		if d.Detail != "" {
+			finding.Message += ": " + d.Detail
+		}
+
+		if d.Range != nil {
+			finding.File = d.Range.Filename
+			finding.Line = d.Range.Start.Line
+		}
+
+		findings = append(findings, finding)
+	}
+	return
+}
+
+func (t *TerraformApp) Check(args LocalAppRunningArgs) ([]db.TaskFinding, error) {
+	var params db.TerraformTemplateParams
+	if err := t.Template.GetParams(&params); err != nil {
+		return nil, err
+	}
+
+	if !params.Validate {
+		return nil, nil
+	}
+
+	t.Logger.Log("Running " + t.Name + " validate")
+
+	cmd := t.makeCmd(t.Name, []string{"validate", "-json", "-no-color"}, args.EnvironmentVars)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+
+	for _, line := range strings.Split(strings.TrimSpace(stderr.String()), "\n") {
+		if line != "" {
+			t.Logger.Log(line)
+		}
+	}
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, err
+	}
+
+	var res terraformValidateResult
+	if jsonErr := json.Unmarshal(stdout.Bytes(), &res); jsonErr != nil {
+		t.Logger.Log(stdout.String())
+		if err != nil {
+			return nil, ErrCheckFailed
+		}
+		return nil, nil
+	}
+
+	findings := res.findings()
+
+	if !res.Valid {
+		return findings, ErrCheckFailed
+	}
+
+	return findings, nil
+}
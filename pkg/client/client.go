@@ -0,0 +1,179 @@
+// Package client is a Go client for the Semaphore API.
+//
+//	c := client.New("https://semaphore.example.com", "api-token")
+//	templates, err := c.Project(1).Templates().List(ctx, nil)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// Client calls Semaphore API with an API token.
+type Client struct {
+	// BaseURL is the URL of Semaphore server without /api suffix.
+	BaseURL string
+	// Token is an API token created in user settings.
+	Token string
+	// HTTPClient is used for requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+func New(baseURL string, token string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Token:   token,
+	}
+}
+
+// APIError is returned when server responds with non-2xx status.
+type APIError struct {
+	StatusCode int
+	Message    string
+	// Fields are errors of the invalid fields of the request by their JSON names.
+	Fields map[string]string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("semaphore api: status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("semaphore api: status %d: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound returns true if err is an API error with status 404.
+func IsNotFound(err error) bool {
+	apiErr, ok := err.(*APIError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// ListOptions are sorting and pagination parameters of list requests.
+type ListOptions struct {
+	Sort   string
+	Desc   bool
+	Offset int
+	Count  int
+}
+
+func (o *ListOptions) query() url.Values {
+	q := url.Values{}
+	if o == nil {
+		return q
+	}
+	if o.Sort != "" {
+		q.Set("sort", o.Sort)
+	}
+	if o.Desc {
+		q.Set("order", "desc")
+	}
+	if o.Count > 0 {
+		q.Set("count", strconv.Itoa(o.Count))
+		if o.Offset > 0 {
+			q.Set("offset", strconv.Itoa(o.Offset))
+		}
+	}
+	return q
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (c *Client) request(ctx context.Context, method string, path string, query url.Values, in any) (*http.Response, error) {
+	u := c.BaseURL + "/api" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close() //nolint:errcheck
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		var msg struct {
+			Error  string            `json:"error"`
+			Fields map[string]string `json:"fields"`
+		}
+		data, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(data, &msg) == nil {
+			apiErr.Message = msg.Error
+			apiErr.Fields = msg.Fields
+		}
+		return nil, apiErr
+	}
+
+	return resp, nil
+}
+
+// Do sends request with JSON body in and decodes JSON response to out.
+// Both in and out can be nil.
+func (c *Client) Do(ctx context.Context, method string, path string, query url.Values, in any, out any) error {
+	resp, err := c.request(ctx, method, path, query, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Ping checks that server is available.
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.request(ctx, "GET", "/ping", nil, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// User returns the owner of the token.
+func (c *Client) User(ctx context.Context) (user db.User, err error) {
+	err = c.Do(ctx, "GET", "/user", nil, nil, &user)
+	return
+}
+
+// Users manages users, requires admin permissions.
+func (c *Client) Users() Resource[db.User] {
+	return Resource[db.User]{c: c, path: "/users"}
+}
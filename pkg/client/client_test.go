@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceListAll(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, "/api/project/1/templates", r.URL.Path)
+
+		count, _ := strconv.Atoi(r.URL.Query().Get("count"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+		var page []db.Template
+		for i := offset; i < offset+count && i < 5; i++ {
+			page = append(page, db.Template{ID: i + 1})
+		}
+
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "token")
+
+	templates, err := c.Project(1).Templates().ListAll(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Len(t, templates, 5)
+	assert.Equal(t, 5, templates[4].ID)
+}
+
+func TestAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/project/1/lookup/repositories", r.URL.Path)
+		assert.Equal(t, "Missing", r.URL.Query().Get("name"))
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error": "Not found"}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "token")
+
+	_, err := c.Project(1).Repositories().Lookup(context.Background(), "Missing")
+	assert.True(t, IsNotFound(err))
+	assert.Equal(t, "semaphore api: status 404: Not found", err.Error())
+}
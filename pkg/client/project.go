@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (c *Client) ListProjects(ctx context.Context) (res []db.Project, err error) {
+	err = c.Do(ctx, "GET", "/projects", nil, nil, &res)
+	return
+}
+
+func (c *Client) GetProject(ctx context.Context, projectID int) (res db.Project, err error) {
+	err = c.Do(ctx, "GET", "/project/"+strconv.Itoa(projectID), nil, nil, &res)
+	return
+}
+
+// LookupProject returns the project with the given name.
+func (c *Client) LookupProject(ctx context.Context, name string) (res db.Project, err error) {
+	err = c.Do(ctx, "GET", "/projects/lookup", url.Values{"name": {name}}, nil, &res)
+	return
+}
+
+func (c *Client) CreateProject(ctx context.Context, project db.Project) (res db.Project, err error) {
+	err = c.Do(ctx, "POST", "/projects", nil, project, &res)
+	return
+}
+
+func (c *Client) UpdateProject(ctx context.Context, project db.Project) error {
+	return c.Do(ctx, "PUT", "/project/"+strconv.Itoa(project.ID), nil, project, nil)
+}
+
+func (c *Client) DeleteProject(ctx context.Context, projectID int) error {
+	return c.Do(ctx, "DELETE", "/project/"+strconv.Itoa(projectID), nil, nil, nil)
+}
+
+// ProjectClient gives access to resources of the project.
+type ProjectClient struct {
+	c    *Client
+	ID   int
+	path string
+}
+
+func (c *Client) Project(projectID int) *ProjectClient {
+	return &ProjectClient{
+		c:    c,
+		ID:   projectID,
+		path: "/project/" + strconv.Itoa(projectID),
+	}
+}
+
+func projectResource[T any](p *ProjectClient, kind string) Resource[T] {
+	return Resource[T]{c: p.c, path: p.path + "/" + kind, kind: kind, project: p.path}
+}
+
+func (p *ProjectClient) Templates() Resource[db.Template] {
+	return projectResource[db.Template](p, "templates")
+}
+
+func (p *ProjectClient) Repositories() Resource[db.Repository] {
+	return projectResource[db.Repository](p, "repositories")
+}
+
+func (p *ProjectClient) Inventories() Resource[db.Inventory] {
+	return projectResource[db.Inventory](p, "inventory")
+}
+
+func (p *ProjectClient) Environments() Resource[db.Environment] {
+	return projectResource[db.Environment](p, "environment")
+}
+
+func (p *ProjectClient) Keys() Resource[db.AccessKey] {
+	return projectResource[db.AccessKey](p, "keys")
+}
+
+func (p *ProjectClient) Views() Resource[db.View] {
+	return projectResource[db.View](p, "views")
+}
+
+// Schedules does not support Lookup and Upsert.
+func (p *ProjectClient) Schedules() Resource[db.Schedule] {
+	return projectResource[db.Schedule](p, "schedules")
+}
+
+// Integrations does not support Lookup and Upsert.
+func (p *ProjectClient) Integrations() Resource[db.Integration] {
+	return projectResource[db.Integration](p, "integrations")
+}
+
+// Backup returns project backup.
+func (p *ProjectClient) Backup(ctx context.Context) (res map[string]any, err error) {
+	err = p.c.Do(ctx, "GET", p.path+"/backup", nil, nil, &res)
+	return
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// DefaultPageSize is used by ListAll if page size is not specified.
+const DefaultPageSize = 100
+
+// Resource is a collection of objects with REST endpoints
+// {path} and {path}/{id}.
+type Resource[T any] struct {
+	c    *Client
+	path string
+	// kind is a segment used by lookup endpoints, empty if lookup is not supported.
+	kind    string
+	project string
+}
+
+func (r Resource[T]) itemPath(id int) string {
+	return r.path + "/" + strconv.Itoa(id)
+}
+
+// List returns one page of objects. If opts is nil, server defaults are used.
+func (r Resource[T]) List(ctx context.Context, opts *ListOptions) (res []T, err error) {
+	err = r.c.Do(ctx, "GET", r.path, opts.query(), nil, &res)
+	return
+}
+
+// ListAll requests pages of pageSize objects until all objects are read.
+func (r Resource[T]) ListAll(ctx context.Context, pageSize int) ([]T, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	var res []T
+	opts := &ListOptions{Count: pageSize}
+
+	for {
+		page, err := r.List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, page...)
+
+		if len(page) < pageSize {
+			return res, nil
+		}
+
+		opts.Offset += pageSize
+	}
+}
+
+func (r Resource[T]) Get(ctx context.Context, id int) (obj T, err error) {
+	err = r.c.Do(ctx, "GET", r.itemPath(id), nil, nil, &obj)
+	return
+}
+
+func (r Resource[T]) Create(ctx context.Context, obj T) (created T, err error) {
+	err = r.c.Do(ctx, "POST", r.path, nil, obj, &created)
+	return
+}
+
+func (r Resource[T]) Update(ctx context.Context, id int, obj T) error {
+	return r.c.Do(ctx, "PUT", r.itemPath(id), nil, obj, nil)
+}
+
+func (r Resource[T]) Delete(ctx context.Context, id int) error {
+	return r.c.Do(ctx, "DELETE", r.itemPath(id), nil, nil, nil)
+}
+
+// Refs returns the objects which use the object. The object can not be deleted
+// while it is used.
+func (r Resource[T]) Refs(ctx context.Context, id int) (refs db.ObjectReferrers, err error) {
+	err = r.c.Do(ctx, "GET", r.itemPath(id)+"/refs", nil, nil, &refs)
+	return
+}
+
+// Lookup returns object by its name. Use IsNotFound to check
+// whether the object exists.
+func (r Resource[T]) Lookup(ctx context.Context, name string) (obj T, err error) {
+	err = r.c.Do(ctx, "GET", r.project+"/lookup/"+r.kind, url.Values{"name": {name}}, nil, &obj)
+	return
+}
+
+// Upsert creates object or updates the existing object with the same name.
+func (r Resource[T]) Upsert(ctx context.Context, obj T) (res T, err error) {
+	err = r.c.Do(ctx, "PUT", r.project+"/lookup/"+r.kind, nil, obj, &res)
+	return
+}
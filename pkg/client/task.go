@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+// TaskClient runs and inspects tasks of the project.
+type TaskClient struct {
+	p *ProjectClient
+}
+
+func (p *ProjectClient) Tasks() *TaskClient {
+	return &TaskClient{p: p}
+}
+
+func (t *TaskClient) taskPath(taskID int) string {
+	return t.p.path + "/tasks/" + strconv.Itoa(taskID)
+}
+
+// Run starts a new task. Only TemplateID is required, other fields override the template.
+func (t *TaskClient) Run(ctx context.Context, task db.Task) (res db.Task, err error) {
+	err = t.p.c.Do(ctx, "POST", t.p.path+"/tasks", nil, task, &res)
+	return
+}
+
+func (t *TaskClient) Get(ctx context.Context, taskID int) (res db.Task, err error) {
+	err = t.p.c.Do(ctx, "GET", t.taskPath(taskID), nil, nil, &res)
+	return
+}
+
+// Last returns the most recent tasks of the project, at most 200.
+func (t *TaskClient) Last(ctx context.Context, limit int) (res []db.TaskWithTpl, err error) {
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	err = t.p.c.Do(ctx, "GET", t.p.path+"/tasks/last", q, nil, &res)
+	return
+}
+
+func (t *TaskClient) Output(ctx context.Context, taskID int) (res []db.TaskOutput, err error) {
+	err = t.p.c.Do(ctx, "GET", t.taskPath(taskID)+"/output", nil, nil, &res)
+	return
+}
+
+// OutputFrom returns the output of the task after offset records,
+// for example after the output offset of the last checkpoint of the task.
+func (t *TaskClient) OutputFrom(ctx context.Context, taskID int, offset int) (res []db.TaskOutput, err error) {
+	q := url.Values{"offset": {strconv.Itoa(offset)}}
+	err = t.p.c.Do(ctx, "GET", t.taskPath(taskID)+"/output", q, nil, &res)
+	return
+}
+
+func (t *TaskClient) Stop(ctx context.Context, taskID int, force bool) error {
+	return t.p.c.Do(ctx, "POST", t.taskPath(taskID)+"/stop", nil, map[string]bool{"force": force}, nil)
+}
+
+// Confirm continues the task which waits for the confirmation.
+func (t *TaskClient) Confirm(ctx context.Context, taskID int) error {
+	return t.p.c.Do(ctx, "POST", t.taskPath(taskID)+"/confirm", nil, nil, nil)
+}
+
+func (t *TaskClient) Delete(ctx context.Context, taskID int) error {
+	return t.p.c.Do(ctx, "DELETE", t.taskPath(taskID), nil, nil, nil)
+}
+
+// Wait polls the task until it is finished and returns its final state.
+func (t *TaskClient) Wait(ctx context.Context, taskID int, interval time.Duration) (db.Task, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		task, err := t.Get(ctx, taskID)
+		if err != nil {
+			return task, err
+		}
+
+		if task.Status.IsFinished() {
+			return task, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return task, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// IsSuccess returns true if the task is finished successfully.
+func IsSuccess(task db.Task) bool {
+	return task.Status == task_logger.TaskSuccessStatus
+}
@@ -0,0 +1,177 @@
+// Package gitcredential serves credentials of HTTP repositories to git commands
+// run by Semaphore. The credentials are kept in the memory of the Semaphore process
+// and are passed to git through the socket by the credential helper command,
+// so they are never written to the repository or to the git config.
+package gitcredential
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// SocketEnv is the environment variable which contains the socket of the helper.
+const SocketEnv = "SEMAPHORE_GIT_CREDENTIAL_SOCKET"
+
+// Command is the subcommand of the Semaphore binary which is the credential helper of git.
+const Command = "git-credential"
+
+type Helper struct {
+	// Host is the host of the repository. Credentials are not served for other hosts,
+	// for example for submodules hosted elsewhere.
+	Host     string
+	Username string
+	Password string
+
+	SocketFile string
+	listener   net.Listener
+}
+
+var (
+	activeSocketsMu sync.Mutex
+	// activeSockets contains socket files of the helpers of this process.
+	activeSockets = make(map[string]struct{})
+)
+
+func setSocketActive(socketFile string, active bool) {
+	activeSocketsMu.Lock()
+	defer activeSocketsMu.Unlock()
+
+	if active {
+		activeSockets[socketFile] = struct{}{}
+	} else {
+		delete(activeSockets, socketFile)
+	}
+}
+
+// IsActiveSocket returns true if the socket file is served by the helper of this process.
+// Other socket files are left by crashed processes and can be removed.
+func IsActiveSocket(socketFile string) bool {
+	activeSocketsMu.Lock()
+	defer activeSocketsMu.Unlock()
+
+	_, ok := activeSockets[socketFile]
+	return ok
+}
+
+func (h *Helper) Listen() error {
+	// the socket is registered before it is created, so it is never considered as orphaned
+	setSocketActive(h.SocketFile, true)
+
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Net: "unix", Name: h.SocketFile})
+	if err != nil {
+		setSocketActive(h.SocketFile, false)
+		return fmt.Errorf("listening on socket %q: %w", h.SocketFile, err)
+	}
+
+	if err = os.Chmod(h.SocketFile, 0600); err != nil {
+		_ = l.Close()
+		setSocketActive(h.SocketFile, false)
+		return err
+	}
+
+	l.SetUnlinkOnClose(true)
+	h.listener = l
+
+	go func() {
+		for {
+			conn, err := h.listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go h.serve(conn)
+		}
+	}()
+
+	return nil
+}
+
+func (h *Helper) Close() error {
+	defer setSocketActive(h.SocketFile, false)
+	return h.listener.Close()
+}
+
+// serve reads the credential request of git and writes the credentials
+// if the request is for the host of the repository.
+func (h *Helper) serve(conn net.Conn) {
+	defer conn.Close() //nolint:errcheck
+
+	request, err := ReadAttributes(conn)
+	if err != nil {
+		return
+	}
+
+	if request["protocol"] != "http" && request["protocol"] != "https" {
+		return
+	}
+
+	if !strings.EqualFold(request["host"], h.Host) {
+		return
+	}
+
+	_, _ = fmt.Fprintf(conn, "username=%s\npassword=%s\n\n", h.Username, h.Password)
+}
+
+// GitArgs returns the arguments of git which replace credential helpers of the git config
+// by the helper command. The empty helper resets helpers of the global config,
+// so they do not store the credentials.
+func GitArgs(executable string) []string {
+	return []string{
+		"-c", "credential.helper=",
+		"-c", "credential.helper=!'" + strings.ReplaceAll(executable, "'", `'\''`) + "' " + Command,
+	}
+}
+
+// ReadAttributes reads the attributes of the git credential protocol until the empty line.
+func ReadAttributes(r io.Reader) (map[string]string, error) {
+	attrs := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+
+		if key, value, ok := strings.Cut(line, "="); ok {
+			attrs[key] = value
+		}
+	}
+
+	return attrs, scanner.Err()
+}
+
+// Get sends the request of git to the helper of the socket and writes its response.
+// It is run by the credential helper command.
+func Get(socketFile string, request io.Reader, response io.Writer) error {
+	attrs, err := ReadAttributes(request)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.Dial("unix", socketFile)
+	if err != nil {
+		return err
+	}
+	defer conn.Close() //nolint:errcheck
+
+	for _, key := range []string{"protocol", "host", "path"} {
+		if value, ok := attrs[key]; ok {
+			if _, err = fmt.Fprintf(conn, "%s=%s\n", key, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err = fmt.Fprint(conn, "\n"); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(response, conn)
+	return err
+}
@@ -0,0 +1,70 @@
+package gitcredential
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestHelperServesCredentialsOfHost(t *testing.T) {
+	helper := &Helper{
+		Host:       "github.com",
+		Username:   "login",
+		Password:   "password",
+		SocketFile: path.Join(t.TempDir(), "git-credential-test.sock"),
+	}
+
+	if err := helper.Listen(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsActiveSocket(helper.SocketFile) {
+		t.Fatal("socket must be active")
+	}
+
+	var res bytes.Buffer
+	err := Get(helper.SocketFile, strings.NewReader("protocol=https\nhost=GitHub.com\npath=user/project.git\n\n"), &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.String() != "username=login\npassword=password\n\n" {
+		t.Fatal("invalid credentials", res.String())
+	}
+
+	res.Reset()
+	err = Get(helper.SocketFile, strings.NewReader("protocol=https\nhost=example.com\n\n"), &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Len() != 0 {
+		t.Fatal("credentials must not be served for other hosts", res.String())
+	}
+
+	if err = helper.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if IsActiveSocket(helper.SocketFile) {
+		t.Fatal("socket must not be active after close")
+	}
+
+	if _, err = os.Stat(helper.SocketFile); !os.IsNotExist(err) {
+		t.Fatal("socket file must be removed", err)
+	}
+}
+
+func TestGitArgs(t *testing.T) {
+	args := GitArgs("/opt/it's/semaphore")
+
+	if len(args) != 4 || args[1] != "credential.helper=" {
+		t.Fatal("helpers of git config must be reset", args)
+	}
+
+	if args[3] != `credential.helper=!'/opt/it'\''s/semaphore' git-credential` {
+		t.Fatal("invalid helper", args[3])
+	}
+}
@@ -0,0 +1,87 @@
+package i18n
+
+var de = map[string]string{
+	// validation errors
+	"Username cannot be empty":                 "Der Benutzername darf nicht leer sein",
+	"Email cannot be empty":                    "Die E-Mail-Adresse darf nicht leer sein",
+	"Name cannot be empty":                     "Der Name darf nicht leer sein",
+	"Unsupported locale":                       "Nicht unterstützte Sprache",
+	"name can not be empty":                    "Der Name darf nicht leer sein",
+	"title can not be empty":                   "Der Titel darf nicht leer sein",
+	"project name can not be empty":            "Der Projektname darf nicht leer sein",
+	"template name can not be empty":           "Der Vorlagenname darf nicht leer sein",
+	"template playbook can not be empty":       "Das Playbook der Vorlage darf nicht leer sein",
+	"template inventory can not be empty":      "Das Inventar der Vorlage darf nicht leer sein",
+	"template arguments must be valid JSON":    "Die Argumente der Vorlage müssen gültiges JSON sein",
+	"repository name can't be empty":           "Der Repository-Name darf nicht leer sein",
+	"repository url can't be empty":            "Die Repository-URL darf nicht leer sein",
+	"repository branch can't be empty":         "Der Repository-Branch darf nicht leer sein",
+	"Environment name can not be empty":        "Der Name der Umgebung darf nicht leer sein",
+	"Extra variables must be valid JSON":       "Zusätzliche Variablen müssen gültiges JSON sein",
+	"Environment variables must be valid JSON": "Umgebungsvariablen müssen gültiges JSON sein",
+	"pattern can not be empty":                 "Das Muster darf nicht leer sein",
+	"You have no subscription.":                "Sie haben kein Abonnement.",
+	"Not found":                                "Nicht gefunden",
+	"Bad request":                              "Ungültige Anfrage",
+	"Invalid operation":                        "Ungültige Operation",
+	"Invalid request body":                     "Ungültiger Anfragetext",
+
+	// alerts
+	"Task '%s' failed":                       "Aufgabe '%s' ist fehlgeschlagen",
+	"Task %s with template '%s' has failed!": "Aufgabe %s mit der Vorlage '%s' ist fehlgeschlagen!",
+	"Task Log":                               "Aufgabenprotokoll",
+	"Link":                                   "Link",
+	"Schedule '%s' did not fire":             "Zeitplan '%s' wurde nicht ausgelöst",
+	"Project %s: schedule '%s' (%s) was expected to fire at %s":               "Projekt %s: Zeitplan '%s' (%s) sollte um %s ausgelöst werden",
+	"Schedule '%s' disabled":                                                  "Zeitplan '%s' deaktiviert",
+	"Project %s: schedule '%s' was disabled after %d consecutive failed runs": "Projekt %s: Zeitplan '%s' wurde nach %d aufeinanderfolgenden fehlgeschlagenen Ausführungen deaktiviert",
+	"EXCEEDED RUNTIME BUDGET OF %s":                                           "LAUFZEITBUDGET VON %s ÜBERSCHRITTEN",
+
+	// template health
+	"Template '%s' is flaky": "Vorlage '%s' ist instabil",
+	"Project %s: results of template '%s' alternate without configuration changes in %d%% of the last %d runs": "Projekt %s: Ergebnisse der Vorlage '%s' wechseln ohne Konfigurationsänderungen in %d%% der letzten %d Ausführungen",
+
+	// digests
+	"Digest of scheduled runs of project %s":                                        "Zusammenfassung der geplanten Ausführungen des Projekts %s",
+	"Scheduled runs from %s to %s, failed: %d":                                      "Geplante Ausführungen von %s bis %s, fehlgeschlagen: %d",
+	"%s: %d succeeded, %d failed, %d stopped, average duration %s, max duration %s": "%s: %d erfolgreich, %d fehlgeschlagen, %d gestoppt, durchschnittliche Dauer %s, maximale Dauer %s",
+
+	// access reviews
+	"Access review of project %s":                       "Zugriffsüberprüfung des Projekts %s",
+	"Users who have access to the project on %s: %d":    "Benutzer mit Zugriff auf das Projekt am %s: %d",
+	"%s (%s), role: %s, expires: %s, last activity: %s": "%s (%s), Rolle: %s, läuft ab: %s, letzte Aktivität: %s",
+
+	// access changes
+	"Access to project %s changed":                        "Zugriff auf das Projekt %s geändert",
+	"User %s was added to the project with role %s by %s": "Benutzer %s wurde mit der Rolle %s von %s zum Projekt hinzugefügt",
+	"User %s was removed from the project by %s":          "Benutzer %s wurde von %s aus dem Projekt entfernt",
+	"Role of user %s was changed from %s to %s by %s":     "Die Rolle des Benutzers %s wurde von %s zu %s geändert durch %s",
+	"API token created":                                   "API-Token erstellt",
+	"User %s created an API token":                        "Benutzer %s hat ein API-Token erstellt",
+
+	// key rotation
+	"Access keys of project %s must be rotated":                        "Zugriffsschlüssel des Projekts %s müssen rotiert werden",
+	"Secrets of %d access keys were not changed for more than %d days": "Geheimnisse von %d Zugriffsschlüsseln wurden seit mehr als %d Tagen nicht geändert",
+	"%s: last changed %s": "%s: zuletzt geändert %s",
+
+	// template references
+	"template repository can not be empty":                      "Das Repository der Vorlage darf nicht leer sein",
+	"repository not found in the project":                       "Das Repository wurde im Projekt nicht gefunden",
+	"inventory not found in the project":                        "Das Inventar wurde im Projekt nicht gefunden",
+	"workspace inventory can not be used by ansible template":   "Ein Workspace-Inventar kann nicht von einer Ansible-Vorlage verwendet werden",
+	"inventory user key type can not be used to access hosts":   "Der Typ des Benutzerschlüssels des Inventars kann nicht für den Zugriff auf Hosts verwendet werden",
+	"inventory become key type can not be used to become user":  "Der Typ des Become-Schlüssels des Inventars kann nicht zum Benutzerwechsel verwendet werden",
+	"environment not found in the project":                      "Die Umgebung wurde im Projekt nicht gefunden",
+	"build template not found in the project":                   "Die Build-Vorlage wurde im Projekt nicht gefunden",
+	"abort template not found":                                  "Die Abbruchvorlage wurde nicht gefunden",
+	"abort template can not have change window":                 "Die Abbruchvorlage darf kein Änderungsfenster haben",
+	"key not found in the project":                              "Der Schlüssel wurde im Projekt nicht gefunden",
+	"key type can not be used as vault password":                "Der Schlüsseltyp kann nicht als Vault-Passwort verwendet werden",
+	"only string and SSH keys can be installed as secret files": "Nur Zeichenketten- und SSH-Schlüssel können als geheime Dateien installiert werden",
+
+	// objects in use
+	"Access Key is in use by one or more templates":  "Der Zugriffsschlüssel wird von einer oder mehreren Vorlagen verwendet",
+	"Inventory is in use by one or more templates":   "Das Inventar wird von einer oder mehreren Vorlagen verwendet",
+	"Repository is in use by one or more templates":  "Das Repository wird von einer oder mehreren Vorlagen verwendet",
+	"Environment is in use by one or more templates": "Die Umgebung wird von einer oder mehreren Vorlagen verwendet",
+}
@@ -0,0 +1,87 @@
+package i18n
+
+var fr = map[string]string{
+	// validation errors
+	"Username cannot be empty":                 "Le nom d'utilisateur ne peut pas être vide",
+	"Email cannot be empty":                    "L'adresse e-mail ne peut pas être vide",
+	"Name cannot be empty":                     "Le nom ne peut pas être vide",
+	"Unsupported locale":                       "Langue non prise en charge",
+	"name can not be empty":                    "Le nom ne peut pas être vide",
+	"title can not be empty":                   "Le titre ne peut pas être vide",
+	"project name can not be empty":            "Le nom du projet ne peut pas être vide",
+	"template name can not be empty":           "Le nom du modèle ne peut pas être vide",
+	"template playbook can not be empty":       "Le playbook du modèle ne peut pas être vide",
+	"template inventory can not be empty":      "L'inventaire du modèle ne peut pas être vide",
+	"template arguments must be valid JSON":    "Les arguments du modèle doivent être un JSON valide",
+	"repository name can't be empty":           "Le nom du dépôt ne peut pas être vide",
+	"repository url can't be empty":            "L'URL du dépôt ne peut pas être vide",
+	"repository branch can't be empty":         "La branche du dépôt ne peut pas être vide",
+	"Environment name can not be empty":        "Le nom de l'environnement ne peut pas être vide",
+	"Extra variables must be valid JSON":       "Les variables supplémentaires doivent être un JSON valide",
+	"Environment variables must be valid JSON": "Les variables d'environnement doivent être un JSON valide",
+	"pattern can not be empty":                 "Le motif ne peut pas être vide",
+	"You have no subscription.":                "Vous n'avez pas d'abonnement.",
+	"Not found":                                "Introuvable",
+	"Bad request":                              "Requête invalide",
+	"Invalid operation":                        "Opération invalide",
+	"Invalid request body":                     "Corps de requête invalide",
+
+	// alerts
+	"Task '%s' failed":                       "La tâche '%s' a échoué",
+	"Task %s with template '%s' has failed!": "La tâche %s avec le modèle '%s' a échoué !",
+	"Task Log":                               "Journal de la tâche",
+	"Link":                                   "Lien",
+	"Schedule '%s' did not fire":             "La planification '%s' ne s'est pas déclenchée",
+	"Project %s: schedule '%s' (%s) was expected to fire at %s":               "Projet %s : la planification '%s' (%s) devait se déclencher à %s",
+	"Schedule '%s' disabled":                                                  "La planification '%s' est désactivée",
+	"Project %s: schedule '%s' was disabled after %d consecutive failed runs": "Projet %s : la planification '%s' a été désactivée après %d exécutions échouées consécutives",
+	"EXCEEDED RUNTIME BUDGET OF %s":                                           "BUDGET D'EXÉCUTION DE %s DÉPASSÉ",
+
+	// template health
+	"Template '%s' is flaky": "Le modèle '%s' est instable",
+	"Project %s: results of template '%s' alternate without configuration changes in %d%% of the last %d runs": "Projet %s : les résultats du modèle '%s' alternent sans modification de configuration dans %d%% des %d dernières exécutions",
+
+	// digests
+	"Digest of scheduled runs of project %s":                                        "Résumé des exécutions planifiées du projet %s",
+	"Scheduled runs from %s to %s, failed: %d":                                      "Exécutions planifiées de %s à %s, échouées : %d",
+	"%s: %d succeeded, %d failed, %d stopped, average duration %s, max duration %s": "%s : %d réussies, %d échouées, %d arrêtées, durée moyenne %s, durée maximale %s",
+
+	// access reviews
+	"Access review of project %s":                       "Revue des accès du projet %s",
+	"Users who have access to the project on %s: %d":    "Utilisateurs ayant accès au projet le %s : %d",
+	"%s (%s), role: %s, expires: %s, last activity: %s": "%s (%s), rôle : %s, expire : %s, dernière activité : %s",
+
+	// access changes
+	"Access to project %s changed":                        "Accès au projet %s modifié",
+	"User %s was added to the project with role %s by %s": "L'utilisateur %s a été ajouté au projet avec le rôle %s par %s",
+	"User %s was removed from the project by %s":          "L'utilisateur %s a été retiré du projet par %s",
+	"Role of user %s was changed from %s to %s by %s":     "Le rôle de l'utilisateur %s a été changé de %s à %s par %s",
+	"API token created":                                   "Jeton d'API créé",
+	"User %s created an API token":                        "L'utilisateur %s a créé un jeton d'API",
+
+	// key rotation
+	"Access keys of project %s must be rotated":                        "Les clés d'accès du projet %s doivent être renouvelées",
+	"Secrets of %d access keys were not changed for more than %d days": "Les secrets de %d clés d'accès n'ont pas été modifiés depuis plus de %d jours",
+	"%s: last changed %s": "%s : dernière modification %s",
+
+	// template references
+	"template repository can not be empty":                      "Le dépôt du modèle ne peut pas être vide",
+	"repository not found in the project":                       "Dépôt introuvable dans le projet",
+	"inventory not found in the project":                        "Inventaire introuvable dans le projet",
+	"workspace inventory can not be used by ansible template":   "Un inventaire d'espace de travail ne peut pas être utilisé par un modèle Ansible",
+	"inventory user key type can not be used to access hosts":   "Le type de la clé utilisateur de l'inventaire ne peut pas être utilisé pour accéder aux hôtes",
+	"inventory become key type can not be used to become user":  "Le type de la clé become de l'inventaire ne peut pas être utilisé pour changer d'utilisateur",
+	"environment not found in the project":                      "Environnement introuvable dans le projet",
+	"build template not found in the project":                   "Modèle de build introuvable dans le projet",
+	"abort template not found":                                  "Modèle d'abandon introuvable",
+	"abort template can not have change window":                 "Le modèle d'abandon ne peut pas avoir de fenêtre de changement",
+	"key not found in the project":                              "Clé introuvable dans le projet",
+	"key type can not be used as vault password":                "Le type de clé ne peut pas être utilisé comme mot de passe du coffre",
+	"only string and SSH keys can be installed as secret files": "Seules les clés de type chaîne et SSH peuvent être installées comme fichiers secrets",
+
+	// objects in use
+	"Access Key is in use by one or more templates":  "La clé d'accès est utilisée par un ou plusieurs modèles",
+	"Inventory is in use by one or more templates":   "L'inventaire est utilisé par un ou plusieurs modèles",
+	"Repository is in use by one or more templates":  "Le dépôt est utilisé par un ou plusieurs modèles",
+	"Environment is in use by one or more templates": "L'environnement est utilisé par un ou plusieurs modèles",
+}
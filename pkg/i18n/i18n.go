@@ -0,0 +1,115 @@
+// Package i18n translates messages generated by the server, such as validation
+// errors and alerts. Messages are identified by their English text, so the text
+// which has no translation is returned as is.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale is the language of the messages in the source code.
+const DefaultLocale = "en"
+
+var catalogs = map[string]map[string]string{
+	DefaultLocale: {},
+	"de":          de,
+	"fr":          fr,
+	"ru":          ru,
+}
+
+// Locales returns supported locales in alphabetical order.
+func Locales() []string {
+	res := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		res = append(res, locale)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// IsSupported returns true if messages can be translated to the locale.
+// Empty locale is supported and means the default locale.
+func IsSupported(locale string) bool {
+	if locale == "" {
+		return true
+	}
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// Normalize converts the language tag, for example de-AT, to the supported locale.
+// It returns empty string if the language is not supported.
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if _, ok := catalogs[tag]; !ok {
+		return ""
+	}
+	return tag
+}
+
+// FromAcceptLanguage returns the supported locale with the highest weight
+// from the value of the Accept-Language header or empty string.
+func FromAcceptLanguage(header string) (locale string) {
+	weight := 0.0
+
+	for _, item := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(item, ";")
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+
+		if l := Normalize(tag); l != "" && q > weight {
+			locale = l
+			weight = q
+		}
+	}
+
+	return
+}
+
+// T translates the message to the locale. Arguments are formatted
+// by fmt.Sprintf using the translated message as the format.
+func T(locale string, msg string, args ...any) string {
+	res := msg
+	if tr, ok := catalogs[locale][msg]; ok {
+		res = tr
+	}
+
+	if len(args) > 0 {
+		res = fmt.Sprintf(res, args...)
+	}
+
+	return res
+}
+
+// Message is the message which is translated when the locale of the recipient is known.
+type Message struct {
+	Text string
+	Args []any
+}
+
+// Msg creates the message which is translated later.
+func Msg(text string, args ...any) Message {
+	return Message{Text: text, Args: args}
+}
+
+// Translate returns the message translated to the locale.
+func (m Message) Translate(locale string) string {
+	return T(locale, m.Text, m.Args...)
+}
+
+// String returns the message in the default locale.
+func (m Message) String() string {
+	return m.Translate(DefaultLocale)
+}
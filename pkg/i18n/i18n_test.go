@@ -0,0 +1,61 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCatalogsContainSameMessages(t *testing.T) {
+	for locale, catalog := range catalogs {
+		if locale == DefaultLocale {
+			continue
+		}
+
+		for msg := range de {
+			if _, ok := catalog[msg]; !ok {
+				t.Errorf("message %q is not translated to %s", msg, locale)
+			}
+		}
+
+		for msg, tr := range catalog {
+			if _, ok := de[msg]; !ok {
+				t.Errorf("message %q of %s is missing in other catalogs", msg, locale)
+			}
+
+			if strings.Count(msg, "%s") != strings.Count(tr, "%s") {
+				t.Errorf("translation of %q to %s has different arguments", msg, locale)
+			}
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if res := T("de", "Task '%s' failed", "Deploy"); res != "Aufgabe 'Deploy' ist fehlgeschlagen" {
+		t.Fatal("invalid translation", res)
+	}
+
+	if res := T("ru", "Unknown message"); res != "Unknown message" {
+		t.Fatal("message without translation must be returned as is", res)
+	}
+
+	if res := T("", "Task '%s' failed", "Deploy"); res != "Task 'Deploy' failed" {
+		t.Fatal("invalid message in default locale", res)
+	}
+}
+
+func TestFromAcceptLanguage(t *testing.T) {
+	cases := map[string]string{
+		"":                          "",
+		"de-DE":                     "de",
+		"ja, fr-CA;q=0.8, ru;q=0.9": "ru",
+		"en-US,en;q=0.9,de;q=0.8":   "en",
+		"zh-CN, zh;q=0.9":           "",
+		"fr;q=invalid, de;q=0.1":    "de",
+	}
+
+	for header, expected := range cases {
+		if res := FromAcceptLanguage(header); res != expected {
+			t.Errorf("expected %q for %q, got %q", expected, header, res)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package i18n
+
+var ru = map[string]string{
+	// validation errors
+	"Username cannot be empty":                 "Имя пользователя не может быть пустым",
+	"Email cannot be empty":                    "Email не может быть пустым",
+	"Name cannot be empty":                     "Имя не может быть пустым",
+	"Unsupported locale":                       "Неподдерживаемый язык",
+	"name can not be empty":                    "Имя не может быть пустым",
+	"title can not be empty":                   "Заголовок не может быть пустым",
+	"project name can not be empty":            "Название проекта не может быть пустым",
+	"template name can not be empty":           "Название шаблона не может быть пустым",
+	"template playbook can not be empty":       "Плейбук шаблона не может быть пустым",
+	"template inventory can not be empty":      "Инвентарь шаблона не может быть пустым",
+	"template arguments must be valid JSON":    "Аргументы шаблона должны быть корректным JSON",
+	"repository name can't be empty":           "Название репозитория не может быть пустым",
+	"repository url can't be empty":            "URL репозитория не может быть пустым",
+	"repository branch can't be empty":         "Ветка репозитория не может быть пустой",
+	"Environment name can not be empty":        "Название окружения не может быть пустым",
+	"Extra variables must be valid JSON":       "Дополнительные переменные должны быть корректным JSON",
+	"Environment variables must be valid JSON": "Переменные окружения должны быть корректным JSON",
+	"pattern can not be empty":                 "Шаблон поиска не может быть пустым",
+	"You have no subscription.":                "У вас нет подписки.",
+	"Not found":                                "Не найдено",
+	"Bad request":                              "Некорректный запрос",
+	"Invalid operation":                        "Недопустимая операция",
+	"Invalid request body":                     "Некорректное тело запроса",
+
+	// alerts
+	"Task '%s' failed":                       "Задача '%s' завершилась с ошибкой",
+	"Task %s with template '%s' has failed!": "Задача %s шаблона '%s' завершилась с ошибкой!",
+	"Task Log":                               "Лог задачи",
+	"Link":                                   "Ссылка",
+	"Schedule '%s' did not fire":             "Расписание '%s' не сработало",
+	"Project %s: schedule '%s' (%s) was expected to fire at %s":               "Проект %s: расписание '%s' (%s) должно было сработать в %s",
+	"Schedule '%s' disabled":                                                  "Расписание '%s' отключено",
+	"Project %s: schedule '%s' was disabled after %d consecutive failed runs": "Проект %s: расписание '%s' отключено после %d неудачных запусков подряд",
+	"EXCEEDED RUNTIME BUDGET OF %s":                                           "ПРЕВЫШЕН БЮДЖЕТ ВРЕМЕНИ ВЫПОЛНЕНИЯ %s",
+
+	// template health
+	"Template '%s' is flaky": "Шаблон '%s' нестабилен",
+	"Project %s: results of template '%s' alternate without configuration changes in %d%% of the last %d runs": "Проект %s: результаты шаблона '%s' чередуются без изменений конфигурации в %d%% из последних %d запусков",
+
+	// digests
+	"Digest of scheduled runs of project %s":                                        "Сводка запусков по расписанию проекта %s",
+	"Scheduled runs from %s to %s, failed: %d":                                      "Запуски по расписанию с %s по %s, с ошибкой: %d",
+	"%s: %d succeeded, %d failed, %d stopped, average duration %s, max duration %s": "%s: успешно %d, с ошибкой %d, остановлено %d, средняя длительность %s, максимальная длительность %s",
+
+	// access reviews
+	"Access review of project %s":                       "Проверка доступа к проекту %s",
+	"Users who have access to the project on %s: %d":    "Пользователи с доступом к проекту на %s: %d",
+	"%s (%s), role: %s, expires: %s, last activity: %s": "%s (%s), роль: %s, истекает: %s, последняя активность: %s",
+
+	// access changes
+	"Access to project %s changed":                        "Доступ к проекту %s изменён",
+	"User %s was added to the project with role %s by %s": "Пользователь %s добавлен в проект с ролью %s пользователем %s",
+	"User %s was removed from the project by %s":          "Пользователь %s удалён из проекта пользователем %s",
+	"Role of user %s was changed from %s to %s by %s":     "Роль пользователя %s изменена с %s на %s пользователем %s",
+	"API token created":                                   "Создан API-токен",
+	"User %s created an API token":                        "Пользователь %s создал API-токен",
+
+	// key rotation
+	"Access keys of project %s must be rotated":                        "Ключи доступа проекта %s нужно сменить",
+	"Secrets of %d access keys were not changed for more than %d days": "Секреты %d ключей доступа не менялись более %d дней",
+	"%s: last changed %s": "%s: последнее изменение %s",
+
+	// template references
+	"template repository can not be empty":                      "Репозиторий шаблона не может быть пустым",
+	"repository not found in the project":                       "Репозиторий не найден в проекте",
+	"inventory not found in the project":                        "Инвентарь не найден в проекте",
+	"workspace inventory can not be used by ansible template":   "Инвентарь рабочего пространства не может использоваться шаблоном Ansible",
+	"inventory user key type can not be used to access hosts":   "Тип ключа пользователя инвентаря не может использоваться для доступа к хостам",
+	"inventory become key type can not be used to become user":  "Тип ключа become инвентаря не может использоваться для смены пользователя",
+	"environment not found in the project":                      "Окружение не найдено в проекте",
+	"build template not found in the project":                   "Шаблон сборки не найден в проекте",
+	"abort template not found":                                  "Шаблон прерывания не найден",
+	"abort template can not have change window":                 "Шаблон прерывания не может иметь окно изменений",
+	"key not found in the project":                              "Ключ не найден в проекте",
+	"key type can not be used as vault password":                "Тип ключа не может использоваться как пароль хранилища",
+	"only string and SSH keys can be installed as secret files": "Только строковые и SSH-ключи могут быть установлены как секретные файлы",
+
+	// objects in use
+	"Access Key is in use by one or more templates":  "Ключ доступа используется одним или несколькими шаблонами",
+	"Inventory is in use by one or more templates":   "Инвентарь используется одним или несколькими шаблонами",
+	"Repository is in use by one or more templates":  "Репозиторий используется одним или несколькими шаблонами",
+	"Environment is in use by one or more templates": "Окружение используется одним или несколькими шаблонами",
+}
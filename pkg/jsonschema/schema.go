@@ -0,0 +1,435 @@
+// Package jsonschema validates JSON values against the subset of JSON Schema
+// used to describe variables: types, properties, arrays, enums, numeric and string limits.
+// References ($ref) are not supported, unknown keywords are ignored.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var knownTypes = map[string]bool{
+	"null":    true,
+	"boolean": true,
+	"object":  true,
+	"array":   true,
+	"number":  true,
+	"string":  true,
+	"integer": true,
+}
+
+// Schema is the parsed JSON schema. Boolean schemas true and false are
+// represented by Schema with only Bool set.
+type Schema struct {
+	Bool *bool `json:"-"`
+
+	Ref   string     `json:"$ref"`
+	Type  typeList   `json:"type"`
+	Enum  []any      `json:"enum"`
+	Const *jsonValue `json:"const"`
+
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *Schema            `json:"additionalProperties"`
+
+	Items    *Schema `json:"items"`
+	MinItems *int    `json:"minItems"`
+	MaxItems *int    `json:"maxItems"`
+
+	Minimum          *float64 `json:"minimum"`
+	Maximum          *float64 `json:"maximum"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum"`
+
+	MinLength *int   `json:"minLength"`
+	MaxLength *int   `json:"maxLength"`
+	Pattern   string `json:"pattern"`
+
+	AllOf []*Schema `json:"allOf"`
+	AnyOf []*Schema `json:"anyOf"`
+	OneOf []*Schema `json:"oneOf"`
+
+	pattern *regexp.Regexp
+}
+
+// typeList is the value of the type keyword which can be a string or an array of strings.
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = typeList{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errors.New("type must be a string or an array of strings")
+	}
+
+	*t = list
+	return nil
+}
+
+// jsonValue keeps the value of the const keyword, it can be null.
+type jsonValue struct {
+	value any
+}
+
+func (v *jsonValue) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &v.value)
+}
+
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	var b bool
+	if err := json.Unmarshal(data, &b); err == nil {
+		s.Bool = &b
+		return nil
+	}
+
+	type plain Schema
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+
+	*s = Schema(p)
+	return nil
+}
+
+// Parse parses the schema and checks that its keywords are valid.
+func Parse(data []byte) (*Schema, error) {
+	s := &Schema{}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+
+	if err := s.compile(""); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Schema) compile(path string) error {
+	if s == nil || s.Bool != nil {
+		return nil
+	}
+
+	if s.Ref != "" {
+		return fmt.Errorf("%s: $ref is not supported", displayPath(path))
+	}
+
+	for _, t := range s.Type {
+		if !knownTypes[t] {
+			return fmt.Errorf("%s: unknown type %q", displayPath(path), t)
+		}
+	}
+
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern: %s", displayPath(path), err.Error())
+		}
+		s.pattern = re
+	}
+
+	for name, p := range s.Properties {
+		if err := p.compile(path + "/properties/" + escapePointer(name)); err != nil {
+			return err
+		}
+	}
+
+	if err := s.AdditionalProperties.compile(path + "/additionalProperties"); err != nil {
+		return err
+	}
+
+	if err := s.Items.compile(path + "/items"); err != nil {
+		return err
+	}
+
+	for keyword, list := range map[string][]*Schema{"allOf": s.AllOf, "anyOf": s.AnyOf, "oneOf": s.OneOf} {
+		for i, sub := range list {
+			if err := sub.compile(path + "/" + keyword + "/" + strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Error describes the value which does not match the schema.
+type Error struct {
+	// Path is JSON pointer of the value, empty for the root value.
+	Path    string
+	Message string
+}
+
+func (e Error) Error() string {
+	return displayPath(e.Path) + ": " + e.Message
+}
+
+// Errors is the list of all problems found in the value.
+type Errors []Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate checks the value decoded by encoding/json against the schema.
+// It returns nil or Errors.
+func (s *Schema) Validate(value any) error {
+	errs := s.validate("", value)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// ValidateJSON decodes the JSON document and validates it.
+func (s *Schema) ValidateJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	return s.Validate(value)
+}
+
+func (s *Schema) validate(path string, value any) (errs Errors) {
+	if s == nil {
+		return nil
+	}
+
+	if s.Bool != nil {
+		if !*s.Bool {
+			errs = append(errs, Error{path, "value is not allowed"})
+		}
+		return
+	}
+
+	if len(s.Type) > 0 && !s.matchesType(value) {
+		return Errors{{path, "expected " + strings.Join(s.Type, " or ") + ", got " + typeOf(value)}}
+	}
+
+	if len(s.Enum) > 0 && !containsValue(s.Enum, value) {
+		errs = append(errs, Error{path, "value must be one of " + formatValues(s.Enum)})
+	}
+
+	if s.Const != nil && !reflect.DeepEqual(s.Const.value, value) {
+		errs = append(errs, Error{path, "value must be " + formatValues([]any{s.Const.value})})
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		errs = append(errs, s.validateObject(path, v)...)
+	case []any:
+		errs = append(errs, s.validateArray(path, v)...)
+	case float64:
+		errs = append(errs, s.validateNumber(path, v)...)
+	case string:
+		errs = append(errs, s.validateString(path, v)...)
+	}
+
+	for _, sub := range s.AllOf {
+		errs = append(errs, sub.validate(path, value)...)
+	}
+
+	if len(s.AnyOf) > 0 {
+		matched := false
+		for _, sub := range s.AnyOf {
+			if len(sub.validate(path, value)) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			errs = append(errs, Error{path, "value does not match any of the allowed schemas"})
+		}
+	}
+
+	if len(s.OneOf) > 0 {
+		matched := 0
+		for _, sub := range s.OneOf {
+			if len(sub.validate(path, value)) == 0 {
+				matched++
+			}
+		}
+		if matched != 1 {
+			errs = append(errs, Error{path, "value must match exactly one of the allowed schemas, matched " + strconv.Itoa(matched)})
+		}
+	}
+
+	return
+}
+
+func (s *Schema) validateObject(path string, obj map[string]any) (errs Errors) {
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			errs = append(errs, Error{path, "missing required property \"" + name + "\""})
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		propPath := path + "/" + escapePointer(name)
+
+		if prop, ok := s.Properties[name]; ok {
+			errs = append(errs, prop.validate(propPath, obj[name])...)
+			continue
+		}
+
+		if s.AdditionalProperties != nil {
+			if s.AdditionalProperties.Bool != nil && !*s.AdditionalProperties.Bool {
+				errs = append(errs, Error{propPath, "property is not allowed"})
+				continue
+			}
+			errs = append(errs, s.AdditionalProperties.validate(propPath, obj[name])...)
+		}
+	}
+
+	return
+}
+
+func (s *Schema) validateArray(path string, arr []any) (errs Errors) {
+	if s.MinItems != nil && len(arr) < *s.MinItems {
+		errs = append(errs, Error{path, "array must contain at least " + strconv.Itoa(*s.MinItems) + " items"})
+	}
+
+	if s.MaxItems != nil && len(arr) > *s.MaxItems {
+		errs = append(errs, Error{path, "array must contain at most " + strconv.Itoa(*s.MaxItems) + " items"})
+	}
+
+	for i, item := range arr {
+		errs = append(errs, s.Items.validate(path+"/"+strconv.Itoa(i), item)...)
+	}
+
+	return
+}
+
+func (s *Schema) validateNumber(path string, n float64) (errs Errors) {
+	if s.Minimum != nil && n < *s.Minimum {
+		errs = append(errs, Error{path, "value must be >= " + formatNumber(*s.Minimum)})
+	}
+
+	if s.Maximum != nil && n > *s.Maximum {
+		errs = append(errs, Error{path, "value must be <= " + formatNumber(*s.Maximum)})
+	}
+
+	if s.ExclusiveMinimum != nil && n <= *s.ExclusiveMinimum {
+		errs = append(errs, Error{path, "value must be > " + formatNumber(*s.ExclusiveMinimum)})
+	}
+
+	if s.ExclusiveMaximum != nil && n >= *s.ExclusiveMaximum {
+		errs = append(errs, Error{path, "value must be < " + formatNumber(*s.ExclusiveMaximum)})
+	}
+
+	return
+}
+
+func (s *Schema) validateString(path string, str string) (errs Errors) {
+	length := utf8.RuneCountInString(str)
+
+	if s.MinLength != nil && length < *s.MinLength {
+		errs = append(errs, Error{path, "string must be at least " + strconv.Itoa(*s.MinLength) + " characters long"})
+	}
+
+	if s.MaxLength != nil && length > *s.MaxLength {
+		errs = append(errs, Error{path, "string must be at most " + strconv.Itoa(*s.MaxLength) + " characters long"})
+	}
+
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		errs = append(errs, Error{path, "string does not match pattern " + s.Pattern})
+	}
+
+	return
+}
+
+func (s *Schema) matchesType(value any) bool {
+	actual := typeOf(value)
+
+	for _, t := range s.Type {
+		// integers are numbers too
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func typeOf(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func containsValue(values []any, value any) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatValues(values []any) string {
+	strs := make([]string, 0, len(values))
+	for _, v := range values {
+		b, _ := json.Marshal(v)
+		strs = append(strs, string(b))
+	}
+	return strings.Join(strs, ", ")
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
@@ -0,0 +1,71 @@
+package jsonschema
+
+import (
+	"errors"
+	"testing"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["region", "port"],
+	"properties": {
+		"region": {"type": "string", "enum": ["eu", "us"]},
+		"port": {"type": "integer", "minimum": 1, "maximum": 65535},
+		"hosts": {"type": "array", "items": {"type": "string", "pattern": "^[a-z0-9.-]+$"}, "minItems": 1},
+		"debug": {"type": ["boolean", "null"]}
+	},
+	"additionalProperties": false
+}`
+
+func TestValidate(t *testing.T) {
+	s, err := Parse([]byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.ValidateJSON([]byte(`{"region": "eu", "port": 22, "hosts": ["web1"], "debug": null}`)); err != nil {
+		t.Fatal("valid value is rejected", err)
+	}
+
+	err = s.ValidateJSON([]byte(`{"region": "asia", "port": 22.5, "hosts": ["Web 1"], "extra": 1}`))
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected validation errors, got %v", err)
+	}
+
+	expected := []string{
+		`/extra: property is not allowed`,
+		`/hosts/0: string does not match pattern ^[a-z0-9.-]+$`,
+		`/port: expected integer, got number`,
+		`/region: value must be one of "eu", "us"`,
+	}
+
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %v", len(expected), errs)
+	}
+
+	for i, msg := range expected {
+		if errs[i].Error() != msg {
+			t.Errorf("expected %q, got %q", msg, errs[i].Error())
+		}
+	}
+
+	err = s.ValidateJSON([]byte(`{}`))
+	if err == nil || err.Error() != `(root): missing required property "region"; (root): missing required property "port"` {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestParseInvalidSchema(t *testing.T) {
+	for _, schema := range []string{
+		`{"type": "text"}`,
+		`{"properties": {"a": {"pattern": "("}}}`,
+		`{"$ref": "#/definitions/a"}`,
+		`[]`,
+	} {
+		if _, err := Parse([]byte(schema)); err == nil {
+			t.Errorf("schema %s must be rejected", schema)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// Package mtls authenticates API clients and runners by client certificates.
+// Certificates are verified during the TLS handshake against the client CAs
+// and are rejected if they are revoked by the CRL or by the OCSP responder.
+package mtls
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// IdentityField is the field of the certificate which identifies the client.
+type IdentityField string
+
+const (
+	IdentityCommonName IdentityField = "cn"
+	IdentityEmail      IdentityField = "email"
+)
+
+// ocspCacheTTL is the time the OCSP response without NextUpdate is cached.
+const ocspCacheTTL = 5 * time.Minute
+
+// LoadCertificates reads PEM encoded certificates from the file.
+func LoadCertificates(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*x509.Certificate
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+
+	return certs, nil
+}
+
+// LoadCRL reads the PEM or DER encoded CRL and checks that it is signed by one of the issuers.
+func LoadCRL(path string, issuers []*x509.Certificate) (*x509.RevocationList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+
+	crl, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, issuer := range issuers {
+		if crl.CheckSignatureFrom(issuer) == nil {
+			return crl, nil
+		}
+	}
+
+	return nil, fmt.Errorf("CRL %s is not signed by the client CA", path)
+}
+
+// RevocationChecker rejects revoked client certificates.
+type RevocationChecker struct {
+	// CRL contains revoked certificates, it is not checked if nil.
+	CRL *x509.RevocationList
+	// OCSP enables checking of certificates which contain the OCSP server.
+	OCSP bool
+	// HTTPClient is used for OCSP requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+
+	mu sync.Mutex
+	// goodUntil caches OCSP responses, the key is the serial number.
+	goodUntil map[string]time.Time
+}
+
+// VerifyConnection can be used as tls.Config.VerifyConnection.
+// Connections without client certificate are accepted.
+func (c *RevocationChecker) VerifyConnection(state tls.ConnectionState) error {
+	for _, chain := range state.VerifiedChains {
+		if len(chain) == 0 {
+			continue
+		}
+
+		var issuer *x509.Certificate
+		if len(chain) > 1 {
+			issuer = chain[1]
+		}
+
+		if err := c.Check(chain[0], issuer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Check returns the error if the certificate is revoked or its status is unknown.
+func (c *RevocationChecker) Check(cert *x509.Certificate, issuer *x509.Certificate) error {
+	if c.CRL != nil {
+		for _, revoked := range c.CRL.RevokedCertificateEntries {
+			if revoked.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				return fmt.Errorf("certificate %s is revoked", cert.Subject.CommonName)
+			}
+		}
+	}
+
+	if !c.OCSP || len(cert.OCSPServer) == 0 || issuer == nil {
+		return nil
+	}
+
+	return c.checkOCSP(cert, issuer)
+}
+
+func (c *RevocationChecker) checkOCSP(cert *x509.Certificate, issuer *x509.Certificate) error {
+	serial := cert.SerialNumber.String()
+	now := time.Now()
+
+	c.mu.Lock()
+	until, ok := c.goodUntil[serial]
+	c.mu.Unlock()
+
+	if ok && now.Before(until) {
+		return nil
+	}
+
+	req, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return err
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Post(cert.OCSPServer[0], "application/ocsp-request", bytes.NewReader(req))
+	if err != nil {
+		return fmt.Errorf("OCSP request failed: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	res, err := ocsp.ParseResponseForCert(body, cert, issuer)
+	if err != nil {
+		return fmt.Errorf("invalid OCSP response: %w", err)
+	}
+
+	switch res.Status {
+	case ocsp.Good:
+	case ocsp.Revoked:
+		return fmt.Errorf("certificate %s is revoked", cert.Subject.CommonName)
+	default:
+		return fmt.Errorf("status of certificate %s is unknown", cert.Subject.CommonName)
+	}
+
+	until = res.NextUpdate
+	if until.IsZero() {
+		until = now.Add(ocspCacheTTL)
+	}
+
+	c.mu.Lock()
+	if c.goodUntil == nil {
+		c.goodUntil = make(map[string]time.Time)
+	}
+	c.goodUntil[serial] = until
+	c.mu.Unlock()
+
+	return nil
+}
+
+// ClientCertificate returns the verified client certificate of the request or nil.
+func ClientCertificate(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
+
+// Identity returns the value of the field of the certificate, for example the common name.
+func Identity(cert *x509.Certificate, field IdentityField) (string, error) {
+	switch field {
+	case "", IdentityCommonName:
+		if cert.Subject.CommonName == "" {
+			return "", errors.New("certificate has no common name")
+		}
+		return cert.Subject.CommonName, nil
+	case IdentityEmail:
+		if len(cert.EmailAddresses) == 0 {
+			return "", errors.New("certificate has no email address")
+		}
+		return strings.ToLower(cert.EmailAddresses[0]), nil
+	default:
+		return "", fmt.Errorf("unknown certificate field %s", field)
+	}
+}
@@ -0,0 +1,202 @@
+package mtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testCA{cert: cert, key: key}
+}
+
+func (ca testCA) issue(t *testing.T, serial int64, cn string, ocspServer string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl := &x509.Certificate{
+		SerialNumber:   big.NewInt(serial),
+		Subject:        pkix.Name{CommonName: cn},
+		EmailAddresses: []string{"Test@Example.com"},
+		NotBefore:      time.Now().Add(-time.Hour),
+		NotAfter:       time.Now().Add(time.Hour),
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	if ocspServer != "" {
+		tpl.OCSPServer = []string{ocspServer}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert
+}
+
+func TestCheckCRL(t *testing.T) {
+	ca := newTestCA(t)
+	revoked := ca.issue(t, 10, "revoked", "")
+	valid := ca.issue(t, 11, "valid", "")
+
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now(),
+		NextUpdate: time.Now().Add(time.Hour),
+		RevokedCertificateEntries: []x509.RevocationListEntry{
+			{SerialNumber: revoked.SerialNumber, RevocationTime: time.Now()},
+		},
+	}, ca.cert, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "crl.pem")
+	if err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = LoadCRL(path, []*x509.Certificate{newTestCA(t).cert}); err == nil {
+		t.Fatal("CRL signed by other CA must be rejected")
+	}
+
+	crl, err := LoadCRL(path, []*x509.Certificate{ca.cert})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checker := &RevocationChecker{CRL: crl}
+
+	if checker.Check(revoked, ca.cert) == nil {
+		t.Fatal("revoked certificate must be rejected")
+	}
+
+	if err = checker.Check(valid, ca.cert); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckOCSP(t *testing.T) {
+	ca := newTestCA(t)
+
+	var revokedSerial *big.Int
+	requests := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		body, _ := io.ReadAll(r.Body)
+		req, err := ocsp.ParseRequest(body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		status := ocsp.Good
+		if req.SerialNumber.Cmp(revokedSerial) == 0 {
+			status = ocsp.Revoked
+		}
+
+		res, err := ocsp.CreateResponse(ca.cert, ca.cert, ocsp.Response{
+			Status:       status,
+			SerialNumber: req.SerialNumber,
+			ThisUpdate:   time.Now(),
+			NextUpdate:   time.Now().Add(time.Hour),
+			RevokedAt:    time.Now(),
+		}, ca.key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		_, _ = w.Write(res)
+	}))
+	defer srv.Close()
+
+	revoked := ca.issue(t, 20, "revoked", srv.URL)
+	valid := ca.issue(t, 21, "valid", srv.URL)
+	revokedSerial = revoked.SerialNumber
+
+	checker := &RevocationChecker{OCSP: true}
+
+	if checker.Check(revoked, ca.cert) == nil {
+		t.Fatal("revoked certificate must be rejected")
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := checker.Check(valid, ca.cert); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if requests != 2 {
+		t.Fatalf("good OCSP response must be cached, %d requests sent", requests)
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	cert := newTestCA(t).issue(t, 30, "john", "")
+
+	if id, err := Identity(cert, IdentityCommonName); err != nil || id != "john" {
+		t.Fatal("common name expected", id, err)
+	}
+
+	if id, err := Identity(cert, IdentityEmail); err != nil || id != "test@example.com" {
+		t.Fatal("email expected", id, err)
+	}
+
+	if _, err := Identity(cert, "uid"); err == nil {
+		t.Fatal("unknown field must be rejected")
+	}
+}
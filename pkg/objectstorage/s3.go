@@ -0,0 +1,198 @@
+// Package objectstorage implements a minimal client of S3-compatible object storages.
+package objectstorage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// unsignedPayload allows to stream the request body without hashing it in advance.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+var ErrNotFound = fmt.Errorf("object not found")
+
+// Client signs requests with AWS Signature Version 4.
+type Client struct {
+	// Endpoint is the URL of the storage, for example https://s3.eu-west-1.amazonaws.com.
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle puts the bucket name to the path instead of the host name.
+	// It is required by most self-hosted storages like MinIO.
+	PathStyle bool
+
+	HTTPClient *http.Client
+
+	now func() time.Time
+}
+
+func (c *Client) getObjectURL(key string) (*url.URL, error) {
+	u, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.PathStyle {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + c.Bucket + "/" + key
+	} else {
+		u.Host = c.Bucket + "." + u.Host
+		u.Path = "/" + key
+	}
+
+	return u, nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// encodePath encodes the path as required by the canonical request of S3.
+func encodePath(path string) string {
+	var b strings.Builder
+	for _, c := range []byte(path) {
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// sign adds the authorization header. All headers of the request are signed.
+func (c *Client) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	dateStamp := now.UTC().Format("20060102")
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		encodePath(req.URL.Path),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := dateStamp + "/" + c.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.SecretAccessKey), dateStamp)
+	key = hmacSHA256(key, c.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+c.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func (c *Client) do(method string, key string, body io.Reader, size int64) (*http.Response, error) {
+	u, err := c.getObjectURL(key)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.ContentLength = size
+	}
+
+	now := time.Now
+	if c.now != nil {
+		now = c.now
+	}
+
+	c.sign(req, unsignedPayload, now())
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		_ = resp.Body.Close()
+		return nil, ErrNotFound
+	}
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("object storage returned status %d: %s", resp.StatusCode, string(msg))
+	}
+
+	return resp, nil
+}
+
+// PutObject uploads the object. The body is streamed, so size must be known in advance.
+func (c *Client) PutObject(key string, body io.Reader, size int64) error {
+	resp, err := c.do("PUT", key, body, size)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// GetObject returns the content of the object. The caller must close it.
+func (c *Client) GetObject(key string) (io.ReadCloser, error) {
+	resp, err := c.do("GET", key, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// DeleteObject removes the object. Removing of not existing object is not an error.
+func (c *Client) DeleteObject(key string) error {
+	resp, err := c.do("DELETE", key, nil, 0)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -0,0 +1,339 @@
+// Package openapi builds OpenAPI 3.1 documents from gorilla/mux routers
+// and Go types, so the specification can not drift from the actual API.
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const Version = "3.1.0"
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+// PathItem maps lower case HTTP methods to operations.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags        []string             `json:"tags,omitempty"`
+	OperationID string               `json:"operationId"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// OperationSpec describes request and response bodies of an operation.
+// Request and Response are sample values of Go types, nil means no body.
+type OperationSpec struct {
+	Request      any
+	Response     any
+	ResponseList bool
+	Status       int
+}
+
+// Generator accumulates paths and schemas of a document.
+type Generator struct {
+	doc Document
+}
+
+func NewGenerator(title string, version string, serverURL string) *Generator {
+	g := &Generator{
+		doc: Document{
+			OpenAPI: Version,
+			Info: Info{
+				Title:   title,
+				Version: version,
+			},
+			Paths: make(map[string]*PathItem),
+			Components: Components{
+				Schemas: make(map[string]*Schema),
+			},
+		},
+	}
+
+	if serverURL != "" {
+		g.doc.Servers = []Server{{URL: serverURL}}
+	}
+
+	return g
+}
+
+// Document returns the generated document.
+func (g *Generator) Document() *Document {
+	return &g.doc
+}
+
+// JSON returns the generated document serialized to JSON.
+func (g *Generator) JSON() ([]byte, error) {
+	return json.MarshalIndent(g.doc, "", "  ")
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaOf returns schema of the Go type. Named structs are added
+// to components and referenced.
+func (g *Generator) SchemaOf(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Ptr {
+		s := g.SchemaOf(t.Elem())
+		if s.Ref == "" && s.Type != nil {
+			s.Type = []any{s.Type, "null"}
+		}
+		return s
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.SchemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.SchemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		if _, ok := g.doc.Components.Schemas[t.Name()]; !ok {
+			// register before filling to stop recursion on self-referencing types
+			g.doc.Components.Schemas[t.Name()] = &Schema{}
+			*g.doc.Components.Schemas[t.Name()] = *g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	default:
+		return &Schema{}
+	}
+}
+
+func (g *Generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	g.addFields(s, t)
+
+	return s
+}
+
+func (g *Generator) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if !f.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(s, ft)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		s.Properties[name] = g.SchemaOf(f.Type)
+
+		if f.Tag.Get("binding") == "required" {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func (g *Generator) bodySchema(sample any, list bool) *Schema {
+	s := g.SchemaOf(reflect.TypeOf(sample))
+	if list {
+		s = &Schema{Type: "array", Items: s}
+	}
+	return s
+}
+
+var pathParamRE = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?}`)
+
+// AddRouter adds all routes of the router which have methods.
+// basePath is removed from path templates, it should be the server URL.
+func (g *Generator) AddRouter(router *mux.Router, basePath string, spec func(path string, method string) OperationSpec) error {
+	return router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		if !strings.HasPrefix(tpl, basePath) {
+			return nil
+		}
+
+		path := pathParamRE.ReplaceAllString(strings.TrimPrefix(tpl, basePath), "{$1}")
+		if path == "" {
+			path = "/"
+		}
+
+		for _, method := range methods {
+			if method == "HEAD" || method == "OPTIONS" {
+				continue
+			}
+			g.addOperation(path, method, spec(path, method))
+		}
+
+		return nil
+	})
+}
+
+func operationID(path string, method string) string {
+	id := strings.ToLower(method)
+	for _, part := range strings.Split(path, "/") {
+		part = strings.Trim(part, "{}")
+		for _, word := range strings.FieldsFunc(part, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+			id += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return id
+}
+
+func (g *Generator) addOperation(path string, method string, spec OperationSpec) {
+	item, ok := g.doc.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		g.doc.Paths[path] = item
+	}
+
+	op := &Operation{
+		OperationID: operationID(path, method),
+		Responses:   make(map[string]*Response),
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) > 0 && segments[0] != "" {
+		op.Tags = []string{segments[0]}
+	}
+
+	for _, m := range pathParamRE.FindAllStringSubmatch(path, -1) {
+		schema := &Schema{Type: "string"}
+		if strings.HasSuffix(m[1], "_id") {
+			schema = &Schema{Type: "integer"}
+		}
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     m[1],
+			In:       "path",
+			Required: true,
+			Schema:   schema,
+		})
+	}
+
+	if spec.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{
+				"application/json": {Schema: g.bodySchema(spec.Request, false)},
+			},
+		}
+	}
+
+	status := spec.Status
+	if status == 0 {
+		status = 200
+	}
+
+	resp := &Response{Description: "Successful response"}
+	if spec.Response != nil {
+		resp.Content = map[string]MediaType{
+			"application/json": {Schema: g.bodySchema(spec.Response, spec.ResponseList)},
+		}
+	}
+	op.Responses[strconv.Itoa(status)] = resp
+
+	(*item)[strings.ToLower(method)] = op
+}
@@ -0,0 +1,86 @@
+// Package ratelimit implements fixed window request counters.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Result describes the state of the counter after the request.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time left until the counter is reset.
+	Reset time.Duration
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+// Limiter allows at most Limit requests per Window for every key.
+type Limiter struct {
+	Limit  int
+	Window time.Duration
+
+	mu        sync.Mutex
+	windows   map[string]*window
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func New(limit int, period time.Duration) *Limiter {
+	return &Limiter{
+		Limit:   limit,
+		Window:  period,
+		windows: make(map[string]*window),
+		now:     time.Now,
+	}
+}
+
+// sweep removes expired windows to keep memory bounded.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.Window {
+		return
+	}
+
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= l.Window {
+			delete(l.windows, key)
+		}
+	}
+
+	l.lastSweep = now
+}
+
+// Allow counts the request for the key and reports whether it fits into the limit.
+func (l *Limiter) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.Window {
+		w = &window{start: now}
+		l.windows[key] = w
+	}
+
+	res := Result{
+		Limit: l.Limit,
+		Reset: w.start.Add(l.Window).Sub(now),
+	}
+
+	if w.count >= l.Limit {
+		return res
+	}
+
+	w.count++
+	res.Allowed = true
+	res.Remaining = l.Limit - w.count
+
+	return res
+}
@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	l := New(2, time.Minute)
+	l.now = func() time.Time { return now }
+
+	if res := l.Allow("a"); !res.Allowed || res.Remaining != 1 {
+		t.Fatal("first request must be allowed", res)
+	}
+
+	if res := l.Allow("a"); !res.Allowed || res.Remaining != 0 {
+		t.Fatal("second request must be allowed", res)
+	}
+
+	now = now.Add(10 * time.Second)
+
+	res := l.Allow("a")
+	if res.Allowed {
+		t.Fatal("third request must be rejected")
+	}
+
+	if res.Reset != 50*time.Second {
+		t.Fatal("unexpected reset", res.Reset)
+	}
+
+	if !l.Allow("b").Allowed {
+		t.Fatal("other keys must not be affected")
+	}
+
+	now = now.Add(time.Minute)
+
+	if !l.Allow("a").Allowed {
+		t.Fatal("request must be allowed in the next window")
+	}
+
+	if len(l.windows) != 1 {
+		t.Fatal("expired windows must be removed")
+	}
+}
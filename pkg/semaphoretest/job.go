@@ -0,0 +1,28 @@
+package semaphoretest
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/services/tasks"
+)
+
+// fakeJob completes the task without running Ansible, Terraform or other apps.
+type fakeJob struct {
+	runner *tasks.TaskRunner
+	run    func(task db.Task, log func(msg string)) error
+}
+
+func (j *fakeJob) Run(username string, incomingVersion *string) error {
+	j.runner.SetStatus(task_logger.TaskRunningStatus)
+	j.runner.Log("Fake run of template " + j.runner.Template.Name)
+
+	if j.run == nil {
+		return nil
+	}
+
+	return j.run(j.runner.Task, j.runner.Log)
+}
+
+func (j *fakeJob) Kill() {
+	j.runner.Log("Fake job killed")
+}
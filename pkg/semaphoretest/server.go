@@ -0,0 +1,177 @@
+// Package semaphoretest runs Semaphore in memory for integration tests of
+// API clients, such as the Terraform provider or SDKs. The server uses
+// a temporary Bolt database and runs tasks by the fake job, so neither
+// Docker nor Ansible is required.
+//
+//	srv := semaphoretest.NewServer(t)
+//	c := client.New(srv.URL, srv.Token)
+package semaphoretest
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/context"
+	"github.com/semaphoreui/semaphore/api"
+	"github.com/semaphoreui/semaphore/api/sockets"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/services/tasks"
+	"github.com/semaphoreui/semaphore/util"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// startWS starts the websocket hub which is shared by all test servers,
+// task logs are sent through it.
+var startWS sync.Once
+
+// Options configure the test server.
+type Options struct {
+	// RunTask is called by the fake job instead of running the task.
+	// The task fails if it returns the error. Default job succeeds.
+	RunTask func(task db.Task, log func(msg string)) error
+
+	// UseRemoteRunner makes the server assign tasks to remote runners instead of
+	// running them by the fake job. Tests connect runners themselves.
+	UseRemoteRunner bool
+}
+
+// Server is Semaphore running in memory.
+type Server struct {
+	// URL is the base URL of the server without /api suffix.
+	URL string
+	// Token is the API token of the admin user.
+	Token string
+	// Admin is the user which owns Token.
+	Admin db.User
+	// Store is the database of the server, it can be used to prepare
+	// data which can not be created by the API.
+	Store db.Store
+	// TaskPool runs tasks created by the API.
+	TaskPool *tasks.TaskPool
+
+	httpServer *httptest.Server
+}
+
+// NewServer starts the test server with default options.
+// The server is stopped when the test finishes.
+func NewServer(t testing.TB) *Server {
+	return NewServerWithOptions(t, Options{})
+}
+
+// NewServerWithOptions starts the test server. The server is stopped when the test finishes.
+func NewServerWithOptions(t testing.TB, opts Options) *Server {
+	t.Helper()
+
+	store := bolt.CreateTestStore()
+	util.Config.TmpPath = t.TempDir()
+	util.Config.Port = ":0"
+
+	// the fake job runs templates of any app, binaries of the apps are not required
+	util.Config.Apps = make(map[string]util.App)
+	for _, app := range []db.TemplateApp{
+		db.AppAnsible, db.AppTerraform, db.AppTofu, db.AppBash, db.AppPowerShell, db.AppPython, db.AppPulumi,
+	} {
+		util.Config.Apps[string(app)] = util.App{Active: true}
+	}
+
+	if err := db.Migrate(store); err != nil {
+		t.Fatal(err)
+	}
+
+	admin, err := store.CreateUser(db.UserWithPwd{
+		Pwd: "password",
+		User: db.User{
+			Username: "admin",
+			Name:     "Admin",
+			Email:    "admin@example.com",
+			Admin:    true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenID := make([]byte, 32)
+	if _, err = rand.Read(tokenID); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := store.CreateAPIToken(db.APIToken{
+		ID:     strings.ToLower(base64.URLEncoding.EncodeToString(tokenID)),
+		UserID: admin.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	taskPool := tasks.CreateTaskPool(store)
+	if opts.UseRemoteRunner {
+		util.Config.UseRemoteRunner = true
+		t.Cleanup(func() {
+			util.Config.UseRemoteRunner = false
+		})
+	} else {
+		taskPool.JobFactory = func(taskRunner *tasks.TaskRunner) tasks.Job {
+			return &fakeJob{runner: taskRunner, run: opts.RunTask}
+		}
+	}
+	taskPool.DispatchInterval = 100 * time.Millisecond
+	startWS.Do(func() {
+		go sockets.StartWS()
+	})
+	go taskPool.Run()
+
+	route := api.Route()
+	route.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			context.Set(r, "store", store)
+			context.Set(r, "task_pool", &taskPool)
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	// streams of runners are served by h2c like by the real server
+	httpServer := httptest.NewServer(h2c.NewHandler(route, &http2.Server{}))
+
+	t.Cleanup(func() {
+		httpServer.Close()
+		store.Close("test")
+		_ = os.Remove(store.Filename)
+	})
+
+	return &Server{
+		URL:        httpServer.URL,
+		Token:      token.ID,
+		Admin:      admin,
+		Store:      store,
+		TaskPool:   &taskPool,
+		httpServer: httpServer,
+	}
+}
+
+// Client returns the HTTP client which sends the API token of the admin with every request.
+func (s *Server) Client() *http.Client {
+	return &http.Client{
+		Transport: &tokenTransport{token: s.Token, base: s.httpServer.Client().Transport},
+	}
+}
+
+type tokenTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(r)
+}
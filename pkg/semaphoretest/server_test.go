@@ -0,0 +1,622 @@
+package semaphoretest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/client"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/services/runners"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestServerRunsTask(t *testing.T) {
+	srv := NewServerWithOptions(t, Options{
+		RunTask: func(task db.Task, log func(msg string)) error {
+			if task.Message == "fail" {
+				return errors.New("failed by test")
+			}
+			return nil
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	c := client.New(srv.URL, srv.Token)
+
+	user, err := c.User(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, srv.Admin.ID, user.ID)
+
+	project, err := c.CreateProject(ctx, db.Project{Name: "Test"})
+	require.NoError(t, err)
+
+	p := c.Project(project.ID)
+
+	key, err := p.Keys().Create(ctx, db.AccessKey{Name: "None", Type: db.AccessKeyNone, ProjectID: &project.ID})
+	require.NoError(t, err)
+
+	repo, err := p.Repositories().Create(ctx, db.Repository{
+		Name:      "Repo",
+		ProjectID: project.ID,
+		GitURL:    "https://example.com/repo.git",
+		GitBranch: "main",
+		SSHKeyID:  key.ID,
+	})
+	require.NoError(t, err)
+
+	inv, err := p.Inventories().Create(ctx, db.Inventory{
+		Name:      "Localhost",
+		ProjectID: project.ID,
+		Type:      db.InventoryStatic,
+		Inventory: "localhost ansible_connection=local",
+		SSHKeyID:  &key.ID,
+	})
+	require.NoError(t, err)
+
+	env, err := p.Environments().Create(ctx, db.Environment{Name: "Empty", ProjectID: project.ID, JSON: "{}"})
+	require.NoError(t, err)
+
+	tpl, err := p.Templates().Create(ctx, db.Template{
+		Name:          "Ping",
+		ProjectID:     project.ID,
+		Playbook:      "ping.yml",
+		App:           db.AppAnsible,
+		RepositoryID:  repo.ID,
+		InventoryID:   &inv.ID,
+		EnvironmentID: &env.ID,
+	})
+	require.NoError(t, err)
+
+	for message, status := range map[string]task_logger.TaskStatus{
+		"":     task_logger.TaskSuccessStatus,
+		"fail": task_logger.TaskFailStatus,
+	} {
+		task, err := p.Tasks().Run(ctx, db.Task{TemplateID: tpl.ID, Message: message})
+		require.NoError(t, err)
+
+		task, err = p.Tasks().Wait(ctx, task.ID, 100*time.Millisecond)
+		require.NoError(t, err)
+		assert.Equal(t, status, task.Status)
+	}
+}
+
+func TestServerRunsCanaryDeployment(t *testing.T) {
+	srv := NewServerWithOptions(t, Options{
+		RunTask: func(task db.Task, log func(msg string)) error {
+			if task.Message == "fail" {
+				return errors.New("failed by test")
+			}
+			return nil
+		},
+	})
+
+	srv.TaskPool.HostLister = func(task db.Task, tpl db.Template) ([]string, error) {
+		return []string{"web1", "web2", "web3", "web4"}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	c := client.New(srv.URL, srv.Token)
+
+	project, err := c.CreateProject(ctx, db.Project{Name: "Canary"})
+	require.NoError(t, err)
+
+	p := c.Project(project.ID)
+
+	key, err := p.Keys().Create(ctx, db.AccessKey{Name: "None", Type: db.AccessKeyNone, ProjectID: &project.ID})
+	require.NoError(t, err)
+
+	repo, err := p.Repositories().Create(ctx, db.Repository{
+		Name:      "Repo",
+		ProjectID: project.ID,
+		GitURL:    "https://example.com/repo.git",
+		GitBranch: "main",
+		SSHKeyID:  key.ID,
+	})
+	require.NoError(t, err)
+
+	inv, err := p.Inventories().Create(ctx, db.Inventory{
+		Name:      "Web",
+		ProjectID: project.ID,
+		Type:      db.InventoryStatic,
+		Inventory: "web[1:4]",
+		SSHKeyID:  &key.ID,
+	})
+	require.NoError(t, err)
+
+	tpl, err := p.Templates().Create(ctx, db.Template{
+		Name:         "Deploy",
+		ProjectID:    project.ID,
+		Playbook:     "deploy.yml",
+		App:          db.AppAnsible,
+		RepositoryID: repo.ID,
+		InventoryID:  &inv.ID,
+	})
+	require.NoError(t, err)
+
+	batches := func(parentID int) map[db.CanaryBatch]db.Task {
+		res := make(map[db.CanaryBatch]db.Task)
+		last, err := p.Tasks().Last(ctx, 0)
+		require.NoError(t, err)
+		for _, task := range last {
+			if task.ParentTaskID != nil && *task.ParentTaskID == parentID {
+				res[task.CanaryBatch] = task.Task
+			}
+		}
+		return res
+	}
+
+	waitStatus := func(taskID int, status task_logger.TaskStatus) db.Task {
+		for {
+			task, err := p.Tasks().Get(ctx, taskID)
+			require.NoError(t, err)
+			if task.Status == status || task.Status.IsFinished() {
+				return task
+			}
+			select {
+			case <-ctx.Done():
+				t.Fatal(ctx.Err())
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+	}
+
+	parent, err := p.Tasks().Run(ctx, db.Task{
+		TemplateID: tpl.ID,
+		Params:     db.MapStringAnyField{"canary": "25%"},
+	})
+	require.NoError(t, err)
+	assert.True(t, parent.IsCanaryDeployment())
+
+	parent = waitStatus(parent.ID, task_logger.TaskWaitingConfirmation)
+	assert.Equal(t, task_logger.TaskWaitingConfirmation, parent.Status)
+
+	canary := batches(parent.ID)[db.CanaryBatchCanary]
+	assert.Equal(t, "web1", canary.Limit)
+	assert.Equal(t, task_logger.TaskSuccessStatus, canary.Status)
+	assert.NotContains(t, batches(parent.ID), db.CanaryBatchRemainder)
+
+	require.NoError(t, p.Tasks().Confirm(ctx, parent.ID))
+
+	parent, err = p.Tasks().Wait(ctx, parent.ID, 100*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, task_logger.TaskSuccessStatus, parent.Status)
+
+	remainder := batches(parent.ID)[db.CanaryBatchRemainder]
+	assert.Equal(t, "web2,web3,web4", remainder.Limit)
+	assert.Equal(t, task_logger.TaskSuccessStatus, remainder.Status)
+
+	// the remainder batch is not started if the canary batch fails
+	parent, err = p.Tasks().Run(ctx, db.Task{
+		TemplateID: tpl.ID,
+		Message:    "fail",
+		Params:     db.MapStringAnyField{"canary": "1"},
+	})
+	require.NoError(t, err)
+
+	parent, err = p.Tasks().Wait(ctx, parent.ID, 100*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, task_logger.TaskFailStatus, parent.Status)
+	assert.Len(t, batches(parent.ID), 1)
+
+	_, err = p.Tasks().Run(ctx, db.Task{
+		TemplateID: tpl.ID,
+		Params:     db.MapStringAnyField{"canary": "4"},
+	})
+	assert.Error(t, err)
+}
+
+func TestServerValidatesTemplateRefs(t *testing.T) {
+	srv := NewServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	c := client.New(srv.URL, srv.Token)
+
+	project, err := c.CreateProject(ctx, db.Project{Name: "Refs"})
+	require.NoError(t, err)
+
+	other, err := c.CreateProject(ctx, db.Project{Name: "Other"})
+	require.NoError(t, err)
+
+	p := c.Project(project.ID)
+
+	key, err := p.Keys().Create(ctx, db.AccessKey{Name: "None", Type: db.AccessKeyNone, ProjectID: &project.ID})
+	require.NoError(t, err)
+
+	token, err := p.Keys().Create(ctx, db.AccessKey{
+		Name:      "Token",
+		Type:      db.AccessKeyString,
+		ProjectID: &project.ID,
+		String:    "secret",
+	})
+	require.NoError(t, err)
+
+	repo, err := p.Repositories().Create(ctx, db.Repository{
+		Name:      "Repo",
+		ProjectID: project.ID,
+		GitURL:    "https://example.com/repo.git",
+		GitBranch: "main",
+		SSHKeyID:  key.ID,
+	})
+	require.NoError(t, err)
+
+	otherKey, err := c.Project(other.ID).Keys().Create(ctx, db.AccessKey{Name: "None", Type: db.AccessKeyNone, ProjectID: &other.ID})
+	require.NoError(t, err)
+
+	otherInv, err := c.Project(other.ID).Inventories().Create(ctx, db.Inventory{
+		Name:      "Other",
+		ProjectID: other.ID,
+		Type:      db.InventoryStatic,
+		Inventory: "localhost",
+		SSHKeyID:  &otherKey.ID,
+	})
+	require.NoError(t, err)
+
+	missingID := 1000
+
+	_, err = p.Templates().Create(ctx, db.Template{
+		Name:          "Deploy",
+		ProjectID:     project.ID,
+		Playbook:      "deploy.yml",
+		App:           db.AppAnsible,
+		RepositoryID:  repo.ID,
+		InventoryID:   &otherInv.ID,
+		EnvironmentID: &missingID,
+		Vaults: []db.TemplateVault{
+			{Type: db.TemplateVaultPassword, VaultKeyID: &token.ID},
+		},
+		SecretFiles: db.TemplateSecretFiles{
+			{KeyID: token.ID, Path: "token.txt"},
+			{KeyID: key.ID, Path: "none.txt"},
+		},
+	})
+	require.Error(t, err)
+
+	apiErr, ok := err.(*client.APIError)
+	require.True(t, ok)
+	assert.Equal(t, 400, apiErr.StatusCode)
+	assert.Equal(t, map[string]string{
+		"inventory_id":           "inventory not found in the project",
+		"environment_id":         "environment not found in the project",
+		"vaults[0].vault_key_id": "key type can not be used as vault password",
+		"secret_files[1].key_id": "only string and SSH keys can be installed as secret files",
+	}, apiErr.Fields)
+}
+
+func TestServerBlocksDeletionOfUsedObjects(t *testing.T) {
+	srv := NewServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	c := client.New(srv.URL, srv.Token)
+
+	project, err := c.CreateProject(ctx, db.Project{Name: "Refs"})
+	require.NoError(t, err)
+
+	p := c.Project(project.ID)
+
+	key, err := p.Keys().Create(ctx, db.AccessKey{Name: "None", Type: db.AccessKeyNone, ProjectID: &project.ID})
+	require.NoError(t, err)
+
+	vault, err := p.Keys().Create(ctx, db.AccessKey{
+		Name:          "Vault",
+		Type:          db.AccessKeyLoginPassword,
+		ProjectID:     &project.ID,
+		LoginPassword: db.LoginPassword{Password: "secret"},
+	})
+	require.NoError(t, err)
+
+	repo, err := p.Repositories().Create(ctx, db.Repository{
+		Name:      "Repo",
+		ProjectID: project.ID,
+		GitURL:    "https://example.com/repo.git",
+		GitBranch: "main",
+		SSHKeyID:  key.ID,
+	})
+	require.NoError(t, err)
+
+	inv, err := p.Inventories().Create(ctx, db.Inventory{
+		Name:      "Localhost",
+		ProjectID: project.ID,
+		Type:      db.InventoryStatic,
+		Inventory: "localhost",
+		SSHKeyID:  &key.ID,
+	})
+	require.NoError(t, err)
+
+	tpl, err := p.Templates().Create(ctx, db.Template{
+		Name:         "Deploy",
+		ProjectID:    project.ID,
+		Playbook:     "deploy.yml",
+		App:          db.AppAnsible,
+		RepositoryID: repo.ID,
+		InventoryID:  &inv.ID,
+		Vaults: []db.TemplateVault{
+			{Type: db.TemplateVaultPassword, VaultKeyID: &vault.ID},
+		},
+	})
+	require.NoError(t, err)
+
+	refs, err := p.Keys().Refs(ctx, vault.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []db.ObjectReferrer{{ID: tpl.ID, Name: "Deploy"}}, refs.Templates)
+
+	for name, del := range map[string]func() error{
+		"key":        func() error { return p.Keys().Delete(ctx, vault.ID) },
+		"inventory":  func() error { return p.Inventories().Delete(ctx, inv.ID) },
+		"repository": func() error { return p.Repositories().Delete(ctx, repo.ID) },
+	} {
+		err = del()
+		apiErr, ok := err.(*client.APIError)
+		require.True(t, ok, name)
+		assert.Equal(t, 400, apiErr.StatusCode, name)
+	}
+
+	_, err = p.Templates().Get(ctx, tpl.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, p.Templates().Delete(ctx, tpl.ID))
+	require.NoError(t, p.Keys().Delete(ctx, vault.ID))
+	require.NoError(t, p.Inventories().Delete(ctx, inv.ID))
+}
+
+func TestServerStreamsJobsToRunner(t *testing.T) {
+	srv := NewServerWithOptions(t, Options{UseRemoteRunner: true})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	runner, err := srv.Store.CreateRunner(db.Runner{Name: "Stream", Active: true})
+	require.NoError(t, err)
+
+	c := client.New(srv.URL, srv.Token)
+
+	project, err := c.CreateProject(ctx, db.Project{Name: "Runners"})
+	require.NoError(t, err)
+
+	p := c.Project(project.ID)
+
+	key, err := p.Keys().Create(ctx, db.AccessKey{Name: "None", Type: db.AccessKeyNone, ProjectID: &project.ID})
+	require.NoError(t, err)
+
+	repo, err := p.Repositories().Create(ctx, db.Repository{
+		Name:      "Repo",
+		ProjectID: project.ID,
+		GitURL:    "https://example.com/repo.git",
+		GitBranch: "main",
+		SSHKeyID:  key.ID,
+	})
+	require.NoError(t, err)
+
+	inv, err := p.Inventories().Create(ctx, db.Inventory{
+		Name:      "Localhost",
+		ProjectID: project.ID,
+		Type:      db.InventoryStatic,
+		Inventory: "localhost ansible_connection=local",
+		SSHKeyID:  &key.ID,
+	})
+	require.NoError(t, err)
+
+	tpl, err := p.Templates().Create(ctx, db.Template{
+		Name:         "Ping",
+		ProjectID:    project.ID,
+		Playbook:     "ping.yml",
+		App:          db.AppAnsible,
+		RepositoryID: repo.ID,
+		InventoryID:  &inv.ID,
+	})
+	require.NoError(t, err)
+
+	conn, err := grpc.NewClient(strings.TrimPrefix(srv.URL, "http://"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close() //nolint:errcheck
+
+	method := runners.StreamPath + "/" + runners.StreamServiceName + "/" + runners.StreamMethodName
+
+	// the runner is authenticated when the stream receives the first message
+	rejected, err := conn.NewStream(
+		metadata.AppendToOutgoingContext(ctx, "x-runner-token", "invalid"),
+		&runners.StreamDesc, method, grpc.CallContentSubtype("json"))
+	require.NoError(t, err)
+	require.NoError(t, rejected.SendMsg(&runners.RunnerMessage{Heartbeat: true}))
+	assert.Equal(t, codes.Unimplemented, status.Code(rejected.RecvMsg(&runners.ServerMessage{})),
+		"runner which is not found must be rejected")
+
+	stream, err := conn.NewStream(
+		metadata.AppendToOutgoingContext(ctx, "x-runner-token", runner.Token),
+		&runners.StreamDesc, method, grpc.CallContentSubtype("json"))
+	require.NoError(t, err)
+	require.NoError(t, stream.SendMsg(&runners.RunnerMessage{Heartbeat: true}))
+
+	// receive waits for the message which satisfies the condition
+	receive := func(cond func(msg *runners.ServerMessage) bool) *runners.ServerMessage {
+		for {
+			msg := &runners.ServerMessage{}
+			require.NoError(t, stream.RecvMsg(msg))
+			if cond(msg) {
+				return msg
+			}
+		}
+	}
+
+	hasNewJob := func(taskID int) func(msg *runners.ServerMessage) bool {
+		return func(msg *runners.ServerMessage) bool {
+			if msg.State == nil {
+				return false
+			}
+			for _, job := range msg.State.NewJobs {
+				if job.Task.ID == taskID {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	task, err := p.Tasks().Run(ctx, db.Task{TemplateID: tpl.ID})
+	require.NoError(t, err)
+
+	started := time.Now()
+	receive(hasNewJob(task.ID))
+	assert.Less(t, time.Since(started), 5*time.Second, "job must be pushed before the resync")
+
+	require.NoError(t, stream.SendMsg(&runners.RunnerMessage{Progress: &runners.RunnerProgress{Jobs: []runners.JobProgress{{
+		ID:         task.ID,
+		Status:     task_logger.TaskSuccessStatus,
+		LogRecords: []runners.LogRecord{{Time: time.Now(), Message: "streamed by runner"}},
+	}}}}))
+
+	task, err = p.Tasks().Wait(ctx, task.ID, 100*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, task_logger.TaskSuccessStatus, task.Status)
+
+	// the task is stopped by the user while the runner runs it
+	task, err = p.Tasks().Run(ctx, db.Task{TemplateID: tpl.ID})
+	require.NoError(t, err)
+
+	receive(hasNewJob(task.ID))
+
+	require.NoError(t, stream.SendMsg(&runners.RunnerMessage{Progress: &runners.RunnerProgress{Jobs: []runners.JobProgress{{
+		ID:     task.ID,
+		Status: task_logger.TaskRunningStatus,
+	}}}}))
+
+	require.Eventually(t, func() bool {
+		tsk := srv.TaskPool.GetTask(task.ID)
+		return tsk != nil && tsk.Task.Status == task_logger.TaskRunningStatus
+	}, 10*time.Second, 50*time.Millisecond)
+
+	require.NoError(t, p.Tasks().Stop(ctx, task.ID, false))
+
+	receive(func(msg *runners.ServerMessage) bool {
+		return len(msg.Cancel) == 1 && msg.Cancel[0] == task.ID
+	})
+
+	require.NoError(t, stream.SendMsg(&runners.RunnerMessage{Progress: &runners.RunnerProgress{Jobs: []runners.JobProgress{{
+		ID:     task.ID,
+		Status: task_logger.TaskStoppedStatus,
+	}}}}))
+
+	task, err = p.Tasks().Wait(ctx, task.ID, 100*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, task_logger.TaskStoppedStatus, task.Status)
+
+	// the remote job notices the status by polling, the task must end before the store is closed
+	require.Eventually(t, func() bool {
+		task, err = p.Tasks().Get(ctx, task.ID)
+		return err == nil && task.End != nil
+	}, 10*time.Second, 100*time.Millisecond)
+}
+
+func TestServerFollowsTaskOutputFromOffset(t *testing.T) {
+	srv := NewServerWithOptions(t, Options{
+		RunTask: func(task db.Task, log func(msg string)) error {
+			for _, line := range []string{"first", "second", "third"} {
+				log(line)
+			}
+			return nil
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	c := client.New(srv.URL, srv.Token)
+
+	project, err := c.CreateProject(ctx, db.Project{Name: "Output"})
+	require.NoError(t, err)
+
+	p := c.Project(project.ID)
+
+	key, err := p.Keys().Create(ctx, db.AccessKey{Name: "None", Type: db.AccessKeyNone, ProjectID: &project.ID})
+	require.NoError(t, err)
+
+	repo, err := p.Repositories().Create(ctx, db.Repository{
+		Name:      "Repo",
+		ProjectID: project.ID,
+		GitURL:    "https://example.com/repo.git",
+		GitBranch: "main",
+		SSHKeyID:  key.ID,
+	})
+	require.NoError(t, err)
+
+	inv, err := p.Inventories().Create(ctx, db.Inventory{
+		Name:      "Localhost",
+		ProjectID: project.ID,
+		Type:      db.InventoryStatic,
+		Inventory: "localhost ansible_connection=local",
+		SSHKeyID:  &key.ID,
+	})
+	require.NoError(t, err)
+
+	tpl, err := p.Templates().Create(ctx, db.Template{
+		Name:         "Ping",
+		ProjectID:    project.ID,
+		Playbook:     "ping.yml",
+		App:          db.AppAnsible,
+		RepositoryID: repo.ID,
+		InventoryID:  &inv.ID,
+	})
+	require.NoError(t, err)
+
+	task, err := p.Tasks().Run(ctx, db.Task{TemplateID: tpl.ID})
+	require.NoError(t, err)
+
+	// the stream is opened while the task is running and ends when it is finished
+	req, err := http.NewRequestWithContext(ctx, "GET",
+		fmt.Sprintf("%s/api/project/%d/tasks/%d/output/stream", srv.URL, project.ID, task.ID), nil)
+	require.NoError(t, err)
+
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.NoError(t, err)
+
+	output, err := p.Tasks().Output(ctx, task.ID)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(output), 3)
+	assert.Contains(t, string(body), fmt.Sprintf("id: %d\nevent: output\n", len(output)))
+	assert.Contains(t, string(body), fmt.Sprintf("event: end\ndata: {\"offset\":%d,\"status\":\"success\"}", len(output)))
+
+	rest, err := p.Tasks().OutputFrom(ctx, task.ID, len(output)-2)
+	require.NoError(t, err)
+	assert.Equal(t, output[len(output)-2:], rest)
+
+	// the client resumes the stream by the ID of the last received event
+	req, err = http.NewRequestWithContext(ctx, "GET",
+		fmt.Sprintf("%s/api/project/%d/tasks/%d/output/stream?offset=0", srv.URL, project.ID, task.ID), nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", strconv.Itoa(len(output)-1))
+
+	resp, err = srv.Client().Do(req)
+	require.NoError(t, err)
+
+	body, err = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(body), "event: output\n"))
+	assert.Contains(t, string(body), fmt.Sprintf("id: %d\n", len(output)))
+}
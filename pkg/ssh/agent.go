@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"sync"
 
 	"github.com/semaphoreui/semaphore/pkg/task_logger"
 	"golang.org/x/crypto/ssh"
@@ -13,6 +14,9 @@ import (
 type AgentKey struct {
 	Key        []byte
 	Passphrase []byte
+	// Certificate is an optional OpenSSH certificate of the key in the authorized_keys format.
+	// The key is added to the agent both with and without the certificate.
+	Certificate []byte
 }
 
 type Agent struct {
@@ -20,7 +24,38 @@ type Agent struct {
 	Logger     task_logger.Logger
 	listener   net.Listener
 	SocketFile string
-	done       chan struct{}
+	// ForwardSocket is the socket of the upstream agent, usually SSH_AUTH_SOCK of the host.
+	// If it is set, identities of the upstream agent are also served and signing
+	// requests for them are forwarded, so the keys are never stored in Semaphore.
+	ForwardSocket string
+	done          chan struct{}
+}
+
+var (
+	activeSocketsMu sync.Mutex
+	// activeSockets contains socket files of the agents of this process.
+	activeSockets = make(map[string]struct{})
+)
+
+func setSocketActive(socketFile string, active bool) {
+	activeSocketsMu.Lock()
+	defer activeSocketsMu.Unlock()
+
+	if active {
+		activeSockets[socketFile] = struct{}{}
+	} else {
+		delete(activeSockets, socketFile)
+	}
+}
+
+// IsActiveSocket returns true if the socket file is served by the agent of this process.
+// Other socket files are left by crashed processes and can be removed.
+func IsActiveSocket(socketFile string) bool {
+	activeSocketsMu.Lock()
+	defer activeSocketsMu.Unlock()
+
+	_, ok := activeSockets[socketFile]
+	return ok
 }
 
 func NewAgent() Agent {
@@ -31,28 +66,14 @@ func (a *Agent) Listen() error {
 	keyring := agent.NewKeyring()
 
 	for _, k := range a.Keys {
-		var (
-			key interface{}
-			err error
-		)
-
-		if len(k.Passphrase) == 0 {
-			key, err = ssh.ParseRawPrivateKey(k.Key)
-		} else {
-			key, err = ssh.ParseRawPrivateKeyWithPassphrase(k.Key, k.Passphrase)
-		}
-
-		if err != nil {
-			return fmt.Errorf("parsing private key: %w", err)
-		}
-
-		if err := keyring.Add(agent.AddedKey{
-			PrivateKey: key,
-		}); err != nil {
-			return fmt.Errorf("adding private key: %w", err)
+		if err := addAgentKey(keyring, k); err != nil {
+			return err
 		}
 	}
 
+	// the socket is registered before it is created, so it is never considered as orphaned
+	setSocketActive(a.SocketFile, true)
+
 	l, err := net.ListenUnix(
 		"unix",
 		&net.UnixAddr{
@@ -62,6 +83,7 @@ func (a *Agent) Listen() error {
 	)
 
 	if err != nil {
+		setSocketActive(a.SocketFile, false)
 		return fmt.Errorf("listening on socket %q: %w", a.SocketFile, err)
 	}
 
@@ -86,7 +108,22 @@ func (a *Agent) Listen() error {
 			go func(conn net.Conn) {
 				defer conn.Close()
 
-				if err := agent.ServeAgent(keyring, conn); err != nil && err != io.EOF {
+				var served agent.Agent = keyring
+
+				if a.ForwardSocket != "" {
+					upstream, err := net.Dial("unix", a.ForwardSocket)
+					if err != nil {
+						a.Logger.Logf("error connecting to forwarded SSH agent: %w", err)
+					} else {
+						defer upstream.Close()
+						served = &forwardingAgent{
+							ExtendedAgent: keyring.(agent.ExtendedAgent),
+							upstream:      agent.NewClient(upstream),
+						}
+					}
+				}
+
+				if err := agent.ServeAgent(served, conn); err != nil && err != io.EOF {
 					a.Logger.Logf("error serving SSH agent listener: %w", err)
 				}
 			}(conn)
@@ -96,7 +133,64 @@ func (a *Agent) Listen() error {
 	return nil
 }
 
+// ParseCertificate parses the OpenSSH certificate in the authorized_keys format.
+func ParseCertificate(data []byte) (*ssh.Certificate, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing certificate: %w", err)
+	}
+
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("parsing certificate: %s is not a certificate", pub.Type())
+	}
+
+	return cert, nil
+}
+
+func addAgentKey(keyring agent.Agent, k AgentKey) error {
+	var (
+		key interface{}
+		err error
+	)
+
+	if len(k.Passphrase) == 0 {
+		key, err = ssh.ParseRawPrivateKey(k.Key)
+	} else {
+		key, err = ssh.ParseRawPrivateKeyWithPassphrase(k.Key, k.Passphrase)
+	}
+
+	if err != nil {
+		return fmt.Errorf("parsing private key: %w", err)
+	}
+
+	if err = keyring.Add(agent.AddedKey{
+		PrivateKey: key,
+	}); err != nil {
+		return fmt.Errorf("adding private key: %w", err)
+	}
+
+	if len(k.Certificate) == 0 {
+		return nil
+	}
+
+	cert, err := ParseCertificate(k.Certificate)
+	if err != nil {
+		return err
+	}
+
+	if err = keyring.Add(agent.AddedKey{
+		PrivateKey:  key,
+		Certificate: cert,
+	}); err != nil {
+		return fmt.Errorf("adding certificate: %w", err)
+	}
+
+	return nil
+}
+
 func (a *Agent) Close() error {
 	close(a.done)
+	defer setSocketActive(a.SocketFile, false)
 	return a.listener.Close()
 }
@@ -0,0 +1,175 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"path"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func generateTestKey(t *testing.T) (ed25519.PrivateKey, []byte) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return priv, pem.EncodeToMemory(block)
+}
+
+func TestAgentCertificateAndForwarding(t *testing.T) {
+	dir := t.TempDir()
+
+	// upstream agent holds the identity which is not stored in Semaphore
+	upstreamKey, _ := generateTestKey(t)
+	upstreamKeyring := agent.NewKeyring()
+	if err := upstreamKeyring.Add(agent.AddedKey{PrivateKey: upstreamKey}); err != nil {
+		t.Fatal(err)
+	}
+
+	upstreamSocket := path.Join(dir, "upstream.sock")
+	l, err := net.Listen("unix", upstreamSocket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = agent.ServeAgent(upstreamKeyring, conn)
+			}()
+		}
+	}()
+
+	userKey, userKeyPEM := generateTestKey(t)
+	caKey, _ := generateTestKey(t)
+
+	userPub, err := ssh.NewPublicKey(userKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caSigner, err := ssh.NewSignerFromKey(caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert := &ssh.Certificate{
+		Key:             userPub,
+		CertType:        ssh.UserCert,
+		ValidPrincipals: []string{"semaphore"},
+		ValidBefore:     ssh.CertTimeInfinity,
+	}
+	if err = cert.SignCert(rand.Reader, caSigner); err != nil {
+		t.Fatal(err)
+	}
+
+	a := Agent{
+		Keys: []AgentKey{{
+			Key:         userKeyPEM,
+			Certificate: ssh.MarshalAuthorizedKey(cert),
+		}},
+		SocketFile:    path.Join(dir, "agent.sock"),
+		ForwardSocket: upstreamSocket,
+	}
+
+	if err = a.Listen(); err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	conn, err := net.Dial("unix", a.SocketFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	client := agent.NewClient(conn)
+
+	keys, err := client.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 3 {
+		t.Fatalf("expected user key, certificate and forwarded key, got %d keys", len(keys))
+	}
+
+	upstreamPub, err := ssh.NewPublicKey(upstreamKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := client.Sign(upstreamPub, []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = upstreamPub.Verify([]byte("data"), sig); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.Sign(userPub, []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckKey(t *testing.T) {
+	key, _ := generateTestKey(t)
+
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(key, "", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted := pem.EncodeToMemory(block)
+
+	res := CheckKey(AgentKey{Key: encrypted, Passphrase: []byte("secret")}, KeyCheckOptions{})
+	if !res.Success || !res.Encrypted || res.KeyType != ssh.KeyAlgoED25519 || len(res.Steps) != 2 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	res = CheckKey(AgentKey{Key: encrypted, Passphrase: []byte("wrong")}, KeyCheckOptions{})
+	if res.Success || res.Steps[1].Name != KeyCheckDecrypt || res.Steps[1].Message != "invalid passphrase" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	res = CheckKey(AgentKey{Key: []byte("invalid")}, KeyCheckOptions{})
+	if res.Success || len(res.Steps) != 1 || res.Steps[0].Name != KeyCheckParse || res.Steps[0].Success {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	_, otherKeyPEM := generateTestKey(t)
+	caKey, _ := generateTestKey(t)
+	caSigner, err := ssh.NewSignerFromKey(caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := &ssh.Certificate{Key: pub, CertType: ssh.UserCert, ValidBefore: ssh.CertTimeInfinity}
+	if err = cert.SignCert(rand.Reader, caSigner); err != nil {
+		t.Fatal(err)
+	}
+
+	res = CheckKey(AgentKey{Key: otherKeyPEM, Certificate: ssh.MarshalAuthorizedKey(cert)}, KeyCheckOptions{})
+	if res.Success || res.Steps[1].Name != KeyCheckCertificate {
+		t.Fatalf("unexpected result %+v", res)
+	}
+}
@@ -0,0 +1,170 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	KeyCheckParse       = "parse"
+	KeyCheckDecrypt     = "decrypt"
+	KeyCheckCertificate = "certificate"
+	KeyCheckHandshake   = "handshake"
+
+	defaultHandshakeTimeout = 10 * time.Second
+)
+
+// KeyCheckOptions describes the optional SSH handshake performed by CheckKey.
+type KeyCheckOptions struct {
+	// Host is the address of the SSH server in the form host or host:port.
+	// The handshake is skipped if it is empty.
+	Host    string
+	Login   string
+	Timeout time.Duration
+}
+
+type KeyCheckStep struct {
+	Name    string `json:"name"`
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+}
+
+// KeyCheckResult contains diagnostics of the private key. Steps are listed
+// in the order they were performed, the check stops on the first failed step.
+type KeyCheckResult struct {
+	Success     bool           `json:"success"`
+	KeyType     string         `json:"key_type,omitempty"`
+	Fingerprint string         `json:"fingerprint,omitempty"`
+	Encrypted   bool           `json:"encrypted"`
+	Steps       []KeyCheckStep `json:"steps"`
+}
+
+func (r *KeyCheckResult) addStep(name string, err error, message string) bool {
+	step := KeyCheckStep{Name: name, Success: err == nil, Message: message}
+	if err != nil {
+		step.Message = err.Error()
+	}
+	r.Steps = append(r.Steps, step)
+	return err == nil
+}
+
+// CheckKey parses and decrypts the private key, verifies its certificate
+// and optionally connects to the SSH server using the key.
+func CheckKey(k AgentKey, opts KeyCheckOptions) (res KeyCheckResult) {
+	res.Steps = []KeyCheckStep{}
+
+	key, err := ssh.ParseRawPrivateKey(k.Key)
+
+	var missingErr *ssh.PassphraseMissingError
+	if errors.As(err, &missingErr) {
+		res.Encrypted = true
+		res.addStep(KeyCheckParse, nil, "the key is encrypted")
+
+		if len(k.Passphrase) == 0 {
+			res.addStep(KeyCheckDecrypt, fmt.Errorf("the key is encrypted, but passphrase is empty"), "")
+			return
+		}
+
+		key, err = ssh.ParseRawPrivateKeyWithPassphrase(k.Key, k.Passphrase)
+		if errors.Is(err, x509.IncorrectPasswordError) {
+			err = fmt.Errorf("invalid passphrase")
+		}
+		if !res.addStep(KeyCheckDecrypt, err, "") {
+			return
+		}
+	} else if !res.addStep(KeyCheckParse, err, "") {
+		return
+	}
+
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		res.addStep(KeyCheckParse, err, "")
+		return
+	}
+
+	res.KeyType = signer.PublicKey().Type()
+	res.Fingerprint = ssh.FingerprintSHA256(signer.PublicKey())
+
+	if len(k.Certificate) > 0 {
+		var cert *ssh.Certificate
+		cert, err = checkCertificate(k.Certificate, signer.PublicKey(), time.Now())
+		if !res.addStep(KeyCheckCertificate, err, "") {
+			return
+		}
+
+		signer, err = ssh.NewCertSigner(cert, signer)
+		if err != nil {
+			res.addStep(KeyCheckCertificate, err, "")
+			return
+		}
+	}
+
+	if opts.Host != "" {
+		var hostKey string
+		hostKey, err = checkHandshake(signer, opts)
+		if !res.addStep(KeyCheckHandshake, err, "server host key "+hostKey) {
+			return
+		}
+	}
+
+	res.Success = true
+	return
+}
+
+func checkCertificate(data []byte, pub ssh.PublicKey, now time.Time) (*ssh.Certificate, error) {
+	cert, err := ParseCertificate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(cert.Key.Marshal(), pub.Marshal()) {
+		return nil, fmt.Errorf("the certificate is issued for another key")
+	}
+
+	unixNow := uint64(now.Unix())
+
+	if cert.ValidAfter != 0 && unixNow < cert.ValidAfter {
+		return nil, fmt.Errorf("the certificate is not valid yet")
+	}
+
+	if cert.ValidBefore != ssh.CertTimeInfinity && unixNow >= cert.ValidBefore {
+		return nil, fmt.Errorf("the certificate has expired")
+	}
+
+	return cert, nil
+}
+
+func checkHandshake(signer ssh.Signer, opts KeyCheckOptions) (hostKey string, err error) {
+	addr := opts.Host
+	if _, _, splitErr := net.SplitHostPort(addr); splitErr != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = defaultHandshakeTimeout
+	}
+
+	client, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User: opts.Login,
+		Auth: []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		// the host key is not verified, it is only reported to the user
+		HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			hostKey = ssh.FingerprintSHA256(key)
+			return nil
+		},
+		Timeout: timeout,
+	})
+	if err != nil {
+		return
+	}
+
+	err = client.Close()
+	return
+}
@@ -0,0 +1,75 @@
+package ssh
+
+import (
+	"bytes"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// forwardingAgent serves keys of the local keyring and keys of the upstream agent.
+// Keys can be added and removed only in the local keyring.
+type forwardingAgent struct {
+	agent.ExtendedAgent
+	upstream agent.ExtendedAgent
+}
+
+func (a *forwardingAgent) isLocal(key ssh.PublicKey) bool {
+	keys, err := a.ExtendedAgent.List()
+	if err != nil {
+		return false
+	}
+
+	wanted := key.Marshal()
+	for _, k := range keys {
+		if bytes.Equal(k.Blob, wanted) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a *forwardingAgent) List() ([]*agent.Key, error) {
+	keys, err := a.ExtendedAgent.List()
+	if err != nil {
+		return nil, err
+	}
+
+	upstreamKeys, err := a.upstream.List()
+	if err != nil {
+		// the upstream agent can be locked or gone, local keys are still usable
+		return keys, nil
+	}
+
+	return append(keys, upstreamKeys...), nil
+}
+
+func (a *forwardingAgent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	return a.SignWithFlags(key, data, 0)
+}
+
+func (a *forwardingAgent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	if a.isLocal(key) {
+		return a.ExtendedAgent.SignWithFlags(key, data, flags)
+	}
+	return a.upstream.SignWithFlags(key, data, flags)
+}
+
+func (a *forwardingAgent) Signers() ([]ssh.Signer, error) {
+	signers, err := a.ExtendedAgent.Signers()
+	if err != nil {
+		return nil, err
+	}
+
+	upstreamSigners, err := a.upstream.Signers()
+	if err != nil {
+		return signers, nil
+	}
+
+	return append(signers, upstreamSigners...), nil
+}
+
+func (a *forwardingAgent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	return nil, agent.ErrExtensionUnsupported
+}
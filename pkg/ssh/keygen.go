@@ -0,0 +1,34 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// GenerateKey creates the ephemeral ed25519 key pair. It returns the private key
+// in the OpenSSH PEM format and the public key in the authorized_keys format.
+func GenerateKey() (privateKey []byte, publicKey string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return
+	}
+
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		return nil, "", fmt.Errorf("marshaling private key: %w", err)
+	}
+
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil, "", fmt.Errorf("marshaling public key: %w", err)
+	}
+
+	privateKey = pem.EncodeToMemory(block)
+	publicKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPub)))
+	return
+}
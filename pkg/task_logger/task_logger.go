@@ -18,10 +18,14 @@ const (
 	TaskStoppedStatus       TaskStatus = "stopped"
 	TaskSuccessStatus       TaskStatus = "success"
 	TaskFailStatus          TaskStatus = "error"
+
+	// TaskPartialStatus means that the playbook succeeded on the reachable hosts,
+	// but some hosts were unreachable.
+	TaskPartialStatus TaskStatus = "partial"
 )
 
 func (s TaskStatus) IsNotifiable() bool {
-	return s == TaskSuccessStatus || s == TaskFailStatus || s == TaskWaitingConfirmation
+	return s == TaskSuccessStatus || s == TaskPartialStatus || s == TaskFailStatus || s == TaskWaitingConfirmation
 }
 
 func (s TaskStatus) Format() (res string) {
@@ -31,6 +35,8 @@ func (s TaskStatus) Format() (res string) {
 		res += "❌"
 	case TaskSuccessStatus:
 		res += "✅"
+	case TaskPartialStatus:
+		res += "🟠"
 	case TaskStoppedStatus:
 		res += "⏹️"
 	case TaskWaitingConfirmation:
@@ -42,7 +48,7 @@ func (s TaskStatus) Format() (res string) {
 }
 
 func (s TaskStatus) IsFinished() bool {
-	return s == TaskStoppedStatus || s == TaskSuccessStatus || s == TaskFailStatus
+	return s == TaskStoppedStatus || s == TaskSuccessStatus || s == TaskPartialStatus || s == TaskFailStatus
 }
 
 type StatusListener func(status TaskStatus)
@@ -0,0 +1,139 @@
+package chatops
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// Platform is the chat which sends slash commands.
+type Platform string
+
+const (
+	PlatformSlack      Platform = "slack"
+	PlatformMattermost Platform = "mattermost"
+)
+
+// slackMaxRequestAge limits the age of signed Slack requests to prevent replays.
+const slackMaxRequestAge = 5 * time.Minute
+
+var defaultConfirmTags = []string{"prod", "production"}
+
+var ErrInvalidSignature = errors.New("invalid signature of the chat request")
+
+// Request is the slash command sent by the chat.
+type Request struct {
+	Platform    Platform
+	UserID      string
+	UserName    string
+	ChannelID   string
+	Text        string
+	ResponseURL string
+	Token       string
+}
+
+// ParseRequest reads the form of the slash command. Slack and Mattermost send the same fields.
+func ParseRequest(platform Platform, body []byte) (req Request, err error) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return
+	}
+
+	req = Request{
+		Platform:    platform,
+		UserID:      form.Get("user_id"),
+		UserName:    form.Get("user_name"),
+		ChannelID:   form.Get("channel_id"),
+		Text:        strings.TrimSpace(form.Get("text")),
+		ResponseURL: form.Get("response_url"),
+		Token:       form.Get("token"),
+	}
+
+	if req.UserID == "" {
+		err = fmt.Errorf("user_id is required")
+	}
+
+	return
+}
+
+// VerifySlackSignature checks the X-Slack-Signature header of the request
+// signed by the signing secret of the Slack app.
+func VerifySlackSignature(secret string, timestamp string, body []byte, signature string, now time.Time) error {
+	if secret == "" {
+		return ErrInvalidSignature
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	age := now.Sub(time.Unix(ts, 0))
+	if age > slackMaxRequestAge || age < -slackMaxRequestAge {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// VerifyMattermostToken checks the token of the Mattermost slash command.
+func VerifyMattermostToken(expected string, token string) error {
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(token)) != 1 {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// GetUsername returns the Semaphore username of the chat user, or an empty string
+// if the user is not mapped.
+func GetUsername(config *util.ChatOpsConfig, req Request) string {
+	if username, ok := config.Users[string(req.Platform)+":"+req.UserID]; ok {
+		return username
+	}
+
+	if username, ok := config.Users[req.UserID]; ok {
+		return username
+	}
+
+	if config.MatchUsernames {
+		return req.UserName
+	}
+
+	return ""
+}
+
+// NeedsConfirmation returns true if the template has one of the tags
+// which require the confirmation of the run.
+func NeedsConfirmation(config *util.ChatOpsConfig, tags []string) bool {
+	confirmTags := config.ConfirmTags
+	if len(confirmTags) == 0 {
+		confirmTags = defaultConfirmTags
+	}
+
+	for _, tag := range tags {
+		if slices.Contains(confirmTags, strings.ToLower(tag)) {
+			return true
+		}
+	}
+
+	return false
+}
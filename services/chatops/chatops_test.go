@@ -0,0 +1,120 @@
+package chatops
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestParseCommand(t *testing.T) {
+	cmd, err := ParseCommand(`run "deploy web" env=prod version=1.2`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cmd.Name != CommandRun || cmd.Template != "deploy web" {
+		t.Fatalf("unexpected command %+v", cmd)
+	}
+
+	if cmd.Vars["env"] != "prod" || cmd.Vars["version"] != "1.2" {
+		t.Fatalf("unexpected variables %v", cmd.Vars)
+	}
+
+	if cmd, err = ParseCommand(""); err != nil || cmd.Name != CommandHelp {
+		t.Fatalf("expected help, got %+v, %v", cmd, err)
+	}
+
+	for _, text := range []string{"run", "run deploy env", "confirm", "deploy", `run "deploy`} {
+		if _, err = ParseCommand(text); err == nil {
+			t.Fatalf("expected error for %q", text)
+		}
+	}
+}
+
+func TestVerifySlackSignature(t *testing.T) {
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	body := []byte("user_id=U1&text=help")
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	signature := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	if err := VerifySlackSignature("secret", timestamp, body, signature, now); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifySlackSignature("other", timestamp, body, signature, now); err == nil {
+		t.Fatal("expected error for wrong secret")
+	}
+
+	if err := VerifySlackSignature("secret", timestamp, body, signature, now.Add(10*time.Minute)); err == nil {
+		t.Fatal("expected error for old request")
+	}
+}
+
+func TestGetUsername(t *testing.T) {
+	config := &util.ChatOpsConfig{
+		Users: map[string]string{
+			"slack:U1": "alice",
+			"U2":       "bob",
+		},
+	}
+
+	if u := GetUsername(config, Request{Platform: PlatformSlack, UserID: "U1"}); u != "alice" {
+		t.Fatalf("expected alice, got %q", u)
+	}
+
+	if u := GetUsername(config, Request{Platform: PlatformMattermost, UserID: "U1", UserName: "carol"}); u != "" {
+		t.Fatalf("expected unmapped user, got %q", u)
+	}
+
+	if u := GetUsername(config, Request{Platform: PlatformMattermost, UserID: "U2"}); u != "bob" {
+		t.Fatalf("expected bob, got %q", u)
+	}
+
+	config.MatchUsernames = true
+
+	if u := GetUsername(config, Request{Platform: PlatformMattermost, UserID: "U3", UserName: "carol"}); u != "carol" {
+		t.Fatalf("expected carol, got %q", u)
+	}
+}
+
+func TestPendingRun(t *testing.T) {
+	now := time.Now()
+
+	code, err := AddPendingRun(PendingRun{
+		Platform:   PlatformSlack,
+		ChatUserID: "U1",
+		ProjectID:  1,
+		TemplateID: 2,
+	}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := TakePendingRun(code, PlatformSlack, "U2", 1, now); ok {
+		t.Fatal("run must be confirmed by the same user")
+	}
+
+	run, ok := TakePendingRun(code, PlatformSlack, "U1", 1, now)
+	if !ok || run.TemplateID != 2 {
+		t.Fatalf("expected pending run, got %+v", run)
+	}
+
+	if _, ok = TakePendingRun(code, PlatformSlack, "U1", 1, now); ok {
+		t.Fatal("run must be confirmed once")
+	}
+
+	code, _ = AddPendingRun(PendingRun{Platform: PlatformSlack, ChatUserID: "U1", ProjectID: 1}, now)
+
+	if _, ok = TakePendingRun(code, PlatformSlack, "U1", 1, now.Add(confirmationTTL+time.Second)); ok {
+		t.Fatal("expired run must not be confirmed")
+	}
+}
@@ -0,0 +1,124 @@
+package chatops
+
+import (
+	"fmt"
+	"strings"
+)
+
+type CommandName string
+
+const (
+	CommandHelp    CommandName = "help"
+	CommandRun     CommandName = "run"
+	CommandConfirm CommandName = "confirm"
+)
+
+// Command is the parsed text of the slash command, e.g.
+// "run deploy-web env=prod" or "confirm 4f2a9c".
+type Command struct {
+	Name CommandName
+	// Template is the name of the template to run.
+	Template string
+	// Vars are the extra variables of the task.
+	Vars map[string]string
+	// Code is the confirmation code.
+	Code string
+}
+
+// ParseCommand parses the text of the slash command. The name of the template
+// can be quoted if it contains spaces: run "deploy web" env=prod.
+func ParseCommand(text string) (cmd Command, err error) {
+	words, err := splitWords(text)
+	if err != nil {
+		return
+	}
+
+	if len(words) == 0 {
+		cmd.Name = CommandHelp
+		return
+	}
+
+	cmd.Name = CommandName(strings.ToLower(words[0]))
+
+	switch cmd.Name {
+	case CommandHelp:
+	case CommandRun:
+		if len(words) < 2 {
+			err = fmt.Errorf("template name is required: run <template> [var=value ...]")
+			return
+		}
+
+		cmd.Template = words[1]
+		cmd.Vars = make(map[string]string)
+
+		for _, word := range words[2:] {
+			name, value, ok := strings.Cut(word, "=")
+			if !ok || name == "" {
+				err = fmt.Errorf("invalid variable '%s', expected var=value", word)
+				return
+			}
+			cmd.Vars[name] = value
+		}
+	case CommandConfirm:
+		if len(words) != 2 {
+			err = fmt.Errorf("confirmation code is required: confirm <code>")
+			return
+		}
+		cmd.Code = words[1]
+	default:
+		err = fmt.Errorf("unknown command '%s'", words[0])
+	}
+
+	return
+}
+
+// splitWords splits the text by spaces, quoted parts are not split.
+func splitWords(text string) (words []string, err error) {
+	var word strings.Builder
+	var quote rune
+	inWord := false
+
+	for _, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '"' || r == '\'' || r == '“' || r == '”':
+			if r == '“' {
+				quote = '”'
+			} else {
+				quote = r
+			}
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		err = fmt.Errorf("unclosed quote")
+		return
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return
+}
+
+// HelpText describes the commands.
+const HelpText = "Commands:\n" +
+	"`run <template> [var=value ...]` runs the template of the project with the extra variables\n" +
+	"`confirm <code>` confirms the run of the template which requires confirmation\n" +
+	"`help` shows this message"
@@ -0,0 +1,73 @@
+package chatops
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// confirmationTTL is the time to confirm the run.
+const confirmationTTL = 5 * time.Minute
+
+// PendingRun is the run of the template which waits for the confirmation
+// of the chat user who requested it.
+type PendingRun struct {
+	Platform   Platform
+	ChatUserID string
+	ProjectID  int
+	TemplateID int
+	Vars       map[string]string
+	Expires    time.Time
+}
+
+// confirmations keeps the pending runs in memory, they are lost on restart.
+var confirmations = struct {
+	sync.Mutex
+	runs map[string]PendingRun
+}{
+	runs: make(map[string]PendingRun),
+}
+
+// AddPendingRun stores the run and returns the code to confirm it.
+func AddPendingRun(run PendingRun, now time.Time) (string, error) {
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := hex.EncodeToString(b)
+
+	run.Expires = now.Add(confirmationTTL)
+
+	confirmations.Lock()
+	defer confirmations.Unlock()
+
+	for c, r := range confirmations.runs {
+		if now.After(r.Expires) {
+			delete(confirmations.runs, c)
+		}
+	}
+
+	confirmations.runs[code] = run
+
+	return code, nil
+}
+
+// TakePendingRun removes and returns the run confirmed by the same chat user in the same project.
+func TakePendingRun(code string, platform Platform, chatUserID string, projectID int, now time.Time) (PendingRun, bool) {
+	confirmations.Lock()
+	defer confirmations.Unlock()
+
+	run, ok := confirmations.runs[code]
+	if !ok || run.Platform != platform || run.ChatUserID != chatUserID || run.ProjectID != projectID {
+		return PendingRun{}, false
+	}
+
+	delete(confirmations.runs, code)
+
+	if now.After(run.Expires) {
+		return PendingRun{}, false
+	}
+
+	return run, true
+}
@@ -0,0 +1,108 @@
+package chatops
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// resultTimeout is the time to wait for the result of the task. Slack accepts
+	// messages to response_url for 30 minutes after the command.
+	resultTimeout      = 30 * time.Minute
+	resultPollInterval = 5 * time.Second
+	responseTimeout    = 10 * time.Second
+)
+
+type ResponseType string
+
+const (
+	// ResponseEphemeral is visible to the user who sent the command only.
+	ResponseEphemeral ResponseType = "ephemeral"
+	// ResponseInChannel is visible to all members of the channel.
+	ResponseInChannel ResponseType = "in_channel"
+)
+
+// Response is the message returned to the chat, the format is the same for Slack and Mattermost.
+type Response struct {
+	ResponseType ResponseType `json:"response_type"`
+	Text         string       `json:"text"`
+}
+
+func Ephemeral(format string, args ...any) Response {
+	return Response{ResponseType: ResponseEphemeral, Text: fmt.Sprintf(format, args...)}
+}
+
+func InChannel(format string, args ...any) Response {
+	return Response{ResponseType: ResponseInChannel, Text: fmt.Sprintf(format, args...)}
+}
+
+// PostResponse sends the delayed message to response_url of the command.
+func PostResponse(responseURL string, res Response) error {
+	body, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: responseTimeout}
+
+	resp, err := client.Post(responseURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint: errcheck
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("chat responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// TaskResultText returns the message about the finished task.
+func TaskResultText(task db.Task, tpl db.Template) string {
+	text := fmt.Sprintf("Task #%d of '%s' finished: %s", task.ID, tpl.Name, strings.ToUpper(string(task.Status)))
+	if u := task.GetUrl(); u != nil {
+		text += "\n" + *u
+	}
+	return text
+}
+
+// WatchTask waits for the end of the task and posts its result to the channel.
+// The task is polled from the store, so it can be run by any node.
+func WatchTask(store db.Store, task db.Task, tpl db.Template, responseURL string) {
+	if responseURL == "" {
+		return
+	}
+
+	deadline := time.Now().Add(resultTimeout)
+
+	for time.Now().Before(deadline) {
+		time.Sleep(resultPollInterval)
+
+		current, err := store.GetTask(task.ProjectID, task.ID)
+		if err != nil {
+			util.LogError(err)
+			return
+		}
+
+		if !current.Status.IsFinished() {
+			continue
+		}
+
+		if err = PostResponse(responseURL, InChannel("%s", TaskResultText(current, tpl))); err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"task_id":    task.ID,
+				"project_id": task.ProjectID,
+			}).Warn("Can not post the task result to the chat")
+		}
+		return
+	}
+}
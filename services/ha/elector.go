@@ -0,0 +1,148 @@
+package ha
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	leaderLockName   = "leader"
+	electionInterval = 10 * time.Second
+)
+
+// LeadershipListener is called when the node becomes the leader or loses the leadership.
+type LeadershipListener func(leader bool)
+
+// Elector elects the leader among Semaphore nodes which use the same database.
+// The leader holds the database advisory lock. If the leader node stops or loses
+// the connection, the lock is released and another node takes the leadership.
+// Without HA mode the node is always the leader.
+type Elector struct {
+	store  db.Store
+	leader atomic.Bool
+
+	mu        sync.Mutex
+	listeners []LeadershipListener
+
+	done chan struct{}
+}
+
+var defaultNodeID = getDefaultNodeID()
+
+func getDefaultNodeID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "semaphore"
+	}
+	return hostname + "-" + strconv.Itoa(os.Getpid())
+}
+
+func IsEnabled() bool {
+	return util.Config.HA != nil && util.Config.HA.Enabled
+}
+
+// GetNodeID returns the ID of this node in the cluster.
+func GetNodeID() string {
+	if util.Config.HA != nil && util.Config.HA.NodeID != "" {
+		return util.Config.HA.NodeID
+	}
+	return defaultNodeID
+}
+
+func CreateElector(store db.Store) *Elector {
+	e := &Elector{
+		store: store,
+		done:  make(chan struct{}),
+	}
+	e.leader.Store(!IsEnabled())
+	return e
+}
+
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+func (e *Elector) AddListener(l LeadershipListener) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.listeners = append(e.listeners, l)
+}
+
+// Run makes the first election synchronously and then re-checks the leadership
+// in background until Destroy is called.
+func (e *Elector) Run() {
+	if !IsEnabled() {
+		return
+	}
+
+	if dialect, err := util.Config.GetDialect(); err == nil && dialect == util.DbDriverBolt {
+		log.Warn("HA mode requires MySQL or PostgreSQL, BoltDB can be used by a single node only")
+	}
+
+	e.elect()
+
+	go func() {
+		ticker := time.NewTicker(electionInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-e.done:
+				return
+			case <-ticker.C:
+				e.elect()
+			}
+		}
+	}()
+}
+
+func (e *Elector) Destroy() {
+	if !IsEnabled() {
+		return
+	}
+
+	close(e.done)
+
+	if e.IsLeader() {
+		if err := e.store.Unlock(leaderLockName); err != nil {
+			log.Error(err)
+		}
+		e.setLeader(false)
+	}
+}
+
+func (e *Elector) elect() {
+	leader, err := e.store.TryLock(leaderLockName)
+	if err != nil {
+		log.Error("Leader election failed: " + err.Error())
+	}
+
+	e.setLeader(leader)
+}
+
+func (e *Elector) setLeader(leader bool) {
+	if e.leader.Swap(leader) == leader {
+		return
+	}
+
+	if leader {
+		log.Info("This node became the leader")
+	} else {
+		log.Info("This node is not the leader anymore")
+	}
+
+	e.mu.Lock()
+	listeners := append([]LeadershipListener{}, e.listeners...)
+	e.mu.Unlock()
+
+	for _, l := range listeners {
+		l(leader)
+	}
+}
@@ -0,0 +1,39 @@
+package ha
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestElector(t *testing.T) {
+	store := bolt.CreateTestStore()
+
+	util.Config.HA = nil
+
+	if !CreateElector(store).IsLeader() {
+		t.Fatal("node must be the leader without HA mode")
+	}
+
+	util.Config.HA = &util.HAConfig{Enabled: true}
+	defer func() { util.Config.HA = nil }()
+
+	e := CreateElector(store)
+
+	if e.IsLeader() {
+		t.Fatal("node must not be the leader before the election")
+	}
+
+	var changes []bool
+	e.AddListener(func(leader bool) {
+		changes = append(changes, leader)
+	})
+
+	e.Run()
+	e.Destroy()
+
+	if len(changes) != 2 || !changes[0] || changes[1] {
+		t.Fatal("invalid leadership changes", changes)
+	}
+}
@@ -0,0 +1,49 @@
+package housekeeping
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	log "github.com/sirupsen/logrus"
+)
+
+// FixOrphanedReferences applies the fixes of the references whose fix is one of fixes
+// and returns the number of fixed references.
+func FixOrphanedReferences(store db.Store, refs []db.OrphanedReference, fixes ...db.OrphanFix) (fixed int, err error) {
+	for _, ref := range refs {
+		if !slices.Contains(fixes, ref.Fix) {
+			continue
+		}
+
+		if err = store.FixOrphanedReference(ref); err != nil {
+			return
+		}
+		fixed++
+	}
+
+	return
+}
+
+// checkConsistency reports rows which refer to deleted objects. The rows are not changed,
+// they are repaired or purged by `semaphore fsck` or by the API after the confirmation.
+func checkConsistency(store db.Store, _ time.Time) (res JobResult, err error) {
+	refs, err := store.GetOrphanedReferences()
+	if err != nil {
+		return
+	}
+
+	res.Counters = map[string]int{"orphaned_references": len(refs)}
+	for _, ref := range refs {
+		res.Counters["orphaned_"+string(ref.Kind)]++
+	}
+
+	res.Message = fmt.Sprintf("%d orphaned references found", len(refs))
+
+	if len(refs) > 0 {
+		log.WithField("orphaned_references", len(refs)).Warn("Database contains rows which refer to deleted objects, run `semaphore fsck` to repair them")
+	}
+
+	return
+}
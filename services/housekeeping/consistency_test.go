@@ -0,0 +1,109 @@
+package housekeeping
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+func TestCheckAndFixConsistency(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	project, err := store.CreateProject(db.Project{Name: "Legacy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, err := store.CreateTemplate(db.Template{ProjectID: project.ID, Name: "Deploy", Playbook: "deploy.yml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := store.CreateTask(db.Task{ProjectID: project.ID, TemplateID: tpl.ID, Status: task_logger.TaskSuccessStatus}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orphanedTask, err := store.CreateTask(db.Task{ProjectID: project.ID, TemplateID: tpl.ID + 100, Status: task_logger.TaskSuccessStatus}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deletedID := 1000
+
+	envKey, err := store.CreateAccessKey(db.AccessKey{Name: "token", Type: db.AccessKeyNone, ProjectID: &project.ID, EnvironmentID: &deletedID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userKey, err := store.CreateAccessKey(db.AccessKey{Name: "personal", Type: db.AccessKeyNone, ProjectID: &project.ID, UserID: &deletedID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := checkConsistency(store, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Counters["orphaned_references"] != 3 || res.Counters["orphaned_task_template"] != 1 {
+		t.Fatal("invalid number of orphaned references", res.Counters)
+	}
+
+	refs, err := store.GetOrphanedReferences()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixed, err := FixOrphanedReferences(store, refs, db.OrphanFixUnset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fixed != 1 {
+		t.Fatal("only the reference to the user must be unset", fixed)
+	}
+
+	key, err := store.GetAccessKey(project.ID, userKey.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.UserID != nil {
+		t.Fatal("reference to the deleted user must be unset")
+	}
+
+	refs, err = store.GetOrphanedReferences()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fixed, err = FixOrphanedReferences(store, refs, db.OrphanFixPurge); err != nil {
+		t.Fatal(err)
+	}
+
+	if fixed != 2 {
+		t.Fatal("orphaned task and key must be purged", fixed)
+	}
+
+	if _, err = store.GetTask(project.ID, orphanedTask.ID); err == nil {
+		t.Fatal("task of the deleted template must be removed")
+	}
+
+	if _, err = store.GetAccessKey(project.ID, envKey.ID); err == nil {
+		t.Fatal("secret of the deleted environment must be removed")
+	}
+
+	if _, err = store.GetTask(project.ID, task.ID); err != nil {
+		t.Fatal("task of the existing template must be kept")
+	}
+
+	if refs, err = store.GetOrphanedReferences(); err != nil || len(refs) != 0 {
+		t.Fatal("orphaned references must be fixed", refs, err)
+	}
+}
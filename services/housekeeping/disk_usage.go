@@ -0,0 +1,140 @@
+package housekeeping
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db_lib"
+	"github.com/semaphoreui/semaphore/services/tasks"
+)
+
+type repositoryCacheKey struct {
+	repositoryID int
+	templateID   int
+}
+
+// diskUsageOwners maps the caches and the temporary workspaces of the tmp directory to projects.
+// IDs of the Bolt database are unique only in the project, so entries which match
+// templates of several projects are not attributed.
+type diskUsageOwners struct {
+	repositories map[repositoryCacheKey][]int
+	templates    map[int][]int
+	tasks        map[int]int
+}
+
+func getDiskUsageOwners(store db.Store) (owners diskUsageOwners, err error) {
+	owners = diskUsageOwners{
+		repositories: make(map[repositoryCacheKey][]int),
+		templates:    make(map[int][]int),
+		tasks:        make(map[int]int),
+	}
+
+	projects, err := store.GetAllProjects()
+	if err != nil {
+		return
+	}
+
+	for _, project := range projects {
+		var templates []db.Template
+		templates, err = store.GetTemplates(project.ID, db.TemplateFilter{}, db.RetrieveQueryParams{})
+		if err != nil {
+			return
+		}
+
+		for _, tpl := range templates {
+			owners.templates[tpl.ID] = append(owners.templates[tpl.ID], project.ID)
+
+			key := repositoryCacheKey{repositoryID: tpl.RepositoryID, templateID: tpl.ID}
+			owners.repositories[key] = append(owners.repositories[key], project.ID)
+		}
+	}
+
+	unfinished, err := store.GetUnfinishedTasks()
+	if err != nil {
+		return
+	}
+
+	for _, task := range unfinished {
+		owners.tasks[task.ID] = task.ProjectID
+	}
+
+	return
+}
+
+func getSingleProject(projects []int) *int {
+	if len(projects) != 1 {
+		return nil
+	}
+	return &projects[0]
+}
+
+func (o diskUsageOwners) projectOf(entry db.DiskUsageEntry) *int {
+	switch entry.Kind {
+	case db.DiskUsageRepository:
+		return getSingleProject(o.repositories[repositoryCacheKey{
+			repositoryID: entry.RepositoryID,
+			templateID:   entry.TemplateID,
+		}])
+	case db.DiskUsageTemplate:
+		return getSingleProject(o.templates[entry.TemplateID])
+	case db.DiskUsageTask:
+		if projectID, ok := o.tasks[entry.TaskID]; ok {
+			return &projectID
+		}
+	}
+	return nil
+}
+
+// isDiskUsageEntryInUse returns true if the cache can be used by the running tasks.
+// Shared caches are used by all tasks.
+func isDiskUsageEntryInUse(running []*tasks.TaskRunner, entry db.DiskUsageEntry) bool {
+	for _, t := range running {
+		switch entry.Kind {
+		case db.DiskUsageShared:
+			return true
+		case db.DiskUsageRepository:
+			if t.Template.ID == entry.TemplateID && t.Template.RepositoryID == entry.RepositoryID {
+				return true
+			}
+		case db.DiskUsageTemplate:
+			if t.Template.ID == entry.TemplateID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// collectDiskUsage stores the disk consumed by the tmp directory of the server by project
+// and removes caches which exceed the limits of the disk usage config.
+func collectDiskUsage(taskPool *tasks.TaskPool) func(db.Store, time.Time) (JobResult, error) {
+	return func(store db.Store, now time.Time) (res JobResult, err error) {
+		owners, err := getDiskUsageOwners(store)
+		if err != nil {
+			return
+		}
+
+		running := taskPool.GetRunningTasks()
+
+		usage, evicted, err := db_lib.CollectDiskUsage(owners.projectOf, func(entry db.DiskUsageEntry) bool {
+			return isDiskUsageEntryInUse(running, entry)
+		}, now)
+		if err != nil {
+			return
+		}
+
+		if err = store.SetDiskUsage(nil, usage); err != nil {
+			return
+		}
+
+		var total int64
+		for _, u := range usage {
+			total += u.Size
+		}
+
+		res.Message = fmt.Sprintf("%d bytes used, %d cache entries removed", total, len(evicted))
+		res.Counters = map[string]int{"evicted_cache_entries": len(evicted)}
+		return
+	}
+}
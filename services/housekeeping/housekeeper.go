@@ -0,0 +1,203 @@
+package housekeeping
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// JobResult describes the result of the job run. Counters are summed
+// over all runs and reported in the job status.
+type JobResult struct {
+	Message  string
+	Counters map[string]int
+}
+
+type JobFunc func(store db.Store, now time.Time) (JobResult, error)
+
+type Job struct {
+	Name            string
+	DefaultSchedule string
+	// RunOnStart runs the job when Semaphore starts, in addition to the schedule.
+	RunOnStart bool
+	Run        JobFunc
+}
+
+// JobStatus describes the last run of the job.
+type JobStatus struct {
+	Name         string     `json:"name"`
+	Schedule     string     `json:"schedule"`
+	Running      bool       `json:"running"`
+	LastRun      *time.Time `json:"last_run"`
+	LastFinished *time.Time `json:"last_finished"`
+	LastResult   string     `json:"last_result"`
+	LastError    string     `json:"last_error"`
+	NextRun      *time.Time `json:"next_run"`
+	// Counters contain totals since Semaphore start.
+	Counters map[string]int64 `json:"counters"`
+}
+
+// Housekeeper runs background maintenance jobs by their schedules.
+type Housekeeper struct {
+	store db.Store
+	cron  *cron.Cron
+	jobs  map[string]Job
+
+	mu       sync.Mutex
+	statuses map[string]*JobStatus
+	entries  map[string]cron.EntryID
+}
+
+func CreateHousekeeper(store db.Store, jobs []Job) *Housekeeper {
+	h := &Housekeeper{
+		store:    store,
+		cron:     cron.New(),
+		jobs:     make(map[string]Job),
+		statuses: make(map[string]*JobStatus),
+		entries:  make(map[string]cron.EntryID),
+	}
+
+	for _, job := range jobs {
+		h.jobs[job.Name] = job
+		h.statuses[job.Name] = &JobStatus{
+			Name:     job.Name,
+			Schedule: getJobSchedule(job),
+			Counters: make(map[string]int64),
+		}
+	}
+
+	return h
+}
+
+func getJobSchedule(job Job) string {
+	if util.Config.Housekeeping != nil {
+		if schedule, ok := util.Config.Housekeeping.Schedules[job.Name]; ok && schedule != "" {
+			return schedule
+		}
+	}
+	return job.DefaultSchedule
+}
+
+// Run schedules the jobs. Invalid schedules are reported and the jobs are not run.
+func (h *Housekeeper) Run() {
+	for name, job := range h.jobs {
+		j := job
+		entryID, err := h.cron.AddFunc(h.statuses[name].Schedule, func() {
+			h.runJob(j)
+		})
+		if err != nil {
+			log.Error("Invalid schedule of housekeeping job " + name + ": " + err.Error())
+			continue
+		}
+		h.mu.Lock()
+		h.entries[name] = entryID
+		h.mu.Unlock()
+	}
+
+	for _, job := range h.jobs {
+		if job.RunOnStart {
+			go h.runJob(job)
+		}
+	}
+
+	h.cron.Start()
+}
+
+func (h *Housekeeper) Destroy() {
+	h.cron.Stop()
+}
+
+// Trigger runs the job immediately in background. It returns db.ErrNotFound
+// for unknown job and db.ErrInvalidOperation if the job is already running.
+func (h *Housekeeper) Trigger(name string) error {
+	job, ok := h.jobs[name]
+	if !ok {
+		return db.ErrNotFound
+	}
+
+	h.mu.Lock()
+	running := h.statuses[name].Running
+	h.mu.Unlock()
+
+	if running {
+		return db.ErrInvalidOperation
+	}
+
+	go h.runJob(job)
+	return nil
+}
+
+func (h *Housekeeper) runJob(job Job) {
+	now := time.Now()
+
+	h.mu.Lock()
+	status := h.statuses[job.Name]
+	if status.Running {
+		h.mu.Unlock()
+		return
+	}
+	status.Running = true
+	status.LastRun = &now
+	h.mu.Unlock()
+
+	if !h.store.PermanentConnection() {
+		h.store.Connect("housekeeping " + job.Name)
+		defer h.store.Close("housekeeping " + job.Name)
+	}
+
+	result, err := job.Run(h.store, now)
+
+	finished := time.Now()
+
+	h.mu.Lock()
+	status.Running = false
+	status.LastFinished = &finished
+	status.LastResult = result.Message
+	for name, value := range result.Counters {
+		status.Counters[name] += int64(value)
+	}
+	status.LastError = ""
+	if err != nil {
+		status.LastError = err.Error()
+	}
+	h.mu.Unlock()
+
+	if err != nil {
+		log.Error("Housekeeping job " + job.Name + " failed: " + err.Error())
+	} else if result.Message != "" {
+		log.Info("Housekeeping job " + job.Name + ": " + result.Message)
+	}
+}
+
+// GetStatuses returns statuses of all jobs sorted by name.
+func (h *Housekeeper) GetStatuses() []JobStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	res := make([]JobStatus, 0, len(h.statuses))
+
+	for name, status := range h.statuses {
+		s := *status
+		s.Counters = make(map[string]int64, len(status.Counters))
+		for k, v := range status.Counters {
+			s.Counters[k] = v
+		}
+		if entryID, ok := h.entries[name]; ok {
+			if next := h.cron.Entry(entryID).Next; !next.IsZero() {
+				s.NextRun = &next
+			}
+		}
+		res = append(res, s)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+
+	return res
+}
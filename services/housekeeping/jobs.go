@@ -0,0 +1,341 @@
+package housekeeping
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db_lib"
+	"github.com/semaphoreui/semaphore/pkg/gitcredential"
+	"github.com/semaphoreui/semaphore/pkg/ssh"
+	"github.com/semaphoreui/semaphore/services/tasks"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+const (
+	JobTaskPruning       = "task_pruning"
+	JobTmpCleanup        = "tmp_cleanup"
+	JobSSHAgentSocket    = "ssh_agent_sockets"
+	JobGitCredential     = "git_credential_sockets"
+	JobSessionExpiry     = "session_expiry"
+	JobCacheEviction     = "cache_eviction"
+	JobProjectUserExpiry = "project_user_expiry"
+	JobKeyRotation       = "key_rotation_reminders"
+	JobHostExclusion     = "host_exclusion_expiry"
+	JobIdempotencyKey    = "idempotency_key_expiry"
+	JobMetricsRollup     = "metrics_rollup"
+	JobDiskUsage         = "disk_usage"
+	JobTemplateSummaries = "template_summaries"
+	JobConsistencyCheck  = "consistency_check"
+
+	// sessionInactivityTimeout must match the session timeout of the API authentication.
+	sessionInactivityTimeout = 7 * 24 * time.Hour
+	taskPruningBatchSize     = 500
+	metricsRollupBatchSize   = 1000
+	defaultMetricsRollupDays = 30
+)
+
+var (
+	// taskTmpFileRegexp matches files and directories created in TmpPath for the task.
+	// The submatch is the task ID.
+	taskTmpFileRegexp   = regexp.MustCompile(`^(?:inventory|ssh_control)_(\d+)`)
+	sshAgentRegexp      = regexp.MustCompile(`^ssh-agent-.*\.sock$`)
+	gitCredentialRegexp = regexp.MustCompile(`^git-credential-.*\.sock$`)
+)
+
+// DefaultJobs returns all housekeeping jobs of Semaphore. Temporary files
+// of the tasks which are not in the task pool are removed on start,
+// so files left by crashes do not accumulate.
+func DefaultJobs(taskPool *tasks.TaskPool) []Job {
+	return []Job{
+		{Name: JobTaskPruning, DefaultSchedule: "30 3 * * *", Run: pruneTasks},
+		{Name: JobTmpCleanup, DefaultSchedule: "0 * * * *", RunOnStart: true, Run: func(_ db.Store, _ time.Time) (JobResult, error) {
+			return cleanupTaskTmpFiles(func(taskID int) bool {
+				return taskPool.GetTask(taskID) != nil
+			})
+		}},
+		{Name: JobSSHAgentSocket, DefaultSchedule: "*/15 * * * *", RunOnStart: true, Run: cleanupSSHAgentSockets},
+		{Name: JobGitCredential, DefaultSchedule: "*/15 * * * *", RunOnStart: true, Run: cleanupGitCredentialSockets},
+		{Name: JobSessionExpiry, DefaultSchedule: "0 4 * * *", Run: expireSessions},
+		{Name: JobCacheEviction, DefaultSchedule: "0 5 * * *", Run: evictCaches},
+		{Name: JobProjectUserExpiry, DefaultSchedule: "*/15 * * * *", RunOnStart: true, Run: expireProjectUsers},
+		{Name: JobKeyRotation, DefaultSchedule: "0 8 * * *", Run: remindKeyRotation},
+		{Name: JobHostExclusion, DefaultSchedule: "*/15 * * * *", RunOnStart: true, Run: expireHostExclusions},
+		{Name: JobIdempotencyKey, DefaultSchedule: "0 * * * *", Run: expireIdempotencyKeys},
+		{Name: JobMetricsRollup, DefaultSchedule: "15 3 * * *", Run: rollupTaskMetrics},
+		{Name: JobDiskUsage, DefaultSchedule: "*/30 * * * *", RunOnStart: true, Run: collectDiskUsage(taskPool)},
+		{Name: JobTemplateSummaries, DefaultSchedule: "45 3 * * *", RunOnStart: true, Run: rebuildTemplateSummaries},
+		{Name: JobConsistencyCheck, DefaultSchedule: "0 6 * * 0", Run: checkConsistency},
+	}
+}
+
+func pruneTasks(store db.Store, now time.Time) (res JobResult, err error) {
+	if util.Config.Housekeeping == nil || util.Config.Housekeeping.TaskRetentionDays <= 0 {
+		res.Message = "task retention is not configured"
+		return
+	}
+
+	before := now.AddDate(0, 0, -util.Config.Housekeeping.TaskRetentionDays)
+	removed := 0
+
+	defer func() {
+		res.Message = fmt.Sprintf("%d tasks removed", removed)
+		res.Counters = map[string]int{"removed_tasks": removed}
+	}()
+
+	for {
+		var taskList []db.Task
+		taskList, err = store.GetFinishedTasksCreatedBefore(before, taskPruningBatchSize)
+		if err != nil || len(taskList) == 0 {
+			return
+		}
+
+		for _, task := range taskList {
+			if err = tasks.DeleteTaskWithOutputs(store, task); err != nil {
+				return
+			}
+			removed++
+		}
+	}
+}
+
+// rollupTaskMetrics downsamples old task metrics into daily statistics, so statistics
+// are kept for years after the tasks are pruned. The days are aligned to UTC midnight,
+// so metrics of the same day are rolled up together.
+func rollupTaskMetrics(store db.Store, now time.Time) (res JobResult, err error) {
+	rollupDays := defaultMetricsRollupDays
+	retentionDays := 0
+
+	if util.Config.Housekeeping != nil {
+		if util.Config.Housekeeping.MetricsRollupDays > 0 {
+			rollupDays = util.Config.Housekeeping.MetricsRollupDays
+		}
+		retentionDays = util.Config.Housekeeping.MetricsRetentionDays
+	}
+
+	rolledUp := 0
+	removed := 0
+
+	defer func() {
+		res.Message = fmt.Sprintf("%d task metrics rolled up, %d daily statistics removed", rolledUp, removed)
+		res.Counters = map[string]int{"rolled_up_metrics": rolledUp, "removed_daily_metrics": removed}
+	}()
+
+	before := db.GetTaskMetricDay(now.AddDate(0, 0, -rollupDays))
+
+	for {
+		var metrics []db.TaskMetric
+		metrics, err = store.GetTaskMetricsBefore(before, metricsRollupBatchSize)
+		if err != nil || len(metrics) == 0 {
+			break
+		}
+
+		if err = store.RollupTaskMetrics(metrics); err != nil {
+			return
+		}
+		rolledUp += len(metrics)
+	}
+
+	if err != nil || retentionDays <= 0 {
+		return
+	}
+
+	removed, err = store.DeleteTaskMetricsDailyBefore(db.GetTaskMetricDay(now.AddDate(0, 0, -retentionDays)))
+	return
+}
+
+// removeOrphanedTmpEntries removes entries of TmpPath which match the pattern
+// and are not used.
+func removeOrphanedTmpEntries(pattern *regexp.Regexp, isUsed func(name string, match []string) bool) (removed int, err error) {
+	entries, err := os.ReadDir(util.Config.TmpPath)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		match := pattern.FindStringSubmatch(entry.Name())
+		if match == nil || isUsed(entry.Name(), match) {
+			continue
+		}
+
+		if err = os.RemoveAll(path.Join(util.Config.TmpPath, entry.Name())); err != nil {
+			return
+		}
+		removed++
+	}
+
+	return
+}
+
+func cleanupTaskTmpFiles(isTaskLive func(taskID int) bool) (res JobResult, err error) {
+	removed, err := removeOrphanedTmpEntries(taskTmpFileRegexp, func(_ string, match []string) bool {
+		taskID, convErr := strconv.Atoi(match[1])
+		return convErr != nil || isTaskLive(taskID)
+	})
+
+	res.Message = fmt.Sprintf("%d orphaned task files removed", removed)
+	res.Counters = map[string]int{"removed_files": removed}
+	return
+}
+
+func cleanupSSHAgentSockets(_ db.Store, _ time.Time) (res JobResult, err error) {
+	removed, err := removeOrphanedTmpEntries(sshAgentRegexp, func(name string, _ []string) bool {
+		return ssh.IsActiveSocket(path.Join(util.Config.TmpPath, name))
+	})
+
+	res.Message = fmt.Sprintf("%d orphaned ssh-agent sockets removed", removed)
+	res.Counters = map[string]int{"removed_sockets": removed}
+	return
+}
+
+func cleanupGitCredentialSockets(_ db.Store, _ time.Time) (res JobResult, err error) {
+	removed, err := removeOrphanedTmpEntries(gitCredentialRegexp, func(name string, _ []string) bool {
+		return gitcredential.IsActiveSocket(path.Join(util.Config.TmpPath, name))
+	})
+
+	res.Message = fmt.Sprintf("%d orphaned git credential sockets removed", removed)
+	res.Counters = map[string]int{"removed_sockets": removed}
+	return
+}
+
+func expireSessions(store db.Store, now time.Time) (res JobResult, err error) {
+	removed, err := store.DeleteInactiveSessions(now.Add(-sessionInactivityTimeout))
+	res.Message = fmt.Sprintf("%d sessions removed", removed)
+	res.Counters = map[string]int{"removed_sessions": removed}
+	return
+}
+
+func expireIdempotencyKeys(store db.Store, now time.Time) (res JobResult, err error) {
+	removed, err := store.DeleteIdempotencyKeysBefore(now.Add(-db.IdempotencyKeyTTL))
+	res.Message = fmt.Sprintf("%d expired idempotency keys removed", removed)
+	res.Counters = map[string]int{"removed_idempotency_keys": removed}
+	return
+}
+
+func evictCaches(_ db.Store, _ time.Time) (res JobResult, err error) {
+	removed, err := db_lib.EvictGalaxyCache()
+	res.Message = fmt.Sprintf("%d galaxy cache entries removed", removed)
+	res.Counters = map[string]int{"removed_cache_entries": removed}
+	return
+}
+
+// expireProjectUsers removes expired project memberships and logs the removals to the project events.
+func expireProjectUsers(store db.Store, now time.Time) (res JobResult, err error) {
+	expired, err := store.DeleteExpiredProjectUsers(now)
+
+	for _, projectUser := range expired {
+		objType := db.EventUser
+		desc := fmt.Sprintf("Membership of User ID %d expired", projectUser.UserID)
+
+		_, eventErr := store.CreateEvent(db.Event{
+			ProjectID:   &projectUser.ProjectID,
+			ObjectType:  &objType,
+			ObjectID:    &projectUser.UserID,
+			Action:      "delete",
+			Description: &desc,
+		})
+		if eventErr != nil {
+			util.LogError(eventErr)
+		}
+	}
+
+	res.Message = fmt.Sprintf("%d expired project memberships removed", len(expired))
+	res.Counters = map[string]int{"removed_project_users": len(expired)}
+	return
+}
+
+// expireHostExclusions removes expired exclusions, their hosts are already returned to the runs.
+func expireHostExclusions(store db.Store, now time.Time) (res JobResult, err error) {
+	expired, err := store.DeleteExpiredHostExclusions(now)
+
+	for _, exclusion := range expired {
+		objType := db.EventHostExclusion
+		desc := fmt.Sprintf("Exclusion of host %s expired", exclusion.Host)
+
+		_, eventErr := store.CreateEvent(db.Event{
+			ProjectID:   &exclusion.ProjectID,
+			ObjectType:  &objType,
+			ObjectID:    &exclusion.ID,
+			Action:      "delete",
+			Description: &desc,
+		})
+		if eventErr != nil {
+			util.LogError(eventErr)
+		}
+	}
+
+	res.Message = fmt.Sprintf("%d expired host exclusions removed", len(expired))
+	res.Counters = map[string]int{"removed_host_exclusions": len(expired)}
+	return
+}
+
+// remindKeyRotation alerts projects which have access keys overdue for rotation.
+func remindKeyRotation(store db.Store, now time.Time) (res JobResult, err error) {
+	projects, err := store.GetAllProjects()
+	if err != nil {
+		return
+	}
+
+	overdueKeys := 0
+	remindedProjects := 0
+
+	for _, project := range projects {
+		if project.Archived || project.KeyRotationDays <= 0 {
+			continue
+		}
+
+		var n int
+		if n, err = tasks.SendKeyRotationReminder(store, project, now); err != nil {
+			return
+		}
+
+		if n > 0 {
+			overdueKeys += n
+			remindedProjects++
+		}
+	}
+
+	res.Message = fmt.Sprintf("%d overdue access keys in %d projects", overdueKeys, remindedProjects)
+	res.Counters = map[string]int{"overdue_keys": overdueKeys, "reminded_projects": remindedProjects}
+	return
+}
+
+// rebuildTemplateSummaries makes summaries of the task history of all templates from their tasks,
+// so summaries reflect pruned tasks and the days which left the window of the statistics.
+func rebuildTemplateSummaries(store db.Store, now time.Time) (res JobResult, err error) {
+	rebuilt := 0
+
+	defer func() {
+		res.Message = fmt.Sprintf("%d template summaries rebuilt", rebuilt)
+		res.Counters = map[string]int{"rebuilt_template_summaries": rebuilt}
+	}()
+
+	projects, err := store.GetAllProjects()
+	if err != nil {
+		return
+	}
+
+	for _, project := range projects {
+		var templates []db.Template
+		templates, err = store.GetTemplates(project.ID, db.TemplateFilter{}, db.RetrieveQueryParams{})
+		if err != nil {
+			return
+		}
+
+		for _, tpl := range templates {
+			if err = tasks.RebuildTemplateSummary(store, tpl, now); err != nil {
+				return
+			}
+			rebuilt++
+		}
+	}
+
+	return
+}
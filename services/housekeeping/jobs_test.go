@@ -0,0 +1,245 @@
+package housekeeping
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestRemoveOrphanedTmpEntries(t *testing.T) {
+	util.Config = &util.ConfigType{TmpPath: t.TempDir()}
+
+	for _, name := range []string{
+		"inventory_1",
+		"inventory_2",
+		"ssh-agent-1-abcdef.sock",
+		"git-credential-abcdef.sock",
+		"repository_1_1",
+	} {
+		if err := os.WriteFile(path.Join(util.Config.TmpPath, name), []byte{}, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := cleanupTaskTmpFiles(func(taskID int) bool {
+		return taskID == 2
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Counters["removed_files"] != 1 {
+		t.Fatal("invalid number of removed files", res.Counters)
+	}
+
+	res, err = cleanupSSHAgentSockets(nil, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Counters["removed_sockets"] != 1 {
+		t.Fatal("invalid number of removed sockets", res.Counters)
+	}
+
+	res, err = cleanupGitCredentialSockets(nil, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Counters["removed_sockets"] != 1 {
+		t.Fatal("invalid number of removed git credential sockets", res.Counters)
+	}
+
+	entries, err := os.ReadDir(util.Config.TmpPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	if len(names) != 2 || names[0] != "inventory_2" || names[1] != "repository_1_1" {
+		t.Fatal("invalid remaining files", names)
+	}
+}
+
+func TestExpireProjectUsers(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	project, err := store.CreateProject(db.Project{Name: "Temporary access"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	expired := now.Add(-time.Minute)
+	expires := now.Add(time.Hour)
+
+	for _, pu := range []db.ProjectUser{
+		{ProjectID: project.ID, UserID: 1, Role: db.ProjectOwner},
+		{ProjectID: project.ID, UserID: 2, Role: db.ProjectGuest, ExpiresAt: &expires},
+		{ProjectID: project.ID, UserID: 3, Role: db.ProjectGuest, ExpiresAt: &expired},
+	} {
+		if _, err = store.CreateProjectUser(pu); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := expireProjectUsers(store, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Counters["removed_project_users"] != 1 {
+		t.Fatal("invalid number of removed memberships", res.Counters)
+	}
+
+	if _, err = store.GetProjectUser(project.ID, 3); err == nil {
+		t.Fatal("expired membership must be removed")
+	}
+
+	for _, userID := range []int{1, 2} {
+		if _, err = store.GetProjectUser(project.ID, userID); err != nil {
+			t.Fatal("active membership must be kept", userID)
+		}
+	}
+
+	events, err := store.GetEvents(project.ID, db.RetrieveQueryParams{Count: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 || *events[0].ObjectID != 3 {
+		t.Fatal("removal of the membership must be logged", events)
+	}
+}
+
+func TestExpireHostExclusions(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	project, err := store.CreateProject(db.Project{Name: "Maintenance"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	expired := now.Add(-time.Minute)
+	expires := now.Add(time.Hour)
+
+	for _, e := range []db.HostExclusion{
+		{ProjectID: project.ID, Host: "web1"},
+		{ProjectID: project.ID, Host: "web2", ExpiresAt: &expires},
+		{ProjectID: project.ID, Host: "web3", ExpiresAt: &expired},
+	} {
+		if _, err = store.CreateHostExclusion(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := expireHostExclusions(store, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Counters["removed_host_exclusions"] != 1 {
+		t.Fatal("invalid number of removed exclusions", res.Counters)
+	}
+
+	exclusions, err := store.GetHostExclusions(project.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(exclusions) != 2 {
+		t.Fatal("active exclusions must be kept", exclusions)
+	}
+
+	for _, e := range exclusions {
+		if e.Host == "web3" {
+			t.Fatal("expired exclusion must be removed")
+		}
+	}
+}
+
+func TestRollupTaskMetrics(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	util.Config = &util.ConfigType{
+		Housekeeping: &util.HousekeepingConfig{MetricsRollupDays: 30, MetricsRetentionDays: 3650},
+	}
+
+	project, err := store.CreateProject(db.Project{Name: "Statistics"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, finished := range []time.Time{
+		now.AddDate(-11, 0, 0),
+		now.AddDate(-1, 0, 0),
+		now.AddDate(-1, 0, 0).Add(time.Hour),
+		now.AddDate(0, 0, -1),
+	} {
+		_, err = store.CreateTaskMetric(db.TaskMetric{
+			ProjectID:  project.ID,
+			TemplateID: 1,
+			Status:     task_logger.TaskSuccessStatus,
+			Finished:   finished,
+			Duration:   60,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the rollup of the previous run of the job for the same day
+	err = store.RollupTaskMetrics([]db.TaskMetric{{
+		ProjectID:  project.ID,
+		TemplateID: 1,
+		Status:     task_logger.TaskFailStatus,
+		Finished:   now.AddDate(-1, 0, 0),
+		Duration:   120,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := rollupTaskMetrics(store, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Counters["rolled_up_metrics"] != 3 || res.Counters["removed_daily_metrics"] != 1 {
+		t.Fatal("unexpected counters", res.Counters)
+	}
+
+	metrics, err := store.GetTaskMetrics(project.ID, db.TaskMetricFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(metrics) != 1 {
+		t.Fatal("recent metrics must not be rolled up", metrics)
+	}
+
+	rollups, err := store.GetTaskMetricsDaily(project.ID, db.TaskMetricFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rollups) != 1 || rollups[0].TaskCount != 3 || rollups[0].FailCount != 1 || rollups[0].DurationSum != 240 || rollups[0].DurationMax != 120 {
+		t.Fatal("unexpected rollups", rollups)
+	}
+}
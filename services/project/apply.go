@@ -0,0 +1,671 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/semaphoreui/semaphore/db"
+	"gopkg.in/yaml.v3"
+)
+
+// ApplyConfig is a declarative description of projects managed as code.
+// It is reconciled with the database by Apply.
+type ApplyConfig struct {
+	Projects []ApplyProject `yaml:"projects"`
+}
+
+type ApplyProject struct {
+	Name             string `yaml:"name"`
+	Alert            bool   `yaml:"alert"`
+	MaxParallelTasks int    `yaml:"max_parallel_tasks"`
+
+	// Owner is a login of the user which becomes the owner of a created project.
+	Owner string `yaml:"owner"`
+
+	Keys         []ApplyKey         `yaml:"keys"`
+	Repositories []ApplyRepository  `yaml:"repositories"`
+	Inventories  []ApplyInventory   `yaml:"inventories"`
+	Environments []ApplyEnvironment `yaml:"environments"`
+	Templates    []ApplyTemplate    `yaml:"templates"`
+}
+
+// ApplyKey describes an access key. If no secret fields are specified,
+// the key is only referenced: it is created empty or left as is.
+type ApplyKey struct {
+	Name       string           `yaml:"name"`
+	Type       db.AccessKeyType `yaml:"type"`
+	Login      *ApplyValue      `yaml:"login"`
+	Password   *ApplyValue      `yaml:"password"`
+	PrivateKey *ApplyValue      `yaml:"private_key"`
+	Passphrase *ApplyValue      `yaml:"passphrase"`
+	String     *ApplyValue      `yaml:"string"`
+}
+
+type ApplyRepository struct {
+	Name      string `yaml:"name"`
+	GitURL    string `yaml:"git_url"`
+	GitBranch string `yaml:"git_branch"`
+	SSHKey    string `yaml:"ssh_key"`
+}
+
+type ApplyInventory struct {
+	Name      string           `yaml:"name"`
+	Type      db.InventoryType `yaml:"type"`
+	Inventory string           `yaml:"inventory"`
+	SSHKey    string           `yaml:"ssh_key"`
+	BecomeKey string           `yaml:"become_key"`
+}
+
+type ApplyEnvironment struct {
+	Name string            `yaml:"name"`
+	Vars map[string]any    `yaml:"vars"`
+	Env  map[string]string `yaml:"env"`
+}
+
+type ApplyTemplate struct {
+	Name        string         `yaml:"name"`
+	App         db.TemplateApp `yaml:"app"`
+	Playbook    string         `yaml:"playbook"`
+	Description string         `yaml:"description"`
+	Arguments   []string       `yaml:"arguments"`
+	GitBranch   string         `yaml:"git_branch"`
+	Repository  string         `yaml:"repository"`
+	Inventory   string         `yaml:"inventory"`
+	Environment string         `yaml:"environment"`
+}
+
+type ApplyAction string
+
+const (
+	ApplyCreate    ApplyAction = "create"
+	ApplyUpdate    ApplyAction = "update"
+	ApplyUnchanged ApplyAction = "unchanged"
+)
+
+// ApplyChange is a single line of the diff reported by Apply.
+type ApplyChange struct {
+	Action  ApplyAction `json:"action"`
+	Project string      `json:"project"`
+	Kind    string      `json:"kind"`
+	Name    string      `json:"name"`
+	Fields  []string    `json:"fields,omitempty"`
+}
+
+func (c ApplyChange) String() string {
+	sign := "="
+	switch c.Action {
+	case ApplyCreate:
+		sign = "+"
+	case ApplyUpdate:
+		sign = "~"
+	}
+
+	res := fmt.Sprintf("%s %s %q", sign, c.Kind, c.Name)
+	if c.Kind != "project" {
+		res += fmt.Sprintf(" in project %q", c.Project)
+	}
+	if len(c.Fields) > 0 {
+		res += " (" + strings.Join(c.Fields, ", ") + ")"
+	}
+	return res
+}
+
+// ParseApplyConfig parses YAML config.
+func ParseApplyConfig(data []byte) (*ApplyConfig, error) {
+	var config ApplyConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+type applier struct {
+	store   db.Store
+	dryRun  bool
+	changes []ApplyChange
+}
+
+type applyRefs struct {
+	project      string
+	keys         map[string]int
+	repositories map[string]int
+	inventories  map[string]int
+	environments map[string]int
+}
+
+func (r *applyRefs) lookup(kind string, refs map[string]int, name string) (*int, error) {
+	if name == "" {
+		return nil, nil
+	}
+	id, ok := refs[name]
+	if !ok {
+		return nil, fmt.Errorf("%s %q referenced in project %q does not exist", kind, name, r.project)
+	}
+	return &id, nil
+}
+
+func changed[T comparable](fields *[]string, name string, oldValue T, newValue T) {
+	if oldValue != newValue {
+		*fields = append(*fields, name)
+	}
+}
+
+func changedPtr[T comparable](fields *[]string, name string, oldValue *T, newValue *T) {
+	if oldValue == nil || newValue == nil {
+		if oldValue != newValue {
+			*fields = append(*fields, name)
+		}
+		return
+	}
+	changed(fields, name, *oldValue, *newValue)
+}
+
+func strPtr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func (a *applier) report(project string, kind string, name string, isNew bool, fields []string) {
+	action := ApplyUnchanged
+	if isNew {
+		action = ApplyCreate
+		fields = nil
+	} else if len(fields) > 0 {
+		action = ApplyUpdate
+	}
+
+	a.changes = append(a.changes, ApplyChange{
+		Action:  action,
+		Project: project,
+		Kind:    kind,
+		Name:    name,
+		Fields:  fields,
+	})
+}
+
+// Apply reconciles projects with the config and returns list of changes.
+// If dryRun is true, changes are only computed, nothing is written.
+// Objects which exist in the database but are absent in the config are kept.
+func Apply(config *ApplyConfig, store db.Store, dryRun bool) ([]ApplyChange, error) {
+	a := &applier{store: store, dryRun: dryRun}
+
+	projects, err := store.GetAllProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range config.Projects {
+		if p.Name == "" {
+			return a.changes, &db.ValidationError{Message: "project name can not be empty"}
+		}
+
+		var existing *db.Project
+		for i := range projects {
+			if projects[i].Name == p.Name {
+				existing = &projects[i]
+				break
+			}
+		}
+
+		if err = a.applyProject(p, existing); err != nil {
+			return a.changes, fmt.Errorf("project %q: %s", p.Name, err.Error())
+		}
+	}
+
+	return a.changes, nil
+}
+
+func (a *applier) applyProject(p ApplyProject, existing *db.Project) (err error) {
+	project := db.Project{}
+	isNew := existing == nil
+	if !isNew {
+		project = *existing
+	}
+
+	var fields []string
+	changed(&fields, "alert", project.Alert, p.Alert)
+	changed(&fields, "max_parallel_tasks", project.MaxParallelTasks, p.MaxParallelTasks)
+
+	project.Name = p.Name
+	project.Alert = p.Alert
+	project.MaxParallelTasks = p.MaxParallelTasks
+
+	if !a.dryRun {
+		if isNew {
+			project, err = a.store.CreateProject(project)
+			if err == nil && p.Owner != "" {
+				err = a.addOwner(project.ID, p.Owner)
+			}
+		} else if len(fields) > 0 {
+			err = a.store.UpdateProject(project)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	a.report(p.Name, "project", p.Name, isNew, fields)
+
+	refs := &applyRefs{
+		project:      p.Name,
+		keys:         make(map[string]int),
+		repositories: make(map[string]int),
+		inventories:  make(map[string]int),
+		environments: make(map[string]int),
+	}
+
+	for _, o := range p.Keys {
+		if err = a.applyKey(project.ID, refs, o); err != nil {
+			return
+		}
+	}
+
+	for _, o := range p.Repositories {
+		if err = a.applyRepository(project.ID, refs, o); err != nil {
+			return
+		}
+	}
+
+	for _, o := range p.Inventories {
+		if err = a.applyInventory(project.ID, refs, o); err != nil {
+			return
+		}
+	}
+
+	for _, o := range p.Environments {
+		if err = a.applyEnvironment(project.ID, refs, o); err != nil {
+			return
+		}
+	}
+
+	for _, o := range p.Templates {
+		if err = a.applyTemplate(project.ID, refs, o); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (a *applier) addOwner(projectID int, login string) error {
+	user, err := a.store.GetUserByLoginOrEmail(login, login)
+	if err != nil {
+		return fmt.Errorf("owner %q: %s", login, err.Error())
+	}
+
+	_, err = a.store.CreateProjectUser(db.ProjectUser{
+		ProjectID: projectID,
+		UserID:    user.ID,
+		Role:      db.ProjectOwner,
+	})
+	return err
+}
+
+func (k *ApplyKey) hasSecret() bool {
+	return !k.Login.IsEmpty() || !k.Password.IsEmpty() || !k.PrivateKey.IsEmpty() ||
+		!k.Passphrase.IsEmpty() || !k.String.IsEmpty()
+}
+
+func (k *ApplyKey) fill(key *db.AccessKey) (err error) {
+	resolve := func(v *ApplyValue) string {
+		if err != nil {
+			return ""
+		}
+		var res string
+		res, err = v.Resolve()
+		return res
+	}
+
+	switch key.Type {
+	case db.AccessKeySSH:
+		key.SshKey = db.SshKey{
+			Login:      resolve(k.Login),
+			Passphrase: resolve(k.Passphrase),
+			PrivateKey: resolve(k.PrivateKey),
+		}
+	case db.AccessKeyLoginPassword:
+		key.LoginPassword = db.LoginPassword{
+			Login:    resolve(k.Login),
+			Password: resolve(k.Password),
+		}
+	case db.AccessKeyString:
+		key.String = resolve(k.String)
+	}
+
+	return
+}
+
+func (a *applier) applyKey(projectID int, refs *applyRefs, o ApplyKey) (err error) {
+	if o.Type == "" {
+		o.Type = db.AccessKeyNone
+	}
+
+	keys, err := a.store.GetAccessKeys(projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return
+	}
+
+	existing := findEntityByName[db.AccessKey](&o.Name, keys)
+	isNew := existing == nil
+
+	key := db.AccessKey{
+		Name:      o.Name,
+		Type:      o.Type,
+		ProjectID: &projectID,
+	}
+
+	var fields []string
+
+	if o.hasSecret() || isNew {
+		if err = o.fill(&key); err != nil {
+			return fmt.Errorf("key %q: %s", o.Name, err.Error())
+		}
+	}
+
+	if !isNew {
+		key.ID = existing.ID
+
+		if o.hasSecret() {
+			old := *existing
+			if err = old.DeserializeSecret(); err != nil {
+				return
+			}
+			changed(&fields, "type", old.Type, key.Type)
+			if old.String != key.String || old.LoginPassword != key.LoginPassword || old.SshKey != key.SshKey {
+				fields = append(fields, "secret")
+			}
+			key.OverrideSecret = true
+		}
+	}
+
+	if !a.dryRun {
+		if isNew {
+			if err = db.CheckAccessKeyQuota(a.store, projectID); err != nil {
+				return
+			}
+			key, err = a.store.CreateAccessKey(key)
+		} else if len(fields) > 0 {
+			err = a.store.UpdateAccessKey(key)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	refs.keys[o.Name] = key.ID
+	a.report(refs.project, "key", o.Name, isNew, fields)
+	return
+}
+
+func (a *applier) applyRepository(projectID int, refs *applyRefs, o ApplyRepository) (err error) {
+	keyID, err := refs.lookup("key", refs.keys, o.SSHKey)
+	if err != nil {
+		return
+	}
+
+	repos, err := a.store.GetRepositories(projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return
+	}
+
+	existing := findEntityByName[db.Repository](&o.Name, repos)
+	isNew := existing == nil
+
+	repo := db.Repository{ProjectID: projectID}
+	if !isNew {
+		repo = *existing
+	}
+
+	var fields []string
+	changed(&fields, "git_url", repo.GitURL, o.GitURL)
+	changed(&fields, "git_branch", repo.GitBranch, o.GitBranch)
+
+	repo.Name = o.Name
+	repo.GitURL = o.GitURL
+	repo.GitBranch = o.GitBranch
+	if keyID != nil {
+		changed(&fields, "ssh_key", repo.SSHKeyID, *keyID)
+		repo.SSHKeyID = *keyID
+	}
+
+	if err = repo.Validate(); err != nil {
+		return
+	}
+
+	if !a.dryRun {
+		if isNew {
+			repo, err = a.store.CreateRepository(repo)
+		} else if len(fields) > 0 {
+			err = a.store.UpdateRepository(repo)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	refs.repositories[o.Name] = repo.ID
+	a.report(refs.project, "repository", o.Name, isNew, fields)
+	return
+}
+
+func (a *applier) applyInventory(projectID int, refs *applyRefs, o ApplyInventory) (err error) {
+	sshKeyID, err := refs.lookup("key", refs.keys, o.SSHKey)
+	if err != nil {
+		return
+	}
+
+	becomeKeyID, err := refs.lookup("key", refs.keys, o.BecomeKey)
+	if err != nil {
+		return
+	}
+
+	if o.Type == "" {
+		o.Type = db.InventoryStatic
+	}
+
+	inventories, err := a.store.GetInventories(projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return
+	}
+
+	existing := findEntityByName[db.Inventory](&o.Name, inventories)
+	isNew := existing == nil
+
+	inv := db.Inventory{ProjectID: projectID}
+	if !isNew {
+		inv = *existing
+	}
+
+	var fields []string
+	changed(&fields, "type", inv.Type, o.Type)
+	changed(&fields, "inventory", inv.Inventory, o.Inventory)
+	changedPtr(&fields, "ssh_key", inv.SSHKeyID, sshKeyID)
+	changedPtr(&fields, "become_key", inv.BecomeKeyID, becomeKeyID)
+
+	inv.Name = o.Name
+	inv.Type = o.Type
+	inv.Inventory = o.Inventory
+	inv.SSHKeyID = sshKeyID
+	inv.BecomeKeyID = becomeKeyID
+
+	if !a.dryRun {
+		if isNew {
+			inv, err = a.store.CreateInventory(inv)
+		} else if len(fields) > 0 {
+			err = a.store.UpdateInventory(inv)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	refs.inventories[o.Name] = inv.ID
+	a.report(refs.project, "inventory", o.Name, isNew, fields)
+	return
+}
+
+func (a *applier) applyEnvironment(projectID int, refs *applyRefs, o ApplyEnvironment) (err error) {
+	vars := o.Vars
+	if vars == nil {
+		vars = make(map[string]any)
+	}
+	varsJSON, err := json.Marshal(vars)
+	if err != nil {
+		return
+	}
+
+	envVars := o.Env
+	if envVars == nil {
+		envVars = make(map[string]string)
+	}
+	envJSON, err := json.Marshal(envVars)
+	if err != nil {
+		return
+	}
+
+	envs, err := a.store.GetEnvironments(projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return
+	}
+
+	existing := findEntityByName[db.Environment](&o.Name, envs)
+	isNew := existing == nil
+
+	env := db.Environment{ProjectID: projectID}
+	if !isNew {
+		env = *existing
+	}
+
+	var fields []string
+	if !jsonEqual(env.JSON, string(varsJSON)) {
+		fields = append(fields, "vars")
+	}
+	oldEnv := "{}"
+	if env.ENV != nil {
+		oldEnv = *env.ENV
+	}
+	if !jsonEqual(oldEnv, string(envJSON)) {
+		fields = append(fields, "env")
+	}
+
+	env.Name = o.Name
+	env.JSON = string(varsJSON)
+	envStr := string(envJSON)
+	env.ENV = &envStr
+
+	if !a.dryRun {
+		if isNew {
+			env, err = a.store.CreateEnvironment(env)
+		} else if len(fields) > 0 {
+			err = a.store.UpdateEnvironment(env)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	refs.environments[o.Name] = env.ID
+	a.report(refs.project, "environment", o.Name, isNew, fields)
+	return
+}
+
+func jsonEqual(a string, b string) bool {
+	var va, vb any
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return a == b
+	}
+	ja, _ := json.Marshal(va)
+	jb, _ := json.Marshal(vb)
+	return string(ja) == string(jb)
+}
+
+func (a *applier) applyTemplate(projectID int, refs *applyRefs, o ApplyTemplate) (err error) {
+	repoID, err := refs.lookup("repository", refs.repositories, o.Repository)
+	if err != nil {
+		return
+	}
+	if repoID == nil {
+		return fmt.Errorf("template %q: repository is required", o.Name)
+	}
+
+	invID, err := refs.lookup("inventory", refs.inventories, o.Inventory)
+	if err != nil {
+		return
+	}
+
+	envID, err := refs.lookup("environment", refs.environments, o.Environment)
+	if err != nil {
+		return
+	}
+
+	var args *string
+	if len(o.Arguments) > 0 {
+		var argsJSON []byte
+		argsJSON, err = json.Marshal(o.Arguments)
+		if err != nil {
+			return
+		}
+		args = strPtr(string(argsJSON))
+	}
+
+	templates, err := a.store.GetTemplates(projectID, db.TemplateFilter{}, db.RetrieveQueryParams{})
+	if err != nil {
+		return
+	}
+
+	existing := findEntityByName[db.Template](&o.Name, templates)
+	isNew := existing == nil
+
+	tpl := db.Template{ProjectID: projectID}
+	if !isNew {
+		// load the full template with vaults, they are rewritten on update
+		tpl, err = a.store.GetTemplate(projectID, existing.ID)
+		if err != nil {
+			return
+		}
+	}
+
+	var fields []string
+	changed(&fields, "app", tpl.App, o.App)
+	changed(&fields, "playbook", tpl.Playbook, o.Playbook)
+	changedPtr(&fields, "description", tpl.Description, strPtr(o.Description))
+	changedPtr(&fields, "arguments", tpl.Arguments, args)
+	changedPtr(&fields, "git_branch", tpl.GitBranch, strPtr(o.GitBranch))
+	changed(&fields, "repository", tpl.RepositoryID, *repoID)
+	changedPtr(&fields, "inventory", tpl.InventoryID, invID)
+	changedPtr(&fields, "environment", tpl.EnvironmentID, envID)
+
+	tpl.Name = o.Name
+	tpl.App = o.App
+	tpl.Playbook = o.Playbook
+	tpl.Description = strPtr(o.Description)
+	tpl.Arguments = args
+	tpl.GitBranch = strPtr(o.GitBranch)
+	tpl.RepositoryID = *repoID
+	tpl.InventoryID = invID
+	tpl.EnvironmentID = envID
+
+	if !a.dryRun {
+		if err = tpl.Validate(); err != nil {
+			return
+		}
+
+		if isNew {
+			if err = db.CheckTemplateQuota(a.store, tpl.ProjectID); err != nil {
+				return
+			}
+			tpl, err = a.store.CreateTemplate(tpl)
+		} else if len(fields) > 0 {
+			err = a.store.UpdateTemplate(tpl)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	a.report(refs.project, "template", o.Name, isNew, fields)
+	return
+}
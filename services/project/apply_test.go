@@ -0,0 +1,90 @@
+package project
+
+import (
+	"os"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const testApplyConfig = `
+projects:
+  - name: Infra
+    keys:
+      - name: deploy
+        type: login_password
+        login: deployer
+        password: { env: TEST_APPLY_PASSWORD }
+    repositories:
+      - name: playbooks
+        git_url: https://example.com/playbooks.git
+        git_branch: main
+        ssh_key: deploy
+    inventories:
+      - name: prod
+        inventory: "web1.example.com"
+        ssh_key: deploy
+    environments:
+      - name: default
+        vars:
+          region: eu
+    templates:
+      - name: Deploy
+        app: ansible
+        playbook: deploy.yml
+        repository: playbooks
+        inventory: prod
+        environment: default
+`
+
+func TestApply(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: "/tmp",
+	}
+
+	store := bolt.CreateTestStore()
+
+	err := os.Setenv("TEST_APPLY_PASSWORD", "secret")
+	assert.NoError(t, err)
+	defer os.Unsetenv("TEST_APPLY_PASSWORD") //nolint:errcheck
+
+	config, err := ParseApplyConfig([]byte(testApplyConfig))
+	assert.NoError(t, err)
+
+	changes, err := Apply(config, store, true)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 6)
+	projects, err := store.GetAllProjects()
+	assert.NoError(t, err)
+	assert.Len(t, projects, 0)
+
+	changes, err = Apply(config, store, false)
+	assert.NoError(t, err)
+	for _, c := range changes {
+		assert.Equal(t, ApplyCreate, c.Action)
+	}
+
+	changes, err = Apply(config, store, false)
+	assert.NoError(t, err)
+	for _, c := range changes {
+		assert.Equal(t, ApplyUnchanged, c.Action, c.String())
+	}
+
+	config.Projects[0].Templates[0].Playbook = "site.yml"
+	changes, err = Apply(config, store, false)
+	assert.NoError(t, err)
+	assert.Equal(t, ApplyUpdate, changes[5].Action)
+	assert.Equal(t, []string{"playbook"}, changes[5].Fields)
+
+	projects, err = store.GetAllProjects()
+	assert.NoError(t, err)
+	assert.Len(t, projects, 1)
+
+	templates, err := store.GetTemplates(projects[0].ID, db.TemplateFilter{}, db.RetrieveQueryParams{})
+	assert.NoError(t, err)
+	assert.Len(t, templates, 1)
+	assert.Equal(t, "site.yml", templates[0].Playbook)
+}
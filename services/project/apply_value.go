@@ -0,0 +1,108 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ApplyValue is a value of the declarative config which can be given inline
+// or read from an environment variable or HashiCorp Vault:
+//
+//	password: secret
+//	password: { env: DB_PASSWORD }
+//	password: { vault: "secret/data/db#password" }
+type ApplyValue struct {
+	Value string `yaml:"value"`
+	Env   string `yaml:"env"`
+	Vault string `yaml:"vault"`
+}
+
+func (v *ApplyValue) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		v.Value = node.Value
+		return nil
+	}
+
+	type plain ApplyValue
+	return node.Decode((*plain)(v))
+}
+
+// IsEmpty returns true if value is not specified in config.
+func (v *ApplyValue) IsEmpty() bool {
+	return v == nil || (v.Value == "" && v.Env == "" && v.Vault == "")
+}
+
+// Resolve returns the actual value.
+func (v *ApplyValue) Resolve() (string, error) {
+	if v == nil {
+		return "", nil
+	}
+
+	switch {
+	case v.Env != "":
+		res, ok := os.LookupEnv(v.Env)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", v.Env)
+		}
+		return res, nil
+	case v.Vault != "":
+		return readVaultSecret(v.Vault)
+	default:
+		return v.Value, nil
+	}
+}
+
+// readVaultSecret reads field of the secret in format "path#field".
+// Vault address and token are taken from VAULT_ADDR and VAULT_TOKEN.
+// Both KV v1 and KV v2 secret engines are supported.
+func readVaultSecret(ref string) (string, error) {
+	secretPath, field, ok := strings.Cut(ref, "#")
+	if !ok || field == "" {
+		return "", fmt.Errorf("vault reference %s must be in format path#field", ref)
+	}
+
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return "", fmt.Errorf("VAULT_ADDR is not set")
+	}
+
+	req, err := http.NewRequest("GET", strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(secretPath, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", os.Getenv("VAULT_TOKEN"))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned status %d for %s", resp.StatusCode, secretPath)
+	}
+
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+
+	data := body.Data
+	if nested, ok := data["data"].(map[string]any); ok {
+		data = nested
+	}
+
+	val, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no field %s", secretPath, field)
+	}
+
+	return fmt.Sprint(val), nil
+}
@@ -0,0 +1,808 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/semaphoreui/semaphore/db"
+	"gopkg.in/yaml.v3"
+)
+
+// ParseAWXExport parses the output of `awx export`.
+func ParseAWXExport(data []byte) (*AWXExport, error) {
+	var export AWXExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (export *AWXExport) getProjectName(options AWXImportOptions) string {
+	if options.ProjectName != "" {
+		return options.ProjectName
+	}
+
+	if options.Organization != "" {
+		return options.Organization
+	}
+
+	if len(export.Organizations) == 1 {
+		return export.Organizations[0].Name
+	}
+
+	return "AWX"
+}
+
+// Import creates a project with equivalents of the exported credentials, projects,
+// inventories, job templates and schedules, and reports objects which can not be mapped.
+// Secrets are not exported by AWX, so the imported keys must be filled manually.
+func (export *AWXExport) Import(options AWXImportOptions, store db.Store) (*AWXImportReport, error) {
+	if options.Organization != "" && len(export.Organizations) > 0 {
+		found := false
+		for _, org := range export.Organizations {
+			if org.Name == options.Organization {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, &db.ValidationError{Message: "organization " + options.Organization + " is not found in the export"}
+		}
+	}
+
+	imp := awxImporter{
+		export:       export,
+		options:      options,
+		store:        store,
+		report:       &AWXImportReport{Imported: []ImportItem{}, Warnings: []ImportItem{}, Unmapped: []ImportItem{}},
+		keys:         make(map[string]*awxKey),
+		repositories: make(map[string]db.Repository),
+		inventories:  make(map[string]*awxInventory),
+		templates:    make(map[string]bool),
+	}
+
+	if err := imp.createProject(); err != nil {
+		return nil, err
+	}
+
+	for _, step := range []func() error{
+		imp.importCredentials,
+		imp.importProjects,
+		imp.prepareInventories,
+		imp.importJobTemplates,
+		imp.importUnusedInventories,
+	} {
+		if err := step(); err != nil {
+			return imp.report, err
+		}
+	}
+
+	imp.reportUnsupported()
+
+	return imp.report, nil
+}
+
+func (imp *awxImporter) imported(kind string, name string) {
+	imp.report.Imported = append(imp.report.Imported, ImportItem{Kind: kind, Name: name})
+}
+
+func (imp *awxImporter) warning(kind string, name string, reason string) {
+	imp.report.Warnings = append(imp.report.Warnings, ImportItem{Kind: kind, Name: name, Reason: reason})
+}
+
+func (imp *awxImporter) unmapped(kind string, name string, reason string) {
+	imp.report.Unmapped = append(imp.report.Unmapped, ImportItem{Kind: kind, Name: name, Reason: reason})
+}
+
+// inOrganization returns true if the object belongs to the imported organization.
+// Objects without an organization are always imported.
+func (imp *awxImporter) inOrganization(org *AWXRef) bool {
+	return imp.options.Organization == "" || org == nil || org.Name == imp.options.Organization
+}
+
+func (imp *awxImporter) createProject() error {
+	project, err := imp.store.CreateProject(db.Project{
+		Name: imp.export.getProjectName(imp.options),
+	})
+	if err != nil {
+		return err
+	}
+
+	imp.projectID = project.ID
+	imp.report.Project = project
+
+	if imp.options.Owner != nil {
+		if _, err = imp.store.CreateProjectUser(db.ProjectUser{
+			ProjectID: project.ID,
+			UserID:    imp.options.Owner.ID,
+			Role:      db.ProjectOwner,
+		}); err != nil {
+			return err
+		}
+	}
+
+	noneKey, err := imp.store.CreateAccessKey(db.AccessKey{
+		Name:      "None",
+		Type:      db.AccessKeyNone,
+		ProjectID: &project.ID,
+	})
+	if err != nil {
+		return err
+	}
+	imp.noneKeyID = noneKey.ID
+
+	emptyEnv, err := imp.store.CreateEnvironment(db.Environment{
+		Name:      "Empty",
+		ProjectID: project.ID,
+		JSON:      "{}",
+	})
+	if err != nil {
+		return err
+	}
+	imp.emptyEnvID = emptyEnv.ID
+
+	return nil
+}
+
+// awxInput returns the value of the credential input and true if the value
+// is hidden by AWX.
+func awxInput(inputs map[string]any, name string) (string, bool) {
+	value, ok := inputs[name].(string)
+	if !ok {
+		return "", false
+	}
+	if value == awxEncrypted {
+		return "", true
+	}
+	return value, false
+}
+
+func (imp *awxImporter) createKey(key db.AccessKey, kind string) (*awxKey, error) {
+	key.ProjectID = &imp.projectID
+
+	newKey, err := imp.store.CreateAccessKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	imp.imported("key", newKey.Name)
+
+	return &awxKey{AccessKey: newKey, kind: kind}, nil
+}
+
+func (imp *awxImporter) importCredentials() error {
+	for _, cred := range imp.export.Credentials {
+		if !imp.inOrganization(cred.Organization) {
+			continue
+		}
+
+		if _, ok := imp.keys[cred.Name]; ok {
+			imp.unmapped("credential", cred.Name, "credential with the same name is already imported")
+			continue
+		}
+
+		kind := cred.CredentialType.Kind
+		key := db.AccessKey{Name: cred.Name}
+
+		login, _ := awxInput(cred.Inputs, "username")
+		var secret string
+		var hidden bool
+
+		switch kind {
+		case "ssh", "scm":
+			if _, ok := cred.Inputs["ssh_key_data"]; ok {
+				key.Type = db.AccessKeySSH
+				secret, hidden = awxInput(cred.Inputs, "ssh_key_data")
+				passphrase, passphraseHidden := awxInput(cred.Inputs, "ssh_key_unlock")
+				key.SshKey = db.SshKey{Login: login, PrivateKey: secret, Passphrase: passphrase}
+				hidden = hidden || passphraseHidden
+			} else {
+				key.Type = db.AccessKeyLoginPassword
+				secret, hidden = awxInput(cred.Inputs, "password")
+				key.LoginPassword = db.LoginPassword{Login: login, Password: secret}
+			}
+		case "vault":
+			key.Type = db.AccessKeyLoginPassword
+			secret, hidden = awxInput(cred.Inputs, "vault_password")
+			key.LoginPassword = db.LoginPassword{Password: secret}
+		default:
+			imp.unmapped("credential", cred.Name, fmt.Sprintf("credential type %s is not supported", cred.CredentialType.Name))
+			continue
+		}
+
+		if secret == "" {
+			// the key can not be stored with the login only
+			key.SshKey = db.SshKey{}
+			key.LoginPassword = db.LoginPassword{}
+		}
+
+		if hidden {
+			reason := "secret is not exported by AWX, fill the key manually"
+			if login != "" {
+				reason += " (login " + login + ")"
+			}
+			imp.warning("credential", cred.Name, reason)
+		}
+
+		newKey, err := imp.createKey(key, kind)
+		if err != nil {
+			return err
+		}
+
+		newKey.vaultID, _ = awxInput(cred.Inputs, "vault_id")
+
+		if newKey.becomeKeyID, err = imp.importBecomeKey(cred); err != nil {
+			return err
+		}
+
+		imp.keys[cred.Name] = newKey
+	}
+
+	return nil
+}
+
+// importBecomeKey creates the separate key for the become password of the machine credential.
+func (imp *awxImporter) importBecomeKey(cred AWXCredential) (*int, error) {
+	if cred.CredentialType.Kind != "ssh" {
+		return nil, nil
+	}
+
+	if _, ok := cred.Inputs["become_password"]; !ok {
+		return nil, nil
+	}
+
+	login, _ := awxInput(cred.Inputs, "become_username")
+	password, hidden := awxInput(cred.Inputs, "become_password")
+
+	key := db.AccessKey{
+		Name: cred.Name + " (become)",
+		Type: db.AccessKeyLoginPassword,
+	}
+
+	if password != "" {
+		key.LoginPassword = db.LoginPassword{Login: login, Password: password}
+	}
+
+	if hidden {
+		imp.warning("credential", cred.Name, "become password is not exported by AWX, fill the key "+key.Name+" manually")
+	}
+
+	newKey, err := imp.createKey(key, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &newKey.ID, nil
+}
+
+func (imp *awxImporter) importProjects() error {
+	for _, p := range imp.export.Projects {
+		if !imp.inOrganization(p.Organization) {
+			continue
+		}
+
+		if _, ok := imp.repositories[p.Name]; ok {
+			imp.unmapped("project", p.Name, "project with the same name is already imported")
+			continue
+		}
+
+		switch p.ScmType {
+		case "git":
+		case "":
+			imp.unmapped("project", p.Name, "manual projects are not supported")
+			continue
+		default:
+			imp.unmapped("project", p.Name, fmt.Sprintf("source control type %s is not supported", p.ScmType))
+			continue
+		}
+
+		keyID := imp.noneKeyID
+		if p.Credential != nil {
+			if key, ok := imp.keys[p.Credential.Name]; ok {
+				keyID = key.ID
+			} else {
+				imp.warning("project", p.Name, "credential "+p.Credential.Name+" is not imported")
+			}
+		}
+
+		branch := p.ScmBranch
+		if branch == "" {
+			branch = "main"
+			imp.warning("project", p.Name, "branch is not set, main is used")
+		}
+
+		repo, err := imp.store.CreateRepository(db.Repository{
+			Name:      p.Name,
+			ProjectID: imp.projectID,
+			GitURL:    p.ScmURL,
+			GitBranch: branch,
+			SSHKeyID:  keyID,
+		})
+		if err != nil {
+			return err
+		}
+
+		imp.repositories[p.Name] = repo
+		imp.imported("repository", repo.Name)
+	}
+
+	return nil
+}
+
+// parseAWXVariables parses variables of AWX objects which are stored as YAML or JSON.
+func parseAWXVariables(vars string) (map[string]any, error) {
+	if strings.TrimSpace(vars) == "" {
+		return nil, nil
+	}
+
+	var res map[string]any
+	if err := yaml.Unmarshal([]byte(vars), &res); err != nil {
+		return nil, err
+	}
+
+	if len(res) == 0 {
+		return nil, nil
+	}
+
+	return res, nil
+}
+
+type awxYamlGroup struct {
+	Vars     map[string]any            `yaml:"vars,omitempty"`
+	Hosts    map[string]map[string]any `yaml:"hosts,omitempty"`
+	Children map[string]*awxYamlGroup  `yaml:"children,omitempty"`
+}
+
+// getInventoryContent converts hosts and groups of the inventory to the YAML inventory.
+func (imp *awxImporter) getInventoryContent(inv AWXInventory) (string, error) {
+	var err error
+
+	all := &awxYamlGroup{
+		Hosts:    make(map[string]map[string]any),
+		Children: make(map[string]*awxYamlGroup),
+	}
+
+	if all.Vars, err = parseAWXVariables(inv.Variables); err != nil {
+		imp.warning("inventory", inv.Name, "variables are not imported: "+err.Error())
+	}
+
+	enabled := make(map[string]bool)
+
+	for _, host := range inv.Related.Hosts {
+		if host.Enabled != nil && !*host.Enabled {
+			imp.unmapped("host", host.Name, "host of inventory "+inv.Name+" is disabled")
+			continue
+		}
+
+		enabled[host.Name] = true
+
+		if all.Hosts[host.Name], err = parseAWXVariables(host.Variables); err != nil {
+			imp.warning("host", host.Name, "variables are not imported: "+err.Error())
+		}
+	}
+
+	for _, group := range inv.Related.Groups {
+		g := &awxYamlGroup{
+			Hosts:    make(map[string]map[string]any),
+			Children: make(map[string]*awxYamlGroup),
+		}
+
+		if g.Vars, err = parseAWXVariables(group.Variables); err != nil {
+			imp.warning("group", group.Name, "variables are not imported: "+err.Error())
+		}
+
+		for _, host := range group.Related.Hosts {
+			if enabled[host.Name] {
+				g.Hosts[host.Name] = nil
+			}
+		}
+
+		// children are defined in all.children, the reference merges them into the group
+		for _, child := range group.Related.Children {
+			g.Children[child.Name] = &awxYamlGroup{}
+		}
+
+		all.Children[group.Name] = g
+	}
+
+	content, err := yaml.Marshal(map[string]*awxYamlGroup{"all": all})
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
+func (imp *awxImporter) prepareInventories() error {
+	for _, inv := range imp.export.Inventories {
+		if !imp.inOrganization(inv.Organization) {
+			continue
+		}
+
+		if _, ok := imp.inventories[inv.Name]; ok {
+			imp.unmapped("inventory", inv.Name, "inventory with the same name is already imported")
+			continue
+		}
+
+		if inv.Kind != "" {
+			imp.unmapped("inventory", inv.Name, fmt.Sprintf("%s inventories are not supported", inv.Kind))
+			continue
+		}
+
+		for _, source := range inv.Related.InventorySources {
+			imp.unmapped("inventory_source", source.Name,
+				fmt.Sprintf("dynamic inventory source %s of inventory %s is not supported", source.Source, inv.Name))
+		}
+
+		content, err := imp.getInventoryContent(inv)
+		if err != nil {
+			return err
+		}
+
+		imp.inventories[inv.Name] = &awxInventory{
+			content: content,
+			names:   make(map[string]int),
+		}
+	}
+
+	return nil
+}
+
+// getInventory returns the inventory which uses the key of the machine credential
+// and creates it if needed.
+func (imp *awxImporter) getInventory(name string, machine *awxKey) (int, error) {
+	inv := imp.inventories[name]
+
+	keyID := imp.noneKeyID
+	keyName := ""
+	var becomeKeyID *int
+
+	if machine != nil {
+		keyID = machine.ID
+		keyName = machine.Name
+		becomeKeyID = machine.becomeKeyID
+	}
+
+	if id, ok := inv.names[keyName]; ok {
+		return id, nil
+	}
+
+	invName := name
+	if len(inv.names) > 0 {
+		suffix := keyName
+		if suffix == "" {
+			suffix = "None"
+		}
+		invName = fmt.Sprintf("%s (%s)", name, suffix)
+	}
+
+	newInv, err := imp.store.CreateInventory(db.Inventory{
+		Name:        invName,
+		ProjectID:   imp.projectID,
+		Inventory:   inv.content,
+		Type:        db.InventoryStaticYaml,
+		SSHKeyID:    &keyID,
+		BecomeKeyID: becomeKeyID,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	inv.names[keyName] = newInv.ID
+	imp.imported("inventory", newInv.Name)
+
+	return newInv.ID, nil
+}
+
+func (imp *awxImporter) importUnusedInventories() error {
+	for _, inv := range imp.export.Inventories {
+		prepared, ok := imp.inventories[inv.Name]
+		if !ok || len(prepared.names) > 0 {
+			continue
+		}
+
+		if _, err := imp.getInventory(inv.Name, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getAWXTemplateArguments converts options of the job template to arguments of ansible-playbook.
+func getAWXTemplateArguments(jt AWXJobTemplate) []string {
+	var args []string
+
+	if jt.JobType == "check" {
+		args = append(args, "--check")
+	}
+
+	if jt.DiffMode {
+		args = append(args, "--diff")
+	}
+
+	if jt.Limit != "" {
+		args = append(args, "--limit", jt.Limit)
+	}
+
+	if jt.JobTags != "" {
+		args = append(args, "--tags", jt.JobTags)
+	}
+
+	if jt.SkipTags != "" {
+		args = append(args, "--skip-tags", jt.SkipTags)
+	}
+
+	if jt.Forks > 0 {
+		args = append(args, "--forks", strconv.Itoa(jt.Forks))
+	}
+
+	if jt.Verbosity > 0 {
+		args = append(args, "-"+strings.Repeat("v", jt.Verbosity))
+	}
+
+	return args
+}
+
+func getAWXSurveyChoices(choices json.RawMessage) []string {
+	var list []string
+	if err := json.Unmarshal(choices, &list); err == nil {
+		return list
+	}
+
+	var str string
+	if err := json.Unmarshal(choices, &str); err == nil && str != "" {
+		return strings.Split(str, "\n")
+	}
+
+	return nil
+}
+
+func (imp *awxImporter) getSurveyVars(jt AWXJobTemplate) []db.SurveyVar {
+	if !jt.SurveyEnabled || jt.Related.SurveySpec == nil {
+		return nil
+	}
+
+	var vars []db.SurveyVar
+
+	for _, q := range jt.Related.SurveySpec.Spec {
+		v := db.SurveyVar{
+			Name:        q.Variable,
+			Title:       q.QuestionName,
+			Required:    q.Required,
+			Description: q.QuestionDescription,
+		}
+
+		switch q.Type {
+		case "text", "textarea", "float":
+			v.Type = db.SurveyVarType(db.SurveyVarStr)
+		case "password":
+			v.Type = db.SurveyVarType(db.SurveyVarSecret)
+		case "integer":
+			v.Type = db.SurveyVarType(db.SurveyVarInt)
+		case "multiplechoice":
+			v.Type = db.SurveyVarType(db.SurveyVarEnum)
+			for _, choice := range getAWXSurveyChoices(q.Choices) {
+				v.Values = append(v.Values, db.SurveyVarEnumValue{Name: choice, Value: choice})
+			}
+		default:
+			imp.unmapped("survey_question", q.Variable,
+				fmt.Sprintf("question of type %s of job template %s is not supported", q.Type, jt.Name))
+			continue
+		}
+
+		vars = append(vars, v)
+	}
+
+	return vars
+}
+
+func (imp *awxImporter) importJobTemplates() error {
+	for _, jt := range imp.export.JobTemplates {
+		if imp.templates[jt.Name] {
+			imp.unmapped("job_template", jt.Name, "job template with the same name is already imported")
+			continue
+		}
+
+		if jt.Project == nil {
+			imp.unmapped("job_template", jt.Name, "job template has no project")
+			continue
+		}
+
+		repo, ok := imp.repositories[jt.Project.Name]
+		if !ok {
+			if imp.inOrganization(jt.Project.Organization) {
+				imp.unmapped("job_template", jt.Name, "project "+jt.Project.Name+" is not imported")
+			}
+			continue
+		}
+
+		if jt.Inventory == nil {
+			imp.unmapped("job_template", jt.Name, "job template has no inventory")
+			continue
+		}
+
+		if _, ok = imp.inventories[jt.Inventory.Name]; !ok {
+			imp.unmapped("job_template", jt.Name, "inventory "+jt.Inventory.Name+" is not imported")
+			continue
+		}
+
+		var machine *awxKey
+		var vaults []*awxKey
+
+		for _, ref := range jt.Related.Credentials {
+			key, found := imp.keys[ref.Name]
+			switch {
+			case !found:
+				imp.warning("job_template", jt.Name, "credential "+ref.Name+" is not imported")
+			case key.kind == "ssh" && machine == nil:
+				machine = key
+			case key.kind == "vault":
+				vaults = append(vaults, key)
+			default:
+				imp.warning("job_template", jt.Name, "credential "+ref.Name+" is not attached")
+			}
+		}
+
+		if err := imp.importJobTemplate(jt, repo, machine, vaults); err != nil {
+			return err
+		}
+
+		imp.templates[jt.Name] = true
+	}
+
+	return nil
+}
+
+func (imp *awxImporter) importJobTemplate(jt AWXJobTemplate, repo db.Repository, machine *awxKey, vaults []*awxKey) error {
+	inventoryID, err := imp.getInventory(jt.Inventory.Name, machine)
+	if err != nil {
+		return err
+	}
+
+	environmentID := imp.emptyEnvID
+
+	extraVars, err := parseAWXVariables(jt.ExtraVars)
+	if err != nil {
+		imp.warning("job_template", jt.Name, "extra variables are not imported: "+err.Error())
+	}
+
+	if extraVars != nil {
+		var content []byte
+		if content, err = json.Marshal(extraVars); err != nil {
+			return err
+		}
+
+		var env db.Environment
+		if env, err = imp.store.CreateEnvironment(db.Environment{
+			Name:      jt.Name,
+			ProjectID: imp.projectID,
+			JSON:      string(content),
+		}); err != nil {
+			return err
+		}
+
+		environmentID = env.ID
+		imp.imported("environment", env.Name)
+	}
+
+	tpl := db.Template{
+		ProjectID:     imp.projectID,
+		Name:          jt.Name,
+		App:           db.AppAnsible,
+		Playbook:      jt.Playbook,
+		RepositoryID:  repo.ID,
+		InventoryID:   &inventoryID,
+		EnvironmentID: &environmentID,
+		SurveyVars:    imp.getSurveyVars(jt),
+	}
+
+	if jt.Description != "" {
+		tpl.Description = &jt.Description
+	}
+
+	if jt.ScmBranch != "" {
+		tpl.GitBranch = &jt.ScmBranch
+	}
+
+	if args := getAWXTemplateArguments(jt); len(args) > 0 {
+		content, err := json.Marshal(args)
+		if err != nil {
+			return err
+		}
+		arguments := string(content)
+		tpl.Arguments = &arguments
+	}
+
+	tpl, err = imp.store.CreateTemplate(tpl)
+	if err != nil {
+		return err
+	}
+
+	imp.imported("template", tpl.Name)
+
+	for _, vault := range vaults {
+		tplVault := db.TemplateVault{
+			ProjectID:  imp.projectID,
+			TemplateID: tpl.ID,
+			VaultKeyID: &vault.ID,
+			Type:       db.TemplateVaultPassword,
+		}
+
+		if vault.vaultID != "" {
+			name := vault.vaultID
+			tplVault.Name = &name
+		}
+
+		if _, err = imp.store.CreateTemplateVault(tplVault); err != nil {
+			return err
+		}
+	}
+
+	return imp.importSchedules(jt, tpl)
+}
+
+func (imp *awxImporter) importSchedules(jt AWXJobTemplate, tpl db.Template) error {
+	for _, s := range jt.Related.Schedules {
+		cronFormat, warnings, err := awxRruleToCron(s.Rrule)
+		if err != nil {
+			imp.unmapped("schedule", s.Name, err.Error())
+			continue
+		}
+
+		for _, w := range warnings {
+			imp.warning("schedule", s.Name, w)
+		}
+
+		if len(s.ExtraData) > 0 {
+			imp.warning("schedule", s.Name, "extra variables of the schedule are not imported")
+		}
+
+		schedule, err := imp.store.CreateSchedule(db.Schedule{
+			ProjectID:  imp.projectID,
+			TemplateID: tpl.ID,
+			Type:       db.ScheduleTypeCron,
+			CronFormat: cronFormat,
+			Name:       s.Name,
+			Active:     s.Enabled,
+		})
+		if err != nil {
+			return err
+		}
+
+		imp.imported("schedule", schedule.Name)
+	}
+
+	return nil
+}
+
+// reportUnsupported adds objects which have no equivalent in Semaphore to the report.
+func (imp *awxImporter) reportUnsupported() {
+	for _, group := range []struct {
+		kind    string
+		objects []AWXNamedObject
+		reason  string
+	}{
+		{"workflow_job_template", imp.export.WorkflowJobTemplates, "workflows are not supported"},
+		{"notification_template", imp.export.NotificationTemplates, "notifications are not supported, configure alerts of the project"},
+		{"execution_environment", imp.export.ExecutionEnvironments, "execution environments are not supported"},
+		{"team", imp.export.Teams, "teams are not imported, add members to the project"},
+		{"user", imp.export.Users, "users are not imported, add members to the project"},
+	} {
+		for _, o := range group.objects {
+			if !imp.inOrganization(o.Organization) {
+				continue
+			}
+
+			name := o.Name
+			if name == "" {
+				name = o.Username
+			}
+
+			imp.unmapped(group.kind, name, group.reason)
+		}
+	}
+}
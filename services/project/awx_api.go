@@ -0,0 +1,282 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// awxClient reads objects from the API v2 of AWX or Ansible Tower.
+type awxClient struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// awxSummary contains names of related objects. The API returns IDs of related objects,
+// their names which are used by the export are in summary_fields.
+type awxSummary struct {
+	Organization   *AWXRef  `json:"organization"`
+	CredentialType *AWXRef  `json:"credential_type"`
+	Credential     *AWXRef  `json:"credential"`
+	Credentials    []AWXRef `json:"credentials"`
+	Inventory      *AWXRef  `json:"inventory"`
+	Project        *AWXRef  `json:"project"`
+}
+
+// The API objects embed objects of the export. Fields which have the same names
+// but contain IDs or links in the API shadow the embedded fields.
+
+type awxAPICredential struct {
+	AWXCredential
+	Kind           string     `json:"kind"`
+	CredentialType int        `json:"credential_type"`
+	Organization   *int       `json:"organization"`
+	SummaryFields  awxSummary `json:"summary_fields"`
+}
+
+type awxAPIProject struct {
+	AWXProject
+	Credential    *int       `json:"credential"`
+	Organization  *int       `json:"organization"`
+	SummaryFields awxSummary `json:"summary_fields"`
+}
+
+type awxAPIInventory struct {
+	AWXInventory
+	ID            int             `json:"id"`
+	Organization  *int            `json:"organization"`
+	Related       json.RawMessage `json:"related"`
+	SummaryFields awxSummary      `json:"summary_fields"`
+}
+
+type awxAPIGroup struct {
+	AWXGroup
+	ID      int             `json:"id"`
+	Related json.RawMessage `json:"related"`
+}
+
+type awxAPIJobTemplate struct {
+	AWXJobTemplate
+	ID            int             `json:"id"`
+	Inventory     *int            `json:"inventory"`
+	Project       *int            `json:"project"`
+	Related       json.RawMessage `json:"related"`
+	SummaryFields awxSummary      `json:"summary_fields"`
+}
+
+type awxAPINamedObject struct {
+	AWXNamedObject
+	Organization  *int       `json:"organization"`
+	SummaryFields awxSummary `json:"summary_fields"`
+}
+
+type awxPage[T any] struct {
+	Next    *string `json:"next"`
+	Results []T     `json:"results"`
+}
+
+func (c *awxClient) get(path string, target any) error {
+	req, err := http.NewRequest(http.MethodGet, c.url+path, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("AWX API %s returned %s", path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+// getAWXList reads all pages of the list.
+func getAWXList[T any](c *awxClient, path string) ([]T, error) {
+	var res []T
+
+	next := &path
+	for next != nil && *next != "" {
+		var page awxPage[T]
+		if err := c.get(*next, &page); err != nil {
+			return nil, err
+		}
+		res = append(res, page.Results...)
+		next = page.Next
+	}
+
+	return res, nil
+}
+
+// FetchAWXExport reads objects from the API of AWX or Ansible Tower and
+// converts them to the format of `awx export`.
+func FetchAWXExport(url string, token string) (*AWXExport, error) {
+	c := &awxClient{
+		url:    strings.TrimSuffix(url, "/"),
+		token:  token,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	export := &AWXExport{}
+
+	var err error
+	if export.Organizations, err = getAWXList[AWXOrganization](c, "/api/v2/organizations/"); err != nil {
+		return nil, err
+	}
+
+	for _, step := range []func(*awxClient, *AWXExport) error{
+		fetchAWXCredentials,
+		fetchAWXProjects,
+		fetchAWXInventories,
+		fetchAWXJobTemplates,
+		fetchAWXUnsupported,
+	} {
+		if err = step(c, export); err != nil {
+			return nil, err
+		}
+	}
+
+	return export, nil
+}
+
+func fetchAWXCredentials(c *awxClient, export *AWXExport) error {
+	credentials, err := getAWXList[awxAPICredential](c, "/api/v2/credentials/")
+	if err != nil {
+		return err
+	}
+
+	for _, o := range credentials {
+		cred := o.AWXCredential
+		cred.Organization = o.SummaryFields.Organization
+		cred.CredentialType = AWXCredentialType{Kind: o.Kind}
+		if o.SummaryFields.CredentialType != nil {
+			cred.CredentialType.Name = o.SummaryFields.CredentialType.Name
+		}
+		export.Credentials = append(export.Credentials, cred)
+	}
+
+	return nil
+}
+
+func fetchAWXProjects(c *awxClient, export *AWXExport) error {
+	projects, err := getAWXList[awxAPIProject](c, "/api/v2/projects/")
+	if err != nil {
+		return err
+	}
+
+	for _, o := range projects {
+		p := o.AWXProject
+		p.Organization = o.SummaryFields.Organization
+		p.Credential = o.SummaryFields.Credential
+		export.Projects = append(export.Projects, p)
+	}
+
+	return nil
+}
+
+func fetchAWXInventories(c *awxClient, export *AWXExport) error {
+	inventories, err := getAWXList[awxAPIInventory](c, "/api/v2/inventories/")
+	if err != nil {
+		return err
+	}
+
+	for _, o := range inventories {
+		inv := o.AWXInventory
+		inv.Organization = o.SummaryFields.Organization
+
+		if inv.Kind == "" {
+			if inv.Related.Hosts, err = getAWXList[AWXHost](c, fmt.Sprintf("/api/v2/inventories/%d/hosts/", o.ID)); err != nil {
+				return err
+			}
+
+			var groups []awxAPIGroup
+			if groups, err = getAWXList[awxAPIGroup](c, fmt.Sprintf("/api/v2/inventories/%d/groups/", o.ID)); err != nil {
+				return err
+			}
+
+			for _, g := range groups {
+				group := g.AWXGroup
+				if group.Related.Hosts, err = getAWXList[AWXRef](c, fmt.Sprintf("/api/v2/groups/%d/hosts/", g.ID)); err != nil {
+					return err
+				}
+				if group.Related.Children, err = getAWXList[AWXRef](c, fmt.Sprintf("/api/v2/groups/%d/children/", g.ID)); err != nil {
+					return err
+				}
+				inv.Related.Groups = append(inv.Related.Groups, group)
+			}
+
+			inv.Related.InventorySources, err = getAWXList[AWXInventorySource](c, fmt.Sprintf("/api/v2/inventories/%d/inventory_sources/", o.ID))
+			if err != nil {
+				return err
+			}
+		}
+
+		export.Inventories = append(export.Inventories, inv)
+	}
+
+	return nil
+}
+
+func fetchAWXJobTemplates(c *awxClient, export *AWXExport) error {
+	templates, err := getAWXList[awxAPIJobTemplate](c, "/api/v2/job_templates/")
+	if err != nil {
+		return err
+	}
+
+	for _, o := range templates {
+		jt := o.AWXJobTemplate
+		jt.Inventory = o.SummaryFields.Inventory
+		jt.Project = o.SummaryFields.Project
+		jt.Related.Credentials = o.SummaryFields.Credentials
+
+		if jt.Related.Schedules, err = getAWXList[AWXSchedule](c, fmt.Sprintf("/api/v2/job_templates/%d/schedules/", o.ID)); err != nil {
+			return err
+		}
+
+		if jt.SurveyEnabled {
+			jt.Related.SurveySpec = &AWXSurveySpec{}
+			if err = c.get(fmt.Sprintf("/api/v2/job_templates/%d/survey_spec/", o.ID), jt.Related.SurveySpec); err != nil {
+				return err
+			}
+		}
+
+		export.JobTemplates = append(export.JobTemplates, jt)
+	}
+
+	return nil
+}
+
+// fetchAWXUnsupported reads objects which are only reported as unmapped.
+func fetchAWXUnsupported(c *awxClient, export *AWXExport) error {
+	for path, target := range map[string]*[]AWXNamedObject{
+		"/api/v2/workflow_job_templates/": &export.WorkflowJobTemplates,
+		"/api/v2/notification_templates/": &export.NotificationTemplates,
+		"/api/v2/execution_environments/": &export.ExecutionEnvironments,
+		"/api/v2/teams/":                  &export.Teams,
+		"/api/v2/users/":                  &export.Users,
+	} {
+		objects, err := getAWXList[awxAPINamedObject](c, path)
+		if err != nil {
+			return err
+		}
+
+		for _, o := range objects {
+			obj := o.AWXNamedObject
+			obj.Organization = o.SummaryFields.Organization
+			*target = append(*target, obj)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package project
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/semaphoreui/semaphore/services/schedules"
+)
+
+var awxWeekdays = map[string]int{
+	"SU": 0,
+	"MO": 1,
+	"TU": 2,
+	"WE": 3,
+	"TH": 4,
+	"FR": 5,
+	"SA": 6,
+}
+
+// parseAWXDtstart parses the DTSTART line of the rrule, e.g. DTSTART;TZID=UTC:20240101T120000.
+func parseAWXDtstart(line string) (start time.Time, tz string, err error) {
+	params, value, ok := strings.Cut(strings.TrimPrefix(line, "DTSTART"), ":")
+	if !ok {
+		err = fmt.Errorf("invalid start of the schedule %s", line)
+		return
+	}
+
+	tz = "UTC"
+	for _, param := range strings.Split(params, ";") {
+		if name, v, found := strings.Cut(param, "="); found && name == "TZID" {
+			tz = v
+		}
+	}
+
+	start, err = time.Parse("20060102T150405", strings.TrimSuffix(value, "Z"))
+	if err != nil {
+		err = fmt.Errorf("invalid start of the schedule %s", line)
+	}
+
+	return
+}
+
+// getAWXRruleInterval returns the interval of the rule which must divide the period
+// of the next cron field, e.g. 15 minutes or 6 hours.
+func getAWXRruleInterval(rule map[string]string, period int) (int, error) {
+	interval := 1
+
+	if v, ok := rule["INTERVAL"]; ok {
+		var err error
+		if interval, err = strconv.Atoi(v); err != nil || interval < 1 {
+			return 0, fmt.Errorf("invalid interval %s", v)
+		}
+	}
+
+	if period%interval != 0 {
+		return 0, fmt.Errorf("interval %d can not be expressed in cron format", interval)
+	}
+
+	return interval, nil
+}
+
+// getAWXCronStep returns the cron field which fires every interval starting from start.
+func getAWXCronStep(start int, interval int) string {
+	if interval == 1 {
+		return "*"
+	}
+	return fmt.Sprintf("%d/%d", start%interval, interval)
+}
+
+// awxRruleToCron converts the rrule of AWX schedule to the cron format.
+// Only rules which have an exact cron equivalent are converted.
+func awxRruleToCron(rrule string) (cronFormat string, warnings []string, err error) {
+	var start time.Time
+	var tz string
+	var rule map[string]string
+
+	for _, line := range strings.Fields(rrule) {
+		switch {
+		case strings.HasPrefix(line, "DTSTART"):
+			if start, tz, err = parseAWXDtstart(line); err != nil {
+				return
+			}
+		case strings.HasPrefix(line, "RRULE:"):
+			if rule != nil {
+				err = fmt.Errorf("schedules with several rules are not supported")
+				return
+			}
+			rule = make(map[string]string)
+			for _, part := range strings.Split(strings.TrimPrefix(line, "RRULE:"), ";") {
+				name, value, _ := strings.Cut(part, "=")
+				rule[name] = value
+			}
+		default:
+			err = fmt.Errorf("schedules with exclusions are not supported")
+			return
+		}
+	}
+
+	if rule == nil || start.IsZero() {
+		err = fmt.Errorf("invalid schedule rule %s", rrule)
+		return
+	}
+
+	for name := range rule {
+		switch name {
+		case "FREQ", "INTERVAL", "BYDAY", "BYMONTHDAY", "COUNT", "UNTIL", "WKST":
+		default:
+			err = fmt.Errorf("rule %s is not supported", name)
+			return
+		}
+	}
+
+	if tz != "UTC" {
+		warnings = append(warnings, "time zone "+tz+" is not converted, the schedule runs in the server time zone")
+	}
+
+	if rule["COUNT"] != "" || rule["UNTIL"] != "" {
+		warnings = append(warnings, "end of the schedule is not supported, the schedule runs until it is disabled")
+	}
+
+	if rule["BYDAY"] != "" && rule["FREQ"] != "WEEKLY" {
+		err = fmt.Errorf("days of week are supported only for weekly schedules")
+		return
+	}
+
+	if rule["BYMONTHDAY"] != "" && rule["FREQ"] != "MONTHLY" {
+		err = fmt.Errorf("days of month are supported only for monthly schedules")
+		return
+	}
+
+	minute := strconv.Itoa(start.Minute())
+	hour := strconv.Itoa(start.Hour())
+	var interval int
+
+	switch rule["FREQ"] {
+	case "MINUTELY":
+		if interval, err = getAWXRruleInterval(rule, 60); err != nil {
+			return
+		}
+		cronFormat = getAWXCronStep(start.Minute(), interval) + " * * * *"
+	case "HOURLY":
+		if interval, err = getAWXRruleInterval(rule, 24); err != nil {
+			return
+		}
+		cronFormat = minute + " " + getAWXCronStep(start.Hour(), interval) + " * * *"
+	case "DAILY":
+		if _, err = getAWXRruleInterval(rule, 1); err != nil {
+			return
+		}
+		cronFormat = minute + " " + hour + " * * *"
+	case "WEEKLY":
+		if _, err = getAWXRruleInterval(rule, 1); err != nil {
+			return
+		}
+
+		days := []string{strconv.Itoa(int(start.Weekday()))}
+		if rule["BYDAY"] != "" {
+			days = nil
+			for _, day := range strings.Split(rule["BYDAY"], ",") {
+				n, ok := awxWeekdays[day]
+				if !ok {
+					err = fmt.Errorf("day of week %s is not supported", day)
+					return
+				}
+				days = append(days, strconv.Itoa(n))
+			}
+		}
+
+		cronFormat = minute + " " + hour + " * * " + strings.Join(days, ",")
+	case "MONTHLY":
+		if _, err = getAWXRruleInterval(rule, 1); err != nil {
+			return
+		}
+
+		days := strconv.Itoa(start.Day())
+		if rule["BYMONTHDAY"] != "" {
+			for _, day := range strings.Split(rule["BYMONTHDAY"], ",") {
+				if n, convErr := strconv.Atoi(day); convErr != nil || n < 1 || n > 31 {
+					err = fmt.Errorf("day of month %s is not supported", day)
+					return
+				}
+			}
+			days = rule["BYMONTHDAY"]
+		}
+
+		cronFormat = minute + " " + hour + " " + days + " * *"
+	case "YEARLY":
+		if _, err = getAWXRruleInterval(rule, 1); err != nil {
+			return
+		}
+		cronFormat = fmt.Sprintf("%s %s %d %d *", minute, hour, start.Day(), int(start.Month()))
+	default:
+		err = fmt.Errorf("frequency %s is not supported", rule["FREQ"])
+		return
+	}
+
+	err = schedules.ValidateCronFormat(cronFormat)
+	return
+}
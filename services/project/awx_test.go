@@ -0,0 +1,197 @@
+package project
+
+import (
+	"os"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const testAWXExport = `{
+  "organizations": [{"name": "Default"}],
+  "credentials": [
+    {
+      "name": "machine",
+      "credential_type": {"name": "Machine", "kind": "ssh"},
+      "organization": {"name": "Default"},
+      "inputs": {"username": "root", "ssh_key_data": "$encrypted$", "become_password": "$encrypted$"}
+    },
+    {
+      "name": "vault",
+      "credential_type": {"name": "Vault", "kind": "vault"},
+      "organization": {"name": "Default"},
+      "inputs": {"vault_password": "$encrypted$", "vault_id": "prod"}
+    },
+    {
+      "name": "aws",
+      "credential_type": {"name": "Amazon Web Services", "kind": "cloud"},
+      "organization": {"name": "Default"},
+      "inputs": {"username": "AKIA"}
+    }
+  ],
+  "projects": [
+    {"name": "playbooks", "scm_type": "git", "scm_url": "https://example.com/playbooks.git", "scm_branch": "main", "organization": {"name": "Default"}},
+    {"name": "manual", "scm_type": "", "organization": {"name": "Default"}}
+  ],
+  "inventory": [
+    {
+      "name": "prod",
+      "variables": "---\nregion: eu",
+      "organization": {"name": "Default"},
+      "related": {
+        "hosts": [
+          {"name": "web1", "variables": "{\"ansible_host\": \"10.0.0.1\"}"},
+          {"name": "web2", "enabled": false}
+        ],
+        "groups": [
+          {"name": "web", "variables": "", "related": {"hosts": [{"name": "web1"}, {"name": "web2"}], "children": []}}
+        ],
+        "inventory_sources": [{"name": "ec2", "source": "ec2"}]
+      }
+    },
+    {"name": "smart", "kind": "smart", "organization": {"name": "Default"}}
+  ],
+  "job_templates": [
+    {
+      "name": "Deploy",
+      "job_type": "run",
+      "playbook": "deploy.yml",
+      "limit": "web",
+      "verbosity": 2,
+      "extra_vars": "---\nversion: 1",
+      "survey_enabled": true,
+      "inventory": {"name": "prod", "organization": {"name": "Default"}},
+      "project": {"name": "playbooks", "organization": {"name": "Default"}},
+      "related": {
+        "credentials": [{"name": "machine"}, {"name": "vault"}, {"name": "aws"}],
+        "schedules": [
+          {"name": "nightly", "rrule": "DTSTART;TZID=UTC:20240101T023000 RRULE:FREQ=DAILY;INTERVAL=1", "enabled": true},
+          {"name": "every other day", "rrule": "DTSTART;TZID=UTC:20240101T023000 RRULE:FREQ=DAILY;INTERVAL=2", "enabled": true}
+        ],
+        "survey_spec": {"spec": [
+          {"variable": "env", "question_name": "Environment", "type": "multiplechoice", "choices": "dev\nprod", "required": true},
+          {"variable": "hosts", "question_name": "Hosts", "type": "multiselect", "choices": ["a", "b"]}
+        ]}
+      }
+    },
+    {
+      "name": "Manual",
+      "playbook": "site.yml",
+      "inventory": {"name": "prod"},
+      "project": {"name": "manual", "organization": {"name": "Default"}}
+    }
+  ],
+  "workflow_job_templates": [{"name": "Release", "organization": {"name": "Default"}}]
+}`
+
+func findAWXItem(items []ImportItem, kind string, name string) *ImportItem {
+	for _, item := range items {
+		if item.Kind == kind && item.Name == name {
+			return &item
+		}
+	}
+	return nil
+}
+
+func TestImportAWX(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: "/tmp",
+	}
+
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	export, err := ParseAWXExport([]byte(testAWXExport))
+	assert.NoError(t, err)
+
+	report, err := export.Import(AWXImportOptions{}, store)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Default", report.Project.Name)
+
+	for _, item := range []ImportItem{
+		{Kind: "credential", Name: "aws"},
+		{Kind: "project", Name: "manual"},
+		{Kind: "inventory", Name: "smart"},
+		{Kind: "inventory_source", Name: "ec2"},
+		{Kind: "host", Name: "web2"},
+		{Kind: "job_template", Name: "Manual"},
+		{Kind: "schedule", Name: "every other day"},
+		{Kind: "survey_question", Name: "hosts"},
+		{Kind: "workflow_job_template", Name: "Release"},
+	} {
+		assert.NotNil(t, findAWXItem(report.Unmapped, item.Kind, item.Name), "%s %s must be unmapped", item.Kind, item.Name)
+	}
+
+	assert.NotNil(t, findAWXItem(report.Warnings, "credential", "machine"))
+	assert.NotNil(t, findAWXItem(report.Warnings, "job_template", "Deploy"))
+
+	templates, err := store.GetTemplates(report.Project.ID, db.TemplateFilter{}, db.RetrieveQueryParams{})
+	assert.NoError(t, err)
+	assert.Len(t, templates, 1)
+
+	tpl := templates[0]
+	assert.Equal(t, "deploy.yml", tpl.Playbook)
+	assert.Equal(t, `["--limit","web","-vv"]`, *tpl.Arguments)
+	assert.Len(t, tpl.SurveyVars, 1)
+	assert.Len(t, tpl.SurveyVars[0].Values, 2)
+
+	env, err := store.GetEnvironment(report.Project.ID, *tpl.EnvironmentID)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"version": 1}`, env.JSON)
+
+	inv, err := store.GetInventory(report.Project.ID, *tpl.InventoryID)
+	assert.NoError(t, err)
+	assert.Equal(t, db.InventoryStaticYaml, inv.Type)
+	assert.NotNil(t, inv.BecomeKeyID)
+	assert.Contains(t, inv.Inventory, "ansible_host: 10.0.0.1")
+	assert.NotContains(t, inv.Inventory, "web2")
+
+	key, err := store.GetAccessKey(report.Project.ID, *inv.SSHKeyID)
+	assert.NoError(t, err)
+	assert.Equal(t, "machine", key.Name)
+	assert.Equal(t, db.AccessKeySSH, key.Type)
+
+	vaults, err := store.GetTemplateVaults(report.Project.ID, tpl.ID)
+	assert.NoError(t, err)
+	assert.Len(t, vaults, 1)
+	assert.Equal(t, "prod", *vaults[0].Name)
+
+	schedules, err := store.GetProjectSchedules(report.Project.ID)
+	assert.NoError(t, err)
+	assert.Len(t, schedules, 1)
+	assert.Equal(t, "30 2 * * *", schedules[0].CronFormat)
+}
+
+func TestAWXRruleToCron(t *testing.T) {
+	for rrule, expected := range map[string]string{
+		"DTSTART;TZID=UTC:20240101T023000 RRULE:FREQ=MINUTELY;INTERVAL=15":             "0/15 * * * *",
+		"DTSTART;TZID=UTC:20240101T023000 RRULE:FREQ=HOURLY;INTERVAL=1":                "30 * * * *",
+		"DTSTART;TZID=UTC:20240101T053000 RRULE:FREQ=HOURLY;INTERVAL=6":                "30 5/6 * * *",
+		"DTSTART:20240101T023000Z RRULE:FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,FR":            "30 2 * * 1,5",
+		"DTSTART;TZID=UTC:20240101T023000 RRULE:FREQ=WEEKLY;INTERVAL=1":                "30 2 * * 1",
+		"DTSTART;TZID=UTC:20240101T023000 RRULE:FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15": "30 2 15 * *",
+		"DTSTART;TZID=UTC:20240301T023000 RRULE:FREQ=YEARLY;INTERVAL=1":                "30 2 1 3 *",
+	} {
+		cronFormat, _, err := awxRruleToCron(rrule)
+		assert.NoError(t, err, rrule)
+		assert.Equal(t, expected, cronFormat, rrule)
+	}
+
+	for _, rrule := range []string{
+		"DTSTART;TZID=UTC:20240101T023000 RRULE:FREQ=MINUTELY;INTERVAL=7",
+		"DTSTART;TZID=UTC:20240101T023000 RRULE:FREQ=WEEKLY;INTERVAL=2",
+		"DTSTART;TZID=UTC:20240101T023000 RRULE:FREQ=MONTHLY;INTERVAL=1;BYDAY=1MO",
+		"DTSTART;TZID=UTC:20240101T023000 RRULE:FREQ=DAILY;INTERVAL=1 EXRULE:FREQ=WEEKLY;BYDAY=SA",
+	} {
+		_, _, err := awxRruleToCron(rrule)
+		assert.Error(t, err, rrule)
+	}
+
+	_, warnings, err := awxRruleToCron("DTSTART;TZID=Europe/Berlin:20240101T023000 RRULE:FREQ=DAILY;INTERVAL=1;COUNT=5")
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 2)
+}
@@ -0,0 +1,202 @@
+package project
+
+import (
+	"encoding/json"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// awxEncrypted is the value of secret fields in the output of AWX.
+const awxEncrypted = "$encrypted$"
+
+// AWXExport is the output of `awx export` of AWX or Ansible Tower.
+// Related objects are referenced by natural keys, the importer matches them by name.
+type AWXExport struct {
+	Organizations         []AWXOrganization `json:"organizations"`
+	Credentials           []AWXCredential   `json:"credentials"`
+	Projects              []AWXProject      `json:"projects"`
+	Inventories           []AWXInventory    `json:"inventory"`
+	JobTemplates          []AWXJobTemplate  `json:"job_templates"`
+	WorkflowJobTemplates  []AWXNamedObject  `json:"workflow_job_templates"`
+	NotificationTemplates []AWXNamedObject  `json:"notification_templates"`
+	ExecutionEnvironments []AWXNamedObject  `json:"execution_environments"`
+	Teams                 []AWXNamedObject  `json:"teams"`
+	Users                 []AWXNamedObject  `json:"users"`
+}
+
+// AWXRef is the natural key of the related object.
+type AWXRef struct {
+	Name         string  `json:"name"`
+	Organization *AWXRef `json:"organization,omitempty"`
+}
+
+// AWXNamedObject is the object which has no equivalent in Semaphore,
+// only its name is used for the report.
+type AWXNamedObject struct {
+	Name         string  `json:"name"`
+	Username     string  `json:"username"`
+	Organization *AWXRef `json:"organization"`
+}
+
+type AWXOrganization struct {
+	Name string `json:"name"`
+}
+
+type AWXCredentialType struct {
+	Name string `json:"name"`
+	// Kind is ssh for machine credentials, scm for source control, vault, cloud, etc.
+	Kind string `json:"kind"`
+}
+
+type AWXCredential struct {
+	Name           string            `json:"name"`
+	CredentialType AWXCredentialType `json:"credential_type"`
+	Organization   *AWXRef           `json:"organization"`
+	Inputs         map[string]any    `json:"inputs"`
+}
+
+type AWXProject struct {
+	Name         string  `json:"name"`
+	ScmType      string  `json:"scm_type"`
+	ScmURL       string  `json:"scm_url"`
+	ScmBranch    string  `json:"scm_branch"`
+	Credential   *AWXRef `json:"credential"`
+	Organization *AWXRef `json:"organization"`
+}
+
+type AWXInventory struct {
+	Name string `json:"name"`
+	// Kind is empty for regular inventories, smart or constructed.
+	Kind         string              `json:"kind"`
+	Variables    string              `json:"variables"`
+	Organization *AWXRef             `json:"organization"`
+	Related      AWXInventoryRelated `json:"related"`
+}
+
+type AWXInventoryRelated struct {
+	Hosts            []AWXHost            `json:"hosts"`
+	Groups           []AWXGroup           `json:"groups"`
+	InventorySources []AWXInventorySource `json:"inventory_sources"`
+}
+
+type AWXHost struct {
+	Name      string `json:"name"`
+	Variables string `json:"variables"`
+	Enabled   *bool  `json:"enabled"`
+}
+
+type AWXGroup struct {
+	Name      string          `json:"name"`
+	Variables string          `json:"variables"`
+	Related   AWXGroupRelated `json:"related"`
+}
+
+type AWXGroupRelated struct {
+	Hosts    []AWXRef `json:"hosts"`
+	Children []AWXRef `json:"children"`
+}
+
+type AWXInventorySource struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+}
+
+type AWXJobTemplate struct {
+	Name          string                `json:"name"`
+	Description   string                `json:"description"`
+	JobType       string                `json:"job_type"`
+	Playbook      string                `json:"playbook"`
+	Limit         string                `json:"limit"`
+	JobTags       string                `json:"job_tags"`
+	SkipTags      string                `json:"skip_tags"`
+	ExtraVars     string                `json:"extra_vars"`
+	ScmBranch     string                `json:"scm_branch"`
+	Verbosity     int                   `json:"verbosity"`
+	Forks         int                   `json:"forks"`
+	DiffMode      bool                  `json:"diff_mode"`
+	SurveyEnabled bool                  `json:"survey_enabled"`
+	Inventory     *AWXRef               `json:"inventory"`
+	Project       *AWXRef               `json:"project"`
+	Related       AWXJobTemplateRelated `json:"related"`
+}
+
+type AWXJobTemplateRelated struct {
+	Credentials []AWXRef       `json:"credentials"`
+	Schedules   []AWXSchedule  `json:"schedules"`
+	SurveySpec  *AWXSurveySpec `json:"survey_spec"`
+}
+
+type AWXSchedule struct {
+	Name      string         `json:"name"`
+	Rrule     string         `json:"rrule"`
+	Enabled   bool           `json:"enabled"`
+	ExtraData map[string]any `json:"extra_data"`
+}
+
+type AWXSurveySpec struct {
+	Spec []AWXSurveyQuestion `json:"spec"`
+}
+
+type AWXSurveyQuestion struct {
+	Variable            string `json:"variable"`
+	QuestionName        string `json:"question_name"`
+	QuestionDescription string `json:"question_description"`
+	Type                string `json:"type"`
+	Required            bool   `json:"required"`
+	// Choices is a list or a string with one choice per line.
+	Choices json.RawMessage `json:"choices"`
+}
+
+// AWXImportOptions are parameters of the import.
+type AWXImportOptions struct {
+	// ProjectName is the name of the created project.
+	// If it is empty, the name of the imported organization is used.
+	ProjectName string
+	// Organization limits the import to objects of the organization.
+	// All objects are imported to the project if it is empty.
+	Organization string
+	// Owner becomes the owner of the project if it is not nil.
+	Owner *db.User
+}
+
+// AWXImportReport describes the result of the import. Imported contains created
+// objects of Semaphore. Warnings and Unmapped contain objects of AWX which are
+// imported partially or not imported at all.
+type AWXImportReport struct {
+	Project  db.Project   `json:"project"`
+	Imported []ImportItem `json:"imported"`
+	Warnings []ImportItem `json:"warnings"`
+	Unmapped []ImportItem `json:"unmapped"`
+}
+
+type awxKey struct {
+	db.AccessKey
+	kind string
+	// vaultID is the vault identity of the vault credential.
+	vaultID string
+	// becomeKeyID is the key with the become password of the machine credential.
+	becomeKeyID *int
+}
+
+type awxInventory struct {
+	content string
+	// names contains names of created inventories by names of their SSH keys.
+	// An inventory is created for each machine credential used with it.
+	names map[string]int
+}
+
+type awxImporter struct {
+	export  *AWXExport
+	options AWXImportOptions
+	store   db.Store
+	report  *AWXImportReport
+
+	projectID  int
+	noneKeyID  int
+	emptyEnvID int
+
+	keys         map[string]*awxKey
+	repositories map[string]db.Repository
+	inventories  map[string]*awxInventory
+	templates    map[string]bool
+}
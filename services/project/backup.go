@@ -1,9 +1,11 @@
 package project
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"strings"
 
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/pkg/random"
@@ -157,6 +159,13 @@ func (b *BackupDB) load(projectID int, store db.Store) (err error) {
 		return
 	}
 
+	defaultEnvironment, err := store.GetProjectDefaultEnvironment(projectID)
+	if err == nil {
+		b.defaultEnvironment = &defaultEnvironment
+	} else if !errors.Is(err, db.ErrNotFound) {
+		return
+	}
+
 	schedules, err := store.GetSchedules()
 	if err != nil {
 		return
@@ -217,6 +226,13 @@ func (b *BackupDB) format() (*BackupFormat, error) {
 		}
 	}
 
+	var defaultEnvironment *BackupEnvironment
+	if b.defaultEnvironment != nil {
+		defaultEnvironment = &BackupEnvironment{
+			*b.defaultEnvironment,
+		}
+	}
+
 	inventories := make([]BackupInventory, len(b.inventories))
 	for i, o := range b.inventories {
 		var SSHKey *string = nil
@@ -256,6 +272,12 @@ func (b *BackupDB) format() (*BackupFormat, error) {
 		if o.ViewID != nil {
 			View, _ = findNameByID[db.View](*o.ViewID, b.views)
 		}
+		var Views []string
+		for _, viewID := range o.ViewIDs {
+			if name, err := findNameByID[db.View](viewID, b.views); err == nil {
+				Views = append(Views, *name)
+			}
+		}
 		var vaults []BackupTemplateVault = nil
 		for _, vault := range o.Vaults {
 			var vaultKey *string = nil
@@ -276,6 +298,10 @@ func (b *BackupDB) format() (*BackupFormat, error) {
 		if o.BuildTemplateID != nil {
 			BuildTemplate, _ = findNameByID[db.Template](*o.BuildTemplateID, b.templates)
 		}
+		var AbortTemplate *string = nil
+		if o.AbortTemplateID != nil {
+			AbortTemplate, _ = findNameByID[db.Template](*o.AbortTemplateID, b.templates)
+		}
 		Repository, _ := findNameByID[db.Repository](o.RepositoryID, b.repositories)
 
 		var Inventory *string = nil
@@ -286,10 +312,12 @@ func (b *BackupDB) format() (*BackupFormat, error) {
 		templates[i] = BackupTemplate{
 			Template:      o,
 			View:          View,
+			Views:         Views,
 			Repository:    *Repository,
 			Inventory:     Inventory,
 			Environment:   Environment,
 			BuildTemplate: BuildTemplate,
+			AbortTemplate: AbortTemplate,
 			Cron:          getScheduleByTemplate(o.ID, b.schedules),
 			Vaults:        vaults,
 		}
@@ -338,6 +366,7 @@ func (b *BackupDB) format() (*BackupFormat, error) {
 		},
 		Inventories:        inventories,
 		Environments:       environments,
+		DefaultEnvironment: defaultEnvironment,
 		Views:              views,
 		Repositories:       repositories,
 		Keys:               keys,
@@ -356,29 +385,29 @@ func GetBackup(projectID int, store db.Store) (*BackupFormat, error) {
 }
 
 func (b *BackupFormat) Marshal() (res string, err error) {
-	data, err := marshalValue(reflect.ValueOf(b))
-	if err != nil {
-		return
-	}
+	var buf strings.Builder
 
-	bytes, err := json.Marshal(data)
-	if err != nil {
+	if err = b.MarshalTo(&buf); err != nil {
 		return
 	}
 
-	res = string(bytes)
+	res = buf.String()
 
 	return
 }
 
+// MarshalTo writes the backup to w. Templates, keys and other entities
+// are serialized one by one.
+func (b *BackupFormat) MarshalTo(w io.Writer) error {
+	return marshalStructTo(w, reflect.ValueOf(b))
+}
+
 func (b *BackupFormat) Unmarshal(res string) (err error) {
-	// Parse the JSON data into a map
-	var jsonData interface{}
-	if err = json.Unmarshal([]byte(res), &jsonData); err != nil {
-		return
-	}
+	return b.UnmarshalFrom(strings.NewReader(res))
+}
 
-	// Start the recursive unmarshaling process
-	err = unmarshalValueWithBackupTags(jsonData, reflect.ValueOf(b))
-	return
+// UnmarshalFrom reads the backup from r. Entities are decoded one by one,
+// so the raw JSON document is not kept in memory.
+func (b *BackupFormat) UnmarshalFrom(r io.Reader) error {
+	return unmarshalStructFrom(r, reflect.ValueOf(b))
 }
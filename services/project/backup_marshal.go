@@ -1,8 +1,11 @@
 package project
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"reflect"
+	"sort"
 )
 
 func marshalValue(v reflect.Value) (interface{}, error) {
@@ -291,3 +294,228 @@ func unmarshalStructWithBackupTags(data map[string]interface{}, v reflect.Value)
 
 	return nil
 }
+
+// backupFieldKey returns the key of the struct field in the backup JSON.
+func backupFieldKey(fieldType reflect.StructField) (string, bool) {
+	tag := fieldType.Tag.Get("backup")
+	if tag == "-" {
+		return "", false
+	}
+
+	if tag == "" {
+		tag = fieldType.Tag.Get("db")
+		if tag == "" || tag == "-" {
+			return "", false
+		}
+	}
+
+	return tag, true
+}
+
+type backupField struct {
+	key   string
+	value reflect.Value
+}
+
+// getBackupFields returns fields of the struct sorted by key. Embedded structs are not supported.
+func getBackupFields(v reflect.Value) []backupField {
+	var fields []backupField
+
+	for i := 0; i < v.NumField(); i++ {
+		key, ok := backupFieldKey(v.Type().Field(i))
+		if !ok {
+			continue
+		}
+		fields = append(fields, backupField{key: key, value: v.Field(i)})
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].key < fields[j].key
+	})
+
+	return fields
+}
+
+func writeBackupJSON(w io.Writer, data interface{}) error {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(bytes)
+	return err
+}
+
+// marshalStructTo writes the struct to w in the same format as marshalValue does.
+// Slices are written element by element, so only one element is kept
+// in the intermediate representation at a time.
+func marshalStructTo(w io.Writer, v reflect.Value) (err error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			_, err = io.WriteString(w, "null")
+			return
+		}
+		v = v.Elem()
+	}
+
+	if _, err = io.WriteString(w, "{"); err != nil {
+		return
+	}
+
+	first := true
+
+	for _, field := range getBackupFields(v) {
+		if field.value.Kind() == reflect.Ptr && field.value.IsNil() {
+			continue
+		}
+
+		if !first {
+			if _, err = io.WriteString(w, ","); err != nil {
+				return
+			}
+		}
+		first = false
+
+		if err = writeBackupJSON(w, field.key); err != nil {
+			return
+		}
+
+		if _, err = io.WriteString(w, ":"); err != nil {
+			return
+		}
+
+		if field.value.Kind() != reflect.Slice {
+			var value interface{}
+			value, err = marshalValue(field.value)
+			if err != nil {
+				return
+			}
+			if err = writeBackupJSON(w, value); err != nil {
+				return
+			}
+			continue
+		}
+
+		if _, err = io.WriteString(w, "["); err != nil {
+			return
+		}
+
+		for i := 0; i < field.value.Len(); i++ {
+			if i > 0 {
+				if _, err = io.WriteString(w, ","); err != nil {
+					return
+				}
+			}
+
+			var elem interface{}
+			elem, err = marshalValue(field.value.Index(i))
+			if err != nil {
+				return
+			}
+			if err = writeBackupJSON(w, elem); err != nil {
+				return
+			}
+		}
+
+		if _, err = io.WriteString(w, "]"); err != nil {
+			return
+		}
+	}
+
+	_, err = io.WriteString(w, "}")
+	return
+}
+
+func expectJSONDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %v, got %v", delim, tok)
+	}
+	return nil
+}
+
+// unmarshalStructFrom reads the struct written by marshalStructTo from r.
+// Elements of slices are decoded one by one, so the whole JSON document
+// is never kept in memory.
+func unmarshalStructFrom(r io.Reader, v reflect.Value) (err error) {
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	fields := make(map[string]reflect.Value)
+	for _, field := range getBackupFields(v) {
+		fields[field.key] = field.value
+	}
+
+	dec := json.NewDecoder(r)
+
+	if err = expectJSONDelim(dec, '{'); err != nil {
+		return
+	}
+
+	for dec.More() {
+		var tok json.Token
+		tok, err = dec.Token()
+		if err != nil {
+			return
+		}
+
+		field, ok := fields[tok.(string)]
+		if !ok {
+			var skip json.RawMessage
+			if err = dec.Decode(&skip); err != nil {
+				return
+			}
+			continue
+		}
+
+		if field.Kind() != reflect.Slice {
+			var data interface{}
+			if err = dec.Decode(&data); err != nil {
+				return
+			}
+			if data == nil {
+				continue
+			}
+			if err = unmarshalValueWithBackupTags(data, field); err != nil {
+				return
+			}
+			continue
+		}
+
+		tok, err = dec.Token()
+		if err != nil {
+			return
+		}
+		if tok == nil {
+			continue
+		}
+		if tok != json.Delim('[') {
+			return fmt.Errorf("expected array for slice, got %v", tok)
+		}
+
+		slice := reflect.MakeSlice(field.Type(), 0, 0)
+
+		for dec.More() {
+			var data interface{}
+			if err = dec.Decode(&data); err != nil {
+				return
+			}
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err = unmarshalValueWithBackupTags(data, elem); err != nil {
+				return
+			}
+			slice = reflect.Append(slice, elem)
+		}
+
+		if err = expectJSONDelim(dec, ']'); err != nil {
+			return
+		}
+
+		field.Set(slice)
+	}
+
+	return expectJSONDelim(dec, '}')
+}
@@ -1,6 +1,7 @@
 package project
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/semaphoreui/semaphore/db"
@@ -69,7 +70,7 @@ func TestBackupProject(t *testing.T) {
 
 	str, err := backup.Marshal()
 	assert.NoError(t, err)
-	assert.Equal(t, "{\"environments\":[{\"json\":\"{\\\"author\\\": \\\"Denis\\\", \\\"comment\\\": \\\"Hello, World!\\\"}\",\"name\":\"test\"}],\"integration_aliases\":[],\"integrations\":[],\"inventories\":[{\"inventory\":\"\",\"name\":\"\",\"type\":\"\"}],\"keys\":[{\"name\":\"\",\"type\":\"none\"}],\"meta\":{\"alert\":false,\"max_parallel_tasks\":0,\"name\":\"Test 123\",\"type\":\"\"},\"repositories\":[{\"git_branch\":\"master\",\"git_url\":\"git@example.com:test/test\",\"name\":\"Test\",\"ssh_key\":\"\"}],\"templates\":[{\"allow_override_args_in_task\":false,\"app\":\"\",\"autorun\":false,\"environment\":\"test\",\"inventory\":\"\",\"name\":\"Test\",\"playbook\":\"test.yml\",\"repository\":\"Test\",\"suppress_success_alerts\":false,\"survey_vars\":[],\"task_params\":{},\"type\":\"\",\"vaults\":[]}],\"views\":[]}", str)
+	assert.Equal(t, "{\"environments\":[{\"json\":\"{\\\"author\\\": \\\"Denis\\\", \\\"comment\\\": \\\"Hello, World!\\\"}\",\"name\":\"test\"}],\"integration_aliases\":[],\"integrations\":[],\"inventories\":[{\"inventory\":\"\",\"name\":\"\",\"type\":\"\"}],\"keys\":[{\"name\":\"\",\"type\":\"none\"}],\"meta\":{\"alert\":false,\"max_parallel_tasks\":0,\"name\":\"Test 123\",\"type\":\"\"},\"repositories\":[{\"git_branch\":\"master\",\"git_url\":\"git@example.com:test/test\",\"name\":\"Test\",\"ssh_key\":\"\"}],\"templates\":[{\"allow_override_args_in_task\":false,\"app\":\"\",\"approval_emails\":false,\"autorun\":false,\"change_window_end\":\"\",\"change_window_start\":\"\",\"concurrency_group\":\"\",\"duration_factor\":0,\"environment\":\"test\",\"inventory\":\"\",\"max_duration\":0,\"name\":\"Test\",\"playbook\":\"test.yml\",\"repository\":\"Test\",\"run_conflict_policy\":\"\",\"secrets_scan\":\"\",\"suppress_success_alerts\":false,\"survey_vars\":[],\"tags\":[],\"task_params\":{},\"type\":\"\",\"vaults\":[],\"views\":[]}],\"views\":[]}", str)
 
 	restoredBackup := &BackupFormat{}
 	err = restoredBackup.Unmarshal(str)
@@ -126,3 +127,24 @@ func TestMakeUniqueNames(t *testing.T) {
 
 	assert.True(t, isUnique(items), "Not unique names")
 }
+
+func TestBackupUnmarshalFrom(t *testing.T) {
+	backup := &BackupFormat{}
+
+	err := backup.UnmarshalFrom(strings.NewReader(`{
+		"meta": {"name": "Test"},
+		"unknown": {"a": [1, 2]},
+		"views": null,
+		"keys": [{"name": "key1", "type": "none"}, {"name": "key2", "type": "ssh"}],
+		"integration_aliases": ["a", "b"]
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "Test", backup.Meta.Name)
+	assert.Nil(t, backup.Views)
+	assert.Len(t, backup.Keys, 2)
+	assert.Equal(t, "key2", backup.Keys[1].Name)
+	assert.Equal(t, []string{"a", "b"}, backup.IntegrationAliases)
+
+	err = backup.UnmarshalFrom(strings.NewReader(`{"keys": {}}`))
+	assert.Error(t, err)
+}
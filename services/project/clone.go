@@ -0,0 +1,210 @@
+package project
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// CloneOptions describes how CloneProject copies a project.
+type CloneOptions struct {
+	// Name of the new project. Required.
+	Name string `json:"name"`
+
+	// CopyKeys copies secrets of access keys and environment secrets.
+	// If false, keys are created empty with the same names and types,
+	// so all references stay valid and only secrets must be filled in.
+	CopyKeys bool `json:"copy_keys"`
+
+	// Rename contains substrings which are replaced in names of all
+	// copied objects, for example {"dev": "prod"}.
+	Rename map[string]string `json:"rename"`
+}
+
+func (o *CloneOptions) Validate() error {
+	if o.Name == "" {
+		return &db.ValidationError{Message: "project name can not be empty"}
+	}
+
+	for from := range o.Rename {
+		if from == "" {
+			return &db.ValidationError{Message: "rename source can not be empty"}
+		}
+	}
+
+	return nil
+}
+
+func (o *CloneOptions) replacer() *strings.Replacer {
+	from := make([]string, 0, len(o.Rename))
+	for k := range o.Rename {
+		from = append(from, k)
+	}
+
+	// longer substrings must be replaced first, so "dev-eu" wins over "dev"
+	sort.Slice(from, func(i, j int) bool {
+		if len(from[i]) != len(from[j]) {
+			return len(from[i]) > len(from[j])
+		}
+		return from[i] < from[j]
+	})
+
+	pairs := make([]string, 0, len(from)*2)
+	for _, k := range from {
+		pairs = append(pairs, k, o.Rename[k])
+	}
+
+	return strings.NewReplacer(pairs...)
+}
+
+func renamePtr(r *strings.Replacer, name *string) *string {
+	if name == nil {
+		return nil
+	}
+	res := r.Replace(*name)
+	return &res
+}
+
+// rename replaces names of all objects and references to them.
+// References are stored as names, so the same replacement keeps them consistent.
+func (b *BackupFormat) rename(r *strings.Replacer) {
+	for i := range b.Keys {
+		b.Keys[i].Name = r.Replace(b.Keys[i].Name)
+	}
+
+	for i := range b.Environments {
+		b.Environments[i].Name = r.Replace(b.Environments[i].Name)
+	}
+
+	for i := range b.Views {
+		b.Views[i].Title = r.Replace(b.Views[i].Title)
+	}
+
+	for i := range b.Repositories {
+		b.Repositories[i].Name = r.Replace(b.Repositories[i].Name)
+		b.Repositories[i].SSHKey = renamePtr(r, b.Repositories[i].SSHKey)
+	}
+
+	for i := range b.Inventories {
+		b.Inventories[i].Name = r.Replace(b.Inventories[i].Name)
+		b.Inventories[i].SSHKey = renamePtr(r, b.Inventories[i].SSHKey)
+		b.Inventories[i].BecomeKey = renamePtr(r, b.Inventories[i].BecomeKey)
+	}
+
+	for i := range b.Templates {
+		tpl := &b.Templates[i]
+		tpl.Name = r.Replace(tpl.Name)
+		tpl.Repository = r.Replace(tpl.Repository)
+		tpl.Inventory = renamePtr(r, tpl.Inventory)
+		tpl.Environment = renamePtr(r, tpl.Environment)
+		tpl.BuildTemplate = renamePtr(r, tpl.BuildTemplate)
+		tpl.View = renamePtr(r, tpl.View)
+		tpl.VaultKey = renamePtr(r, tpl.VaultKey)
+		for k := range tpl.Vaults {
+			tpl.Vaults[k].VaultKey = renamePtr(r, tpl.Vaults[k].VaultKey)
+		}
+	}
+
+	for i := range b.Integration {
+		b.Integration[i].Name = r.Replace(b.Integration[i].Name)
+		b.Integration[i].Template = r.Replace(b.Integration[i].Template)
+		b.Integration[i].AuthSecret = renamePtr(r, b.Integration[i].AuthSecret)
+	}
+}
+
+func cloneEnvironmentSecrets(store db.Store, srcProjectID int, dstProjectID int, r *strings.Replacer) error {
+	srcEnvs, err := store.GetEnvironments(srcProjectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+
+	dstEnvs, err := store.GetEnvironments(dstProjectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+
+	for _, src := range srcEnvs {
+		dst := findEntityByName[db.Environment](renamePtr(r, &src.Name), dstEnvs)
+		if dst == nil {
+			continue
+		}
+
+		var keys []db.AccessKey
+		keys, err = store.GetEnvironmentSecrets(srcProjectID, src.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if err = key.DeserializeSecret(); err != nil {
+				return err
+			}
+
+			key.ID = 0
+			key.ProjectID = &dstProjectID
+			key.EnvironmentID = &dst.ID
+
+			if _, err = store.CreateAccessKey(key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// CloneProject copies templates, inventories, environments, repositories,
+// views, schedules and keys of the project into a new project owned by the user.
+// Integration aliases are not copied because they must be unique.
+func CloneProject(projectID int, opts CloneOptions, user db.User, store db.Store) (*db.Project, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	b := BackupDB{}
+	if err := b.load(projectID, store); err != nil {
+		return nil, err
+	}
+
+	if opts.CopyKeys {
+		for i := range b.keys {
+			if err := b.keys[i].DeserializeSecret(); err != nil {
+				return nil, fmt.Errorf("can not read secret of key %s: %s", b.keys[i].Name, err.Error())
+			}
+		}
+	}
+
+	backup, err := b.format()
+	if err != nil {
+		return nil, err
+	}
+
+	backup.Meta.Name = opts.Name
+	backup.IntegrationAliases = nil
+	for i := range backup.Integration {
+		backup.Integration[i].Aliases = nil
+	}
+
+	r := opts.replacer()
+	backup.rename(r)
+
+	if err = backup.Verify(); err != nil {
+		return nil, &db.ValidationError{Message: err.Error()}
+	}
+
+	newProject, err := backup.Restore(user, store)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.CopyKeys {
+		err = cloneEnvironmentSecrets(store, projectID, newProject.ID, r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newProject, nil
+}
@@ -0,0 +1,89 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloneProject(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: "/tmp",
+	}
+
+	store := bolt.CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{
+		Name: "Dev Project",
+	})
+	assert.NoError(t, err)
+
+	key, err := store.CreateAccessKey(db.AccessKey{
+		ProjectID: &proj.ID,
+		Name:      "dev-key",
+		Type:      db.AccessKeyNone,
+	})
+	assert.NoError(t, err)
+
+	repo, err := store.CreateRepository(db.Repository{
+		ProjectID: proj.ID,
+		SSHKeyID:  key.ID,
+		Name:      "dev-repo",
+		GitURL:    "git@example.com:test/test",
+		GitBranch: "master",
+	})
+	assert.NoError(t, err)
+
+	env, err := store.CreateEnvironment(db.Environment{
+		ProjectID: proj.ID,
+		Name:      "dev-env",
+		JSON:      "{}",
+	})
+	assert.NoError(t, err)
+
+	_, err = store.CreateTemplate(db.Template{
+		Name:          "Deploy dev",
+		Playbook:      "deploy.yml",
+		ProjectID:     proj.ID,
+		RepositoryID:  repo.ID,
+		EnvironmentID: &env.ID,
+	})
+	assert.NoError(t, err)
+
+	user, err := store.CreateUser(db.UserWithPwd{
+		Pwd: "3412341234123",
+		User: db.User{
+			Username: "test",
+			Name:     "Test",
+			Email:    "test@example.com",
+			Admin:    true,
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = CloneProject(proj.ID, CloneOptions{}, user, store)
+	assert.Error(t, err)
+
+	cloned, err := CloneProject(proj.ID, CloneOptions{
+		Name:   "Prod Project",
+		Rename: map[string]string{"dev": "prod"},
+	}, user, store)
+	assert.NoError(t, err)
+	assert.Equal(t, "Prod Project", cloned.Name)
+
+	templates, err := store.GetTemplates(cloned.ID, db.TemplateFilter{}, db.RetrieveQueryParams{})
+	assert.NoError(t, err)
+	assert.Len(t, templates, 1)
+	assert.Equal(t, "Deploy prod", templates[0].Name)
+
+	clonedRepo, err := store.GetRepository(cloned.ID, templates[0].RepositoryID)
+	assert.NoError(t, err)
+	assert.Equal(t, "prod-repo", clonedRepo.Name)
+
+	clonedEnv, err := store.GetEnvironment(cloned.ID, *templates[0].EnvironmentID)
+	assert.NoError(t, err)
+	assert.Equal(t, "prod-env", clonedEnv.Name)
+}
@@ -0,0 +1,170 @@
+package project
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// CreateDemoProject fills the project with the demo repository, inventories
+// (including localhost), templates and the vault key, so the project can be
+// used as a playground right away.
+func CreateDemoProject(projectID int, noneKeyID int, emptyEnvID int, store db.Store) (err error) {
+	var demoRepo db.Repository
+
+	var buildInv db.Inventory
+	var devInv db.Inventory
+	var prodInv db.Inventory
+
+	vaultKey, err := store.CreateAccessKey(db.AccessKey{
+		Name:      "Vault Password",
+		Type:      db.AccessKeyLoginPassword,
+		ProjectID: &projectID,
+		LoginPassword: db.LoginPassword{
+			Password: "RAX6yKN7sBn2qDagRPls",
+		},
+	})
+
+	if err != nil {
+		return
+	}
+
+	demoRepo, err = store.CreateRepository(db.Repository{
+		Name:      "Demo",
+		ProjectID: projectID,
+		GitURL:    "https://github.com/semaphoreui/demo-project.git",
+		GitBranch: "main",
+		SSHKeyID:  noneKeyID,
+	})
+
+	if err != nil {
+		return
+	}
+
+	buildInv, err = store.CreateInventory(db.Inventory{
+		Name:      "Build",
+		ProjectID: projectID,
+		Inventory: "[builder]\nlocalhost ansible_connection=local",
+		Type:      "static",
+		SSHKeyID:  &noneKeyID,
+	})
+
+	if err != nil {
+		return
+	}
+
+	devInv, err = store.CreateInventory(db.Inventory{
+		Name:      "Dev",
+		ProjectID: projectID,
+		Inventory: "invs/dev/hosts",
+		Type:      "file",
+		SSHKeyID:  &noneKeyID,
+	})
+
+	if err != nil {
+		return
+	}
+
+	prodInv, err = store.CreateInventory(db.Inventory{
+		Name:      "Prod",
+		ProjectID: projectID,
+		Inventory: "invs/prod/hosts",
+		Type:      "file",
+		SSHKeyID:  &noneKeyID,
+	})
+
+	var desc string
+
+	if err != nil {
+		return
+	}
+
+	desc = "This task pings the website to provide real word example of using Semaphore."
+	_, err = store.CreateTemplate(db.Template{
+		Name:          "Ping Site",
+		Playbook:      "ping.yml",
+		Description:   &desc,
+		ProjectID:     projectID,
+		InventoryID:   &prodInv.ID,
+		EnvironmentID: &emptyEnvID,
+		RepositoryID:  demoRepo.ID,
+		App:           db.AppAnsible,
+	})
+
+	if err != nil {
+		return
+	}
+
+	desc = "Creates artifact and store it in the cache."
+
+	var startVersion = "1.0.0"
+	buildTpl, err := store.CreateTemplate(db.Template{
+		Name:          "Build",
+		Playbook:      "build.yml",
+		Type:          db.TemplateBuild,
+		ProjectID:     projectID,
+		InventoryID:   &buildInv.ID,
+		EnvironmentID: &emptyEnvID,
+		RepositoryID:  demoRepo.ID,
+		StartVersion:  &startVersion,
+		App:           db.AppAnsible,
+	})
+
+	if err != nil {
+		return
+	}
+
+	var template db.Template
+	template, err = store.CreateTemplate(db.Template{
+		Name:            "Deploy to Dev",
+		Type:            db.TemplateDeploy,
+		Playbook:        "deploy.yml",
+		ProjectID:       projectID,
+		InventoryID:     &devInv.ID,
+		EnvironmentID:   &emptyEnvID,
+		RepositoryID:    demoRepo.ID,
+		BuildTemplateID: &buildTpl.ID,
+		Autorun:         true,
+		App:             db.AppAnsible,
+	})
+
+	if err != nil {
+		return
+	}
+
+	_, err = store.CreateTemplateVault(db.TemplateVault{
+		ProjectID:  projectID,
+		TemplateID: template.ID,
+		VaultKeyID: &vaultKey.ID,
+		Name:       nil,
+		Type:       "password",
+	})
+
+	if err != nil {
+		return
+	}
+
+	template, err = store.CreateTemplate(db.Template{
+		Name:            "Deploy to Production",
+		Type:            db.TemplateDeploy,
+		Playbook:        "deploy.yml",
+		ProjectID:       projectID,
+		InventoryID:     &prodInv.ID,
+		EnvironmentID:   &emptyEnvID,
+		RepositoryID:    demoRepo.ID,
+		BuildTemplateID: &buildTpl.ID,
+		App:             db.AppAnsible,
+	})
+
+	if err != nil {
+		return
+	}
+
+	_, err = store.CreateTemplateVault(db.TemplateVault{
+		ProjectID:  projectID,
+		TemplateID: template.ID,
+		VaultKeyID: &vaultKey.ID,
+		Name:       nil,
+		Type:       "password",
+	})
+
+	return
+}
@@ -0,0 +1,53 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateDemoProject(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: "/tmp",
+	}
+
+	store := bolt.CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{Name: "Demo"})
+	assert.NoError(t, err)
+
+	key, err := store.CreateAccessKey(db.AccessKey{
+		ProjectID: &proj.ID,
+		Name:      "None",
+		Type:      db.AccessKeyNone,
+	})
+	assert.NoError(t, err)
+
+	env, err := store.CreateEnvironment(db.Environment{
+		ProjectID: proj.ID,
+		Name:      "Empty",
+		JSON:      "{}",
+	})
+	assert.NoError(t, err)
+
+	err = CreateDemoProject(proj.ID, key.ID, env.ID, store)
+	assert.NoError(t, err)
+
+	templates, err := store.GetTemplates(proj.ID, db.TemplateFilter{}, db.RetrieveQueryParams{})
+	assert.NoError(t, err)
+	assert.Len(t, templates, 4)
+
+	inventories, err := store.GetInventories(proj.ID, db.RetrieveQueryParams{})
+	assert.NoError(t, err)
+
+	hasLocalhost := false
+	for _, inv := range inventories {
+		if inv.Type == db.InventoryStatic && inv.Inventory == "[builder]\nlocalhost ansible_connection=local" {
+			hasLocalhost = true
+		}
+	}
+	assert.True(t, hasLocalhost, "demo project must have localhost inventory")
+}
@@ -185,6 +185,12 @@ func (e BackupTemplate) Verify(backup *BackupFormat) error {
 		return fmt.Errorf("view does not exist in views[].name")
 	}
 
+	for _, view := range e.Views {
+		if getEntryByName[BackupView](&view, backup.Views) == nil {
+			return fmt.Errorf("views[] does not exist in views[].name")
+		}
+	}
+
 	if buildTemplate := getEntryByName[BackupTemplate](e.BuildTemplate, backup.Templates); string(e.Type) == "deploy" && buildTemplate == nil {
 		return fmt.Errorf("deploy is build but build_template does not exist in templates[].name")
 	}
@@ -235,6 +241,12 @@ func (e BackupTemplate) Restore(store db.Store, b *BackupDB) error {
 		BuildTemplateID = &(k.ID)
 	}
 
+	// the abort template is restored only if it precedes the template in the backup
+	var AbortTemplateID *int
+	if k := findEntityByName[db.Template](e.AbortTemplate, b.templates); k != nil {
+		AbortTemplateID = &(k.ID)
+	}
+
 	var ViewID *int
 	if k := findEntityByName[db.View](e.View, b.views); k == nil {
 		ViewID = nil
@@ -242,13 +254,22 @@ func (e BackupTemplate) Restore(store db.Store, b *BackupDB) error {
 		ViewID = &k.ID
 	}
 
+	var ViewIDs []int
+	for _, view := range e.Views {
+		if k := findEntityByName[db.View](&view, b.views); k != nil {
+			ViewIDs = append(ViewIDs, k.ID)
+		}
+	}
+
 	template := e.Template
 	template.ProjectID = b.meta.ID
 	template.RepositoryID = RepositoryID
 	template.EnvironmentID = EnvironmentID
 	template.InventoryID = InventoryID
 	template.ViewID = ViewID
+	template.ViewIDs = ViewIDs
 	template.BuildTemplateID = BuildTemplateID
+	template.AbortTemplateID = AbortTemplateID
 
 	newTemplate, err := store.CreateTemplate(template)
 	if err != nil {
@@ -403,6 +424,16 @@ func (backup *BackupFormat) Restore(user db.User, store db.Store) (*db.Project,
 		}
 	}
 
+	if backup.DefaultEnvironment != nil {
+		env := backup.DefaultEnvironment.Environment
+		env.ProjectID = b.meta.ID
+		env.Name = db.ProjectDefaultEnvironmentName
+		env.ProjectDefault = true
+		if _, err = store.CreateEnvironment(env); err != nil {
+			return nil, fmt.Errorf("error at default_environment: %s", err.Error())
+		}
+	}
+
 	for i, o := range backup.Views {
 		if err := o.Restore(store, &b); err != nil {
 			return nil, fmt.Errorf("error at views[%d]: %s", i, err.Error())
@@ -0,0 +1,536 @@
+package project
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/semaphoreui/semaphore/db"
+	"gopkg.in/yaml.v3"
+)
+
+// RundeckJob is the job definition exported from Rundeck in YAML or JSON format.
+// Jobs exported in XML format are converted to it.
+type RundeckJob struct {
+	UUID            string              `yaml:"uuid"`
+	Name            string              `yaml:"name"`
+	Group           string              `yaml:"group"`
+	Description     string              `yaml:"description"`
+	Options         []RundeckOption     `yaml:"options"`
+	Sequence        RundeckSequence     `yaml:"sequence"`
+	NodeFilters     *RundeckNodeFilters `yaml:"nodefilters"`
+	Schedule        *RundeckSchedule    `yaml:"schedule"`
+	ScheduleEnabled *bool               `yaml:"scheduleEnabled"`
+	Timeout         string              `yaml:"timeout"`
+}
+
+type RundeckOption struct {
+	Name        string   `yaml:"name"`
+	Label       string   `yaml:"label"`
+	Description string   `yaml:"description"`
+	Value       string   `yaml:"value"`
+	Values      []string `yaml:"values"`
+	Enforced    bool     `yaml:"enforced"`
+	Required    bool     `yaml:"required"`
+	Secure      bool     `yaml:"secure"`
+	Multivalued bool     `yaml:"multivalued"`
+	Type        string   `yaml:"type"`
+	StoragePath string   `yaml:"storagePath"`
+}
+
+type RundeckSequence struct {
+	KeepGoing bool             `yaml:"keepgoing"`
+	Commands  []RundeckCommand `yaml:"commands"`
+}
+
+type RundeckCommand struct {
+	Description  string          `yaml:"description"`
+	Exec         string          `yaml:"exec"`
+	Script       string          `yaml:"script"`
+	Args         string          `yaml:"args"`
+	ScriptFile   string          `yaml:"scriptfile"`
+	ScriptURL    string          `yaml:"scripturl"`
+	JobRef       *RundeckJobRef  `yaml:"jobref"`
+	Type         string          `yaml:"type"`
+	ErrorHandler *RundeckCommand `yaml:"errorhandler"`
+}
+
+type RundeckJobRef struct {
+	Name  string `yaml:"name" xml:"name,attr"`
+	Group string `yaml:"group" xml:"group,attr"`
+}
+
+type RundeckNodeFilters struct {
+	Filter string `yaml:"filter"`
+}
+
+// RundeckSchedule is either the Quartz cron expression or the simple schedule.
+type RundeckSchedule struct {
+	Crontab    string               `yaml:"crontab"`
+	Time       *RundeckScheduleTime `yaml:"time"`
+	Weekday    *RundeckScheduleDay  `yaml:"weekday"`
+	DayOfMonth *RundeckScheduleDay  `yaml:"dayofmonth"`
+	Month      string               `yaml:"month"`
+	Year       string               `yaml:"year"`
+}
+
+type RundeckScheduleTime struct {
+	Hour    string `yaml:"hour" xml:"hour,attr"`
+	Minute  string `yaml:"minute" xml:"minute,attr"`
+	Seconds string `yaml:"seconds" xml:"seconds,attr"`
+}
+
+type RundeckScheduleDay struct {
+	Day string `yaml:"day" xml:"day,attr"`
+}
+
+type rundeckXMLJobList struct {
+	Jobs []rundeckXMLJob `xml:"job"`
+}
+
+type rundeckXMLJob struct {
+	UUID            string              `xml:"uuid"`
+	Name            string              `xml:"name"`
+	Group           string              `xml:"group"`
+	Description     string              `xml:"description"`
+	Timeout         string              `xml:"timeout"`
+	Options         []rundeckXMLOption  `xml:"context>options>option"`
+	Sequence        rundeckXMLSequence  `xml:"sequence"`
+	NodeFilter      string              `xml:"nodefilters>filter"`
+	Schedule        *rundeckXMLSchedule `xml:"schedule"`
+	ScheduleEnabled *bool               `xml:"scheduleEnabled"`
+}
+
+type rundeckXMLOption struct {
+	Name        string `xml:"name,attr"`
+	Label       string `xml:"label,attr"`
+	Description string `xml:"description"`
+	Value       string `xml:"value,attr"`
+	Values      string `xml:"values,attr"`
+	Delimiter   string `xml:"valuesListDelimiter,attr"`
+	Enforced    bool   `xml:"enforcedvalues,attr"`
+	Required    bool   `xml:"required,attr"`
+	Secure      bool   `xml:"secure,attr"`
+	Multivalued bool   `xml:"multivalued,attr"`
+	Type        string `xml:"type,attr"`
+	StoragePath string `xml:"storagePath,attr"`
+}
+
+type rundeckXMLSequence struct {
+	KeepGoing bool                `xml:"keepgoing,attr"`
+	Commands  []rundeckXMLCommand `xml:"command"`
+}
+
+type rundeckXMLPlugin struct {
+	Type string `xml:"type,attr"`
+}
+
+type rundeckXMLCommand struct {
+	Description    string             `xml:"description"`
+	Exec           string             `xml:"exec"`
+	Script         string             `xml:"script"`
+	ScriptArgs     string             `xml:"scriptargs"`
+	ScriptFile     string             `xml:"scriptfile"`
+	ScriptURL      string             `xml:"scripturl"`
+	JobRef         *RundeckJobRef     `xml:"jobref"`
+	NodeStepPlugin *rundeckXMLPlugin  `xml:"node-step-plugin"`
+	StepPlugin     *rundeckXMLPlugin  `xml:"step-plugin"`
+	ErrorHandler   *rundeckXMLCommand `xml:"errorhandler"`
+}
+
+type rundeckXMLSchedule struct {
+	Crontab string               `xml:"crontab,attr"`
+	Time    *RundeckScheduleTime `xml:"time"`
+	Weekday *RundeckScheduleDay  `xml:"weekday"`
+	Month   *struct {
+		Month string `xml:"month,attr"`
+		Day   string `xml:"day,attr"`
+	} `xml:"month"`
+	Year *struct {
+		Year string `xml:"year,attr"`
+	} `xml:"year"`
+}
+
+func (c rundeckXMLCommand) toCommand() RundeckCommand {
+	cmd := RundeckCommand{
+		Description: c.Description,
+		Exec:        c.Exec,
+		Script:      c.Script,
+		Args:        c.ScriptArgs,
+		ScriptFile:  c.ScriptFile,
+		ScriptURL:   c.ScriptURL,
+		JobRef:      c.JobRef,
+	}
+
+	if c.NodeStepPlugin != nil {
+		cmd.Type = c.NodeStepPlugin.Type
+	} else if c.StepPlugin != nil {
+		cmd.Type = c.StepPlugin.Type
+	}
+
+	if c.ErrorHandler != nil {
+		handler := c.ErrorHandler.toCommand()
+		cmd.ErrorHandler = &handler
+	}
+
+	return cmd
+}
+
+func (s rundeckXMLSchedule) toSchedule() *RundeckSchedule {
+	res := &RundeckSchedule{
+		Crontab: s.Crontab,
+		Time:    s.Time,
+		Weekday: s.Weekday,
+	}
+
+	if s.Month != nil {
+		res.Month = s.Month.Month
+		if s.Month.Day != "" {
+			res.DayOfMonth = &RundeckScheduleDay{Day: s.Month.Day}
+		}
+	}
+
+	if s.Year != nil {
+		res.Year = s.Year.Year
+	}
+
+	return res
+}
+
+func (j rundeckXMLJob) toJob() RundeckJob {
+	job := RundeckJob{
+		UUID:            j.UUID,
+		Name:            j.Name,
+		Group:           j.Group,
+		Description:     j.Description,
+		Timeout:         j.Timeout,
+		ScheduleEnabled: j.ScheduleEnabled,
+		Sequence:        RundeckSequence{KeepGoing: j.Sequence.KeepGoing},
+	}
+
+	for _, o := range j.Options {
+		opt := RundeckOption{
+			Name:        o.Name,
+			Label:       o.Label,
+			Description: o.Description,
+			Value:       o.Value,
+			Enforced:    o.Enforced,
+			Required:    o.Required,
+			Secure:      o.Secure,
+			Multivalued: o.Multivalued,
+			Type:        o.Type,
+			StoragePath: o.StoragePath,
+		}
+
+		if o.Values != "" {
+			delimiter := o.Delimiter
+			if delimiter == "" {
+				delimiter = ","
+			}
+			opt.Values = strings.Split(o.Values, delimiter)
+		}
+
+		job.Options = append(job.Options, opt)
+	}
+
+	for _, c := range j.Sequence.Commands {
+		job.Sequence.Commands = append(job.Sequence.Commands, c.toCommand())
+	}
+
+	if j.NodeFilter != "" {
+		job.NodeFilters = &RundeckNodeFilters{Filter: j.NodeFilter}
+	}
+
+	if j.Schedule != nil {
+		job.Schedule = j.Schedule.toSchedule()
+	}
+
+	return job
+}
+
+// ParseRundeckJobs parses job definitions exported from Rundeck in XML, YAML or JSON format.
+func ParseRundeckJobs(data []byte) ([]RundeckJob, error) {
+	data = bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(data, []byte("<")) {
+		var list rundeckXMLJobList
+		if err := xml.Unmarshal(data, &list); err != nil {
+			return nil, err
+		}
+
+		jobs := make([]RundeckJob, 0, len(list.Jobs))
+		for _, j := range list.Jobs {
+			jobs = append(jobs, j.toJob())
+		}
+		return jobs, nil
+	}
+
+	var jobs []RundeckJob
+	if err := yaml.Unmarshal(data, &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// RundeckImportOptions are parameters of the import.
+type RundeckImportOptions struct {
+	ProjectID int
+	// RepositoryID is the repository to which the generated scripts must be committed.
+	RepositoryID int
+	// ScriptsDir is the directory of the generated scripts in the repository.
+	ScriptsDir string
+}
+
+// RundeckScript is the script generated for the job which must be committed to the repository.
+type RundeckScript struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// RundeckImportReport describes the result of the import. Imported contains created
+// objects of Semaphore. Warnings and Unmapped contain jobs, steps and options of Rundeck
+// which are imported partially or not imported at all.
+type RundeckImportReport struct {
+	Scripts  []RundeckScript `json:"scripts"`
+	Imported []ImportItem    `json:"imported"`
+	Warnings []ImportItem    `json:"warnings"`
+	Unmapped []ImportItem    `json:"unmapped"`
+}
+
+type rundeckImporter struct {
+	options RundeckImportOptions
+	store   db.Store
+	report  *RundeckImportReport
+	names   map[string]bool
+}
+
+var rundeckPathRegexp = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// getRundeckJobScriptPath returns the path of the script of the job in the repository.
+func getRundeckJobScriptPath(dir string, job RundeckJob) string {
+	parts := []string{dir}
+
+	for _, part := range strings.Split(job.Group, "/") {
+		if part = strings.Trim(rundeckPathRegexp.ReplaceAllString(strings.ToLower(part), "-"), "-."); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	name := strings.Trim(rundeckPathRegexp.ReplaceAllString(strings.ToLower(job.Name), "-"), "-.")
+	if name == "" {
+		name = "job"
+	}
+
+	return path.Join(append(parts, name+".sh")...)
+}
+
+// ImportRundeckJobs creates bash templates for script and command jobs of Rundeck.
+// Steps of the job are converted to the script which must be committed to the repository,
+// options become survey variables and schedules become cron schedules of the template.
+func ImportRundeckJobs(jobs []RundeckJob, options RundeckImportOptions, store db.Store) (*RundeckImportReport, error) {
+	if _, err := store.GetRepository(options.ProjectID, options.RepositoryID); err != nil {
+		return nil, err
+	}
+
+	if options.ScriptsDir == "" {
+		options.ScriptsDir = "rundeck"
+	}
+
+	if !isLocalRundeckPath(options.ScriptsDir) {
+		return nil, &db.ValidationError{Message: "scripts directory must be relative to the repository"}
+	}
+
+	imp := rundeckImporter{
+		options: options,
+		store:   store,
+		report: &RundeckImportReport{
+			Scripts:  []RundeckScript{},
+			Imported: []ImportItem{},
+			Warnings: []ImportItem{},
+			Unmapped: []ImportItem{},
+		},
+		names: make(map[string]bool),
+	}
+
+	for _, job := range jobs {
+		if err := imp.importJob(job); err != nil {
+			return imp.report, err
+		}
+	}
+
+	return imp.report, nil
+}
+
+func isLocalRundeckPath(dir string) bool {
+	dir = path.Clean(dir)
+	return !path.IsAbs(dir) && dir != ".." && !strings.HasPrefix(dir, "../")
+}
+
+func (imp *rundeckImporter) imported(kind string, name string) {
+	imp.report.Imported = append(imp.report.Imported, ImportItem{Kind: kind, Name: name})
+}
+
+func (imp *rundeckImporter) warning(kind string, name string, reason string) {
+	imp.report.Warnings = append(imp.report.Warnings, ImportItem{Kind: kind, Name: name, Reason: reason})
+}
+
+func (imp *rundeckImporter) unmapped(kind string, name string, reason string) {
+	imp.report.Unmapped = append(imp.report.Unmapped, ImportItem{Kind: kind, Name: name, Reason: reason})
+}
+
+// getSurveyVars converts options of the job to survey variables and returns
+// default values of the options.
+func (imp *rundeckImporter) getSurveyVars(job RundeckJob) ([]db.SurveyVar, map[string]string) {
+	var vars []db.SurveyVar
+	defaults := make(map[string]string)
+
+	for _, o := range job.Options {
+		if o.Type == "file" {
+			imp.unmapped("option", o.Name, "file option of job "+job.Name+" is not supported")
+			continue
+		}
+
+		v := db.SurveyVar{
+			Name:        o.Name,
+			Title:       o.Label,
+			Required:    o.Required,
+			Description: o.Description,
+			Type:        db.SurveyVarType(db.SurveyVarStr),
+		}
+
+		if v.Title == "" {
+			v.Title = o.Name
+		}
+
+		switch {
+		case o.Secure:
+			v.Type = db.SurveyVarType(db.SurveyVarSecret)
+		case o.Enforced && len(o.Values) > 0 && !o.Multivalued:
+			v.Type = db.SurveyVarType(db.SurveyVarEnum)
+			for _, value := range o.Values {
+				v.Values = append(v.Values, db.SurveyVarEnumValue{Name: value, Value: value})
+			}
+		}
+
+		if o.Multivalued {
+			imp.warning("option", o.Name, "multiple values of the option of job "+job.Name+" are passed as one string")
+		}
+
+		if o.StoragePath != "" {
+			imp.warning("option", o.Name, "value from key storage of the option of job "+job.Name+" is not imported")
+		}
+
+		if o.Value != "" && !o.Secure {
+			defaults[o.Name] = o.Value
+		}
+
+		vars = append(vars, v)
+	}
+
+	return vars, defaults
+}
+
+func (imp *rundeckImporter) importJob(job RundeckJob) error {
+	script, warnings, ok := getRundeckJobScript(job)
+
+	for _, w := range warnings {
+		imp.warning("job", job.Name, w)
+	}
+
+	if !ok {
+		imp.unmapped("job", job.Name, "job has no command or script steps")
+		return nil
+	}
+
+	if job.NodeFilters != nil && job.NodeFilters.Filter != "" {
+		imp.warning("job", job.Name, "node filter "+job.NodeFilters.Filter+" is not supported, the script runs on the Semaphore server")
+	}
+
+	if job.Timeout != "" {
+		imp.warning("job", job.Name, "timeout is not imported")
+	}
+
+	name := job.Name
+	if imp.names[name] && job.Group != "" {
+		name = job.Group + "/" + job.Name
+	}
+	imp.names[name] = true
+
+	scriptPath := getRundeckJobScriptPath(imp.options.ScriptsDir, job)
+	imp.report.Scripts = append(imp.report.Scripts, RundeckScript{Path: scriptPath, Content: script})
+
+	surveyVars, defaults := imp.getSurveyVars(job)
+
+	tpl := db.Template{
+		ProjectID:    imp.options.ProjectID,
+		Name:         name,
+		App:          db.AppBash,
+		Playbook:     scriptPath,
+		RepositoryID: imp.options.RepositoryID,
+		SurveyVars:   surveyVars,
+	}
+
+	if job.Description != "" {
+		tpl.Description = &job.Description
+	}
+
+	if job.Group != "" {
+		tpl.Tags = []string{job.Group}
+	}
+
+	if len(defaults) > 0 {
+		content, err := json.Marshal(defaults)
+		if err != nil {
+			return err
+		}
+
+		env, err := imp.store.CreateEnvironment(db.Environment{
+			Name:      name,
+			ProjectID: imp.options.ProjectID,
+			JSON:      string(content),
+		})
+		if err != nil {
+			return err
+		}
+
+		tpl.EnvironmentID = &env.ID
+		imp.imported("environment", env.Name)
+	}
+
+	tpl, err := imp.store.CreateTemplate(tpl)
+	if err != nil {
+		return err
+	}
+
+	imp.imported("template", tpl.Name)
+
+	if job.Schedule == nil {
+		return nil
+	}
+
+	cronFormat, err := getRundeckScheduleCron(*job.Schedule)
+	if err != nil {
+		imp.unmapped("schedule", job.Name, err.Error())
+		return nil
+	}
+
+	schedule, err := imp.store.CreateSchedule(db.Schedule{
+		ProjectID:  imp.options.ProjectID,
+		TemplateID: tpl.ID,
+		Type:       db.ScheduleTypeCron,
+		CronFormat: cronFormat,
+		Name:       tpl.Name,
+		Active:     job.ScheduleEnabled == nil || *job.ScheduleEnabled,
+	})
+	if err != nil {
+		return err
+	}
+
+	imp.imported("schedule", schedule.Name)
+
+	return nil
+}
@@ -0,0 +1,243 @@
+package project
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/semaphoreui/semaphore/services/schedules"
+)
+
+// rundeckOptionRefRegexp matches references to options in commands and scripts,
+// e.g. @option.env@ or ${option.env}.
+var rundeckOptionRefRegexp = regexp.MustCompile(`@option\.([\w.-]+)@|\$\{option\.([\w.-]+)\}`)
+
+var rundeckEnvNameRegexp = regexp.MustCompile(`[^A-Z0-9_]`)
+
+var rundeckNumberRegexp = regexp.MustCompile(`\d+`)
+
+// rundeckScriptHeader exports survey variables, which Semaphore passes to the script
+// as name=value arguments, as RD_OPTION_* variables like Rundeck does.
+const rundeckScriptHeader = `for arg in "$@"; do
+  if [[ "$arg" == *=* ]]; then
+    name="${arg%%=*}"
+    name="${name^^}"
+    export "RD_OPTION_${name//[^A-Z0-9_]/_}=${arg#*=}"
+  fi
+done
+`
+
+func getRundeckOptionEnvName(name string) string {
+	return "RD_OPTION_" + rundeckEnvNameRegexp.ReplaceAllString(strings.ToUpper(name), "_")
+}
+
+func replaceRundeckOptionRefs(s string) string {
+	return rundeckOptionRefRegexp.ReplaceAllStringFunc(s, func(ref string) string {
+		m := rundeckOptionRefRegexp.FindStringSubmatch(ref)
+		name := m[1]
+		if name == "" {
+			name = m[2]
+		}
+		return "${" + getRundeckOptionEnvName(name) + "}"
+	})
+}
+
+// getRundeckJobScript converts command and script steps of the job to the bash script.
+// It returns false if the job has no steps which can be converted.
+func getRundeckJobScript(job RundeckJob) (string, []string, bool) {
+	var warnings []string
+	var steps strings.Builder
+
+	hasScripts := false
+	converted := 0
+
+	onFailure := ""
+	if job.Sequence.KeepGoing {
+		onFailure = " || failed=1"
+	}
+
+	for i, cmd := range job.Sequence.Commands {
+		n := i + 1
+
+		if cmd.ErrorHandler != nil {
+			warnings = append(warnings, fmt.Sprintf("error handler of step %d is not imported", n))
+		}
+
+		var step string
+
+		switch {
+		case cmd.Exec != "":
+			// each step runs in the separate shell like in Rundeck
+			step = "(" + replaceRundeckOptionRefs(cmd.Exec) + ")" + onFailure + "\n"
+		case cmd.Script != "":
+			hasScripts = true
+
+			file := fmt.Sprintf(`"$steps/step%d"`, n)
+			delimiter := fmt.Sprintf("RUNDECK_STEP_%d_EOF", n)
+
+			run := file
+			if !strings.HasPrefix(cmd.Script, "#!") {
+				run = "bash " + file
+			}
+			if cmd.Args != "" {
+				run += " " + replaceRundeckOptionRefs(cmd.Args)
+			}
+
+			step = fmt.Sprintf("cat > %s <<'%s'\n%s\n%s\nchmod +x %s\n%s%s\n",
+				file, delimiter, strings.TrimSuffix(replaceRundeckOptionRefs(cmd.Script), "\n"), delimiter, file, run, onFailure)
+		case cmd.ScriptFile != "" || cmd.ScriptURL != "":
+			warnings = append(warnings, fmt.Sprintf("step %d runs the script file which is not imported", n))
+		case cmd.JobRef != nil:
+			warnings = append(warnings, fmt.Sprintf("step %d runs job %s which is not imported", n, cmd.JobRef.Name))
+		default:
+			warnings = append(warnings, fmt.Sprintf("step %d uses plugin %s which is not supported", n, cmd.Type))
+		}
+
+		if step == "" {
+			continue
+		}
+
+		converted++
+
+		steps.WriteString("\n# step " + strconv.Itoa(n))
+		if cmd.Description != "" {
+			steps.WriteString(": " + strings.ReplaceAll(cmd.Description, "\n", " "))
+		}
+		steps.WriteString("\n" + step)
+	}
+
+	if converted == 0 {
+		return "", warnings, false
+	}
+
+	var script strings.Builder
+
+	script.WriteString("#!/usr/bin/env bash\n")
+	script.WriteString("# Imported from Rundeck job " + strings.TrimPrefix(job.Group+"/"+job.Name, "/") + "\n\n")
+	script.WriteString(rundeckScriptHeader)
+
+	if job.Sequence.KeepGoing {
+		script.WriteString("\nfailed=0\n")
+	} else {
+		script.WriteString("\nset -e\n")
+	}
+
+	if hasScripts {
+		script.WriteString("steps=$(mktemp -d)\ntrap 'rm -rf \"$steps\"' EXIT\n")
+	}
+
+	script.WriteString(steps.String())
+
+	if job.Sequence.KeepGoing {
+		script.WriteString("\nexit $failed\n")
+	}
+
+	return script.String(), warnings, true
+}
+
+// convertRundeckDayOfWeek converts the day of week field of Quartz, where 1 is Sunday,
+// to the cron field, where 0 is Sunday. Names of days are kept.
+func convertRundeckDayOfWeek(field string) (string, error) {
+	if field == "?" || field == "*" {
+		return "*", nil
+	}
+
+	if strings.ContainsAny(field, "/L#") {
+		return "", fmt.Errorf("day of week %s is not supported", field)
+	}
+
+	var err error
+
+	res := rundeckNumberRegexp.ReplaceAllStringFunc(field, func(s string) string {
+		n, _ := strconv.Atoi(s)
+		if n < 1 || n > 7 {
+			err = fmt.Errorf("invalid day of week %s", field)
+		}
+		return strconv.Itoa(n - 1)
+	})
+
+	return res, err
+}
+
+// rundeckQuartzToCron converts the Quartz cron expression used by Rundeck to the cron format.
+func rundeckQuartzToCron(expr string) (string, error) {
+	fields := strings.Fields(expr)
+
+	if len(fields) != 6 && len(fields) != 7 {
+		return "", fmt.Errorf("invalid schedule %s", expr)
+	}
+
+	if strings.Trim(fields[0], "0") != "" {
+		return "", fmt.Errorf("schedules with seconds are not supported")
+	}
+
+	if len(fields) == 7 && fields[6] != "*" {
+		return "", fmt.Errorf("schedules for specific years are not supported")
+	}
+
+	dayOfMonth := fields[3]
+	if dayOfMonth == "?" {
+		dayOfMonth = "*"
+	}
+
+	if strings.ContainsAny(dayOfMonth, "LW") {
+		return "", fmt.Errorf("day of month %s is not supported", dayOfMonth)
+	}
+
+	dayOfWeek, err := convertRundeckDayOfWeek(fields[5])
+	if err != nil {
+		return "", err
+	}
+
+	cronFormat := strings.Join([]string{fields[1], fields[2], dayOfMonth, fields[4], dayOfWeek}, " ")
+
+	if err = schedules.ValidateCronFormat(cronFormat); err != nil {
+		return "", fmt.Errorf("schedule %s can not be converted: %s", expr, err.Error())
+	}
+
+	return cronFormat, nil
+}
+
+func getRundeckScheduleField(value string) string {
+	if value == "" {
+		return "*"
+	}
+	return value
+}
+
+// getRundeckScheduleCron returns the cron format of the job schedule.
+func getRundeckScheduleCron(s RundeckSchedule) (string, error) {
+	if s.Crontab != "" {
+		return rundeckQuartzToCron(s.Crontab)
+	}
+
+	if s.Time == nil {
+		return "", fmt.Errorf("schedule has no time")
+	}
+
+	seconds := s.Time.Seconds
+	if seconds == "" {
+		seconds = "0"
+	}
+
+	dayOfWeek := "*"
+	if s.Weekday != nil {
+		dayOfWeek = getRundeckScheduleField(s.Weekday.Day)
+	}
+
+	dayOfMonth := "*"
+	if s.DayOfMonth != nil {
+		dayOfMonth = getRundeckScheduleField(s.DayOfMonth.Day)
+	}
+
+	return rundeckQuartzToCron(strings.Join([]string{
+		seconds,
+		getRundeckScheduleField(s.Time.Minute),
+		getRundeckScheduleField(s.Time.Hour),
+		dayOfMonth,
+		getRundeckScheduleField(s.Month),
+		dayOfWeek,
+		getRundeckScheduleField(s.Year),
+	}, " "))
+}
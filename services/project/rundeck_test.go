@@ -0,0 +1,206 @@
+package project
+
+import (
+	"os"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const testRundeckYAML = `
+- name: Deploy
+  group: ops/web
+  description: Deploys the application
+  options:
+  - name: env
+    enforced: true
+    required: true
+    values: [dev, prod]
+    value: dev
+  - name: token
+    secure: true
+  - name: bundle
+    type: file
+  sequence:
+    keepgoing: false
+    commands:
+    - exec: echo deploying @option.env@
+    - script: |-
+        #!/bin/sh
+        echo ${option.env}
+      args: --verbose
+    - jobref:
+        name: Notify
+  nodefilters:
+    filter: tags:web
+  schedule:
+    crontab: 0 30 2 ? * MON-FRI *
+  scheduleEnabled: true
+- name: Cleanup
+  sequence:
+    commands:
+    - type: some-plugin
+`
+
+const testRundeckXML = `<joblist>
+  <job>
+    <name>Backup</name>
+    <group>db</group>
+    <context>
+      <options>
+        <option name="target" value="s3" />
+      </options>
+    </context>
+    <sequence keepgoing="true">
+      <command>
+        <exec>pg_dump app &gt; /tmp/app.sql</exec>
+      </command>
+    </sequence>
+    <schedule>
+      <time hour="12" minute="15" seconds="0" />
+      <weekday day="*" />
+      <month month="*" />
+      <year year="*" />
+    </schedule>
+  </job>
+</joblist>`
+
+func createRundeckTestProject(t *testing.T, store db.Store) (db.Project, db.Repository) {
+	project, err := store.CreateProject(db.Project{Name: "Rundeck"})
+	assert.NoError(t, err)
+
+	key, err := store.CreateAccessKey(db.AccessKey{Name: "None", Type: db.AccessKeyNone, ProjectID: &project.ID})
+	assert.NoError(t, err)
+
+	repo, err := store.CreateRepository(db.Repository{
+		Name:      "scripts",
+		ProjectID: project.ID,
+		GitURL:    "https://example.com/scripts.git",
+		GitBranch: "main",
+		SSHKeyID:  key.ID,
+	})
+	assert.NoError(t, err)
+
+	return project, repo
+}
+
+func TestImportRundeckJobs(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: "/tmp",
+	}
+
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	project, repo := createRundeckTestProject(t, store)
+
+	jobs, err := ParseRundeckJobs([]byte(testRundeckYAML))
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 2)
+
+	report, err := ImportRundeckJobs(jobs, RundeckImportOptions{
+		ProjectID:    project.ID,
+		RepositoryID: repo.ID,
+	}, store)
+	assert.NoError(t, err)
+
+	assert.NotNil(t, findAWXItem(report.Unmapped, "job", "Cleanup"))
+	assert.NotNil(t, findAWXItem(report.Unmapped, "option", "bundle"))
+	assert.NotNil(t, findAWXItem(report.Imported, "template", "Deploy"))
+
+	assert.Len(t, report.Scripts, 1)
+	assert.Equal(t, "rundeck/ops/web/deploy.sh", report.Scripts[0].Path)
+	assert.Contains(t, report.Scripts[0].Content, "echo deploying ${RD_OPTION_ENV}")
+	assert.Contains(t, report.Scripts[0].Content, `"$steps/step2" --verbose`)
+	assert.Contains(t, report.Scripts[0].Content, "set -e")
+
+	templates, err := store.GetTemplates(project.ID, db.TemplateFilter{}, db.RetrieveQueryParams{})
+	assert.NoError(t, err)
+	assert.Len(t, templates, 1)
+
+	tpl := templates[0]
+	assert.Equal(t, db.AppBash, tpl.App)
+	assert.Equal(t, "rundeck/ops/web/deploy.sh", tpl.Playbook)
+	assert.Len(t, tpl.SurveyVars, 2)
+	assert.Equal(t, db.SurveyVarType(db.SurveyVarEnum), tpl.SurveyVars[0].Type)
+	assert.Len(t, tpl.SurveyVars[0].Values, 2)
+	assert.Equal(t, db.SurveyVarType(db.SurveyVarSecret), tpl.SurveyVars[1].Type)
+
+	env, err := store.GetEnvironment(project.ID, *tpl.EnvironmentID)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"env": "dev"}`, env.JSON)
+
+	schedules, err := store.GetProjectSchedules(project.ID)
+	assert.NoError(t, err)
+	assert.Len(t, schedules, 1)
+	assert.Equal(t, "30 2 * * MON-FRI", schedules[0].CronFormat)
+	assert.True(t, schedules[0].Active)
+}
+
+func TestImportRundeckJobsXML(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: "/tmp",
+	}
+
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	project, repo := createRundeckTestProject(t, store)
+
+	jobs, err := ParseRundeckJobs([]byte(testRundeckXML))
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 1)
+
+	report, err := ImportRundeckJobs(jobs, RundeckImportOptions{
+		ProjectID:    project.ID,
+		RepositoryID: repo.ID,
+		ScriptsDir:   "jobs",
+	}, store)
+	assert.NoError(t, err)
+
+	assert.Len(t, report.Scripts, 1)
+	assert.Equal(t, "jobs/db/backup.sh", report.Scripts[0].Path)
+	assert.Contains(t, report.Scripts[0].Content, "(pg_dump app > /tmp/app.sql) || failed=1")
+	assert.Contains(t, report.Scripts[0].Content, "exit $failed")
+
+	schedules, err := store.GetProjectSchedules(project.ID)
+	assert.NoError(t, err)
+	assert.Len(t, schedules, 1)
+	assert.Equal(t, "15 12 * * *", schedules[0].CronFormat)
+
+	_, err = ImportRundeckJobs(jobs, RundeckImportOptions{
+		ProjectID:    project.ID,
+		RepositoryID: repo.ID,
+		ScriptsDir:   "../outside",
+	}, store)
+	assert.Error(t, err)
+}
+
+func TestRundeckQuartzToCron(t *testing.T) {
+	for expr, expected := range map[string]string{
+		"0 30 2 ? * MON-FRI *": "30 2 * * MON-FRI",
+		"0 0 12 ? * 2":         "0 12 * * 1",
+		"0 0/15 * * * ? *":     "0/15 * * * *",
+		"00 0 1 1 * ?":         "0 1 1 * *",
+		"0 0 8 ? * 2-6":        "0 8 * * 1-5",
+	} {
+		cronFormat, err := rundeckQuartzToCron(expr)
+		assert.NoError(t, err, expr)
+		assert.Equal(t, expected, cronFormat, expr)
+	}
+
+	for _, expr := range []string{
+		"30 0 12 * * ?",
+		"0 0 12 * * ? 2025",
+		"0 0 12 L * ?",
+		"0 0 12 ? * 6#3",
+		"0 0 12 ? * 8",
+		"0 0 12",
+	} {
+		_, err := rundeckQuartzToCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
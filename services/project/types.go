@@ -12,6 +12,8 @@ type BackupDB struct {
 	views        []db.View
 	inventories  []db.Inventory
 	environments []db.Environment
+	// defaultEnvironment holds project-wide variables, nil if the project has no defaults.
+	defaultEnvironment *db.Environment
 	schedules    []db.Schedule
 
 	integrationProjAliases   []db.IntegrationAlias
@@ -29,6 +31,7 @@ type BackupFormat struct {
 	Views              []BackupView        `backup:"views"`
 	Inventories        []BackupInventory   `backup:"inventories"`
 	Environments       []BackupEnvironment `backup:"environments"`
+	DefaultEnvironment *BackupEnvironment  `backup:"default_environment"`
 	Integration        []BackupIntegration `backup:"integrations"`
 	IntegrationAliases []string            `backup:"integration_aliases"`
 }
@@ -67,7 +70,9 @@ type BackupTemplate struct {
 	Repository    string                `backup:"repository"`
 	Environment   *string               `backup:"environment"`
 	BuildTemplate *string               `backup:"build_template"`
+	AbortTemplate *string               `backup:"abort_template"`
 	View          *string               `backup:"view"`
+	Views         []string              `backup:"views"`
 	Vaults        []BackupTemplateVault `backup:"vaults"`
 	Cron          *string               `backup:"cron"`
 
@@ -118,3 +123,11 @@ func (e BackupView) GetName() string {
 func (e BackupTemplate) GetName() string {
 	return e.Name
 }
+
+// ImportItem is the line of the report of importers from other tools.
+// Reason explains why the object is not imported or what must be done manually.
+type ImportItem struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Reason string `json:"reason,omitempty"`
+}
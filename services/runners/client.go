@@ -0,0 +1,31 @@
+package runners
+
+import (
+	"net/http"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// newHTTPClient returns the client which connects to the server
+// with the client certificate of the runner if it is configured.
+func newHTTPClient() (*http.Client, error) {
+	tlsConfig, err := util.Config.Runner.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConfig == nil {
+		return &http.Client{}, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// hasCredentials returns true if the runner can authenticate
+// by the token or by the client certificate.
+func hasCredentials() bool {
+	return util.Config.Runner.Token != "" || util.Config.Runner.ClientCertFile != ""
+}
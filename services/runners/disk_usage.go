@@ -0,0 +1,80 @@
+package runners
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db_lib"
+	"github.com/semaphoreui/semaphore/services/tasks"
+	log "github.com/sirupsen/logrus"
+)
+
+// diskUsageInterval is how often the runner measures its tmp directory and reports it to the server.
+const diskUsageInterval = 10 * time.Minute
+
+// rememberCacheProjects records the project of the caches used by the job, so the runner
+// can attribute them to projects. The runner does not know projects of caches left
+// by jobs run before its start.
+func (p *JobPool) rememberCacheProjects(j *tasks.LocalJob) {
+	if p.cacheProjects == nil {
+		p.cacheProjects = make(map[string]int)
+	}
+
+	p.cacheProjects[j.Repository.GetDirName(j.Template.ID)] = j.Task.ProjectID
+	p.cacheProjects["template_"+strconv.Itoa(j.Template.ID)] = j.Task.ProjectID
+}
+
+func (p *JobPool) diskUsageProjectOf(entry db.DiskUsageEntry) *int {
+	if entry.Kind == db.DiskUsageTask {
+		if j, ok := p.runningJobs[entry.TaskID]; ok {
+			return &j.job.Task.ProjectID
+		}
+		return nil
+	}
+
+	if projectID, ok := p.cacheProjects[entry.Name]; ok {
+		return &projectID
+	}
+
+	return nil
+}
+
+func (p *JobPool) isDiskUsageEntryInUse(entry db.DiskUsageEntry) bool {
+	for _, j := range p.runningJobs {
+		if j.status.IsFinished() {
+			continue
+		}
+
+		switch entry.Kind {
+		case db.DiskUsageShared:
+			return true
+		case db.DiskUsageRepository, db.DiskUsageTemplate:
+			if projectID, ok := p.cacheProjects[entry.Name]; ok && projectID == j.job.Task.ProjectID &&
+				entry.TemplateID == j.job.Template.ID {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// collectDiskUsage measures the tmp directory of the runner and removes caches
+// which exceed the limits of the disk usage config.
+func (p *JobPool) collectDiskUsage() *RunnerDiskUsage {
+	usage, evicted, err := db_lib.CollectDiskUsage(p.diskUsageProjectOf, p.isDiskUsageEntryInUse, time.Now())
+	if err != nil {
+		log.WithError(err).Warn("Can not collect disk usage")
+		return nil
+	}
+
+	for _, entry := range evicted {
+		log.WithField("entry", entry.Name).Info("Cache removed to fit the disk usage limits")
+	}
+
+	return &RunnerDiskUsage{
+		Usage:   usage,
+		Evicted: len(evicted),
+	}
+}
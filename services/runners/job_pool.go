@@ -74,6 +74,11 @@ type JobPool struct {
 	//token *string
 
 	processing int32
+
+	// cacheProjects maps caches of the tmp directory to the projects of the jobs which used them.
+	cacheProjects map[string]int
+
+	diskUsageMeasured time.Time
 }
 
 func (p *JobPool) existsInQueue(taskID int) bool {
@@ -113,11 +118,14 @@ func (p *JobPool) Register() (err error) {
 
 func (p *JobPool) Unregister() (err error) {
 
-	if util.Config.Runner.Token == "" {
+	if !hasCredentials() {
 		return fmt.Errorf("runner is not registered")
 	}
 
-	client := &http.Client{}
+	client, err := newHTTPClient()
+	if err != nil {
+		return
+	}
 
 	url := util.Config.WebHost + "/api/internal/runners"
 
@@ -146,13 +154,27 @@ func (p *JobPool) Unregister() (err error) {
 func (p *JobPool) Run() {
 	logger := JobLogger{Context: "running"}
 
-	if util.Config.Runner.Token == "" {
+	if !hasCredentials() {
 		logger.Panic(fmt.Errorf("no token provided"), "read input", "can not retrieve runner token")
 	}
 
+	p.runningJobs = make(map[int]*runningJob)
+
+	if !util.Config.Runner.DisableStream {
+		p.runStream()
+		logger.Info("Falling back to polling of the server")
+	}
+
+	p.runPolling()
+}
+
+// runPolling runs jobs of the protocol v1 which polls the server for new jobs
+// and sends logs of running jobs every second.
+func (p *JobPool) runPolling() {
+	logger := JobLogger{Context: "running"}
+
 	queueTicker := time.NewTicker(5 * time.Second)
 	requestTimer := time.NewTicker(1 * time.Second)
-	p.runningJobs = make(map[int]*runningJob)
 
 	defer func() {
 		queueTicker.Stop()
@@ -164,50 +186,7 @@ func (p *JobPool) Run() {
 
 		case <-queueTicker.C: // timer 5 seconds: get task from queue and run it
 			logger.Debug("Checking queue")
-
-			if len(p.queue) == 0 {
-				break
-			}
-
-			t := p.queue[0]
-			if t.status == task_logger.TaskFailStatus {
-				//delete failed TaskRunner from queue
-				p.queue = p.queue[1:]
-				logger.TaskInfo("Task dequeued", t.job.Task.ID, "failed")
-				break
-			}
-
-			p.runningJobs[t.job.Task.ID] = &runningJob{
-				job: t.job,
-			}
-
-			t.job.Logger = t.job.App.SetLogger(p.runningJobs[t.job.Task.ID])
-
-			go func(runningJob *runningJob) {
-				runningJob.SetStatus(task_logger.TaskRunningStatus)
-
-				err := runningJob.job.Run(t.username, t.incomingVersion)
-
-				if runningJob.status.IsFinished() {
-					return
-				}
-
-				if err != nil {
-					if runningJob.status == task_logger.TaskStoppingStatus {
-						runningJob.SetStatus(task_logger.TaskStoppedStatus)
-					} else {
-						runningJob.SetStatus(task_logger.TaskFailStatus)
-					}
-				} else {
-					runningJob.SetStatus(task_logger.TaskSuccessStatus)
-				}
-
-				logger.TaskInfo("Task finished", runningJob.job.Task.ID, string(runningJob.status))
-			}(p.runningJobs[t.job.Task.ID])
-
-			p.queue = p.queue[1:]
-			logger.TaskInfo("Task dequeued", t.job.Task.ID, string(t.job.Task.Status))
-			logger.TaskInfo("Task started", t.job.Task.ID, string(t.job.Task.Status))
+			p.dequeueJob()
 
 		case <-requestTimer.C:
 
@@ -221,9 +200,7 @@ func (p *JobPool) Run() {
 
 				p.sendProgress()
 
-				if util.Config.Runner.OneOff && len(p.runningJobs) > 0 && !p.hasRunningJobs() {
-					os.Exit(0)
-				}
+				p.exitIfOneOffFinished()
 
 				p.checkNewJobs()
 			}()
@@ -232,13 +209,76 @@ func (p *JobPool) Run() {
 	}
 }
 
-func (p *JobPool) sendProgress() {
+// exitIfOneOffFinished exits the one-off runner when all its jobs are finished.
+func (p *JobPool) exitIfOneOffFinished() {
+	if util.Config.Runner.OneOff && len(p.runningJobs) > 0 && !p.hasRunningJobs() {
+		os.Exit(0)
+	}
+}
 
-	logger := JobLogger{Context: "sending_progress"}
+// startQueuedJobs starts all jobs of the queue.
+func (p *JobPool) startQueuedJobs() {
+	for p.dequeueJob() {
+	}
+}
 
-	client := &http.Client{}
+// dequeueJob starts the first job of the queue. It returns false if the queue is empty.
+func (p *JobPool) dequeueJob() bool {
+	logger := JobLogger{Context: "running"}
 
-	url := util.Config.WebHost + "/api/internal/runners"
+	if len(p.queue) == 0 {
+		return false
+	}
+
+	t := p.queue[0]
+	if t.status == task_logger.TaskFailStatus {
+		//delete failed TaskRunner from queue
+		p.queue = p.queue[1:]
+		logger.TaskInfo("Task dequeued", t.job.Task.ID, "failed")
+		return true
+	}
+
+	p.runningJobs[t.job.Task.ID] = &runningJob{
+		job: t.job,
+	}
+
+	p.rememberCacheProjects(t.job)
+
+	t.job.Logger = t.job.App.SetLogger(p.runningJobs[t.job.Task.ID])
+
+	go func(runningJob *runningJob) {
+		runningJob.SetStatus(task_logger.TaskRunningStatus)
+
+		err := runningJob.job.Run(t.username, t.incomingVersion)
+
+		if runningJob.status.IsFinished() {
+			return
+		}
+
+		if err != nil {
+			if runningJob.status == task_logger.TaskStoppingStatus {
+				runningJob.SetStatus(task_logger.TaskStoppedStatus)
+			} else {
+				runningJob.SetStatus(task_logger.TaskFailStatus)
+			}
+		} else {
+			runningJob.SetStatus(task_logger.TaskSuccessStatus)
+		}
+
+		logger.TaskInfo("Task finished", runningJob.job.Task.ID, string(runningJob.status))
+	}(p.runningJobs[t.job.Task.ID])
+
+	p.queue = p.queue[1:]
+	logger.TaskInfo("Task dequeued", t.job.Task.ID, string(t.job.Task.Status))
+	logger.TaskInfo("Task started", t.job.Task.ID, string(t.job.Task.Status))
+
+	return true
+}
+
+// collectProgress returns logs and statuses of the running jobs since the previous call.
+// Finished jobs are removed from the running list.
+func (p *JobPool) collectProgress() RunnerProgress {
+	logger := JobLogger{Context: "sending_progress"}
 
 	body := RunnerProgress{
 		Jobs: nil,
@@ -250,9 +290,17 @@ func (p *JobPool) sendProgress() {
 			ID:         id,
 			LogRecords: j.logRecords,
 			Status:     j.status,
+			Findings:   j.findings,
+			RunOutputs: j.outputs,
+			Artifacts:  j.artifacts,
+			Commit:     j.commit,
 		})
 
 		j.logRecords = make([]LogRecord, 0)
+		j.findings = nil
+		j.outputs = nil
+		j.artifacts = nil
+		j.commit = nil
 
 		if j.status.IsFinished() {
 			logger.TaskInfo("Task removed from running list", id, string(j.status))
@@ -260,6 +308,28 @@ func (p *JobPool) sendProgress() {
 		}
 	}
 
+	if time.Since(p.diskUsageMeasured) >= diskUsageInterval {
+		p.diskUsageMeasured = time.Now()
+		body.DiskUsage = p.collectDiskUsage()
+	}
+
+	return body
+}
+
+func (p *JobPool) sendProgress() {
+
+	logger := JobLogger{Context: "sending_progress"}
+
+	client, err := newHTTPClient()
+	if err != nil {
+		logger.ActionError(err, "create client", "can not load client certificate")
+		return
+	}
+
+	url := util.Config.WebHost + "/api/internal/runners"
+
+	body := p.collectProgress()
+
 	jsonBytes, err := json.Marshal(body)
 
 	if err != nil {
@@ -299,7 +369,11 @@ func (p *JobPool) tryRegisterRunner() bool {
 		return false
 	}
 
-	client := &http.Client{}
+	client, err := newHTTPClient()
+	if err != nil {
+		logger.ActionError(err, "create client", "can not load client certificate")
+		return false
+	}
 
 	url := util.Config.WebHost + "/api/internal/runners"
 
@@ -366,12 +440,16 @@ func (p *JobPool) checkNewJobs() {
 
 	logger := JobLogger{Context: "checking new jobs"}
 
-	if util.Config.Runner.Token == "" {
+	if !hasCredentials() {
 		logger.ActionError(fmt.Errorf("no token provided"), "read input", "can not retrieve runner token")
 		return
 	}
 
-	client := &http.Client{}
+	client, err := newHTTPClient()
+	if err != nil {
+		logger.ActionError(err, "create client", "can not load client certificate")
+		return
+	}
 
 	url := util.Config.WebHost + "/api/internal/runners"
 
@@ -412,6 +490,15 @@ func (p *JobPool) checkNewJobs() {
 		return
 	}
 
+	p.applyState(response)
+}
+
+// applyState updates statuses of the running jobs by the state received from the server
+// and enqueues new jobs of the runner.
+func (p *JobPool) applyState(response RunnerState) {
+
+	logger := JobLogger{Context: "checking new jobs"}
+
 	for _, currJob := range response.CurrentJobs {
 		runJob, exists := p.runningJobs[currJob.ID]
 
@@ -503,6 +590,18 @@ func (p *JobPool) checkNewJobs() {
 		}
 		taskRunner.job.Template.Vaults = vaults
 
+		for i, file := range taskRunner.job.Template.SecretFiles {
+			key := response.AccessKeys[file.KeyID]
+			taskRunner.job.Template.SecretFiles[i].Key = &key
+		}
+
+		for i, artifact := range taskRunner.job.Template.Artifacts {
+			if artifact.KeyID != nil {
+				key := response.AccessKeys[*artifact.KeyID]
+				taskRunner.job.Template.Artifacts[i].Key = &key
+			}
+		}
+
 		if taskRunner.job.Inventory.RepositoryID != nil {
 			taskRunner.job.Inventory.Repository.SSHKey = response.AccessKeys[taskRunner.job.Inventory.Repository.SSHKeyID]
 		}
@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"time"
 
+	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/pkg/task_logger"
 	"github.com/semaphoreui/semaphore/services/tasks"
 	"github.com/semaphoreui/semaphore/util"
@@ -15,6 +16,10 @@ import (
 type runningJob struct {
 	status     task_logger.TaskStatus
 	logRecords []LogRecord
+	findings   []db.TaskFinding
+	outputs    []db.RunOutput
+	artifacts  []db.TaskArtifact
+	commit     *JobCommit
 	job        *tasks.LocalJob
 
 	statusListeners []task_logger.StatusListener
@@ -54,6 +59,29 @@ func (p *runningJob) LogfWithTime(now time.Time, format string, a ...any) {
 	p.LogWithTime(now, fmt.Sprintf(format, a...))
 }
 
+// AddFindings keeps findings of the pre-run checks until they are sent to the server.
+func (p *runningJob) AddFindings(findings []db.TaskFinding) {
+	p.findings = append(p.findings, findings...)
+}
+
+// SetRunOutputs keeps outputs registered by the job until they are sent to the server.
+func (p *runningJob) SetRunOutputs(outputs []db.RunOutput) {
+	p.outputs = append(p.outputs, outputs...)
+}
+
+// AddArtifacts keeps artifacts published by the job until they are sent to the server.
+func (p *runningJob) AddArtifacts(artifacts []db.TaskArtifact) {
+	p.artifacts = append(p.artifacts, artifacts...)
+}
+
+// SetCommit keeps the commit checked out by the job until it is sent to the server.
+func (p *runningJob) SetCommit(hash string, message string) {
+	p.commit = &JobCommit{
+		Hash:    hash,
+		Message: message,
+	}
+}
+
 func (p *runningJob) LogCmd(cmd *exec.Cmd) {
 	stderr, _ := cmd.StderrPipe()
 	stdout, _ := cmd.StdoutPipe()
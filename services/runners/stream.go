@@ -0,0 +1,321 @@
+package runners
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/semaphoreui/semaphore/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// The protocol v2 connects the runner to the server by the bidirectional gRPC stream.
+// The server pushes jobs and cancellations as soon as they happen and the runner
+// streams logs and statuses of its jobs, so neither side polls the other.
+// The stream is served on the port of the web server under StreamPath, runners
+// fall back to polling of the HTTP API if the server does not support it.
+const (
+	// StreamPath is the path prefix of the stream relative to the web host.
+	StreamPath = "/api/internal/runners/stream"
+
+	StreamServiceName = "semaphore.runner.v2.Runner"
+	StreamMethodName  = "Connect"
+
+	// streamCodecName is the content subtype of the stream, messages are encoded to JSON
+	// so the stream uses the same types as the HTTP API.
+	streamCodecName = "json"
+)
+
+const (
+	// StreamHeartbeatInterval is the period of heartbeats of the runner.
+	// The server answers the heartbeat with the full state of the runner.
+	StreamHeartbeatInterval = 15 * time.Second
+
+	// streamProgressInterval is the period of sending logs of running jobs.
+	streamProgressInterval = 250 * time.Millisecond
+
+	// streamConnectAttempts is the number of failed attempts to open the stream
+	// after which the runner falls back to polling. After the stream was opened
+	// once, the runner reconnects until it succeeds.
+	streamConnectAttempts = 3
+
+	streamRetryDelay = 5 * time.Second
+)
+
+// ServerMessage is sent by the server to the runner.
+type ServerMessage struct {
+	// State contains new jobs assigned to the runner and statuses of its current jobs.
+	State *RunnerState `json:",omitempty"`
+
+	// Cancel contains IDs of jobs which must be stopped by the runner.
+	Cancel []int `json:",omitempty"`
+}
+
+// RunnerMessage is sent by the runner to the server.
+type RunnerMessage struct {
+	// Progress contains logs and statuses of the jobs since the previous message.
+	Progress *RunnerProgress `json:",omitempty"`
+
+	// Heartbeat requests the full state of the runner. The runner sends it
+	// when it connects and periodically, so the stream is not closed by proxies.
+	Heartbeat bool `json:",omitempty"`
+}
+
+type streamCodec struct{}
+
+func (streamCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (streamCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (streamCodec) Name() string {
+	return streamCodecName
+}
+
+func init() {
+	encoding.RegisterCodec(streamCodec{})
+}
+
+// StreamDesc describes the single method of the stream service.
+var StreamDesc = grpc.StreamDesc{
+	StreamName:    StreamMethodName,
+	ServerStreams: true,
+	ClientStreams: true,
+}
+
+// NewStreamServer returns the gRPC server which calls handler for every stream of runners.
+func NewStreamServer(handler grpc.StreamHandler) *grpc.Server {
+	desc := StreamDesc
+	desc.Handler = handler
+
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: StreamServiceName,
+		HandlerType: (*any)(nil),
+		Streams:     []grpc.StreamDesc{desc},
+	}, struct{}{})
+
+	return server
+}
+
+// streamTarget returns the address of the server and the full method of the stream.
+// The method includes the path of the web host, so the stream passes
+// through the same reverse proxy as the HTTP API.
+func streamTarget(webHost string) (target string, method string, secure bool, err error) {
+	u, err := url.Parse(webHost)
+	if err != nil {
+		return
+	}
+
+	switch u.Scheme {
+	case "https":
+		secure = true
+	case "http":
+	default:
+		err = fmt.Errorf("unsupported scheme of web host %q", webHost)
+		return
+	}
+
+	target = u.Host
+	if u.Port() == "" {
+		port := "80"
+		if secure {
+			port = "443"
+		}
+		target = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	method = strings.TrimSuffix(u.Path, "/") + StreamPath + "/" + StreamServiceName + "/" + StreamMethodName
+	return
+}
+
+// openStream connects to the stream of the server. The returned function closes the connection.
+func openStream(ctx context.Context) (stream grpc.ClientStream, closeStream func(), err error) {
+	target, method, secure, err := streamTarget(util.Config.WebHost)
+	if err != nil {
+		return
+	}
+
+	creds := insecure.NewCredentials()
+	if secure {
+		var tlsConfig *tls.Config
+		tlsConfig, err = util.Config.Runner.ClientConfig()
+		if err != nil {
+			return
+		}
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	conn, err := grpc.NewClient(target,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{Time: time.Minute}),
+	)
+	if err != nil {
+		return
+	}
+
+	if util.Config.Runner.Token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-runner-token", util.Config.Runner.Token)
+	}
+
+	stream, err = conn.NewStream(ctx, &StreamDesc, method, grpc.CallContentSubtype(streamCodecName))
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+
+	closeStream = func() {
+		_ = conn.Close()
+	}
+
+	return
+}
+
+// isStreamUnsupported returns true if the server can not serve the stream,
+// for example the server of the older version or the proxy without HTTP/2.
+func isStreamUnsupported(err error) bool {
+	switch status.Code(err) {
+	case codes.Unimplemented, codes.Unauthenticated, codes.PermissionDenied, codes.NotFound:
+		return true
+	default:
+		return false
+	}
+}
+
+// runStream runs jobs received by the stream. It returns if the stream can not be opened,
+// so the runner falls back to polling.
+func (p *JobPool) runStream() {
+	logger := JobLogger{Context: "stream"}
+
+	established := false
+	failures := 0
+
+	for {
+		err := p.serveStream(func() {
+			if !established {
+				logger.Info("Connected to the server by the stream")
+			}
+			established = true
+		})
+
+		if !established {
+			failures++
+
+			if isStreamUnsupported(err) || failures >= streamConnectAttempts {
+				logger.ActionError(err, "open stream", "the server does not support the stream")
+				return
+			}
+		}
+
+		logger.ActionError(err, "receive message", "the stream is closed, reconnecting")
+		time.Sleep(streamRetryDelay)
+	}
+}
+
+// serveStream opens the stream and handles its messages until the stream is closed.
+// onConnected is called for every message of the server.
+func (p *JobPool) serveStream(onConnected func()) error {
+	logger := JobLogger{Context: "stream"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, closeStream, err := openStream(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeStream()
+
+	if err = stream.SendMsg(&RunnerMessage{Heartbeat: true}); err != nil {
+		return err
+	}
+
+	messages := make(chan *ServerMessage)
+	receiveErr := make(chan error, 1)
+
+	go func() {
+		for {
+			msg := &ServerMessage{}
+			if err := stream.RecvMsg(msg); err != nil {
+				receiveErr <- err
+				return
+			}
+
+			select {
+			case messages <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	queueTicker := time.NewTicker(time.Second)
+	progressTicker := time.NewTicker(streamProgressInterval)
+	heartbeatTicker := time.NewTicker(StreamHeartbeatInterval)
+
+	defer func() {
+		queueTicker.Stop()
+		progressTicker.Stop()
+		heartbeatTicker.Stop()
+	}()
+
+	for {
+		select {
+		case msg := <-messages:
+			onConnected()
+
+			for _, id := range msg.Cancel {
+				if j, ok := p.runningJobs[id]; ok && !j.status.IsFinished() {
+					logger.TaskInfo("Task cancelled by the server", id, string(j.status))
+					j.job.Kill()
+				}
+			}
+
+			if msg.State != nil {
+				p.applyState(*msg.State)
+			}
+
+			// jobs are started as soon as they are received
+			p.startQueuedJobs()
+
+		case err = <-receiveErr:
+			return err
+
+		case <-queueTicker.C:
+			p.startQueuedJobs()
+
+		case <-progressTicker.C:
+			progress := p.collectProgress()
+			if len(progress.Jobs) > 0 || progress.DiskUsage != nil {
+				if err = stream.SendMsg(&RunnerMessage{Progress: &progress}); err != nil {
+					return err
+				}
+			}
+
+			p.exitIfOneOffFinished()
+
+		case <-heartbeatTicker.C:
+			if err = stream.SendMsg(&RunnerMessage{Heartbeat: true}); err != nil {
+				return err
+			}
+		}
+	}
+}
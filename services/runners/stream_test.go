@@ -0,0 +1,49 @@
+package runners
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStreamTarget(t *testing.T) {
+	for _, v := range []struct {
+		webHost string
+		target  string
+		method  string
+		secure  bool
+	}{
+		{"http://localhost:3000", "localhost:3000", "/api/internal/runners/stream/semaphore.runner.v2.Runner/Connect", false},
+		{"https://semaphore.example.com", "semaphore.example.com:443", "/api/internal/runners/stream/semaphore.runner.v2.Runner/Connect", true},
+		{"http://example.com/semaphore/", "example.com:80", "/semaphore/api/internal/runners/stream/semaphore.runner.v2.Runner/Connect", false},
+	} {
+		target, method, secure, err := streamTarget(v.webHost)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if target != v.target || method != v.method || secure != v.secure {
+			t.Fatal("invalid stream target of "+v.webHost, target, method, secure)
+		}
+	}
+
+	if _, _, _, err := streamTarget("ftp://example.com"); err == nil {
+		t.Fatal("unsupported scheme must fail")
+	}
+}
+
+func TestIsStreamUnsupported(t *testing.T) {
+	if !isStreamUnsupported(status.Error(codes.Unimplemented, "404")) {
+		t.Fatal("server without the stream must be unsupported")
+	}
+
+	if isStreamUnsupported(status.Error(codes.Unavailable, "connection refused")) {
+		t.Fatal("unavailable server must be retried")
+	}
+
+	if isStreamUnsupported(errors.New("unknown")) {
+		t.Fatal("unknown error must be retried")
+	}
+}
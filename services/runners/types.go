@@ -37,12 +37,32 @@ type LogRecord struct {
 
 type RunnerProgress struct {
 	Jobs []JobProgress
+
+	// DiskUsage is sent periodically, it is empty in other messages.
+	DiskUsage *RunnerDiskUsage `json:",omitempty"`
+}
+
+// RunnerDiskUsage is the disk consumed by the tmp directory of the runner.
+type RunnerDiskUsage struct {
+	Usage []db.DiskUsage
+	// Evicted is the number of cache entries removed to fit the limits.
+	Evicted int
 }
 
 type JobProgress struct {
 	ID         int
 	Status     task_logger.TaskStatus
 	LogRecords []LogRecord
+	Findings   []db.TaskFinding  `json:",omitempty"`
+	RunOutputs []db.RunOutput    `json:",omitempty"`
+	Artifacts  []db.TaskArtifact `json:",omitempty"`
+	Commit     *JobCommit        `json:",omitempty"`
+}
+
+// JobCommit is the commit of the repository checked out by the job.
+type JobCommit struct {
+	Hash    string
+	Message string
 }
 
 type RunnerRegistration struct {
@@ -1,16 +1,28 @@
 package schedules
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/semaphoreui/semaphore/api/sse"
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/db_lib"
+	"github.com/semaphoreui/semaphore/services/ha"
 	"github.com/semaphoreui/semaphore/services/tasks"
 	"github.com/robfig/cron/v3"
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	scheduleSyncInterval = 30 * time.Second
+	// intervalScheduleCheckPeriod is the period of checking if interval schedules are due.
+	intervalScheduleCheckPeriod = time.Minute
+)
+
 type ScheduleRunner struct {
 	projectID  int
 	scheduleID int
@@ -53,6 +65,10 @@ func (r ScheduleRunner) tryUpdateScheduleCommitHash(schedule db.Schedule) (updat
 }
 
 func (r ScheduleRunner) Run() {
+	if !r.pool.isLeader() {
+		return
+	}
+
 	if !r.pool.store.PermanentConnection() {
 		r.pool.store.Connect("schedule " + strconv.Itoa(r.scheduleID))
 		defer r.pool.store.Close("schedule " + strconv.Itoa(r.scheduleID))
@@ -64,6 +80,39 @@ func (r ScheduleRunner) Run() {
 		return
 	}
 
+	project, err := r.pool.store.GetProject(schedule.ProjectID)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	// schedules of archived projects are kept, but never fired
+	if project.Archived {
+		return
+	}
+
+	// the schedule could be disabled by failures after the pool was refreshed
+	if schedule.AutoDisabled {
+		r.createScheduleEvent(schedule, db.EventActionScheduleSkip, "skipped because it is disabled after failures")
+		return
+	}
+
+	if schedule.IsInterval() {
+		var due bool
+		due, err = r.pool.isIntervalScheduleDue(schedule, time.Now())
+		if err != nil {
+			log.Error(err)
+			return
+		}
+		if !due {
+			return
+		}
+	}
+
+	if err = r.pool.store.SetScheduleLastFired(schedule.ProjectID, schedule.ID, time.Now()); err != nil {
+		log.Error(err)
+	}
+
 	if schedule.RepositoryID != nil {
 		var updated bool
 		updated, err = r.tryUpdateScheduleCommitHash(schedule)
@@ -76,11 +125,45 @@ func (r ScheduleRunner) Run() {
 		}
 	}
 
-	_, err = r.pool.taskPool.AddTask(db.Task{
+	task, err := r.pool.taskPool.AddTask(db.Task{
 		TemplateID: schedule.TemplateID,
 		ProjectID:  schedule.ProjectID,
+		ScheduleID: &schedule.ID,
 	}, nil, schedule.ProjectID)
 
+	if err != nil {
+		log.Error(err)
+		r.createScheduleEvent(schedule, db.EventActionScheduleSkip, "skipped because the task can not be added: "+err.Error())
+		return
+	}
+
+	r.createScheduleEvent(schedule, db.EventActionScheduleFire, "fired, task ID "+strconv.Itoa(task.ID)+" added")
+
+	sse.Publish(schedule.ProjectID, sse.EventScheduleFired, map[string]interface{}{
+		"schedule_id": schedule.ID,
+		"template_id": schedule.TemplateID,
+		"task_id":     task.ID,
+	})
+}
+
+// createScheduleEvent records that the schedule fired or was skipped. Runs of repository
+// schedules without new commits are not recorded because they are the normal result of polling.
+func (r ScheduleRunner) createScheduleEvent(schedule db.Schedule, action string, reason string) {
+	name := schedule.Name
+	if name == "" {
+		name = "ID " + strconv.Itoa(schedule.ID)
+	}
+
+	objType := db.EventSchedule
+	desc := "Schedule " + name + " " + reason
+
+	_, err := r.pool.store.CreateEvent(db.Event{
+		ProjectID:   &schedule.ProjectID,
+		ObjectType:  &objType,
+		ObjectID:    &schedule.ID,
+		Action:      action,
+		Description: &desc,
+	})
 	if err != nil {
 		log.Error(err)
 	}
@@ -91,11 +174,23 @@ type SchedulePool struct {
 	locker   sync.Locker
 	store    db.Store
 	taskPool *tasks.TaskPool
+	drift    *driftState
+	// elector is used in HA mode, only the leader node fires schedules.
+	elector *ha.Elector
+	// fingerprint identifies the loaded schedules, it is used to detect
+	// changes made by other nodes in HA mode.
+	fingerprint *string
+}
+
+func (p *SchedulePool) isLeader() bool {
+	return p.elector == nil || p.elector.IsLeader()
 }
 
 func (p *SchedulePool) init() {
 	p.cron = cron.New()
 	p.locker = &sync.Mutex{}
+	p.drift = newDriftState()
+	p.fingerprint = new(string)
 }
 
 func (p *SchedulePool) Refresh() {
@@ -108,24 +203,70 @@ func (p *SchedulePool) Refresh() {
 		return
 	}
 
+	p.drift.register(schedules, time.Now())
+
 	p.locker.Lock()
+
+	projects, err := p.store.GetAllProjects()
+
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	*p.fingerprint = getSchedulesFingerprint(schedules) + getDigestsFingerprint(projects) + getAccessReviewsFingerprint(projects)
 	p.clear()
+	p.addDigestRunners(projects)
+	p.addAccessReviewRunners(projects)
 	for _, schedule := range schedules {
-		if schedule.RepositoryID == nil && !schedule.Active {
+		if schedule.RepositoryID == nil && !schedule.Active || schedule.AutoDisabled {
 			continue
 		}
 
-		_, err := p.addRunner(ScheduleRunner{
+		runner := ScheduleRunner{
 			projectID:  schedule.ProjectID,
 			scheduleID: schedule.ID,
 			pool:       p,
-		}, schedule.CronFormat)
+		}
+
+		if schedule.IsInterval() {
+			// the runner checks on every tick if the previous run finished and the interval is passed
+			p.cron.Schedule(cron.Every(intervalScheduleCheckPeriod), runner)
+			continue
+		}
+
+		_, err := p.addRunner(runner, schedule.CronFormat)
 		if err != nil {
 			log.Error(err)
 		}
 	}
 }
 
+// getScheduleLastTask returns the last task started by the schedule or nil.
+func (p *SchedulePool) getScheduleLastTask(schedule db.Schedule) (*db.Task, error) {
+	task, err := p.store.GetScheduleLastTask(schedule.ProjectID, schedule.ID)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// isIntervalScheduleDue returns true if the previous run of the interval schedule
+// finished and the interval is passed.
+func (p *SchedulePool) isIntervalScheduleDue(schedule db.Schedule, now time.Time) (bool, error) {
+	lastTask, err := p.getScheduleLastTask(schedule)
+	if err != nil {
+		return false, err
+	}
+
+	next := schedule.GetIntervalNextFire(lastTask, p.drift.registeredAt(schedule.ID))
+
+	return next != nil && !next.After(now), nil
+}
+
 func (p *SchedulePool) addRunner(runner ScheduleRunner, cronFormat string) (int, error) {
 	id, err := p.cron.AddJob(cronFormat, runner)
 
@@ -137,9 +278,71 @@ func (p *SchedulePool) addRunner(runner ScheduleRunner, cronFormat string) (int,
 }
 
 func (p *SchedulePool) Run() {
+	go p.runDriftCheck()
+	if ha.IsEnabled() {
+		go p.runSync()
+	}
 	p.cron.Run()
 }
 
+func getSchedulesFingerprint(schedules []db.Schedule) string {
+	var b strings.Builder
+	for _, schedule := range schedules {
+		repositoryID := 0
+		if schedule.RepositoryID != nil {
+			repositoryID = *schedule.RepositoryID
+		}
+		fmt.Fprintf(&b, "%d:%s:%t:%t:%d;", schedule.ID, schedule.GetFormat(), schedule.Active, schedule.AutoDisabled, repositoryID)
+	}
+	return b.String()
+}
+
+// runSync reloads schedules changed by other nodes in HA mode.
+func (p *SchedulePool) runSync() {
+	ticker := time.NewTicker(scheduleSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.drift.done:
+			return
+		case <-ticker.C:
+			p.syncSchedules()
+		}
+	}
+}
+
+func (p *SchedulePool) syncSchedules() {
+	if !p.isLeader() {
+		return
+	}
+
+	if !p.store.PermanentConnection() {
+		p.store.Connect("schedule sync")
+		defer p.store.Close("schedule sync")
+	}
+
+	schedules, err := p.store.GetSchedules()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	projects, err := p.store.GetAllProjects()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	p.locker.Lock()
+	changed := *p.fingerprint != getSchedulesFingerprint(schedules)+getDigestsFingerprint(projects)+getAccessReviewsFingerprint(projects)
+	p.locker.Unlock()
+
+	if changed {
+		p.Refresh()
+	}
+}
+
 func (p *SchedulePool) clear() {
 	runners := p.cron.Entries()
 	for _, r := range runners {
@@ -153,12 +356,14 @@ func (p *SchedulePool) Destroy() {
 	p.cron.Stop()
 	p.clear()
 	p.cron = nil
+	close(p.drift.done)
 }
 
-func CreateSchedulePool(store db.Store, taskPool *tasks.TaskPool) SchedulePool {
+func CreateSchedulePool(store db.Store, taskPool *tasks.TaskPool, elector *ha.Elector) SchedulePool {
 	pool := SchedulePool{
 		store:    store,
 		taskPool: taskPool,
+		elector:  elector,
 	}
 	pool.init()
 	pool.Refresh()
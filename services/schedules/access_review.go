@@ -0,0 +1,71 @@
+package schedules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/tasks"
+	log "github.com/sirupsen/logrus"
+)
+
+// AccessReviewRunner sends the access review report of the project to its owners.
+type AccessReviewRunner struct {
+	projectID int
+	pool      *SchedulePool
+}
+
+func (r AccessReviewRunner) Run() {
+	if !r.pool.isLeader() {
+		return
+	}
+
+	if !r.pool.store.PermanentConnection() {
+		r.pool.store.Connect("access review " + strconv.Itoa(r.projectID))
+		defer r.pool.store.Close("access review " + strconv.Itoa(r.projectID))
+	}
+
+	project, err := r.pool.store.GetProject(r.projectID)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	if project.AccessReview == "" || project.Archived {
+		return
+	}
+
+	if err = tasks.SendProjectAccessReview(r.pool.store, project, time.Now()); err != nil {
+		log.Error(err)
+	}
+}
+
+// addAccessReviewRunners adds runners of the access reviews of the projects. The locker must be locked.
+func (p *SchedulePool) addAccessReviewRunners(projects []db.Project) {
+	for _, project := range projects {
+		if project.AccessReview == "" {
+			continue
+		}
+
+		_, err := p.cron.AddJob(project.AccessReview, AccessReviewRunner{
+			projectID: project.ID,
+			pool:      p,
+		})
+		if err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+func getAccessReviewsFingerprint(projects []db.Project) string {
+	var b strings.Builder
+	for _, project := range projects {
+		if project.AccessReview == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "access_review%d:%s;", project.ID, project.AccessReview)
+	}
+	return b.String()
+}
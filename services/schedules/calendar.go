@@ -0,0 +1,183 @@
+package schedules
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+const (
+	calendarTimeFormat = "20060102T150405Z"
+	// maxCalendarEventsPerSchedule limits the feed size for schedules which fire very often.
+	maxCalendarEventsPerSchedule = 500
+	// calendarLineLength is the maximum length of the iCalendar content line in octets.
+	calendarLineLength = 75
+)
+
+// CalendarEvent is an upcoming run of the schedule.
+type CalendarEvent struct {
+	UID         string
+	Start       time.Time
+	Summary     string
+	Description string
+	URL         string
+}
+
+// GetScheduleOccurrences returns fire times of the schedule in the interval (from, to].
+func GetScheduleOccurrences(cronFormat string, from time.Time, to time.Time, limit int) ([]time.Time, error) {
+	sched, err := cron.ParseStandard(cronFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []time.Time
+	for t := sched.Next(from); len(res) < limit && !t.IsZero() && !t.After(to); t = sched.Next(t) {
+		res = append(res, t)
+	}
+
+	return res, nil
+}
+
+// GetProjectCalendarEvents returns upcoming runs of active schedules of the project.
+// Schedules which check repository for new commits are skipped because
+// it is unknown if they will run a task. Interval schedules are skipped because
+// their runs depend on the duration of the previous runs.
+func GetProjectCalendarEvents(store db.Store, projectID int, from time.Time, to time.Time) (events []CalendarEvent, err error) {
+	schedules, err := store.GetProjectSchedules(projectID)
+	if err != nil {
+		return
+	}
+
+	for _, schedule := range schedules {
+		if !schedule.Active || schedule.RepositoryID != nil || schedule.IsInterval() {
+			continue
+		}
+
+		occurrences, parseErr := GetScheduleOccurrences(schedule.CronFormat, from, to, maxCalendarEventsPerSchedule)
+		if parseErr != nil {
+			continue
+		}
+
+		summary := schedule.TemplateName
+		if schedule.Name != "" {
+			summary = schedule.Name + " (" + schedule.TemplateName + ")"
+		}
+
+		for _, t := range occurrences {
+			events = append(events, CalendarEvent{
+				UID:         fmt.Sprintf("schedule-%d-%d@semaphore", schedule.ID, t.Unix()),
+				Start:       t,
+				Summary:     summary,
+				Description: "Scheduled run: " + schedule.CronFormat,
+				URL:         fmt.Sprintf("%s/project/%d/templates/%d", util.Config.WebHost, projectID, schedule.TemplateID),
+			})
+		}
+	}
+
+	return
+}
+
+// FillTemplatesNextRun sets NextRun of the template stats to the nearest run
+// of the active schedules of the template.
+func FillTemplatesNextRun(store db.Store, projectID int, stats []db.TemplateStats, now time.Time) error {
+	schedules, err := store.GetProjectSchedules(projectID)
+	if err != nil {
+		return err
+	}
+
+	for _, schedule := range schedules {
+		if !schedule.Active {
+			continue
+		}
+
+		sched, parseErr := cron.ParseStandard(schedule.CronFormat)
+		if parseErr != nil {
+			continue
+		}
+
+		next := sched.Next(now)
+
+		for i := range stats {
+			if stats[i].TemplateID != schedule.TemplateID {
+				continue
+			}
+			if stats[i].NextRun == nil || next.Before(*stats[i].NextRun) {
+				stats[i].NextRun = &next
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
+func escapeCalendarText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// foldCalendarLine splits the line into lines of at most 75 octets
+// without breaking UTF-8 sequences (RFC 5545, section 3.1).
+func foldCalendarLine(line string) string {
+	var b strings.Builder
+	length := 0
+
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > calendarLineLength {
+			b.WriteString("\r\n ")
+			length = 1
+		}
+		b.WriteRune(r)
+		length += size
+	}
+
+	return b.String()
+}
+
+// WriteCalendar writes the events in iCalendar format.
+func WriteCalendar(w io.Writer, name string, events []CalendarEvent, now time.Time) error {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Semaphore UI//Schedules//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:" + escapeCalendarText(name),
+	}
+
+	for _, event := range events {
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+event.UID,
+			"DTSTAMP:"+now.UTC().Format(calendarTimeFormat),
+			"DTSTART:"+event.Start.UTC().Format(calendarTimeFormat),
+			"SUMMARY:"+escapeCalendarText(event.Summary),
+			"DESCRIPTION:"+escapeCalendarText(event.Description),
+		)
+		if event.URL != "" {
+			lines = append(lines, "URL:"+event.URL)
+		}
+		lines = append(lines, "END:VEVENT")
+	}
+
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		if _, err := io.WriteString(w, foldCalendarLine(line)+"\r\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package schedules
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetScheduleOccurrences(t *testing.T) {
+	from := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	occurrences, err := GetScheduleOccurrences("0 12 * * *", from, from.AddDate(0, 0, 3), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(occurrences) != 3 || !occurrences[0].Equal(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Fatal("invalid occurrences", occurrences)
+	}
+
+	occurrences, err = GetScheduleOccurrences("* * * * *", from, from.AddDate(0, 0, 3), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(occurrences) != 10 {
+		t.Fatal("occurrences must be limited")
+	}
+
+	if _, err = GetScheduleOccurrences("invalid", from, from, 10); err == nil {
+		t.Fatal("invalid cron format must fail")
+	}
+}
+
+func TestWriteCalendar(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	err := WriteCalendar(&buf, "Ops, prod", []CalendarEvent{{
+		UID:         "schedule-1-1704110400@semaphore",
+		Start:       time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		Summary:     "Nightly; backup",
+		Description: strings.Repeat("x", 100),
+	}}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := buf.String()
+
+	for _, expected := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:Ops\\, prod\r\n",
+		"DTSTART:20240101T120000Z\r\n",
+		"SUMMARY:Nightly\\; backup\r\n",
+		"\r\n " + strings.Repeat("x", 37) + "\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(res, expected) {
+			t.Fatal("calendar must contain " + expected)
+		}
+	}
+
+	for _, line := range strings.Split(res, "\r\n") {
+		if len(line) > calendarLineLength {
+			t.Fatal("line is too long: " + line)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package schedules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/tasks"
+	log "github.com/sirupsen/logrus"
+)
+
+// DigestRunner sends the digest of scheduled runs of the project.
+type DigestRunner struct {
+	projectID int
+	pool      *SchedulePool
+}
+
+func (r DigestRunner) Run() {
+	if !r.pool.isLeader() {
+		return
+	}
+
+	if !r.pool.store.PermanentConnection() {
+		r.pool.store.Connect("digest " + strconv.Itoa(r.projectID))
+		defer r.pool.store.Close("digest " + strconv.Itoa(r.projectID))
+	}
+
+	project, err := r.pool.store.GetProject(r.projectID)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	if project.AlertDigest == "" || project.Archived {
+		return
+	}
+
+	if err = tasks.SendProjectDigest(r.pool.store, project, time.Now()); err != nil {
+		log.Error(err)
+	}
+}
+
+// addDigestRunners adds runners of the digests of the projects. The locker must be locked.
+// Runners of archived projects are added too, they are skipped when fired.
+func (p *SchedulePool) addDigestRunners(projects []db.Project) {
+	for _, project := range projects {
+		if project.AlertDigest == "" {
+			continue
+		}
+
+		_, err := p.cron.AddJob(project.AlertDigest, DigestRunner{
+			projectID: project.ID,
+			pool:      p,
+		})
+		if err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+func getDigestsFingerprint(projects []db.Project) string {
+	var b strings.Builder
+	for _, project := range projects {
+		if project.AlertDigest == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "digest%d:%s;", project.ID, project.AlertDigest)
+	}
+	return b.String()
+}
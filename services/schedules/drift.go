@@ -0,0 +1,299 @@
+package schedules
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/i18n"
+	"github.com/semaphoreui/semaphore/services/tasks"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// scheduleFireGracePeriod is the delay after which not fired schedule is considered as missed.
+	scheduleFireGracePeriod = time.Minute
+	driftCheckInterval      = time.Minute
+	// maxScheduleOccurrences limits the search of the expected fire time
+	// for schedules which fire very often.
+	maxScheduleOccurrences = 100000
+)
+
+type scheduleRegistration struct {
+	format string
+	at     time.Time
+}
+
+// driftState is shared between copies of the schedule pool.
+type driftState struct {
+	mu sync.Mutex
+	// registered contains time since which enabled schedules are expected to fire.
+	registered  map[int]scheduleRegistration
+	alerted     map[int]time.Time
+	initialized bool
+	done        chan struct{}
+}
+
+func newDriftState() *driftState {
+	return &driftState{
+		registered: make(map[int]scheduleRegistration),
+		alerted:    make(map[int]time.Time),
+		done:       make(chan struct{}),
+	}
+}
+
+// register updates registrations of the schedules. On the first call the schedules
+// are expected to fire since their last fire time, so schedules missed while
+// Semaphore was down are detected. Schedules enabled or changed later are
+// expected to fire since now.
+func (s *driftState) register(schedules []db.Schedule, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	registered := make(map[int]scheduleRegistration)
+
+	for _, schedule := range schedules {
+		if !isScheduleEnabled(schedule) {
+			continue
+		}
+
+		reg, ok := s.registered[schedule.ID]
+		if !ok || reg.format != schedule.GetFormat() {
+			reg = scheduleRegistration{format: schedule.GetFormat(), at: now}
+			if !s.initialized && schedule.LastFired != nil {
+				reg.at = *schedule.LastFired
+			}
+		}
+
+		registered[schedule.ID] = reg
+	}
+
+	s.registered = registered
+	s.initialized = true
+}
+
+func (s *driftState) registeredAt(scheduleID int) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if reg, ok := s.registered[scheduleID]; ok {
+		return reg.at
+	}
+
+	// the schedule is not registered, so it is not expected to fire
+	return time.Now()
+}
+
+// markAlerted returns false if the alert was already sent for the occurrence.
+func (s *driftState) markAlerted(scheduleID int, expected time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, ok := s.alerted[scheduleID]; ok && !expected.After(last) {
+		return false
+	}
+
+	s.alerted[scheduleID] = expected
+	return true
+}
+
+func isScheduleEnabled(schedule db.Schedule) bool {
+	// schedules of the repository are checked even if they are not active, see Refresh
+	return schedule.Active || schedule.RepositoryID != nil
+}
+
+// GetScheduleStatus returns the status of the schedule at the moment now.
+// Occurrences before since (the time when the schedule was registered)
+// and before the last fire time are not expected.
+func GetScheduleStatus(schedule db.Schedule, since time.Time, now time.Time) db.ScheduleStatus {
+	status := db.ScheduleStatus{
+		ScheduleID: schedule.ID,
+		TemplateID: schedule.TemplateID,
+		Name:       schedule.Name,
+		CronFormat: schedule.CronFormat,
+		LastFired:  schedule.LastFired,
+		State:      db.ScheduleStateOK,
+	}
+
+	sched, err := cron.ParseStandard(schedule.CronFormat)
+	if err != nil {
+		status.State = db.ScheduleStateInvalid
+		return status
+	}
+
+	if !isScheduleEnabled(schedule) {
+		status.State = db.ScheduleStateDisabled
+		return status
+	}
+
+	next := sched.Next(now)
+	status.NextFire = &next
+
+	ref := since
+	if schedule.LastFired != nil && schedule.LastFired.After(ref) {
+		ref = *schedule.LastFired
+	}
+
+	deadline := now.Add(-scheduleFireGracePeriod)
+
+	var expected *time.Time
+	for i, t := 0, sched.Next(ref); i < maxScheduleOccurrences && !t.IsZero() && !t.After(deadline); i, t = i+1, sched.Next(t) {
+		occurrence := t
+		expected = &occurrence
+	}
+
+	if expected != nil {
+		status.State = db.ScheduleStateMissed
+		status.ExpectedFired = expected
+	}
+
+	return status
+}
+
+// GetIntervalScheduleStatus returns the status of the interval schedule at the moment now.
+// lastTask is the last task started by the schedule. The schedule is missed if
+// the previous run finished but the schedule has not fired after the interval.
+func GetIntervalScheduleStatus(schedule db.Schedule, lastTask *db.Task, since time.Time, now time.Time) db.ScheduleStatus {
+	status := db.ScheduleStatus{
+		ScheduleID: schedule.ID,
+		TemplateID: schedule.TemplateID,
+		Name:       schedule.Name,
+		CronFormat: schedule.CronFormat,
+		LastFired:  schedule.LastFired,
+		State:      db.ScheduleStateOK,
+	}
+
+	if schedule.ValidateInterval() != nil {
+		status.State = db.ScheduleStateInvalid
+		return status
+	}
+
+	if !isScheduleEnabled(schedule) {
+		status.State = db.ScheduleStateDisabled
+		return status
+	}
+
+	// the previous run is not finished, so the next run is unknown
+	next := schedule.GetIntervalNextFire(lastTask, since)
+	if next == nil {
+		return status
+	}
+
+	status.NextFire = next
+
+	// interval schedules are checked periodically, so they can fire later than expected by the check period
+	if next.Before(now.Add(-scheduleFireGracePeriod - intervalScheduleCheckPeriod)) {
+		status.State = db.ScheduleStateMissed
+		status.ExpectedFired = next
+	}
+
+	return status
+}
+
+func (p *SchedulePool) getScheduleStatus(schedule db.Schedule, now time.Time) (db.ScheduleStatus, error) {
+	since := p.drift.registeredAt(schedule.ID)
+
+	if !schedule.IsInterval() {
+		return GetScheduleStatus(schedule, since, now), nil
+	}
+
+	lastTask, err := p.getScheduleLastTask(schedule)
+	if err != nil {
+		return db.ScheduleStatus{}, err
+	}
+
+	return GetIntervalScheduleStatus(schedule, lastTask, since, now), nil
+}
+
+// GetProjectScheduleStatuses returns statuses of all schedules of the project.
+func (p *SchedulePool) GetProjectScheduleStatuses(projectID int, now time.Time) (statuses []db.ScheduleStatus, err error) {
+	schedules, err := p.store.GetProjectSchedules(projectID)
+	if err != nil {
+		return
+	}
+
+	statuses = make([]db.ScheduleStatus, 0)
+
+	for _, schedule := range schedules {
+		var status db.ScheduleStatus
+		status, err = p.getScheduleStatus(schedule.Schedule, now)
+		if err != nil {
+			return
+		}
+		statuses = append(statuses, status)
+	}
+
+	return
+}
+
+func (p *SchedulePool) runDriftCheck() {
+	ticker := time.NewTicker(driftCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.drift.done:
+			return
+		case now := <-ticker.C:
+			p.checkDrift(now)
+		}
+	}
+}
+
+// checkDrift sends alerts about schedules which have not fired when expected.
+// The alert is sent once for every missed occurrence.
+func (p *SchedulePool) checkDrift(now time.Time) {
+	if !p.isLeader() {
+		return
+	}
+
+	if !p.store.PermanentConnection() {
+		p.store.Connect("schedule drift")
+		defer p.store.Close("schedule drift")
+	}
+
+	schedules, err := p.store.GetSchedules()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		status, err := p.getScheduleStatus(schedule, now)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+
+		if status.State != db.ScheduleStateMissed || !p.drift.markAlerted(schedule.ID, *status.ExpectedFired) {
+			continue
+		}
+
+		project, err := p.store.GetProject(schedule.ProjectID)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+
+		// schedules of archived projects are not fired intentionally
+		if project.Archived {
+			continue
+		}
+
+		log.Warn("Schedule " + strconv.Itoa(schedule.ID) + " did not fire at " + status.ExpectedFired.Format(time.RFC3339))
+
+		tasks.SendProjectAlert(p.store, project, tasks.ProjectAlert{
+			Subject: i18n.Msg("Schedule '%s' did not fire", schedule.Name),
+			Text: i18n.Msg("Project %s: schedule '%s' (%s) was expected to fire at %s",
+				project.Name,
+				schedule.Name,
+				schedule.GetFormat(),
+				status.ExpectedFired.Format(time.RFC3339)),
+			URL: fmt.Sprintf("%s/project/%d/templates/%d", util.Config.WebHost, schedule.ProjectID, schedule.TemplateID),
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package schedules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+func TestGetScheduleStatus(t *testing.T) {
+	since := time.Date(2024, 1, 1, 10, 30, 0, 0, time.Local)
+	now := time.Date(2024, 1, 1, 12, 30, 0, 0, time.Local)
+
+	schedule := db.Schedule{ID: 1, CronFormat: "0 * * * *", Active: true}
+
+	status := GetScheduleStatus(schedule, since, now)
+	if status.State != db.ScheduleStateMissed || !status.ExpectedFired.Equal(time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)) {
+		t.Fatal("schedule must be missed at 12:00")
+	}
+
+	if !status.NextFire.Equal(time.Date(2024, 1, 1, 13, 0, 0, 0, time.Local)) {
+		t.Fatal("invalid next fire time")
+	}
+
+	lastFired := time.Date(2024, 1, 1, 12, 0, 5, 0, time.Local)
+	schedule.LastFired = &lastFired
+
+	status = GetScheduleStatus(schedule, since, now)
+	if status.State != db.ScheduleStateOK || status.ExpectedFired != nil {
+		t.Fatal("schedule must be ok")
+	}
+
+	// occurrence is not missed during grace period
+	status = GetScheduleStatus(schedule, since, time.Date(2024, 1, 1, 13, 0, 30, 0, time.Local))
+	if status.State != db.ScheduleStateOK {
+		t.Fatal("schedule must be ok during grace period")
+	}
+
+	schedule.Active = false
+	if GetScheduleStatus(schedule, since, now).State != db.ScheduleStateDisabled {
+		t.Fatal("schedule must be disabled")
+	}
+
+	schedule.CronFormat = "invalid"
+	if GetScheduleStatus(schedule, since, now).State != db.ScheduleStateInvalid {
+		t.Fatal("schedule must be invalid")
+	}
+}
+
+func TestDriftStateRegister(t *testing.T) {
+	state := newDriftState()
+
+	lastFired := time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+
+	schedules := []db.Schedule{
+		{ID: 1, CronFormat: "0 * * * *", Active: true, LastFired: &lastFired},
+		{ID: 2, CronFormat: "0 * * * *", Active: false, LastFired: &lastFired},
+	}
+
+	state.register(schedules, start)
+
+	if !state.registeredAt(1).Equal(lastFired) {
+		t.Fatal("schedule must be expected since last fire on start")
+	}
+
+	later := start.Add(time.Hour)
+	schedules[1].Active = true
+	schedules[0].CronFormat = "30 * * * *"
+	state.register(schedules, later)
+
+	if !state.registeredAt(1).Equal(later) || !state.registeredAt(2).Equal(later) {
+		t.Fatal("changed and enabled schedules must be expected since refresh")
+	}
+
+	if !state.markAlerted(1, later) || state.markAlerted(1, later) {
+		t.Fatal("alert must be sent once")
+	}
+}
+
+func TestGetIntervalScheduleStatus(t *testing.T) {
+	since := time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local)
+
+	lastFired := time.Date(2024, 1, 1, 11, 0, 0, 0, time.Local)
+	schedule := db.Schedule{
+		ID:              1,
+		Type:            db.ScheduleTypeInterval,
+		IntervalMinutes: 15,
+		Active:          true,
+		LastFired:       &lastFired,
+	}
+
+	// the previous run is still active, so the schedule waits for it
+	running := db.Task{Status: task_logger.TaskRunningStatus}
+	status := GetIntervalScheduleStatus(schedule, &running, since, time.Date(2024, 1, 1, 13, 0, 0, 0, time.Local))
+	if status.State != db.ScheduleStateOK || status.NextFire != nil {
+		t.Fatal("schedule must wait for the previous run")
+	}
+
+	// the interval is counted from the end of the previous run
+	end := time.Date(2024, 1, 1, 11, 40, 0, 0, time.Local)
+	finished := db.Task{Status: task_logger.TaskSuccessStatus, End: &end}
+	status = GetIntervalScheduleStatus(schedule, &finished, since, time.Date(2024, 1, 1, 11, 50, 0, 0, time.Local))
+	if status.State != db.ScheduleStateOK || !status.NextFire.Equal(time.Date(2024, 1, 1, 11, 55, 0, 0, time.Local)) {
+		t.Fatalf("invalid next fire time %v", status.NextFire)
+	}
+
+	status = GetIntervalScheduleStatus(schedule, &finished, since, time.Date(2024, 1, 1, 12, 30, 0, 0, time.Local))
+	if status.State != db.ScheduleStateMissed {
+		t.Fatal("schedule must be missed")
+	}
+
+	schedule.IntervalMinutes = 0
+	if GetIntervalScheduleStatus(schedule, nil, since, since).State != db.ScheduleStateInvalid {
+		t.Fatal("schedule without interval must be invalid")
+	}
+}
@@ -0,0 +1,102 @@
+package schedules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// DefaultPreviewCount is the number of fire times returned by the preview by default.
+	DefaultPreviewCount = 5
+	// MaxPreviewCount is the maximum number of fire times returned by the preview.
+	MaxPreviewCount = 100
+)
+
+// CronPreviewRequest is the cron format of the schedule which is checked before saving.
+type CronPreviewRequest struct {
+	CronFormat string `json:"cron_format"`
+	// Timezone is the IANA name of the time zone of the cron format, for example Europe/Berlin.
+	// The time zone of the server is used if it is empty.
+	Timezone string `json:"timezone"`
+	// Count is the number of fire times to return, DefaultPreviewCount by default.
+	Count int `json:"count"`
+}
+
+// CronPreview contains the upcoming fire times of the valid cron format.
+type CronPreview struct {
+	// CronFormat is the cron format which must be saved to the schedule.
+	// It contains the time zone of the request as the CRON_TZ prefix.
+	CronFormat string      `json:"cron_format"`
+	Timezone   string      `json:"timezone"`
+	Next       []time.Time `json:"next"`
+}
+
+// splitCronTimezone returns the time zone of the CRON_TZ or TZ prefix and the rest of the cron format.
+func splitCronTimezone(cronFormat string) (timezone string, spec string) {
+	spec = strings.TrimSpace(cronFormat)
+
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if strings.HasPrefix(spec, prefix) {
+			timezone, spec, _ = strings.Cut(strings.TrimPrefix(spec, prefix), " ")
+			return timezone, strings.TrimSpace(spec)
+		}
+	}
+
+	return "", spec
+}
+
+// PreviewCronFormat validates the cron format in the time zone of the request and returns
+// the fire times after from. The schedule pool parses the saved cron format the same way.
+func PreviewCronFormat(req CronPreviewRequest, from time.Time) (preview CronPreview, err error) {
+	count := req.Count
+	if count <= 0 {
+		count = DefaultPreviewCount
+	}
+
+	if count > MaxPreviewCount {
+		err = fmt.Errorf("count can not be greater than %d", MaxPreviewCount)
+		return
+	}
+
+	timezone, spec := splitCronTimezone(req.CronFormat)
+
+	if req.Timezone != "" {
+		if timezone != "" && timezone != req.Timezone {
+			err = fmt.Errorf("time zone %s differs from the time zone of the cron format %s", req.Timezone, timezone)
+			return
+		}
+		timezone = req.Timezone
+	}
+
+	loc := time.Local
+	preview.CronFormat = spec
+
+	if timezone != "" {
+		loc, err = time.LoadLocation(timezone)
+		if err != nil {
+			err = fmt.Errorf("unknown time zone %s", timezone)
+			return
+		}
+		preview.CronFormat = "CRON_TZ=" + timezone + " " + spec
+	}
+
+	sched, err := cron.ParseStandard(preview.CronFormat)
+	if err != nil {
+		return
+	}
+
+	preview.Timezone = timezone
+	if preview.Timezone == "" {
+		preview.Timezone, _ = from.In(loc).Zone()
+	}
+	preview.Next = make([]time.Time, 0, count)
+
+	for t := sched.Next(from.In(loc)); len(preview.Next) < count && !t.IsZero(); t = sched.Next(t) {
+		preview.Next = append(preview.Next, t)
+	}
+
+	return
+}
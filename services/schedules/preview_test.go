@@ -0,0 +1,60 @@
+package schedules
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPreviewCronFormat(t *testing.T) {
+	from := time.Date(2024, 3, 30, 12, 0, 0, 0, time.UTC)
+
+	preview, err := PreviewCronFormat(CronPreviewRequest{
+		CronFormat: "0 9 * * *",
+		Timezone:   "Europe/Berlin",
+		Count:      3,
+	}, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if preview.CronFormat != "CRON_TZ=Europe/Berlin 0 9 * * *" {
+		t.Fatal("time zone must be added to the cron format", preview.CronFormat)
+	}
+
+	// the daylight saving time starts in Berlin on March 31
+	expected := []time.Time{
+		time.Date(2024, 3, 31, 7, 0, 0, 0, time.UTC),
+		time.Date(2024, 4, 1, 7, 0, 0, 0, time.UTC),
+		time.Date(2024, 4, 2, 7, 0, 0, 0, time.UTC),
+	}
+
+	if len(preview.Next) != len(expected) {
+		t.Fatal("invalid number of fire times", preview.Next)
+	}
+
+	for i := range expected {
+		if !preview.Next[i].Equal(expected[i]) {
+			t.Fatal("invalid fire times", preview.Next)
+		}
+	}
+
+	preview, err = PreviewCronFormat(CronPreviewRequest{CronFormat: "TZ=Asia/Tokyo 30 * * * *"}, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if preview.Timezone != "Asia/Tokyo" || len(preview.Next) != DefaultPreviewCount {
+		t.Fatal("time zone of the cron format must be used", preview)
+	}
+
+	for _, req := range []CronPreviewRequest{
+		{CronFormat: "0 9 * *"},
+		{CronFormat: "0 9 * * *", Timezone: "Mars/Olympus"},
+		{CronFormat: "CRON_TZ=Asia/Tokyo 0 9 * * *", Timezone: "Europe/Berlin"},
+		{CronFormat: "0 9 * * *", Count: MaxPreviewCount + 1},
+	} {
+		if _, err = PreviewCronFormat(req, from); err == nil {
+			t.Error("request must be rejected", req)
+		}
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"os"
 
 	"path"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/db_lib"
@@ -25,6 +27,10 @@ type LocalJob struct {
 	Secret      string
 	Logger      task_logger.Logger
 
+	// BecomePassword is the become password entered at the launch of the task.
+	// It is used instead of the password of the inventory's become key.
+	BecomePassword *sealedSecret
+
 	App db_lib.LocalApp
 
 	// Internal field
@@ -33,6 +39,16 @@ type LocalJob struct {
 	sshKeyInstallation     db.AccessKeyInstallation
 	becomeKeyInstallation  db.AccessKeyInstallation
 	vaultFileInstallations map[string]db.AccessKeyInstallation
+
+	// secretFiles are full paths of installed secret files and uploaded files of the template.
+	secretFiles []string
+
+	// tmpName is used in names of temporary files of the job instead of the task ID.
+	tmpName string
+
+	// previousCommitHash is the commit of the repository checked out by the previous run
+	// of the template. It is the base of the secrets scan.
+	previousCommitHash string
 }
 
 func (t *LocalJob) Kill() {
@@ -172,7 +188,7 @@ func (t *LocalJob) getEnvironmentENV() (res []string, err error) {
 }
 
 // nolint: gocyclo
-func (t *LocalJob) getShellArgs(username string, incomingVersion *string) (args []string, err error) {
+func (t *LocalJob) getShellArgs(username string, incomingVersion *string) (args []string, env []string, err error) {
 	extraVars, err := t.getEnvironmentExtraVars(username, incomingVersion)
 
 	if err != nil {
@@ -181,6 +197,14 @@ func (t *LocalJob) getShellArgs(username string, incomingVersion *string) (args
 		return
 	}
 
+	var params db.ShellTemplateParams
+	if t.Template.App.IsShell() {
+		if err = t.Template.GetParams(&params); err != nil {
+			t.Log("Invalid format of the template shell params")
+			return
+		}
+	}
+
 	var templateExtraArgs []string
 	if t.Template.Arguments != nil {
 		err = json.Unmarshal([]byte(*t.Template.Arguments), &templateExtraArgs)
@@ -199,26 +223,52 @@ func (t *LocalJob) getShellArgs(username string, incomingVersion *string) (args
 		}
 	}
 
-	// Script to run
-	args = append(args, t.Template.Playbook)
+	// Script to run, the path is relative to the repository but the script runs in the working directory
+	script := t.Template.Playbook
+	if params.WorkingDirectory != "" {
+		script, err = filepath.Rel(params.WorkingDirectory, script)
+		if err != nil {
+			t.Log("Can not find the script relative to the working directory")
+			return
+		}
+	}
+	args = append(args, script)
+
+	var secretVars, vars []string
 
 	// Include Environment Secret Vars
 	for _, secret := range t.Environment.Secrets {
 		if secret.Type == db.EnvironmentSecretVar {
-			args = append(args, fmt.Sprintf("%s=%s", secret.Name, secret.Secret))
+			secretVars = append(secretVars, fmt.Sprintf("%s=%s", secret.Name, secret.Secret))
 		}
 	}
 
-	// Include extra args from template
-	args = append(args, templateExtraArgs...)
-
 	// Include ExtraVars and Survey Vars
 	for name, value := range extraVars {
-		if name != "semaphore_vars" {
-			args = append(args, fmt.Sprintf("%s=%s", name, value))
+		if name == "semaphore_vars" {
+			continue
 		}
+
+		if params.VarsMode == db.ShellVarsEnv && !db.IsValidEnvVarName(name) {
+			t.Log("Variable " + name + " is skipped, it can not be passed as environment variable")
+			continue
+		}
+
+		vars = append(vars, fmt.Sprintf("%s=%s", name, value))
 	}
 
+	if params.VarsMode == db.ShellVarsEnv {
+		env = append(secretVars, vars...)
+		secretVars, vars = nil, nil
+	}
+
+	args = append(args, secretVars...)
+
+	// Include extra args from template
+	args = append(args, templateExtraArgs...)
+
+	args = append(args, vars...)
+
 	// Include extra args from task
 	args = append(args, taskExtraArgs...)
 
@@ -265,17 +315,42 @@ func (t *LocalJob) getTerraformArgs(username string, incomingVersion *string) (a
 	return
 }
 
-// nolint: gocyclo
-func (t *LocalJob) getPlaybookArgs(username string, incomingVersion *string) (args []string, inputs map[string]string, err error) {
+// getRunAsUsers returns users which connect to the hosts and which are become on the hosts.
+// Users set by the ansible template override logins of the inventory keys.
+func (t *LocalJob) getRunAsUsers() (remoteUser string, becomeUser string) {
+	if t.Inventory.SSHKeyID != nil {
+		remoteUser = t.sshKeyInstallation.Login
+	}
 
-	inputMap := make(map[db.AccessKeyRole]string)
-	inputs = make(map[string]string)
+	if t.Inventory.BecomeKeyID != nil {
+		becomeUser = t.becomeKeyInstallation.Login
+	}
 
-	playbookName := t.Task.Playbook
-	if playbookName == "" {
-		playbookName = t.Template.Playbook
+	if !t.Template.App.IsAnsible() {
+		return
+	}
+
+	var params db.AnsibleTemplateParams
+	if err := t.Template.GetParams(&params); err != nil {
+		return
 	}
 
+	if params.RemoteUser != "" {
+		remoteUser = params.RemoteUser
+	}
+
+	if params.BecomeUser != "" {
+		becomeUser = params.BecomeUser
+	}
+
+	return
+}
+
+// getInventoryArgs returns arguments of ansible and ansible-playbook which define the inventory
+// and credentials of its hosts, and answers to password prompts.
+func (t *LocalJob) getInventoryArgs() (args []string, inputs map[string]string, err error) {
+	inputs = make(map[string]string)
+
 	var inventoryFilename string
 	switch t.Inventory.Type {
 	case db.InventoryFile:
@@ -295,19 +370,19 @@ func (t *LocalJob) getPlaybookArgs(username string, incomingVersion *string) (ar
 		"-i", inventoryFilename,
 	}
 
+	remoteUser, becomeUser := t.getRunAsUsers()
+
+	if remoteUser != "" {
+		args = append(args, "--user", remoteUser)
+	}
+
 	if t.Inventory.SSHKeyID != nil {
 		switch t.Inventory.SSHKey.Type {
 		case db.AccessKeySSH:
-			if t.sshKeyInstallation.Login != "" {
-				args = append(args, "--user", t.sshKeyInstallation.Login)
-			}
-		case db.AccessKeyLoginPassword:
-			if t.sshKeyInstallation.Login != "" {
-				args = append(args, "--user", t.sshKeyInstallation.Login)
-			}
+		case db.AccessKeyLoginPassword, db.AccessKeyVault:
 			if t.sshKeyInstallation.Password != "" {
 				args = append(args, "--ask-pass")
-				inputMap[db.AccessKeyRoleAnsibleUser] = t.sshKeyInstallation.Password
+				inputs["SSH password:"] = t.sshKeyInstallation.Password
 			}
 		case db.AccessKeyNone:
 		default:
@@ -316,15 +391,31 @@ func (t *LocalJob) getPlaybookArgs(username string, incomingVersion *string) (ar
 		}
 	}
 
-	if t.Inventory.BecomeKeyID != nil {
+	if becomeUser != "" {
+		args = append(args, "--become-user", becomeUser)
+	}
+
+	if t.Task.InteractiveBecome {
+		if t.BecomePassword == nil {
+			err = fmt.Errorf("become password entered at the launch is not available, the task must be restarted")
+			return
+		}
+
+		var password string
+		password, err = t.BecomePassword.open()
+		if err != nil {
+			err = fmt.Errorf("become password entered at the launch can not be used: %s", err.Error())
+			return
+		}
+
+		args = append(args, "--ask-become-pass")
+		inputs["BECOME password"] = password
+	} else if t.Inventory.BecomeKeyID != nil {
 		switch t.Inventory.BecomeKey.Type {
-		case db.AccessKeyLoginPassword:
-			if t.becomeKeyInstallation.Login != "" {
-				args = append(args, "--become-user", t.becomeKeyInstallation.Login)
-			}
+		case db.AccessKeyLoginPassword, db.AccessKeyVault:
 			if t.becomeKeyInstallation.Password != "" {
 				args = append(args, "--ask-become-pass")
-				inputMap[db.AccessKeyRoleAnsibleBecomeUser] = t.becomeKeyInstallation.Password
+				inputs["BECOME password"] = t.becomeKeyInstallation.Password
 			}
 		case db.AccessKeyNone:
 		default:
@@ -333,6 +424,21 @@ func (t *LocalJob) getPlaybookArgs(username string, incomingVersion *string) (ar
 		}
 	}
 
+	return
+}
+
+// nolint: gocyclo
+func (t *LocalJob) getPlaybookArgs(username string, incomingVersion *string) (args []string, inputs map[string]string, err error) {
+	playbookName := t.Task.Playbook
+	if playbookName == "" {
+		playbookName = t.Template.Playbook
+	}
+
+	args, inputs, err = t.getInventoryArgs()
+	if err != nil {
+		return
+	}
+
 	var params db.AnsibleTaskParams
 
 	err = t.Task.GetParams(&params)
@@ -404,14 +510,6 @@ func (t *LocalJob) getPlaybookArgs(username string, incomingVersion *string) (ar
 	args = append(args, taskExtraArgs...)
 	args = append(args, playbookName)
 
-	if line, ok := inputMap[db.AccessKeyRoleAnsibleUser]; ok {
-		inputs["SSH password:"] = line
-	}
-
-	if line, ok := inputMap[db.AccessKeyRoleAnsibleBecomeUser]; ok {
-		inputs["BECOME password"] = line
-	}
-
 	return
 }
 
@@ -424,6 +522,9 @@ func (t *LocalJob) Run(username string, incomingVersion *string) (err error) {
 		return
 	}
 
+	// secret files are installed into the repository, so they are removed even if the preparation fails
+	defer t.destroySecretFiles()
+
 	err = t.prepareRun(&environmentVariables)
 	if err != nil {
 		return err
@@ -431,39 +532,100 @@ func (t *LocalJob) Run(username string, incomingVersion *string) (err error) {
 
 	defer func() {
 		t.destroyKeys()
+		t.destroySSHConfig()
+		t.destroySSHControlDir()
 		t.destroyInventoryFile()
 	}()
 
+	runningArgs, err := t.getRunningArgs(username, incomingVersion, environmentVariables)
+	if err != nil {
+		return
+	}
+
+	outputFile := t.runOutputFilename()
+	*runningArgs.EnvironmentVars = append(*runningArgs.EnvironmentVars, "SEMAPHORE_OUTPUT_FILE="+outputFile)
+	defer os.Remove(outputFile) //nolint:errcheck
+
+	// the workspace is prepared by the server, the app runs its commands on the worker
+	var worker *sshWorkerSession
+	if isSSHWorkersEnabled() {
+		if worker, err = t.startOnWorker(); err != nil {
+			return
+		}
+		defer worker.finish()
+	}
+
+	runningArgs.Callback = func(p *os.Process) {
+		t.Process = p
+	}
+
+	if checker, ok := t.App.(db_lib.LocalAppChecker); ok {
+		if err = t.check(checker, runningArgs); err != nil {
+			return
+		}
+	}
+
+	if counter, ok := t.App.(db_lib.LocalAppTaskCounter); ok {
+		t.countTasks(counter, runningArgs)
+	}
+
+	if watchdog := newTaskWatchdog(t); watchdog != nil {
+		watchdog.start()
+		defer watchdog.stop()
+	}
+
+	err = t.App.Run(runningArgs)
+	if err != nil {
+		return
+	}
+
+	if worker != nil {
+		if err = worker.download(outputFile); err != nil {
+			return
+		}
+	}
+
+	if err = t.collectRunOutputs(outputFile); err != nil {
+		return
+	}
+
+	return t.publishArtifacts(incomingVersion, worker)
+}
+
+// getRunningArgs returns arguments of the app which runs the task.
+// environmentVariables are variables of the task environment.
+func (t *LocalJob) getRunningArgs(username string, incomingVersion *string, environmentVariables []string) (runningArgs db_lib.LocalAppRunningArgs, err error) {
 	var args []string
 	var inputs map[string]string
-	var params interface{}
 
 	switch t.Template.App {
 	case db.AppAnsible:
 		args, inputs, err = t.getPlaybookArgs(username, incomingVersion)
-		params = &db.AnsibleTaskParams{}
 	case db.AppTerraform, db.AppTofu:
 		args, err = t.getTerraformArgs(username, incomingVersion)
-		params = &db.TerraformTaskParams{}
 	default:
-		args, err = t.getShellArgs(username, incomingVersion)
-		params = &db.DefaultTaskParams{}
+		var shellEnv []string
+		args, shellEnv, err = t.getShellArgs(username, incomingVersion)
+		environmentVariables = append(environmentVariables, shellEnv...)
 	}
 
 	if err != nil {
 		return
 	}
 
-	err = t.Task.GetParams(params)
-
+	params, err := t.getTaskParams()
 	if err != nil {
 		return
 	}
 
-	if t.Inventory.SSHKey.Type == db.AccessKeySSH && t.Inventory.SSHKeyID != nil {
+	if t.sshKeyInstallation.SSHAgent != nil {
 		environmentVariables = append(environmentVariables, fmt.Sprintf("SSH_AUTH_SOCK=%s", t.sshKeyInstallation.SSHAgent.SocketFile))
 	}
 
+	if t.Template.App.IsAnsible() {
+		environmentVariables = append(environmentVariables, t.getSSHOptionsEnv()...)
+	}
+
 	if t.Template.Type != db.TemplateTask {
 
 		environmentVariables = append(environmentVariables, fmt.Sprintf("SEMAPHORE_TASK_TYPE=%s", t.Template.Type))
@@ -481,16 +643,151 @@ func (t *LocalJob) Run(username string, incomingVersion *string) (err error) {
 		}
 	}
 
-	return t.App.Run(db_lib.LocalAppRunningArgs{
+	runningArgs = db_lib.LocalAppRunningArgs{
 		CliArgs:         args,
 		EnvironmentVars: &environmentVariables,
 		Inputs:          inputs,
 		TaskParams:      params,
-		Callback: func(p *os.Process) {
-			t.Process = p
-		},
-	})
+	}
+
+	return
+}
+
+// TaskFindingsLogger is implemented by loggers which can store findings of the pre-run checks.
+type TaskFindingsLogger interface {
+	AddFindings(findings []db.TaskFinding)
+}
+
+// TaskCommitLogger is implemented by loggers which can store the commit checked out by the task.
+type TaskCommitLogger interface {
+	SetCommit(hash string, message string)
+}
+
+// TemplateDocLogger is implemented by loggers which can store the documentation
+// of the template generated from the playbook.
+type TemplateDocLogger interface {
+	SetTemplateDoc(playbook string, content db.TemplateDocContent)
+}
+
+// check runs pre-run checks of the app and reports found problems.
+func (t *LocalJob) check(checker db_lib.LocalAppChecker, args db_lib.LocalAppRunningArgs) error {
+	findings, err := checker.Check(args)
+
+	t.reportFindings(findings)
+
+	if err != nil {
+		t.Log("Pre-run check failed: " + err.Error())
+	}
+
+	return err
+}
+
+// countTasks lists tasks before the run to track the progress of the run.
+// The run is not affected if tasks can not be listed.
+func (t *LocalJob) countTasks(counter db_lib.LocalAppTaskCounter, args db_lib.LocalAppRunningArgs) {
+	total, err := counter.CountTasks(args)
+	if err != nil {
+		t.Log("Can not list tasks to track progress: " + err.Error())
+		return
+	}
+
+	if total == 0 {
+		return
+	}
+
+	if logger, ok := t.Logger.(TaskProgressLogger); ok {
+		logger.SetTasksTotal(total)
+	}
+}
+
+// scanSecrets searches raw credentials in the commits added since the previous run of the template.
+func (t *LocalJob) scanSecrets() error {
+	severity := db.TaskFindingWarning
+	if t.Template.SecretsScan == db.SecretsScanFail {
+		severity = db.TaskFindingError
+	}
+
+	if t.previousCommitHash == "" {
+		t.Log("Scanning repository for secrets")
+	} else {
+		t.Log("Scanning repository for secrets added since " + t.previousCommitHash)
+	}
+
+	findings, err := db_lib.ScanSecrets(t.Repository.GetFullPath(t.Template.ID), t.previousCommitHash, severity)
+	if err != nil {
+		return err
+	}
+
+	t.reportFindings(findings)
+
+	if len(findings) > 0 && t.Template.SecretsScan == db.SecretsScanFail {
+		return db_lib.ErrSecretsFound
+	}
+
+	return nil
+}
+
+// documentPlaybook parses plays, variables and tags of the checked out playbook and stores them
+// as the documentation of the template. Problems of parsing do not fail the task.
+func (t *LocalJob) documentPlaybook() error {
+	logger, ok := t.Logger.(TemplateDocLogger)
+	if !ok {
+		return nil
+	}
 
+	// the playbook overridden by the task does not describe the template
+	if t.Task.Playbook != "" && t.Task.Playbook != t.Template.Playbook {
+		return nil
+	}
+
+	if strings.Contains(t.Template.Playbook, "{{") {
+		return nil
+	}
+
+	content, err := db_lib.ParsePlaybookDoc(t.Repository.GetFullPath(t.Template.ID), t.Template.Playbook)
+	if err != nil {
+		t.Log("Can't document playbook: " + err.Error())
+		return nil
+	}
+
+	logger.SetTemplateDoc(t.Template.Playbook, content)
+	return nil
+}
+
+// reportFindings writes findings to the task log and stores them if the logger supports it.
+func (t *LocalJob) reportFindings(findings []db.TaskFinding) {
+	for _, f := range findings {
+		location := f.File
+		if f.Line > 0 {
+			location += ":" + strconv.Itoa(f.Line)
+		}
+		msg := fmt.Sprintf("%s: %s %s", f.Severity, location, f.Message)
+		if f.Rule != "" {
+			msg += " [" + f.Rule + "]"
+		}
+		t.Log(msg)
+	}
+
+	if len(findings) > 0 {
+		if logger, ok := t.Logger.(TaskFindingsLogger); ok {
+			logger.AddFindings(findings)
+		}
+	}
+}
+
+// getTaskParams returns task params of the type which corresponds to the template app.
+func (t *LocalJob) getTaskParams() (params interface{}, err error) {
+	switch t.Template.App {
+	case db.AppAnsible:
+		params = &db.AnsibleTaskParams{}
+	case db.AppTerraform, db.AppTofu:
+		params = &db.TerraformTaskParams{}
+	default:
+		params = &db.DefaultTaskParams{}
+	}
+
+	err = t.Task.GetParams(params)
+	return
 }
 
 func (t *LocalJob) prepareRun(environmentVars *[]string) error {
@@ -511,38 +808,57 @@ func (t *LocalJob) prepareRun(environmentVars *[]string) error {
 		t.Repository.GitBranch = *t.Task.GitBranch
 	}
 
+	// steps which use the repository are run one by one, other steps are run in parallel with them
+	var repositorySteps []preparationStep
+
 	if t.Repository.GetType() == db.RepositoryLocal {
 		if _, err := os.Stat(t.Repository.GitURL); err != nil {
 			t.Log("Failed in finding static repository at " + t.Repository.GitURL + ": " + err.Error())
 			return err
 		}
 	} else {
-		if err := t.updateRepository(); err != nil {
-			t.Log("Failed updating repository: " + err.Error())
-			return err
-		}
-		if err := t.checkoutRepository(); err != nil {
-			t.Log("Failed to checkout repository to required commit: " + err.Error())
-			return err
+		repositorySteps = append(repositorySteps,
+			preparationStep{name: "update_repository", message: "Failed updating repository", run: t.updateRepository},
+			preparationStep{name: "checkout_repository", message: "Failed to checkout repository to required commit", run: t.checkoutRepository},
+		)
+		if t.Template.SecretsScan != db.SecretsScanDisabled {
+			repositorySteps = append(repositorySteps,
+				preparationStep{name: "scan_secrets", message: "Secrets scan failed", run: t.scanSecrets})
 		}
 	}
 
-	if err := t.installInventory(); err != nil {
-		t.Log("Failed to install inventory: " + err.Error())
-		return err
+	if t.Template.App == db.AppAnsible {
+		repositorySteps = append(repositorySteps,
+			preparationStep{name: "document_playbook", message: "Failed to document playbook", run: t.documentPlaybook})
 	}
 
-	if err := t.App.InstallRequirements(environmentVars); err != nil {
-		t.Log("Running galaxy failed: " + err.Error())
-		return err
-	}
+	repositorySteps = append(repositorySteps,
+		preparationStep{name: "install_secret_files", message: "Failed to install secret files", run: t.installSecretFiles},
+		preparationStep{name: "install_uploads", message: "Failed to install uploaded files", run: t.installUploads},
+		preparationStep{name: "install_requirements", message: "Running galaxy failed", run: func() error {
+			params, err := t.getTaskParams()
+			if err != nil {
+				return err
+			}
 
-	if err := t.installVaultKeyFiles(); err != nil {
-		t.Log("Failed to install vault password files: " + err.Error())
-		return err
+			return t.App.InstallRequirements(db_lib.LocalAppInstallingArgs{
+				EnvironmentVars: environmentVars,
+				TaskParams:      params,
+			})
+		}},
+	)
+
+	steps, err := runPreparationSteps([][]preparationStep{
+		repositorySteps,
+		{{name: "install_inventory", message: "Failed to install inventory", run: t.installInventory}},
+		{{name: "install_vault_keys", message: "Failed to install vault password files", run: t.installVaultKeyFiles}},
+	}, maxParallelPreparationSteps, t.Logger)
+
+	if l, ok := t.Logger.(TaskPreparationLogger); ok {
+		l.SetPreparationSteps(steps)
 	}
 
-	return nil
+	return err
 }
 
 func (t *LocalJob) updateRepository() error {
@@ -555,6 +871,10 @@ func (t *LocalJob) updateRepository() error {
 
 	err := repo.ValidateRepo()
 
+	if err == nil && t.Template.SecretsScan != db.SecretsScanDisabled {
+		t.previousCommitHash, _ = repo.GetLastCommitHash()
+	}
+
 	if err != nil {
 		if !os.IsNotExist(err) {
 			err = os.RemoveAll(repo.GetFullPath())
@@ -601,19 +921,21 @@ func (t *LocalJob) checkoutRepository() error {
 	}
 
 	// store commit to TaskRunner table
+	logger, ok := t.Logger.(TaskCommitLogger)
+	if !ok {
+		return nil
+	}
+
+	commitHash, err := repo.GetLastCommitHash()
+
+	if err != nil {
+		return err
+	}
+
+	commitMessage, _ := repo.GetLastCommitMessage()
+
+	logger.SetCommit(commitHash, commitMessage)
 
-	//commitHash, err := repo.GetLastCommitHash()
-	//
-	//if err != nil {
-	//	return err
-	//}
-	//
-	//commitMessage, _ := repo.GetLastCommitMessage()
-	//
-	//t.task.CommitHash = &commitHash
-	//t.task.CommitMessage = commitMessage
-	//
-	//return t.pool.store.UpdateTask(t.task)
 	return nil
 }
 
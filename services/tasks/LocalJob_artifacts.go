@@ -0,0 +1,352 @@
+package tasks
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/objectstorage"
+)
+
+const artifactsTimeout = 10 * time.Minute
+
+// githubAPIURL is the URL of the GitHub API used to publish artifacts to releases.
+var githubAPIURL = "https://api.github.com"
+
+// TaskArtifactsLogger is implemented by loggers which can store artifacts published by the task.
+type TaskArtifactsLogger interface {
+	AddArtifacts(artifacts []db.TaskArtifact)
+}
+
+// getArtifactVars returns values of the version and name templates of the artifacts.
+func (t *LocalJob) getArtifactVars(incomingVersion *string) db.ArtifactVars {
+	vars := db.ArtifactVars{
+		TaskID:     t.Task.ID,
+		TemplateID: t.Template.ID,
+		Version:    strconv.Itoa(t.Task.ID),
+	}
+
+	if t.Task.Version != nil && *t.Task.Version != "" {
+		vars.Version = *t.Task.Version
+	} else if incomingVersion != nil && *incomingVersion != "" {
+		vars.Version = *incomingVersion
+	}
+
+	if t.Task.CommitHash != nil {
+		vars.CommitHash = *t.Task.CommitHash
+	}
+
+	return vars
+}
+
+// artifactFullPath returns the absolute path of the artifact and checks that
+// the file does not escape the repository through symlinks.
+func artifactFullPath(root string, filePath string) (string, error) {
+	if !filepath.IsLocal(filePath) {
+		return "", fmt.Errorf("artifact path %s must be relative to the repository", filePath)
+	}
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+
+	realPath, err := filepath.EvalSymlinks(filepath.Join(root, filePath))
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(realPath, realRoot+string(filepath.Separator)) {
+		return "", fmt.Errorf("artifact path %s is outside of the repository", filePath)
+	}
+
+	return realPath, nil
+}
+
+// publishArtifacts publishes artifacts of the template after the successful run and reports
+// them to the logger. Artifacts published before the failure are reported too.
+func (t *LocalJob) publishArtifacts(incomingVersion *string, worker *sshWorkerSession) (err error) {
+	if len(t.Template.Artifacts) == 0 {
+		return nil
+	}
+
+	root := t.Repository.GetFullPath(t.Template.ID)
+	vars := t.getArtifactVars(incomingVersion)
+
+	var published []db.TaskArtifact
+
+	defer func() {
+		if len(published) == 0 {
+			return
+		}
+		if logger, ok := t.Logger.(TaskArtifactsLogger); ok {
+			logger.AddArtifacts(published)
+		}
+	}()
+
+	for i := range t.Template.Artifacts {
+		artifact := &t.Template.Artifacts[i]
+
+		if worker != nil {
+			if err = worker.download(filepath.Join(root, artifact.Path)); err != nil {
+				return
+			}
+		}
+
+		var fullPath string
+		fullPath, err = artifactFullPath(root, artifact.Path)
+		if err != nil {
+			t.Log("Artifact " + artifact.Path + " not found: " + err.Error())
+			return
+		}
+
+		if artifact.Key != nil {
+			if err = artifact.Key.DeserializeSecret(); err != nil {
+				return
+			}
+		}
+
+		var res db.TaskArtifact
+		res, err = publishArtifact(artifact, fullPath, vars)
+		if err != nil {
+			t.Log("Can not publish artifact " + artifact.Path + ": " + err.Error())
+			return
+		}
+
+		t.Log(fmt.Sprintf("Artifact %s published to %s (%d bytes, sha256 %s)", res.Path, res.URL, res.Size, res.SHA256))
+
+		published = append(published, res)
+	}
+
+	return
+}
+
+// publishArtifact uploads the file to the registry of the artifact.
+func publishArtifact(artifact *db.TemplateArtifact, fullPath string, vars db.ArtifactVars) (res db.TaskArtifact, err error) {
+	version, err := artifact.GetVersion(vars)
+	if err != nil {
+		return
+	}
+
+	name, err := artifact.GetName(vars)
+	if err != nil {
+		return
+	}
+
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return
+	}
+	defer f.Close() //nolint:errcheck
+
+	stat, err := f.Stat()
+	if err != nil {
+		return
+	}
+
+	if !stat.Mode().IsRegular() {
+		err = fmt.Errorf("artifact %s is not a regular file", artifact.Path)
+		return
+	}
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, f); err != nil {
+		return
+	}
+
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+
+	res = db.TaskArtifact{
+		TaskID:    vars.TaskID,
+		Path:      artifact.Path,
+		Registry:  artifact.Registry,
+		Version:   version,
+		Name:      name,
+		Size:      stat.Size(),
+		SHA256:    hex.EncodeToString(hash.Sum(nil)),
+		Published: time.Now().UTC(),
+	}
+
+	client := &http.Client{Timeout: artifactsTimeout}
+
+	switch artifact.Registry {
+	case db.ArtifactRegistryS3:
+		res.URL, err = publishArtifactToS3(client, artifact, version+"/"+name, f, res.Size)
+	case db.ArtifactRegistryNexusRaw:
+		res.URL, err = publishArtifactToNexus(client, artifact, version, name, f, res.Size)
+	case db.ArtifactRegistryGitHubRelease:
+		res.URL, err = publishArtifactToGitHub(client, artifact, version, name, f, res.Size)
+	default:
+		err = fmt.Errorf("unsupported registry %s", artifact.Registry)
+	}
+
+	return
+}
+
+func publishArtifactToS3(client *http.Client, artifact *db.TemplateArtifact, key string, body io.Reader, size int64) (string, error) {
+	if artifact.Key == nil || artifact.Key.Type != db.AccessKeyLoginPassword {
+		return "", fmt.Errorf("S3 registry requires login/password key")
+	}
+
+	storage := &objectstorage.Client{
+		Endpoint:        artifact.URL,
+		Region:          artifact.Region,
+		Bucket:          artifact.Bucket,
+		AccessKeyID:     artifact.Key.LoginPassword.Login,
+		SecretAccessKey: artifact.Key.LoginPassword.Password,
+		PathStyle:       artifact.PathStyle,
+		HTTPClient:      client,
+	}
+
+	if err := storage.PutObject(key, body, size); err != nil {
+		return "", err
+	}
+
+	return "s3://" + artifact.Bucket + "/" + key, nil
+}
+
+func publishArtifactToNexus(client *http.Client, artifact *db.TemplateArtifact, version string, name string, body io.Reader, size int64) (string, error) {
+	location := strings.TrimSuffix(artifact.URL, "/") + "/" + url.PathEscape(version) + "/" + url.PathEscape(name)
+
+	req, err := http.NewRequest(http.MethodPut, location, body)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	if artifact.Key != nil && artifact.Key.Type == db.AccessKeyLoginPassword {
+		req.SetBasicAuth(artifact.Key.LoginPassword.Login, artifact.Key.LoginPassword.Password)
+	}
+
+	if _, err = doArtifactRequest(client, req, nil); err != nil {
+		return "", err
+	}
+
+	return location, nil
+}
+
+type githubRelease struct {
+	ID        int    `json:"id"`
+	UploadURL string `json:"upload_url"`
+}
+
+type githubReleaseAsset struct {
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+func publishArtifactToGitHub(client *http.Client, artifact *db.TemplateArtifact, version string, name string, body io.Reader, size int64) (string, error) {
+	if artifact.Key == nil {
+		return "", fmt.Errorf("GitHub registry requires the token")
+	}
+
+	var token string
+	switch artifact.Key.Type {
+	case db.AccessKeyString:
+		token = artifact.Key.String
+	case db.AccessKeyLoginPassword:
+		token = artifact.Key.LoginPassword.Password
+	default:
+		return "", fmt.Errorf("GitHub registry requires string or login/password key")
+	}
+
+	newRequest := func(method string, u string, body io.Reader) (*http.Request, error) {
+		req, err := http.NewRequest(method, u, body)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req, nil
+	}
+
+	repoURL := strings.TrimSuffix(githubAPIURL, "/") + "/repos/" + artifact.URL
+
+	var release githubRelease
+
+	req, err := newRequest(http.MethodGet, repoURL+"/releases/tags/"+url.PathEscape(version), nil)
+	if err != nil {
+		return "", err
+	}
+
+	status, err := doArtifactRequest(client, req, &release)
+
+	if status == http.StatusNotFound {
+		var payload []byte
+		payload, err = json.Marshal(map[string]string{
+			"tag_name": version,
+			"name":     version,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		req, err = newRequest(http.MethodPost, repoURL+"/releases", bytes.NewReader(payload))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		_, err = doArtifactRequest(client, req, &release)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	// upload_url is the URI template, e.g. https://uploads.github.com/repos/o/r/releases/1/assets{?name,label}
+	uploadURL, _, _ := strings.Cut(release.UploadURL, "{")
+	if uploadURL == "" {
+		return "", fmt.Errorf("GitHub did not return the upload URL of release %s", version)
+	}
+
+	req, err = newRequest(http.MethodPost, uploadURL+"?name="+url.QueryEscape(name), body)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	var asset githubReleaseAsset
+	if _, err = doArtifactRequest(client, req, &asset); err != nil {
+		return "", err
+	}
+
+	return asset.BrowserDownloadURL, nil
+}
+
+// doArtifactRequest sends the request to the registry and decodes the response to res if it is not nil.
+// It returns the status of the response even if the status is the error.
+func doArtifactRequest(client *http.Client, req *http.Request, res interface{}) (int, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return resp.StatusCode, fmt.Errorf("%s %s responded with status %d: %s", req.Method, req.URL.Redacted(), resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if res != nil {
+		if err = json.NewDecoder(resp.Body).Decode(res); err != nil {
+			return resp.StatusCode, err
+		}
+	}
+
+	return resp.StatusCode, nil
+}
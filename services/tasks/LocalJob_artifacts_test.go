@@ -0,0 +1,170 @@
+package tasks
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func writeTestArtifact(t *testing.T, content string) (root string, fullPath string) {
+	root = t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(root, "dist"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "dist", "app.tar.gz"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fullPath, err := artifactFullPath(root, "dist/app.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return
+}
+
+func TestPublishArtifactToNexus(t *testing.T) {
+	_, fullPath := writeTestArtifact(t, "bundle")
+
+	var uploadPath, uploaded, user string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("unexpected method %s", r.Method)
+		}
+		body, _ := io.ReadAll(r.Body)
+		uploadPath = r.URL.Path
+		uploaded = string(body)
+		user, _, _ = r.BasicAuth()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	artifact := db.TemplateArtifact{
+		Path:     "dist/app.tar.gz",
+		Registry: db.ArtifactRegistryNexusRaw,
+		URL:      server.URL + "/repository/bundles/",
+		Version:  "v{{ .Version }}",
+		Name:     "app-{{ .Version }}.tar.gz",
+		Key: &db.AccessKey{
+			Type:          db.AccessKeyLoginPassword,
+			LoginPassword: db.LoginPassword{Login: "deployer", Password: "secret"},
+		},
+	}
+
+	res, err := publishArtifact(&artifact, fullPath, db.ArtifactVars{TaskID: 7, Version: "1.2.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if uploadPath != "/repository/bundles/v1.2.0/app-1.2.0.tar.gz" {
+		t.Fatalf("unexpected upload path %s", uploadPath)
+	}
+
+	if uploaded != "bundle" || user != "deployer" {
+		t.Fatalf("unexpected upload %q by %q", uploaded, user)
+	}
+
+	if res.URL != server.URL+"/repository/bundles/v1.2.0/app-1.2.0.tar.gz" {
+		t.Fatalf("unexpected URL %s", res.URL)
+	}
+
+	// sha256 of "bundle"
+	if res.SHA256 != "1e6ed65d77d6364eeaed5a745ba5c4985ae2b700dd85d7cf7f027bdf294a33fc" {
+		t.Fatalf("unexpected checksum %s", res.SHA256)
+	}
+
+	if res.Size != 6 || res.Version != "v1.2.0" || res.Name != "app-1.2.0.tar.gz" || res.TaskID != 7 {
+		t.Fatalf("unexpected artifact %+v", res)
+	}
+}
+
+func TestPublishArtifactToGitHub(t *testing.T) {
+	_, fullPath := writeTestArtifact(t, "bundle")
+
+	var server *httptest.Server
+	var created bool
+	var assetName, auth string
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/app/releases/tags/v3":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/app/releases":
+			var payload map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			created = payload["tag_name"] == "v3"
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":         1,
+				"upload_url": server.URL + "/uploads/releases/1/assets{?name,label}",
+			})
+		case r.Method == http.MethodPost && r.URL.Path == "/uploads/releases/1/assets":
+			assetName = r.URL.Query().Get("name")
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"browser_download_url": "https://github.com/acme/app/releases/download/v3/" + assetName,
+			})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	prevURL := githubAPIURL
+	githubAPIURL = server.URL
+	defer func() { githubAPIURL = prevURL }()
+
+	artifact := db.TemplateArtifact{
+		Path:     "dist/app.tar.gz",
+		Registry: db.ArtifactRegistryGitHubRelease,
+		URL:      "acme/app",
+		Version:  "v{{ .TaskID }}",
+		Key:      &db.AccessKey{Type: db.AccessKeyString, String: "token"},
+	}
+
+	res, err := publishArtifact(&artifact, fullPath, db.ArtifactVars{TaskID: 3, Version: "3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !created {
+		t.Fatal("release must be created")
+	}
+
+	if assetName != "app.tar.gz" || auth != "Bearer token" {
+		t.Fatalf("unexpected asset %q uploaded with %q", assetName, auth)
+	}
+
+	if res.URL != "https://github.com/acme/app/releases/download/v3/app.tar.gz" {
+		t.Fatalf("unexpected URL %s", res.URL)
+	}
+}
+
+func TestArtifactFullPathOutsideRepository(t *testing.T) {
+	root, _ := writeTestArtifact(t, "bundle")
+
+	outside := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(outside, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(outside, filepath.Join(root, "dist", "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := artifactFullPath(root, "dist/link"); err == nil {
+		t.Fatal("artifact linked outside of the repository must be rejected")
+	}
+}
@@ -1,9 +1,11 @@
 package tasks
 
 import (
+	"fmt"
 	"os"
 	"path"
 	"strconv"
+	"strings"
 
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/db_lib"
@@ -27,6 +29,16 @@ func (t *LocalJob) installInventory() (err error) {
 		}
 	}
 
+	if !t.Inventory.SSHOptions.IsEmpty() {
+		if err = t.installSSHConfig(); err != nil {
+			return
+		}
+	}
+
+	if err = t.installSSHControlDir(); err != nil {
+		return
+	}
+
 	if t.Inventory.Type == db.InventoryFile {
 		err = t.cloneInventoryRepo()
 	} else if t.Inventory.Type == db.InventoryStatic || t.Inventory.Type == db.InventoryStaticYaml {
@@ -36,8 +48,100 @@ func (t *LocalJob) installInventory() (err error) {
 	return
 }
 
+// tmpFileSuffix is the suffix of temporary files of the job.
+func (t *LocalJob) tmpFileSuffix() string {
+	if t.tmpName != "" {
+		return t.tmpName
+	}
+	return strconv.Itoa(t.Task.ID)
+}
+
 func (t *LocalJob) tmpInventoryFilename() string {
-	return "inventory_" + strconv.Itoa(t.Task.ID)
+	return "inventory_" + t.tmpFileSuffix()
+}
+
+func (t *LocalJob) tmpSSHConfigFullPath() string {
+	return path.Join(util.Config.TmpPath, "ssh_config_"+t.tmpFileSuffix())
+}
+
+// renderSSHConfig renders SSH options of the inventory into ssh_config format.
+// The custom SSH config of Semaphore is included after the options, so the options win.
+func renderSSHConfig(options db.InventorySSHOptions) string {
+	var b strings.Builder
+
+	b.WriteString("# SSH options of the inventory rendered by Semaphore\n")
+	b.WriteString("Host *\n")
+
+	if options.Port != 0 {
+		b.WriteString("    Port " + strconv.Itoa(options.Port) + "\n")
+	}
+
+	if options.User != "" {
+		b.WriteString("    User " + options.User + "\n")
+	}
+
+	if options.StrictHostKeyChecking != db.StrictHostKeyCheckingDefault {
+		b.WriteString("    StrictHostKeyChecking " + string(options.StrictHostKeyChecking) + "\n")
+	}
+
+	if options.ProxyCommand != "" {
+		b.WriteString("    ProxyCommand " + options.ProxyCommand + "\n")
+	}
+
+	if util.Config.SshConfigPath != "" {
+		b.WriteString("\nMatch all\n")
+		b.WriteString("Include " + util.Config.SshConfigPath + "\n")
+	}
+
+	return b.String()
+}
+
+func (t *LocalJob) installSSHConfig() error {
+	return os.WriteFile(t.tmpSSHConfigFullPath(), []byte(renderSSHConfig(*t.Inventory.SSHOptions)), 0600)
+}
+
+func (t *LocalJob) destroySSHConfig() {
+	if t.Inventory.SSHOptions.IsEmpty() {
+		return
+	}
+
+	if err := os.Remove(t.tmpSSHConfigFullPath()); err != nil && !os.IsNotExist(err) {
+		log.Error(err)
+	}
+}
+
+// getSSHOptionsEnv returns environment variables which make Ansible use SSH options of the inventory
+// and control sockets of the job. ANSIBLE_SSH_ARGS replaces default arguments of Ansible, so they are
+// repeated. It is not set without SSH options and ControlPersist, so ssh_args of ansible.cfg are kept.
+func (t *LocalJob) getSSHOptionsEnv() (res []string) {
+	if isSSHMultiplexingEnabled() {
+		res = append(res, "ANSIBLE_SSH_CONTROL_PATH_DIR="+t.tmpSSHControlDir())
+	}
+
+	controlPersist := t.getSSHControlPersist()
+
+	options := t.Inventory.SSHOptions
+	if options.IsEmpty() {
+		if isSSHMultiplexingEnabled() && controlPersist != "" {
+			res = append(res, fmt.Sprintf("ANSIBLE_SSH_ARGS=-C -o ControlMaster=auto -o ControlPersist=%s", controlPersist))
+		}
+		return
+	}
+
+	if controlPersist == "" {
+		controlPersist = defaultSSHControlPersist
+	}
+
+	res = append(res, fmt.Sprintf("ANSIBLE_SSH_ARGS=-C -o ControlMaster=auto -o ControlPersist=%s -F '%s'", controlPersist, t.tmpSSHConfigFullPath()))
+
+	// Ansible passes StrictHostKeyChecking=no to ssh if host key checking is disabled
+	// which overrides the option of the ssh_config file
+	switch options.StrictHostKeyChecking {
+	case db.StrictHostKeyCheckingYes, db.StrictHostKeyCheckingAcceptNew:
+		res = append(res, "ANSIBLE_HOST_KEY_CHECKING=True")
+	}
+
+	return
 }
 
 func (t *LocalJob) tmpInventoryFullPath() string {
@@ -83,8 +187,18 @@ func (t *LocalJob) installStaticInventory() error {
 
 	fullPath := t.tmpInventoryFullPath()
 
-	// create inventory file
-	return os.WriteFile(fullPath, []byte(t.Inventory.Inventory), 0664)
+	inventory, err := t.Inventory.RenderSecrets()
+	if err != nil {
+		return err
+	}
+
+	// create inventory file, it is readable only by the owner if it contains secrets
+	perm := os.FileMode(0664)
+	if len(t.Inventory.Secrets) > 0 {
+		perm = 0600
+	}
+
+	return os.WriteFile(fullPath, []byte(inventory), perm)
 }
 
 func (t *LocalJob) destroyInventoryFile() {
@@ -105,6 +219,10 @@ func (t *LocalJob) destroyKeys() {
 		t.Log("Can't destroy inventory become user key, error: " + err.Error())
 	}
 
+	if t.BecomePassword != nil {
+		t.BecomePassword.destroy()
+	}
+
 	for _, vault := range t.vaultFileInstallations {
 		err = vault.Destroy()
 		if err != nil {
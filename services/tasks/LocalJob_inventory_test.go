@@ -0,0 +1,158 @@
+package tasks
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestInventorySSHOptions(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath:       "/tmp/semaphore",
+		SshConfigPath: "/etc/semaphore/ssh_config",
+	}
+
+	job := LocalJob{
+		Task: db.Task{ID: 5},
+		Inventory: db.Inventory{
+			SSHOptions: &db.InventorySSHOptions{
+				Port:                  2222,
+				User:                  "deploy",
+				ControlPersist:        "10m",
+				StrictHostKeyChecking: db.StrictHostKeyCheckingAcceptNew,
+				ProxyCommand:          "ssh -W %h:%p bastion",
+			},
+		},
+	}
+
+	config := renderSSHConfig(*job.Inventory.SSHOptions)
+
+	for _, line := range []string{
+		"Host *\n",
+		"    Port 2222\n",
+		"    User deploy\n",
+		"    StrictHostKeyChecking accept-new\n",
+		"    ProxyCommand ssh -W %h:%p bastion\n",
+		"Match all\nInclude /etc/semaphore/ssh_config\n",
+	} {
+		if !strings.Contains(config, line) {
+			t.Errorf("ssh config must contain %q, got:\n%s", line, config)
+		}
+	}
+
+	env := job.getSSHOptionsEnv()
+	expected := []string{
+		"ANSIBLE_SSH_CONTROL_PATH_DIR=/tmp/semaphore/ssh_control_5",
+		"ANSIBLE_SSH_ARGS=-C -o ControlMaster=auto -o ControlPersist=10m -F '/tmp/semaphore/ssh_config_5'",
+		"ANSIBLE_HOST_KEY_CHECKING=True",
+	}
+
+	if strings.Join(env, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected %v, got %v", expected, env)
+	}
+
+	job.Inventory.SSHOptions = &db.InventorySSHOptions{}
+	if env = job.getSSHOptionsEnv(); len(env) != 1 || env[0] != "ANSIBLE_SSH_CONTROL_PATH_DIR=/tmp/semaphore/ssh_control_5" {
+		t.Fatalf("empty options must not change ansible arguments, got %v", env)
+	}
+
+	util.Config.SSHMultiplexing = &util.SSHMultiplexingConfig{ControlPersist: "5m"}
+	if env = job.getSSHOptionsEnv(); len(env) != 2 || env[1] != "ANSIBLE_SSH_ARGS=-C -o ControlMaster=auto -o ControlPersist=5m" {
+		t.Fatalf("expected ControlPersist of the config, got %v", env)
+	}
+
+	util.Config.SSHMultiplexing = &util.SSHMultiplexingConfig{Disabled: true}
+	if env = job.getSSHOptionsEnv(); len(env) != 0 {
+		t.Fatalf("disabled multiplexing must not change ansible arguments, got %v", env)
+	}
+}
+
+func TestDestroySSHControlDir(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: t.TempDir(),
+	}
+
+	job := LocalJob{Task: db.Task{ID: 7}}
+
+	if err := job.installSSHControlDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(job.tmpSSHControlDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0700 {
+		t.Fatalf("control directory must be private, got %v", info.Mode().Perm())
+	}
+
+	if err = os.WriteFile(path.Join(job.tmpSSHControlDir(), "stale"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	job.destroySSHControlDir()
+
+	if _, err = os.Stat(job.tmpSSHControlDir()); !os.IsNotExist(err) {
+		t.Fatal("control directory must be removed")
+	}
+}
+
+func TestInventoryRunAsUsers(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: "/tmp/semaphore",
+	}
+
+	sshKeyID := 1
+	becomeKeyID := 2
+
+	job := LocalJob{
+		Task: db.Task{ID: 5},
+		Template: db.Template{
+			App: db.AppAnsible,
+			TaskParams: db.MapStringAnyField{
+				"remote_user": "deploy",
+				"become_user": "postgres",
+			},
+		},
+		Inventory: db.Inventory{
+			Type:        db.InventoryStatic,
+			SSHKeyID:    &sshKeyID,
+			SSHKey:      db.AccessKey{Type: db.AccessKeySSH},
+			BecomeKeyID: &becomeKeyID,
+			BecomeKey:   db.AccessKey{Type: db.AccessKeyLoginPassword},
+		},
+		sshKeyInstallation:    db.AccessKeyInstallation{Login: "ubuntu"},
+		becomeKeyInstallation: db.AccessKeyInstallation{Login: "root", Password: "secret"},
+	}
+
+	args, inputs, err := job.getInventoryArgs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	joined := strings.Join(args, " ")
+	if !strings.Contains(joined, "--user deploy") || !strings.Contains(joined, "--become-user postgres --ask-become-pass") {
+		t.Fatalf("template users must override logins of the keys, got %v", args)
+	}
+
+	if inputs["BECOME password"] != "secret" {
+		t.Fatalf("expected become password input, got %v", inputs)
+	}
+
+	job.Template.TaskParams = nil
+
+	args, _, err = job.getInventoryArgs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	joined = strings.Join(args, " ")
+	if !strings.Contains(joined, "--user ubuntu") || !strings.Contains(joined, "--become-user root") {
+		t.Fatalf("logins of the keys must be used without overrides, got %v", args)
+	}
+}
@@ -0,0 +1,92 @@
+package tasks
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+// maxParallelPreparationSteps limits the number of preparation steps run at once.
+const maxParallelPreparationSteps = 4
+
+// TaskPreparationLogger is implemented by loggers which can store durations of the preparation steps.
+type TaskPreparationLogger interface {
+	SetPreparationSteps(steps []db.TaskPreparationStep)
+}
+
+// preparationStep is the step of the preparation of the job. The message is logged if the step fails.
+type preparationStep struct {
+	name    string
+	message string
+	run     func() error
+}
+
+// runPreparationSteps runs chains of steps in parallel using at most limit goroutines.
+// Steps of the chain depend on previous steps of the chain and are run one by one.
+// After the first failure remaining steps are not started and the error is returned.
+// Durations of the started steps are returned sorted by the start time.
+func runPreparationSteps(chains [][]preparationStep, limit int, logger task_logger.Logger) ([]db.TaskPreparationStep, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		steps    []db.TaskPreparationStep
+	)
+
+	workers := make(chan struct{}, limit)
+
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+
+	for _, chain := range chains {
+		wg.Add(1)
+		workers <- struct{}{}
+
+		go func(chain []preparationStep) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+
+			for _, step := range chain {
+				if failed() {
+					return
+				}
+
+				start := time.Now()
+				err := step.run()
+
+				mu.Lock()
+				steps = append(steps, db.TaskPreparationStep{
+					Name:       step.name,
+					Start:      start,
+					DurationMs: time.Since(start).Milliseconds(),
+					Failed:     err != nil,
+				})
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+
+				if err != nil {
+					logger.Log(step.message + ": " + err.Error())
+					return
+				}
+			}
+		}(chain)
+	}
+
+	wg.Wait()
+
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].Start.Before(steps[j].Start)
+	})
+
+	return steps, firstErr
+}
@@ -0,0 +1,76 @@
+package tasks
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunPreparationSteps(t *testing.T) {
+	var running, maxRunning int32
+
+	sleep := func() error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	}
+
+	var order []string
+
+	steps, err := runPreparationSteps([][]preparationStep{
+		{
+			{name: "update_repository", run: func() error { order = append(order, "update"); return sleep() }},
+			{name: "install_requirements", run: func() error { order = append(order, "install"); return sleep() }},
+		},
+		{{name: "install_inventory", run: sleep}},
+		{{name: "install_vault_keys", run: sleep}},
+	}, 2, &testLogger{onLog: func(msg string) {}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(steps) != 4 || steps[0].DurationMs < 50 {
+		t.Fatal("durations of all steps must be recorded", steps)
+	}
+
+	if len(order) != 2 || order[0] != "update" {
+		t.Fatal("steps of the chain must be run in order", order)
+	}
+
+	if maxRunning != 2 {
+		t.Fatal("steps must be run in parallel up to the limit", maxRunning)
+	}
+}
+
+func TestRunPreparationStepsFailure(t *testing.T) {
+	var logged string
+	installed := false
+
+	steps, err := runPreparationSteps([][]preparationStep{
+		{
+			{name: "update_repository", message: "Failed updating repository", run: func() error { return errors.New("no access") }},
+			{name: "install_requirements", run: func() error { installed = true; return nil }},
+		},
+	}, 1, &testLogger{onLog: func(msg string) { logged = msg }})
+
+	if err == nil || installed {
+		t.Fatal("steps after the failed step must not be run")
+	}
+
+	if logged != "Failed updating repository: no access" {
+		t.Fatal("failure must be logged", logged)
+	}
+
+	if len(steps) != 1 || !steps[0].Failed {
+		t.Fatal("failed step must be recorded", steps)
+	}
+}
@@ -0,0 +1,51 @@
+package tasks
+
+import (
+	"os"
+	"path"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// TaskRunOutputsLogger is implemented by loggers which can store outputs registered by the task.
+type TaskRunOutputsLogger interface {
+	SetRunOutputs(outputs []db.RunOutput)
+}
+
+// runOutputFilename returns the file which the task writes its outputs to.
+// The path is passed to the task by SEMAPHORE_OUTPUT_FILE environment variable.
+func (t *LocalJob) runOutputFilename() string {
+	return path.Join(util.Config.TmpPath, "outputs_"+t.tmpFileSuffix())
+}
+
+// collectRunOutputs reads outputs registered by the task and reports them to the logger.
+func (t *LocalJob) collectRunOutputs(filename string) error {
+	content, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	outputs, err := db.ParseRunOutputs(content)
+	if err != nil {
+		t.Log("Can not read outputs of the task: " + err.Error())
+		return err
+	}
+
+	if len(outputs) == 0 {
+		return nil
+	}
+
+	for _, output := range outputs {
+		t.Log("Output " + output.Name + " registered")
+	}
+
+	if logger, ok := t.Logger.(TaskRunOutputsLogger); ok {
+		logger.SetRunOutputs(outputs)
+	}
+
+	return nil
+}
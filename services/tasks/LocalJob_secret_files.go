@@ -0,0 +1,149 @@
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// secretFileFullPath returns the absolute path of the secret file and checks that
+// the file does not escape the repository through symlinks.
+func secretFileFullPath(root string, filePath string) (string, error) {
+	if !filepath.IsLocal(filePath) {
+		return "", fmt.Errorf("secret file path %s must be relative to the repository", filePath)
+	}
+
+	fullPath := filepath.Join(root, filePath)
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0700); err != nil {
+		return "", err
+	}
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+
+	realDir, err := filepath.EvalSymlinks(filepath.Dir(fullPath))
+	if err != nil {
+		return "", err
+	}
+
+	if realDir != realRoot && !strings.HasPrefix(realDir, realRoot+string(filepath.Separator)) {
+		return "", fmt.Errorf("secret file path %s is outside of the repository", filePath)
+	}
+
+	return fullPath, nil
+}
+
+func writeSecretFile(fullPath string, content []byte, mode os.FileMode) (err error) {
+	// O_EXCL does not follow symlinks, so the content is never written through a link from the repository
+	f, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	// the mode passed to OpenFile is reduced by umask
+	if err = f.Chmod(mode); err != nil {
+		return
+	}
+
+	_, err = f.Write(content)
+	return
+}
+
+// installSecretFiles writes access keys of the template to files in the repository.
+func (t *LocalJob) installSecretFiles() error {
+	root := t.Repository.GetFullPath(t.Template.ID)
+
+	for i := range t.Template.SecretFiles {
+		file := &t.Template.SecretFiles[i]
+
+		if file.Key == nil {
+			return fmt.Errorf("key of secret file %s is not loaded", file.Path)
+		}
+
+		if err := file.Key.DeserializeSecret(); err != nil {
+			return err
+		}
+
+		content, err := file.GetContent()
+		if err != nil {
+			return err
+		}
+
+		fullPath, err := secretFileFullPath(root, file.Path)
+		if err != nil {
+			return err
+		}
+
+		if _, err = os.Lstat(fullPath); err == nil {
+			t.Log("Secret file " + file.Path + " replaces the file from the repository")
+			if err = os.Remove(fullPath); err != nil {
+				return err
+			}
+		}
+
+		t.Log("Installing secret file " + file.Path)
+
+		if err = writeSecretFile(fullPath, content, os.FileMode(file.GetMode())); err != nil {
+			return err
+		}
+
+		t.secretFiles = append(t.secretFiles, fullPath)
+	}
+
+	return nil
+}
+
+// installUploads writes files uploaded to the template to the repository.
+// They are removed with secret files after the run.
+func (t *LocalJob) installUploads() error {
+	root := t.Repository.GetFullPath(t.Template.ID)
+
+	for _, upload := range t.Template.Uploads {
+		if upload.Content == nil && upload.Size > 0 {
+			return fmt.Errorf("content of uploaded file %s is not loaded", upload.Path)
+		}
+
+		fullPath, err := secretFileFullPath(root, upload.Path)
+		if err != nil {
+			return err
+		}
+
+		if _, err = os.Lstat(fullPath); err == nil {
+			t.Log("Uploaded file " + upload.Path + " replaces the file from the repository")
+			if err = os.Remove(fullPath); err != nil {
+				return err
+			}
+		}
+
+		t.Log("Installing uploaded file " + upload.Path)
+
+		if err = writeSecretFile(fullPath, upload.Content, os.FileMode(upload.GetMode())); err != nil {
+			return err
+		}
+
+		t.secretFiles = append(t.secretFiles, fullPath)
+	}
+
+	return nil
+}
+
+// destroySecretFiles removes secret files installed by installSecretFiles.
+func (t *LocalJob) destroySecretFiles() {
+	for _, fullPath := range t.secretFiles {
+		if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+			t.Log("Can't remove secret file, error: " + err.Error())
+		}
+	}
+
+	t.secretFiles = nil
+}
@@ -0,0 +1,119 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestInstallSecretFiles(t *testing.T) {
+	util.Config = &util.ConfigType{TmpPath: t.TempDir()}
+
+	job := LocalJob{
+		Logger:     &testLogger{onLog: func(msg string) {}},
+		Repository: db.Repository{ID: 1, GitURL: "https://example.com/repo.git"},
+		Template: db.Template{
+			ID: 2,
+			SecretFiles: db.TemplateSecretFiles{{
+				KeyID: 3,
+				Path:  ".kube/config",
+				Key:   &db.AccessKey{Type: db.AccessKeyString, String: "apiVersion: v1"},
+			}, {
+				KeyID: 4,
+				Path:  "keys/id_ed25519",
+				Mode:  "0400",
+				Key:   &db.AccessKey{Type: db.AccessKeySSH, SshKey: db.SshKey{PrivateKey: "private"}},
+			}},
+		},
+	}
+
+	root := job.Repository.GetFullPath(job.Template.ID)
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// the file from the repository is replaced for the run
+	if err := os.MkdirAll(filepath.Join(root, ".kube"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".kube/config"), []byte("example"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := job.installSecretFiles(); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, expected := range map[string]struct {
+		content string
+		mode    os.FileMode
+	}{
+		".kube/config":    {"apiVersion: v1", 0600},
+		"keys/id_ed25519": {"private", 0400},
+	} {
+		fullPath := filepath.Join(root, path)
+
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(content) != expected.content {
+			t.Errorf("unexpected content of %s: %q", path, content)
+		}
+
+		stat, err := os.Stat(fullPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stat.Mode().Perm() != expected.mode {
+			t.Errorf("unexpected mode of %s: %o", path, stat.Mode().Perm())
+		}
+	}
+
+	job.destroySecretFiles()
+
+	if _, err := os.Stat(filepath.Join(root, ".kube/config")); !os.IsNotExist(err) {
+		t.Fatal("secret file must be removed after the run")
+	}
+}
+
+func TestInstallSecretFileOutsideRepository(t *testing.T) {
+	util.Config = &util.ConfigType{TmpPath: t.TempDir()}
+
+	outside := t.TempDir()
+
+	job := LocalJob{
+		Logger:     &testLogger{onLog: func(msg string) {}},
+		Repository: db.Repository{ID: 1, GitURL: "https://example.com/repo.git"},
+		Template: db.Template{
+			ID: 2,
+			SecretFiles: db.TemplateSecretFiles{{
+				KeyID: 3,
+				Path:  "link/config",
+				Key:   &db.AccessKey{Type: db.AccessKeyString, String: "secret"},
+			}},
+		},
+	}
+
+	root := job.Repository.GetFullPath(job.Template.ID)
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := job.installSecretFiles(); err == nil {
+		t.Fatal("secret file must not be written through the symlink")
+	}
+
+	if _, err := os.Stat(filepath.Join(outside, "config")); !os.IsNotExist(err) {
+		t.Fatal("secret file is written outside of the repository")
+	}
+}
@@ -0,0 +1,57 @@
+package tasks
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestGetShellArgs(t *testing.T) {
+	util.Config = &util.ConfigType{}
+
+	args := `["--verbose"]`
+
+	job := LocalJob{
+		Logger: &testLogger{onLog: func(msg string) {}},
+		Template: db.Template{
+			App:       db.AppBash,
+			Playbook:  "scripts/deploy.sh",
+			Arguments: &args,
+		},
+		Environment: db.Environment{JSON: `{"release": "1.2.0", "bad-name": "x"}`},
+	}
+
+	cliArgs, env, err := job.getShellArgs("admin", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(cliArgs, []string{"scripts/deploy.sh", "--verbose", "bad-name=x", "release=1.2.0"}) &&
+		!slices.Equal(cliArgs, []string{"scripts/deploy.sh", "--verbose", "release=1.2.0", "bad-name=x"}) {
+		t.Fatal("variables must be passed as arguments by default", cliArgs)
+	}
+
+	if len(env) != 0 {
+		t.Fatal("variables must not be passed as environment variables by default", env)
+	}
+
+	job.Template.TaskParams = db.MapStringAnyField{
+		"working_directory": "app",
+		"vars_mode":         "env",
+	}
+
+	cliArgs, env, err = job.getShellArgs("admin", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(cliArgs, []string{"../scripts/deploy.sh", "--verbose"}) {
+		t.Fatal("script must be relative to the working directory", cliArgs)
+	}
+
+	if !slices.Equal(env, []string{"release=1.2.0"}) {
+		t.Fatal("variables with valid names must be passed as environment variables", env)
+	}
+}
@@ -0,0 +1,82 @@
+package tasks
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path"
+	"time"
+
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultSSHControlPersist = "60s"
+	sshControlExitTimeout    = 5 * time.Second
+)
+
+func isSSHMultiplexingEnabled() bool {
+	return util.Config.SSHMultiplexing == nil || !util.Config.SSHMultiplexing.Disabled
+}
+
+// getSSHControlPersist returns ControlPersist of the inventory or of the config,
+// it returns an empty string if none of them is set.
+func (t *LocalJob) getSSHControlPersist() string {
+	if options := t.Inventory.SSHOptions; !options.IsEmpty() && options.ControlPersist != "" {
+		return options.ControlPersist
+	}
+
+	if util.Config.SSHMultiplexing != nil {
+		return util.Config.SSHMultiplexing.ControlPersist
+	}
+
+	return ""
+}
+
+// tmpSSHControlDir is the directory of SSH control sockets of the job. Sockets of different
+// tasks are not shared, so a task never reuses the connection authenticated by the key of another task.
+func (t *LocalJob) tmpSSHControlDir() string {
+	return path.Join(util.Config.TmpPath, "ssh_control_"+t.tmpFileSuffix())
+}
+
+func (t *LocalJob) installSSHControlDir() error {
+	if !isSSHMultiplexingEnabled() {
+		return nil
+	}
+	return os.MkdirAll(t.tmpSSHControlDir(), 0700)
+}
+
+// destroySSHControlDir closes the master connections which are kept open by ControlPersist
+// and removes their sockets, so connections do not outlive the task.
+func (t *LocalJob) destroySSHControlDir() {
+	dir := t.tmpSSHControlDir()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Error(err)
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		closeSSHControlMaster(path.Join(dir, entry.Name()))
+	}
+
+	if err = os.RemoveAll(dir); err != nil {
+		log.Error(err)
+	}
+}
+
+// closeSSHControlMaster asks the master connection of the socket to exit.
+// The host is required by ssh but is not used because the control path is fixed.
+func closeSSHControlMaster(socket string) {
+	ctx, cancel := context.WithTimeout(context.Background(), sshControlExitTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "ssh", "-o", "ControlPath="+socket, "-O", "exit", "semaphore-control-master")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		log.WithError(err).WithField("socket", socket).Debug("Can not close SSH master connection: " + string(out))
+	}
+}
@@ -10,11 +10,14 @@ import (
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/pkg/task_logger"
 	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
 )
 
 type RemoteJob struct {
 	Task     db.Task
 	taskPool *TaskPool
+	// taskRunner is the runner of the task, it receives statuses sent by the remote runner.
+	taskRunner *TaskRunner
 }
 
 type runnerWebhookPayload struct {
@@ -68,7 +71,7 @@ func callRunnerWebhook(runner *db.Runner, tsk *TaskRunner, action string) (err e
 
 func (t *RemoteJob) Run(username string, incomingVersion *string) (err error) {
 
-	tsk := t.taskPool.GetTask(t.Task.ID)
+	tsk := t.taskRunner
 
 	if tsk == nil {
 		return fmt.Errorf("task not found")
@@ -124,6 +127,9 @@ func (t *RemoteJob) Run(username string, incomingVersion *string) (err error) {
 	}
 
 	tsk.RunnerID = runner.ID
+	t.taskPool.notifyRunner(runner.ID)
+
+	t.createTaskAssignEvent(*runner)
 
 	startTime := time.Now()
 
@@ -136,7 +142,6 @@ func (t *RemoteJob) Run(username string, incomingVersion *string) (err error) {
 		}
 
 		time.Sleep(1_000_000_000)
-		tsk = t.taskPool.GetTask(t.Task.ID)
 		if tsk.Task.Status == task_logger.TaskSuccessStatus ||
 			tsk.Task.Status == task_logger.TaskStoppedStatus ||
 			tsk.Task.Status == task_logger.TaskFailStatus {
@@ -159,6 +164,27 @@ func (t *RemoteJob) Run(username string, incomingVersion *string) (err error) {
 	return
 }
 
+// createTaskAssignEvent records that the task is assigned to the runner.
+func (t *RemoteJob) createTaskAssignEvent(runner db.Runner) {
+	objType := db.EventTask
+	desc := fmt.Sprintf("Task ID %d assigned to runner %s (ID %d)", t.Task.ID, runner.Name, runner.ID)
+
+	db.StoreSession(t.taskPool.store, "assign task", func() {
+		_, err := t.taskPool.store.CreateEvent(db.Event{
+			UserID:        t.Task.UserID,
+			ProjectID:     &t.Task.ProjectID,
+			IntegrationID: t.Task.IntegrationID,
+			ObjectType:    &objType,
+			ObjectID:      &t.Task.ID,
+			Action:        db.EventActionTaskAssign,
+			Description:   &desc,
+		})
+		if err != nil {
+			log.Error(err)
+		}
+	})
+}
+
 func (t *RemoteJob) Kill() {
 	// Do nothing because you can't kill remote process
 }
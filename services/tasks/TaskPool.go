@@ -4,13 +4,18 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/semaphoreui/semaphore/api/sse"
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/db_lib"
 	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/services/ha"
 
 	"github.com/semaphoreui/semaphore/util"
 	log "github.com/sirupsen/logrus"
@@ -20,11 +25,16 @@ type logRecord struct {
 	task   *TaskRunner
 	output string
 	time   time.Time
+	// archive is sent when the task is finished. All output of the task
+	// is already stored, so it can be moved to the object storage.
+	archive bool
 }
 
 type resourceLock struct {
 	lock   bool
 	holder *TaskRunner
+	// locked is closed when the holder is added to the running tasks.
+	locked chan struct{}
 }
 
 type TaskPool struct {
@@ -46,6 +56,29 @@ type TaskPool struct {
 	store db.Store
 
 	resourceLocker chan *resourceLock
+
+	// JobFactory creates the job which runs the task instead of the local
+	// or the remote job, for example the fake job in integration tests.
+	JobFactory func(taskRunner *TaskRunner) Job
+
+	// HostLister returns the hosts of the task split into batches of the canary launch
+	// instead of ansible, for example in tests.
+	HostLister func(task db.Task, tpl db.Template) ([]string, error)
+
+	// DispatchInterval is the period of starting queued tasks, 5 seconds by default.
+	DispatchInterval time.Duration
+
+	// CheckpointInterval is the period of output checkpoints of running tasks,
+	// TaskOutput.CheckpointMinutes of the config by default.
+	CheckpointInterval time.Duration
+
+	// adhocCommands contains running ad-hoc commands. Map key is a command ID.
+	adhocCommands map[int]*AdhocRunner
+	adhocLock     sync.Mutex
+
+	// runnerListeners contains channels of streams of remote runners. Map key is a runner ID.
+	runnerListeners     map[int][]chan struct{}
+	runnerListenersLock sync.Mutex
 }
 
 var ErrInvalidSubscription = errors.New("has no active subscription")
@@ -59,6 +92,32 @@ func (p *TaskPool) GetNumberOfRunningTasksOfRunner(runnerID int) (res int) {
 	return
 }
 
+// getNumberOfActiveTasksOfProject returns number of waiting and running tasks of the project.
+func (p *TaskPool) getNumberOfActiveTasksOfProject(projectID int) (res int) {
+	for _, task := range p.Queue {
+		if task.Task.ProjectID == projectID {
+			res++
+		}
+	}
+	for _, task := range p.RunningTasks {
+		if task.Task.ProjectID == projectID {
+			res++
+		}
+	}
+	return
+}
+
+// getNumberOfCountedRunningTasks returns number of running tasks which occupy slots of parallel tasks.
+// Tasks of archived projects are not counted.
+func (p *TaskPool) getNumberOfCountedRunningTasks() (res int) {
+	for _, task := range p.RunningTasks {
+		if !task.projectArchived {
+			res++
+		}
+	}
+	return
+}
+
 func (p *TaskPool) GetRunningTasks() (res []*TaskRunner) {
 	for _, task := range p.RunningTasks {
 		res = append(res, task)
@@ -89,7 +148,12 @@ func (p *TaskPool) GetTask(id int) (task *TaskRunner) {
 
 // nolint: gocyclo
 func (p *TaskPool) Run() {
-	ticker := time.NewTicker(5 * time.Second)
+	interval := p.DispatchInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
 
 	defer func() {
 		close(p.resourceLocker)
@@ -113,6 +177,9 @@ func (p *TaskPool) Run() {
 				}
 				projTasks[t.Task.ID] = t
 				p.RunningTasks[t.Task.ID] = t
+				if l.locked != nil {
+					close(l.locked)
+				}
 				continue
 			}
 
@@ -127,68 +194,234 @@ func (p *TaskPool) Run() {
 		}
 	}(p.resourceLocker)
 
+	go p.writeOutput()
+
+	if !ha.IsEnabled() {
+		// in HA mode delayed tasks are restored by syncQueue
+		p.restoreDelayedTasks()
+	}
+
 	for {
 		select {
-		case record := <-p.logger: // new log message which should be put to database
-			db.StoreSession(p.store, "logger", func() {
-				_, err := p.store.CreateTaskOutput(db.TaskOutput{
-					TaskID: record.task.Task.ID,
-					Output: record.output,
-					Time:   record.time,
-				})
-				if err != nil {
-					log.Error(err)
-				}
-			})
-
 		case task := <-p.register: // new task created by API or schedule
 
 			db.StoreSession(p.store, "new task", func() {
-				p.Queue = append(p.Queue, task)
+				p.putToQueue(task)
 				log.Debug(task)
 				msg := "Task " + strconv.Itoa(task.Task.ID) + " added to queue"
+				if task.Task.RunAt != nil {
+					msg += ", it will start at " + task.Task.RunAt.Format(time.RFC3339)
+				}
 				task.Log(msg)
 				log.Info(msg)
 				task.saveStatus()
 			})
 
 		case <-ticker.C: // timer 5 seconds
-			if len(p.Queue) == 0 {
-				break
+			p.dispatchNext()
+
+			if ha.IsEnabled() {
+				p.syncQueue()
 			}
+		}
+	}
+}
 
-			//get TaskRunner from top of queue
-			t := p.Queue[0]
-			if t.Task.Status == task_logger.TaskFailStatus {
-				//delete failed TaskRunner from queue
-				p.Queue = p.Queue[1:]
-				log.Info("Task " + strconv.Itoa(t.Task.ID) + " removed from queue")
-				break
+// putToQueue adds the task to the queue. The task already added by syncQueue
+// is replaced because the registered task also contains secret variables.
+func (p *TaskPool) putToQueue(task *TaskRunner) {
+	for i, t := range p.Queue {
+		if t.Task.ID == task.Task.ID {
+			p.Queue[i] = task
+			return
+		}
+	}
+	p.Queue = append(p.Queue, task)
+}
+
+func (p *TaskPool) dispatchNext() {
+	// delayed tasks wait in the queue until their start time
+	i := 0
+	for i < len(p.Queue) && p.Queue[i].Task.IsDelayed() {
+		i++
+	}
+
+	if i == len(p.Queue) {
+		return
+	}
+
+	//get first due TaskRunner from queue
+	t := p.Queue[i]
+	if t.Task.Status.IsFinished() {
+		//delete failed or cancelled TaskRunner from queue
+		p.removeFromQueue(i)
+		log.Info("Task " + strconv.Itoa(t.Task.ID) + " removed from queue")
+
+		if t.Task.ParentTaskID != nil {
+			// the batch stopped before the start is not run, its canary deployment is finished here
+			go db.StoreSession(p.store, "finish canary batch", func() {
+				p.onCanaryBatchFinished(t.Task)
+			})
+		}
+		return
+	}
+
+	if p.blocks(t) || (ha.IsEnabled() && p.concurrencyGroupBusyOnOtherNodes(t)) {
+		//move blocked TaskRunner to end of queue
+		p.removeFromQueue(i)
+		p.Queue = append(p.Queue, t)
+		return
+	}
+
+	if ha.IsEnabled() && !p.claim(t) {
+		p.removeFromQueue(i)
+		log.Info("Task " + strconv.Itoa(t.Task.ID) + " is run by another node, removed from queue")
+		return
+	}
+
+	p.createTaskDequeueEvent(t)
+
+	log.Info("Set resource locker with TaskRunner " + strconv.Itoa(t.Task.ID))
+	lock := &resourceLock{lock: true, holder: t, locked: make(chan struct{})}
+	p.resourceLocker <- lock
+
+	// the task is started after it is added to the running tasks, so remote runners can find it
+	<-lock.locked
+
+	go t.run()
+
+	p.removeFromQueue(i)
+	log.Info("Task " + strconv.Itoa(t.Task.ID) + " removed from queue")
+}
+
+func (p *TaskPool) removeFromQueue(i int) {
+	p.Queue = append(p.Queue[:i], p.Queue[i+1:]...)
+}
+
+// restoreDelayedTasks puts to the queue the delayed tasks created before the restart
+// of the server. Secret variables of these tasks are lost because they are not stored.
+func (p *TaskPool) restoreDelayedTasks() {
+	db.StoreSession(p.store, "restore delayed tasks", func() {
+		stored, err := p.store.GetUnfinishedTasks()
+		if err != nil {
+			log.Error(err)
+			return
+		}
+
+		for _, task := range stored {
+			if task.Status != task_logger.TaskWaitingStatus || task.RunAt == nil {
+				continue
 			}
 
-			if p.blocks(t) {
-				//move blocked TaskRunner to end of queue
-				p.Queue = append(p.Queue[1:], t)
-				break
+			runner, err := p.createTaskRunner(task, "", nil)
+			if err != nil {
+				runner.Log("Error: " + err.Error())
+				runner.SetStatus(task_logger.TaskFailStatus)
+				continue
 			}
 
-			log.Info("Set resource locker with TaskRunner " + strconv.Itoa(t.Task.ID))
-			p.resourceLocker <- &resourceLock{lock: true, holder: t}
+			p.Queue = append(p.Queue, runner)
+			log.Info("Delayed task " + strconv.Itoa(task.ID) + " restored to queue")
+		}
+	})
+}
 
-			go t.run()
+// claim marks the task as run by this node. Every node of the HA cluster
+// can have the task in its queue, but only one node claims it.
+func (p *TaskPool) claim(t *TaskRunner) (claimed bool) {
+	nodeID := ha.GetNodeID()
 
-			p.Queue = p.Queue[1:]
-			log.Info("Task " + strconv.Itoa(t.Task.ID) + " removed from queue")
+	db.StoreSession(p.store, "claim task", func() {
+		var err error
+		claimed, err = p.store.ClaimTask(t.Task.ID, nodeID)
+		if err != nil {
+			log.Error(err)
 		}
+	})
+
+	if claimed {
+		t.Task.ClaimedBy = &nodeID
 	}
+
+	return
+}
+
+// syncQueue is used in HA mode. It adds to the queue unclaimed tasks created
+// by other nodes and applies stop and confirm requests received by other nodes.
+func (p *TaskPool) syncQueue() {
+	db.StoreSession(p.store, "sync queue", func() {
+		stored, err := p.store.GetUnfinishedTasks()
+		if err != nil {
+			log.Error(err)
+			return
+		}
+
+		for _, task := range stored {
+			if task.Status != task_logger.TaskWaitingStatus || task.ClaimedBy != nil || p.GetTask(task.ID) != nil {
+				continue
+			}
+
+			runner, err := p.createTaskRunner(task, "", nil)
+			if err != nil {
+				runner.Log("Error: " + err.Error())
+				runner.SetStatus(task_logger.TaskFailStatus)
+				continue
+			}
+
+			p.Queue = append(p.Queue, runner)
+			log.Info("Task " + strconv.Itoa(task.ID) + " created by another node added to queue")
+		}
+
+		local := append([]*TaskRunner{}, p.Queue...)
+		local = append(local, p.GetRunningTasks()...)
+
+		for _, t := range local {
+			task, err := p.store.GetTask(t.Task.ProjectID, t.Task.ID)
+			if err != nil {
+				log.Error(err)
+				continue
+			}
+
+			if task.Status == t.Task.Status || t.Task.Status.IsFinished() {
+				continue
+			}
+
+			if task.ClaimedBy != nil && *task.ClaimedBy != ha.GetNodeID() {
+				// the task is run by another node
+				continue
+			}
+
+			switch task.Status {
+			case task_logger.TaskStoppingStatus, task_logger.TaskStoppedStatus:
+				err = p.StopTask(t.Task, task.Status == task_logger.TaskStoppedStatus)
+			case task_logger.TaskConfirmed:
+				if t.Task.Status == task_logger.TaskWaitingConfirmation {
+					t.SetStatus(task_logger.TaskConfirmed)
+				}
+			default:
+			}
+
+			if err != nil {
+				log.Error(err)
+			}
+		}
+	})
 }
 
 func (p *TaskPool) blocks(t *TaskRunner) bool {
 
-	if util.Config.MaxParallelTasks > 0 && len(p.RunningTasks) >= util.Config.MaxParallelTasks {
+	if util.Config.MaxParallelTasks > 0 && p.getNumberOfCountedRunningTasks() >= util.Config.MaxParallelTasks {
 		return true
 	}
 
+	if t.Template.ConcurrencyGroup != "" {
+		for _, r := range p.RunningTasks {
+			if !r.Task.Status.IsFinished() && !r.projectArchived && r.Template.ConcurrencyGroup == t.Template.ConcurrencyGroup {
+				return true
+			}
+		}
+	}
+
 	if p.activeProj[t.Task.ProjectID] == nil || len(p.activeProj[t.Task.ProjectID]) == 0 {
 		return false
 	}
@@ -212,6 +445,43 @@ func (p *TaskPool) blocks(t *TaskRunner) bool {
 	return proj.MaxParallelTasks > 0 && len(p.activeProj[t.Task.ProjectID]) >= proj.MaxParallelTasks
 }
 
+// concurrencyGroupBusyOnOtherNodes is used in HA mode. It returns true if a task of the
+// concurrency group of the template is run by another node of the cluster.
+func (p *TaskPool) concurrencyGroupBusyOnOtherNodes(t *TaskRunner) (busy bool) {
+	if t.Template.ConcurrencyGroup == "" {
+		return false
+	}
+
+	nodeID := ha.GetNodeID()
+
+	db.StoreSession(p.store, "check concurrency group", func() {
+		tasks, err := p.store.GetUnfinishedTasks()
+		if err != nil {
+			log.Error(err)
+			return
+		}
+
+		for _, task := range tasks {
+			if task.ClaimedBy == nil || *task.ClaimedBy == nodeID {
+				continue
+			}
+
+			tpl, err := p.store.GetTemplate(task.ProjectID, task.TemplateID)
+			if err != nil {
+				log.Error(err)
+				continue
+			}
+
+			if tpl.ConcurrencyGroup == t.Template.ConcurrencyGroup {
+				busy = true
+				return
+			}
+		}
+	})
+
+	return
+}
+
 func CreateTaskPool(store db.Store) TaskPool {
 	return TaskPool{
 		Queue:          make([]*TaskRunner, 0), // queue of waiting tasks
@@ -221,12 +491,23 @@ func CreateTaskPool(store db.Store) TaskPool {
 		logger:         make(chan logRecord, 10000), // store log records to database
 		store:          store,
 		resourceLocker: make(chan *resourceLock),
+		adhocCommands:  make(map[int]*AdhocRunner),
 	}
 }
 
 func (p *TaskPool) ConfirmTask(targetTask db.Task) error {
+	if targetTask.IsCanaryDeployment() {
+		return p.confirmCanaryDeployment(targetTask)
+	}
+
 	tsk := p.GetTask(targetTask.ID)
 
+	if tsk == nil && ha.IsEnabled() && targetTask.Status == task_logger.TaskWaitingConfirmation {
+		// the task is run by another node which applies the status, see syncQueue
+		targetTask.Status = task_logger.TaskConfirmed
+		return p.store.UpdateTask(targetTask)
+	}
+
 	if tsk == nil { // task not active, but exists in database
 		return fmt.Errorf("task is not active")
 	}
@@ -237,7 +518,21 @@ func (p *TaskPool) ConfirmTask(targetTask db.Task) error {
 }
 
 func (p *TaskPool) StopTask(targetTask db.Task, forceStop bool) error {
+	if targetTask.IsCanaryDeployment() {
+		return p.stopCanaryDeployment(targetTask, forceStop)
+	}
+
 	tsk := p.GetTask(targetTask.ID)
+
+	if tsk == nil && ha.IsEnabled() && !targetTask.Status.IsFinished() {
+		// the task is run by another node which applies the status, see syncQueue
+		targetTask.Status = task_logger.TaskStoppingStatus
+		if forceStop {
+			targetTask.Status = task_logger.TaskStoppedStatus
+		}
+		return p.store.UpdateTask(targetTask)
+	}
+
 	if tsk == nil { // task not active, but exists in database
 		tsk = &TaskRunner{
 			Task: targetTask,
@@ -266,6 +561,67 @@ func (p *TaskPool) StopTask(targetTask db.Task, forceStop bool) error {
 	return nil
 }
 
+// StopProjectTasks stops all waiting, delayed and running tasks of the archived project.
+// Stopping tasks are excluded from the accounting of parallel tasks.
+func (p *TaskPool) StopProjectTasks(projectID int) error {
+	tasks, err := p.store.GetUnfinishedTasks()
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		if task.ProjectID != projectID {
+			continue
+		}
+
+		if tsk := p.GetTask(task.ID); tsk != nil {
+			tsk.projectArchived = true
+		}
+
+		if err = p.StopTask(task, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetDelayedTasks returns the tasks of the project which wait for their start time, ordered by the start time.
+func (p *TaskPool) GetDelayedTasks(projectID int) (res []db.Task, err error) {
+	tasks, err := p.store.GetUnfinishedTasks()
+	if err != nil {
+		return
+	}
+
+	res = make([]db.Task, 0)
+
+	for _, task := range tasks {
+		if task.ProjectID == projectID && task.IsDelayed() {
+			res = append(res, task)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].RunAt.Before(*res[j].RunAt)
+	})
+
+	return
+}
+
+// CancelDelayedTask stops the delayed task before it starts.
+// It returns db.ErrInvalidOperation if the task is not delayed.
+func (p *TaskPool) CancelDelayedTask(targetTask db.Task) error {
+	if tsk := p.GetTask(targetTask.ID); tsk != nil {
+		targetTask = tsk.Task
+	}
+
+	if !targetTask.IsDelayed() {
+		return fmt.Errorf("task is not delayed: %w", db.ErrInvalidOperation)
+	}
+
+	return p.StopTask(targetTask, true)
+}
+
 func getNextBuildVersion(startVersion string, currentVersion string) string {
 	re := regexp.MustCompile(`^(.*[^\d])?(\d+)([^\d].*)?$`)
 	m := re.FindStringSubmatch(startVersion)
@@ -320,6 +676,37 @@ func getNextBuildVersion(startVersion string, currentVersion string) string {
 	return prefix + strconv.Itoa(newVer) + suffix
 }
 
+// checkBranchProtection checks that the production template runs for one of the protected
+// branches of the project. The branch of the task overrides the branch of the template,
+// which overrides the branch of the repository, the same way as when the task is run.
+func (p *TaskPool) checkBranchProtection(project db.Project, tpl db.Template, task db.Task) error {
+	if len(project.GetProtectedBranches()) == 0 || !slices.Contains(tpl.Tags, db.ProductionTag) {
+		return nil
+	}
+
+	repo, err := p.store.GetRepository(tpl.ProjectID, tpl.RepositoryID)
+	if err != nil {
+		return err
+	}
+
+	if repo.GetType() == db.RepositoryLocal {
+		// local repositories have no branches
+		return nil
+	}
+
+	branch := repo.GitBranch
+
+	if tpl.GitBranch != nil && *tpl.GitBranch != "" {
+		branch = *tpl.GitBranch
+	}
+
+	if task.GitBranch != nil && *task.GitBranch != "" {
+		branch = *task.GitBranch
+	}
+
+	return project.CheckBranchProtection(tpl, branch)
+}
+
 func (p *TaskPool) AddTask(taskObj db.Task, userID *int, projectID int) (newTask db.Task, err error) {
 	taskObj.Created = time.Now()
 	taskObj.Status = task_logger.TaskWaitingStatus
@@ -327,6 +714,19 @@ func (p *TaskPool) AddTask(taskObj db.Task, userID *int, projectID int) (newTask
 	taskObj.ProjectID = projectID
 	extraSecretVars := taskObj.Secret
 	taskObj.Secret = "{}"
+	becomePassword := taskObj.BecomePassword
+	taskObj.BecomePassword = ""
+	taskObj.InteractiveBecome = becomePassword != ""
+
+	project, err := p.store.GetProject(projectID)
+	if err != nil {
+		return
+	}
+
+	if project.Archived {
+		err = db.ErrProjectArchived
+		return
+	}
 
 	tpl, err := p.store.GetTemplate(projectID, taskObj.TemplateID)
 	if err != nil {
@@ -338,6 +738,58 @@ func (p *TaskPool) AddTask(taskObj db.Task, userID *int, projectID int) (newTask
 		return
 	}
 
+	err = db.ValidateTaskEnvironment(p.store, tpl, taskObj.Environment)
+	if err != nil {
+		return
+	}
+
+	err = p.checkBranchProtection(project, tpl, taskObj)
+	if err != nil {
+		return
+	}
+
+	if taskObj.InteractiveBecome {
+		if !tpl.App.IsAnsible() {
+			err = &db.ValidationError{Message: "become password can be entered only for ansible templates"}
+			return
+		}
+		if util.Config.UseRemoteRunner {
+			err = &db.ValidationError{Message: "become password can not be entered for tasks of remote runners"}
+			return
+		}
+	}
+
+	if ha.IsEnabled() && (extraSecretVars != "" && extraSecretVars != "{}" || taskObj.InteractiveBecome) {
+		// secret variables and the become password are not stored in the database,
+		// so the task can be run only by this node
+		nodeID := ha.GetNodeID()
+		taskObj.ClaimedBy = &nodeID
+	}
+
+	err = db.CheckConcurrentTasksQuota(p.getNumberOfActiveTasksOfProject(projectID))
+	if err != nil {
+		return
+	}
+
+	if tpl.App.IsAnsible() && taskObj.ParentTaskID == nil {
+		var params db.AnsibleTaskParams
+		if err = taskObj.GetParams(&params); err != nil {
+			return
+		}
+
+		if params.Canary != "" {
+			if extraSecretVars != "" && extraSecretVars != "{}" {
+				err = &db.ValidationError{Message: "canary launch does not support secret variables"}
+				return
+			}
+			if taskObj.InteractiveBecome {
+				err = &db.ValidationError{Message: "canary launch does not support become password entered at launch"}
+				return
+			}
+			return p.startCanaryDeployment(taskObj, tpl, params.Canary)
+		}
+	}
+
 	if tpl.Type == db.TemplateBuild { // get next version for TaskRunner if it is a Build
 		var builds []db.TaskWithTpl
 		builds, err = p.store.GetTemplateTasks(tpl.ProjectID, tpl.ID, db.RetrieveQueryParams{Count: 1})
@@ -352,62 +804,193 @@ func (p *TaskPool) AddTask(taskObj db.Task, userID *int, projectID int) (newTask
 		}
 	}
 
+	if tpl.App.IsTerraform() {
+		err = p.fillTerraformWorkspace(&taskObj, tpl)
+		if err != nil {
+			return
+		}
+	}
+
+	var sealedBecomePassword *sealedSecret
+	if taskObj.InteractiveBecome {
+		sealedBecomePassword, err = sealSecret(becomePassword, interactiveBecomeTTL)
+		if err != nil {
+			return
+		}
+	}
+
 	newTask, err = p.store.CreateTask(taskObj, util.Config.MaxTasksPerTemplate)
 	if err != nil {
 		return
 	}
 
-	taskRunner := TaskRunner{
-		Task: newTask,
+	updateTemplateSummary(p.store, newTask, true, false)
+
+	taskRunner, err := p.createTaskRunner(newTask, extraSecretVars, sealedBecomePassword)
+	if err != nil {
+		taskRunner.Log("Error: " + err.Error())
+		taskRunner.SetStatus(task_logger.TaskFailStatus)
+		return
+	}
+
+	skipped, err := p.applyRunConflictPolicy(taskRunner)
+	if err != nil {
+		return
+	}
+
+	if skipped {
+		newTask = taskRunner.Task
+		return
+	}
+
+	p.register <- taskRunner
+
+	sse.Publish(projectID, sse.EventTaskCreated, newTask)
+	taskRunner.sendEvent(TaskEventCreated)
+
+	err = p.createTaskQueueEvent(newTask)
+	if err != nil {
+		return
+	}
+
+	if newTask.InteractiveBecome {
+		err = p.createInteractiveBecomeEvent(newTask)
+	}
+
+	return
+}
+
+func (p *TaskPool) createTaskQueueEvent(task db.Task) error {
+	objType := db.EventTask
+	desc := "Task ID " + strconv.Itoa(task.ID) + " queued for running"
+	_, err := p.store.CreateEvent(db.Event{
+		UserID:        task.UserID,
+		ProjectID:     &task.ProjectID,
+		IntegrationID: task.IntegrationID,
+		ObjectType:    &objType,
+		ObjectID:      &task.ID,
+		Action:        db.EventActionTaskQueue,
+		Description:   &desc,
+	})
+	return err
+}
+
+// createTaskDequeueEvent records that the task left the queue with the time it waited in it.
+func (p *TaskPool) createTaskDequeueEvent(t *TaskRunner) {
+	objType := db.EventTask
+	desc := fmt.Sprintf("Task ID %d dequeued after waiting %s", t.Task.ID, time.Since(t.Task.Created).Round(time.Second))
+
+	db.StoreSession(p.store, "dequeue task", func() {
+		_, err := p.store.CreateEvent(db.Event{
+			UserID:        t.Task.UserID,
+			ProjectID:     &t.Task.ProjectID,
+			IntegrationID: t.Task.IntegrationID,
+			ObjectType:    &objType,
+			ObjectID:      &t.Task.ID,
+			Action:        db.EventActionTaskDequeue,
+			Description:   &desc,
+		})
+		if err != nil {
+			log.Error(err)
+		}
+	})
+}
+
+// createInteractiveBecomeEvent records the use of the become password entered at the launch.
+func (p *TaskPool) createInteractiveBecomeEvent(task db.Task) error {
+	objType := db.EventTask
+	desc := "Task ID " + strconv.Itoa(task.ID) + " launched with become password entered at launch"
+	_, err := p.store.CreateEvent(db.Event{
+		UserID:      task.UserID,
+		ProjectID:   &task.ProjectID,
+		ObjectType:  &objType,
+		ObjectID:    &task.ID,
+		Action:      db.EventActionTaskInteractiveBecome,
+		Description: &desc,
+	})
+	return err
+}
+
+// fillTerraformWorkspace stores the workspace the task runs in to the task params,
+// so tasks can be grouped by workspace even if the inventory is changed later.
+func (p *TaskPool) fillTerraformWorkspace(task *db.Task, tpl db.Template) error {
+	var params db.TerraformTaskParams
+	if err := task.GetParams(&params); err != nil {
+		return err
+	}
+
+	if params.Workspace != "" {
+		return nil
+	}
+
+	inventoryID := tpl.InventoryID
+	if task.InventoryID != nil {
+		inventoryID = task.InventoryID
+	}
+
+	var inventory *db.Inventory
+	if inventoryID != nil {
+		inv, err := p.store.GetInventory(tpl.ProjectID, *inventoryID)
+		if err != nil && !errors.Is(err, db.ErrNotFound) {
+			return err
+		}
+		if err == nil {
+			inventory = &inv
+		}
+	}
+
+	if task.Params == nil {
+		task.Params = make(db.MapStringAnyField)
+	}
+	task.Params["workspace"] = db.GetTerraformWorkspace(params, inventory)
+
+	return nil
+}
+
+// createTaskRunner creates the runner of the task stored in the database.
+// The returned runner can be used for logging even if error is returned.
+func (p *TaskPool) createTaskRunner(task db.Task, secret string, becomePassword *sealedSecret) (taskRunner *TaskRunner, err error) {
+	taskRunner = &TaskRunner{
+		Task: task,
 		pool: p,
 	}
 
 	err = taskRunner.populateDetails()
 	if err != nil {
-		taskRunner.Log("Error: " + err.Error())
-		taskRunner.SetStatus(task_logger.TaskFailStatus)
 		return
 	}
 
 	var job Job
 
-	if util.Config.UseRemoteRunner {
+	if p.JobFactory != nil {
+		job = p.JobFactory(taskRunner)
+	} else if util.Config.UseRemoteRunner {
 		job = &RemoteJob{
-			Task:     taskRunner.Task,
-			taskPool: p,
+			Task:       taskRunner.Task,
+			taskPool:   p,
+			taskRunner: taskRunner,
 		}
 	} else {
 		app := db_lib.CreateApp(
 			taskRunner.Template,
 			taskRunner.Repository,
 			taskRunner.Inventory,
-			&taskRunner)
+			taskRunner)
 
 		job = &LocalJob{
-			Task:        taskRunner.Task,
-			Template:    taskRunner.Template,
-			Inventory:   taskRunner.Inventory,
-			Repository:  taskRunner.Repository,
-			Environment: taskRunner.Environment,
-			Secret:      extraSecretVars,
-			Logger:      app.SetLogger(&taskRunner),
-			App:         app,
+			Task:           taskRunner.Task,
+			Template:       taskRunner.Template,
+			Inventory:      taskRunner.Inventory,
+			Repository:     taskRunner.Repository,
+			Environment:    taskRunner.Environment,
+			Secret:         secret,
+			BecomePassword: becomePassword,
+			Logger:         app.SetLogger(taskRunner),
+			App:            app,
 		}
 	}
 
 	taskRunner.job = job
 
-	p.register <- &taskRunner
-
-	objType := db.EventTask
-	desc := "Task ID " + strconv.Itoa(newTask.ID) + " queued for running"
-	_, err = p.store.CreateEvent(db.Event{
-		UserID:      userID,
-		ProjectID:   &projectID,
-		ObjectType:  &objType,
-		ObjectID:    &newTask.ID,
-		Description: &desc,
-	})
-
 	return
 }
@@ -0,0 +1,192 @@
+package tasks
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestTaskPoolBlocksConcurrencyGroup(t *testing.T) {
+	util.Config = &util.ConfigType{}
+
+	pool := CreateTaskPool(nil)
+
+	running := &TaskRunner{
+		Task:     db.Task{ID: 1, ProjectID: 1, TemplateID: 1, Status: task_logger.TaskRunningStatus},
+		Template: db.Template{ID: 1, ProjectID: 1, ConcurrencyGroup: "prod-db"},
+	}
+	pool.RunningTasks[running.Task.ID] = running
+
+	sameGroup := &TaskRunner{
+		Task:     db.Task{ID: 2, ProjectID: 2, TemplateID: 2, Status: task_logger.TaskWaitingStatus},
+		Template: db.Template{ID: 2, ProjectID: 2, ConcurrencyGroup: "prod-db"},
+	}
+
+	if !pool.blocks(sameGroup) {
+		t.Fatal("task of the same concurrency group in another project must be blocked")
+	}
+
+	otherGroup := &TaskRunner{
+		Task:     db.Task{ID: 3, ProjectID: 2, TemplateID: 3, Status: task_logger.TaskWaitingStatus},
+		Template: db.Template{ID: 3, ProjectID: 2, ConcurrencyGroup: "prod-web"},
+	}
+
+	if pool.blocks(otherGroup) {
+		t.Fatal("task of another concurrency group must not be blocked")
+	}
+
+	running.Task.Status = task_logger.TaskSuccessStatus
+
+	if pool.blocks(sameGroup) {
+		t.Fatal("finished task must not block the concurrency group")
+	}
+}
+
+func TestTaskPoolGetDelayedTasks(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	pool := CreateTaskPool(store)
+
+	later := time.Now().Add(2 * time.Hour)
+	sooner := time.Now().Add(time.Hour)
+
+	var created []db.Task
+
+	for _, task := range []db.Task{
+		{ProjectID: 1, TemplateID: 1, Status: task_logger.TaskWaitingStatus, RunAt: &later},
+		{ProjectID: 1, TemplateID: 1, Status: task_logger.TaskWaitingStatus},
+		{ProjectID: 1, TemplateID: 1, Status: task_logger.TaskWaitingStatus, RunAt: &sooner},
+		{ProjectID: 2, TemplateID: 2, Status: task_logger.TaskWaitingStatus, RunAt: &sooner},
+		{ProjectID: 1, TemplateID: 1, Status: task_logger.TaskStoppedStatus, RunAt: &sooner},
+	} {
+		newTask, err := store.CreateTask(task, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		created = append(created, newTask)
+	}
+
+	delayed, err := pool.GetDelayedTasks(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(delayed) != 2 || delayed[0].ID != created[2].ID || delayed[1].ID != created[0].ID {
+		t.Fatalf("expected delayed tasks %d and %d, got %v", created[2].ID, created[0].ID, delayed)
+	}
+
+	err = pool.CancelDelayedTask(created[1])
+	if !errors.Is(err, db.ErrInvalidOperation) {
+		t.Fatalf("expected invalid operation for the task without start time, got %v", err)
+	}
+}
+
+func TestTaskPoolArchivedProject(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	util.Config = &util.ConfigType{MaxParallelTasks: 1}
+
+	pool := CreateTaskPool(store)
+
+	project, err := store.CreateProject(db.Project{Name: "Archived"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = store.SetProjectArchived(project.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = pool.AddTask(db.Task{TemplateID: 1}, nil, project.ID)
+	if !errors.Is(err, db.ErrProjectArchived) {
+		t.Fatalf("expected archived project error, got %v", err)
+	}
+
+	stopping := &TaskRunner{
+		Task:            db.Task{ID: 1, ProjectID: project.ID, TemplateID: 1, Status: task_logger.TaskStoppingStatus},
+		Template:        db.Template{ID: 1, ProjectID: project.ID},
+		projectArchived: true,
+	}
+	pool.RunningTasks[stopping.Task.ID] = stopping
+
+	waiting := &TaskRunner{
+		Task:     db.Task{ID: 2, ProjectID: project.ID + 1, TemplateID: 2, Status: task_logger.TaskWaitingStatus},
+		Template: db.Template{ID: 2, ProjectID: project.ID + 1},
+	}
+
+	if pool.blocks(waiting) {
+		t.Fatal("task of the archived project must not occupy the slot of parallel tasks")
+	}
+
+	stopping.projectArchived = false
+
+	if !pool.blocks(waiting) {
+		t.Fatal("running task must occupy the slot of parallel tasks")
+	}
+}
+
+func TestTaskPoolCheckBranchProtection(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	pool := CreateTaskPool(store)
+
+	project, err := store.CreateProject(db.Project{Name: "Protected", ProtectedBranches: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := store.CreateAccessKey(db.AccessKey{
+		ProjectID: &project.ID,
+		Type:      db.AccessKeyNone,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := store.CreateRepository(db.Repository{
+		ProjectID: project.ID,
+		SSHKeyID:  key.ID,
+		Name:      "app",
+		GitURL:    "https://example.com/app.git",
+		GitBranch: "develop",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mainBranch := "main"
+	featureBranch := "feature/login"
+
+	tpl := db.Template{ProjectID: project.ID, RepositoryID: repo.ID, Tags: []string{db.ProductionTag}}
+
+	err = pool.checkBranchProtection(project, tpl, db.Task{})
+	if _, ok := err.(*db.ValidationError); !ok {
+		t.Fatalf("production template must not run for the branch of the repository, got %v", err)
+	}
+
+	tpl.GitBranch = &mainBranch
+
+	if err = pool.checkBranchProtection(project, tpl, db.Task{}); err != nil {
+		t.Fatal("production template must run for the protected branch of the template", err)
+	}
+
+	err = pool.checkBranchProtection(project, tpl, db.Task{GitBranch: &featureBranch})
+	if _, ok := err.(*db.ValidationError); !ok {
+		t.Fatalf("branch of the task must override the protected branch of the template, got %v", err)
+	}
+
+	tpl.Tags = nil
+
+	if err = pool.checkBranchProtection(project, tpl, db.Task{GitBranch: &featureBranch}); err != nil {
+		t.Fatal("template without production tag must run for any branch", err)
+	}
+}
@@ -6,9 +6,12 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/semaphoreui/semaphore/api/sockets"
+	"github.com/semaphoreui/semaphore/api/sse"
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/pkg/task_logger"
 	"github.com/semaphoreui/semaphore/util"
@@ -41,6 +44,37 @@ type TaskRunner struct {
 
 	statusListeners []task_logger.StatusListener
 	logListeners    []task_logger.LogListener
+
+	// masker hides values matched by masking rules of the project in the output
+	masker *db.OutputMasker
+	// outputLimiter limits the output stored in the database
+	outputLimiter *outputLimiter
+	// storedOutput is the number of output records stored in the database.
+	// It is changed only by the output writer of the pool.
+	storedOutput int
+
+	// alertLock prevents sending of status alerts during budget alerts
+	alertLock sync.Mutex
+	// budgetAlert replaces task status in alerts sent when the task exceeds runtime budget
+	budgetAlert string
+
+	// cmdOutput writes the output of the last command started by the job to the log
+	cmdOutput []*lineWriter
+
+	// projectArchived is set if the project is archived while the task is active.
+	// Such tasks are being stopped and do not occupy slots of parallel tasks.
+	projectArchived bool
+
+	// progressLock guards the progress of the task which is updated by the log listener.
+	progressLock sync.Mutex
+
+	// excludedHosts are the hosts of the project exclusion list at the start of the task.
+	excludedHosts []string
+
+	// recap collects results of the hosts of ansible tasks.
+	recap *playRecap
+	// outputSize is the number of bytes of the task output, it is saved to the task metrics.
+	outputSize atomic.Int64
 }
 
 func (t *TaskRunner) AddStatusListener(l task_logger.StatusListener) {
@@ -72,6 +106,17 @@ func (t *TaskRunner) saveStatus() {
 	if err := t.pool.store.UpdateTask(t.Task); err != nil {
 		t.panicOnError(err, "Failed to update TaskRunner status")
 	}
+
+	updateTemplateSummary(t.pool.store, t.Task, false, false)
+
+	sse.Publish(t.Task.ProjectID, sse.EventTaskStatus, map[string]interface{}{
+		"task_id":     t.Task.ID,
+		"template_id": t.Task.TemplateID,
+		"status":      t.Task.Status,
+		"start":       t.Task.Start,
+		"end":         t.Task.End,
+		"version":     t.Task.Version,
+	})
 }
 
 func (t *TaskRunner) kill() {
@@ -83,11 +128,13 @@ func (t *TaskRunner) createTaskEvent() {
 	desc := "Task ID " + strconv.Itoa(t.Task.ID) + " (" + t.Template.Name + ")" + " finished - " + strings.ToUpper(string(t.Task.Status))
 
 	_, err := t.pool.store.CreateEvent(db.Event{
-		UserID:      t.Task.UserID,
-		ProjectID:   &t.Task.ProjectID,
-		ObjectType:  &objType,
-		ObjectID:    &t.Task.ID,
-		Description: &desc,
+		UserID:        t.Task.UserID,
+		ProjectID:     &t.Task.ProjectID,
+		IntegrationID: t.Task.IntegrationID,
+		ObjectType:    &objType,
+		ObjectID:      &t.Task.ID,
+		Action:        db.EventActionTaskFinish,
+		Description:   &desc,
 	})
 
 	if err != nil {
@@ -95,6 +142,63 @@ func (t *TaskRunner) createTaskEvent() {
 	}
 }
 
+// createRunRecord appends the finished task to the hash chain of the project runs.
+func (t *TaskRunner) createRunRecord() {
+	var username *string
+
+	if t.Task.UserID != nil {
+		user, err := t.pool.store.GetUser(*t.Task.UserID)
+		if err == nil {
+			username = &user.Username
+		}
+	}
+
+	record, err := db.NewRunRecord(t.Task, t.Template, username)
+	if err == nil {
+		_, err = t.pool.store.AppendRunRecord(record)
+	}
+
+	if err != nil {
+		log.Error("Can't create run record of task " + strconv.Itoa(t.Task.ID) + "! Error: " + err.Error())
+	}
+}
+
+// createTaskMetric stores the metrics of the finished task for the long-term statistics.
+func (t *TaskRunner) createTaskMetric() {
+	var changed, failed int
+	if t.recap != nil {
+		changed, failed = t.recap.changedAndFailed()
+	}
+
+	metric := db.NewTaskMetric(t.Task, changed, failed, t.outputSize.Load())
+
+	if _, err := t.pool.store.CreateTaskMetric(metric); err != nil {
+		log.Error("Can't create metrics of task " + strconv.Itoa(t.Task.ID) + "! Error: " + err.Error())
+	}
+}
+
+// createTaskHosts stores the results of the hosts of the finished ansible task
+// for the run history of the hosts.
+func (t *TaskRunner) createTaskHosts() {
+	if t.recap == nil {
+		return
+	}
+
+	results := t.recap.results()
+	if len(results) == 0 {
+		return
+	}
+
+	hostGroups, err := db.GetInventoryHostGroups(t.Inventory)
+	if err != nil {
+		log.Warn("Can't read groups of inventory of task " + strconv.Itoa(t.Task.ID) + ": " + err.Error())
+	}
+
+	if err = t.pool.store.CreateTaskHosts(db.NewTaskHosts(t.Task, results, hostGroups)); err != nil {
+		log.Error("Can't create host results of task " + strconv.Itoa(t.Task.ID) + "! Error: " + err.Error())
+	}
+}
+
 func (t *TaskRunner) run() {
 	if !t.pool.store.PermanentConnection() {
 		t.pool.store.Connect("run task " + strconv.Itoa(t.Task.ID))
@@ -107,9 +211,25 @@ func (t *TaskRunner) run() {
 		t.pool.resourceLocker <- &resourceLock{lock: false, holder: t}
 
 		now := time.Now()
+		t.flushOutput(now)
 		t.Task.End = &now
 		t.saveStatus()
 		t.createTaskEvent()
+		t.createRunRecord()
+		t.createTaskMetric()
+		t.createTaskHosts()
+		updateTemplateSummary(t.pool.store, t.Task, false, true)
+		t.recordScheduleRun()
+		t.checkTemplateHealth()
+		t.exportDeployedVersions()
+
+		if t.Task.ParentTaskID != nil {
+			t.pool.onCanaryBatchFinished(t.Task)
+		}
+
+		if getOutputStorage() != nil {
+			t.pool.logger <- logRecord{task: t, archive: true}
+		}
 	}()
 
 	// Mark task as stopped if user stopped task during preparation (before task run).
@@ -118,17 +238,33 @@ func (t *TaskRunner) run() {
 		return
 	}
 
+	// remote runners receive the job when the task is starting, so hosts are excluded before
+	if err := t.excludeHosts(); err != nil {
+		t.Log("Can not get excluded hosts: " + err.Error())
+		t.SetStatus(task_logger.TaskFailStatus)
+		return
+	}
+
+	if err := t.resolveRunOutputs(); err != nil {
+		t.Log("Can not substitute outputs of the build task: " + err.Error())
+		t.SetStatus(task_logger.TaskFailStatus)
+		return
+	}
+
 	t.SetStatus(task_logger.TaskStartingStatus)
+	t.recordObjectVersions()
 
 	objType := db.EventTask
 	desc := "Task ID " + strconv.Itoa(t.Task.ID) + " (" + t.Template.Name + ")" + " is running"
 
 	_, err := t.pool.store.CreateEvent(db.Event{
-		UserID:      t.Task.UserID,
-		ProjectID:   &t.Task.ProjectID,
-		ObjectType:  &objType,
-		ObjectID:    &t.Task.ID,
-		Description: &desc,
+		UserID:        t.Task.UserID,
+		ProjectID:     &t.Task.ProjectID,
+		IntegrationID: t.Task.IntegrationID,
+		ObjectType:    &objType,
+		ObjectID:      &t.Task.ID,
+		Action:        db.EventActionTaskStart,
+		Description:   &desc,
 	})
 
 	if err != nil {
@@ -155,7 +291,60 @@ func (t *TaskRunner) run() {
 
 	}
 
+	if budget := t.getRuntimeBudget(); budget > 0 {
+		timer := time.AfterFunc(budget, func() {
+			t.exceedBudget(budget)
+		})
+		defer timer.Stop()
+	}
+
+	if t.Template.HasChangeWindow() {
+		timer, ok := t.startChangeWindowTimer()
+		if !ok {
+			t.Log("Task is started outside the change window " + t.Template.ChangeWindowStart + "-" + t.Template.ChangeWindowEnd)
+			t.SetStatus(task_logger.TaskFailStatus)
+			return
+		}
+		defer timer.Stop()
+	}
+
+	if t.Template.App.IsAnsible() {
+		t.recap = newPlayRecap()
+		t.AddLogListener(t.recap.parseLine)
+	}
+
+	if t.Template.App.IsAnsible() {
+		t.AddLogListener(newTaskProgress(t.setTasksDone).parseLine)
+	}
+
+	var plan *terraformPlan
+	if t.Template.App.IsTerraform() {
+		plan = newTerraformPlan()
+		t.AddLogListener(plan.parseLine)
+	}
+
 	err = t.job.Run(username, incomingVersion)
+	t.flushCmdOutput()
+
+	if plan != nil {
+		t.saveTerraformPlan(plan)
+	}
+
+	if t.isChangeWindowClosed() {
+		t.SetStatus(task_logger.TaskFailStatus)
+		t.runAbortTemplate()
+		return
+	}
+
+	if t.recap != nil {
+		t.Task.HostsTotal, t.Task.HostsUnreachable, _ = t.recap.counts()
+
+		if t.Task.Status == task_logger.TaskRunningStatus && t.recap.isPartial(err) {
+			t.Logf("Playbook succeeded but %d of %d hosts were unreachable", t.Task.HostsUnreachable, t.Task.HostsTotal)
+			t.SetStatus(task_logger.TaskPartialStatus)
+			return
+		}
+	}
 
 	if err != nil {
 		t.Log("Running app failed: " + err.Error())
@@ -213,15 +402,36 @@ func (t *TaskRunner) populateDetails() error {
 		return t.prepareError(err, "Template not found!")
 	}
 
+	if err = db.FillTemplateSecretFiles(t.pool.store, &t.Template); err != nil {
+		return t.prepareError(err, "Key of secret file not found!")
+	}
+
+	if err = db.FillTemplateUploads(t.pool.store, &t.Template); err != nil {
+		return t.prepareError(err, "Uploaded files of template can not be loaded!")
+	}
+
+	if err = db.FillTemplateArtifacts(t.pool.store, &t.Template); err != nil {
+		return t.prepareError(err, "Key of artifact not found!")
+	}
+
 	// get project alert setting
 	project, err := t.pool.store.GetProject(t.Template.ProjectID)
 	if err != nil {
 		return t.prepareError(err, "Project not found!")
 	}
 
-	t.alert = project.Alert
+	// scheduled runs are reported by the digest if it is enabled for the project
+	t.alert = project.Alert && (project.AlertDigest == "" || t.Task.ScheduleID == nil)
 	t.alertChat = project.AlertChat
 
+	maskingRules, err := t.pool.store.GetMaskingRules(t.Template.ProjectID)
+	if err != nil {
+		return t.prepareError(err, "Masking rules not found!")
+	}
+
+	t.masker = db.NewOutputMasker(maskingRules)
+	t.outputLimiter = newOutputLimiter(getTaskOutputConfig())
+
 	// get project users
 	projectUsers, err := t.pool.store.GetProjectUsers(t.Template.ProjectID, db.RetrieveQueryParams{})
 	if err != nil {
@@ -248,23 +458,22 @@ func (t *TaskRunner) populateDetails() error {
 		t.users = append(t.users, userID)
 	}
 
-	// get inventory
-	if t.Task.InventoryID != nil {
-		t.Inventory, err = t.pool.store.GetInventory(t.Template.ProjectID, *t.Task.InventoryID)
-		if err != nil {
-			if t.Template.InventoryID != nil {
-				t.Inventory, err = t.pool.store.GetInventory(t.Template.ProjectID, *t.Template.InventoryID)
-				if err != nil {
-					return t.prepareError(err, "Template Inventory not found!")
-				}
-			}
+	t.Inventory, err = t.getInventory()
+	if err != nil {
+		return t.prepareError(err, "Template Inventory not found!")
+	}
+
+	if err = db.ApplyProjectDefaultKeys(t.pool.store, project, &t.Inventory, &t.Template); err != nil {
+		return t.prepareError(err, "Project default key not found!")
+	}
+
+	if t.Inventory.ID != 0 {
+		if err = db.FillInventorySecrets(t.pool.store, &t.Inventory, true); err != nil {
+			return err
 		}
-	} else {
-		if t.Template.InventoryID != nil {
-			t.Inventory, err = t.pool.store.GetInventory(t.Template.ProjectID, *t.Template.InventoryID)
-			if err != nil {
-				return t.prepareError(err, "Template Inventory not found!")
-			}
+
+		for _, secret := range t.Inventory.Secrets {
+			t.masker.AddValues(secret.Secret)
 		}
 	}
 
@@ -280,6 +489,29 @@ func (t *TaskRunner) populateDetails() error {
 		return err
 	}
 
+	return t.populateEnvironment()
+}
+
+// getInventory returns the inventory of the task. The inventory of the template is used
+// if the task has no inventory or its inventory is not found.
+func (t *TaskRunner) getInventory() (inventory db.Inventory, err error) {
+	if t.Task.InventoryID != nil {
+		inventory, err = t.pool.store.GetInventory(t.Template.ProjectID, *t.Task.InventoryID)
+		if err == nil || t.Template.InventoryID == nil {
+			return inventory, nil
+		}
+	}
+
+	if t.Template.InventoryID != nil {
+		inventory, err = t.pool.store.GetInventory(t.Template.ProjectID, *t.Template.InventoryID)
+	}
+
+	return
+}
+
+// populateEnvironment resolves variables of the task. Later sources win: extra variables
+// of the task, project defaults, variable groups and the template environment.
+func (t *TaskRunner) populateEnvironment() (err error) {
 	// get environment
 	if t.Template.EnvironmentID != nil {
 		t.Environment, err = t.pool.store.GetEnvironment(t.Template.ProjectID, *t.Template.EnvironmentID)
@@ -292,6 +524,23 @@ func (t *TaskRunner) populateDetails() error {
 		}
 	}
 
+	if err = db.MergeTemplateVariableGroups(t.pool.store, t.Template, &t.Environment, true); err != nil {
+		return err
+	}
+
+	defaultEnvironment, err := t.pool.store.GetProjectDefaultEnvironment(t.Template.ProjectID)
+	if err == nil {
+		if err = db.FillEnvironmentSecrets(t.pool.store, &defaultEnvironment, true); err != nil {
+			return err
+		}
+
+		if err = t.Environment.MergeProjectDefaults(defaultEnvironment); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, db.ErrNotFound) {
+		return err
+	}
+
 	if t.Task.Environment != "" {
 		environment := make(map[string]interface{})
 		if t.Environment.JSON != "" {
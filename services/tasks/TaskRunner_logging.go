@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/semaphoreui/semaphore/api/sockets"
+	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/pkg/task_logger"
 	"github.com/semaphoreui/semaphore/util"
 	log "github.com/sirupsen/logrus"
@@ -22,6 +23,9 @@ func (t *TaskRunner) Logf(format string, a ...any) {
 }
 
 func (t *TaskRunner) LogWithTime(now time.Time, msg string) {
+	msg = t.masker.Mask(msg)
+	t.outputSize.Add(int64(len(msg)))
+
 	for _, user := range t.users {
 		b, err := json.Marshal(&map[string]interface{}{
 			"type":       "log",
@@ -35,10 +39,17 @@ func (t *TaskRunner) LogWithTime(now time.Time, msg string) {
 		sockets.Message(user, b)
 	}
 
-	t.pool.logger <- logRecord{
+	stored := msg
+	if util.Config.TaskOutput != nil && util.Config.TaskOutput.StripANSI {
+		stored = util.StripANSI(stored)
+	}
+
+	for _, record := range t.outputLimiter.add(logRecord{
 		task:   t,
-		output: msg,
+		output: stored,
 		time:   now,
+	}) {
+		t.pool.logger <- record
 	}
 
 	for _, l := range t.logListeners {
@@ -46,16 +57,88 @@ func (t *TaskRunner) LogWithTime(now time.Time, msg string) {
 	}
 }
 
+// flushOutput stores the tail of the output which exceeded the limits.
+func (t *TaskRunner) flushOutput(now time.Time) {
+	for _, record := range t.outputLimiter.flush(t, now) {
+		t.pool.logger <- record
+	}
+}
+
 func (t *TaskRunner) LogfWithTime(now time.Time, format string, a ...any) {
 	t.LogWithTime(now, fmt.Sprintf(format, a...))
 }
 
+// AddFindings stores problems found by the pre-run checks of the task.
+func (t *TaskRunner) AddFindings(findings []db.TaskFinding) {
+	for _, finding := range findings {
+		finding.TaskID = t.Task.ID
+		if _, err := t.pool.store.CreateTaskFinding(finding); err != nil {
+			util.LogErrorWithFields(err, log.Fields{"error": "Failed to store task finding"})
+		}
+	}
+}
+
+// SetPreparationSteps stores durations of the steps which prepared the task to run.
+func (t *TaskRunner) SetPreparationSteps(steps []db.TaskPreparationStep) {
+	t.Task.PreparationSteps = steps
+
+	if err := t.pool.store.UpdateTask(t.Task); err != nil {
+		util.LogErrorWithFields(err, log.Fields{"error": "Failed to store preparation steps of the task"})
+	}
+}
+
+// SetRunOutputs stores outputs registered by the task.
+func (t *TaskRunner) SetRunOutputs(outputs []db.RunOutput) {
+	for _, output := range outputs {
+		output.TaskID = t.Task.ID
+		if _, err := t.pool.store.CreateRunOutput(output); err != nil {
+			util.LogErrorWithFields(err, log.Fields{"error": "Failed to store task output"})
+		}
+	}
+}
+
+// AddArtifacts stores artifacts published by the task.
+func (t *TaskRunner) AddArtifacts(artifacts []db.TaskArtifact) {
+	for _, artifact := range artifacts {
+		artifact.TaskID = t.Task.ID
+		if _, err := t.pool.store.CreateTaskArtifact(artifact); err != nil {
+			util.LogErrorWithFields(err, log.Fields{"error": "Failed to store task artifact"})
+		}
+	}
+}
+
+// SetTemplateDoc stores the documentation of the template generated from the checked out playbook.
+func (t *TaskRunner) SetTemplateDoc(playbook string, content db.TemplateDocContent) {
+	err := t.pool.store.SetTemplateDoc(db.TemplateDoc{
+		TemplateID: t.Template.ID,
+		ProjectID:  t.Template.ProjectID,
+		Playbook:   playbook,
+		CommitHash: t.Task.CommitHash,
+		Updated:    time.Now(),
+		Content:    content,
+	})
+	if err != nil {
+		util.LogErrorWithFields(err, log.Fields{"error": "Failed to store documentation of the template"})
+	}
+}
+
 func (t *TaskRunner) LogCmd(cmd *exec.Cmd) {
-	stderr, _ := cmd.StderrPipe()
-	stdout, _ := cmd.StdoutPipe()
+	t.flushCmdOutput()
+
+	stdout := &lineWriter{log: t.Log}
+	stderr := &lineWriter{log: t.Log}
+	t.cmdOutput = []*lineWriter{stdout, stderr}
+
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+}
 
-	go t.logPipe(bufio.NewReader(stderr))
-	go t.logPipe(bufio.NewReader(stdout))
+// flushCmdOutput logs the last unterminated lines of the last command.
+func (t *TaskRunner) flushCmdOutput() {
+	for _, w := range t.cmdOutput {
+		w.flush()
+	}
+	t.cmdOutput = nil
 }
 
 func (t *TaskRunner) SetStatus(status task_logger.TaskStatus) {
@@ -96,6 +179,17 @@ func (t *TaskRunner) SetStatus(status task_logger.TaskStatus) {
 		localJob.SetStatus(status)
 	}
 
+	if t.RunnerID != 0 && t.pool != nil {
+		t.pool.notifyRunner(t.RunnerID)
+	}
+
+	if status == task_logger.TaskWaitingConfirmation {
+		t.sendApprovalEmails()
+	}
+
+	t.alertLock.Lock()
+	defer t.alertLock.Unlock()
+
 	if status == task_logger.TaskFailStatus {
 		t.sendMailAlert()
 	}
@@ -109,6 +203,16 @@ func (t *TaskRunner) SetStatus(status task_logger.TaskStatus) {
 		t.sendGotifyAlert()
 	}
 
+	if status == task_logger.TaskRunningStatus || status.IsFinished() {
+		t.sendCommitStatus()
+	}
+
+	if status == task_logger.TaskRunningStatus {
+		t.sendEvent(TaskEventStarted)
+	} else if status.IsFinished() {
+		t.sendEvent(TaskEventFinished)
+	}
+
 	for _, l := range t.statusListeners {
 		l(status)
 	}
@@ -121,20 +225,6 @@ func (t *TaskRunner) panicOnError(err error, msg string) {
 	}
 }
 
-func (t *TaskRunner) logPipe(reader *bufio.Reader) {
-	line, err := Readln(reader)
-
-	for err == nil {
-		t.Log(line)
-		line, err = Readln(reader)
-	}
-
-	if err != nil && err.Error() != "EOF" {
-		//don't panic on these errors, sometimes it throws not dangerous "read |0: file already closed" error
-		util.LogWarningWithFields(err, log.Fields{"error": "Failed to read TaskRunner output"})
-	}
-}
-
 // Readln reads from the pipe
 func Readln(r *bufio.Reader) (string, error) {
 	var (
@@ -5,7 +5,9 @@ import (
 	"os"
 	"path"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/semaphoreui/semaphore/db_lib"
 
@@ -59,7 +61,25 @@ func TestTaskRunnerRun(t *testing.T) {
 			},
 		},
 	}
+
+	var logged atomic.Int32
+	taskRunner.AddLogListener(func(_ time.Time, _ string) {
+		logged.Add(1)
+	})
+
 	taskRunner.run()
+
+	// wait until the pool stores the output, otherwise it can use config replaced by the next tests
+	for i := 0; i < 100; i++ {
+		var output []db.TaskOutput
+		db.StoreSession(store, "", func() {
+			output, err = store.GetTaskOutputs(task.ProjectID, task.ID)
+		})
+		if err == nil && len(output) >= int(logged.Load()) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
 }
 
 func TestGetRepoPath(t *testing.T) {
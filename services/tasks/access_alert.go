@@ -0,0 +1,125 @@
+package tasks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/i18n"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// AccessChangeType is the kind of the change of access.
+type AccessChangeType string
+
+const (
+	AccessUserAdded       AccessChangeType = "user_added"
+	AccessUserRemoved     AccessChangeType = "user_removed"
+	AccessRoleChanged     AccessChangeType = "role_changed"
+	AccessAPITokenCreated AccessChangeType = "api_token_created"
+)
+
+// AccessChange describes the change of access which is sent to the webhook
+// of the access alerts as JSON.
+type AccessChange struct {
+	Type AccessChangeType `json:"type"`
+	// ProjectID is zero for changes which are not related to a project, like API tokens.
+	ProjectID   int    `json:"project_id,omitempty"`
+	ProjectName string `json:"project_name,omitempty"`
+	UserID      int    `json:"user_id"`
+	Username    string `json:"username"`
+	// Role is the new role of the user, PreviousRole is set when the role is changed or the user is removed.
+	Role         db.ProjectUserRole `json:"role,omitempty"`
+	PreviousRole db.ProjectUserRole `json:"previous_role,omitempty"`
+	// ActorID is the user who changed the access.
+	ActorID       int       `json:"actor_id"`
+	ActorUsername string    `json:"actor_username"`
+	Time          time.Time `json:"time"`
+}
+
+// Alert returns the change as the project alert.
+func (c AccessChange) Alert() ProjectAlert {
+	alert := ProjectAlert{
+		Subject: i18n.Msg("Access to project %s changed", c.ProjectName),
+	}
+
+	if c.ProjectID != 0 {
+		alert.URL = fmt.Sprintf("%s/project/%d/team", util.Config.WebHost, c.ProjectID)
+	}
+
+	switch c.Type {
+	case AccessUserAdded:
+		alert.Text = i18n.Msg("User %s was added to the project with role %s by %s", c.Username, c.Role, c.ActorUsername)
+	case AccessUserRemoved:
+		alert.Text = i18n.Msg("User %s was removed from the project by %s", c.Username, c.ActorUsername)
+	case AccessRoleChanged:
+		alert.Text = i18n.Msg("Role of user %s was changed from %s to %s by %s", c.Username, c.PreviousRole, c.Role, c.ActorUsername)
+	case AccessAPITokenCreated:
+		alert.Subject = i18n.Msg("API token created")
+		alert.Text = i18n.Msg("User %s created an API token", c.Username)
+	}
+
+	return alert
+}
+
+// SendAccessChangeAlert notifies about the change of access if access alerts are enabled
+// in the config. The change is sent to the email and the webhook of the access alerts,
+// changes in projects are also sent through the alerts of the project. Recipients are read
+// from the store before returning, the alert is sent in the background.
+func SendAccessChangeAlert(store db.Store, change AccessChange) {
+	cfg := util.Config.AccessAlert
+	if cfg == nil || !cfg.Enabled {
+		return
+	}
+
+	if change.Time.IsZero() {
+		change.Time = time.Now()
+	}
+
+	var project db.Project
+	var members []db.UserWithProjectRole
+
+	if change.ProjectID != 0 {
+		var err error
+
+		project, err = store.GetProject(change.ProjectID)
+		if err != nil {
+			util.LogError(err)
+			return
+		}
+
+		change.ProjectName = project.Name
+
+		if project.Alert && util.Config.EmailAlert {
+			members, err = store.GetProjectUsers(project.ID, db.RetrieveQueryParams{})
+			if err != nil {
+				util.LogError(err)
+				return
+			}
+		}
+	}
+
+	alert := change.Alert()
+
+	go func() {
+		if cfg.Email != "" && util.Config.EmailAlert {
+			sendProjectMail(db.User{Email: cfg.Email}, alert)
+		}
+
+		if cfg.WebhookUrl != "" {
+			postProjectAlert("access", cfg.WebhookUrl, change)
+		}
+
+		if change.ProjectID == 0 || !project.Alert {
+			return
+		}
+
+		for _, member := range members {
+			if member.Alert {
+				sendProjectMail(member.User, alert)
+			}
+		}
+
+		sendChatAlert(project, alert)
+	}()
+}
@@ -0,0 +1,100 @@
+package tasks
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestSendAccessChangeAlert(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	project, err := store.CreateProject(db.Project{Name: "Payments"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan AccessChange, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var change AccessChange
+		if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+			t.Error(err)
+		}
+		received <- change
+	}))
+	defer server.Close()
+
+	util.Config = &util.ConfigType{AccessAlert: &util.AccessAlertConfig{WebhookUrl: server.URL}}
+
+	change := AccessChange{
+		Type:          AccessRoleChanged,
+		ProjectID:     project.ID,
+		UserID:        2,
+		Username:      "dev",
+		Role:          db.ProjectOwner,
+		PreviousRole:  db.ProjectTaskRunner,
+		ActorID:       1,
+		ActorUsername: "admin",
+	}
+
+	SendAccessChangeAlert(store, change)
+
+	select {
+	case <-received:
+		t.Fatal("disabled access alerts must not be sent")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	util.Config.AccessAlert.Enabled = true
+
+	SendAccessChangeAlert(store, change)
+
+	select {
+	case got := <-received:
+		if got.Type != AccessRoleChanged || got.ProjectName != "Payments" || got.PreviousRole != db.ProjectTaskRunner {
+			t.Fatalf("unexpected change %+v", got)
+		}
+		if got.Time.IsZero() {
+			t.Fatal("time of the change must be set")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("access alert must be sent to the webhook")
+	}
+}
+
+func TestAccessChangeAlert(t *testing.T) {
+	util.Config = &util.ConfigType{WebHost: "https://semaphore.example.com"}
+
+	alert := AccessChange{
+		Type:          AccessUserAdded,
+		ProjectID:     3,
+		ProjectName:   "Payments",
+		Username:      "dev",
+		Role:          db.ProjectManager,
+		ActorUsername: "admin",
+	}.Alert()
+
+	msg := alert.message("en")
+
+	for _, part := range []string{"Access to project Payments changed", "User dev was added to the project with role manager by admin", "/project/3/team"} {
+		if !strings.Contains(msg, part) {
+			t.Fatalf("alert %q must contain %q", msg, part)
+		}
+	}
+
+	alert = AccessChange{Type: AccessAPITokenCreated, Username: "dev"}.Alert()
+
+	if alert.URL != "" || alert.message("en") != "API token created\nUser dev created an API token" {
+		t.Fatalf("unexpected alert %q", alert.message("en"))
+	}
+}
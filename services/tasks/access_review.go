@@ -0,0 +1,161 @@
+package tasks
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/i18n"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// AccessReviewMember describes the access of the user to the project.
+type AccessReviewMember struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	// Role is empty for admins who are not members of the project.
+	Role      db.ProjectUserRole `json:"role"`
+	Admin     bool               `json:"admin"`
+	ExpiresAt *time.Time         `json:"expires_at"`
+	// LastActivity is the time of the last event of the user in the project.
+	LastActivity *time.Time `json:"last_activity"`
+}
+
+// AccessReview lists users who have access to the project at the time of generation.
+type AccessReview struct {
+	ProjectID   int                  `json:"project_id"`
+	ProjectName string               `json:"project_name"`
+	Generated   time.Time            `json:"generated"`
+	Members     []AccessReviewMember `json:"members"`
+}
+
+// GetProjectAccessReview collects members of the project and admins, who have access
+// to all projects. Members whose membership expired are skipped.
+func GetProjectAccessReview(store db.Store, project db.Project, now time.Time) (review AccessReview, err error) {
+	review = AccessReview{
+		ProjectID:   project.ID,
+		ProjectName: project.Name,
+		Generated:   now,
+		Members:     []AccessReviewMember{},
+	}
+
+	users, err := store.GetProjectUsers(project.ID, db.RetrieveQueryParams{})
+	if err != nil {
+		return
+	}
+
+	admins, err := store.GetAllAdmins()
+	if err != nil {
+		return
+	}
+
+	activity, err := store.GetProjectUsersLastActivity(project.ID)
+	if err != nil {
+		return
+	}
+
+	added := make(map[int]bool)
+
+	addMember := func(user db.User, role db.ProjectUserRole, expiresAt *time.Time) {
+		member := AccessReviewMember{
+			UserID:    user.ID,
+			Username:  user.Username,
+			Name:      user.Name,
+			Email:     user.Email,
+			Role:      role,
+			Admin:     user.Admin,
+			ExpiresAt: expiresAt,
+		}
+
+		if last, ok := activity[user.ID]; ok {
+			member.LastActivity = &last
+		}
+
+		added[user.ID] = true
+		review.Members = append(review.Members, member)
+	}
+
+	for _, user := range users {
+		if user.IsExpired(now) {
+			continue
+		}
+		addMember(user.User, user.Role, user.ExpiresAt)
+	}
+
+	for _, admin := range admins {
+		if !added[admin.ID] {
+			addMember(admin, db.ProjectNone, nil)
+		}
+	}
+
+	sort.Slice(review.Members, func(i, j int) bool {
+		return review.Members[i].Username < review.Members[j].Username
+	})
+
+	return
+}
+
+func formatAccessReviewTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+// Alert returns the access review as the project alert.
+func (r *AccessReview) Alert() ProjectAlert {
+	alert := ProjectAlert{
+		Subject: i18n.Msg("Access review of project %s", r.ProjectName),
+		Text: i18n.Msg("Users who have access to the project on %s: %d",
+			r.Generated.Format(time.RFC3339),
+			len(r.Members)),
+		URL: fmt.Sprintf("%s/project/%d/team", util.Config.WebHost, r.ProjectID),
+	}
+
+	for _, member := range r.Members {
+		role := string(member.Role)
+		if role == "" {
+			role = "admin"
+		}
+
+		alert.Details = append(alert.Details, i18n.Msg("%s (%s), role: %s, expires: %s, last activity: %s",
+			member.Username,
+			member.Email,
+			role,
+			formatAccessReviewTime(member.ExpiresAt),
+			formatAccessReviewTime(member.LastActivity)))
+	}
+
+	return alert
+}
+
+// SendProjectAccessReview emails the access review to the owners of the project.
+// It does nothing if email alerts are disabled in the config.
+func SendProjectAccessReview(store db.Store, project db.Project, now time.Time) error {
+	if !util.Config.EmailAlert {
+		return nil
+	}
+
+	review, err := GetProjectAccessReview(store, project, now)
+	if err != nil {
+		return err
+	}
+
+	users, err := store.GetProjectUsers(project.ID, db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+
+	alert := review.Alert()
+
+	for _, user := range users {
+		if user.Role == db.ProjectOwner && !user.IsExpired(now) {
+			sendProjectMail(user.User, alert)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package tasks
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+)
+
+func TestGetProjectAccessReview(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	project, err := store.CreateProject(db.Project{Name: "Audit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	expired := now.Add(-time.Hour)
+	expires := now.Add(24 * time.Hour)
+
+	var users []db.User
+	for _, u := range []db.User{
+		{Username: "owner", Name: "Owner", Email: "owner@example.com"},
+		{Username: "contractor", Name: "Contractor", Email: "contractor@example.com"},
+		{Username: "former", Name: "Former", Email: "former@example.com"},
+		{Username: "admin", Name: "Admin", Email: "admin@example.com", Admin: true},
+	} {
+		user, createErr := store.CreateUserWithoutPassword(u)
+		if createErr != nil {
+			t.Fatal(createErr)
+		}
+		users = append(users, user)
+	}
+
+	for _, pu := range []db.ProjectUser{
+		{ProjectID: project.ID, UserID: users[0].ID, Role: db.ProjectOwner},
+		{ProjectID: project.ID, UserID: users[1].ID, Role: db.ProjectTaskRunner, ExpiresAt: &expires},
+		{ProjectID: project.ID, UserID: users[2].ID, Role: db.ProjectManager, ExpiresAt: &expired},
+	} {
+		if _, err = store.CreateProjectUser(pu); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	objType := db.EventTask
+	if _, err = store.CreateEvent(db.Event{UserID: &users[1].ID, ProjectID: &project.ID, ObjectType: &objType}); err != nil {
+		t.Fatal(err)
+	}
+
+	review, err := GetProjectAccessReview(store, project, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(review.Members) != 3 {
+		t.Fatalf("expected owner, contractor and admin, got %v", review.Members)
+	}
+
+	admin, contractor, owner := review.Members[0], review.Members[1], review.Members[2]
+
+	if admin.Username != "admin" || !admin.Admin || admin.Role != db.ProjectNone {
+		t.Fatalf("unexpected admin %v", admin)
+	}
+
+	if contractor.ExpiresAt == nil || !contractor.ExpiresAt.Equal(expires) || contractor.LastActivity == nil {
+		t.Fatalf("unexpected contractor %v", contractor)
+	}
+
+	if owner.Role != db.ProjectOwner || owner.LastActivity != nil {
+		t.Fatalf("unexpected owner %v", owner)
+	}
+
+	alert := review.Alert()
+	if len(alert.Details) != 3 {
+		t.Fatal("alert must list all members", alert.Details)
+	}
+}
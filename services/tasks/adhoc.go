@@ -0,0 +1,361 @@
+package tasks
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db_lib"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// AdhocRunner runs the ad-hoc command with the ansible executable
+// and stores its status and output like TaskRunner does for tasks.
+type AdhocRunner struct {
+	Command db.AdhocCommand
+
+	job    LocalJob
+	pool   *TaskPool
+	masker *db.OutputMasker
+
+	statusListeners []task_logger.StatusListener
+	logListeners    []task_logger.LogListener
+
+	stdout *lineWriter
+	stderr *lineWriter
+}
+
+// lineWriter passes the output of the command to log line by line. Unlike pipes,
+// it is filled by exec.Cmd before Wait returns, so no output is lost.
+type lineWriter struct {
+	log func(string)
+	buf []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.log(strings.TrimSuffix(string(w.buf[:i]), "\r"))
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// flush logs the last line which is not terminated by the new line.
+func (w *lineWriter) flush() {
+	if w == nil || len(w.buf) == 0 {
+		return
+	}
+	w.log(string(w.buf))
+	w.buf = nil
+}
+
+// getAdhocInventory returns the inventory with installable keys for running Ansible modules
+// without a template. keyID overrides the SSH key of the inventory if it is not nil.
+func getAdhocInventory(store db.Store, projectID int, inventoryID int, keyID *int) (inventory db.Inventory, err error) {
+	inventory, err = store.GetInventory(projectID, inventoryID)
+	if err != nil {
+		return
+	}
+
+	switch inventory.Type {
+	case db.InventoryStatic, db.InventoryStaticYaml:
+	case db.InventoryFile:
+		if inventory.RepositoryID == nil && !path.IsAbs(inventory.GetFilename()) {
+			err = &db.ValidationError{Message: "inventory file must be stored in the inventory repository or have absolute path"}
+			return
+		}
+	default:
+		err = &db.ValidationError{Message: "inventory type is not supported by ad-hoc commands"}
+		return
+	}
+
+	project, err := store.GetProject(projectID)
+	if err != nil {
+		return
+	}
+
+	if err = db.ApplyProjectDefaultKeys(store, project, &inventory, &db.Template{App: db.AppAnsible}); err != nil {
+		return
+	}
+
+	if err = db.FillInventorySecrets(store, &inventory, true); err != nil {
+		return
+	}
+
+	if keyID != nil {
+		inventory.SSHKeyID = keyID
+		inventory.SSHKey, err = store.GetAccessKey(projectID, *keyID)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// RunAdhocCommand stores the ad-hoc command and starts it in the background.
+func (p *TaskPool) RunAdhocCommand(command db.AdhocCommand, userID *int, projectID int) (newCommand db.AdhocCommand, err error) {
+	command.ProjectID = projectID
+	command.UserID = userID
+	command.Status = task_logger.TaskWaitingStatus
+	command.Created = time.Now()
+	command.Start = nil
+	command.End = nil
+
+	if err = command.Validate(); err != nil {
+		return
+	}
+
+	project, err := p.store.GetProject(projectID)
+	if err != nil {
+		return
+	}
+
+	if project.Archived {
+		err = db.ErrProjectArchived
+		return
+	}
+
+	inventory, err := getAdhocInventory(p.store, projectID, command.InventoryID, command.KeyID)
+	if err != nil {
+		return
+	}
+
+	maskingRules, err := p.store.GetMaskingRules(projectID)
+	if err != nil {
+		return
+	}
+
+	newCommand, err = p.store.CreateAdhocCommand(command)
+	if err != nil {
+		return
+	}
+
+	runner := &AdhocRunner{
+		Command: newCommand,
+		pool:    p,
+		masker:  db.NewOutputMasker(maskingRules),
+	}
+
+	for _, secret := range inventory.Secrets {
+		runner.masker.AddValues(secret.Secret)
+	}
+
+	runner.job = LocalJob{
+		Inventory: inventory,
+		Logger:    runner,
+		tmpName:   "adhoc_" + strconv.Itoa(newCommand.ID),
+	}
+
+	p.adhocLock.Lock()
+	p.adhocCommands[newCommand.ID] = runner
+	p.adhocLock.Unlock()
+
+	go runner.run()
+
+	return
+}
+
+// StopAdhocCommand kills the process of the running ad-hoc command.
+// It returns db.ErrInvalidOperation if the command is not running.
+func (p *TaskPool) StopAdhocCommand(commandID int) error {
+	p.adhocLock.Lock()
+	runner, ok := p.adhocCommands[commandID]
+	p.adhocLock.Unlock()
+
+	if !ok {
+		return fmt.Errorf("ad-hoc command is not active: %w", db.ErrInvalidOperation)
+	}
+
+	runner.SetStatus(task_logger.TaskStoppingStatus)
+	runner.job.Kill()
+	return nil
+}
+
+func (r *AdhocRunner) name() string {
+	return "ad-hoc command " + strconv.Itoa(r.Command.ID)
+}
+
+func (r *AdhocRunner) run() {
+	if !r.pool.store.PermanentConnection() {
+		r.pool.store.Connect("run " + r.name())
+		defer r.pool.store.Close("run " + r.name())
+	}
+
+	defer func() {
+		r.pool.adhocLock.Lock()
+		delete(r.pool.adhocCommands, r.Command.ID)
+		r.pool.adhocLock.Unlock()
+
+		now := time.Now()
+		r.Command.End = &now
+		r.saveStatus()
+	}()
+
+	err := r.execute()
+	r.stdout.flush()
+	r.stderr.flush()
+
+	switch {
+	case r.Command.Status == task_logger.TaskStoppingStatus:
+		r.SetStatus(task_logger.TaskStoppedStatus)
+	case err != nil:
+		r.Log("Running ad-hoc command failed: " + err.Error())
+		r.SetStatus(task_logger.TaskFailStatus)
+	default:
+		r.SetStatus(task_logger.TaskSuccessStatus)
+	}
+}
+
+func (r *AdhocRunner) execute() error {
+	r.SetStatus(task_logger.TaskRunningStatus)
+
+	args := []string{"-m", r.Command.Module}
+
+	if r.Command.Args != "" {
+		args = append(args, "-a", r.Command.Args)
+	}
+
+	if r.Command.Become {
+		args = append(args, "--become")
+	}
+
+	return runAnsibleModule(&r.job, r.Command.GetPattern(), args)
+}
+
+// runAnsibleModule installs the inventory of the job and runs ansible on the hosts
+// matching the pattern. The output is passed to the logger of the job.
+func runAnsibleModule(job *LocalJob, pattern string, moduleArgs []string) error {
+	if err := checkTmpDir(util.Config.TmpPath); err != nil {
+		return err
+	}
+
+	if err := job.installInventory(); err != nil {
+		return err
+	}
+
+	defer func() {
+		job.destroyKeys()
+		job.destroySSHConfig()
+		job.destroySSHControlDir()
+		if job.Inventory.Type != db.InventoryFile {
+			job.destroyInventoryFile()
+		}
+	}()
+
+	inventoryArgs, inputs, err := job.getInventoryArgs()
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{pattern}, inventoryArgs...)
+	args = append(args, moduleArgs...)
+
+	var environmentVars []string
+	if job.sshKeyInstallation.SSHAgent != nil {
+		environmentVars = append(environmentVars, fmt.Sprintf("SSH_AUTH_SOCK=%s", job.sshKeyInstallation.SSHAgent.SocketFile))
+	}
+	environmentVars = append(environmentVars, job.getSSHOptionsEnv()...)
+
+	playbook := db_lib.AnsiblePlaybook{
+		Logger:     job.Logger,
+		Repository: db.Repository{GitURL: util.Config.TmpPath},
+	}
+
+	return playbook.RunAdhoc(args, &environmentVars, inputs, func(p *os.Process) {
+		job.Process = p
+	})
+}
+
+func (r *AdhocRunner) saveStatus() {
+	if err := r.pool.store.UpdateAdhocCommand(r.Command); err != nil {
+		log.WithError(err).Error("Failed to update status of " + r.name())
+	}
+}
+
+func (r *AdhocRunner) Log(msg string) {
+	r.LogWithTime(time.Now(), msg)
+}
+
+func (r *AdhocRunner) Logf(format string, a ...any) {
+	r.LogfWithTime(time.Now(), format, a...)
+}
+
+func (r *AdhocRunner) LogWithTime(now time.Time, msg string) {
+	msg = r.masker.Mask(msg)
+
+	stored := msg
+	if util.Config.TaskOutput != nil && util.Config.TaskOutput.StripANSI {
+		stored = util.StripANSI(stored)
+	}
+
+	err := r.pool.store.CreateAdhocCommandOutput(db.AdhocCommandOutput{
+		CommandID: r.Command.ID,
+		Time:      now,
+		Output:    stored,
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to store output of " + r.name())
+	}
+
+	for _, l := range r.logListeners {
+		l(now, msg)
+	}
+}
+
+func (r *AdhocRunner) LogfWithTime(now time.Time, format string, a ...any) {
+	r.LogWithTime(now, fmt.Sprintf(format, a...))
+}
+
+func (r *AdhocRunner) LogCmd(cmd *exec.Cmd) {
+	r.stdout = &lineWriter{log: r.Log}
+	r.stderr = &lineWriter{log: r.Log}
+	cmd.Stdout = r.stdout
+	cmd.Stderr = r.stderr
+}
+
+func (r *AdhocRunner) SetStatus(status task_logger.TaskStatus) {
+	if status == r.Command.Status || r.Command.Status.IsFinished() {
+		return
+	}
+
+	if r.Command.Status == task_logger.TaskStoppingStatus && status != task_logger.TaskStoppedStatus {
+		return
+	}
+
+	r.Command.Status = status
+
+	if status == task_logger.TaskRunningStatus {
+		now := time.Now()
+		r.Command.Start = &now
+	}
+
+	r.saveStatus()
+
+	for _, l := range r.statusListeners {
+		l(status)
+	}
+}
+
+func (r *AdhocRunner) AddStatusListener(l task_logger.StatusListener) {
+	r.statusListeners = append(r.statusListeners, l)
+}
+
+func (r *AdhocRunner) AddLogListener(l task_logger.LogListener) {
+	r.logListeners = append(r.logListeners, l)
+}
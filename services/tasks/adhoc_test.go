@@ -0,0 +1,99 @@
+package tasks
+
+import (
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestRunAdhocCommand(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	util.Config.TmpPath = t.TempDir()
+
+	// fake ansible prints its arguments
+	binPath := t.TempDir()
+	err := os.WriteFile(path.Join(binPath, "ansible"), []byte("#!/bin/sh\necho \"ansible $@\"\n"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binPath+":"+os.Getenv("PATH"))
+
+	project, err := store.CreateProject(db.Project{Name: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inventory, err := store.CreateInventory(db.Inventory{
+		ProjectID: project.ID,
+		Name:      "Local",
+		Type:      db.InventoryStatic,
+		Inventory: "localhost",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := CreateTaskPool(store)
+
+	command, err := pool.RunAdhocCommand(db.AdhocCommand{
+		InventoryID: inventory.ID,
+		Module:      "shell",
+		Args:        "uptime",
+		Limit:       "web",
+		Become:      true,
+	}, nil, project.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !command.Status.IsFinished() {
+		if time.Now().After(deadline) {
+			t.Fatalf("ad-hoc command is not finished, status %s", command.Status)
+		}
+		time.Sleep(50 * time.Millisecond)
+
+		command, err = store.GetAdhocCommand(project.ID, command.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if command.Status != task_logger.TaskSuccessStatus {
+		t.Fatalf("expected success, got %s", command.Status)
+	}
+
+	if command.Start == nil || command.End == nil {
+		t.Fatal("start and end of the command must be set")
+	}
+
+	outputs, err := store.GetAdhocCommandOutputs(project.ID, command.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, o := range outputs {
+		if strings.HasPrefix(o.Output, "ansible web -i ") && strings.HasSuffix(o.Output, "-m shell -a uptime --become") {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatalf("ansible arguments are not found in the output %v", outputs)
+	}
+
+	if _, err = os.Stat(path.Join(util.Config.TmpPath, "inventory_adhoc_"+strconv.Itoa(command.ID))); !os.IsNotExist(err) {
+		t.Fatal("inventory file must be removed")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"text/template"
 
 	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/i18n"
 	"github.com/semaphoreui/semaphore/pkg/task_logger"
 	"github.com/semaphoreui/semaphore/util"
 	"github.com/semaphoreui/semaphore/util/mailer"
@@ -19,11 +20,12 @@ var templates embed.FS
 
 // Alert represents an alert that will be templated and sent to the appropriate service
 type Alert struct {
-	Name   string
-	Author string
-	Color  string
-	Task   alertTask
-	Chat   alertChat
+	Name    string
+	Author  string
+	Color   string
+	Product string
+	Task    alertTask
+	Chat    alertChat
 }
 
 type alertTask struct {
@@ -43,38 +45,24 @@ func (t *TaskRunner) sendMailAlert() {
 		return
 	}
 
-	body := bytes.NewBufferString("")
 	author, version := t.alertInfos()
 
 	alert := Alert{
-		Name:   t.Template.Name,
-		Author: author,
-		Color:  t.alertColor("email"),
+		Name:    t.Template.Name,
+		Author:  author,
+		Color:   t.alertColor("email"),
+		Product: util.Config.GetBranding().ProductName,
 		Task: alertTask{
 			ID:      strconv.Itoa(t.Task.ID),
 			URL:     t.taskLink(),
-			Result:  t.Task.Status.Format(),
+			Result:  t.alertResult(),
 			Version: version,
 			Desc:    t.Task.Message,
 		},
 	}
 
-	tpl, err := template.ParseFS(templates, "templates/email.tmpl")
-
-	if err != nil {
-		t.Log("Can't parse email alert template!")
-		panic(err)
-	}
-
-	if err := tpl.Execute(body, alert); err != nil {
-		t.Log("Can't generate email alert template!")
-		panic(err)
-	}
-
-	if body.Len() == 0 {
-		t.Log("Buffer for email alert is empty")
-		return
-	}
+	// bodies contains the alert rendered for every locale of the users
+	bodies := make(map[string]string)
 
 	for _, uid := range t.users {
 		user, err := t.pool.store.GetUser(uid)
@@ -88,6 +76,22 @@ func (t *TaskRunner) sendMailAlert() {
 			continue
 		}
 
+		locale := user.Locale
+		if locale == "" {
+			locale = util.Config.GetLocale()
+		}
+
+		body, ok := bodies[locale]
+		if !ok {
+			body = t.renderMailAlert(alert, locale)
+			bodies[locale] = body
+		}
+
+		if body == "" {
+			t.Log("Buffer for email alert is empty")
+			return
+		}
+
 		t.Logf("Attempting to send email alert to %s", user.Email)
 
 		if err := mailer.Send(
@@ -98,8 +102,8 @@ func (t *TaskRunner) sendMailAlert() {
 			util.Config.EmailPassword,
 			util.Config.EmailSender,
 			user.Email,
-			fmt.Sprintf("Task '%s' failed", t.Template.Name),
-			body.String(),
+			brandedSubject(i18n.T(locale, "Task '%s' failed", t.Template.Name)),
+			body,
 		); err != nil {
 			util.LogError(err)
 			continue
@@ -109,6 +113,29 @@ func (t *TaskRunner) sendMailAlert() {
 	}
 }
 
+// renderMailAlert renders the email alert translated to the locale.
+func (t *TaskRunner) renderMailAlert(alert Alert, locale string) string {
+	body := bytes.NewBufferString("")
+
+	tpl, err := template.New("email.tmpl").Funcs(template.FuncMap{
+		"T": func(msg string, args ...any) string {
+			return i18n.T(locale, msg, args...)
+		},
+	}).ParseFS(templates, "templates/email.tmpl")
+
+	if err != nil {
+		t.Log("Can't parse email alert template!")
+		panic(err)
+	}
+
+	if err := tpl.Execute(body, alert); err != nil {
+		t.Log("Can't generate email alert template!")
+		panic(err)
+	}
+
+	return body.String()
+}
+
 func (t *TaskRunner) sendTelegramAlert() {
 	if !util.Config.TelegramAlert || !t.alert {
 		return
@@ -137,7 +164,7 @@ func (t *TaskRunner) sendTelegramAlert() {
 		Task: alertTask{
 			ID:      strconv.Itoa(t.Task.ID),
 			URL:     t.taskLink(),
-			Result:  t.Task.Status.Format(),
+			Result:  t.alertResult(),
 			Version: version,
 			Desc:    t.Task.Message,
 		},
@@ -202,7 +229,7 @@ func (t *TaskRunner) sendSlackAlert() {
 		Task: alertTask{
 			ID:      strconv.Itoa(t.Task.ID),
 			URL:     t.taskLink(),
-			Result:  t.Task.Status.Format(),
+			Result:  t.alertResult(),
 			Version: version,
 			Desc:    t.Task.Message,
 		},
@@ -261,7 +288,7 @@ func (t *TaskRunner) sendRocketChatAlert() {
 		Task: alertTask{
 			ID:      strconv.Itoa(t.Task.ID),
 			URL:     t.taskLink(),
-			Result:  t.Task.Status.Format(),
+			Result:  t.alertResult(),
 			Version: version,
 			Desc:    t.Task.Message,
 		},
@@ -320,7 +347,7 @@ func (t *TaskRunner) sendMicrosoftTeamsAlert() {
 		Task: alertTask{
 			ID:      strconv.Itoa(t.Task.ID),
 			URL:     t.taskLink(),
-			Result:  t.Task.Status.Format(),
+			Result:  t.alertResult(),
 			Version: version,
 			Desc:    t.Task.Message,
 		},
@@ -379,7 +406,7 @@ func (t *TaskRunner) sendDingTalkAlert() {
 		Task: alertTask{
 			ID:      strconv.Itoa(t.Task.ID),
 			URL:     t.taskLink(),
-			Result:  t.Task.Status.Format(),
+			Result:  t.alertResult(),
 			Version: version,
 			Desc:    t.Task.Message,
 		},
@@ -438,7 +465,7 @@ func (t *TaskRunner) sendGotifyAlert() {
 		Task: alertTask{
 			ID:      strconv.Itoa(t.Task.ID),
 			URL:     t.taskLink(),
-			Result:  t.Task.Status.Format(),
+			Result:  t.alertResult(),
 			Version: version,
 			Desc:    t.Task.Message,
 		},
@@ -512,12 +539,22 @@ func (t *TaskRunner) alertInfos() (string, string) {
 	return author, version
 }
 
+// alertResult returns the result shown in the alert.
+func (t *TaskRunner) alertResult() string {
+	if t.budgetAlert != "" {
+		return t.budgetAlert
+	}
+	return t.Task.Status.Format()
+}
+
 func (t *TaskRunner) alertColor(kind string) string {
 	switch kind {
 	case "slack":
 		switch t.Task.Status {
 		case task_logger.TaskSuccessStatus:
 			return "good"
+		case task_logger.TaskPartialStatus:
+			return "warning"
 		case task_logger.TaskFailStatus:
 			return "danger"
 		case task_logger.TaskRunningStatus:
@@ -533,6 +570,8 @@ func (t *TaskRunner) alertColor(kind string) string {
 		switch t.Task.Status {
 		case task_logger.TaskSuccessStatus:
 			return "#00EE00"
+		case task_logger.TaskPartialStatus:
+			return "#FF9900"
 		case task_logger.TaskFailStatus:
 			return "#EE0000"
 		case task_logger.TaskRunningStatus:
@@ -0,0 +1,147 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/i18n"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// defaultDigestPeriod is the period covered by the first digest of the project.
+const defaultDigestPeriod = 24 * time.Hour
+
+// DigestTemplate summarizes scheduled runs of the template.
+type DigestTemplate struct {
+	TemplateID    int
+	Name          string
+	Succeeded     int
+	Failed        int
+	Stopped       int
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+}
+
+// AverageDuration returns the average duration of the runs which were started.
+func (t *DigestTemplate) AverageDuration() time.Duration {
+	n := t.Succeeded + t.Failed + t.Stopped
+	if n == 0 {
+		return 0
+	}
+	return t.TotalDuration / time.Duration(n)
+}
+
+// ProjectDigest summarizes scheduled runs of the project which finished in the period [From, To).
+type ProjectDigest struct {
+	From      time.Time
+	To        time.Time
+	Templates []DigestTemplate
+}
+
+func (d *ProjectDigest) IsEmpty() bool {
+	return len(d.Templates) == 0
+}
+
+// GetProjectDigest collects scheduled runs of the project grouped by templates.
+func GetProjectDigest(store db.Store, projectID int, from time.Time, to time.Time) (digest ProjectDigest, err error) {
+	digest = ProjectDigest{From: from, To: to}
+
+	tasks, err := store.GetProjectScheduledTasks(projectID, from, to)
+	if err != nil {
+		return
+	}
+
+	indexes := make(map[int]int)
+
+	for _, task := range tasks {
+		i, ok := indexes[task.TemplateID]
+		if !ok {
+			name := fmt.Sprintf("#%d", task.TemplateID)
+
+			tpl, tplErr := store.GetTemplate(projectID, task.TemplateID)
+			if tplErr == nil {
+				name = tpl.Name
+			} else if !errors.Is(tplErr, db.ErrNotFound) {
+				err = tplErr
+				return
+			}
+
+			i = len(digest.Templates)
+			indexes[task.TemplateID] = i
+			digest.Templates = append(digest.Templates, DigestTemplate{TemplateID: task.TemplateID, Name: name})
+		}
+
+		summary := &digest.Templates[i]
+
+		switch task.Status {
+		case task_logger.TaskSuccessStatus:
+			summary.Succeeded++
+		case task_logger.TaskStoppedStatus:
+			summary.Stopped++
+		default:
+			summary.Failed++
+		}
+
+		if task.Start != nil && task.End != nil {
+			duration := task.End.Sub(*task.Start)
+			summary.TotalDuration += duration
+			if duration > summary.MaxDuration {
+				summary.MaxDuration = duration
+			}
+		}
+	}
+
+	return
+}
+
+// Alert returns the digest as the project alert.
+func (d *ProjectDigest) Alert(project db.Project) ProjectAlert {
+	failed := 0
+	for _, tpl := range d.Templates {
+		failed += tpl.Failed
+	}
+
+	alert := ProjectAlert{
+		Subject: i18n.Msg("Digest of scheduled runs of project %s", project.Name),
+		Text: i18n.Msg("Scheduled runs from %s to %s, failed: %d",
+			d.From.Format(time.RFC3339),
+			d.To.Format(time.RFC3339),
+			failed),
+		URL: fmt.Sprintf("%s/project/%d/history", util.Config.WebHost, project.ID),
+	}
+
+	for _, tpl := range d.Templates {
+		alert.Details = append(alert.Details, i18n.Msg("%s: %d succeeded, %d failed, %d stopped, average duration %s, max duration %s",
+			tpl.Name,
+			tpl.Succeeded,
+			tpl.Failed,
+			tpl.Stopped,
+			tpl.AverageDuration().Round(time.Second),
+			tpl.MaxDuration.Round(time.Second)))
+	}
+
+	return alert
+}
+
+// SendProjectDigest sends the digest of scheduled runs finished since the previous digest.
+// Nothing is sent if there were no scheduled runs.
+func SendProjectDigest(store db.Store, project db.Project, now time.Time) error {
+	from := now.Add(-defaultDigestPeriod)
+	if project.AlertDigestSent != nil {
+		from = *project.AlertDigestSent
+	}
+
+	digest, err := GetProjectDigest(store, project.ID, from, now)
+	if err != nil {
+		return err
+	}
+
+	if !digest.IsEmpty() {
+		SendProjectAlert(store, project, digest.Alert(project))
+	}
+
+	return store.SetProjectAlertDigestSent(project.ID, now)
+}
@@ -0,0 +1,86 @@
+package tasks
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+func TestGetProjectDigest(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	project, err := store.CreateProject(db.Project{Name: "Nightly"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, err := store.CreateTemplate(db.Template{ProjectID: project.ID, Name: "Backup", Playbook: "backup.yml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	scheduleID := 1
+
+	for _, run := range []struct {
+		status   task_logger.TaskStatus
+		start    time.Time
+		duration time.Duration
+		schedule *int
+	}{
+		{task_logger.TaskSuccessStatus, from.Add(time.Hour), 10 * time.Minute, &scheduleID},
+		{task_logger.TaskFailStatus, from.Add(2 * time.Hour), 20 * time.Minute, &scheduleID},
+		// manual run is reported by its own alert
+		{task_logger.TaskFailStatus, from.Add(3 * time.Hour), time.Minute, nil},
+		// the run finished after the period is reported by the next digest
+		{task_logger.TaskSuccessStatus, to.Add(-time.Minute), 5 * time.Minute, &scheduleID},
+	} {
+		start := run.start
+		end := run.start.Add(run.duration)
+		_, err = store.CreateTask(db.Task{
+			ProjectID:  project.ID,
+			TemplateID: tpl.ID,
+			ScheduleID: run.schedule,
+			Status:     run.status,
+			Start:      &start,
+			End:        &end,
+		}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	digest, err := GetProjectDigest(store, project.ID, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(digest.Templates) != 1 {
+		t.Fatalf("expected one template in the digest, got %d", len(digest.Templates))
+	}
+
+	summary := digest.Templates[0]
+
+	if summary.Name != "Backup" || summary.Succeeded != 1 || summary.Failed != 1 || summary.Stopped != 0 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+
+	if summary.AverageDuration() != 15*time.Minute || summary.MaxDuration != 20*time.Minute {
+		t.Fatalf("unexpected durations %s, %s", summary.AverageDuration(), summary.MaxDuration)
+	}
+
+	alert := digest.Alert(project)
+	if len(alert.Details) != 1 {
+		t.Fatal("digest must contain a line per template")
+	}
+
+	if text := alert.Details[0].String(); text != "Backup: 1 succeeded, 1 failed, 0 stopped, average duration 15m0s, max duration 20m0s" {
+		t.Fatalf("unexpected digest line %q", text)
+	}
+}
@@ -0,0 +1,172 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/i18n"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/semaphoreui/semaphore/util/mailer"
+	log "github.com/sirupsen/logrus"
+)
+
+// ProjectAlert is an alert which is not related to a task run, for example
+// about the schedule which did not fire. It is sent as plain text translated
+// to the locale of every email recipient and to the server locale for chats.
+type ProjectAlert struct {
+	Subject i18n.Message
+	Text    i18n.Message
+	// Details are appended to the text line by line.
+	Details []i18n.Message
+	URL     string
+}
+
+func (a ProjectAlert) text(locale string) string {
+	text := a.Text.Translate(locale)
+	for _, line := range a.Details {
+		text += "\n" + line.Translate(locale)
+	}
+	return text
+}
+
+func (a ProjectAlert) message(locale string) string {
+	msg := a.Subject.Translate(locale) + "\n" + a.text(locale)
+	if a.URL != "" {
+		msg += "\n" + a.URL
+	}
+	return msg
+}
+
+// brandedSubject prefixes the subject of emails and notifications
+// with the product name if the instance is branded.
+func brandedSubject(subject string) string {
+	if name := util.Config.GetBranding().ProductName; name != "" {
+		return "[" + name + "] " + subject
+	}
+	return subject
+}
+
+func postProjectAlert(service string, url string, payload any) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		util.LogError(err)
+		return
+	}
+
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+
+	if err != nil {
+		log.Error("Can't send " + service + " alert! Error: " + err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		log.Error("Can't send " + service + " alert! Response code: " + strconv.Itoa(resp.StatusCode))
+	}
+}
+
+func sendProjectMailAlert(store db.Store, project db.Project, alert ProjectAlert) {
+	users, err := store.GetProjectUsers(project.ID, db.RetrieveQueryParams{})
+	if err != nil {
+		util.LogError(err)
+		return
+	}
+
+	for _, user := range users {
+		if user.Alert {
+			sendProjectMail(user.User, alert)
+		}
+	}
+}
+
+// sendProjectMail sends the alert to the user translated to the user's locale.
+func sendProjectMail(user db.User, alert ProjectAlert) {
+	locale := user.Locale
+	if locale == "" {
+		locale = util.Config.GetLocale()
+	}
+
+	if err := mailer.Send(
+		util.Config.EmailSecure,
+		util.Config.EmailHost,
+		util.Config.EmailPort,
+		util.Config.EmailUsername,
+		util.Config.EmailPassword,
+		util.Config.EmailSender,
+		user.Email,
+		brandedSubject(alert.Subject.Translate(locale)),
+		alert.message(locale),
+	); err != nil {
+		util.LogError(err)
+	}
+}
+
+// SendProjectAlert sends the alert through all alert services enabled in the config
+// if alerts are enabled for the project.
+func SendProjectAlert(store db.Store, project db.Project, alert ProjectAlert) {
+	if !project.Alert {
+		return
+	}
+
+	if util.Config.EmailAlert {
+		sendProjectMailAlert(store, project, alert)
+	}
+
+	sendChatAlert(project, alert)
+}
+
+// sendChatAlert sends the alert to all chats enabled in the config. The Telegram chat
+// of the project overrides the chat of the config.
+func sendChatAlert(project db.Project, alert ProjectAlert) {
+	locale := util.Config.GetLocale()
+	msg := alert.message(locale)
+
+	if util.Config.TelegramAlert {
+		chatID := util.Config.TelegramChat
+		if project.AlertChat != nil && *project.AlertChat != "" {
+			chatID = *project.AlertChat
+		}
+
+		if chatID != "" {
+			postProjectAlert("telegram", fmt.Sprintf(
+				"https://api.telegram.org/bot%s/sendMessage",
+				util.Config.TelegramToken,
+			), map[string]string{"chat_id": chatID, "text": msg})
+		}
+	}
+
+	if util.Config.SlackAlert {
+		postProjectAlert("slack", util.Config.SlackUrl, map[string]string{"text": msg})
+	}
+
+	if util.Config.RocketChatAlert {
+		postProjectAlert("rocketchat", util.Config.RocketChatUrl, map[string]string{"text": msg})
+	}
+
+	if util.Config.MicrosoftTeamsAlert {
+		postProjectAlert("microsoft teams", util.Config.MicrosoftTeamsUrl, map[string]string{"text": msg})
+	}
+
+	if util.Config.DingTalkAlert {
+		postProjectAlert("dingtalk", util.Config.DingTalkUrl, map[string]any{
+			"msgtype": "text",
+			"text":    map[string]string{"content": msg},
+		})
+	}
+
+	if util.Config.GotifyAlert {
+		postProjectAlert("gotify", fmt.Sprintf(
+			"%s/message?token=%s",
+			util.Config.GotifyUrl,
+			util.Config.GotifyToken,
+		), map[string]string{
+			"title":   brandedSubject(alert.Subject.Translate(locale)),
+			"message": alert.text(locale) + "\n" + alert.URL,
+		})
+	}
+}
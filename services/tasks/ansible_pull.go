@@ -0,0 +1,377 @@
+package tasks
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db_lib"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// pullRepositorySyncInterval limits updates of the repository served to pull hosts,
+// so hosts which run ansible-pull at the same minute do not pull the repository many times.
+const pullRepositorySyncInterval = time.Minute
+
+// ErrAnsiblePullDisabled is returned to the pull host if the ansible-pull mode of its template is disabled.
+var ErrAnsiblePullDisabled = &db.ValidationError{Message: "ansible-pull mode of the template is disabled"}
+
+// pullRepositoryFileRegexp matches files of the git directory which are needed to clone
+// the repository by the dumb HTTP protocol.
+var pullRepositoryFileRegexp = regexp.MustCompile(`^(HEAD|info/refs|objects/info/packs|objects/[0-9a-f]{2}/[0-9a-f]{38}|objects/pack/pack-[0-9a-f]+\.(pack|idx))$`)
+
+type pullRepositoryState struct {
+	lock   sync.Mutex
+	synced time.Time
+}
+
+var pullRepositories sync.Map
+
+// PullConfig is the ansible-pull configuration of the host.
+type PullConfig struct {
+	Host       string `json:"host"`
+	TemplateID int    `json:"template_id"`
+	Playbook   string `json:"playbook"`
+	// RepositoryURL contains the short-lived token of the host.
+	RepositoryURL        string    `json:"repository_url"`
+	RepositoryURLExpires time.Time `json:"repository_url_expires"`
+	Branch               string    `json:"branch"`
+	Schedule             string    `json:"schedule"`
+	OnlyIfChanged        bool      `json:"only_if_changed"`
+	ScriptURL            string    `json:"script_url"`
+	ReportURL            string    `json:"report_url"`
+	// Cron is the crontab line which runs the script. The host token is read from SEMAPHORE_PULL_TOKEN.
+	Cron string `json:"cron"`
+}
+
+// PullReport is the result of the ansible-pull run reported by the host.
+type PullReport struct {
+	Status task_logger.TaskStatus
+	Start  time.Time
+	End    time.Time
+	Output string
+	Commit string
+}
+
+func getPullRepositoryDirName(tpl db.Template) string {
+	return fmt.Sprintf("pull_repository_%d_%d", tpl.ProjectID, tpl.ID)
+}
+
+// GetPullRepositoryFile returns the path of the file of the repository served to pull hosts.
+// It returns ErrNotFound for files which are not needed to clone the repository.
+func GetPullRepositoryFile(tpl db.Template, name string) (string, error) {
+	if !pullRepositoryFileRegexp.MatchString(name) {
+		return "", db.ErrNotFound
+	}
+	return filepath.Join(util.Config.TmpPath, getPullRepositoryDirName(tpl), ".git", filepath.FromSlash(name)), nil
+}
+
+func generatePullToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(tokenBytes), nil
+}
+
+// CreatePullHost enrolls the host to the ansible-pull mode of the template and returns
+// the token of the host. The token is shown once, only its hash is stored.
+func CreatePullHost(store db.Store, tpl db.Template, name string) (host db.PullHost, token string, err error) {
+	token, err = generatePullToken()
+	if err != nil {
+		return
+	}
+
+	host, err = store.CreatePullHost(db.PullHost{
+		ProjectID:  tpl.ProjectID,
+		TemplateID: tpl.ID,
+		Name:       name,
+		TokenHash:  db.HashPullToken(token),
+		Created:    time.Now().UTC(),
+	})
+	return
+}
+
+// GetPullHostCron returns the crontab line which runs ansible-pull on the host.
+func GetPullHostCron(schedule string, token string) string {
+	return fmt.Sprintf(
+		"%s SEMAPHORE_PULL_TOKEN=%s sh -c 'curl -fsS -H \"Authorization: Bearer $SEMAPHORE_PULL_TOKEN\" %s | sh'",
+		schedule,
+		token,
+		util.Config.WebHost+"/api/pull/run.sh")
+}
+
+func getAnsiblePull(tpl db.Template) (pull db.AnsiblePull, err error) {
+	if !tpl.App.IsAnsible() {
+		err = ErrAnsiblePullDisabled
+		return
+	}
+
+	var params db.AnsibleTemplateParams
+	if err = tpl.GetParams(&params); err != nil {
+		return
+	}
+
+	if !params.Pull.Enabled {
+		err = ErrAnsiblePullDisabled
+		return
+	}
+
+	pull = params.Pull
+	return
+}
+
+// syncPullRepository updates the clone of the repository of the template which is served
+// to pull hosts by the dumb HTTP protocol. The clone is separated from the clone used by tasks.
+func syncPullRepository(store db.Store, tpl db.Template, now time.Time) (branch string, err error) {
+	repo, err := store.GetRepository(tpl.ProjectID, tpl.RepositoryID)
+	if err != nil {
+		return
+	}
+
+	if repo.GetType() == db.RepositoryLocal {
+		err = &db.ValidationError{Message: "ansible-pull mode requires a git repository"}
+		return
+	}
+
+	if tpl.GitBranch != nil && *tpl.GitBranch != "" {
+		repo.GitBranch = *tpl.GitBranch
+	}
+	branch = repo.GitBranch
+
+	dirName := getPullRepositoryDirName(tpl)
+
+	value, _ := pullRepositories.LoadOrStore(dirName, &pullRepositoryState{})
+	state := value.(*pullRepositoryState)
+
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	if now.Sub(state.synced) < pullRepositorySyncInterval {
+		return
+	}
+
+	if err = repo.SSHKey.DeserializeSecret(); err != nil {
+		return
+	}
+
+	// collects the output of git which is returned in the error
+	var logger pingLogger
+
+	gitRepo := db_lib.GitRepository{
+		TmpDirName: dirName,
+		TemplateID: tpl.ID,
+		Repository: repo,
+		Logger:     &logger,
+		Client:     db_lib.CreateDefaultGitClient(),
+	}
+
+	if gitRepo.ValidateRepo() != nil || !gitRepo.CanBePulled() || gitRepo.Pull() != nil {
+		if err = os.RemoveAll(gitRepo.GetFullPath()); err != nil {
+			return
+		}
+
+		if err = gitRepo.Clone(); err != nil {
+			err = fmt.Errorf("can not clone the repository: %w: %s", err, strings.Join(logger.lines, "\n"))
+			return
+		}
+	}
+
+	// info/refs and objects/info/packs are required by the dumb HTTP protocol
+	cmd := exec.Command("git", "update-server-info")
+	cmd.Dir = gitRepo.GetFullPath()
+	if out, cmdErr := cmd.CombinedOutput(); cmdErr != nil {
+		err = fmt.Errorf("can not prepare the repository: %w: %s", cmdErr, string(out))
+		return
+	}
+
+	state.synced = now
+	return
+}
+
+// GetPullConfig updates the repository of the template and returns the configuration of the host
+// with the new repository URL. The previous repository URL of the host stops working.
+func GetPullConfig(store db.Store, host db.PullHost, now time.Time) (cfg PullConfig, err error) {
+	tpl, err := store.GetTemplate(host.ProjectID, host.TemplateID)
+	if err != nil {
+		return
+	}
+
+	pull, err := getAnsiblePull(tpl)
+	if err != nil {
+		return
+	}
+
+	branch, err := syncPullRepository(store, tpl, now)
+	if err != nil {
+		return
+	}
+
+	repositoryToken, err := generatePullToken()
+	if err != nil {
+		return
+	}
+
+	expires := now.UTC().Add(db.PullRepositoryTokenLifetime)
+	host.RepositoryTokenHash = db.HashPullToken(repositoryToken)
+	host.RepositoryTokenExpires = &expires
+
+	if err = store.UpdatePullHost(host); err != nil {
+		return
+	}
+
+	cfg = PullConfig{
+		Host:                 host.Name,
+		TemplateID:           tpl.ID,
+		Playbook:             tpl.Playbook,
+		RepositoryURL:        fmt.Sprintf("%s/api/pull/repository/%s", util.Config.WebHost, repositoryToken),
+		RepositoryURLExpires: expires,
+		Branch:               branch,
+		Schedule:             pull.GetSchedule(),
+		OnlyIfChanged:        pull.OnlyIfChanged,
+		ScriptURL:            util.Config.WebHost + "/api/pull/run.sh",
+		ReportURL:            util.Config.WebHost + "/api/pull/report",
+		Cron:                 GetPullHostCron(pull.GetSchedule(), "<token>"),
+	}
+
+	return
+}
+
+func quotePullScriptArg(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// RenderPullScript returns the shell script which runs ansible-pull with the configuration
+// and reports the result. The host token is read from SEMAPHORE_PULL_TOKEN.
+func RenderPullScript(cfg PullConfig) string {
+	var b strings.Builder
+
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString("# ansible-pull script of Semaphore for host " + cfg.Host + ", template " + strconv.Itoa(cfg.TemplateID) + "\n")
+	b.WriteString("set -u\n\n")
+	b.WriteString("dir=\"${SEMAPHORE_PULL_DIR:-$HOME/.semaphore-pull/template_" + strconv.Itoa(cfg.TemplateID) + "}\"\n")
+	b.WriteString("log=$(mktemp)\n")
+	b.WriteString("start=$(date -u +%Y-%m-%dT%H:%M:%SZ)\n\n")
+
+	args := []string{
+		"ansible-pull",
+		"-U", quotePullScriptArg(cfg.RepositoryURL),
+		"-C", quotePullScriptArg(cfg.Branch),
+		"-d", "\"$dir\"",
+		"-i", quotePullScriptArg(cfg.Host + ","),
+		"-l", quotePullScriptArg(cfg.Host),
+	}
+	if cfg.OnlyIfChanged {
+		args = append(args, "--only-if-changed")
+	}
+	args = append(args, quotePullScriptArg(cfg.Playbook))
+
+	b.WriteString(strings.Join(args, " ") + " >\"$log\" 2>&1\n")
+	b.WriteString("code=$?\n\n")
+	b.WriteString("end=$(date -u +%Y-%m-%dT%H:%M:%SZ)\n")
+	b.WriteString("commit=$(git -C \"$dir\" rev-parse HEAD 2>/dev/null || true)\n")
+	b.WriteString("status=success\n")
+	b.WriteString("[ \"$code\" -eq 0 ] || status=error\n\n")
+	b.WriteString("curl -fsS -X POST -H \"Authorization: Bearer $SEMAPHORE_PULL_TOKEN\" " +
+		"-F \"status=$status\" -F \"start=$start\" -F \"end=$end\" -F \"commit=$commit\" -F \"output=<$log\" " +
+		quotePullScriptArg(cfg.ReportURL) + "\n")
+	b.WriteString("rm -f \"$log\"\n")
+	b.WriteString("exit \"$code\"\n")
+
+	return b.String()
+}
+
+// ReportPullRun stores the run reported by the pull host as the finished task of the template,
+// so runs of pull hosts are shown in the task history of the template.
+func ReportPullRun(store db.Store, host db.PullHost, report PullReport) (task db.Task, err error) {
+	if report.Status != task_logger.TaskSuccessStatus && report.Status != task_logger.TaskFailStatus {
+		err = &db.ValidationError{Message: "status must be success or error"}
+		return
+	}
+
+	tpl, err := store.GetTemplate(host.ProjectID, host.TemplateID)
+	if err != nil {
+		return
+	}
+
+	if _, err = getAnsiblePull(tpl); err != nil {
+		return
+	}
+
+	now := time.Now().UTC()
+
+	start := report.Start.UTC()
+	end := report.End.UTC()
+	if report.Start.IsZero() || report.End.IsZero() || end.Before(start) || end.After(now) {
+		start, end = now, now
+	}
+
+	task = db.Task{
+		ProjectID:  host.ProjectID,
+		TemplateID: tpl.ID,
+		Status:     report.Status,
+		Playbook:   tpl.Playbook,
+		Created:    start,
+		Start:      &start,
+		End:        &end,
+		Message:    "ansible-pull on " + host.Name,
+	}
+
+	if report.Commit != "" {
+		commit := report.Commit
+		task.CommitHash = &commit
+	}
+
+	task, err = store.CreateTask(task, util.Config.MaxTasksPerTemplate)
+	if err != nil {
+		return
+	}
+
+	updateTemplateSummary(store, task, true, true)
+
+	var outputs []db.TaskOutput
+	for _, line := range strings.Split(strings.TrimRight(report.Output, "\n"), "\n") {
+		outputs = append(outputs, db.TaskOutput{TaskID: task.ID, Time: end, Output: line})
+	}
+
+	if err = store.CreateTaskOutputs(outputs); err != nil {
+		return
+	}
+
+	host.LastReport = &now
+	host.LastStatus = report.Status
+	host.LastTaskID = &task.ID
+
+	err = store.UpdatePullHost(host)
+	return
+}
+
+// GetPullHostByRepositoryToken returns the host and the template of the repository URL.
+func GetPullHostByRepositoryToken(store db.Store, token string, now time.Time) (host db.PullHost, tpl db.Template, err error) {
+	host, err = store.GetPullHostByRepositoryToken(db.HashPullToken(token))
+	if err == nil && !host.CheckRepositoryToken(token, now) {
+		err = db.ErrNotFound
+	}
+
+	if err != nil {
+		return
+	}
+
+	tpl, err = store.GetTemplate(host.ProjectID, host.TemplateID)
+	if err != nil {
+		return
+	}
+
+	_, err = getAnsiblePull(tpl)
+	return
+}
@@ -0,0 +1,133 @@
+package tasks
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestReportPullRun(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	util.Config = &util.ConfigType{}
+
+	proj, err := store.CreateProject(db.Project{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inv, err := store.CreateInventory(db.Inventory{
+		ProjectID: proj.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, err := store.CreateTemplate(db.Template{
+		Name:        "Pull",
+		Playbook:    "site.yml",
+		ProjectID:   proj.ID,
+		InventoryID: &inv.ID,
+		App:         db.AppAnsible,
+		TaskParams: db.MapStringAnyField{
+			"pull": map[string]any{"enabled": true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host, token, err := CreatePullHost(store, tpl, "web1.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := store.GetPullHostByToken(db.HashPullToken(token))
+	if err != nil || found.ID != host.ID {
+		t.Fatalf("expected the host to be found by its token, got %v", err)
+	}
+
+	start := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+
+	task, err := ReportPullRun(store, host, PullReport{
+		Status: task_logger.TaskFailStatus,
+		Start:  start,
+		End:    start.Add(30 * time.Second),
+		Output: "PLAY [all]\nfatal: [web1.example.com]: FAILED!\n",
+		Commit: "0123456789abcdef",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if task.TemplateID != tpl.ID || task.Status != task_logger.TaskFailStatus || task.Message != "ansible-pull on web1.example.com" {
+		t.Fatalf("unexpected task %+v", task)
+	}
+
+	outputs, err := store.GetTaskOutputs(proj.ID, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(outputs) != 2 {
+		t.Fatalf("expected 2 output lines, got %d", len(outputs))
+	}
+
+	host, err = store.GetPullHost(proj.ID, host.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if host.LastStatus != task_logger.TaskFailStatus || host.LastTaskID == nil || *host.LastTaskID != task.ID {
+		t.Fatalf("expected the last run of the host to be stored, got %+v", host)
+	}
+
+	if _, err = ReportPullRun(store, host, PullReport{Status: task_logger.TaskRunningStatus}); err == nil {
+		t.Fatal("expected the report with unfinished status to be rejected")
+	}
+}
+
+func TestRenderPullScript(t *testing.T) {
+	script := RenderPullScript(PullConfig{
+		Host:          "web1",
+		TemplateID:    3,
+		Playbook:      "it's.yml",
+		RepositoryURL: "https://semaphore.example.com/api/pull/repository/abc",
+		Branch:        "main",
+		OnlyIfChanged: true,
+		ReportURL:     "https://semaphore.example.com/api/pull/report",
+	})
+
+	for _, expected := range []string{
+		"ansible-pull -U 'https://semaphore.example.com/api/pull/repository/abc' -C 'main'",
+		"--only-if-changed 'it'\\''s.yml'",
+		"'https://semaphore.example.com/api/pull/report'",
+	} {
+		if !strings.Contains(script, expected) {
+			t.Fatalf("expected the script to contain %q:\n%s", expected, script)
+		}
+	}
+}
+
+func TestGetPullRepositoryFile(t *testing.T) {
+	util.Config = &util.ConfigType{TmpPath: "/tmp/semaphore"}
+
+	tpl := db.Template{ID: 2, ProjectID: 1}
+
+	if _, err := GetPullRepositoryFile(tpl, "info/refs"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"config", "../../etc/passwd", "objects/../config", "hooks/pre-commit"} {
+		if _, err := GetPullRepositoryFile(tpl, name); err == nil {
+			t.Fatalf("expected %s not to be served", name)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package tasks
+
+import (
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/i18n"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// budgetSampleSize is the number of recent successful tasks used to calculate average runtime.
+const budgetSampleSize = 10
+
+// budgetMinSamples is the number of successful tasks required to use the average runtime.
+const budgetMinSamples = 3
+
+// getAverageDuration returns average runtime of the recent successful tasks.
+// Tasks must be sorted from the newest.
+func getAverageDuration(tasks []db.TaskWithTpl) (avg time.Duration, ok bool) {
+	var total time.Duration
+	n := 0
+
+	for _, task := range tasks {
+		if n == budgetSampleSize {
+			break
+		}
+
+		if task.Status != task_logger.TaskSuccessStatus || task.Start == nil || task.End == nil {
+			continue
+		}
+
+		total += task.End.Sub(*task.Start)
+		n++
+	}
+
+	if n < budgetMinSamples {
+		return
+	}
+
+	return total / time.Duration(n), true
+}
+
+// calcRuntimeBudget returns runtime after which the task exceeds the budget of the template
+// or 0 if the template has no budget.
+func calcRuntimeBudget(tpl db.Template, tasks []db.TaskWithTpl) (budget time.Duration) {
+	if tpl.MaxDuration > 0 {
+		budget = time.Duration(tpl.MaxDuration) * time.Minute
+	}
+
+	if tpl.DurationFactor > 0 {
+		if avg, ok := getAverageDuration(tasks); ok {
+			b := avg * time.Duration(tpl.DurationFactor)
+			if budget == 0 || b < budget {
+				budget = b
+			}
+		}
+	}
+
+	return
+}
+
+func (t *TaskRunner) getRuntimeBudget() time.Duration {
+	var tasks []db.TaskWithTpl
+
+	if t.Template.DurationFactor > 0 {
+		var err error
+		// failed and running tasks are skipped, so take more tasks than needed
+		tasks, err = t.pool.store.GetTemplateTasks(t.Task.ProjectID, t.Task.TemplateID, db.RetrieveQueryParams{
+			Count: budgetSampleSize * 5,
+		})
+		if err != nil {
+			t.Log("Failed to get recent tasks for runtime budget: " + err.Error())
+		}
+	}
+
+	return calcRuntimeBudget(t.Template, tasks)
+}
+
+// exceedBudget marks the running task as exceeded the runtime budget and sends alerts.
+func (t *TaskRunner) exceedBudget(budget time.Duration) {
+	t.alertLock.Lock()
+	defer t.alertLock.Unlock()
+
+	if t.Task.Status.IsFinished() {
+		return
+	}
+
+	t.Task.BudgetExceeded = true
+	t.Log("Task exceeded runtime budget of " + budget.String())
+	t.saveStatus()
+
+	t.budgetAlert = "⏱️" + i18n.T(util.Config.GetLocale(), "EXCEEDED RUNTIME BUDGET OF %s", budget.String())
+	defer func() {
+		t.budgetAlert = ""
+	}()
+
+	t.sendTelegramAlert()
+	t.sendSlackAlert()
+	t.sendRocketChatAlert()
+	t.sendMicrosoftTeamsAlert()
+	t.sendDingTalkAlert()
+	t.sendGotifyAlert()
+}
@@ -0,0 +1,40 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+func makeBudgetTestTask(status task_logger.TaskStatus, duration time.Duration) db.TaskWithTpl {
+	start := time.Now()
+	end := start.Add(duration)
+	return db.TaskWithTpl{Task: db.Task{Status: status, Start: &start, End: &end}}
+}
+
+func TestCalcRuntimeBudget(t *testing.T) {
+	tasks := []db.TaskWithTpl{
+		makeBudgetTestTask(task_logger.TaskFailStatus, time.Hour),
+		makeBudgetTestTask(task_logger.TaskSuccessStatus, 2*time.Minute),
+		makeBudgetTestTask(task_logger.TaskSuccessStatus, 4*time.Minute),
+		makeBudgetTestTask(task_logger.TaskSuccessStatus, 3*time.Minute),
+	}
+
+	if budget := calcRuntimeBudget(db.Template{}, tasks); budget != 0 {
+		t.Fatal("template without budget must have no budget")
+	}
+
+	if budget := calcRuntimeBudget(db.Template{DurationFactor: 2}, tasks); budget != 6*time.Minute {
+		t.Fatal("budget must be twice of average runtime of successful tasks, got " + budget.String())
+	}
+
+	if budget := calcRuntimeBudget(db.Template{DurationFactor: 2, MaxDuration: 5}, tasks); budget != 5*time.Minute {
+		t.Fatal("hard budget must be used if it is less than average budget, got " + budget.String())
+	}
+
+	if budget := calcRuntimeBudget(db.Template{DurationFactor: 2, MaxDuration: 5}, tasks[:2]); budget != 5*time.Minute {
+		t.Fatal("average budget must not be used without enough successful tasks, got " + budget.String())
+	}
+}
@@ -0,0 +1,282 @@
+package tasks
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/semaphoreui/semaphore/api/sse"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// listHostsHeaderRegexp matches the header of ansible --list-hosts output, e.g. "  hosts (3):".
+var listHostsHeaderRegexp = regexp.MustCompile(`^hosts \(\d+\):$`)
+
+// parseListHosts returns the hosts printed by ansible --list-hosts.
+func parseListHosts(lines []string) []string {
+	hosts := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, line := range lines {
+		line = strings.TrimSpace(util.StripANSI(line))
+
+		if line == "" || strings.HasPrefix(line, "[") || listHostsHeaderRegexp.MatchString(line) {
+			continue
+		}
+
+		if !seen[line] {
+			seen[line] = true
+			hosts = append(hosts, line)
+		}
+	}
+
+	return hosts
+}
+
+// ListInventoryHosts returns the hosts of the inventory matching the limit.
+func ListInventoryHosts(store db.Store, projectID int, inventoryID int, limit string) ([]string, error) {
+	inventory, err := getAdhocInventory(store, projectID, inventoryID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit == "" {
+		limit = "all"
+	}
+
+	logger := &pingLogger{}
+
+	job := LocalJob{
+		Inventory: inventory,
+		Logger:    logger,
+		tmpName:   "hosts_" + strconv.Itoa(inventoryID) + "_" + util.RandString(8),
+	}
+
+	err = runAnsibleModule(&job, limit, []string{"--list-hosts"})
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("can not list hosts of the inventory: %s", strings.Join(logger.lines, "\n"))
+		}
+		return nil, err
+	}
+
+	return parseListHosts(logger.lines), nil
+}
+
+// listCanaryHosts returns the hosts of the task which are split into batches of the canary launch.
+func (p *TaskPool) listCanaryHosts(task db.Task, tpl db.Template) ([]string, error) {
+	if p.HostLister != nil {
+		return p.HostLister(task, tpl)
+	}
+
+	inventoryID := tpl.InventoryID
+	if task.InventoryID != nil {
+		inventoryID = task.InventoryID
+	}
+
+	if inventoryID == nil {
+		return nil, &db.ValidationError{Message: "canary launch requires the inventory"}
+	}
+
+	return ListInventoryHosts(p.store, task.ProjectID, *inventoryID, task.Limit)
+}
+
+// startCanaryDeployment creates the task which coordinates the canary launch
+// and starts its canary batch. The remainder batch is started after the confirmation.
+func (p *TaskPool) startCanaryDeployment(taskObj db.Task, tpl db.Template, size string) (newTask db.Task, err error) {
+	if tpl.Type == db.TemplateBuild {
+		err = &db.ValidationError{Message: "canary launch is not supported by build templates"}
+		return
+	}
+
+	if taskObj.RunAt != nil {
+		err = &db.ValidationError{Message: "canary launch can not be delayed"}
+		return
+	}
+
+	hosts, err := p.listCanaryHosts(taskObj, tpl)
+	if err != nil {
+		return
+	}
+
+	canary, remainder, err := db.SplitCanaryHosts(hosts, size)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	taskObj.Status = task_logger.TaskRunningStatus
+	taskObj.Start = &now
+	taskObj.Params[db.CanaryRemainderParam] = strings.Join(remainder, ",")
+
+	newTask, err = p.store.CreateTask(taskObj, util.Config.MaxTasksPerTemplate)
+	if err != nil {
+		return
+	}
+
+	updateTemplateSummary(p.store, newTask, true, false)
+
+	sse.Publish(newTask.ProjectID, sse.EventTaskCreated, newTask)
+	err = p.createTaskQueueEvent(newTask)
+	if err != nil {
+		return
+	}
+
+	runner, err := p.createCanaryRunner(newTask)
+	if err != nil {
+		return
+	}
+
+	runner.sendEvent(TaskEventCreated)
+	runner.sendEvent(TaskEventStarted)
+
+	runner.Log("Canary batch: " + strings.Join(canary, ", "))
+	runner.Log("Remainder batch: " + strings.Join(remainder, ", "))
+
+	p.addCanaryBatch(runner, db.CanaryBatchCanary, strings.Join(canary, ","))
+
+	return
+}
+
+// createCanaryRunner creates the runner of the canary deployment task which is not run
+// by the pool. The runner is used to log messages and change the status of the task.
+func (p *TaskPool) createCanaryRunner(task db.Task) (*TaskRunner, error) {
+	runner := &TaskRunner{
+		Task: task,
+		pool: p,
+	}
+
+	if err := runner.populateDetails(); err != nil {
+		return nil, err
+	}
+
+	return runner, nil
+}
+
+// addCanaryBatch starts the child task which runs the playbook against the hosts of the batch.
+// The canary deployment fails if the task can not be started.
+func (p *TaskPool) addCanaryBatch(runner *TaskRunner, batch db.CanaryBatch, limit string) {
+	child := runner.Task
+	child.ID = 0
+	child.ParentTaskID = &runner.Task.ID
+	child.CanaryBatch = batch
+	child.Limit = limit
+	child.Start = nil
+	child.End = nil
+	child.Params = make(db.MapStringAnyField)
+
+	for k, v := range runner.Task.Params {
+		if k != "canary" && k != db.CanaryRemainderParam {
+			child.Params[k] = v
+		}
+	}
+
+	newTask, err := p.AddTask(child, runner.Task.UserID, runner.Task.ProjectID)
+	if err != nil {
+		runner.Log("Can not start " + string(batch) + " batch: " + err.Error())
+		p.finishCanaryDeployment(runner, task_logger.TaskFailStatus)
+		return
+	}
+
+	runner.Logf("Task %d started for %s batch", newTask.ID, batch)
+}
+
+func (p *TaskPool) finishCanaryDeployment(runner *TaskRunner, status task_logger.TaskStatus) {
+	now := time.Now()
+	runner.Task.End = &now
+	runner.SetStatus(status)
+	runner.createTaskEvent()
+	updateTemplateSummary(p.store, runner.Task, false, true)
+}
+
+// onCanaryBatchFinished continues the canary deployment after the task of its batch finished.
+// The deployment waits for the confirmation after the successful canary batch
+// and fails if the canary batch did not succeed.
+func (p *TaskPool) onCanaryBatchFinished(batch db.Task) {
+	parent, err := p.store.GetTask(batch.ProjectID, *batch.ParentTaskID)
+	if err != nil {
+		log.Error("Can't get canary deployment of task " + strconv.Itoa(batch.ID) + "! Error: " + err.Error())
+		return
+	}
+
+	if parent.Status.IsFinished() {
+		return
+	}
+
+	runner, err := p.createCanaryRunner(parent)
+	if err != nil {
+		log.Error("Can't continue canary deployment " + strconv.Itoa(parent.ID) + "! Error: " + err.Error())
+		return
+	}
+
+	runner.Logf("Task %d of %s batch finished - %s", batch.ID, batch.CanaryBatch, strings.ToUpper(string(batch.Status)))
+
+	switch {
+	case batch.CanaryBatch == db.CanaryBatchRemainder:
+		p.finishCanaryDeployment(runner, batch.Status)
+	case batch.Status == task_logger.TaskSuccessStatus:
+		runner.Log("Confirm the task to run the remainder batch")
+		runner.SetStatus(task_logger.TaskWaitingConfirmation)
+	case batch.Status == task_logger.TaskStoppedStatus:
+		p.finishCanaryDeployment(runner, task_logger.TaskStoppedStatus)
+	default:
+		p.finishCanaryDeployment(runner, task_logger.TaskFailStatus)
+	}
+}
+
+// confirmCanaryDeployment starts the remainder batch of the canary deployment.
+func (p *TaskPool) confirmCanaryDeployment(parent db.Task) error {
+	if parent.Status != task_logger.TaskWaitingConfirmation {
+		return fmt.Errorf("canary deployment is not waiting for confirmation: %w", db.ErrInvalidOperation)
+	}
+
+	runner, err := p.createCanaryRunner(parent)
+	if err != nil {
+		return err
+	}
+
+	remainder, _ := parent.Params[db.CanaryRemainderParam].(string)
+
+	runner.Log("Remainder batch confirmed")
+	runner.SetStatus(task_logger.TaskRunningStatus)
+	p.addCanaryBatch(runner, db.CanaryBatchRemainder, remainder)
+
+	return nil
+}
+
+// stopCanaryDeployment stops the active batch of the canary deployment and the deployment itself.
+func (p *TaskPool) stopCanaryDeployment(parent db.Task, forceStop bool) error {
+	tasks, err := p.store.GetUnfinishedTasks()
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		if task.ParentTaskID == nil || *task.ParentTaskID != parent.ID {
+			continue
+		}
+
+		if err = p.StopTask(task, forceStop); err != nil {
+			return err
+		}
+	}
+
+	if parent.Status.IsFinished() {
+		return nil
+	}
+
+	runner, err := p.createCanaryRunner(parent)
+	if err != nil {
+		return err
+	}
+
+	p.finishCanaryDeployment(runner, task_logger.TaskStoppedStatus)
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package tasks
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseListHosts(t *testing.T) {
+	hosts := parseListHosts([]string{
+		"[WARNING]: Invalid characters were found in group names",
+		"  hosts (3):",
+		"    web1",
+		"    web2",
+		"    db1",
+		"",
+	})
+
+	if !reflect.DeepEqual(hosts, []string{"web1", "web2", "db1"}) {
+		t.Fatal("unexpected hosts", hosts)
+	}
+}
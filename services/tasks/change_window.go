@@ -0,0 +1,65 @@
+package tasks
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// startChangeWindowTimer aborts the task when the change window of the template closes.
+// It returns false if the task is started outside the change window.
+func (t *TaskRunner) startChangeWindowTimer() (timer *time.Timer, ok bool) {
+	windowEnd, ok := t.Template.GetChangeWindowEnd(time.Now())
+	if !ok {
+		return
+	}
+
+	t.Log("Task must complete before the change window closes at " + windowEnd.Format(time.RFC3339))
+
+	timer = time.AfterFunc(time.Until(windowEnd), t.closeChangeWindow)
+	return
+}
+
+// closeChangeWindow flags the task and kills it because its change window is closed.
+func (t *TaskRunner) closeChangeWindow() {
+	t.alertLock.Lock()
+	defer t.alertLock.Unlock()
+
+	if t.Task.Status.IsFinished() {
+		return
+	}
+
+	t.Task.ChangeWindowClosed = true
+	t.Log("Change window " + t.Template.ChangeWindowStart + "-" + t.Template.ChangeWindowEnd + " closed, aborting the task")
+	t.saveStatus()
+
+	t.kill()
+}
+
+func (t *TaskRunner) isChangeWindowClosed() bool {
+	t.alertLock.Lock()
+	defer t.alertLock.Unlock()
+	return t.Task.ChangeWindowClosed
+}
+
+// runAbortTemplate starts the abort template of the task aborted by the change window.
+func (t *TaskRunner) runAbortTemplate() {
+	if t.Template.AbortTemplateID == nil {
+		return
+	}
+
+	abortTask, err := t.pool.AddTask(db.Task{
+		TemplateID: *t.Template.AbortTemplateID,
+		ProjectID:  t.Task.ProjectID,
+		Message:    fmt.Sprintf("Abort of task #%d: change window closed", t.Task.ID),
+	}, nil, t.Task.ProjectID)
+
+	if err != nil {
+		t.Log("Failed to start abort template: " + err.Error())
+		return
+	}
+
+	t.Log("Abort task " + strconv.Itoa(abortTask.ID) + " started")
+}
@@ -0,0 +1,185 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+const commitStatusTimeout = 10 * time.Second
+
+// commitStatusRequest is a request to the forge API which sets the commit status.
+type commitStatusRequest struct {
+	url    string
+	header http.Header
+	body   map[string]string
+}
+
+// parseRepositoryURL returns base URL of the forge and path of the repository
+// in form owner/name.
+func parseRepositoryURL(gitURL string) (baseURL string, repoPath string, err error) {
+	u, err := url.Parse(gitURL)
+	if err != nil {
+		return
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		err = fmt.Errorf("commit status can be reported only for HTTP(S) repositories")
+		return
+	}
+
+	repoPath = strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if !strings.Contains(repoPath, "/") {
+		err = fmt.Errorf("invalid repository path: %s", u.Path)
+		return
+	}
+
+	baseURL = u.Scheme + "://" + u.Host
+	return
+}
+
+func makeCommitStatusRequest(
+	provider db.IntegrationCommitStatus,
+	gitURL string,
+	token string,
+	commit string,
+	status task_logger.TaskStatus,
+	context string,
+	targetURL string,
+) (req commitStatusRequest, err error) {
+	baseURL, repoPath, err := parseRepositoryURL(gitURL)
+	if err != nil {
+		return
+	}
+
+	req.header = http.Header{}
+	req.header.Set("Content-Type", "application/json")
+	req.body = map[string]string{
+		"target_url":  targetURL,
+		"description": "Task " + status.Format(),
+	}
+
+	switch provider {
+	case db.IntegrationCommitStatusGitHub:
+		apiURL := baseURL + "/api/v3"
+		if baseURL == "https://github.com" {
+			apiURL = "https://api.github.com"
+		}
+		req.url = apiURL + "/repos/" + repoPath + "/statuses/" + commit
+		req.header.Set("Authorization", "Bearer "+token)
+		req.header.Set("Accept", "application/vnd.github+json")
+		req.body["context"] = context
+		req.body["state"] = getCommitState(status, "pending", "pending", "success", "failure", "error")
+	case db.IntegrationCommitStatusGitLab:
+		req.url = baseURL + "/api/v4/projects/" + url.PathEscape(repoPath) + "/statuses/" + commit
+		req.header.Set("PRIVATE-TOKEN", token)
+		req.body["name"] = context
+		req.body["state"] = getCommitState(status, "pending", "running", "success", "failed", "canceled")
+	case db.IntegrationCommitStatusGitea:
+		req.url = baseURL + "/api/v1/repos/" + repoPath + "/statuses/" + commit
+		req.header.Set("Authorization", "token "+token)
+		req.body["context"] = context
+		req.body["state"] = getCommitState(status, "pending", "pending", "success", "failure", "error")
+	default:
+		err = fmt.Errorf("unknown commit status provider: %s", provider)
+	}
+
+	return
+}
+
+// getCommitState maps the task status to the forge specific commit state.
+func getCommitState(status task_logger.TaskStatus, waiting, running, success, failure, stopped string) string {
+	switch status {
+	case task_logger.TaskRunningStatus:
+		return running
+	case task_logger.TaskSuccessStatus:
+		return success
+	case task_logger.TaskFailStatus, task_logger.TaskPartialStatus:
+		return failure
+	case task_logger.TaskStoppedStatus:
+		return stopped
+	default:
+		return waiting
+	}
+}
+
+// sendCommitStatus reports the task status to the forge for the commit which
+// was checked out by the task. It does nothing for tasks which were not
+// started by an integration with enabled commit status reporting.
+func (t *TaskRunner) sendCommitStatus() {
+	if t.Task.IntegrationID == nil || t.Task.CommitHash == nil {
+		return
+	}
+
+	integration, err := t.pool.store.GetIntegration(t.Task.ProjectID, *t.Task.IntegrationID)
+	if err != nil || integration.CommitStatus == db.IntegrationCommitStatusNone {
+		return
+	}
+
+	if t.Repository.SSHKey.Type != db.AccessKeyLoginPassword {
+		t.Log("Can't send commit status! Repository access key must be login with password or token")
+		return
+	}
+
+	req, err := makeCommitStatusRequest(
+		integration.CommitStatus,
+		t.Repository.GitURL,
+		t.Repository.SSHKey.LoginPassword.Password,
+		*t.Task.CommitHash,
+		t.Task.Status,
+		"semaphore/"+t.Template.Name,
+		t.taskLink(),
+	)
+	if err != nil {
+		t.Log("Can't send commit status! Error: " + err.Error())
+		return
+	}
+
+	body, err := json.Marshal(req.body)
+	if err != nil {
+		t.Log("Can't send commit status! Error: " + err.Error())
+		return
+	}
+
+	httpReq, err := http.NewRequest("POST", req.url, bytes.NewReader(body))
+	if err != nil {
+		t.Log("Can't send commit status! Error: " + err.Error())
+		return
+	}
+	httpReq.Header = req.header
+
+	client := &http.Client{Timeout: commitStatusTimeout}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		t.Log("Can't send commit status! Error: " + err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		t.Log("Can't send commit status! Response code: " + strconv.Itoa(resp.StatusCode))
+	}
+}
+
+// SetCommit stores the commit which was checked out by the task and reports
+// the task status for it.
+func (t *TaskRunner) SetCommit(hash string, message string) {
+	t.Task.CommitHash = &hash
+	t.Task.CommitMessage = message
+
+	if err := t.pool.store.UpdateTask(t.Task); err != nil {
+		t.Log("Failed to store commit of the task: " + err.Error())
+	}
+
+	if !t.Task.Status.IsFinished() {
+		t.sendCommitStatus()
+	}
+}
@@ -0,0 +1,90 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+func TestMakeCommitStatusRequest(t *testing.T) {
+	req, err := makeCommitStatusRequest(
+		db.IntegrationCommitStatusGitHub,
+		"https://github.com/semaphoreui/semaphore.git",
+		"secret",
+		"abc123",
+		task_logger.TaskFailStatus,
+		"semaphore/deploy",
+		"http://localhost/project/1/templates/2?t=3",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.url != "https://api.github.com/repos/semaphoreui/semaphore/statuses/abc123" {
+		t.Fatal("invalid url: " + req.url)
+	}
+
+	if req.body["state"] != "failure" || req.body["context"] != "semaphore/deploy" {
+		t.Fatal("invalid body")
+	}
+
+	if req.header.Get("Authorization") != "Bearer secret" {
+		t.Fatal("invalid auth header")
+	}
+
+	req, err = makeCommitStatusRequest(
+		db.IntegrationCommitStatusGitLab,
+		"https://gitlab.example.com/group/sub/project",
+		"secret",
+		"abc123",
+		task_logger.TaskRunningStatus,
+		"semaphore/deploy",
+		"",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.url != "https://gitlab.example.com/api/v4/projects/group%2Fsub%2Fproject/statuses/abc123" {
+		t.Fatal("invalid url: " + req.url)
+	}
+
+	if req.body["state"] != "running" || req.body["name"] != "semaphore/deploy" {
+		t.Fatal("invalid body")
+	}
+
+	req, err = makeCommitStatusRequest(
+		db.IntegrationCommitStatusGitea,
+		"http://gitea.local:3000/owner/repo.git",
+		"secret",
+		"abc123",
+		task_logger.TaskStoppedStatus,
+		"semaphore/deploy",
+		"",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.url != "http://gitea.local:3000/api/v1/repos/owner/repo/statuses/abc123" {
+		t.Fatal("invalid url: " + req.url)
+	}
+
+	if req.body["state"] != "error" || req.header.Get("Authorization") != "token secret" {
+		t.Fatal("invalid request")
+	}
+
+	_, err = makeCommitStatusRequest(
+		db.IntegrationCommitStatusGitHub,
+		"git@github.com:semaphoreui/semaphore.git",
+		"secret",
+		"abc123",
+		task_logger.TaskSuccessStatus,
+		"semaphore/deploy",
+		"",
+	)
+	if err == nil {
+		t.Fatal("ssh repository must not be supported")
+	}
+}
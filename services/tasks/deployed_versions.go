@@ -0,0 +1,228 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/objectstorage"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+const deployedVersionsTimeout = 30 * time.Second
+
+// deployedVersionsMutex serializes exports, so the manifest of the latest deployment is published last.
+var deployedVersionsMutex sync.Mutex
+
+// DeployedVersion is the last successful deployment of the template to the environment.
+type DeployedVersion struct {
+	TemplateID int    `json:"template_id"`
+	Template   string `json:"template"`
+	TaskID     int    `json:"task_id"`
+	// Version is the version of the build task or the version deployed by the deploy task.
+	Version    *string    `json:"version"`
+	CommitHash *string    `json:"commit_hash"`
+	DeployedAt *time.Time `json:"deployed_at"`
+	DeployedBy *string    `json:"deployed_by"`
+	URL        *string    `json:"url"`
+}
+
+type DeployedVersionsEnvironment struct {
+	ID          int               `json:"id"`
+	Name        string            `json:"name"`
+	Position    int               `json:"position"`
+	Deployments []DeployedVersion `json:"deployments"`
+}
+
+// DeployedVersionsManifest lists versions currently deployed to the deployment environments of the project.
+type DeployedVersionsManifest struct {
+	ProjectID    int                           `json:"project_id"`
+	Project      string                        `json:"project"`
+	Generated    time.Time                     `json:"generated"`
+	Environments []DeployedVersionsEnvironment `json:"environments"`
+}
+
+// GetDeployedVersionsManifest returns the last successful deployment of each template
+// to each deployment environment of the project. Environments are in order of promotion.
+func GetDeployedVersionsManifest(store db.Store, project db.Project, now time.Time) (manifest DeployedVersionsManifest, err error) {
+	envs, err := store.GetDeploymentEnvironments(project.ID)
+	if err != nil {
+		return
+	}
+	db.SortDeploymentEnvironments(envs)
+
+	templates, err := store.GetTemplates(project.ID, db.TemplateFilter{}, db.RetrieveQueryParams{})
+	if err != nil {
+		return
+	}
+
+	manifest = DeployedVersionsManifest{
+		ProjectID:    project.ID,
+		Project:      project.Name,
+		Generated:    now.UTC(),
+		Environments: make([]DeployedVersionsEnvironment, 0, len(envs)),
+	}
+
+	usernames := make(map[int]*string)
+
+	for _, env := range envs {
+		environment := DeployedVersionsEnvironment{
+			ID:          env.ID,
+			Name:        env.Name,
+			Position:    env.Position,
+			Deployments: make([]DeployedVersion, 0),
+		}
+
+		for _, tpl := range templates {
+			var task db.Task
+			task, err = store.GetLastDeploymentTask(project.ID, tpl.ID, env.ID)
+			if errors.Is(err, db.ErrNotFound) {
+				err = nil
+				continue
+			}
+			if err != nil {
+				return
+			}
+
+			version := task.Version
+			if version == nil {
+				version = task.GetIncomingVersion(store)
+			}
+
+			deployment := DeployedVersion{
+				TemplateID: tpl.ID,
+				Template:   tpl.Name,
+				TaskID:     task.ID,
+				Version:    version,
+				CommitHash: task.CommitHash,
+				DeployedAt: getUTCTime(task.End),
+				URL:        task.GetUrl(),
+			}
+
+			if task.UserID != nil {
+				username, ok := usernames[*task.UserID]
+				if !ok {
+					if user, userErr := store.GetUser(*task.UserID); userErr == nil {
+						username = &user.Username
+					}
+					usernames[*task.UserID] = username
+				}
+				deployment.DeployedBy = username
+			}
+
+			environment.Deployments = append(environment.Deployments, deployment)
+		}
+
+		manifest.Environments = append(manifest.Environments, environment)
+	}
+
+	return
+}
+
+func getDeployedVersionsObjectKey(cfg *util.DeployedVersionsConfig, projectID int) string {
+	return fmt.Sprintf("%sproject_%d/deployed_versions.json", cfg.Prefix, projectID)
+}
+
+// publishDeployedVersions uploads the manifest to the bucket and sends it to the URL of the configuration.
+func publishDeployedVersions(cfg *util.DeployedVersionsConfig, manifest DeployedVersionsManifest) error {
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Bucket != "" {
+		storage := &objectstorage.Client{
+			Endpoint:        cfg.Endpoint,
+			Region:          cfg.Region,
+			Bucket:          cfg.Bucket,
+			AccessKeyID:     cfg.AccessKeyID,
+			SecretAccessKey: cfg.SecretAccessKey,
+			PathStyle:       cfg.PathStyle,
+			HTTPClient:      &http.Client{Timeout: deployedVersionsTimeout},
+		}
+
+		key := getDeployedVersionsObjectKey(cfg, manifest.ProjectID)
+		if err = storage.PutObject(key, bytes.NewReader(body), int64(len(body))); err != nil {
+			return fmt.Errorf("can not upload %s: %s", key, err.Error())
+		}
+	}
+
+	if cfg.URL != "" {
+		var req *http.Request
+		req, err = http.NewRequest(http.MethodPost, cfg.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		if cfg.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+cfg.Token)
+		}
+
+		client := &http.Client{Timeout: deployedVersionsTimeout}
+
+		var resp *http.Response
+		resp, err = client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close() //nolint: errcheck
+
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("%s responded with status %d", cfg.URL, resp.StatusCode)
+		}
+	}
+
+	return nil
+}
+
+// ExportDeployedVersions publishes the manifest of the versions deployed to the environments of the project.
+func ExportDeployedVersions(store db.Store, projectID int) error {
+	cfg := util.Config.DeployedVersions
+	if !cfg.IsEnabled() {
+		return nil
+	}
+
+	deployedVersionsMutex.Lock()
+	defer deployedVersionsMutex.Unlock()
+
+	project, err := store.GetProject(projectID)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := GetDeployedVersionsManifest(store, project, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return publishDeployedVersions(cfg, manifest)
+}
+
+// exportDeployedVersions publishes the manifest in the background when the task
+// successfully deploys to the deployment environment.
+func (t *TaskRunner) exportDeployedVersions() {
+	if !util.Config.DeployedVersions.IsEnabled() {
+		return
+	}
+
+	if t.Task.Status != task_logger.TaskSuccessStatus || t.Task.DeploymentEnvironmentID == nil {
+		return
+	}
+
+	go db.StoreSession(t.pool.store, "export deployed versions", func() {
+		if err := ExportDeployedVersions(t.pool.store, t.Task.ProjectID); err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"project_id": t.Task.ProjectID,
+				"task_id":    t.Task.ID,
+			}).Error("Can not export deployed versions")
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package tasks
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestExportDeployedVersions(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	project, err := store.CreateProject(db.Project{Name: "Shop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prod, err := store.CreateDeploymentEnvironment(db.DeploymentEnvironment{ProjectID: project.ID, Name: "prod", Position: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.CreateDeploymentEnvironment(db.DeploymentEnvironment{ProjectID: project.ID, Name: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, err := store.CreateTemplate(db.Template{ProjectID: project.ID, Name: "Deploy web", Playbook: "web.yml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version := "1.4.2"
+	end := time.Now()
+	task, err := store.CreateTask(db.Task{
+		ProjectID:               project.ID,
+		TemplateID:              tpl.ID,
+		Status:                  task_logger.TaskSuccessStatus,
+		Version:                 &version,
+		DeploymentEnvironmentID: &prod.ID,
+		End:                     &end,
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var manifest DeployedVersionsManifest
+	var auth string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&manifest); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	util.Config = &util.ConfigType{
+		DeployedVersions: &util.DeployedVersionsConfig{URL: server.URL, Token: "secret"},
+	}
+
+	if err = ExportDeployedVersions(store, project.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if auth != "Bearer secret" {
+		t.Fatalf("expected bearer token, got %q", auth)
+	}
+
+	if manifest.ProjectID != project.ID || len(manifest.Environments) != 2 {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+
+	if manifest.Environments[0].Name != "dev" || len(manifest.Environments[0].Deployments) != 0 {
+		t.Fatalf("environments must be in order of promotion, got %+v", manifest.Environments)
+	}
+
+	deployments := manifest.Environments[1].Deployments
+	if len(deployments) != 1 || deployments[0].TaskID != task.ID || deployments[0].Version == nil || *deployments[0].Version != version {
+		t.Fatalf("unexpected deployments of prod %+v", deployments)
+	}
+}
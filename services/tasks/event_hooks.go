@@ -0,0 +1,118 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os/exec"
+	"slices"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// TaskEvent is the name of the task lifecycle event passed to event hooks.
+type TaskEvent string
+
+const (
+	TaskEventCreated  TaskEvent = "task_created"
+	TaskEventStarted  TaskEvent = "task_started"
+	TaskEventFinished TaskEvent = "task_finished"
+)
+
+const defaultEventHookTimeout = 30 * time.Second
+
+// TaskEventTemplate is the template of the task in the event payload.
+type TaskEventTemplate struct {
+	ID   int             `json:"id"`
+	Name string          `json:"name"`
+	App  db.TemplateApp  `json:"app"`
+	Type db.TemplateType `json:"type"`
+}
+
+// TaskEventPayload is written to stdin of event hooks as JSON.
+type TaskEventPayload struct {
+	Event    TaskEvent              `json:"event"`
+	Time     time.Time              `json:"time"`
+	Status   task_logger.TaskStatus `json:"status"`
+	URL      string                 `json:"url"`
+	Task     db.Task                `json:"task"`
+	Template TaskEventTemplate      `json:"template"`
+}
+
+func eventHookHandles(hook util.EventHookConfig, event TaskEvent) bool {
+	return len(hook.Events) == 0 || slices.Contains(hook.Events, string(event))
+}
+
+// runEventHook starts the executable of the hook and waits for its completion
+// or for the timeout of the hook. It returns the combined output of the process.
+func runEventHook(hook util.EventHookConfig, event TaskEvent, payload []byte) (string, error) {
+	timeout := defaultEventHookTimeout
+	if hook.TimeoutSec > 0 {
+		timeout = time.Duration(hook.TimeoutSec) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var output bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, hook.Command, hook.Args...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.Env = append(util.AllowedEnvironmentVars(), "SEMAPHORE_EVENT="+string(event))
+
+	err := cmd.Run()
+
+	return output.String(), err
+}
+
+// sendEvent runs the event hooks registered for the event in the background.
+// Failures of hooks are logged and do not affect the task.
+func (t *TaskRunner) sendEvent(event TaskEvent) {
+	var hooks []util.EventHookConfig
+	for _, hook := range util.Config.EventHooks {
+		if hook.Command != "" && eventHookHandles(hook, event) {
+			hooks = append(hooks, hook)
+		}
+	}
+
+	if len(hooks) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(TaskEventPayload{
+		Event:  event,
+		Time:   time.Now(),
+		Status: t.Task.Status,
+		URL:    t.taskLink(),
+		Task:   t.Task,
+		Template: TaskEventTemplate{
+			ID:   t.Template.ID,
+			Name: t.Template.Name,
+			App:  t.Template.App,
+			Type: t.Template.Type,
+		},
+	})
+	if err != nil {
+		log.WithError(err).WithField("task_id", t.Task.ID).Error("Failed to encode the task event")
+		return
+	}
+
+	for _, hook := range hooks {
+		go func(hook util.EventHookConfig) {
+			if output, err := runEventHook(hook, event, payload); err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"task_id": t.Task.ID,
+					"event":   event,
+					"command": hook.Command,
+					"output":  output,
+				}).Error("Event hook failed")
+			}
+		}(hook)
+	}
+}
@@ -0,0 +1,82 @@
+package tasks
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestTaskRunnerSendEvent(t *testing.T) {
+	dir := t.TempDir()
+	out := path.Join(dir, "event.json")
+
+	util.Config = &util.ConfigType{
+		WebHost: "https://semaphore.example.com",
+		EventHooks: []util.EventHookConfig{
+			{Command: "sh", Args: []string{"-c", "cat > " + out}, Events: []string{"task_finished"}},
+			{Command: "sh", Args: []string{"-c", "touch " + path.Join(dir, "started")}, Events: []string{"task_started"}},
+		},
+	}
+
+	tr := &TaskRunner{
+		Task:     db.Task{ID: 5, ProjectID: 1, TemplateID: 2, Status: task_logger.TaskSuccessStatus},
+		Template: db.Template{ID: 2, ProjectID: 1, Name: "Deploy", App: db.AppAnsible},
+	}
+
+	tr.sendEvent(TaskEventFinished)
+
+	var payload TaskEventPayload
+	for i := 0; ; i++ {
+		data, err := os.ReadFile(out)
+		if err == nil && json.Unmarshal(data, &payload) == nil {
+			break
+		}
+		if i == 50 {
+			t.Fatal("hook must write the event", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if payload.Event != TaskEventFinished || payload.Task.ID != 5 || payload.Template.Name != "Deploy" ||
+		payload.Status != task_logger.TaskSuccessStatus {
+		t.Fatal("invalid payload", payload)
+	}
+
+	if payload.URL != "https://semaphore.example.com/project/1/templates/2?t=5" {
+		t.Fatal("invalid task link", payload.URL)
+	}
+
+	if _, err := os.Stat(path.Join(dir, "started")); err == nil {
+		t.Fatal("hook must not handle other events")
+	}
+}
+
+func TestRunEventHook(t *testing.T) {
+	util.Config = &util.ConfigType{}
+
+	output, err := runEventHook(util.EventHookConfig{
+		Command: "sh",
+		Args:    []string{"-c", "echo $SEMAPHORE_EVENT; exit 1"},
+	}, TaskEventCreated, nil)
+
+	if err == nil || output != "task_created\n" {
+		t.Fatal("hook must fail with the output", err, output)
+	}
+
+	start := time.Now()
+	_, err = runEventHook(util.EventHookConfig{
+		Command:    "sleep",
+		Args:       []string{"10"},
+		TimeoutSec: 1,
+	}, TaskEventCreated, nil)
+
+	if err == nil || time.Since(start) > 5*time.Second {
+		t.Fatal("hook must be killed after the timeout", err)
+	}
+}
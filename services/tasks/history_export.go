@@ -0,0 +1,139 @@
+package tasks
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// TaskHistoryRecord is the row of the exported task history.
+type TaskHistoryRecord struct {
+	ID            int        `parquet:"id"`
+	TemplateID    int        `parquet:"template_id"`
+	Template      string     `parquet:"template"`
+	Status        string     `parquet:"status"`
+	User          *string    `parquet:"user,optional"`
+	Created       time.Time  `parquet:"created"`
+	Start         *time.Time `parquet:"start,optional"`
+	End           *time.Time `parquet:"end,optional"`
+	Duration      *float64   `parquet:"duration,optional"`
+	CommitHash    *string    `parquet:"commit_hash,optional"`
+	CommitMessage string     `parquet:"commit_message"`
+}
+
+var taskHistoryCSVHeader = []string{
+	"id",
+	"template_id",
+	"template",
+	"status",
+	"user",
+	"created",
+	"start",
+	"end",
+	"duration",
+	"commit_hash",
+	"commit_message",
+}
+
+// GetTaskHistory returns records of tasks of the project created in the period [from, to).
+// Duration is the number of seconds between the start and the end of the task.
+func GetTaskHistory(store db.Store, projectID int, from time.Time, to time.Time) ([]TaskHistoryRecord, error) {
+	tasks, err := store.GetProjectTaskHistory(projectID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]TaskHistoryRecord, 0, len(tasks))
+
+	for _, task := range tasks {
+		record := TaskHistoryRecord{
+			ID:            task.ID,
+			TemplateID:    task.TemplateID,
+			Template:      task.TemplateAlias,
+			Status:        string(task.Status),
+			User:          task.UserName,
+			Created:       task.Created.UTC(),
+			Start:         getUTCTime(task.Start),
+			End:           getUTCTime(task.End),
+			CommitHash:    task.CommitHash,
+			CommitMessage: task.CommitMessage,
+		}
+
+		if task.Start != nil && task.End != nil {
+			duration := task.End.Sub(*task.Start).Seconds()
+			record.Duration = &duration
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func getUTCTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	res := t.UTC()
+	return &res
+}
+
+func formatCSVTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func formatCSVString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// WriteTaskHistoryCSV writes the records as CSV with the header row.
+func WriteTaskHistoryCSV(w io.Writer, records []TaskHistoryRecord) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(taskHistoryCSVHeader); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		duration := ""
+		if record.Duration != nil {
+			duration = strconv.FormatFloat(*record.Duration, 'f', 3, 64)
+		}
+
+		err := writer.Write([]string{
+			strconv.Itoa(record.ID),
+			strconv.Itoa(record.TemplateID),
+			record.Template,
+			record.Status,
+			formatCSVString(record.User),
+			formatCSVTime(&record.Created),
+			formatCSVTime(record.Start),
+			formatCSVTime(record.End),
+			duration,
+			formatCSVString(record.CommitHash),
+			record.CommitMessage,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteTaskHistoryParquet writes the records as the Parquet file compressed by Snappy.
+func WriteTaskHistoryParquet(w io.Writer, records []TaskHistoryRecord) error {
+	return parquet.Write(w, records, parquet.Compression(&parquet.Snappy))
+}
@@ -0,0 +1,106 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+func TestTaskHistoryExport(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	project, err := store.CreateProject(db.Project{Name: "Reports"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, err := store.CreateTemplate(db.Template{ProjectID: project.ID, Name: "Deploy", Playbook: "deploy.yml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(90 * time.Second)
+	commit := "abc123"
+
+	for _, task := range []db.Task{
+		{ProjectID: project.ID, TemplateID: tpl.ID, Status: task_logger.TaskSuccessStatus, Start: &start, End: &end, CommitHash: &commit, CommitMessage: "Fix, deploy"},
+		{ProjectID: project.ID, TemplateID: tpl.ID, Status: task_logger.TaskWaitingStatus},
+	} {
+		if _, err = store.CreateTask(task, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+
+	records, err := GetTaskHistory(store, project.ID, now.Add(-time.Hour), now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 2 || records[0].Status != string(task_logger.TaskSuccessStatus) {
+		t.Fatalf("expected two records in the order of creation, got %v", records)
+	}
+
+	if records[0].Template != "Deploy" || records[0].Duration == nil || *records[0].Duration != 90 {
+		t.Fatalf("unexpected record %v", records[0])
+	}
+
+	if records[1].Duration != nil {
+		t.Fatal("task which was not started must have no duration")
+	}
+
+	empty, err := GetTaskHistory(store, project.ID, now.Add(time.Hour), now.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(empty) != 0 {
+		t.Fatal("tasks created before the period must not be exported")
+	}
+
+	var buf bytes.Buffer
+	if err = WriteTaskHistoryCSV(&buf, records); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 3 || rows[0][0] != "id" {
+		t.Fatalf("expected header and two rows, got %v", rows)
+	}
+
+	if rows[1][8] != "90.000" || rows[1][9] != commit || rows[1][10] != "Fix, deploy" {
+		t.Fatalf("unexpected row %v", rows[1])
+	}
+
+	if rows[2][6] != "" || rows[2][8] != "" {
+		t.Fatalf("unexpected row %v", rows[2])
+	}
+
+	buf.Reset()
+	if err = WriteTaskHistoryParquet(&buf, records); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := parquet.Read[TaskHistoryRecord](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(read) != 2 || *read[0].CommitHash != commit || !read[0].Start.Equal(start) || read[1].End != nil {
+		t.Fatalf("unexpected parquet rows %v", read)
+	}
+}
@@ -0,0 +1,47 @@
+package tasks
+
+import (
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// excludeHosts loads the hosts excluded from the runs of the project and
+// adds them to the limit of the local job. Remote runners receive the limit
+// by GetJobTask. Only ansible supports the limit of hosts.
+func (t *TaskRunner) excludeHosts() error {
+	if !t.Template.App.IsAnsible() {
+		return nil
+	}
+
+	exclusions, err := db.GetActiveHostExclusions(t.pool.store, t.Task.ProjectID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	t.excludedHosts = nil
+
+	for _, exclusion := range exclusions {
+		t.excludedHosts = append(t.excludedHosts, exclusion.Host)
+
+		if exclusion.Reason == "" {
+			t.Log("Host " + exclusion.Host + " is excluded from the run")
+		} else {
+			t.Log("Host " + exclusion.Host + " is excluded from the run: " + exclusion.Reason)
+		}
+	}
+
+	if localJob, ok := t.job.(*LocalJob); ok {
+		localJob.Task.Limit = db.ExcludeHosts(t.Task.Limit, t.excludedHosts)
+	}
+
+	return nil
+}
+
+// GetJobTask returns the task which is run by the job. Its limit excludes
+// the hosts excluded from the runs of the project.
+func (t *TaskRunner) GetJobTask() db.Task {
+	task := t.Task
+	task.Limit = db.ExcludeHosts(task.Limit, t.excludedHosts)
+	return task
+}
@@ -0,0 +1,55 @@
+package tasks
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+)
+
+func TestTaskRunnerExcludeHosts(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	pool := CreateTaskPool(store)
+
+	expired := time.Now().Add(-time.Minute)
+
+	for _, e := range []db.HostExclusion{
+		{ProjectID: 1, Host: "web3", Reason: "disk replacement"},
+		{ProjectID: 1, Host: "web4", ExpiresAt: &expired},
+		{ProjectID: 2, Host: "web5"},
+	} {
+		if _, err := store.CreateHostExclusion(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	task := db.Task{ID: 1, ProjectID: 1, Limit: "web"}
+	job := &LocalJob{Task: task}
+
+	runner := &TaskRunner{
+		Task:     task,
+		Template: db.Template{ProjectID: 1, App: db.AppAnsible},
+		pool:     &pool,
+		job:      job,
+	}
+
+	if err := runner.excludeHosts(); err != nil {
+		t.Fatal(err)
+	}
+
+	if job.Task.Limit != "web,!web3" {
+		t.Fatal("invalid limit of the local job", job.Task.Limit)
+	}
+
+	if runner.GetJobTask().Limit != "web,!web3" {
+		t.Fatal("invalid limit of the remote job", runner.GetJobTask().Limit)
+	}
+
+	if runner.Task.Limit != "web" {
+		t.Fatal("limit of the task must not be changed", runner.Task.Limit)
+	}
+}
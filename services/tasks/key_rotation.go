@@ -0,0 +1,65 @@
+package tasks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/i18n"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// GetOverdueAccessKeys returns keys of the project whose secrets were not changed
+// for longer than the rotation policy of the project.
+func GetOverdueAccessKeys(store db.Store, project db.Project, now time.Time) ([]db.AccessKey, error) {
+	if project.KeyRotationDays <= 0 {
+		return nil, nil
+	}
+
+	keys, err := store.GetAccessKeys(project.ID, db.RetrieveQueryParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	var overdue []db.AccessKey
+	for _, key := range keys {
+		if key.IsRotationOverdue(project.KeyRotationDays, now) {
+			overdue = append(overdue, key)
+		}
+	}
+
+	return overdue, nil
+}
+
+func keyRotationAlert(project db.Project, keys []db.AccessKey) ProjectAlert {
+	alert := ProjectAlert{
+		Subject: i18n.Msg("Access keys of project %s must be rotated", project.Name),
+		Text: i18n.Msg("Secrets of %d access keys were not changed for more than %d days",
+			len(keys),
+			project.KeyRotationDays),
+		URL: fmt.Sprintf("%s/project/%d/keys", util.Config.WebHost, project.ID),
+	}
+
+	for _, key := range keys {
+		changed := "-"
+		if key.SecretChanged != nil {
+			changed = key.SecretChanged.Format(time.RFC3339)
+		}
+		alert.Details = append(alert.Details, i18n.Msg("%s: last changed %s", key.Name, changed))
+	}
+
+	return alert
+}
+
+// SendKeyRotationReminder sends the alert listing the overdue keys of the project.
+// It returns the number of overdue keys, nothing is sent if there are no such keys.
+func SendKeyRotationReminder(store db.Store, project db.Project, now time.Time) (int, error) {
+	keys, err := GetOverdueAccessKeys(store, project, now)
+	if err != nil || len(keys) == 0 {
+		return 0, err
+	}
+
+	SendProjectAlert(store, project, keyRotationAlert(project, keys))
+
+	return len(keys), nil
+}
@@ -0,0 +1,99 @@
+package tasks
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestGetOverdueAccessKeys(t *testing.T) {
+	util.Config = &util.ConfigType{}
+
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	project, err := store.CreateProject(db.Project{Name: "Rotation", KeyRotationDays: 90})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	none, err := store.CreateAccessKey(db.AccessKey{Name: "None", Type: db.AccessKeyNone, ProjectID: &project.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := store.CreateAccessKey(db.AccessKey{Name: "Token", Type: db.AccessKeyString, String: "secret", ProjectID: &project.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token.SecretChanged == nil {
+		t.Fatal("time of the secret change must be set when the key is created")
+	}
+
+	created := *token.SecretChanged
+
+	// renaming the key does not change the secret
+	token.Name = "API token"
+	if err = store.UpdateAccessKey(token); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err = store.GetAccessKey(project.ID, token.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token.SecretChanged == nil || !token.SecretChanged.Equal(created) {
+		t.Fatal("time of the secret change must be kept when the key is renamed")
+	}
+
+	keys, err := GetOverdueAccessKeys(store, project, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 0 {
+		t.Fatal("new keys must not be overdue", keys)
+	}
+
+	keys, err = GetOverdueAccessKeys(store, project, time.Now().AddDate(0, 0, 91))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 1 || keys[0].ID != token.ID {
+		t.Fatal("only the key with the secret must be overdue", keys, none.ID)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	token.String = "rotated"
+	token.OverrideSecret = true
+	if err = store.UpdateAccessKey(token); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err = store.GetAccessKey(project.ID, token.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !token.SecretChanged.After(created) {
+		t.Fatal("time of the secret change must be updated when the secret is changed")
+	}
+
+	project.KeyRotationDays = 0
+	keys, err = GetOverdueAccessKeys(store, project, time.Now().AddDate(1, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 0 {
+		t.Fatal("keys must not be overdue if the project has no rotation policy", keys)
+	}
+}
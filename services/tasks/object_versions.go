@@ -0,0 +1,61 @@
+package tasks
+
+import (
+	"errors"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// getObjectRefs returns the inventory, the environments and the access keys used by the task.
+func (t *TaskRunner) getObjectRefs() (refs []db.TaskObjectRef, err error) {
+	addKey := func(keyID *int) {
+		if keyID != nil {
+			refs = append(refs, db.TaskObjectRef{ObjectType: db.EventKey, ObjectID: *keyID})
+		}
+	}
+
+	if t.Inventory.ID != 0 {
+		refs = append(refs, db.TaskObjectRef{ObjectType: db.EventInventory, ObjectID: t.Inventory.ID})
+	}
+	addKey(t.Inventory.SSHKeyID)
+	addKey(t.Inventory.BecomeKeyID)
+	addKey(&t.Repository.SSHKeyID)
+
+	for _, vault := range t.Template.Vaults {
+		addKey(vault.VaultKeyID)
+	}
+
+	if t.Template.EnvironmentID != nil {
+		refs = append(refs, db.TaskObjectRef{ObjectType: db.EventEnvironment, ObjectID: *t.Template.EnvironmentID})
+	}
+
+	for _, groupID := range t.Template.VariableGroupIDs {
+		refs = append(refs, db.TaskObjectRef{ObjectType: db.EventEnvironment, ObjectID: groupID})
+	}
+
+	defaultEnvironment, err := t.pool.store.GetProjectDefaultEnvironment(t.Task.ProjectID)
+	if err == nil {
+		refs = append(refs, db.TaskObjectRef{ObjectType: db.EventEnvironment, ObjectID: defaultEnvironment.ID})
+	} else if errors.Is(err, db.ErrNotFound) {
+		err = nil
+	}
+
+	return
+}
+
+// recordObjectVersions stores versions of the objects used by the task, so changes made
+// while the task runs are detected. The task is not failed if versions can not be recorded.
+func (t *TaskRunner) recordObjectVersions() {
+	refs, err := t.getObjectRefs()
+	if err == nil {
+		var versions []db.TaskObjectVersion
+		versions, err = db.MakeTaskObjectVersions(t.pool.store, t.Task, refs)
+		if err == nil {
+			err = t.pool.store.CreateTaskObjectVersions(versions)
+		}
+	}
+
+	if err != nil {
+		t.Log("Can not record versions of the inventory, environments and keys: " + err.Error())
+	}
+}
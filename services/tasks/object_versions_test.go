@@ -0,0 +1,86 @@
+package tasks
+
+import (
+	"os"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestRecordObjectVersions(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	util.Config = &util.ConfigType{}
+
+	proj, err := store.CreateProject(db.Project{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := store.CreateAccessKey(db.AccessKey{ProjectID: &proj.ID, Name: "None", Type: db.AccessKeyNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inv, err := store.CreateInventory(db.Inventory{ProjectID: proj.ID, Name: "Production", Type: db.InventoryStatic, Inventory: "web1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env, err := store.CreateEnvironment(db.Environment{ProjectID: proj.ID, Name: "Env", JSON: `{"region": "us-east-1"}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := store.CreateTask(db.Task{ProjectID: proj.ID, Status: task_logger.TaskRunningStatus}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runner := &TaskRunner{
+		Task:       task,
+		Template:   db.Template{ProjectID: proj.ID, EnvironmentID: &env.ID},
+		Inventory:  inv,
+		Repository: db.Repository{SSHKeyID: key.ID},
+		pool:       &TaskPool{store: store},
+	}
+
+	runner.recordObjectVersions()
+
+	versions, err := store.GetTaskObjectVersions(proj.ID, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(versions) != 3 {
+		t.Fatalf("expected versions of the inventory, the key and the environment, got %v", versions)
+	}
+
+	tasks, err := db.GetRunningTasksUsingObject(store, proj.ID, db.TaskObjectRef{ObjectType: db.EventInventory, ObjectID: inv.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tasks) != 1 || tasks[0].ID != task.ID {
+		t.Fatalf("expected the running task to use the inventory, got %v", tasks)
+	}
+
+	inv.Inventory = "web2"
+	if err = store.UpdateInventory(inv); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = db.FillTaskObjectVersionsChanged(store, versions); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range versions {
+		if v.Changed != (v.ObjectType == db.EventInventory) {
+			t.Fatalf("expected only the inventory to be changed, got %v", versions)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package tasks
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// outputLimiter protects the database from tasks which print huge output.
+// Lines exceeding the limits are not stored, except the last TailLines lines
+// which are stored when the task finishes.
+type outputLimiter struct {
+	maxLines  int
+	maxBytes  int
+	tailLines int
+
+	mu        sync.Mutex
+	lines     int
+	bytes     int
+	truncated bool
+	// skipped is the number of lines which were not stored, including the tail.
+	skipped int
+	// tail contains the last skipped lines.
+	tail []logRecord
+}
+
+// newOutputLimiter returns nil if the output is not limited.
+func newOutputLimiter(cfg *util.TaskOutputConfig) *outputLimiter {
+	if cfg == nil || (cfg.MaxLines <= 0 && cfg.MaxBytes <= 0) {
+		return nil
+	}
+
+	return &outputLimiter{
+		maxLines:  cfg.MaxLines,
+		maxBytes:  cfg.MaxBytes,
+		tailLines: cfg.TailLines,
+	}
+}
+
+// getTaskOutputConfig applies the project quota of stored output to the task output limits.
+func getTaskOutputConfig() *util.TaskOutputConfig {
+	cfg := util.Config.TaskOutput
+	quotas := util.Config.Quotas
+
+	if quotas == nil || quotas.MaxOutputBytes <= 0 {
+		return cfg
+	}
+
+	res := util.TaskOutputConfig{}
+	if cfg != nil {
+		res = *cfg
+	}
+
+	if res.MaxBytes <= 0 || res.MaxBytes > quotas.MaxOutputBytes {
+		res.MaxBytes = quotas.MaxOutputBytes
+	}
+
+	return &res
+}
+
+func (l *outputLimiter) exceeds(output string) bool {
+	return (l.maxLines > 0 && l.lines+1 > l.maxLines) ||
+		(l.maxBytes > 0 && l.bytes+len(output) > l.maxBytes)
+}
+
+// add returns the records which must be stored for the new output line.
+func (l *outputLimiter) add(record logRecord) []logRecord {
+	if l == nil {
+		return []logRecord{record}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var res []logRecord
+
+	if !l.truncated {
+		if !l.exceeds(record.output) {
+			l.lines++
+			l.bytes += len(record.output)
+			return []logRecord{record}
+		}
+
+		l.truncated = true
+		res = append(res, logRecord{
+			task:   record.task,
+			output: "Output limit exceeded, the rest of the output is not stored",
+			time:   record.time,
+		})
+	}
+
+	l.skipped++
+
+	if l.tailLines > 0 {
+		l.tail = append(l.tail, record)
+		if len(l.tail) > l.tailLines {
+			l.tail = l.tail[1:]
+		}
+	}
+
+	return res
+}
+
+// flush returns the tail of the truncated output.
+func (l *outputLimiter) flush(task *TaskRunner, now time.Time) []logRecord {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.truncated || l.skipped == 0 {
+		return nil
+	}
+
+	if len(l.tail) > 0 {
+		now = l.tail[0].time
+	}
+
+	res := []logRecord{{
+		task:   task,
+		output: fmt.Sprintf("%d lines skipped, the last %d lines of the output:", l.skipped-len(l.tail), len(l.tail)),
+		time:   now,
+	}}
+
+	res = append(res, l.tail...)
+
+	l.skipped = 0
+	l.tail = nil
+
+	return res
+}
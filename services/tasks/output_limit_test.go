@@ -0,0 +1,62 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestOutputLimiter(t *testing.T) {
+	l := newOutputLimiter(&util.TaskOutputConfig{MaxLines: 2, TailLines: 2})
+
+	var stored []string
+	for _, line := range []string{"1", "2", "3", "4", "5", "6"} {
+		for _, r := range l.add(logRecord{output: line}) {
+			stored = append(stored, r.output)
+		}
+	}
+
+	for _, r := range l.flush(nil, time.Now()) {
+		stored = append(stored, r.output)
+	}
+
+	if len(stored) != 6 {
+		t.Fatalf("unexpected output: %v", stored)
+	}
+
+	if stored[0] != "1" || stored[1] != "2" || stored[4] != "5" || stored[5] != "6" {
+		t.Fatalf("unexpected output: %v", stored)
+	}
+
+	if !strings.HasPrefix(stored[3], "2 lines skipped") {
+		t.Fatal("unexpected skip marker: " + stored[3])
+	}
+}
+
+func TestOutputLimiterBytes(t *testing.T) {
+	l := newOutputLimiter(&util.TaskOutputConfig{MaxBytes: 10})
+
+	if len(l.add(logRecord{output: "12345"})) != 1 {
+		t.Fatal("line must be stored")
+	}
+
+	if len(l.add(logRecord{output: "123456"})) != 1 {
+		t.Fatal("truncation marker must be stored")
+	}
+
+	if len(l.add(logRecord{output: "1"})) != 0 {
+		t.Fatal("line must not be stored")
+	}
+
+	if len(l.flush(nil, time.Now())) != 1 {
+		t.Fatal("only skip marker must be stored")
+	}
+}
+
+func TestOutputLimiterDisabled(t *testing.T) {
+	if newOutputLimiter(&util.TaskOutputConfig{StripANSI: true}) != nil {
+		t.Fatal("limiter must be disabled")
+	}
+}
@@ -0,0 +1,183 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/objectstorage"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// getOutputStorage returns the client of the task output storage
+// or nil if output is stored in the database.
+func getOutputStorage() *objectstorage.Client {
+	cfg := util.Config.TaskOutputStorage
+	if cfg == nil || cfg.Bucket == "" {
+		return nil
+	}
+
+	return &objectstorage.Client{
+		Endpoint:        cfg.Endpoint,
+		Region:          cfg.Region,
+		Bucket:          cfg.Bucket,
+		AccessKeyID:     cfg.AccessKeyID,
+		SecretAccessKey: cfg.SecretAccessKey,
+		PathStyle:       cfg.PathStyle,
+	}
+}
+
+func getTaskOutputObjectKey(task db.Task) string {
+	prefix := ""
+	if util.Config.TaskOutputStorage != nil {
+		prefix = util.Config.TaskOutputStorage.Prefix
+	}
+	return fmt.Sprintf("%sproject_%d/task_%d.jsonl", prefix, task.ProjectID, task.ID)
+}
+
+// archiveTaskOutput moves the output of the finished task from the database
+// to the object storage. The output is stored as one object with a JSON record per line.
+func archiveTaskOutput(store db.Store, storage *objectstorage.Client, task db.Task) (key string, err error) {
+	output, err := store.GetTaskOutputs(task.ProjectID, task.ID)
+	if err != nil {
+		return
+	}
+
+	// the object is written to the temporary file first because the size must be known for uploading
+	file, err := os.CreateTemp(util.Config.TmpPath, "task_output_*.jsonl")
+	if err != nil {
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, record := range output {
+		if err = encoder.Encode(record); err != nil {
+			return
+		}
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return
+	}
+
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+
+	key = getTaskOutputObjectKey(task)
+
+	if err = storage.PutObject(key, file, size); err != nil {
+		return
+	}
+
+	err = store.SetTaskOutputObject(task.ProjectID, task.ID, key)
+	return
+}
+
+// archiveOutput is called when all output of the finished task is stored in the database.
+// If the output can not be moved, it stays in the database.
+func (t *TaskRunner) archiveOutput() {
+	storage := getOutputStorage()
+	if storage == nil {
+		return
+	}
+
+	db.StoreSession(t.pool.store, "archive output", func() {
+		enabled, err := db.IsFeatureEnabled(t.pool.store, db.FeatureTaskOutputStorage, &t.Task.ProjectID)
+		if err != nil {
+			log.Error(err)
+			return
+		}
+
+		if !enabled {
+			return
+		}
+
+		key, err := archiveTaskOutput(t.pool.store, storage, t.Task)
+		if err != nil {
+			log.Error("Can not move output of the task " + strconv.Itoa(t.Task.ID) + " to the object storage: " + err.Error())
+			return
+		}
+		t.Task.OutputObject = &key
+	})
+}
+
+// GetTaskOutputs returns the output of the task from the database or the object storage.
+func GetTaskOutputs(store db.Store, task db.Task) (output []db.TaskOutput, err error) {
+	if task.OutputObject == nil {
+		return store.GetTaskOutputs(task.ProjectID, task.ID)
+	}
+
+	storage := getOutputStorage()
+	if storage == nil {
+		err = fmt.Errorf("output of the task is in the object storage which is not configured")
+		return
+	}
+
+	body, err := storage.GetObject(*task.OutputObject)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	output = make([]db.TaskOutput, 0)
+	decoder := json.NewDecoder(body)
+
+	for {
+		var record db.TaskOutput
+		err = decoder.Decode(&record)
+		if err == io.EOF {
+			return output, nil
+		}
+		if err != nil {
+			return
+		}
+		output = append(output, record)
+	}
+}
+
+// DeleteTaskWithOutputs removes the task and its output from the database and the object storage.
+func DeleteTaskWithOutputs(store db.Store, task db.Task) error {
+	if task.OutputObject != nil {
+		if storage := getOutputStorage(); storage != nil {
+			if err := storage.DeleteObject(*task.OutputObject); err != nil {
+				return err
+			}
+		}
+	}
+
+	return store.DeleteTaskWithOutputs(task.ProjectID, task.ID)
+}
+
+// GetTaskOutputsFrom returns at most count output records of the task after offset records.
+func GetTaskOutputsFrom(store db.Store, task db.Task, offset int, count int) (output []db.TaskOutput, err error) {
+	if task.OutputObject == nil {
+		return store.GetTaskOutputsPage(task.ProjectID, task.ID, db.RetrieveQueryParams{
+			Offset: offset,
+			Count:  count,
+		})
+	}
+
+	output, err = GetTaskOutputs(store, task)
+	if err != nil {
+		return
+	}
+
+	if offset >= len(output) {
+		return []db.TaskOutput{}, nil
+	}
+
+	output = output[offset:]
+	if len(output) > count {
+		output = output[:count]
+	}
+
+	return
+}
@@ -0,0 +1,84 @@
+package tasks
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestArchiveTaskOutput(t *testing.T) {
+	objects := make(map[string][]byte)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT":
+			objects[r.URL.Path], _ = io.ReadAll(r.Body)
+		case "GET":
+			_, _ = w.Write(objects[r.URL.Path])
+		}
+	}))
+	defer server.Close()
+
+	store := bolt.CreateTestStore()
+
+	util.Config.TmpPath = t.TempDir()
+	util.Config.TaskOutputStorage = &util.ObjectStorageConfig{
+		Endpoint:  server.URL,
+		Bucket:    "semaphore",
+		PathStyle: true,
+	}
+	defer func() { util.Config.TaskOutputStorage = nil }()
+
+	task, err := store.CreateTask(db.Task{ProjectID: 1, TemplateID: 1}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{"first", "second"} {
+		if _, err = store.CreateTaskOutput(db.TaskOutput{TaskID: task.ID, Output: line, Time: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	key, err := archiveTaskOutput(store, getOutputStorage(), task)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := objects["/semaphore/"+key]; !ok {
+		t.Fatal("output must be uploaded to the storage")
+	}
+
+	task, err = store.GetTask(1, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if task.OutputObject == nil || *task.OutputObject != key {
+		t.Fatal("task must refer to the output object")
+	}
+
+	stored, err := store.GetTaskOutputs(1, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stored) != 0 {
+		t.Fatal("output must be removed from the database")
+	}
+
+	output, err := GetTaskOutputs(store, task)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(output) != 2 || output[0].Output != "first" || output[1].Output != "second" {
+		t.Fatal("output must be read from the storage", output)
+	}
+}
@@ -0,0 +1,131 @@
+package tasks
+
+import (
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	outputBatchSize     = 500
+	outputFlushInterval = time.Second
+
+	defaultCheckpointInterval = 5 * time.Minute
+)
+
+// getCheckpointInterval returns the period of output checkpoints of running tasks.
+func (p *TaskPool) getCheckpointInterval() time.Duration {
+	if p.CheckpointInterval > 0 {
+		return p.CheckpointInterval
+	}
+
+	if cfg := util.Config.TaskOutput; cfg != nil && cfg.CheckpointMinutes > 0 {
+		return time.Duration(cfg.CheckpointMinutes) * time.Minute
+	}
+
+	return defaultCheckpointInterval
+}
+
+// saveCheckpoint saves the number of stored output records of the task with its status,
+// so the clients which follow the output of the task can resume from this offset.
+func (t *TaskRunner) saveCheckpoint(now time.Time) {
+	t.Task.OutputOffset = t.storedOutput
+	t.Task.CheckpointAt = &now
+
+	if err := t.pool.store.UpdateTask(t.Task); err != nil {
+		log.WithError(err).WithField("task_id", t.Task.ID).Error("Failed to save output checkpoint of the task")
+	}
+}
+
+// writeOutput stores log records to the database by batches. The batch is flushed
+// when it is full or by the timer. While the batch is being stored, new records wait
+// in the logger channel, and when the channel is full, the tasks wait for the database.
+// Tasks which stored output since the previous checkpoint are checkpointed periodically.
+func (p *TaskPool) writeOutput() {
+	ticker := time.NewTicker(outputFlushInterval)
+	defer ticker.Stop()
+
+	checkpointTicker := time.NewTicker(p.getCheckpointInterval())
+	defer checkpointTicker.Stop()
+
+	batch := make([]db.TaskOutput, 0, outputBatchSize)
+
+	// batchRecords is the number of records of every task in the batch.
+	batchRecords := make(map[*TaskRunner]int)
+
+	// changed contains tasks which stored output since the previous checkpoint.
+	changed := make(map[*TaskRunner]struct{})
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		db.StoreSession(p.store, "logger", func() {
+			if err := p.store.CreateTaskOutputs(batch); err != nil {
+				log.Error(err)
+				return
+			}
+
+			for t, n := range batchRecords {
+				t.storedOutput += n
+				changed[t] = struct{}{}
+			}
+		})
+
+		batch = batch[:0]
+		clear(batchRecords)
+	}
+
+	checkpoint := func(now time.Time) {
+		// the buffered output is stored before the offsets are saved
+		flush()
+
+		if len(changed) == 0 {
+			return
+		}
+
+		db.StoreSession(p.store, "checkpoint", func() {
+			for t := range changed {
+				// finished tasks save their status themselves
+				if !t.Task.Status.IsFinished() {
+					t.saveCheckpoint(now)
+				}
+			}
+		})
+
+		clear(changed)
+	}
+
+	for {
+		select {
+		case record := <-p.logger: // new log message which should be put to database
+			if record.archive {
+				// all output of the task must be stored before archiving
+				flush()
+				delete(changed, record.task)
+				go record.task.archiveOutput()
+				break
+			}
+
+			batch = append(batch, db.TaskOutput{
+				TaskID: record.task.Task.ID,
+				Output: record.output,
+				Time:   record.time,
+			})
+			batchRecords[record.task]++
+
+			if len(batch) >= outputBatchSize {
+				flush()
+			}
+
+		case <-ticker.C:
+			flush()
+
+		case now := <-checkpointTicker.C:
+			checkpoint(now)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package tasks
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestWriteOutputCheckpointsRunningTasks(t *testing.T) {
+	util.Config = &util.ConfigType{}
+
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	pool := CreateTaskPool(store)
+	pool.CheckpointInterval = 50 * time.Millisecond
+
+	task, err := store.CreateTask(db.Task{ProjectID: 1, TemplateID: 1, Status: task_logger.TaskRunningStatus}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runner := &TaskRunner{Task: task, pool: &pool}
+
+	go pool.writeOutput()
+
+	for _, line := range []string{"first", "second", "third"} {
+		pool.logger <- logRecord{task: runner, output: line, time: time.Now()}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		task, err = store.GetTask(1, task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if task.CheckpointAt != nil {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("task must be checkpointed")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if task.OutputOffset != 3 {
+		t.Fatal("checkpoint must contain the number of stored records", task.OutputOffset)
+	}
+
+	if task.Status != task_logger.TaskRunningStatus {
+		t.Fatal("checkpoint must contain the status of the task", task.Status)
+	}
+
+	output, err := GetTaskOutputsFrom(store, task, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(output) != 1 || output[0].Output != "second" {
+		t.Fatal("invalid output after the offset", output)
+	}
+}
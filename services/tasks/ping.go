@@ -0,0 +1,162 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// pingTimeout is the connection timeout of ansible ping in seconds.
+const pingTimeout = 10
+
+type InventoryHostStatus string
+
+const (
+	InventoryHostReachable   InventoryHostStatus = "reachable"
+	InventoryHostUnreachable InventoryHostStatus = "unreachable"
+	InventoryHostFailed      InventoryHostStatus = "failed"
+)
+
+// InventoryHostPing is the result of the ping of the inventory host.
+type InventoryHostPing struct {
+	Host    string              `json:"host"`
+	Status  InventoryHostStatus `json:"status"`
+	Message string              `json:"message,omitempty"`
+}
+
+// InventoryPingResult contains results of ansible ping for each host of the inventory
+// and the output of ansible which explains the problem if no host was pinged.
+type InventoryPingResult struct {
+	Hosts  []InventoryHostPing `json:"hosts"`
+	Output []string            `json:"output"`
+}
+
+// pingLineRegexp matches lines of ansible --one-line output, e.g.
+// "web1 | SUCCESS => {...}" or "web2 | UNREACHABLE!: Failed to connect to the host via ssh".
+var pingLineRegexp = regexp.MustCompile(`^(\S+) \| ([A-Z]+!?)(.*)$`)
+
+// parsePingLine returns the result of the host or false if the line is not a host result.
+func parsePingLine(line string) (res InventoryHostPing, ok bool) {
+	m := pingLineRegexp.FindStringSubmatch(strings.TrimSpace(util.StripANSI(line)))
+	if m == nil {
+		return
+	}
+
+	res.Host = m[1]
+
+	switch m[2] {
+	case "SUCCESS", "CHANGED":
+		res.Status = InventoryHostReachable
+	case "UNREACHABLE!":
+		res.Status = InventoryHostUnreachable
+	case "FAILED!":
+		res.Status = InventoryHostFailed
+	default:
+		return
+	}
+
+	rest := m[3]
+
+	if strings.HasPrefix(rest, ": ") {
+		rest = strings.TrimPrefix(rest, ": ")
+		if i := strings.Index(rest, " => "); i >= 0 {
+			rest = rest[:i]
+		}
+		res.Message = strings.TrimSpace(rest)
+	} else if strings.HasPrefix(rest, " => ") && res.Status != InventoryHostReachable {
+		var details struct {
+			Msg string `json:"msg"`
+		}
+		if json.Unmarshal([]byte(strings.TrimPrefix(rest, " => ")), &details) == nil {
+			res.Message = details.Msg
+		}
+	}
+
+	return res, true
+}
+
+// pingLogger collects the output of ansible ping.
+type pingLogger struct {
+	lock  sync.Mutex
+	lines []string
+}
+
+func (l *pingLogger) Log(msg string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.lines = append(l.lines, msg)
+}
+
+func (l *pingLogger) Logf(format string, a ...any) {
+	l.Log(fmt.Sprintf(format, a...))
+}
+
+func (l *pingLogger) LogWithTime(_ time.Time, msg string) {
+	l.Log(msg)
+}
+
+func (l *pingLogger) LogfWithTime(_ time.Time, format string, a ...any) {
+	l.Logf(format, a...)
+}
+
+func (l *pingLogger) LogCmd(cmd *exec.Cmd) {
+	cmd.Stdout = &lineWriter{log: l.Log}
+	cmd.Stderr = &lineWriter{log: l.Log}
+}
+
+func (l *pingLogger) SetStatus(task_logger.TaskStatus) {}
+
+func (l *pingLogger) AddStatusListener(task_logger.StatusListener) {}
+
+func (l *pingLogger) AddLogListener(task_logger.LogListener) {}
+
+// PingInventory runs the Ansible ping module against the hosts of the inventory matching the limit.
+// keyID overrides the SSH key of the inventory if it is not nil.
+func PingInventory(store db.Store, projectID int, inventoryID int, keyID *int, limit string) (res InventoryPingResult, err error) {
+	inventory, err := getAdhocInventory(store, projectID, inventoryID, keyID)
+	if err != nil {
+		return
+	}
+
+	if limit == "" {
+		limit = "all"
+	}
+
+	logger := &pingLogger{}
+
+	job := LocalJob{
+		Inventory: inventory,
+		Logger:    logger,
+		tmpName:   "ping_" + strconv.Itoa(inventoryID) + "_" + util.RandString(8),
+	}
+
+	runErr := runAnsibleModule(&job, limit, []string{"-m", "ping", "--one-line", "-T", strconv.Itoa(pingTimeout)})
+
+	res.Hosts = make([]InventoryHostPing, 0)
+	res.Output = make([]string, 0, len(logger.lines))
+
+	for _, line := range logger.lines {
+		res.Output = append(res.Output, util.StripANSI(line))
+		if host, ok := parsePingLine(line); ok {
+			res.Hosts = append(res.Hosts, host)
+		}
+	}
+
+	// ansible fails if some hosts are unreachable, it is a valid result of the ping
+	if len(res.Hosts) == 0 && runErr != nil {
+		if _, ok := runErr.(*exec.ExitError); !ok {
+			err = runErr
+		}
+	}
+
+	return
+}
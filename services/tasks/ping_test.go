@@ -0,0 +1,67 @@
+package tasks
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestPingInventory(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	util.Config.TmpPath = t.TempDir()
+
+	// fake ansible prints results in the one-line format and fails like ansible does for unreachable hosts
+	binPath := t.TempDir()
+	script := `#!/bin/sh
+echo 'web1 | SUCCESS => {"changed": false, "ping": "pong"}'
+echo 'web2 | UNREACHABLE!: Failed to connect to the host via ssh: Connection refused'
+echo 'web3 | FAILED! => {"changed": false, "msg": "Missing sudo password"}'
+exit 4
+`
+	if err := os.WriteFile(path.Join(binPath, "ansible"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binPath+":"+os.Getenv("PATH"))
+
+	project, err := store.CreateProject(db.Project{Name: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inventory, err := store.CreateInventory(db.Inventory{
+		ProjectID: project.ID,
+		Name:      "Web",
+		Type:      db.InventoryStatic,
+		Inventory: "web1\nweb2\nweb3",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := PingInventory(store, project.ID, inventory.ID, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []InventoryHostPing{
+		{Host: "web1", Status: InventoryHostReachable},
+		{Host: "web2", Status: InventoryHostUnreachable, Message: "Failed to connect to the host via ssh: Connection refused"},
+		{Host: "web3", Status: InventoryHostFailed, Message: "Missing sudo password"},
+	}
+
+	if len(res.Hosts) != len(expected) {
+		t.Fatalf("expected %d hosts, got %v", len(expected), res.Hosts)
+	}
+
+	for i, h := range expected {
+		if res.Hosts[i] != h {
+			t.Fatalf("expected %v, got %v", h, res.Hosts[i])
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package tasks
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/semaphoreui/semaphore/api/sockets"
+	"github.com/semaphoreui/semaphore/api/sse"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// taskHeaderRegexp matches headers of tasks in ansible-playbook output, e.g.
+// "TASK [nginx : Start service] ***********".
+var taskHeaderRegexp = regexp.MustCompile(`^TASK \[(.+)\]`)
+
+// gatheringFactsTask is run implicitly and is not listed by ansible-playbook --list-tasks.
+const gatheringFactsTask = "Gathering Facts"
+
+// TaskProgressLogger is implemented by loggers which can track the progress of the run.
+type TaskProgressLogger interface {
+	SetTasksTotal(total int)
+}
+
+// taskProgress counts tasks of ansible-playbook output completed by the run.
+// A task is completed when the next task or the PLAY RECAP starts.
+type taskProgress struct {
+	lock     sync.Mutex
+	started  int
+	finished bool
+	onChange func(done int)
+}
+
+func newTaskProgress(onChange func(done int)) *taskProgress {
+	return &taskProgress{onChange: onChange}
+}
+
+// parseLine is the log listener of the task which counts started tasks.
+func (p *taskProgress) parseLine(_ time.Time, line string) {
+	line = strings.TrimSpace(util.StripANSI(line))
+
+	p.lock.Lock()
+
+	if p.finished {
+		p.lock.Unlock()
+		return
+	}
+
+	if strings.HasPrefix(line, "PLAY RECAP") {
+		p.finished = true
+	} else if m := taskHeaderRegexp.FindStringSubmatch(line); m != nil && m[1] != gatheringFactsTask {
+		p.started++
+	} else {
+		p.lock.Unlock()
+		return
+	}
+
+	done := p.done()
+	p.lock.Unlock()
+
+	p.onChange(done)
+}
+
+func (p *taskProgress) done() int {
+	if p.finished || p.started == 0 {
+		return p.started
+	}
+	return p.started - 1
+}
+
+// SetTasksTotal stores the number of tasks listed before the run.
+func (t *TaskRunner) SetTasksTotal(total int) {
+	t.progressLock.Lock()
+	t.Task.TasksTotal = total
+	t.progressLock.Unlock()
+
+	t.saveProgress()
+}
+
+// setTasksDone updates the progress of the run if the number of tasks is known.
+// The number of done tasks can exceed the total because of dynamic includes.
+func (t *TaskRunner) setTasksDone(done int) {
+	t.progressLock.Lock()
+
+	if t.Task.TasksTotal == 0 || t.Task.TasksDone == min(done, t.Task.TasksTotal) {
+		t.progressLock.Unlock()
+		return
+	}
+
+	t.Task.TasksDone = min(done, t.Task.TasksTotal)
+	t.progressLock.Unlock()
+
+	t.saveProgress()
+}
+
+// saveProgress stores the progress and sends it to the clients.
+func (t *TaskRunner) saveProgress() {
+	t.progressLock.Lock()
+	done, total, progress := t.Task.TasksDone, t.Task.TasksTotal, t.Task.GetProgress()
+	t.progressLock.Unlock()
+
+	for _, user := range t.users {
+		b, err := json.Marshal(&map[string]interface{}{
+			"type":        "progress",
+			"task_id":     t.Task.ID,
+			"project_id":  t.Task.ProjectID,
+			"tasks_total": total,
+			"tasks_done":  done,
+			"progress":    progress,
+		})
+
+		util.LogPanic(err)
+
+		sockets.Message(user, b)
+	}
+
+	if err := t.pool.store.UpdateTask(t.Task); err != nil {
+		util.LogErrorWithFields(err, log.Fields{"error": "Failed to store progress of the task"})
+	}
+
+	sse.Publish(t.Task.ProjectID, sse.EventTaskProgress, map[string]interface{}{
+		"task_id":     t.Task.ID,
+		"template_id": t.Task.TemplateID,
+		"tasks_total": total,
+		"tasks_done":  done,
+		"progress":    progress,
+	})
+}
@@ -0,0 +1,31 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTaskProgress(t *testing.T) {
+	var done []int
+	progress := newTaskProgress(func(d int) {
+		done = append(done, d)
+	})
+
+	for _, line := range []string{
+		"PLAY [web] *********************************************************************",
+		"TASK [Gathering Facts] *********************************************************",
+		"ok: [web1]",
+		"\x1b[0;32mTASK [Install nginx] ***\x1b[0m",
+		"changed: [web1]",
+		"TASK [nginx : Start service] ***************************************************",
+		"ok: [web1]",
+		"PLAY RECAP *********************************************************************",
+		"TASK [Not a task after recap]",
+	} {
+		progress.parseLine(time.Now(), line)
+	}
+
+	if len(done) != 3 || done[0] != 0 || done[1] != 1 || done[2] != 2 {
+		t.Fatalf("unexpected progress %v", done)
+	}
+}
@@ -0,0 +1,136 @@
+package tasks
+
+import (
+	"errors"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// ansibleUnreachableExitCode is the exit code of ansible-playbook
+// when some hosts are unreachable and no task failed.
+const ansibleUnreachableExitCode = 4
+
+// recapLineRegexp matches host lines of the PLAY RECAP section of ansible-playbook output, e.g.
+// "web1   : ok=3    changed=1    unreachable=0    failed=0    skipped=0 ...".
+var recapLineRegexp = regexp.MustCompile(`^(\S+)\s+:\s+ok=(\d+)\s+changed=(\d+)\s+unreachable=(\d+)\s+failed=(\d+)`)
+
+type recapHost struct {
+	changed     bool
+	unreachable bool
+	failed      bool
+}
+
+// playRecap collects results of the hosts from the PLAY RECAP of ansible-playbook output.
+type playRecap struct {
+	lock  sync.Mutex
+	hosts map[string]recapHost
+}
+
+func newPlayRecap() *playRecap {
+	return &playRecap{hosts: make(map[string]recapHost)}
+}
+
+// parseLine is the log listener of the task which remembers host lines of the recap.
+// A playbook can contain several plays, the last line of the host wins.
+func (r *playRecap) parseLine(_ time.Time, line string) {
+	m := recapLineRegexp.FindStringSubmatch(strings.TrimSpace(util.StripANSI(line)))
+	if m == nil {
+		return
+	}
+
+	changed, _ := strconv.Atoi(m[3])
+	unreachable, _ := strconv.Atoi(m[4])
+	failed, _ := strconv.Atoi(m[5])
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.hosts[m[1]] = recapHost{
+		changed:     changed > 0,
+		unreachable: unreachable > 0,
+		failed:      failed > 0,
+	}
+}
+
+// counts returns the number of hosts in the recap, unreachable hosts
+// and hosts with failed tasks.
+func (r *playRecap) counts() (total int, unreachable int, failed int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, h := range r.hosts {
+		total++
+		if h.unreachable {
+			unreachable++
+		}
+		if h.failed {
+			failed++
+		}
+	}
+
+	return
+}
+
+// changedAndFailed returns the number of hosts with changes and hosts with failed tasks.
+func (r *playRecap) changedAndFailed() (changed int, failed int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, h := range r.hosts {
+		if h.changed {
+			changed++
+		}
+		if h.failed {
+			failed++
+		}
+	}
+
+	return
+}
+
+// results returns the result of each host in the recap.
+func (r *playRecap) results() map[string]db.TaskHostStatus {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	res := make(map[string]db.TaskHostStatus, len(r.hosts))
+
+	for name, h := range r.hosts {
+		switch {
+		case h.unreachable:
+			res[name] = db.TaskHostUnreachable
+		case h.failed:
+			res[name] = db.TaskHostFailed
+		case h.changed:
+			res[name] = db.TaskHostChanged
+		default:
+			res[name] = db.TaskHostOK
+		}
+	}
+
+	return res
+}
+
+// isPartial returns true if the playbook succeeded on all reachable hosts but some
+// hosts were unreachable. runErr is the error returned by the job.
+func (r *playRecap) isPartial(runErr error) bool {
+	total, unreachable, failed := r.counts()
+
+	if unreachable == 0 || failed > 0 || unreachable == total {
+		return false
+	}
+
+	if runErr == nil {
+		return true
+	}
+
+	var exitErr *exec.ExitError
+	return errors.As(runErr, &exitErr) && exitErr.ExitCode() == ansibleUnreachableExitCode
+}
@@ -0,0 +1,44 @@
+package tasks
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPlayRecap(t *testing.T) {
+	recap := newPlayRecap()
+
+	for _, line := range []string{
+		"PLAY RECAP *********************************************************************",
+		"\x1b[0;33mweb1\x1b[0m                       : \x1b[0;32mok=3   \x1b[0m changed=1    unreachable=0    failed=0    skipped=0    rescued=0    ignored=0",
+		"web2                       : ok=0    changed=0    \x1b[1;31munreachable=1   \x1b[0m failed=0    skipped=0    rescued=0    ignored=0",
+		"db1                        : ok=2    changed=0    unreachable=0    failed=0    skipped=1    rescued=0    ignored=0",
+		"ok: [web1] => (item=web2 : ok=1)",
+	} {
+		recap.parseLine(time.Now(), line)
+	}
+
+	total, unreachable, failed := recap.counts()
+	if total != 3 || unreachable != 1 || failed != 0 {
+		t.Fatalf("unexpected counts %d %d %d", total, unreachable, failed)
+	}
+
+	if changed, _ := recap.changedAndFailed(); changed != 1 {
+		t.Fatalf("unexpected number of changed hosts %d", changed)
+	}
+
+	if !recap.isPartial(nil) {
+		t.Fatal("task with unreachable host must be partial")
+	}
+
+	if recap.isPartial(errors.New("failed to clone repository")) {
+		t.Fatal("task failed by other error must not be partial")
+	}
+
+	recap.parseLine(time.Now(), "db1                        : ok=1    changed=0    unreachable=0    failed=1    skipped=0")
+
+	if recap.isPartial(nil) {
+		t.Fatal("task with failed host must not be partial")
+	}
+}
@@ -0,0 +1,77 @@
+package tasks
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+// getTaskOrigin returns the description of the task origin which is used in the task log.
+func getTaskOrigin(task db.Task) string {
+	if task.ScheduleID != nil {
+		return "scheduled"
+	}
+	return "manual"
+}
+
+// getRunConflicts returns unfinished tasks of the template which conflict with the new task.
+// A scheduled task conflicts with manual runs and a manual run conflicts with scheduled tasks.
+func getRunConflicts(store db.Store, task db.Task) (conflicts []db.Task, err error) {
+	unfinished, err := store.GetUnfinishedTasks()
+	if err != nil {
+		return
+	}
+
+	for _, other := range unfinished {
+		if other.ID == task.ID ||
+			other.ProjectID != task.ProjectID ||
+			other.TemplateID != task.TemplateID ||
+			(other.ScheduleID != nil) == (task.ScheduleID != nil) {
+			continue
+		}
+
+		conflicts = append(conflicts, other)
+	}
+
+	return
+}
+
+// applyRunConflictPolicy applies the run conflict policy of the template to the new task
+// and writes the decision to the task log. It returns true if the new task is skipped.
+func (p *TaskPool) applyRunConflictPolicy(t *TaskRunner) (skipped bool, err error) {
+	conflicts, err := getRunConflicts(p.store, t.Task)
+	if err != nil || len(conflicts) == 0 {
+		return
+	}
+
+	origin := getTaskOrigin(t.Task)
+
+	switch t.Template.RunConflictPolicy {
+	case db.RunConflictSkip:
+		t.Logf("Run conflict: %s task #%d of the template is active, the %s task is skipped",
+			getTaskOrigin(conflicts[0]), conflicts[0].ID, origin)
+		t.SetStatus(task_logger.TaskStoppedStatus)
+		t.createTaskEvent()
+		skipped = true
+
+	case db.RunConflictCancelOlder:
+		for _, older := range conflicts {
+			if runner := p.GetTask(older.ID); runner != nil {
+				runner.Logf("Run conflict: the task is cancelled by %s task #%d", origin, t.Task.ID)
+			}
+
+			err = p.StopTask(older, older.Status != task_logger.TaskRunningStatus)
+			if err != nil {
+				return
+			}
+
+			t.Logf("Run conflict: %s task #%d of the template is cancelled", getTaskOrigin(older), older.ID)
+		}
+
+	default:
+		for _, other := range conflicts {
+			t.Logf("Run conflict: the task waits for %s task #%d of the template", getTaskOrigin(other), other.ID)
+		}
+	}
+
+	return
+}
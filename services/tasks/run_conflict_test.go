@@ -0,0 +1,73 @@
+package tasks
+
+import (
+	"os"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestApplyRunConflictPolicy(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	util.Config = &util.ConfigType{}
+
+	pool := CreateTaskPool(store)
+
+	scheduleID := 1
+
+	var created []db.Task
+
+	for _, task := range []db.Task{
+		{ProjectID: 1, TemplateID: 1, Status: task_logger.TaskRunningStatus, ScheduleID: &scheduleID},
+		{ProjectID: 1, TemplateID: 1, Status: task_logger.TaskWaitingStatus},
+		{ProjectID: 1, TemplateID: 2, Status: task_logger.TaskRunningStatus, ScheduleID: &scheduleID},
+		{ProjectID: 1, TemplateID: 1, Status: task_logger.TaskSuccessStatus, ScheduleID: &scheduleID},
+	} {
+		newTask, err := store.CreateTask(task, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		created = append(created, newTask)
+	}
+
+	conflicts, err := getRunConflicts(store, created[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(conflicts) != 1 || conflicts[0].ID != created[0].ID {
+		t.Fatalf("expected the conflict with the scheduled task %d, got %v", created[0].ID, conflicts)
+	}
+
+	manual := &TaskRunner{
+		Task:     created[1],
+		Template: db.Template{ID: 1, ProjectID: 1, RunConflictPolicy: db.RunConflictSkip},
+		pool:     &pool,
+	}
+
+	skipped, err := pool.applyRunConflictPolicy(manual)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !skipped || manual.Task.Status != task_logger.TaskStoppedStatus {
+		t.Fatalf("expected the manual run to be skipped, got status %s", manual.Task.Status)
+	}
+
+	manual.Template.RunConflictPolicy = db.RunConflictQueue
+	manual.Task.Status = task_logger.TaskWaitingStatus
+
+	skipped, err = pool.applyRunConflictPolicy(manual)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if skipped {
+		t.Fatal("the queued task must not be skipped")
+	}
+}
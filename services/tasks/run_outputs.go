@@ -0,0 +1,53 @@
+package tasks
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// resolveRunOutputs replaces references to outputs of the build task in extra vars
+// and environment variables of the task, e.g. "${outputs.ami_id}".
+// Remote runners receive the resolved environment of the runner.
+func (t *TaskRunner) resolveRunOutputs() error {
+	env := ""
+	if t.Environment.ENV != nil {
+		env = *t.Environment.ENV
+	}
+
+	if !db.HasRunOutputRefs(t.Environment.JSON) && !db.HasRunOutputRefs(env) {
+		return nil
+	}
+
+	if t.Task.BuildTaskID == nil {
+		return errors.New("extra vars reference outputs but the task has no build task")
+	}
+
+	outputs, err := t.pool.store.GetRunOutputs(t.Task.ProjectID, *t.Task.BuildTaskID)
+	if err != nil {
+		return err
+	}
+
+	t.Environment.JSON, err = db.ResolveRunOutputRefs(t.Environment.JSON, outputs)
+	if err != nil {
+		return err
+	}
+
+	if env != "" {
+		env, err = db.ResolveRunOutputEnvRefs(env, outputs)
+		if err != nil {
+			return err
+		}
+		t.Environment.ENV = &env
+	}
+
+	t.Log("Outputs of task " + strconv.Itoa(*t.Task.BuildTaskID) + " are substituted")
+
+	if localJob, ok := t.job.(*LocalJob); ok {
+		localJob.Environment.JSON = t.Environment.JSON
+		localJob.Environment.ENV = t.Environment.ENV
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package tasks
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+)
+
+func TestTaskRunnerResolveRunOutputs(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	pool := CreateTaskPool(store)
+
+	project, err := store.CreateProject(db.Project{Name: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	build, err := store.CreateTask(db.Task{ProjectID: project.ID, TemplateID: 1}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = store.CreateRunOutput(db.RunOutput{TaskID: build.ID, Name: "ami_id", Type: db.RunOutputString, Value: `"ami-123"`}); err != nil {
+		t.Fatal(err)
+	}
+
+	outputs, err := store.GetRunOutputs(project.ID, build.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(outputs) != 1 || outputs[0].Value != `"ami-123"` {
+		t.Fatal("invalid outputs of the build task", outputs)
+	}
+
+	env := `{"AMI": "${outputs.ami_id}"}`
+	task := db.Task{ID: 2, ProjectID: project.ID, BuildTaskID: &build.ID}
+	environment := db.Environment{JSON: `{"ami": "${outputs.ami_id}"}`, ENV: &env}
+	job := &LocalJob{Task: task, Environment: environment}
+
+	runner := &TaskRunner{
+		Task:        task,
+		Environment: environment,
+		pool:        &pool,
+		job:         job,
+	}
+
+	if err = runner.resolveRunOutputs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if job.Environment.JSON != `{"ami":"ami-123"}` || runner.Environment.JSON != job.Environment.JSON {
+		t.Fatal("invalid extra vars", job.Environment.JSON)
+	}
+
+	if *job.Environment.ENV != `{"AMI":"ami-123"}` {
+		t.Fatal("invalid environment variables", *job.Environment.ENV)
+	}
+
+	runner.Task.BuildTaskID = nil
+	runner.Environment.JSON = `{"ami": "${outputs.ami_id}"}`
+
+	if err = runner.resolveRunOutputs(); err == nil {
+		t.Fatal("task without build task must fail")
+	}
+}
+
+func TestLocalJobCollectRunOutputs(t *testing.T) {
+	dir := t.TempDir()
+	filename := path.Join(dir, "outputs")
+
+	logger := &testRunOutputsLogger{}
+	job := &LocalJob{Logger: logger}
+
+	if err := job.collectRunOutputs(filename); err != nil {
+		t.Fatal("missing file must be ignored", err)
+	}
+
+	if err := os.WriteFile(filename, []byte("ami_id=ami-123\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := job.collectRunOutputs(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logger.outputs) != 1 || logger.outputs[0].String() != "ami-123" {
+		t.Fatal("invalid outputs", logger.outputs)
+	}
+}
+
+type testRunOutputsLogger struct {
+	pingLogger
+	outputs []db.RunOutput
+}
+
+func (l *testRunOutputsLogger) SetRunOutputs(outputs []db.RunOutput) {
+	l.outputs = append(l.outputs, outputs...)
+}
@@ -0,0 +1,48 @@
+package tasks
+
+// SubscribeRunner returns the channel which receives a value when tasks of the runner
+// are assigned or change their status, so the stream of the runner sends them
+// without waiting for the heartbeat. The returned function unsubscribes the channel.
+func (p *TaskPool) SubscribeRunner(runnerID int) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	p.runnerListenersLock.Lock()
+	defer p.runnerListenersLock.Unlock()
+
+	if p.runnerListeners == nil {
+		p.runnerListeners = make(map[int][]chan struct{})
+	}
+
+	p.runnerListeners[runnerID] = append(p.runnerListeners[runnerID], ch)
+
+	return ch, func() {
+		p.runnerListenersLock.Lock()
+		defer p.runnerListenersLock.Unlock()
+
+		listeners := p.runnerListeners[runnerID]
+		for i, l := range listeners {
+			if l == ch {
+				p.runnerListeners[runnerID] = append(listeners[:i], listeners[i+1:]...)
+				break
+			}
+		}
+
+		if len(p.runnerListeners[runnerID]) == 0 {
+			delete(p.runnerListeners, runnerID)
+		}
+	}
+}
+
+// notifyRunner wakes up streams of the runner. Notifications are not queued,
+// the stream sends the current state of the runner.
+func (p *TaskPool) notifyRunner(runnerID int) {
+	p.runnerListenersLock.Lock()
+	defer p.runnerListenersLock.Unlock()
+
+	for _, ch := range p.runnerListeners[runnerID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
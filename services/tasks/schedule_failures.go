@@ -0,0 +1,64 @@
+package tasks
+
+import (
+	"fmt"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/i18n"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+func scheduleDisabledAlert(project db.Project, schedule db.Schedule) ProjectAlert {
+	return ProjectAlert{
+		Subject: i18n.Msg("Schedule '%s' disabled", schedule.Name),
+		Text: i18n.Msg("Project %s: schedule '%s' was disabled after %d consecutive failed runs",
+			project.Name,
+			schedule.Name,
+			schedule.ConsecutiveFailures),
+		URL: fmt.Sprintf("%s/project/%d/templates/%d", util.Config.WebHost, project.ID, schedule.TemplateID),
+	}
+}
+
+// recordScheduleRun counts consecutive failures of the schedule which started the task
+// and disables the schedule when the failure limit of the schedule is reached.
+// Stopped tasks are neither failures nor successes of the schedule.
+func (t *TaskRunner) recordScheduleRun() {
+	if t.Task.ScheduleID == nil || t.Task.Status == task_logger.TaskStoppedStatus {
+		return
+	}
+
+	schedule, err := t.pool.store.GetSchedule(t.Task.ProjectID, *t.Task.ScheduleID)
+	if err != nil {
+		// the schedule can be deleted while the task is running
+		return
+	}
+
+	failures := schedule.ConsecutiveFailures
+	disabled := schedule.RecordRun(t.Task.Status == task_logger.TaskFailStatus)
+
+	if schedule.ConsecutiveFailures == failures && !disabled {
+		return
+	}
+
+	err = t.pool.store.SetScheduleFailures(schedule.ProjectID, schedule.ID, schedule.ConsecutiveFailures, disabled)
+	if err != nil {
+		util.LogErrorWithFields(err, log.Fields{"error": "Failed to store failures of the schedule"})
+		return
+	}
+
+	if !disabled {
+		return
+	}
+
+	t.Logf("Schedule '%s' is disabled after %d consecutive failed runs", schedule.Name, schedule.ConsecutiveFailures)
+
+	project, err := t.pool.store.GetProject(schedule.ProjectID)
+	if err != nil {
+		util.LogError(err)
+		return
+	}
+
+	SendProjectAlert(t.pool.store, project, scheduleDisabledAlert(project, schedule))
+}
@@ -0,0 +1,102 @@
+package tasks
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// interactiveBecomeTTL is the time during which the become password entered
+// at the launch can be used. The task fails if it does not start in this time.
+const interactiveBecomeTTL = time.Hour
+
+var (
+	sealKeyOnce sync.Once
+	sealGCM     cipher.AEAD
+	sealKeyErr  error
+)
+
+// getSealGCM returns the cipher of the key which is generated at the start
+// of the process and exists in its memory only.
+func getSealGCM() (cipher.AEAD, error) {
+	sealKeyOnce.Do(func() {
+		key := make([]byte, 32)
+		if _, sealKeyErr = rand.Read(key); sealKeyErr != nil {
+			return
+		}
+
+		var block cipher.Block
+		if block, sealKeyErr = aes.NewCipher(key); sealKeyErr != nil {
+			return
+		}
+
+		sealGCM, sealKeyErr = cipher.NewGCM(block)
+	})
+
+	return sealGCM, sealKeyErr
+}
+
+// sealedSecret is the secret of the run, e.g. the become password entered at the launch.
+// It is kept encrypted in memory until the run and is destroyed after the run.
+type sealedSecret struct {
+	mu      sync.Mutex
+	data    []byte
+	expires time.Time
+}
+
+func sealSecret(secret string, ttl time.Duration) (*sealedSecret, error) {
+	gcm, err := getSealGCM()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return &sealedSecret{
+		data:    gcm.Seal(nonce, nonce, []byte(secret), nil),
+		expires: time.Now().Add(ttl),
+	}, nil
+}
+
+// open returns the secret if it is neither expired nor destroyed.
+func (s *sealedSecret) open() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data == nil {
+		return "", fmt.Errorf("secret is destroyed")
+	}
+
+	if time.Now().After(s.expires) {
+		return "", fmt.Errorf("secret is expired")
+	}
+
+	gcm, err := getSealGCM()
+	if err != nil {
+		return "", err
+	}
+
+	nonceSize := gcm.NonceSize()
+	plaintext, err := gcm.Open(nil, s.data[:nonceSize], s.data[nonceSize:], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+func (s *sealedSecret) destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data {
+		s.data[i] = 0
+	}
+	s.data = nil
+}
@@ -0,0 +1,74 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestSealedSecret(t *testing.T) {
+	s, err := sealSecret("p@ss", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(s.data), "p@ss") {
+		t.Fatal("secret must be encrypted in memory")
+	}
+
+	secret, err := s.open()
+	if err != nil || secret != "p@ss" {
+		t.Fatalf("expected p@ss, got %q, %v", secret, err)
+	}
+
+	s.destroy()
+	if _, err = s.open(); err == nil {
+		t.Fatal("destroyed secret must not be opened")
+	}
+
+	expired, err := sealSecret("p@ss", -time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = expired.open(); err == nil {
+		t.Fatal("expired secret must not be opened")
+	}
+}
+
+func TestInteractiveBecomeArgs(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: "/tmp/semaphore",
+	}
+
+	password, err := sealSecret("p@ss", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job := LocalJob{
+		Task:           db.Task{ID: 5, InteractiveBecome: true},
+		Inventory:      db.Inventory{Type: db.InventoryStatic},
+		BecomePassword: password,
+	}
+
+	args, inputs, err := job.getInventoryArgs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(strings.Join(args, " "), "--ask-become-pass") {
+		t.Fatalf("expected --ask-become-pass, got %v", args)
+	}
+
+	if inputs["BECOME password"] != "p@ss" {
+		t.Fatalf("expected become password input, got %v", inputs)
+	}
+
+	job.BecomePassword = nil
+	if _, _, err = job.getInventoryArgs(); err == nil {
+		t.Fatal("task must fail if the become password is not available")
+	}
+}
@@ -0,0 +1,283 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/semaphoreui/semaphore/db_lib"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+var errNoSSHWorkers = errors.New("no SSH workers available")
+
+// sshWorkerEnvSkipped are variables which are not passed to the worker: PATH of the server
+// differs from PATH of the worker, the worker sets PWD itself and forwards the SSH agent.
+var sshWorkerEnvSkipped = map[string]bool{
+	"PATH":          true,
+	"PWD":           true,
+	"SSH_AUTH_SOCK": true,
+}
+
+var sshWorkerTasks = struct {
+	sync.Mutex
+	// running is the number of tasks running on the worker, the key is the index of the worker in the config.
+	running map[int]int
+}{running: make(map[int]int)}
+
+func isSSHWorkersEnabled() bool {
+	return len(util.Config.SSHWorkers) > 0
+}
+
+// acquireSSHWorker returns the worker with the least number of running tasks
+// which can run one more task. The returned function releases the worker.
+func acquireSSHWorker() (worker util.SSHWorkerConfig, release func(), err error) {
+	sshWorkerTasks.Lock()
+	defer sshWorkerTasks.Unlock()
+
+	index := -1
+
+	for i, w := range util.Config.SSHWorkers {
+		n := sshWorkerTasks.running[i]
+		if w.MaxParallelTasks > 0 && n >= w.MaxParallelTasks {
+			continue
+		}
+		if index < 0 || n < sshWorkerTasks.running[index] {
+			index = i
+		}
+	}
+
+	if index < 0 {
+		err = errNoSSHWorkers
+		return
+	}
+
+	sshWorkerTasks.running[index]++
+	worker = util.Config.SSHWorkers[index]
+
+	var once sync.Once
+	release = func() {
+		once.Do(func() {
+			sshWorkerTasks.Lock()
+			defer sshWorkerTasks.Unlock()
+			sshWorkerTasks.running[index]--
+		})
+	}
+
+	return
+}
+
+// sshWorkerSession runs commands of the job on the worker. Files of the job are copied
+// to the same paths on the worker, so arguments of commands are not changed.
+type sshWorkerSession struct {
+	worker util.SSHWorkerConfig
+	// agentSocket is the SSH agent of the job which is forwarded to the worker.
+	agentSocket string
+	// envFile is the file with environment variables of the command, it is removed by the command.
+	envFile string
+	// paths are the workspace of the job copied to the worker.
+	paths []string
+	// tmpPaths are temporary files of the job removed from the worker when the job is finished.
+	tmpPaths []string
+	release  func()
+}
+
+func (s *sshWorkerSession) getDestination() string {
+	if s.worker.User != "" {
+		return s.worker.User + "@" + s.worker.Host
+	}
+	return s.worker.Host
+}
+
+// getSSHArgs returns arguments of ssh connecting to the worker without the destination.
+func (s *sshWorkerSession) getSSHArgs() (args []string) {
+	args = append(args, "-o", "BatchMode=yes")
+
+	if s.worker.Port > 0 {
+		args = append(args, "-p", strconv.Itoa(s.worker.Port))
+	}
+
+	if s.worker.KeyFile != "" {
+		args = append(args, "-i", s.worker.KeyFile)
+	}
+
+	for _, option := range s.worker.Options {
+		args = append(args, "-o", option)
+	}
+
+	if s.agentSocket != "" {
+		args = append(args, "-A")
+	}
+
+	return
+}
+
+func (s *sshWorkerSession) getSSHEnv() []string {
+	env := util.AllowedEnvironmentVars()
+	if s.agentSocket != "" {
+		env = append(env, "SSH_AUTH_SOCK="+s.agentSocket)
+	}
+	return env
+}
+
+func (s *sshWorkerSession) makeSSHCmd(tty bool, command string) *exec.Cmd {
+	args := s.getSSHArgs()
+	if tty {
+		args = append(args, "-tt")
+	}
+
+	cmd := exec.Command("ssh", append(args, s.getDestination(), command)...) //nolint: gas
+	cmd.Env = s.getSSHEnv()
+	return cmd
+}
+
+func (s *sshWorkerSession) makeRsyncCmd(args ...string) *exec.Cmd {
+	ssh := []string{"ssh"}
+	for _, arg := range s.getSSHArgs() {
+		ssh = append(ssh, quotePullScriptArg(arg))
+	}
+
+	cmd := exec.Command("rsync", append([]string{"-az", "-e", strings.Join(ssh, " ")}, args...)...) //nolint: gas
+	cmd.Env = s.getSSHEnv()
+	return cmd
+}
+
+// upload copies paths to the same paths on the worker. Files removed on the server are removed on the worker.
+func (s *sshWorkerSession) upload(paths []string) error {
+	args := append([]string{"--delete", "--relative"}, paths...)
+	return runSSHWorkerCmd(s.makeRsyncCmd(append(args, s.getDestination()+":/")...))
+}
+
+func runSSHWorkerCmd(cmd *exec.Cmd) error {
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", cmd.Args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// download copies the file from the worker to the server. Missing files are ignored.
+func (s *sshWorkerSession) download(filename string) error {
+	return runSSHWorkerCmd(s.makeRsyncCmd("--ignore-missing-args", s.getDestination()+":"+filename, filename))
+}
+
+// finish removes temporary files of the job from the worker and releases the worker.
+// Repositories are kept, so next tasks copy only changes.
+func (s *sshWorkerSession) finish() {
+	defer s.release()
+
+	args := []string{"rm", "-rf"}
+	for _, p := range s.tmpPaths {
+		args = append(args, quotePullScriptArg(p))
+	}
+
+	if err := runSSHWorkerCmd(s.makeSSHCmd(false, strings.Join(args, " "))); err != nil {
+		log.Error("Can't remove temporary files from SSH worker " + s.worker.Host + ": " + err.Error())
+	}
+}
+
+// renderSSHWorkerEnv returns the shell script which exports environment variables.
+func renderSSHWorkerEnv(env []string) string {
+	var b strings.Builder
+
+	for _, v := range env {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok || name == "" || sshWorkerEnvSkipped[name] {
+			continue
+		}
+		b.WriteString("export " + name + "=" + quotePullScriptArg(value) + "\n")
+	}
+
+	return b.String()
+}
+
+// makeSSHWorkerScript returns the remote command which loads and removes the environment file,
+// then runs the command in the directory.
+func makeSSHWorkerScript(envFile string, dir string, args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, quotePullScriptArg(arg))
+	}
+
+	script := ". " + quotePullScriptArg(envFile) + " && rm -f " + quotePullScriptArg(envFile)
+	if dir != "" {
+		script += " && cd " + quotePullScriptArg(dir)
+	}
+
+	return script + " && exec " + strings.Join(quoted, " ")
+}
+
+// wrapCmd replaces the command with ssh which runs it on the worker. Environment variables
+// are copied in the file, so secrets are not visible in arguments of processes of the worker.
+// The terminal is allocated, so prompts can be answered and the command is stopped with ssh.
+func (s *sshWorkerSession) wrapCmd(cmd *exec.Cmd) *exec.Cmd {
+	sshCmd := s.makeSSHCmd(true, makeSSHWorkerScript(s.envFile, cmd.Dir, cmd.Args))
+
+	err := os.WriteFile(s.envFile, []byte(renderSSHWorkerEnv(cmd.Env)), 0600)
+	if err == nil {
+		err = s.upload([]string{s.envFile})
+	}
+
+	if e := os.Remove(s.envFile); e != nil && !os.IsNotExist(e) {
+		log.Error(e)
+	}
+
+	if err != nil {
+		sshCmd.Err = fmt.Errorf("failed to copy environment to SSH worker %s: %w", s.worker.Host, err)
+	}
+
+	return sshCmd
+}
+
+// startOnWorker copies the prepared workspace of the job to the worker
+// and makes the app run its commands there.
+func (t *LocalJob) startOnWorker() (session *sshWorkerSession, err error) {
+	wrapper, ok := t.App.(db_lib.LocalAppCmdWrapper)
+	if !ok {
+		err = fmt.Errorf("app %s can not be run by SSH workers", t.Template.App)
+		return
+	}
+
+	worker, release, err := acquireSSHWorker()
+	if err != nil {
+		return
+	}
+
+	session = &sshWorkerSession{
+		worker:  worker,
+		envFile: path.Join(util.Config.TmpPath, "ssh_worker_env_"+t.tmpFileSuffix()),
+		release: release,
+	}
+
+	if t.sshKeyInstallation.SSHAgent != nil {
+		session.agentSocket = t.sshKeyInstallation.SSHAgent.SocketFile
+	}
+
+	session.paths = []string{t.Repository.GetFullPath(t.Template.ID)}
+
+	for _, p := range []string{t.tmpInventoryFullPath(), t.tmpSSHConfigFullPath(), t.tmpSSHControlDir()} {
+		if _, e := os.Stat(p); e == nil {
+			session.paths = append(session.paths, p)
+			session.tmpPaths = append(session.tmpPaths, p)
+		}
+	}
+
+	session.tmpPaths = append(session.tmpPaths, session.envFile, t.runOutputFilename())
+
+	t.Log("Copying workspace to SSH worker " + worker.Host)
+
+	if err = session.upload(session.paths); err != nil {
+		release()
+		session = nil
+		return
+	}
+
+	wrapper.SetCmdWrapper(session.wrapCmd)
+	return
+}
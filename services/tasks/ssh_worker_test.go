@@ -0,0 +1,61 @@
+package tasks
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestRenderSSHWorkerEnv(t *testing.T) {
+	env := renderSSHWorkerEnv([]string{"PATH=/usr/bin", "HOME=/tmp/semaphore", "SECRET=it's", "SSH_AUTH_SOCK=/tmp/agent.sock"})
+
+	if env != "export HOME='/tmp/semaphore'\nexport SECRET='it'\\''s'\n" {
+		t.Fatalf("unexpected environment %q", env)
+	}
+
+	script := makeSSHWorkerScript("/tmp/semaphore/ssh_worker_env_1", "/tmp/semaphore/repository_1_1", []string{"ansible-playbook", "-e", "a b"})
+	expected := ". '/tmp/semaphore/ssh_worker_env_1' && rm -f '/tmp/semaphore/ssh_worker_env_1' && " +
+		"cd '/tmp/semaphore/repository_1_1' && exec 'ansible-playbook' '-e' 'a b'"
+
+	if script != expected {
+		t.Fatalf("unexpected script %q", script)
+	}
+}
+
+func TestAcquireSSHWorker(t *testing.T) {
+	util.Config = &util.ConfigType{
+		SSHWorkers: []util.SSHWorkerConfig{
+			{Host: "worker1", MaxParallelTasks: 1},
+			{Host: "worker2", MaxParallelTasks: 1},
+		},
+	}
+
+	worker1, release1, err := acquireSSHWorker()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	worker2, release2, err := acquireSSHWorker()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if worker1.Host == worker2.Host {
+		t.Fatalf("expected tasks to be assigned to different workers")
+	}
+
+	if _, _, err = acquireSSHWorker(); !errors.Is(err, errNoSSHWorkers) {
+		t.Fatalf("expected no available workers, got %v", err)
+	}
+
+	release1()
+	release1()
+	release2()
+
+	worker, release, err := acquireSSHWorker()
+	if err != nil || worker.Host != "worker1" {
+		t.Fatalf("expected the first worker to be released, got %v %v", worker, err)
+	}
+	release()
+}
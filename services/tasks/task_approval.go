@@ -0,0 +1,177 @@
+package tasks
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/i18n"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrTaskNotWaitingApproval is returned by the approval link of the task
+// which was already confirmed, stopped or finished.
+var ErrTaskNotWaitingApproval = &db.ValidationError{Message: "the task does not wait for approval"}
+
+// taskApprovalMail is the approval email of one approver.
+type taskApprovalMail struct {
+	user  db.User
+	alert ProjectAlert
+}
+
+// getTaskApprovalLink returns the link of the approval page which applies the decision.
+func getTaskApprovalLink(approval db.TaskApproval, token string, decision db.TaskApprovalDecision) string {
+	query := url.Values{}
+	query.Set("token", token)
+	query.Set("decision", string(decision))
+
+	return fmt.Sprintf("%s/api/project/%d/approvals/%d?%s",
+		util.Config.WebHost,
+		approval.ProjectID,
+		approval.ID,
+		query.Encode())
+}
+
+// createTaskApprovals creates single-use approval links for members of the project
+// who can run tasks and returns the emails with the links.
+func createTaskApprovals(store db.Store, task db.Task, tpl db.Template, now time.Time) (mails []taskApprovalMail, err error) {
+	users, err := store.GetProjectUsers(task.ProjectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return
+	}
+
+	for _, user := range users {
+		if user.Email == "" || user.IsExpired(now) || !user.Role.Can(db.CanRunProjectTasks) {
+			continue
+		}
+
+		tokenBytes := make([]byte, 32)
+		if _, err = rand.Read(tokenBytes); err != nil {
+			return
+		}
+		token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+		var approval db.TaskApproval
+		approval, err = store.CreateTaskApproval(db.TaskApproval{
+			ProjectID: task.ProjectID,
+			TaskID:    task.ID,
+			UserID:    user.ID,
+			TokenHash: db.HashTaskApprovalToken(token),
+			Created:   now.UTC(),
+			Expires:   now.UTC().Add(db.TaskApprovalLinkLifetime),
+		})
+		if err != nil {
+			return
+		}
+
+		mails = append(mails, taskApprovalMail{
+			user: user.User,
+			alert: ProjectAlert{
+				Subject: i18n.Msg("Task '%s' waits for approval", tpl.Name),
+				Text:    i18n.Msg("Task #%d of template '%s' waits for your approval.", task.ID, tpl.Name),
+				Details: []i18n.Message{
+					i18n.Msg("Approve: %s", getTaskApprovalLink(approval, token, db.TaskApprovalApprove)),
+					i18n.Msg("Reject: %s", getTaskApprovalLink(approval, token, db.TaskApprovalReject)),
+					i18n.Msg("The links can be used once and expire in %d hours.", int(db.TaskApprovalLinkLifetime.Hours())),
+				},
+				URL: fmt.Sprintf("%s/project/%d/templates/%d?t=%d", util.Config.WebHost, task.ProjectID, tpl.ID, task.ID),
+			},
+		})
+	}
+
+	return
+}
+
+// sendApprovalEmails sends approvers the links to approve or reject the task
+// which waits for confirmation if the template requires it.
+func (t *TaskRunner) sendApprovalEmails() {
+	if !t.Template.ApprovalEmails || !util.Config.EmailAlert {
+		return
+	}
+
+	if util.Config.WebHost == "" {
+		t.Log("Approval emails are not sent because web host is not configured")
+		return
+	}
+
+	mails, err := createTaskApprovals(t.pool.store, t.Task, t.Template, time.Now())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"task_id": t.Task.ID,
+		}).Error("Can not create approval links of the task")
+		return
+	}
+
+	for _, mail := range mails {
+		t.Logf("Sending approval email to %s", mail.user.Email)
+	}
+
+	go func() {
+		for _, mail := range mails {
+			sendProjectMail(mail.user, mail.alert)
+		}
+	}()
+}
+
+// DecideTaskApproval confirms or stops the task by the link of the approval email.
+// The link can be used once. The links of other approvers can not be used after
+// the decision because the task does not wait for confirmation anymore.
+// It returns ErrNotFound for unknown, used, expired and invalid links.
+func (p *TaskPool) DecideTaskApproval(
+	projectID int,
+	approvalID int,
+	token string,
+	decision db.TaskApprovalDecision,
+) (task db.Task, err error) {
+	now := time.Now()
+
+	approval, err := p.store.GetTaskApproval(projectID, approvalID)
+	if err != nil || !approval.CheckToken(token) || !approval.IsUsable(now) {
+		err = db.ErrNotFound
+		return
+	}
+
+	task, err = p.store.GetTask(projectID, approval.TaskID)
+	if err != nil {
+		return
+	}
+
+	if task.Status != task_logger.TaskWaitingConfirmation {
+		err = ErrTaskNotWaitingApproval
+		return
+	}
+
+	if err = p.store.UseTaskApproval(projectID, approvalID, decision, now); err != nil {
+		return
+	}
+
+	desc := "Task ID " + strconv.Itoa(task.ID) + " is approved by email"
+	if decision == db.TaskApprovalApprove {
+		err = p.ConfirmTask(task)
+	} else {
+		desc = "Task ID " + strconv.Itoa(task.ID) + " is rejected by email"
+		err = p.StopTask(task, true)
+	}
+
+	if err != nil {
+		return
+	}
+
+	objType := db.EventTask
+	_, err = p.store.CreateEvent(db.Event{
+		UserID:      &approval.UserID,
+		ProjectID:   &task.ProjectID,
+		ObjectType:  &objType,
+		ObjectID:    &task.ID,
+		Action:      string(decision),
+		Description: &desc,
+	})
+
+	return
+}
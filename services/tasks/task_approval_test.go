@@ -0,0 +1,115 @@
+package tasks
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func parseTaskApprovalLink(t *testing.T, mail taskApprovalMail) *url.URL {
+	link, err := url.Parse(mail.alert.Details[0].Args[0].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return link
+}
+
+func TestDecideTaskApproval(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	util.Config = &util.ConfigType{WebHost: "https://semaphore.example.com"}
+
+	project, err := store.CreateProject(db.Project{Name: "Approvals"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, member := range []struct {
+		username string
+		role     db.ProjectUserRole
+	}{
+		{"owner", db.ProjectOwner},
+		{"runner", db.ProjectTaskRunner},
+		{"guest", db.ProjectGuest},
+	} {
+		user, err2 := store.CreateUserWithoutPassword(db.User{
+			Username: member.username,
+			Name:     member.username,
+			Email:    member.username + "@example.com",
+		})
+		if err2 != nil {
+			t.Fatal(err2)
+		}
+
+		if _, err2 = store.CreateProjectUser(db.ProjectUser{ProjectID: project.ID, UserID: user.ID, Role: member.role}); err2 != nil {
+			t.Fatal(err2)
+		}
+	}
+
+	tpl := db.Template{ID: 1, ProjectID: project.ID, Name: "Apply", ApprovalEmails: true}
+
+	task, err := store.CreateTask(db.Task{
+		ProjectID:  project.ID,
+		TemplateID: tpl.ID,
+		Status:     task_logger.TaskWaitingConfirmation,
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mails, err := createTaskApprovals(store, task, tpl, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mails) != 2 {
+		t.Fatalf("only members who can run tasks must receive approval emails, got %d", len(mails))
+	}
+
+	pool := CreateTaskPool(store)
+	pool.RunningTasks[task.ID] = &TaskRunner{Task: task, Template: tpl, pool: &pool}
+
+	link := parseTaskApprovalLink(t, mails[0])
+	if link.Query().Get("decision") != string(db.TaskApprovalApprove) {
+		t.Fatalf("unexpected approve link %s", link)
+	}
+
+	approval, err := store.GetTaskApproval(project.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = pool.DecideTaskApproval(project.ID, approval.ID, "invalid", db.TaskApprovalApprove)
+	if !errors.Is(err, db.ErrNotFound) {
+		t.Fatalf("expected not found for invalid token, got %v", err)
+	}
+
+	token := link.Query().Get("token")
+
+	if _, err = pool.DecideTaskApproval(project.ID, approval.ID, token, db.TaskApprovalApprove); err != nil {
+		t.Fatal(err)
+	}
+
+	if pool.RunningTasks[task.ID].Task.Status != task_logger.TaskConfirmed {
+		t.Fatalf("task must be confirmed, got %s", pool.RunningTasks[task.ID].Task.Status)
+	}
+
+	_, err = pool.DecideTaskApproval(project.ID, approval.ID, token, db.TaskApprovalApprove)
+	if !errors.Is(err, db.ErrNotFound) {
+		t.Fatalf("link must be used once, got %v", err)
+	}
+
+	other := parseTaskApprovalLink(t, mails[1])
+	_, err = pool.DecideTaskApproval(project.ID, 2, other.Query().Get("token"), db.TaskApprovalReject)
+	if !errors.Is(err, ErrTaskNotWaitingApproval) {
+		t.Fatalf("links of other approvers must not be usable after the decision, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package tasks
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/i18n"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func flakyTemplateAlert(project db.Project, tpl db.Template, health db.TemplateHealth) ProjectAlert {
+	return ProjectAlert{
+		Subject: i18n.Msg("Template '%s' is flaky", tpl.Name),
+		Text: i18n.Msg("Project %s: results of template '%s' alternate without configuration changes in %d%% of the last %d runs",
+			project.Name,
+			tpl.Name,
+			int(math.Round(health.FlakinessRate*100)),
+			health.TaskCount),
+		URL: fmt.Sprintf("%s/project/%d/templates/%d", util.Config.WebHost, project.ID, tpl.ID),
+	}
+}
+
+// checkTemplateHealth sends the alert when the finished task makes the template flaky.
+// The alert is not repeated while the template stays flaky.
+func (t *TaskRunner) checkTemplateHealth() {
+	if t.Task.Status != task_logger.TaskSuccessStatus && t.Task.Status != task_logger.TaskFailStatus {
+		return
+	}
+
+	tasks, err := t.pool.store.GetTemplateTasks(t.Task.ProjectID, t.Task.TemplateID, db.RetrieveQueryParams{
+		Count: db.TemplateHealthWindow + 1,
+	})
+	if err != nil {
+		util.LogError(err)
+		return
+	}
+
+	var previous []db.TaskWithTpl
+	for _, task := range tasks {
+		if task.ID < t.Task.ID {
+			previous = append(previous, task)
+		}
+	}
+
+	health := db.GetTemplateHealth(t.Task.TemplateID, tasks, db.TemplateHealthWindow)
+	if !health.Flaky || db.GetTemplateHealth(t.Task.TemplateID, previous, db.TemplateHealthWindow).Flaky {
+		return
+	}
+
+	t.Logf("Template '%s' is flaky: %d of the last %d runs alternate between success and failure",
+		t.Template.Name, health.Flips, health.TaskCount)
+
+	project, err := t.pool.store.GetProject(t.Task.ProjectID)
+	if err != nil {
+		util.LogError(err)
+		return
+	}
+
+	SendProjectAlert(t.pool.store, project, flakyTemplateAlert(project, t.Template, health))
+}
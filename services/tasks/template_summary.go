@@ -0,0 +1,81 @@
+package tasks
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// templateSummaryLock serializes updates of the summaries, which are read, changed and written back.
+var templateSummaryLock sync.Mutex
+
+// updateTemplateSummary applies the task to the summary of its template. created is true
+// for the new task, finished is true when the task is finished, it must be passed once per task.
+func updateTemplateSummary(store db.Store, task db.Task, created bool, finished bool) {
+	templateSummaryLock.Lock()
+	defer templateSummaryLock.Unlock()
+
+	summary, err := store.GetTemplateSummary(task.ProjectID, task.TemplateID)
+	if errors.Is(err, db.ErrNotFound) {
+		summary = db.TemplateSummary{ProjectID: task.ProjectID, TemplateID: task.TemplateID}
+		err = nil
+	}
+
+	if err == nil {
+		now := time.Now()
+
+		summary.ApplyTask(task, created)
+		if finished {
+			summary.ApplyFinishedTask(task, now)
+		}
+
+		// the oldest tasks are removed when the template has more tasks than allowed
+		if util.Config.MaxTasksPerTemplate > 0 && summary.TaskCount > util.Config.MaxTasksPerTemplate {
+			summary.TaskCount = util.Config.MaxTasksPerTemplate
+		}
+
+		summary.Updated = now
+		err = store.SetTemplateSummary(summary)
+	}
+
+	if err != nil {
+		log.Error("Can't update summary of template " + strconv.Itoa(task.TemplateID) + "! Error: " + err.Error())
+	}
+}
+
+// RebuildTemplateSummary makes the summary of the template from its tasks. It is used to fill
+// summaries of the tasks created before summaries were introduced and to correct counters after
+// tasks are removed.
+func RebuildTemplateSummary(store db.Store, tpl db.Template, now time.Time) error {
+	templateSummaryLock.Lock()
+	defer templateSummaryLock.Unlock()
+
+	tasks, err := store.GetTemplateTasks(tpl.ProjectID, tpl.ID, db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+
+	summary := db.TemplateSummary{ProjectID: tpl.ProjectID, TemplateID: tpl.ID, Updated: now}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].Created.Before(tasks[j].Created)
+	})
+
+	for _, t := range tasks {
+		task := t.Task
+
+		summary.ApplyTask(task, true)
+
+		if task.Status.IsFinished() && task.End != nil {
+			summary.ApplyFinishedTask(task, now)
+		}
+	}
+
+	return store.SetTemplateSummary(summary)
+}
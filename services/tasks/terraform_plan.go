@@ -0,0 +1,78 @@
+package tasks
+
+import (
+	"sync"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// terraformPlan collects resource changes from terraform plan output.
+type terraformPlan struct {
+	lock    sync.Mutex
+	changes []db.TerraformPlanChange
+	// indexes contains the positions of the changes by addresses of the resources.
+	indexes map[string]int
+}
+
+func newTerraformPlan() *terraformPlan {
+	return &terraformPlan{indexes: make(map[string]int)}
+}
+
+// parseLine is the log listener of the task which remembers the changes of the plan.
+// terraform apply prints the plan again, so the last action of the resource wins.
+func (p *terraformPlan) parseLine(_ time.Time, line string) {
+	change, ok := db.ParseTerraformPlanLine(util.StripANSI(line))
+	if !ok {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if i, ok := p.indexes[change.Address]; ok {
+		p.changes[i] = change
+		return
+	}
+
+	p.indexes[change.Address] = len(p.changes)
+	p.changes = append(p.changes, change)
+}
+
+// getChanges returns the changes of the plan in the order of the output.
+func (p *terraformPlan) getChanges() []db.TerraformPlanChange {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return append([]db.TerraformPlanChange{}, p.changes...)
+}
+
+// saveTerraformPlan stores the changes of the plan of the task.
+func (t *TaskRunner) saveTerraformPlan(plan *terraformPlan) {
+	for _, change := range plan.getChanges() {
+		change.TaskID = t.Task.ID
+		if _, err := t.pool.store.CreateTerraformPlanChange(change); err != nil {
+			util.LogErrorWithFields(err, log.Fields{"error": "Failed to store terraform plan change"})
+		}
+	}
+}
+
+// GetTerraformPlanDiff compares the plan of the task with the plan of the base task of the project.
+func GetTerraformPlanDiff(store db.Store, projectID int, baseTaskID int, taskID int) (diff db.TerraformPlanDiff, err error) {
+	base, err := store.GetTerraformPlanChanges(projectID, baseTaskID)
+	if err != nil {
+		return
+	}
+
+	plan, err := store.GetTerraformPlanChanges(projectID, taskID)
+	if err != nil {
+		return
+	}
+
+	diff = db.DiffTerraformPlans(base, plan)
+	diff.BaseTaskID = baseTaskID
+	diff.TaskID = taskID
+	return
+}
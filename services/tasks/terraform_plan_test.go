@@ -0,0 +1,78 @@
+package tasks
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+)
+
+func TestTerraformPlanParseLine(t *testing.T) {
+	plan := newTerraformPlan()
+
+	for _, line := range []string{
+		"Terraform will perform the following actions:",
+		"\x1b[1m  # aws_instance.web\x1b[0m will be created",
+		"  # aws_s3_bucket.logs will be destroyed",
+		"Plan: 1 to add, 0 to change, 1 to destroy.",
+		// terraform apply prints the plan again
+		"  # aws_instance.web will be updated in-place",
+	} {
+		plan.parseLine(time.Now(), line)
+	}
+
+	changes := plan.getChanges()
+
+	if len(changes) != 2 ||
+		changes[0] != (db.TerraformPlanChange{Address: "aws_instance.web", Action: db.TerraformPlanUpdate}) ||
+		changes[1] != (db.TerraformPlanChange{Address: "aws_s3_bucket.logs", Action: db.TerraformPlanDelete}) {
+		t.Fatal("invalid changes of the plan", changes)
+	}
+}
+
+func TestGetTerraformPlanDiff(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	pool := CreateTaskPool(store)
+
+	var runners []*TaskRunner
+
+	for _, lines := range [][]string{
+		{"  # aws_instance.web will be updated in-place"},
+		{"  # aws_instance.web will be updated in-place", "  # aws_instance.db will be destroyed"},
+	} {
+		task, err := store.CreateTask(db.Task{ProjectID: 1, TemplateID: 1}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		plan := newTerraformPlan()
+		for _, line := range lines {
+			plan.parseLine(time.Now(), line)
+		}
+
+		runner := &TaskRunner{Task: task, pool: &pool}
+		runner.saveTerraformPlan(plan)
+		runners = append(runners, runner)
+	}
+
+	diff, err := GetTerraformPlanDiff(store, 1, runners[0].Task.ID, runners[1].Task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff.BaseTaskID != runners[0].Task.ID || diff.TaskID != runners[1].Task.ID {
+		t.Fatal("diff must contain IDs of the tasks", diff)
+	}
+
+	if len(diff.Added) != 1 || diff.Added[0].Address != "aws_instance.db" || diff.Added[0].Action != db.TerraformPlanDelete {
+		t.Fatal("destroyed resource must be added to the diff", diff.Added)
+	}
+
+	if len(diff.Removed) != 0 || len(diff.Changed) != 0 {
+		t.Fatal("unexpected differences", diff)
+	}
+}
@@ -0,0 +1,222 @@
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+const watchdogCheckInterval = 10 * time.Second
+
+// taskWatchdog reports the task as stuck if it produces no output for a long time
+// and optionally terminates it.
+type taskWatchdog struct {
+	job      *LocalJob
+	silence  time.Duration
+	kill     time.Duration
+	snapshot bool
+
+	// lastOutput is the time of the last output of the task in Unix nanoseconds.
+	lastOutput atomic.Int64
+	// reportedOutput is the value of lastOutput when the task was reported as stuck.
+	reportedOutput int64
+	// logging is set while the watchdog writes to the task log,
+	// its own messages must not be considered as task output.
+	logging atomic.Bool
+
+	done chan struct{}
+}
+
+// newTaskWatchdog returns nil if the watchdog is disabled.
+func newTaskWatchdog(job *LocalJob) *taskWatchdog {
+	cfg := util.Config.TaskWatchdog
+	if cfg == nil || cfg.SilenceMinutes <= 0 {
+		return nil
+	}
+
+	w := &taskWatchdog{
+		job:      job,
+		silence:  time.Duration(cfg.SilenceMinutes) * time.Minute,
+		kill:     time.Duration(cfg.KillMinutes) * time.Minute,
+		snapshot: cfg.Snapshot,
+		done:     make(chan struct{}),
+	}
+	w.lastOutput.Store(time.Now().UnixNano())
+
+	return w
+}
+
+func (w *taskWatchdog) start() {
+	w.job.Logger.AddLogListener(func(now time.Time, _ string) {
+		if !w.logging.Load() {
+			w.lastOutput.Store(now.UnixNano())
+		}
+	})
+
+	go func() {
+		ticker := time.NewTicker(watchdogCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.done:
+				return
+			case now := <-ticker.C:
+				if w.check(now) {
+					return
+				}
+			}
+		}
+	}()
+}
+
+func (w *taskWatchdog) stop() {
+	close(w.done)
+}
+
+func (w *taskWatchdog) log(msg string) {
+	w.logging.Store(true)
+	defer w.logging.Store(false)
+	w.job.Log(msg)
+}
+
+// check reports the stuck task and terminates it if required.
+// It returns true if the task was terminated.
+func (w *taskWatchdog) check(now time.Time) bool {
+	lastOutput := w.lastOutput.Load()
+	silence := now.Sub(time.Unix(0, lastOutput)).Truncate(time.Second)
+
+	if w.kill > 0 && silence >= w.kill {
+		w.log(fmt.Sprintf("Watchdog: no output for %s, terminating the task", silence))
+		w.job.Kill()
+		return true
+	}
+
+	if silence >= w.silence && w.reportedOutput != lastOutput {
+		w.reportedOutput = lastOutput
+		w.log(fmt.Sprintf("Watchdog: no output for %s, the task may be stuck", silence))
+
+		if w.snapshot {
+			w.logSnapshot()
+		}
+	}
+
+	return false
+}
+
+func (w *taskWatchdog) logSnapshot() {
+	if w.job.Process == nil {
+		w.log("Watchdog: the task has no running process")
+		return
+	}
+
+	snapshot, err := getProcessTreeSnapshot(w.job.Process.Pid)
+	if err != nil {
+		w.log("Watchdog: can't make process snapshot: " + err.Error())
+		return
+	}
+
+	w.log("Watchdog: process snapshot (pid ppid state wchan command):")
+	for _, line := range snapshot {
+		w.log(line)
+	}
+}
+
+type procInfo struct {
+	pid   int
+	ppid  int
+	state string
+}
+
+// readProcStat parses /proc/<pid>/stat. The command name is skipped
+// because it can contain spaces and parentheses.
+func readProcStat(pid int) (info procInfo, err error) {
+	content, err := os.ReadFile(path.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return
+	}
+
+	stat := string(content)
+	end := strings.LastIndex(stat, ")")
+	if end == -1 {
+		err = fmt.Errorf("invalid stat of process %d", pid)
+		return
+	}
+
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 2 {
+		err = fmt.Errorf("invalid stat of process %d", pid)
+		return
+	}
+
+	info.pid = pid
+	info.state = fields[0]
+	info.ppid, err = strconv.Atoi(fields[1])
+	return
+}
+
+func readProcFile(pid int, name string) string {
+	content, err := os.ReadFile(path.Join("/proc", strconv.Itoa(pid), name))
+	if err != nil {
+		return "?"
+	}
+	return strings.TrimSpace(strings.ReplaceAll(string(content), "\x00", " "))
+}
+
+// getProcessTreeSnapshot describes the process and all its descendants. It requires procfs.
+func getProcessTreeSnapshot(rootPid int) (lines []string, err error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return
+	}
+
+	children := make(map[int][]procInfo)
+	var root *procInfo
+
+	for _, entry := range entries {
+		pid, convErr := strconv.Atoi(entry.Name())
+		if convErr != nil {
+			continue
+		}
+
+		info, statErr := readProcStat(pid)
+		if statErr != nil {
+			// process exited
+			continue
+		}
+
+		if pid == rootPid {
+			root = &info
+		}
+
+		children[info.ppid] = append(children[info.ppid], info)
+	}
+
+	if root == nil {
+		err = fmt.Errorf("process %d not found", rootPid)
+		return
+	}
+
+	queue := []procInfo{*root}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+
+		lines = append(lines, fmt.Sprintf("%d %d %s %s %s",
+			p.pid,
+			p.ppid,
+			p.state,
+			readProcFile(p.pid, "wchan"),
+			readProcFile(p.pid, "cmdline")))
+
+		queue = append(queue, children[p.pid]...)
+	}
+
+	return
+}
@@ -0,0 +1,87 @@
+package tasks
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+type testLogger struct {
+	onLog func(msg string)
+}
+
+func (l *testLogger) Log(msg string)                                        { l.onLog(msg) }
+func (l *testLogger) Logf(format string, a ...any)                          {}
+func (l *testLogger) LogWithTime(now time.Time, msg string)                 { l.onLog(msg) }
+func (l *testLogger) LogfWithTime(now time.Time, format string, a ...any)   {}
+func (l *testLogger) LogCmd(cmd *exec.Cmd)                                  {}
+func (l *testLogger) SetStatus(status task_logger.TaskStatus)               {}
+func (l *testLogger) AddStatusListener(listener task_logger.StatusListener) {}
+func (l *testLogger) AddLogListener(listener task_logger.LogListener)       {}
+
+func TestTaskWatchdogCheck(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TaskWatchdog: &util.TaskWatchdogConfig{
+			SilenceMinutes: 1,
+			KillMinutes:    3,
+		},
+	}
+
+	var logged []string
+	logger := &testLogger{onLog: func(msg string) {
+		logged = append(logged, msg)
+	}}
+
+	w := newTaskWatchdog(&LocalJob{Logger: logger})
+	start := time.Unix(0, w.lastOutput.Load())
+
+	if w.check(start.Add(30 * time.Second)) {
+		t.Fatal("task must not be terminated")
+	}
+	if len(logged) != 0 {
+		t.Fatal("task must not be reported before silence period")
+	}
+
+	w.check(start.Add(time.Minute))
+	w.check(start.Add(2 * time.Minute))
+	if len(logged) != 1 || !strings.Contains(logged[0], "may be stuck") {
+		t.Fatal("task must be reported once")
+	}
+
+	if !w.check(start.Add(3 * time.Minute)) {
+		t.Fatal("task must be terminated")
+	}
+}
+
+func TestGetProcessTreeSnapshot(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("procfs is not available")
+	}
+
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Skip("sleep is not available")
+	}
+	defer cmd.Process.Kill() //nolint:errcheck
+
+	lines, err := getProcessTreeSnapshot(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, line := range lines {
+		if strings.Contains(line, "sleep 10") {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatal("child process must be in the snapshot")
+	}
+}
@@ -0,0 +1,345 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db_lib"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// TaskSimulation is the command which the task would run. It is made without running
+// anything to debug how variables of the template are resolved. Secrets are masked.
+type TaskSimulation struct {
+	Command []string `json:"command"`
+	// Environment contains variables set for the command,
+	// variables inherited from the server environment are not included.
+	Environment []string `json:"environment"`
+	// Variables are resolved extra variables of the task.
+	Variables json.RawMessage `json:"variables"`
+	// Prompts are prompts of the command which are answered by Semaphore, e.g. passwords.
+	Prompts   []string                 `json:"prompts"`
+	Inventory *TaskSimulationInventory `json:"inventory"`
+	// Messages are logged while the command is made, e.g. about skipped variables.
+	Messages []string `json:"messages"`
+}
+
+// TaskSimulationInventory is the inventory selected for the task.
+type TaskSimulationInventory struct {
+	ID   int              `json:"id"`
+	Name string           `json:"name"`
+	Type db.InventoryType `json:"type"`
+	// FromTask is true if the inventory of the task is used instead of the inventory of the template.
+	FromTask bool   `json:"from_task"`
+	Limit    string `json:"limit,omitempty"`
+}
+
+// simulationLogger collects messages logged while the command of the simulated task is made.
+type simulationLogger struct {
+	messages []string
+}
+
+func (l *simulationLogger) Log(msg string) {
+	l.messages = append(l.messages, msg)
+}
+
+func (l *simulationLogger) Logf(format string, a ...any) {
+	l.Log(fmt.Sprintf(format, a...))
+}
+
+func (l *simulationLogger) LogWithTime(_ time.Time, msg string) {
+	l.Log(msg)
+}
+
+func (l *simulationLogger) LogfWithTime(_ time.Time, format string, a ...any) {
+	l.Logf(format, a...)
+}
+
+func (l *simulationLogger) LogCmd(_ *exec.Cmd)                             {}
+func (l *simulationLogger) SetStatus(_ task_logger.TaskStatus)             {}
+func (l *simulationLogger) AddStatusListener(_ task_logger.StatusListener) {}
+func (l *simulationLogger) AddLogListener(_ task_logger.LogListener)       {}
+
+// previewKeyInstallation returns credentials of the key which are passed to the command.
+// Unlike Install, it does not start the SSH agent and does not request credentials of Vault.
+func previewKeyInstallation(key *db.AccessKey, logger task_logger.Logger) (install db.AccessKeyInstallation, err error) {
+	if err = key.DeserializeSecret(); err != nil {
+		return
+	}
+
+	switch key.Type {
+	case db.AccessKeySSH:
+		install.Login = key.SshKey.Login
+	case db.AccessKeyLoginPassword:
+		install.Login = key.LoginPassword.Login
+		install.Password = key.LoginPassword.Password
+	case db.AccessKeyVault:
+		logger.Log("Credentials of key " + key.Name + " are issued by Vault when the task runs")
+	}
+
+	return
+}
+
+// getSimulationSecrets returns values which are masked in the simulation:
+// secrets of the environment and the inventory, passwords and secret variables of the task.
+func (t *LocalJob) getSimulationSecrets() (secrets []string, err error) {
+	for _, secret := range t.Environment.Secrets {
+		secrets = append(secrets, secret.Secret)
+	}
+
+	for _, secret := range t.Inventory.Secrets {
+		secrets = append(secrets, secret.Secret)
+	}
+
+	secrets = append(secrets, t.sshKeyInstallation.Password, t.becomeKeyInstallation.Password)
+
+	for _, install := range t.vaultFileInstallations {
+		secrets = append(secrets, install.Password)
+	}
+
+	secretVars := make(map[string]any)
+
+	if t.Secret != "" {
+		if err = json.Unmarshal([]byte(t.Secret), &secretVars); err != nil {
+			return
+		}
+	}
+
+	if t.Task.Environment != "" {
+		vars := make(map[string]any)
+		if err = json.Unmarshal([]byte(t.Task.Environment), &vars); err != nil {
+			return
+		}
+
+		for _, v := range t.Template.SurveyVars {
+			if v.Type == db.SurveyVarType(db.SurveyVarSecret) {
+				if value, ok := vars[v.Name]; ok {
+					secretVars[v.Name] = value
+				}
+			}
+		}
+	}
+
+	for _, value := range secretVars {
+		if s, ok := value.(string); ok {
+			secrets = append(secrets, s)
+		} else {
+			secrets = append(secrets, fmt.Sprint(value))
+		}
+	}
+
+	return
+}
+
+// simulate makes the command of the job like Run without preparing the workspace.
+func (t *LocalJob) simulate(username string, masker *db.OutputMasker) (sim TaskSimulation, err error) {
+	if t.Inventory.SSHKeyID != nil {
+		if t.sshKeyInstallation, err = previewKeyInstallation(&t.Inventory.SSHKey, t.Logger); err != nil {
+			return
+		}
+	}
+
+	if t.Inventory.BecomeKeyID != nil {
+		if t.becomeKeyInstallation, err = previewKeyInstallation(&t.Inventory.BecomeKey, t.Logger); err != nil {
+			return
+		}
+	}
+
+	t.vaultFileInstallations = make(map[string]db.AccessKeyInstallation)
+
+	for _, vault := range t.Template.Vaults {
+		name := "default"
+		if vault.Name != nil {
+			name = *vault.Name
+		}
+
+		var install db.AccessKeyInstallation
+		if vault.Type == db.TemplateVaultPassword && vault.Vault != nil {
+			if install, err = previewKeyInstallation(vault.Vault, t.Logger); err != nil {
+				return
+			}
+		}
+		if vault.Type == db.TemplateVaultScript && vault.Script != nil {
+			install.Script = *vault.Script
+		}
+
+		t.vaultFileInstallations[name] = install
+	}
+
+	secrets, err := t.getSimulationSecrets()
+	if err != nil {
+		return
+	}
+	masker.AddValues(secrets...)
+
+	variables, err := t.getEnvironmentExtraVars(username, nil)
+	if err != nil {
+		return
+	}
+
+	environmentVariables, err := t.getEnvironmentENV()
+	if err != nil {
+		return
+	}
+
+	args, err := t.getRunningArgs(username, nil, environmentVariables)
+	if err != nil {
+		return
+	}
+
+	sim.Command = args.CliArgs
+	env := *args.EnvironmentVars
+
+	if previewer, ok := t.App.(db_lib.LocalAppCmdPreviewer); ok {
+		var cmd *exec.Cmd
+		if cmd, err = previewer.PreviewCmd(args); err != nil {
+			return
+		}
+		sim.Command = cmd.Args
+		env = cmd.Env
+	}
+
+	for i := range sim.Command {
+		sim.Command[i] = masker.Mask(sim.Command[i])
+	}
+
+	serverEnv := make(map[string]bool)
+	for _, v := range util.AllowedEnvironmentVars() {
+		serverEnv[v] = true
+	}
+	for k, v := range util.Config.EnvVars {
+		serverEnv[k+"="+v] = true
+	}
+
+	sim.Environment = []string{}
+	for _, v := range env {
+		if !serverEnv[v] {
+			sim.Environment = append(sim.Environment, masker.Mask(v))
+		}
+	}
+
+	sim.Prompts = []string{}
+	for prompt := range args.Inputs {
+		sim.Prompts = append(sim.Prompts, prompt)
+	}
+	sort.Strings(sim.Prompts)
+
+	b, err := json.Marshal(variables)
+	if err != nil {
+		return
+	}
+
+	masked := masker.Mask(string(b))
+	if !json.Valid([]byte(masked)) {
+		// masking rules can match across JSON tokens
+		b, _ = json.Marshal(masked)
+		masked = string(b)
+	}
+	sim.Variables = json.RawMessage(masked)
+
+	return
+}
+
+// SimulateTask returns the command which the task of the template would run with the survey
+// values and the inventory of the task. Nothing is installed and run.
+func (p *TaskPool) SimulateTask(taskObj db.Task, projectID int, username string) (sim TaskSimulation, err error) {
+	taskObj.ID = 0
+	taskObj.ProjectID = projectID
+
+	tpl, err := p.store.GetTemplate(projectID, taskObj.TemplateID)
+	if err != nil {
+		return
+	}
+
+	if err = taskObj.ValidateNewTask(tpl); err != nil {
+		return
+	}
+
+	if err = db.ValidateTaskEnvironment(p.store, tpl, taskObj.Environment); err != nil {
+		return
+	}
+
+	project, err := p.store.GetProject(projectID)
+	if err != nil {
+		return
+	}
+
+	runner := &TaskRunner{Task: taskObj, Template: tpl, pool: p}
+
+	if runner.Inventory, err = runner.getInventory(); err != nil {
+		return
+	}
+
+	if err = db.ApplyProjectDefaultKeys(p.store, project, &runner.Inventory, &runner.Template); err != nil {
+		return
+	}
+
+	if runner.Inventory.ID != 0 {
+		if err = db.FillInventorySecrets(p.store, &runner.Inventory, true); err != nil {
+			return
+		}
+
+		sim.Inventory = &TaskSimulationInventory{
+			ID:       runner.Inventory.ID,
+			Name:     runner.Inventory.Name,
+			Type:     runner.Inventory.Type,
+			FromTask: taskObj.InventoryID != nil && *taskObj.InventoryID == runner.Inventory.ID,
+			Limit:    taskObj.Limit,
+		}
+	}
+
+	if runner.Repository, err = p.store.GetRepository(projectID, tpl.RepositoryID); err != nil {
+		return
+	}
+
+	if err = runner.populateEnvironment(); err != nil {
+		return
+	}
+
+	maskingRules, err := p.store.GetMaskingRules(projectID)
+	if err != nil {
+		return
+	}
+
+	logger := &simulationLogger{}
+
+	job := &LocalJob{
+		Task:        runner.Task,
+		Template:    runner.Template,
+		Inventory:   runner.Inventory,
+		Repository:  runner.Repository,
+		Environment: runner.Environment,
+		Secret:      taskObj.Secret,
+		Logger:      logger,
+		App:         db_lib.CreateApp(runner.Template, runner.Repository, runner.Inventory, logger),
+	}
+
+	if taskObj.InteractiveBecome {
+		// the become password is entered at the launch of the task
+		if job.BecomePassword, err = sealSecret(db.MaskedValue, time.Minute); err != nil {
+			return
+		}
+		defer job.BecomePassword.destroy()
+	}
+
+	masker := db.NewOutputMasker(maskingRules)
+
+	inventory := sim.Inventory
+	sim, err = job.simulate(username, masker)
+	if err != nil {
+		return
+	}
+
+	sim.Inventory = inventory
+	sim.Messages = []string{}
+	for _, msg := range logger.messages {
+		sim.Messages = append(sim.Messages, masker.Mask(msg))
+	}
+
+	return
+}
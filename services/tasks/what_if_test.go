@@ -0,0 +1,121 @@
+package tasks
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+func TestSimulateTask(t *testing.T) {
+	store := bolt.CreateTestStore()
+	defer os.Remove(store.Filename) //nolint: errcheck
+
+	util.Config = &util.ConfigType{TmpPath: "/tmp/semaphore"}
+
+	proj, err := store.CreateProject(db.Project{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inv, err := store.CreateInventory(db.Inventory{ProjectID: proj.ID, Name: "Production", Type: db.InventoryStatic})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	staging, err := store.CreateInventory(db.Inventory{ProjectID: proj.ID, Name: "Staging", Type: db.InventoryStatic})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := store.CreateAccessKey(db.AccessKey{ProjectID: &proj.ID, Name: "None", Type: db.AccessKeyNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := store.CreateRepository(db.Repository{ProjectID: proj.ID, Name: "Repo", GitURL: "/srv/playbooks", SSHKeyID: key.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envVars := `{"DEPLOY_MODE": "fast"}`
+	env, err := store.CreateEnvironment(db.Environment{
+		ProjectID: proj.ID,
+		Name:      "Env",
+		JSON:      `{"region": "us-east-1", "app_version": "1.0"}`,
+		ENV:       &envVars,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	group, err := store.CreateEnvironment(db.Environment{
+		ProjectID:     proj.ID,
+		Name:          "Common",
+		JSON:          `{"region": "eu-west-1", "team": "ops"}`,
+		VariableGroup: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, err := store.CreateTemplate(db.Template{
+		Name:             "Deploy",
+		Playbook:         "site.yml",
+		ProjectID:        proj.ID,
+		InventoryID:      &inv.ID,
+		RepositoryID:     repo.ID,
+		EnvironmentID:    &env.ID,
+		VariableGroupIDs: db.TemplateVariableGroups{group.ID},
+		App:              db.AppAnsible,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := TaskPool{store: store}
+
+	sim, err := pool.SimulateTask(db.Task{
+		TemplateID:  tpl.ID,
+		InventoryID: &staging.ID,
+		Environment: `{"app_version": "2.0", "build": "42"}`,
+		Secret:      `{"api_token": "s3cr3t-value"}`,
+		Limit:       "web",
+	}, proj.ID, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	command := strings.Join(sim.Command, " ")
+
+	if sim.Command[0] != "ansible-playbook" || !slices.Contains(sim.Command, "--limit=web") || sim.Command[len(sim.Command)-1] != "site.yml" {
+		t.Fatalf("unexpected command %v", sim.Command)
+	}
+
+	if strings.Contains(command, "s3cr3t-value") || !strings.Contains(command, db.MaskedValue) {
+		t.Fatalf("expected the secret variable to be masked, got %s", command)
+	}
+
+	if !slices.Contains(sim.Environment, "DEPLOY_MODE=fast") {
+		t.Fatalf("expected the environment variable of the environment, got %v", sim.Environment)
+	}
+
+	if sim.Inventory == nil || sim.Inventory.ID != staging.ID || !sim.Inventory.FromTask || sim.Inventory.Limit != "web" {
+		t.Fatalf("expected the inventory of the task to be selected, got %+v", sim.Inventory)
+	}
+
+	var vars map[string]any
+	if err = json.Unmarshal(sim.Variables, &vars); err != nil {
+		t.Fatal(err)
+	}
+
+	// the template environment wins over the variable group and the variables of the task
+	if vars["region"] != "us-east-1" || vars["team"] != "ops" || vars["app_version"] != "1.0" || vars["build"] != "42" {
+		t.Fatalf("unexpected variables %v", vars)
+	}
+}
@@ -0,0 +1,11 @@
+package util
+
+import "regexp"
+
+// ansiSequenceRegexp matches CSI sequences (colors, cursor movement) and OSC sequences (titles, links).
+var ansiSequenceRegexp = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// StripANSI removes ANSI escape sequences from the string.
+func StripANSI(s string) string {
+	return ansiSequenceRegexp.ReplaceAllString(s, "")
+}
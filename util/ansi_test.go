@@ -0,0 +1,10 @@
+package util
+
+import "testing"
+
+func TestStripANSI(t *testing.T) {
+	s := StripANSI("\x1b[0;32mok: [localhost]\x1b[0m \x1b]0;title\x07done\x1b[2K")
+	if s != "ok: [localhost] done" {
+		t.Fatal("unexpected output: " + s)
+	}
+}
@@ -2,6 +2,7 @@ package util
 
 import (
 	"context"
+	"crypto/ed25519"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -19,6 +20,7 @@ import (
 
 	"github.com/google/go-github/github"
 	"github.com/gorilla/securecookie"
+	"github.com/semaphoreui/semaphore/pkg/i18n"
 )
 
 // Cookie is a runtime generated secure cookie used for authentication
@@ -33,6 +35,10 @@ const (
 	DbDriverPostgres = "postgres"
 )
 
+// DbConfig is the connection of the database. Options are passed to the connection
+// string of SQL databases. BoltDB supports the sessionConnection option and the engine
+// option: "bolt" by default, or "badger" to store the database in the BadgerDB directory
+// at the host path.
 type DbConfig struct {
 	Dialect string `json:"-"`
 
@@ -110,6 +116,271 @@ type RunnerConfig struct {
 	Webhook string `json:"webhook,omitempty" env:"SEMAPHORE_RUNNER_WEBHOOK"`
 
 	MaxParallelTasks int `json:"max_parallel_tasks,omitempty" default:"1" env:"SEMAPHORE_RUNNER_MAX_PARALLEL_TASKS"`
+
+	// ClientCertFile and ClientKeyFile authenticate the runner by the client certificate
+	// instead of the token. The certificate common name must be the name of the runner.
+	ClientCertFile string `json:"client_cert_file,omitempty" env:"SEMAPHORE_RUNNER_CLIENT_CERT_FILE"`
+	ClientKeyFile  string `json:"client_key_file,omitempty" env:"SEMAPHORE_RUNNER_CLIENT_KEY_FILE"`
+	// ServerCAFile contains CA certificates of the server, system CAs are used if empty.
+	ServerCAFile string `json:"server_ca_file,omitempty" env:"SEMAPHORE_RUNNER_SERVER_CA_FILE"`
+
+	// DisableStream makes the runner poll the server instead of connecting by the gRPC stream.
+	// The runner falls back to polling itself if the server does not support the stream.
+	DisableStream bool `json:"disable_stream,omitempty" env:"SEMAPHORE_RUNNER_DISABLE_STREAM"`
+}
+
+// RateLimitConfig contains maximum numbers of API requests per minute.
+// Zero value means no limit.
+type RateLimitConfig struct {
+	Global   int `json:"global,omitempty" env:"SEMAPHORE_RATE_LIMIT_GLOBAL"`
+	PerToken int `json:"per_token,omitempty" env:"SEMAPHORE_RATE_LIMIT_PER_TOKEN"`
+	PerIP    int `json:"per_ip,omitempty" env:"SEMAPHORE_RATE_LIMIT_PER_IP"`
+}
+
+// GalaxyCacheConfig configures sharing of ansible-galaxy installation results between tasks.
+// Installed roles and collections are reused while requirements file content is not changed.
+type GalaxyCacheConfig struct {
+	Enabled bool `json:"enabled,omitempty" env:"SEMAPHORE_GALAXY_CACHE_ENABLED"`
+	// TTLHours is how long installed requirements are reused. Zero means no expiration.
+	TTLHours int `json:"ttl_hours,omitempty" env:"SEMAPHORE_GALAXY_CACHE_TTL_HOURS"`
+	// MaxSizeMB limits total size of the cache, least recently used entries are removed first.
+	// Zero means no limit.
+	MaxSizeMB int `json:"max_size_mb,omitempty" env:"SEMAPHORE_GALAXY_CACHE_MAX_SIZE_MB"`
+}
+
+// DiskUsageConfig configures the accounting of the disk consumed by the tmp directory of the server
+// or the runner. Caches of repositories and templates which exceed the limits are removed,
+// least recently used first. Zero means no limit.
+type DiskUsageConfig struct {
+	// ProjectCacheLimitMB limits the caches of each project.
+	ProjectCacheLimitMB int `json:"project_cache_limit_mb,omitempty" env:"SEMAPHORE_DISK_USAGE_PROJECT_CACHE_LIMIT_MB"`
+	// CacheLimitMB limits all caches including the caches shared by projects.
+	CacheLimitMB int `json:"cache_limit_mb,omitempty" env:"SEMAPHORE_DISK_USAGE_CACHE_LIMIT_MB"`
+}
+
+// SSHMultiplexingConfig configures the reuse of SSH connections by Ansible. Every task gets
+// its own directory of control sockets, the master connections are closed when the task finishes.
+type SSHMultiplexingConfig struct {
+	Disabled bool `json:"disabled,omitempty" env:"SEMAPHORE_SSH_MULTIPLEXING_DISABLED"`
+	// ControlPersist is how long master connections stay open after the last session, e.g. 60s or 10m.
+	// The ControlPersist of the inventory SSH options takes precedence.
+	ControlPersist string `json:"control_persist,omitempty" env:"SEMAPHORE_SSH_MULTIPLEXING_CONTROL_PERSIST"`
+}
+
+// SSHWorkerConfig is the host which runs tasks of the server over SSH without the runner.
+// The workspace of the task is copied by rsync to the same paths on the worker, so the worker
+// needs rsync, the apps of the templates and the writable tmp_path of the server.
+type SSHWorkerConfig struct {
+	Host string `json:"host"`
+	Port int    `json:"port,omitempty"`
+	User string `json:"user,omitempty"`
+	// KeyFile is the private key of the connection, keys of the SSH agent of the server are used if empty.
+	KeyFile string `json:"key_file,omitempty"`
+	// Options are additional ssh options, e.g. StrictHostKeyChecking=accept-new.
+	Options []string `json:"options,omitempty"`
+	// MaxParallelTasks limits the number of tasks running on the worker, zero means no limit.
+	MaxParallelTasks int `json:"max_parallel_tasks,omitempty"`
+}
+
+// TaskWatchdogConfig configures detection of tasks which produce no output for a long time.
+type TaskWatchdogConfig struct {
+	// SilenceMinutes is the period without output after which the task is reported as stuck.
+	// Zero disables the watchdog.
+	SilenceMinutes int `json:"silence_minutes,omitempty" env:"SEMAPHORE_TASK_WATCHDOG_SILENCE_MINUTES"`
+	// Snapshot logs the process tree of the stuck task.
+	Snapshot bool `json:"snapshot,omitempty" env:"SEMAPHORE_TASK_WATCHDOG_SNAPSHOT"`
+	// KillMinutes is the period without output after which the stuck task is terminated.
+	// Zero means the task is never terminated.
+	KillMinutes int `json:"kill_minutes,omitempty" env:"SEMAPHORE_TASK_WATCHDOG_KILL_MINUTES"`
+}
+
+// HAConfig configures running of several Semaphore nodes with the same database.
+type HAConfig struct {
+	// Enabled turns on the leader election and the shared task queue. Only the leader
+	// node fires schedules, queued tasks are claimed and run by any node.
+	Enabled bool `json:"enabled,omitempty" env:"SEMAPHORE_HA_ENABLED"`
+	// NodeID identifies the node in the cluster. Default is the host name and the process ID.
+	NodeID string `json:"node_id,omitempty" env:"SEMAPHORE_HA_NODE_ID"`
+}
+
+// BrandingConfig customizes the instance for embedding Semaphore in other products.
+type BrandingConfig struct {
+	// LogoURL is the URL of the logo shown instead of the Semaphore logo.
+	LogoURL string `json:"logo_url,omitempty" rule:"^(|https?://.+|/.*)$" env:"SEMAPHORE_BRANDING_LOGO_URL"`
+	// ProductName replaces Semaphore in emails and notifications.
+	ProductName string `json:"product_name,omitempty" env:"SEMAPHORE_BRANDING_PRODUCT_NAME"`
+	// LoginMessage is shown on the login page.
+	LoginMessage string `json:"login_message,omitempty" env:"SEMAPHORE_BRANDING_LOGIN_MESSAGE"`
+}
+
+// PasswordPolicyConfig restricts passwords of local users. LDAP and OIDC users are not affected.
+type PasswordPolicyConfig struct {
+	// MinLength is the minimum number of characters of the password.
+	MinLength        int  `json:"min_length,omitempty" env:"SEMAPHORE_PASSWORD_POLICY_MIN_LENGTH"`
+	RequireUppercase bool `json:"require_uppercase,omitempty" env:"SEMAPHORE_PASSWORD_POLICY_REQUIRE_UPPERCASE"`
+	RequireLowercase bool `json:"require_lowercase,omitempty" env:"SEMAPHORE_PASSWORD_POLICY_REQUIRE_LOWERCASE"`
+	RequireDigit     bool `json:"require_digit,omitempty" env:"SEMAPHORE_PASSWORD_POLICY_REQUIRE_DIGIT"`
+	RequireSymbol    bool `json:"require_symbol,omitempty" env:"SEMAPHORE_PASSWORD_POLICY_REQUIRE_SYMBOL"`
+	// HistorySize is the number of last passwords of the user, including the current one,
+	// which can not be reused. Zero allows any password.
+	HistorySize int `json:"history_size,omitempty" env:"SEMAPHORE_PASSWORD_POLICY_HISTORY_SIZE"`
+	// MaxAgeDays is the age of the password after which the user must change it.
+	// Zero disables the rotation.
+	MaxAgeDays int `json:"max_age_days,omitempty" env:"SEMAPHORE_PASSWORD_POLICY_MAX_AGE_DAYS"`
+}
+
+// HousekeepingConfig configures background maintenance jobs.
+type HousekeepingConfig struct {
+	// Schedules overrides cron schedules of the jobs, the key is the job name.
+	Schedules map[string]string `json:"schedules,omitempty" env:"SEMAPHORE_HOUSEKEEPING_SCHEDULES"`
+	// TaskRetentionDays is the age of finished tasks which are removed. Zero disables task pruning.
+	TaskRetentionDays int `json:"task_retention_days,omitempty" env:"SEMAPHORE_HOUSEKEEPING_TASK_RETENTION_DAYS"`
+	// MetricsRollupDays is the age of task metrics which are rolled up into daily statistics.
+	// The default is 30 days.
+	MetricsRollupDays int `json:"metrics_rollup_days,omitempty" env:"SEMAPHORE_HOUSEKEEPING_METRICS_ROLLUP_DAYS"`
+	// MetricsRetentionDays is the age of daily statistics which are removed. Zero keeps them forever.
+	MetricsRetentionDays int `json:"metrics_retention_days,omitempty" env:"SEMAPHORE_HOUSEKEEPING_METRICS_RETENTION_DAYS"`
+}
+
+// QuotaConfig bounds resources of every project of the instance. Zero means no limit.
+type QuotaConfig struct {
+	MaxTemplates       int `json:"max_templates,omitempty" env:"SEMAPHORE_QUOTA_MAX_TEMPLATES"`
+	MaxConcurrentTasks int `json:"max_concurrent_tasks,omitempty" env:"SEMAPHORE_QUOTA_MAX_CONCURRENT_TASKS"`
+	// MaxOutputBytes is the maximum size of stored output of a task.
+	MaxOutputBytes int `json:"max_output_bytes,omitempty" env:"SEMAPHORE_QUOTA_MAX_OUTPUT_BYTES"`
+	MaxKeys        int `json:"max_keys,omitempty" env:"SEMAPHORE_QUOTA_MAX_KEYS"`
+}
+
+// ChatOpsConfig enables slash commands of Slack and Mattermost, e.g.
+// "/semaphore run deploy-web env=prod". Chat users are mapped to Semaphore users
+// by Users, the keys are chat user IDs optionally prefixed by the platform, e.g. "slack:U024BE7LH".
+type ChatOpsConfig struct {
+	Enabled bool `json:"enabled,omitempty" env:"SEMAPHORE_CHATOPS_ENABLED"`
+	// SlackSigningSecret verifies the signature of Slack requests.
+	SlackSigningSecret string `json:"slack_signing_secret,omitempty" env:"SEMAPHORE_CHATOPS_SLACK_SIGNING_SECRET"`
+	// MattermostToken is the token of the Mattermost slash command.
+	MattermostToken string            `json:"mattermost_token,omitempty" env:"SEMAPHORE_CHATOPS_MATTERMOST_TOKEN"`
+	Users           map[string]string `json:"users,omitempty" env:"SEMAPHORE_CHATOPS_USERS"`
+	// MatchUsernames maps chat users which are not in Users to Semaphore users with the same username.
+	MatchUsernames bool `json:"match_usernames,omitempty" env:"SEMAPHORE_CHATOPS_MATCH_USERNAMES"`
+	// ConfirmTags are the tags of templates which are run after the confirmation
+	// of the user. Default is prod and production.
+	ConfirmTags []string `json:"confirm_tags,omitempty" env:"SEMAPHORE_CHATOPS_CONFIRM_TAGS"`
+}
+
+// AccessAlertConfig enables alerts about changes of access: users added to or removed
+// from projects, changed roles and created API tokens. Changes in projects are also
+// sent through the alerts of the project if they are enabled for the project.
+type AccessAlertConfig struct {
+	Enabled bool `json:"enabled,omitempty" env:"SEMAPHORE_ACCESS_ALERT_ENABLED"`
+	// Email receives alerts about all changes, for example the address of the security team.
+	Email string `json:"email,omitempty" env:"SEMAPHORE_ACCESS_ALERT_EMAIL"`
+	// WebhookUrl receives every change as JSON by POST request.
+	WebhookUrl string `json:"webhook_url,omitempty" env:"SEMAPHORE_ACCESS_ALERT_WEBHOOK_URL"`
+}
+
+// EventHookConfig registers the executable which is started on task lifecycle events.
+// The event is written to stdin of the process as JSON, the name of the event is also
+// passed in SEMAPHORE_EVENT variable. The process inherits allowed variables of the
+// server environment only, see EnvAllowlist.
+type EventHookConfig struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	// Events lists names of the events handled by the hook, e.g. task_finished.
+	// The hook handles all events if the list is empty.
+	Events []string `json:"events,omitempty"`
+	// TimeoutSec limits the run time of the process, 30 seconds by default.
+	TimeoutSec int `json:"timeout_sec,omitempty"`
+}
+
+// TaskOutputConfig limits task output stored in the database.
+// Limits do not affect output streamed to the running task view.
+type TaskOutputConfig struct {
+	// StripANSI removes ANSI escape sequences (colors) from the stored output.
+	StripANSI bool `json:"strip_ansi,omitempty" env:"SEMAPHORE_TASK_OUTPUT_STRIP_ANSI"`
+	// MaxLines is the maximum number of stored lines per task. Zero means no limit.
+	MaxLines int `json:"max_lines,omitempty" env:"SEMAPHORE_TASK_OUTPUT_MAX_LINES"`
+	// MaxBytes is the maximum size of stored output per task. Zero means no limit.
+	MaxBytes int `json:"max_bytes,omitempty" env:"SEMAPHORE_TASK_OUTPUT_MAX_BYTES"`
+	// TailLines is the number of last lines stored when the task finishes if the output was truncated.
+	TailLines int `json:"tail_lines,omitempty" env:"SEMAPHORE_TASK_OUTPUT_TAIL_LINES"`
+	// CheckpointMinutes is the period of checkpoints of running tasks. The checkpoint stores
+	// the buffered output and saves the number of stored records with the task status.
+	// Zero means 5 minutes.
+	CheckpointMinutes int `json:"checkpoint_minutes,omitempty" env:"SEMAPHORE_TASK_OUTPUT_CHECKPOINT_MINUTES"`
+}
+
+// ObjectStorageConfig configures S3-compatible object storage.
+type ObjectStorageConfig struct {
+	// Endpoint is the URL of the storage, for example https://s3.eu-west-1.amazonaws.com.
+	Endpoint        string `json:"endpoint,omitempty" env:"SEMAPHORE_TASK_OUTPUT_STORAGE_ENDPOINT"`
+	Region          string `json:"region,omitempty" env:"SEMAPHORE_TASK_OUTPUT_STORAGE_REGION"`
+	Bucket          string `json:"bucket,omitempty" env:"SEMAPHORE_TASK_OUTPUT_STORAGE_BUCKET"`
+	Prefix          string `json:"prefix,omitempty" env:"SEMAPHORE_TASK_OUTPUT_STORAGE_PREFIX"`
+	AccessKeyID     string `json:"access_key_id,omitempty" env:"SEMAPHORE_TASK_OUTPUT_STORAGE_ACCESS_KEY_ID"`
+	SecretAccessKey string `json:"secret_access_key,omitempty" env:"SEMAPHORE_TASK_OUTPUT_STORAGE_SECRET_ACCESS_KEY"`
+	// PathStyle puts the bucket name to the URL path, it is required by MinIO and similar storages.
+	PathStyle bool `json:"path_style,omitempty" env:"SEMAPHORE_TASK_OUTPUT_STORAGE_PATH_STYLE"`
+}
+
+// UploadsConfig configures files uploaded to templates.
+type UploadsConfig struct {
+	// MaxSize is the maximum size of the uploaded file in bytes. The default is 1 MiB.
+	MaxSize int64 `json:"max_size,omitempty" env:"SEMAPHORE_UPLOADS_MAX_SIZE"`
+	// Storage keeps the encrypted content of files in the object storage instead of the database.
+	Storage *UploadStorageConfig `json:"storage,omitempty"`
+}
+
+// UploadStorageConfig configures S3-compatible object storage of uploaded files.
+type UploadStorageConfig struct {
+	Endpoint        string `json:"endpoint,omitempty" env:"SEMAPHORE_UPLOADS_STORAGE_ENDPOINT"`
+	Region          string `json:"region,omitempty" env:"SEMAPHORE_UPLOADS_STORAGE_REGION"`
+	Bucket          string `json:"bucket,omitempty" env:"SEMAPHORE_UPLOADS_STORAGE_BUCKET"`
+	Prefix          string `json:"prefix,omitempty" env:"SEMAPHORE_UPLOADS_STORAGE_PREFIX"`
+	AccessKeyID     string `json:"access_key_id,omitempty" env:"SEMAPHORE_UPLOADS_STORAGE_ACCESS_KEY_ID"`
+	SecretAccessKey string `json:"secret_access_key,omitempty" env:"SEMAPHORE_UPLOADS_STORAGE_SECRET_ACCESS_KEY"`
+	PathStyle       bool   `json:"path_style,omitempty" env:"SEMAPHORE_UPLOADS_STORAGE_PATH_STYLE"`
+}
+
+// DeployedVersionsConfig publishes the manifest of versions deployed to the deployment environments
+// of the project after each successful deployment. The manifest is uploaded to the S3-compatible
+// bucket if Bucket is set and is sent by POST request to URL if URL is set.
+type DeployedVersionsConfig struct {
+	URL string `json:"url,omitempty" env:"SEMAPHORE_DEPLOYED_VERSIONS_URL"`
+	// Token is sent to URL as the bearer token.
+	Token string `json:"token,omitempty" env:"SEMAPHORE_DEPLOYED_VERSIONS_TOKEN"`
+
+	Endpoint        string `json:"endpoint,omitempty" env:"SEMAPHORE_DEPLOYED_VERSIONS_STORAGE_ENDPOINT"`
+	Region          string `json:"region,omitempty" env:"SEMAPHORE_DEPLOYED_VERSIONS_STORAGE_REGION"`
+	Bucket          string `json:"bucket,omitempty" env:"SEMAPHORE_DEPLOYED_VERSIONS_STORAGE_BUCKET"`
+	Prefix          string `json:"prefix,omitempty" env:"SEMAPHORE_DEPLOYED_VERSIONS_STORAGE_PREFIX"`
+	AccessKeyID     string `json:"access_key_id,omitempty" env:"SEMAPHORE_DEPLOYED_VERSIONS_STORAGE_ACCESS_KEY_ID"`
+	SecretAccessKey string `json:"secret_access_key,omitempty" env:"SEMAPHORE_DEPLOYED_VERSIONS_STORAGE_SECRET_ACCESS_KEY"`
+	PathStyle       bool   `json:"path_style,omitempty" env:"SEMAPHORE_DEPLOYED_VERSIONS_STORAGE_PATH_STYLE"`
+}
+
+func (c *DeployedVersionsConfig) IsEnabled() bool {
+	return c != nil && (c.URL != "" || c.Bucket != "")
+}
+
+// SecretsDbConfig is the database which keeps the encrypted secrets of access keys
+// apart from the operational data. Dialect is bolt, mysql or postgres. For bolt
+// the host is the path to the database file.
+type SecretsDbConfig struct {
+	Dialect  string            `json:"dialect,omitempty" env:"SEMAPHORE_SECRETS_DB_DIALECT"`
+	Hostname string            `json:"host,omitempty" env:"SEMAPHORE_SECRETS_DB_HOST"`
+	Username string            `json:"user,omitempty" env:"SEMAPHORE_SECRETS_DB_USER"`
+	Password string            `json:"pass,omitempty" env:"SEMAPHORE_SECRETS_DB_PASS"`
+	DbName   string            `json:"name,omitempty" env:"SEMAPHORE_SECRETS_DB"`
+	Options  map[string]string `json:"options,omitempty" env:"SEMAPHORE_SECRETS_DB_OPTIONS"`
+}
+
+func (d *SecretsDbConfig) IsEnabled() bool {
+	return d != nil && d.Dialect != "" && d.Hostname != ""
+}
+
+func (d *SecretsDbConfig) GetConnectionString() (string, error) {
+	return buildConnectionString(d.Dialect, d.Hostname, d.Username, d.Password, d.DbName, d.Options, true)
 }
 
 // ConfigType mapping between Config and the json file that sets it
@@ -120,6 +391,9 @@ type ConfigType struct {
 
 	Dialect string `json:"dialect,omitempty" default:"bolt" rule:"^mysql|bolt|postgres$" env:"SEMAPHORE_DB_DIALECT"`
 
+	// SecretsDb keeps the encrypted secrets of access keys in a separate database.
+	SecretsDb *SecretsDbConfig `json:"secrets_db,omitempty"`
+
 	// Format `:port_num` eg, :3000
 	// if : is missing it will be corrected
 	Port string `json:"port,omitempty" default:":3000" rule:"^:?([0-9]{1,5})$" env:"SEMAPHORE_PORT"`
@@ -128,6 +402,13 @@ type ConfigType struct {
 	// defaults to empty
 	Interface string `json:"interface,omitempty" env:"SEMAPHORE_INTERFACE"`
 
+	// TLS enables HTTPS and authentication by client certificates.
+	TLS *TLSConfig `json:"tls,omitempty"`
+
+	// DisableCompression turns off gzip and zstd compression of API responses,
+	// e.g. if responses are compressed by the reverse proxy.
+	DisableCompression bool `json:"disable_compression,omitempty" env:"SEMAPHORE_DISABLE_COMPRESSION"`
+
 	// semaphore stores ephemeral projects here
 	TmpPath string `json:"tmp_path,omitempty" default:"/tmp/semaphore" env:"SEMAPHORE_TMP_PATH"`
 
@@ -140,12 +421,19 @@ type ConfigType struct {
 	// web host
 	WebHost string `json:"web_host,omitempty" env:"SEMAPHORE_WEB_ROOT"`
 
+	// Locale is the language of server messages for users without own locale
+	// and of alerts sent to chats. Default is en.
+	Locale string `json:"locale,omitempty" env:"SEMAPHORE_LOCALE"`
+
 	// cookie hashing & encryption
 	CookieHash       string `json:"cookie_hash,omitempty" env:"SEMAPHORE_COOKIE_HASH"`
 	CookieEncryption string `json:"cookie_encryption,omitempty" env:"SEMAPHORE_COOKIE_ENCRYPTION"`
 	// AccessKeyEncryption is BASE64 encoded byte array used
 	// for encrypting and decrypting access keys stored in database.
 	AccessKeyEncryption string `json:"access_key_encryption,omitempty" env:"SEMAPHORE_ACCESS_KEY_ENCRYPTION"`
+	// RunAttestationKey is BASE64 encoded Ed25519 seed used
+	// for signing attestations of task runs.
+	RunAttestationKey string `json:"run_attestation_key,omitempty" env:"SEMAPHORE_RUN_ATTESTATION_KEY"`
 
 	// email alerting
 	EmailAlert    bool   `json:"email_alert,omitempty" env:"SEMAPHORE_EMAIL_ALERT"`
@@ -191,14 +479,70 @@ type ConfigType struct {
 	// task concurrency
 	MaxParallelTasks int `json:"max_parallel_tasks,omitempty" default:"10" rule:"^[0-9]{1,10}$" env:"SEMAPHORE_MAX_PARALLEL_TASKS"`
 
+	// InUseObjectEdit is what happens when an inventory, an environment or a key used by
+	// a running task is saved: "warn" (default) saves the changes and warns, "block" rejects them.
+	InUseObjectEdit string `json:"in_use_object_edit,omitempty" rule:"^(|warn|block)$" env:"SEMAPHORE_IN_USE_OBJECT_EDIT"`
+
 	RunnerRegistrationToken string `json:"runner_registration_token,omitempty" env:"SEMAPHORE_RUNNER_REGISTRATION_TOKEN"`
 
 	// feature switches
 	PasswordLoginDisable     bool `json:"password_login_disable,omitempty" env:"SEMAPHORE_PASSWORD_LOGIN_DISABLED"`
 	NonAdminCanCreateProject bool `json:"non_admin_can_create_project,omitempty" env:"SEMAPHORE_NON_ADMIN_CAN_CREATE_PROJECT"`
+	// DestructiveActionReauth requires elevation token (see /api/auth/elevate) for destructive actions.
+	DestructiveActionReauth bool `json:"destructive_action_reauth,omitempty" env:"SEMAPHORE_DESTRUCTIVE_ACTION_REAUTH"`
 
 	UseRemoteRunner bool `json:"use_remote_runner,omitempty" env:"SEMAPHORE_USE_REMOTE_RUNNER"`
 
+	// SSHWorkers are hosts which run tasks over SSH instead of the server.
+	// It is a simpler alternative to runners, the task is assigned to the least busy worker.
+	SSHWorkers []SSHWorkerConfig `json:"ssh_workers,omitempty"`
+
+	// SSHAgentForwarding adds identities of the SSH agent of the Semaphore process (SSH_AUTH_SOCK)
+	// to the agents started for SSH keys, so these identities are not stored in Semaphore.
+	SSHAgentForwarding bool `json:"ssh_agent_forwarding,omitempty" env:"SEMAPHORE_SSH_AGENT_FORWARDING"`
+
+	// GraphQLEnabled enables read-only GraphQL endpoint /api/graphql.
+	GraphQLEnabled bool `json:"graphql_enabled,omitempty" env:"SEMAPHORE_GRAPHQL_ENABLED"`
+
+	RateLimit *RateLimitConfig `json:"rate_limit,omitempty"`
+
+	GalaxyCache *GalaxyCacheConfig `json:"galaxy_cache,omitempty"`
+
+	DiskUsage *DiskUsageConfig `json:"disk_usage,omitempty"`
+
+	SSHMultiplexing *SSHMultiplexingConfig `json:"ssh_multiplexing,omitempty"`
+
+	TaskWatchdog *TaskWatchdogConfig `json:"task_watchdog,omitempty"`
+
+	AccessAlert *AccessAlertConfig `json:"access_alert,omitempty"`
+
+	ChatOps *ChatOpsConfig `json:"chatops,omitempty"`
+
+	// EventHooks are executables which implement custom behavior on task events.
+	EventHooks []EventHookConfig `json:"event_hooks,omitempty"`
+
+	TaskOutput *TaskOutputConfig `json:"task_output,omitempty"`
+
+	// TaskOutputStorage moves the output of finished tasks from the database to the object storage.
+	TaskOutputStorage *ObjectStorageConfig `json:"task_output_storage,omitempty"`
+
+	DeployedVersions *DeployedVersionsConfig `json:"deployed_versions,omitempty"`
+
+	Uploads *UploadsConfig `json:"uploads,omitempty"`
+
+	Quotas *QuotaConfig `json:"quotas,omitempty"`
+
+	Housekeeping *HousekeepingConfig `json:"housekeeping,omitempty"`
+
+	HA *HAConfig `json:"ha,omitempty"`
+
+	Branding *BrandingConfig `json:"branding,omitempty"`
+
+	PasswordPolicy *PasswordPolicyConfig `json:"password_policy,omitempty"`
+
+	// EnableDiagnostics exposes pprof endpoints and the diagnostics bundle to admins.
+	EnableDiagnostics bool `json:"enable_diagnostics,omitempty" env:"SEMAPHORE_ENABLE_DIAGNOSTICS"`
+
 	IntegrationAlias string `json:"global_integration_alias,omitempty" env:"SEMAPHORE_INTEGRATION_ALIAS"`
 
 	Apps map[string]App `json:"apps,omitempty" env:"SEMAPHORE_APPS"`
@@ -207,6 +551,10 @@ type ConfigType struct {
 
 	EnvVars map[string]string `json:"env_vars,omitempty" env:"SEMAPHORE_ENV_VARS"`
 
+	// EnvAllowlist lists variables of the server environment inherited by child processes
+	// in addition to PATH. Entries ending with * match by prefix. ForwardedEnvVars extends the list.
+	EnvAllowlist []string `json:"env_allowlist,omitempty" env:"SEMAPHORE_ENV_ALLOWLIST" default:"[\"HOME\",\"USER\",\"LOGNAME\",\"SHELL\",\"TERM\",\"TZ\",\"TMPDIR\",\"LANG\",\"LANGUAGE\",\"LC_*\",\"http_proxy\",\"https_proxy\",\"no_proxy\",\"HTTP_PROXY\",\"HTTPS_PROXY\",\"NO_PROXY\",\"SSL_CERT_FILE\",\"SSL_CERT_DIR\"]"`
+
 	ForwardedEnvVars []string `json:"forwarded_env_vars,omitempty" env:"SEMAPHORE_FORWARDED_ENV_VARS"`
 }
 
@@ -235,6 +583,7 @@ func ConfigInit(configPath string, noConfigFile bool) {
 		loadConfigFile(configPath)
 	}
 	loadConfigEnvironment()
+	loadConfigReferences()
 	loadConfigDefaults()
 
 	fmt.Println("Validating config")
@@ -259,6 +608,8 @@ func ConfigInit(configPath string, noConfigFile bool) {
 			Config.Runner.Token = strings.TrimSpace(string(runnerTokenBytes))
 		}
 	}
+
+	snapshotSettings()
 }
 
 func loadConfigFile(configPath string) {
@@ -613,7 +964,9 @@ func FindSemaphore() string {
 }
 
 func AnsibleVersion() string {
-	bytes, err := exec.Command("ansible", "--version").Output()
+	cmd := exec.Command("ansible", "--version")
+	cmd.Env = AllowedEnvironmentVars()
+	bytes, err := cmd.Output()
 	if err != nil {
 		return ""
 	}
@@ -688,12 +1041,19 @@ func (d *DbConfig) GetHostname() string {
 // - connectionString: the constructed database connection string.
 // - err: an error if the dialect is unsupported.
 func (d *DbConfig) GetConnectionString(includeDbName bool) (connectionString string, err error) {
-	dbName := d.GetDbName()
-	dbUser := d.GetUsername()
-	dbPass := d.GetPassword()
-	dbHost := d.GetHostname()
+	return buildConnectionString(d.Dialect, d.GetHostname(), d.GetUsername(), d.GetPassword(), d.GetDbName(), d.Options, includeDbName)
+}
 
-	switch d.Dialect {
+func buildConnectionString(
+	dialect string,
+	dbHost string,
+	dbUser string,
+	dbPass string,
+	dbName string,
+	dbOptions map[string]string,
+	includeDbName bool,
+) (connectionString string, err error) {
+	switch dialect {
 	case DbDriverBolt:
 		connectionString = dbHost
 	case DbDriverMySQL:
@@ -715,7 +1075,7 @@ func (d *DbConfig) GetConnectionString(includeDbName bool) (connectionString str
 			"parseTime":         "true",
 			"interpolateParams": "true",
 		}
-		for v, k := range d.Options {
+		for v, k := range dbOptions {
 			options[v] = k
 		}
 		connectionString += mapToQueryString(options)
@@ -734,9 +1094,9 @@ func (d *DbConfig) GetConnectionString(includeDbName bool) (connectionString str
 				url.QueryEscape(dbPass),
 				dbHost)
 		}
-		connectionString += mapToQueryString(d.Options)
+		connectionString += mapToQueryString(dbOptions)
 	default:
-		err = fmt.Errorf("unsupported database driver: %s", d.Dialect)
+		err = fmt.Errorf("unsupported database driver: %s", dialect)
 	}
 	return
 }
@@ -812,10 +1172,65 @@ func (conf *ConfigType) GenerateSecrets() {
 	hash := securecookie.GenerateRandomKey(32)
 	encryption := securecookie.GenerateRandomKey(32)
 	accessKeyEncryption := securecookie.GenerateRandomKey(32)
+	runAttestationKey := securecookie.GenerateRandomKey(ed25519.SeedSize)
 
 	conf.CookieHash = base64.StdEncoding.EncodeToString(hash)
 	conf.CookieEncryption = base64.StdEncoding.EncodeToString(encryption)
 	conf.AccessKeyEncryption = base64.StdEncoding.EncodeToString(accessKeyEncryption)
+	conf.RunAttestationKey = base64.StdEncoding.EncodeToString(runAttestationKey)
+}
+
+// GetLocale returns the locale of server messages which have no recipient
+// or whose recipient has not chosen a locale.
+func (conf *ConfigType) GetLocale() string {
+	if conf == nil || conf.Locale == "" || !i18n.IsSupported(conf.Locale) {
+		return i18n.DefaultLocale
+	}
+	return conf.Locale
+}
+
+// GetPasswordPolicy returns the password policy of local users,
+// the empty policy allows any password.
+func (conf *ConfigType) GetPasswordPolicy() PasswordPolicyConfig {
+	if conf == nil || conf.PasswordPolicy == nil {
+		return PasswordPolicyConfig{}
+	}
+	return *conf.PasswordPolicy
+}
+
+// GetBranding returns the branding of the instance, empty values mean
+// that the defaults are used.
+func (conf *ConfigType) GetBranding() BrandingConfig {
+	if conf == nil || conf.Branding == nil {
+		return BrandingConfig{}
+	}
+	return *conf.Branding
+}
+
+// GetProductName returns the name of the product used in emails and notifications.
+func (conf *ConfigType) GetProductName() string {
+	if name := conf.GetBranding().ProductName; name != "" {
+		return name
+	}
+	return "Semaphore"
+}
+
+// GetRunAttestationKey returns the private key which signs attestations of task runs.
+func (conf *ConfigType) GetRunAttestationKey() (ed25519.PrivateKey, error) {
+	if conf.RunAttestationKey == "" {
+		return nil, fmt.Errorf("run attestation key is not configured")
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(conf.RunAttestationKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("run attestation key must contain %d bytes", ed25519.SeedSize)
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
 }
 
 var appCommands = map[string]string{
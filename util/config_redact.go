@@ -0,0 +1,53 @@
+package util
+
+import (
+	"encoding/json"
+	"regexp"
+)
+
+const redactedValue = "*****"
+
+// secretConfigKeyRegexp matches config keys which contain secrets.
+// Webhook URLs are secrets because they contain tokens.
+var secretConfigKeyRegexp = regexp.MustCompile(`(?i)(pass|secret|token|hash|encryption|_key|_url|dsn)`)
+
+func redactConfigValue(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			if key == "env_vars" {
+				v[k] = redactedValue
+				continue
+			}
+			v[k] = redactConfigValue(k, item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactConfigValue(key, item)
+		}
+		return v
+	case string:
+		if v != "" && secretConfigKeyRegexp.MatchString(key) {
+			return redactedValue
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// ToRedactedJSON returns the config in JSON format with hidden secrets.
+func (conf *ConfigType) ToRedactedJSON() ([]byte, error) {
+	content, err := json.Marshal(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]interface{}
+	if err = json.Unmarshal(content, &values); err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(redactConfigValue("", values), "", "\t")
+}
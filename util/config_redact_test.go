@@ -0,0 +1,37 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestToRedactedJSON(t *testing.T) {
+	conf := &ConfigType{
+		MySQL:         &DbConfig{Hostname: "localhost", Password: "db-secret"},
+		EmailHost:     "smtp.example.com",
+		EmailPassword: "smtp-secret",
+		SlackUrl:      "https://hooks.slack.com/services/secret",
+		CookieHash:    "cookie-secret",
+		EnvVars:       map[string]string{"AWS_ACCESS_KEY": "aws-secret"},
+	}
+
+	content, err := conf.ToRedactedJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res ConfigType
+	if err = json.Unmarshal(content, &res); err != nil {
+		t.Fatal(err)
+	}
+
+	if res.MySQL.Password != redactedValue || res.EmailPassword != redactedValue ||
+		res.SlackUrl != redactedValue || res.CookieHash != redactedValue ||
+		res.EnvVars["AWS_ACCESS_KEY"] != redactedValue {
+		t.Fatal("secrets must be hidden", string(content))
+	}
+
+	if res.MySQL.Hostname != "localhost" || res.EmailHost != "smtp.example.com" {
+		t.Fatal("other values must be kept", string(content))
+	}
+}
@@ -0,0 +1,169 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// configReferenceRegexp matches references to secrets stored outside the config:
+// ${env:VAR}, ${file:/run/secrets/x} and ${vault:path#key}.
+var configReferenceRegexp = regexp.MustCompile(`\$\{(env|file|vault):([^}]+)}`)
+
+const vaultRequestTimeout = 10 * time.Second
+
+// resolveVaultSecret reads the key of the Vault secret. Vault address and token
+// are taken from VAULT_ADDR and VAULT_TOKEN environment variables.
+// Both KV v1 and KV v2 secrets engines are supported.
+func resolveVaultSecret(ref string) (string, error) {
+	secretPath, key, ok := strings.Cut(ref, "#")
+	if !ok || secretPath == "" || key == "" {
+		return "", fmt.Errorf("vault reference must be in format path#key")
+	}
+
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return "", fmt.Errorf("VAULT_ADDR is not set")
+	}
+
+	req, err := http.NewRequest("GET", strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(secretPath, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", os.Getenv("VAULT_TOKEN"))
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	client := &http.Client{Timeout: vaultRequestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned status %d for %s", resp.StatusCode, secretPath)
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+
+	data := body.Data
+	// KV v2 wraps the secret into data.data
+	if nested, ok := data["data"].(map[string]interface{}); ok && data["metadata"] != nil {
+		data = nested
+	}
+
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in vault secret %s", key, secretPath)
+	}
+
+	return fmt.Sprintf("%v", value), nil
+}
+
+func resolveConfigReference(kind string, ref string) (string, error) {
+	switch kind {
+	case "env":
+		value, ok := os.LookupEnv(ref)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", ref)
+		}
+		return value, nil
+	case "file":
+		content, err := os.ReadFile(ref)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	case "vault":
+		return resolveVaultSecret(ref)
+	default:
+		return "", fmt.Errorf("unknown reference type %s", kind)
+	}
+}
+
+// ResolveConfigReferences replaces all references in the value with the secrets.
+func ResolveConfigReferences(value string) (string, error) {
+	var err error
+
+	res := configReferenceRegexp.ReplaceAllStringFunc(value, func(match string) string {
+		if err != nil {
+			return match
+		}
+
+		m := configReferenceRegexp.FindStringSubmatch(match)
+
+		var resolved string
+		resolved, err = resolveConfigReference(m[1], m[2])
+		if err != nil {
+			err = fmt.Errorf("can not resolve %s: %w", match, err)
+		}
+
+		return resolved
+	})
+
+	return res, err
+}
+
+func resolveReferencesInValue(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.String:
+		if !v.CanSet() {
+			return nil
+		}
+		resolved, err := ResolveConfigReferences(v.String())
+		if err != nil {
+			return err
+		}
+		v.SetString(resolved)
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return resolveReferencesInValue(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			if err := resolveReferencesInValue(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := resolveReferencesInValue(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// map elements are not addressable, so they are copied, resolved and stored back
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			if err := resolveReferencesInValue(elem); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, elem)
+		}
+	default:
+	}
+
+	return nil
+}
+
+func loadConfigReferences() {
+	if err := resolveReferencesInValue(reflect.ValueOf(Config)); err != nil {
+		exitOnConfigError(err.Error())
+	}
+}
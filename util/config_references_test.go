@@ -0,0 +1,63 @@
+package util
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestResolveConfigReferences(t *testing.T) {
+	t.Setenv("SEMAPHORE_TEST_DB_PASS", "env-secret")
+
+	secretFile := path.Join(t.TempDir(), "smtp_password")
+	if err := os.WriteFile(secretFile, []byte("file-secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/semaphore" || r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"encryption":"vault-secret"},"metadata":{"version":1}}}`))
+	}))
+	defer vault.Close()
+
+	t.Setenv("VAULT_ADDR", vault.URL)
+	t.Setenv("VAULT_TOKEN", "token")
+
+	Config = &ConfigType{
+		MySQL:               &DbConfig{Password: "${env:SEMAPHORE_TEST_DB_PASS}"},
+		EmailPassword:       "${file:" + secretFile + "}",
+		AccessKeyEncryption: "${vault:secret/data/semaphore#encryption}",
+		EmailHost:           "smtp.example.com",
+	}
+
+	loadConfigReferences()
+
+	if Config.MySQL.Password != "env-secret" {
+		t.Fatal("env reference must be resolved")
+	}
+
+	if Config.EmailPassword != "file-secret" {
+		t.Fatal("file reference must be resolved")
+	}
+
+	if Config.AccessKeyEncryption != "vault-secret" {
+		t.Fatal("vault reference must be resolved")
+	}
+
+	if Config.EmailHost != "smtp.example.com" {
+		t.Fatal("plain values must not be changed")
+	}
+
+	if _, err := ResolveConfigReferences("${env:SEMAPHORE_TEST_NOT_EXISTING_VAR}"); err == nil {
+		t.Fatal("missing variable must fail")
+	}
+
+	if _, err := ResolveConfigReferences("${vault:secret/data/semaphore}"); err == nil {
+		t.Fatal("vault reference without key must fail")
+	}
+}
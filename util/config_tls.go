@@ -0,0 +1,256 @@
+package util
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/semaphoreui/semaphore/pkg/mtls"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// TLSConfig configures HTTPS of the server. API clients and runners
+// can be authenticated by client certificates signed by ClientCAFile.
+type TLSConfig struct {
+	Enabled  bool   `json:"enabled,omitempty" env:"SEMAPHORE_TLS_ENABLED"`
+	CertFile string `json:"cert_file,omitempty" env:"SEMAPHORE_TLS_CERT_FILE"`
+	KeyFile  string `json:"key_file,omitempty" env:"SEMAPHORE_TLS_KEY_FILE"`
+
+	// ClientCAFile contains CA certificates which sign client certificates.
+	// Client certificates are not requested if it is empty.
+	ClientCAFile string `json:"client_ca_file,omitempty" env:"SEMAPHORE_TLS_CLIENT_CA_FILE"`
+	// RequireClientCert rejects connections without client certificate.
+	RequireClientCert bool `json:"require_client_cert,omitempty" env:"SEMAPHORE_TLS_REQUIRE_CLIENT_CERT"`
+	// ClientCertUserField is the field of the client certificate which is matched
+	// with the username or the email of the user: cn (default) or email.
+	ClientCertUserField string `json:"client_cert_user_field,omitempty" rule:"^(|cn|email)$" env:"SEMAPHORE_TLS_CLIENT_CERT_USER_FIELD"`
+	// CRLFile contains revoked client certificates.
+	CRLFile string `json:"crl_file,omitempty" env:"SEMAPHORE_TLS_CRL_FILE"`
+	// OCSP checks client certificates by OCSP responders specified in the certificates.
+	OCSP bool `json:"ocsp,omitempty" env:"SEMAPHORE_TLS_OCSP"`
+
+	// ACME issues and renews the certificate automatically, e.g. by Let's Encrypt.
+	// CertFile and KeyFile are not used if it is enabled.
+	ACME *ACMEConfig `json:"acme,omitempty"`
+}
+
+// ACMEConfig configures automatic certificates. TLS-ALPN-01 challenges are answered
+// by the HTTPS server, HTTP-01 challenges are answered if HTTPAddr is set.
+type ACMEConfig struct {
+	Enabled bool     `json:"enabled,omitempty" env:"SEMAPHORE_TLS_ACME_ENABLED"`
+	Domains []string `json:"domains,omitempty" env:"SEMAPHORE_TLS_ACME_DOMAINS"`
+	// Email is the contact of the ACME account for notices about expiring certificates.
+	Email string `json:"email,omitempty" env:"SEMAPHORE_TLS_ACME_EMAIL"`
+	// CacheDir keeps the account key and the certificates between restarts.
+	CacheDir string `json:"cache_dir,omitempty" env:"SEMAPHORE_TLS_ACME_CACHE_DIR"`
+	// DirectoryURL is the directory of the ACME server, Let's Encrypt is used by default.
+	DirectoryURL string `json:"directory_url,omitempty" env:"SEMAPHORE_TLS_ACME_DIRECTORY_URL"`
+	// HTTPAddr is the address of the HTTP server, e.g. ":80", which answers HTTP-01 challenges
+	// and redirects other requests to HTTPS.
+	HTTPAddr string `json:"http_addr,omitempty" env:"SEMAPHORE_TLS_ACME_HTTP_ADDR"`
+}
+
+// ClientCertAuthEnabled returns true if the server requests client certificates.
+func (conf *TLSConfig) ClientCertAuthEnabled() bool {
+	return conf != nil && conf.Enabled && conf.ClientCAFile != ""
+}
+
+// ACMEEnabled returns true if the certificate is issued by the ACME server.
+func (conf *TLSConfig) ACMEEnabled() bool {
+	return conf != nil && conf.Enabled && conf.ACME != nil && conf.ACME.Enabled
+}
+
+func (conf *ACMEConfig) manager() (*autocert.Manager, error) {
+	if len(conf.Domains) == 0 {
+		return nil, fmt.Errorf("tls acme domains required")
+	}
+
+	if conf.CacheDir == "" {
+		return nil, fmt.Errorf("tls acme cache_dir required")
+	}
+
+	if err := os.MkdirAll(conf.CacheDir, 0700); err != nil {
+		return nil, err
+	}
+
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(conf.Domains...),
+		Cache:      autocert.DirCache(conf.CacheDir),
+		Email:      conf.Email,
+	}
+
+	if conf.DirectoryURL != "" {
+		m.Client = &acme.Client{DirectoryURL: conf.DirectoryURL}
+	}
+
+	return m, nil
+}
+
+// certificateReloader loads the certificate again when its files are changed,
+// so the renewed certificate is used without restart.
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+
+	lock    sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func newCertificateReloader(certFile string, keyFile string) (*certificateReloader, error) {
+	r := &certificateReloader{certFile: certFile, keyFile: keyFile}
+
+	modTime, err := r.lastModified()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.load(modTime); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *certificateReloader) lastModified() (res time.Time, err error) {
+	for _, name := range []string{r.certFile, r.keyFile} {
+		var info os.FileInfo
+		if info, err = os.Stat(name); err != nil {
+			return
+		}
+		if info.ModTime().After(res) {
+			res = info.ModTime()
+		}
+	}
+	return
+}
+
+func (r *certificateReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	r.cert = &cert
+	r.modTime = modTime
+	return nil
+}
+
+// GetCertificate returns the current certificate. The previous certificate is used
+// if the changed files can not be loaded, e.g. they are partially written.
+func (r *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	modTime, err := r.lastModified()
+	if err == nil && !modTime.Equal(r.modTime) {
+		err = r.load(modTime)
+	}
+
+	if err != nil {
+		log.WithError(err).Warn("Can not reload TLS certificate, the previous certificate is used")
+	}
+
+	return r.cert, nil
+}
+
+// ServerConfig returns the TLS config of the HTTPS server. If certificates are issued by ACME,
+// challengeHandler answers HTTP-01 challenges and redirects other requests to HTTPS.
+func (conf *TLSConfig) ServerConfig() (res *tls.Config, challengeHandler http.Handler, err error) {
+	res = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+
+	if conf.ACMEEnabled() {
+		var m *autocert.Manager
+		if m, err = conf.ACME.manager(); err != nil {
+			return nil, nil, err
+		}
+
+		res.GetCertificate = m.GetCertificate
+		res.NextProtos = append(res.NextProtos, acme.ALPNProto)
+		challengeHandler = m.HTTPHandler(nil)
+	} else {
+		if conf.CertFile == "" || conf.KeyFile == "" {
+			return nil, nil, fmt.Errorf("tls cert_file and key_file required")
+		}
+
+		var reloader *certificateReloader
+		if reloader, err = newCertificateReloader(conf.CertFile, conf.KeyFile); err != nil {
+			return nil, nil, err
+		}
+
+		res.GetCertificate = reloader.GetCertificate
+	}
+
+	if !conf.ClientCertAuthEnabled() {
+		return
+	}
+
+	cas, err := mtls.LoadCertificates(conf.ClientCAFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res.ClientCAs = x509.NewCertPool()
+	for _, ca := range cas {
+		res.ClientCAs.AddCert(ca)
+	}
+
+	res.ClientAuth = tls.VerifyClientCertIfGiven
+	if conf.RequireClientCert {
+		res.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	checker := &mtls.RevocationChecker{OCSP: conf.OCSP}
+
+	if conf.CRLFile != "" {
+		if checker.CRL, err = mtls.LoadCRL(conf.CRLFile, cas); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	res.VerifyConnection = checker.VerifyConnection
+
+	return
+}
+
+// ClientConfig returns the TLS config which the runner uses to connect to the server,
+// nil if the runner uses defaults.
+func (conf *RunnerConfig) ClientConfig() (*tls.Config, error) {
+	if conf == nil || (conf.ClientCertFile == "" && conf.ServerCAFile == "") {
+		return nil, nil
+	}
+
+	res := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if conf.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(conf.ClientCertFile, conf.ClientKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		res.Certificates = []tls.Certificate{cert}
+	}
+
+	if conf.ServerCAFile != "" {
+		cas, err := mtls.LoadCertificates(conf.ServerCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		res.RootCAs = x509.NewCertPool()
+		for _, ca := range cas {
+			res.RootCAs.AddCert(ca)
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,133 @@
+package util
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestCertificate(t *testing.T, certFile string, keyFile string, cn string, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]*pem.Block{
+		certFile: {Type: "CERTIFICATE", Bytes: der},
+		keyFile:  {Type: "EC PRIVATE KEY", Bytes: keyDER},
+	}
+
+	for name, block := range files {
+		if err = os.WriteFile(name, pem.EncodeToMemory(block), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err = os.Chtimes(name, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func getTestCertificateCN(t *testing.T, conf *tls.Config) string {
+	cert, err := conf.GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return leaf.Subject.CommonName
+}
+
+func TestTLSConfig_ServerConfigReloadsCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	modTime := time.Now().Add(-time.Minute)
+	writeTestCertificate(t, certFile, keyFile, "old", modTime)
+
+	conf := &TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile}
+
+	serverConfig, challengeHandler, err := conf.ServerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if challengeHandler != nil {
+		t.Fatal("challenges are answered only for ACME certificates")
+	}
+
+	if cn := getTestCertificateCN(t, serverConfig); cn != "old" {
+		t.Fatal("invalid certificate", cn)
+	}
+
+	writeTestCertificate(t, certFile, keyFile, "renewed", modTime.Add(time.Second))
+
+	if cn := getTestCertificateCN(t, serverConfig); cn != "renewed" {
+		t.Fatal("renewed certificate must be loaded", cn)
+	}
+
+	if err = os.WriteFile(keyFile, []byte("partial"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if cn := getTestCertificateCN(t, serverConfig); cn != "renewed" {
+		t.Fatal("previous certificate must be used if files are invalid", cn)
+	}
+}
+
+func TestTLSConfig_ServerConfigACME(t *testing.T) {
+	conf := &TLSConfig{Enabled: true, ACME: &ACMEConfig{Enabled: true, Domains: []string{"semaphore.example.com"}}}
+
+	if _, _, err := conf.ServerConfig(); err == nil {
+		t.Fatal("cache_dir must be required")
+	}
+
+	conf.ACME.CacheDir = filepath.Join(t.TempDir(), "acme")
+
+	serverConfig, challengeHandler, err := conf.ServerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if challengeHandler == nil {
+		t.Fatal("handler of HTTP-01 challenges required")
+	}
+
+	if serverConfig.NextProtos[len(serverConfig.NextProtos)-1] != "acme-tls/1" {
+		t.Fatal("TLS-ALPN-01 challenges must be answered", serverConfig.NextProtos)
+	}
+
+	if _, err = os.Stat(conf.ACME.CacheDir); err != nil {
+		t.Fatal("cache dir must be created", err)
+	}
+}
@@ -0,0 +1,58 @@
+package util
+
+import (
+	"os"
+	"strings"
+)
+
+// protectedEnvPrefix is the prefix of variables which configure Semaphore itself,
+// e.g. SEMAPHORE_DB_PASS. They are passed to child processes only if listed by exact name.
+const protectedEnvPrefix = "SEMAPHORE_"
+
+// matchEnvName checks the name of the variable against the allowlist entry.
+// The entry can end with * to match all variables with the prefix, e.g. LC_*.
+func matchEnvName(pattern string, name string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(name, prefix) && !strings.HasPrefix(name, protectedEnvPrefix)
+	}
+	return pattern == name
+}
+
+// IsEnvVarAllowed returns true if the variable of the server environment
+// can be passed to child processes.
+func IsEnvVarAllowed(name string) bool {
+	if name == "PATH" {
+		return true
+	}
+
+	if Config == nil {
+		return false
+	}
+
+	for _, list := range [][]string{Config.EnvAllowlist, Config.ForwardedEnvVars} {
+		for _, pattern := range list {
+			if matchEnvName(pattern, name) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// AllowedEnvironmentVars returns the variables of the server environment which can be
+// passed to child processes (ansible, terraform, git, etc.) in the NAME=value format.
+// Other variables, including database credentials, are not inherited by child processes.
+func AllowedEnvironmentVars() []string {
+	var res []string
+
+	for _, e := range os.Environ() {
+		name, value, _ := strings.Cut(e, "=")
+		if name == "" || value == "" || !IsEnvVarAllowed(name) {
+			continue
+		}
+		res = append(res, name+"="+value)
+	}
+
+	return res
+}
@@ -0,0 +1,38 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestAllowedEnvironmentVars(t *testing.T) {
+	t.Setenv("LC_TIME", "C")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("SEMAPHORE_DB_PASS", "secret")
+	t.Setenv("SEMAPHORE_TEST", "forwarded")
+	t.Setenv("DATABASE_URL", "postgres://secret")
+
+	Config = &ConfigType{}
+	if err := loadDefaultsToObject(Config); err != nil {
+		t.Fatal(err)
+	}
+
+	Config.EnvAllowlist = append(Config.EnvAllowlist, "AWS_*", "SEMA*")
+	Config.ForwardedEnvVars = []string{"SEMAPHORE_TEST"}
+
+	vars := make(map[string]bool)
+	for _, e := range AllowedEnvironmentVars() {
+		vars[e] = true
+	}
+
+	for _, e := range []string{"LC_TIME=C", "AWS_REGION=eu-west-1", "SEMAPHORE_TEST=forwarded"} {
+		if !vars[e] {
+			t.Errorf("%s must be passed to child processes", e)
+		}
+	}
+
+	for _, e := range []string{"SEMAPHORE_DB_PASS=secret", "DATABASE_URL=postgres://secret"} {
+		if vars[e] {
+			t.Errorf("%s must not be passed to child processes", e)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package util
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const recentLogsSize = 1000
+
+// LogBuffer is the logrus hook which keeps last log records in memory.
+// The records are included into the diagnostics bundle.
+type LogBuffer struct {
+	mu      sync.Mutex
+	size    int
+	records []string
+	next    int
+}
+
+var RecentLogs = NewLogBuffer(recentLogsSize)
+
+func NewLogBuffer(size int) *LogBuffer {
+	return &LogBuffer{size: size}
+}
+
+func (b *LogBuffer) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (b *LogBuffer) Fire(entry *log.Entry) error {
+	formatter := log.TextFormatter{DisableColors: true, FullTimestamp: true}
+
+	line, err := formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.records) < b.size {
+		b.records = append(b.records, string(line))
+		return nil
+	}
+
+	b.records[b.next] = string(line)
+	b.next = (b.next + 1) % b.size
+	return nil
+}
+
+// Lines returns log records from the oldest to the newest.
+func (b *LogBuffer) Lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	res := make([]string, 0, len(b.records))
+	res = append(res, b.records[b.next:]...)
+	res = append(res, b.records[:b.next]...)
+	return res
+}
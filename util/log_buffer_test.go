@@ -0,0 +1,27 @@
+package util
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestLogBuffer(t *testing.T) {
+	buf := NewLogBuffer(2)
+
+	logger := log.New()
+	logger.Out = io.Discard
+	logger.AddHook(buf)
+
+	logger.Info("first")
+	logger.Info("second")
+	logger.Info("third")
+
+	lines := buf.Lines()
+
+	if len(lines) != 2 || !strings.Contains(lines[0], "second") || !strings.Contains(lines[1], "third") {
+		t.Fatal("buffer must contain last records in order", lines)
+	}
+}
@@ -0,0 +1,277 @@
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Setting is the config option which can be changed by the admin at runtime.
+// Changed settings are stored in the database and override values
+// from the config file and environment variables.
+type Setting struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+	// Secret values are never returned, IsSet reports if the value is not empty.
+	Secret bool `json:"secret"`
+	IsSet  bool `json:"is_set"`
+	// Overridden is true if the value is stored in the database.
+	Overridden bool `json:"overridden"`
+}
+
+type settingDefinition struct {
+	key    string
+	secret bool
+}
+
+var editableSettings = []settingDefinition{
+	{key: "ldap_enable"},
+	{key: "ldap_binddn"},
+	{key: "ldap_bindpassword", secret: true},
+	{key: "ldap_server"},
+	{key: "ldap_searchdn"},
+	{key: "ldap_searchfilter"},
+	{key: "ldap_needtls"},
+	{key: "ldap_mappings.dn"},
+	{key: "ldap_mappings.mail"},
+	{key: "ldap_mappings.uid"},
+	{key: "ldap_mappings.cn"},
+
+	{key: "email_alert"},
+	{key: "email_sender"},
+	{key: "email_host"},
+	{key: "email_port"},
+	{key: "email_username"},
+	{key: "email_password", secret: true},
+	{key: "email_secure"},
+
+	{key: "telegram_alert"},
+	{key: "telegram_chat"},
+	{key: "telegram_token", secret: true},
+	{key: "slack_alert"},
+	{key: "slack_url", secret: true},
+	{key: "rocketchat_alert"},
+	{key: "rocketchat_url", secret: true},
+	{key: "microsoft_teams_alert"},
+	{key: "microsoft_teams_url", secret: true},
+	{key: "dingtalk_alert"},
+	{key: "dingtalk_url", secret: true},
+	{key: "gotify_alert"},
+	{key: "gotify_url"},
+	{key: "gotify_token", secret: true},
+
+	{key: "max_parallel_tasks"},
+	{key: "max_tasks_per_template"},
+	{key: "max_task_duration_sec"},
+	{key: "in_use_object_edit"},
+
+	{key: "non_admin_can_create_project"},
+
+	{key: "branding.logo_url"},
+	{key: "branding.product_name"},
+	{key: "branding.login_message"},
+
+	{key: "password_policy.min_length"},
+	{key: "password_policy.require_uppercase"},
+	{key: "password_policy.require_lowercase"},
+	{key: "password_policy.require_digit"},
+	{key: "password_policy.require_symbol"},
+	{key: "password_policy.history_size"},
+	{key: "password_policy.max_age_days"},
+}
+
+// settingsBaseline contains values of the settings loaded from the config file,
+// environment variables and defaults. They are restored when the setting is reset.
+var settingsBaseline map[string]interface{}
+
+func getSettingDefinition(key string) (settingDefinition, bool) {
+	for _, def := range editableSettings {
+		if def.key == key {
+			return def, true
+		}
+	}
+	return settingDefinition{}, false
+}
+
+// IsEditableSetting returns true if the setting can be changed at runtime.
+func IsEditableSetting(key string) bool {
+	_, ok := getSettingDefinition(key)
+	return ok
+}
+
+// lookupSetting returns the config field for the setting key. Nested keys are
+// separated by dot. Nil pointers to nested structs are allocated if alloc is true,
+// otherwise the returned field is invalid and only its type is known.
+func lookupSetting(key string, alloc bool) (field reflect.Value, fieldType reflect.StructField, err error) {
+	field = reflect.ValueOf(Config).Elem()
+	t := field.Type()
+
+	for _, name := range strings.Split(key, ".") {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+			if field.IsValid() {
+				if field.IsNil() && alloc {
+					field.Set(reflect.New(t))
+				}
+				if field.IsNil() {
+					field = reflect.Value{}
+				} else {
+					field = field.Elem()
+				}
+			}
+		}
+
+		if t.Kind() != reflect.Struct {
+			err = fmt.Errorf("invalid setting %s", key)
+			return
+		}
+
+		found := false
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if strings.Split(f.Tag.Get("json"), ",")[0] == name {
+				fieldType = f
+				t = f.Type
+				if field.IsValid() {
+					field = field.Field(i)
+				}
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			err = fmt.Errorf("invalid setting %s", key)
+			return
+		}
+	}
+
+	return
+}
+
+func getSettingValue(key string) interface{} {
+	field, fieldType, err := lookupSetting(key, false)
+	if err != nil {
+		return nil
+	}
+
+	if !field.IsValid() {
+		return reflect.Zero(fieldType.Type).Interface()
+	}
+
+	return field.Interface()
+}
+
+// ParseSettingValue converts the string value to the type of the setting
+// and validates it.
+func ParseSettingValue(key string, value string) (res interface{}, err error) {
+	if !IsEditableSetting(key) {
+		err = fmt.Errorf("setting %s can not be changed", key)
+		return
+	}
+
+	_, fieldType, err := lookupSetting(key, false)
+	if err != nil {
+		return
+	}
+
+	switch fieldType.Type.Kind() {
+	case reflect.String:
+		res = value
+	case reflect.Int:
+		res, err = strconv.Atoi(value)
+		if err != nil {
+			err = fmt.Errorf("setting %s must be integer", key)
+			return
+		}
+	case reflect.Bool:
+		res, err = strconv.ParseBool(value)
+		if err != nil {
+			err = fmt.Errorf("setting %s must be boolean", key)
+			return
+		}
+	default:
+		err = fmt.Errorf("setting %s has unsupported type", key)
+		return
+	}
+
+	if rule := fieldType.Tag.Get("rule"); rule != "" {
+		if match, _ := regexp.MatchString(rule, value); !match {
+			err = fmt.Errorf("value of setting %s is not valid (must match regex: '%s')", key, rule)
+		}
+	}
+
+	return
+}
+
+// ApplySetting changes the setting in the loaded config.
+func ApplySetting(key string, value string) error {
+	parsed, err := ParseSettingValue(key, value)
+	if err != nil {
+		return err
+	}
+
+	field, _, err := lookupSetting(key, true)
+	if err != nil {
+		return err
+	}
+
+	field.Set(reflect.ValueOf(parsed))
+	return nil
+}
+
+// ResetSetting restores the value of the setting loaded on startup.
+func ResetSetting(key string) error {
+	if !IsEditableSetting(key) {
+		return fmt.Errorf("setting %s can not be changed", key)
+	}
+
+	field, fieldType, err := lookupSetting(key, true)
+	if err != nil {
+		return err
+	}
+
+	value, ok := settingsBaseline[key]
+	if !ok {
+		value = reflect.Zero(fieldType.Type).Interface()
+	}
+
+	field.Set(reflect.ValueOf(value))
+	return nil
+}
+
+// snapshotSettings remembers values of the settings loaded on startup.
+func snapshotSettings() {
+	settingsBaseline = make(map[string]interface{})
+	for _, def := range editableSettings {
+		settingsBaseline[def.key] = getSettingValue(def.key)
+	}
+}
+
+// GetSettings returns current values of the editable settings. Values of the
+// settings stored in the database are marked as overridden.
+func GetSettings(overridden map[string]string) []Setting {
+	settings := make([]Setting, 0, len(editableSettings))
+
+	for _, def := range editableSettings {
+		value := getSettingValue(def.key)
+
+		setting := Setting{
+			Key:    def.key,
+			Secret: def.secret,
+			IsSet:  !reflect.ValueOf(value).IsZero(),
+		}
+
+		if !def.secret {
+			setting.Value = value
+		}
+
+		_, setting.Overridden = overridden[def.key]
+
+		settings = append(settings, setting)
+	}
+
+	return settings
+}
@@ -0,0 +1,87 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestApplySetting(t *testing.T) {
+	Config = &ConfigType{
+		MaxParallelTasks: 10,
+		EmailPassword:    "secret",
+	}
+	snapshotSettings()
+
+	if err := ApplySetting("max_parallel_tasks", "3"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ApplySetting("ldap_mappings.uid", "sAMAccountName"); err != nil {
+		t.Fatal(err)
+	}
+
+	if Config.MaxParallelTasks != 3 || Config.LdapMappings.UID != "sAMAccountName" {
+		t.Fatal("settings must be applied")
+	}
+
+	if err := ApplySetting("max_parallel_tasks", "abc"); err == nil {
+		t.Fatal("invalid value must be rejected")
+	}
+
+	if err := ApplySetting("email_port", "70000a"); err == nil {
+		t.Fatal("value which does not match the rule must be rejected")
+	}
+
+	if err := ApplySetting("cookie_hash", "abc"); err == nil {
+		t.Fatal("not editable setting must be rejected")
+	}
+
+	if err := ResetSetting("max_parallel_tasks"); err != nil {
+		t.Fatal(err)
+	}
+
+	if Config.MaxParallelTasks != 10 {
+		t.Fatal("setting must be reset to the startup value")
+	}
+
+	for _, setting := range GetSettings(map[string]string{"ldap_mappings.uid": "sAMAccountName"}) {
+		switch setting.Key {
+		case "email_password":
+			if setting.Value != nil || !setting.IsSet {
+				t.Fatal("secret value must be hidden")
+			}
+		case "ldap_mappings.uid":
+			if setting.Value != "sAMAccountName" || !setting.Overridden {
+				t.Fatal("invalid setting")
+			}
+		}
+	}
+}
+
+func TestApplyBrandingSetting(t *testing.T) {
+	Config = &ConfigType{}
+	snapshotSettings()
+
+	if Config.GetProductName() != "Semaphore" {
+		t.Fatal("default product name expected")
+	}
+
+	if err := ApplySetting("branding.product_name", "Acme Automation"); err != nil {
+		t.Fatal(err)
+	}
+
+	if Config.GetProductName() != "Acme Automation" {
+		t.Fatal("product name must be applied")
+	}
+
+	if err := ApplySetting("branding.logo_url", "javascript:alert(1)"); err == nil {
+		t.Fatal("logo URL which is not http(s) must be rejected")
+	}
+
+	if err := ResetSetting("branding.product_name"); err != nil {
+		t.Fatal(err)
+	}
+
+	if Config.GetProductName() != "Semaphore" {
+		t.Fatal("product name must be reset")
+	}
+}
@@ -35,6 +35,8 @@ export default {
       item: null,
       formValid: false,
       formError: null,
+      // errors of the fields returned by the server, by JSON names of the fields
+      fieldErrors: {},
       formSaving: false,
     };
   },
@@ -66,6 +68,7 @@ export default {
     async reset() {
       this.item = null;
       this.formError = null;
+      this.fieldErrors = {};
       if (this.$refs.form) {
         this.$refs.form.resetValidation();
       }
@@ -157,6 +160,7 @@ export default {
      */
     async save(data = {}) {
       this.formError = null;
+      this.fieldErrors = {};
 
       if (!this.$refs.form.validate()) {
         this.$emit('error', {});
@@ -191,6 +195,7 @@ export default {
         });
       } catch (err) {
         this.formError = getErrorMessage(err);
+        this.fieldErrors = (err.response && err.response.data && err.response.data.fields) || {};
         this.$emit('error', {
           message: this.formError,
         });
@@ -92,6 +92,7 @@ export default {
         if (this.itemRefs.templates.length > 0
           || this.itemRefs.repositories.length > 0
           || this.itemRefs.inventories.length > 0
+          || this.itemRefs.integrations.length > 0
           || this.itemRefs.schedules.length > 0) {
           this.itemRefsDialog = true;
           return;
@@ -122,6 +123,13 @@ export default {
 
         await this.loadItems();
       } catch (err) {
+        // the object became used after the references were checked
+        if (err.response && err.response.data && err.response.data.refs) {
+          this.itemRefs = err.response.data.refs;
+          this.itemRefsDialog = true;
+          return;
+        }
+
         EventBus.$emit('i-snackbar', {
           color: 'error',
           text: getErrorMessage(err),
@@ -7,6 +7,12 @@ export default {
   editSchedule: 'Edit Schedule',
   backup: 'Backup Project',
   downloadTheProjectBackupFile: 'Download the project backup file (in json)',
+  calendarFeed: 'Calendar',
+  calendarFeedDescription: 'Subscribe to the iCalendar feed of upcoming scheduled runs. Anyone with the link can see the schedules of the project.',
+  calendarFeedUrl: 'Calendar feed URL',
+  calendarFeedEnable: 'Enable feed',
+  calendarFeedRegenerate: 'Regenerate link',
+  calendarFeedDisable: 'Disable feed',
   restoreProject: 'Restore Project...',
   incorrectUsrPwd: 'Incorrect login or password',
   askDeleteUser: 'Do you really want to delete this user?',
@@ -85,7 +91,13 @@ export default {
   name: 'Name',
   userCredentials: 'User Credentials',
   sudoCredentialsOptional: 'Sudo Credentials (Optional)',
+  defaultUserCredentialsOptional: 'Default User Credentials (Optional)',
+  defaultSudoCredentialsOptional: 'Default Sudo Credentials (Optional)',
+  defaultVaultPasswordOptional: 'Default Vault Password (Optional)',
   type: 'Type',
+  loadMore: 'Load more',
+  impersonationBanner: 'You are signed in as {user} by admin {admin}',
+  stopImpersonation: 'Stop impersonation',
   pathToInventoryFile: 'Path to Inventory file',
   enterInventory: 'Enter inventory...',
   staticInventoryExample: 'Static inventory example:',
@@ -142,6 +154,10 @@ export default {
   columns: 'Columns',
   buildVersion: 'Build Version',
   messageOptional: 'Message (Optional)',
+  runAtOptional: 'Start at (Optional)',
+  runAtHint: 'The task waits in the queue until this time and can be cancelled before it starts',
+  becomePasswordOptional: 'Become password (Optional)',
+  becomePasswordHint: 'Used for this run only instead of the become key of the inventory, it is never stored',
   debug: 'Debug',
   dryRun: 'Dry Run',
   diff: 'Diff',
@@ -188,6 +204,28 @@ export default {
   readThe: 'Read the',
   toLearnMoreAboutCron: 'to learn more about Cron.',
   suppressSuccessAlerts: 'Suppress success alerts',
+  approvalEmails: 'Send approval emails',
+  approvalEmailsHint: 'Members who can run tasks receive links to approve or reject the task waiting for confirmation',
+  maxDuration: 'Max duration (minutes)',
+  maxDurationHint: 'Alert if the task runs longer, 0 means no limit',
+  durationFactor: 'Max duration (× average)',
+  durationFactorHint: 'Alert if the task runs N times longer than recent successful tasks, 0 disables',
+  secretsScan: 'Secrets scan',
+  secretsScanHint: 'Search raw credentials in the commits added since the last run',
+  secretsScanDisabled: 'Disabled',
+  secretsScanWarn: 'Warn',
+  secretsScanFail: 'Fail the task',
+  runConflictPolicy: 'Schedule and manual run conflict',
+  runConflictPolicyHint: 'Applied when a scheduled run and a manual run of the template overlap',
+  runConflictQueue: 'Queue the new task',
+  runConflictSkip: 'Skip the new task',
+  runConflictCancelOlder: 'Cancel the older task',
+  concurrencyGroup: 'Concurrency group',
+  concurrencyGroupHint: 'Tasks of templates with the same group never run simultaneously, even in different projects',
+  changeWindowStart: 'Change window start',
+  changeWindowEnd: 'Change window end',
+  abortTemplate: 'Abort template',
+  abortTemplateHint: 'Runs automatically if the change window closes before the task completes',
   cliArgsJsonArrayExampleIMyinventoryshPrivatekeythe2: 'CLI Args (JSON array). Example: [ "-i", "@myinventory.sh", "--private-key=/there/id_rsa", "-vvvv" ]',
   allowCliArgsInTask: 'Allow CLI args in Task',
   docs: 'docs',
@@ -204,6 +242,8 @@ export default {
   email: 'Email',
   adminUser: 'Admin user',
   sendAlerts: 'Send alerts',
+  messageLanguage: 'Language of emails and messages',
+  serverDefault: 'Server default',
   deleteUser: 'Delete user',
   newUser: 'New User',
   re: 'Re{getActionButtonTitle}',
@@ -305,5 +345,99 @@ export default {
 
   status_success: 'Success',
   status_failed: 'Failed',
+  status_partial: 'Partial',
   status_stopped: 'Stopped',
+  environmentSchema: 'Schema',
+  environmentSchemaHint: 'JSON Schema of extra variables checked on save and when a task is started',
+  environmentSchemaPlaceholder: 'Example: {"type": "object", "required": ["region"]}',
+  shareTaskOutput: 'Share',
+  shareTaskOutputCopied: 'The read-only link to the task output has been copied to the clipboard. It expires {expires}.',
+  inventorySSHOptions: 'SSH options',
+  inventorySSHPort: 'Port',
+  inventorySSHPortInvalid: 'Port must be between 1 and 65535',
+  inventorySSHUser: 'Default user',
+  inventorySSHUserHint: 'Used if neither the credentials nor the host define the user',
+  inventorySSHControlPersist: 'ControlPersist',
+  inventorySSHStrictHostKeyChecking: 'StrictHostKeyChecking',
+  inventorySSHProxyCommand: 'Proxy command',
+  inventorySSHDefault: 'Default',
+  templateParamsStatus: 'Last runs by parameters',
+  templateParamsLastSuccess: 'Last success',
+  templateParamsNone: 'No parameters',
+  terraformWorkspaces: 'Workspaces',
+  terraformWorkspace: 'Workspace',
+  terraformWorkspaceHint: 'Leave empty to use the workspace of the inventory. The workspace is created if it does not exist.',
+  terraformWorkspaceInvalid: 'Invalid workspace name',
+  terraformWorkspaceInUse: 'in use',
+  terraformWorkspaceLastApply: 'Last apply',
+  terraformWorkspaceLastDestroy: 'Last destroy',
+  terraformWorkspaceLastTask: 'Last task',
+  terraformWorkspaceNeverApplied: 'Never applied',
+  keyFormVault: 'HashiCorp Vault dynamic credentials',
+  keyFormVaultEngine: 'Credentials',
+  keyFormVaultSSHSign: 'Signed SSH certificate',
+  keyFormVaultSSHOTP: 'One-time SSH password',
+  keyFormVaultDatabase: 'Database login and password',
+  keyFormVaultAddress: 'Vault address (Optional)',
+  keyFormVaultToken: 'Vault token (Optional)',
+  keyFormVaultNamespace: 'Namespace (Optional)',
+  keyFormVaultMount: 'Secrets engine path',
+  keyFormVaultRole: 'Role',
+  keyFormVaultIP: 'Host IP',
+  keyFormVaultHint: 'Credentials are requested from Vault when a task starts and revoked when it finishes. VAULT_ADDR and VAULT_TOKEN of the server are used if the address or the token is empty.',
+  secretFiles: 'Secret files',
+  secretFileAdd: 'Add secret file',
+  secretFileKey: 'Key (string or SSH key)',
+  secretFileKeyRequired: 'Key is required',
+  secretFilePath: 'Path in repository',
+  secretFilePathRequired: 'Path is required',
+  secretFilePathRelative: 'Path must be relative to the repository',
+  secretFilePathUnique: 'Path must be unique',
+  secretFileMode: 'Mode (Optional)',
+  secretFileModeInvalid: 'Mode must be octal, e.g. 0600',
+  archiveProject: 'Archive Project',
+  unarchiveProject: 'Unarchive Project',
+  archiveProjectDescription: 'Archived project is read-only: tasks, schedules and integrations do not run, history is kept.',
+  unarchiveProjectDescription: 'The project is archived. Unarchive it to run tasks and change its resources.',
+  askArchiveProj: 'Do you really want to archive this project? Its running tasks will be stopped.',
+  archived: 'Archived',
+  scheduleType: 'Schedule type',
+  scheduleTypeCron: 'Cron',
+  scheduleTypeInterval: 'Interval after the previous run',
+  scheduleIntervalMinutes: 'Interval (minutes)',
+  scheduleIntervalHint: 'The next run starts when the interval is passed after the previous run finished',
+  scheduleIntervalRequired: 'Interval must be at least 1 minute',
+  scheduleIntervalDescription: 'Every {minutes} min after the previous run',
+  scheduleDisableAfterFailures: 'Disable after consecutive failures (Optional)',
+  scheduleDisableAfterFailuresHint: 'The schedule is disabled when this number of runs in a row failed, 0 never disables it',
+  scheduleDisableAfterFailuresInvalid: 'Number of failures can not be negative',
+  scheduleAutoDisabled: 'The schedule was disabled after {failures} consecutive failed runs. Enable it to start counting again.',
+  alertDigestOptional: 'Digest of scheduled runs, cron format (Optional)',
+  alertDigestHint: 'Scheduled runs are reported by one summary instead of an alert per run',
+  hostsUnreachable: '{unreachable} of {total} hosts unreachable',
+  membershipExpires: 'Expires',
+  membershipExpiresOptional: 'Expiry date (Optional)',
+  membershipExpiresHint: 'The user loses access to the project on this date',
+  accessReviewOptional: 'Access review report, cron format (Optional)',
+  accessReviewHint: 'Owners receive the list of users who have access to the project and their last activity by email',
+  keyRotationDaysOptional: 'Key rotation period, days (Optional)',
+  keyRotationDaysHint: 'Owners are reminded about keys whose secrets were not changed for this period, 0 - disabled',
+  rotationOverdue: 'Rotation overdue',
+  canaryBatchOptional: 'Canary batch, hosts or percentage (Optional)',
+  canaryBatchHint: 'The playbook runs against the canary batch first, the remaining hosts are run after the confirmation',
+  canaryBatch: 'Canary batch',
+  remainderBatch: 'Remainder batch',
+  hostExclusions: 'Excluded Hosts',
+  hostExclusion: 'Host Exclusion',
+  excludeHost: 'Exclude Host',
+  host: 'Host',
+  host_required: 'Host is required',
+  excludedHostHint: 'Name or pattern of the host which is excluded from all runs of the project',
+  exclusionReason: 'Reason',
+  exclusionReasonOptional: 'Reason (Optional)',
+  exclusionExpires: 'Expires',
+  exclusionExpiresOptional: 'Expires (Optional)',
+  exclusionExpiresHint: 'The host returns to the runs at this time',
+  deleteHostExclusion: 'Return host to runs',
+  askDeleteHostExclusion: 'Do you really want to return this host to the runs?',
 };
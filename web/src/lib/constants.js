@@ -21,6 +21,8 @@ export const USER_PERMISSIONS = {
   updateProject: 2,
   manageProjectResources: 4,
   manageProjectUsers: 8,
+  viewProjectKeys: 16,
+  useProjectKeys: 32,
 };
 
 export const USER_ROLES = [{
@@ -2,6 +2,10 @@
 export function getErrorMessage(err) {
   if (err.response) {
     if (err.response.data && err.response.data.error) {
+      // hint describes how to fix the error identified by err.response.data.code
+      if (err.response.data.hint) {
+        return `${err.response.data.error}. ${err.response.data.hint}`;
+      }
       return err.response.data.error;
     }
 
@@ -11,6 +11,7 @@ import TemplateView from '../views/project/TemplateView.vue';
 import Environment from '../views/project/Environment.vue';
 import Inventory from '../views/project/Inventory.vue';
 import Keys from '../views/project/Keys.vue';
+import HostExclusions from '../views/project/HostExclusions.vue';
 import Repositories from '../views/project/Repositories.vue';
 import Team from '../views/project/Team.vue';
 import Users from '../views/Users.vue';
@@ -84,6 +85,10 @@ const routes = [
     path: '/project/:projectId/inventory',
     component: Inventory,
   },
+  {
+    path: '/project/:projectId/host_exclusions',
+    component: HostExclusions,
+  },
   {
     path: '/project/:projectId/integrations',
     component: Integrations,